@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	defaultconfig "github.com/14mdzk/goscratch/config"
 	"github.com/14mdzk/goscratch/internal/platform/app"
 	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/pkg/types"
@@ -16,13 +17,16 @@ import (
 func main() {
 	// Register custom Fiber decoders for Opt types
 	types.RegisterFiberDecoders()
-	// Load configuration
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "config/config.default.json"
+	// Load configuration. An explicit CONFIG_PATH must exist; otherwise
+	// config/config.default.json is used when deployed beside the binary, and
+	// the copy embedded at build time when not.
+	var cfg *config.Config
+	var err error
+	if configPath := os.Getenv("CONFIG_PATH"); configPath != "" {
+		cfg, err = config.Load(configPath)
+	} else {
+		cfg, _, err = config.LoadOrDefault("config/config.default.json", defaultconfig.DefaultJSON)
 	}
-
-	cfg, err := config.Load(configPath)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
+)
+
+const usage = `usage:
+  policy export [-format yaml|json] [-o file]
+  policy import [-dry-run] file
+
+import reads the policy from stdin when file is "-".
+`
+
+// command is a parsed policy command line.
+type command struct {
+	name   string // "export" or "import"
+	format string // export format
+	output string // export destination; stdout when empty
+	dryRun bool   // import: report the diff without applying it
+	input  string // import source; "-" is stdin
+}
+
+// parseArgs parses the policy command line. It returns flag.ErrHelp for -h,
+// after printing usage to output.
+func parseArgs(args []string, output io.Writer) (*command, error) {
+	if len(args) == 0 {
+		fmt.Fprint(output, usage)
+		return nil, fmt.Errorf("missing command")
+	}
+	c := &command{name: args[0]}
+
+	fs := flag.NewFlagSet("policy "+c.name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() { fmt.Fprint(output, usage) }
+	switch c.name {
+	case "export":
+		fs.StringVar(&c.format, "format", dto.PolicyFormatYAML, "output format: yaml or json")
+		fs.StringVar(&c.output, "o", "", "write the policy to this file instead of stdout")
+	case "import":
+		fs.BoolVar(&c.dryRun, "dry-run", false, "print the changes without applying them")
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(output, usage)
+		return nil, flag.ErrHelp
+	default:
+		fmt.Fprint(output, usage)
+		return nil, fmt.Errorf("unknown command %q", c.name)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	switch c.name {
+	case "export":
+		if c.format != dto.PolicyFormatYAML && c.format != dto.PolicyFormatJSON {
+			return nil, fmt.Errorf("-format must be %s or %s", dto.PolicyFormatYAML, dto.PolicyFormatJSON)
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+		}
+	case "import":
+		if fs.NArg() != 1 {
+			return nil, fmt.Errorf("import needs exactly one policy file (or - for stdin)")
+		}
+		c.input = fs.Arg(0)
+	}
+	return c, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Run("export defaults", func(t *testing.T) {
+		c, err := parseArgs([]string{"export"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, &command{name: "export", format: "yaml"}, c)
+	})
+
+	t.Run("export flags", func(t *testing.T) {
+		c, err := parseArgs([]string{"export", "-format", "json", "-o", "policy.json"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, &command{name: "export", format: "json", output: "policy.json"}, c)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		c, err := parseArgs([]string{"import", "-dry-run", "-"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, &command{name: "import", dryRun: true, input: "-"}, c)
+	})
+
+	t.Run("help", func(t *testing.T) {
+		_, err := parseArgs([]string{"-h"}, io.Discard)
+		assert.True(t, errors.Is(err, flag.ErrHelp))
+	})
+
+	for name, args := range map[string][]string{
+		"no command":       nil,
+		"unknown command":  {"apply"},
+		"bad format":       {"export", "-format", "xml"},
+		"export extra arg": {"export", "policy.yaml"},
+		"import no file":   {"import"},
+		"import two files": {"import", "a.yaml", "b.yaml"},
+		"import bad flag":  {"import", "-format", "json", "a.yaml"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseArgs(args, io.Discard)
+			assert.Error(t, err)
+		})
+	}
+}
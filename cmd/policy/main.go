@@ -0,0 +1,132 @@
+// Command policy exports the authorization policy to a file and imports one,
+// the same operations as GET and PUT /roles/policy. It talks to the database
+// directly; running API instances pick imported changes up through the
+// casbin_rules watcher, or on their next reload interval.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	defaultconfig "github.com/14mdzk/goscratch/config"
+	"github.com/14mdzk/goscratch/internal/adapter/audit"
+	casbinadapter "github.com/14mdzk/goscratch/internal/adapter/casbin"
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
+	rolerepo "github.com/14mdzk/goscratch/internal/module/role/repository"
+	"github.com/14mdzk/goscratch/internal/module/role/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	cmd, err := parseArgs(os.Args[1:], os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	// Load configuration the same way the API and worker do.
+	var cfg *config.Config
+	if configPath := os.Getenv("CONFIG_PATH"); configPath != "" {
+		cfg, err = config.Load(configPath)
+	} else {
+		cfg, _, err = config.LoadOrDefault("config/config.default.json", defaultconfig.DefaultJSON)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var modelText []byte
+	if path := cfg.Authorization.ModelPath; path != "" {
+		modelText, err = os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("authorization.model_path: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+	pool, err := database.NewPostgresPool(ctx, cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	authorizer, err := casbinadapter.NewAdapter(casbinadapter.Config{
+		DatabaseURL: cfg.Database.DSN(),
+		ModelText:   string(modelText),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load policy: %w", err)
+	}
+	defer authorizer.Close()
+
+	// Imported rule changes are audited as the API's are.
+	var auditor port.Auditor = audit.NewNoOpAuditor()
+	if cfg.Audit.Enabled {
+		auditor = audit.NewPostgresFromConfig(pool, cfg.Audit)
+	}
+	defer auditor.Close()
+
+	uc := usecase.NewUseCase(rolerepo.NewRepository(pool), casbinadapter.NewAuditedAuthorizer(authorizer, auditor))
+	switch cmd.name {
+	case "export":
+		return exportPolicy(ctx, uc, cmd)
+	default:
+		return importPolicy(ctx, uc, cmd)
+	}
+}
+
+func exportPolicy(ctx context.Context, uc usecase.UseCase, cmd *command) error {
+	p, err := uc.ExportPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := dto.EncodePolicy(p, cmd.format)
+	if err != nil {
+		return err
+	}
+	if cmd.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(cmd.output, data, 0o644)
+}
+
+func importPolicy(ctx context.Context, uc usecase.UseCase, cmd *command) error {
+	var data []byte
+	var err error
+	if cmd.input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(cmd.input)
+	}
+	if err != nil {
+		return err
+	}
+	p, err := dto.DecodePolicy(data)
+	if err != nil {
+		return fmt.Errorf("invalid policy file: %w", err)
+	}
+	diff, err := uc.ImportPolicy(ctx, *p, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(diff)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+)
+
+// workerFlags are the command-line overrides for worker.queues, worker.only
+// and worker.dry_run. A flag that is not given leaves the config value, which
+// may come from the config file or its WORKER_* env var.
+type workerFlags struct {
+	queues []string
+	only   []string
+	dryRun bool
+	set    map[string]bool
+}
+
+// parseFlags parses the worker's command line. It returns flag.ErrHelp for
+// -h, after printing usage to output.
+func parseFlags(args []string, output io.Writer) (*workerFlags, error) {
+	f := &workerFlags{set: make(map[string]bool)}
+	var queues, only string
+
+	fs := flag.NewFlagSet("worker", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&queues, "queues", "", "comma-separated queues to consume (default: worker.queue_name)")
+	fs.StringVar(&only, "only", "", "comma-separated job types to run; other jobs are left on the queue")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "log each job and put it back instead of running it; exit after one pass")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	fs.Visit(func(fl *flag.Flag) { f.set[fl.Name] = true })
+	f.queues = splitList(queues)
+	f.only = splitList(only)
+	if f.set["queues"] && len(f.queues) == 0 {
+		return nil, fmt.Errorf("-queues needs at least one queue name")
+	}
+	return f, nil
+}
+
+// apply overrides wc with the flags that were given.
+func (f *workerFlags) apply(wc *config.WorkerConfig) {
+	if f.set["queues"] {
+		wc.Queues = f.queues
+	}
+	if f.set["only"] {
+		wc.Only = f.only
+	}
+	if f.set["dry-run"] {
+		wc.DryRun = f.dryRun
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
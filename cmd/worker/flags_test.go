@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+)
+
+func TestParseFlags(t *testing.T) {
+	base := config.WorkerConfig{QueueName: "jobs", Queues: []string{"from-env"}, Only: []string{"audit.cleanup"}, DryRun: true}
+
+	t.Run("no flags keep config", func(t *testing.T) {
+		f, err := parseFlags(nil, io.Discard)
+		require.NoError(t, err)
+		wc := base
+		f.apply(&wc)
+		assert.Equal(t, base, wc)
+	})
+
+	t.Run("flags override config", func(t *testing.T) {
+		f, err := parseFlags([]string{"-queues", "jobs, emails,", "--only=email.send", "-dry-run=false"}, io.Discard)
+		require.NoError(t, err)
+		wc := base
+		f.apply(&wc)
+		assert.Equal(t, []string{"jobs", "emails"}, wc.Queues)
+		assert.Equal(t, []string{"email.send"}, wc.Only)
+		assert.False(t, wc.DryRun)
+	})
+
+	t.Run("empty only clears the filter", func(t *testing.T) {
+		f, err := parseFlags([]string{"-only="}, io.Discard)
+		require.NoError(t, err)
+		wc := base
+		f.apply(&wc)
+		assert.Empty(t, wc.Only)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := parseFlags([]string{"-queues="}, io.Discard)
+		assert.Error(t, err)
+		_, err = parseFlags([]string{"extra"}, io.Discard)
+		assert.Error(t, err)
+		_, err = parseFlags([]string{"-h"}, io.Discard)
+		assert.True(t, errors.Is(err, flag.ErrHelp))
+	})
+}
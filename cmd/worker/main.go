@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,14 +11,27 @@ import (
 	"syscall"
 	"time"
 
+	defaultconfig "github.com/14mdzk/goscratch/config"
+	"github.com/14mdzk/goscratch/internal/adapter/audit"
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
 	emailadapter "github.com/14mdzk/goscratch/internal/adapter/email"
+	"github.com/14mdzk/goscratch/internal/adapter/jobstatus"
 	"github.com/14mdzk/goscratch/internal/adapter/queue"
+	"github.com/14mdzk/goscratch/internal/adapter/storage"
+	authusecase "github.com/14mdzk/goscratch/internal/module/auth/usecase"
+	userrepo "github.com/14mdzk/goscratch/internal/module/user/repository"
 	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/14mdzk/goscratch/internal/platform/watchdog"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
 	"github.com/14mdzk/goscratch/internal/worker/handlers"
+	"github.com/14mdzk/goscratch/internal/worker/outbox"
+	"github.com/14mdzk/goscratch/internal/worker/scheduler"
 	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
@@ -26,16 +41,27 @@ func main() {
 }
 
 func run() error {
-	// Load configuration
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "config/config.default.json"
+	flags, err := parseFlags(os.Args[1:], os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
 	}
 
-	cfg, err := config.Load(configPath)
+	// Load configuration. An explicit CONFIG_PATH must exist; otherwise
+	// config/config.default.json is used when deployed beside the binary, and
+	// the copy embedded at build time when not.
+	var cfg *config.Config
+	if configPath := os.Getenv("CONFIG_PATH"); configPath != "" {
+		cfg, err = config.Load(configPath)
+	} else {
+		cfg, _, err = config.LoadOrDefault("config/config.default.json", defaultconfig.DefaultJSON)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	flags.apply(&cfg.Worker)
 
 	// Initialize logger
 	logLevel := "info"
@@ -47,16 +73,58 @@ func run() error {
 		Format: "json",
 	})
 
+	// Same resource attributes as the API, so both processes' telemetry
+	// carries one set of labels.
+	resourceAttrs, err := observability.RenderResourceAttributes(cfg.Observability.ResourceAttributes, observability.ResourceInfo{
+		Service:     cfg.App.Name,
+		Environment: cfg.App.Env,
+		Version:     "1.0.0",
+		Process:     "worker",
+	})
+	if err != nil {
+		return fmt.Errorf("invalid observability.resource_attributes: %w", err)
+	}
+	appLogger = &logger.Logger{Logger: appLogger.With(resourceAttrs.LogArgs()...)}
+
 	appLogger.Info("Starting worker process",
 		"app", cfg.App.Name,
 		"env", cfg.App.Env,
 	)
 
+	// Env override names only, never values
+	envReport := cfg.EnvReport()
+	if len(envReport.Applied) > 0 {
+		appLogger.Info("Applied config env overrides", "vars", envReport.Applied)
+	}
+	for _, v := range envReport.Invalid {
+		appLogger.Warn("Ignored invalid config env override", "var", v)
+	}
+	for _, v := range envReport.Unknown {
+		appLogger.Warn("Unknown config env var matched no setting", "var", v)
+	}
+
 	ctx := context.Background()
 
+	if cfg.Observability.Tracing.Enabled {
+		shutdown, err := observability.InitTracer(ctx, observability.TracerConfig{
+			ServiceName:    cfg.App.Name,
+			ServiceVersion: "1.0.0",
+			Environment:    cfg.App.Env,
+			Endpoint:       cfg.Observability.Tracing.Endpoint,
+			Enabled:        true,
+			Attributes:     resourceAttrs,
+		})
+		if err != nil {
+			appLogger.Warn("Failed to initialize tracing", "error", err)
+		} else {
+			defer func() { _ = shutdown(context.Background()) }()
+		}
+	}
+
 	// Validate queue configuration before creating connections
-	if !cfg.RabbitMQ.Enabled {
-		return fmt.Errorf("RabbitMQ must be enabled to run the worker. Set rabbitmq.enabled=true in config")
+	broker := cfg.QueueBroker()
+	if broker == "" {
+		return fmt.Errorf("a queue broker must be enabled to run the worker. Set rabbitmq.enabled=true or nats.enabled=true in config")
 	}
 
 	// Initialize database connection
@@ -68,15 +136,13 @@ func run() error {
 	defer pool.Close()
 	appLogger.Info("Database connected successfully")
 
-	appLogger.Info("Connecting to RabbitMQ...")
-	queueAdapter, err := queue.NewRabbitMQWithOptions(cfg.RabbitMQ.URL, queue.Options{
-		PrefetchCount: cfg.RabbitMQ.PrefetchCount,
-	})
+	appLogger.Info("Connecting to queue broker...", "broker", broker)
+	queueAdapter, err := queue.Connect(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return fmt.Errorf("failed to connect to %s: %w", broker, err)
 	}
 	defer queueAdapter.Close()
-	appLogger.Info("RabbitMQ connected successfully")
+	appLogger.Info("Queue broker connected successfully", "broker", broker)
 
 	// Get worker config with defaults
 	queueName := cfg.Worker.QueueName
@@ -88,51 +154,159 @@ func run() error {
 		concurrency = 2
 	}
 
-	// Create worker
+	// Read-only mode is flipped through the API and shared via Redis, as are
+	// idempotency keys; without Redis the worker cannot see them and runs
+	// every job.
 	workerCfg := worker.Config{
-		QueueName:   queueName,
-		Exchange:    cfg.Worker.Exchange,
-		Concurrency: concurrency,
+		QueueName:    queueName,
+		Queues:       cfg.Worker.Queues,
+		Exchange:     cfg.Worker.Exchange,
+		Concurrency:  concurrency,
+		Only:         cfg.Worker.Only,
+		DryRun:       cfg.Worker.DryRun,
+		QueueOptions: queue.OptionsFromConfig(cfg.Worker),
+		DeadLetter:   cfg.Worker.DLQ,
+	}
+	if cfg.Worker.Tracking {
+		workerCfg.Jobs = jobstatus.NewPostgresStore(pool)
+	}
+	if cfg.Observability.Tracing.Enabled {
+		workerCfg.Middleware = append(workerCfg.Middleware, worker.Tracing(observability.GetTracer()))
+	}
+	// The worker declares its own queues on Start; the dead-letter queue
+	// they route to must exist first.
+	if err := queue.DeclareTopology(ctx, queueAdapter, cfg.Worker); err != nil {
+		return fmt.Errorf("failed to declare dead-letter topology: %w", err)
 	}
+	var redisCache *cache.RedisCache
+	if cfg.Redis.Enabled {
+		redisCache, err = cache.NewRedisCache(cfg.Redis.Addr(), cfg.Redis.Password, cfg.Redis.DB)
+		if err != nil {
+			appLogger.Warn("Failed to connect to Redis; read-only mode will not pause jobs and idempotency keys are not checked", "error", err)
+			redisCache = nil
+		} else {
+			defer redisCache.Close()
+			readOnly := readonly.New(redisCache, readonly.Config{
+				Refresh: time.Duration(cfg.ReadOnly.Refresh),
+				Logger:  appLogger,
+			})
+			readOnly.Start(ctx)
+			defer readOnly.Close()
+			workerCfg.ReadOnly = readOnly
+			workerCfg.Idempotency = redisCache
+			workerCfg.IdempotencyTTL = time.Duration(cfg.Worker.IdempotencyTTL)
+		}
+	} else {
+		appLogger.Warn("Redis is disabled; read-only mode will not pause jobs and idempotency keys are not checked")
+	}
+
+	// Create worker
 	w := worker.New(queueAdapter, appLogger, workerCfg)
 
 	// Initialize email sender
-	var emailSender port.EmailSender
 	if cfg.Email.Enabled {
-		appLogger.Info("Initializing SMTP email sender...")
-		emailSender = emailadapter.NewSMTPSender(emailadapter.SMTPConfig{
-			Host:     cfg.Email.Host,
-			Port:     cfg.Email.Port,
-			Username: cfg.Email.Username,
-			Password: cfg.Email.Password,
-			From:     cfg.Email.From,
-		})
-	} else {
-		emailSender = emailadapter.NewNoOpSender(appLogger)
+		appLogger.Info("Initializing SMTP email sender...", "tenants", len(cfg.Email.Tenants))
 	}
+	emailSender := emailadapter.NewSender(cfg.Email, appLogger)
 	defer emailSender.Close()
 
 	// Register job handlers
 	w.RegisterHandler(handlers.NewEmailHandler(appLogger, emailSender))
-	w.RegisterHandler(handlers.NewAuditCleanupHandler(pool, appLogger))
+	userRepo := userrepo.NewRepository(pool, userrepo.WithGmailAliases(cfg.Users.GmailAliases))
+	w.RegisterHandler(handlers.NewDormantUsersHandler(userRepo, emailSender, appLogger))
+	w.RegisterHandler(handlers.NewNormalizeEmailsHandler(userRepo, appLogger))
+	// Asynchronous user and audit exports are uploaded to the storage the
+	// API links to.
+	exportStorage, storageErr := newStorage(ctx, cfg.Storage)
+	if storageErr != nil {
+		appLogger.Warn("Failed to initialize storage; export jobs will not be handled", "error", storageErr)
+	} else {
+		defer exportStorage.Close()
+		w.RegisterHandler(handlers.NewUserExportHandler(userRepo, exportStorage, appLogger))
+		w.RegisterHandler(handlers.NewAuditExportHandler(audit.NewPostgresAuditor(pool), exportStorage, appLogger))
+	}
+	// Audit entries are archived to the same storage. Without it, cleanup
+	// jobs are left queued rather than deleting entries unarchived.
+	auditCleanup := handlers.AuditCleanupOptions{
+		RetentionDays: cfg.Audit.Retention.Days,
+		ArchivePrefix: cfg.Audit.Retention.ArchivePrefix,
+	}
+	if cfg.Audit.Retention.Archive {
+		auditCleanup.Archive = exportStorage
+	}
+	if cfg.Audit.Retention.Archive && storageErr != nil {
+		appLogger.Warn("Storage is unavailable; audit cleanup jobs will not be handled")
+	} else {
+		w.RegisterHandler(handlers.NewAuditCleanupHandler(handlers.NewPostgresAuditRetentionStore(pool), auditCleanup, appLogger))
+	}
+	w.RegisterHandler(handlers.NewAnomalyDetectionHandler(handlers.NewPostgresAnomalyStore(pool), emailSender, appLogger))
+	// Refresh-token sessions live in Redis; without it there is nothing to sweep.
+	if redisCache != nil {
+		w.RegisterHandler(handlers.NewSessionSweepHandler(authusecase.NewSessionSweeper(redisCache, cfg.JWT), appLogger))
+	} else {
+		appLogger.Warn("Redis is unavailable; session sweep jobs will not be handled")
+	}
+
+	// The worker serves no metrics, so the watchdog only logs. Leaked retry
+	// goroutines show up as sustained goroutine growth.
+	if cfg.Watchdog.Enabled {
+		leakWatchdog := watchdog.New(watchdog.Config{
+			Interval:        time.Duration(cfg.Watchdog.Interval),
+			Window:          cfg.Watchdog.Window,
+			GoroutineGrowth: cfg.Watchdog.GoroutineGrowth,
+			Logger:          appLogger,
+		})
+		leakWatchdog.Start()
+		defer leakWatchdog.Close()
+	}
 
 	// Start worker
 	if err := w.Start(); err != nil {
 		return fmt.Errorf("failed to start worker: %w", err)
 	}
 
+	// Every instance runs the scheduler; the Redis lock lets only one of
+	// them enqueue each firing. A dry run only drains, so it schedules nothing.
+	if cfg.Scheduler.Enabled && !cfg.Worker.DryRun {
+		sched, err := newScheduler(cfg, queueAdapter, pool, redisCache, appLogger)
+		if err != nil {
+			return fmt.Errorf("failed to create scheduler: %w", err)
+		}
+		sched.Start(ctx)
+		defer sched.Close()
+	}
+
+	// Every instance relays the outbox; row locks keep them from publishing
+	// a message twice at once. A dry run only drains, so it relays nothing.
+	if cfg.Worker.Outbox.Enabled && !cfg.Worker.DryRun {
+		relay := outbox.New(outbox.NewPostgresStore(pool), queueAdapter, appLogger, outbox.Config{
+			PollInterval: time.Duration(cfg.Worker.Outbox.PollInterval),
+			BatchSize:    cfg.Worker.Outbox.BatchSize,
+			Retention:    time.Duration(cfg.Worker.Outbox.Retention),
+		})
+		relay.Start(ctx)
+		defer relay.Close()
+	}
+
 	appLogger.Info("Worker is running",
 		"queue", queueName,
+		"queues", cfg.Worker.Queues,
 		"concurrency", concurrency,
+		"only", cfg.Worker.Only,
+		"dry_run", cfg.Worker.DryRun,
+		"outbox", cfg.Worker.Outbox.Enabled,
 	)
 	appLogger.Info("Press Ctrl+C to stop.")
 
-	// Wait for interrupt signal
+	// Wait for interrupt signal, or for a dry run to finish its pass
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	appLogger.Info("Received shutdown signal")
+	select {
+	case <-quit:
+		appLogger.Info("Received shutdown signal")
+	case <-w.DryRunDone():
+		appLogger.Info("Dry run complete")
+	}
 
 	// Graceful shutdown with timeout
 	shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -145,3 +319,47 @@ func run() error {
 	appLogger.Info("Worker process stopped")
 	return nil
 }
+
+// newScheduler builds the job scheduler from cfg.Scheduler. Without Redis
+// it runs unlocked, so every worker instance enqueues every firing.
+func newScheduler(cfg *config.Config, q port.Queue, pool *pgxpool.Pool, redisCache *cache.RedisCache, log *logger.Logger) (*scheduler.Scheduler, error) {
+	codecs, err := worker.ParseCodecs(cfg.Worker.Codec, cfg.Worker.JobCodecs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid worker codecs: %w", err)
+	}
+	priorities, err := worker.ParsePriorities(cfg.Worker.JobPriorities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid worker job priorities: %w", err)
+	}
+	publisherOpts := []worker.PublisherOption{worker.WithPriorities(priorities)}
+	if cfg.Worker.Tracking {
+		publisherOpts = append(publisherOpts, worker.WithJobRecorder(jobstatus.NewPostgresStore(pool)))
+	}
+	publisher := worker.NewPublisherWithCodecs(q, cfg.Worker.QueueName, cfg.Worker.Exchange, codecs, publisherOpts...)
+
+	schedCfg, err := scheduler.FromConfig(cfg.Scheduler)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Scheduler.Database {
+		schedCfg.Store = scheduler.NewPostgresStore(pool)
+	}
+	if redisCache != nil {
+		schedCfg.Lock = redisCache
+	}
+	return scheduler.New(publisher, log, schedCfg)
+}
+
+// newStorage opens the storage backend selected by cfg.Mode.
+func newStorage(ctx context.Context, cfg config.StorageConfig) (port.Storage, error) {
+	if cfg.Mode == "s3" {
+		return storage.NewS3Storage(ctx, storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Bucket:    cfg.S3.Bucket,
+			Region:    cfg.S3.Region,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+		})
+	}
+	return storage.NewLocalStorage(cfg.Local.BasePath, "")
+}
@@ -0,0 +1,11 @@
+// Package config embeds config.default.json, so the binaries start with
+// sensible defaults when no config file is deployed beside them. Loading and
+// validating the config is internal/platform/config's job.
+package config
+
+import _ "embed"
+
+// DefaultJSON is config.default.json as of the build
+//
+//go:embed config.default.json
+var DefaultJSON []byte
@@ -70,9 +70,12 @@ func TestNoOpAuditor_Query_WithAllFilters(t *testing.T) {
 	now := time.Now()
 	entries, err := a.Query(context.Background(), port.AuditFilter{
 		UserID:     "user-1",
-		Action:     port.AuditActionDelete,
-		Resource:   "order",
+		Involving:  "user-2",
+		Actions:    []port.AuditAction{port.AuditActionDelete},
+		Resources:  []string{"order"},
 		ResourceID: "order-1",
+		Contains:   map[string]any{"status": "paid"},
+		Search:     "paid",
 		StartTime:  &now,
 		EndTime:    &now,
 		Limit:      10,
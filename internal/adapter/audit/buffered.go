@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// DropWrite is the reason passed to BufferedOptions.OnDrop for entries lost
+// because their batch could not be written
+const DropWrite = "write_failed"
+
+// BatchLogger is implemented by auditors that store many entries in one
+// round trip. BufferedAuditor uses it when its inner auditor has it.
+type BatchLogger interface {
+	LogBatch(ctx context.Context, entries []port.AuditEntry) error
+}
+
+// BufferedOptions configures a BufferedAuditor
+type BufferedOptions struct {
+	// BufferSize is how many entries wait to be written before Log writes
+	// synchronously instead (default: 1024).
+	BufferSize int
+	// BatchSize is the most entries written at once (default: 100).
+	BatchSize int
+	// FlushInterval is the longest an entry waits for a batch to fill
+	// (default: 1s).
+	FlushInterval time.Duration
+	// Timeout bounds each batch write (default: 5s).
+	Timeout time.Duration
+	// OnDrop is called for every entry that is not written
+	OnDrop func(reason string)
+	Logger *logger.Logger
+}
+
+// BufferedAuditor implements port.Auditor by queueing entries and writing
+// them to an inner auditor in batches, off the request path. When the queue
+// is full, or after Close, Log writes synchronously rather than drop the
+// entry. Entries are visible to Query once their batch is written.
+type BufferedAuditor struct {
+	inner port.Auditor
+	opts  BufferedOptions
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan port.AuditEntry
+	done   chan struct{}
+}
+
+var _ port.Auditor = (*BufferedAuditor)(nil)
+
+// NewBufferedAuditor starts writing the entries logged through it to inner
+func NewBufferedAuditor(inner port.Auditor, opts BufferedOptions) *BufferedAuditor {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	a := &BufferedAuditor{
+		inner: inner,
+		opts:  opts,
+		queue: make(chan port.AuditEntry, opts.BufferSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// NewBufferedFromConfig wraps inner with the buffering cfg describes
+func NewBufferedFromConfig(inner port.Auditor, cfg config.AuditAsyncConfig, opts BufferedOptions) *BufferedAuditor {
+	opts.BufferSize = cfg.BufferSize
+	opts.BatchSize = cfg.BatchSize
+	opts.FlushInterval = time.Duration(cfg.FlushInterval)
+	return NewBufferedAuditor(inner, opts)
+}
+
+// Log queues entry for the next batch. The entry is stamped now if it has no
+// timestamp, so it records when the event happened rather than when it was
+// written.
+func (a *BufferedAuditor) Log(ctx context.Context, entry port.AuditEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	a.mu.RLock()
+	if !a.closed {
+		select {
+		case a.queue <- entry:
+			a.mu.RUnlock()
+			return nil
+		default:
+		}
+	}
+	a.mu.RUnlock()
+	return a.inner.Log(ctx, entry)
+}
+
+// Query implements port.Auditor by delegating to the inner auditor
+func (a *BufferedAuditor) Query(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.inner.Query(ctx, filter)
+}
+
+// Close writes the queued entries, then closes the inner auditor
+func (a *BufferedAuditor) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	<-a.done
+	return a.inner.Close()
+}
+
+func (a *BufferedAuditor) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]port.AuditEntry, 0, a.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), a.opts.Timeout)
+		failed, err := a.write(ctx, batch)
+		cancel()
+		if failed > 0 {
+			for range failed {
+				a.drop()
+			}
+			if a.opts.Logger != nil {
+				a.opts.Logger.Error("audit batch write failed", "entries", len(batch), "failed", failed, "error", err)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= a.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write stores batch through the inner auditor, in one call when it is a
+// BatchLogger, and returns how many entries were not stored
+func (a *BufferedAuditor) write(ctx context.Context, batch []port.AuditEntry) (int, error) {
+	if b, ok := a.inner.(BatchLogger); ok {
+		if err := b.LogBatch(ctx, batch); err != nil {
+			return len(batch), err
+		}
+		return 0, nil
+	}
+	failed := 0
+	var lastErr error
+	for _, entry := range batch {
+		if err := a.inner.Log(ctx, entry); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	return failed, lastErr
+}
+
+func (a *BufferedAuditor) drop() {
+	if a.opts.OnDrop != nil {
+		a.opts.OnDrop(DropWrite)
+	}
+}
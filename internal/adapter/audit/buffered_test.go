@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchAuditor records single and batched writes, and can be made to fail or
+// block them
+type batchAuditor struct {
+	NoOpAuditor
+	mu      sync.Mutex
+	logged  []string   // resource IDs written by Log
+	batches [][]string // resource IDs written by LogBatch
+	err     error
+	block   chan struct{}
+	closed  bool
+}
+
+func (a *batchAuditor) Log(_ context.Context, entry port.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.logged = append(a.logged, entry.ResourceID)
+	return nil
+}
+
+func (a *batchAuditor) LogBatch(_ context.Context, entries []port.AuditEntry) error {
+	if a.block != nil {
+		<-a.block
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	batch := make([]string, len(entries))
+	for i, e := range entries {
+		batch[i] = e.ResourceID
+		if e.Timestamp.IsZero() {
+			return errors.New("entry without a timestamp")
+		}
+	}
+	a.batches = append(a.batches, batch)
+	return a.err
+}
+
+func (a *batchAuditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	return nil
+}
+
+func (a *batchAuditor) state() (logged []string, batches [][]string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.logged...), append([][]string(nil), a.batches...)
+}
+
+func entry(id string) port.AuditEntry {
+	return port.AuditEntry{Action: port.AuditActionCreate, Resource: "user", ResourceID: id}
+}
+
+func TestBufferedAuditor_BatchesAndFlushesOnClose(t *testing.T) {
+	inner := &batchAuditor{}
+	a := NewBufferedAuditor(inner, BufferedOptions{BatchSize: 2, FlushInterval: time.Hour})
+
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, a.Log(context.Background(), entry(id)))
+	}
+	require.Eventually(t, func() bool {
+		_, batches := inner.state()
+		return len(batches) == 1
+	}, time.Second, 5*time.Millisecond, "a full batch is written at once")
+
+	require.NoError(t, a.Close())
+	logged, batches := inner.state()
+	assert.Empty(t, logged, "nothing was written on the request path")
+	assert.Equal(t, [][]string{{"1", "2"}, {"3"}}, batches, "the rest is written on close")
+	assert.True(t, inner.closed)
+	require.NoError(t, a.Close(), "closing twice is harmless")
+
+	require.NoError(t, a.Log(context.Background(), entry("4")))
+	logged, _ = inner.state()
+	assert.Equal(t, []string{"4"}, logged, "after close, entries are written synchronously")
+}
+
+func TestBufferedAuditor_FlushesOnInterval(t *testing.T) {
+	inner := &batchAuditor{}
+	a := NewBufferedAuditor(inner, BufferedOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer a.Close()
+
+	require.NoError(t, a.Log(context.Background(), entry("1")))
+	require.Eventually(t, func() bool {
+		_, batches := inner.state()
+		return len(batches) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBufferedAuditor_FullBufferWritesSynchronously(t *testing.T) {
+	inner := &batchAuditor{block: make(chan struct{})}
+	a := NewBufferedAuditor(inner, BufferedOptions{BufferSize: 1, BatchSize: 1, FlushInterval: time.Hour})
+
+	// The first entry is taken by the blocked writer, the second fills the
+	// buffer, and the third cannot be queued.
+	require.NoError(t, a.Log(context.Background(), entry("1")))
+	require.Eventually(t, func() bool { return len(a.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, a.Log(context.Background(), entry("2")))
+	require.NoError(t, a.Log(context.Background(), entry("3")))
+
+	logged, _ := inner.state()
+	assert.Equal(t, []string{"3"}, logged)
+
+	close(inner.block)
+	require.NoError(t, a.Close())
+	_, batches := inner.state()
+	assert.Equal(t, [][]string{{"1"}, {"2"}}, batches, "no entry is lost")
+}
+
+func TestBufferedAuditor_CountsFailedWrites(t *testing.T) {
+	drops := &dropCounter{}
+	inner := &batchAuditor{err: errors.New("db down")}
+	a := NewBufferedAuditor(inner, BufferedOptions{BatchSize: 10, FlushInterval: time.Hour, OnDrop: drops.onDrop})
+
+	require.NoError(t, a.Log(context.Background(), entry("1")))
+	require.NoError(t, a.Log(context.Background(), entry("2")))
+	require.NoError(t, a.Close())
+	assert.Equal(t, 2, drops.get(DropWrite))
+}
+
+func TestBufferedAuditor_WithoutBatchLogger(t *testing.T) {
+	drops := &dropCounter{}
+	a := NewBufferedAuditor(&failingAuditor{}, BufferedOptions{FlushInterval: time.Hour, OnDrop: drops.onDrop})
+
+	require.NoError(t, a.Log(context.Background(), entry("1")), "the write happens later, so Log cannot fail")
+	require.NoError(t, a.Close())
+	assert.Equal(t, 1, drops.get(DropWrite), "entries are written one by one")
+}
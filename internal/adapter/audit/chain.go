@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5"
+)
+
+// A chained entry stores chain_seq, one more than the entry before it, that
+// entry's hash as prev_hash, and the SHA-256 of its own content and
+// prev_hash as hash. Changing a stored entry breaks its hash; removing one
+// leaves a gap in chain_seq; rewriting one along with its hash breaks the
+// prev_hash of the entry after it.
+//
+// Writers take a transaction-scoped advisory lock, so chained writes are
+// serialised across instances.
+
+// auditChainLockKey is the advisory lock chained writes hold ("auditch")
+const auditChainLockKey int64 = 0x61756469746368
+
+// chainVerifyPage is how many entries VerifyChain reads at once
+const chainVerifyPage = 1000
+
+// Chain verification failures
+const (
+	ChainReasonGap      = "entries before this one are missing"
+	ChainReasonPrevHash = "prev_hash does not match the entry before it"
+	ChainReasonHash     = "content does not match its hash"
+)
+
+// insertChainedAuditLog stores one chained entry
+const insertChainedAuditLog = `
+	INSERT INTO audit_logs (user_id, actor_id, action, resource, resource_id, old_value, new_value, ip_address, user_agent, created_at, request_id, trace_id, chain_seq, prev_hash, hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+`
+
+// chainRecord is what an entry's hash covers. Values are normalised to the
+// form they are read back in, so the hash of a stored entry can be
+// recomputed from its row. The correlation IDs are left out when empty, so
+// entries chained before they were recorded keep their hashes.
+type chainRecord struct {
+	Seq        int64           `json:"seq"`
+	PrevHash   string          `json:"prev_hash"`
+	UserID     string          `json:"user_id"`
+	ActorID    string          `json:"actor_id"`
+	Action     string          `json:"action"`
+	Resource   string          `json:"resource"`
+	ResourceID string          `json:"resource_id"`
+	OldValue   json.RawMessage `json:"old_value"`
+	NewValue   json.RawMessage `json:"new_value"`
+	IPAddress  string          `json:"ip_address"`
+	UserAgent  string          `json:"user_agent"`
+	CreatedAt  string          `json:"created_at"`
+	RequestID  string          `json:"request_id,omitempty"`
+	TraceID    string          `json:"trace_id,omitempty"`
+}
+
+// newChainRecord normalises the content of an entry
+func newChainRecord(seq int64, prevHash, userID, actorID, action, resource, resourceID string, oldValue, newValue []byte, ip, userAgent, requestID, traceID string, createdAt time.Time) chainRecord {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		ip = addr.String()
+	}
+	return chainRecord{
+		Seq:        seq,
+		PrevHash:   prevHash,
+		UserID:     strings.ToLower(userID),
+		ActorID:    strings.ToLower(actorID),
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		OldValue:   canonicalJSON(oldValue),
+		NewValue:   canonicalJSON(newValue),
+		IPAddress:  ip,
+		UserAgent:  userAgent,
+		CreatedAt:  createdAt.UTC().Format(time.RFC3339Nano),
+		RequestID:  requestID,
+		TraceID:    traceID,
+	}
+}
+
+// hash returns the hex SHA-256 of the record
+func (r chainRecord) hash() string {
+	b, _ := json.Marshal(r) // a chainRecord always marshals
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalJSON re-encodes JSON with sorted keys and normalised numbers, so
+// a value hashes the same before and after a JSONB round trip
+func canonicalJSON(b []byte) json.RawMessage {
+	if b == nil {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return b
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// logChained stores entries at the end of the chain in one transaction
+func (a *PostgresAuditor) logChained(ctx context.Context, entries []port.AuditEntry) error {
+	tx, err := a.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", auditChainLockKey); err != nil {
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+	var seq int64
+	var prevHash string
+	err = tx.QueryRow(ctx, `SELECT chain_seq, hash FROM audit_logs WHERE chain_seq IS NOT NULL ORDER BY chain_seq DESC LIMIT 1`).Scan(&seq, &prevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+
+	for _, entry := range entries {
+		// Stored timestamps have microsecond precision
+		entry.Timestamp = entry.Timestamp.Truncate(time.Microsecond)
+		args, err := insertArgs(entry)
+		if err != nil {
+			return err
+		}
+		seq++
+		hash := newChainRecord(seq, prevHash, entry.UserID, entry.ActorID, string(entry.Action), entry.Resource, entry.ResourceID,
+			args[5].([]byte), args[6].([]byte), entry.IPAddress, entry.UserAgent, entry.RequestID, entry.TraceID, entry.Timestamp).hash()
+		if _, err := tx.Exec(ctx, insertChainedAuditLog, append(args, seq, nullString(prevHash), hash)...); err != nil {
+			return fmt.Errorf("failed to insert audit log: %w", err)
+		}
+		prevHash = hash
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+	return nil
+}
+
+// chainRow is a stored chained entry
+type chainRow struct {
+	id     string
+	hash   string
+	record chainRecord
+}
+
+// VerifyChain implements port.AuditChainVerifier. Entries written with the
+// chain off are skipped. Starting at the oldest stored entry, its prev_hash
+// is taken on trust, as the entries before it may have been deleted by
+// retention.
+func (a *PostgresAuditor) VerifyChain(ctx context.Context, fromSeq int64, limit int) (*port.AuditChainReport, error) {
+	report := &port.AuditChainReport{Valid: true}
+
+	// The entry before fromSeq anchors the first one checked
+	var wantSeq int64
+	var wantPrev string
+	anchored := false
+	if fromSeq > 0 {
+		err := a.pool.QueryRow(ctx,
+			`SELECT chain_seq, hash FROM audit_logs WHERE chain_seq < $1 ORDER BY chain_seq DESC LIMIT 1`, fromSeq,
+		).Scan(&wantSeq, &wantPrev)
+		switch {
+		case err == nil:
+			wantSeq++
+			anchored = true
+		case !errors.Is(err, pgx.ErrNoRows):
+			return nil, fmt.Errorf("failed to read audit chain: %w", err)
+		}
+	}
+
+	next := fromSeq
+	for report.Checked < limit {
+		rows, err := a.chainRows(ctx, next, min(chainVerifyPage, limit-report.Checked))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return report, nil
+		}
+		for _, row := range rows {
+			r := row.record
+			reason := ""
+			switch {
+			case anchored && r.Seq != wantSeq:
+				reason = ChainReasonGap
+			case anchored && r.PrevHash != wantPrev:
+				reason = ChainReasonPrevHash
+			case r.hash() != row.hash:
+				reason = ChainReasonHash
+			}
+			if reason != "" {
+				report.Valid = false
+				report.BrokenSeq, report.BrokenID, report.Reason = r.Seq, row.id, reason
+				return report, nil
+			}
+
+			if report.Checked == 0 {
+				report.FirstSeq = r.Seq
+			}
+			report.Checked++
+			report.LastSeq, report.LastHash = r.Seq, row.hash
+			wantSeq, wantPrev, anchored = r.Seq+1, row.hash, true
+		}
+		next = wantSeq
+	}
+
+	// Stopped at limit: report where to continue if the chain goes on
+	var more bool
+	if err := a.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM audit_logs WHERE chain_seq > $1)`, report.LastSeq).Scan(&more); err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+	if more {
+		report.NextSeq = report.LastSeq + 1
+	}
+	return report, nil
+}
+
+// chainRows reads up to limit chained entries from sequence number fromSeq
+func (a *PostgresAuditor) chainRows(ctx context.Context, fromSeq int64, limit int) ([]chainRow, error) {
+	rows, err := a.pool.Query(ctx, `
+		SELECT id, chain_seq, COALESCE(prev_hash, ''), COALESCE(hash, ''),
+		       COALESCE(user_id::text, ''), COALESCE(actor_id::text, ''), action, resource,
+		       COALESCE(resource_id, ''), old_value, new_value,
+		       COALESCE(host(ip_address), ''), COALESCE(user_agent, ''),
+		       COALESCE(request_id, ''), COALESCE(trace_id, ''), created_at
+		FROM audit_logs
+		WHERE chain_seq >= $1
+		ORDER BY chain_seq
+		LIMIT $2`,
+		fromSeq, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	var out []chainRow
+	for rows.Next() {
+		var row chainRow
+		var seq int64
+		var prevHash, userID, actorID, action, resource, resourceID, ip, userAgent, requestID, traceID string
+		var oldValue, newValue []byte
+		var createdAt time.Time
+		if err := rows.Scan(&row.id, &seq, &prevHash, &row.hash, &userID, &actorID, &action, &resource,
+			&resourceID, &oldValue, &newValue, &ip, &userAgent, &requestID, &traceID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit chain: %w", err)
+		}
+		row.record = newChainRecord(seq, prevHash, userID, actorID, action, resource, resourceID, oldValue, newValue, ip, userAgent, requestID, traceID, createdAt)
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+var _ port.AuditChainVerifier = (*PostgresAuditor)(nil)
@@ -0,0 +1,124 @@
+//go:build integration
+
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/audit"
+	userrepo "github.com/14mdzk/goscratch/internal/module/user/repository"
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresAuditor_Chain(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// Entries written before the chain was turned on are skipped
+	require.NoError(t, audit.NewPostgresAuditor(pool).Log(ctx, port.AuditEntry{Action: port.AuditActionLogin, Resource: "auth", Timestamp: time.Now()}))
+
+	auditor := audit.NewPostgresAuditor(pool, audit.WithChain())
+	entry := func(id string) port.AuditEntry {
+		return port.AuditEntry{
+			Action:     port.AuditActionUpdate,
+			Resource:   "user",
+			ResourceID: id,
+			OldValue:   map[string]any{"name": "Ann", "age": 30},
+			NewValue:   map[string]any{"name": "Anna", "age": 30},
+			IPAddress:  "2001:DB8::1",
+			UserAgent:  "test",
+			Timestamp:  time.Now(),
+		}
+	}
+	require.NoError(t, auditor.Log(ctx, entry("1")))
+	require.NoError(t, auditor.LogBatch(ctx, []port.AuditEntry{entry("2"), entry("3"), entry("4")}))
+
+	report, err := auditor.VerifyChain(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, 4, report.Checked)
+	assert.Equal(t, int64(1), report.FirstSeq)
+	assert.Equal(t, int64(4), report.LastSeq)
+
+	t.Run("paged", func(t *testing.T) {
+		report, err := auditor.VerifyChain(ctx, 0, 3)
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, int64(4), report.NextSeq)
+
+		report, err = auditor.VerifyChain(ctx, report.NextSeq, 3)
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, 1, report.Checked)
+		assert.Zero(t, report.NextSeq)
+	})
+
+	t.Run("changed content", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE audit_logs SET resource_id = '99' WHERE chain_seq = 2`)
+		require.NoError(t, err)
+		defer pool.Exec(ctx, `UPDATE audit_logs SET resource_id = '2' WHERE chain_seq = 2`) //nolint:errcheck
+
+		report, err := auditor.VerifyChain(ctx, 0, 100)
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, int64(2), report.BrokenSeq)
+		assert.Equal(t, audit.ChainReasonHash, report.Reason)
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `DELETE FROM audit_logs WHERE chain_seq = 3`)
+		require.NoError(t, err)
+
+		report, err := auditor.VerifyChain(ctx, 0, 100)
+		require.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, int64(4), report.BrokenSeq)
+		assert.Equal(t, audit.ChainReasonGap, report.Reason)
+	})
+}
+
+func TestPostgresAuditor_ChainSurvivesMerge(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	users := userrepo.NewRepository(pool)
+	source, err := users.Create(ctx, "source@example.com", "hash", "Source")
+	require.NoError(t, err)
+	target, err := users.Create(ctx, "target@example.com", "hash", "Target")
+	require.NoError(t, err)
+
+	entry := port.AuditEntry{Action: port.AuditActionLogin, Resource: "auth", UserID: source.ID.String(), Timestamp: time.Now()}
+	require.NoError(t, audit.NewPostgresAuditor(pool).Log(ctx, entry))
+	auditor := audit.NewPostgresAuditor(pool, audit.WithChain())
+	require.NoError(t, auditor.Log(ctx, entry))
+	require.NoError(t, auditor.Log(ctx, entry))
+
+	moved, err := users.ReassignAuditLogs(ctx, source.ID.String(), target.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), moved, "only the unchained entry moves")
+
+	report, err := auditor.VerifyChain(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, 2, report.Checked)
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRecord_HashSurvivesRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 15, 9, 30, 0, 123456000, time.FixedZone("CEST", 2*3600))
+	written := newChainRecord(7, "abc", "0192F0A4-7B1C-7000-8000-000000000001", "", "UPDATE", "user", "42",
+		[]byte(`{"name":"Ann","age":30.0,"tags":["a"]}`), nil, "2001:DB8::1", "curl", "req-1", "4bf92f3577b34da6a3ce929d0e0e4736", at)
+	// As Postgres returns it: lower-case UUID, JSONB key order and number
+	// form, compressed IPv6, UTC time
+	read := newChainRecord(7, "abc", "0192f0a4-7b1c-7000-8000-000000000001", "", "UPDATE", "user", "42",
+		[]byte(`{"age": 30, "name": "Ann", "tags": ["a"]}`), nil, "2001:db8::1", "curl", "req-1", "4bf92f3577b34da6a3ce929d0e0e4736", at.UTC())
+
+	assert.Equal(t, written.hash(), read.hash())
+	assert.Len(t, written.hash(), 64)
+}
+
+func TestChainRecord_HashCoversContent(t *testing.T) {
+	at := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	base := newChainRecord(1, "", "", "", "DELETE", "user", "42", nil, nil, "", "", "", "", at)
+
+	for name, changed := range map[string]chainRecord{
+		"seq":        newChainRecord(2, "", "", "", "DELETE", "user", "42", nil, nil, "", "", "", "", at),
+		"prev hash":  newChainRecord(1, "x", "", "", "DELETE", "user", "42", nil, nil, "", "", "", "", at),
+		"action":     newChainRecord(1, "", "", "", "UPDATE", "user", "42", nil, nil, "", "", "", "", at),
+		"resource":   newChainRecord(1, "", "", "", "DELETE", "user", "43", nil, nil, "", "", "", "", at),
+		"old value":  newChainRecord(1, "", "", "", "DELETE", "user", "42", []byte(`{}`), nil, "", "", "", "", at),
+		"time":       newChainRecord(1, "", "", "", "DELETE", "user", "42", nil, nil, "", "", "", "", at.Add(time.Microsecond)),
+		"request id": newChainRecord(1, "", "", "", "DELETE", "user", "42", nil, nil, "", "", "req-1", "", at),
+		"trace id":   newChainRecord(1, "", "", "", "DELETE", "user", "42", nil, nil, "", "", "", "abc", at),
+	} {
+		assert.NotEqual(t, base.hash(), changed.hash(), name)
+	}
+}
+
+func TestChainRecord_CorrelationIDsOptional(t *testing.T) {
+	// Entries chained before request and trace IDs were recorded hash as
+	// they did then
+	b, err := json.Marshal(newChainRecord(1, "", "", "", "DELETE", "user", "42", nil, nil, "", "", "", "", time.Now()))
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "request_id")
+	assert.NotContains(t, string(b), "trace_id")
+}
@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// Reasons passed to ExportOptions.OnDrop
+const (
+	DropBufferFull = "buffer_full"
+	DropFormat     = "format_failed"
+	DropSend       = "send_failed"
+)
+
+// ExportOptions configures an ExportingAuditor
+type ExportOptions struct {
+	// BufferSize is how many events wait for the sink before new ones are
+	// dropped (default: 1024).
+	BufferSize int
+	// BatchSize is the most events sent at once (default: 100).
+	BatchSize int
+	// FlushInterval is the longest an event waits for a batch to fill
+	// (default: 1s).
+	FlushInterval time.Duration
+	// Timeout bounds each send (default: 5s).
+	Timeout time.Duration
+	// OnDrop is called for every event that is not exported
+	OnDrop func(reason string)
+	Logger *logger.Logger
+	// Sink names the sink in log messages
+	Sink string
+}
+
+// ExportingAuditor implements port.Auditor by passing entries to an inner
+// auditor and exporting a copy to a SIEM. Export is asynchronous and
+// best-effort: Log never waits on the sink, and when the sink falls behind
+// events are dropped rather than slowing requests down.
+type ExportingAuditor struct {
+	inner     port.Auditor
+	formatter Formatter
+	sink      Sink
+	opts      ExportOptions
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+}
+
+var _ port.Auditor = (*ExportingAuditor)(nil)
+
+// NewExportingAuditor starts exporting the entries logged through inner
+func NewExportingAuditor(inner port.Auditor, formatter Formatter, sink Sink, opts ExportOptions) *ExportingAuditor {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	a := &ExportingAuditor{
+		inner:     inner,
+		formatter: formatter,
+		sink:      sink,
+		opts:      opts,
+		queue:     make(chan []byte, opts.BufferSize),
+		done:      make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// NewExporterFromConfig wraps inner with the export cfg describes. Every
+// sink it lists gets an ExportingAuditor of its own, so a slow or failing
+// sink neither delays nor drops the events of another.
+func NewExporterFromConfig(inner port.Auditor, cfg config.AuditExportConfig, svc ServiceInfo, opts ExportOptions) (*ExportingAuditor, error) {
+	formatter, err := NewFormatter(cfg.Format, svc)
+	if err != nil {
+		return nil, err
+	}
+	names := cfg.Sinks()
+	sinks := make([]Sink, 0, len(names))
+	for _, name := range names {
+		sink, err := newSink(name, cfg, formatter)
+		if err != nil {
+			for _, s := range sinks {
+				_ = s.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	opts.BufferSize = cfg.BufferSize
+	opts.BatchSize = cfg.BatchSize
+	opts.FlushInterval = time.Duration(cfg.FlushInterval)
+	opts.Timeout = time.Duration(cfg.Timeout)
+	var a *ExportingAuditor
+	for i, sink := range sinks {
+		opts.Sink = names[i]
+		a = NewExportingAuditor(inner, formatter, sink, opts)
+		inner = a
+	}
+	return a, nil
+}
+
+// newSink creates the sink called name as cfg configures it
+func newSink(name string, cfg config.AuditExportConfig, formatter Formatter) (Sink, error) {
+	switch name {
+	case "file":
+		return NewFileSink(cfg.Path)
+	case "http":
+		return NewHTTPSink(&http.Client{}, cfg.URL, formatter.ContentType(), cfg.Authorization), nil
+	case "loki":
+		return NewLokiSink(&http.Client{}, cfg.Loki.URL, cfg.Loki.Labels, cfg.Loki.TenantID, cfg.Loki.Authorization), nil
+	case "elasticsearch":
+		return NewElasticsearchSink(&http.Client{}, cfg.Elasticsearch.URL, cfg.Elasticsearch.Index, cfg.Elasticsearch.Authorization), nil
+	case "kafka":
+		return NewKafkaSink(&http.Client{}, cfg.Kafka.URL, cfg.Kafka.Topic, cfg.Kafka.Authorization), nil
+	default:
+		return nil, fmt.Errorf("unknown audit export sink %q", name)
+	}
+}
+
+// Log records entry with the inner auditor and queues it for export. The
+// export is attempted even when the inner auditor fails, so the SIEM still
+// sees the event.
+func (a *ExportingAuditor) Log(ctx context.Context, entry port.AuditEntry) error {
+	err := a.inner.Log(ctx, entry)
+
+	record, ferr := a.formatter.Format(entry)
+	if ferr != nil {
+		a.drop(DropFormat, ferr)
+		return err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return err
+	}
+	select {
+	case a.queue <- record:
+	default:
+		a.drop(DropBufferFull, nil)
+	}
+	return err
+}
+
+// Query implements port.Auditor by delegating to the inner auditor
+func (a *ExportingAuditor) Query(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.inner.Query(ctx, filter)
+}
+
+// Close flushes the queued events, then closes the sink and the inner
+// auditor.
+func (a *ExportingAuditor) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	<-a.done
+	serr := a.sink.Close()
+	if err := a.inner.Close(); err != nil {
+		return err
+	}
+	return serr
+}
+
+func (a *ExportingAuditor) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, a.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), a.opts.Timeout)
+		err := a.sink.Send(ctx, batch)
+		cancel()
+		if err != nil {
+			for range batch {
+				a.drop(DropSend, nil)
+			}
+			if a.opts.Logger != nil {
+				a.opts.Logger.Warn("audit export failed", "sink", a.opts.Sink, "events", len(batch), "error", err)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case record, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= a.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (a *ExportingAuditor) drop(reason string, err error) {
+	if a.opts.OnDrop != nil {
+		a.opts.OnDrop(reason)
+	}
+	if err != nil && a.opts.Logger != nil {
+		a.opts.Logger.Warn("audit export dropped an event", "sink", a.opts.Sink, "reason", reason, "error", err)
+	}
+}
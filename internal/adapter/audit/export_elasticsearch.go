@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultElasticsearchIndex is the index of an ElasticsearchSink configured
+// with none
+const DefaultElasticsearchIndex = "goscratch-audit"
+
+// ElasticsearchSink creates a document per record through the bulk API.
+// Records must be JSON objects, as the ECS formatter writes them.
+type ElasticsearchSink struct {
+	client        *http.Client
+	url           string
+	authorization string
+}
+
+// NewElasticsearchSink creates a sink indexing into index (default:
+// DefaultElasticsearchIndex) of the cluster at baseURL. An index name that
+// matches a data stream template creates the data stream. authorization,
+// when set, is sent as the Authorization header, e.g. "ApiKey ...". client
+// defaults to http.DefaultClient.
+func NewElasticsearchSink(client *http.Client, baseURL, index, authorization string) *ElasticsearchSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if index == "" {
+		index = DefaultElasticsearchIndex
+	}
+	return &ElasticsearchSink{
+		client:        client,
+		url:           strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(index) + "/_bulk",
+		authorization: authorization,
+	}
+}
+
+// bulkCreate is the action line of every document. "create" rather than
+// "index", as data streams accept only that.
+var bulkCreate = []byte(`{"create":{}}`)
+
+// bulkResponse is the part of a bulk API response Send reads
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Error *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// Send implements Sink. The bulk API answers 200 even when documents are
+// rejected, so the response is read: any rejection fails the batch,
+// although the other documents of it were created.
+func (s *ElasticsearchSink) Send(ctx context.Context, records [][]byte) error {
+	var buf bytes.Buffer
+	for _, r := range records {
+		buf.Write(bulkCreate)
+		buf.WriteByte('\n')
+		buf.Write(r)
+		buf.WriteByte('\n')
+	}
+	header := http.Header{"Content-Type": {"application/x-ndjson"}}
+	if s.authorization != "" {
+		header.Set("Authorization", s.authorization)
+	}
+	return postBatch(ctx, s.client, s.url, header, &buf, func(body io.Reader) error {
+		var resp bulkResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return fmt.Errorf("read bulk response: %w", err)
+		}
+		if !resp.Errors {
+			return nil
+		}
+		rejected, first := 0, ""
+		for _, item := range resp.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					if rejected == 0 {
+						first = result.Error.Type + ": " + result.Error.Reason
+					}
+					rejected++
+				}
+			}
+		}
+		return fmt.Errorf("%d of %d events rejected, first: %s", rejected, len(records), first)
+	})
+}
+
+// Close implements Sink
+func (s *ElasticsearchSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,312 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// ECSVersion is the Elastic Common Schema version ECSFormatter writes
+const ECSVersion = "8.11.0"
+
+// Formatter renders an audit entry as one SIEM record
+type Formatter interface {
+	Format(entry port.AuditEntry) ([]byte, error)
+	// ContentType is the media type of a batch of records joined by
+	// newlines, as sent by HTTPSink.
+	ContentType() string
+}
+
+// ServiceInfo identifies the service the exported events come from
+type ServiceInfo struct {
+	Name        string
+	Version     string
+	Environment string
+}
+
+// NewFormatter returns the formatter for format: "ecs" (default) or "cef".
+func NewFormatter(format string, svc ServiceInfo) (Formatter, error) {
+	switch format {
+	case "", "ecs":
+		return ECSFormatter{Service: svc}, nil
+	case "cef":
+		return CEFFormatter{Service: svc}, nil
+	default:
+		return nil, fmt.Errorf("unknown audit export format %q", format)
+	}
+}
+
+// outcome reports whether entry records a failure. Only LOGIN entries carry
+// an outcome today; every other audited action is logged after it succeeded.
+func outcome(entry port.AuditEntry) string {
+	if o, _ := entry.Metadata["outcome"].(string); o == "failed" || o == "failure" {
+		return "failure"
+	}
+	return "success"
+}
+
+// subject returns who performed the action. Login is performed
+// unauthenticated, so its subject is the resource: the user ID on success
+// and the attempted email on failure.
+func subject(entry port.AuditEntry) (id, name string) {
+	if entry.UserID != "" {
+		return entry.UserID, ""
+	}
+	if entry.Action == port.AuditActionLogin && entry.Resource == "user" {
+		if outcome(entry) == "failure" {
+			return "", entry.ResourceID
+		}
+		return entry.ResourceID, ""
+	}
+	return "", ""
+}
+
+// ECSFormatter renders entries as Elastic Common Schema JSON documents.
+// Fields ECS has no place for go under the "goscratch" namespace. Old and
+// new values are not exported: they can hold personal data the SIEM does
+// not need. For entries written during an impersonation, user.id is the
+// admin and user.effective.id the impersonated user.
+type ECSFormatter struct {
+	Service ServiceInfo
+}
+
+// ContentType implements Formatter
+func (ECSFormatter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+// Format implements Formatter
+func (f ECSFormatter) Format(entry port.AuditEntry) ([]byte, error) {
+	category, typ := ecsCategory(entry)
+	event := map[string]any{
+		"kind":     "event",
+		"category": []string{category},
+		"type":     []string{typ},
+		"action":   strings.ToLower(string(entry.Action)),
+		"outcome":  outcome(entry),
+		"dataset":  "goscratch.audit",
+	}
+	if entry.ID != "" {
+		event["id"] = entry.ID
+	}
+	if reason, ok := entry.Metadata["reason"].(string); ok && reason != "" {
+		event["reason"] = reason
+	}
+
+	if entry.Action == port.AuditActionAlert {
+		event["kind"] = "alert"
+		if severity, ok := entry.Metadata["severity"].(string); ok {
+			event["severity"] = cefAlertSeverity(severity)
+		}
+	}
+
+	doc := map[string]any{
+		"@timestamp": entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		"ecs":        map[string]any{"version": ECSVersion},
+		"event":      event,
+	}
+	if id, name := subject(entry); id != "" || name != "" {
+		user := map[string]any{}
+		if id != "" {
+			user["id"] = id
+		}
+		if name != "" {
+			user["name"] = name
+		}
+		if entry.ActorID != "" {
+			user = map[string]any{"id": entry.ActorID, "effective": user}
+		}
+		doc["user"] = user
+	}
+	if entry.IPAddress != "" {
+		doc["source"] = map[string]any{"ip": entry.IPAddress}
+	}
+	if entry.UserAgent != "" {
+		doc["user_agent"] = map[string]any{"original": entry.UserAgent}
+	}
+	if entry.RequestID != "" {
+		doc["http"] = map[string]any{"request": map[string]any{"id": entry.RequestID}}
+	}
+	if entry.TraceID != "" {
+		doc["trace"] = map[string]any{"id": entry.TraceID}
+	}
+	if svc := ecsService(f.Service); len(svc) > 0 {
+		doc["service"] = svc
+	}
+	custom := map[string]any{"resource": entry.Resource}
+	if entry.ResourceID != "" {
+		custom["resource_id"] = entry.ResourceID
+	}
+	if len(entry.Metadata) > 0 {
+		custom["metadata"] = entry.Metadata
+	}
+	doc["goscratch"] = custom
+
+	return json.Marshal(doc)
+}
+
+// ecsCategory maps an entry to an ECS event.category and event.type
+func ecsCategory(entry port.AuditEntry) (string, string) {
+	switch entry.Action {
+	case port.AuditActionLogin:
+		return "authentication", "start"
+	case port.AuditActionLogout:
+		return "authentication", "end"
+	case port.AuditActionAlert:
+		return "intrusion_detection", "indicator"
+	case port.AuditActionBlock:
+		return "network", "denied"
+	case port.AuditActionRequest:
+		return "web", "access"
+	}
+
+	category := "database"
+	switch entry.Resource {
+	case "user", "role", "permission":
+		category = "iam"
+	case "file":
+		category = "file"
+	}
+	switch entry.Action {
+	case port.AuditActionCreate:
+		return category, "creation"
+	case port.AuditActionDelete:
+		return category, "deletion"
+	case port.AuditActionRead:
+		return category, "access"
+	default:
+		return category, "change"
+	}
+}
+
+func ecsService(svc ServiceInfo) map[string]any {
+	m := map[string]any{}
+	if svc.Name != "" {
+		m["name"] = svc.Name
+	}
+	if svc.Version != "" {
+		m["version"] = svc.Version
+	}
+	if svc.Environment != "" {
+		m["environment"] = svc.Environment
+	}
+	return m
+}
+
+// CEFFormatter renders entries as ArcSight Common Event Format lines
+type CEFFormatter struct {
+	Service ServiceInfo
+}
+
+// ContentType implements Formatter
+func (CEFFormatter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// Format implements Formatter
+func (f CEFFormatter) Format(entry port.AuditEntry) ([]byte, error) {
+	product := f.Service.Name
+	if product == "" {
+		product = "goscratch"
+	}
+	action := strings.ToLower(string(entry.Action))
+	signature := entry.Resource + ":" + action
+	name := fmt.Sprintf("%s %s", entry.Resource, action)
+	if outcome(entry) == "failure" {
+		name += " failed"
+	}
+
+	var ext []string
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefExtension(value))
+		}
+	}
+	add("rt", fmt.Sprint(entry.Timestamp.UnixMilli()))
+	add("act", action)
+	add("outcome", outcome(entry))
+	id, userName := subject(entry)
+	add("suid", id)
+	add("suser", userName)
+	add("src", entry.IPAddress)
+	add("requestClientApplication", entry.UserAgent)
+	add("cs1Label", "resource")
+	add("cs1", entry.Resource)
+	if entry.ResourceID != "" {
+		add("cs2Label", "resourceId")
+		add("cs2", entry.ResourceID)
+	}
+	if reason, ok := entry.Metadata["reason"].(string); ok && reason != "" {
+		add("reason", reason)
+	}
+	add("externalId", entry.ID)
+	if f.Service.Environment != "" {
+		add("cs3Label", "environment")
+		add("cs3", f.Service.Environment)
+	}
+	if entry.ActorID != "" {
+		add("cs4Label", "actorId")
+		add("cs4", entry.ActorID)
+	}
+	if entry.RequestID != "" {
+		add("cs5Label", "requestId")
+		add("cs5", entry.RequestID)
+	}
+	if entry.TraceID != "" {
+		add("cs6Label", "traceId")
+		add("cs6", entry.TraceID)
+	}
+
+	line := fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeader("goscratch"), cefHeader(product), cefHeader(f.Service.Version),
+		cefHeader(signature), cefHeader(name), cefSeverity(entry), strings.Join(ext, " "))
+	return []byte(line), nil
+}
+
+// cefSeverity rates an entry 0-10: failed logins and deletions stand out
+// from routine activity.
+func cefSeverity(entry port.AuditEntry) int {
+	switch {
+	case entry.Action == port.AuditActionAlert:
+		severity, _ := entry.Metadata["severity"].(string)
+		return cefAlertSeverity(severity)
+	case outcome(entry) == "failure":
+		return 5
+	case entry.Action == port.AuditActionDelete:
+		return 4
+	case entry.Action == port.AuditActionLogin, entry.Action == port.AuditActionLogout:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// cefAlertSeverity maps an alert's severity name to the 0-10 scale
+func cefAlertSeverity(severity string) int {
+	switch severity {
+	case "critical":
+		return 10
+	case "high":
+		return 8
+	case "medium":
+		return 6
+	default:
+		return 3
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+func cefHeader(s string) string {
+	return cefHeaderEscaper.Replace(s)
+}
+
+func cefExtension(s string) string {
+	return cefExtensionEscaper.Replace(s)
+}
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Sink ships a batch of formatted records to a SIEM
+type Sink interface {
+	Send(ctx context.Context, records [][]byte) error
+	Close() error
+}
+
+// FileSink appends one record per line to a file, for a log shipper
+// (Filebeat, Fluent Bit, ...) to tail.
+type FileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileSink opens path for appending, creating it readable by the owner
+// only.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit export file: %w", err)
+	}
+	return &FileSink{f: f}, nil
+}
+
+// Send implements Sink. The batch is written with a single write so lines
+// from concurrent writers do not interleave.
+func (s *FileSink) Send(_ context.Context, records [][]byte) error {
+	var buf bytes.Buffer
+	for _, r := range records {
+		buf.Write(r)
+		buf.WriteByte('\n')
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write audit export file: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// HTTPSink POSTs each batch, one record per line, to a SIEM ingest endpoint
+// such as an Elasticsearch-compatible collector or a Splunk/Logstash HTTP
+// input.
+type HTTPSink struct {
+	client        *http.Client
+	url           string
+	contentType   string
+	authorization string
+}
+
+// NewHTTPSink creates a sink posting to url. authorization, when set, is
+// sent as the Authorization header. client defaults to http.DefaultClient.
+func NewHTTPSink(client *http.Client, url, contentType, authorization string) *HTTPSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSink{client: client, url: url, contentType: contentType, authorization: authorization}
+}
+
+// Send implements Sink. Any status other than 2xx is an error.
+func (s *HTTPSink) Send(ctx context.Context, records [][]byte) error {
+	var buf bytes.Buffer
+	for _, r := range records {
+		buf.Write(r)
+		buf.WriteByte('\n')
+	}
+	header := http.Header{"Content-Type": {s.contentType}}
+	if s.authorization != "" {
+		header.Set("Authorization", s.authorization)
+	}
+	return postBatch(ctx, s.client, s.url, header, &buf, nil)
+}
+
+// Close implements Sink
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// postBatch POSTs body to url with header and fails on any status other
+// than 2xx. read, when set, is given the body of a 2xx response.
+func postBatch(ctx context.Context, client *http.Client, url string, header http.Header, body io.Reader, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return fmt.Errorf("build audit export request: %w", err)
+	}
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may carry credentials; url.Error would repeat it.
+		return fmt.Errorf("send audit export batch: %w", redactURLError(err))
+	}
+	defer resp.Body.Close()
+	// Limited so a misbehaving endpoint cannot exhaust memory
+	respBody := io.LimitReader(resp.Body, 4<<20)
+	defer io.Copy(io.Discard, respBody) //nolint:errcheck // draining for connection reuse
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("send audit export batch: unexpected status %d", resp.StatusCode)
+	}
+	if read != nil {
+		if err := read(respBody); err != nil {
+			return fmt.Errorf("send audit export batch: %w", err)
+		}
+	}
+	return nil
+}
+
+func redactURLError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		if u, perr := url.Parse(ue.URL); perr == nil {
+			return &url.Error{Op: ue.Op, URL: u.Redacted(), Err: ue.Err}
+		}
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -13,44 +14,101 @@ import (
 
 // PostgresAuditor implements port.Auditor using PostgreSQL
 type PostgresAuditor struct {
-	pool *pgxpool.Pool
+	pool  *pgxpool.Pool
+	chain bool
+}
+
+// PostgresOption configures a PostgresAuditor
+type PostgresOption func(*PostgresAuditor)
+
+// WithChain makes the auditor chain the entries it writes (see chain.go)
+func WithChain() PostgresOption {
+	return func(a *PostgresAuditor) { a.chain = true }
 }
 
 // NewPostgresAuditor creates a new PostgreSQL auditor
-func NewPostgresAuditor(pool *pgxpool.Pool) *PostgresAuditor {
-	return &PostgresAuditor{pool: pool}
+func NewPostgresAuditor(pool *pgxpool.Pool, opts ...PostgresOption) *PostgresAuditor {
+	a := &PostgresAuditor{pool: pool}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
+// NewPostgresFromConfig creates a PostgreSQL auditor that chains entries
+// when cfg.Chain is set
+func NewPostgresFromConfig(pool *pgxpool.Pool, cfg config.AuditConfig) *PostgresAuditor {
+	if cfg.Chain {
+		return NewPostgresAuditor(pool, WithChain())
+	}
+	return NewPostgresAuditor(pool)
+}
+
+// insertAuditLog stores one entry
+const insertAuditLog = `
+	INSERT INTO audit_logs (user_id, actor_id, action, resource, resource_id, old_value, new_value, ip_address, user_agent, created_at, request_id, trace_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+`
+
 func (a *PostgresAuditor) Log(ctx context.Context, entry port.AuditEntry) error {
+	if a.chain {
+		return a.logChained(ctx, []port.AuditEntry{entry})
+	}
+	args, err := insertArgs(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := a.pool.Exec(ctx, insertAuditLog, args...); err != nil {
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+	return nil
+}
+
+// LogBatch stores entries in one round trip. The batch runs in a single
+// implicit transaction, so either every entry is stored or none is.
+func (a *PostgresAuditor) LogBatch(ctx context.Context, entries []port.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if a.chain {
+		return a.logChained(ctx, entries)
+	}
+	batch := &pgx.Batch{}
+	for _, entry := range entries {
+		args, err := insertArgs(entry)
+		if err != nil {
+			return err
+		}
+		batch.Queue(insertAuditLog, args...)
+	}
+	if err := a.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to insert audit logs: %w", err)
+	}
+	return nil
+}
+
+// insertArgs returns the insertAuditLog arguments for entry
+func insertArgs(entry port.AuditEntry) ([]any, error) {
 	var oldValueJSON, newValueJSON []byte
 	var err error
 
 	if entry.OldValue != nil {
 		oldValueJSON, err = json.Marshal(entry.OldValue)
 		if err != nil {
-			return fmt.Errorf("failed to marshal old value: %w", err)
+			return nil, fmt.Errorf("failed to marshal old value: %w", err)
 		}
 	}
 
 	if entry.NewValue != nil {
 		newValueJSON, err = json.Marshal(entry.NewValue)
 		if err != nil {
-			return fmt.Errorf("failed to marshal new value: %w", err)
+			return nil, fmt.Errorf("failed to marshal new value: %w", err)
 		}
 	}
 
-	query := `
-		INSERT INTO audit_logs (user_id, action, resource, resource_id, old_value, new_value, ip_address, user_agent, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`
-
-	var userID any
-	if entry.UserID != "" {
-		userID = entry.UserID
-	}
-
-	_, err = a.pool.Exec(ctx, query,
-		userID,
+	return []any{
+		nullString(entry.UserID),
+		nullString(entry.ActorID),
 		entry.Action,
 		entry.Resource,
 		entry.ResourceID,
@@ -59,18 +117,14 @@ func (a *PostgresAuditor) Log(ctx context.Context, entry port.AuditEntry) error
 		nullString(entry.IPAddress),
 		nullString(entry.UserAgent),
 		entry.Timestamp,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to insert audit log: %w", err)
-	}
-
-	return nil
+		nullString(entry.RequestID),
+		nullString(entry.TraceID),
+	}, nil
 }
 
 func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
 	query := `
-		SELECT id, user_id, action, resource, resource_id, old_value, new_value, ip_address, user_agent, created_at
+		SELECT id, user_id, actor_id, action, resource, resource_id, old_value, new_value, ip_address, user_agent, request_id, trace_id, created_at
 		FROM audit_logs
 		WHERE 1=1
 	`
@@ -83,15 +137,25 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 		argIndex++
 	}
 
-	if filter.Action != "" {
-		query += fmt.Sprintf(" AND action = $%d", argIndex)
-		args = append(args, filter.Action)
+	if filter.Involving != "" {
+		query += fmt.Sprintf(" AND (user_id = $%[1]d::uuid OR actor_id = $%[1]d::uuid OR (resource = 'user' AND resource_id = $%[1]d::uuid::text))", argIndex)
+		args = append(args, filter.Involving)
+		argIndex++
+	}
+
+	if len(filter.Actions) > 0 {
+		actions := make([]string, len(filter.Actions))
+		for i, a := range filter.Actions {
+			actions[i] = string(a)
+		}
+		query += fmt.Sprintf(" AND action = ANY($%d)", argIndex)
+		args = append(args, actions)
 		argIndex++
 	}
 
-	if filter.Resource != "" {
-		query += fmt.Sprintf(" AND resource = $%d", argIndex)
-		args = append(args, filter.Resource)
+	if len(filter.Resources) > 0 {
+		query += fmt.Sprintf(" AND resource = ANY($%d)", argIndex)
+		args = append(args, filter.Resources)
 		argIndex++
 	}
 
@@ -101,6 +165,34 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 		argIndex++
 	}
 
+	if filter.RequestID != "" {
+		query += fmt.Sprintf(" AND request_id = $%d", argIndex)
+		args = append(args, filter.RequestID)
+		argIndex++
+	}
+
+	if filter.TraceID != "" {
+		query += fmt.Sprintf(" AND trace_id = $%d", argIndex)
+		args = append(args, filter.TraceID)
+		argIndex++
+	}
+
+	if len(filter.Contains) > 0 {
+		contains, err := json.Marshal(filter.Contains)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal contains filter: %w", err)
+		}
+		query += fmt.Sprintf(" AND (old_value @> $%[1]d::jsonb OR new_value @> $%[1]d::jsonb)", argIndex)
+		args = append(args, string(contains))
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		query += fmt.Sprintf(" AND (old_value::text ILIKE '%%' || $%[1]d || '%%' OR new_value::text ILIKE '%%' || $%[1]d || '%%')", argIndex)
+		args = append(args, filter.Search)
+		argIndex++
+	}
+
 	if filter.StartTime != nil {
 		query += fmt.Sprintf(" AND created_at >= $%d", argIndex)
 		args = append(args, filter.StartTime)
@@ -113,7 +205,15 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 		argIndex++
 	}
 
-	query += " ORDER BY created_at DESC"
+	if filter.Cursor != "" {
+		query += fmt.Sprintf(" AND (created_at, id) < (SELECT created_at, id FROM audit_logs WHERE id = $%d)", argIndex)
+		args = append(args, filter.Cursor)
+		argIndex++
+	}
+
+	// id breaks ties between entries logged at the same instant, so the
+	// cursor never skips or repeats one
+	query += " ORDER BY created_at DESC, id DESC"
 
 	limit := filter.Limit
 	if limit <= 0 {
@@ -132,14 +232,15 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 	for rows.Next() {
 		var entry port.AuditEntry
 		var id string
-		var userID *string
+		var userID, actorID *string
 		var oldValue, newValue []byte
-		var ipAddress, userAgent *string
+		var ipAddress, userAgent, requestID, traceID *string
 		var createdAt time.Time
 
 		err := rows.Scan(
 			&id,
 			&userID,
+			&actorID,
 			&entry.Action,
 			&entry.Resource,
 			&entry.ResourceID,
@@ -147,6 +248,8 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 			&newValue,
 			&ipAddress,
 			&userAgent,
+			&requestID,
+			&traceID,
 			&createdAt,
 		)
 		if err != nil {
@@ -157,6 +260,9 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 		if userID != nil {
 			entry.UserID = *userID
 		}
+		if actorID != nil {
+			entry.ActorID = *actorID
+		}
 		if oldValue != nil {
 			_ = json.Unmarshal(oldValue, &entry.OldValue)
 		}
@@ -169,10 +275,21 @@ func (a *PostgresAuditor) Query(ctx context.Context, filter port.AuditFilter) ([
 		if userAgent != nil {
 			entry.UserAgent = *userAgent
 		}
+		if requestID != nil {
+			entry.RequestID = *requestID
+		}
+		if traceID != nil {
+			entry.TraceID = *traceID
+		}
 		entry.Timestamp = createdAt
 
 		entries = append(entries, entry)
 	}
+	// A connection lost mid-read ends rows.Next early; returning the short
+	// page would look like the last one.
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit logs: %w", err)
+	}
 
 	return entries, nil
 }
@@ -188,8 +305,11 @@ func nullString(s string) any {
 	return s
 }
 
-// Ensure PostgresAuditor implements the interface
-var _ port.Auditor = (*PostgresAuditor)(nil)
+// Ensure PostgresAuditor implements the interfaces
+var (
+	_ port.Auditor = (*PostgresAuditor)(nil)
+	_ BatchLogger  = (*PostgresAuditor)(nil)
+)
 
 // Compile-time check for pgx.Row interface
 var _ pgx.Row = pgx.Row(nil)
@@ -0,0 +1,72 @@
+//go:build integration
+
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/audit"
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresAuditor_Query(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	const target = "01912345-abcd-7def-8000-0000000000aa"
+	auditor := audit.NewPostgresAuditor(pool)
+	at := time.Now().Add(-time.Hour)
+	for i, e := range []port.AuditEntry{
+		{Action: port.AuditActionCreate, Resource: "user", ResourceID: target, NewValue: map[string]any{"email": "ann@example.com", "name": "Ann"}},
+		{Action: port.AuditActionUpdate, Resource: "user", ResourceID: target, OldValue: map[string]any{"name": "Ann"}, NewValue: map[string]any{"name": "Anna"}},
+		{Action: port.AuditActionCreate, Resource: "role", ResourceID: "editor", NewValue: map[string]any{"name": "editor"}, RequestID: "req-1"},
+		{Action: port.AuditActionDelete, Resource: "file", ResourceID: "a.pdf"},
+	} {
+		e.Timestamp = at.Add(time.Duration(i) * time.Second)
+		require.NoError(t, auditor.Log(ctx, e))
+	}
+
+	query := func(f port.AuditFilter) []string {
+		t.Helper()
+		entries, err := auditor.Query(ctx, f)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, string(e.Action)+" "+e.Resource)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"DELETE file", "CREATE role"}, query(port.AuditFilter{Resources: []string{"role", "file"}}))
+	assert.Equal(t, []string{"DELETE file", "UPDATE user"}, query(port.AuditFilter{Actions: []port.AuditAction{port.AuditActionUpdate, port.AuditActionDelete}}))
+	assert.Equal(t, []string{"UPDATE user", "CREATE user"}, query(port.AuditFilter{Involving: target}))
+	assert.Equal(t, []string{"CREATE user"}, query(port.AuditFilter{Contains: map[string]any{"email": "ann@example.com"}}))
+	assert.Equal(t, []string{"UPDATE user", "CREATE user"}, query(port.AuditFilter{Contains: map[string]any{"name": "Ann"}}), "old values are searched too")
+	assert.Equal(t, []string{"CREATE role"}, query(port.AuditFilter{Search: "EDIT"}))
+	assert.Equal(t, []string{"CREATE role"}, query(port.AuditFilter{RequestID: "req-1"}))
+
+	t.Run("pages with a cursor", func(t *testing.T) {
+		page, err := auditor.Query(ctx, port.AuditFilter{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		rest, err := auditor.Query(ctx, port.AuditFilter{Limit: 2, Cursor: page[1].ID})
+		require.NoError(t, err)
+		require.Len(t, rest, 2)
+		assert.Equal(t, port.AuditActionUpdate, rest[0].Action)
+		assert.Equal(t, port.AuditActionCreate, rest[1].Action)
+		assert.Equal(t, "user", rest[1].Resource)
+	})
+}
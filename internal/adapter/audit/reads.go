@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// ReadSamplingAuditor implements port.AuditReadSampler on top of an inner
+// auditor, so that modules audit record reads through port.LogRead. Each
+// read is kept with probability rate; entries logged directly, including
+// READ entries such as exports, always are.
+type ReadSamplingAuditor struct {
+	inner     port.Auditor
+	rate      float64
+	resources map[string]bool // nil: every resource
+	randF     func() float64
+}
+
+var (
+	_ port.Auditor          = (*ReadSamplingAuditor)(nil)
+	_ port.AuditReadSampler = (*ReadSamplingAuditor)(nil)
+)
+
+// NewReadSamplingAuditor audits reads of resources, or of every resource
+// when none are given, at rate, clamped to [0, 1]
+func NewReadSamplingAuditor(inner port.Auditor, rate float64, resources []string) *ReadSamplingAuditor {
+	a := &ReadSamplingAuditor{inner: inner, rate: min(max(rate, 0), 1), randF: rand.Float64}
+	if len(resources) > 0 {
+		a.resources = make(map[string]bool, len(resources))
+		for _, r := range resources {
+			a.resources[r] = true
+		}
+	}
+	return a
+}
+
+// NewReadSamplingFromConfig creates a ReadSamplingAuditor from cfg
+func NewReadSamplingFromConfig(inner port.Auditor, cfg config.AuditReadsConfig) *ReadSamplingAuditor {
+	return NewReadSamplingAuditor(inner, cfg.SampleRate, cfg.Resources)
+}
+
+// SampleRead implements port.AuditReadSampler
+func (a *ReadSamplingAuditor) SampleRead(resource string) bool {
+	if a.resources != nil && !a.resources[resource] {
+		return false
+	}
+	return a.rate >= 1 || a.randF() < a.rate
+}
+
+// Log records entry with the inner auditor
+func (a *ReadSamplingAuditor) Log(ctx context.Context, entry port.AuditEntry) error {
+	return a.inner.Log(ctx, entry)
+}
+
+// Query reads from the inner auditor
+func (a *ReadSamplingAuditor) Query(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.inner.Query(ctx, filter)
+}
+
+// Close closes the inner auditor
+func (a *ReadSamplingAuditor) Close() error {
+	return a.inner.Close()
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSamplingAuditor(t *testing.T) {
+	ctx := context.Background()
+	read := func(resource, id string) port.AuditEntry {
+		return port.AuditEntry{Action: port.AuditActionRead, Resource: resource, ResourceID: id, Timestamp: time.Now()}
+	}
+
+	t.Run("samples reads at the rate", func(t *testing.T) {
+		inner := &batchAuditor{}
+		a := NewReadSamplingAuditor(inner, 0.25, nil)
+		draws := []float64{0.1, 0.25, 0.9, 0.24}
+		a.randF = func() float64 {
+			d := draws[0]
+			draws = draws[1:]
+			return d
+		}
+		for _, id := range []string{"1", "2", "3", "4"} {
+			require.NoError(t, port.LogRead(ctx, a, read("user", id)))
+		}
+		logged, _ := inner.state()
+		assert.Equal(t, []string{"1", "4"}, logged)
+	})
+
+	t.Run("limits reads to the listed resources", func(t *testing.T) {
+		inner := &batchAuditor{}
+		a := NewReadSamplingAuditor(inner, 1, []string{"user"})
+		require.NoError(t, port.LogRead(ctx, a, read("user", "1")))
+		require.NoError(t, port.LogRead(ctx, a, read("file", "a.pdf")))
+		logged, _ := inner.state()
+		assert.Equal(t, []string{"1"}, logged)
+	})
+
+	t.Run("logs other entries as they come", func(t *testing.T) {
+		inner := &batchAuditor{}
+		a := NewReadSamplingAuditor(inner, 0, nil)
+		assert.False(t, a.SampleRead("user"))
+		require.NoError(t, a.Log(ctx, read("user", "export")))
+		logged, _ := inner.state()
+		assert.Equal(t, []string{"export"}, logged)
+	})
+
+	t.Run("auditors that do not sample reads audit none", func(t *testing.T) {
+		inner := &batchAuditor{}
+		require.NoError(t, port.LogRead(ctx, inner, read("user", "1")))
+		logged, _ := inner.state()
+		assert.Empty(t, logged)
+	})
+}
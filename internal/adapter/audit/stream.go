@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// StreamTopic is the SSE topic StreamingAuditor publishes entries to
+const StreamTopic = "audit"
+
+// StreamEvent is the SSE event type of a published entry; its data is the
+// entry as JSON
+const StreamEvent = "audit.entry"
+
+// StreamingAuditor implements port.Auditor by publishing every entry an
+// inner auditor accepts to the SSE topic StreamTopic, for live activity
+// feeds. Publishing never blocks or fails a Log: clients that fall behind
+// miss events. Published entries carry no ID, as the store assigns it.
+type StreamingAuditor struct {
+	inner  port.Auditor
+	broker port.SSEBroker
+}
+
+var _ port.Auditor = (*StreamingAuditor)(nil)
+
+// NewStreamingAuditor publishes the entries logged through it to broker
+func NewStreamingAuditor(inner port.Auditor, broker port.SSEBroker) *StreamingAuditor {
+	return &StreamingAuditor{inner: inner, broker: broker}
+}
+
+// Log records entry with the inner auditor, then publishes it
+func (a *StreamingAuditor) Log(ctx context.Context, entry port.AuditEntry) error {
+	if err := a.inner.Log(ctx, entry); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil // stored; an entry that cannot be encoded is not published
+	}
+	a.broker.BroadcastToTopic(StreamTopic, port.NewEvent(StreamEvent, data))
+	return nil
+}
+
+// Query reads from the inner auditor
+func (a *StreamingAuditor) Query(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.inner.Query(ctx, filter)
+}
+
+// Close closes the inner auditor
+func (a *StreamingAuditor) Close() error {
+	return a.inner.Close()
+}
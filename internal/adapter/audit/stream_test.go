@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/sse"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingAuditor(t *testing.T) {
+	broker := sse.NewBroker(10)
+	defer broker.Close()
+	feed := broker.Subscribe("dashboard", StreamTopic)
+	other := broker.Subscribe("orders", "orders")
+
+	inner := &batchAuditor{}
+	a := NewStreamingAuditor(inner, broker)
+	entry := port.AuditEntry{UserID: "u1", Action: port.AuditActionUpdate, Resource: "user", ResourceID: "42", Timestamp: time.Now()}
+	require.NoError(t, a.Log(context.Background(), entry))
+
+	logged, _ := inner.state()
+	assert.Equal(t, []string{"42"}, logged, "the entry is stored first")
+	select {
+	case event := <-feed:
+		assert.Equal(t, StreamEvent, event.Event)
+		var got port.AuditEntry
+		require.NoError(t, json.Unmarshal(event.Data, &got))
+		assert.Equal(t, "42", got.ResourceID)
+		assert.Equal(t, port.AuditActionUpdate, got.Action)
+	case <-time.After(time.Second):
+		t.Fatal("entry should be published to the audit topic")
+	}
+	assert.Empty(t, other, "other topics get nothing")
+
+	t.Run("failed writes are not published", func(t *testing.T) {
+		a := NewStreamingAuditor(&failingAuditor{}, broker)
+		require.Error(t, a.Log(context.Background(), entry))
+		assert.Empty(t, feed)
+	})
+
+	require.NoError(t, a.Close())
+	assert.True(t, inner.closed)
+}
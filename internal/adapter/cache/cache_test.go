@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -91,6 +92,12 @@ func TestNoOpCache_DeleteByPrefix_ReturnsErrCacheUnavailable(t *testing.T) {
 	assert.ErrorIs(t, err, port.ErrCacheUnavailable)
 }
 
+func TestNoOpCache_KeysByPrefix_ReturnsErrCacheUnavailable(t *testing.T) {
+	c := NewNoOpCache()
+	_, err := c.KeysByPrefix(context.Background(), "refresh:user:")
+	assert.ErrorIs(t, err, port.ErrCacheUnavailable)
+}
+
 // =============================================================================
 // RedisCache Tests (using miniredis)
 // =============================================================================
@@ -325,6 +332,25 @@ func TestRedisCache_DeleteByPrefix_NoMatchingKeys(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRedisCache_KeysByPrefix(t *testing.T) {
+	rc, _ := newTestRedisCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < 250; i++ { // more than one SCAN page
+		require.NoError(t, rc.Set(ctx, fmt.Sprintf("refresh:user:u1:%03d", i), []byte("1"), time.Minute))
+	}
+	require.NoError(t, rc.Set(ctx, "refresh:tok:aaa", []byte("u1"), time.Minute))
+
+	keys, err := rc.KeysByPrefix(ctx, "refresh:user:u1:")
+	require.NoError(t, err)
+	assert.Len(t, keys, 250)
+	assert.NotContains(t, keys, "refresh:tok:aaa")
+
+	keys, err = rc.KeysByPrefix(ctx, "refresh:user:nonexistent:")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
 func TestRedisCache_SetAndGet_BinaryData(t *testing.T) {
 	rc, _ := newTestRedisCache(t)
 	ctx := context.Background()
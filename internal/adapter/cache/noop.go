@@ -38,6 +38,12 @@ func (c *NoOpCache) DeleteByPrefix(ctx context.Context, prefix string) error {
 	return port.ErrCacheUnavailable
 }
 
+// KeysByPrefix returns ErrCacheUnavailable on the NoOp implementation; it
+// stores nothing, so an empty list would misreport active sessions.
+func (c *NoOpCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	return nil, port.ErrCacheUnavailable
+}
+
 func (c *NoOpCache) Exists(ctx context.Context, key string) (bool, error) {
 	return false, nil
 }
@@ -83,6 +83,27 @@ func (c *RedisCache) DeleteByPrefix(ctx context.Context, prefix string) error {
 	return nil
 }
 
+// KeysByPrefix lists all keys whose name starts with prefix using SCAN, so it
+// does not block Redis the way KEYS would on a large keyspace.
+func (c *RedisCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	var (
+		cursor uint64
+		result []string
+	)
+	for {
+		keys, nextCursor, err := c.client.Scan(ctx, cursor, prefix+"*", 100).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis scan error: %w", err)
+		}
+		result = append(result, keys...)
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+	return result, nil
+}
+
 func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	n, err := c.client.Exists(ctx, key).Result()
 	if err != nil {
@@ -0,0 +1,132 @@
+package casbin
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// auditRuleResource is the audit resource of rule changes; the resource ID
+// is the rule in CSV form, e.g. "g, <user id>, admin".
+const auditRuleResource = "authz_rule"
+
+// defaultAuditTimeout bounds each audit write, as mutations take no context
+const defaultAuditTimeout = 2 * time.Second
+
+// AuditedAuthorizer decorates a port.Authorizer so every rule it adds or
+// removes is recorded through a port.Auditor: a CREATE entry with the rule
+// as its new value, or a DELETE entry with the rule as its old value. It
+// sees every change, whichever module, endpoint or command made it.
+//
+// Mutations take no context, so entries carry no user; the role module's
+// own entries record who asked for a change. Failed changes are not
+// recorded, and a failed audit write is logged without failing the change.
+type AuditedAuthorizer struct {
+	port.Authorizer
+	auditor port.Auditor
+	timeout time.Duration
+}
+
+// NewAuditedAuthorizer wraps inner so its rule changes are logged to auditor
+func NewAuditedAuthorizer(inner port.Authorizer, auditor port.Auditor) *AuditedAuthorizer {
+	return &AuditedAuthorizer{Authorizer: inner, auditor: auditor, timeout: defaultAuditTimeout}
+}
+
+// record logs the addition or removal of rule once the change succeeded
+func (a *AuditedAuthorizer) record(added bool, rule port.PolicyRule, err error) error {
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	action := port.AuditActionDelete
+	if added {
+		action = port.AuditActionCreate
+	}
+	entry := port.NewAuditEntry(ctx, action, auditRuleResource, rule.String())
+	if added {
+		entry.NewValue = rule
+	} else {
+		entry.OldValue = rule
+	}
+	if err := a.auditor.Log(ctx, entry); err != nil {
+		slog.Warn("authorization rule audit failed", "rule", rule.String(), "error", err)
+	}
+	return nil
+}
+
+// AddRoleForUser grants a role and records the "g" rule
+func (a *AuditedAuthorizer) AddRoleForUser(userID, role string) error {
+	return a.record(true, grantRule(userID, role), a.Authorizer.AddRoleForUser(userID, role))
+}
+
+// RemoveRoleForUser revokes a role and records the "g" rule
+func (a *AuditedAuthorizer) RemoveRoleForUser(userID, role string) error {
+	return a.record(false, grantRule(userID, role), a.Authorizer.RemoveRoleForUser(userID, role))
+}
+
+// AddRoleForUserInDomain grants a role in a domain and records the "g2"
+// rule
+func (a *AuditedAuthorizer) AddRoleForUserInDomain(userID, role, domain string) error {
+	return a.record(true, domainGrantRule(userID, role, domain), a.Authorizer.AddRoleForUserInDomain(userID, role, domain))
+}
+
+// RemoveRoleForUserInDomain revokes a role in a domain and records the "g2"
+// rule
+func (a *AuditedAuthorizer) RemoveRoleForUserInDomain(userID, role, domain string) error {
+	return a.record(false, domainGrantRule(userID, role, domain), a.Authorizer.RemoveRoleForUserInDomain(userID, role, domain))
+}
+
+// AddPermissionForRole grants a role a permission and records the "p" rule
+func (a *AuditedAuthorizer) AddPermissionForRole(role, obj, act string) error {
+	return a.record(true, permissionRule(role, obj, act), a.Authorizer.AddPermissionForRole(role, obj, act))
+}
+
+// RemovePermissionForRole revokes a role's permission and records the "p"
+// rule
+func (a *AuditedAuthorizer) RemovePermissionForRole(role, obj, act string) error {
+	return a.record(false, permissionRule(role, obj, act), a.Authorizer.RemovePermissionForRole(role, obj, act))
+}
+
+// AddPermissionForUser grants a direct permission and records the "p" rule
+func (a *AuditedAuthorizer) AddPermissionForUser(userID, obj, act string) error {
+	return a.record(true, permissionRule(userID, obj, act), a.Authorizer.AddPermissionForUser(userID, obj, act))
+}
+
+// RemovePermissionForUser revokes a direct permission and records the "p"
+// rule
+func (a *AuditedAuthorizer) RemovePermissionForUser(userID, obj, act string) error {
+	return a.record(false, permissionRule(userID, obj, act), a.Authorizer.RemovePermissionForUser(userID, obj, act))
+}
+
+// AddDeny adds a deny rule and records it
+func (a *AuditedAuthorizer) AddDeny(sub, obj, act string) error {
+	return a.record(true, denyRule(sub, obj, act), a.Authorizer.AddDeny(sub, obj, act))
+}
+
+// RemoveDeny removes a deny rule and records it
+func (a *AuditedAuthorizer) RemoveDeny(sub, obj, act string) error {
+	return a.record(false, denyRule(sub, obj, act), a.Authorizer.RemoveDeny(sub, obj, act))
+}
+
+func permissionRule(sub, obj, act string) port.PolicyRule {
+	return port.PolicyRule{Type: "p", Values: []string{sub, obj, act}}
+}
+
+func denyRule(sub, obj, act string) port.PolicyRule {
+	return port.PolicyRule{Type: "p", Values: []string{sub, obj, act, port.EffectDeny}}
+}
+
+func grantRule(userID, role string) port.PolicyRule {
+	return port.PolicyRule{Type: "g", Values: []string{userID, role}}
+}
+
+func domainGrantRule(userID, role, domain string) port.PolicyRule {
+	return port.PolicyRule{Type: "g2", Values: []string{userID, role, domain}}
+}
+
+// Ensure AuditedAuthorizer implements port.Authorizer
+var _ port.Authorizer = (*AuditedAuthorizer)(nil)
@@ -0,0 +1,96 @@
+package casbin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditor struct {
+	entries []port.AuditEntry
+	err     error
+}
+
+func (a *recordingAuditor) Log(_ context.Context, e port.AuditEntry) error {
+	a.entries = append(a.entries, e)
+	return a.err
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return nil, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+func TestAuditedAuthorizer(t *testing.T) {
+	auditor := &recordingAuditor{}
+	authz := NewAuditedAuthorizer(newCachedTestAdapter(t, 0), auditor)
+
+	require.NoError(t, authz.AddPermissionForRole("editor", "users", "update"))
+	require.NoError(t, authz.AddRoleForUser("alice", "editor"))
+	require.NoError(t, authz.AddRoleForUserInDomain("bob", "editor", "acme"))
+	require.NoError(t, authz.AddPermissionForUser("carol", "files", "read"))
+	require.NoError(t, authz.RemovePermissionForUser("carol", "files", "read"))
+	require.NoError(t, authz.AddDeny("dave", "users", "delete"))
+	require.NoError(t, authz.RemoveDeny("dave", "users", "delete"))
+	require.NoError(t, authz.RemoveRoleForUserInDomain("bob", "editor", "acme"))
+	require.NoError(t, authz.RemoveRoleForUser("alice", "editor"))
+	require.NoError(t, authz.RemovePermissionForRole("editor", "users", "update"))
+
+	allowed, err := authz.Enforce("alice", "users", "update")
+	require.NoError(t, err)
+	assert.False(t, allowed, "changes reach the wrapped authorizer")
+
+	type change struct {
+		action port.AuditAction
+		rule   string
+	}
+	var got []change
+	for _, e := range auditor.entries {
+		assert.Equal(t, "authz_rule", e.Resource)
+		got = append(got, change{e.Action, e.ResourceID})
+		switch e.Action {
+		case port.AuditActionCreate:
+			assert.Nil(t, e.OldValue)
+			assert.Equal(t, e.ResourceID, e.NewValue.(port.PolicyRule).String())
+		case port.AuditActionDelete:
+			assert.Nil(t, e.NewValue)
+			assert.Equal(t, e.ResourceID, e.OldValue.(port.PolicyRule).String())
+		}
+	}
+	assert.Equal(t, []change{
+		{port.AuditActionCreate, "p, editor, users, update"},
+		{port.AuditActionCreate, "g, alice, editor"},
+		{port.AuditActionCreate, "g2, bob, editor, acme"},
+		{port.AuditActionCreate, "p, carol, files, read"},
+		{port.AuditActionDelete, "p, carol, files, read"},
+		{port.AuditActionCreate, "p, dave, users, delete, deny"},
+		{port.AuditActionDelete, "p, dave, users, delete, deny"},
+		{port.AuditActionDelete, "g2, bob, editor, acme"},
+		{port.AuditActionDelete, "g, alice, editor"},
+		{port.AuditActionDelete, "p, editor, users, update"},
+	}, got)
+}
+
+func TestAuditedAuthorizer_FailedChangeNotRecorded(t *testing.T) {
+	auditor := &recordingAuditor{}
+	authz := NewAuditedAuthorizer(newCachedTestAdapter(t, 0), auditor)
+
+	assert.ErrorIs(t, authz.AddRoleForUser("al\x00ice", "editor"), ErrInvalidPolicyArg)
+	assert.Empty(t, auditor.entries)
+}
+
+func TestAuditedAuthorizer_AuditFailureDoesNotFailChange(t *testing.T) {
+	auditor := &recordingAuditor{err: errors.New("audit down")}
+	authz := NewAuditedAuthorizer(newCachedTestAdapter(t, 0), auditor)
+
+	require.NoError(t, authz.AddRoleForUser("alice", "editor"))
+	has, err := authz.HasRoleForUser("alice", "editor")
+	require.NoError(t, err)
+	assert.True(t, has)
+	assert.Len(t, auditor.entries, 1)
+}
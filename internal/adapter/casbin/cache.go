@@ -6,7 +6,7 @@ import (
 	"sync"
 )
 
-// decisionCache is a thread-safe LRU cache mapping "sub\x00obj\x00act" keys to
+// decisionCache is a thread-safe LRU cache mapping "sub\x00dom\x00obj\x00act" keys to
 // bool authorization decisions. A maxSize of 0 disables the cache entirely;
 // all operations become no-ops and lookups always return a miss.
 //
@@ -34,14 +34,17 @@ func newDecisionCache(maxSize int) *decisionCache {
 	}
 }
 
-// cacheKey encodes (sub, obj, act) into a single map key using a \x00
-// separator. Callers (get/put) must reject inputs that contain \x00 before
-// calling this function; see the null-byte guards in get and put.
-func cacheKey(sub, obj, act string) string {
-	// pre-allocate: len(sub)+1+len(obj)+1+len(act)
-	b := make([]byte, 0, len(sub)+1+len(obj)+1+len(act))
+// cacheKey encodes (sub, dom, obj, act) into a single map key using a \x00
+// separator. dom is "" for checks outside any domain. Callers (get/put) must
+// reject inputs that contain \x00 before calling this function; see the
+// null-byte guards in get and put.
+func cacheKey(sub, dom, obj, act string) string {
+	// pre-allocate: len(sub)+1+len(dom)+1+len(obj)+1+len(act)
+	b := make([]byte, 0, len(sub)+1+len(dom)+1+len(obj)+1+len(act))
 	b = append(b, sub...)
 	b = append(b, '\x00')
+	b = append(b, dom...)
+	b = append(b, '\x00')
 	b = append(b, obj...)
 	b = append(b, '\x00')
 	b = append(b, act...)
@@ -53,14 +56,14 @@ func cacheKey(sub, obj, act string) string {
 // A nil receiver is treated as a disabled cache (always misses).
 // If any argument contains \x00 (the cache key separator), the lookup is
 // skipped to prevent cache key collisions from untrusted Enforce input.
-func (c *decisionCache) get(sub, obj, act string) (value, ok bool) {
+func (c *decisionCache) get(sub, dom, obj, act string) (value, ok bool) {
 	if c == nil || c.maxSize == 0 {
 		return false, false
 	}
-	if strings.ContainsRune(sub, 0) || strings.ContainsRune(obj, 0) || strings.ContainsRune(act, 0) {
+	if strings.ContainsRune(sub, 0) || strings.ContainsRune(dom, 0) || strings.ContainsRune(obj, 0) || strings.ContainsRune(act, 0) {
 		return false, false
 	}
-	key := cacheKey(sub, obj, act)
+	key := cacheKey(sub, dom, obj, act)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	el, ok := c.items[key]
@@ -75,14 +78,14 @@ func (c *decisionCache) get(sub, obj, act string) (value, ok bool) {
 // A nil receiver is a no-op.
 // If any argument contains \x00 (the cache key separator), the put is
 // skipped to prevent cache key collisions from untrusted Enforce input.
-func (c *decisionCache) put(sub, obj, act string, value bool) {
+func (c *decisionCache) put(sub, dom, obj, act string, value bool) {
 	if c == nil || c.maxSize == 0 {
 		return
 	}
-	if strings.ContainsRune(sub, 0) || strings.ContainsRune(obj, 0) || strings.ContainsRune(act, 0) {
+	if strings.ContainsRune(sub, 0) || strings.ContainsRune(dom, 0) || strings.ContainsRune(obj, 0) || strings.ContainsRune(act, 0) {
 		return
 	}
-	key := cacheKey(sub, obj, act)
+	key := cacheKey(sub, dom, obj, act)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if el, ok := c.items[key]; ok {
@@ -0,0 +1,287 @@
+package casbin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// decisionKeyPrefix prefixes every decision CachedAuthorizer stores
+const decisionKeyPrefix = "authz:decision:"
+
+// ownedActionSuffix tells an owner's decision from the plain one in its key
+const ownedActionSuffix = "\x00owned"
+
+// Defaults of CachedAuthorizerOptions
+const (
+	defaultDecisionTTL     = time.Minute
+	defaultDecisionTimeout = 100 * time.Millisecond
+)
+
+// CachedAuthorizerOptions configures NewCachedAuthorizer
+type CachedAuthorizerOptions struct {
+	// TTL bounds how long a decision is served; 0 = 1 minute. It is also
+	// the longest a change that skipped invalidation can go unseen.
+	TTL time.Duration
+	// Timeout bounds each cache call made on behalf of a caller without a
+	// context (Enforce, EnforceInDomain, mutations); 0 = 100ms.
+	Timeout time.Duration
+	// OnHit and OnMiss, when set, are called for every decision served
+	// from or missing in the cache, e.g. to count them.
+	OnHit  func()
+	OnMiss func()
+}
+
+// CachedAuthorizer decorates a port.Authorizer with a decision cache in a
+// port.Cache, so every instance sharing the cache shares decisions. Only
+// the Enforce methods are cached; everything else passes through.
+//
+// Decisions are keyed by user, so changes to one user's roles or direct
+// permissions drop only that user's decisions, while changes to role
+// permissions or a policy reload drop them all. Cache failures never fail a
+// check: it falls through to the wrapped authorizer. A failed invalidation is
+// logged, and the stale decisions expire with TTL.
+type CachedAuthorizer struct {
+	port.Authorizer
+	cache   port.Cache
+	ttl     time.Duration
+	timeout time.Duration
+	onHit   func()
+	onMiss  func()
+}
+
+// NewCachedAuthorizer wraps inner with a decision cache in cache
+func NewCachedAuthorizer(inner port.Authorizer, cache port.Cache, opts CachedAuthorizerOptions) *CachedAuthorizer {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultDecisionTTL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultDecisionTimeout
+	}
+	return &CachedAuthorizer{
+		Authorizer: inner,
+		cache:      cache,
+		ttl:        opts.TTL,
+		timeout:    opts.Timeout,
+		onHit:      opts.OnHit,
+		onMiss:     opts.OnMiss,
+	}
+}
+
+// Enforce checks a permission, from the cache when possible
+func (a *CachedAuthorizer) Enforce(sub, obj, act string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	return a.decide(ctx, sub, "", obj, act, func() (bool, error) {
+		return a.Authorizer.Enforce(sub, obj, act)
+	})
+}
+
+// EnforceWithContext checks a permission, from the cache when possible
+func (a *CachedAuthorizer) EnforceWithContext(ctx context.Context, sub, obj, act string) (bool, error) {
+	return a.decide(ctx, sub, "", obj, act, func() (bool, error) {
+		return a.Authorizer.EnforceWithContext(ctx, sub, obj, act)
+	})
+}
+
+// EnforceInDomain checks a permission in a domain, from the cache when
+// possible
+func (a *CachedAuthorizer) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	return a.decide(ctx, sub, dom, obj, act, func() (bool, error) {
+		return a.Authorizer.EnforceInDomain(sub, dom, obj, act)
+	})
+}
+
+// EnforceOwnership checks a permission on an object owned by owner, from
+// the cache when possible. An owner's decisions are kept apart from their
+// plain ones, since the permissions of port.RoleOwner apply to them.
+func (a *CachedAuthorizer) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	if sub != owner || owner == "" {
+		return a.EnforceInDomain(sub, dom, obj, act)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	return a.decide(ctx, sub, dom, obj, act+ownedActionSuffix, func() (bool, error) {
+		return a.Authorizer.EnforceOwnership(sub, owner, dom, obj, act)
+	})
+}
+
+// EnforceBatch checks many permissions, serving the cached decisions and
+// passing the rest to the wrapped authorizer in one batch
+func (a *CachedAuthorizer) EnforceBatch(ctx context.Context, requests []port.EnforceRequest) ([]bool, error) {
+	results := make([]bool, len(requests))
+	var misses []int
+	var batch []port.EnforceRequest
+	for i, r := range requests {
+		if b, err := a.cache.Get(ctx, decisionKey(r.Sub, r.Dom, r.Obj, r.Act)); err == nil && len(b) == 1 {
+			if a.onHit != nil {
+				a.onHit()
+			}
+			results[i] = b[0] == '1'
+			continue
+		}
+		if a.onMiss != nil {
+			a.onMiss()
+		}
+		misses = append(misses, i)
+		batch = append(batch, r)
+	}
+	if len(batch) == 0 {
+		return results, nil
+	}
+
+	decisions, err := a.Authorizer.EnforceBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range misses {
+		r := requests[i]
+		results[i] = decisions[j]
+		value := []byte{'0'}
+		if decisions[j] {
+			value[0] = '1'
+		}
+		_ = a.cache.Set(ctx, decisionKey(r.Sub, r.Dom, r.Obj, r.Act), value, a.ttl)
+	}
+	return results, nil
+}
+
+// decide serves a cached decision, or computes it with enforce and caches
+// it. Errors are never cached.
+func (a *CachedAuthorizer) decide(ctx context.Context, sub, dom, obj, act string, enforce func() (bool, error)) (bool, error) {
+	key := decisionKey(sub, dom, obj, act)
+	if b, err := a.cache.Get(ctx, key); err == nil && len(b) == 1 {
+		if a.onHit != nil {
+			a.onHit()
+		}
+		return b[0] == '1', nil
+	}
+	if a.onMiss != nil {
+		a.onMiss()
+	}
+
+	allowed, err := enforce()
+	if err != nil {
+		return false, err
+	}
+	value := []byte{'0'}
+	if allowed {
+		value[0] = '1'
+	}
+	_ = a.cache.Set(ctx, key, value, a.ttl)
+	return allowed, nil
+}
+
+// decisionKey returns the cache key of a decision. Both parts are hashed,
+// so the key holds no characters DeleteByPrefix's pattern would interpret,
+// and subjects cannot run into each other.
+func decisionKey(sub, dom, obj, act string) string {
+	req := sha256.Sum256([]byte(dom + "\x00" + obj + "\x00" + act))
+	return subjectPrefix(sub) + hex.EncodeToString(req[:16])
+}
+
+// subjectPrefix returns the key prefix of all of sub's decisions
+func subjectPrefix(sub string) string {
+	h := sha256.Sum256([]byte(sub))
+	return decisionKeyPrefix + hex.EncodeToString(h[:16]) + ":"
+}
+
+// Invalidate drops every cached decision
+func (a *CachedAuthorizer) Invalidate(ctx context.Context) error {
+	return a.cache.DeleteByPrefix(ctx, decisionKeyPrefix)
+}
+
+// invalidate drops the cached decisions of sub, or all of them when sub is
+// empty, once a change succeeded.
+func (a *CachedAuthorizer) invalidate(sub string, err error) error {
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	prefix := decisionKeyPrefix
+	if sub != "" {
+		prefix = subjectPrefix(sub)
+	}
+	if err := a.cache.DeleteByPrefix(ctx, prefix); err != nil {
+		slog.Warn("authorization decision cache invalidation failed", "subject", sub, "error", err)
+	}
+	return nil
+}
+
+// AddRoleForUser grants a role and drops the user's decisions
+func (a *CachedAuthorizer) AddRoleForUser(userID, role string) error {
+	return a.invalidate(userID, a.Authorizer.AddRoleForUser(userID, role))
+}
+
+// RemoveRoleForUser revokes a role and drops the user's decisions
+func (a *CachedAuthorizer) RemoveRoleForUser(userID, role string) error {
+	return a.invalidate(userID, a.Authorizer.RemoveRoleForUser(userID, role))
+}
+
+// AddRoleForUserInDomain grants a role in a domain and drops the user's
+// decisions
+func (a *CachedAuthorizer) AddRoleForUserInDomain(userID, role, domain string) error {
+	return a.invalidate(userID, a.Authorizer.AddRoleForUserInDomain(userID, role, domain))
+}
+
+// RemoveRoleForUserInDomain revokes a role in a domain and drops the user's
+// decisions
+func (a *CachedAuthorizer) RemoveRoleForUserInDomain(userID, role, domain string) error {
+	return a.invalidate(userID, a.Authorizer.RemoveRoleForUserInDomain(userID, role, domain))
+}
+
+// AddPermissionForUser grants a direct permission and drops the user's
+// decisions
+func (a *CachedAuthorizer) AddPermissionForUser(userID, obj, act string) error {
+	return a.invalidate(userID, a.Authorizer.AddPermissionForUser(userID, obj, act))
+}
+
+// RemovePermissionForUser revokes a direct permission and drops the user's
+// decisions
+func (a *CachedAuthorizer) RemovePermissionForUser(userID, obj, act string) error {
+	return a.invalidate(userID, a.Authorizer.RemovePermissionForUser(userID, obj, act))
+}
+
+// AddPermissionForRole grants a role a permission and drops all decisions,
+// as any user may hold the role
+func (a *CachedAuthorizer) AddPermissionForRole(role, obj, act string) error {
+	return a.invalidate("", a.Authorizer.AddPermissionForRole(role, obj, act))
+}
+
+// RemovePermissionForRole revokes a role's permission and drops all
+// decisions
+func (a *CachedAuthorizer) RemovePermissionForRole(role, obj, act string) error {
+	return a.invalidate("", a.Authorizer.RemovePermissionForRole(role, obj, act))
+}
+
+// AddDeny adds a deny rule and drops all decisions, as its subject may be
+// a role
+func (a *CachedAuthorizer) AddDeny(sub, obj, act string) error {
+	return a.invalidate("", a.Authorizer.AddDeny(sub, obj, act))
+}
+
+// RemoveDeny removes a deny rule and drops all decisions
+func (a *CachedAuthorizer) RemoveDeny(sub, obj, act string) error {
+	return a.invalidate("", a.Authorizer.RemoveDeny(sub, obj, act))
+}
+
+// LoadPolicy reloads the policy and drops all decisions
+func (a *CachedAuthorizer) LoadPolicy() error {
+	return a.invalidate("", a.Authorizer.LoadPolicy())
+}
+
+// SavePolicy saves the policy and drops all decisions
+func (a *CachedAuthorizer) SavePolicy() error {
+	return a.invalidate("", a.Authorizer.SavePolicy())
+}
+
+// Ensure CachedAuthorizer implements port.Authorizer
+var _ port.Authorizer = (*CachedAuthorizer)(nil)
@@ -0,0 +1,212 @@
+package casbin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAuthorizer counts the checks that reach the wrapped authorizer
+type countingAuthorizer struct {
+	port.Authorizer
+	calls int
+	err   error
+}
+
+func (c *countingAuthorizer) Enforce(sub, obj, act string) (bool, error) {
+	c.calls++
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.Authorizer.Enforce(sub, obj, act)
+}
+
+func (c *countingAuthorizer) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	c.calls++
+	return c.Authorizer.EnforceInDomain(sub, dom, obj, act)
+}
+
+func (c *countingAuthorizer) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	c.calls++
+	return c.Authorizer.EnforceOwnership(sub, owner, dom, obj, act)
+}
+
+type cachedFixture struct {
+	authz        *CachedAuthorizer
+	inner        *countingAuthorizer
+	hits, misses int
+}
+
+func newCachedAuthorizer(t *testing.T) *cachedFixture {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rc, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rc.Close() })
+
+	f := &cachedFixture{inner: &countingAuthorizer{Authorizer: newCachedTestAdapter(t, 0)}}
+	f.authz = NewCachedAuthorizer(f.inner, rc, CachedAuthorizerOptions{
+		OnHit:  func() { f.hits++ },
+		OnMiss: func() { f.misses++ },
+	})
+	return f
+}
+
+func (f *cachedFixture) enforce(t *testing.T, sub, obj, act string) bool {
+	t.Helper()
+	allowed, err := f.authz.Enforce(sub, obj, act)
+	require.NoError(t, err)
+	return allowed
+}
+
+func TestCachedAuthorizer_ServesRepeatDecisions(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddPermissionForUser("alice", "users", "read"))
+
+	assert.True(t, f.enforce(t, "alice", "users", "read"))
+	assert.True(t, f.enforce(t, "alice", "users", "read"))
+	assert.False(t, f.enforce(t, "alice", "users", "delete"))
+	assert.False(t, f.enforce(t, "alice", "users", "delete"))
+
+	assert.Equal(t, 2, f.inner.calls, "denials are cached as well as grants")
+	assert.Equal(t, 2, f.hits)
+	assert.Equal(t, 2, f.misses)
+}
+
+func TestCachedAuthorizer_OwnershipKeptApart(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddPermissionForRole(port.RoleOwner, "users", "read"))
+
+	owned := func(sub, owner string) bool {
+		t.Helper()
+		allowed, err := f.authz.EnforceOwnership(sub, owner, "", "users", "read")
+		require.NoError(t, err)
+		return allowed
+	}
+	assert.True(t, owned("alice", "alice"))
+	assert.True(t, owned("alice", "alice"))
+	assert.False(t, f.enforce(t, "alice", "users", "read"), "an owner's decision does not leak into plain checks")
+	assert.False(t, owned("alice", "bob"), "someone else's object is a plain check")
+	assert.Equal(t, 2, f.inner.calls, "the second plain check was served from the cache")
+
+	require.NoError(t, f.authz.RemovePermissionForRole(port.RoleOwner, "users", "read"))
+	assert.False(t, owned("alice", "alice"), "owner role changes drop owner decisions")
+}
+
+func TestCachedAuthorizer_KeyedByDomain(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddPermissionForRole("editor", "projects", "update"))
+	require.NoError(t, f.authz.AddRoleForUserInDomain("alice", "editor", "acme"))
+
+	allowed, err := f.authz.EnforceInDomain("alice", "acme", "projects", "update")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = f.authz.EnforceInDomain("alice", "globex", "projects", "update")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, f.enforce(t, "alice", "projects", "update"))
+}
+
+func TestCachedAuthorizer_UserChangesDropOnlyThatUser(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddPermissionForRole("editor", "posts", "update"))
+	assert.False(t, f.enforce(t, "alice", "posts", "update"))
+	assert.False(t, f.enforce(t, "bob", "posts", "update"))
+
+	require.NoError(t, f.authz.AddRoleForUser("alice", "editor"))
+	assert.True(t, f.enforce(t, "alice", "posts", "update"), "alice's cached denial was dropped")
+
+	calls := f.inner.calls
+	assert.False(t, f.enforce(t, "bob", "posts", "update"))
+	assert.Equal(t, calls, f.inner.calls, "bob's decision is still cached")
+
+	require.NoError(t, f.authz.RemoveRoleForUser("alice", "editor"))
+	assert.False(t, f.enforce(t, "alice", "posts", "update"), "revocation takes effect at once")
+}
+
+func TestCachedAuthorizer_RoleChangesDropAll(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddRoleForUser("alice", "editor"))
+	assert.False(t, f.enforce(t, "alice", "posts", "update"))
+
+	require.NoError(t, f.authz.AddPermissionForRole("editor", "posts", "update"))
+	assert.True(t, f.enforce(t, "alice", "posts", "update"))
+
+	require.NoError(t, f.authz.RemovePermissionForRole("editor", "posts", "update"))
+	assert.False(t, f.enforce(t, "alice", "posts", "update"))
+
+	assert.False(t, f.enforce(t, "alice", "posts", "read"))
+	require.NoError(t, f.authz.Invalidate(context.Background()))
+	calls := f.inner.calls
+	f.enforce(t, "alice", "posts", "read")
+	assert.Equal(t, calls+1, f.inner.calls, "Invalidate drops every decision")
+}
+
+func TestCachedAuthorizer_DenyChangesDropAll(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddPermissionForRole("editor", "posts", "update"))
+	require.NoError(t, f.authz.AddRoleForUser("alice", "editor"))
+	assert.True(t, f.enforce(t, "alice", "posts", "update"))
+
+	require.NoError(t, f.authz.AddDeny("editor", "posts", "update"))
+	assert.False(t, f.enforce(t, "alice", "posts", "update"), "a deny on a role drops its holders' decisions")
+
+	require.NoError(t, f.authz.RemoveDeny("editor", "posts", "update"))
+	assert.True(t, f.enforce(t, "alice", "posts", "update"))
+}
+
+func TestCachedAuthorizer_EnforceBatch(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	require.NoError(t, f.authz.AddPermissionForRole("editor", "posts", "update"))
+	require.NoError(t, f.authz.AddRoleForUser("alice", "editor"))
+	assert.True(t, f.enforce(t, "alice", "posts", "update"))
+	hits, misses := f.hits, f.misses
+
+	allowed, err := f.authz.EnforceBatch(context.Background(), []port.EnforceRequest{
+		{Sub: "alice", Obj: "posts", Act: "update"},
+		{Sub: "alice", Obj: "posts", Act: "delete"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, allowed)
+	assert.Equal(t, hits+1, f.hits, "the earlier decision is served from the cache")
+	assert.Equal(t, misses+1, f.misses)
+
+	calls := f.inner.calls
+	assert.False(t, f.enforce(t, "alice", "posts", "delete"))
+	assert.Equal(t, calls, f.inner.calls, "batch decisions are cached too")
+}
+
+func TestCachedAuthorizer_ErrorsAreNotCached(t *testing.T) {
+	f := newCachedAuthorizer(t)
+	f.inner.err = errors.New("enforcer down")
+	_, err := f.authz.Enforce("alice", "users", "read")
+	require.Error(t, err)
+
+	f.inner.err = nil
+	require.NoError(t, f.authz.AddPermissionForUser("alice", "users", "read"))
+	assert.True(t, f.enforce(t, "alice", "users", "read"))
+}
+
+func TestCachedAuthorizer_CacheUnavailable(t *testing.T) {
+	inner := &countingAuthorizer{Authorizer: newCachedTestAdapter(t, 0)}
+	authz := NewCachedAuthorizer(inner, cache.NewNoOpCache(), CachedAuthorizerOptions{})
+
+	require.NoError(t, authz.AddPermissionForUser("alice", "users", "read"), "a failed invalidation does not fail the change")
+	allowed, err := authz.Enforce("alice", "users", "read")
+	require.NoError(t, err)
+	assert.True(t, allowed, "checks fall through to the wrapped authorizer")
+}
+
+func TestDecisionKey(t *testing.T) {
+	k := decisionKey("alice", "", "users", "read")
+	assert.Regexp(t, `^authz:decision:[0-9a-f]{32}:[0-9a-f]{32}$`, k)
+	assert.Contains(t, k, subjectPrefix("alice"))
+	assert.NotEqual(t, k, decisionKey("alice", "acme", "users", "read"))
+	assert.NotEqual(t, subjectPrefix("a*"), subjectPrefix("ab"), "subjects are hashed, not matched as patterns")
+}
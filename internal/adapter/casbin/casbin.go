@@ -3,10 +3,12 @@ package casbin
 import (
 	"context"
 	"database/sql"
+	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +32,8 @@ type Adapter struct {
 	closeOnce      sync.Once
 	closeErr       error
 	cache          *decisionCache
+	onReload       func(trigger string, d time.Duration, err error)
+	onEnforce      func(obj, act string, allowed, cached bool, d time.Duration, err error)
 }
 
 // Config holds configuration for the Casbin adapter
@@ -39,11 +43,41 @@ type Config struct {
 	ReloadInterval    time.Duration   // 0 = default 5 minutes
 	Watcher           persist.Watcher // nil = backstop tick only
 	DecisionCacheSize int             // LRU decision-cache capacity; 0 = default (10 000); negative = disabled
+	// OnReload, when set, is called after every full policy reload with its
+	// trigger ("watcher", "backstop" or "api"), duration and error.
+	OnReload func(trigger string, d time.Duration, err error)
+	// OnEnforce, when set, is called for every decision with its object,
+	// action, outcome, whether the decision cache served it, and how long
+	// it took.
+	OnEnforce func(obj, act string, allowed, cached bool, d time.Duration, err error)
 }
 
+// Triggers of a full policy reload, as passed to Config.OnReload
+const (
+	ReloadTriggerWatcher  = "watcher"
+	ReloadTriggerBackstop = "backstop"
+	ReloadTriggerAPI      = "api"
+)
+
 // ErrInvalidPolicyArg is returned when a policy argument contains disallowed bytes.
 var ErrInvalidPolicyArg = errors.New("invalid policy argument")
 
+// ErrDomainsUnsupported is returned when granting a domain role under a model
+// without domain-scoped roles.
+var ErrDomainsUnsupported = errors.New("casbin model has no domain-scoped roles")
+
+// ErrDenyUnsupported is returned when adding a deny rule under a model whose
+// permissions have no effect.
+var ErrDenyUnsupported = errors.New("casbin model has no rule effects")
+
+// domainRoleType is the grouping policy holding domain role grants:
+// g2, user, role, domain
+const domainRoleType = "g2"
+
+// ownershipContext evaluates ownership requests (sub, owner, dom, obj, act)
+// with matcher m2 against the same policies as every other request
+var ownershipContext = casbin.EnforceContext{RType: "r2", PType: "p", EType: "e", MType: "m2"}
+
 // watcherOp is the JSON envelope for incremental policy updates.
 type watcherOp struct {
 	Op     string   `json:"op"`
@@ -52,6 +86,16 @@ type watcherOp struct {
 	Params []string `json:"params"`
 }
 
+// ptype returns the op's policy type, or def for messages without one.
+// Watchers report grouping rules (sec "g") as add_policy/remove_policy, so
+// the section picks the enforcer call and the type picks g or g2.
+func (op watcherOp) ptype(def string) string {
+	if op.Ptype == "" {
+		return def
+	}
+	return op.Ptype
+}
+
 // validatePolicyArgs returns ErrInvalidPolicyArg if any arg contains null bytes.
 func validatePolicyArgs(args ...string) error {
 	for _, arg := range args {
@@ -70,22 +114,9 @@ func encodeOp(op, sec, ptype string, params []string) string {
 
 // Default RBAC model with permission-based enforcement
 // Uses simple equality matching with wildcard (*) support
-const defaultModel = `
-[request_definition]
-r = sub, obj, act
-
-[policy_definition]
-p = sub, obj, act
-
-[role_definition]
-g = _, _
-
-[policy_effect]
-e = some(where (p.eft == allow))
-
-[matchers]
-m = g(r.sub, p.sub) && (p.obj == "*" || r.obj == p.obj) && (p.act == "*" || r.act == p.act)
-`
+//
+//go:embed model.conf
+var defaultModel string
 
 // NewAdapter creates a new Casbin adapter
 func NewAdapter(cfg Config) (*Adapter, error) {
@@ -156,6 +187,8 @@ func NewAdapter(cfg Config) (*Adapter, error) {
 		reloadInterval: cfg.ReloadInterval,
 		watcher:        cfg.Watcher,
 		cache:          newDecisionCache(cacheSize),
+		onReload:       cfg.OnReload,
+		onEnforce:      cfg.OnEnforce,
 	}, nil
 }
 
@@ -189,7 +222,7 @@ func (a *Adapter) Start(ctx context.Context) error {
 			case <-derived.Done():
 				return
 			case <-t.C:
-				if err := a.LoadPolicy(); err != nil {
+				if err := a.reload(ReloadTriggerBackstop); err != nil {
 					slog.Error("casbin backstop reload failed", "error", err)
 				}
 			}
@@ -207,29 +240,43 @@ func (a *Adapter) makeUpdateCallback() func(string) {
 		var op watcherOp
 		if err := json.Unmarshal([]byte(msg), &op); err != nil {
 			// Unknown format — full reload as fallback.
-			_ = a.LoadPolicy()
+			a.watcherReload()
 			return
 		}
 		ifaces := stringsToIfaces(op.Params)
-		switch op.Op {
-		case "add_policy":
-			_, _ = a.enforcer.AddPolicy(ifaces...)
+		switch {
+		case op.Op == "add_policy" && op.Sec == "g":
+			_, _ = a.enforcer.AddNamedGroupingPolicy(op.ptype("g"), ifaces...)
+			a.cache.flush()
+		case op.Op == "remove_policy" && op.Sec == "g":
+			_, _ = a.enforcer.RemoveNamedGroupingPolicy(op.ptype("g"), ifaces...)
 			a.cache.flush()
-		case "remove_policy":
-			_, _ = a.enforcer.RemovePolicy(ifaces...)
+		case op.Op == "add_policy":
+			_, _ = a.enforcer.AddNamedPolicy(op.ptype("p"), ifaces...)
 			a.cache.flush()
-		case "add_grouping":
-			_, _ = a.enforcer.AddGroupingPolicy(ifaces...)
+		case op.Op == "remove_policy":
+			_, _ = a.enforcer.RemoveNamedPolicy(op.ptype("p"), ifaces...)
 			a.cache.flush()
-		case "remove_grouping":
-			_, _ = a.enforcer.RemoveGroupingPolicy(ifaces...)
+		case op.Op == "add_grouping":
+			_, _ = a.enforcer.AddNamedGroupingPolicy(op.ptype("g"), ifaces...)
+			a.cache.flush()
+		case op.Op == "remove_grouping":
+			_, _ = a.enforcer.RemoveNamedGroupingPolicy(op.ptype("g"), ifaces...)
 			a.cache.flush()
 		default:
-			_ = a.LoadPolicy()
+			a.watcherReload()
 		}
 	}
 }
 
+// watcherReload fully reloads the policy on behalf of the watcher, which
+// has no caller to return an error to.
+func (a *Adapter) watcherReload() {
+	if err := a.reload(ReloadTriggerWatcher); err != nil {
+		slog.Error("casbin watcher reload failed", "error", err)
+	}
+}
+
 // stringsToIfaces converts a []string to []any for Casbin v3 API calls.
 func stringsToIfaces(ss []string) []any {
 	out := make([]any, len(ss))
@@ -239,18 +286,11 @@ func stringsToIfaces(ss []string) []any {
 	return out
 }
 
-// Enforce checks if subject has permission to perform action on object.
+// Enforce checks if subject has permission to perform action on object,
+// outside any domain: only roles granted without a domain apply.
 // Results are memoised in the decision cache. Errors are never cached.
 func (a *Adapter) Enforce(sub, obj, act string) (bool, error) {
-	if hit, ok := a.cache.get(sub, obj, act); ok {
-		return hit, nil
-	}
-	allowed, err := a.enforcer.Enforce(sub, obj, act)
-	slog.Debug("Casbin enforce", "sub", sub, "obj", obj, "act", act, "allowed", allowed, "error", err)
-	if err == nil {
-		a.cache.put(sub, obj, act, allowed)
-	}
-	return allowed, err
+	return a.enforce(sub, "", obj, act)
 }
 
 // EnforceWithContext checks permission with context for cancellation.
@@ -262,16 +302,138 @@ func (a *Adapter) EnforceWithContext(ctx context.Context, sub, obj, act string)
 		return false, ctx.Err()
 	default:
 	}
-	if hit, ok := a.cache.get(sub, obj, act); ok {
-		return hit, nil
+	return a.enforce(sub, "", obj, act)
+}
+
+// EnforceInDomain checks if subject has permission to perform action on
+// object in domain dom, through roles granted in dom or without a domain.
+// Under a model without domains it is Enforce.
+func (a *Adapter) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	return a.enforce(sub, dom, obj, act)
+}
+
+// EnforceBatch checks many requests at once. Decisions in the decision
+// cache are served from it; the rest go through the enforcer in one batch
+// and are memoised. Errors are never cached.
+func (a *Adapter) EnforceBatch(ctx context.Context, requests []port.EnforceRequest) ([]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	results := make([]bool, len(requests))
+	domains := a.hasDomains()
+	var misses []int
+	var batch [][]any
+	for i, r := range requests {
+		if hit, ok := a.cache.get(r.Sub, r.Dom, r.Obj, r.Act); ok {
+			results[i] = hit
+			a.observe(r.Obj, r.Act, hit, true, start, nil)
+			continue
+		}
+		misses = append(misses, i)
+		if domains {
+			batch = append(batch, []any{r.Sub, r.Dom, r.Obj, r.Act})
+		} else {
+			batch = append(batch, []any{r.Sub, r.Obj, r.Act})
+		}
+	}
+	if len(batch) == 0 {
+		return results, nil
+	}
+
+	decisions, err := a.enforcer.BatchEnforce(batch)
+	slog.Debug("Casbin batch enforce", "requests", len(requests), "evaluated", len(batch), "error", err)
+	for j, i := range misses {
+		r := requests[i]
+		if err != nil {
+			a.observe(r.Obj, r.Act, false, false, start, err)
+			continue
+		}
+		results[i] = decisions[j]
+		a.cache.put(r.Sub, r.Dom, r.Obj, r.Act, decisions[j])
+		a.observe(r.Obj, r.Act, decisions[j], false, start, nil)
+	}
+	if err != nil {
+		return nil, err
 	}
-	allowed, err := a.enforcer.Enforce(sub, obj, act)
+	return results, nil
+}
+
+// enforce evaluates a request and reports the decision to OnEnforce
+func (a *Adapter) enforce(sub, dom, obj, act string) (bool, error) {
+	start := time.Now()
+	allowed, cached, err := a.decide(sub, dom, obj, act)
+	a.observe(obj, act, allowed, cached, start, err)
+	return allowed, err
+}
+
+// decide evaluates a request, memoising the decision per domain. cached
+// reports whether the decision cache served it.
+func (a *Adapter) decide(sub, dom, obj, act string) (allowed, cached bool, err error) {
+	if hit, ok := a.cache.get(sub, dom, obj, act); ok {
+		return hit, true, nil
+	}
+	if a.hasDomains() {
+		allowed, err = a.enforcer.Enforce(sub, dom, obj, act)
+	} else {
+		allowed, err = a.enforcer.Enforce(sub, obj, act)
+	}
+	slog.Debug("Casbin enforce", "sub", sub, "dom", dom, "obj", obj, "act", act, "allowed", allowed, "error", err)
 	if err == nil {
-		a.cache.put(sub, obj, act, allowed)
+		a.cache.put(sub, dom, obj, act, allowed)
+	}
+	return allowed, false, err
+}
+
+// observe reports a decision made since start to OnEnforce, if set
+func (a *Adapter) observe(obj, act string, allowed, cached bool, start time.Time, err error) {
+	if a.onEnforce != nil {
+		a.onEnforce(obj, act, allowed, cached, time.Since(start), err)
 	}
+}
+
+// hasDomains reports whether the model takes a domain in requests and has
+// domain role grants, as the built-in model does. A custom model from
+// authorization.model_path may not.
+func (a *Adapter) hasDomains() bool {
+	m := a.enforcer.GetModel()
+	r, ok := m["r"]["r"]
+	if !ok || len(r.Tokens) != 4 {
+		return false
+	}
+	_, ok = m["g"][domainRoleType]
+	return ok
+}
+
+// EnforceOwnership checks if subject may perform action on an object owned
+// by owner in domain dom. When subject is not owner it is EnforceInDomain;
+// when it is, the permissions of port.RoleOwner apply too. Under a model
+// without an ownership matcher ownership grants nothing.
+// Only decisions that need no ownership are memoised.
+func (a *Adapter) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	start := time.Now()
+	allowed, cached, err := a.decide(sub, dom, obj, act)
+	if err == nil && !allowed && sub == owner && owner != "" && a.hasOwnership() {
+		allowed, err = a.enforcer.Enforce(ownershipContext, sub, owner, dom, obj, act)
+		cached = false
+		slog.Debug("Casbin enforce ownership", "sub", sub, "dom", dom, "obj", obj, "act", act, "allowed", allowed, "error", err)
+	}
+	a.observe(obj, act, allowed, cached, start, err)
 	return allowed, err
 }
 
+// hasOwnership reports whether the model defines the ownership request and
+// matcher, as the built-in model does
+func (a *Adapter) hasOwnership() bool {
+	m := a.enforcer.GetModel()
+	r, ok := m["r"][ownershipContext.RType]
+	if !ok || len(r.Tokens) != 5 {
+		return false
+	}
+	_, ok = m["m"][ownershipContext.MType]
+	return ok
+}
+
 // AddRoleForUser assigns a role to a user.
 // Invalidates all cache entries where sub == userID because the user's
 // effective permission set has changed.
@@ -314,6 +476,73 @@ func (a *Adapter) HasRoleForUser(userID, role string) (bool, error) {
 	return a.enforcer.HasRoleForUser(userID, role)
 }
 
+// AddRoleForUserInDomain grants a role to a user in one domain only.
+// Invalidates all cache entries where sub == userID.
+func (a *Adapter) AddRoleForUserInDomain(userID, role, domain string) error {
+	if err := a.validateDomainGrant(userID, role, domain); err != nil {
+		return err
+	}
+	_, err := a.enforcer.AddNamedGroupingPolicy(domainRoleType, userID, role, domain)
+	if err == nil {
+		a.cache.invalidateSub(userID)
+	}
+	return err
+}
+
+// RemoveRoleForUserInDomain revokes a role granted to a user in domain.
+// Invalidates all cache entries where sub == userID.
+func (a *Adapter) RemoveRoleForUserInDomain(userID, role, domain string) error {
+	if err := a.validateDomainGrant(userID, role, domain); err != nil {
+		return err
+	}
+	_, err := a.enforcer.RemoveNamedGroupingPolicy(domainRoleType, userID, role, domain)
+	if err == nil {
+		a.cache.invalidateSub(userID)
+	}
+	return err
+}
+
+// GetRolesForUserInDomain returns the roles granted to a user in domain,
+// not including roles granted without a domain
+func (a *Adapter) GetRolesForUserInDomain(userID, domain string) ([]string, error) {
+	if !a.hasDomains() {
+		return []string{}, nil
+	}
+	rules, err := a.enforcer.GetFilteredNamedGroupingPolicy(domainRoleType, 0, userID, "", domain)
+	return ruleField(rules, 1), err
+}
+
+// GetUsersForRoleInDomain returns the users granted a role in domain
+func (a *Adapter) GetUsersForRoleInDomain(role, domain string) ([]string, error) {
+	if !a.hasDomains() {
+		return []string{}, nil
+	}
+	rules, err := a.enforcer.GetFilteredNamedGroupingPolicy(domainRoleType, 1, role, domain)
+	return ruleField(rules, 0), err
+}
+
+// validateDomainGrant checks the arguments of a domain role grant
+func (a *Adapter) validateDomainGrant(userID, role, domain string) error {
+	if !a.hasDomains() {
+		return ErrDomainsUnsupported
+	}
+	if domain == "" {
+		return fmt.Errorf("empty domain: %w", ErrInvalidPolicyArg)
+	}
+	return validatePolicyArgs(userID, role, domain)
+}
+
+// ruleField returns field i of every rule
+func ruleField(rules [][]string, i int) []string {
+	out := make([]string, 0, len(rules))
+	for _, r := range rules {
+		if i < len(r) {
+			out = append(out, r[i])
+		}
+	}
+	return out
+}
+
 // AddPermissionForRole adds a permission to a role.
 // Flushes the entire cache because the role's effective permission set has
 // changed, and any user who inherits this role transitively is also affected.
@@ -324,7 +553,7 @@ func (a *Adapter) AddPermissionForRole(role, obj, act string) error {
 	if err := validatePolicyArgs(role, obj, act); err != nil {
 		return err
 	}
-	_, err := a.enforcer.AddPolicy(role, obj, act)
+	_, err := a.enforcer.AddPolicy(a.effectRule(role, obj, act, port.EffectAllow))
 	if err == nil {
 		a.cache.flush()
 	}
@@ -338,16 +567,18 @@ func (a *Adapter) RemovePermissionForRole(role, obj, act string) error {
 	if err := validatePolicyArgs(role, obj, act); err != nil {
 		return err
 	}
-	_, err := a.enforcer.RemovePolicy(role, obj, act)
+	_, err := a.enforcer.RemovePolicy(a.effectRule(role, obj, act, port.EffectAllow))
 	if err == nil {
 		a.cache.flush()
 	}
 	return err
 }
 
-// GetPermissionsForRole returns all permissions for a role
+// GetPermissionsForRole returns all permissions for a role, not including
+// deny rules
 func (a *Adapter) GetPermissionsForRole(role string) ([][]string, error) {
-	return a.enforcer.GetPermissionsForUser(role)
+	rules, err := a.enforcer.GetPermissionsForUser(role)
+	return allowed(rules), err
 }
 
 // AddPermissionForUser adds a direct permission to a user.
@@ -356,7 +587,7 @@ func (a *Adapter) AddPermissionForUser(userID, obj, act string) error {
 	if err := validatePolicyArgs(userID, obj, act); err != nil {
 		return err
 	}
-	_, err := a.enforcer.AddPolicy(userID, obj, act)
+	_, err := a.enforcer.AddPolicy(a.effectRule(userID, obj, act, port.EffectAllow))
 	if err == nil {
 		a.cache.invalidateSub(userID)
 	}
@@ -369,30 +600,179 @@ func (a *Adapter) RemovePermissionForUser(userID, obj, act string) error {
 	if err := validatePolicyArgs(userID, obj, act); err != nil {
 		return err
 	}
-	_, err := a.enforcer.RemovePolicy(userID, obj, act)
+	_, err := a.enforcer.RemovePolicy(a.effectRule(userID, obj, act, port.EffectAllow))
 	if err == nil {
 		a.cache.invalidateSub(userID)
 	}
 	return err
 }
 
-// GetPermissionsForUser returns direct permissions for a user
+// GetPermissionsForUser returns direct permissions for a user, not
+// including deny rules
 func (a *Adapter) GetPermissionsForUser(userID string) ([][]string, error) {
-	return a.enforcer.GetPermissionsForUser(userID)
+	rules, err := a.enforcer.GetPermissionsForUser(userID)
+	return allowed(rules), err
 }
 
-// GetImplicitPermissionsForUser returns all permissions including via roles
+// GetImplicitPermissionsForUser returns all permissions including via roles,
+// less those a deny rule of the user or one of their roles covers
 func (a *Adapter) GetImplicitPermissionsForUser(userID string) ([][]string, error) {
-	return a.enforcer.GetImplicitPermissionsForUser(userID)
+	rules, err := a.enforcer.GetImplicitPermissionsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	var denies [][]string
+	for _, r := range rules {
+		if len(r) > 3 && r[3] == port.EffectDeny {
+			denies = append(denies, r)
+		}
+	}
+	perms := allowed(rules)
+	if len(denies) == 0 {
+		return perms, nil
+	}
+	out := perms[:0]
+	for _, p := range perms {
+		if !deniedBy(p, denies) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// AddDeny adds a deny rule refusing sub, a user or a role, action on
+// object even where a role grants it. Flushes the entire cache, as sub may
+// be a role.
+func (a *Adapter) AddDeny(sub, obj, act string) error {
+	if !a.hasEffects() {
+		return ErrDenyUnsupported
+	}
+	if err := validatePolicyArgs(sub, obj, act); err != nil {
+		return err
+	}
+	_, err := a.enforcer.AddPolicy(a.effectRule(sub, obj, act, port.EffectDeny))
+	if err == nil {
+		a.cache.flush()
+	}
+	return err
+}
+
+// RemoveDeny removes a deny rule. Flushes the entire cache, as for AddDeny.
+func (a *Adapter) RemoveDeny(sub, obj, act string) error {
+	if !a.hasEffects() {
+		return ErrDenyUnsupported
+	}
+	if err := validatePolicyArgs(sub, obj, act); err != nil {
+		return err
+	}
+	_, err := a.enforcer.RemovePolicy(a.effectRule(sub, obj, act, port.EffectDeny))
+	if err == nil {
+		a.cache.flush()
+	}
+	return err
+}
+
+// hasEffects reports whether permission rules carry an effect (p.eft), as
+// in the built-in model. A custom model from authorization.model_path may
+// not, and then every rule allows.
+func (a *Adapter) hasEffects() bool {
+	p, ok := a.enforcer.GetModel()["p"]["p"]
+	if !ok {
+		return false
+	}
+	for _, t := range p.Tokens {
+		if t == "p_eft" {
+			return true
+		}
+	}
+	return false
+}
+
+// effectRule returns the rule letting (or with port.EffectDeny,
+// refusing) sub act on obj, with its effect when the model has effects
+func (a *Adapter) effectRule(sub, obj, act, eft string) []string {
+	if a.hasEffects() {
+		return []string{sub, obj, act, eft}
+	}
+	return []string{sub, obj, act}
+}
+
+// allowed returns the allow rules of rules as sub, obj, act
+func allowed(rules [][]string) [][]string {
+	out := make([][]string, 0, len(rules))
+	for _, r := range rules {
+		if len(r) > 3 {
+			if r[3] != port.EffectAllow {
+				continue
+			}
+			r = r[:3]
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// deniedBy reports whether one of denies covers permission p
+func deniedBy(p []string, denies [][]string) bool {
+	for _, d := range denies {
+		if (d[1] == "*" || d[1] == p[1]) && (d[2] == "*" || d[2] == p[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRules returns every stored rule, permissions first, then role grants,
+// each type in name order. Permissions leave out the allow effect, so only
+// deny rules carry one.
+func (a *Adapter) GetRules() ([]port.PolicyRule, error) {
+	m := a.enforcer.GetModel()
+	var rules []port.PolicyRule
+	for _, sec := range []string{"p", "g"} {
+		types := make([]string, 0, len(m[sec]))
+		for ptype := range m[sec] {
+			types = append(types, ptype)
+		}
+		sort.Strings(types)
+		for _, ptype := range types {
+			var policies [][]string
+			var err error
+			if sec == "p" {
+				policies, err = a.enforcer.GetNamedPolicy(ptype)
+			} else {
+				policies, err = a.enforcer.GetNamedGroupingPolicy(ptype)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, values := range policies {
+				if sec == "p" && len(values) == 4 && values[3] == port.EffectAllow {
+					values = values[:3]
+				}
+				rules = append(rules, port.PolicyRule{Type: ptype, Values: values})
+			}
+		}
+	}
+	return rules, nil
 }
 
 // LoadPolicy reloads policies from database and flushes the decision cache.
-// Called by the backstop ticker and by the watcher callback on full-reload ops.
 func (a *Adapter) LoadPolicy() error {
+	return a.reload(ReloadTriggerAPI)
+}
+
+// reload reloads policies from database, flushes the decision cache and
+// reports the reload to OnReload. Used by LoadPolicy, the backstop ticker
+// and the watcher callback on full-reload ops.
+func (a *Adapter) reload(trigger string) error {
+	start := time.Now()
 	err := a.enforcer.LoadPolicy()
 	if err == nil {
 		a.cache.flush()
 	}
+	if a.onReload != nil {
+		a.onReload(trigger, time.Since(start), err)
+	}
 	return err
 }
 
@@ -20,7 +20,7 @@ func newBenchAdapter(b *testing.B, cacheSize, numPolicies int) *Adapter {
 	require.NoError(b, err)
 
 	for i := range numPolicies {
-		_, err := enforcer.AddPolicy(fmt.Sprintf("user%d", i), "resource", "read")
+		_, err := enforcer.AddPolicy(fmt.Sprintf("user%d", i), "resource", "read", "allow")
 		require.NoError(b, err)
 	}
 
@@ -36,93 +36,109 @@ func newCachedTestAdapter(t *testing.T, cacheSize int) *Adapter {
 
 func TestDecisionCache_CacheKey_NullSeparator(t *testing.T) {
 	// Ensure the key encodes correctly and that \x00 separator is used.
-	k := cacheKey("alice", "data1", "read")
-	assert.Equal(t, "alice\x00data1\x00read", k)
+	k := cacheKey("alice", "", "data1", "read")
+	assert.Equal(t, "alice\x00\x00data1\x00read", k)
+
+	k = cacheKey("alice", "acme", "data1", "read")
+	assert.Equal(t, "alice\x00acme\x00data1\x00read", k)
+}
+
+func TestDecisionCache_KeyedByDomain(t *testing.T) {
+	c := newDecisionCache(10)
+	c.put("alice", "acme", "data", "read", true)
+
+	_, ok := c.get("alice", "", "data", "read")
+	assert.False(t, ok, "a decision in one domain must not answer for another")
+	_, ok = c.get("alice", "globex", "data", "read")
+	assert.False(t, ok)
+
+	c.invalidateSub("alice")
+	assert.Zero(t, c.len(), "invalidateSub drops the subject's entries in every domain")
 }
 
 func TestDecisionCache_GetPut(t *testing.T) {
 	c := newDecisionCache(10)
-	_, ok := c.get("s", "o", "a")
+	_, ok := c.get("s", "", "o", "a")
 	assert.False(t, ok)
 
-	c.put("s", "o", "a", true)
-	v, ok := c.get("s", "o", "a")
+	c.put("s", "", "o", "a", true)
+	v, ok := c.get("s", "", "o", "a")
 	assert.True(t, ok)
 	assert.True(t, v)
 }
 
 func TestDecisionCache_NilReceiver(t *testing.T) {
 	var c *decisionCache
-	_, ok := c.get("s", "o", "a")
+	_, ok := c.get("s", "", "o", "a")
 	assert.False(t, ok)
-	c.put("s", "o", "a", true) // must not panic
-	c.invalidateSub("s")       // must not panic
-	c.flush()                  // must not panic
+	c.put("s", "", "o", "a", true) // must not panic
+	c.invalidateSub("s")           // must not panic
+	c.flush()                      // must not panic
 	assert.Equal(t, 0, c.len())
 }
 
 func TestDecisionCache_SizeZero_Disabled(t *testing.T) {
 	c := newDecisionCache(0)
-	c.put("s", "o", "a", true)
-	_, ok := c.get("s", "o", "a")
+	c.put("s", "", "o", "a", true)
+	_, ok := c.get("s", "", "o", "a")
 	assert.False(t, ok, "size-0 cache must never return a hit")
 }
 
 func TestDecisionCache_LRUEviction(t *testing.T) {
 	c := newDecisionCache(3)
-	c.put("u1", "o", "a", true)
-	c.put("u2", "o", "a", true)
-	c.put("u3", "o", "a", true)
+	c.put("u1", "", "o", "a", true)
+	c.put("u2", "", "o", "a", true)
+	c.put("u3", "", "o", "a", true)
 	assert.Equal(t, 3, c.len())
 
 	// Adding a 4th entry should evict u1 (LRU).
-	c.put("u4", "o", "a", true)
+	c.put("u4", "", "o", "a", true)
 	assert.Equal(t, 3, c.len())
-	_, ok := c.get("u1", "o", "a")
+	_, ok := c.get("u1", "", "o", "a")
 	assert.False(t, ok, "u1 should have been evicted")
 
 	// u4 is MRU, must be present.
-	_, ok = c.get("u4", "o", "a")
+	_, ok = c.get("u4", "", "o", "a")
 	assert.True(t, ok)
 }
 
 func TestDecisionCache_LRUPromotion(t *testing.T) {
 	c := newDecisionCache(3)
-	c.put("u1", "o", "a", true)
-	c.put("u2", "o", "a", true)
-	c.put("u3", "o", "a", true)
+	c.put("u1", "", "o", "a", true)
+	c.put("u2", "", "o", "a", true)
+	c.put("u3", "", "o", "a", true)
 
 	// Access u1 to promote it to MRU position.
-	c.get("u1", "o", "a")
+	c.get("u1", "", "o", "a")
 
 	// Adding u4 should evict the new LRU which is u2.
-	c.put("u4", "o", "a", true)
-	_, ok := c.get("u2", "o", "a")
+	c.put("u4", "", "o", "a", true)
+	_, ok := c.get("u2", "", "o", "a")
 	assert.False(t, ok, "u2 should have been evicted after u1 was promoted")
-	_, ok = c.get("u1", "o", "a")
+	_, ok = c.get("u1", "", "o", "a")
 	assert.True(t, ok, "u1 was promoted; it must still be present")
 }
 
 func TestDecisionCache_InvalidateSub(t *testing.T) {
 	c := newDecisionCache(100)
-	c.put("alice", "data1", "read", true)
-	c.put("alice", "data2", "write", false)
-	c.put("bob", "data1", "read", true)
+	c.put("alice", "", "data1", "read", true)
+	c.put("alice", "", "data2", "write", false)
+	c.put("bob", "", "data1", "read", true)
 
 	c.invalidateSub("alice")
 
-	_, ok := c.get("alice", "data1", "read")
+	_, ok := c.get("alice", "", "data1", "read")
 	assert.False(t, ok, "alice entries must be removed")
-	_, ok = c.get("alice", "data2", "write")
+	_, ok = c.get("alice", "", "data2", "write")
 	assert.False(t, ok, "alice entries must be removed")
-	_, ok = c.get("bob", "data1", "read")
+	_, ok = c.get("bob", "", "data1", "read")
 	assert.True(t, ok, "bob entry must be unaffected")
 }
 
 func TestDecisionCache_Flush(t *testing.T) {
 	c := newDecisionCache(100)
-	c.put("a", "b", "c", true)
-	c.put("d", "e", "f", false)
+	c.put("a", "", "b", "c", true)
+	c.put("d", "", "e", "f", false)
 	c.flush()
 	assert.Equal(t, 0, c.len())
 }
@@ -143,7 +159,7 @@ func TestDecisionCache_Hit(t *testing.T) {
 	assert.False(t, v1)
 
 	// Add permission directly to the underlying enforcer (bypasses cache invalidation).
-	_, err = a.enforcer.AddPolicy("user1", "res", "read")
+	_, err = a.enforcer.AddPolicy("user1", "res", "read", "allow")
 	require.NoError(t, err)
 
 	// Cache still returns the stale false.
@@ -170,7 +186,7 @@ func TestDecisionCache_Disabled_Size0(t *testing.T) {
 	assert.False(t, v1)
 
 	// Mutate enforcer directly — no cache so the new answer must be visible immediately.
-	_, err = a.enforcer.AddPolicy("user1", "res", "read")
+	_, err = a.enforcer.AddPolicy("user1", "res", "read", "allow")
 	require.NoError(t, err)
 
 	// With size-0 cache the Enforce call must go to the enforcer every time.
@@ -195,7 +211,7 @@ func TestDecisionCache_InvalidatesOn_AddRoleForUser(t *testing.T) {
 	// Entry is now cached (false).
 
 	// Give the role the permission directly on the enforcer.
-	_, err = a.enforcer.AddPolicy("editor", "res", "read")
+	_, err = a.enforcer.AddPolicy("editor", "res", "read", "allow")
 	require.NoError(t, err)
 
 	// AddRoleForUser via adapter must invalidate user1's cache entries.
@@ -347,7 +363,7 @@ func TestDecisionCache_InvalidatesOn_WatcherCallback(t *testing.T) {
 
 	// Send an add_policy watcher op for "user1 res read".
 	// The callback will call enforcer.AddPolicy AND flush the cache.
-	require.NoError(t, w.UpdateForAddPolicy("p", "p", "user1", "res", "read"))
+	require.NoError(t, w.UpdateForAddPolicy("p", "p", "user1", "res", "read", "allow"))
 
 	// Give the goroutine time to dispatch and flush.
 	assert.Eventually(t, func() bool {
@@ -385,18 +401,18 @@ func TestDecisionCache_NullByteInEnforceInput_NoCollision(t *testing.T) {
 	c := newDecisionCache(100)
 
 	// Store a legitimate entry.
-	c.put("alice", "data", "read", true)
+	c.put("alice", "", "data", "read", true)
 
 	// A crafted sub that contains \x00 produces the same raw key string as
 	// ("alice", "data", "read") but must NOT collide because get/put bypass
 	// the cache when any arg contains \x00.
-	v, ok := c.get("alice\x00data", "read", "x")
+	v, ok := c.get("alice\x00data", "", "read", "x")
 	assert.False(t, ok, "null-byte input must bypass cache (miss), not collide with stored entry")
 	assert.False(t, v)
 
 	// put with null-byte input must be a no-op — legitimate entry unaffected.
-	c.put("alice\x00data", "read", "x", false)
-	v2, ok2 := c.get("alice", "data", "read")
+	c.put("alice\x00data", "", "read", "x", false)
+	v2, ok2 := c.get("alice", "", "data", "read")
 	assert.True(t, ok2, "legitimate entry must survive a null-byte put attempt")
 	assert.True(t, v2)
 
@@ -428,6 +444,6 @@ func TestDecisionCache_OtherSubjectUnaffected(t *testing.T) {
 	require.NoError(t, a.AddPermissionForUser("user1", "other", "read"))
 	// After AddPermissionForUser: user1's old cache entry was invalidated.
 	// user2's entry must still be cached.
-	_, ok := a.cache.get("user2", "res", "read")
+	_, ok := a.cache.get("user2", "", "res", "read")
 	assert.True(t, ok, "user2's cache entry must not be affected by user1 invalidation")
 }
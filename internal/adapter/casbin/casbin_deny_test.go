@@ -0,0 +1,85 @@
+package casbin
+
+import (
+	"testing"
+
+	casbinlib "github.com/casbin/casbin/v3"
+	"github.com/casbin/casbin/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func TestAdapter_Deny(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole("editor", "users", "*"))
+	require.NoError(t, a.AddPermissionForRole(port.RoleOwner, "users", "update"))
+	require.NoError(t, a.AddRoleForUser("alice", "editor"))
+	require.NoError(t, a.AddRoleForUser("bob", "editor"))
+
+	check := func(sub, act string) bool {
+		t.Helper()
+		allowed, err := a.Enforce(sub, "users", act)
+		require.NoError(t, err)
+		return allowed
+	}
+	assert.True(t, check("alice", "delete"))
+
+	require.NoError(t, a.AddDeny("alice", "users", "delete"))
+	assert.False(t, check("alice", "delete"), "a deny overrides the role's allow, and drops the cached decision")
+	assert.True(t, check("alice", "update"), "other actions stay allowed")
+	assert.True(t, check("bob", "delete"), "other holders of the role are not affected")
+
+	require.NoError(t, a.AddDeny("alice", "users", "update"))
+	owned, err := a.EnforceOwnership("alice", "alice", "", "users", "update")
+	require.NoError(t, err)
+	assert.False(t, owned, "a deny overrides ownership too")
+	inDomain, err := a.EnforceInDomain("alice", "acme", "users", "update")
+	require.NoError(t, err)
+	assert.False(t, inDomain, "and applies in every domain")
+
+	require.NoError(t, a.AddDeny("editor", "users", "create"))
+	assert.False(t, check("bob", "create"), "a deny on a role applies to its holders")
+
+	perms, err := a.GetPermissionsForRole("editor")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"editor", "users", "*"}}, perms, "deny rules are not permissions")
+	implicit, err := a.GetImplicitPermissionsForUser("alice")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"editor", "users", "*"}}, implicit, "a wildcard is only partly denied, so it stays")
+
+	rules, err := a.GetRules()
+	require.NoError(t, err)
+	assert.Contains(t, rules, port.PolicyRule{Type: "p", Values: []string{"editor", "users", "*"}})
+	assert.Contains(t, rules, port.PolicyRule{Type: "p", Values: []string{"alice", "users", "delete", "deny"}})
+
+	require.NoError(t, a.RemoveDeny("alice", "users", "delete"))
+	assert.True(t, check("alice", "delete"))
+}
+
+func TestAdapter_Deny_ImplicitPermissions(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole("viewer", "users", "read"))
+	require.NoError(t, a.AddPermissionForRole("viewer", "files", "read"))
+	require.NoError(t, a.AddRoleForUser("alice", "viewer"))
+	require.NoError(t, a.AddDeny("alice", "files", "*"))
+
+	perms, err := a.GetImplicitPermissionsForUser("alice")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"viewer", "users", "read"}}, perms)
+}
+
+func TestAdapter_Deny_LegacyModel(t *testing.T) {
+	m, err := model.NewModelFromString(legacyModel)
+	require.NoError(t, err)
+	enforcer, err := casbinlib.NewEnforcer(m)
+	require.NoError(t, err)
+	a := &Adapter{enforcer: enforcer}
+
+	require.NoError(t, a.AddPermissionForUser("alice", "users", "read"))
+	assert.ErrorIs(t, a.AddDeny("alice", "users", "read"), ErrDenyUnsupported)
+	perms, err := a.GetPermissionsForUser("alice")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"alice", "users", "read"}}, perms)
+}
@@ -0,0 +1,105 @@
+package casbin
+
+import (
+	"testing"
+
+	casbinlib "github.com/casbin/casbin/v3"
+	"github.com/casbin/casbin/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyModel is the pre-domain RBAC model, as a custom model_path file may
+// still define it.
+const legacyModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && (p.obj == "*" || r.obj == p.obj) && (p.act == "*" || r.act == p.act)
+`
+
+func TestAdapter_DomainRoles(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole("editor", "projects", "update"))
+	require.NoError(t, a.AddPermissionForRole("viewer", "projects", "read"))
+	require.NoError(t, a.AddRoleForUserInDomain("alice", "editor", "acme"))
+	require.NoError(t, a.AddRoleForUser("alice", "viewer"))
+
+	check := func(dom, act string) bool {
+		t.Helper()
+		allowed, err := a.EnforceInDomain("alice", dom, "projects", act)
+		require.NoError(t, err)
+		return allowed
+	}
+	assert.True(t, check("acme", "update"), "role granted in the domain applies there")
+	assert.False(t, check("globex", "update"), "and nowhere else")
+	assert.True(t, check("globex", "read"), "global roles apply in every domain")
+
+	allowed, err := a.Enforce("alice", "projects", "update")
+	require.NoError(t, err)
+	assert.False(t, allowed, "domain roles do not apply outside a domain")
+
+	roles, err := a.GetRolesForUserInDomain("alice", "acme")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, roles)
+	users, err := a.GetUsersForRoleInDomain("editor", "acme")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, users)
+	global, err := a.GetRolesForUser("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"viewer"}, global, "domain grants are not global roles")
+
+	require.NoError(t, a.RemoveRoleForUserInDomain("alice", "editor", "acme"))
+	assert.False(t, check("acme", "update"), "revoking invalidates the cached decision")
+}
+
+func TestAdapter_DomainRoles_InvalidArgs(t *testing.T) {
+	a := newTestAdapter(t)
+	assert.ErrorIs(t, a.AddRoleForUserInDomain("alice", "editor", ""), ErrInvalidPolicyArg)
+	assert.ErrorIs(t, a.AddRoleForUserInDomain("alice", "editor", "ac\x00me"), ErrInvalidPolicyArg)
+}
+
+func TestAdapter_DomainRoles_LegacyModel(t *testing.T) {
+	m, err := model.NewModelFromString(legacyModel)
+	require.NoError(t, err)
+	enforcer, err := casbinlib.NewEnforcer(m)
+	require.NoError(t, err)
+	a := &Adapter{enforcer: enforcer}
+
+	require.NoError(t, a.AddPermissionForUser("alice", "projects", "read"))
+	allowed, err := a.EnforceInDomain("alice", "acme", "projects", "read")
+	require.NoError(t, err)
+	assert.True(t, allowed, "without domains, a domain check is a global one")
+
+	assert.ErrorIs(t, a.AddRoleForUserInDomain("alice", "editor", "acme"), ErrDomainsUnsupported)
+	roles, err := a.GetRolesForUserInDomain("alice", "acme")
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+}
+
+func TestAdapter_WatcherCallback_AppliesDomainGrants(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole("editor", "projects", "update"))
+	cb := a.makeUpdateCallback()
+
+	// Casbin reports grouping rules as add_policy in section "g".
+	cb(encodeOp("add_policy", "g", "g2", []string{"bob", "editor", "acme"}))
+	allowed, err := a.EnforceInDomain("bob", "acme", "projects", "update")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	cb(encodeOp("remove_policy", "g", "g2", []string{"bob", "editor", "acme"}))
+	allowed, err = a.EnforceInDomain("bob", "acme", "projects", "update")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,59 @@
+package casbin
+
+import (
+	"testing"
+
+	casbinlib "github.com/casbin/casbin/v3"
+	"github.com/casbin/casbin/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func TestAdapter_EnforceOwnership(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole(port.RoleOwner, "users", "read"))
+	require.NoError(t, a.AddPermissionForRole("admin", "users", "*"))
+	require.NoError(t, a.AddRoleForUser("root", "admin"))
+	require.NoError(t, a.AddRoleForUserInDomain("carol", "admin", "acme"))
+
+	check := func(sub, owner, dom, act string) bool {
+		t.Helper()
+		allowed, err := a.EnforceOwnership(sub, owner, dom, "users", act)
+		require.NoError(t, err)
+		return allowed
+	}
+	assert.True(t, check("alice", "alice", "", "read"), "owners have the owner role's permissions")
+	assert.True(t, check("alice", "alice", "acme", "read"), "in every domain")
+	assert.False(t, check("alice", "alice", "", "delete"), "and no others")
+	assert.False(t, check("alice", "bob", "", "read"), "on their own objects only")
+	assert.False(t, check("alice", "", "", "read"), "objects without an owner are nobody's")
+	assert.True(t, check("root", "bob", "", "delete"), "permissions apply as usual")
+	assert.True(t, check("carol", "bob", "acme", "delete"), "domain roles too")
+	assert.False(t, check("carol", "bob", "", "delete"))
+
+	allowed, err := a.Enforce("alice", "users", "read")
+	require.NoError(t, err)
+	assert.False(t, allowed, "the owner role is not granted to anyone")
+
+	require.NoError(t, a.RemovePermissionForRole(port.RoleOwner, "users", "read"))
+	assert.False(t, check("alice", "alice", "", "read"))
+}
+
+func TestAdapter_EnforceOwnership_LegacyModel(t *testing.T) {
+	m, err := model.NewModelFromString(legacyModel)
+	require.NoError(t, err)
+	enforcer, err := casbinlib.NewEnforcer(m)
+	require.NoError(t, err)
+	a := &Adapter{enforcer: enforcer}
+	require.NoError(t, a.AddPermissionForRole(port.RoleOwner, "users", "read"))
+	require.NoError(t, a.AddPermissionForUser("bob", "users", "read"))
+
+	allowed, err := a.EnforceOwnership("alice", "alice", "", "users", "read")
+	require.NoError(t, err)
+	assert.False(t, allowed, "without an ownership matcher, ownership grants nothing")
+	allowed, err = a.EnforceOwnership("bob", "alice", "", "users", "read")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -49,6 +49,16 @@ func TestNoOpAdapter_EnforceWithContext_AlwaysTrue(t *testing.T) {
 	assert.True(t, allowed)
 }
 
+func TestNoOpAdapter_EnforceBatch_AlwaysTrue(t *testing.T) {
+	a := NewNoOpAdapter()
+	allowed, err := a.EnforceBatch(context.Background(), []port.EnforceRequest{
+		{Sub: "user", Obj: "res", Act: "read"},
+		{Sub: "user", Dom: "acme", Obj: "res", Act: "delete"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, allowed)
+}
+
 func TestNoOpAdapter_RoleOperations(t *testing.T) {
 	a := NewNoOpAdapter()
 
@@ -293,6 +303,86 @@ func TestAdapter_RemovePermissionForRole(t *testing.T) {
 	assert.Len(t, perms, 1)
 }
 
+func TestAdapter_EnforceBatch(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole("editor", "posts", "*"))
+	require.NoError(t, a.AddDeny("editor", "posts", "delete"))
+	require.NoError(t, a.AddRoleForUser("alice", "viewer"))
+	require.NoError(t, a.AddRoleForUserInDomain("alice", "editor", "acme"))
+
+	requests := []port.EnforceRequest{
+		{Sub: "alice", Dom: "acme", Obj: "posts", Act: "update"},
+		{Sub: "alice", Dom: "acme", Obj: "posts", Act: "delete"},
+		{Sub: "alice", Obj: "posts", Act: "update"},
+		{Sub: "alice", Dom: "globex", Obj: "posts", Act: "update"},
+	}
+	allowed, err := a.EnforceBatch(context.Background(), requests)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, false, false}, allowed)
+
+	for i, r := range requests {
+		single, err := a.EnforceInDomain(r.Sub, r.Dom, r.Obj, r.Act)
+		require.NoError(t, err)
+		assert.Equal(t, allowed[i], single, "request %d agrees with EnforceInDomain", i)
+	}
+
+	_, ok := a.cache.get("alice", "acme", "posts", "update")
+	assert.True(t, ok, "batch decisions are memoised")
+	require.NoError(t, a.RemoveRoleForUserInDomain("alice", "editor", "acme"))
+	allowed, err = a.EnforceBatch(context.Background(), requests[:1])
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, allowed, "and dropped with the user's other decisions")
+
+	allowed, err = a.EnforceBatch(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, allowed)
+}
+
+func TestAdapter_OnEnforce(t *testing.T) {
+	a := newCachedTestAdapter(t, 100)
+	require.NoError(t, a.AddPermissionForRole("editor", "posts", "read"))
+	require.NoError(t, a.AddPermissionForRole(port.RoleOwner, "posts", "update"))
+	require.NoError(t, a.AddRoleForUser("alice", "editor"))
+
+	type decision struct {
+		obj, act        string
+		allowed, cached bool
+	}
+	var got []decision
+	a.onEnforce = func(obj, act string, allowed, cached bool, d time.Duration, err error) {
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		got = append(got, decision{obj, act, allowed, cached})
+	}
+
+	_, _ = a.Enforce("alice", "posts", "read")
+	_, _ = a.Enforce("alice", "posts", "read")
+	_, _ = a.EnforceInDomain("alice", "acme", "posts", "delete")
+	_, _ = a.EnforceOwnership("alice", "alice", "", "posts", "update")
+	_, _ = a.EnforceBatch(context.Background(), []port.EnforceRequest{
+		{Sub: "alice", Obj: "posts", Act: "read"},
+		{Sub: "bob", Obj: "posts", Act: "read"},
+	})
+
+	assert.Equal(t, []decision{
+		{"posts", "read", true, false},
+		{"posts", "read", true, true},
+		{"posts", "delete", false, false},
+		{"posts", "update", true, false},
+		{"posts", "read", true, true},
+		{"posts", "read", false, false},
+	}, got, "one report per decision; an ownership check reports its final outcome")
+}
+
+func TestAdapter_EnforceBatch_CancelledContext(t *testing.T) {
+	a := newTestAdapter(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := a.EnforceBatch(ctx, []port.EnforceRequest{{Sub: "user1", Obj: "res", Act: "act"}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestAdapter_GetImplicitPermissionsForUser(t *testing.T) {
 	a := newTestAdapter(t)
 
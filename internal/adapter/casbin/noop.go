@@ -25,6 +25,20 @@ func (a *NoOpAdapter) EnforceWithContext(ctx context.Context, sub, obj, act stri
 	return true, nil
 }
 
+// EnforceOwnership always returns true
+func (a *NoOpAdapter) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	return true, nil
+}
+
+// EnforceBatch allows every request
+func (a *NoOpAdapter) EnforceBatch(_ context.Context, requests []port.EnforceRequest) ([]bool, error) {
+	results := make([]bool, len(requests))
+	for i := range results {
+		results[i] = true
+	}
+	return results, nil
+}
+
 // AddRoleForUser is a no-op
 func (a *NoOpAdapter) AddRoleForUser(userID, role string) error {
 	return nil
@@ -50,6 +64,31 @@ func (a *NoOpAdapter) HasRoleForUser(userID, role string) (bool, error) {
 	return true, nil
 }
 
+// EnforceInDomain always returns true
+func (a *NoOpAdapter) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	return true, nil
+}
+
+// AddRoleForUserInDomain is a no-op
+func (a *NoOpAdapter) AddRoleForUserInDomain(userID, role, domain string) error {
+	return nil
+}
+
+// RemoveRoleForUserInDomain is a no-op
+func (a *NoOpAdapter) RemoveRoleForUserInDomain(userID, role, domain string) error {
+	return nil
+}
+
+// GetRolesForUserInDomain returns empty slice
+func (a *NoOpAdapter) GetRolesForUserInDomain(userID, domain string) ([]string, error) {
+	return []string{}, nil
+}
+
+// GetUsersForRoleInDomain returns empty slice
+func (a *NoOpAdapter) GetUsersForRoleInDomain(role, domain string) ([]string, error) {
+	return []string{}, nil
+}
+
 // AddPermissionForRole is a no-op
 func (a *NoOpAdapter) AddPermissionForRole(role, obj, act string) error {
 	return nil
@@ -85,6 +124,21 @@ func (a *NoOpAdapter) GetImplicitPermissionsForUser(userID string) ([][]string,
 	return [][]string{}, nil
 }
 
+// AddDeny is a no-op
+func (a *NoOpAdapter) AddDeny(sub, obj, act string) error {
+	return nil
+}
+
+// RemoveDeny is a no-op
+func (a *NoOpAdapter) RemoveDeny(sub, obj, act string) error {
+	return nil
+}
+
+// GetRules returns empty slice
+func (a *NoOpAdapter) GetRules() ([]port.PolicyRule, error) {
+	return []port.PolicyRule{}, nil
+}
+
 // LoadPolicy is a no-op
 func (a *NoOpAdapter) LoadPolicy() error {
 	return nil
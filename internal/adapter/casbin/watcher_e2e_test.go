@@ -222,7 +222,7 @@ func TestWatcherE2E_Memory(t *testing.T) {
 	})
 
 	// Pre-condition: B has no policy.
-	allowed, err := enforcerB.Enforce("e2e-role", "orders", "delete")
+	allowed, err := enforcerB.Enforce("e2e-role", "", "orders", "delete")
 	require.NoError(t, err)
 	assert.False(t, allowed, "pre-condition: B must not have the rule before A mutates")
 
@@ -236,7 +236,7 @@ func TestWatcherE2E_Memory(t *testing.T) {
 
 	// Assertion: B must see the rule — exclusively via the watcher callback
 	// (incremental add_policy path), not a full reload.
-	allowed, err = enforcerB.Enforce("e2e-role", "orders", "delete")
+	allowed, err = enforcerB.Enforce("e2e-role", "", "orders", "delete")
 	require.NoError(t, err)
 	assert.True(t, allowed,
 		"MemoryWatcher e2e: B must enforce the rule via the watcher path")
@@ -271,7 +271,7 @@ func TestWatcherE2E_Memory_Remove(t *testing.T) {
 	nw.waitForUpdate(t)
 
 	// Verify B has the rule.
-	allowed, err := enforcerB.Enforce("e2e-role", "invoices", "read")
+	allowed, err := enforcerB.Enforce("e2e-role", "", "invoices", "read")
 	require.NoError(t, err)
 	require.True(t, allowed, "setup: B must see initial rule before remove test")
 
@@ -280,7 +280,7 @@ func TestWatcherE2E_Memory_Remove(t *testing.T) {
 	nw.waitForUpdate(t)
 
 	// Assertion: B must lose the rule via the incremental remove path.
-	allowed, err = enforcerB.Enforce("e2e-role", "invoices", "read")
+	allowed, err = enforcerB.Enforce("e2e-role", "", "invoices", "read")
 	require.NoError(t, err)
 	assert.False(t, allowed,
 		"MemoryWatcher e2e remove: B must lose the rule via the watcher path")
@@ -338,19 +338,19 @@ func TestWatcherE2E_Redis(t *testing.T) {
 	})
 
 	// Pre-condition: B has no policy.
-	allowed, err := enforcerB.Enforce("e2e-redis-role", "shipments", "create")
+	allowed, err := enforcerB.Enforce("e2e-redis-role", "", "shipments", "create")
 	require.NoError(t, err)
 	assert.False(t, allowed, "pre-condition: B must not have the rule before A mutates")
 
 	// Action: A mutates its enforcer and publishes the watcher op to Redis.
 	require.NoError(t, adapterA.AddPermissionForRole("e2e-redis-role", "shipments", "create"))
-	publishOp(t, ctx, client, channel, "add_policy", "p", []string{"e2e-redis-role", "shipments", "create"})
+	publishOp(t, ctx, client, channel, "add_policy", "p", []string{"e2e-redis-role", "shipments", "create", "allow"})
 
 	// Wait for B's callback to complete before reading B's enforcer.
 	watcherB.waitForUpdate(t)
 
 	// Assertion: B's enforcer must see the rule via the pub/sub path.
-	allowed, err = enforcerB.Enforce("e2e-redis-role", "shipments", "create")
+	allowed, err = enforcerB.Enforce("e2e-redis-role", "", "shipments", "create")
 	require.NoError(t, err)
 	assert.True(t, allowed,
 		"RedisWatcher e2e: B must enforce the rule via pub/sub path")
@@ -382,21 +382,21 @@ func TestWatcherE2E_Redis_Remove(t *testing.T) {
 
 	// Seed: add rule on A and propagate to B via pub/sub.
 	require.NoError(t, adapterA.AddPermissionForRole("e2e-redis-role", "returns", "approve"))
-	publishOp(t, ctx, client, channel, "add_policy", "p", []string{"e2e-redis-role", "returns", "approve"})
+	publishOp(t, ctx, client, channel, "add_policy", "p", []string{"e2e-redis-role", "returns", "approve", "allow"})
 	watcherB.waitForUpdate(t)
 
 	// Verify B has the rule.
-	allowed, err := enforcerB.Enforce("e2e-redis-role", "returns", "approve")
+	allowed, err := enforcerB.Enforce("e2e-redis-role", "", "returns", "approve")
 	require.NoError(t, err)
 	require.True(t, allowed, "setup: B must see initial rule before remove test")
 
 	// Action: A removes and publishes the remove op.
 	require.NoError(t, adapterA.RemovePermissionForRole("e2e-redis-role", "returns", "approve"))
-	publishOp(t, ctx, client, channel, "remove_policy", "p", []string{"e2e-redis-role", "returns", "approve"})
+	publishOp(t, ctx, client, channel, "remove_policy", "p", []string{"e2e-redis-role", "returns", "approve", "allow"})
 	watcherB.waitForUpdate(t)
 
 	// Assertion: B must lose the rule via the incremental remove path.
-	allowed, err = enforcerB.Enforce("e2e-redis-role", "returns", "approve")
+	allowed, err = enforcerB.Enforce("e2e-redis-role", "", "returns", "approve")
 	require.NoError(t, err)
 	assert.False(t, allowed,
 		"RedisWatcher e2e remove: B must lose the rule via pub/sub path")
@@ -434,18 +434,18 @@ func TestWatcherE2E_Redis_IsolatedChannels(t *testing.T) {
 	})
 
 	// Publish to pair1 channel only.
-	publishOp(t, ctx, client, chPair1, "add_policy", "p", []string{"pair1-role", "widget", "read"})
+	publishOp(t, ctx, client, chPair1, "add_policy", "p", []string{"pair1-role", "widget", "read", "allow"})
 	watcherB1.waitForUpdate(t)
 
 	// B1 must see the change.
-	allowed, err := enforcerB1.Enforce("pair1-role", "widget", "read")
+	allowed, err := enforcerB1.Enforce("pair1-role", "", "widget", "read")
 	require.NoError(t, err)
 	assert.True(t, allowed, "pair1 B must see the rule")
 
 	// B2 must NOT be affected (different channel).
 	// Allow a brief window in case any message leaked.
 	time.Sleep(50 * time.Millisecond)
-	allowed, err = enforcerB2.Enforce("pair1-role", "widget", "read")
+	allowed, err = enforcerB2.Enforce("pair1-role", "", "widget", "read")
 	require.NoError(t, err)
 	assert.False(t, allowed, "pair2 B must not receive pair1 messages (different channel)")
 }
@@ -0,0 +1,190 @@
+package casbin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgresChannel is the channel the casbin_rules trigger (migration
+// 000020) notifies on
+const PostgresChannel = "casbin_rules_changed"
+
+// Defaults of PostgresWatcher
+const (
+	defaultPostgresDebounce   = 500 * time.Millisecond
+	postgresReconnectMin      = time.Second
+	postgresReconnectMax      = 30 * time.Second
+	postgresWatcherConnectTTL = 10 * time.Second
+)
+
+// PostgresWatcher is a Casbin Watcher that reloads the policy whenever
+// casbin_rules changes. A trigger on the table notifies PostgresChannel for
+// every statement that changes it, so unlike RedisWatcher it also sees rules
+// written outside the enforcer, e.g. by a user purge or by hand. Instances
+// only need the database they already share.
+//
+// Notifications carry no payload: each one leads to a full reload, and
+// notifications arriving within the debounce window are coalesced into one.
+// An instance also reloads after its own changes, which it already applied;
+// the debounce keeps that to one reload per burst.
+//
+// The watcher holds a dedicated connection for LISTEN. When it is lost the
+// watcher reconnects with backoff and reloads once reconnected, since
+// changes made meanwhile were not notified.
+type PostgresWatcher struct {
+	dsn      string
+	debounce time.Duration
+
+	mu       sync.Mutex
+	callback func(string)
+	pending  *time.Timer
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPostgresWatcher connects to dsn, listens on PostgresChannel and starts
+// the listener goroutine. debounce defaults to 500ms when zero.
+func NewPostgresWatcher(ctx context.Context, dsn string, debounce time.Duration) (*PostgresWatcher, error) {
+	if debounce <= 0 {
+		debounce = defaultPostgresDebounce
+	}
+	w := &PostgresWatcher{
+		dsn:      dsn,
+		debounce: debounce,
+		done:     make(chan struct{}),
+	}
+
+	conn, err := w.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	go w.run(ctx, conn)
+	return w, nil
+}
+
+// SetUpdateCallback stores the callback invoked for each reload.
+func (w *PostgresWatcher) SetUpdateCallback(f func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = f
+	return nil
+}
+
+// Update is a no-op: the casbin_rules trigger notifies every instance of
+// the enforcer's own changes once they are committed.
+func (w *PostgresWatcher) Update() error {
+	return nil
+}
+
+// Close stops the listener, closes its connection and drops any pending
+// reload.
+func (w *PostgresWatcher) Close() {
+	w.cancel()
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending != nil {
+		w.pending.Stop()
+		w.pending = nil
+	}
+}
+
+// connect opens the LISTEN connection
+func (w *PostgresWatcher) connect(ctx context.Context) (*pgx.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, postgresWatcherConnectTTL)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, w.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("casbin: postgres watcher connect: %w", err)
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+PostgresChannel); err != nil {
+		_ = conn.Close(context.Background())
+		return nil, fmt.Errorf("casbin: postgres watcher listen: %w", err)
+	}
+	return conn, nil
+}
+
+// run waits for notifications on conn, reconnecting whenever it is lost,
+// until ctx is cancelled.
+func (w *PostgresWatcher) run(ctx context.Context, conn *pgx.Conn) {
+	defer close(w.done)
+	for {
+		err := w.listen(ctx, conn)
+		_ = conn.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("casbin postgres watcher disconnected", "error", err)
+
+		if conn = w.reconnect(ctx); conn == nil {
+			return
+		}
+		// Changes made while disconnected were not notified.
+		w.schedule()
+	}
+}
+
+// listen schedules a reload for every notification on conn, until conn
+// fails or ctx is cancelled.
+func (w *PostgresWatcher) listen(ctx context.Context, conn *pgx.Conn) error {
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		w.schedule()
+	}
+}
+
+// reconnect retries connect with exponential backoff. It returns nil when
+// ctx is cancelled first.
+func (w *PostgresWatcher) reconnect(ctx context.Context) *pgx.Conn {
+	backoff := postgresReconnectMin
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		conn, err := w.connect(ctx)
+		if err == nil {
+			slog.Info("casbin postgres watcher reconnected")
+			return conn
+		}
+		slog.Warn("casbin postgres watcher reconnect failed", "error", err, "retry_in", backoff.String())
+		backoff = min(backoff*2, postgresReconnectMax)
+	}
+}
+
+// schedule arranges a reload at the end of the debounce window, unless one
+// is already pending.
+func (w *PostgresWatcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending != nil {
+		return
+	}
+	w.pending = time.AfterFunc(w.debounce, w.fire)
+}
+
+// fire invokes the callback with a full-reload op. A notification arriving
+// during the reload schedules another one.
+func (w *PostgresWatcher) fire() {
+	w.mu.Lock()
+	w.pending = nil
+	cb := w.callback
+	w.mu.Unlock()
+
+	if cb != nil {
+		cb(encodeOp("reload", "", "", nil))
+	}
+}
@@ -0,0 +1,88 @@
+package casbin
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	casbinlib "github.com/casbin/casbin/v3"
+	"github.com/casbin/casbin/v3/model"
+	stringadapter "github.com/casbin/casbin/v3/persist/string-adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDebounceWatcher returns a PostgresWatcher with no connection, for
+// exercising the debounce alone.
+func newDebounceWatcher(debounce time.Duration) *PostgresWatcher {
+	return &PostgresWatcher{debounce: debounce}
+}
+
+func TestPostgresWatcher_CoalescesNotifications(t *testing.T) {
+	w := newDebounceWatcher(50 * time.Millisecond)
+	var calls atomic.Int32
+	var msg atomic.Value
+	require.NoError(t, w.SetUpdateCallback(func(m string) {
+		msg.Store(m)
+		calls.Add(1)
+	}))
+
+	for range 10 {
+		w.schedule()
+	}
+
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, testTimeout(), testPoll())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load(), "a burst of notifications reloads once")
+
+	var op watcherOp
+	require.NoError(t, json.Unmarshal([]byte(msg.Load().(string)), &op))
+	assert.Equal(t, "reload", op.Op)
+}
+
+func TestPostgresWatcher_NotificationAfterReloadSchedulesAnother(t *testing.T) {
+	w := newDebounceWatcher(10 * time.Millisecond)
+	var calls atomic.Int32
+	require.NoError(t, w.SetUpdateCallback(func(string) { calls.Add(1) }))
+
+	w.schedule()
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, testTimeout(), testPoll())
+
+	w.schedule()
+	assert.Eventually(t, func() bool { return calls.Load() == 2 }, testTimeout(), testPoll())
+}
+
+func TestPostgresWatcher_UpdateIsNoOp(t *testing.T) {
+	w := newDebounceWatcher(time.Millisecond)
+	var calls atomic.Int32
+	require.NoError(t, w.SetUpdateCallback(func(string) { calls.Add(1) }))
+
+	require.NoError(t, w.Update())
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, calls.Load(), "the trigger, not Update, notifies instances")
+}
+
+func TestAdapter_ReportsReloads(t *testing.T) {
+	m, err := model.NewModelFromString(defaultModel)
+	require.NoError(t, err)
+	// Full reloads need a persist adapter to load from.
+	enforcer, err := casbinlib.NewEnforcer(m, stringadapter.NewAdapter("p, u, o, a"))
+	require.NoError(t, err)
+
+	a := &Adapter{enforcer: enforcer, cache: newDecisionCache(0)}
+	var triggers []string
+	a.onReload = func(trigger string, d time.Duration, err error) {
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		triggers = append(triggers, trigger)
+	}
+
+	require.NoError(t, a.LoadPolicy())
+	a.makeUpdateCallback()(encodeOp("reload", "", "", nil))
+	a.makeUpdateCallback()("not json")
+	a.makeUpdateCallback()(encodeOp("add_policy", "p", "p", []string{"u", "o", "a", "allow"}))
+
+	assert.Equal(t, []string{ReloadTriggerAPI, ReloadTriggerWatcher, ReloadTriggerWatcher}, triggers,
+		"incremental ops are not reloads")
+}
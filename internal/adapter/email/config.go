@@ -0,0 +1,58 @@
+package email
+
+import (
+	"os"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// NewSender creates the email sender described by cfg: an SMTP sender
+// resolving per-tenant profiles from cfg.Tenants when email is enabled, a
+// NoOpSender otherwise.
+func NewSender(cfg config.EmailConfig, log *logger.Logger) port.EmailSender {
+	if !cfg.Enabled {
+		return NewNoOpSender(log)
+	}
+	return NewResolvingSMTPSender(ResolverFromConfig(cfg))
+}
+
+// ResolverFromConfig builds a StaticResolver from the email config. Tenant
+// passwords are read from the env vars named by password_env.
+func ResolverFromConfig(cfg config.EmailConfig) *StaticResolver {
+	def := Profile{
+		SMTP: SMTPConfig{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+		},
+		FromName:   cfg.FromName,
+		ReplyTo:    cfg.ReplyTo,
+		Footer:     cfg.Footer,
+		HTMLFooter: cfg.HTMLFooter,
+	}
+
+	tenants := make(map[string]Profile, len(cfg.Tenants))
+	for name, t := range cfg.Tenants {
+		p := Profile{
+			SMTP: SMTPConfig{
+				Host:     t.Host,
+				Port:     t.Port,
+				Username: t.Username,
+				From:     t.From,
+			},
+			FromName:   t.FromName,
+			ReplyTo:    t.ReplyTo,
+			Footer:     t.Footer,
+			HTMLFooter: t.HTMLFooter,
+		}
+		if t.PasswordEnv != "" {
+			p.SMTP.Password = os.Getenv(t.PasswordEnv)
+		}
+		tenants[name] = p
+	}
+	return NewStaticResolver(def, tenants)
+}
@@ -3,10 +3,13 @@ package email
 import (
 	"context"
 	"net"
+	"net/textproto"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/stretchr/testify/assert"
@@ -201,3 +204,195 @@ func TestEmailSenderInterface(t *testing.T) {
 	var _ port.EmailSender = NewNoOpSender(log)
 	var _ port.EmailSender = NewSMTPSender(SMTPConfig{})
 }
+
+// fakeSMTPServer accepts SMTP sessions and records each message's DATA, with
+// line endings normalized to "\n".
+type fakeSMTPServer struct {
+	host     string
+	port     int
+	messages chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	host, portStr, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	srv := &fakeSMTPServer{host: host, port: portNum, messages: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO", "MAIL", "RCPT":
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.messages <- string(data)
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case msg := <-s.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func TestStaticResolver(t *testing.T) {
+	def := Profile{
+		SMTP:     SMTPConfig{Host: "smtp.default", Port: 587, Username: "default", Password: "secret", From: "noreply@default.com"},
+		FromName: "Default",
+		Footer:   "default footer",
+	}
+	r := NewStaticResolver(def, map[string]Profile{
+		"acme":   {SMTP: SMTPConfig{From: "hello@acme.com"}, FromName: "Acme"},
+		"globex": {SMTP: SMTPConfig{Host: "smtp.globex", Port: 2525, From: "hi@globex.com"}},
+	})
+	ctx := context.Background()
+
+	t.Run("default tenant", func(t *testing.T) {
+		p, err := r.Resolve(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, def, p)
+	})
+
+	t.Run("tenant without host inherits the default transport", func(t *testing.T) {
+		p, err := r.Resolve(ctx, "acme")
+		require.NoError(t, err)
+		assert.Equal(t, "smtp.default", p.SMTP.Host)
+		assert.Equal(t, "default", p.SMTP.Username)
+		assert.Equal(t, "hello@acme.com", p.SMTP.From)
+		assert.Equal(t, "Acme", p.FromName)
+		assert.Equal(t, "default footer", p.Footer)
+	})
+
+	t.Run("tenant with host never gets the default credentials", func(t *testing.T) {
+		p, err := r.Resolve(ctx, "globex")
+		require.NoError(t, err)
+		assert.Equal(t, "smtp.globex", p.SMTP.Host)
+		assert.Empty(t, p.SMTP.Username)
+		assert.Empty(t, p.SMTP.Password)
+		assert.Equal(t, "Default", p.FromName)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "initech")
+		assert.ErrorIs(t, err, port.ErrUnknownEmailTenant)
+	})
+}
+
+func TestSMTPSender_Send_TenantProfile(t *testing.T) {
+	defaultSrv := newFakeSMTPServer(t)
+	tenantSrv := newFakeSMTPServer(t)
+
+	sender := NewResolvingSMTPSender(NewStaticResolver(
+		Profile{SMTP: SMTPConfig{Host: defaultSrv.host, Port: defaultSrv.port, From: "noreply@example.com"}},
+		map[string]Profile{
+			"acme": {
+				SMTP:       SMTPConfig{Host: tenantSrv.host, Port: tenantSrv.port, From: "hello@acme.com"},
+				FromName:   "Acme Support",
+				ReplyTo:    "support@acme.com",
+				Footer:     "Acme Inc.",
+				HTMLFooter: "<p>Acme Inc.</p>",
+			},
+		},
+	))
+	ctx := context.Background()
+
+	t.Run("default profile", func(t *testing.T) {
+		require.NoError(t, sender.Send(ctx, port.EmailMessage{To: []string{"user@example.com"}, Subject: "Hi", Body: "Body"}))
+		msg := defaultSrv.next(t)
+		assert.Contains(t, msg, "From: noreply@example.com\n")
+		assert.NotContains(t, msg, "Reply-To")
+	})
+
+	t.Run("tenant plain text", func(t *testing.T) {
+		require.NoError(t, sender.Send(ctx, port.EmailMessage{To: []string{"user@example.com"}, Subject: "Hi", Body: "Body", Tenant: "acme"}))
+		msg := tenantSrv.next(t)
+		assert.Contains(t, msg, `From: "Acme Support" <hello@acme.com>`)
+		assert.Contains(t, msg, "Reply-To: support@acme.com\n")
+		assert.True(t, strings.HasSuffix(msg, "Body\n\nAcme Inc.\n"), "footer must follow the body: %q", msg)
+	})
+
+	t.Run("tenant html", func(t *testing.T) {
+		require.NoError(t, sender.Send(ctx, port.EmailMessage{To: []string{"user@example.com"}, Subject: "Hi", Body: "<p>Body</p>", IsHTML: true, Tenant: "acme"}))
+		msg := tenantSrv.next(t)
+		assert.Contains(t, msg, "<p>Body</p><p>Acme Inc.</p>")
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		err := sender.Send(ctx, port.EmailMessage{To: []string{"user@example.com"}, Subject: "Hi", Body: "Body", Tenant: "initech"})
+		assert.ErrorIs(t, err, port.ErrUnknownEmailTenant)
+	})
+}
+
+func TestResolverFromConfig(t *testing.T) {
+	t.Setenv("ACME_SMTP_PASSWORD", "acme-secret")
+
+	r := ResolverFromConfig(config.EmailConfig{
+		Host:     "smtp.example.com",
+		Port:     587,
+		Username: "default",
+		Password: "default-secret",
+		From:     "noreply@example.com",
+		FromName: "Example",
+		Tenants: map[string]config.EmailTenantConfig{
+			"acme": {Host: "smtp.acme.com", Port: 465, Username: "acme", PasswordEnv: "ACME_SMTP_PASSWORD", From: "hello@acme.com"},
+		},
+	})
+
+	p, err := r.Resolve(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, SMTPConfig{Host: "smtp.acme.com", Port: 465, Username: "acme", Password: "acme-secret", From: "hello@acme.com"}, p.SMTP)
+	assert.Equal(t, "Example", p.FromName)
+
+	p, err = r.Resolve(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "default-secret", p.SMTP.Password)
+}
+
+func TestNewSender(t *testing.T) {
+	log := logger.New(logger.Config{Level: "debug", Format: "json"})
+
+	assert.IsType(t, &NoOpSender{}, NewSender(config.EmailConfig{}, log))
+	assert.IsType(t, &SMTPSender{}, NewSender(config.EmailConfig{Enabled: true, Host: "smtp.example.com", Port: 587}, log))
+}
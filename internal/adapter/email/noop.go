@@ -24,6 +24,7 @@ func NewNoOpSender(log *logger.Logger) *NoOpSender {
 func (s *NoOpSender) Send(ctx context.Context, msg port.EmailMessage) error {
 	s.logger.Info("NoOp email send",
 		"to", msg.To,
+		"tenant", msg.Tenant,
 		"cc", msg.CC,
 		"bcc", msg.BCC,
 		"subject", msg.Subject,
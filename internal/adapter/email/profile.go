@@ -0,0 +1,79 @@
+package email
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// Profile is the SMTP transport, sender identity and branding used for one
+// tenant's mail.
+type Profile struct {
+	SMTP       SMTPConfig
+	FromName   string // display name on the From header, e.g. "Acme Support"
+	ReplyTo    string // optional Reply-To address
+	Footer     string // appended to plain-text bodies
+	HTMLFooter string // appended to HTML bodies
+}
+
+// ProfileResolver selects the Profile for a message at send time. tenant is
+// EmailMessage.Tenant; "" means the deployment default. An unknown tenant
+// must return an error wrapping port.ErrUnknownEmailTenant so the mail is not
+// sent under another tenant's brand.
+type ProfileResolver interface {
+	Resolve(ctx context.Context, tenant string) (Profile, error)
+}
+
+// StaticResolver resolves profiles from a fixed table, such as the
+// email.tenants config section.
+type StaticResolver struct {
+	def     Profile
+	tenants map[string]Profile
+}
+
+// NewStaticResolver creates a resolver serving def for the default tenant and
+// tenants by name. A tenant profile without an SMTP host uses def's transport
+// as a whole (host, port and credentials); its other empty fields inherit
+// def's value one by one.
+func NewStaticResolver(def Profile, tenants map[string]Profile) *StaticResolver {
+	merged := make(map[string]Profile, len(tenants))
+	for name, p := range tenants {
+		if p.SMTP.Host == "" {
+			from := p.SMTP.From
+			p.SMTP = def.SMTP
+			if from != "" {
+				p.SMTP.From = from
+			}
+		}
+		if p.SMTP.From == "" {
+			p.SMTP.From = def.SMTP.From
+		}
+		if p.FromName == "" {
+			p.FromName = def.FromName
+		}
+		if p.ReplyTo == "" {
+			p.ReplyTo = def.ReplyTo
+		}
+		if p.Footer == "" {
+			p.Footer = def.Footer
+		}
+		if p.HTMLFooter == "" {
+			p.HTMLFooter = def.HTMLFooter
+		}
+		merged[name] = p
+	}
+	return &StaticResolver{def: def, tenants: merged}
+}
+
+// Resolve returns the profile for tenant.
+func (r *StaticResolver) Resolve(_ context.Context, tenant string) (Profile, error) {
+	if tenant == "" {
+		return r.def, nil
+	}
+	p, ok := r.tenants[tenant]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %q", port.ErrUnknownEmailTenant, tenant)
+	}
+	return p, nil
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"net/mail"
 	"net/smtp"
 	"strings"
 	"time"
@@ -29,13 +30,21 @@ const defaultSMTPTimeout = 30 * time.Second
 
 // SMTPSender implements port.EmailSender using SMTP
 type SMTPSender struct {
-	config SMTPConfig
+	resolver ProfileResolver
 }
 
-// NewSMTPSender creates a new SMTP email sender
+// NewSMTPSender creates a new SMTP email sender that sends every message
+// with cfg
 func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	return NewResolvingSMTPSender(NewStaticResolver(Profile{SMTP: cfg}, nil))
+}
+
+// NewResolvingSMTPSender creates an SMTP email sender that picks the SMTP
+// server, credentials, from address and branding for each message from
+// resolver, keyed by EmailMessage.Tenant
+func NewResolvingSMTPSender(resolver ProfileResolver) *SMTPSender {
 	return &SMTPSender{
-		config: cfg,
+		resolver: resolver,
 	}
 }
 
@@ -47,17 +56,30 @@ func (s *SMTPSender) Send(ctx context.Context, msg port.EmailMessage) error {
 		return fmt.Errorf("email recipient is required")
 	}
 
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	profile, err := s.resolver.Resolve(ctx, msg.Tenant)
+	if err != nil {
+		return fmt.Errorf("failed to resolve email profile: %w", err)
+	}
+	cfg := profile.SMTP
+	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 
 	// Build the email message
 	var body strings.Builder
-	body.WriteString(fmt.Sprintf("From: %s\r\n", s.config.From))
+	from := cfg.From
+	if profile.FromName != "" {
+		from = (&mail.Address{Name: profile.FromName, Address: cfg.From}).String()
+	}
+	body.WriteString(fmt.Sprintf("From: %s\r\n", from))
 	body.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(msg.To, ", ")))
 
 	if len(msg.CC) > 0 {
 		body.WriteString(fmt.Sprintf("Cc: %s\r\n", strings.Join(msg.CC, ", ")))
 	}
 
+	if profile.ReplyTo != "" {
+		body.WriteString(fmt.Sprintf("Reply-To: %s\r\n", profile.ReplyTo))
+	}
+
 	body.WriteString(fmt.Sprintf("Subject: %s\r\n", msg.Subject))
 
 	if msg.IsHTML {
@@ -69,6 +91,13 @@ func (s *SMTPSender) Send(ctx context.Context, msg port.EmailMessage) error {
 
 	body.WriteString("\r\n")
 	body.WriteString(msg.Body)
+	switch {
+	case msg.IsHTML && profile.HTMLFooter != "":
+		body.WriteString(profile.HTMLFooter)
+	case !msg.IsHTML && profile.Footer != "":
+		body.WriteString("\r\n\r\n")
+		body.WriteString(profile.Footer)
+	}
 
 	// Collect all recipients
 	recipients := make([]string, 0, len(msg.To)+len(msg.CC)+len(msg.BCC))
@@ -78,11 +107,11 @@ func (s *SMTPSender) Send(ctx context.Context, msg port.EmailMessage) error {
 
 	// Setup authentication
 	var auth smtp.Auth
-	if s.config.Username != "" {
-		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
 	}
 
-	if err := s.sendMailContext(ctx, addr, auth, s.config.From, recipients, []byte(body.String())); err != nil {
+	if err := sendMailContext(ctx, cfg.Host, addr, auth, cfg.From, recipients, []byte(body.String())); err != nil {
 		return fmt.Errorf("failed to send email via SMTP: %w", err)
 	}
 
@@ -93,7 +122,7 @@ func (s *SMTPSender) Send(ctx context.Context, msg port.EmailMessage) error {
 // It dials with net.Dialer.DialContext and applies any context deadline to the
 // underlying connection, so cancellation and timeouts are honoured throughout
 // the SMTP exchange.
-func (s *SMTPSender) sendMailContext(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, body []byte) error {
+func sendMailContext(ctx context.Context, host, addr string, auth smtp.Auth, from string, to []string, body []byte) error {
 	// Ensure we always have a bounded deadline so a blackhole server cannot
 	// hang the worker for the OS TCP timeout.
 	if _, ok := ctx.Deadline(); !ok {
@@ -126,7 +155,7 @@ func (s *SMTPSender) sendMailContext(ctx context.Context, addr string, auth smtp
 		}
 	}()
 
-	client, err := smtp.NewClient(conn, s.config.Host)
+	client, err := smtp.NewClient(conn, host)
 	if err != nil {
 		_ = conn.Close()
 		return fmt.Errorf("smtp client: %w", err)
@@ -144,7 +173,7 @@ func (s *SMTPSender) sendMailContext(ctx context.Context, addr string, auth smtp
 
 	// Opportunistic STARTTLS when the server advertises it.
 	if ok, _ := client.Extension("STARTTLS"); ok {
-		tlsConfig := &tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}
+		tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
 		if err := client.StartTLS(tlsConfig); err != nil {
 			return fmt.Errorf("starttls: %w", err)
 		}
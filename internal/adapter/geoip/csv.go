@@ -0,0 +1,121 @@
+// Package geoip resolves IP addresses to countries from a local database.
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+var _ port.GeoIP = (*CSVDatabase)(nil)
+
+// CSVDatabase is an in-memory GeoIP database loaded from a CSV file of
+// address ranges, one per line:
+//
+//	start_ip,end_ip,country_code
+//
+// This is the layout of DB-IP's free "IP to Country Lite" database and of
+// similar exports. Extra columns are ignored, IPv4 and IPv6 ranges may be
+// mixed, and lines whose country is "ZZ" or empty are skipped. Lookups are a
+// binary search, so checking a request costs no I/O.
+type CSVDatabase struct {
+	ranges []ipRange
+}
+
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+// LoadCSV reads a CSV database from path
+func LoadCSV(path string) (*CSVDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer f.Close()
+	db, err := ParseCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("GeoIP database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// ParseCSV reads a CSV database from r
+func ParseCSV(r io.Reader) (*CSVDatabase, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	var ranges []ipRange
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 3 {
+			return nil, fmt.Errorf("line %d: want start_ip,end_ip,country_code", line)
+		}
+		start, err1 := netip.ParseAddr(strings.TrimSpace(rec[0]))
+		end, err2 := netip.ParseAddr(strings.TrimSpace(rec[1]))
+		if err1 != nil || err2 != nil {
+			if line == 1 {
+				continue // header row
+			}
+			return nil, fmt.Errorf("line %d: invalid address range %q-%q", line, rec[0], rec[1])
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid address range %s-%s", line, start, end)
+		}
+		country := strings.ToUpper(strings.TrimSpace(rec[2]))
+		if country == "" || country == "ZZ" {
+			continue
+		}
+		ranges = append(ranges, ipRange{start: start, end: end, country: country})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Less(ranges[j].start) })
+	for i := 1; i < len(ranges); i++ {
+		if !ranges[i-1].end.Less(ranges[i].start) {
+			return nil, fmt.Errorf("overlapping ranges %s-%s and %s-%s",
+				ranges[i-1].start, ranges[i-1].end, ranges[i].start, ranges[i].end)
+		}
+	}
+	return &CSVDatabase{ranges: ranges}, nil
+}
+
+// Country implements port.GeoIP
+func (d *CSVDatabase) Country(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	// The first range starting after addr; the one before it is the only
+	// candidate.
+	i := sort.Search(len(d.ranges), func(i int) bool { return addr.Less(d.ranges[i].start) })
+	if i == 0 {
+		return ""
+	}
+	r := d.ranges[i-1]
+	if r.end.Less(addr) {
+		return ""
+	}
+	return r.country
+}
+
+// Len returns the number of ranges loaded
+func (d *CSVDatabase) Len() int {
+	return len(d.ranges)
+}
@@ -0,0 +1,68 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `start_ip,end_ip,country
+1.0.0.0,1.0.0.255,au
+1.0.1.0,1.0.3.255,CN
+"5.62.60.0","5.62.61.255","ZZ"
+203.0.113.0,203.0.113.255,KP,extra
+2001:db8::,2001:db8:ffff:ffff:ffff:ffff:ffff:ffff,ID
+`
+
+func TestParseCSV(t *testing.T) {
+	db, err := ParseCSV(strings.NewReader(sample))
+	require.NoError(t, err)
+	assert.Equal(t, 4, db.Len(), "header and ZZ are skipped")
+
+	for ip, want := range map[string]string{
+		"1.0.0.0":          "AU",
+		"1.0.0.255":        "AU",
+		"1.0.2.7":          "CN",
+		"1.0.4.0":          "",
+		"0.255.255.255":    "",
+		"5.62.60.1":        "",
+		"203.0.113.7":      "KP",
+		"::ffff:1.0.2.7":   "CN",
+		"2001:db8::1":      "ID",
+		"2001:db9::1":      "",
+		"not-an-ip":        "",
+		"":                 "",
+		"255.255.255.255":  "",
+		"2001:db7:ffff::1": "",
+	} {
+		assert.Equal(t, want, db.Country(ip), ip)
+	}
+}
+
+func TestParseCSV_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"too few columns": "1.0.0.0,1.0.0.255\n",
+		"bad address":     "1.0.0.0,1.0.0.255,AU\n1.0.1.0,bogus,CN\n",
+		"reversed range":  "1.0.0.255,1.0.0.0,AU\n",
+		"mixed families":  "1.0.0.0,2001:db8::,AU\n",
+		"overlap":         "1.0.0.0,1.0.1.0,AU\n1.0.1.0,1.0.1.255,CN\n",
+	} {
+		_, err := ParseCSV(strings.NewReader(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "country.csv")
+	require.NoError(t, os.WriteFile(path, []byte(sample), 0o600))
+	db, err := LoadCSV(path)
+	require.NoError(t, err)
+	assert.Equal(t, "AU", db.Country("1.0.0.1"))
+
+	_, err = LoadCSV(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
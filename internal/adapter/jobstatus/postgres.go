@@ -0,0 +1,148 @@
+// Package jobstatus stores the tracked state of background jobs.
+package jobstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore implements port.JobStore on the jobs table
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a job store on pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+var _ port.JobStore = (*PostgresStore)(nil)
+
+// recordJob upserts a job. Only the first write sets created_at; a failure
+// message stays until the job succeeds.
+const recordJob = `
+	INSERT INTO jobs (id, type, queue, status, attempts, max_retry, error, created_at, started_at, finished_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+	ON CONFLICT (id) DO UPDATE SET
+		type = EXCLUDED.type,
+		queue = EXCLUDED.queue,
+		status = EXCLUDED.status,
+		attempts = EXCLUDED.attempts,
+		max_retry = EXCLUDED.max_retry,
+		error = CASE WHEN EXCLUDED.status = 'succeeded' THEN NULL ELSE COALESCE(EXCLUDED.error, jobs.error) END,
+		started_at = COALESCE(EXCLUDED.started_at, jobs.started_at),
+		finished_at = EXCLUDED.finished_at,
+		updated_at = NOW()
+`
+
+// Record implements port.JobRecorder
+func (s *PostgresStore) Record(ctx context.Context, rec port.JobRecord) error {
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	var errMsg *string
+	if rec.Error != "" {
+		errMsg = &rec.Error
+	}
+	_, err := s.pool.Exec(ctx, recordJob,
+		rec.ID, rec.Type, rec.Queue, string(rec.Status), rec.Attempts, rec.MaxRetry,
+		errMsg, createdAt, rec.StartedAt, rec.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record job: %w", err)
+	}
+	return nil
+}
+
+const selectJob = `
+	SELECT id, type, queue, status, attempts, max_retry, error, created_at, started_at, finished_at, updated_at
+	FROM jobs
+`
+
+// Get implements port.JobStore
+func (s *PostgresStore) Get(ctx context.Context, id string) (*port.JobRecord, error) {
+	rec, err := scanJob(s.pool.QueryRow(ctx, selectJob+" WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, port.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return &rec, nil
+}
+
+// List implements port.JobStore
+func (s *PostgresStore) List(ctx context.Context, filter port.JobFilter) ([]port.JobRecord, error) {
+	query := selectJob + " WHERE 1=1"
+	args := []any{}
+	argIndex := 1
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, st := range filter.Statuses {
+			statuses[i] = string(st)
+		}
+		query += fmt.Sprintf(" AND status = ANY($%d)", argIndex)
+		args = append(args, statuses)
+		argIndex++
+	}
+
+	if len(filter.Types) > 0 {
+		query += fmt.Sprintf(" AND type = ANY($%d)", argIndex)
+		args = append(args, filter.Types)
+		argIndex++
+	}
+
+	if filter.Cursor != "" {
+		query += fmt.Sprintf(" AND (created_at, id) < (SELECT created_at, id FROM jobs WHERE id = $%d)", argIndex)
+		args = append(args, filter.Cursor)
+		argIndex++
+	}
+
+	query += " ORDER BY created_at DESC, id DESC"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	query += fmt.Sprintf(" LIMIT $%d", argIndex)
+	args = append(args, limit)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []port.JobRecord
+	for rows.Next() {
+		rec, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func scanJob(row pgx.Row) (port.JobRecord, error) {
+	var rec port.JobRecord
+	var status string
+	var errMsg *string
+	err := row.Scan(&rec.ID, &rec.Type, &rec.Queue, &status, &rec.Attempts, &rec.MaxRetry,
+		&errMsg, &rec.CreatedAt, &rec.StartedAt, &rec.FinishedAt, &rec.UpdatedAt)
+	if err != nil {
+		return port.JobRecord{}, err
+	}
+	rec.Status = port.JobStatus(status)
+	if errMsg != nil {
+		rec.Error = *errMsg
+	}
+	return rec, nil
+}
@@ -0,0 +1,102 @@
+//go:build integration
+
+package jobstatus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/jobstatus"
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+	store := jobstatus.NewPostgresStore(pool)
+
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	started := createdAt.Add(time.Minute)
+	finished := started.Add(time.Second)
+
+	t.Run("Record moves a job through its states", func(t *testing.T) {
+		rec := port.JobRecord{ID: "job-1", Type: "email.send", Queue: "jobs", Status: port.JobStatusQueued, MaxRetry: 3, CreatedAt: createdAt}
+		require.NoError(t, store.Record(ctx, rec))
+
+		rec.Status, rec.Attempts, rec.StartedAt = port.JobStatusRunning, 1, &started
+		require.NoError(t, store.Record(ctx, rec))
+
+		rec.Status, rec.Error, rec.StartedAt = port.JobStatusQueued, "smtp timeout", nil
+		rec.CreatedAt = time.Now() // ignored after the first write
+		require.NoError(t, store.Record(ctx, rec))
+
+		got, err := store.Get(ctx, "job-1")
+		require.NoError(t, err)
+		assert.Equal(t, port.JobStatusQueued, got.Status)
+		assert.Equal(t, "smtp timeout", got.Error)
+		assert.True(t, got.CreatedAt.Equal(createdAt))
+		require.NotNil(t, got.StartedAt, "a nil StartedAt keeps the stored one")
+		assert.True(t, got.StartedAt.Equal(started))
+
+		rec.Status, rec.Attempts, rec.Error = port.JobStatusRunning, 2, ""
+		require.NoError(t, store.Record(ctx, rec))
+		got, err = store.Get(ctx, "job-1")
+		require.NoError(t, err)
+		assert.Equal(t, "smtp timeout", got.Error, "an empty error keeps the last failure")
+
+		rec.Status, rec.FinishedAt = port.JobStatusSucceeded, &finished
+		require.NoError(t, store.Record(ctx, rec))
+		got, err = store.Get(ctx, "job-1")
+		require.NoError(t, err)
+		assert.Equal(t, port.JobStatusSucceeded, got.Status)
+		assert.Equal(t, 2, got.Attempts)
+		assert.Empty(t, got.Error)
+		require.NotNil(t, got.FinishedAt)
+		assert.True(t, got.FinishedAt.Equal(finished))
+	})
+
+	t.Run("Get unknown job", func(t *testing.T) {
+		_, err := store.Get(ctx, "missing")
+		assert.ErrorIs(t, err, port.ErrJobNotFound)
+	})
+
+	t.Run("List filters and pages newest first", func(t *testing.T) {
+		for i, r := range []port.JobRecord{
+			{ID: "job-2", Type: "audit.cleanup", Status: port.JobStatusFailed, Error: "boom"},
+			{ID: "job-3", Type: "email.send", Status: port.JobStatusDead, Error: "bounced"},
+			{ID: "job-4", Type: "email.send", Status: port.JobStatusQueued},
+		} {
+			r.CreatedAt = createdAt.Add(time.Duration(i+1) * time.Hour)
+			require.NoError(t, store.Record(ctx, r))
+		}
+
+		ids := func(f port.JobFilter) []string {
+			t.Helper()
+			recs, err := store.List(ctx, f)
+			require.NoError(t, err)
+			out := make([]string, 0, len(recs))
+			for _, r := range recs {
+				out = append(out, r.ID)
+			}
+			return out
+		}
+
+		assert.Equal(t, []string{"job-4", "job-3", "job-2", "job-1"}, ids(port.JobFilter{}))
+		assert.Equal(t, []string{"job-4", "job-3", "job-1"}, ids(port.JobFilter{Types: []string{"email.send"}}))
+		assert.Equal(t, []string{"job-3", "job-2"}, ids(port.JobFilter{Statuses: []port.JobStatus{port.JobStatusFailed, port.JobStatusDead}}))
+		assert.Equal(t, []string{"job-4", "job-3"}, ids(port.JobFilter{Limit: 2}))
+		assert.Equal(t, []string{"job-2", "job-1"}, ids(port.JobFilter{Limit: 2, Cursor: "job-3"}))
+	})
+}
@@ -0,0 +1,63 @@
+// Package oauth implements port.OAuthProvider for Google, GitHub and any
+// OpenID Connect issuer.
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"golang.org/x/oauth2"
+)
+
+// googleIssuer is Google's OpenID Connect issuer
+const googleIssuer = "https://accounts.google.com"
+
+// httpTimeout bounds every call to a provider: discovery, key fetches, code
+// exchange and profile lookups.
+const httpTimeout = 10 * time.Second
+
+// New builds the providers in cfg, keyed by name. OpenID Connect providers
+// (google, oidc) fetch their issuer's discovery document, so New fails when
+// an issuer is unreachable. Client secrets are read from the env vars named
+// by client_secret_env.
+func New(cfg config.OAuthConfig) (map[string]port.OAuthProvider, error) {
+	client := &http.Client{Timeout: httpTimeout}
+	providers := make(map[string]port.OAuthProvider, len(cfg.Providers))
+	for name, p := range cfg.Providers {
+		oc := oauth2.Config{
+			ClientID:     p.ClientID,
+			ClientSecret: os.Getenv(p.ClientSecretEnv),
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}
+		var (
+			provider port.OAuthProvider
+			err      error
+		)
+		switch p.Type {
+		case "google":
+			provider, err = NewOIDCProvider(googleIssuer, oc, client)
+		case "oidc":
+			provider, err = NewOIDCProvider(p.IssuerURL, oc, client)
+		case "github":
+			provider = NewGitHubProvider(oc, client)
+		default:
+			err = fmt.Errorf("unknown type %q", p.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("oauth provider %s: %w", name, err)
+		}
+		providers[name] = provider
+	}
+	return providers, nil
+}
+
+// withClient makes oauth2 use client for the code exchange
+func withClient(ctx context.Context, client *http.Client) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
@@ -0,0 +1,171 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// fakeGitHub serves the token endpoint and the two API calls
+func fakeGitHub(t *testing.T, wantVerifier string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "the-code", r.PostForm.Get("code"))
+		assert.Equal(t, wantVerifier, r.PostForm.Get("code_verifier"))
+		writeJSON(w, map[string]any{"access_token": "gh-token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		writeJSON(w, map[string]any{"id": 583231, "login": "octocat", "name": ""})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, []map[string]any{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": true},
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGitHubProvider(t *testing.T) {
+	verifier := oauth2.GenerateVerifier()
+	srv := fakeGitHub(t, verifier)
+	defer srv.Close()
+
+	p := NewGitHubProvider(oauth2.Config{ClientID: "id", ClientSecret: "secret", RedirectURL: "https://api.example.com/cb"}, srv.Client())
+	p.config.Endpoint = oauth2.Endpoint{AuthURL: srv.URL + "/login/oauth/authorize", TokenURL: srv.URL + "/login/oauth/access_token"}
+	p.apiBase = srv.URL
+
+	consent, err := url.Parse(p.AuthCodeURL("the-state", "unused", verifier))
+	require.NoError(t, err)
+	q := consent.Query()
+	assert.Equal(t, "the-state", q.Get("state"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, "read:user user:email", q.Get("scope"))
+
+	identity, err := p.Exchange(context.Background(), "the-code", "unused", verifier)
+	require.NoError(t, err)
+	assert.Equal(t, "583231", identity.Subject)
+	assert.Equal(t, "octo@example.com", identity.Email, "the primary address is used")
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "octocat", identity.Name, "the login stands in for an empty name")
+}
+
+// fakeIssuer is an OpenID Connect issuer signing ID tokens with an RSA key
+type fakeIssuer struct {
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	f := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                                f.srv.URL,
+			"authorization_endpoint":                f.srv.URL + "/authorize",
+			"token_endpoint":                        f.srv.URL + "/token",
+			"jwks_uri":                              f.srv.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "k1", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, _ *http.Request) {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: "k1"}}, nil)
+		require.NoError(t, err)
+		payload, err := json.Marshal(f.claims)
+		require.NoError(t, err)
+		jws, err := signer.Sign(payload)
+		require.NoError(t, err)
+		idToken, err := jws.CompactSerialize()
+		require.NoError(t, err)
+		writeJSON(w, map[string]any{"access_token": "at", "token_type": "bearer", "id_token": idToken})
+	})
+	f.srv = httptest.NewServer(mux)
+	return f
+}
+
+func (f *fakeIssuer) idClaims(nonce string) map[string]any {
+	return map[string]any{
+		"iss":            f.srv.URL,
+		"aud":            "client-id",
+		"sub":            "248289761001",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane Doe",
+	}
+}
+
+func TestOIDCProvider(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	defer issuer.srv.Close()
+
+	p, err := NewOIDCProvider(issuer.srv.URL, oauth2.Config{ClientID: "client-id", ClientSecret: "secret", RedirectURL: "https://api.example.com/cb"}, issuer.srv.Client())
+	require.NoError(t, err)
+
+	verifier := oauth2.GenerateVerifier()
+	consent, err := url.Parse(p.AuthCodeURL("the-state", "the-nonce", verifier))
+	require.NoError(t, err)
+	assert.Equal(t, issuer.srv.URL+"/authorize", consent.Scheme+"://"+consent.Host+consent.Path)
+	assert.Equal(t, "the-nonce", consent.Query().Get("nonce"))
+	assert.Equal(t, "openid email profile", consent.Query().Get("scope"))
+
+	t.Run("valid id token", func(t *testing.T) {
+		issuer.claims = issuer.idClaims("the-nonce")
+		identity, err := p.Exchange(context.Background(), "code", "the-nonce", verifier)
+		require.NoError(t, err)
+		assert.Equal(t, "248289761001", identity.Subject)
+		assert.Equal(t, "jane@example.com", identity.Email)
+		assert.True(t, identity.EmailVerified)
+		assert.Equal(t, "Jane Doe", identity.Name)
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		issuer.claims = issuer.idClaims("another-nonce")
+		_, err := p.Exchange(context.Background(), "code", "the-nonce", verifier)
+		assert.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("token for another client", func(t *testing.T) {
+		issuer.claims = issuer.idClaims("the-nonce")
+		issuer.claims["aud"] = "someone-else"
+		_, err := p.Exchange(context.Background(), "code", "the-nonce", verifier)
+		assert.ErrorContains(t, err, "verify id_token")
+	})
+}
+
+func TestOIDCProvider_UnreachableIssuer(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := NewOIDCProvider(srv.URL, oauth2.Config{ClientID: "id"}, srv.Client())
+	assert.ErrorContains(t, err, "discover")
+}
@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider signs users in with an OpenID Connect issuer. The identity
+// comes from the ID token, whose signature, issuer, audience, expiry and
+// nonce are verified.
+type OIDCProvider struct {
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	client   *http.Client
+}
+
+// NewOIDCProvider discovers issuer's endpoints and signing keys. Without
+// scopes in cfg it asks for openid, email and profile.
+func NewOIDCProvider(issuer string, cfg oauth2.Config, client *http.Client) (*OIDCProvider, error) {
+	// The context is kept by the provider for fetching signing keys later,
+	// so it must not be one that gets cancelled.
+	provider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), client), issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", issuer, err)
+	}
+	cfg.Endpoint = provider.Endpoint()
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	return &OIDCProvider{
+		config:   cfg,
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		client:   client,
+	}, nil
+}
+
+// AuthCodeURL returns the issuer's consent page
+func (p *OIDCProvider) AuthCodeURL(state, nonce, verifier string) string {
+	return p.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange redeems code and verifies the ID token that comes with the
+// access token.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce, verifier string) (*port.ExternalIdentity, error) {
+	ctx = withClient(ctx, p.client)
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce does not match")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("decode id_token claims: %w", err)
+	}
+	return &port.ExternalIdentity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+)
+
+// Connect connects to the broker cfg enables: RabbitMQ or NATS JetStream
+// (see config.Config.QueueBroker).
+func Connect(cfg *config.Config) (port.Queue, error) {
+	switch cfg.QueueBroker() {
+	case "rabbitmq":
+		return NewRabbitMQWithOptions(cfg.RabbitMQ.URL, Options{
+			PrefetchCount: cfg.RabbitMQ.PrefetchCount,
+		})
+	case "nats":
+		return NewNATS(cfg.NATS.URL, NATSOptions{
+			StreamPrefix:  cfg.NATS.StreamPrefix,
+			PrefetchCount: cfg.NATS.PrefetchCount,
+			AckWait:       time.Duration(cfg.NATS.AckWait),
+		})
+	}
+	return nil, errors.New("queue: no broker enabled")
+}
+
+// OptionsFromConfig returns the queue policies the job queues are declared
+// with. Dead letters go to cfg.DeadLetterQueue, through
+// cfg.QueuePolicy.DeadLetterExchange when it is set and the default exchange
+// otherwise.
+func OptionsFromConfig(cfg config.WorkerConfig) []port.QueueOption {
+	qp := cfg.QueuePolicy
+	var opts []port.QueueOption
+	if qp.MessageTTL > 0 {
+		opts = append(opts, port.WithMessageTTL(time.Duration(qp.MessageTTL)))
+	}
+	if qp.MaxLength > 0 || qp.Overflow != "" {
+		opts = append(opts, port.WithMaxLength(qp.MaxLength, qp.Overflow))
+	}
+	if cfg.DeadLetterQueue != "" {
+		opts = append(opts, port.WithDeadLetter(qp.DeadLetterExchange, cfg.DeadLetterQueue))
+	}
+	if qp.Lazy {
+		opts = append(opts, port.WithLazyMode())
+	}
+	if qp.Priority {
+		opts = append(opts, port.WithMaxPriority(uint8(worker.MaxPriority)))
+	}
+	return opts
+}
+
+// DeclareTopology declares the dead-letter queue, its exchange and binding
+// when configured, then each of queues with OptionsFromConfig. The
+// dead-letter queue comes first so nothing is dead-lettered into a missing
+// queue.
+func DeclareTopology(ctx context.Context, q port.Queue, cfg config.WorkerConfig, queues ...string) error {
+	if dlq := cfg.DeadLetterQueue; dlq != "" {
+		if err := q.DeclareQueue(ctx, dlq, true); err != nil {
+			return fmt.Errorf("declare dead-letter queue: %w", err)
+		}
+		if dlx := cfg.QueuePolicy.DeadLetterExchange; dlx != "" {
+			if err := q.DeclareExchange(ctx, dlx, "direct", true); err != nil {
+				return fmt.Errorf("declare dead-letter exchange: %w", err)
+			}
+			if err := q.BindQueue(ctx, dlq, dlx, dlq); err != nil {
+				return fmt.Errorf("bind dead-letter queue: %w", err)
+			}
+		}
+	}
+
+	opts := OptionsFromConfig(cfg)
+	for _, name := range queues {
+		if name == "" {
+			continue // an empty name would declare a server-named queue
+		}
+		if err := q.DeclareQueue(ctx, name, true, opts...); err != nil {
+			return fmt.Errorf("declare queue: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topologyQueue records declarations in order.
+type topologyQueue struct {
+	NoOpQueue
+	calls   []string
+	options map[string]port.QueueOptions
+	failOn  string
+}
+
+func (q *topologyQueue) DeclareQueue(_ context.Context, name string, _ bool, opts ...port.QueueOption) error {
+	if q.options == nil {
+		q.options = make(map[string]port.QueueOptions)
+	}
+	q.calls = append(q.calls, "queue "+name)
+	q.options[name] = port.ApplyQueueOptions(opts...)
+	if name == q.failOn {
+		return errors.New("precondition failed")
+	}
+	return nil
+}
+
+func (q *topologyQueue) DeclareExchange(_ context.Context, name, kind string, _ bool) error {
+	q.calls = append(q.calls, fmt.Sprintf("exchange %s %s", name, kind))
+	return nil
+}
+
+func (q *topologyQueue) BindQueue(_ context.Context, queue, exchange, routingKey string) error {
+	q.calls = append(q.calls, fmt.Sprintf("bind %s %s %s", queue, exchange, routingKey))
+	return nil
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	assert.Empty(t, OptionsFromConfig(config.WorkerConfig{QueueName: "jobs"}))
+
+	cfg := config.WorkerConfig{
+		DeadLetterQueue: "jobs.dlq",
+		QueuePolicy: config.QueuePolicyConfig{
+			MessageTTL: config.Duration(time.Hour),
+			MaxLength:  500,
+			Overflow:   "reject-publish-dlx",
+			Lazy:       true,
+			Priority:   true,
+		},
+	}
+	assert.Equal(t, port.QueueOptions{
+		MessageTTL:           time.Hour,
+		MaxLength:            500,
+		Overflow:             "reject-publish-dlx",
+		DeadLetterRoutingKey: "jobs.dlq",
+		Lazy:                 true,
+		MaxPriority:          9,
+	}, port.ApplyQueueOptions(OptionsFromConfig(cfg)...))
+}
+
+func TestDeclareTopology(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default exchange", func(t *testing.T) {
+		q := &topologyQueue{}
+		cfg := config.WorkerConfig{DeadLetterQueue: "jobs.dlq"}
+		require.NoError(t, DeclareTopology(ctx, q, cfg, "jobs", "emails"))
+		assert.Equal(t, []string{"queue jobs.dlq", "queue jobs", "queue emails"}, q.calls)
+		assert.False(t, q.options["jobs.dlq"].DeadLetters(), "the dead-letter queue has no policies")
+		assert.Equal(t, "jobs.dlq", q.options["emails"].DeadLetterRoutingKey)
+	})
+
+	t.Run("dead-letter exchange", func(t *testing.T) {
+		q := &topologyQueue{}
+		cfg := config.WorkerConfig{
+			DeadLetterQueue: "jobs.dlq",
+			QueuePolicy:     config.QueuePolicyConfig{DeadLetterExchange: "jobs.dlx"},
+		}
+		require.NoError(t, DeclareTopology(ctx, q, cfg, "jobs"))
+		assert.Equal(t, []string{
+			"queue jobs.dlq",
+			"exchange jobs.dlx direct",
+			"bind jobs.dlq jobs.dlx jobs.dlq",
+			"queue jobs",
+		}, q.calls)
+		assert.Equal(t, "jobs.dlx", q.options["jobs"].DeadLetterExchange)
+	})
+
+	t.Run("no dead-letter queue", func(t *testing.T) {
+		q := &topologyQueue{}
+		require.NoError(t, DeclareTopology(ctx, q, config.WorkerConfig{}, "jobs"))
+		assert.Equal(t, []string{"queue jobs"}, q.calls)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		q := &topologyQueue{failOn: "jobs"}
+		err := DeclareTopology(ctx, q, config.WorkerConfig{}, "jobs", "emails")
+		assert.ErrorContains(t, err, "precondition failed")
+		assert.Equal(t, []string{"queue jobs"}, q.calls)
+	})
+}
@@ -0,0 +1,332 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// Mapping onto JetStream:
+//   - Each queue is a work-queue stream named <prefix>_<queue> (dots and
+//     other characters invalid in stream names become underscores) that
+//     holds the subject <prefix>.<queue>. A message is removed once it is
+//     acknowledged, as on an AMQP queue.
+//   - Publish sends to <prefix>.<routingKey>. There are no exchanges, so the
+//     exchange is ignored and DeclareExchange and BindQueue do nothing.
+//   - Consume pulls from one durable consumer per queue, shared by every
+//     worker, so each message goes to one of them. A handler error naks the
+//     message for redelivery, like an AMQP nack with requeue.
+//   - A message not acknowledged within AckWait is redelivered, so AckWait
+//     must outlast the slowest handler.
+
+// Default values for NATS tunables not present in config.
+const (
+	defaultNATSStreamPrefix = "goscratch"
+	defaultNATSAckWait      = 6 * time.Minute
+	// natsConsumerName is the durable consumer every worker of a queue
+	// shares
+	natsConsumerName = "workers"
+	// natsContentType carries the message's content type, which JetStream
+	// has no field for
+	natsContentType = "Content-Type"
+)
+
+// NATSOptions tunes the NATS JetStream adapter. Zero values fall back to
+// defaults.
+type NATSOptions struct {
+	// StreamPrefix namespaces the adapter's streams and subjects
+	StreamPrefix string
+	// PrefetchCount caps the messages each Consume call buffers
+	PrefetchCount int
+	// AckWait is how long a delivered message may go unacknowledged before
+	// it is redelivered
+	AckWait time.Duration
+}
+
+func (o NATSOptions) withDefaults() NATSOptions {
+	if o.StreamPrefix == "" {
+		o.StreamPrefix = defaultNATSStreamPrefix
+	}
+	if o.PrefetchCount <= 0 {
+		o.PrefetchCount = defaultPrefetchCount
+	}
+	if o.AckWait <= 0 {
+		o.AckWait = defaultNATSAckWait
+	}
+	return o
+}
+
+// NATS implements port.Queue using NATS JetStream.
+type NATS struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+	opts NATSOptions
+
+	mu       sync.Mutex
+	consumes []jetstream.ConsumeContext
+	closed   bool
+}
+
+var _ port.Queue = (*NATS)(nil)
+
+// NewNATS connects to the NATS server at url, reconnecting on its own when
+// the connection drops.
+func NewNATS(url string, opts NATSOptions) (*NATS, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("goscratch"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				slog.Warn("nats disconnected", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			slog.Info("nats reconnected", "url", c.ConnectedUrlRedacted())
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open jetstream context: %w", err)
+	}
+	return &NATS{conn: conn, js: js, opts: opts.withDefaults()}, nil
+}
+
+// streamName returns the stream holding queue
+func (q *NATS) streamName(queue string) string {
+	return q.opts.StreamPrefix + "_" + sanitizeStreamName(queue)
+}
+
+// subject returns the subject messages for queue are published on
+func (q *NATS) subject(queue string) string {
+	return q.opts.StreamPrefix + "." + queue
+}
+
+// sanitizeStreamName replaces the characters stream names may not contain
+func sanitizeStreamName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', '/', '\\', ' ', '\t':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func (q *NATS) Publish(ctx context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	o := port.ApplyPublishOptions(opts...)
+	msg := nats.NewMsg(q.subject(routingKey))
+	msg.Data = body
+	for k, v := range o.Headers {
+		msg.Header.Set(k, fmt.Sprint(v))
+	}
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	msg.Header.Set(natsContentType, contentType)
+	// JetStream drops a message whose ID it has seen within the stream's
+	// duplicate window
+	if o.MessageID != "" {
+		msg.Header.Set(jetstream.MsgIDHeader, o.MessageID)
+	}
+
+	if _, err := q.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("nats publish to %s: %w", msg.Subject, err)
+	}
+	return nil
+}
+
+func (q *NATS) PublishJSON(ctx context.Context, exchange, routingKey string, message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return q.Publish(ctx, exchange, routingKey, body, port.WithPublishContentType("application/json"))
+}
+
+// Consume binds the queue's durable consumer and delivers its messages to
+// handler until ctx is done. The consumer is created when missing, so the
+// queue must have been declared.
+func (q *NATS) Consume(ctx context.Context, queueName string, handler func(msg port.Message) error) error {
+	cons, err := q.js.CreateOrUpdateConsumer(ctx, q.streamName(queueName), jetstream.ConsumerConfig{
+		Durable:       natsConsumerName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       q.opts.AckWait,
+		FilterSubject: q.subject(queueName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register consumer: %w", err)
+	}
+
+	cc, err := cons.Consume(func(m jetstream.Msg) {
+		deliverNATS(m, handler)
+	},
+		jetstream.PullMaxMessages(q.opts.PrefetchCount),
+		jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+			slog.Warn("nats consumer error", "queue", queueName, "error", err)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start consumer: %w", err)
+	}
+
+	q.mu.Lock()
+	q.consumes = append(q.consumes, cc)
+	q.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			cc.Stop()
+		case <-cc.Closed():
+		}
+	}()
+	return nil
+}
+
+// deliverNATS hands m to handler, acknowledging it on success and naking
+// it for redelivery on error.
+func deliverNATS(m jetstream.Msg, handler func(msg port.Message) error) {
+	if err := handler(natsToMessage(m)); err != nil {
+		_ = m.Nak()
+		return
+	}
+	_ = m.Ack()
+}
+
+// natsToMessage converts a JetStream message for a Consume handler.
+func natsToMessage(m jetstream.Msg) port.Message {
+	msg := port.Message{Body: m.Data()}
+	if h := m.Headers(); len(h) > 0 {
+		msg.ID = h.Get(jetstream.MsgIDHeader)
+		msg.ContentType = h.Get(natsContentType)
+		headers := make(map[string]any, len(h))
+		for k := range h {
+			if k == natsContentType || k == jetstream.MsgIDHeader {
+				continue
+			}
+			headers[k] = h.Get(k)
+		}
+		if len(headers) > 0 {
+			msg.Headers = headers
+		}
+	}
+	if md, err := m.Metadata(); err == nil {
+		msg.Redelivered = md.NumDelivered > 1
+	}
+	return msg
+}
+
+// Ping checks the connection with a JetStream account info request, which
+// reads but does not change server state.
+func (q *NATS) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !q.conn.IsConnected() {
+		return fmt.Errorf("queue ping: nats %s", q.conn.Status())
+	}
+	if _, err := q.js.AccountInfo(ctx); err != nil {
+		return fmt.Errorf("queue ping: %w", err)
+	}
+	return nil
+}
+
+// DeclareQueue creates or updates the queue's stream. Unlike RabbitMQ,
+// JetStream changes the limits of an existing stream in place.
+func (q *NATS) DeclareQueue(ctx context.Context, name string, durable bool, opts ...port.QueueOption) error {
+	cfg, err := q.streamConfig(name, durable, port.ApplyQueueOptions(opts...))
+	if err != nil {
+		return err
+	}
+	if _, err := q.js.CreateOrUpdateStream(ctx, cfg); err != nil {
+		return fmt.Errorf("nats declare stream %s: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// streamConfig maps a queue and its policies onto a work-queue stream. Dead
+// letters and priorities have no JetStream equivalent and are rejected;
+// lazy mode and expiry are ignored.
+func (q *NATS) streamConfig(name string, durable bool, o port.QueueOptions) (jetstream.StreamConfig, error) {
+	if name == "" {
+		return jetstream.StreamConfig{}, errors.New("nats: queue name is required")
+	}
+	if o.DeadLetters() {
+		return jetstream.StreamConfig{}, errors.New("nats: dead-letter queue policies are not supported")
+	}
+	if o.MaxPriority > 0 {
+		return jetstream.StreamConfig{}, errors.New("nats: priority queues are not supported")
+	}
+
+	cfg := jetstream.StreamConfig{
+		Name:      q.streamName(name),
+		Subjects:  []string{q.subject(name)},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+		MaxAge:    o.MessageTTL,
+		MaxMsgs:   -1,
+	}
+	if !durable {
+		cfg.Storage = jetstream.MemoryStorage
+	}
+	if o.MaxLength > 0 {
+		cfg.MaxMsgs = int64(o.MaxLength)
+	}
+	switch o.Overflow {
+	case "", "drop-head":
+		cfg.Discard = jetstream.DiscardOld
+	case "reject-publish":
+		cfg.Discard = jetstream.DiscardNew
+	default:
+		return jetstream.StreamConfig{}, fmt.Errorf("nats: overflow %q is not supported", o.Overflow)
+	}
+	return cfg, nil
+}
+
+// DeclareExchange does nothing: JetStream has no exchanges.
+func (q *NATS) DeclareExchange(ctx context.Context, name, kind string, durable bool) error {
+	return nil
+}
+
+// BindQueue does nothing: a queue's stream holds its own subject.
+func (q *NATS) BindQueue(ctx context.Context, queue, exchange, routingKey string) error {
+	return nil
+}
+
+// Close stops the consumers and drains the connection, waiting for
+// handlers of messages already delivered.
+func (q *NATS) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	consumes := q.consumes
+	q.consumes = nil
+	q.mu.Unlock()
+
+	for _, cc := range consumes {
+		cc.Stop()
+	}
+	if err := q.conn.Drain(); err != nil {
+		q.conn.Close()
+		return fmt.Errorf("nats drain: %w", err)
+	}
+	return nil
+}
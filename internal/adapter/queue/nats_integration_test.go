@@ -0,0 +1,97 @@
+//go:build integration
+
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/adapter/queue"
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func TestNATS(t *testing.T) {
+	ctx := context.Background()
+
+	url, cleanup, err := testutil.StartNATS(ctx)
+	require.NoError(t, err)
+	defer cleanup()
+
+	q, err := queue.NewNATS(url, queue.NATSOptions{StreamPrefix: "test"})
+	require.NoError(t, err)
+	defer q.Close()
+
+	require.NoError(t, q.Ping(ctx))
+	require.NoError(t, q.DeclareQueue(ctx, "jobs", true, port.WithMessageTTL(time.Hour)))
+	require.NoError(t, q.DeclareQueue(ctx, "jobs", true, port.WithMessageTTL(time.Hour)), "declaring again is a no-op")
+
+	require.NoError(t, q.Publish(ctx, "", "jobs", []byte("first"),
+		port.WithPublishContentType("application/msgpack"),
+		port.WithPublishHeaders(map[string]any{"x-tenant": "acme"}),
+	))
+	require.NoError(t, q.Publish(ctx, "", "jobs", []byte("second")))
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	received := make(chan port.Message, 10)
+	var failed atomic.Bool
+	require.NoError(t, q.Consume(consumeCtx, "jobs", func(msg port.Message) error {
+		received <- msg
+		// The second message fails once and is redelivered
+		if string(msg.Body) == "second" && failed.CompareAndSwap(false, true) {
+			return errors.New("try again")
+		}
+		return nil
+	}))
+
+	var got []port.Message
+	for len(got) < 3 {
+		select {
+		case msg := <-received:
+			got = append(got, msg)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("received %d of 3 deliveries", len(got))
+		}
+	}
+
+	assert.Equal(t, "first", string(got[0].Body))
+	assert.Equal(t, "application/msgpack", got[0].ContentType)
+	assert.Equal(t, "acme", got[0].Headers["x-tenant"])
+	assert.Equal(t, "second", string(got[1].Body))
+	assert.False(t, got[1].Redelivered)
+	assert.Equal(t, "second", string(got[2].Body))
+	assert.True(t, got[2].Redelivered, "a failed message is redelivered")
+
+	t.Run("a second consumer shares the queue", func(t *testing.T) {
+		other, err := queue.NewNATS(url, queue.NATSOptions{StreamPrefix: "test"})
+		require.NoError(t, err)
+		defer other.Close()
+		require.NoError(t, other.Consume(consumeCtx, "jobs", func(msg port.Message) error {
+			received <- msg
+			return nil
+		}))
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, q.Publish(ctx, "", "jobs", []byte("job")))
+		}
+		for i := 0; i < 10; i++ {
+			select {
+			case <-received:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("received %d of 10 jobs", i)
+			}
+		}
+		select {
+		case msg := <-received:
+			t.Fatalf("job delivered twice: %q", msg.Body)
+		case <-time.After(500 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// fakeNATSMsg is a jetstream.Msg recording how it was settled
+type fakeNATSMsg struct {
+	jetstream.Msg
+	data      []byte
+	headers   nats.Header
+	delivered uint64
+	acked     bool
+	naked     bool
+}
+
+func (m *fakeNATSMsg) Data() []byte         { return m.data }
+func (m *fakeNATSMsg) Headers() nats.Header { return m.headers }
+func (m *fakeNATSMsg) Ack() error           { m.acked = true; return nil }
+func (m *fakeNATSMsg) Nak() error           { m.naked = true; return nil }
+func (m *fakeNATSMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.delivered}, nil
+}
+
+func TestNATSOptions_Defaults(t *testing.T) {
+	o := NATSOptions{}.withDefaults()
+	assert.Equal(t, "goscratch", o.StreamPrefix)
+	assert.Equal(t, defaultPrefetchCount, o.PrefetchCount)
+	assert.Equal(t, 6*time.Minute, o.AckWait)
+}
+
+func TestNATS_Naming(t *testing.T) {
+	q := &NATS{opts: NATSOptions{StreamPrefix: "app"}.withDefaults()}
+	assert.Equal(t, "app_jobs", q.streamName("jobs"))
+	assert.Equal(t, "app_jobs_dlq", q.streamName("jobs.dlq"))
+	assert.Equal(t, "app.jobs.dlq", q.subject("jobs.dlq"))
+}
+
+func TestNATS_StreamConfig(t *testing.T) {
+	q := &NATS{opts: NATSOptions{}.withDefaults()}
+
+	cfg, err := q.streamConfig("jobs", true, port.QueueOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, jetstream.StreamConfig{
+		Name:      "goscratch_jobs",
+		Subjects:  []string{"goscratch.jobs"},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+		MaxMsgs:   -1,
+		Discard:   jetstream.DiscardOld,
+	}, cfg)
+
+	cfg, err = q.streamConfig("jobs", false, port.ApplyQueueOptions(
+		port.WithMessageTTL(time.Hour),
+		port.WithMaxLength(500, "reject-publish"),
+		port.WithLazyMode(),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, jetstream.MemoryStorage, cfg.Storage)
+	assert.Equal(t, time.Hour, cfg.MaxAge)
+	assert.Equal(t, int64(500), cfg.MaxMsgs)
+	assert.Equal(t, jetstream.DiscardNew, cfg.Discard)
+
+	for name, opt := range map[string]port.QueueOption{
+		"dead letters":       port.WithDeadLetter("", "jobs.dead"),
+		"priority":           port.WithMaxPriority(9),
+		"reject-publish-dlx": port.WithMaxLength(10, "reject-publish-dlx"),
+	} {
+		_, err := q.streamConfig("jobs", true, port.ApplyQueueOptions(opt))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNATS_Deliver(t *testing.T) {
+	headers := nats.Header{}
+	headers.Set(natsContentType, "application/msgpack")
+	headers.Set(jetstream.MsgIDHeader, "m-1")
+	headers.Set("x-failed-queue", "jobs")
+
+	t.Run("acknowledges a handled message", func(t *testing.T) {
+		m := &fakeNATSMsg{data: []byte("body"), headers: headers, delivered: 2}
+		var got port.Message
+		deliverNATS(m, func(msg port.Message) error {
+			got = msg
+			return nil
+		})
+		assert.True(t, m.acked)
+		assert.False(t, m.naked)
+		assert.Equal(t, port.Message{
+			ID:          "m-1",
+			Body:        []byte("body"),
+			ContentType: "application/msgpack",
+			Headers:     map[string]any{"x-failed-queue": "jobs"},
+			Redelivered: true,
+		}, got)
+	})
+
+	t.Run("naks a failed message", func(t *testing.T) {
+		m := &fakeNATSMsg{data: []byte("body"), delivered: 1}
+		deliverNATS(m, func(msg port.Message) error {
+			assert.False(t, msg.Redelivered)
+			assert.Empty(t, msg.ContentType)
+			return errors.New("boom")
+		})
+		assert.True(t, m.naked)
+		assert.False(t, m.acked)
+	})
+}
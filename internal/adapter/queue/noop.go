@@ -1,6 +1,11 @@
 package queue
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
 
 // NoOpQueue implements port.Queue as a no-op
 // Used when RabbitMQ is disabled
@@ -11,7 +16,11 @@ func NewNoOpQueue() *NoOpQueue {
 	return &NoOpQueue{}
 }
 
-func (q *NoOpQueue) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+func (q *NoOpQueue) Publish(ctx context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	return nil
+}
+
+func (q *NoOpQueue) PublishDelayed(ctx context.Context, exchange, routingKey string, body []byte, delay time.Duration, opts ...port.PublishOption) error {
 	return nil
 }
 
@@ -19,7 +28,7 @@ func (q *NoOpQueue) PublishJSON(ctx context.Context, exchange, routingKey string
 	return nil
 }
 
-func (q *NoOpQueue) Consume(ctx context.Context, queue string, handler func(body []byte) error) error {
+func (q *NoOpQueue) Consume(ctx context.Context, queue string, handler func(msg port.Message) error) error {
 	// No-op: just block until context is done
 	<-ctx.Done()
 	return ctx.Err()
@@ -29,7 +38,7 @@ func (q *NoOpQueue) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (q *NoOpQueue) DeclareQueue(ctx context.Context, name string, durable bool) error {
+func (q *NoOpQueue) DeclareQueue(ctx context.Context, name string, durable bool, opts ...port.QueueOption) error {
 	return nil
 }
 
@@ -37,7 +37,7 @@ func TestNoOpQueue_Consume_BlocksUntilContextDone(t *testing.T) {
 	defer cancel()
 
 	start := time.Now()
-	err := q.Consume(ctx, "test-queue", func(body []byte) error {
+	err := q.Consume(ctx, "test-queue", func(port.Message) error {
 		t.Fatal("handler should never be called in NoOpQueue")
 		return nil
 	})
@@ -55,7 +55,7 @@ func TestNoOpQueue_Consume_CancelledContext(t *testing.T) {
 
 	done := make(chan error, 1)
 	go func() {
-		done <- q.Consume(ctx, "test-queue", func(body []byte) error { return nil })
+		done <- q.Consume(ctx, "test-queue", func(port.Message) error { return nil })
 	}()
 
 	// Cancel after a short delay
@@ -10,13 +10,18 @@ import (
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/14mdzk/goscratch/internal/port"
 )
 
 // Channel shape:
 //   - One cached publisher channel guarded by a mutex (used by Publish,
-//     PublishJSON, DeclareQueue, DeclareExchange, BindQueue). Cached because
+//     PublishJSON, DeclareExchange, BindQueue). Cached because
 //     declares run at startup and publishes are hot-path; opening a channel
 //     per call would add a network round-trip per message.
+//   - A transient channel per Ping, DeclareQueue, InspectQueue, PurgeQueue
+//     and MoveMessages, whose failures (missing queue, mismatched arguments) close
+//     the channel they run on.
 //   - One channel per Consume call, opened inside Consume and dedicated to a
 //     single goroutine. AMQP channels are not goroutine-safe, so the consumer
 //     must never share with the publisher.
@@ -47,6 +52,8 @@ type amqpChannel interface {
 	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
 	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
 	QueueDeclarePassive(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueuePurge(name string, noWait bool) (int, error)
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
 	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
 	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
 	Qos(prefetchCount, prefetchSize int, global bool) error
@@ -102,7 +109,8 @@ func (o Options) withDefaults() Options {
 	return o
 }
 
-// RabbitMQ implements port.Queue using RabbitMQ.
+// RabbitMQ implements port.Queue, port.QueueInspector and
+// port.DelayedPublisher using RabbitMQ.
 type RabbitMQ struct {
 	url    string
 	dial   dialer
@@ -113,6 +121,11 @@ type RabbitMQ struct {
 	closed bool
 }
 
+var (
+	_ port.QueueInspector   = (*RabbitMQ)(nil)
+	_ port.DelayedPublisher = (*RabbitMQ)(nil)
+)
+
 // NewRabbitMQ creates a new RabbitMQ connection with default options.
 func NewRabbitMQ(url string) (*RabbitMQ, error) {
 	return NewRabbitMQWithOptions(url, Options{})
@@ -155,7 +168,12 @@ func (q *RabbitMQ) withPubChannel(fn func(ch amqpChannel) error) error {
 	return fn(q.pubCh)
 }
 
-func (q *RabbitMQ) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+func (q *RabbitMQ) Publish(ctx context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	o := port.ApplyPublishOptions(opts...)
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
 	return q.withPubChannel(func(ch amqpChannel) error {
 		return ch.PublishWithContext(
 			ctx,
@@ -164,8 +182,11 @@ func (q *RabbitMQ) Publish(ctx context.Context, exchange, routingKey string, bod
 			false, // mandatory
 			false, // immediate
 			amqp.Publishing{
-				ContentType:  "application/octet-stream",
+				ContentType:  contentType,
+				Headers:      amqp.Table(o.Headers),
 				Body:         body,
+				MessageId:    o.MessageID,
+				Priority:     o.Priority,
 				DeliveryMode: amqp.Persistent,
 				Timestamp:    time.Now(),
 			},
@@ -200,7 +221,7 @@ func (q *RabbitMQ) PublishJSON(ctx context.Context, exchange, routingKey string,
 // exits cleanly on ctx cancellation. The first channel-open + Qos + Consume
 // is performed synchronously so the caller learns immediately about a bad
 // queue name or AMQP-level rejection.
-func (q *RabbitMQ) Consume(ctx context.Context, queueName string, handler func(body []byte) error) error {
+func (q *RabbitMQ) Consume(ctx context.Context, queueName string, handler func(msg port.Message) error) error {
 	ch, deliveries, closeCh, err := q.openConsumer(queueName)
 	if err != nil {
 		return err
@@ -242,7 +263,7 @@ func (q *RabbitMQ) openConsumer(queueName string) (ch amqpChannel, deliveries <-
 func (q *RabbitMQ) runConsumer(
 	ctx context.Context,
 	queueName string,
-	handler func(body []byte) error,
+	handler func(msg port.Message) error,
 	ch amqpChannel,
 	deliveries <-chan amqp.Delivery,
 	closeCh chan *amqp.Error,
@@ -269,7 +290,7 @@ func (q *RabbitMQ) runConsumer(
 // false when the channel closed and the caller should attempt reconnect.
 func (q *RabbitMQ) drainDeliveries(
 	ctx context.Context,
-	handler func(body []byte) error,
+	handler func(msg port.Message) error,
 	deliveries <-chan amqp.Delivery,
 	closeCh chan *amqp.Error,
 ) bool {
@@ -290,7 +311,7 @@ func (q *RabbitMQ) drainDeliveries(
 				// disconnect and let the caller decide whether to reconnect.
 				return false
 			}
-			if err := handler(msg.Body); err != nil {
+			if err := handler(toMessage(msg)); err != nil {
 				_ = msg.Nack(false, true)
 			} else {
 				_ = msg.Ack(false)
@@ -299,6 +320,18 @@ func (q *RabbitMQ) drainDeliveries(
 	}
 }
 
+// toMessage converts an AMQP delivery for a Consume handler.
+func toMessage(d amqp.Delivery) port.Message {
+	return port.Message{
+		ID:          d.MessageId,
+		Body:        d.Body,
+		ContentType: d.ContentType,
+		Headers:     d.Headers,
+		Priority:    d.Priority,
+		Redelivered: d.Redelivered,
+	}
+}
+
 // reconnectConsumer attempts to re-open the consumer channel (and connection
 // if necessary) with bounded backoff. Returns an error when retries are
 // exhausted or the parent context is done.
@@ -377,47 +410,240 @@ func (q *RabbitMQ) Ping(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
+	ch, err := q.transientChannel()
+	if err != nil {
+		return fmt.Errorf("queue ping: %w", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	if _, err := ch.QueueDeclarePassive(healthzProbeQueue, true, false, false, false, nil); err != nil {
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			return nil
+		}
+		return fmt.Errorf("queue ping: %w", err)
+	}
+	return nil
+}
+
+// transientChannel opens a throwaway channel for calls the broker may answer
+// with a channel exception (a passive declare or purge of a missing queue
+// closes the channel), so the cached publisher channel is never poisoned.
+func (q *RabbitMQ) transientChannel() (amqpChannel, error) {
 	q.pubMu.Lock()
 	conn := q.conn
 	closed := q.closed
 	q.pubMu.Unlock()
 	if closed {
-		return errors.New("queue: closed")
+		return nil, errors.New("queue: closed")
 	}
 	if conn == nil {
-		return errors.New("queue: not connected")
+		return nil, errors.New("queue: not connected")
 	}
 	if conn.IsClosed() {
-		return errors.New("queue: connection closed")
+		return nil, errors.New("queue: connection closed")
 	}
-
 	ch, err := conn.Channel()
 	if err != nil {
-		return fmt.Errorf("queue ping: open channel: %w", err)
+		return nil, fmt.Errorf("open channel: %w", err)
+	}
+	return ch, nil
+}
+
+// InspectQueue implements port.QueueInspector with a passive declare, which
+// reports the queue's ready messages and consumers without creating it.
+func (q *RabbitMQ) InspectQueue(ctx context.Context, name string) (port.QueueStats, error) {
+	if err := ctx.Err(); err != nil {
+		return port.QueueStats{}, err
+	}
+	ch, err := q.transientChannel()
+	if err != nil {
+		return port.QueueStats{}, fmt.Errorf("inspect queue: %w", err)
 	}
 	defer func() { _ = ch.Close() }()
 
-	if _, err := ch.QueueDeclarePassive(healthzProbeQueue, true, false, false, false, nil); err != nil {
-		var amqpErr *amqp.Error
-		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
-			return nil
+	info, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+	if err != nil {
+		return port.QueueStats{}, mapQueueError("inspect queue", name, err)
+	}
+	return port.QueueStats{Name: name, Messages: info.Messages, Consumers: info.Consumers}, nil
+}
+
+// PurgeQueue implements port.QueueInspector.
+func (q *RabbitMQ) PurgeQueue(ctx context.Context, name string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ch, err := q.transientChannel()
+	if err != nil {
+		return 0, fmt.Errorf("purge queue: %w", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	n, err := ch.QueuePurge(name, false)
+	if err != nil {
+		return 0, mapQueueError("purge queue", name, err)
+	}
+	return n, nil
+}
+
+// MoveMessages implements port.QueueInspector. Messages are fetched one at a
+// time and acknowledged after they are published, so a failure part-way
+// leaves every message on one queue or the other.
+func (q *RabbitMQ) MoveMessages(ctx context.Context, from, to string, limit int, transform func(port.Message) (port.Message, error)) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ch, err := q.transientChannel()
+	if err != nil {
+		return 0, fmt.Errorf("move messages: %w", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	moved := 0
+	for limit <= 0 || moved < limit {
+		if err := ctx.Err(); err != nil {
+			return moved, err
 		}
-		return fmt.Errorf("queue ping: %w", err)
+		d, ok, err := ch.Get(from, false)
+		if err != nil {
+			return moved, mapQueueError("move messages", from, err)
+		}
+		if !ok {
+			break
+		}
+		if limit <= 0 {
+			// Stop at the messages ready now, not those put back meanwhile
+			limit = moved + int(d.MessageCount) + 1
+		}
+
+		msg, err := transform(toMessage(d))
+		if err == nil {
+			err = ch.PublishWithContext(ctx, "", to, false, false, amqp.Publishing{
+				ContentType:  msg.ContentType,
+				Headers:      amqp.Table(msg.Headers),
+				Body:         msg.Body,
+				MessageId:    msg.ID,
+				Priority:     msg.Priority,
+				DeliveryMode: amqp.Persistent,
+				Timestamp:    time.Now(),
+			})
+		}
+		if err != nil {
+			_ = d.Nack(false, true)
+			return moved, fmt.Errorf("move messages from %q to %q: %w", from, to, err)
+		}
+		if err := d.Ack(false); err != nil {
+			return moved, fmt.Errorf("move messages from %q: %w", from, err)
+		}
+		moved++
 	}
-	return nil
+	return moved, nil
+}
+
+// delayQueueIdle is how long a delay queue outlives its last declare, by
+// which time every message published to it has expired out of it
+const delayQueueIdle = time.Minute
+
+// PublishDelayed implements port.DelayedPublisher without the
+// delayed-message plugin. Messages wait in a holding queue per delay and
+// destination, whose TTL dead-letters them to exchange with routingKey. All
+// messages of a holding queue share its TTL, so none waits behind a longer
+// one. Delays are rounded up to whole seconds to bound the number of holding
+// queues, which the broker deletes once idle.
+func (q *RabbitMQ) PublishDelayed(ctx context.Context, exchange, routingKey string, body []byte, delay time.Duration, opts ...port.PublishOption) error {
+	if delay <= 0 {
+		return q.Publish(ctx, exchange, routingKey, body, opts...)
+	}
+	if r := delay % time.Second; r != 0 {
+		delay += time.Second - r
+	}
+
+	name := delayQueueName(exchange, routingKey, delay)
+	err := q.DeclareQueue(ctx, name, true,
+		port.WithMessageTTL(delay),
+		port.WithDeadLetter(exchange, routingKey),
+		port.WithExpires(delay+delayQueueIdle),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare delay queue: %w", err)
+	}
+	return q.Publish(ctx, "", name, body, opts...)
+}
+
+// delayQueueName names the holding queue of a delay and destination, e.g.
+// delay.30s.jobs for the default exchange and delay.30s.events/jobs otherwise
+func delayQueueName(exchange, routingKey string, delay time.Duration) string {
+	dest := routingKey
+	if exchange != "" {
+		dest = exchange + "/" + routingKey
+	}
+	return fmt.Sprintf("delay.%ds.%s", int64(delay/time.Second), dest)
+}
+
+// mapQueueError turns the broker's 404 into port.ErrQueueNotFound.
+func mapQueueError(op, name string, err error) error {
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+		return fmt.Errorf("%s %q: %w", op, name, port.ErrQueueNotFound)
+	}
+	return fmt.Errorf("%s %q: %w", op, name, err)
 }
 
-func (q *RabbitMQ) DeclareQueue(ctx context.Context, name string, durable bool) error {
+// DeclareQueue declares name with opts as queue arguments. It runs on a
+// transient channel: a queue that already exists with other arguments fails
+// with PRECONDITION_FAILED, which closes the channel.
+func (q *RabbitMQ) DeclareQueue(ctx context.Context, name string, durable bool, opts ...port.QueueOption) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	return q.withPubChannel(func(ch amqpChannel) error {
-		_, err := ch.QueueDeclare(name, durable, false, false, false, nil)
-		if err != nil {
-			return fmt.Errorf("failed to declare queue: %w", err)
+	ch, err := q.transientChannel()
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	if _, err := ch.QueueDeclare(name, durable, false, false, false, queueArgs(port.ApplyQueueOptions(opts...))); err != nil {
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed {
+			return fmt.Errorf("failed to declare queue %q: it exists with different settings; delete it or change them with a broker policy: %w", name, err)
 		}
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	return nil
+}
+
+// queueArgs maps o onto RabbitMQ's x-arguments; nil when o is empty.
+func queueArgs(o port.QueueOptions) amqp.Table {
+	args := amqp.Table{}
+	if o.MessageTTL > 0 {
+		args["x-message-ttl"] = o.MessageTTL.Milliseconds()
+	}
+	if o.MaxLength > 0 {
+		args["x-max-length"] = int64(o.MaxLength)
+	}
+	if o.Overflow != "" {
+		args["x-overflow"] = o.Overflow
+	}
+	if o.DeadLetters() {
+		args["x-dead-letter-exchange"] = o.DeadLetterExchange
+		if o.DeadLetterRoutingKey != "" {
+			args["x-dead-letter-routing-key"] = o.DeadLetterRoutingKey
+		}
+	}
+	if o.Lazy {
+		args["x-queue-mode"] = "lazy"
+	}
+	if o.Expires > 0 {
+		args["x-expires"] = o.Expires.Milliseconds()
+	}
+	if o.MaxPriority > 0 {
+		args["x-max-priority"] = int64(o.MaxPriority)
+	}
+	if len(args) == 0 {
 		return nil
-	})
+	}
+	return args
 }
 
 func (q *RabbitMQ) DeclareExchange(_ context.Context, name, kind string, durable bool) error {
@@ -10,6 +10,8 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
 )
 
 // =============================================================================
@@ -24,6 +26,39 @@ type fakeConn struct {
 	failOpen              bool
 	openCount             int32
 	nextPassiveDeclareErr error
+	queueInfo             amqp.Queue // what passive declares report
+	purgeErr              error
+	declareErr            error
+	ready                 *fakeReady // what Get returns
+}
+
+// fakeReady holds the ready messages of a queue for Get, and takes back
+// those that are rejected with requeue
+type fakeReady struct {
+	mu       sync.Mutex
+	messages []amqp.Delivery
+	acked    int
+}
+
+func (r *fakeReady) Ack(uint64, bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acked++
+	return nil
+}
+
+func (r *fakeReady) Nack(tag uint64, _ bool, requeue bool) error {
+	return r.Reject(tag, requeue)
+}
+
+func (r *fakeReady) Reject(tag uint64, requeue bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if requeue {
+		d := amqp.Delivery{Acknowledger: r, DeliveryTag: tag, Body: []byte{byte(tag)}}
+		r.messages = append([]amqp.Delivery{d}, r.messages...)
+	}
+	return nil
 }
 
 func newFakeConn() *fakeConn {
@@ -38,6 +73,10 @@ func (c *fakeConn) Channel() (amqpChannel, error) {
 	}
 	atomic.AddInt32(&c.openCount, 1)
 	ch := newFakeChannel()
+	ch.queueInfo = c.queueInfo
+	ch.purgeErr = c.purgeErr
+	ch.declareErr = c.declareErr
+	ch.ready = c.ready
 	if c.nextPassiveDeclareErr != nil {
 		ch.passiveDeclareErr = c.nextPassiveDeclareErr
 		c.nextPassiveDeclareErr = nil
@@ -80,8 +119,17 @@ type fakeChannel struct {
 	qosBeforeConsume    bool
 	closed              bool
 	publishCount        int
+	published           []amqp.Publishing
+	publishedKeys       []string
 	passiveDeclareCount int
 	passiveDeclareErr   error
+	queueInfo           amqp.Queue
+	purged              []string
+	purgeErr            error
+	declaredNames       []string
+	declaredArgs        []amqp.Table
+	declareErr          error
+	ready               *fakeReady
 }
 
 func newFakeChannel() *fakeChannel {
@@ -90,13 +138,28 @@ func newFakeChannel() *fakeChannel {
 	}
 }
 
-func (c *fakeChannel) PublishWithContext(_ context.Context, _, _ string, _, _ bool, _ amqp.Publishing) error {
+func (c *fakeChannel) PublishWithContext(_ context.Context, _, key string, _, _ bool, msg amqp.Publishing) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.publishCount++
+	c.published = append(c.published, msg)
+	c.publishedKeys = append(c.publishedKeys, key)
 	return nil
 }
 
+func (c *fakeChannel) Get(string, bool) (amqp.Delivery, bool, error) {
+	r := c.ready
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.messages) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	d := r.messages[0]
+	r.messages = r.messages[1:]
+	d.MessageCount = uint32(len(r.messages))
+	return d, true, nil
+}
+
 func (c *fakeChannel) Consume(_, _ string, _, _, _, _ bool, _ amqp.Table) (<-chan amqp.Delivery, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -107,8 +170,12 @@ func (c *fakeChannel) Consume(_, _ string, _, _, _, _ bool, _ amqp.Table) (<-cha
 	return c.deliveries, nil
 }
 
-func (c *fakeChannel) QueueDeclare(_ string, _, _, _, _ bool, _ amqp.Table) (amqp.Queue, error) {
-	return amqp.Queue{}, nil
+func (c *fakeChannel) QueueDeclare(name string, _, _, _, _ bool, args amqp.Table) (amqp.Queue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.declaredNames = append(c.declaredNames, name)
+	c.declaredArgs = append(c.declaredArgs, args)
+	return amqp.Queue{}, c.declareErr
 }
 
 func (c *fakeChannel) QueueDeclarePassive(_ string, _, _, _, _ bool, _ amqp.Table) (amqp.Queue, error) {
@@ -118,7 +185,17 @@ func (c *fakeChannel) QueueDeclarePassive(_ string, _, _, _, _ bool, _ amqp.Tabl
 	if c.passiveDeclareErr != nil {
 		return amqp.Queue{}, c.passiveDeclareErr
 	}
-	return amqp.Queue{}, nil
+	return c.queueInfo, nil
+}
+
+func (c *fakeChannel) QueuePurge(name string, _ bool) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.purgeErr != nil {
+		return 0, c.purgeErr
+	}
+	c.purged = append(c.purged, name)
+	return c.queueInfo.Messages, nil
 }
 
 func (c *fakeChannel) ExchangeDeclare(_, _ string, _, _, _, _ bool, _ amqp.Table) error {
@@ -193,13 +270,49 @@ func TestRabbitMQ_PublishAndConsume_OpenDistinctChannels(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	require.NoError(t, q.Consume(ctx, "queue", func(b []byte) error { return nil }))
+	require.NoError(t, q.Consume(ctx, "queue", func(port.Message) error { return nil }))
 
 	// Consume opens a second, distinct channel.
 	assert.Equal(t, 2, conn.ChannelCount())
 	assert.NotSame(t, conn.channels[0], conn.channels[1], "publisher and consumer must use separate channels")
 }
 
+func TestRabbitMQ_ContentType(t *testing.T) {
+	conn := newFakeConn()
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	require.NoError(t, q.Publish(context.Background(), "", "jobs", []byte("x")))
+	require.NoError(t, q.Publish(context.Background(), "", "jobs", []byte("x"), port.WithPublishContentType("application/msgpack"),
+		port.WithPublishHeaders(map[string]any{"x-attempts": int64(3)}), port.WithPublishPriority(9), port.WithPublishMessageID("m-9")))
+	pub := conn.channels[0]
+	require.Len(t, pub.published, 2)
+	assert.Equal(t, "application/octet-stream", pub.published[0].ContentType)
+	assert.Zero(t, pub.published[0].Priority)
+	assert.Equal(t, "application/msgpack", pub.published[1].ContentType)
+	assert.Equal(t, amqp.Table{"x-attempts": int64(3)}, pub.published[1].Headers)
+	assert.Equal(t, uint8(9), pub.published[1].Priority)
+	assert.Equal(t, "m-9", pub.published[1].MessageId)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan port.Message, 1)
+	require.NoError(t, q.Consume(ctx, "jobs", func(msg port.Message) error {
+		got <- msg
+		return nil
+	}))
+	conn.channels[1].deliveries <- amqp.Delivery{Body: []byte("x"), ContentType: "application/msgpack", MessageId: "m-1", Priority: 5}
+
+	select {
+	case msg := <-got:
+		assert.Equal(t, port.Message{ID: "m-1", Body: []byte("x"), ContentType: "application/msgpack", Priority: 5}, msg)
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
 func TestRabbitMQ_Consume_QosBeforeConsume(t *testing.T) {
 	conn := newFakeConn()
 	dial := func(url string) (amqpConnection, error) { return conn, nil }
@@ -210,7 +323,7 @@ func TestRabbitMQ_Consume_QosBeforeConsume(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	require.NoError(t, q.Consume(ctx, "queue", func(b []byte) error { return nil }))
+	require.NoError(t, q.Consume(ctx, "queue", func(port.Message) error { return nil }))
 
 	consumerCh := conn.channels[1]
 	consumerCh.mu.Lock()
@@ -232,7 +345,7 @@ func TestRabbitMQ_PrefetchDefault(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	require.NoError(t, q.Consume(ctx, "queue", func(b []byte) error { return nil }))
+	require.NoError(t, q.Consume(ctx, "queue", func(port.Message) error { return nil }))
 
 	consumerCh := conn.channels[1]
 	consumerCh.mu.Lock()
@@ -310,6 +423,198 @@ func TestRabbitMQ_Ping_ReturnsErrorWhenConnectionClosed(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestRabbitMQ_InspectQueue(t *testing.T) {
+	conn := newFakeConn()
+	conn.queueInfo = amqp.Queue{Name: "jobs", Messages: 42, Consumers: 3}
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	stats, err := q.InspectQueue(context.Background(), "jobs")
+	require.NoError(t, err)
+	assert.Equal(t, port.QueueStats{Name: "jobs", Messages: 42, Consumers: 3}, stats)
+
+	require.Equal(t, 2, conn.ChannelCount(), "InspectQueue should open a transient channel")
+	inspectCh := conn.channels[1]
+	inspectCh.mu.Lock()
+	assert.True(t, inspectCh.closed, "transient channel must be closed")
+	inspectCh.mu.Unlock()
+
+	conn.nextPassiveDeclareErr = &amqp.Error{Code: amqp.NotFound, Reason: "no queue 'gone'"}
+	_, err = q.InspectQueue(context.Background(), "gone")
+	assert.ErrorIs(t, err, port.ErrQueueNotFound)
+
+	pubCh := conn.channels[0]
+	pubCh.mu.Lock()
+	defer pubCh.mu.Unlock()
+	assert.Equal(t, 0, pubCh.passiveDeclareCount, "publisher channel must not be used for inspection")
+}
+
+func TestRabbitMQ_PurgeQueue(t *testing.T) {
+	conn := newFakeConn()
+	conn.queueInfo = amqp.Queue{Messages: 7}
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	n, err := q.PurgeQueue(context.Background(), "jobs.dlq")
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+	purgeCh := conn.channels[1]
+	purgeCh.mu.Lock()
+	assert.Equal(t, []string{"jobs.dlq"}, purgeCh.purged)
+	purgeCh.mu.Unlock()
+
+	conn.purgeErr = &amqp.Error{Code: amqp.NotFound, Reason: "no queue"}
+	_, err = q.PurgeQueue(context.Background(), "gone")
+	assert.ErrorIs(t, err, port.ErrQueueNotFound)
+
+	require.NoError(t, q.Close())
+	_, err = q.PurgeQueue(context.Background(), "jobs")
+	assert.Error(t, err)
+}
+
+func TestRabbitMQ_MoveMessages(t *testing.T) {
+	ready := &fakeReady{}
+	for tag := uint64(1); tag <= 3; tag++ {
+		ready.messages = append(ready.messages, amqp.Delivery{
+			Acknowledger: ready, DeliveryTag: tag, Body: []byte{byte(tag)},
+			ContentType: "application/json", Headers: amqp.Table{"x-error": "boom"}, Priority: 9,
+		})
+	}
+	conn := newFakeConn()
+	conn.ready = ready
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	strip := func(m port.Message) (port.Message, error) {
+		m.Headers = nil
+		return m, nil
+	}
+	n, err := q.MoveMessages(context.Background(), "jobs.dlq", "jobs", 2, strip)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	moveCh := conn.channels[1]
+	moveCh.mu.Lock()
+	require.Len(t, moveCh.published, 2)
+	assert.Equal(t, []byte{1}, moveCh.published[0].Body)
+	assert.Equal(t, "application/json", moveCh.published[0].ContentType)
+	assert.Nil(t, moveCh.published[0].Headers)
+	assert.Equal(t, uint8(9), moveCh.published[0].Priority, "priority is kept")
+	moveCh.mu.Unlock()
+	assert.Equal(t, 2, ready.acked)
+
+	t.Run("a failed transform puts the message back", func(t *testing.T) {
+		n, err := q.MoveMessages(context.Background(), "jobs.dlq", "jobs", 0, func(port.Message) (port.Message, error) {
+			return port.Message{}, assertErr("cannot decode")
+		})
+		assert.Error(t, err)
+		assert.Zero(t, n)
+		assert.Len(t, ready.messages, 1)
+	})
+
+	t.Run("limit 0 moves every ready message", func(t *testing.T) {
+		n, err := q.MoveMessages(context.Background(), "jobs.dlq", "jobs", 0, strip)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Empty(t, ready.messages)
+	})
+}
+
+func TestRabbitMQ_DeclareQueue_Arguments(t *testing.T) {
+	conn := newFakeConn()
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	require.NoError(t, q.DeclareQueue(context.Background(), "plain", true))
+	require.NoError(t, q.DeclareQueue(context.Background(), "jobs", true,
+		port.WithMessageTTL(90*time.Second),
+		port.WithMaxLength(1000, "reject-publish-dlx"),
+		port.WithDeadLetter("", "jobs.dlq"),
+		port.WithLazyMode(),
+		port.WithExpires(time.Hour),
+		port.WithMaxPriority(9),
+	))
+
+	require.Equal(t, 3, conn.ChannelCount(), "each declare runs on its own transient channel")
+	plainCh, jobsCh := conn.channels[1], conn.channels[2]
+	assert.Equal(t, []amqp.Table{nil}, plainCh.declaredArgs, "no options, no arguments")
+	assert.Equal(t, []amqp.Table{{
+		"x-message-ttl":             int64(90000),
+		"x-max-length":              int64(1000),
+		"x-overflow":                "reject-publish-dlx",
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": "jobs.dlq",
+		"x-queue-mode":              "lazy",
+		"x-expires":                 int64(3600000),
+		"x-max-priority":            int64(9),
+	}}, jobsCh.declaredArgs)
+	assert.True(t, jobsCh.closed)
+	assert.Empty(t, conn.channels[0].declaredArgs, "publisher channel must not be used for declares")
+}
+
+func TestRabbitMQ_PublishDelayed(t *testing.T) {
+	conn := newFakeConn()
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx := context.Background()
+	require.NoError(t, q.PublishDelayed(ctx, "", "jobs", []byte("x"), 1500*time.Millisecond,
+		port.WithPublishContentType("application/json")))
+	require.NoError(t, q.PublishDelayed(ctx, "events", "jobs", []byte("y"), 30*time.Second))
+
+	require.Equal(t, 3, conn.ChannelCount())
+	first, second := conn.channels[1], conn.channels[2]
+	assert.Equal(t, []string{"delay.2s.jobs"}, first.declaredNames, "delays round up to whole seconds")
+	assert.Equal(t, []amqp.Table{{
+		"x-message-ttl":             int64(2000),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": "jobs",
+		"x-expires":                 int64(62000),
+	}}, first.declaredArgs)
+	assert.Equal(t, []string{"delay.30s.events/jobs"}, second.declaredNames)
+	assert.Equal(t, "events", second.declaredArgs[0]["x-dead-letter-exchange"])
+
+	pub := conn.channels[0]
+	assert.Equal(t, []string{"delay.2s.jobs", "delay.30s.events/jobs"}, pub.publishedKeys)
+	assert.Equal(t, "application/json", pub.published[0].ContentType)
+
+	t.Run("no delay publishes directly", func(t *testing.T) {
+		require.NoError(t, q.PublishDelayed(ctx, "", "jobs", []byte("z"), 0))
+		assert.Equal(t, "jobs", pub.publishedKeys[len(pub.publishedKeys)-1])
+		assert.Equal(t, 3, conn.ChannelCount(), "nothing to declare")
+	})
+}
+
+func TestRabbitMQ_DeclareQueue_MismatchedArguments(t *testing.T) {
+	conn := newFakeConn()
+	conn.declareErr = &amqp.Error{Code: amqp.PreconditionFailed, Reason: "inequivalent arg 'x-message-ttl'"}
+	dial := func(url string) (amqpConnection, error) { return conn, nil }
+
+	q, err := newRabbitMQ("amqp://test", Options{}, dial)
+	require.NoError(t, err)
+	defer q.Close()
+
+	err = q.DeclareQueue(context.Background(), "jobs", true, port.WithMessageTTL(time.Minute))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exists with different settings")
+
+	require.NoError(t, q.Publish(context.Background(), "", "jobs", []byte("x")), "publisher channel survives a failed declare")
+}
+
 func TestRabbitMQ_NotifyClose_TriggersReconnect(t *testing.T) {
 	conn := newFakeConn()
 	dial := func(url string) (amqpConnection, error) { return conn, nil }
@@ -325,7 +630,7 @@ func TestRabbitMQ_NotifyClose_TriggersReconnect(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	require.NoError(t, q.Consume(ctx, "queue", func(b []byte) error { return nil }))
+	require.NoError(t, q.Consume(ctx, "queue", func(port.Message) error { return nil }))
 
 	// 1 publisher + 1 initial consumer.
 	require.Equal(t, 2, conn.ChannelCount())
@@ -354,7 +659,7 @@ func TestRabbitMQ_Reconnect_HaltsOnContextCancel(t *testing.T) {
 	defer q.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
-	require.NoError(t, q.Consume(ctx, "queue", func(b []byte) error { return nil }))
+	require.NoError(t, q.Consume(ctx, "queue", func(port.Message) error { return nil }))
 
 	// Force open to fail so reconnect must keep retrying.
 	conn.mu.Lock()
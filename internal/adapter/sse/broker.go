@@ -1,16 +1,33 @@
 package sse
 
 import (
+	"iter"
 	"sync"
 
 	"github.com/14mdzk/goscratch/internal/port"
 )
 
-// Broker implements port.SSEBroker for Server-Sent Events
+// Broker implements port.SSEBroker for Server-Sent Events. With a replay
+// buffer it also implements port.SSEReplayer.
 type Broker struct {
 	mu         sync.RWMutex
 	clients    map[string]clientInfo
 	bufferSize int
+	replay     *replayBuffer
+	private    map[string]struct{}
+}
+
+// Options configures a Broker
+type Options struct {
+	// BufferSize is each client's channel capacity (default: 100).
+	BufferSize int
+	// ReplaySize is how many recent broadcasts are kept for Replay. 0
+	// keeps none.
+	ReplaySize int
+	// PrivateTopics reach only clients that name them when subscribing; a
+	// client subscribed to every topic does not receive them. Callers
+	// check who may name them.
+	PrivateTopics []string
 }
 
 type clientInfo struct {
@@ -18,17 +35,33 @@ type clientInfo struct {
 	topics  map[string]struct{}
 }
 
-// NewBroker creates a new SSE broker
+// NewBroker creates a new SSE broker without a replay buffer
 func NewBroker(bufferSize int) *Broker {
-	if bufferSize <= 0 {
-		bufferSize = 100
+	return NewBrokerWithOptions(Options{BufferSize: bufferSize})
+}
+
+// NewBrokerWithOptions creates a new SSE broker
+func NewBrokerWithOptions(opts Options) *Broker {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 100
 	}
-	return &Broker{
+	b := &Broker{
 		clients:    make(map[string]clientInfo),
-		bufferSize: bufferSize,
+		bufferSize: opts.BufferSize,
+		private:    make(map[string]struct{}, len(opts.PrivateTopics)),
+	}
+	for _, t := range opts.PrivateTopics {
+		b.private[t] = struct{}{}
 	}
+	if opts.ReplaySize > 0 {
+		b.replay = newReplayBuffer(opts.ReplaySize)
+		b.replay.private = b.private
+	}
+	return b
 }
 
+var _ port.SSEReplayer = (*Broker)(nil)
+
 // Subscribe registers a new client identified by clientID. clientID must be a
 // per-connection identifier (e.g. UUID) — block-ship #11/#12: keying by userID
 // caused a second tab to silently overwrite the first subscription, leaking the
@@ -71,6 +104,12 @@ func (b *Broker) Broadcast(event port.Event) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	// Buffered under the read lock: a concurrent Subscribe either sees the
+	// event on its channel or finds it in the replay, never neither.
+	if b.replay != nil {
+		event = b.replay.add("", event)
+	}
+
 	for _, info := range b.clients {
 		select {
 		case info.channel <- event:
@@ -84,11 +123,17 @@ func (b *Broker) BroadcastToTopic(topic string, event port.Event) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	if b.replay != nil {
+		event = b.replay.add(topic, event)
+	}
+
+	_, private := b.private[topic]
 	for _, info := range b.clients {
 		// Check if client is subscribed to this topic
 		if _, subscribed := info.topics[topic]; !subscribed {
-			// Also broadcast to clients with no specific topics (they get everything)
-			if len(info.topics) > 0 {
+			// Also broadcast to clients with no specific topics (they get
+			// everything but private topics)
+			if len(info.topics) > 0 || private {
 				continue
 			}
 		}
@@ -114,12 +159,36 @@ func (b *Broker) SendTo(clientID string, event port.Event) {
 	}
 }
 
+// Replay returns buffered broadcasts after cursor. Events sent with SendTo
+// are never buffered.
+func (b *Broker) Replay(cursor string, topics []string, limit int) (port.ReplayResult, error) {
+	if b.replay == nil {
+		return port.ReplayResult{}, port.ErrReplayDisabled
+	}
+	return b.replay.replay(cursor, topics, limit)
+}
+
 func (b *Broker) ClientCount() int {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	return len(b.clients)
 }
 
+// ChannelUsage yields the length and capacity of each client's channel. A
+// channel that stays full belongs to a client that stopped reading; its
+// events are being dropped.
+func (b *Broker) ChannelUsage() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		for _, info := range b.clients {
+			if !yield(len(info.channel), cap(info.channel)) {
+				return
+			}
+		}
+	}
+}
+
 func (b *Broker) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -128,6 +197,9 @@ func (b *Broker) Close() error {
 		close(info.channel)
 		delete(b.clients, clientID)
 	}
+	if b.replay != nil {
+		b.replay.close()
+	}
 
 	return nil
 }
@@ -0,0 +1,133 @@
+package sse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// replayBuffer keeps the last broadcasts in a ring, numbered by a sequence
+// that starts at 1. Cursors are "<epoch>.<seq>": the epoch changes on every
+// start, so a cursor from before a restart is recognised as stale instead of
+// pointing into the new sequence.
+type replayBuffer struct {
+	mu      sync.Mutex
+	epoch   string
+	entries []replayEntry
+	next    int // ring index the next entry is written to
+	count   int
+	seq     uint64
+	updated chan struct{}
+	closed  bool
+	private map[string]struct{} // topics left out of replays for every topic
+}
+
+type replayEntry struct {
+	seq   uint64
+	topic string // "" for events sent to every client
+	event port.Event
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		entries: make([]replayEntry, size),
+		updated: make(chan struct{}),
+	}
+}
+
+// add buffers event for topic and returns it with its ID set to its cursor
+// when it had none, so SSE clients resume from it via Last-Event-ID.
+func (r *replayBuffer) add(topic string, event port.Event) port.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	if event.ID == "" {
+		event.ID = r.cursor(r.seq)
+	}
+	r.entries[r.next] = replayEntry{seq: r.seq, topic: topic, event: event}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.count < len(r.entries) {
+		r.count++
+	}
+
+	if !r.closed {
+		close(r.updated)
+		r.updated = make(chan struct{})
+	}
+	return event
+}
+
+// close releases everyone waiting for the next event.
+func (r *replayBuffer) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.updated)
+	}
+}
+
+func (r *replayBuffer) cursor(seq uint64) string {
+	return fmt.Sprintf("%s.%d", r.epoch, seq)
+}
+
+func (r *replayBuffer) replay(cursor string, topics []string, limit int) (port.ReplayResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := port.ReplayResult{Cursor: r.cursor(r.seq), Wait: r.updated}
+	if cursor == "" {
+		return res, nil
+	}
+
+	epoch, seqStr, ok := strings.Cut(cursor, ".")
+	after, err := strconv.ParseUint(seqStr, 10, 64)
+	if !ok || err != nil {
+		return port.ReplayResult{}, port.ErrInvalidCursor
+	}
+	if epoch != r.epoch {
+		// Issued before a restart: replay everything kept.
+		after = 0
+		res.Missed = true
+	} else if after > r.seq {
+		return port.ReplayResult{}, port.ErrInvalidCursor
+	} else {
+		res.Cursor = cursor
+	}
+	oldest := r.seq - uint64(r.count) + 1
+	if after+1 < oldest {
+		res.Missed = true
+	}
+
+	wanted := make(map[string]bool, len(topics))
+	for _, t := range topics {
+		wanted[t] = true
+	}
+	first := (r.next - r.count + len(r.entries)) % len(r.entries)
+	for i := 0; i < r.count; i++ {
+		e := r.entries[(first+i)%len(r.entries)]
+		if e.seq <= after {
+			continue
+		}
+		if limit > 0 && len(res.Events) == limit {
+			res.More = true
+			break
+		}
+		res.Cursor = r.cursor(e.seq)
+		if e.topic == "" || wanted[e.topic] || len(wanted) == 0 && !r.isPrivate(e.topic) {
+			res.Events = append(res.Events, e.event)
+		}
+	}
+	return res, nil
+}
+
+func (r *replayBuffer) isPrivate(topic string) bool {
+	_, ok := r.private[topic]
+	return ok
+}
@@ -190,6 +190,42 @@ func TestBroker_BroadcastToTopic_OnlySubscribedClients(t *testing.T) {
 	}
 }
 
+func TestBroker_PrivateTopics(t *testing.T) {
+	b := NewBrokerWithOptions(Options{ReplaySize: 10, PrivateTopics: []string{"audit"}})
+	defer b.Close()
+	start, _ := b.Replay("", nil, 0)
+
+	chAudit := b.Subscribe("audit-client", "audit")
+	chAll := b.Subscribe("all-client")
+
+	event := port.Event{Event: "audit.entry"}
+	b.BroadcastToTopic("audit", event)
+	b.BroadcastToTopic("news", port.Event{Event: "update"})
+
+	select {
+	case received := <-chAudit:
+		assert.Equal(t, "audit.entry", received.Event)
+	case <-time.After(time.Second):
+		t.Fatal("client naming the private topic should receive its events")
+	}
+	select {
+	case received := <-chAll:
+		assert.Equal(t, "update", received.Event, "all-topic client skips private topics")
+	case <-time.After(time.Second):
+		t.Fatal("all-topic client should receive public topic events")
+	}
+
+	r, err := b.Replay(start.Cursor, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, r.Events, 1)
+	assert.Equal(t, "update", r.Events[0].Event)
+
+	r, err = b.Replay(start.Cursor, []string{"audit"}, 10)
+	require.NoError(t, err)
+	require.Len(t, r.Events, 1)
+	assert.Equal(t, "audit.entry", r.Events[0].Event)
+}
+
 func TestBroker_SendTo_SpecificClient(t *testing.T) {
 	b := NewBroker(10)
 	defer b.Close()
@@ -246,6 +282,25 @@ func TestBroker_ClientCount(t *testing.T) {
 	assert.Equal(t, 0, b.ClientCount())
 }
 
+func TestBroker_ChannelUsage(t *testing.T) {
+	b := NewBroker(4)
+	defer b.Close()
+
+	reader := b.Subscribe("reader", "orders")
+	b.Subscribe("stuck")
+	for range 3 {
+		b.Broadcast(port.Event{Data: []byte("x")})
+	}
+	<-reader
+
+	usage := make(map[int]int)
+	for length, capacity := range b.ChannelUsage() {
+		assert.Equal(t, 4, capacity)
+		usage[length]++
+	}
+	assert.Equal(t, map[int]int{2: 1, 3: 1}, usage)
+}
+
 func TestBroker_Close_ShutsDownGracefully(t *testing.T) {
 	b := NewBroker(10)
 
@@ -492,3 +547,125 @@ func TestBroker_Subscribe_DuplicateConnID_ClosesPrior(t *testing.T) {
 
 	assert.Equal(t, 1, b.ClientCount(), "duplicate id must not double-count")
 }
+
+// =============================================================================
+// Replay Tests
+// =============================================================================
+
+func TestBroker_Replay_Disabled(t *testing.T) {
+	b := NewBroker(10)
+	_, err := b.Replay("", nil, 10)
+	assert.ErrorIs(t, err, port.ErrReplayDisabled)
+
+	b.Broadcast(port.Event{Event: "a"})
+	ch := b.Subscribe("c1")
+	b.Broadcast(port.Event{Event: "b"})
+	assert.Empty(t, (<-ch).ID, "events keep their IDs without a replay buffer")
+}
+
+func TestBroker_Replay(t *testing.T) {
+	b := NewBrokerWithOptions(Options{ReplaySize: 10})
+	defer b.Close()
+
+	start, err := b.Replay("", nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, start.Events)
+
+	b.Broadcast(port.Event{Event: "all"})
+	b.BroadcastToTopic("orders", port.Event{Event: "order"})
+	b.BroadcastToTopic("billing", port.Event{ID: "custom", Event: "invoice"})
+	b.SendTo("nobody", port.Event{Event: "direct"})
+
+	r, err := b.Replay(start.Cursor, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, r.Events, 3, "SendTo events are not buffered")
+	assert.Equal(t, "all", r.Events[0].Event)
+	assert.NotEmpty(t, r.Events[0].ID, "broadcasts get their cursor as ID")
+	assert.Equal(t, "custom", r.Events[2].ID)
+	assert.False(t, r.Missed)
+
+	t.Run("topics", func(t *testing.T) {
+		r, err := b.Replay(start.Cursor, []string{"orders"}, 10)
+		require.NoError(t, err)
+		require.Len(t, r.Events, 2)
+		assert.Equal(t, "order", r.Events[1].Event)
+
+		next, err := b.Replay(r.Cursor, []string{"orders"}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, next.Events, "filtered events are skipped, not replayed later")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		r, err := b.Replay(start.Cursor, nil, 2)
+		require.NoError(t, err)
+		assert.Len(t, r.Events, 2)
+		assert.True(t, r.More)
+
+		rest, err := b.Replay(r.Cursor, nil, 2)
+		require.NoError(t, err)
+		require.Len(t, rest.Events, 1)
+		assert.Equal(t, "invoice", rest.Events[0].Event)
+		assert.False(t, rest.More)
+	})
+
+	t.Run("resume from event ID", func(t *testing.T) {
+		r, err := b.Replay(r.Events[0].ID, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, r.Events, 2)
+	})
+
+	t.Run("invalid cursors", func(t *testing.T) {
+		for _, c := range []string{"garbage", "x.y", start.Cursor + "9"} {
+			_, err := b.Replay(c, nil, 10)
+			assert.ErrorIs(t, err, port.ErrInvalidCursor, c)
+		}
+	})
+
+	t.Run("cursor from another broker", func(t *testing.T) {
+		r, err := b.Replay("0.5", nil, 10)
+		require.NoError(t, err)
+		assert.True(t, r.Missed)
+		assert.Len(t, r.Events, 3)
+	})
+}
+
+func TestBroker_Replay_Overflow(t *testing.T) {
+	b := NewBrokerWithOptions(Options{ReplaySize: 2})
+	defer b.Close()
+	start, _ := b.Replay("", nil, 0)
+
+	for _, name := range []string{"a", "b", "c"} {
+		b.Broadcast(port.Event{Event: name})
+	}
+	r, err := b.Replay(start.Cursor, nil, 0)
+	require.NoError(t, err)
+	assert.True(t, r.Missed)
+	require.Len(t, r.Events, 2)
+	assert.Equal(t, "b", r.Events[0].Event)
+}
+
+func TestBroker_Replay_Wait(t *testing.T) {
+	b := NewBrokerWithOptions(Options{ReplaySize: 2})
+	r, err := b.Replay("", nil, 0)
+	require.NoError(t, err)
+
+	select {
+	case <-r.Wait:
+		t.Fatal("nothing was broadcast yet")
+	default:
+	}
+	b.Broadcast(port.Event{Event: "a"})
+	select {
+	case <-r.Wait:
+	case <-time.After(time.Second):
+		t.Fatal("Wait not closed by a broadcast")
+	}
+
+	r, _ = b.Replay(r.Cursor, nil, 0)
+	require.NoError(t, b.Close())
+	select {
+	case <-r.Wait:
+	case <-time.After(time.Second):
+		t.Fatal("Wait not closed by Close")
+	}
+}
@@ -77,8 +77,9 @@ func (s *LocalStorage) Upload(ctx context.Context, path string, data io.Reader,
 	}
 	defer file.Close()
 
-	// Copy data
-	if _, err := io.Copy(file, data); err != nil {
+	// Copy data, stopping early if the caller gives up (e.g. the client
+	// disconnected mid-upload)
+	if _, err := io.Copy(file, contextReader{ctx: ctx, r: data}); err != nil {
 		os.Remove(fullPath) // Clean up on error
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
@@ -195,3 +196,16 @@ func (s *LocalStorage) List(ctx context.Context, prefix string) ([]port.FileInfo
 func (s *LocalStorage) Close() error {
 	return nil // No resources to close
 }
+
+// contextReader fails reads once ctx is done, so io.Copy stops mid-stream.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -322,6 +322,19 @@ func TestLocalStorage_Upload_LargeFile(t *testing.T) {
 	assert.Equal(t, data, downloaded)
 }
 
+func TestLocalStorage_Upload_CancelledContext(t *testing.T) {
+	s := newTestLocalStorage(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Upload(ctx, "cancelled.bin", bytes.NewReader(make([]byte, 1024)))
+	require.ErrorIs(t, err, context.Canceled)
+
+	exists, err := s.Exists(context.Background(), "cancelled.bin")
+	require.NoError(t, err)
+	assert.False(t, exists, "a partial file must be removed")
+}
+
 // =============================================================================
 // S3Storage Tests (interface compliance only - no real S3)
 // =============================================================================
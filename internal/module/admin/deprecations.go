@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultQuietDays is how long a sunset route must go uncalled before the
+// report calls it removable
+const defaultQuietDays = 30
+
+// DeprecationsResponse is the usage report of the deprecated routes
+type DeprecationsResponse struct {
+	// TrackingSince is when this instance started counting; usage before a
+	// restart, or on other instances, is not included.
+	TrackingSince time.Time          `json:"tracking_since"`
+	QuietDays     int                `json:"quiet_days"`
+	Routes        []DeprecationUsage `json:"routes"`
+}
+
+// DeprecationUsage is the usage of one deprecated route
+type DeprecationUsage struct {
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Deprecated time.Time     `json:"deprecated"`
+	Sunset     *time.Time    `json:"sunset,omitempty"`
+	Successor  string        `json:"successor,omitempty"`
+	Requests   int64         `json:"requests"`
+	LastSeen   *time.Time    `json:"last_seen,omitempty"`
+	Clients    []ClientUsage `json:"clients"`
+	Removable  bool          `json:"removable"`
+}
+
+// ClientUsage is one client's calls to a deprecated route
+type ClientUsage struct {
+	Client   string    `json:"client"`
+	Requests int64     `json:"requests"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// GetDeprecations reports how much each deprecated route is still called,
+// and by which clients, as seen by the instance that served the request. A
+// route is removable once its sunset has passed and it has gone uncalled for
+// ?quiet_days (default 30).
+func (h *Handler) GetDeprecations(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	quietDays := c.QueryInt("quiet_days", defaultQuietDays)
+	if quietDays < 0 {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("quiet_days must not be negative"))
+	}
+
+	reports := h.registry.Deprecations(time.Now(), time.Duration(quietDays)*24*time.Hour)
+	out := DeprecationsResponse{
+		TrackingSince: h.registry.TrackingSince(),
+		QuietDays:     quietDays,
+		Routes:        make([]DeprecationUsage, 0, len(reports)),
+	}
+	for _, rep := range reports {
+		u := DeprecationUsage{
+			Method:     rep.Method,
+			Path:       rep.Path,
+			Deprecated: rep.Since,
+			Successor:  rep.Successor,
+			Requests:   rep.Requests,
+			Clients:    make([]ClientUsage, 0, len(rep.Clients)),
+			Removable:  rep.Removable,
+		}
+		if !rep.Sunset.IsZero() {
+			sunset := rep.Sunset
+			u.Sunset = &sunset
+		}
+		if !rep.LastSeen.IsZero() {
+			lastSeen := rep.LastSeen
+			u.LastSeen = &lastSeen
+		}
+		for _, cu := range rep.Clients {
+			u.Clients = append(u.Clients, ClientUsage{Client: cu.Client, Requests: cu.Requests, LastSeen: cu.LastSeen})
+		}
+		out.Routes = append(out.Routes, u)
+	}
+	return response.Success(c, out)
+}
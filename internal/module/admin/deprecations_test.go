@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeprecations(t *testing.T) {
+	registry := routes.NewRegistry()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	since := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	app := fiber.New()
+	r := routes.New(app, routes.Config{Registry: registry})
+	r.Get("/widgets/search", ok).Deprecated(since).Sunset(sunset).Successor("/widgets")
+	r.Get("/widgets/legacy", ok).Deprecated(since)
+	r.Get("/widgets", ok)
+	r.Mount()
+	h := NewHandler(&config.Config{}, nil, registry, nil, nil)
+	app.Get("/admin/deprecations", h.GetDeprecations)
+	app.Get("/admin/routes", h.GetRoutes)
+
+	for _, ua := range []string{"okhttp/4.12.0", "okhttp/4.12.0", "billing-sync/2.0"} {
+		req := httptest.NewRequest("GET", "/widgets/search", nil)
+		req.Header.Set("User-Agent", ua)
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/deprecations", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+	var result struct {
+		Data DeprecationsResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, defaultQuietDays, result.Data.QuietDays)
+	assert.False(t, result.Data.TrackingSince.IsZero())
+	require.Len(t, result.Data.Routes, 2)
+
+	legacy := result.Data.Routes[0]
+	assert.Equal(t, "/widgets/legacy", legacy.Path)
+	assert.Equal(t, int64(0), legacy.Requests)
+	assert.Nil(t, legacy.LastSeen)
+	assert.Nil(t, legacy.Sunset)
+	assert.NotNil(t, legacy.Clients)
+
+	search := result.Data.Routes[1]
+	assert.Equal(t, "/widgets/search", search.Path)
+	assert.True(t, since.Equal(search.Deprecated))
+	require.NotNil(t, search.Sunset)
+	assert.True(t, sunset.Equal(*search.Sunset))
+	assert.Equal(t, "/widgets", search.Successor)
+	assert.Equal(t, int64(3), search.Requests)
+	require.NotNil(t, search.LastSeen)
+	require.Len(t, search.Clients, 2)
+	assert.Equal(t, "okhttp", search.Clients[0].Client)
+	assert.Equal(t, int64(2), search.Clients[0].Requests)
+	assert.False(t, search.Removable)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/admin/deprecations?quiet_days=-1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/admin/routes", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var catalog struct {
+		Data RoutesResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&catalog))
+	require.Len(t, catalog.Data.Routes, 3)
+	assert.False(t, catalog.Data.Routes[0].Deprecated)
+	assert.True(t, catalog.Data.Routes[1].Deprecated)
+	assert.Nil(t, catalog.Data.Routes[1].Sunset)
+	assert.True(t, catalog.Data.Routes[2].Deprecated)
+	require.NotNil(t, catalog.Data.Routes[2].Sunset)
+}
@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles operator/admin requests
+type Handler struct {
+	cfg      *config.Config
+	readOnly *readonly.Switch
+	registry *routes.Registry
+	queues   port.QueueInspector // nil when the queue adapter cannot be inspected
+	ipRules  *iprules.Set        // nil when ip_rules.enabled is off
+}
+
+// NewHandler creates a new admin handler
+func NewHandler(cfg *config.Config, readOnly *readonly.Switch, registry *routes.Registry, queues port.QueueInspector, ipRules *iprules.Set) *Handler {
+	return &Handler{cfg: cfg, readOnly: readOnly, registry: registry, queues: queues, ipRules: ipRules}
+}
+
+// ConfigResponse is the effective runtime configuration of this instance
+type ConfigResponse struct {
+	Env      string           `json:"env"`
+	Settings []config.Setting `json:"settings"`
+}
+
+// GetConfig returns the effective configuration (file + env overrides) with
+// secrets redacted and the source of every value.
+func (h *Handler) GetConfig(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return response.Success(c, ConfigResponse{
+		Env:      h.cfg.App.Env,
+		Settings: h.cfg.Settings(),
+	})
+}
+
+// RouteResponse describes a route mounted through the route builder
+type RouteResponse struct {
+	Method        string             `json:"method"`
+	Path          string             `json:"path"`
+	Permission    string             `json:"permission,omitempty"`
+	OwnerAccess   bool               `json:"owner_access,omitempty"`
+	Authenticated bool               `json:"authenticated"`
+	RateLimit     *RateLimitResponse `json:"rate_limit,omitempty"`
+	CacheTTL      string             `json:"cache_ttl,omitempty"`
+	Deprecated    bool               `json:"deprecated,omitempty"`
+	Sunset        *time.Time         `json:"sunset,omitempty"`
+}
+
+// RateLimitResponse is a route's declared rate limit
+type RateLimitResponse struct {
+	Max    int    `json:"max"`
+	Window string `json:"window"`
+}
+
+// RoutesResponse is the route catalog and the permissions it checks
+type RoutesResponse struct {
+	Routes      []RouteResponse `json:"routes"`
+	Permissions []string        `json:"permissions"`
+}
+
+// GetRoutes returns every route declared through the route builder with its
+// permission, rate limit and cache settings, plus the permission catalog.
+func (h *Handler) GetRoutes(c *fiber.Ctx) error {
+	infos := h.registry.Routes()
+	out := RoutesResponse{
+		Routes:      make([]RouteResponse, 0, len(infos)),
+		Permissions: h.registry.Permissions(),
+	}
+	for _, info := range infos {
+		r := RouteResponse{
+			Method:        info.Method,
+			Path:          info.Path,
+			Permission:    info.Permission,
+			OwnerAccess:   info.OwnerAccess,
+			Authenticated: info.Authenticated,
+		}
+		if info.RateLimit != nil {
+			r.RateLimit = &RateLimitResponse{Max: info.RateLimit.Max, Window: info.RateLimit.Window.String()}
+		}
+		if info.CacheTTL > 0 {
+			r.CacheTTL = info.CacheTTL.String()
+		}
+		if info.Deprecation != nil {
+			r.Deprecated = true
+			if !info.Deprecation.Sunset.IsZero() {
+				sunset := info.Deprecation.Sunset
+				r.Sunset = &sunset
+			}
+		}
+		out.Routes = append(out.Routes, r)
+	}
+	return response.Success(c, out)
+}
+
+// SetReadOnlyRequest turns read-only mode on or off
+type SetReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled" validate:"required"`
+	Reason  string `json:"reason" validate:"max=200"`
+}
+
+// GetReadOnly returns the read-only state as this instance sees it
+func (h *Handler) GetReadOnly(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return response.Success(c, h.readOnly.State())
+}
+
+// SetReadOnly turns read-only mode on or off for every instance sharing the
+// cache.
+func (h *Handler) SetReadOnly(c *fiber.Ctx) error {
+	var req SetReadOnlyRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	st := readonly.State{
+		Enabled: *req.Enabled,
+		Reason:  req.Reason,
+		By:      middleware.GetUserID(c),
+	}
+	if err := h.readOnly.Set(c.UserContext(), st); err != nil {
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	return response.Success(c, h.readOnly.State())
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfig(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Env = "staging"
+	cfg.JWT.Secret = "super-secret-jwt-key"
+	cfg.Database.Password = "db-pass"
+	cfg.Server.Port = 3000
+
+	app := fiber.New()
+	app.Get("/admin/config", NewHandler(cfg, nil, nil, nil, nil).GetConfig)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/config", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "super-secret-jwt-key")
+	assert.NotContains(t, string(body), "db-pass")
+
+	var result struct {
+		Data ConfigResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+	assert.Equal(t, "staging", result.Data.Env)
+
+	values := make(map[string]any)
+	for _, s := range result.Data.Settings {
+		values[s.Key] = s.Value
+	}
+	assert.Equal(t, "[REDACTED]", values["jwt.secret"])
+	assert.Equal(t, "[REDACTED]", values["database.password"])
+	assert.EqualValues(t, 3000, values["server.port"])
+}
+
+func TestReadOnly_GetAndSet(t *testing.T) {
+	sw := readonly.New(nil, readonly.Config{})
+	h := NewHandler(&config.Config{}, sw, nil, nil, nil)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", "admin-1")
+		return c.Next()
+	})
+	app.Get("/admin/read-only", h.GetReadOnly)
+	app.Put("/admin/read-only", h.SetReadOnly)
+
+	put := func(body string) *http.Response {
+		req := httptest.NewRequest("PUT", "/admin/read-only", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := put(`{"enabled": true, "reason": "db failover"}`)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.True(t, sw.Enabled())
+	assert.Equal(t, "db failover", sw.State().Reason)
+	assert.Equal(t, "admin-1", sw.State().By)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/read-only", nil))
+	require.NoError(t, err)
+	var result struct {
+		Data readonly.State `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.True(t, result.Data.Enabled)
+	assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+	resp = put(`{"enabled": false}`)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.False(t, sw.Enabled())
+
+	resp = put(`{"reason": "missing enabled"}`)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.False(t, sw.Enabled())
+}
+
+func TestGetRoutes(t *testing.T) {
+	registry := routes.NewRegistry()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+
+	app := fiber.New()
+	r := routes.New(app, routes.Config{Authorizer: allowAll{}, Registry: registry})
+	r.Get("/widgets", ok).Require("widgets:read").RateLimit(10).Cache(time.Minute)
+	r.Post("/widgets", ok).Require("widgets:create")
+	r.Mount()
+
+	app.Get("/admin/routes", NewHandler(&config.Config{}, nil, registry, nil, nil).GetRoutes)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/routes", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Data RoutesResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, []string{"widgets:create", "widgets:read"}, result.Data.Permissions)
+	require.Len(t, result.Data.Routes, 2)
+
+	get := result.Data.Routes[0]
+	assert.Equal(t, "GET", get.Method)
+	assert.Equal(t, "/widgets", get.Path)
+	assert.Equal(t, "widgets:read", get.Permission)
+	require.NotNil(t, get.RateLimit)
+	assert.Equal(t, 10, get.RateLimit.Max)
+	assert.Equal(t, "1m0s", get.RateLimit.Window)
+	assert.Equal(t, "1m0s", get.CacheTTL)
+
+	post := result.Data.Routes[1]
+	assert.Equal(t, "POST", post.Method)
+	assert.Nil(t, post.RateLimit)
+	assert.Empty(t, post.CacheTTL)
+}
+
+// allowAll is a port.Authorizer stub; GetRoutes never checks permissions.
+type allowAll struct {
+	port.Authorizer
+}
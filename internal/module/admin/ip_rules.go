@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ipRulesPath is where the rules are managed. A change that would refuse the
+// caller here is rejected unless forced, so an admin cannot lock themselves
+// out by accident.
+const ipRulesPath = "/admin/ip-rules"
+
+// IPRulesResponse lists the active IP rules
+type IPRulesResponse struct {
+	Enabled bool           `json:"enabled"`
+	Rules   []iprules.Rule `json:"rules"`
+}
+
+// AddIPRuleRequest adds a runtime IP rule
+type AddIPRuleRequest struct {
+	Action string `json:"action" validate:"required,oneof=allow deny"`
+	CIDR   string `json:"cidr" validate:"required,max=64"`
+	Prefix string `json:"prefix" validate:"max=200"`
+	Note   string `json:"note" validate:"max=200"`
+}
+
+// errIPRulesDisabled is returned when ip_rules.enabled is off
+var errIPRulesDisabled = apperr.ErrServiceUnavailable.WithMessage("IP rules are not enabled")
+
+// errLockout is returned for a change that would refuse the caller
+var errLockout = apperr.ErrConflict.WithMessage("This change would block your own address from managing IP rules; repeat with ?force=true to apply it anyway")
+
+// ListIPRules returns the fixed and runtime IP rules as this instance sees
+// them.
+func (h *Handler) ListIPRules(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	rules := h.ipRules.Rules()
+	if rules == nil {
+		rules = []iprules.Rule{}
+	}
+	return response.Success(c, IPRulesResponse{Enabled: h.ipRules != nil, Rules: rules})
+}
+
+// AddIPRule adds a runtime rule for every instance sharing the cache
+func (h *Handler) AddIPRule(c *fiber.Ctx) error {
+	if h.ipRules == nil {
+		return response.Fail(c, errIPRulesDisabled)
+	}
+	var req AddIPRuleRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	rule := iprules.Rule{
+		Action:    iprules.Action(req.Action),
+		CIDR:      req.CIDR,
+		Prefix:    req.Prefix,
+		Note:      req.Note,
+		CreatedBy: middleware.GetUserID(c),
+	}
+	if err := rule.Validate(); err != nil {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+	}
+	if !c.QueryBool("force") && iprules.Evaluate(append(h.ipRules.Rules(), rule), c.IP(), ipRulesPath).Reason() != "" {
+		return response.Fail(c, errLockout)
+	}
+
+	added, err := h.ipRules.Add(c.UserContext(), rule)
+	if err != nil {
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	return response.Created(c, added)
+}
+
+// RemoveIPRule removes a runtime rule. Rules from the configuration cannot be
+// removed.
+func (h *Handler) RemoveIPRule(c *fiber.Ctx) error {
+	if h.ipRules == nil {
+		return response.Fail(c, errIPRulesDisabled)
+	}
+	id := c.Params("id")
+
+	if !c.QueryBool("force") {
+		var remaining []iprules.Rule
+		for _, r := range h.ipRules.Rules() {
+			if r.ID != id {
+				remaining = append(remaining, r)
+			}
+		}
+		if iprules.Evaluate(remaining, c.IP(), ipRulesPath).Reason() != "" {
+			return response.Fail(c, errLockout)
+		}
+	}
+
+	err := h.ipRules.Remove(c.UserContext(), id)
+	switch {
+	case errors.Is(err, iprules.ErrNotFound):
+		return response.Fail(c, apperr.ErrNotFound.WithMessage("IP rule not found"))
+	case errors.Is(err, iprules.ErrFixed):
+		return response.Fail(c, apperr.ErrConflict.WithMessage("IP rule comes from the configuration and cannot be removed at runtime"))
+	case err != nil:
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	return response.NoContent(c)
+}
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// app.Test requests come from 0.0.0.0.
+const testIP = "0.0.0.0"
+
+func newIPRulesApp(t *testing.T, set *iprules.Set) *fiber.App {
+	t.Helper()
+	h := NewHandler(&config.Config{}, nil, nil, nil, set)
+	app := fiber.New()
+	app.Get("/admin/ip-rules", h.ListIPRules)
+	app.Post("/admin/ip-rules", h.AddIPRule)
+	app.Delete("/admin/ip-rules/:id", h.RemoveIPRule)
+	return app
+}
+
+func newIPRuleSet(t *testing.T, fixed ...iprules.Rule) *iprules.Set {
+	t.Helper()
+	set, err := iprules.New(nil, fixed, iprules.Config{})
+	require.NoError(t, err)
+	return set
+}
+
+func postIPRule(t *testing.T, app *fiber.App, target, body string) (int, iprules.Rule) {
+	t.Helper()
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var result struct {
+		Data iprules.Rule `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result.Data
+}
+
+func deleteIPRule(t *testing.T, app *fiber.App, target string) int {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("DELETE", target, nil))
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestListIPRules(t *testing.T) {
+	set := newIPRuleSet(t, iprules.Rule{Action: iprules.Deny, CIDR: "203.0.113.0/24"})
+	app := newIPRulesApp(t, set)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/ip-rules", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+	var result struct {
+		Data IPRulesResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.True(t, result.Data.Enabled)
+	require.Len(t, result.Data.Rules, 1)
+	assert.Equal(t, "config-0", result.Data.Rules[0].ID)
+	assert.Equal(t, iprules.SourceConfig, result.Data.Rules[0].Source)
+}
+
+func TestListIPRules_Disabled(t *testing.T) {
+	app := newIPRulesApp(t, nil)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/ip-rules", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var result struct {
+		Data IPRulesResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.False(t, result.Data.Enabled)
+	assert.NotNil(t, result.Data.Rules)
+
+	code, _ := postIPRule(t, app, "/admin/ip-rules", `{"action":"deny","cidr":"203.0.113.7"}`)
+	assert.Equal(t, fiber.StatusServiceUnavailable, code)
+	assert.Equal(t, fiber.StatusServiceUnavailable, deleteIPRule(t, app, "/admin/ip-rules/abc"))
+}
+
+func TestAddAndRemoveIPRule(t *testing.T) {
+	set := newIPRuleSet(t)
+	app := newIPRulesApp(t, set)
+
+	code, added := postIPRule(t, app, "/admin/ip-rules", `{"action":"deny","cidr":"203.0.113.7","prefix":"/api/","note":"scanner"}`)
+	require.Equal(t, fiber.StatusCreated, code)
+	assert.NotEmpty(t, added.ID)
+	assert.Equal(t, "203.0.113.7/32", added.CIDR)
+	assert.Equal(t, "/api", added.Prefix)
+	assert.Equal(t, iprules.SourceRuntime, added.Source)
+	assert.False(t, set.Check("203.0.113.7", "/api/users").Allowed)
+
+	assert.Equal(t, fiber.StatusNoContent, deleteIPRule(t, app, "/admin/ip-rules/"+added.ID))
+	assert.True(t, set.Check("203.0.113.7", "/api/users").Allowed)
+	assert.Equal(t, fiber.StatusNotFound, deleteIPRule(t, app, "/admin/ip-rules/"+added.ID))
+}
+
+func TestAddIPRule_Invalid(t *testing.T) {
+	app := newIPRulesApp(t, newIPRuleSet(t))
+
+	for _, body := range []string{
+		`{"action":"block","cidr":"203.0.113.7"}`,
+		`{"action":"deny"}`,
+		`{"action":"deny","cidr":"203.0.113.0/33"}`,
+		`{"action":"deny","cidr":"203.0.113.7","prefix":"api"}`,
+	} {
+		code, _ := postIPRule(t, app, "/admin/ip-rules", body)
+		assert.True(t, code == fiber.StatusBadRequest || code == fiber.StatusUnprocessableEntity, "%s: %d", body, code)
+	}
+}
+
+func TestIPRules_LockoutProtection(t *testing.T) {
+	set := newIPRuleSet(t, iprules.Rule{Action: iprules.Allow, CIDR: "10.8.0.0/16", Prefix: "/api"})
+	app := newIPRulesApp(t, set)
+
+	t.Run("an allowlist for /admin without the caller", func(t *testing.T) {
+		body := `{"action":"allow","cidr":"10.8.0.0/16","prefix":"/admin"}`
+		code, _ := postIPRule(t, app, "/admin/ip-rules", body)
+		assert.Equal(t, fiber.StatusConflict, code)
+		assert.Len(t, set.Rules(), 1, "nothing was added")
+
+		code, _ = postIPRule(t, app, "/admin/ip-rules?force=true", body)
+		assert.Equal(t, fiber.StatusCreated, code)
+	})
+
+	t.Run("rules elsewhere are not a lockout", func(t *testing.T) {
+		set := newIPRuleSet(t)
+		app := newIPRulesApp(t, set)
+		code, _ := postIPRule(t, app, "/admin/ip-rules", `{"action":"deny","cidr":"`+testIP+`","prefix":"/api"}`)
+		assert.Equal(t, fiber.StatusCreated, code)
+	})
+
+	t.Run("removing the caller's only allow rule", func(t *testing.T) {
+		set := newIPRuleSet(t)
+		app := newIPRulesApp(t, set)
+		_, mine := postIPRule(t, app, "/admin/ip-rules", `{"action":"allow","cidr":"`+testIP+`","prefix":"/admin"}`)
+		require.NotEmpty(t, mine.ID)
+		_, other := postIPRule(t, app, "/admin/ip-rules", `{"action":"allow","cidr":"10.8.0.0/16","prefix":"/admin"}`)
+		require.NotEmpty(t, other.ID)
+
+		assert.Equal(t, fiber.StatusConflict, deleteIPRule(t, app, "/admin/ip-rules/"+mine.ID))
+		assert.Equal(t, fiber.StatusNoContent, deleteIPRule(t, app, "/admin/ip-rules/"+mine.ID+"?force=true"))
+		assert.Equal(t, []iprules.Rule{other}, set.Rules())
+	})
+}
+
+func TestRemoveIPRule_Fixed(t *testing.T) {
+	app := newIPRulesApp(t, newIPRuleSet(t, iprules.Rule{Action: iprules.Deny, CIDR: "203.0.113.0/24"}))
+	assert.Equal(t, fiber.StatusConflict, deleteIPRule(t, app, "/admin/ip-rules/config-0"))
+}
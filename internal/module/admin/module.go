@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Module represents the operator/admin module
+type Module struct {
+	handler *Handler
+	routes  routes.Config
+	authCfg middleware.AuthConfig
+}
+
+// NewModule creates a new admin module
+func NewModule(cfg *config.Config, authCfg middleware.AuthConfig, readOnly *readonly.Switch, routeCfg routes.Config, queues port.QueueInspector, ipRules *iprules.Set) *Module {
+	return &Module{
+		handler: NewHandler(cfg, readOnly, routeCfg.Registry, queues, ipRules),
+		routes:  routeCfg,
+		authCfg: authCfg,
+	}
+}
+
+// RegisterRoutes registers admin module routes.
+//
+// GET /admin/config requires the config:read permission, which only
+// superadmin holds by default (via its "*" wildcard). The route catalog, its
+// policy file and the deprecation report need routes:read. The read-only
+// switch needs read_only:read and read_only:update; PUT /admin/read-only must
+// stay exempt from middleware.ReadOnly so the switch can be turned off. The
+// queue endpoints need queues:read, purging also needs queues:purge, and
+// requeueing a dead-letter queue queues:requeue. IP
+// rules need ip_rules:read and ip_rules:update.
+func (m *Module) RegisterRoutes(router fiber.Router) {
+	authMiddleware := middleware.Auth(m.authCfg)
+
+	r := routes.New(router, m.routes)
+	admin := r.Group("/admin").Authenticated(authMiddleware)
+
+	admin.Get("/config", m.handler.GetConfig).Require("config:read")
+	admin.Get("/routes", m.handler.GetRoutes).Require("routes:read")
+	admin.Get("/routes/policy", m.handler.GetRoutesPolicy).Require("routes:read")
+	admin.Get("/deprecations", m.handler.GetDeprecations).Require("routes:read")
+	admin.Get("/read-only", m.handler.GetReadOnly).Require("read_only:read")
+	admin.Put("/read-only", m.handler.SetReadOnly).Require("read_only:update")
+	admin.Get("/queues", m.handler.ListQueues).Require("queues:read")
+	admin.Get("/queues/:name", m.handler.GetQueue).Require("queues:read")
+	admin.Delete("/queues/:name/messages", m.handler.PurgeQueue).Require("queues:purge")
+	admin.Post("/queues/:name/requeue", m.handler.RequeueQueue).Require("queues:requeue")
+	admin.Get("/ip-rules", m.handler.ListIPRules).Require("ip_rules:read")
+	admin.Post("/ip-rules", m.handler.AddIPRule).Require("ip_rules:update")
+	admin.Delete("/ip-rules/:id", m.handler.RemoveIPRule).Require("ip_rules:update")
+
+	r.Mount()
+}
@@ -0,0 +1,198 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// QueueResponse is the state of one configured queue
+type QueueResponse struct {
+	Name       string `json:"name"`
+	DeadLetter bool   `json:"dead_letter"`
+	Exists     bool   `json:"exists"`
+	Messages   int    `json:"messages"`
+	Consumers  int    `json:"consumers"`
+}
+
+// QueuesResponse lists the configured queues
+type QueuesResponse struct {
+	Queues []QueueResponse `json:"queues"`
+}
+
+// PurgeQueueResponse reports a purge
+type PurgeQueueResponse struct {
+	Queue  string `json:"queue"`
+	Purged int    `json:"purged"`
+}
+
+// RequeueQueueResponse reports a requeue from a dead-letter queue
+type RequeueQueueResponse struct {
+	Queue    string `json:"queue"`
+	Target   string `json:"target"`
+	Requeued int    `json:"requeued"`
+}
+
+// configuredQueue is a queue the admin endpoints may touch.
+type configuredQueue struct {
+	name       string
+	deadLetter bool
+	// source is the job queue a worker.dlq dead-letter queue belongs to;
+	// only those can be requeued
+	source string
+}
+
+// configuredQueues returns the job queues (worker.queue_name, then
+// worker.queues), their <queue>.dlq with worker.dlq on, and the dead-letter
+// queue, without duplicates. Only these can be inspected or purged, so the
+// endpoints cannot reach arbitrary broker queues.
+func (h *Handler) configuredQueues() []configuredQueue {
+	var out []configuredQueue
+	seen := make(map[string]bool)
+	add := func(q configuredQueue) {
+		if q.name == "" || seen[q.name] {
+			return
+		}
+		seen[q.name] = true
+		out = append(out, q)
+	}
+	jobQueues := append([]string{h.cfg.Worker.QueueName}, h.cfg.Worker.Queues...)
+	for _, name := range jobQueues {
+		add(configuredQueue{name: name})
+	}
+	if h.cfg.Worker.DLQ {
+		for _, name := range jobQueues {
+			if name != "" {
+				add(configuredQueue{name: worker.DeadLetterQueueName(name), deadLetter: true, source: name})
+			}
+		}
+	}
+	add(configuredQueue{name: h.cfg.Worker.DeadLetterQueue, deadLetter: true})
+	return out
+}
+
+func (h *Handler) lookupQueue(name string) (configuredQueue, bool) {
+	for _, q := range h.configuredQueues() {
+		if q.name == name {
+			return q, true
+		}
+	}
+	return configuredQueue{}, false
+}
+
+// errQueuesUnavailable is returned when the queue adapter cannot be
+// inspected (RabbitMQ disabled or unreachable at startup).
+var errQueuesUnavailable = apperr.ErrServiceUnavailable.WithMessage("Queue inspection is not available")
+
+// ListQueues returns message and consumer counts for every configured queue,
+// including the dead-letter queue.
+func (h *Handler) ListQueues(c *fiber.Ctx) error {
+	if h.queues == nil {
+		return response.Fail(c, errQueuesUnavailable)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	configured := h.configuredQueues()
+	out := QueuesResponse{Queues: make([]QueueResponse, 0, len(configured))}
+	for _, q := range configured {
+		r, err := h.inspect(c, q)
+		if err != nil {
+			return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+		}
+		out.Queues = append(out.Queues, r)
+	}
+	return response.Success(c, out)
+}
+
+// GetQueue returns message and consumer counts for one configured queue.
+func (h *Handler) GetQueue(c *fiber.Ctx) error {
+	if h.queues == nil {
+		return response.Fail(c, errQueuesUnavailable)
+	}
+	q, ok := h.lookupQueue(c.Params("name"))
+	if !ok {
+		return response.Fail(c, apperr.ErrNotFound.WithMessage("Queue is not configured"))
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	r, err := h.inspect(c, q)
+	if err != nil {
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	return response.Success(c, r)
+}
+
+// PurgeQueue deletes every ready message of a configured queue. The caller
+// must repeat the queue name in ?confirm= so a mistyped path cannot empty
+// the wrong queue.
+func (h *Handler) PurgeQueue(c *fiber.Ctx) error {
+	if h.queues == nil {
+		return response.Fail(c, errQueuesUnavailable)
+	}
+	q, ok := h.lookupQueue(c.Params("name"))
+	if !ok {
+		return response.Fail(c, apperr.ErrNotFound.WithMessage("Queue is not configured"))
+	}
+	if c.Query("confirm") != q.name {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("Set confirm to the queue name to purge it"))
+	}
+
+	n, err := h.queues.PurgeQueue(c.UserContext(), q.name)
+	if errors.Is(err, port.ErrQueueNotFound) {
+		return response.Fail(c, apperr.ErrNotFound.WithMessage("Queue does not exist on the broker"))
+	}
+	if err != nil {
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	return response.Success(c, PurgeQueueResponse{Queue: q.name, Purged: n})
+}
+
+// RequeueQueue moves up to ?limit= jobs (default: all ready) from a
+// <queue>.dlq back to its job queue, with their attempts reset. Jobs that
+// cannot be decoded stay on the dead-letter queue and end the requeue.
+func (h *Handler) RequeueQueue(c *fiber.Ctx) error {
+	if h.queues == nil {
+		return response.Fail(c, errQueuesUnavailable)
+	}
+	q, ok := h.lookupQueue(c.Params("name"))
+	if !ok {
+		return response.Fail(c, apperr.ErrNotFound.WithMessage("Queue is not configured"))
+	}
+	if q.source == "" {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("Only the dead-letter queue of a job queue can be requeued"))
+	}
+	limit := c.QueryInt("limit", 0)
+	if limit < 0 {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("limit must not be negative"))
+	}
+
+	n, err := h.queues.MoveMessages(c.UserContext(), q.name, q.source, limit, worker.RequeueDeadLetter)
+	if errors.Is(err, port.ErrQueueNotFound) {
+		return response.Fail(c, apperr.ErrNotFound.WithMessage("Queue does not exist on the broker"))
+	}
+	if err != nil {
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err).WithMessage(fmt.Sprintf("Requeue stopped after %d jobs", n)))
+	}
+	return response.Success(c, RequeueQueueResponse{Queue: q.name, Target: q.source, Requeued: n})
+}
+
+// inspect reports q, treating a queue missing on the broker as empty.
+func (h *Handler) inspect(c *fiber.Ctx, q configuredQueue) (QueueResponse, error) {
+	r := QueueResponse{Name: q.name, DeadLetter: q.deadLetter}
+	stats, err := h.queues.InspectQueue(c.UserContext(), q.name)
+	if errors.Is(err, port.ErrQueueNotFound) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	r.Exists = true
+	r.Messages = stats.Messages
+	r.Consumers = stats.Consumers
+	return r, nil
+}
@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInspector is an in-memory port.QueueInspector; queues missing from the
+// map do not exist on the "broker".
+type fakeInspector struct {
+	queues map[string]port.QueueStats
+	err    error
+	purged []string
+	// ready holds the messages MoveMessages takes, per queue
+	ready map[string][]port.Message
+}
+
+func (f *fakeInspector) InspectQueue(_ context.Context, name string) (port.QueueStats, error) {
+	if f.err != nil {
+		return port.QueueStats{}, f.err
+	}
+	s, ok := f.queues[name]
+	if !ok {
+		return port.QueueStats{}, fmt.Errorf("inspect %q: %w", name, port.ErrQueueNotFound)
+	}
+	return s, nil
+}
+
+func (f *fakeInspector) PurgeQueue(_ context.Context, name string) (int, error) {
+	s, ok := f.queues[name]
+	if !ok {
+		return 0, fmt.Errorf("purge %q: %w", name, port.ErrQueueNotFound)
+	}
+	f.purged = append(f.purged, name)
+	s.Messages = 0
+	f.queues[name] = s
+	return 5, nil
+}
+
+func (f *fakeInspector) MoveMessages(_ context.Context, from, to string, limit int, transform func(port.Message) (port.Message, error)) (int, error) {
+	if _, ok := f.queues[from]; !ok {
+		return 0, fmt.Errorf("move %q: %w", from, port.ErrQueueNotFound)
+	}
+	moved := 0
+	for len(f.ready[from]) > 0 && (limit <= 0 || moved < limit) {
+		msg, err := transform(f.ready[from][0])
+		if err != nil {
+			return moved, err
+		}
+		f.ready[from] = f.ready[from][1:]
+		f.ready[to] = append(f.ready[to], msg)
+		moved++
+	}
+	return moved, nil
+}
+
+func newQueuesApp(inspector port.QueueInspector) *fiber.App {
+	cfg := &config.Config{}
+	cfg.Worker.QueueName = "jobs"
+	cfg.Worker.Queues = []string{"jobs", "emails"}
+	cfg.Worker.DeadLetterQueue = "jobs.dlq"
+
+	h := NewHandler(cfg, nil, nil, inspector, nil)
+	app := fiber.New()
+	app.Get("/admin/queues", h.ListQueues)
+	app.Get("/admin/queues/:name", h.GetQueue)
+	app.Delete("/admin/queues/:name/messages", h.PurgeQueue)
+	app.Post("/admin/queues/:name/requeue", h.RequeueQueue)
+	return app
+}
+
+func TestListQueues(t *testing.T) {
+	inspector := &fakeInspector{queues: map[string]port.QueueStats{
+		"jobs":     {Name: "jobs", Messages: 12, Consumers: 2},
+		"jobs.dlq": {Name: "jobs.dlq", Messages: 3},
+	}}
+	app := newQueuesApp(inspector)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/queues", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+	var result struct {
+		Data QueuesResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, []QueueResponse{
+		{Name: "jobs", Exists: true, Messages: 12, Consumers: 2},
+		{Name: "emails"},
+		{Name: "jobs.dlq", DeadLetter: true, Exists: true, Messages: 3},
+	}, result.Data.Queues)
+
+	t.Run("broker error", func(t *testing.T) {
+		app := newQueuesApp(&fakeInspector{err: errors.New("connection closed")})
+		resp, err := app.Test(httptest.NewRequest("GET", "/admin/queues", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	})
+
+	t.Run("no inspector", func(t *testing.T) {
+		resp, err := newQueuesApp(nil).Test(httptest.NewRequest("GET", "/admin/queues", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	})
+}
+
+func TestGetQueue(t *testing.T) {
+	app := newQueuesApp(&fakeInspector{queues: map[string]port.QueueStats{
+		"emails": {Name: "emails", Messages: 4, Consumers: 1},
+	}})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/queues/emails", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var result struct {
+		Data QueueResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, QueueResponse{Name: "emails", Exists: true, Messages: 4, Consumers: 1}, result.Data)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/admin/queues/other", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, "unconfigured queues are not exposed")
+}
+
+func TestPurgeQueue(t *testing.T) {
+	inspector := &fakeInspector{queues: map[string]port.QueueStats{
+		"jobs.dlq": {Name: "jobs.dlq", Messages: 5},
+	}}
+	app := newQueuesApp(inspector)
+
+	purge := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest("DELETE", path, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusBadRequest, purge("/admin/queues/jobs.dlq/messages"), "confirm is required")
+	assert.Equal(t, fiber.StatusBadRequest, purge("/admin/queues/jobs.dlq/messages?confirm=jobs"), "confirm must match")
+	assert.Empty(t, inspector.purged)
+
+	assert.Equal(t, fiber.StatusNotFound, purge("/admin/queues/other/messages?confirm=other"))
+	assert.Equal(t, fiber.StatusNotFound, purge("/admin/queues/emails/messages?confirm=emails"), "missing on the broker")
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/admin/queues/jobs.dlq/messages?confirm=jobs.dlq", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var result struct {
+		Data PurgeQueueResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, PurgeQueueResponse{Queue: "jobs.dlq", Purged: 5}, result.Data)
+	assert.Equal(t, []string{"jobs.dlq"}, inspector.purged)
+}
+
+func TestRequeueQueue(t *testing.T) {
+	job, err := worker.NewJob("email.send", "data")
+	require.NoError(t, err)
+	job.Attempts = 3
+	body, err := job.Encode()
+	require.NoError(t, err)
+	dead := port.Message{Body: body, ContentType: worker.ContentTypeJSON, Headers: map[string]any{
+		worker.HeaderFailedQueue: "emails",
+		worker.HeaderFailedError: "smtp down",
+	}}
+
+	inspector := &fakeInspector{
+		queues: map[string]port.QueueStats{"emails.dlq": {Name: "emails.dlq", Messages: 3}},
+		ready:  map[string][]port.Message{"emails.dlq": {dead, dead, dead}},
+	}
+	cfg := &config.Config{}
+	cfg.Worker.QueueName = "jobs"
+	cfg.Worker.Queues = []string{"emails"}
+	cfg.Worker.DeadLetterQueue = "failed"
+	cfg.Worker.DLQ = true
+	h := NewHandler(cfg, nil, nil, inspector, nil)
+	app := fiber.New()
+	app.Get("/admin/queues", h.ListQueues)
+	app.Post("/admin/queues/:name/requeue", h.RequeueQueue)
+
+	requeue := func(path string) (int, RequeueQueueResponse) {
+		resp, err := app.Test(httptest.NewRequest("POST", path, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var result struct {
+			Data RequeueQueueResponse `json:"data"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result.Data
+	}
+
+	t.Run("lists per-queue dead-letter queues", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/admin/queues", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var result struct {
+			Data QueuesResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		names := make([]string, len(result.Data.Queues))
+		for i, q := range result.Data.Queues {
+			names[i] = q.Name
+		}
+		assert.Equal(t, []string{"jobs", "emails", "jobs.dlq", "emails.dlq", "failed"}, names)
+	})
+
+	t.Run("only per-queue dead-letter queues", func(t *testing.T) {
+		status, _ := requeue("/admin/queues/emails/requeue")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		status, _ = requeue("/admin/queues/failed/requeue")
+		assert.Equal(t, fiber.StatusBadRequest, status, "the broker dead-letter queue has no single source")
+		status, _ = requeue("/admin/queues/other.dlq/requeue")
+		assert.Equal(t, fiber.StatusNotFound, status)
+		status, _ = requeue("/admin/queues/jobs.dlq/requeue")
+		assert.Equal(t, fiber.StatusNotFound, status, "missing on the broker")
+		status, _ = requeue("/admin/queues/emails.dlq/requeue?limit=-1")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		status, got := requeue("/admin/queues/emails.dlq/requeue?limit=1")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, RequeueQueueResponse{Queue: "emails.dlq", Target: "emails", Requeued: 1}, got)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		status, got := requeue("/admin/queues/emails.dlq/requeue")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, 2, got.Requeued)
+		assert.Empty(t, inspector.ready["emails.dlq"])
+		require.Len(t, inspector.ready["emails"], 3)
+
+		requeued, err := worker.DecodeMessage(inspector.ready["emails"][0])
+		require.NoError(t, err)
+		assert.Equal(t, 0, requeued.Attempts, "requeued jobs get every retry again")
+		assert.Empty(t, inspector.ready["emails"][0].Headers)
+	})
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// RoutesPolicy is a policy file granting one role every permission the
+// routes check. It has the shape of GET /roles/policy, so it can be
+// imported, or seeded through authorization.policy_file.
+type RoutesPolicy struct {
+	Permissions []RoutesPolicyPermission `json:"permissions" yaml:"permissions"`
+}
+
+// RoutesPolicyPermission lets Subject perform Action on Object
+type RoutesPolicyPermission struct {
+	Subject string `json:"subject" yaml:"subject"`
+	Object  string `json:"object" yaml:"object"`
+	Action  string `json:"action" yaml:"action"`
+}
+
+// GetRoutesPolicy returns a policy file granting ?role every permission a
+// route checks, as YAML or, with ?format=json, JSON. It is a starting point
+// for seeding a role; edit it down before granting less than everything.
+func (h *Handler) GetRoutesPolicy(c *fiber.Ctx) error {
+	role := c.Query("role")
+	if role == "" {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("role is required"))
+	}
+	format := c.Query("format", "yaml")
+	if format != "yaml" && format != "json" {
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("format must be yaml or json"))
+	}
+
+	perms := h.registry.Permissions()
+	out := RoutesPolicy{Permissions: make([]RoutesPolicyPermission, 0, len(perms))}
+	for _, p := range perms {
+		// The route builder refuses permissions not in "object:action" form
+		obj, act, _ := strings.Cut(p, ":")
+		out.Permissions = append(out.Permissions, RoutesPolicyPermission{Subject: role, Object: obj, Action: act})
+	}
+
+	var body []byte
+	var err error
+	contentType := "application/yaml"
+	if format == "json" {
+		body, err = json.MarshalIndent(out, "", "  ")
+		contentType = fiber.MIMEApplicationJSON
+	} else {
+		body, err = yaml.Marshal(out)
+	}
+	if err != nil {
+		return response.Fail(c, apperr.ErrInternal.WithError(err))
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="policy.`+format+`"`)
+	return c.Send(body)
+}
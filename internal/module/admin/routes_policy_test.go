@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGetRoutesPolicy(t *testing.T) {
+	registry := routes.NewRegistry()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+
+	app := fiber.New()
+	r := routes.New(app, routes.Config{Authorizer: allowAll{}, Registry: registry})
+	r.Get("/widgets", ok).Require("widgets:read")
+	r.Get("/widgets/:id", ok).Require("widgets:read")
+	r.Post("/widgets", ok).Require("widgets:create")
+	r.Get("/public", ok)
+	r.Mount()
+
+	app.Get("/admin/routes/policy", NewHandler(&config.Config{}, nil, registry, nil, nil).GetRoutesPolicy)
+
+	get := func(query string) (int, string, []byte) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/admin/routes/policy"+query, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), body
+	}
+	want := RoutesPolicy{Permissions: []RoutesPolicyPermission{
+		{Subject: "editor", Object: "widgets", Action: "create"},
+		{Subject: "editor", Object: "widgets", Action: "read"},
+	}}
+
+	t.Run("yaml", func(t *testing.T) {
+		status, contentType, body := get("?role=editor")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "application/yaml", contentType)
+		var got RoutesPolicy
+		require.NoError(t, yaml.Unmarshal(body, &got))
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		status, contentType, body := get("?role=editor&format=json")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, fiber.MIMEApplicationJSON, contentType)
+		var got RoutesPolicy
+		require.NoError(t, json.Unmarshal(body, &got))
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("role is required", func(t *testing.T) {
+		status, _, _ := get("")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		status, _, _ := get("?role=editor&format=toml")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+}
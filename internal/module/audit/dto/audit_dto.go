@@ -0,0 +1,83 @@
+package dto
+
+import "time"
+
+// ListAuditLogsRequest is the audit log query. Every filter is optional.
+type ListAuditLogsRequest struct {
+	// Pagination
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+
+	// Filters
+	UserID string `query:"user_id" validate:"omitempty,uuid"`
+	// Involving matches entries the user acted in or was the target of
+	Involving string `query:"involving" validate:"omitempty,uuid"`
+	// Action and Resource take a comma-separated list; an entry matches
+	// any of its values
+	Action     string `query:"action" validate:"omitempty,max=500"`
+	Resource   string `query:"resource" validate:"omitempty,max=1000"`
+	ResourceID string `query:"resource_id" validate:"omitempty,max=255"`
+	RequestID  string `query:"request_id" validate:"omitempty,max=255"`
+	TraceID    string `query:"trace_id" validate:"omitempty,max=64"`
+	// Contains is a JSON object the old or new value must contain
+	Contains string `query:"contains" validate:"omitempty,max=1000"`
+	// Q searches the old and new values for text
+	Q string `query:"q" validate:"omitempty,max=200"`
+	// From and To bound the entry time, inclusive, in RFC 3339
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// ExportAuditLogsRequest is the audit log export query. The filters are
+// those of ListAuditLogsRequest.
+type ExportAuditLogsRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=csv ndjson"` // csv (default) or ndjson
+	Async  bool   `query:"async"`                                        // Generate in the worker whatever the range
+
+	UserID     string `query:"user_id" validate:"omitempty,uuid"`
+	Involving  string `query:"involving" validate:"omitempty,uuid"`
+	Action     string `query:"action" validate:"omitempty,max=500"`
+	Resource   string `query:"resource" validate:"omitempty,max=1000"`
+	ResourceID string `query:"resource_id" validate:"omitempty,max=255"`
+	RequestID  string `query:"request_id" validate:"omitempty,max=255"`
+	TraceID    string `query:"trace_id" validate:"omitempty,max=64"`
+	Contains   string `query:"contains" validate:"omitempty,max=1000"`
+	Q          string `query:"q" validate:"omitempty,max=200"`
+	From       string `query:"from"`
+	To         string `query:"to"`
+}
+
+// AuditExportResponse reports an asynchronous export
+type AuditExportResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // pending or ready
+	Format string `json:"format,omitempty"`
+	// URL is the download link once the export is ready. It expires; fetch
+	// the export again for a fresh one.
+	URL string `json:"url,omitempty"`
+}
+
+// VerifyChainRequest selects the chained entries to verify
+type VerifyChainRequest struct {
+	// From is the sequence number to start at; 0 starts at the oldest
+	// stored entry. Pass a report's next_seq to continue it.
+	From  int64 `query:"from" validate:"omitempty,min=0"`
+	Limit int   `query:"limit" validate:"omitempty,min=1,max=100000"`
+}
+
+// AuditLogResponse is one audit log entry
+type AuditLogResponse struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id,omitempty"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	ResourceID string    `json:"resource_id,omitempty"`
+	OldValue   any       `json:"old_value,omitempty"`
+	NewValue   any       `json:"new_value,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"bufio"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/module/audit/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles audit log HTTP requests
+type Handler struct {
+	useCase usecase.UseCase
+}
+
+// NewHandler creates a new audit log handler
+func NewHandler(useCase usecase.UseCase) *Handler {
+	return &Handler{useCase: useCase}
+}
+
+// List handles GET /audit-logs
+func (h *Handler) List(c *fiber.Ctx) error {
+	var req dto.ListAuditLogsRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.List(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Paginated(c, result.GetItems(), result.GetMeta())
+}
+
+// VerifyChain handles GET /audit-logs/verify
+func (h *Handler) VerifyChain(c *fiber.Ctx) error {
+	var req dto.VerifyChainRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	report, err := h.useCase.VerifyChain(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, report)
+}
+
+// Export streams the entries matching the list filters as CSV or NDJSON.
+// Open-ended ranges, ranges longer than usecase.SyncExportMaxRange and
+// requests with async=true are generated by the worker instead, and the
+// response carries an ID to poll with GetExport.
+func (h *Handler) Export(c *fiber.Ctx) error {
+	var req dto.ExportAuditLogsRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+	if req.Format == "" {
+		req.Format = usecase.ExportFormatCSV
+	}
+
+	if usecase.RunsInWorker(req, time.Now()) {
+		export, err := h.useCase.StartExport(c.UserContext(), req)
+		if err != nil {
+			return response.Fail(c, err)
+		}
+		return response.Accepted(c, export)
+	}
+
+	entries, err := h.useCase.Export(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="audit-logs.`+req.Format+`"`)
+	return response.StreamBody(c, usecase.ExportContentType(req.Format), func(w *bufio.Writer) {
+		if _, err := usecase.WriteEntries(w, req.Format, entries); err != nil {
+			exportAborted(w, req.Format)
+		}
+	})
+}
+
+// exportAborted ends an export cut short by an error. The 200 status is
+// already on the wire, so the body ends with a marker line instead: a CSV
+// record holding only the code, or an NDJSON error object.
+func exportAborted(w *bufio.Writer, format string) {
+	if format == usecase.ExportFormatNDJSON {
+		_, _ = w.WriteString(`{"error":{"code":"` + response.CodeStreamAborted + `","message":"The export was interrupted"}}` + "\n")
+		return
+	}
+	_, _ = w.WriteString(response.CodeStreamAborted + "\n")
+}
+
+// GetExport reports an asynchronous export and, once it is ready, its
+// download link
+func (h *Handler) GetExport(c *fiber.Ctx) error {
+	export, err := h.useCase.GetExport(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, export)
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/module/audit/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUseCase returns page and records the request
+type stubUseCase struct {
+	page      shareddomain.CursorPage[dto.AuditLogResponse]
+	req       dto.ListAuditLogsRequest
+	verifyReq dto.VerifyChainRequest
+	exportReq dto.ExportAuditLogsRequest
+	entries   []port.AuditEntry
+	exportErr error // ends the exported entries
+	started   bool
+}
+
+func (s *stubUseCase) List(_ context.Context, req dto.ListAuditLogsRequest) (shareddomain.CursorPage[dto.AuditLogResponse], error) {
+	s.req = req
+	return s.page, nil
+}
+
+func (s *stubUseCase) VerifyChain(_ context.Context, req dto.VerifyChainRequest) (*port.AuditChainReport, error) {
+	s.verifyReq = req
+	return &port.AuditChainReport{Valid: true, Checked: 2, LastSeq: 2}, nil
+}
+
+func (s *stubUseCase) Export(_ context.Context, req dto.ExportAuditLogsRequest) (iter.Seq2[port.AuditEntry, error], error) {
+	s.exportReq = req
+	return func(yield func(port.AuditEntry, error) bool) {
+		for _, e := range s.entries {
+			if !yield(e, nil) {
+				return
+			}
+		}
+		if s.exportErr != nil {
+			yield(port.AuditEntry{}, s.exportErr)
+		}
+	}, nil
+}
+
+func (s *stubUseCase) StartExport(_ context.Context, req dto.ExportAuditLogsRequest) (*dto.AuditExportResponse, error) {
+	s.exportReq, s.started = req, true
+	return &dto.AuditExportResponse{ID: "x1", Status: usecase.ExportStatusPending, Format: req.Format}, nil
+}
+
+func (s *stubUseCase) GetExport(_ context.Context, id string) (*dto.AuditExportResponse, error) {
+	return &dto.AuditExportResponse{ID: id, Status: usecase.ExportStatusReady, URL: "https://files.example.com/x"}, nil
+}
+
+func TestList(t *testing.T) {
+	next := "cursor-2"
+	uc := &stubUseCase{page: shareddomain.CursorPage[dto.AuditLogResponse]{
+		Items:          []dto.AuditLogResponse{{ID: "e1", Action: "CREATE", Resource: "role"}},
+		PaginationMeta: shareddomain.PaginationMeta{NextCursor: &next, HasMore: true},
+	}}
+	app := fiber.New()
+	app.Get("/audit-logs", NewHandler(uc).List)
+
+	t.Run("lists a page", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/audit-logs?resource=role&action=CREATE&limit=1&cursor=cursor-1", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Data       []dto.AuditLogResponse      `json:"data"`
+			Pagination shareddomain.PaginationMeta `json:"pagination"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Data, 1)
+		assert.Equal(t, "e1", body.Data[0].ID)
+		assert.True(t, body.Pagination.HasMore)
+		assert.Equal(t, dto.ListAuditLogsRequest{Resource: "role", Action: "CREATE", Limit: 1, Cursor: "cursor-1"}, uc.req)
+	})
+
+	t.Run("validates the query", func(t *testing.T) {
+		for _, query := range []string{"?user_id=alice", "?limit=1000"} {
+			resp, err := app.Test(httptest.NewRequest("GET", "/audit-logs"+query, nil))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+}
+
+func TestVerifyChain(t *testing.T) {
+	uc := &stubUseCase{}
+	app := fiber.New()
+	app.Get("/audit-logs/verify", NewHandler(uc).VerifyChain)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/audit-logs/verify?from=3&limit=50", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data port.AuditChainReport `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.True(t, body.Data.Valid)
+	assert.Equal(t, 2, body.Data.Checked)
+	assert.Equal(t, dto.VerifyChainRequest{From: 3, Limit: 50}, uc.verifyReq)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/audit-logs/verify?from=-1", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestExport(t *testing.T) {
+	entries := []port.AuditEntry{{ID: "e1", Action: "CREATE", Resource: "role"}, {ID: "e2", Action: "DELETE", Resource: "role"}}
+	do := func(uc *stubUseCase, query string) (int, string, string) {
+		app := fiber.New()
+		app.Get("/audit-logs/export", NewHandler(uc).Export)
+		resp, err := app.Test(httptest.NewRequest("GET", "/audit-logs/export"+query, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), string(body)
+	}
+
+	t.Run("streams a short range", func(t *testing.T) {
+		uc := &stubUseCase{entries: entries}
+		status, contentType, body := do(uc, "?resource=role&from=2026-01-01T00:00:00Z&to=2026-01-02T00:00:00Z")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "text/csv; charset=utf-8", contentType)
+		assert.Equal(t, 3, strings.Count(body, "\n"), "header and two rows")
+		assert.False(t, uc.started)
+		assert.Equal(t, "role", uc.exportReq.Resource)
+	})
+
+	t.Run("a failed stream ends with a marker", func(t *testing.T) {
+		uc := &stubUseCase{entries: entries, exportErr: errors.New("db down")}
+		_, _, body := do(uc, "?format=ndjson&from=2026-01-01T00:00:00Z&to=2026-01-02T00:00:00Z")
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[2], response.CodeStreamAborted)
+	})
+
+	t.Run("hands long and open-ended ranges to the worker", func(t *testing.T) {
+		for _, query := range []string{"", "?from=2026-01-01T00:00:00Z&to=2026-03-01T00:00:00Z", "?async=true&from=2026-01-01T00:00:00Z&to=2026-01-02T00:00:00Z"} {
+			uc := &stubUseCase{}
+			status, _, body := do(uc, query)
+			assert.Equal(t, fiber.StatusAccepted, status, query)
+			assert.True(t, uc.started, query)
+			assert.Contains(t, body, `"id":"x1"`)
+			assert.Equal(t, usecase.ExportFormatCSV, uc.exportReq.Format, "defaults to csv")
+		}
+	})
+
+	t.Run("validates the query", func(t *testing.T) {
+		status, _, _ := do(&stubUseCase{}, "?format=xlsx")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+}
+
+func TestGetExport(t *testing.T) {
+	app := fiber.New()
+	app.Get("/audit-logs/exports/:id", NewHandler(&stubUseCase{}).GetExport)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/audit-logs/exports/x1", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data dto.AuditExportResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, dto.AuditExportResponse{ID: "x1", Status: usecase.ExportStatusReady, URL: "https://files.example.com/x"}, body.Data)
+}
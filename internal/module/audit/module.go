@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"github.com/14mdzk/goscratch/internal/module/audit/handler"
+	"github.com/14mdzk/goscratch/internal/module/audit/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Module represents the audit log module
+type Module struct {
+	handler *handler.Handler
+	routes  routes.Config
+	authCfg middleware.AuthConfig
+}
+
+// NewModule creates a new audit log module reading through auditor. chain
+// verifies tamper-evident entries; nil when audit.chain is off. exports
+// enables exports generated by the worker; nil when no worker runs.
+func NewModule(auditor port.Auditor, chain port.AuditChainVerifier, exports *usecase.AsyncExport, authCfg middleware.AuthConfig, routeCfg routes.Config) *Module {
+	return &Module{
+		handler: handler.NewHandler(usecase.NewUseCase(auditor, chain, exports)),
+		routes:  routeCfg,
+		authCfg: authCfg,
+	}
+}
+
+// RegisterRoutes registers audit log routes. Reading the log needs
+// audit:read and verifying its chain audit:verify, which only superadmin
+// holds by default.
+func (m *Module) RegisterRoutes(router fiber.Router) {
+	authMiddleware := middleware.Auth(m.authCfg)
+
+	r := routes.New(router, m.routes)
+	logs := r.Group("/audit-logs").Authenticated(authMiddleware)
+	logs.Get("/", m.handler.List).Require("audit:read")
+	logs.Get("/verify", m.handler.VerifyChain).Require("audit:verify")
+	logs.Get("/export", m.handler.Export).Require("audit:read")
+	logs.Get("/exports/:id", m.handler.GetExport).Require("audit:read")
+
+	r.Mount()
+}
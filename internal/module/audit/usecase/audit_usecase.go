@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// defaultVerifyLimit is how many chained entries one verification checks
+// unless the request says otherwise
+const defaultVerifyLimit = 10000
+
+// auditUseCase reads the audit log through the Auditor
+type auditUseCase struct {
+	auditor port.Auditor
+	chain   port.AuditChainVerifier
+	exports *AsyncExport
+}
+
+// NewUseCase creates a new audit log use case. chain is nil unless entries
+// are chained; exports is nil when no worker generates large exports.
+func NewUseCase(auditor port.Auditor, chain port.AuditChainVerifier, exports *AsyncExport) UseCase {
+	return &auditUseCase{auditor: auditor, chain: chain, exports: exports}
+}
+
+// List returns a page of audit entries matching req, newest first
+func (uc *auditUseCase) List(ctx context.Context, req dto.ListAuditLogsRequest) (shareddomain.CursorPage[dto.AuditLogResponse], error) {
+	limit := shareddomain.NormalizeLimit(req.Limit)
+	filter, err := buildFilter(req)
+	if err != nil {
+		return shareddomain.CursorPage[dto.AuditLogResponse]{}, err
+	}
+	filter.Limit = limit + 1 // one extra to detect another page
+
+	if req.Cursor != "" {
+		cursor, err := shareddomain.DecodeCursor(req.Cursor)
+		if err != nil || cursor == nil {
+			return shareddomain.CursorPage[dto.AuditLogResponse]{}, apperr.BadRequestf("invalid cursor")
+		}
+		filter.Cursor = cursor.LastID
+	}
+
+	entries, err := uc.auditor.Query(ctx, filter)
+	if err != nil {
+		return shareddomain.CursorPage[dto.AuditLogResponse]{}, apperr.ErrInternal.WithError(err)
+	}
+	items := make([]dto.AuditLogResponse, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, toResponse(e))
+	}
+	return shareddomain.NewCursorPage(items, limit, func(e dto.AuditLogResponse) *shareddomain.Cursor {
+		return &shareddomain.Cursor{LastID: e.ID}
+	}), nil
+}
+
+// buildFilter converts the query filters of req, leaving paging unset
+func buildFilter(req dto.ListAuditLogsRequest) (port.AuditFilter, error) {
+	filter := port.AuditFilter{
+		UserID:     req.UserID,
+		Involving:  req.Involving,
+		Resources:  splitList(req.Resource),
+		ResourceID: req.ResourceID,
+		RequestID:  req.RequestID,
+		TraceID:    req.TraceID,
+		Search:     req.Q,
+	}
+	for _, a := range splitList(req.Action) {
+		filter.Actions = append(filter.Actions, port.AuditAction(strings.ToUpper(a)))
+	}
+	if req.Contains != "" {
+		if err := json.Unmarshal([]byte(req.Contains), &filter.Contains); err != nil || len(filter.Contains) == 0 {
+			return port.AuditFilter{}, apperr.BadRequestf(`contains must be a non-empty JSON object, e.g. {"email":"a@example.com"}`)
+		}
+	}
+
+	var err error
+	if filter.StartTime, err = parseTime("from", req.From); err != nil {
+		return port.AuditFilter{}, err
+	}
+	if filter.EndTime, err = parseTime("to", req.To); err != nil {
+		return port.AuditFilter{}, err
+	}
+	if filter.StartTime != nil && filter.EndTime != nil && filter.EndTime.Before(*filter.StartTime) {
+		return port.AuditFilter{}, apperr.BadRequestf("to is before from")
+	}
+	return filter, nil
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items
+func splitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseTime parses the RFC 3339 time in query parameter name; empty is nil
+func parseTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, apperr.BadRequestf("%s must be an RFC 3339 time, e.g. 2026-01-02T15:04:05Z", name)
+	}
+	return &t, nil
+}
+
+func toResponse(e port.AuditEntry) dto.AuditLogResponse {
+	return dto.AuditLogResponse{
+		ID:         e.ID,
+		UserID:     e.UserID,
+		ActorID:    e.ActorID,
+		Action:     string(e.Action),
+		Resource:   e.Resource,
+		ResourceID: e.ResourceID,
+		OldValue:   e.OldValue,
+		NewValue:   e.NewValue,
+		IPAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		TraceID:    e.TraceID,
+		Timestamp:  e.Timestamp,
+	}
+}
+
+// VerifyChain checks the hash chain of the stored entries
+func (uc *auditUseCase) VerifyChain(ctx context.Context, req dto.VerifyChainRequest) (*port.AuditChainReport, error) {
+	if uc.chain == nil {
+		return nil, apperr.NotFoundf("audit chain is not enabled")
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultVerifyLimit
+	}
+	report, err := uc.chain.VerifyChain(ctx, req.From, limit)
+	if err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+	return report, nil
+}
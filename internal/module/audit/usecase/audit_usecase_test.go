@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditor returns entries for every query and records the filter
+type fakeAuditor struct {
+	entries []port.AuditEntry
+	err     error
+	filter  port.AuditFilter
+}
+
+func (a *fakeAuditor) Log(context.Context, port.AuditEntry) error { return nil }
+func (a *fakeAuditor) Query(_ context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	a.filter = filter
+	return a.entries, a.err
+}
+func (a *fakeAuditor) Close() error { return nil }
+
+func entries(n int) []port.AuditEntry {
+	out := make([]port.AuditEntry, n)
+	for i := range out {
+		out[i] = port.AuditEntry{ID: string(rune('a' + i)), Action: port.AuditActionUpdate, Resource: "user"}
+	}
+	return out
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("passes the filters on", func(t *testing.T) {
+		auditor := &fakeAuditor{}
+		_, err := NewUseCase(auditor, nil, nil).List(ctx, dto.ListAuditLogsRequest{
+			UserID:     "01912345-abcd-7def-8000-000000000001",
+			Involving:  "01912345-abcd-7def-8000-000000000002",
+			Action:     "delete, update",
+			Resource:   "user,role",
+			ResourceID: "42",
+			RequestID:  "req-1",
+			TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+			Contains:   `{"email":"a@example.com"}`,
+			Q:          "example",
+			From:       "2026-01-01T00:00:00Z",
+			To:         "2026-02-01T00:00:00Z",
+			Limit:      10,
+		})
+		require.NoError(t, err)
+
+		f := auditor.filter
+		assert.Equal(t, "01912345-abcd-7def-8000-000000000001", f.UserID)
+		assert.Equal(t, "01912345-abcd-7def-8000-000000000002", f.Involving)
+		assert.Equal(t, []port.AuditAction{port.AuditActionDelete, port.AuditActionUpdate}, f.Actions, "actions match in upper case")
+		assert.Equal(t, []string{"user", "role"}, f.Resources)
+		assert.Equal(t, "42", f.ResourceID)
+		assert.Equal(t, "req-1", f.RequestID)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", f.TraceID)
+		assert.Equal(t, map[string]any{"email": "a@example.com"}, f.Contains)
+		assert.Equal(t, "example", f.Search)
+		require.NotNil(t, f.StartTime)
+		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), f.StartTime.UTC())
+		require.NotNil(t, f.EndTime)
+		assert.Equal(t, 11, f.Limit, "one extra entry tells whether there is another page")
+		assert.Empty(t, f.Cursor)
+	})
+
+	t.Run("pages with a cursor", func(t *testing.T) {
+		auditor := &fakeAuditor{entries: entries(3)}
+		uc := NewUseCase(auditor, nil, nil)
+
+		page, err := uc.List(ctx, dto.ListAuditLogsRequest{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.True(t, page.HasMore)
+		require.NotNil(t, page.NextCursor)
+
+		auditor.entries = entries(1)
+		page, err = uc.List(ctx, dto.ListAuditLogsRequest{Limit: 2, Cursor: *page.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, "b", auditor.filter.Cursor, "the cursor names the last entry seen")
+		assert.False(t, page.HasMore)
+		assert.Nil(t, page.NextCursor)
+	})
+
+	t.Run("maps entries", func(t *testing.T) {
+		at := time.Now()
+		auditor := &fakeAuditor{entries: []port.AuditEntry{{
+			ID: "e1", UserID: "u1", ActorID: "a1", Action: port.AuditActionCreate, Resource: "role",
+			ResourceID: "editor", NewValue: map[string]any{"name": "editor"}, IPAddress: "10.0.0.1",
+			UserAgent: "curl", RequestID: "req-1", TraceID: "t1", Timestamp: at,
+		}}}
+		page, err := NewUseCase(auditor, nil, nil).List(ctx, dto.ListAuditLogsRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, []dto.AuditLogResponse{{
+			ID: "e1", UserID: "u1", ActorID: "a1", Action: "CREATE", Resource: "role",
+			ResourceID: "editor", NewValue: map[string]any{"name": "editor"}, IPAddress: "10.0.0.1",
+			UserAgent: "curl", RequestID: "req-1", TraceID: "t1", Timestamp: at,
+		}}, page.Items)
+		assert.Equal(t, shareddomain.DefaultLimit+1, auditor.filter.Limit)
+	})
+
+	t.Run("rejects bad input", func(t *testing.T) {
+		uc := NewUseCase(&fakeAuditor{}, nil, nil)
+		for name, req := range map[string]dto.ListAuditLogsRequest{
+			"cursor":   {Cursor: "not base64!"},
+			"from":     {From: "yesterday"},
+			"to":       {To: "2026-01-01"},
+			"to first": {From: "2026-02-01T00:00:00Z", To: "2026-01-01T00:00:00Z"},
+			"contains": {Contains: `"a@example.com"`},
+			"empty":    {Contains: `{}`},
+		} {
+			_, err := uc.List(ctx, req)
+			var appErr *apperr.Error
+			require.ErrorAs(t, err, &appErr, name)
+			assert.Equal(t, apperr.CodeBadRequest, appErr.Code, name)
+		}
+	})
+
+	t.Run("query failure", func(t *testing.T) {
+		_, err := NewUseCase(&fakeAuditor{err: errors.New("db down")}, nil, nil).List(ctx, dto.ListAuditLogsRequest{})
+		var appErr *apperr.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperr.CodeInternalError, appErr.Code)
+	})
+}
+
+// fakeChain returns report and records the range asked for
+type fakeChain struct {
+	report  port.AuditChainReport
+	err     error
+	fromSeq int64
+	limit   int
+}
+
+func (c *fakeChain) VerifyChain(_ context.Context, fromSeq int64, limit int) (*port.AuditChainReport, error) {
+	c.fromSeq, c.limit = fromSeq, limit
+	return &c.report, c.err
+}
+
+func TestVerifyChain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports the chain", func(t *testing.T) {
+		chain := &fakeChain{report: port.AuditChainReport{Valid: true, Checked: 3}}
+		report, err := NewUseCase(&fakeAuditor{}, chain, nil).VerifyChain(ctx, dto.VerifyChainRequest{From: 5})
+		require.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, int64(5), chain.fromSeq)
+		assert.Equal(t, defaultVerifyLimit, chain.limit)
+	})
+
+	t.Run("not found without a chain", func(t *testing.T) {
+		_, err := NewUseCase(&fakeAuditor{}, nil, nil).VerifyChain(ctx, dto.VerifyChainRequest{})
+		var appErr *apperr.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+	})
+
+	t.Run("read failure", func(t *testing.T) {
+		_, err := NewUseCase(&fakeAuditor{}, &fakeChain{err: errors.New("db down")}, nil).VerifyChain(ctx, dto.VerifyChainRequest{Limit: 10})
+		var appErr *apperr.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperr.CodeInternalError, appErr.Code)
+	})
+}
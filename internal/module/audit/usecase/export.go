@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/google/uuid"
+)
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// Asynchronous export states
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+)
+
+// SyncExportMaxRange is the longest time range exported in the request.
+// Longer or open-ended ranges are generated by the worker.
+const SyncExportMaxRange = 7 * 24 * time.Hour
+
+// exportPageSize is how many entries are read from the auditor at a time
+const exportPageSize = 500
+
+// exportFlushEvery is how many entries are written between flushes,
+// matching response.StreamArray.
+const exportFlushEvery = 64
+
+// exportURLTTL is how long a download link returned by GetExport is valid.
+const exportURLTTL = 15 * time.Minute
+
+// exportColumns is the CSV header row. NDJSON lines carry the same fields as
+// dto.AuditLogResponse.
+var exportColumns = []string{"id", "timestamp", "user_id", "actor_id", "action", "resource", "resource_id", "ip_address", "user_agent", "request_id", "trace_id", "old_value", "new_value"}
+
+// AsyncExport enables exports generated by the worker. Publisher enqueues
+// the audit.export job; Storage holds the files the worker writes.
+type AsyncExport struct {
+	Publisher JobPublisher
+	Storage   port.Storage
+}
+
+// ExportContentType returns the media type of an export in format.
+func ExportContentType(format string) string {
+	if format == ExportFormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportPath is where an asynchronous export is stored.
+func ExportPath(exportID, format string) string {
+	return "exports/audit/" + exportID + "." + format
+}
+
+// RunsInWorker reports whether req is exported by the worker: when it asks
+// to be, or its range is open-ended or longer than SyncExportMaxRange. A
+// missing to is now. Invalid times report false, leaving Export to reject
+// them.
+func RunsInWorker(req dto.ExportAuditLogsRequest, now time.Time) bool {
+	if req.Async || req.From == "" {
+		return true
+	}
+	from, err := time.Parse(time.RFC3339, req.From)
+	if err != nil {
+		return false
+	}
+	to := now
+	if req.To != "" {
+		if to, err = time.Parse(time.RFC3339, req.To); err != nil {
+			return false
+		}
+	}
+	return to.Sub(from) > SyncExportMaxRange
+}
+
+// Entries reads every entry matching filter from auditor, newest first, a
+// page at a time. It stops at the first query error and yields it.
+func Entries(ctx context.Context, auditor AuditQuerier, filter port.AuditFilter) iter.Seq2[port.AuditEntry, error] {
+	return func(yield func(port.AuditEntry, error) bool) {
+		filter.Limit = exportPageSize
+		for {
+			page, err := auditor.Query(ctx, filter)
+			if err != nil {
+				yield(port.AuditEntry{}, err)
+				return
+			}
+			for _, e := range page {
+				if !yield(e, nil) {
+					return
+				}
+			}
+			if len(page) < exportPageSize {
+				return
+			}
+			filter.Cursor = page[len(page)-1].ID
+		}
+	}
+}
+
+// WriteEntries writes entries to w as CSV (the default) or NDJSON and
+// returns how many were written. When w has a Flush method it is flushed
+// every few dozen entries, so a streamed response starts arriving before the
+// export is complete.
+//
+// It stops at the first iteration or write error and returns it; what has
+// been written stays written.
+func WriteEntries(w io.Writer, format string, entries iter.Seq2[port.AuditEntry, error]) (int, error) {
+	var enc exportEncoder
+	if format == ExportFormatNDJSON {
+		enc = &ndjsonEncoder{w: w, enc: json.NewEncoder(w)}
+	} else {
+		enc = &csvEncoder{w: w, csv: csv.NewWriter(w)}
+	}
+
+	if err := enc.begin(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for e, err := range entries {
+		if err != nil {
+			_ = enc.flush()
+			return n, err
+		}
+		if err := enc.write(toResponse(e)); err != nil {
+			return n, err
+		}
+		n++
+		if n%exportFlushEvery == 0 {
+			if err := enc.flush(); err != nil {
+				return n, err // consumer went away; stop reading entries
+			}
+		}
+	}
+	return n, enc.flush()
+}
+
+// exportEncoder writes one export format.
+type exportEncoder interface {
+	begin() error
+	write(e dto.AuditLogResponse) error
+	flush() error
+}
+
+type csvEncoder struct {
+	w   io.Writer
+	csv *csv.Writer
+}
+
+func (e *csvEncoder) begin() error { return e.csv.Write(exportColumns) }
+
+func (e *csvEncoder) write(r dto.AuditLogResponse) error {
+	return e.csv.Write([]string{
+		r.ID, r.Timestamp.Format(time.RFC3339Nano), r.UserID, r.ActorID, r.Action, r.Resource, r.ResourceID,
+		r.IPAddress, r.UserAgent, r.RequestID, r.TraceID, jsonOrEmpty(r.OldValue), jsonOrEmpty(r.NewValue),
+	})
+}
+
+func (e *csvEncoder) flush() error {
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	return flushWriter(e.w)
+}
+
+type ndjsonEncoder struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+// write relies on json.Encoder ending every value with a newline.
+func (e *ndjsonEncoder) write(r dto.AuditLogResponse) error { return e.enc.Encode(r) }
+
+func (e *ndjsonEncoder) flush() error { return flushWriter(e.w) }
+
+// flushWriter flushes w if it buffers.
+func flushWriter(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// jsonOrEmpty encodes an old or new value for a CSV cell
+func jsonOrEmpty(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// exportFormat returns the requested format, defaulting to CSV.
+func exportFormat(req dto.ExportAuditLogsRequest) string {
+	if req.Format == "" {
+		return ExportFormatCSV
+	}
+	return req.Format
+}
+
+// exportFilter converts the export query to an auditor filter.
+func exportFilter(req dto.ExportAuditLogsRequest) (port.AuditFilter, error) {
+	return buildFilter(dto.ListAuditLogsRequest{
+		UserID:     req.UserID,
+		Involving:  req.Involving,
+		Action:     req.Action,
+		Resource:   req.Resource,
+		ResourceID: req.ResourceID,
+		RequestID:  req.RequestID,
+		TraceID:    req.TraceID,
+		Contains:   req.Contains,
+		Q:          req.Q,
+		From:       req.From,
+		To:         req.To,
+	})
+}
+
+// Export validates req and returns the entries it matches, read as they are
+// consumed. The export itself is audited once the entries run out or the
+// consumer stops, with how many were read.
+func (uc *auditUseCase) Export(ctx context.Context, req dto.ExportAuditLogsRequest) (iter.Seq2[port.AuditEntry, error], error) {
+	filter, err := exportFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(port.AuditEntry, error) bool) {
+		n, completed := 0, false
+		defer func() {
+			entry := port.NewAuditEntry(ctx, port.AuditActionRead, "audit_log", "")
+			entry.Metadata = map[string]any{
+				"export":    exportFormat(req),
+				"rows":      n,
+				"completed": completed,
+			}
+			_ = uc.auditor.Log(ctx, entry)
+		}()
+
+		for e, err := range Entries(ctx, uc.auditor, filter) {
+			if !yield(e, err) || err != nil {
+				return
+			}
+			n++
+		}
+		completed = true
+	}, nil
+}
+
+// StartExport enqueues an audit.export job that writes the export to
+// storage, and returns its ID for GetExport.
+func (uc *auditUseCase) StartExport(ctx context.Context, req dto.ExportAuditLogsRequest) (*dto.AuditExportResponse, error) {
+	if uc.exports == nil {
+		return nil, apperr.ErrServiceUnavailable.WithMessage("Asynchronous exports are not enabled; narrow the export to at most 7 days")
+	}
+
+	filter, err := exportFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	format := exportFormat(req)
+	payload := worker.AuditExportPayload{
+		ExportID:   uuid.NewString(),
+		Format:     format,
+		UserID:     filter.UserID,
+		Involving:  filter.Involving,
+		Resources:  filter.Resources,
+		ResourceID: filter.ResourceID,
+		RequestID:  filter.RequestID,
+		TraceID:    filter.TraceID,
+		Contains:   filter.Contains,
+		Search:     filter.Search,
+		From:       filter.StartTime,
+		To:         filter.EndTime,
+	}
+	for _, a := range filter.Actions {
+		payload.Actions = append(payload.Actions, string(a))
+	}
+	if err := uc.exports.Publisher.Publish(ctx, worker.JobTypeAuditExport, payload); err != nil {
+		return nil, apperr.Internalf("failed to queue audit export")
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionRead, "audit_log", "")
+	entry.Metadata = map[string]any{
+		"export":    format,
+		"export_id": payload.ExportID,
+		"async":     true,
+	}
+	_ = uc.auditor.Log(ctx, entry)
+
+	return &dto.AuditExportResponse{ID: payload.ExportID, Status: ExportStatusPending, Format: format}, nil
+}
+
+// GetExport reports whether an asynchronous export has been written and,
+// once it has, returns a download link. The worker uploads the file only
+// when the export is complete; an export whose job failed stays pending.
+func (uc *auditUseCase) GetExport(ctx context.Context, id string) (*dto.AuditExportResponse, error) {
+	if uc.exports == nil {
+		return nil, apperr.ErrServiceUnavailable.WithMessage("Asynchronous exports are not enabled")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.NotFoundf("export %s not found", id)
+	}
+
+	for _, format := range []string{ExportFormatCSV, ExportFormatNDJSON} {
+		path := ExportPath(id, format)
+		ok, err := uc.exports.Storage.Exists(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up export: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		url, err := uc.exports.Storage.GetURL(ctx, path, exportURLTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign export link: %w", err)
+		}
+		return &dto.AuditExportResponse{ID: id, Status: ExportStatusReady, Format: format, URL: url}, nil
+	}
+
+	return &dto.AuditExportResponse{ID: id, Status: ExportStatusPending}, nil
+}
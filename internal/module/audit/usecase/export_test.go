@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedAuditor serves entries a page at a time after the filter's cursor
+// and records what is logged
+type pagedAuditor struct {
+	entries []port.AuditEntry
+	err     error
+	// errAfter is how many queries succeed before err is returned
+	errAfter int
+	queries  int
+	logged   []port.AuditEntry
+}
+
+func (a *pagedAuditor) Log(_ context.Context, e port.AuditEntry) error {
+	a.logged = append(a.logged, e)
+	return nil
+}
+
+func (a *pagedAuditor) Query(_ context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	a.queries++
+	if a.err != nil && a.queries > a.errAfter {
+		return nil, a.err
+	}
+	start := 0
+	for i, e := range a.entries {
+		if e.ID == filter.Cursor {
+			start = i + 1
+		}
+	}
+	end := min(start+filter.Limit, len(a.entries))
+	return a.entries[start:end], nil
+}
+
+func (a *pagedAuditor) Close() error { return nil }
+
+func exportEntries(n int) []port.AuditEntry {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	out := make([]port.AuditEntry, n)
+	for i := range out {
+		out[i] = port.AuditEntry{
+			ID:        fmt.Sprintf("e%04d", i),
+			UserID:    "u1",
+			Action:    port.AuditActionUpdate,
+			Resource:  "user",
+			NewValue:  map[string]any{"name": "A, \"quoted\""},
+			Timestamp: at,
+		}
+	}
+	return out
+}
+
+func TestEntries(t *testing.T) {
+	auditor := &pagedAuditor{entries: exportEntries(exportPageSize*2 + 1)}
+
+	var ids []string
+	for e, err := range Entries(context.Background(), auditor, port.AuditFilter{}) {
+		require.NoError(t, err)
+		ids = append(ids, e.ID)
+	}
+	assert.Len(t, ids, exportPageSize*2+1)
+	assert.Equal(t, "e0000", ids[0])
+	assert.Equal(t, 3, auditor.queries)
+
+	auditor = &pagedAuditor{err: errors.New("db down")}
+	for _, err := range Entries(context.Background(), auditor, port.AuditFilter{}) {
+		assert.EqualError(t, err, "db down")
+	}
+}
+
+func TestWriteEntries(t *testing.T) {
+	all := exportEntries(100) // crosses a flush boundary
+	seq := func(yield func(port.AuditEntry, error) bool) {
+		for _, e := range all {
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := WriteEntries(&buf, ExportFormatCSV, seq)
+		require.NoError(t, err)
+		assert.Equal(t, 100, n)
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 101)
+		assert.Equal(t, exportColumns, records[0])
+		assert.Equal(t, []string{"e0000", "2026-01-02T03:04:05Z", "u1", "", "UPDATE", "user", "", "", "", "", "", "", `{"name":"A, \"quoted\""}`}, records[1])
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := WriteEntries(&buf, ExportFormatNDJSON, seq)
+		require.NoError(t, err)
+		assert.Equal(t, 100, n)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 100)
+		var first dto.AuditLogResponse
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		assert.Equal(t, "e0000", first.ID)
+		assert.Equal(t, "UPDATE", first.Action)
+	})
+
+	t.Run("stops at an error", func(t *testing.T) {
+		failing := func(yield func(port.AuditEntry, error) bool) {
+			if yield(all[0], nil) {
+				yield(port.AuditEntry{}, errors.New("connection reset"))
+			}
+		}
+		var buf bytes.Buffer
+		n, err := WriteEntries(&buf, ExportFormatCSV, failing)
+		assert.EqualError(t, err, "connection reset")
+		assert.Equal(t, 1, n)
+	})
+}
+
+func TestRunsInWorker(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		req  dto.ExportAuditLogsRequest
+		want bool
+	}{
+		{"open-ended", dto.ExportAuditLogsRequest{}, true},
+		{"asked to", dto.ExportAuditLogsRequest{Async: true, From: "2026-10-14T00:00:00Z"}, true},
+		{"short range", dto.ExportAuditLogsRequest{From: "2026-10-01T00:00:00Z", To: "2026-10-08T00:00:00Z"}, false},
+		{"long range", dto.ExportAuditLogsRequest{From: "2026-10-01T00:00:00Z", To: "2026-10-08T00:00:01Z"}, true},
+		{"until now", dto.ExportAuditLogsRequest{From: "2026-10-01T00:00:00Z"}, true},
+		{"invalid time", dto.ExportAuditLogsRequest{From: "yesterday"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, RunsInWorker(tc.req, now))
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads every entry and audits the export", func(t *testing.T) {
+		auditor := &pagedAuditor{entries: exportEntries(3)}
+		seq, err := NewUseCase(auditor, nil, nil).Export(ctx, dto.ExportAuditLogsRequest{Format: ExportFormatNDJSON, From: "2026-01-01T00:00:00Z"})
+		require.NoError(t, err)
+
+		n, err := WriteEntries(&bytes.Buffer{}, ExportFormatNDJSON, seq)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		require.Len(t, auditor.logged, 1)
+		assert.Equal(t, port.AuditActionRead, auditor.logged[0].Action)
+		assert.Equal(t, "audit_log", auditor.logged[0].Resource)
+		assert.Equal(t, map[string]any{"export": ExportFormatNDJSON, "rows": 3, "completed": true}, auditor.logged[0].Metadata)
+	})
+
+	t.Run("a read failing mid-stream fails the export", func(t *testing.T) {
+		auditor := &pagedAuditor{entries: exportEntries(exportPageSize*2 + 1), err: errors.New("conn reset"), errAfter: 1}
+		seq, err := NewUseCase(auditor, nil, nil).Export(ctx, dto.ExportAuditLogsRequest{From: "2026-01-01T00:00:00Z"})
+		require.NoError(t, err)
+
+		n, err := WriteEntries(&bytes.Buffer{}, ExportFormatCSV, seq)
+		assert.EqualError(t, err, "conn reset")
+		assert.Equal(t, exportPageSize, n)
+
+		require.Len(t, auditor.logged, 1)
+		assert.Equal(t, map[string]any{"export": ExportFormatCSV, "rows": exportPageSize, "completed": false}, auditor.logged[0].Metadata)
+	})
+
+	t.Run("rejects an invalid filter before reading", func(t *testing.T) {
+		auditor := &pagedAuditor{}
+		_, err := NewUseCase(auditor, nil, nil).Export(ctx, dto.ExportAuditLogsRequest{From: "2026-01-02T00:00:00Z", To: "2026-01-01T00:00:00Z"})
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+		assert.Zero(t, auditor.queries)
+	})
+}
+
+type exportPublisher struct {
+	jobType string
+	payload any
+	err     error
+}
+
+func (p *exportPublisher) Publish(_ context.Context, jobType string, payload any) error {
+	p.jobType, p.payload = jobType, payload
+	return p.err
+}
+
+// exportStorage is a port.Storage holding a set of paths; methods the export
+// does not use are left to the nil embedded interface.
+type exportStorage struct {
+	port.Storage
+	files map[string]bool
+}
+
+func (s *exportStorage) Exists(_ context.Context, path string) (bool, error) {
+	return s.files[path], nil
+}
+
+func (s *exportStorage) GetURL(_ context.Context, path string, _ time.Duration) (string, error) {
+	return "https://files.example.com/" + path + "?sig=x", nil
+}
+
+func TestStartExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("enqueues the job with the filters", func(t *testing.T) {
+		pub := &exportPublisher{}
+		auditor := &pagedAuditor{}
+		uc := NewUseCase(auditor, nil, &AsyncExport{Publisher: pub, Storage: &exportStorage{}})
+
+		resp, err := uc.StartExport(ctx, dto.ExportAuditLogsRequest{Action: "update,login", Contains: `{"email":"a@example.com"}`, From: "2026-01-01T00:00:00Z"})
+		require.NoError(t, err)
+		assert.Equal(t, ExportStatusPending, resp.Status)
+		assert.Equal(t, ExportFormatCSV, resp.Format)
+
+		assert.Equal(t, worker.JobTypeAuditExport, pub.jobType)
+		payload := pub.payload.(worker.AuditExportPayload)
+		assert.Equal(t, resp.ID, payload.ExportID)
+		assert.Equal(t, []string{"UPDATE", "LOGIN"}, payload.Actions)
+		assert.Equal(t, map[string]any{"email": "a@example.com"}, payload.Contains)
+		require.NotNil(t, payload.From)
+		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *payload.From)
+		assert.Nil(t, payload.To)
+
+		require.Len(t, auditor.logged, 1)
+		assert.Equal(t, resp.ID, auditor.logged[0].Metadata["export_id"])
+	})
+
+	t.Run("a failed publish is an internal error", func(t *testing.T) {
+		uc := NewUseCase(&pagedAuditor{}, nil, &AsyncExport{Publisher: &exportPublisher{err: errors.New("broker down")}, Storage: &exportStorage{}})
+		_, err := uc.StartExport(ctx, dto.ExportAuditLogsRequest{})
+		assert.ErrorIs(t, err, apperr.ErrInternal)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := NewUseCase(&pagedAuditor{}, nil, nil).StartExport(ctx, dto.ExportAuditLogsRequest{})
+		assert.ErrorIs(t, err, apperr.ErrServiceUnavailable)
+	})
+}
+
+func TestGetExport(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.NewString()
+	store := &exportStorage{files: map[string]bool{}}
+	uc := NewUseCase(&pagedAuditor{}, nil, &AsyncExport{Publisher: &exportPublisher{}, Storage: store})
+
+	resp, err := uc.GetExport(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatusPending, resp.Status)
+	assert.Empty(t, resp.URL)
+
+	store.files[ExportPath(id, ExportFormatCSV)] = true
+	resp, err = uc.GetExport(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatusReady, resp.Status)
+	assert.Equal(t, ExportFormatCSV, resp.Format)
+	assert.Equal(t, "https://files.example.com/exports/audit/"+id+".csv?sig=x", resp.URL)
+
+	_, err = uc.GetExport(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
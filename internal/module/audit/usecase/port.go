@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"iter"
+
+	"github.com/14mdzk/goscratch/internal/module/audit/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+)
+
+// UseCase defines the audit log operations the handler depends on
+type UseCase interface {
+	List(ctx context.Context, req dto.ListAuditLogsRequest) (shareddomain.CursorPage[dto.AuditLogResponse], error)
+	VerifyChain(ctx context.Context, req dto.VerifyChainRequest) (*port.AuditChainReport, error)
+	Export(ctx context.Context, req dto.ExportAuditLogsRequest) (iter.Seq2[port.AuditEntry, error], error)
+	StartExport(ctx context.Context, req dto.ExportAuditLogsRequest) (*dto.AuditExportResponse, error)
+	GetExport(ctx context.Context, id string) (*dto.AuditExportResponse, error)
+}
+
+// AuditQuerier reads audit entries. Every port.Auditor satisfies it.
+type AuditQuerier interface {
+	Query(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntry, error)
+}
+
+// JobPublisher enqueues background jobs. The concrete *worker.Publisher
+// satisfies it.
+type JobPublisher interface {
+	Publish(ctx context.Context, jobType string, payload any) error
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a key a user created for a machine-to-machine caller. Requests
+// made with it act as the user, limited to Scopes.
+type APIKey struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Name is the label the user gave the key, e.g. "CI deploy"
+	Name string
+	// Prefix identifies the key; it is shown in listings and is not secret
+	Prefix string
+	// SecretHash is the SHA-256 of the key's secret part
+	SecretHash []byte
+	// Scopes are the permissions ("object:action", "object:*" or "*") the key
+	// may use, on top of the user's own
+	Scopes     []string
+	ExpiresAt  *time.Time // nil when the key does not expire
+	CreatedAt  time.Time
+	LastUsedAt *time.Time // nil until the key is first used
+	RevokedAt  *time.Time // nil while the key is active
+}
+
+// Active reports whether the key can authenticate at now
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
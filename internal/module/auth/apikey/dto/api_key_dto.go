@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// CreateAPIKeyRequest creates an API key for the caller
+type CreateAPIKeyRequest struct {
+	// Name labels the key in the caller's key list
+	Name string `json:"name" validate:"required,max=100"`
+	// Scopes are the permissions the key may use: "object:action",
+	// "object:*" or "*"
+	Scopes []string `json:"scopes" validate:"required,min=1,max=50,dive,required,max=100"`
+	// ExpiresAt is optional; api_key.max_ttl applies when it is omitted
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse describes one of the caller's API keys. The secret is
+// never returned after creation.
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	RevokedAt  *string  `json:"revoked_at"`
+}
+
+// CreateAPIKeyResponse is the new key. Key is shown only once.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
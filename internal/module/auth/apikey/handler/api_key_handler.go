@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles API key management HTTP requests
+type Handler struct {
+	useCase usecase.UseCase
+}
+
+// NewHandler creates a new API key handler
+func NewHandler(useCase usecase.UseCase) *Handler {
+	return &Handler{useCase: useCase}
+}
+
+// Create issues a new API key for the caller
+func (h *Handler) Create(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+	// A key would outlive the impersonation token it was created with.
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot create an API key for an impersonated user")
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.Create(c.UserContext(), callerID, req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Created(c, result)
+}
+
+// List returns the caller's API keys
+func (h *Handler) List(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+
+	result, err := h.useCase.List(c.UserContext(), callerID)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// Revoke revokes one of the caller's API keys
+func (h *Handler) Revoke(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+
+	if err := h.useCase.Revoke(c.UserContext(), callerID, c.Params("id")); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.NoContent(c)
+}
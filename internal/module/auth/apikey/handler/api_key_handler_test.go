@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/usecase"
+)
+
+// createStub records the user a key was created for
+type createStub struct {
+	usecase.UseCase
+	userID string
+}
+
+func (s *createStub) Create(_ context.Context, userID string, _ dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error) {
+	s.userID = userID
+	return &dto.CreateAPIKeyResponse{}, nil
+}
+
+func TestCreate_Impersonation(t *testing.T) {
+	const userID = "user-1"
+
+	send := func(stub *createStub, actorID string) *http.Response {
+		t.Helper()
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID)
+			if actorID != "" {
+				c.Locals("actor_id", actorID)
+			}
+			return c.Next()
+		})
+		app.Post("/auth/api-keys", NewHandler(stub).Create)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/api-keys", strings.NewReader(`{"name":"CI","scopes":["*"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("creates a key for the caller", func(t *testing.T) {
+		stub := &createStub{}
+		assert.Equal(t, http.StatusCreated, send(stub, "").StatusCode)
+		assert.Equal(t, userID, stub.userID)
+	})
+
+	t.Run("refused while impersonating", func(t *testing.T) {
+		stub := &createStub{}
+		assert.Equal(t, http.StatusForbidden, send(stub, "admin-1").StatusCode)
+		assert.Empty(t, stub.userID)
+	})
+}
@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/handler"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/repository"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Module represents the API key module
+type Module struct {
+	handler  *handler.Handler
+	verifier middleware.APIKeyVerifier
+	header   string
+	authCfg  middleware.AuthConfig
+}
+
+// NewModule creates a new API key module.
+// users is shared with the auth and user modules and loads each key's owner.
+func NewModule(repo *repository.Repository, users usecase.UserLookup, auditor port.Auditor, authCfg middleware.AuthConfig, cfg config.APIKeyConfig) *Module {
+	uc := usecase.NewUseCase(repo, users, cfg)
+
+	return &Module{
+		handler:  handler.NewHandler(usecase.NewAuditedUseCase(uc, auditor)),
+		verifier: uc,
+		header:   cfg.Header,
+		authCfg:  authCfg,
+	}
+}
+
+// AuthConfig returns the configuration for middleware.APIKeyAuth. Set it as
+// routes.Config.APIKeys so route builder groups accept API keys.
+func (m *Module) AuthConfig() *middleware.APIKeyAuthConfig {
+	return &middleware.APIKeyAuthConfig{Verifier: m.verifier, Header: m.header}
+}
+
+// RegisterRoutes registers API key management routes under /auth/api-keys.
+// They require a JWT: an API key cannot mint or revoke keys, so a leaked key
+// cannot be used to keep access after it is revoked.
+func (m *Module) RegisterRoutes(router fiber.Router) {
+	authMiddleware := middleware.Auth(m.authCfg)
+	group := router.Group("/auth/api-keys")
+
+	group.Post("/", authMiddleware, m.handler.Create)
+	group.Get("/", authMiddleware, m.handler.List)
+	group.Delete("/:id", authMiddleware, m.handler.Revoke)
+}
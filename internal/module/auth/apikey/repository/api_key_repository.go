@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/repository/sqlc"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores API keys in api_keys using SQLC-generated queries. Like
+// the user repository it is TX-aware.
+type Repository struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithQueryTimeout bounds every query issued by the repository to d (see
+// database.WithQueryTimeout). Zero disables the per-query deadline.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(r *Repository) {
+		r.queryTimeout = d
+	}
+}
+
+// NewRepository creates a new API key repository
+func NewRepository(pool *pgxpool.Pool, opts ...Option) *Repository {
+	r := &Repository{pool: pool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// queries returns a *sqlc.Queries bound to the transaction in ctx, or to the
+// pool when no transaction is active.
+func (r *Repository) queries(ctx context.Context) *sqlc.Queries {
+	return sqlc.New(database.DBFromContext(ctx, r.pool))
+}
+
+// Create stores a new API key. key.ID and key.CreatedAt are set by the
+// database.
+func (r *Repository) Create(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("insert", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "CreateAPIKey", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	params := sqlc.CreateAPIKeyParams{
+		UserID:     pgutil.UUIDToPgtype(key.UserID),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		SecretHash: key.SecretHash,
+		Scopes:     key.Scopes,
+	}
+	if key.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *key.ExpiresAt, Valid: true}
+	}
+
+	row, err := r.queries(ctx).CreateAPIKey(ctx, params)
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to create API key: %w", err), "API key")
+	}
+
+	return sqlcAPIKeyToDomain(&row), nil
+}
+
+// GetByPrefix returns the key with the given prefix, revoked or not
+func (r *Repository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "GetAPIKeyByPrefix", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	row, err := r.queries(ctx).GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to get API key: %w", err), "API key")
+	}
+
+	return sqlcAPIKeyToDomain(&row), nil
+}
+
+// ListByUser returns the user's keys, revoked ones included, oldest first
+func (r *Repository) ListByUser(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ListAPIKeysByUser", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return []domain.APIKey{}, nil
+	}
+
+	rows, err := r.queries(ctx).ListAPIKeysByUser(ctx, pgutil.UUIDToPgtype(uid))
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]domain.APIKey, 0, len(rows))
+	for i := range rows {
+		keys = append(keys, *sqlcAPIKeyToDomain(&rows[i]))
+	}
+	return keys, nil
+}
+
+// CountActiveByUser returns how many of the user's keys are neither revoked
+// nor expired
+func (r *Repository) CountActiveByUser(ctx context.Context, userID string) (int, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "CountActiveAPIKeysByUser", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, nil
+	}
+
+	n, err := r.queries(ctx).CountActiveAPIKeysByUser(ctx, pgutil.UUIDToPgtype(uid))
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	return int(n), nil
+}
+
+// RecordUse sets last_used_at
+func (r *Repository) RecordUse(ctx context.Context, id string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "UpdateAPIKeyLastUsed", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	kid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("API key %s not found", id)
+	}
+
+	if err := r.queries(ctx).UpdateAPIKeyLastUsed(ctx, pgutil.UUIDToPgtype(kid)); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	return nil
+}
+
+// Revoke marks one of the user's active keys revoked. Another user's key, or
+// one already revoked, is reported as not found.
+func (r *Repository) Revoke(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "RevokeAPIKey", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	kid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("API key %s not found", id)
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return apperr.NotFoundf("API key %s not found", id)
+	}
+
+	n, err := r.queries(ctx).RevokeAPIKey(ctx, sqlc.RevokeAPIKeyParams{
+		ID:     pgutil.UUIDToPgtype(kid),
+		UserID: pgutil.UUIDToPgtype(uid),
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if n == 0 {
+		return apperr.NotFoundf("API key %s not found", id)
+	}
+	return nil
+}
+
+// RevokeAllForUser marks every active key of the user revoked and returns
+// how many it revoked
+func (r *Repository) RevokeAllForUser(ctx context.Context, userID string) (int64, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "api_keys", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "RevokeAPIKeysByUser", "api_keys")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, nil
+	}
+
+	n, err := r.queries(ctx).RevokeAPIKeysByUser(ctx, pgutil.UUIDToPgtype(uid))
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return 0, fmt.Errorf("failed to revoke API keys: %w", err)
+	}
+	return n, nil
+}
+
+// sqlcAPIKeyToDomain converts a SQLC row to a domain API key
+func sqlcAPIKeyToDomain(k *sqlc.ApiKey) *domain.APIKey {
+	key := &domain.APIKey{
+		ID:         pgutil.PgtypeToUUID(k.ID),
+		UserID:     pgutil.PgtypeToUUID(k.UserID),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		SecretHash: k.SecretHash,
+		Scopes:     k.Scopes,
+		ExpiresAt:  timestamptzPtr(k.ExpiresAt),
+		LastUsedAt: timestamptzPtr(k.LastUsedAt),
+		RevokedAt:  timestamptzPtr(k.RevokedAt),
+	}
+	if k.CreatedAt.Valid {
+		key.CreatedAt = k.CreatedAt.Time
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	return key
+}
+
+// timestamptzPtr returns nil for a NULL timestamp
+func timestamptzPtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
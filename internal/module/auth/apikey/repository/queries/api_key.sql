@@ -0,0 +1,37 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, prefix, secret_hash, scopes, expires_at, created_at, last_used_at, revoked_at;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT id, user_id, name, prefix, secret_hash, scopes, expires_at, created_at, last_used_at, revoked_at
+FROM api_keys
+WHERE prefix = $1;
+
+-- name: ListAPIKeysByUser :many
+SELECT id, user_id, name, prefix, secret_hash, scopes, expires_at, created_at, last_used_at, revoked_at
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC;
+
+-- name: CountActiveAPIKeysByUser :one
+SELECT COUNT(*)
+FROM api_keys
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: UpdateAPIKeyLastUsed :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeAPIKeysByUser :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
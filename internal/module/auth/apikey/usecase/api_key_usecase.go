@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/google/uuid"
+)
+
+// A key reads "gsk_<prefix>_<secret>". The prefix is 12 hex characters that
+// find the key's row; the secret is 32 random bytes, base64url-encoded, of
+// which only the SHA-256 is stored. The secret is random enough that a fast
+// hash is safe, which keeps per-request verification cheap.
+const (
+	keyTag       = "gsk_"
+	prefixBytes  = 6
+	secretBytes  = 32
+	prefixLength = prefixBytes * 2
+)
+
+// lastUsedInterval throttles last_used_at updates: a key in constant use
+// writes at most once per interval.
+const lastUsedInterval = time.Minute
+
+var (
+	// errInvalidKey covers unknown, mistyped and revoked keys and keys of
+	// inactive users, or of users who must change their password, alike.
+	errInvalidKey = apperr.ErrUnauthorized.WithMessage("Invalid API key")
+	errExpiredKey = apperr.ErrUnauthorized.WithMessage("API key has expired")
+)
+
+type apiKeyUseCase struct {
+	repo       Repository
+	users      UserLookup
+	maxPerUser int
+	maxTTL     time.Duration
+	now        func() time.Time
+}
+
+// NewUseCase creates the API key use case
+func NewUseCase(repo Repository, users UserLookup, cfg config.APIKeyConfig) UseCase {
+	return &apiKeyUseCase{
+		repo:       repo,
+		users:      users,
+		maxPerUser: cfg.MaxPerUser,
+		maxTTL:     time.Duration(cfg.MaxTTL),
+		now:        time.Now,
+	}
+}
+
+// Create issues a new key for the caller
+func (uc *apiKeyUseCase) Create(ctx context.Context, userID string, req dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", userID)
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	expiresAt := req.ExpiresAt
+	switch {
+	case expiresAt != nil && !expiresAt.After(now):
+		return nil, apperr.BadRequestf("expires_at must be in the future")
+	case uc.maxTTL > 0 && expiresAt == nil:
+		t := now.Add(uc.maxTTL)
+		expiresAt = &t
+	case uc.maxTTL > 0 && expiresAt.After(now.Add(uc.maxTTL)):
+		return nil, apperr.BadRequestf("expires_at must be within %s", uc.maxTTL)
+	}
+
+	active, err := uc.repo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= uc.maxPerUser {
+		return nil, apperr.Conflictf("you already have %d active API keys; revoke one first", active)
+	}
+
+	prefix, err := randomBytes(prefixBytes)
+	if err != nil {
+		return nil, apperr.Internalf("failed to generate API key")
+	}
+	secret, err := randomBytes(secretBytes)
+	if err != nil {
+		return nil, apperr.Internalf("failed to generate API key")
+	}
+	prefixHex := hex.EncodeToString(prefix)
+	secretText := base64.RawURLEncoding.EncodeToString(secret)
+	hash := sha256.Sum256([]byte(secretText))
+
+	key, err := uc.repo.Create(ctx, &domain.APIKey{
+		UserID:     uid,
+		Name:       req.Name,
+		Prefix:     prefixHex,
+		SecretHash: hash[:],
+		Scopes:     scopes,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateAPIKeyResponse{
+		APIKeyResponse: *toAPIKeyResponse(key),
+		Key:            keyTag + prefixHex + "_" + secretText,
+	}, nil
+}
+
+// List returns the caller's keys
+func (uc *apiKeyUseCase) List(ctx context.Context, userID string) ([]dto.APIKeyResponse, error) {
+	keys, err := uc.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, *toAPIKeyResponse(&keys[i]))
+	}
+	return resp, nil
+}
+
+// Revoke revokes one of the caller's keys
+func (uc *apiKeyUseCase) Revoke(ctx context.Context, userID, id string) error {
+	return uc.repo.Revoke(ctx, id, userID)
+}
+
+// VerifyAPIKey checks the key's secret, that it is neither revoked nor
+// expired and that its owner is active. While the owner must change their
+// password the key is refused, like their access tokens outside the
+// change-password route.
+func (uc *apiKeyUseCase) VerifyAPIKey(ctx context.Context, key string) (*authdomain.APIKeyPrincipal, error) {
+	prefix, secret, ok := parseKey(key)
+	if !ok {
+		return nil, errInvalidKey
+	}
+	k, err := uc.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, errInvalidKey
+		}
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(hash[:], k.SecretHash) != 1 || k.RevokedAt != nil {
+		return nil, errInvalidKey
+	}
+	now := uc.now()
+	if !k.Active(now) {
+		return nil, errExpiredKey
+	}
+
+	user, err := uc.users.GetByID(ctx, k.UserID.String())
+	if err != nil {
+		if isNotFound(err) {
+			return nil, errInvalidKey
+		}
+		return nil, err
+	}
+	if !user.IsActive || user.MustChangePassword {
+		return nil, errInvalidKey
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedInterval {
+		// Best effort: a failed write must not fail the request.
+		_ = uc.repo.RecordUse(ctx, k.ID.String())
+	}
+
+	return &authdomain.APIKeyPrincipal{
+		KeyID:  k.ID.String(),
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		Name:   user.Name,
+		Scopes: k.Scopes,
+	}, nil
+}
+
+// parseKey splits "gsk_<prefix>_<secret>"
+func parseKey(key string) (prefix, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, keyTag)
+	if !ok || len(rest) <= prefixLength+1 || rest[prefixLength] != '_' {
+		return "", "", false
+	}
+	return rest[:prefixLength], rest[prefixLength+1:], true
+}
+
+// normalizeScopes checks each scope is "*", "object:*" or "object:action"
+// and drops duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope != "*" {
+			obj, act, ok := strings.Cut(scope, ":")
+			if !ok || obj == "" || act == "" || obj == "*" || strings.ContainsAny(scope, " \t") || strings.Count(scope, ":") > 1 {
+				return nil, apperr.BadRequestf("scope %q must be \"object:action\", \"object:*\" or \"*\"", scope)
+			}
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
+
+func toAPIKeyResponse(k *domain.APIKey) *dto.APIKeyResponse {
+	return &dto.APIKeyResponse{
+		ID:         k.ID.String(),
+		Name:       k.Name,
+		Prefix:     keyTag + k.Prefix,
+		Scopes:     k.Scopes,
+		ExpiresAt:  formatTime(k.ExpiresAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+		LastUsedAt: formatTime(k.LastUsedAt),
+		RevokedAt:  formatTime(k.RevokedAt),
+	}
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
+
+func isNotFound(err error) bool {
+	ae, ok := apperr.AsAppError(err)
+	return ok && ae.Code == apperr.CodeNotFound
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("read random: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// fakeRepo keeps keys in memory
+type fakeRepo struct {
+	keys []*domain.APIKey
+	uses int
+}
+
+func (r *fakeRepo) Create(_ context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	k := *key
+	k.ID = uuid.New()
+	k.CreatedAt = time.Now()
+	r.keys = append(r.keys, &k)
+	return &k, nil
+}
+
+func (r *fakeRepo) GetByPrefix(_ context.Context, prefix string) (*domain.APIKey, error) {
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			return k, nil
+		}
+	}
+	return nil, apperr.NotFoundf("API key not found")
+}
+
+func (r *fakeRepo) ListByUser(_ context.Context, userID string) ([]domain.APIKey, error) {
+	var out []domain.APIKey
+	for _, k := range r.keys {
+		if k.UserID.String() == userID {
+			out = append(out, *k)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) CountActiveByUser(_ context.Context, userID string) (int, error) {
+	n := 0
+	for _, k := range r.keys {
+		if k.UserID.String() == userID && k.Active(time.Now()) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeRepo) RecordUse(_ context.Context, id string) error {
+	for _, k := range r.keys {
+		if k.ID.String() == id {
+			now := time.Now()
+			k.LastUsedAt = &now
+			r.uses++
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) Revoke(_ context.Context, id, userID string) error {
+	for _, k := range r.keys {
+		if k.ID.String() == id && k.UserID.String() == userID && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return apperr.NotFoundf("API key %s not found", id)
+}
+
+// fakeUsers holds users by ID
+type fakeUsers map[string]*userdomain.User
+
+func (u fakeUsers) GetByID(_ context.Context, id string) (*userdomain.User, error) {
+	if user, ok := u[id]; ok {
+		return user, nil
+	}
+	return nil, apperr.NotFoundf("user %s not found", id)
+}
+
+type fixture struct {
+	uc    *apiKeyUseCase
+	repo  *fakeRepo
+	user  *userdomain.User
+	users fakeUsers
+}
+
+func newFixture(t *testing.T, cfg config.APIKeyConfig) *fixture {
+	t.Helper()
+	if cfg.MaxPerUser == 0 {
+		cfg.MaxPerUser = 3
+	}
+	user := &userdomain.User{ID: uuid.New(), Email: "bot@example.com", Name: "Bot", IsActive: true}
+	users := fakeUsers{user.ID.String(): user}
+	repo := &fakeRepo{}
+	return &fixture{
+		uc:    NewUseCase(repo, users, cfg).(*apiKeyUseCase),
+		repo:  repo,
+		user:  user,
+		users: users,
+	}
+}
+
+func (f *fixture) create(t *testing.T, scopes ...string) *dto.CreateAPIKeyResponse {
+	t.Helper()
+	resp, err := f.uc.Create(context.Background(), f.user.ID.String(), dto.CreateAPIKeyRequest{Name: "CI", Scopes: scopes})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCreate_KeyVerifies(t *testing.T) {
+	f := newFixture(t, config.APIKeyConfig{})
+	resp := f.create(t, "users:read", "roles:*", "users:read")
+
+	assert.True(t, strings.HasPrefix(resp.Key, resp.Prefix+"_"), "the key starts with its listed prefix")
+	assert.Equal(t, []string{"users:read", "roles:*"}, resp.Scopes, "duplicates dropped")
+	assert.Nil(t, resp.ExpiresAt)
+
+	stored := f.repo.keys[0]
+	secret := resp.Key[len(resp.Prefix)+1:]
+	hash := sha256.Sum256([]byte(secret))
+	assert.Equal(t, hash[:], stored.SecretHash, "only the secret's hash is stored")
+
+	principal, err := f.uc.VerifyAPIKey(context.Background(), resp.Key)
+	require.NoError(t, err)
+	assert.Equal(t, resp.ID, principal.KeyID)
+	assert.Equal(t, f.user.ID.String(), principal.UserID)
+	assert.Equal(t, "bot@example.com", principal.Email)
+	assert.Equal(t, []string{"users:read", "roles:*"}, principal.Scopes)
+}
+
+func TestCreate_Rejects(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+	tooLate := time.Now().Add(48 * time.Hour)
+
+	tests := []struct {
+		name string
+		req  dto.CreateAPIKeyRequest
+		want string
+	}{
+		{"scope without action", dto.CreateAPIKeyRequest{Name: "k", Scopes: []string{"users"}}, "must be \"object:action\""},
+		{"wildcard object", dto.CreateAPIKeyRequest{Name: "k", Scopes: []string{"*:read"}}, "must be \"object:action\""},
+		{"expiry in the past", dto.CreateAPIKeyRequest{Name: "k", Scopes: []string{"*"}, ExpiresAt: &past}, "in the future"},
+		{"expiry past max ttl", dto.CreateAPIKeyRequest{Name: "k", Scopes: []string{"*"}, ExpiresAt: &tooLate}, "within 24h0m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, config.APIKeyConfig{MaxTTL: config.Duration(24 * time.Hour)})
+			_, err := f.uc.Create(ctx, f.user.ID.String(), tt.req)
+			ae, ok := apperr.AsAppError(err)
+			require.True(t, ok)
+			assert.Equal(t, apperr.CodeBadRequest, ae.Code)
+			assert.Contains(t, ae.Message, tt.want)
+			assert.Empty(t, f.repo.keys)
+		})
+	}
+}
+
+func TestCreate_MaxTTLIsDefaultExpiry(t *testing.T) {
+	f := newFixture(t, config.APIKeyConfig{MaxTTL: config.Duration(24 * time.Hour)})
+	f.create(t, "*")
+
+	require.NotNil(t, f.repo.keys[0].ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *f.repo.keys[0].ExpiresAt, time.Minute)
+}
+
+func TestCreate_MaxPerUser(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, config.APIKeyConfig{MaxPerUser: 2})
+	f.create(t, "*")
+	second := f.create(t, "*")
+
+	_, err := f.uc.Create(ctx, f.user.ID.String(), dto.CreateAPIKeyRequest{Name: "k", Scopes: []string{"*"}})
+	ae, ok := apperr.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, apperr.CodeConflict, ae.Code)
+
+	// Revoked keys do not count
+	require.NoError(t, f.uc.Revoke(ctx, f.user.ID.String(), second.ID))
+	f.create(t, "*")
+}
+
+func TestVerifyAPIKey_Rejects(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		setup func(f *fixture, key string) string
+		want  *apperr.Error
+	}{
+		{"not a key", func(*fixture, string) string { return "hello" }, errInvalidKey},
+		{"wrong tag", func(_ *fixture, key string) string { return "xyz_" + strings.TrimPrefix(key, keyTag) }, errInvalidKey},
+		{"wrong secret", func(_ *fixture, key string) string { return key[:len(key)-2] + "AA" }, errInvalidKey},
+		{"unknown prefix", func(_ *fixture, key string) string { return keyTag + "000000000000" + key[len(keyTag)+prefixLength:] }, errInvalidKey},
+		{"revoked", func(f *fixture, key string) string {
+			require.NoError(t, f.uc.Revoke(ctx, f.user.ID.String(), f.repo.keys[0].ID.String()))
+			return key
+		}, errInvalidKey},
+		{"expired", func(f *fixture, key string) string {
+			past := time.Now().Add(-time.Second)
+			f.repo.keys[0].ExpiresAt = &past
+			return key
+		}, errExpiredKey},
+		{"inactive owner", func(f *fixture, key string) string {
+			f.user.IsActive = false
+			return key
+		}, errInvalidKey},
+		{"owner must change password", func(f *fixture, key string) string {
+			f.user.MustChangePassword = true
+			return key
+		}, errInvalidKey},
+		{"deleted owner", func(f *fixture, key string) string {
+			delete(f.users, f.user.ID.String())
+			return key
+		}, errInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, config.APIKeyConfig{})
+			key := tt.setup(f, f.create(t, "*").Key)
+
+			_, err := f.uc.VerifyAPIKey(ctx, key)
+			assert.Same(t, tt.want, err)
+		})
+	}
+}
+
+func TestVerifyAPIKey_ThrottlesLastUsed(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, config.APIKeyConfig{})
+	key := f.create(t, "*").Key
+
+	for range 3 {
+		_, err := f.uc.VerifyAPIKey(ctx, key)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, f.repo.uses)
+
+	stale := time.Now().Add(-2 * lastUsedInterval)
+	f.repo.keys[0].LastUsedAt = &stale
+	_, err := f.uc.VerifyAPIKey(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.repo.uses)
+}
+
+func TestRevoke_OtherUsersKey(t *testing.T) {
+	f := newFixture(t, config.APIKeyConfig{})
+	resp := f.create(t, "*")
+
+	err := f.uc.Revoke(context.Background(), uuid.NewString(), resp.ID)
+	ae, ok := apperr.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, apperr.CodeNotFound, ae.Code)
+	assert.Nil(t, f.repo.keys[0].RevokedAt)
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// AuditedUseCase wraps a UseCase and logs API keys being created and
+// revoked. List and VerifyAPIKey are delegated as-is; requests made with a
+// key are audited as their owner by the modules they reach.
+type AuditedUseCase struct {
+	inner   UseCase
+	auditor port.Auditor
+}
+
+// NewAuditedUseCase creates a new AuditedUseCase decorator.
+func NewAuditedUseCase(inner UseCase, auditor port.Auditor) *AuditedUseCase {
+	return &AuditedUseCase{inner: inner, auditor: auditor}
+}
+
+// Create logs a CREATE entry for the new key. The key itself is not logged.
+func (d *AuditedUseCase) Create(ctx context.Context, userID string, req dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error) {
+	resp, err := d.inner.Create(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "api_key", resp.ID)
+	entry.NewValue = map[string]any{
+		"user_id":    userID,
+		"name":       resp.Name,
+		"prefix":     resp.Prefix,
+		"scopes":     resp.Scopes,
+		"expires_at": resp.ExpiresAt,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// List delegates to inner without audit logging.
+func (d *AuditedUseCase) List(ctx context.Context, userID string) ([]dto.APIKeyResponse, error) {
+	return d.inner.List(ctx, userID)
+}
+
+// Revoke logs a DELETE entry for the revoked key.
+func (d *AuditedUseCase) Revoke(ctx context.Context, userID, id string) error {
+	if err := d.inner.Revoke(ctx, userID, id); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "api_key", id)
+	entry.OldValue = map[string]any{"user_id": userID}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// VerifyAPIKey delegates to inner without audit logging.
+func (d *AuditedUseCase) VerifyAPIKey(ctx context.Context, key string) (*authdomain.APIKeyPrincipal, error) {
+	return d.inner.VerifyAPIKey(ctx, key)
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// stubUseCase returns canned results from the audited methods
+type stubUseCase struct {
+	UseCase
+	created *dto.CreateAPIKeyResponse
+	err     error
+}
+
+func (s *stubUseCase) Create(context.Context, string, dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error) {
+	return s.created, s.err
+}
+
+func (s *stubUseCase) Revoke(context.Context, string, string) error { return s.err }
+
+// recordingAuditor records the entries it is given
+type recordingAuditor struct {
+	entries []port.AuditEntry
+}
+
+func (a *recordingAuditor) Log(_ context.Context, entry port.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+func TestAPIKeyAuditDecorator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create logs the key without its secret", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		created := &dto.CreateAPIKeyResponse{
+			APIKeyResponse: dto.APIKeyResponse{ID: "k-1", Name: "CI", Prefix: "gsk_abc", Scopes: []string{"*"}},
+			Key:            "gsk_abc_secret",
+		}
+		dec := NewAuditedUseCase(&stubUseCase{created: created}, auditor)
+
+		_, err := dec.Create(ctx, "u-1", dto.CreateAPIKeyRequest{})
+		require.NoError(t, err)
+
+		require.Len(t, auditor.entries, 1)
+		e := auditor.entries[0]
+		assert.Equal(t, port.AuditActionCreate, e.Action)
+		assert.Equal(t, "api_key", e.Resource)
+		assert.Equal(t, "k-1", e.ResourceID)
+		assert.Equal(t, "gsk_abc", e.NewValue.(map[string]any)["prefix"])
+		assert.NotContains(t, e.NewValue, "key")
+	})
+
+	t.Run("revoke logs a DELETE", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		dec := NewAuditedUseCase(&stubUseCase{}, auditor)
+
+		require.NoError(t, dec.Revoke(ctx, "u-1", "k-1"))
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, port.AuditActionDelete, auditor.entries[0].Action)
+		assert.Equal(t, "k-1", auditor.entries[0].ResourceID)
+	})
+
+	t.Run("failures are not logged", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		dec := NewAuditedUseCase(&stubUseCase{err: apperr.ErrNotFound}, auditor)
+
+		_, err := dec.Create(ctx, "u-1", dto.CreateAPIKeyRequest{})
+		require.Error(t, err)
+		require.Error(t, dec.Revoke(ctx, "u-1", "k-1"))
+		assert.Empty(t, auditor.entries)
+	})
+}
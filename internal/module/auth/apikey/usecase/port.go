@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey/dto"
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+)
+
+// UseCase defines the API key operations. Create, List and Revoke act on the
+// authenticated caller's own keys; VerifyAPIKey authenticates a request.
+type UseCase interface {
+	// Create issues a new key. The response carries the key itself, which
+	// cannot be retrieved again.
+	Create(ctx context.Context, userID string, req dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error)
+	// List returns the caller's keys, revoked ones included.
+	List(ctx context.Context, userID string) ([]dto.APIKeyResponse, error)
+	// Revoke stops one of the caller's keys from authenticating.
+	Revoke(ctx context.Context, userID, id string) error
+	// VerifyAPIKey resolves a presented key to its owner and scopes. It
+	// satisfies middleware.APIKeyVerifier.
+	VerifyAPIKey(ctx context.Context, key string) (*authdomain.APIKeyPrincipal, error)
+}
+
+// Repository stores API keys. The concrete *repository.Repository satisfies
+// it.
+type Repository interface {
+	Create(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]domain.APIKey, error)
+	CountActiveByUser(ctx context.Context, userID string) (int, error)
+	RecordUse(ctx context.Context, id string) error
+	Revoke(ctx context.Context, id, userID string) error
+}
+
+// UserLookup loads the user a key belongs to. The user module's repository
+// satisfies it.
+type UserLookup interface {
+	GetByID(ctx context.Context, id string) (*userdomain.User, error)
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "strings"
+
+// APIKeyPrincipal is a caller authenticated by an API key: the key's owner,
+// limited to the key's scopes. The API key middleware stores it next to the
+// owner's Claims.
+type APIKeyPrincipal struct {
+	KeyID  string
+	UserID string
+	Email  string
+	Name   string
+	// Scopes are "object:action", "object:*" or "*"
+	Scopes []string
+}
+
+// Allows reports whether the key's scopes cover permission ("object:action").
+// The owner must still hold the permission.
+func (p *APIKeyPrincipal) Allows(permission string) bool {
+	obj, _, _ := strings.Cut(permission, ":")
+	for _, scope := range p.Scopes {
+		if scope == "*" || scope == permission || scope == obj+":*" {
+			return true
+		}
+	}
+	return false
+}
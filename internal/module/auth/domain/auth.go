@@ -9,6 +9,40 @@ type TokenPair struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 }
 
+// AccessToken identifies an issued access token
+type AccessToken struct {
+	ID        string // the "jti" claim
+	ExpiresAt time.Time
+}
+
+// RevokedTokenPrefix prefixes the denylist of revoked access tokens:
+//
+//	revoked:jti:<jti> -> user ID, expiring with the token
+//
+// The auth usecase writes it and the Auth middleware reads it.
+const RevokedTokenPrefix = "revoked:jti:"
+
+// RevokedTokenKey returns the denylist key of the access token with ID jti
+func RevokedTokenKey(jti string) string {
+	return RevokedTokenPrefix + jti
+}
+
+// TokenVersionPrefix prefixes each user's access token version:
+//
+//	token:version:<userID> -> version, a decimal integer
+//
+// Access tokens carry the version current when they were issued as the "ver"
+// claim, and the Auth middleware refuses those older than the current one.
+// Logging out of all devices bumps it. The key expires one access token
+// lifetime after the last bump, once no older token can still be valid; a
+// missing key is version 0.
+const TokenVersionPrefix = "token:version:"
+
+// TokenVersionKey returns the key of userID's access token version
+func TokenVersionKey(userID string) string {
+	return TokenVersionPrefix + userID
+}
+
 // RefreshToken represents a stored refresh token
 type RefreshToken struct {
 	ID        string
@@ -17,3 +51,12 @@ type RefreshToken struct {
 	ExpiresAt time.Time
 	CreatedAt time.Time
 }
+
+// SessionSweepResult summarises one run of the session sweeper
+type SessionSweepResult struct {
+	Users          int // users holding at least one session
+	Sessions       int // sessions left after the sweep
+	MaxPerUser     int // most sessions held by a single user
+	OrphansRemoved int // token keys whose partner key was gone
+	Evicted        int // sessions evicted to enforce the per-user limit
+}
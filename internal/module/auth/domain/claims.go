@@ -12,6 +12,22 @@ type Claims struct {
 	UserID  string
 	Email   string
 	Name    string
+	// SessionID is the "sid" claim: the refresh-token session the access
+	// token was issued for. Empty for tokens issued before sessions had IDs.
+	SessionID string
+	// TokenID is the "jti" claim, the key revoked access tokens are denied
+	// by. Empty for tokens issued before tokens had IDs.
+	TokenID string
+	// TokenVersion is the "ver" claim: the user's token version when the
+	// token was issued (see TokenVersionKey). Zero for older tokens.
+	TokenVersion int64
+	// MustChangePassword is set on tokens issued to a user whose password
+	// reset was forced by an admin. Such tokens only reach the routes in
+	// the Auth middleware's PasswordChangeRoutes.
+	MustChangePassword bool
+	// ActorID is the "actor_id" claim of an impersonation token: the admin
+	// acting as UserID. Empty on every other token.
+	ActorID string
 
 	// Token validity fields.
 	Issuer    string
@@ -4,6 +4,9 @@ package dto
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// DeviceName labels the session in GET /auth/sessions. When omitted it
+	// is derived from the User-Agent.
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
 }
 
 // LoginResponse represents the login response.
@@ -16,6 +19,10 @@ type LoginResponse struct {
 	ExpiresIn    int    `json:"expires_in"` // seconds
 	TokenType    string `json:"token_type"`
 	UserID       string `json:"-"`
+	// MustChangePassword is set when an admin has forced a password reset.
+	// The access token then only reaches POST /users/me/password (and
+	// logout) until the password is changed.
+	MustChangePassword bool `json:"must_change_password,omitempty"`
 }
 
 // RefreshRequest represents the token refresh request.
@@ -39,3 +46,91 @@ type RefreshResponse struct {
 type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from a reset link
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+// VerifyEmailRequest confirms an address with the token from a verification
+// link
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChangeRequest confirms a pending email change with the token
+// from a confirmation link
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChangeResponse is the user whose email was changed and their
+// new address
+type ConfirmEmailChangeResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// ResendVerificationRequest asks for a new verification link
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// SessionResponse describes one of the caller's sessions. Current marks the
+// session the request's access token was issued for.
+type SessionResponse struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	Current    bool   `json:"current"`
+}
+
+// RegisterRequest creates an account
+type RegisterRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	// Password is hashed with bcrypt, which reads at most 72 bytes
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	// DeviceName labels the session started for the new account, as on login
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+// RegisterResponse describes the new account. Tokens is nil while
+// email_verification.require_for_login holds the login back until the
+// address is verified.
+type RegisterResponse struct {
+	UserID           string         `json:"user_id"`
+	Email            string         `json:"email"`
+	Name             string         `json:"name"`
+	Role             string         `json:"role"`
+	VerificationSent bool           `json:"verification_sent"`
+	Tokens           *LoginResponse `json:"tokens,omitempty"`
+}
+
+// LogoutAllResponse reports how many sessions and API keys logging out of
+// all devices revoked
+type LogoutAllResponse struct {
+	SessionsRevoked int `json:"sessions_revoked"`
+	APIKeysRevoked  int `json:"api_keys_revoked"`
+}
+
+// RevokeTokenRequest names an access token to revoke before it expires
+type RevokeTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RevokeTokenResponse describes a revoked access token
+type RevokeTokenResponse struct {
+	TokenID   string `json:"token_id"`
+	UserID    string `json:"user_id"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
 	"github.com/14mdzk/goscratch/internal/module/auth/dto"
 	"github.com/14mdzk/goscratch/internal/module/auth/usecase"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
@@ -19,7 +24,8 @@ func NewHandler(useCase usecase.UseCase) *Handler {
 	return &Handler{useCase: useCase}
 }
 
-// Login authenticates a user
+// Login authenticates a user. A login refused by the login throttle is a 429
+// with Retry-After set.
 func (h *Handler) Login(c *fiber.Ctx) error {
 	var req dto.LoginRequest
 	if err := validator.ValidateAndBind(c, &req); err != nil {
@@ -28,6 +34,10 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 
 	result, err := h.useCase.Login(c.UserContext(), req)
 	if err != nil {
+		var throttled *usecase.ThrottledError
+		if errors.As(err, &throttled) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		}
 		return response.Fail(c, err)
 	}
 
@@ -51,6 +61,22 @@ func (h *Handler) Refresh(c *fiber.Ctx) error {
 	return response.Success(c, result)
 }
 
+// Register creates an account and, unless its address must be verified
+// first, logs it in.
+func (h *Handler) Register(c *fiber.Ctx) error {
+	var req dto.RegisterRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.Register(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Created(c, result)
+}
+
 // Logout invalidates the refresh token.
 // This handler requires the Auth middleware (applied in module.go); the caller
 // ID is taken from the JWT claims, not from the request body.
@@ -65,9 +91,160 @@ func (h *Handler) Logout(c *fiber.Ctx) error {
 		return validator.HandleValidationError(c, err)
 	}
 
-	if err := h.useCase.Logout(c.UserContext(), callerID, req.RefreshToken); err != nil {
+	var current authdomain.AccessToken
+	if claims := middleware.GetClaims(c); claims != nil {
+		current = authdomain.AccessToken{ID: claims.TokenID, ExpiresAt: claims.ExpiresAt}
+	}
+	if err := h.useCase.Logout(c.UserContext(), callerID, req.RefreshToken, current); err != nil {
 		return response.Fail(c, err)
 	}
 
 	return response.Message(c, "Logged out successfully")
 }
+
+// LogoutAll ends every session of the caller, on this device and all others
+func (h *Handler) LogoutAll(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+	// The user's sessions are theirs to end, not an admin's acting as them.
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot log an impersonated user out of all devices")
+	}
+
+	result, err := h.useCase.LogoutAll(c.UserContext(), callerID)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// RevokeToken revokes an access token before it expires. It needs the
+// tokens:revoke permission, checked by the route.
+func (h *Handler) RevokeToken(c *fiber.Ctx) error {
+	var req dto.RevokeTokenRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.RevokeAccessToken(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// ForgotPassword sends a password reset link. The response is the same
+// whether or not the address has an account.
+func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	if err := h.useCase.ForgotPassword(c.UserContext(), req); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Message(c, "If the address belongs to an account, a reset link has been sent to it")
+}
+
+// ResetPassword sets a new password with the token from a reset link
+func (h *Handler) ResetPassword(c *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	if _, err := h.useCase.ResetPassword(c.UserContext(), req); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Message(c, "Password has been reset")
+}
+
+// VerifyEmail marks an address verified with the token from a verification
+// link
+func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
+	var req dto.VerifyEmailRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	if _, err := h.useCase.VerifyEmail(c.UserContext(), req); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Message(c, "Email address has been verified")
+}
+
+// ConfirmEmailChange applies a pending email change with the token from a
+// confirmation link
+func (h *Handler) ConfirmEmailChange(c *fiber.Ctx) error {
+	var req dto.ConfirmEmailChangeRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	if _, err := h.useCase.ConfirmEmailChange(c.UserContext(), req); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Message(c, "Email address has been changed")
+}
+
+// ResendVerification sends a new verification link. The response is the same
+// whether or not the address has an unverified account.
+func (h *Handler) ResendVerification(c *fiber.Ctx) error {
+	var req dto.ResendVerificationRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	if err := h.useCase.ResendVerification(c.UserContext(), req); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Message(c, "If the address belongs to an unverified account, a verification link has been sent to it")
+}
+
+// ListSessions lists the caller's active sessions. The session the request's
+// access token belongs to is marked current.
+func (h *Handler) ListSessions(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+
+	var currentID string
+	if claims := middleware.GetClaims(c); claims != nil {
+		currentID = claims.SessionID
+	}
+
+	sessions, err := h.useCase.ListSessions(c.UserContext(), callerID, currentID)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, sessions)
+}
+
+// RevokeSession ends one of the caller's sessions
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot revoke sessions of an impersonated user")
+	}
+
+	if err := h.useCase.RevokeSession(c.UserContext(), callerID, c.Params("id")); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.NoContent(c)
+}
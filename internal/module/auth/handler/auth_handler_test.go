@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -355,6 +356,65 @@ func TestLogout(t *testing.T) {
 	})
 }
 
+// sessionStub records the sessions ended through it
+type sessionStub struct {
+	usecase.UseCase
+	loggedOut, revoked string
+}
+
+func (s *sessionStub) LogoutAll(_ context.Context, userID string) (*dto.LogoutAllResponse, error) {
+	s.loggedOut = userID
+	return &dto.LogoutAllResponse{SessionsRevoked: 1}, nil
+}
+
+func (s *sessionStub) RevokeSession(_ context.Context, _, id string) error {
+	s.revoked = id
+	return nil
+}
+
+func TestSessions_Impersonation(t *testing.T) {
+	newApp := func(stub *sessionStub, actorID string) *fiber.App {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", "user-1")
+			if actorID != "" {
+				c.Locals("actor_id", actorID)
+			}
+			return c.Next()
+		})
+		h := NewHandler(stub)
+		app.Post("/auth/logout-all", h.LogoutAll)
+		app.Delete("/auth/sessions/:id", h.RevokeSession)
+		return app
+	}
+
+	t.Run("ends the caller's sessions", func(t *testing.T) {
+		stub := &sessionStub{}
+		app := newApp(stub, "")
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/auth/sessions/s-1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "user-1", stub.loggedOut)
+		assert.Equal(t, "s-1", stub.revoked)
+	})
+
+	t.Run("refused while impersonating", func(t *testing.T) {
+		stub := &sessionStub{}
+		app := newApp(stub, "admin-1")
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/auth/sessions/s-1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, stub.loggedOut)
+		assert.Empty(t, stub.revoked)
+	})
+}
+
 // TestNewHandler verifies handler construction
 func TestNewHandler(t *testing.T) {
 	var uc usecase.UseCase
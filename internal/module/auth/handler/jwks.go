@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
+	"github.com/gofiber/fiber/v2"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. A key published
+// ahead of a rotation must be listed at least this long before it signs.
+const jwksMaxAge = "public, max-age=300"
+
+// JWKS serves the public keys access tokens are verified with, as a bare
+// JSON Web Key Set rather than the usual response envelope: verifiers expect
+// the standard format.
+func JWKS(keys *jwtkeys.KeySet) fiber.Handler {
+	set := keys.JWKS()
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, jwksMaxAge)
+		return c.JSON(set)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/14mdzk/goscratch/internal/module/auth/handler"
 	"github.com/14mdzk/goscratch/internal/module/auth/usecase"
 	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/honeytoken"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/gofiber/fiber/v2"
@@ -13,10 +14,63 @@ import (
 
 // Module represents the auth module
 type Module struct {
-	handler   *handler.Handler
-	jwtSecret string
-	cache     port.Cache
-	revoker   usecase.Revoker
+	handler    *handler.Handler
+	authCfg    middleware.AuthConfig
+	authorizer port.Authorizer
+	cache      port.Cache
+	revoker    usecase.Revoker
+	issuer     usecase.SessionIssuer
+	// impersonator bypasses the decorators; the user module audits it
+	impersonator usecase.Impersonator
+	// passwordReset registers the forgot/reset password routes
+	passwordReset bool
+	// registration registers the self-registration route
+	registration bool
+	// verifier is nil while email verification is disabled
+	verifier usecase.Verifier
+	// emailChanger is nil while email change confirmation is disabled
+	emailChanger usecase.EmailChanger
+}
+
+// PasswordReset wires the forgot-password flow: Users stores the new
+// password and Publisher enqueues the email.send job carrying the link.
+type PasswordReset struct {
+	Users     usecase.PasswordUpdater
+	Publisher usecase.JobPublisher
+	Config    config.PasswordResetConfig
+}
+
+// EmailVerification wires email verification: Users records verified
+// addresses and Publisher enqueues the email.send job carrying the link.
+type EmailVerification struct {
+	Users     usecase.EmailVerifiedMarker
+	Publisher usecase.JobPublisher
+	Config    config.EmailVerificationConfig
+}
+
+// EmailChange wires confirmed email changes: Users applies confirmed changes
+// and Publisher enqueues the email.send job carrying the link.
+type EmailChange struct {
+	Users     usecase.EmailChangeApplier
+	Publisher usecase.JobPublisher
+	Config    config.EmailChangeConfig
+}
+
+// Registration wires self-registration: Users creates the account in a
+// transaction run by Transactor, and Roles grants it Config.DefaultRole once
+// that commits.
+type Registration struct {
+	Users      usecase.UserCreator
+	Roles      usecase.RoleAssigner
+	Transactor usecase.TxRunner
+	Config     config.AuthConfig
+}
+
+// LoginHistory wires login tracking: Users stores each login as the user's
+// last login and keeps the newest HistorySize in their login history.
+type LoginHistory struct {
+	Users       usecase.LoginRecorder
+	HistorySize int
 }
 
 // NewModule creates a new auth module.
@@ -24,19 +78,70 @@ type Module struct {
 // Accepting the interface lets the caller (app.go) share the repo instance
 // already created for the user module rather than opening a second connection
 // to the same pool (audit finding: auth/module.go instantiates its own repo).
-func NewModule(userRepo usecase.UserRepo, cache port.Cache, auditor port.Auditor, jwtCfg config.JWTConfig) *Module {
-	uc := usecase.NewUseCase(userRepo, cache, jwtCfg)
-	audited := usecase.NewAuditedUseCase(uc, auditor)
+//
+// canaries may be nil. Logins to its canary accounts are refused before the
+// inner usecase runs and are still audited as failed logins.
+//
+// authCfg is the Auth middleware configuration shared by every module; its
+// Keys also sign the access tokens issued here, and its Denylist receives
+// revoked tokens. authorizer checks the tokens:revoke permission.
+//
+// reset, verification, emailChange and registration may be nil, which leaves
+// the password reset, email verification, email change and registration
+// routes unregistered. logins may
+// be nil, which leaves logins unrecorded. throttle applies to Login when
+// enabled. apiKeys is nil while API keys are disabled; otherwise logging out
+// of all devices and a forced password reset revoke the user's keys.
+func NewModule(userRepo usecase.UserRepo, cache port.Cache, auditor port.Auditor, jwtCfg config.JWTConfig, authCfg middleware.AuthConfig, authorizer port.Authorizer, canaries *honeytoken.Detector, reset *PasswordReset, verification *EmailVerification, emailChange *EmailChange, registration *Registration, logins *LoginHistory, throttle config.LoginThrottleConfig, apiKeys usecase.APIKeyRevoker) *Module {
+	opts := []usecase.Option{usecase.WithKeys(authCfg.Keys)}
+	if throttle.Enabled {
+		opts = append(opts, usecase.WithLoginThrottle(throttle))
+	}
+	if reset != nil {
+		opts = append(opts, usecase.WithPasswordReset(reset.Users, reset.Publisher, reset.Config))
+	}
+	if verification != nil {
+		opts = append(opts, usecase.WithEmailVerification(verification.Users, verification.Publisher, verification.Config))
+	}
+	if emailChange != nil {
+		opts = append(opts, usecase.WithEmailChange(emailChange.Users, emailChange.Publisher, emailChange.Config))
+	}
+	if registration != nil {
+		opts = append(opts, usecase.WithRegistration(registration.Users, registration.Roles, registration.Transactor, registration.Config))
+	}
+	if logins != nil {
+		opts = append(opts, usecase.WithLoginHistory(logins.Users, logins.HistorySize))
+	}
+	if apiKeys != nil {
+		opts = append(opts, usecase.WithAPIKeyRevoker(apiKeys))
+	}
+	uc := usecase.NewUseCase(userRepo, cache, jwtCfg, opts...)
+	audited := usecase.NewAuditedUseCase(usecase.NewHoneytokenUseCase(uc, canaries), auditor)
 	h := handler.NewHandler(audited)
 
 	// Expose the concrete usecase as a Revoker so other modules (user) can call
 	// RevokeAllForUser without going through the audit decorator.
-	return &Module{
-		handler:   h,
-		jwtSecret: jwtCfg.Secret,
-		cache:     cache,
-		revoker:   uc.(usecase.Revoker),
+	m := &Module{
+		handler:    h,
+		authCfg:    authCfg,
+		authorizer: authorizer,
+		cache:      cache,
+		revoker:    uc.(usecase.Revoker),
+		issuer:     uc.(usecase.SessionIssuer),
+
+		impersonator: uc.(usecase.Impersonator),
+
+		passwordReset: reset != nil,
+		registration:  registration != nil,
+	}
+	if verification != nil {
+		// Through the decorators, so links sent for new accounts are audited.
+		m.verifier = audited
 	}
+	if emailChange != nil {
+		m.emailChanger = audited
+	}
+	return m
 }
 
 // Revoker returns the auth module's session-revocation interface.
@@ -45,12 +150,53 @@ func (m *Module) Revoker() usecase.Revoker {
 	return m.revoker
 }
 
+// SessionIssuer returns the interface the passkey module uses to issue a
+// token pair once a passkey login is verified. Like Revoker it bypasses the
+// decorators; the passkey module audits its own logins.
+func (m *Module) SessionIssuer() usecase.SessionIssuer {
+	return m.issuer
+}
+
+// Impersonator returns the interface the user module uses to issue
+// impersonation tokens.
+func (m *Module) Impersonator() usecase.Impersonator {
+	return m.impersonator
+}
+
+// Verifier returns the interface the user module uses to send verification
+// links, or nil when email verification is disabled.
+func (m *Module) Verifier() usecase.Verifier {
+	return m.verifier
+}
+
+// EmailChanger returns the interface the user module uses to send email
+// change confirmation links, or nil when email change confirmation is
+// disabled.
+func (m *Module) EmailChanger() usecase.EmailChanger {
+	return m.emailChanger
+}
+
 // RegisterRoutes registers auth module routes.
 //
 //   - /login and /refresh are public but protected by a tight per-IP rate limit
 //     (20 req / 5 min, fail-closed) to throttle credential-stuffing attempts.
+//   - /forgot-password and /reset-password, when password reset is enabled,
+//     share the same limit: both are public and both can be brute-forced.
+//     So do /verify-email and /resend-verification when email verification
+//     is enabled, and /confirm-email-change when email change confirmation
+//     is enabled.
 //   - /logout requires a valid JWT (Auth middleware) so an unauthenticated caller
-//     cannot hit the endpoint at all (block-ship #5).
+//     cannot hit the endpoint at all (block-ship #5). So do /logout-all and
+//     /sessions, which end or list the caller's own sessions.
+//   - /revoke denies any access token before it expires and needs the
+//     tokens:revoke permission.
+//   - /register, when registration is enabled, shares the limit too: it is
+//     public and each call creates an account.
+//   - /login, /register and /refresh record the client IP and User-Agent
+//     (ClientInfo) on the session they start or resume.
+//   - /.well-known/jwks.json publishes the token verification keys when
+//     tokens are signed with RS256 or ES256. It is public and outside /auth,
+//     where other services look for it.
 func (m *Module) RegisterRoutes(router fiber.Router) {
 	authGroup := router.Group("/auth")
 
@@ -67,11 +213,35 @@ func (m *Module) RegisterRoutes(router fiber.Router) {
 		FailClosed: true,
 	}, m.cache)
 
-	authGroup.Post("/login", authRateLimit, m.handler.Login)
-	authGroup.Post("/refresh", authRateLimit, m.handler.Refresh)
+	clientInfo := middleware.ClientInfo()
+	authGroup.Post("/login", authRateLimit, clientInfo, m.handler.Login)
+	authGroup.Post("/refresh", authRateLimit, clientInfo, m.handler.Refresh)
+	if m.registration {
+		authGroup.Post("/register", authRateLimit, clientInfo, m.handler.Register)
+	}
+	if m.passwordReset {
+		authGroup.Post("/forgot-password", authRateLimit, m.handler.ForgotPassword)
+		authGroup.Post("/reset-password", authRateLimit, m.handler.ResetPassword)
+	}
+	if m.verifier != nil {
+		authGroup.Post("/verify-email", authRateLimit, m.handler.VerifyEmail)
+		authGroup.Post("/resend-verification", authRateLimit, m.handler.ResendVerification)
+	}
+	if m.emailChanger != nil {
+		authGroup.Post("/confirm-email-change", authRateLimit, m.handler.ConfirmEmailChange)
+	}
 
 	// Logout is authenticated — Auth middleware validates the JWT before the
 	// handler runs. The callerID is read from the JWT claims by the handler.
-	authMiddleware := middleware.Auth(middleware.DefaultAuthConfig(m.jwtSecret))
+	authMiddleware := middleware.Auth(m.authCfg)
 	authGroup.Post("/logout", authMiddleware, m.handler.Logout)
+	authGroup.Post("/logout-all", authMiddleware, m.handler.LogoutAll)
+	authGroup.Get("/sessions", authMiddleware, m.handler.ListSessions)
+	authGroup.Delete("/sessions/:id", authMiddleware, m.handler.RevokeSession)
+
+	authGroup.Post("/revoke", authMiddleware, middleware.RequirePermission(m.authorizer, "tokens", "revoke"), m.handler.RevokeToken)
+
+	if m.authCfg.Keys.Asymmetric() {
+		router.Get("/.well-known/jwks.json", handler.JWKS(m.authCfg.Keys))
+	}
 }
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links a user to an account at an external identity provider
+type Identity struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Provider is the provider's name in oauth.providers, e.g. "google"
+	Provider string
+	// Subject is the provider's stable ID for the account
+	Subject string
+	// Email is the address the provider reported at the last login
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time // nil until the first login after linking
+}
@@ -0,0 +1,27 @@
+package dto
+
+import authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+
+// BeginResponse is the start of an OAuth login: the provider's consent page
+// and the state it will redirect back with.
+type BeginResponse struct {
+	URL   string
+	State string
+}
+
+// CallbackRequest is the query the provider redirects back with. On refusal
+// the provider sends Error instead of Code.
+type CallbackRequest struct {
+	Code  string `query:"code"`
+	State string `query:"state" validate:"required"`
+	Error string `query:"error"`
+}
+
+// CallbackResult is a completed OAuth login
+type CallbackResult struct {
+	Login *authdto.LoginResponse
+	// Linked is set when this login linked the identity to a user, either an
+	// existing one with the same verified email or a new one (Created).
+	Linked  bool
+	Created bool
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// stateCookie carries the OAuth state from Begin to Callback, binding the
+// login to the browser that started it. Without it an attacker could send a
+// victim their own callback link and log the victim into the attacker's
+// account.
+const stateCookie = "oauth_state"
+
+var errStateMismatch = apperr.ErrBadRequest.WithMessage("OAuth login was started in another browser; start again")
+
+// Handler handles OAuth HTTP requests
+type Handler struct {
+	useCase  usecase.UseCase
+	stateTTL time.Duration
+}
+
+// NewHandler creates a new OAuth handler. stateTTL is the lifetime of the
+// state cookie and should match the use case's.
+func NewHandler(useCase usecase.UseCase, stateTTL time.Duration) *Handler {
+	return &Handler{useCase: useCase, stateTTL: stateTTL}
+}
+
+// Begin redirects the browser to the provider's consent page
+func (h *Handler) Begin(c *fiber.Ctx) error {
+	result, err := h.useCase.Begin(c.UserContext(), c.Params("provider"))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	// The callback path is below this one, so the cookie is sent only there.
+	c.Cookie(&fiber.Cookie{
+		Name:     stateCookie,
+		Value:    result.State,
+		Path:     c.Path(),
+		MaxAge:   int(h.stateTTL.Seconds()),
+		Secure:   c.Secure(),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(result.URL, fiber.StatusFound)
+}
+
+// Callback completes the login the provider redirected back from and returns
+// a token pair, as POST /auth/login does
+func (h *Handler) Callback(c *fiber.Ctx) error {
+	var req dto.CallbackRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	cookie := c.Cookies(stateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     stateCookie,
+		Path:     strings.TrimSuffix(c.Path(), "/callback"),
+		Expires:  time.Now().Add(-time.Hour),
+		Secure:   c.Secure(),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(req.State)) != 1 {
+		return response.Fail(c, errStateMismatch)
+	}
+
+	result, err := h.useCase.Callback(c.UserContext(), c.Params("provider"), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result.Login)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUseCase begins every login with the same state and accepts any
+// callback
+type stubUseCase struct {
+	callbacks int
+}
+
+func (s *stubUseCase) Begin(context.Context, string) (*dto.BeginResponse, error) {
+	return &dto.BeginResponse{URL: "https://idp.example.com/authorize?state=s1", State: "s1"}, nil
+}
+
+func (s *stubUseCase) Callback(context.Context, string, dto.CallbackRequest) (*dto.CallbackResult, error) {
+	s.callbacks++
+	return &dto.CallbackResult{Login: &authdto.LoginResponse{AccessToken: "access"}}, nil
+}
+
+func newTestApp(uc *stubUseCase) *fiber.App {
+	h := NewHandler(uc, 10*time.Minute)
+	app := fiber.New()
+	app.Get("/auth/oauth/:provider", h.Begin)
+	app.Get("/auth/oauth/:provider/callback", h.Callback)
+	return app
+}
+
+func TestBegin_RedirectsAndSetsStateCookie(t *testing.T) {
+	app := newTestApp(&stubUseCase{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/oauth/google", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://idp.example.com/authorize?state=s1", resp.Header.Get("Location"))
+
+	require.Len(t, resp.Cookies(), 1)
+	cookie := resp.Cookies()[0]
+	assert.Equal(t, stateCookie, cookie.Name)
+	assert.Equal(t, "s1", cookie.Value)
+	assert.Equal(t, "/auth/oauth/google", cookie.Path, "sent only to this provider's callback")
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, 600, cookie.MaxAge)
+}
+
+func TestCallback_StateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		status int
+	}{
+		{"matching cookie", "s1", http.StatusOK},
+		{"no cookie", "", http.StatusBadRequest},
+		{"cookie from another login", "s2", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &stubUseCase{}
+			app := newTestApp(uc)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/oauth/google/callback?code=c&state=s1", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: stateCookie, Value: tt.cookie})
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status != http.StatusOK {
+				assert.Zero(t, uc.callbacks, "the use case is not reached")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/handler"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/repository"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Module represents the OAuth login module
+type Module struct {
+	handler *handler.Handler
+	cache   port.Cache
+}
+
+// NewModule creates a new OAuth module.
+// providers are built by the oauth adapter from cfg.Providers. users is
+// shared with the auth and user modules; issuer is the auth module's
+// SessionIssuer, so an OAuth login ends in the same token pair as a password
+// login.
+func NewModule(providers map[string]port.OAuthProvider, repo *repository.Repository, users usecase.UserStore, issuer usecase.SessionIssuer, transactor *database.Transactor, cache port.Cache, auditor port.Auditor, cfg config.OAuthConfig) *Module {
+	uc := usecase.NewUseCase(providers, repo, users, issuer, transactor, cache, cfg)
+	audited := usecase.NewAuditedUseCase(uc, auditor)
+
+	stateTTL := time.Duration(cfg.StateTTL)
+	if stateTTL <= 0 {
+		stateTTL = usecase.DefaultStateTTL
+	}
+	return &Module{
+		handler: handler.NewHandler(audited, stateTTL),
+		cache:   cache,
+	}
+}
+
+// RegisterRoutes registers the OAuth routes under /auth/oauth. Both are
+// public and share the auth module's per-IP limit (20 req / 5 min,
+// fail-closed).
+func (m *Module) RegisterRoutes(router fiber.Router) {
+	group := router.Group("/auth/oauth")
+
+	// The closer is discarded for the same reason as in the auth module:
+	// redisBackend.Close is a no-op.
+	authRateLimit, _ := middleware.RateLimit(middleware.RateLimitConfig{
+		Max:        20,
+		Window:     5 * time.Minute,
+		UseRedis:   true,
+		FailClosed: true,
+	}, m.cache)
+
+	group.Get("/:provider", authRateLimit, m.handler.Begin)
+	group.Get("/:provider/callback", authRateLimit, middleware.ClientInfo(), m.handler.Callback)
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/repository/sqlc"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores external identities in user_identities using
+// SQLC-generated queries. Like the user repository it is TX-aware.
+type Repository struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithQueryTimeout bounds every query issued by the repository to d (see
+// database.WithQueryTimeout). Zero disables the per-query deadline.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(r *Repository) {
+		r.queryTimeout = d
+	}
+}
+
+// NewRepository creates a new identity repository
+func NewRepository(pool *pgxpool.Pool, opts ...Option) *Repository {
+	r := &Repository{pool: pool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// queries returns a *sqlc.Queries bound to the transaction in ctx, or to the
+// pool when no transaction is active.
+func (r *Repository) queries(ctx context.Context) *sqlc.Queries {
+	return sqlc.New(database.DBFromContext(ctx, r.pool))
+}
+
+// Create links the provider account to userID. An account already linked to
+// a user is a conflict.
+func (r *Repository) Create(ctx context.Context, userID, provider, subject, email string) (*domain.Identity, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("insert", "user_identities", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "CreateUserIdentity", "user_identities")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", userID)
+	}
+
+	row, err := r.queries(ctx).CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		UserID:   pgutil.UUIDToPgtype(uid),
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to create identity: %w", err), "identity")
+	}
+
+	return sqlcIdentityToDomain(&row), nil
+}
+
+// Get returns the identity for the provider account
+func (r *Repository) Get(ctx context.Context, provider, subject string) (*domain.Identity, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "user_identities", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "GetUserIdentity", "user_identities")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	row, err := r.queries(ctx).GetUserIdentity(ctx, sqlc.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if err != nil {
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to get identity: %w", err), "identity")
+	}
+
+	return sqlcIdentityToDomain(&row), nil
+}
+
+// RecordLogin stores the email the provider reported and sets last_login_at
+func (r *Repository) RecordLogin(ctx context.Context, id, email string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "user_identities", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "UpdateUserIdentityLogin", "user_identities")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	iid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("identity %s not found", id)
+	}
+
+	if err := r.queries(ctx).UpdateUserIdentityLogin(ctx, sqlc.UpdateUserIdentityLoginParams{
+		ID:    pgutil.UUIDToPgtype(iid),
+		Email: email,
+	}); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to update identity: %w", err)
+	}
+	return nil
+}
+
+// sqlcIdentityToDomain converts a SQLC row to a domain identity
+func sqlcIdentityToDomain(i *sqlc.UserIdentity) *domain.Identity {
+	var createdAt time.Time
+	if i.CreatedAt.Valid {
+		createdAt = i.CreatedAt.Time
+	}
+	var lastLoginAt *time.Time
+	if i.LastLoginAt.Valid {
+		lastLoginAt = &i.LastLoginAt.Time
+	}
+
+	return &domain.Identity{
+		ID:          pgutil.PgtypeToUUID(i.ID),
+		UserID:      pgutil.PgtypeToUUID(i.UserID),
+		Provider:    i.Provider,
+		Subject:     i.Subject,
+		Email:       i.Email,
+		CreatedAt:   createdAt,
+		LastLoginAt: lastLoginAt,
+	}
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	authusecase "github.com/14mdzk/goscratch/internal/module/auth/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// AuditedUseCase wraps a UseCase and logs OAuth logins (success and failure),
+// identities being linked and users created by signup. Begin is delegated
+// as-is.
+type AuditedUseCase struct {
+	inner   UseCase
+	auditor port.Auditor
+}
+
+// NewAuditedUseCase creates a new AuditedUseCase decorator.
+func NewAuditedUseCase(inner UseCase, auditor port.Auditor) *AuditedUseCase {
+	return &AuditedUseCase{inner: inner, auditor: auditor}
+}
+
+// Begin delegates to inner without audit logging.
+func (d *AuditedUseCase) Begin(ctx context.Context, provider string) (*dto.BeginResponse, error) {
+	return d.inner.Begin(ctx, provider)
+}
+
+// Callback logs a LOGIN entry like a password login, with method "oauth"
+// and the provider. A failed login names no account. A signup adds a CREATE
+// entry for the user, and a new link a CREATE entry for the identity.
+func (d *AuditedUseCase) Callback(ctx context.Context, provider string, req dto.CallbackRequest) (*dto.CallbackResult, error) {
+	result, err := d.inner.Callback(ctx, provider, req)
+	if err != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionLogin, "user", "")
+		entry.Metadata = map[string]any{
+			"outcome":  "failed",
+			"method":   "oauth",
+			"provider": provider,
+			"reason":   classifyLoginFailure(err),
+		}
+		_ = d.auditor.Log(ctx, entry)
+		return nil, err
+	}
+
+	userID := result.Login.UserID
+	if result.Created {
+		entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "user", userID)
+		entry.Metadata = map[string]any{"source": "oauth", "provider": provider}
+		_ = d.auditor.Log(ctx, entry)
+	}
+	if result.Linked {
+		entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "identity", userID)
+		entry.NewValue = map[string]any{"provider": provider}
+		_ = d.auditor.Log(ctx, entry)
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionLogin, "user", userID)
+	entry.Metadata = map[string]any{"outcome": "success", "method": "oauth", "provider": provider}
+	_ = d.auditor.Log(ctx, entry)
+
+	return result, nil
+}
+
+// classifyLoginFailure maps a Callback error to a fixed reason, so raw error
+// strings never reach the audit log.
+func classifyLoginFailure(err error) string {
+	var ae *apperr.Error
+	if errors.As(err, &ae) {
+		// apperr's Is matches by code, so the sentinels are compared directly
+		switch ae {
+		case errRefused:
+			return "refused"
+		case ErrNoAccount:
+			return "no_account"
+		case errInactive:
+			return "user_inactive"
+		case authusecase.ErrEmailNotVerified:
+			return "email_unverified"
+		}
+		switch ae.Code {
+		case apperr.CodeUnauthorized:
+			return "invalid_credentials"
+		case apperr.CodeBadRequest, apperr.CodeNotFound:
+			return "invalid_request"
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	authusecase "github.com/14mdzk/goscratch/internal/module/auth/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// stubUseCase returns a canned Callback result
+type stubUseCase struct {
+	UseCase
+	result *dto.CallbackResult
+	err    error
+}
+
+func (s *stubUseCase) Callback(context.Context, string, dto.CallbackRequest) (*dto.CallbackResult, error) {
+	return s.result, s.err
+}
+
+// recordingAuditor records the entries it is given
+type recordingAuditor struct {
+	entries []port.AuditEntry
+}
+
+func (a *recordingAuditor) Log(_ context.Context, entry port.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+func TestOAuthAuditDecorator_Callback(t *testing.T) {
+	ctx := context.Background()
+	login := &authdto.LoginResponse{UserID: "u-1"}
+
+	t.Run("login to a linked account", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		dec := NewAuditedUseCase(&stubUseCase{result: &dto.CallbackResult{Login: login}}, auditor)
+
+		_, err := dec.Callback(ctx, "google", dto.CallbackRequest{})
+		require.NoError(t, err)
+
+		require.Len(t, auditor.entries, 1)
+		entry := auditor.entries[0]
+		assert.Equal(t, port.AuditActionLogin, entry.Action)
+		assert.Equal(t, "u-1", entry.ResourceID)
+		assert.Equal(t, map[string]any{"outcome": "success", "method": "oauth", "provider": "google"}, entry.Metadata)
+	})
+
+	t.Run("signup logs the new user and link first", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		dec := NewAuditedUseCase(&stubUseCase{result: &dto.CallbackResult{Login: login, Linked: true, Created: true}}, auditor)
+
+		_, err := dec.Callback(ctx, "google", dto.CallbackRequest{})
+		require.NoError(t, err)
+
+		require.Len(t, auditor.entries, 3)
+		assert.Equal(t, port.AuditActionCreate, auditor.entries[0].Action)
+		assert.Equal(t, "user", auditor.entries[0].Resource)
+		assert.Equal(t, port.AuditActionCreate, auditor.entries[1].Action)
+		assert.Equal(t, "identity", auditor.entries[1].Resource)
+		assert.Equal(t, port.AuditActionLogin, auditor.entries[2].Action)
+	})
+
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{errRefused, "refused"},
+		{errLoginFailed.WithError(assert.AnError), "invalid_credentials"},
+		{errInvalidState, "invalid_request"},
+		{ErrNoAccount, "no_account"},
+		{errInactive, "user_inactive"},
+		{authusecase.ErrEmailNotVerified, "email_unverified"},
+	}
+	for _, tt := range tests {
+		t.Run("failure reason "+tt.reason, func(t *testing.T) {
+			auditor := &recordingAuditor{}
+			dec := NewAuditedUseCase(&stubUseCase{err: tt.err}, auditor)
+
+			_, err := dec.Callback(ctx, "google", dto.CallbackRequest{})
+			assert.Error(t, err)
+
+			require.Len(t, auditor.entries, 1)
+			entry := auditor.entries[0]
+			assert.Empty(t, entry.ResourceID)
+			assert.Equal(t, "failed", entry.Metadata["outcome"])
+			assert.Equal(t, tt.reason, entry.Metadata["reason"])
+		})
+	}
+}
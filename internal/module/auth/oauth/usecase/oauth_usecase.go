@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Login state is kept in the cache between Begin and Callback, under the
+// random state value the provider echoes back:
+//
+//	oauth:state:<state> -> loginState (JSON)
+//
+// Callback deletes the entry, so each state is redeemed at most once.
+const stateKeyPrefix = "oauth:state:"
+
+// DefaultStateTTL applies when oauth.state_ttl is 0
+const DefaultStateTTL = 10 * time.Minute
+
+var (
+	errUnknownProvider = apperr.ErrNotFound.WithMessage("Unknown OAuth provider")
+	errInvalidState    = apperr.ErrBadRequest.WithMessage("OAuth login expired or unknown; start again")
+	errRefused         = apperr.ErrUnauthorized.WithMessage("Login was cancelled or refused at the provider")
+	errLoginFailed     = apperr.ErrUnauthorized.WithMessage("OAuth login failed")
+	// ErrNoAccount is returned when the identity matches no user and signup is
+	// off or impossible (no verified email).
+	ErrNoAccount = apperr.ErrForbidden.WithMessage("No account is linked to this identity")
+	errInactive  = apperr.ErrForbidden.WithMessage("User account is inactive")
+)
+
+// loginState is what Begin stores for Callback
+type loginState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+type oauthUseCase struct {
+	providers   map[string]port.OAuthProvider
+	identities  IdentityRepository
+	users       UserStore
+	issuer      SessionIssuer
+	transactor  TxRunner
+	cache       port.Cache
+	stateTTL    time.Duration
+	allowSignup bool
+}
+
+// NewUseCase creates the OAuth use case. providers are keyed by the name used
+// in the login URL.
+func NewUseCase(providers map[string]port.OAuthProvider, identities IdentityRepository, users UserStore, issuer SessionIssuer, transactor TxRunner, cache port.Cache, cfg config.OAuthConfig) UseCase {
+	ttl := time.Duration(cfg.StateTTL)
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	return &oauthUseCase{
+		providers:   providers,
+		identities:  identities,
+		users:       users,
+		issuer:      issuer,
+		transactor:  transactor,
+		cache:       cache,
+		stateTTL:    ttl,
+		allowSignup: cfg.AllowSignup,
+	}
+}
+
+// Begin stores a new state, nonce and PKCE verifier and returns the
+// provider's consent page.
+func (uc *oauthUseCase) Begin(ctx context.Context, provider string) (*dto.BeginResponse, error) {
+	p, ok := uc.providers[provider]
+	if !ok {
+		return nil, errUnknownProvider
+	}
+
+	state, err1 := randomString()
+	nonce, err2 := randomString()
+	verifier, err3 := randomString()
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, apperr.Internalf("failed to generate OAuth state")
+	}
+	data, err := json.Marshal(loginState{Provider: provider, Nonce: nonce, Verifier: verifier})
+	if err != nil {
+		return nil, apperr.Internalf("failed to encode OAuth state")
+	}
+	if err := uc.cache.Set(ctx, stateKeyPrefix+state, data, uc.stateTTL); err != nil {
+		return nil, apperr.Internalf("auth: cache unavailable, cannot start OAuth login")
+	}
+
+	return &dto.BeginResponse{URL: p.AuthCodeURL(state, nonce, verifier), State: state}, nil
+}
+
+// Callback redeems the state, exchanges the code and finds the user:
+//
+//  1. the user the identity is already linked to;
+//  2. else the user whose email matches the identity's verified email, to
+//     whom the identity is then linked;
+//  3. else, with oauth.allow_signup, a new user created from the identity.
+//
+// An identity without a verified email can only log in once linked.
+func (uc *oauthUseCase) Callback(ctx context.Context, provider string, req dto.CallbackRequest) (*dto.CallbackResult, error) {
+	state, ok := uc.takeState(ctx, req.State)
+	if !ok || state.Provider != provider {
+		return nil, errInvalidState
+	}
+	if req.Error != "" || req.Code == "" {
+		return nil, errRefused
+	}
+	p, ok := uc.providers[provider]
+	if !ok {
+		return nil, errUnknownProvider
+	}
+
+	ext, err := p.Exchange(ctx, req.Code, state.Nonce, state.Verifier)
+	if err != nil {
+		return nil, errLoginFailed.WithError(err)
+	}
+	if ext.Subject == "" {
+		return nil, errLoginFailed
+	}
+
+	result := &dto.CallbackResult{}
+	identity, user, err := uc.resolve(ctx, provider, ext, result)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errInactive
+	}
+
+	// The provider has verified the address, so the account's matching
+	// address is verified too.
+	if ext.EmailVerified && !user.EmailVerified() && strings.EqualFold(ext.Email, user.Email) {
+		if _, err := uc.users.MarkEmailVerified(ctx, user.ID.String(), user.Email); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := uc.identities.RecordLogin(ctx, identity.ID.String(), ext.Email); err != nil {
+		return nil, err
+	}
+
+	result.Login, err = uc.issuer.IssueSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolve finds or creates the user for ext, linking the identity when it is
+// new. result records what was linked or created.
+func (uc *oauthUseCase) resolve(ctx context.Context, provider string, ext *port.ExternalIdentity, result *dto.CallbackResult) (*domain.Identity, *userdomain.User, error) {
+	identity, err := uc.identities.Get(ctx, provider, ext.Subject)
+	if err == nil {
+		user, err := uc.users.GetByID(ctx, identity.UserID.String())
+		return identity, user, err
+	}
+	if !isNotFound(err) {
+		return nil, nil, err
+	}
+
+	// Linking by email is only safe when the provider vouches for it.
+	if ext.Email == "" || !ext.EmailVerified {
+		return nil, nil, ErrNoAccount
+	}
+
+	user, err := uc.users.GetByEmail(ctx, ext.Email)
+	switch {
+	case err == nil:
+		identity, err = uc.identities.Create(ctx, user.ID.String(), provider, ext.Subject, ext.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Linked = true
+		return identity, user, nil
+	case !isNotFound(err):
+		return nil, nil, err
+	case !uc.allowSignup:
+		return nil, nil, ErrNoAccount
+	}
+
+	// Sign up. The account gets a random password nobody knows; the user
+	// can set one through the password reset flow.
+	password, err := randomString()
+	if err != nil {
+		return nil, nil, apperr.Internalf("failed to generate password")
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, apperr.Internalf("failed to hash password")
+	}
+	if err := uc.transactor.WithTx(ctx, func(ctx context.Context) error {
+		user, err = uc.users.Create(ctx, ext.Email, string(passwordHash), signupName(ext))
+		if err != nil {
+			return err
+		}
+		identity, err = uc.identities.Create(ctx, user.ID.String(), provider, ext.Subject, ext.Email)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+	result.Linked, result.Created = true, true
+	return identity, user, nil
+}
+
+// takeState loads and deletes the state stored by Begin
+func (uc *oauthUseCase) takeState(ctx context.Context, state string) (*loginState, bool) {
+	data, err := uc.cache.Get(ctx, stateKeyPrefix+state)
+	if err != nil {
+		return nil, false
+	}
+	_ = uc.cache.Delete(ctx, stateKeyPrefix+state)
+
+	var s loginState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, false
+	}
+	return &s, true
+}
+
+// signupName is the new user's name: the provider's, or the email's local
+// part when the provider has none. User names are at least 2 characters.
+func signupName(ext *port.ExternalIdentity) string {
+	name := strings.TrimSpace(ext.Name)
+	if len(name) < 2 {
+		name, _, _ = strings.Cut(ext.Email, "@")
+	}
+	if len(name) < 2 {
+		name = ext.Email
+	}
+	if r := []rune(name); len(r) > 100 {
+		name = string(r[:100])
+	}
+	return name
+}
+
+func isNotFound(err error) bool {
+	ae, ok := apperr.AsAppError(err)
+	return ok && ae.Code == apperr.CodeNotFound
+}
+
+// randomString returns 32 random bytes, base64url encoded. PKCE verifiers
+// must be 43 to 128 characters; this is 43.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,334 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// mapCache is an in-memory port.Cache
+type mapCache struct {
+	data map[string][]byte
+}
+
+func newMapCache() *mapCache { return &mapCache{data: map[string][]byte{}} }
+
+func (c *mapCache) Get(_ context.Context, key string) ([]byte, error) {
+	if v, ok := c.data[key]; ok {
+		return v, nil
+	}
+	return nil, port.ErrCacheMiss
+}
+func (c *mapCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.data[key] = value
+	return nil
+}
+func (c *mapCache) Delete(_ context.Context, key string) error {
+	delete(c.data, key)
+	return nil
+}
+func (c *mapCache) DeleteByPrefix(context.Context, string) error           { return nil }
+func (c *mapCache) KeysByPrefix(context.Context, string) ([]string, error) { return nil, nil }
+func (c *mapCache) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := c.data[key]
+	return ok, nil
+}
+func (c *mapCache) SetJSON(context.Context, string, any, time.Duration) error { return nil }
+func (c *mapCache) GetJSON(context.Context, string, any) error                { return port.ErrCacheMiss }
+func (c *mapCache) Increment(context.Context, string) (int64, error)          { return 0, nil }
+func (c *mapCache) Decrement(context.Context, string) (int64, error)          { return 0, nil }
+func (c *mapCache) Expire(context.Context, string, time.Duration) error       { return nil }
+func (c *mapCache) SlidingWindowAllow(_ context.Context, _ string, max int, _ time.Duration) (bool, int, int, error) {
+	return true, max, 0, nil
+}
+func (c *mapCache) Close() error { return nil }
+
+// fakeProvider returns identity for the code "good" and records what
+// Exchange was given
+type fakeProvider struct {
+	identity *port.ExternalIdentity
+	nonce    string
+	verifier string
+}
+
+func (p *fakeProvider) AuthCodeURL(state, nonce, verifier string) string {
+	return "https://idp.example.com/authorize?state=" + url.QueryEscape(state)
+}
+
+func (p *fakeProvider) Exchange(_ context.Context, code, nonce, verifier string) (*port.ExternalIdentity, error) {
+	if code != "good" {
+		return nil, errors.New("invalid_grant")
+	}
+	p.nonce, p.verifier = nonce, verifier
+	id := *p.identity
+	return &id, nil
+}
+
+// fakeIdentities stores identities in memory
+type fakeIdentities struct {
+	rows []domain.Identity
+}
+
+func (f *fakeIdentities) Get(_ context.Context, provider, subject string) (*domain.Identity, error) {
+	for i := range f.rows {
+		if f.rows[i].Provider == provider && f.rows[i].Subject == subject {
+			row := f.rows[i]
+			return &row, nil
+		}
+	}
+	return nil, apperr.NotFoundf("identity not found")
+}
+
+func (f *fakeIdentities) Create(_ context.Context, userID, provider, subject, email string) (*domain.Identity, error) {
+	row := domain.Identity{ID: uuid.New(), UserID: uuid.MustParse(userID), Provider: provider, Subject: subject, Email: email}
+	f.rows = append(f.rows, row)
+	return &row, nil
+}
+
+func (f *fakeIdentities) RecordLogin(_ context.Context, id, email string) error {
+	for i := range f.rows {
+		if f.rows[i].ID.String() == id {
+			now := time.Now()
+			f.rows[i].Email, f.rows[i].LastLoginAt = email, &now
+		}
+	}
+	return nil
+}
+
+// fakeUsers stores users in memory
+type fakeUsers struct {
+	byID     map[string]*userdomain.User
+	verified []string
+}
+
+func (f *fakeUsers) GetByID(_ context.Context, id string) (*userdomain.User, error) {
+	if u, ok := f.byID[id]; ok {
+		return u, nil
+	}
+	return nil, apperr.NotFoundf("user %s not found", id)
+}
+
+func (f *fakeUsers) GetByEmail(_ context.Context, email string) (*userdomain.User, error) {
+	for _, u := range f.byID {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, apperr.NotFoundf("user with email %s not found", email)
+}
+
+func (f *fakeUsers) Create(_ context.Context, email, passwordHash, name string) (*userdomain.User, error) {
+	u := &userdomain.User{ID: uuid.New(), Email: email, PasswordHash: passwordHash, Name: name, IsActive: true}
+	f.byID[u.ID.String()] = u
+	return u, nil
+}
+
+func (f *fakeUsers) MarkEmailVerified(_ context.Context, id, _ string) (bool, error) {
+	f.verified = append(f.verified, id)
+	return true, nil
+}
+
+// fakeIssuer records the users it issued sessions for
+type fakeIssuer struct {
+	issued []string
+}
+
+func (f *fakeIssuer) IssueSession(_ context.Context, user *userdomain.User) (*authdto.LoginResponse, error) {
+	f.issued = append(f.issued, user.ID.String())
+	return &authdto.LoginResponse{AccessToken: "access", UserID: user.ID.String()}, nil
+}
+
+// fakeTx runs fn without a transaction and counts the calls
+type fakeTx struct {
+	calls int
+}
+
+func (f *fakeTx) WithTx(ctx context.Context, fn database.TxFunc, _ ...database.TxOption) error {
+	f.calls++
+	return fn(ctx)
+}
+
+type fixture struct {
+	uc         UseCase
+	provider   *fakeProvider
+	identities *fakeIdentities
+	users      *fakeUsers
+	issuer     *fakeIssuer
+	tx         *fakeTx
+	cache      *mapCache
+}
+
+func newFixture(allowSignup bool) *fixture {
+	f := &fixture{
+		provider: &fakeProvider{identity: &port.ExternalIdentity{
+			Subject: "sub-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe",
+		}},
+		identities: &fakeIdentities{},
+		users:      &fakeUsers{byID: map[string]*userdomain.User{}},
+		issuer:     &fakeIssuer{},
+		tx:         &fakeTx{},
+		cache:      newMapCache(),
+	}
+	f.uc = NewUseCase(map[string]port.OAuthProvider{"google": f.provider}, f.identities, f.users, f.issuer, f.tx, f.cache,
+		config.OAuthConfig{Enabled: true, AllowSignup: allowSignup})
+	return f
+}
+
+func (f *fixture) addUser(email string) *userdomain.User {
+	u, _ := f.users.Create(context.Background(), email, "hash", "Existing")
+	return u
+}
+
+// login runs Begin and Callback with code
+func (f *fixture) login(t *testing.T, code string) (*dto.CallbackResult, error) {
+	t.Helper()
+	begin, err := f.uc.Begin(context.Background(), "google")
+	require.NoError(t, err)
+	return f.uc.Callback(context.Background(), "google", dto.CallbackRequest{Code: code, State: begin.State})
+}
+
+func TestOAuth_BeginStoresState(t *testing.T) {
+	f := newFixture(false)
+	begin, err := f.uc.Begin(context.Background(), "google")
+	require.NoError(t, err)
+	assert.Contains(t, begin.URL, "state="+begin.State)
+	assert.Contains(t, f.cache.data, "oauth:state:"+begin.State)
+
+	_, err = f.uc.Begin(context.Background(), "facebook")
+	assert.Same(t, errUnknownProvider, err)
+}
+
+func TestOAuth_LinkedIdentity(t *testing.T) {
+	f := newFixture(false)
+	user := f.addUser("someone-else@example.com")
+	_, _ = f.identities.Create(context.Background(), user.ID.String(), "google", "sub-1", "old@example.com")
+
+	result, err := f.login(t, "good")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), result.Login.UserID, "the link wins over the email")
+	assert.False(t, result.Linked)
+	assert.Equal(t, "jane@example.com", f.identities.rows[0].Email, "the reported email is recorded")
+	assert.NotNil(t, f.identities.rows[0].LastLoginAt)
+	assert.Len(t, f.provider.verifier, 43, "a PKCE verifier is passed through")
+	assert.NotEmpty(t, f.provider.nonce)
+}
+
+func TestOAuth_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	f := newFixture(false)
+	user := f.addUser("Jane@Example.com")
+
+	result, err := f.login(t, "good")
+	require.NoError(t, err)
+	assert.True(t, result.Linked)
+	assert.False(t, result.Created)
+	assert.Equal(t, user.ID.String(), result.Login.UserID)
+	require.Len(t, f.identities.rows, 1)
+	assert.Equal(t, user.ID, f.identities.rows[0].UserID)
+	assert.Equal(t, []string{user.ID.String()}, f.users.verified, "the provider verified the address")
+}
+
+func TestOAuth_UnverifiedEmailIsNotLinked(t *testing.T) {
+	f := newFixture(true)
+	f.addUser("jane@example.com")
+	f.provider.identity.EmailVerified = false
+
+	_, err := f.login(t, "good")
+	assert.Same(t, ErrNoAccount, err)
+	assert.Empty(t, f.identities.rows)
+	assert.Empty(t, f.issuer.issued)
+}
+
+func TestOAuth_Signup(t *testing.T) {
+	t.Run("off", func(t *testing.T) {
+		f := newFixture(false)
+		_, err := f.login(t, "good")
+		assert.Same(t, ErrNoAccount, err)
+		assert.Empty(t, f.users.byID)
+	})
+
+	t.Run("on", func(t *testing.T) {
+		f := newFixture(true)
+		result, err := f.login(t, "good")
+		require.NoError(t, err)
+		assert.True(t, result.Created)
+		assert.Equal(t, 1, f.tx.calls, "user and identity are created together")
+
+		user := f.users.byID[result.Login.UserID]
+		require.NotNil(t, user)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.Equal(t, "Jane Doe", user.Name)
+		assert.NotEmpty(t, user.PasswordHash)
+		assert.Equal(t, []string{user.ID.String()}, f.users.verified)
+	})
+}
+
+func TestOAuth_CallbackRefused(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(f *fixture, req *dto.CallbackRequest)
+		want   *apperr.Error
+	}{
+		{"unknown state", func(_ *fixture, req *dto.CallbackRequest) { req.State = "made-up" }, errInvalidState},
+		{"provider denied", func(_ *fixture, req *dto.CallbackRequest) { req.Code, req.Error = "", "access_denied" }, errRefused},
+		{"bad code", func(_ *fixture, req *dto.CallbackRequest) { req.Code = "bad" }, errLoginFailed},
+		{"inactive user", func(f *fixture, _ *dto.CallbackRequest) { f.addUser("jane@example.com").IsActive = false }, errInactive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(false)
+			begin, err := f.uc.Begin(context.Background(), "google")
+			require.NoError(t, err)
+			req := dto.CallbackRequest{Code: "good", State: begin.State}
+			tt.mutate(f, &req)
+
+			_, err = f.uc.Callback(context.Background(), "google", req)
+			// apperr's Is matches by code, so compare the messages too
+			var ae *apperr.Error
+			require.ErrorAs(t, err, &ae)
+			assert.Equal(t, tt.want.Message, ae.Message)
+			assert.Empty(t, f.issuer.issued)
+		})
+	}
+}
+
+func TestOAuth_StateIsSingleUseAndBoundToProvider(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(false)
+	f.addUser("jane@example.com")
+
+	begin, err := f.uc.Begin(ctx, "google")
+	require.NoError(t, err)
+	_, err = f.uc.Callback(ctx, "github", dto.CallbackRequest{Code: "good", State: begin.State})
+	assert.Same(t, errInvalidState, err, "a state is redeemed only with its own provider")
+
+	begin, err = f.uc.Begin(ctx, "google")
+	require.NoError(t, err)
+	req := dto.CallbackRequest{Code: "good", State: begin.State}
+	_, err = f.uc.Callback(ctx, "google", req)
+	require.NoError(t, err)
+	_, err = f.uc.Callback(ctx, "google", req)
+	assert.Same(t, errInvalidState, err, "a replayed callback finds no state")
+}
+
+func TestSignupName(t *testing.T) {
+	assert.Equal(t, "Jane", signupName(&port.ExternalIdentity{Name: " Jane ", Email: "j@example.com"}))
+	assert.Equal(t, "jane.doe", signupName(&port.ExternalIdentity{Email: "jane.doe@example.com"}))
+	assert.Equal(t, "j@example.com", signupName(&port.ExternalIdentity{Email: "j@example.com"}))
+	assert.Len(t, []rune(signupName(&port.ExternalIdentity{Name: strings.Repeat("é", 150)})), 100)
+}
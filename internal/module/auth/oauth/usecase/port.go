@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+)
+
+// UseCase defines the OAuth login operations
+type UseCase interface {
+	// Begin starts a login with the named provider
+	Begin(ctx context.Context, provider string) (*dto.BeginResponse, error)
+	// Callback completes the login the provider redirected back from and
+	// issues a token pair.
+	Callback(ctx context.Context, provider string, req dto.CallbackRequest) (*dto.CallbackResult, error)
+}
+
+// IdentityRepository stores external identities. The concrete
+// *repository.Repository satisfies it.
+type IdentityRepository interface {
+	Get(ctx context.Context, provider, subject string) (*domain.Identity, error)
+	Create(ctx context.Context, userID, provider, subject, email string) (*domain.Identity, error)
+	RecordLogin(ctx context.Context, id, email string) error
+}
+
+// UserStore finds, creates and verifies the users identities belong to. The
+// user module's repository satisfies it.
+type UserStore interface {
+	GetByID(ctx context.Context, id string) (*userdomain.User, error)
+	GetByEmail(ctx context.Context, email string) (*userdomain.User, error)
+	Create(ctx context.Context, email, passwordHash, name string) (*userdomain.User, error)
+	MarkEmailVerified(ctx context.Context, id, email string) (bool, error)
+}
+
+// SessionIssuer issues the access and refresh token pair once the provider
+// has vouched for the user. The auth usecase satisfies it.
+type SessionIssuer interface {
+	IssueSession(ctx context.Context, user *userdomain.User) (*authdto.LoginResponse, error)
+}
+
+// TxRunner runs fn inside a database transaction. *database.Transactor
+// satisfies it.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn database.TxFunc, opts ...database.TxOption) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+// Credential is a passkey registered to a user
+type Credential struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Name is the label the user gave the passkey, e.g. "Work laptop"
+	Name string
+	// WebAuthn is the credential record: public key, sign count and flags.
+	// Each login updates it.
+	WebAuthn   webauthn.Credential
+	CreatedAt  time.Time
+	LastUsedAt *time.Time // nil until the passkey is first used to log in
+}
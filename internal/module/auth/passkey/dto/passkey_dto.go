@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+// BeginRegistrationResponse carries the options to pass to
+// navigator.credentials.create(). SessionID identifies the ceremony when it
+// is finished.
+type BeginRegistrationResponse struct {
+	SessionID string                       `json:"session_id"`
+	Options   *protocol.CredentialCreation `json:"options"`
+}
+
+// FinishRegistrationRequest completes a registration with the
+// PublicKeyCredential returned by navigator.credentials.create(), serialized
+// with its toJSON() method.
+type FinishRegistrationRequest struct {
+	SessionID string `json:"session_id" validate:"required"`
+	// Name labels the passkey in the caller's passkey list
+	Name       string          `json:"name" validate:"required,max=100"`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+// BeginLoginResponse carries the options to pass to
+// navigator.credentials.get(). SessionID identifies the ceremony when it is
+// finished.
+type BeginLoginResponse struct {
+	SessionID string                        `json:"session_id"`
+	Options   *protocol.CredentialAssertion `json:"options"`
+}
+
+// FinishLoginRequest completes a login with the PublicKeyCredential returned
+// by navigator.credentials.get(), serialized with its toJSON() method.
+type FinishLoginRequest struct {
+	SessionID  string          `json:"session_id" validate:"required"`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+// CredentialResponse describes one of the caller's passkeys
+type CredentialResponse struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at"`
+	// BackedUp reports whether the passkey is synced across devices, e.g.
+	// through a password manager
+	BackedUp bool `json:"backed_up"`
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles passkey HTTP requests
+type Handler struct {
+	useCase usecase.UseCase
+}
+
+// NewHandler creates a new passkey handler
+func NewHandler(useCase usecase.UseCase) *Handler {
+	return &Handler{useCase: useCase}
+}
+
+// BeginRegistration starts adding a passkey to the caller's account
+func (h *Handler) BeginRegistration(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+	// A passkey would let an admin acting as the user log in as them later.
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot register a passkey for an impersonated user")
+	}
+
+	result, err := h.useCase.BeginRegistration(c.UserContext(), callerID)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// FinishRegistration stores the passkey the authenticator created
+func (h *Handler) FinishRegistration(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot register a passkey for an impersonated user")
+	}
+
+	var req dto.FinishRegistrationRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.FinishRegistration(c.UserContext(), callerID, req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Created(c, result)
+}
+
+// BeginLogin starts a passkey login
+func (h *Handler) BeginLogin(c *fiber.Ctx) error {
+	result, err := h.useCase.BeginLogin(c.UserContext())
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// FinishLogin verifies the passkey assertion and returns a token pair, as
+// POST /auth/login does
+func (h *Handler) FinishLogin(c *fiber.Ctx) error {
+	var req dto.FinishLoginRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.FinishLogin(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// List returns the caller's passkeys
+func (h *Handler) List(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+
+	result, err := h.useCase.List(c.UserContext(), callerID)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.Success(c, result)
+}
+
+// Delete removes one of the caller's passkeys
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	callerID := middleware.GetUserID(c)
+	if callerID == "" {
+		return response.Unauthorized(c, "Missing or invalid token")
+	}
+
+	if err := h.useCase.Delete(c.UserContext(), callerID, c.Params("id")); err != nil {
+		return response.Fail(c, err)
+	}
+
+	return response.NoContent(c)
+}
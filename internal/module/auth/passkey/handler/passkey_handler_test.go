@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/usecase"
+)
+
+// registrationStub records the user each registration step was called for
+type registrationStub struct {
+	usecase.UseCase
+	begun, finished string
+}
+
+func (s *registrationStub) BeginRegistration(_ context.Context, userID string) (*dto.BeginRegistrationResponse, error) {
+	s.begun = userID
+	return &dto.BeginRegistrationResponse{}, nil
+}
+
+func (s *registrationStub) FinishRegistration(_ context.Context, userID string, _ dto.FinishRegistrationRequest) (*dto.CredentialResponse, error) {
+	s.finished = userID
+	return &dto.CredentialResponse{}, nil
+}
+
+func TestRegistration_Impersonation(t *testing.T) {
+	const userID = "user-1"
+
+	newApp := func(stub *registrationStub, actorID string) *fiber.App {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID)
+			if actorID != "" {
+				c.Locals("actor_id", actorID)
+			}
+			return c.Next()
+		})
+		h := NewHandler(stub)
+		app.Post("/auth/passkey/register/begin", h.BeginRegistration)
+		app.Post("/auth/passkey/register/finish", h.FinishRegistration)
+		return app
+	}
+	finish := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/auth/passkey/register/finish",
+			strings.NewReader(`{"session_id":"s","name":"Laptop","credential":{}}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("registers for the caller", func(t *testing.T) {
+		stub := &registrationStub{}
+		app := newApp(stub, "")
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/auth/passkey/register/begin", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, err = app.Test(finish())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, userID, stub.begun)
+		assert.Equal(t, userID, stub.finished)
+	})
+
+	t.Run("refused while impersonating", func(t *testing.T) {
+		stub := &registrationStub{}
+		app := newApp(stub, "admin-1")
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/auth/passkey/register/begin", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp, err = app.Test(finish())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, stub.begun)
+		assert.Empty(t, stub.finished)
+	})
+}
@@ -0,0 +1,67 @@
+package passkey
+
+import (
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/handler"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/repository"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Module represents the passkey module
+type Module struct {
+	handler *handler.Handler
+	authCfg middleware.AuthConfig
+	cache   port.Cache
+}
+
+// NewModule creates a new passkey module.
+// users is shared with the auth and user modules; issuer is the auth
+// module's SessionIssuer, so a passkey login ends in the same token pair as
+// a password login. It fails when cfg does not describe a usable relying
+// party.
+func NewModule(repo *repository.Repository, users usecase.UserLookup, issuer usecase.SessionIssuer, cache port.Cache, auditor port.Auditor, authCfg middleware.AuthConfig, cfg config.PasskeyConfig) (*Module, error) {
+	uc, err := usecase.NewUseCase(repo, users, issuer, cache, cfg)
+	if err != nil {
+		return nil, err
+	}
+	audited := usecase.NewAuditedUseCase(uc, auditor)
+
+	return &Module{
+		handler: handler.NewHandler(audited),
+		authCfg: authCfg,
+		cache:   cache,
+	}, nil
+}
+
+// RegisterRoutes registers passkey routes under /auth/passkey.
+//
+//   - login/begin and login/finish are public and share the auth module's
+//     per-IP limit (20 req / 5 min, fail-closed).
+//   - Registering, listing and deleting passkeys require a valid JWT and act
+//     on the caller's own account.
+func (m *Module) RegisterRoutes(router fiber.Router) {
+	group := router.Group("/auth/passkey")
+
+	// The closer is discarded for the same reason as in the auth module:
+	// redisBackend.Close is a no-op.
+	authRateLimit, _ := middleware.RateLimit(middleware.RateLimitConfig{
+		Max:        20,
+		Window:     5 * time.Minute,
+		UseRedis:   true,
+		FailClosed: true,
+	}, m.cache)
+
+	group.Post("/login/begin", authRateLimit, m.handler.BeginLogin)
+	group.Post("/login/finish", authRateLimit, middleware.ClientInfo(), m.handler.FinishLogin)
+
+	authMiddleware := middleware.Auth(m.authCfg)
+	group.Post("/register/begin", authMiddleware, m.handler.BeginRegistration)
+	group.Post("/register/finish", authMiddleware, m.handler.FinishRegistration)
+	group.Get("/credentials", authMiddleware, m.handler.List)
+	group.Delete("/credentials/:id", authMiddleware, m.handler.Delete)
+}
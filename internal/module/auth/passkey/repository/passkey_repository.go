@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/repository/sqlc"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores passkeys in webauthn_credentials using SQLC-generated
+// queries. Like the user repository it is TX-aware.
+type Repository struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithQueryTimeout bounds every query issued by the repository to d (see
+// database.WithQueryTimeout). Zero disables the per-query deadline.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(r *Repository) {
+		r.queryTimeout = d
+	}
+}
+
+// NewRepository creates a new passkey repository
+func NewRepository(pool *pgxpool.Pool, opts ...Option) *Repository {
+	r := &Repository{pool: pool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// queries returns a *sqlc.Queries bound to the transaction in ctx, or to the
+// pool when no transaction is active.
+func (r *Repository) queries(ctx context.Context) *sqlc.Queries {
+	return sqlc.New(database.DBFromContext(ctx, r.pool))
+}
+
+// Create stores a newly registered passkey
+func (r *Repository) Create(ctx context.Context, userID, name string, cred webauthn.Credential) (*domain.Credential, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("insert", "webauthn_credentials", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "CreateWebauthnCredential", "webauthn_credentials")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", userID)
+	}
+	record, err := json.Marshal(cred)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode passkey: %w", err)
+	}
+
+	row, err := r.queries(ctx).CreateWebauthnCredential(ctx, sqlc.CreateWebauthnCredentialParams{
+		UserID:       pgutil.UUIDToPgtype(uid),
+		CredentialID: cred.ID,
+		Name:         name,
+		Credential:   record,
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to create passkey: %w", err), "passkey")
+	}
+
+	return sqlcCredentialToDomain(&row)
+}
+
+// GetByCredentialID returns the passkey with the given WebAuthn credential ID
+func (r *Repository) GetByCredentialID(ctx context.Context, credentialID []byte) (*domain.Credential, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "webauthn_credentials", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "GetWebauthnCredentialByCredentialID", "webauthn_credentials")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	row, err := r.queries(ctx).GetWebauthnCredentialByCredentialID(ctx, credentialID)
+	if err != nil {
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to get passkey: %w", err), "passkey")
+	}
+
+	return sqlcCredentialToDomain(&row)
+}
+
+// ListByUser returns the user's passkeys, oldest first
+func (r *Repository) ListByUser(ctx context.Context, userID string) ([]domain.Credential, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "webauthn_credentials", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ListWebauthnCredentialsByUser", "webauthn_credentials")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return []domain.Credential{}, nil
+	}
+
+	rows, err := r.queries(ctx).ListWebauthnCredentialsByUser(ctx, pgutil.UUIDToPgtype(uid))
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+
+	creds := make([]domain.Credential, 0, len(rows))
+	for i := range rows {
+		cred, err := sqlcCredentialToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, *cred)
+	}
+	return creds, nil
+}
+
+// RecordUse stores the credential record as updated by a login (sign count,
+// flags) and sets last_used_at.
+func (r *Repository) RecordUse(ctx context.Context, id string, cred webauthn.Credential) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "webauthn_credentials", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "UpdateWebauthnCredentialUse", "webauthn_credentials")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("passkey %s not found", id)
+	}
+	record, err := json.Marshal(cred)
+	if err != nil {
+		return fmt.Errorf("failed to encode passkey: %w", err)
+	}
+
+	if err := r.queries(ctx).UpdateWebauthnCredentialUse(ctx, sqlc.UpdateWebauthnCredentialUseParams{
+		ID:         pgutil.UUIDToPgtype(uid),
+		Credential: record,
+	}); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to update passkey: %w", err)
+	}
+	return nil
+}
+
+// Delete removes one of the user's passkeys. Another user's passkey is
+// reported as not found.
+func (r *Repository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("delete", "webauthn_credentials", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "DeleteWebauthnCredential", "webauthn_credentials")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	cid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("passkey %s not found", id)
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return apperr.NotFoundf("passkey %s not found", id)
+	}
+
+	n, err := r.queries(ctx).DeleteWebauthnCredential(ctx, sqlc.DeleteWebauthnCredentialParams{
+		ID:     pgutil.UUIDToPgtype(cid),
+		UserID: pgutil.UUIDToPgtype(uid),
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if n == 0 {
+		return apperr.NotFoundf("passkey %s not found", id)
+	}
+	return nil
+}
+
+// sqlcCredentialToDomain converts a SQLC row to a domain credential
+func sqlcCredentialToDomain(c *sqlc.WebauthnCredential) (*domain.Credential, error) {
+	var record webauthn.Credential
+	if err := json.Unmarshal(c.Credential, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode passkey: %w", err)
+	}
+
+	var createdAt time.Time
+	if c.CreatedAt.Valid {
+		createdAt = c.CreatedAt.Time
+	}
+	var lastUsedAt *time.Time
+	if c.LastUsedAt.Valid {
+		lastUsedAt = &c.LastUsedAt.Time
+	}
+
+	return &domain.Credential{
+		ID:         pgutil.PgtypeToUUID(c.ID),
+		UserID:     pgutil.PgtypeToUUID(c.UserID),
+		Name:       c.Name,
+		WebAuthn:   record,
+		CreatedAt:  createdAt,
+		LastUsedAt: lastUsedAt,
+	}, nil
+}
@@ -0,0 +1,24 @@
+-- name: CreateWebauthnCredential :one
+INSERT INTO webauthn_credentials (user_id, credential_id, name, credential)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, credential_id, name, credential, created_at, last_used_at;
+
+-- name: GetWebauthnCredentialByCredentialID :one
+SELECT id, user_id, credential_id, name, credential, created_at, last_used_at
+FROM webauthn_credentials
+WHERE credential_id = $1;
+
+-- name: ListWebauthnCredentialsByUser :many
+SELECT id, user_id, credential_id, name, credential, created_at, last_used_at
+FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC;
+
+-- name: UpdateWebauthnCredentialUse :exec
+UPDATE webauthn_credentials
+SET credential = $2, last_used_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteWebauthnCredential :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2;
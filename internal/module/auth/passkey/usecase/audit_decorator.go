@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	authusecase "github.com/14mdzk/goscratch/internal/module/auth/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// AuditedUseCase wraps a UseCase and logs passkey logins (success and
+// failure) and passkeys being added and removed. The begin steps and List are
+// delegated as-is.
+type AuditedUseCase struct {
+	inner   UseCase
+	auditor port.Auditor
+}
+
+// NewAuditedUseCase creates a new AuditedUseCase decorator.
+func NewAuditedUseCase(inner UseCase, auditor port.Auditor) *AuditedUseCase {
+	return &AuditedUseCase{inner: inner, auditor: auditor}
+}
+
+// BeginRegistration delegates to inner without audit logging.
+func (d *AuditedUseCase) BeginRegistration(ctx context.Context, userID string) (*dto.BeginRegistrationResponse, error) {
+	return d.inner.BeginRegistration(ctx, userID)
+}
+
+// FinishRegistration logs a CREATE entry for the new passkey.
+func (d *AuditedUseCase) FinishRegistration(ctx context.Context, userID string, req dto.FinishRegistrationRequest) (*dto.CredentialResponse, error) {
+	resp, err := d.inner.FinishRegistration(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "passkey", resp.ID)
+	entry.NewValue = map[string]any{"user_id": userID, "name": resp.Name}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// BeginLogin delegates to inner without audit logging.
+func (d *AuditedUseCase) BeginLogin(ctx context.Context) (*dto.BeginLoginResponse, error) {
+	return d.inner.BeginLogin(ctx)
+}
+
+// FinishLogin logs a LOGIN entry like a password login, with method
+// "passkey". A failed login names no account: the assertion is not trusted
+// until it verifies.
+func (d *AuditedUseCase) FinishLogin(ctx context.Context, req dto.FinishLoginRequest) (*authdto.LoginResponse, error) {
+	resp, err := d.inner.FinishLogin(ctx, req)
+	if err != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionLogin, "user", "")
+		entry.Metadata = map[string]any{
+			"outcome": "failed",
+			"method":  "passkey",
+			"reason":  classifyLoginFailure(err),
+		}
+		_ = d.auditor.Log(ctx, entry)
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionLogin, "user", resp.UserID)
+	entry.Metadata = map[string]any{"outcome": "success", "method": "passkey"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// List delegates to inner without audit logging.
+func (d *AuditedUseCase) List(ctx context.Context, userID string) ([]dto.CredentialResponse, error) {
+	return d.inner.List(ctx, userID)
+}
+
+// Delete logs a DELETE entry for the removed passkey.
+func (d *AuditedUseCase) Delete(ctx context.Context, userID, id string) error {
+	if err := d.inner.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "passkey", id)
+	entry.OldValue = map[string]any{"user_id": userID}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// classifyLoginFailure maps a FinishLogin error to a fixed reason, so raw
+// error strings never reach the audit log.
+func classifyLoginFailure(err error) string {
+	var ae *apperr.Error
+	if errors.As(err, &ae) {
+		switch {
+		case ae.Code == apperr.CodeUnauthorized:
+			return "invalid_passkey"
+		case ae.Code == apperr.CodeBadRequest:
+			return "invalid_request"
+		case ae == authusecase.ErrEmailNotVerified: // apperr's Is matches any Forbidden
+			return "email_unverified"
+		}
+	}
+	return "unknown"
+}
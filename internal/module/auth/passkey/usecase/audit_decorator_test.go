@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	authusecase "github.com/14mdzk/goscratch/internal/module/auth/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// stubUseCase returns canned results from the audited methods
+type stubUseCase struct {
+	UseCase
+	login *authdto.LoginResponse
+	cred  *dto.CredentialResponse
+	err   error
+}
+
+func (s *stubUseCase) FinishRegistration(context.Context, string, dto.FinishRegistrationRequest) (*dto.CredentialResponse, error) {
+	return s.cred, s.err
+}
+
+func (s *stubUseCase) FinishLogin(context.Context, dto.FinishLoginRequest) (*authdto.LoginResponse, error) {
+	return s.login, s.err
+}
+
+func (s *stubUseCase) Delete(context.Context, string, string) error { return s.err }
+
+// recordingAuditor records the entries it is given
+type recordingAuditor struct {
+	entries []port.AuditEntry
+}
+
+func (a *recordingAuditor) Log(_ context.Context, entry port.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+func TestPasskeyAuditDecorator_FinishLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success is a LOGIN with method passkey", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		dec := NewAuditedUseCase(&stubUseCase{login: &authdto.LoginResponse{UserID: "u-1"}}, auditor)
+
+		_, err := dec.FinishLogin(ctx, dto.FinishLoginRequest{})
+		require.NoError(t, err)
+
+		require.Len(t, auditor.entries, 1)
+		entry := auditor.entries[0]
+		assert.Equal(t, port.AuditActionLogin, entry.Action)
+		assert.Equal(t, "u-1", entry.ResourceID)
+		assert.Equal(t, map[string]any{"outcome": "success", "method": "passkey"}, entry.Metadata)
+	})
+
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{errLoginFailed, "invalid_passkey"},
+		{errCeremonyExpired, "invalid_request"},
+		{authusecase.ErrEmailNotVerified, "email_unverified"},
+	}
+	for _, tt := range tests {
+		t.Run("failure reason "+tt.reason, func(t *testing.T) {
+			auditor := &recordingAuditor{}
+			dec := NewAuditedUseCase(&stubUseCase{err: tt.err}, auditor)
+
+			_, err := dec.FinishLogin(ctx, dto.FinishLoginRequest{})
+			assert.ErrorIs(t, err, tt.err)
+
+			require.Len(t, auditor.entries, 1)
+			entry := auditor.entries[0]
+			assert.Empty(t, entry.ResourceID)
+			assert.Equal(t, "failed", entry.Metadata["outcome"])
+			assert.Equal(t, tt.reason, entry.Metadata["reason"])
+		})
+	}
+}
+
+func TestPasskeyAuditDecorator_Manage(t *testing.T) {
+	ctx := context.Background()
+	auditor := &recordingAuditor{}
+	dec := NewAuditedUseCase(&stubUseCase{cred: &dto.CredentialResponse{ID: "pk-1", Name: "Laptop"}}, auditor)
+
+	_, err := dec.FinishRegistration(ctx, "u-1", dto.FinishRegistrationRequest{})
+	require.NoError(t, err)
+	require.NoError(t, dec.Delete(ctx, "u-1", "pk-1"))
+
+	require.Len(t, auditor.entries, 2)
+	assert.Equal(t, port.AuditActionCreate, auditor.entries[0].Action)
+	assert.Equal(t, "passkey", auditor.entries[0].Resource)
+	assert.Equal(t, "pk-1", auditor.entries[0].ResourceID)
+	assert.Equal(t, port.AuditActionDelete, auditor.entries[1].Action)
+	assert.Equal(t, "pk-1", auditor.entries[1].ResourceID)
+}
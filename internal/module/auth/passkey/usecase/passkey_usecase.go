@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// Ceremony state is kept in the cache between begin and finish, under a
+// random session ID handed to the client:
+//
+//	passkey:register:<sessionID> -> webauthn.SessionData (JSON)
+//	passkey:login:<sessionID>    -> webauthn.SessionData (JSON)
+//
+// Finishing deletes the entry, so each challenge is answered at most once.
+const (
+	registerKeyPrefix = "passkey:register:"
+	loginKeyPrefix    = "passkey:login:"
+)
+
+// DefaultCeremonyTimeout applies when passkey.ceremony_timeout is 0
+const DefaultCeremonyTimeout = 5 * time.Minute
+
+var (
+	errCeremonyExpired = apperr.ErrBadRequest.WithMessage("Passkey session expired or unknown; start again")
+	errBadResponse     = apperr.ErrBadRequest.WithMessage("Invalid passkey response")
+	// errLoginFailed covers every rejected login alike: unknown passkey, bad
+	// signature, inactive owner, suspected clone.
+	errLoginFailed = apperr.ErrUnauthorized.WithMessage("Passkey login failed")
+)
+
+type passkeyUseCase struct {
+	webauthn *webauthn.WebAuthn
+	repo     Repository
+	users    UserLookup
+	issuer   SessionIssuer
+	cache    port.Cache
+	timeout  time.Duration
+}
+
+// NewUseCase creates the passkey use case. It fails when cfg does not
+// describe a usable relying party.
+func NewUseCase(repo Repository, users UserLookup, issuer SessionIssuer, cache port.Cache, cfg config.PasskeyConfig) (UseCase, error) {
+	timeout := time.Duration(cfg.CeremonyTimeout)
+	if timeout <= 0 {
+		timeout = DefaultCeremonyTimeout
+	}
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.Origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: timeout},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: timeout},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &passkeyUseCase{
+		webauthn: wa,
+		repo:     repo,
+		users:    users,
+		issuer:   issuer,
+		cache:    cache,
+		timeout:  timeout,
+	}, nil
+}
+
+// webauthnUser adapts a user and their passkeys to webauthn.User. The user
+// handle is the user's UUID, which carries no personal data.
+type webauthnUser struct {
+	user  *userdomain.User
+	creds []webauthn.Credential
+}
+
+func newWebauthnUser(user *userdomain.User, creds []domain.Credential) webauthnUser {
+	wu := webauthnUser{user: user, creds: make([]webauthn.Credential, len(creds))}
+	for i, c := range creds {
+		wu.creds[i] = c.WebAuthn
+	}
+	return wu
+}
+
+func (u webauthnUser) WebAuthnID() []byte                         { return u.user.ID[:] }
+func (u webauthnUser) WebAuthnName() string                       { return u.user.Email }
+func (u webauthnUser) WebAuthnDisplayName() string                { return u.user.Name }
+func (u webauthnUser) WebAuthnCredentials() []webauthn.Credential { return u.creds }
+
+// BeginRegistration asks for a discoverable credential, so the passkey can
+// later log in without the user typing their email. Passkeys the user
+// already has are excluded, so one authenticator is not registered twice.
+func (uc *passkeyUseCase) BeginRegistration(ctx context.Context, userID string) (*dto.BeginRegistrationResponse, error) {
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := uc.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	wu := newWebauthnUser(user, existing)
+
+	creation, session, err := uc.webauthn.BeginRegistration(wu,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(wu.creds).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, apperr.Internalf("failed to begin passkey registration")
+	}
+	sessionID, err := uc.saveCeremony(ctx, registerKeyPrefix, session)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.BeginRegistrationResponse{SessionID: sessionID, Options: creation}, nil
+}
+
+// FinishRegistration verifies the attestation against the stored challenge
+// and stores the passkey.
+func (uc *passkeyUseCase) FinishRegistration(ctx context.Context, userID string, req dto.FinishRegistrationRequest) (*dto.CredentialResponse, error) {
+	session, ok := uc.takeCeremony(ctx, registerKeyPrefix, req.SessionID)
+	if !ok {
+		return nil, errCeremonyExpired
+	}
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// A ceremony begun by someone else cannot be finished by this caller.
+	if !bytes.Equal(session.UserID, user.ID[:]) {
+		return nil, errCeremonyExpired
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errBadResponse.WithError(err)
+	}
+	cred, err := uc.webauthn.CreateCredential(newWebauthnUser(user, nil), *session, parsed)
+	if err != nil {
+		return nil, apperr.ErrBadRequest.WithMessage("Passkey registration failed").WithError(err)
+	}
+
+	stored, err := uc.repo.Create(ctx, userID, req.Name, *cred)
+	if err != nil {
+		return nil, err
+	}
+	return toCredentialResponse(stored), nil
+}
+
+// BeginLogin starts a discoverable login.
+func (uc *passkeyUseCase) BeginLogin(ctx context.Context) (*dto.BeginLoginResponse, error) {
+	assertion, session, err := uc.webauthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, apperr.Internalf("failed to begin passkey login")
+	}
+	sessionID, err := uc.saveCeremony(ctx, loginKeyPrefix, session)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.BeginLoginResponse{SessionID: sessionID, Options: assertion}, nil
+}
+
+// FinishLogin verifies the assertion, stores the authenticator's new sign
+// count and issues a token pair.
+//
+// A sign count that did not increase suggests the authenticator was cloned;
+// the login is refused. Passkeys that always report 0 (most synced passkeys)
+// are not affected.
+func (uc *passkeyUseCase) FinishLogin(ctx context.Context, req dto.FinishLoginRequest) (*authdto.LoginResponse, error) {
+	session, ok := uc.takeCeremony(ctx, loginKeyPrefix, req.SessionID)
+	if !ok {
+		return nil, errCeremonyExpired
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errBadResponse.WithError(err)
+	}
+
+	var (
+		stored *domain.Credential
+		user   *userdomain.User
+	)
+	// The handler resolves the passkey's owner from the credential ID and
+	// the user handle the authenticator returned; both must agree.
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		c, err := uc.repo.GetByCredentialID(ctx, rawID)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(userHandle, c.UserID[:]) {
+			return nil, errLoginFailed
+		}
+		u, err := uc.users.GetByID(ctx, c.UserID.String())
+		if err != nil {
+			return nil, err
+		}
+		stored, user = c, u
+		return newWebauthnUser(u, []domain.Credential{*c}), nil
+	}
+
+	_, cred, err := uc.webauthn.ValidatePasskeyLogin(handler, *session, parsed)
+	if err != nil {
+		if ae, ok := apperr.AsAppError(err); ok && ae.Code == apperr.CodeInternalError {
+			return nil, err
+		}
+		return nil, errLoginFailed.WithError(err)
+	}
+	if cred.Authenticator.CloneWarning || !user.IsActive {
+		return nil, errLoginFailed
+	}
+
+	// The sign count must be saved for clone detection to work, so a failed
+	// write fails the login.
+	if err := uc.repo.RecordUse(ctx, stored.ID.String(), *cred); err != nil {
+		return nil, err
+	}
+	return uc.issuer.IssueSession(ctx, user)
+}
+
+// List returns the caller's passkeys, oldest first.
+func (uc *passkeyUseCase) List(ctx context.Context, userID string) ([]dto.CredentialResponse, error) {
+	creds, err := uc.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.CredentialResponse, len(creds))
+	for i := range creds {
+		out[i] = *toCredentialResponse(&creds[i])
+	}
+	return out, nil
+}
+
+// Delete removes one of the caller's passkeys. Sessions it opened stay valid
+// until they expire or are revoked.
+func (uc *passkeyUseCase) Delete(ctx context.Context, userID, id string) error {
+	return uc.repo.Delete(ctx, id, userID)
+}
+
+// saveCeremony stores session under a new random session ID.
+func (uc *passkeyUseCase) saveCeremony(ctx context.Context, prefix string, session *webauthn.SessionData) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", apperr.Internalf("failed to generate passkey session ID")
+	}
+	sessionID := base64.RawURLEncoding.EncodeToString(raw)
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", apperr.Internalf("failed to encode passkey session")
+	}
+	if err := uc.cache.Set(ctx, prefix+sessionID, data, uc.timeout); err != nil {
+		return "", apperr.Internalf("auth: cache unavailable, cannot start passkey ceremony")
+	}
+	return sessionID, nil
+}
+
+// takeCeremony loads and deletes the session stored under sessionID.
+func (uc *passkeyUseCase) takeCeremony(ctx context.Context, prefix, sessionID string) (*webauthn.SessionData, bool) {
+	data, err := uc.cache.Get(ctx, prefix+sessionID)
+	if err != nil {
+		return nil, false
+	}
+	_ = uc.cache.Delete(ctx, prefix+sessionID)
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, false
+	}
+	return &session, true
+}
+
+func toCredentialResponse(c *domain.Credential) *dto.CredentialResponse {
+	resp := &dto.CredentialResponse{
+		ID:        c.ID.String(),
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		BackedUp:  c.WebAuthn.Flags.BackupState,
+	}
+	if c.LastUsedAt != nil {
+		lastUsed := c.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsed
+	}
+	return resp
+}
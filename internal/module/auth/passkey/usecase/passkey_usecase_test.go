@@ -0,0 +1,426 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+const testOrigin = "https://app.example.com"
+
+// mapCache is an in-memory port.Cache
+type mapCache struct {
+	data map[string][]byte
+}
+
+func newMapCache() *mapCache { return &mapCache{data: map[string][]byte{}} }
+
+func (c *mapCache) Get(_ context.Context, key string) ([]byte, error) {
+	if v, ok := c.data[key]; ok {
+		return v, nil
+	}
+	return nil, port.ErrCacheMiss
+}
+func (c *mapCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.data[key] = value
+	return nil
+}
+func (c *mapCache) Delete(_ context.Context, key string) error {
+	delete(c.data, key)
+	return nil
+}
+func (c *mapCache) DeleteByPrefix(context.Context, string) error           { return nil }
+func (c *mapCache) KeysByPrefix(context.Context, string) ([]string, error) { return nil, nil }
+func (c *mapCache) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := c.data[key]
+	return ok, nil
+}
+func (c *mapCache) SetJSON(context.Context, string, any, time.Duration) error { return nil }
+func (c *mapCache) GetJSON(context.Context, string, any) error                { return port.ErrCacheMiss }
+func (c *mapCache) Increment(context.Context, string) (int64, error)          { return 0, nil }
+func (c *mapCache) Decrement(context.Context, string) (int64, error)          { return 0, nil }
+func (c *mapCache) Expire(context.Context, string, time.Duration) error       { return nil }
+func (c *mapCache) SlidingWindowAllow(_ context.Context, _ string, max int, _ time.Duration) (bool, int, int, error) {
+	return true, max, 0, nil
+}
+func (c *mapCache) Close() error { return nil }
+
+// fakeRepo stores passkeys in memory
+type fakeRepo struct {
+	creds []domain.Credential
+}
+
+func (r *fakeRepo) Create(_ context.Context, userID, name string, cred webauthn.Credential) (*domain.Credential, error) {
+	c := domain.Credential{ID: uuid.New(), UserID: uuid.MustParse(userID), Name: name, WebAuthn: cred, CreatedAt: time.Now()}
+	r.creds = append(r.creds, c)
+	return &c, nil
+}
+
+func (r *fakeRepo) GetByCredentialID(_ context.Context, credentialID []byte) (*domain.Credential, error) {
+	for i := range r.creds {
+		if string(r.creds[i].WebAuthn.ID) == string(credentialID) {
+			c := r.creds[i]
+			return &c, nil
+		}
+	}
+	return nil, apperr.NotFoundf("passkey not found")
+}
+
+func (r *fakeRepo) ListByUser(_ context.Context, userID string) ([]domain.Credential, error) {
+	var out []domain.Credential
+	for _, c := range r.creds {
+		if c.UserID.String() == userID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) RecordUse(_ context.Context, id string, cred webauthn.Credential) error {
+	for i := range r.creds {
+		if r.creds[i].ID.String() == id {
+			now := time.Now()
+			r.creds[i].WebAuthn = cred
+			r.creds[i].LastUsedAt = &now
+			return nil
+		}
+	}
+	return apperr.NotFoundf("passkey %s not found", id)
+}
+
+func (r *fakeRepo) Delete(_ context.Context, id, userID string) error {
+	for i, c := range r.creds {
+		if c.ID.String() == id && c.UserID.String() == userID {
+			r.creds = append(r.creds[:i], r.creds[i+1:]...)
+			return nil
+		}
+	}
+	return apperr.NotFoundf("passkey %s not found", id)
+}
+
+// fakeUsers looks users up by ID
+type fakeUsers map[string]*userdomain.User
+
+func (f fakeUsers) GetByID(_ context.Context, id string) (*userdomain.User, error) {
+	if u, ok := f[id]; ok {
+		return u, nil
+	}
+	return nil, apperr.NotFoundf("user %s not found", id)
+}
+
+// fakeIssuer records the users it issued sessions for
+type fakeIssuer struct {
+	issued []string
+}
+
+func (f *fakeIssuer) IssueSession(_ context.Context, user *userdomain.User) (*authdto.LoginResponse, error) {
+	f.issued = append(f.issued, user.ID.String())
+	return &authdto.LoginResponse{AccessToken: "access", RefreshToken: "refresh", UserID: user.ID.String()}, nil
+}
+
+// authenticator is a software passkey: a P-256 key pair with "none"
+// attestation, enough to drive both ceremonies end to end.
+type authenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	userID    []byte
+	signCount uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &authenticator{key: key, id: id}
+}
+
+func clientData(t *testing.T, typ string, challenge []byte) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    testOrigin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+// authData builds authenticator data: user present and verified, plus the
+// attested credential when attested is set.
+func (a *authenticator) authData(t *testing.T, attested bool) []byte {
+	t.Helper()
+	rpIDHash := sha256.Sum256([]byte("example.com"))
+	flags := byte(protocol.FlagUserPresent | protocol.FlagUserVerified)
+	if attested {
+		flags |= byte(protocol.FlagAttestedCredentialData)
+	}
+	out := append([]byte{}, rpIDHash[:]...)
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint32(out, a.signCount)
+	if !attested {
+		return out
+	}
+
+	out = append(out, make([]byte, 16)...) // AAGUID
+	out = binary.BigEndian.AppendUint16(out, uint16(len(a.id)))
+	out = append(out, a.id...)
+	pub, err := webauthncbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+	return append(out, pub...)
+}
+
+// create answers a registration challenge
+func (a *authenticator) create(t *testing.T, opts *protocol.CredentialCreation) json.RawMessage {
+	t.Helper()
+	a.userID = opts.Response.User.ID.(protocol.URLEncodedBase64)
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(t, true),
+	})
+	require.NoError(t, err)
+	return credentialJSON(t, a.id, map[string]string{
+		"clientDataJSON":    b64(clientData(t, "webauthn.create", opts.Response.Challenge)),
+		"attestationObject": b64(attestation),
+	})
+}
+
+// get answers a login challenge, bumping the sign count first
+func (a *authenticator) get(t *testing.T, opts *protocol.CredentialAssertion) json.RawMessage {
+	t.Helper()
+	a.signCount++
+	data := a.authData(t, false)
+	cd := clientData(t, "webauthn.get", opts.Response.Challenge)
+	digest := sha256.Sum256(cd)
+	signed := sha256.Sum256(append(append([]byte{}, data...), digest[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, signed[:])
+	require.NoError(t, err)
+	return credentialJSON(t, a.id, map[string]string{
+		"clientDataJSON":    b64(cd),
+		"authenticatorData": b64(data),
+		"signature":         b64(sig),
+		"userHandle":        b64(a.userID),
+	})
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func credentialJSON(t *testing.T, id []byte, response map[string]string) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"id":       b64(id),
+		"rawId":    b64(id),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+type fixture struct {
+	uc     UseCase
+	repo   *fakeRepo
+	users  fakeUsers
+	issuer *fakeIssuer
+	cache  *mapCache
+	user   *userdomain.User
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	user := &userdomain.User{ID: uuid.New(), Email: "user@example.com", Name: "User", IsActive: true}
+	f := &fixture{
+		repo:   &fakeRepo{},
+		users:  fakeUsers{user.ID.String(): user},
+		issuer: &fakeIssuer{},
+		cache:  newMapCache(),
+		user:   user,
+	}
+	uc, err := NewUseCase(f.repo, f.users, f.issuer, f.cache, config.PasskeyConfig{
+		Enabled:       true,
+		RPID:          "example.com",
+		RPDisplayName: "Example",
+		Origins:       []string{testOrigin},
+	})
+	require.NoError(t, err)
+	f.uc = uc
+	return f
+}
+
+// register runs a full registration ceremony for f.user
+func (f *fixture) register(t *testing.T, a *authenticator) *dto.CredentialResponse {
+	t.Helper()
+	ctx := context.Background()
+	begin, err := f.uc.BeginRegistration(ctx, f.user.ID.String())
+	require.NoError(t, err)
+	resp, err := f.uc.FinishRegistration(ctx, f.user.ID.String(), dto.FinishRegistrationRequest{
+		SessionID:  begin.SessionID,
+		Name:       "Laptop",
+		Credential: a.create(t, begin.Options),
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// login runs a full login ceremony with a
+func (f *fixture) login(t *testing.T, a *authenticator) (*authdto.LoginResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+	begin, err := f.uc.BeginLogin(ctx)
+	require.NoError(t, err)
+	return f.uc.FinishLogin(ctx, dto.FinishLoginRequest{SessionID: begin.SessionID, Credential: a.get(t, begin.Options)})
+}
+
+func TestPasskey_RegisterAndLogin(t *testing.T) {
+	f := newFixture(t)
+	a := newAuthenticator(t)
+
+	cred := f.register(t, a)
+	assert.Equal(t, "Laptop", cred.Name)
+	assert.Nil(t, cred.LastUsedAt)
+	assert.Equal(t, f.user.ID[:], a.userID, "the user handle is the user's UUID")
+
+	resp, err := f.login(t, a)
+	require.NoError(t, err)
+	assert.Equal(t, "access", resp.AccessToken)
+	assert.Equal(t, []string{f.user.ID.String()}, f.issuer.issued)
+
+	list, err := f.uc.List(context.Background(), f.user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.NotNil(t, list[0].LastUsedAt)
+	assert.Equal(t, uint32(1), f.repo.creds[0].WebAuthn.Authenticator.SignCount, "the sign count is stored")
+}
+
+func TestPasskey_CeremonyIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	a := newAuthenticator(t)
+	f.register(t, a)
+
+	begin, err := f.uc.BeginLogin(ctx)
+	require.NoError(t, err)
+	req := dto.FinishLoginRequest{SessionID: begin.SessionID, Credential: a.get(t, begin.Options)}
+	_, err = f.uc.FinishLogin(ctx, req)
+	require.NoError(t, err)
+
+	_, err = f.uc.FinishLogin(ctx, req)
+	assert.ErrorIs(t, err, errCeremonyExpired, "a replayed assertion finds no ceremony")
+	for key := range f.cache.data {
+		assert.False(t, strings.HasPrefix(key, "passkey:"), "leftover key %s", key)
+	}
+}
+
+func TestPasskey_UnknownSession(t *testing.T) {
+	f := newFixture(t)
+	_, err := f.uc.FinishLogin(context.Background(), dto.FinishLoginRequest{SessionID: "made-up", Credential: json.RawMessage(`{}`)})
+	assert.ErrorIs(t, err, errCeremonyExpired)
+}
+
+func TestPasskey_RegistrationBoundToCaller(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	other := &userdomain.User{ID: uuid.New(), Email: "other@example.com", IsActive: true}
+	f.users[other.ID.String()] = other
+	a := newAuthenticator(t)
+
+	begin, err := f.uc.BeginRegistration(ctx, f.user.ID.String())
+	require.NoError(t, err)
+	_, err = f.uc.FinishRegistration(ctx, other.ID.String(), dto.FinishRegistrationRequest{
+		SessionID:  begin.SessionID,
+		Name:       "Stolen",
+		Credential: a.create(t, begin.Options),
+	})
+	assert.ErrorIs(t, err, errCeremonyExpired)
+	assert.Empty(t, f.repo.creds)
+}
+
+func TestPasskey_LoginRefused(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *fixture, a *authenticator)
+	}{
+		{"unknown passkey", func(f *fixture, _ *authenticator) { f.repo.creds = nil }},
+		{"inactive user", func(f *fixture, _ *authenticator) { f.user.IsActive = false }},
+		{"sign count went backwards", func(f *fixture, a *authenticator) {
+			f.repo.creds[0].WebAuthn.Authenticator.SignCount = 10
+		}},
+		{"user handle of another user", func(_ *fixture, a *authenticator) {
+			id := uuid.New()
+			a.userID = id[:]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			a := newAuthenticator(t)
+			f.register(t, a)
+			tt.setup(f, a)
+
+			_, err := f.login(t, a)
+
+			var ae *apperr.Error
+			require.ErrorAs(t, err, &ae)
+			assert.Equal(t, apperr.CodeUnauthorized, ae.Code)
+			assert.Empty(t, f.issuer.issued)
+		})
+	}
+}
+
+func TestPasskey_ExcludesRegisteredPasskeys(t *testing.T) {
+	f := newFixture(t)
+	a := newAuthenticator(t)
+	f.register(t, a)
+
+	begin, err := f.uc.BeginRegistration(context.Background(), f.user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, begin.Options.Response.CredentialExcludeList, 1)
+	assert.Equal(t, a.id, []byte(begin.Options.Response.CredentialExcludeList[0].CredentialID))
+}
+
+func TestPasskey_Delete(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	a := newAuthenticator(t)
+	cred := f.register(t, a)
+
+	err := f.uc.Delete(ctx, uuid.NewString(), cred.ID)
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeNotFound, ae.Code, "another user's passkey is not found")
+
+	require.NoError(t, f.uc.Delete(ctx, f.user.ID.String(), cred.ID))
+	_, err = f.login(t, a)
+	assert.Error(t, err, "a deleted passkey cannot log in")
+}
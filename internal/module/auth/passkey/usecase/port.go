@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+
+	authdto "github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// UseCase defines the passkey operations. Registration and credential
+// management act on the authenticated caller; login is anonymous and ends in
+// the same token pair as a password login.
+type UseCase interface {
+	// BeginRegistration returns the options for navigator.credentials.create()
+	BeginRegistration(ctx context.Context, userID string) (*dto.BeginRegistrationResponse, error)
+	// FinishRegistration verifies the authenticator's response and stores
+	// the new passkey.
+	FinishRegistration(ctx context.Context, userID string, req dto.FinishRegistrationRequest) (*dto.CredentialResponse, error)
+	// BeginLogin returns the options for navigator.credentials.get(). No
+	// account is named: the authenticator offers the passkeys it holds.
+	BeginLogin(ctx context.Context) (*dto.BeginLoginResponse, error)
+	// FinishLogin verifies the assertion and issues a token pair for the
+	// passkey's owner.
+	FinishLogin(ctx context.Context, req dto.FinishLoginRequest) (*authdto.LoginResponse, error)
+	// List returns the caller's passkeys.
+	List(ctx context.Context, userID string) ([]dto.CredentialResponse, error)
+	// Delete removes one of the caller's passkeys.
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// Repository stores passkeys. The concrete *repository.Repository satisfies
+// it.
+type Repository interface {
+	Create(ctx context.Context, userID, name string, cred webauthn.Credential) (*domain.Credential, error)
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*domain.Credential, error)
+	ListByUser(ctx context.Context, userID string) ([]domain.Credential, error)
+	RecordUse(ctx context.Context, id string, cred webauthn.Credential) error
+	Delete(ctx context.Context, id, userID string) error
+}
+
+// UserLookup loads the user a passkey belongs to. The user module's
+// repository satisfies it.
+type UserLookup interface {
+	GetByID(ctx context.Context, id string) (*userdomain.User, error)
+}
+
+// SessionIssuer issues the access and refresh token pair once a passkey login
+// is verified. The auth usecase satisfies it.
+type SessionIssuer interface {
+	IssueSession(ctx context.Context, user *userdomain.User) (*authdto.LoginResponse, error)
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// denyAccessToken puts an access token on the denylist until it expires, so
+// the Auth middleware refuses it from now on. Tokens without an ID, or
+// already expired, need no entry.
+func (uc *authUseCase) denyAccessToken(ctx context.Context, userID string, token authdomain.AccessToken) error {
+	ttl := time.Until(token.ExpiresAt)
+	if token.ID == "" || ttl <= 0 {
+		return nil
+	}
+	if err := uc.cache.Set(ctx, authdomain.RevokedTokenKey(token.ID), []byte(userID), ttl); err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot revoke access token")
+	}
+	return nil
+}
+
+// RevokeAccessToken revokes an access token issued by this service before it
+// expires. The token must verify; an expired one is refused, since it is no
+// longer accepted anyway.
+func (uc *authUseCase) RevokeAccessToken(ctx context.Context, req dto.RevokeTokenRequest) (*dto.RevokeTokenResponse, error) {
+	var claims jwtClaims
+	_, err := jwt.ParseWithClaims(req.Token, &claims, uc.keys.Keyfunc,
+		jwt.WithValidMethods(uc.keys.Methods()),
+		jwt.WithIssuer(uc.jwtCfg.Issuer),
+		jwt.WithAudience(uc.jwtCfg.Audience),
+		jwt.WithExpirationRequired(),
+	)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, apperr.BadRequestf("token has already expired")
+	case err != nil:
+		return nil, apperr.BadRequestf("invalid token")
+	case claims.ID == "":
+		// Issued before tokens had IDs; it expires within access_token_ttl.
+		return nil, apperr.BadRequestf("token has no ID and cannot be revoked; revoke the user's sessions instead")
+	}
+
+	token := authdomain.AccessToken{ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}
+	if err := uc.denyAccessToken(ctx, claims.UserID, token); err != nil {
+		return nil, err
+	}
+	return &dto.RevokeTokenResponse{
+		TokenID:   token.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: token.ExpiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// tokenVersion returns userID's access token version; 0 when none is stored.
+func (uc *authUseCase) tokenVersion(ctx context.Context, userID string) (int64, error) {
+	b, err := uc.cache.Get(ctx, authdomain.TokenVersionKey(userID))
+	switch {
+	case errors.Is(err, port.ErrCacheMiss):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+	version, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("token version of user %s: %w", userID, err)
+	}
+	return version, nil
+}
+
+// APIKeyRevoker revokes every API key of a user. An API key is checked
+// against neither the token version nor must_change_password, so the keys
+// are revoked along with the user's tokens; *apikeyrepo.Repository
+// satisfies it.
+type APIKeyRevoker interface {
+	RevokeAllForUser(ctx context.Context, userID string) (int64, error)
+}
+
+// WithAPIKeyRevoker revokes the user's API keys too when they log out of all
+// devices or their access tokens are revoked.
+func WithAPIKeyRevoker(keys APIKeyRevoker) Option {
+	return func(uc *authUseCase) {
+		uc.apiKeys = keys
+	}
+}
+
+// revokeAPIKeys revokes userID's API keys; it does nothing while API keys
+// are disabled.
+func (uc *authUseCase) revokeAPIKeys(ctx context.Context, userID string) (int64, error) {
+	if uc.apiKeys == nil {
+		return 0, nil
+	}
+	return uc.apiKeys.RevokeAllForUser(ctx, userID)
+}
+
+// LogoutAll ends every session of userID: the token version is bumped first,
+// so the user's access tokens are refused at once, then every refresh token
+// and API key is revoked. An error fails the call; if it comes after the
+// bump the access tokens are already revoked and retrying is safe.
+func (uc *authUseCase) LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error) {
+	if err := uc.bumpTokenVersion(ctx, userID); err != nil {
+		return nil, apperr.Internalf("auth: cache unavailable, cannot log out of all devices")
+	}
+
+	n, err := uc.sessions.revokeUser(ctx, userID)
+	if err != nil {
+		return nil, apperr.Internalf("auth: cache unavailable, cannot log out of all devices")
+	}
+	keys, err := uc.revokeAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, apperr.Internalf("auth: cannot revoke API keys, cannot log out of all devices")
+	}
+	return &dto.LogoutAllResponse{SessionsRevoked: n, APIKeysRevoked: int(keys)}, nil
+}
+
+// RevokeAccessTokens bumps userID's token version, so every access token
+// issued to them so far is refused, and revokes their API keys. Refresh
+// tokens are left alone.
+func (uc *authUseCase) RevokeAccessTokens(ctx context.Context, userID string) error {
+	if err := uc.bumpTokenVersion(ctx, userID); err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot revoke access tokens")
+	}
+	if _, err := uc.revokeAPIKeys(ctx, userID); err != nil {
+		return apperr.Internalf("auth: cannot revoke API keys")
+	}
+	return nil
+}
+
+// bumpTokenVersion increments userID's token version
+func (uc *authUseCase) bumpTokenVersion(ctx context.Context, userID string) error {
+	key := authdomain.TokenVersionKey(userID)
+	if _, err := uc.cache.Increment(ctx, key); err != nil {
+		return err
+	}
+	// Tokens older than the bump are expired after one access token TTL.
+	_ = uc.cache.Expire(ctx, key, uc.jwtCfg.AccessTokenDuration())
+	return nil
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// accessTokenClaims parses an access token issued by testUC
+func accessTokenClaims(t *testing.T, accessToken string) jwtClaims {
+	t.Helper()
+	var claims jwtClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(*jwt.Token) (any, error) {
+		return []byte(testJWTConfig().Secret), nil
+	})
+	require.NoError(t, err)
+	return claims
+}
+
+// signTestToken signs claims the way testUC does, with secret
+func signTestToken(t *testing.T, secret string, claims jwtClaims) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestLogin_AccessTokenHasID(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	uc := testUC(mockRepo, newMapCache())
+
+	first, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	second, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+
+	id := accessTokenClaims(t, first.AccessToken).ID
+	require.NotEmpty(t, id)
+	assert.NotEqual(t, id, accessTokenClaims(t, second.AccessToken).ID)
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+
+	login, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	claims := accessTokenClaims(t, login.AccessToken)
+
+	resp, err := uc.RevokeAccessToken(context.Background(), dto.RevokeTokenRequest{Token: login.AccessToken})
+	require.NoError(t, err)
+	assert.Equal(t, claims.ID, resp.TokenID)
+	assert.Equal(t, user.ID.String(), resp.UserID)
+	assert.Equal(t, claims.ExpiresAt.UTC().Format(time.RFC3339), resp.ExpiresAt)
+	assert.Equal(t, []byte(user.ID.String()), cache.data[authdomain.RevokedTokenKey(claims.ID)])
+
+	expired := claims
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	legacy := claims
+	legacy.ID = ""
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"expired", signTestToken(t, testJWTConfig().Secret, expired), "already expired"},
+		{"signed with another key", signTestToken(t, "another-secret-that-is-32-bytes-long!", claims), "invalid token"},
+		{"not a token", "garbage", "invalid token"},
+		{"no ID", signTestToken(t, testJWTConfig().Secret, legacy), "no ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.RevokeAccessToken(context.Background(), dto.RevokeTokenRequest{Token: tt.token})
+			appErr, ok := apperr.AsAppError(err)
+			require.True(t, ok)
+			assert.Equal(t, apperr.CodeBadRequest, appErr.Code)
+			assert.Contains(t, appErr.Message, tt.want)
+		})
+	}
+}
+
+func TestRevokeAccessToken_CacheDown(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+
+	login, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+
+	// failSet counts Set calls from the start; skip the login's
+	cache.setCalls = 0
+	cache.failSet(errors.New("redis down"))
+	_, err = uc.RevokeAccessToken(context.Background(), dto.RevokeTokenRequest{Token: login.AccessToken})
+	assert.ErrorIs(t, err, apperr.ErrInternal)
+}
+
+func TestLogout_RevokesCurrentAccessToken(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+	userID := user.ID.String()
+
+	login, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	claims := accessTokenClaims(t, login.AccessToken)
+
+	current := authdomain.AccessToken{ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}
+	require.NoError(t, uc.Logout(context.Background(), userID, login.RefreshToken, current))
+	assert.Contains(t, cache.data, authdomain.RevokedTokenKey(claims.ID))
+	assert.NotContains(t, cache.data, tokLookupKey(login.RefreshToken))
+
+	t.Run("expired and legacy tokens need no entry", func(t *testing.T) {
+		before := len(cache.data)
+		require.NoError(t, uc.Logout(context.Background(), userID, "gone", authdomain.AccessToken{ID: "old", ExpiresAt: time.Now().Add(-time.Second)}))
+		require.NoError(t, uc.Logout(context.Background(), userID, "gone", authdomain.AccessToken{ExpiresAt: time.Now().Add(time.Minute)}))
+		assert.Len(t, cache.data, before)
+	})
+}
+
+func TestLogoutAll(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+	userID := user.ID.String()
+	login := func() *dto.LoginResponse {
+		resp, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+		require.NoError(t, err)
+		return resp
+	}
+
+	first, second := login(), login()
+	assert.Zero(t, accessTokenClaims(t, first.AccessToken).Version, "no version stored yet")
+
+	resp, err := uc.LogoutAll(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.SessionsRevoked)
+	assert.Equal(t, []byte("1"), cache.data[authdomain.TokenVersionKey(userID)])
+	for _, l := range []*dto.LoginResponse{first, second} {
+		_, err := uc.Refresh(context.Background(), dto.RefreshRequest{RefreshToken: l.RefreshToken})
+		assert.ErrorIs(t, err, apperr.ErrUnauthorized)
+	}
+
+	assert.Equal(t, int64(1), accessTokenClaims(t, login().AccessToken).Version, "new tokens carry the bumped version")
+}
+
+// noIncrementCache is a mapCache whose Increment fails
+type noIncrementCache struct{ *mapCache }
+
+func (noIncrementCache) Increment(context.Context, string) (int64, error) {
+	return 0, errors.New("redis down")
+}
+
+func TestLogoutAll_CacheDown(t *testing.T) {
+	cache := newMapCache()
+	uc := testUC(new(MockUserRepository), noIncrementCache{cache})
+	cache.data[userIdxKey("user-1", "refresh-token")] = []byte("1")
+
+	_, err := uc.LogoutAll(context.Background(), "user-1")
+	assert.ErrorIs(t, err, apperr.ErrInternal)
+	assert.Len(t, cache.data, 1, "sessions are kept when the version cannot be bumped")
+}
+
+func TestRevokeAccessTokens(t *testing.T) {
+	cache := newMapCache()
+	revoker := testUC(new(MockUserRepository), cache).(Revoker)
+	cache.data[userIdxKey("user-1", "refresh-token")] = []byte("1")
+
+	require.NoError(t, revoker.RevokeAccessTokens(context.Background(), "user-1"))
+	assert.Equal(t, []byte("1"), cache.data[authdomain.TokenVersionKey("user-1")])
+	assert.Contains(t, cache.data, userIdxKey("user-1", "refresh-token"), "refresh tokens are kept")
+
+	revoker = testUC(new(MockUserRepository), noIncrementCache{newMapCache()}).(Revoker)
+	assert.ErrorIs(t, revoker.RevokeAccessTokens(context.Background(), "user-1"), apperr.ErrInternal)
+}
+
+// fakeAPIKeys counts the API keys each user has left
+type fakeAPIKeys struct {
+	active map[string]int64
+	err    error
+}
+
+func (k *fakeAPIKeys) RevokeAllForUser(_ context.Context, userID string) (int64, error) {
+	if k.err != nil {
+		return 0, k.err
+	}
+	n := k.active[userID]
+	delete(k.active, userID)
+	return n, nil
+}
+
+func TestLogoutAll_RevokesAPIKeys(t *testing.T) {
+	keys := &fakeAPIKeys{active: map[string]int64{"user-1": 2, "user-2": 1}}
+	uc := NewUseCase(new(MockUserRepository), newMapCache(), testJWTConfig(), WithAPIKeyRevoker(keys))
+
+	resp, err := uc.LogoutAll(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.APIKeysRevoked)
+	assert.Equal(t, map[string]int64{"user-2": 1}, keys.active, "other users keep their keys")
+
+	keys.err = errors.New("db down")
+	_, err = uc.LogoutAll(context.Background(), "user-2")
+	assert.ErrorIs(t, err, apperr.ErrInternal)
+}
+
+func TestRevokeAccessTokens_RevokesAPIKeys(t *testing.T) {
+	keys := &fakeAPIKeys{active: map[string]int64{"user-1": 2}}
+	revoker := NewUseCase(new(MockUserRepository), newMapCache(), testJWTConfig(), WithAPIKeyRevoker(keys)).(Revoker)
+
+	require.NoError(t, revoker.RevokeAccessTokens(context.Background(), "user-1"))
+	assert.Empty(t, keys.active)
+
+	keys.err = errors.New("db down")
+	assert.ErrorIs(t, revoker.RevokeAccessTokens(context.Background(), "user-1"), apperr.ErrInternal)
+}
+
+func TestLogin_MustChangePassword(t *testing.T) {
+	user := makeUser("password123")
+	user.MustChangePassword = true
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+	uc := testUC(mockRepo, newMapCache())
+
+	resp, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	assert.True(t, resp.MustChangePassword)
+	assert.True(t, accessTokenClaims(t, resp.AccessToken).MustChangePassword)
+
+	refreshed, err := uc.Refresh(context.Background(), dto.RefreshRequest{RefreshToken: resp.RefreshToken})
+	require.NoError(t, err)
+	assert.True(t, accessTokenClaims(t, refreshed.AccessToken).MustChangePassword, "kept on refresh")
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"errors"
 
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
 	"github.com/14mdzk/goscratch/internal/module/auth/dto"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/apperr"
 )
 
 // AuditedUseCase wraps a UseCase and adds audit logging for Login (success
-// and failure), Logout. Refresh is delegated as-is.
+// and failure), Logout and the password reset flow. Refresh is delegated
+// as-is.
 type AuditedUseCase struct {
 	inner   UseCase
 	auditor port.Auditor
@@ -45,14 +47,36 @@ func (d *AuditedUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.
 	return resp, nil
 }
 
+// Register logs a CREATE entry for the new user and, when the user is logged
+// in straight away, a LOGIN entry. Refused registrations are not logged.
+func (d *AuditedUseCase) Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error) {
+	resp, err := d.inner.Register(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "user", resp.UserID)
+	entry.NewValue = map[string]any{"email": resp.Email, "name": resp.Name, "role": resp.Role}
+	entry.Metadata = map[string]any{"source": "register"}
+	_ = d.auditor.Log(ctx, entry)
+
+	if resp.Tokens != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionLogin, "user", resp.UserID)
+		entry.Metadata = map[string]any{"outcome": "success", "method": "register"}
+		_ = d.auditor.Log(ctx, entry)
+	}
+
+	return resp, nil
+}
+
 // Refresh delegates to inner without audit logging.
 func (d *AuditedUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (*dto.RefreshResponse, error) {
 	return d.inner.Refresh(ctx, req)
 }
 
 // Logout invalidates the refresh token and logs a LOGOUT audit entry on success.
-func (d *AuditedUseCase) Logout(ctx context.Context, callerID, refreshToken string) error {
-	if err := d.inner.Logout(ctx, callerID, refreshToken); err != nil {
+func (d *AuditedUseCase) Logout(ctx context.Context, callerID, refreshToken string, current authdomain.AccessToken) error {
+	if err := d.inner.Logout(ctx, callerID, refreshToken, current); err != nil {
 		return err
 	}
 
@@ -62,6 +86,188 @@ func (d *AuditedUseCase) Logout(ctx context.Context, callerID, refreshToken stri
 	return nil
 }
 
+// LogoutAll ends the user's sessions and logs a LOGOUT entry on success, with
+// the number of sessions and API keys revoked.
+func (d *AuditedUseCase) LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error) {
+	resp, err := d.inner.LogoutAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionLogout, "user", userID)
+	entry.Metadata = map[string]any{"scope": "all", "sessions_revoked": resp.SessionsRevoked, "api_keys_revoked": resp.APIKeysRevoked}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// ListSessions delegates to inner without audit logging.
+func (d *AuditedUseCase) ListSessions(ctx context.Context, userID, currentID string) ([]dto.SessionResponse, error) {
+	return d.inner.ListSessions(ctx, userID, currentID)
+}
+
+// RevokeSession ends the session and logs a LOGOUT entry for it on success.
+func (d *AuditedUseCase) RevokeSession(ctx context.Context, userID, id string) error {
+	if err := d.inner.RevokeSession(ctx, userID, id); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionLogout, "session", id)
+	entry.Metadata = map[string]any{"user_id": userID}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// RevokeAccessToken denies the token and logs a LOGOUT entry for it on
+// success. ResourceID is the token's jti; the actor is the admin revoking it.
+func (d *AuditedUseCase) RevokeAccessToken(ctx context.Context, req dto.RevokeTokenRequest) (*dto.RevokeTokenResponse, error) {
+	resp, err := d.inner.RevokeAccessToken(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionLogout, "access_token", resp.TokenID)
+	entry.Metadata = map[string]any{"user_id": resp.UserID}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// ForgotPassword logs a PASSWORD_RESET entry for every accepted request.
+// ResourceID is the address asked for, whether or not it has an account, so
+// a burst of requests against one address is visible.
+func (d *AuditedUseCase) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	if err := d.inner.ForgotPassword(ctx, req); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionPasswordReset, "user", req.Email)
+	entry.Metadata = map[string]any{"stage": "requested"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// ResetPassword logs a PASSWORD_RESET entry on both success and failure. A
+// failed attempt has no known user; its reason is a fixed category.
+func (d *AuditedUseCase) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (string, error) {
+	userID, err := d.inner.ResetPassword(ctx, req)
+	if err != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionPasswordReset, "user", "")
+		entry.Metadata = map[string]any{
+			"stage":   "completed",
+			"outcome": "failed",
+			"reason":  classifyResetFailure(err),
+		}
+		_ = d.auditor.Log(ctx, entry)
+		return "", err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionPasswordReset, "user", userID)
+	entry.Metadata = map[string]any{"stage": "completed", "outcome": "success"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return userID, nil
+}
+
+// SendVerification logs an EMAIL_VERIFY entry when a link is sent for a
+// newly created or changed address.
+func (d *AuditedUseCase) SendVerification(ctx context.Context, userID string) error {
+	if err := d.inner.SendVerification(ctx, userID); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionEmailVerify, "user", userID)
+	entry.Metadata = map[string]any{"stage": "sent"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// ResendVerification logs an EMAIL_VERIFY entry for every accepted request.
+// Like ForgotPassword, ResourceID is the address asked for.
+func (d *AuditedUseCase) ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) error {
+	if err := d.inner.ResendVerification(ctx, req); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionEmailVerify, "user", req.Email)
+	entry.Metadata = map[string]any{"stage": "requested"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// VerifyEmail logs an EMAIL_VERIFY entry on both success and failure.
+func (d *AuditedUseCase) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (string, error) {
+	userID, err := d.inner.VerifyEmail(ctx, req)
+	if err != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionEmailVerify, "user", "")
+		entry.Metadata = map[string]any{
+			"stage":   "completed",
+			"outcome": "failed",
+			"reason":  classifyResetFailure(err),
+		}
+		_ = d.auditor.Log(ctx, entry)
+		return "", err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionEmailVerify, "user", userID)
+	entry.Metadata = map[string]any{"stage": "completed", "outcome": "success"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return userID, nil
+}
+
+// SendEmailChange logs an UPDATE entry when a confirmation link is sent for a
+// pending email. The change itself is logged by the user module.
+func (d *AuditedUseCase) SendEmailChange(ctx context.Context, userID string) error {
+	if err := d.inner.SendEmailChange(ctx, userID); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", userID)
+	entry.Metadata = map[string]any{"field": "email", "stage": "sent"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// ConfirmEmailChange logs an UPDATE entry on both success and failure. On
+// success NewValue carries the new address.
+func (d *AuditedUseCase) ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) (*dto.ConfirmEmailChangeResponse, error) {
+	resp, err := d.inner.ConfirmEmailChange(ctx, req)
+	if err != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", "")
+		entry.Metadata = map[string]any{
+			"field":   "email",
+			"stage":   "completed",
+			"outcome": "failed",
+			"reason":  classifyResetFailure(err),
+		}
+		_ = d.auditor.Log(ctx, entry)
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", resp.UserID)
+	entry.NewValue = map[string]any{"email": resp.Email}
+	entry.Metadata = map[string]any{"field": "email", "stage": "completed", "outcome": "success"}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// classifyResetFailure maps a reset or verification error to a fixed
+// sanitized category, like classifyLoginFailure.
+func classifyResetFailure(err error) string {
+	var ae *apperr.Error
+	if errors.As(err, &ae) && ae.Code == apperr.CodeBadRequest {
+		return "invalid_token"
+	}
+	return "unknown"
+}
+
 // classifyLoginFailure maps a login error to a fixed sanitized category so
 // the audit log never echoes raw error strings (which could leak details or
 // vary across releases). Inner usecase wraps both bad-password and
@@ -69,10 +275,14 @@ func (d *AuditedUseCase) Logout(ctx context.Context, callerID, refreshToken stri
 func classifyLoginFailure(err error) string {
 	var ae *apperr.Error
 	if errors.As(err, &ae) {
-		switch ae.Code {
-		case apperr.CodeUnauthorized:
+		switch {
+		case ae.Code == apperr.CodeUnauthorized:
 			return "invalid_credentials"
-		case apperr.CodeForbidden:
+		case ae.Code == CodeLoginThrottled:
+			return "throttled"
+		case ae == ErrEmailNotVerified: // apperr's Is matches any Forbidden
+			return "email_unverified"
+		case ae.Code == apperr.CodeForbidden:
 			return "user_inactive"
 		}
 	}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
 	"github.com/14mdzk/goscratch/internal/module/auth/dto"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/apperr"
@@ -33,11 +35,80 @@ func (m *mockAuthUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (
 	return args.Get(0).(*dto.RefreshResponse), args.Error(1)
 }
 
-func (m *mockAuthUseCase) Logout(ctx context.Context, callerID, refreshToken string) error {
-	args := m.Called(ctx, callerID, refreshToken)
+func (m *mockAuthUseCase) Logout(ctx context.Context, callerID, refreshToken string, current authdomain.AccessToken) error {
+	args := m.Called(ctx, callerID, refreshToken, current)
 	return args.Error(0)
 }
 
+func (m *mockAuthUseCase) Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.RegisterResponse), args.Error(1)
+}
+
+func (m *mockAuthUseCase) LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.LogoutAllResponse), args.Error(1)
+}
+
+func (m *mockAuthUseCase) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *mockAuthUseCase) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockAuthUseCase) SendVerification(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *mockAuthUseCase) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (string, error) {
+	args := m.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockAuthUseCase) ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *mockAuthUseCase) SendEmailChange(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *mockAuthUseCase) ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) (*dto.ConfirmEmailChangeResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ConfirmEmailChangeResponse), args.Error(1)
+}
+
+func (m *mockAuthUseCase) ListSessions(ctx context.Context, userID, currentID string) ([]dto.SessionResponse, error) {
+	args := m.Called(ctx, userID, currentID)
+	sessions, _ := args.Get(0).([]dto.SessionResponse)
+	return sessions, args.Error(1)
+}
+
+func (m *mockAuthUseCase) RevokeSession(ctx context.Context, userID, id string) error {
+	return m.Called(ctx, userID, id).Error(0)
+}
+
+func (m *mockAuthUseCase) RevokeAccessToken(ctx context.Context, req dto.RevokeTokenRequest) (*dto.RevokeTokenResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.RevokeTokenResponse), args.Error(1)
+}
+
 // mockAuditorAuth is a simple in-memory auditor for decorator tests.
 type mockAuditorAuth struct {
 	Entries []port.AuditEntry
@@ -197,15 +268,16 @@ func TestAuthAuditDecorator_Logout(t *testing.T) {
 	ctx := context.Background()
 	callerID := "user-42"
 	refreshToken := "some-refresh-token"
+	current := authdomain.AccessToken{ID: "jti-1", ExpiresAt: time.Now().Add(time.Minute)}
 
 	t.Run("on success, logs LOGOUT audit entry with callerID as ResourceID", func(t *testing.T) {
 		inner := new(mockAuthUseCase)
 		auditor := &mockAuditorAuth{}
 		dec := NewAuditedUseCase(inner, auditor)
 
-		inner.On("Logout", ctx, callerID, refreshToken).Return(nil)
+		inner.On("Logout", ctx, callerID, refreshToken, current).Return(nil)
 
-		err := dec.Logout(ctx, callerID, refreshToken)
+		err := dec.Logout(ctx, callerID, refreshToken, current)
 
 		assert.NoError(t, err)
 		assert.Len(t, auditor.Entries, 1)
@@ -222,12 +294,299 @@ func TestAuthAuditDecorator_Logout(t *testing.T) {
 		auditor := &mockAuditorAuth{}
 		dec := NewAuditedUseCase(inner, auditor)
 
-		inner.On("Logout", ctx, callerID, refreshToken).Return(errors.New("logout failed"))
+		inner.On("Logout", ctx, callerID, refreshToken, current).Return(errors.New("logout failed"))
 
-		err := dec.Logout(ctx, callerID, refreshToken)
+		err := dec.Logout(ctx, callerID, refreshToken, current)
 
 		assert.Error(t, err)
 		assert.Empty(t, auditor.Entries)
 		inner.AssertExpectations(t)
 	})
 }
+
+// ---------------------------------------------------------------------------
+// Password reset
+// ---------------------------------------------------------------------------
+
+func TestAuthAuditDecorator_PasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("forgot password logs the requested address", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		req := dto.ForgotPasswordRequest{Email: "user@example.com"}
+		inner.On("ForgotPassword", ctx, req).Return(nil)
+
+		assert.NoError(t, NewAuditedUseCase(inner, auditor).ForgotPassword(ctx, req))
+
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionPasswordReset, entry.Action)
+		assert.Equal(t, "user@example.com", entry.ResourceID)
+		assert.Equal(t, "requested", entry.Metadata["stage"])
+	})
+
+	t.Run("successful reset logs the user", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		req := dto.ResetPasswordRequest{Token: "tok", NewPassword: "new-password"}
+		inner.On("ResetPassword", ctx, req).Return("user-42", nil)
+
+		userID, err := NewAuditedUseCase(inner, auditor).ResetPassword(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-42", userID)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, "user-42", auditor.Entries[0].ResourceID)
+		assert.Equal(t, "success", auditor.Entries[0].Metadata["outcome"])
+	})
+
+	t.Run("failed reset logs a sanitized reason", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		req := dto.ResetPasswordRequest{Token: "tok", NewPassword: "new-password"}
+		inner.On("ResetPassword", ctx, req).Return("", errInvalidResetToken)
+
+		_, err := NewAuditedUseCase(inner, auditor).ResetPassword(ctx, req)
+
+		assert.ErrorIs(t, err, errInvalidResetToken)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, "failed", auditor.Entries[0].Metadata["outcome"])
+		assert.Equal(t, "invalid_token", auditor.Entries[0].Metadata["reason"])
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Email verification
+// ---------------------------------------------------------------------------
+
+func TestAuthAuditDecorator_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sent link logs the user", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		inner.On("SendVerification", ctx, "user-42").Return(nil)
+
+		assert.NoError(t, NewAuditedUseCase(inner, auditor).SendVerification(ctx, "user-42"))
+
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, port.AuditActionEmailVerify, auditor.Entries[0].Action)
+		assert.Equal(t, "sent", auditor.Entries[0].Metadata["stage"])
+	})
+
+	t.Run("failed verification logs a sanitized reason", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		req := dto.VerifyEmailRequest{Token: "tok"}
+		inner.On("VerifyEmail", ctx, req).Return("", errInvalidVerifyToken)
+
+		_, err := NewAuditedUseCase(inner, auditor).VerifyEmail(ctx, req)
+
+		assert.ErrorIs(t, err, errInvalidVerifyToken)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, "failed", auditor.Entries[0].Metadata["outcome"])
+		assert.Equal(t, "invalid_token", auditor.Entries[0].Metadata["reason"])
+	})
+
+	t.Run("unverified login is classified", func(t *testing.T) {
+		assert.Equal(t, "email_unverified", classifyLoginFailure(ErrEmailNotVerified))
+		assert.Equal(t, "user_inactive", classifyLoginFailure(apperr.ErrForbidden))
+	})
+}
+
+func TestAuthAuditDecorator_EmailChange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("confirmed change logs the new address", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		req := dto.ConfirmEmailChangeRequest{Token: "tok"}
+		inner.On("ConfirmEmailChange", ctx, req).Return(&dto.ConfirmEmailChangeResponse{UserID: "user-42", Email: "new@example.com"}, nil)
+
+		_, err := NewAuditedUseCase(inner, auditor).ConfirmEmailChange(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, port.AuditActionUpdate, auditor.Entries[0].Action)
+		assert.Equal(t, "user-42", auditor.Entries[0].ResourceID)
+		assert.Equal(t, map[string]any{"email": "new@example.com"}, auditor.Entries[0].NewValue)
+		assert.Equal(t, "completed", auditor.Entries[0].Metadata["stage"])
+	})
+
+	t.Run("failed confirmation logs a sanitized reason", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		req := dto.ConfirmEmailChangeRequest{Token: "tok"}
+		inner.On("ConfirmEmailChange", ctx, req).Return(nil, errInvalidEmailChangeToken)
+
+		_, err := NewAuditedUseCase(inner, auditor).ConfirmEmailChange(ctx, req)
+
+		assert.ErrorIs(t, err, errInvalidEmailChangeToken)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, "failed", auditor.Entries[0].Metadata["outcome"])
+		assert.Equal(t, "invalid_token", auditor.Entries[0].Metadata["reason"])
+	})
+}
+
+// ---------------------------------------------------------------------------
+// RevokeSession
+// ---------------------------------------------------------------------------
+
+func TestAuthAuditDecorator_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("on success, logs LOGOUT entry for the session", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("RevokeSession", ctx, "u-1", "s-1").Return(nil)
+
+		assert.NoError(t, dec.RevokeSession(ctx, "u-1", "s-1"))
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionLogout, entry.Action)
+		assert.Equal(t, "session", entry.Resource)
+		assert.Equal(t, "s-1", entry.ResourceID)
+		assert.Equal(t, "u-1", entry.Metadata["user_id"])
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("on error, logs nothing", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("RevokeSession", ctx, "u-1", "s-1").Return(apperr.NotFoundf("session %s not found", "s-1"))
+
+		assert.Error(t, dec.RevokeSession(ctx, "u-1", "s-1"))
+		assert.Empty(t, auditor.Entries)
+		inner.AssertExpectations(t)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// RevokeAccessToken
+// ---------------------------------------------------------------------------
+
+func TestAuthAuditDecorator_RevokeAccessToken(t *testing.T) {
+	ctx := context.Background()
+	req := dto.RevokeTokenRequest{Token: "a.b.c"}
+
+	t.Run("on success, logs LOGOUT entry for the token", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("RevokeAccessToken", ctx, req).Return(&dto.RevokeTokenResponse{TokenID: "jti-1", UserID: "u-1"}, nil)
+
+		_, err := dec.RevokeAccessToken(ctx, req)
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionLogout, entry.Action)
+		assert.Equal(t, "access_token", entry.Resource)
+		assert.Equal(t, "jti-1", entry.ResourceID)
+		assert.Equal(t, "u-1", entry.Metadata["user_id"])
+	})
+
+	t.Run("on error, logs nothing", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("RevokeAccessToken", ctx, req).Return(nil, apperr.BadRequestf("invalid token"))
+
+		_, err := dec.RevokeAccessToken(ctx, req)
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
+func TestAuthAuditDecorator_LogoutAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("on success, logs LOGOUT entry for the user", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("LogoutAll", ctx, "u-1").Return(&dto.LogoutAllResponse{SessionsRevoked: 3}, nil)
+
+		resp, err := dec.LogoutAll(ctx, "u-1")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, resp.SessionsRevoked)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionLogout, entry.Action)
+		assert.Equal(t, "user", entry.Resource)
+		assert.Equal(t, "u-1", entry.ResourceID)
+		assert.Equal(t, "all", entry.Metadata["scope"])
+		assert.Equal(t, 3, entry.Metadata["sessions_revoked"])
+	})
+
+	t.Run("on error, logs nothing", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("LogoutAll", ctx, "u-1").Return(nil, apperr.Internalf("cache down"))
+
+		_, err := dec.LogoutAll(ctx, "u-1")
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
+func TestAuthAuditDecorator_Register(t *testing.T) {
+	ctx := context.Background()
+	req := dto.RegisterRequest{Email: "new@example.com", Password: "password123", Name: "New User"}
+
+	t.Run("logs CREATE, and LOGIN when logged in", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Register", ctx, req).Return(&dto.RegisterResponse{
+			UserID: "u-1", Email: req.Email, Name: req.Name, Role: "viewer",
+			Tokens: &dto.LoginResponse{UserID: "u-1"},
+		}, nil)
+
+		_, err := dec.Register(ctx, req)
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 2)
+		created := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionCreate, created.Action)
+		assert.Equal(t, "u-1", created.ResourceID)
+		assert.Equal(t, "viewer", created.NewValue.(map[string]any)["role"])
+		assert.Equal(t, "register", created.Metadata["source"])
+		assert.Equal(t, port.AuditActionLogin, auditor.Entries[1].Action)
+		assert.Equal(t, "register", auditor.Entries[1].Metadata["method"])
+	})
+
+	t.Run("no LOGIN while verification is pending", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Register", ctx, req).Return(&dto.RegisterResponse{UserID: "u-1"}, nil)
+
+		_, err := dec.Register(ctx, req)
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, port.AuditActionCreate, auditor.Entries[0].Action)
+	})
+
+	t.Run("on error, logs nothing", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		auditor := &mockAuditorAuth{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Register", ctx, req).Return(nil, apperr.Conflictf("taken"))
+
+		_, err := dec.Register(ctx, req)
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+}
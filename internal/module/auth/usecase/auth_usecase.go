@@ -6,15 +6,18 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
-	"fmt"
 	"time"
 
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
 	"github.com/14mdzk/goscratch/internal/module/auth/dto"
 	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
 	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -37,6 +40,23 @@ type authUseCase struct {
 	userRepo userLookup
 	cache    port.Cache
 	jwtCfg   config.JWTConfig
+	keys     *jwtkeys.KeySet
+	sessions sessionStore
+	reset    *passwordReset     // nil while password reset is disabled
+	verify   *emailVerification // nil while email verification is disabled
+	change   *emailChange       // nil while email change confirmation is disabled
+	throttle *loginThrottle     // nil while the login throttle is disabled
+	register *registration      // nil while registration is disabled
+	logins   *loginHistory      // nil while logins are not recorded
+	apiKeys  APIKeyRevoker      // nil while API keys are disabled
+}
+
+// WithKeys signs access tokens with keys instead of HS256 with the
+// configured secret.
+func WithKeys(keys *jwtkeys.KeySet) Option {
+	return func(uc *authUseCase) {
+		uc.keys = keys
+	}
 }
 
 // NewUseCase creates a new auth use case.
@@ -44,12 +64,18 @@ type authUseCase struct {
 // which the concrete *userrepo.Repository satisfies. Accepting the interface
 // allows the caller (auth.Module) to inject the same repository instance
 // already created by the user module, avoiding a second pool connection.
-func NewUseCase(userRepo userLookup, cache port.Cache, jwtCfg config.JWTConfig) UseCase {
-	return &authUseCase{
+func NewUseCase(userRepo userLookup, cache port.Cache, jwtCfg config.JWTConfig, opts ...Option) UseCase {
+	uc := &authUseCase{
 		userRepo: userRepo,
 		cache:    cache,
 		jwtCfg:   jwtCfg,
+		keys:     jwtkeys.NewHMAC(jwtCfg.Secret),
+		sessions: sessionStore{cache: cache, ttl: jwtCfg.RefreshTokenDuration()},
 	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 // tokenHash returns the full SHA-256 hex string (64 chars) of the token.
@@ -64,36 +90,92 @@ func tokenHash(token string) string {
 // Value stored: userID.  Used by Refresh to translate a token into a userID
 // without any client-supplied hint.
 func tokLookupKey(token string) string {
-	return fmt.Sprintf("refresh:tok:%s", tokenHash(token))
+	return tokKeyPrefix + tokenHash(token)
 }
 
 // userIdxKey returns the per-user index key:
 // refresh:user:<userID>:<sha256-hex(token)>
-// Value stored: "1".  Used by RevokeAllForUser to delete all tokens for a user
-// via prefix iteration.
+// Value stored: the JSON session record (see sessionRecord).  Used by
+// RevokeAllForUser to delete all tokens for a user via prefix iteration, by
+// ListSessions to describe them, and by the session sweeper to find the
+// oldest ones.
 func userIdxKey(userID, token string) string {
-	return fmt.Sprintf("refresh:user:%s:%s", userID, tokenHash(token))
+	return userKeyPrefix + userID + ":" + tokenHash(token)
 }
 
 // Login authenticates a user and returns tokens.
 // Dual-key write: both the lookup key and the per-user index key are stored.
 // If either write fails the partner key is deleted best-effort and the login
 // is rejected (fail-closed semantics).
-func (uc *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+//
+// When jwt.max_sessions is set, the user's oldest sessions beyond the limit
+// are then evicted. That step is best-effort: the login has already succeeded
+// and the session sweeper enforces the limit on its next run.
+//
+// With the login throttle on, attempts for a blocked address or client IP are
+// refused with a *ThrottledError before the password is checked, and wrong
+// passwords and unknown addresses count as failures alike.
+func (uc *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (resp *dto.LoginResponse, err error) {
+	defer func() { observability.RecordLoginAttempt(err == nil) }()
+
+	ip := port.ExtractAuditContext(ctx).IPAddress
+	now := time.Now()
+	if uc.throttle != nil {
+		if err := uc.throttle.check(ctx, req.Email, ip, now); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get user by email
 	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		// Don't reveal if user exists
+		uc.loginFailed(ctx, req.Email, ip, now)
 		return nil, apperr.ErrUnauthorized.WithMessage("Invalid email or password")
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		uc.loginFailed(ctx, req.Email, ip, now)
 		return nil, apperr.ErrUnauthorized.WithMessage("Invalid email or password")
 	}
+	if uc.throttle != nil {
+		uc.throttle.succeed(ctx, req.Email)
+	}
+
+	// issueSession checks email verification after the password, so the
+	// result reveals nothing to a guesser.
+	return uc.issueSession(ctx, user, req.DeviceName)
+}
+
+// loginFailed counts a wrong password or unknown address against the login
+// throttle.
+func (uc *authUseCase) loginFailed(ctx context.Context, email, ip string, now time.Time) {
+	if uc.throttle != nil {
+		uc.throttle.fail(ctx, email, ip, now)
+	}
+}
+
+// IssueSession issues an access and refresh token pair for a user who has
+// already proven who they are, enforcing email_verification.require_for_login.
+// Login calls it after checking the password; other login methods (passkeys)
+// call it after their own proof, as does OAuth login. The session's device
+// name is derived from the User-Agent in ctx.
+func (uc *authUseCase) IssueSession(ctx context.Context, user *userdomain.User) (*dto.LoginResponse, error) {
+	return uc.issueSession(ctx, user, "")
+}
+
+// issueSession is IssueSession with a client-supplied device name.
+func (uc *authUseCase) issueSession(ctx context.Context, user *userdomain.User, device string) (*dto.LoginResponse, error) {
+	if err := uc.requireVerified(user); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rec := newSessionRecord(ctx, device, now)
 
 	// Generate tokens
-	accessToken, err := uc.generateAccessToken(user.ID.String(), user.Email, user.Name)
+	accessToken, err := uc.generateAccessToken(ctx, user, rec.ID)
 	if err != nil {
 		return nil, apperr.Internalf("failed to generate access token")
 	}
@@ -114,17 +196,29 @@ func (uc *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.Lo
 
 	// Write per-user index key. On failure, delete the already-written lookup
 	// key best-effort to avoid an orphan, then return.
-	if err := uc.cache.Set(ctx, idxKey, []byte("1"), ttl); err != nil {
+	if err := uc.cache.Set(ctx, idxKey, encodeRecord(rec), ttl); err != nil {
 		_ = uc.cache.Delete(ctx, lookupKey)
 		return nil, apperr.Internalf("auth: cache unavailable, cannot issue refresh token")
 	}
 
+	_, _ = uc.sessions.track(ctx, user.ID.String(), session{Hash: tokenHash(refreshToken), IssuedAt: now}, "", uc.jwtCfg.MaxSessions)
+
+	if uc.logins != nil {
+		uc.logins.record(ctx, userdomain.Login{
+			UserID:     user.ID,
+			IPAddress:  rec.IP,
+			UserAgent:  rec.UserAgent,
+			LoggedInAt: now,
+		})
+	}
+
 	return &dto.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    uc.jwtCfg.AccessTokenTTL * 60, // Convert minutes to seconds
-		TokenType:    "Bearer",
-		UserID:       user.ID.String(),
+		AccessToken:        accessToken,
+		RefreshToken:       refreshToken,
+		ExpiresIn:          int(uc.jwtCfg.AccessTokenDuration().Seconds()),
+		TokenType:          "Bearer",
+		UserID:             user.ID.String(),
+		MustChangePassword: user.MustChangePassword,
 	}, nil
 }
 
@@ -153,7 +247,8 @@ func (uc *authUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (*dt
 	// lookup key has not yet TTL-expired. Use the same error message to avoid
 	// an existence oracle.
 	idxKey := userIdxKey(userID, req.RefreshToken)
-	if _, err := uc.cache.Get(ctx, idxKey); err != nil {
+	recBytes, err := uc.cache.Get(ctx, idxKey)
+	if err != nil {
 		return nil, apperr.ErrUnauthorized.WithMessage("Invalid or expired refresh token")
 	}
 
@@ -167,8 +262,16 @@ func (uc *authUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (*dt
 	_ = uc.cache.Delete(ctx, lookupKey)
 	_ = uc.cache.Delete(ctx, idxKey)
 
+	// The rotated token keeps the session's ID and start time. Sessions
+	// started before session records get an ID now.
+	rec := decodeRecord(recBytes)
+	if rec.ID == "" {
+		rec.ID = uuid.NewString()
+	}
+	rec.touch(ctx, time.Now())
+
 	// Generate new tokens
-	accessToken, err := uc.generateAccessToken(user.ID.String(), user.Email, user.Name)
+	accessToken, err := uc.generateAccessToken(ctx, user, rec.ID)
 	if err != nil {
 		return nil, apperr.Internalf("failed to generate access token")
 	}
@@ -186,15 +289,19 @@ func (uc *authUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (*dt
 	if err := uc.cache.Set(ctx, newLookupKey, []byte(user.ID.String()), ttl); err != nil {
 		return nil, apperr.Internalf("auth: cache unavailable, cannot issue refresh token")
 	}
-	if err := uc.cache.Set(ctx, newIdxKey, []byte("1"), ttl); err != nil {
+	if err := uc.cache.Set(ctx, newIdxKey, encodeRecord(rec), ttl); err != nil {
 		_ = uc.cache.Delete(ctx, newLookupKey)
 		return nil, apperr.Internalf("auth: cache unavailable, cannot issue refresh token")
 	}
 
+	_, _ = uc.sessions.track(ctx, user.ID.String(),
+		session{Hash: tokenHash(newRefreshToken), IssuedAt: rec.IssuedAt},
+		tokenHash(req.RefreshToken), 0)
+
 	return &dto.RefreshResponse{
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
-		ExpiresIn:    uc.jwtCfg.AccessTokenTTL * 60,
+		ExpiresIn:    int(uc.jwtCfg.AccessTokenDuration().Seconds()),
 		TokenType:    "Bearer",
 	}, nil
 }
@@ -207,7 +314,14 @@ func (uc *authUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (*dt
 // success silently — this avoids a token-existence oracle (an attacker who
 // knows another user's refresh token cannot use their own JWT to probe whether
 // that token is still live).
-func (uc *authUseCase) Logout(ctx context.Context, callerID, refreshToken string) error {
+//
+// The caller's current access token is denied too, so it stops working now
+// rather than at expiry.
+func (uc *authUseCase) Logout(ctx context.Context, callerID, refreshToken string, current authdomain.AccessToken) error {
+	if err := uc.denyAccessToken(ctx, callerID, current); err != nil {
+		return err
+	}
+
 	lookupKey := tokLookupKey(refreshToken)
 
 	storedUserID, err := uc.cache.Get(ctx, lookupKey)
@@ -233,8 +347,52 @@ func (uc *authUseCase) Logout(ctx context.Context, callerID, refreshToken string
 // index keys for userID and for each match deletes both the index key and its
 // corresponding lookup key.
 func (uc *authUseCase) RevokeAllForUser(ctx context.Context, userID string) error {
-	prefix := fmt.Sprintf("refresh:user:%s:", userID)
-	return uc.cache.DeleteByPrefix(ctx, prefix)
+	_, err := uc.sessions.revokeUser(ctx, userID)
+	return err
+}
+
+// ListSessions returns userID's active sessions, oldest first. currentID is
+// the session of the caller's access token (the sid claim); that session is
+// marked current.
+func (uc *authUseCase) ListSessions(ctx context.Context, userID, currentID string) ([]dto.SessionResponse, error) {
+	live, err := uc.sessions.list(ctx, userID)
+	if err != nil {
+		return nil, apperr.Internalf("auth: cache unavailable, cannot list sessions")
+	}
+	out := make([]dto.SessionResponse, 0, len(live))
+	for _, l := range live {
+		rec := l.Record
+		out = append(out, dto.SessionResponse{
+			ID:         rec.ID,
+			Device:     rec.Device,
+			UserAgent:  rec.UserAgent,
+			IP:         rec.IP,
+			CreatedAt:  rec.IssuedAt.Format(time.RFC3339),
+			LastUsedAt: rec.LastUsedAt.Format(time.RFC3339),
+			Current:    currentID != "" && rec.ID == currentID,
+		})
+	}
+	return out, nil
+}
+
+// RevokeSession ends one of userID's sessions by deleting its refresh token.
+// Access tokens already issued for the session stay valid until they expire.
+// A session of another user is reported as not found.
+func (uc *authUseCase) RevokeSession(ctx context.Context, userID, id string) error {
+	live, err := uc.sessions.list(ctx, userID)
+	if err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot revoke session")
+	}
+	for _, l := range live {
+		if l.Record.ID != id {
+			continue
+		}
+		if err := uc.sessions.remove(ctx, userID, l.Hash); err != nil {
+			return apperr.Internalf("auth: cache unavailable, cannot revoke session")
+		}
+		return nil
+	}
+	return apperr.NotFoundf("session %s not found", id)
 }
 
 // jwtClaims is a local JWT-lib struct used only for signing access tokens.
@@ -243,13 +401,25 @@ func (uc *authUseCase) RevokeAllForUser(ctx context.Context, userID string) erro
 // is the public contract; this struct is an implementation detail.
 type jwtClaims struct {
 	jwt.RegisteredClaims
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	SessionID string `json:"sid,omitempty"`
+	Version   int64  `json:"ver,omitempty"`
+	// MustChangePassword limits the token to changing the password
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	// ActorID is set on impersonation tokens only
+	ActorID string `json:"actor_id,omitempty"`
 }
 
-// generateAccessToken generates a JWT access token
-func (uc *authUseCase) generateAccessToken(userID, email, name string) (string, error) {
+// generateAccessToken generates a JWT access token for user's session
+// sessionID, stamped with the user's current token version
+func (uc *authUseCase) generateAccessToken(ctx context.Context, user *userdomain.User, sessionID string) (string, error) {
+	userID := user.ID.String()
+	version, err := uc.tokenVersion(ctx, userID)
+	if err != nil {
+		return "", err
+	}
 	now := time.Now()
 
 	claims := jwtClaims{
@@ -260,14 +430,17 @@ func (uc *authUseCase) generateAccessToken(userID, email, name string) (string,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(uc.jwtCfg.AccessTokenDuration())),
 			NotBefore: jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
 		},
-		UserID: userID,
-		Email:  email,
-		Name:   name,
+		UserID:             userID,
+		Email:              user.Email,
+		Name:               user.Name,
+		SessionID:          sessionID,
+		Version:            version,
+		MustChangePassword: user.MustChangePassword,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(uc.jwtCfg.Secret))
+	return uc.keys.Sign(claims)
 }
 
 // generateRefreshToken generates a random refresh token
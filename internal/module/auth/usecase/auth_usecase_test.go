@@ -2,18 +2,29 @@ package usecase
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
 	"github.com/14mdzk/goscratch/internal/module/auth/dto"
 	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
 	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
 	"github.com/14mdzk/goscratch/internal/port"
 )
 
@@ -93,15 +104,42 @@ func (c *mapCache) DeleteByPrefix(_ context.Context, prefix string) error {
 	}
 	return nil
 }
+func (c *mapCache) KeysByPrefix(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for k := range c.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
 func (c *mapCache) Exists(_ context.Context, key string) (bool, error) {
 	_, ok := c.data[key]
 	return ok, nil
 }
-func (c *mapCache) SetJSON(_ context.Context, _ string, _ any, _ time.Duration) error { return nil }
-func (c *mapCache) GetJSON(_ context.Context, _ string, _ any) error                  { return port.ErrCacheMiss }
-func (c *mapCache) Increment(_ context.Context, _ string) (int64, error)              { return 0, nil }
-func (c *mapCache) Decrement(_ context.Context, _ string) (int64, error)              { return 0, nil }
-func (c *mapCache) Expire(_ context.Context, _ string, _ time.Duration) error         { return nil }
+func (c *mapCache) SetJSON(_ context.Context, key string, value any, _ time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.data[key] = data
+	return nil
+}
+func (c *mapCache) GetJSON(_ context.Context, key string, dest any) error {
+	val, ok := c.data[key]
+	if !ok {
+		return port.ErrCacheMiss
+	}
+	return json.Unmarshal(val, dest)
+}
+func (c *mapCache) Increment(_ context.Context, key string) (int64, error) {
+	n, _ := strconv.ParseInt(string(c.data[key]), 10, 64)
+	n++
+	c.data[key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+func (c *mapCache) Decrement(_ context.Context, _ string) (int64, error)      { return 0, nil }
+func (c *mapCache) Expire(_ context.Context, _ string, _ time.Duration) error { return nil }
 func (c *mapCache) SlidingWindowAllow(_ context.Context, _ string, max int, _ time.Duration) (bool, int, int, error) {
 	return true, max, 0, nil
 }
@@ -143,8 +181,8 @@ func makeUser(password string) *userdomain.User {
 func testJWTConfig() config.JWTConfig {
 	return config.JWTConfig{
 		Secret:          "test-secret-that-is-at-least-32-bytes-long!",
-		AccessTokenTTL:  15,
-		RefreshTokenTTL: 1440,
+		AccessTokenTTL:  config.Duration(15 * time.Minute),
+		RefreshTokenTTL: config.Duration(24 * time.Hour),
 		Issuer:          "test-issuer",
 		Audience:        "test-audience",
 	}
@@ -155,11 +193,7 @@ func testJWTConfig() config.JWTConfig {
 // concrete authUseCase directly via the unexported field — we use the internal
 // test package so we have access to the struct.
 func testUC(mockRepo *MockUserRepository, cache port.Cache) UseCase {
-	return &authUseCase{
-		userRepo: mockRepo,
-		cache:    cache,
-		jwtCfg:   testJWTConfig(),
-	}
+	return NewUseCase(mockRepo, cache, testJWTConfig())
 }
 
 // ---------------------------------------------------------------------------
@@ -212,7 +246,8 @@ func TestLogin_WritesBothKeys(t *testing.T) {
 	assert.Contains(t, cache.data, idxKey, "per-user index key must be written")
 
 	assert.Equal(t, user.ID.String(), string(cache.data[lookupKey]))
-	assert.Equal(t, "1", string(cache.data[idxKey]))
+	assert.WithinDuration(t, time.Now(), decodeRecord(cache.data[idxKey]).IssuedAt, time.Minute,
+		"index key must hold the session start time")
 
 	mockRepo.AssertExpectations(t)
 }
@@ -295,6 +330,32 @@ func TestLogin_UserNotFound(t *testing.T) {
 // Refresh
 // ---------------------------------------------------------------------------
 
+// TestLogin_SignsWithKeys checks access tokens are signed with the key set,
+// carrying its kid, when one is configured.
+func TestLogin_SignsWithKeys(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keys, err := jwtkeys.New(jwtkeys.ES256, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+
+	uc := NewUseCase(mockRepo, newMapCache(), testJWTConfig(), WithKeys(keys))
+	resp, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+
+	var claims jwtClaims
+	token, err := jwt.ParseWithClaims(resp.AccessToken, &claims, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", token.Method.Alg())
+	assert.Equal(t, keys.KeyID(), token.Header["kid"])
+	assert.Equal(t, user.ID.String(), claims.UserID)
+}
+
 // TestRefresh_Success verifies that Refresh resolves the userID from the
 // lookup key alone (no client-supplied user_id), rotates both key pairs, and
 // the old token cannot be reused.
@@ -382,10 +443,10 @@ func TestRefresh_OldTokenCannotBeReused(t *testing.T) {
 }
 
 // TestRefresh_RevokedByPasswordChange is the key regression test for the
-// dual-key revocation fix. It proves that after RevokeAllForUser (called on
-// password change) deletes only the per-user index keys, a subsequent Refresh
-// with the old token is rejected even though the lookup key is still present
-// in the cache (orphan). The index key is the authoritative revocation gate.
+// dual-key revocation fix. It proves that RevokeAllForUser (called on
+// password change) deletes both keys, and that a Refresh with the old token is
+// rejected even if a lookup key is still present in the cache (orphan). The
+// index key is the authoritative revocation gate.
 func TestRefresh_RevokedByPasswordChange(t *testing.T) {
 	ctx := context.Background()
 	user := makeUser("password")
@@ -410,9 +471,13 @@ func TestRefresh_RevokedByPasswordChange(t *testing.T) {
 	err := revoker.RevokeAllForUser(ctx, user.ID.String())
 	assert.NoError(t, err)
 
-	// Index key must be gone; lookup key must still be present (orphan).
+	// Both keys must be gone.
 	assert.NotContains(t, cache.data, idxKey, "index key must be deleted by RevokeAllForUser")
-	assert.Contains(t, cache.data, lookupKey, "lookup key must remain as orphan (proves index-key check is the gate)")
+	assert.NotContains(t, cache.data, lookupKey, "lookup key must be deleted by RevokeAllForUser")
+
+	// Put the lookup key back as an orphan, as a failed lookup delete would
+	// leave it, to prove the index key is the gate.
+	cache.data[lookupKey] = []byte(user.ID.String())
 
 	// Refresh with the old token must be rejected despite the orphan lookup key.
 	_, err = uc.Refresh(ctx, dto.RefreshRequest{RefreshToken: token})
@@ -442,7 +507,7 @@ func TestLogout_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	uc := testUC(mockRepo, cache)
 
-	err := uc.Logout(ctx, userID, token)
+	err := uc.Logout(ctx, userID, token, authdomain.AccessToken{})
 	assert.NoError(t, err)
 
 	assert.NotContains(t, cache.data, lookupKey, "lookup key must be deleted on logout")
@@ -469,7 +534,7 @@ func TestLogout_OtherUsersToken_NoDelete(t *testing.T) {
 	uc := testUC(mockRepo, cache)
 
 	// Attacker logs out with their own JWT (attackerID) but supplies victim's token.
-	err := uc.Logout(ctx, attackerID, token)
+	err := uc.Logout(ctx, attackerID, token, authdomain.AccessToken{})
 	assert.NoError(t, err, "must return success silently to avoid oracle")
 
 	// Victim's keys must still be present — no deletion.
@@ -486,7 +551,7 @@ func TestLogout_MissingToken_SilentSuccess(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 
 	uc := testUC(mockRepo, cache)
-	err := uc.Logout(ctx, "user-id", "nonexistent-token")
+	err := uc.Logout(ctx, "user-id", "nonexistent-token", authdomain.AccessToken{})
 	assert.NoError(t, err)
 }
 
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// emailChangeKeyPrefix prefixes the email change token keys; see
+// emailedTokens.
+const emailChangeKeyPrefix = "emailchange:"
+
+// defaultEmailChangeTokenTTL applies when email_change.token_ttl is 0
+const defaultEmailChangeTokenTTL = 24 * time.Hour
+
+// emailChangesPerHour caps the confirmation emails one account receives
+const emailChangesPerHour = 3
+
+// CodeEmailChangeThrottled is the error code of email changes refused because
+// the account reached the hourly confirmation email cap
+const CodeEmailChangeThrottled = "EMAIL_CHANGE_THROTTLED"
+
+var errEmailChangeThrottled = apperr.New(CodeEmailChangeThrottled, "Too many email change requests, please try again later", http.StatusTooManyRequests)
+
+// errInvalidEmailChangeToken is returned for unknown, expired, superseded and
+// used tokens alike, and for tokens sent to an address that is no longer the
+// user's pending email.
+var errInvalidEmailChangeToken = apperr.ErrBadRequest.WithMessage("Invalid or expired email change token")
+
+// EmailChangeApplier applies a confirmed email change. The concrete
+// *userrepo.Repository satisfies it.
+type EmailChangeApplier interface {
+	ApplyPendingEmail(ctx context.Context, id, email string) (bool, error)
+}
+
+// WithEmailChange enables SendEmailChange and ConfirmEmailChange. users
+// applies confirmed changes; publisher enqueues the email.send job carrying
+// the confirmation link.
+func WithEmailChange(users EmailChangeApplier, publisher JobPublisher, cfg config.EmailChangeConfig) Option {
+	return func(uc *authUseCase) {
+		ttl := time.Duration(cfg.TokenTTL)
+		if ttl <= 0 {
+			ttl = defaultEmailChangeTokenTTL
+		}
+		uc.change = &emailChange{
+			users:     users,
+			publisher: publisher,
+			cfg:       cfg,
+			tokens:    emailedTokens{cache: uc.cache, prefix: emailChangeKeyPrefix, ttl: ttl, perHour: emailChangesPerHour},
+		}
+	}
+}
+
+type emailChange struct {
+	users     EmailChangeApplier
+	publisher JobPublisher
+	cfg       config.EmailChangeConfig
+	tokens    emailedTokens
+}
+
+// SendEmailChange emails a confirmation link to the user's pending email. It
+// does nothing when no change is pending or the user is inactive.
+func (uc *authUseCase) SendEmailChange(ctx context.Context, userID string) error {
+	if uc.change == nil {
+		return apperr.ErrNotFound.WithMessage("Email change confirmation is not enabled")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive || user.PendingEmail == "" {
+		return nil
+	}
+
+	allowed, err := uc.change.tokens.allow(ctx, userID)
+	if err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot issue email change token")
+	}
+	if !allowed {
+		return errEmailChangeThrottled
+	}
+
+	token, err := uc.generateRefreshToken()
+	if err != nil {
+		return apperr.Internalf("failed to generate email change token")
+	}
+	link, err := tokenLink(uc.change.cfg.URL, token)
+	if err != nil {
+		return apperr.Internalf("failed to build email change link")
+	}
+	// The owner names the pending address, so a link for an address the
+	// user has since replaced cannot apply it.
+	owner := tokenOwner(userID, user.PendingEmail)
+	if err := uc.change.tokens.store(ctx, owner, token); err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot issue email change token")
+	}
+
+	expires := time.Now().Add(uc.change.tokens.ttl).UTC()
+	payload := worker.EmailPayload{
+		To:      user.PendingEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that you want to use this address for your account instead of %s:\n\n%s\n\n"+
+			"The link can be used once and expires at %s. "+
+			"Until then your account keeps its current address. "+
+			"If you did not ask for this change, ignore this email.\n",
+			user.Name, user.Email, link, expires.Format("2006-01-02 15:04 MST")),
+		Tenant: uc.change.cfg.EmailTenant,
+	}
+	if err := uc.change.publisher.Publish(ctx, worker.JobTypeEmailSend, payload); err != nil {
+		uc.change.tokens.drop(ctx, owner, token)
+		return apperr.Internalf("failed to queue email change confirmation")
+	}
+	return nil
+}
+
+// ConfirmEmailChange consumes a confirmation token and makes the pending email
+// it was sent to the user's address.
+func (uc *authUseCase) ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) (*dto.ConfirmEmailChangeResponse, error) {
+	if uc.change == nil {
+		return nil, apperr.ErrNotFound.WithMessage("Email change confirmation is not enabled")
+	}
+
+	owner, ok := uc.change.tokens.consume(ctx, req.Token)
+	if !ok {
+		return nil, errInvalidEmailChangeToken
+	}
+	userID, email, _ := strings.Cut(owner, ":")
+
+	applied, err := uc.change.users.ApplyPendingEmail(ctx, userID, email)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, errInvalidEmailChangeToken
+	}
+	return &dto.ConfirmEmailChangeResponse{UserID: userID, Email: email}, nil
+}
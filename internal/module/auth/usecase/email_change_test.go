@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// fakeApplier applies pending emails, matching the user's pending address
+// like the repository query does
+type fakeApplier struct {
+	pending map[string]string // userID -> pending address
+	emails  map[string]string // userID -> current address
+}
+
+func (f *fakeApplier) ApplyPendingEmail(_ context.Context, id, email string) (bool, error) {
+	if f.pending[id] == "" || !strings.EqualFold(f.pending[id], email) {
+		return false, nil
+	}
+	f.emails[id] = f.pending[id]
+	delete(f.pending, id)
+	return true, nil
+}
+
+var emailChangeLink = regexp.MustCompile(`https://app\.example\.com/confirm-email\S*`)
+
+func emailChangeTokenFromEmail(t *testing.T, body string) string {
+	t.Helper()
+	link := emailChangeLink.FindString(body)
+	require.NotEmpty(t, link, "no confirmation link in %q", body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+type emailChangeFixture struct {
+	uc        UseCase
+	repo      *MockUserRepository
+	cache     *mapCache
+	applier   *fakeApplier
+	publisher *fakePublisher
+}
+
+func newEmailChangeFixture() *emailChangeFixture {
+	f := &emailChangeFixture{
+		repo:      new(MockUserRepository),
+		cache:     newMapCache(),
+		applier:   &fakeApplier{pending: map[string]string{}, emails: map[string]string{}},
+		publisher: &fakePublisher{},
+	}
+	f.uc = NewUseCase(f.repo, f.cache, testJWTConfig(), WithEmailChange(f.applier, f.publisher, config.EmailChangeConfig{
+		Enabled:     true,
+		URL:         "https://app.example.com/confirm-email",
+		TokenTTL:    config.Duration(time.Hour),
+		EmailTenant: "acme",
+	}))
+	return f
+}
+
+func TestEmailChange_FullFlow(t *testing.T) {
+	ctx := context.Background()
+	f := newEmailChangeFixture()
+	user := makeUser("pw")
+	user.PendingEmail = "new@example.com"
+	f.applier.pending[user.ID.String()] = user.PendingEmail
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	require.NoError(t, f.uc.SendEmailChange(ctx, user.ID.String()))
+	require.Len(t, f.publisher.jobs, 1)
+	assert.Equal(t, "new@example.com", f.publisher.jobs[0].To, "the link goes to the new address")
+	assert.Equal(t, "acme", f.publisher.jobs[0].Tenant)
+	token := emailChangeTokenFromEmail(t, f.publisher.jobs[0].Body)
+
+	resp, err := f.uc.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), resp.UserID)
+	assert.Equal(t, "new@example.com", resp.Email)
+	assert.Equal(t, "new@example.com", f.applier.emails[user.ID.String()])
+
+	_, err = f.uc.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: token})
+	assert.ErrorIs(t, err, errInvalidEmailChangeToken, "a token works once")
+}
+
+func TestEmailChange_LinkForReplacedAddress(t *testing.T) {
+	ctx := context.Background()
+	f := newEmailChangeFixture()
+	user := makeUser("pw")
+	user.PendingEmail = "first@example.com"
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	require.NoError(t, f.uc.SendEmailChange(ctx, user.ID.String()))
+	token := emailChangeTokenFromEmail(t, f.publisher.jobs[0].Body)
+
+	// The user asked for another address after the link was sent.
+	f.applier.pending[user.ID.String()] = "second@example.com"
+
+	_, err := f.uc.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: token})
+	assert.ErrorIs(t, err, errInvalidEmailChangeToken)
+	assert.Empty(t, f.applier.emails)
+}
+
+func TestEmailChange_NothingPending(t *testing.T) {
+	f := newEmailChangeFixture()
+	user := makeUser("pw")
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	require.NoError(t, f.uc.SendEmailChange(context.Background(), user.ID.String()))
+	assert.Empty(t, f.publisher.jobs)
+	assert.Empty(t, f.cache.data)
+}
+
+// cappedCache refuses every rate-limited action
+type cappedCache struct{ *mapCache }
+
+func (cappedCache) SlidingWindowAllow(context.Context, string, int, time.Duration) (bool, int, int, error) {
+	return false, 0, 0, nil
+}
+
+func TestEmailChange_OverHourlyCap(t *testing.T) {
+	f := newEmailChangeFixture()
+	cache := cappedCache{newMapCache()}
+	f.uc = NewUseCase(f.repo, cache, testJWTConfig(), WithEmailChange(f.applier, f.publisher, config.EmailChangeConfig{
+		Enabled: true,
+		URL:     "https://app.example.com/confirm-email",
+	}))
+	user := makeUser("pw")
+	user.PendingEmail = "new@example.com"
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	err := f.uc.SendEmailChange(context.Background(), user.ID.String())
+
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, CodeEmailChangeThrottled, ae.Code)
+	assert.Empty(t, f.publisher.jobs)
+	assert.Empty(t, cache.data)
+}
+
+func TestEmailChange_PublishFailureDropsToken(t *testing.T) {
+	ctx := context.Background()
+	f := newEmailChangeFixture()
+	f.publisher.err = errors.New("queue down")
+	user := makeUser("pw")
+	user.PendingEmail = "new@example.com"
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	err := f.uc.SendEmailChange(ctx, user.ID.String())
+
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeInternalError, ae.Code)
+	for key := range f.cache.data {
+		assert.False(t, strings.HasPrefix(key, emailChangeKeyPrefix+"tok:"), "leftover key %s", key)
+		assert.False(t, strings.HasPrefix(key, emailChangeKeyPrefix+"user:"), "leftover key %s", key)
+	}
+}
+
+func TestEmailChange_Disabled(t *testing.T) {
+	uc := testUC(new(MockUserRepository), newMapCache())
+
+	_, err := uc.ConfirmEmailChange(context.Background(), dto.ConfirmEmailChangeRequest{Token: "t"})
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeNotFound, ae.Code)
+}
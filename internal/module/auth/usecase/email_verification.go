@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// verifyKeyPrefix prefixes the email verification token keys; see
+// emailedTokens.
+const verifyKeyPrefix = "emailverify:"
+
+// defaultVerifyTokenTTL applies when email_verification.token_ttl is 0
+const defaultVerifyTokenTTL = 24 * time.Hour
+
+// verifyEmailsPerHour caps the verification emails one account receives
+const verifyEmailsPerHour = 3
+
+// errInvalidVerifyToken is returned for unknown, expired, superseded and used
+// tokens alike, and for tokens sent to an address the user has since changed.
+var errInvalidVerifyToken = apperr.ErrBadRequest.WithMessage("Invalid or expired verification token")
+
+// ErrEmailNotVerified rejects a login when email_verification.require_for_login
+// is set and the account's address is unverified.
+var ErrEmailNotVerified = apperr.ErrForbidden.WithMessage("Email address is not verified")
+
+// EmailVerifiedMarker records a verified address. The concrete
+// *userrepo.Repository satisfies it.
+type EmailVerifiedMarker interface {
+	MarkEmailVerified(ctx context.Context, id, email string) (bool, error)
+}
+
+// WithEmailVerification enables SendVerification, VerifyEmail and
+// ResendVerification, and with cfg.RequireForLogin, the login check. users
+// records verified addresses; publisher enqueues the email.send job carrying
+// the verification link.
+func WithEmailVerification(users EmailVerifiedMarker, publisher JobPublisher, cfg config.EmailVerificationConfig) Option {
+	return func(uc *authUseCase) {
+		ttl := time.Duration(cfg.TokenTTL)
+		if ttl <= 0 {
+			ttl = defaultVerifyTokenTTL
+		}
+		uc.verify = &emailVerification{
+			users:     users,
+			publisher: publisher,
+			cfg:       cfg,
+			tokens:    emailedTokens{cache: uc.cache, prefix: verifyKeyPrefix, ttl: ttl, perHour: verifyEmailsPerHour},
+		}
+	}
+}
+
+type emailVerification struct {
+	users     EmailVerifiedMarker
+	publisher JobPublisher
+	cfg       config.EmailVerificationConfig
+	tokens    emailedTokens
+}
+
+// tokenOwner ties a token to the address it was sent to, so a link sent
+// before an address change cannot verify the new address.
+func tokenOwner(userID, email string) string {
+	return userID + ":" + strings.ToLower(email)
+}
+
+// SendVerification emails a verification link to the user's current address.
+func (uc *authUseCase) SendVerification(ctx context.Context, userID string) error {
+	if uc.verify == nil {
+		return apperr.ErrNotFound.WithMessage("Email verification is not enabled")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return uc.sendVerification(ctx, user)
+}
+
+// ResendVerification emails a new verification link. Unknown addresses,
+// inactive and verified users, and users over the hourly email cap all
+// succeed without sending anything, so the caller cannot tell them apart.
+func (uc *authUseCase) ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) error {
+	if uc.verify == nil {
+		return apperr.ErrNotFound.WithMessage("Email verification is not enabled")
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if ae, ok := apperr.AsAppError(err); ok && ae.Code == apperr.CodeNotFound {
+			return nil
+		}
+		return err
+	}
+	return uc.sendVerification(ctx, user)
+}
+
+func (uc *authUseCase) sendVerification(ctx context.Context, user *userdomain.User) error {
+	if !user.IsActive || user.EmailVerified() {
+		return nil
+	}
+	userID := user.ID.String()
+
+	allowed, err := uc.verify.tokens.allow(ctx, userID)
+	if err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot issue verification token")
+	}
+	if !allowed {
+		return nil
+	}
+
+	token, err := uc.generateRefreshToken()
+	if err != nil {
+		return apperr.Internalf("failed to generate verification token")
+	}
+	link, err := tokenLink(uc.verify.cfg.URL, token)
+	if err != nil {
+		return apperr.Internalf("failed to build verification link")
+	}
+	owner := tokenOwner(userID, user.Email)
+	if err := uc.verify.tokens.store(ctx, owner, token); err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot issue verification token")
+	}
+
+	expires := time.Now().Add(uc.verify.tokens.ttl).UTC()
+	payload := worker.EmailPayload{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address:\n\n%s\n\n"+
+			"The link can be used once and expires at %s. "+
+			"If you did not create an account, ignore this email.\n",
+			user.Name, link, expires.Format("2006-01-02 15:04 MST")),
+		Tenant: uc.verify.cfg.EmailTenant,
+	}
+	if err := uc.verify.publisher.Publish(ctx, worker.JobTypeEmailSend, payload); err != nil {
+		uc.verify.tokens.drop(ctx, owner, token)
+		return apperr.Internalf("failed to queue verification email")
+	}
+	return nil
+}
+
+// VerifyEmail consumes a verification token and marks the address it was sent
+// to verified.
+func (uc *authUseCase) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (string, error) {
+	if uc.verify == nil {
+		return "", apperr.ErrNotFound.WithMessage("Email verification is not enabled")
+	}
+
+	owner, ok := uc.verify.tokens.consume(ctx, req.Token)
+	if !ok {
+		return "", errInvalidVerifyToken
+	}
+	userID, email, _ := strings.Cut(owner, ":")
+
+	marked, err := uc.verify.users.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		return "", err
+	}
+	if !marked {
+		return "", errInvalidVerifyToken
+	}
+	return userID, nil
+}
+
+// requireVerified enforces email_verification.require_for_login
+func (uc *authUseCase) requireVerified(user *userdomain.User) error {
+	if uc.verify == nil || !uc.verify.cfg.RequireForLogin || user.EmailVerified() {
+		return nil
+	}
+	return ErrEmailNotVerified
+}
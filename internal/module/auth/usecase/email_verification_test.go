@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// fakeMarker marks addresses verified, matching the user's current address
+// like the repository query does
+type fakeMarker struct {
+	emails   map[string]string // userID -> current address
+	verified map[string]bool
+}
+
+func (f *fakeMarker) MarkEmailVerified(_ context.Context, id, email string) (bool, error) {
+	if !strings.EqualFold(f.emails[id], email) || f.verified[id] {
+		return false, nil
+	}
+	f.verified[id] = true
+	return true, nil
+}
+
+var verifyLink = regexp.MustCompile(`https://app\.example\.com/verify\S*`)
+
+func verifyTokenFromEmail(t *testing.T, body string) string {
+	t.Helper()
+	link := verifyLink.FindString(body)
+	require.NotEmpty(t, link, "no verification link in %q", body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+type verifyFixture struct {
+	uc        UseCase
+	repo      *MockUserRepository
+	cache     *mapCache
+	marker    *fakeMarker
+	publisher *fakePublisher
+}
+
+func newVerifyFixture(requireForLogin bool) *verifyFixture {
+	f := &verifyFixture{
+		repo:      new(MockUserRepository),
+		cache:     newMapCache(),
+		marker:    &fakeMarker{emails: map[string]string{}, verified: map[string]bool{}},
+		publisher: &fakePublisher{},
+	}
+	f.uc = NewUseCase(f.repo, f.cache, testJWTConfig(), WithEmailVerification(f.marker, f.publisher, config.EmailVerificationConfig{
+		Enabled:         true,
+		URL:             "https://app.example.com/verify",
+		TokenTTL:        config.Duration(time.Hour),
+		EmailTenant:     "acme",
+		RequireForLogin: requireForLogin,
+	}))
+	return f
+}
+
+func TestEmailVerification_FullFlow(t *testing.T) {
+	ctx := context.Background()
+	f := newVerifyFixture(false)
+	user := makeUser("pw")
+	f.marker.emails[user.ID.String()] = user.Email
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	require.NoError(t, f.uc.SendVerification(ctx, user.ID.String()))
+	require.Len(t, f.publisher.jobs, 1)
+	assert.Equal(t, user.Email, f.publisher.jobs[0].To)
+	assert.Equal(t, "acme", f.publisher.jobs[0].Tenant)
+	token := verifyTokenFromEmail(t, f.publisher.jobs[0].Body)
+
+	userID, err := f.uc.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), userID)
+	assert.True(t, f.marker.verified[userID])
+
+	_, err = f.uc.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token})
+	assert.ErrorIs(t, err, errInvalidVerifyToken, "a token works once")
+}
+
+func TestEmailVerification_LinkForOldAddress(t *testing.T) {
+	ctx := context.Background()
+	f := newVerifyFixture(false)
+	user := makeUser("pw")
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	require.NoError(t, f.uc.SendVerification(ctx, user.ID.String()))
+	token := verifyTokenFromEmail(t, f.publisher.jobs[0].Body)
+
+	// The address changed after the link was sent.
+	f.marker.emails[user.ID.String()] = "new@example.com"
+
+	_, err := f.uc.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token})
+	assert.ErrorIs(t, err, errInvalidVerifyToken)
+	assert.False(t, f.marker.verified[user.ID.String()])
+}
+
+func TestEmailVerification_SkipsVerifiedAndUnknownUsers(t *testing.T) {
+	ctx := context.Background()
+	f := newVerifyFixture(false)
+	verified := makeUser("pw")
+	now := time.Now()
+	verified.EmailVerifiedAt = &now
+	f.repo.On("GetByEmail", mock.Anything, verified.Email).Return(verified, nil)
+	f.repo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, apperr.NotFoundf("user with email nobody@example.com not found"))
+
+	assert.NoError(t, f.uc.ResendVerification(ctx, dto.ResendVerificationRequest{Email: verified.Email}))
+	assert.NoError(t, f.uc.ResendVerification(ctx, dto.ResendVerificationRequest{Email: "nobody@example.com"}))
+	assert.Empty(t, f.publisher.jobs)
+	assert.Empty(t, f.cache.data)
+}
+
+func TestEmailVerification_PublishFailureDropsToken(t *testing.T) {
+	ctx := context.Background()
+	f := newVerifyFixture(false)
+	f.publisher.err = errors.New("queue down")
+	user := makeUser("pw")
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	err := f.uc.SendVerification(ctx, user.ID.String())
+
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeInternalError, ae.Code)
+	for key := range f.cache.data {
+		assert.False(t, strings.HasPrefix(key, verifyKeyPrefix+"tok:"), "leftover key %s", key)
+		assert.False(t, strings.HasPrefix(key, verifyKeyPrefix+"user:"), "leftover key %s", key)
+	}
+}
+
+func TestEmailVerification_RequireForLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unverified user is rejected", func(t *testing.T) {
+		f := newVerifyFixture(true)
+		user := makeUser("pw")
+		f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		_, err := f.uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pw"})
+		assert.Same(t, ErrEmailNotVerified, err)
+	})
+
+	t.Run("wrong password is still a credentials error", func(t *testing.T) {
+		f := newVerifyFixture(true)
+		user := makeUser("pw")
+		f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		_, err := f.uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "wrong"})
+		var ae *apperr.Error
+		require.ErrorAs(t, err, &ae)
+		assert.Equal(t, apperr.CodeUnauthorized, ae.Code)
+	})
+
+	t.Run("verified user logs in", func(t *testing.T) {
+		f := newVerifyFixture(true)
+		user := makeUser("pw")
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		_, err := f.uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pw"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("not required", func(t *testing.T) {
+		f := newVerifyFixture(false)
+		user := makeUser("pw")
+		f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		_, err := f.uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "pw"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestEmailVerification_Disabled(t *testing.T) {
+	uc := testUC(new(MockUserRepository), newMapCache())
+
+	_, err := uc.VerifyEmail(context.Background(), dto.VerifyEmailRequest{Token: "t"})
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeNotFound, ae.Code)
+}
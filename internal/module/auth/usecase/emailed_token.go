@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// emailedTokens stores the single-use tokens sent in emailed links (password
+// reset, email verification). Only the token's hash is stored:
+//
+//	<prefix>tok:<sha256-hex(token)>     -> userID
+//	<prefix>user:<userID>               -> sha256-hex(token)
+//	<prefix>claimed:<sha256-hex(token)> -> redemption attempts
+//
+// The per-user key holds the newest token's hash, so issuing another token
+// invalidates the previous one. All keys expire with the token.
+type emailedTokens struct {
+	cache  port.Cache
+	prefix string
+	ttl    time.Duration
+	// perHour caps the emails one user receives, so the endpoint sending them
+	// cannot be used to flood someone's mailbox.
+	perHour int
+}
+
+func (s emailedTokens) tokKey(hash string) string { return s.prefix + "tok:" + hash }
+
+func (s emailedTokens) userKey(userID string) string { return s.prefix + "user:" + userID }
+
+// allow reports whether another email may be sent to userID this hour.
+func (s emailedTokens) allow(ctx context.Context, userID string) (bool, error) {
+	allowed, _, _, err := s.cache.SlidingWindowAllow(ctx, s.prefix+"throttle:"+userID, s.perHour, time.Hour)
+	return allowed, err
+}
+
+// store saves token for userID, superseding the previous one.
+func (s emailedTokens) store(ctx context.Context, userID, token string) error {
+	if prev, err := s.cache.Get(ctx, s.userKey(userID)); err == nil {
+		_ = s.cache.Delete(ctx, s.tokKey(string(prev)))
+	}
+
+	hash := tokenHash(token)
+	if err := s.cache.Set(ctx, s.tokKey(hash), []byte(userID), s.ttl); err != nil {
+		return err
+	}
+	if err := s.cache.Set(ctx, s.userKey(userID), []byte(hash), s.ttl); err != nil {
+		_ = s.cache.Delete(ctx, s.tokKey(hash))
+		return err
+	}
+	return nil
+}
+
+// drop deletes userID's token, e.g. when the email carrying it could not be
+// queued. A link nobody received is useless.
+func (s emailedTokens) drop(ctx context.Context, userID, token string) {
+	_ = s.cache.Delete(ctx, s.tokKey(tokenHash(token)))
+	_ = s.cache.Delete(ctx, s.userKey(userID))
+}
+
+// consume deletes token and returns the user it was issued to. It reports
+// false for unknown, expired and superseded tokens. The token is deleted
+// before the caller acts on it, so a used link cannot be replayed.
+func (s emailedTokens) consume(ctx context.Context, token string) (string, bool) {
+	hash := tokenHash(token)
+	userIDBytes, err := s.cache.Get(ctx, s.tokKey(hash))
+	if err != nil {
+		return "", false
+	}
+	userID := string(userIDBytes)
+
+	// port.Cache has no atomic get-and-delete. The increment is atomic, so
+	// of two concurrent redemptions only the first sees 1.
+	claimKey := s.prefix + "claimed:" + hash
+	claims, err := s.cache.Increment(ctx, claimKey)
+	if err != nil {
+		return "", false
+	}
+	_ = s.cache.Expire(ctx, claimKey, s.ttl)
+	if claims != 1 {
+		return "", false
+	}
+	_ = s.cache.Delete(ctx, s.tokKey(hash))
+
+	current, err := s.cache.Get(ctx, s.userKey(userID))
+	if err != nil || string(current) != hash {
+		return "", false
+	}
+	_ = s.cache.Delete(ctx, s.userKey(userID))
+	return userID, true
+}
+
+// tokenLink returns page with token added as the "token" query parameter.
+func tokenLink(page, token string) (string, error) {
+	u, err := url.Parse(page)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// racingCache is a mapCache safe for concurrent use that lines redemptions
+// up. A redemption waits at its token key read, and again at its user key
+// read, until every other redemption has read the same key or given up, so
+// all of them see the token before any of them deletes it.
+type racingCache struct {
+	*mapCache
+	mu        sync.Mutex
+	tokReads  sync.WaitGroup
+	userReads sync.WaitGroup
+}
+
+// redemption marks a racing consume call in its context
+type redemption struct {
+	readUser bool
+}
+
+type redemptionKey struct{}
+
+func (c *racingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	v, err := c.mapCache.Get(ctx, key)
+	c.mu.Unlock()
+	if r, ok := ctx.Value(redemptionKey{}).(*redemption); ok {
+		switch {
+		case strings.Contains(key, "tok:"):
+			c.tokReads.Done()
+			c.tokReads.Wait()
+		case strings.Contains(key, "user:"):
+			r.readUser = true
+			c.userReads.Done()
+			c.userReads.Wait()
+		}
+	}
+	return v, err
+}
+
+func (c *racingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mapCache.Set(ctx, key, value, ttl)
+}
+
+func (c *racingCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mapCache.Delete(ctx, key)
+}
+
+func (c *racingCache) Increment(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mapCache.Increment(ctx, key)
+}
+
+func TestEmailedTokens_Consume(t *testing.T) {
+	ctx := context.Background()
+	tokens := emailedTokens{cache: newMapCache(), prefix: "test:", ttl: time.Hour}
+	require.NoError(t, tokens.store(ctx, "user-1", "first"))
+	require.NoError(t, tokens.store(ctx, "user-1", "second"))
+
+	_, ok := tokens.consume(ctx, "first")
+	assert.False(t, ok, "superseded")
+	userID, ok := tokens.consume(ctx, "second")
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", userID)
+	_, ok = tokens.consume(ctx, "second")
+	assert.False(t, ok, "used")
+	_, ok = tokens.consume(ctx, "unknown")
+	assert.False(t, ok)
+}
+
+func TestEmailedTokens_ConcurrentConsume(t *testing.T) {
+	const attempts = 8
+	ctx := context.Background()
+	cache := &racingCache{mapCache: newMapCache()}
+	tokens := emailedTokens{cache: cache, prefix: "test:", ttl: time.Hour}
+	require.NoError(t, tokens.store(ctx, "user-1", "link-token"))
+
+	cache.tokReads.Add(attempts)
+	cache.userReads.Add(attempts)
+	var wg sync.WaitGroup
+	var redeemed atomic.Int32
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &redemption{}
+			if _, ok := tokens.consume(context.WithValue(ctx, redemptionKey{}, r), "link-token"); ok {
+				redeemed.Add(1)
+			}
+			if !r.readUser {
+				cache.userReads.Done() // gave up before reading it
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), redeemed.Load(), "one link is redeemed once")
+}
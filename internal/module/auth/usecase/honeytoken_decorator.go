@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/honeytoken"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// HoneytokenUseCase wraps a UseCase and refuses logins to canary accounts,
+// tripping the detector for each attempt.
+type HoneytokenUseCase struct {
+	inner    UseCase
+	detector *honeytoken.Detector
+}
+
+// NewHoneytokenUseCase creates a new honeytoken decorator
+func NewHoneytokenUseCase(inner UseCase, detector *honeytoken.Detector) *HoneytokenUseCase {
+	return &HoneytokenUseCase{inner: inner, detector: detector}
+}
+
+// Login trips the detector for a canary account and fails it exactly like a
+// wrong password, whatever password was given, so the caller cannot tell the
+// account apart. The password is not checked: a canary never logs in.
+func (d *HoneytokenUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	if d.detector.IsUser(req.Email) {
+		ac := port.ExtractAuditContext(ctx)
+		d.detector.Trip(ctx, honeytoken.Hit{
+			Kind:       honeytoken.KindUser,
+			Credential: req.Email,
+			IPAddress:  ac.IPAddress,
+			UserAgent:  ac.UserAgent,
+			Method:     "POST",
+			Path:       "/auth/login",
+		})
+		return nil, apperr.ErrUnauthorized.WithMessage("Invalid email or password")
+	}
+	return d.inner.Login(ctx, req)
+}
+
+// Register trips the detector for a canary account's address and refuses it
+// as taken, as the real account would be.
+func (d *HoneytokenUseCase) Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error) {
+	if d.detector.IsUser(req.Email) {
+		ac := port.ExtractAuditContext(ctx)
+		d.detector.Trip(ctx, honeytoken.Hit{
+			Kind:       honeytoken.KindUser,
+			Credential: req.Email,
+			IPAddress:  ac.IPAddress,
+			UserAgent:  ac.UserAgent,
+			Method:     "POST",
+			Path:       "/auth/register",
+		})
+		return nil, apperr.Conflictf("user with email %s already exists", req.Email)
+	}
+	return d.inner.Register(ctx, req)
+}
+
+// Refresh delegates to inner
+func (d *HoneytokenUseCase) Refresh(ctx context.Context, req dto.RefreshRequest) (*dto.RefreshResponse, error) {
+	return d.inner.Refresh(ctx, req)
+}
+
+// Logout delegates to inner
+func (d *HoneytokenUseCase) Logout(ctx context.Context, callerID, refreshToken string, current authdomain.AccessToken) error {
+	return d.inner.Logout(ctx, callerID, refreshToken, current)
+}
+
+// LogoutAll delegates to inner
+func (d *HoneytokenUseCase) LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error) {
+	return d.inner.LogoutAll(ctx, userID)
+}
+
+// ForgotPassword trips the detector for a canary account and answers like it
+// does for any address, without sending anything.
+func (d *HoneytokenUseCase) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	if d.detector.IsUser(req.Email) {
+		ac := port.ExtractAuditContext(ctx)
+		d.detector.Trip(ctx, honeytoken.Hit{
+			Kind:       honeytoken.KindUser,
+			Credential: req.Email,
+			IPAddress:  ac.IPAddress,
+			UserAgent:  ac.UserAgent,
+			Method:     "POST",
+			Path:       "/auth/forgot-password",
+		})
+		return nil
+	}
+	return d.inner.ForgotPassword(ctx, req)
+}
+
+// ResetPassword delegates to inner; a canary never receives a reset token
+func (d *HoneytokenUseCase) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (string, error) {
+	return d.inner.ResetPassword(ctx, req)
+}
+
+// SendVerification delegates to inner; it is called for accounts being
+// created or changed, not by anonymous callers.
+func (d *HoneytokenUseCase) SendVerification(ctx context.Context, userID string) error {
+	return d.inner.SendVerification(ctx, userID)
+}
+
+// ResendVerification trips the detector for a canary account and answers
+// like it does for any address, without sending anything.
+func (d *HoneytokenUseCase) ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) error {
+	if d.detector.IsUser(req.Email) {
+		ac := port.ExtractAuditContext(ctx)
+		d.detector.Trip(ctx, honeytoken.Hit{
+			Kind:       honeytoken.KindUser,
+			Credential: req.Email,
+			IPAddress:  ac.IPAddress,
+			UserAgent:  ac.UserAgent,
+			Method:     "POST",
+			Path:       "/auth/resend-verification",
+		})
+		return nil
+	}
+	return d.inner.ResendVerification(ctx, req)
+}
+
+// SendEmailChange delegates to inner; it is called for accounts being
+// updated, not by an attacker probing addresses.
+func (d *HoneytokenUseCase) SendEmailChange(ctx context.Context, userID string) error {
+	return d.inner.SendEmailChange(ctx, userID)
+}
+
+// ConfirmEmailChange delegates to inner; a canary never has a pending email.
+func (d *HoneytokenUseCase) ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) (*dto.ConfirmEmailChangeResponse, error) {
+	return d.inner.ConfirmEmailChange(ctx, req)
+}
+
+// VerifyEmail delegates to inner; a canary never receives a verification token
+func (d *HoneytokenUseCase) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (string, error) {
+	return d.inner.VerifyEmail(ctx, req)
+}
+
+// ListSessions delegates to inner
+func (d *HoneytokenUseCase) ListSessions(ctx context.Context, userID, currentID string) ([]dto.SessionResponse, error) {
+	return d.inner.ListSessions(ctx, userID, currentID)
+}
+
+// RevokeSession delegates to inner
+func (d *HoneytokenUseCase) RevokeSession(ctx context.Context, userID, id string) error {
+	return d.inner.RevokeSession(ctx, userID, id)
+}
+
+// RevokeAccessToken delegates to inner
+func (d *HoneytokenUseCase) RevokeAccessToken(ctx context.Context, req dto.RevokeTokenRequest) (*dto.RevokeTokenResponse, error) {
+	return d.inner.RevokeAccessToken(ctx, req)
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/honeytoken"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoneytokenDecorator_Login(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.IPAddressKey, "203.0.113.7")
+
+	t.Run("canary login is refused like a wrong password and alerts", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		alerts := &mockAuditorAuth{}
+		logins := &mockAuditorAuth{}
+		detector := honeytoken.New(honeytoken.Config{Users: []string{"canary@example.com"}, Auditor: alerts})
+		dec := NewAuditedUseCase(NewHoneytokenUseCase(inner, detector), logins)
+
+		_, err := dec.Login(ctx, dto.LoginRequest{Email: "Canary@Example.com", Password: "the-real-password"})
+
+		var ae *apperr.Error
+		require.ErrorAs(t, err, &ae)
+		assert.Equal(t, apperr.CodeUnauthorized, ae.Code)
+		assert.Equal(t, "Invalid email or password", ae.Message)
+
+		require.Len(t, alerts.Entries, 1)
+		assert.Equal(t, port.AuditActionAlert, alerts.Entries[0].Action)
+		assert.Equal(t, "Canary@Example.com", alerts.Entries[0].ResourceID)
+		assert.Equal(t, "203.0.113.7", alerts.Entries[0].IPAddress)
+
+		require.Len(t, logins.Entries, 1, "the attempt is still audited as a failed login")
+		assert.Equal(t, "invalid_credentials", logins.Entries[0].Metadata["reason"])
+		inner.AssertNotCalled(t, "Login")
+	})
+
+	t.Run("other logins pass through", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		req := dto.LoginRequest{Email: "user@example.com", Password: "password123"}
+		resp := &dto.LoginResponse{UserID: "u-1"}
+		inner.On("Login", ctx, req).Return(resp, nil)
+		dec := NewHoneytokenUseCase(inner, honeytoken.New(honeytoken.Config{Users: []string{"canary@example.com"}}))
+
+		got, err := dec.Login(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, resp, got)
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("nil detector passes through", func(t *testing.T) {
+		inner := new(mockAuthUseCase)
+		req := dto.LoginRequest{Email: "canary@example.com", Password: "x"}
+		inner.On("Login", ctx, req).Return(&dto.LoginResponse{}, nil)
+		_, err := NewHoneytokenUseCase(inner, nil).Login(ctx, req)
+		require.NoError(t, err)
+		inner.AssertExpectations(t)
+	})
+}
+
+func TestHoneytokenDecorator_ForgotPassword(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.IPAddressKey, "203.0.113.7")
+	inner := new(mockAuthUseCase)
+	alerts := &mockAuditorAuth{}
+	dec := NewHoneytokenUseCase(inner, honeytoken.New(honeytoken.Config{Users: []string{"canary@example.com"}, Auditor: alerts}))
+
+	require.NoError(t, dec.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: "canary@example.com"}))
+
+	require.Len(t, alerts.Entries, 1)
+	assert.Equal(t, port.AuditActionAlert, alerts.Entries[0].Action)
+	inner.AssertNotCalled(t, "ForgotPassword")
+}
+
+func TestHoneytokenDecorator_ResendVerification(t *testing.T) {
+	inner := new(mockAuthUseCase)
+	alerts := &mockAuditorAuth{}
+	dec := NewHoneytokenUseCase(inner, honeytoken.New(honeytoken.Config{Users: []string{"canary@example.com"}, Auditor: alerts}))
+
+	require.NoError(t, dec.ResendVerification(context.Background(), dto.ResendVerificationRequest{Email: "canary@example.com"}))
+
+	require.Len(t, alerts.Entries, 1)
+	assert.Equal(t, port.AuditActionAlert, alerts.Entries[0].Action)
+	inner.AssertNotCalled(t, "ResendVerification")
+}
+
+func TestHoneytokenDecorator_Register(t *testing.T) {
+	inner := new(mockAuthUseCase)
+	alerts := &mockAuditorAuth{}
+	dec := NewHoneytokenUseCase(inner, honeytoken.New(honeytoken.Config{Users: []string{"canary@example.com"}, Auditor: alerts}))
+
+	_, err := dec.Register(context.Background(), dto.RegisterRequest{Email: "canary@example.com", Password: "password123", Name: "Canary"})
+	assert.ErrorIs(t, err, apperr.ErrConflict, "refused like a taken address")
+
+	require.Len(t, alerts.Entries, 1)
+	assert.Equal(t, port.AuditActionAlert, alerts.Entries[0].Action)
+	inner.AssertNotCalled(t, "Register")
+}
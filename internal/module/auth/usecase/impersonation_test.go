@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+)
+
+func TestIssueImpersonationToken(t *testing.T) {
+	user := makeUser("password123")
+	user.MustChangePassword = true
+	cache := newMapCache()
+	cache.data[authdomain.TokenVersionKey(user.ID.String())] = []byte("3")
+	cfg := testJWTConfig()
+	cfg.ImpersonationTTL = 0 // defaults to the access token TTL
+	impersonator := NewUseCase(new(MockUserRepository), cache, cfg).(Impersonator)
+
+	token, err := impersonator.IssueImpersonationToken(context.Background(), "admin-1", user)
+	require.NoError(t, err)
+
+	claims := accessTokenClaims(t, token.AccessToken)
+	assert.Equal(t, user.ID.String(), claims.UserID)
+	assert.Equal(t, user.ID.String(), claims.Subject)
+	assert.Equal(t, "admin-1", claims.ActorID)
+	assert.Equal(t, token.TokenID, claims.ID)
+	assert.Equal(t, int64(3), claims.Version, "revoked with the user's tokens")
+	assert.Empty(t, claims.SessionID, "no session to refresh")
+	assert.False(t, claims.MustChangePassword)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), token.ExpiresAt, 5*time.Second)
+	assert.Equal(t, token.ExpiresAt.Unix(), claims.ExpiresAt.Unix())
+	assert.Len(t, cache.data, 1, "no refresh token stored")
+}
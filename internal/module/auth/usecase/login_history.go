@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+)
+
+// loginRecordTimeout bounds one asynchronous login write
+const loginRecordTimeout = 5 * time.Second
+
+// LoginRecorder persists successful logins. *userrepo.Repository satisfies it.
+type LoginRecorder interface {
+	// RecordLogin stores login as the user's latest login and keeps it in
+	// their history, trimmed to the newest keep entries.
+	RecordLogin(ctx context.Context, login userdomain.Login, keep int) error
+}
+
+// WithLoginHistory records every session issued (password, passkey and
+// OAuth logins, and registrations) as the user's last login, keeping the
+// newest historySize in their login history. The write runs in the
+// background, so it never slows down or fails a login.
+func WithLoginHistory(users LoginRecorder, historySize int) Option {
+	return func(uc *authUseCase) {
+		uc.logins = &loginHistory{users: users, keep: historySize}
+	}
+}
+
+// loginHistory writes logins off the request path.
+type loginHistory struct {
+	users LoginRecorder
+	keep  int
+	// pending counts writes in flight, so tests can wait for them
+	pending sync.WaitGroup
+}
+
+// record writes login in the background. The request context may be done by
+// the time it runs, so only its values are kept.
+func (h *loginHistory) record(ctx context.Context, login userdomain.Login) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loginRecordTimeout)
+	h.pending.Add(1)
+	go func() {
+		defer h.pending.Done()
+		defer cancel()
+		if err := h.users.RecordLogin(ctx, login, h.keep); err != nil {
+			slog.Error("failed to record login", "user_id", login.UserID.String(), "error", err)
+		}
+	}()
+}
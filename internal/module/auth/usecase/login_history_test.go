@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+)
+
+// fakeLoginRecorder records the logins written through it
+type fakeLoginRecorder struct {
+	mu     sync.Mutex
+	logins []userdomain.Login
+	keep   int
+	ctxErr error
+	err    error
+}
+
+func (r *fakeLoginRecorder) RecordLogin(ctx context.Context, login userdomain.Login, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logins = append(r.logins, login)
+	r.keep = keep
+	r.ctxErr = ctx.Err()
+	return r.err
+}
+
+func loginHistoryUC(mockRepo *MockUserRepository, recorder LoginRecorder) *authUseCase {
+	return NewUseCase(mockRepo, newMapCache(), testJWTConfig(), WithLoginHistory(recorder, 20)).(*authUseCase)
+}
+
+func TestLogin_RecordsLogin(t *testing.T) {
+	ctx, cancel := context.WithCancel(clientCtx("203.0.113.7", "curl/8.0"))
+	user := makeUser("password123")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	recorder := &fakeLoginRecorder{}
+
+	uc := loginHistoryUC(mockRepo, recorder)
+	_, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	cancel() // the request ends before the write
+	uc.logins.pending.Wait()
+
+	require.Len(t, recorder.logins, 1)
+	login := recorder.logins[0]
+	assert.Equal(t, user.ID, login.UserID)
+	assert.Equal(t, "203.0.113.7", login.IPAddress)
+	assert.Equal(t, "curl/8.0", login.UserAgent)
+	assert.WithinDuration(t, time.Now(), login.LoggedInAt, time.Minute)
+	assert.Equal(t, 20, recorder.keep)
+	assert.NoError(t, recorder.ctxErr, "the write must outlive the request")
+}
+
+func TestLogin_RecordFailureDoesNotFailLogin(t *testing.T) {
+	ctx := context.Background()
+	user := makeUser("password123")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	recorder := &fakeLoginRecorder{err: assert.AnError}
+
+	uc := loginHistoryUC(mockRepo, recorder)
+	resp, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+	uc.logins.pending.Wait()
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.Len(t, recorder.logins, 1)
+}
+
+func TestLogin_FailedLoginNotRecorded(t *testing.T) {
+	ctx := context.Background()
+	user := makeUser("password123")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	recorder := &fakeLoginRecorder{}
+
+	uc := loginHistoryUC(mockRepo, recorder)
+	_, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "wrong-password"})
+	uc.logins.pending.Wait()
+
+	assert.Error(t, err)
+	assert.Empty(t, recorder.logins)
+}
+
+func TestIssueSession_RecordsLogin(t *testing.T) {
+	user := makeUser("password123")
+	recorder := &fakeLoginRecorder{}
+
+	uc := loginHistoryUC(new(MockUserRepository), recorder)
+	_, err := uc.IssueSession(context.Background(), user)
+	require.NoError(t, err)
+	uc.logins.pending.Wait()
+
+	require.Len(t, recorder.logins, 1, "passkey and OAuth logins are recorded too")
+	assert.Empty(t, recorder.logins[0].IPAddress)
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// Login throttle key prefixes. Both are followed by "email:<address>" or
+// "ip:<address>":
+//
+//	login:fail:<subject>  -> failed attempts in the current window
+//	login:block:<subject> -> Unix nanoseconds until which logins are refused
+const (
+	loginFailPrefix  = "login:fail:"
+	loginBlockPrefix = "login:block:"
+)
+
+// CodeLoginThrottled is the error code of a refused login attempt
+const CodeLoginThrottled = "LOGIN_THROTTLED"
+
+// errLoginThrottled is the error a ThrottledError carries
+var errLoginThrottled = apperr.New(CodeLoginThrottled, "Too many failed login attempts, please try again later", http.StatusTooManyRequests)
+
+// ThrottledError refuses a login attempt while the address or client IP is
+// waiting out a delay or locked out. It unwraps to an *apperr.Error, so
+// response.Fail renders it as a 429.
+type ThrottledError struct {
+	// RetryAfter is how long until the next attempt is accepted
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", errLoginThrottled.Error(), e.RetryAfter)
+}
+
+func (e *ThrottledError) Unwrap() error { return errLoginThrottled }
+
+// EmailNormalizer canonicalizes an address the way the user repository
+// looks it up. *userrepo.Repository implements it.
+type EmailNormalizer interface {
+	NormalizeEmail(email string) string
+}
+
+// WithLoginThrottle enables brute-force protection for Login; see
+// config.LoginThrottleConfig. When the user repository is an
+// EmailNormalizer, failures are counted per canonical address, so every
+// spelling that logs in to one account (e.g. Gmail aliases) shares one
+// count.
+func WithLoginThrottle(cfg config.LoginThrottleConfig) Option {
+	return func(uc *authUseCase) {
+		t := &loginThrottle{cache: uc.cache, cfg: cfg, normalize: func(email string) string {
+			return userdomain.NormalizeEmail(email, false)
+		}}
+		if n, ok := uc.userRepo.(EmailNormalizer); ok {
+			t.normalize = n.NormalizeEmail
+		}
+		uc.throttle = t
+	}
+}
+
+// loginThrottle counts failed logins per address and per client IP in the
+// cache. Counters are updated atomically (Increment), so concurrent failures
+// are all counted; the delay or lockout a failure triggers is written after
+// it, so a burst of parallel attempts may get through before it applies.
+type loginThrottle struct {
+	cache     port.Cache
+	cfg       config.LoginThrottleConfig
+	normalize func(email string) string
+}
+
+// throttleSubject is one throttled key: an address or a client IP
+type throttleSubject struct {
+	key string
+	// maxFailures locks the subject out; 0 means never
+	maxFailures int
+}
+
+// emailSubject is the throttle key of an address
+func (t *loginThrottle) emailSubject(email string) string {
+	return "email:" + t.normalize(email)
+}
+
+// subjects returns the subjects of an attempt for email from ip. ip is empty
+// when the request context carries no client address.
+func (t *loginThrottle) subjects(email, ip string) []throttleSubject {
+	subs := []throttleSubject{{key: t.emailSubject(email), maxFailures: t.cfg.MaxEmailFailures}}
+	if ip != "" {
+		subs = append(subs, throttleSubject{key: "ip:" + ip, maxFailures: t.cfg.MaxIPFailures})
+	}
+	return subs
+}
+
+// check refuses the attempt while any of its subjects is blocked. It fails
+// closed: a cache error refuses the login.
+func (t *loginThrottle) check(ctx context.Context, email, ip string, now time.Time) error {
+	var wait time.Duration
+	for _, s := range t.subjects(email, ip) {
+		v, err := t.cache.Get(ctx, loginBlockPrefix+s.key)
+		if err != nil {
+			if errors.Is(err, port.ErrCacheMiss) {
+				continue
+			}
+			return apperr.Internalf("auth: cache unavailable, cannot check login attempts")
+		}
+		n, _ := strconv.ParseInt(string(v), 10, 64)
+		if d := time.Unix(0, n).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// fail records a failed attempt and blocks the subjects that crossed a
+// threshold. It is best-effort: a cache error leaves the attempt uncounted.
+func (t *loginThrottle) fail(ctx context.Context, email, ip string, now time.Time) {
+	for _, s := range t.subjects(email, ip) {
+		failKey := loginFailPrefix + s.key
+		n, err := t.cache.Increment(ctx, failKey)
+		if err != nil {
+			continue
+		}
+		if n == 1 {
+			_ = t.cache.Expire(ctx, failKey, time.Duration(t.cfg.Window))
+		}
+
+		if s.maxFailures > 0 && int(n) >= s.maxFailures {
+			// The lockout starts a fresh count, so an expired lockout does
+			// not relock on the next typo.
+			t.block(ctx, s.key, time.Duration(t.cfg.LockoutDuration), now)
+			_ = t.cache.Delete(ctx, failKey)
+			continue
+		}
+		if d := t.delay(int(n)); d > 0 {
+			t.block(ctx, s.key, d, now)
+		}
+	}
+}
+
+// succeed clears the address's failures. The IP's are kept, so logging in to
+// an account of one's own does not reset a guessing run against others.
+func (t *loginThrottle) succeed(ctx context.Context, email string) {
+	_ = t.cache.Delete(ctx, loginFailPrefix+t.emailSubject(email))
+}
+
+// delay returns the wait imposed after the failures-th failure: BaseDelay at
+// DelayAfter failures, doubling for each further one, up to MaxDelay.
+func (t *loginThrottle) delay(failures int) time.Duration {
+	if t.cfg.DelayAfter <= 0 || failures < t.cfg.DelayAfter {
+		return 0
+	}
+	d, maxDelay := time.Duration(t.cfg.BaseDelay), time.Duration(t.cfg.MaxDelay)
+	for i := t.cfg.DelayAfter; i < failures && d < maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxDelay)
+}
+
+// block refuses logins for key until now+d.
+func (t *loginThrottle) block(ctx context.Context, key string, d time.Duration, now time.Time) {
+	until := now.Add(d).UnixNano()
+	_ = t.cache.Set(ctx, loginBlockPrefix+key, []byte(strconv.FormatInt(until, 10)), d)
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+func testThrottleConfig() config.LoginThrottleConfig {
+	return config.LoginThrottleConfig{
+		Enabled:          true,
+		Window:           config.Duration(15 * time.Minute),
+		DelayAfter:       3,
+		BaseDelay:        config.Duration(time.Second),
+		MaxDelay:         config.Duration(4 * time.Second),
+		MaxEmailFailures: 5,
+		MaxIPFailures:    8,
+		LockoutDuration:  config.Duration(15 * time.Minute),
+	}
+}
+
+// throttledUC returns a usecase with the login throttle on, for a user whose
+// password is "password123"
+func throttledUC(t *testing.T, cfg config.LoginThrottleConfig) (UseCase, *mapCache, string) {
+	t.Helper()
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockRepo.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, apperr.ErrNotFound)
+	cache := newMapCache()
+	return NewUseCase(mockRepo, cache, testJWTConfig(), WithLoginThrottle(cfg)), cache, user.Email
+}
+
+func login(ctx context.Context, uc UseCase, email, password string) error {
+	_, err := uc.Login(ctx, dto.LoginRequest{Email: email, Password: password})
+	return err
+}
+
+// unblock lifts every delay and lockout, as if they had run out
+func unblock(cache *mapCache) {
+	_ = cache.DeleteByPrefix(context.Background(), loginBlockPrefix)
+}
+
+func TestLoginThrottle_ProgressiveDelay(t *testing.T) {
+	uc, cache, email := throttledUC(t, testThrottleConfig())
+	ctx := context.Background()
+
+	for range 2 {
+		require.ErrorIs(t, login(ctx, uc, email, "wrong"), apperr.ErrUnauthorized)
+	}
+	// The third failure starts the delays
+	require.ErrorIs(t, login(ctx, uc, email, "wrong"), apperr.ErrUnauthorized)
+
+	var throttled *ThrottledError
+	require.ErrorAs(t, login(ctx, uc, email, "password123"), &throttled, "even the right password waits")
+	assert.InDelta(t, time.Second, throttled.RetryAfter, float64(100*time.Millisecond))
+	appErr, ok := apperr.AsAppError(throttled)
+	require.True(t, ok)
+	assert.Equal(t, CodeLoginThrottled, appErr.Code)
+	assert.Equal(t, 429, appErr.HTTPStatus)
+
+	unblock(cache)
+	require.ErrorIs(t, login(ctx, uc, email, "wrong"), apperr.ErrUnauthorized)
+	require.ErrorAs(t, login(ctx, uc, email, "wrong"), &throttled)
+	assert.InDelta(t, 2*time.Second, throttled.RetryAfter, float64(100*time.Millisecond), "the delay doubles")
+
+	unblock(cache)
+	require.NoError(t, login(ctx, uc, email, "password123"))
+	assert.NotContains(t, cache.data, loginFailPrefix+"email:"+email, "a successful login clears the address's failures")
+}
+
+func TestLoginThrottle_EmailLockout(t *testing.T) {
+	cfg := testThrottleConfig()
+	cfg.DelayAfter = 0
+	uc, cache, email := throttledUC(t, cfg)
+	ctx := context.Background()
+
+	for range cfg.MaxEmailFailures {
+		require.ErrorIs(t, login(ctx, uc, email, "wrong"), apperr.ErrUnauthorized)
+	}
+
+	var throttled *ThrottledError
+	require.ErrorAs(t, login(ctx, uc, "  USER@example.com ", "password123"), &throttled, "addresses are compared case-insensitively")
+	assert.InDelta(t, 15*time.Minute, throttled.RetryAfter, float64(time.Second))
+
+	// Other addresses are unaffected
+	require.ErrorIs(t, login(ctx, uc, "other@example.com", "wrong"), apperr.ErrUnauthorized)
+
+	unblock(cache)
+	require.NoError(t, login(ctx, uc, email, "password123"), "the lockout ends after lockout_duration")
+}
+
+// gmailAliasRepo canonicalizes addresses like the user repository with
+// users.gmail_aliases on.
+type gmailAliasRepo struct {
+	*MockUserRepository
+}
+
+func (gmailAliasRepo) NormalizeEmail(email string) string {
+	return userdomain.NormalizeEmail(email, true)
+}
+
+func TestLoginThrottle_AliasesShareLockout(t *testing.T) {
+	cfg := testThrottleConfig()
+	cfg.DelayAfter = 0
+	user := makeUser("password123")
+	user.Email = "jane.doe@gmail.com"
+	mockRepo := new(MockUserRepository)
+	// Every alias finds the one account, as GetByEmail does.
+	mockRepo.On("GetByEmail", mock.Anything, mock.Anything).Return(user, nil)
+	uc := NewUseCase(gmailAliasRepo{mockRepo}, newMapCache(), testJWTConfig(), WithLoginThrottle(cfg))
+	ctx := context.Background()
+
+	aliases := []string{"jane.doe@gmail.com", "janedoe@gmail.com", "jane.doe+1@gmail.com", "j.a.n.e.doe+2@googlemail.com", "JaneDoe+3@Gmail.com"}
+	require.Len(t, aliases, cfg.MaxEmailFailures)
+	for _, alias := range aliases {
+		require.ErrorIs(t, login(ctx, uc, alias, "wrong"), apperr.ErrUnauthorized)
+	}
+
+	var throttled *ThrottledError
+	assert.ErrorAs(t, login(ctx, uc, "jane.doe+4@gmail.com", "password123"), &throttled,
+		"rotating aliases of one account does not avoid its lockout")
+}
+
+func TestLoginThrottle_UnknownAddressesCount(t *testing.T) {
+	cfg := testThrottleConfig()
+	cfg.DelayAfter = 0
+	uc, _, _ := throttledUC(t, cfg)
+	ctx := context.Background()
+
+	for range cfg.MaxEmailFailures {
+		require.ErrorIs(t, login(ctx, uc, "ghost@example.com", "x"), apperr.ErrUnauthorized)
+	}
+	var throttled *ThrottledError
+	assert.ErrorAs(t, login(ctx, uc, "ghost@example.com", "x"), &throttled,
+		"an unknown address locks like a real one, so lockouts reveal nothing")
+}
+
+func TestLoginThrottle_IPLockout(t *testing.T) {
+	cfg := testThrottleConfig()
+	cfg.DelayAfter = 0
+	uc, _, email := throttledUC(t, cfg)
+	attacker := clientCtx("203.0.113.7", "curl/8.0")
+
+	// Spread over many addresses, so no address reaches its own limit
+	for i := range cfg.MaxIPFailures {
+		require.ErrorIs(t, login(attacker, uc, string(rune('a'+i))+"@example.com", "x"), apperr.ErrUnauthorized)
+	}
+
+	var throttled *ThrottledError
+	require.ErrorAs(t, login(attacker, uc, email, "password123"), &throttled)
+	assert.NoError(t, login(clientCtx("198.51.100.2", "curl/8.0"), uc, email, "password123"), "other IPs are unaffected")
+}
+
+func TestLoginThrottle_Disabled(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+
+	for range 20 {
+		require.ErrorIs(t, login(context.Background(), uc, user.Email, "wrong"), apperr.ErrUnauthorized)
+	}
+	require.NoError(t, login(context.Background(), uc, user.Email, "password123"))
+	keys, _ := cache.KeysByPrefix(context.Background(), "login:")
+	assert.Empty(t, keys)
+}
+
+func TestLoginThrottle_Delay(t *testing.T) {
+	throttle := &loginThrottle{cfg: testThrottleConfig()}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{50, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, throttle.delay(tt.failures), "after %d failures", tt.failures)
+	}
+}
+
+func TestClassifyLoginFailure_Throttled(t *testing.T) {
+	assert.Equal(t, "throttled", classifyLoginFailure(&ThrottledError{RetryAfter: time.Second}))
+	assert.Equal(t, "unknown", classifyLoginFailure(errors.New("boom")))
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// resetKeyPrefix prefixes the password reset token keys; see emailedTokens.
+const resetKeyPrefix = "pwreset:"
+
+// defaultResetTokenTTL applies when password_reset.token_ttl is 0
+const defaultResetTokenTTL = 30 * time.Minute
+
+// resetEmailsPerHour caps the reset emails one account receives, so the
+// endpoint cannot be used to flood someone's mailbox.
+const resetEmailsPerHour = 3
+
+// errInvalidResetToken is returned for unknown, expired, superseded and used
+// tokens alike.
+var errInvalidResetToken = apperr.ErrBadRequest.WithMessage("Invalid or expired reset token")
+
+// PasswordUpdater stores a user's new password hash. The concrete
+// *userrepo.Repository satisfies it.
+type PasswordUpdater interface {
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
+}
+
+// JobPublisher enqueues background jobs. The concrete *worker.Publisher
+// satisfies it.
+type JobPublisher interface {
+	Publish(ctx context.Context, jobType string, payload any) error
+}
+
+// Option configures the auth usecase
+type Option func(*authUseCase)
+
+// WithPasswordReset enables ForgotPassword and ResetPassword. users stores the
+// new password; publisher enqueues the email.send job carrying the reset link,
+// which the worker's EmailHandler sends.
+func WithPasswordReset(users PasswordUpdater, publisher JobPublisher, cfg config.PasswordResetConfig) Option {
+	return func(uc *authUseCase) {
+		ttl := time.Duration(cfg.TokenTTL)
+		if ttl <= 0 {
+			ttl = defaultResetTokenTTL
+		}
+		uc.reset = &passwordReset{
+			users:     users,
+			publisher: publisher,
+			cfg:       cfg,
+			tokens:    emailedTokens{cache: uc.cache, prefix: resetKeyPrefix, ttl: ttl, perHour: resetEmailsPerHour},
+		}
+	}
+}
+
+type passwordReset struct {
+	users     PasswordUpdater
+	publisher JobPublisher
+	cfg       config.PasswordResetConfig
+	tokens    emailedTokens
+}
+
+// ForgotPassword issues a reset token and enqueues the email carrying it.
+//
+// Unknown addresses, inactive users and users over the hourly email cap all
+// succeed without sending anything, so the caller cannot tell them apart from
+// a sent link. Infrastructure failures (cache, queue) are returned.
+func (uc *authUseCase) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	if uc.reset == nil {
+		return apperr.ErrNotFound.WithMessage("Password reset is not enabled")
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if ae, ok := apperr.AsAppError(err); ok && ae.Code == apperr.CodeNotFound {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+	userID := user.ID.String()
+
+	allowed, err := uc.reset.tokens.allow(ctx, userID)
+	if err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot issue reset token")
+	}
+	if !allowed {
+		return nil
+	}
+
+	token, err := uc.generateRefreshToken()
+	if err != nil {
+		return apperr.Internalf("failed to generate reset token")
+	}
+	link, err := tokenLink(uc.reset.cfg.URL, token)
+	if err != nil {
+		return apperr.Internalf("failed to build reset link")
+	}
+	if err := uc.reset.tokens.store(ctx, userID, token); err != nil {
+		return apperr.Internalf("auth: cache unavailable, cannot issue reset token")
+	}
+
+	expires := time.Now().Add(uc.reset.tokens.ttl).UTC()
+	payload := worker.EmailPayload{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset the password for your account. "+
+			"Choose a new password here:\n\n%s\n\n"+
+			"The link can be used once and expires at %s. "+
+			"If you did not ask for it, ignore this email; your password has not changed.\n",
+			user.Name, link, expires.Format("2006-01-02 15:04 MST")),
+		Tenant: uc.reset.cfg.EmailTenant,
+	}
+	if err := uc.reset.publisher.Publish(ctx, worker.JobTypeEmailSend, payload); err != nil {
+		uc.reset.tokens.drop(ctx, userID, token)
+		return apperr.Internalf("failed to queue password reset email")
+	}
+	return nil
+}
+
+// ResetPassword consumes a reset token and sets the new password.
+//
+// The token is deleted before the password is written, so a used link cannot
+// be replayed. Like ChangePassword, it then revokes every refresh token the
+// user holds.
+func (uc *authUseCase) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (string, error) {
+	if uc.reset == nil {
+		return "", apperr.ErrNotFound.WithMessage("Password reset is not enabled")
+	}
+
+	userID, ok := uc.reset.tokens.consume(ctx, req.Token)
+	if !ok {
+		return "", errInvalidResetToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		return "", errInvalidResetToken
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", apperr.Internalf("failed to hash password")
+	}
+	if err := uc.reset.users.UpdatePassword(ctx, userID, string(passwordHash)); err != nil {
+		return "", err
+	}
+
+	// The old password may be what leaked; end every session it opened.
+	if err := uc.RevokeAllForUser(ctx, userID); err != nil {
+		return "", fmt.Errorf("password updated but refresh token revocation failed: %w", err)
+	}
+	return userID, nil
+}
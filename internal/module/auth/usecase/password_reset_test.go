@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// fakePasswords records UpdatePassword calls
+type fakePasswords struct {
+	hashes map[string]string
+}
+
+func (f *fakePasswords) UpdatePassword(_ context.Context, id, passwordHash string) error {
+	f.hashes[id] = passwordHash
+	return nil
+}
+
+// fakePublisher records published jobs
+type fakePublisher struct {
+	jobs []worker.EmailPayload
+	err  error
+}
+
+func (f *fakePublisher) Publish(_ context.Context, jobType string, payload any) error {
+	if f.err != nil {
+		return f.err
+	}
+	if jobType == worker.JobTypeEmailSend {
+		f.jobs = append(f.jobs, payload.(worker.EmailPayload))
+	}
+	return nil
+}
+
+var resetLinkToken = regexp.MustCompile(`https://app\.example\.com/reset\S*`)
+
+// tokenFromEmail extracts the token from the reset link in an email body.
+func tokenFromEmail(t *testing.T, body string) string {
+	t.Helper()
+	link := resetLinkToken.FindString(body)
+	require.NotEmpty(t, link, "no reset link in %q", body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+type resetFixture struct {
+	uc        UseCase
+	repo      *MockUserRepository
+	cache     *mapCache
+	passwords *fakePasswords
+	publisher *fakePublisher
+}
+
+func newResetFixture() *resetFixture {
+	f := &resetFixture{
+		repo:      new(MockUserRepository),
+		cache:     newMapCache(),
+		passwords: &fakePasswords{hashes: map[string]string{}},
+		publisher: &fakePublisher{},
+	}
+	f.uc = NewUseCase(f.repo, f.cache, testJWTConfig(), WithPasswordReset(f.passwords, f.publisher, config.PasswordResetConfig{
+		Enabled:     true,
+		URL:         "https://app.example.com/reset?lang=en",
+		TokenTTL:    config.Duration(15 * time.Minute),
+		EmailTenant: "acme",
+	}))
+	return f
+}
+
+func TestPasswordReset_FullFlow(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture()
+	user := makeUser("old-password")
+	f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	// An existing session that the reset must end.
+	login, err := f.uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "old-password"})
+	require.NoError(t, err)
+
+	require.NoError(t, f.uc.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: user.Email}))
+	require.Len(t, f.publisher.jobs, 1)
+	email := f.publisher.jobs[0]
+	assert.Equal(t, user.Email, email.To)
+	assert.Equal(t, "acme", email.Tenant)
+	assert.Contains(t, email.Body, "lang=en", "the configured query is kept")
+	token := tokenFromEmail(t, email.Body)
+	require.NotEmpty(t, token)
+
+	for key, value := range f.cache.data {
+		assert.NotContains(t, key, token, "the raw token must not be stored")
+		assert.NotContains(t, string(value), token, "the raw token must not be stored")
+	}
+
+	userID, err := f.uc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, NewPassword: "new-password"})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), userID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(f.passwords.hashes[userID]), []byte("new-password")))
+
+	_, err = f.uc.Refresh(ctx, dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Error(t, err, "sessions opened with the old password are revoked")
+
+	_, err = f.uc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, NewPassword: "another-password"})
+	assert.ErrorIs(t, err, errInvalidResetToken, "a token works once")
+}
+
+func TestPasswordReset_NewLinkSupersedesOld(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture()
+	user := makeUser("old-password")
+	f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	f.repo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+
+	require.NoError(t, f.uc.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: user.Email}))
+	require.NoError(t, f.uc.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: user.Email}))
+	require.Len(t, f.publisher.jobs, 2)
+	first := tokenFromEmail(t, f.publisher.jobs[0].Body)
+	second := tokenFromEmail(t, f.publisher.jobs[1].Body)
+
+	_, err := f.uc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: first, NewPassword: "new-password"})
+	assert.ErrorIs(t, err, errInvalidResetToken)
+	_, err = f.uc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: second, NewPassword: "new-password"})
+	assert.NoError(t, err)
+}
+
+func TestPasswordReset_SilentForUnknownAndInactiveUsers(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture()
+	inactive := makeUser("pw")
+	inactive.Email = "gone@example.com"
+	inactive.IsActive = false
+	f.repo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, apperr.NotFoundf("user with email nobody@example.com not found"))
+	f.repo.On("GetByEmail", mock.Anything, inactive.Email).Return(inactive, nil)
+
+	assert.NoError(t, f.uc.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: "nobody@example.com"}))
+	assert.NoError(t, f.uc.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: inactive.Email}))
+	assert.Empty(t, f.publisher.jobs)
+	assert.Empty(t, f.cache.data)
+}
+
+func TestPasswordReset_PublishFailureDropsToken(t *testing.T) {
+	ctx := context.Background()
+	f := newResetFixture()
+	f.publisher.err = errors.New("queue down")
+	user := makeUser("pw")
+	f.repo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	err := f.uc.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: user.Email})
+
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeInternalError, ae.Code)
+	for key := range f.cache.data {
+		assert.False(t, strings.HasPrefix(key, "pwreset:"), "leftover key %s", key)
+	}
+}
+
+func TestPasswordReset_UnknownToken(t *testing.T) {
+	f := newResetFixture()
+	_, err := f.uc.ResetPassword(context.Background(), dto.ResetPasswordRequest{Token: "made-up", NewPassword: "new-password"})
+	assert.ErrorIs(t, err, errInvalidResetToken)
+	assert.Empty(t, f.passwords.hashes)
+}
+
+func TestPasswordReset_Disabled(t *testing.T) {
+	uc := testUC(new(MockUserRepository), newMapCache())
+
+	err := uc.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: "user@example.com"})
+	var ae *apperr.Error
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeNotFound, ae.Code)
+
+	_, err = uc.ResetPassword(context.Background(), dto.ResetPasswordRequest{Token: "t", NewPassword: "new-password"})
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeNotFound, ae.Code)
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
 	"github.com/14mdzk/goscratch/internal/module/auth/dto"
 )
 
@@ -11,8 +12,47 @@ import (
 // concrete *UseCase struct, enabling testability and extensibility.
 type UseCase interface {
 	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
+	// Register creates an account with the default role and logs it in,
+	// unless its address must be verified first.
+	Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error)
 	Refresh(ctx context.Context, req dto.RefreshRequest) (*dto.RefreshResponse, error)
-	// Logout invalidates the refresh token for the authenticated caller.
+	// Logout invalidates the refresh token for the authenticated caller, and
+	// revokes current, the access token the request was made with.
 	// callerID is the user ID extracted from the JWT by the auth middleware.
-	Logout(ctx context.Context, callerID, refreshToken string) error
+	Logout(ctx context.Context, callerID, refreshToken string, current authdomain.AccessToken) error
+	// LogoutAll ends every session of the user: all refresh tokens are
+	// deleted and all access tokens issued so far are refused.
+	LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error)
+	// ForgotPassword emails a reset link when req.Email belongs to an active
+	// user. It also succeeds for unknown addresses, so the response does not
+	// reveal which addresses have accounts.
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error
+	// ResetPassword sets a new password with the token from a reset link,
+	// revokes the user's sessions and returns the user's ID.
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (string, error)
+	// SendVerification emails a verification link to the user's current
+	// address. It does nothing when the address is already verified.
+	SendVerification(ctx context.Context, userID string) error
+	// VerifyEmail marks an address verified with the token from a
+	// verification link and returns the user's ID.
+	VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (string, error)
+	// ResendVerification emails a new verification link when req.Email
+	// belongs to an active, unverified user. Like ForgotPassword, it also
+	// succeeds when nothing is sent.
+	ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) error
+	// SendEmailChange emails a confirmation link to the user's pending email.
+	// It does nothing when no change is pending.
+	SendEmailChange(ctx context.Context, userID string) error
+	// ConfirmEmailChange makes a pending email the user's address with the
+	// token from a confirmation link.
+	ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) (*dto.ConfirmEmailChangeResponse, error)
+	// ListSessions returns the user's active sessions, oldest first, marking
+	// the one with ID currentID as current.
+	ListSessions(ctx context.Context, userID, currentID string) ([]dto.SessionResponse, error)
+	// RevokeSession ends one of the user's sessions. Sessions of other users
+	// are reported as not found.
+	RevokeSession(ctx context.Context, userID, id string) error
+	// RevokeAccessToken denies an access token until it expires, e.g. one
+	// known to be compromised.
+	RevokeAccessToken(ctx context.Context, req dto.RevokeTokenRequest) (*dto.RevokeTokenResponse, error)
 }
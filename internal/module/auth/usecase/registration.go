@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// UserCreator creates users, and removes an account whose role could not be
+// granted. The concrete *userrepo.Repository satisfies it; an address already
+// taken is a conflict.
+type UserCreator interface {
+	Create(ctx context.Context, email, passwordHash, name string) (*userdomain.User, error)
+	Delete(ctx context.Context, id string) error
+	Purge(ctx context.Context, id string) error
+}
+
+// RoleAssigner grants roles. port.Authorizer satisfies it; it writes through
+// its own connection, outside any transaction.
+type RoleAssigner interface {
+	AddRoleForUser(userID, role string) error
+}
+
+// TxRunner runs fn in a database transaction. The concrete
+// *database.Transactor satisfies it.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn database.TxFunc, opts ...database.TxOption) error
+}
+
+// WithRegistration enables Register. users creates the account in a
+// transaction run by transactor, and once it commits roles grants it
+// cfg.DefaultRole.
+func WithRegistration(users UserCreator, roles RoleAssigner, transactor TxRunner, cfg config.AuthConfig) Option {
+	return func(uc *authUseCase) {
+		uc.register = &registration{users: users, roles: roles, transactor: transactor, role: cfg.DefaultRole}
+	}
+}
+
+type registration struct {
+	users      UserCreator
+	roles      RoleAssigner
+	transactor TxRunner
+	role       string
+}
+
+// Register creates an account with the default role, emails a verification
+// link when email verification is enabled, and logs the new user in. With
+// email_verification.require_for_login the response carries no tokens: the
+// user logs in once the address is verified.
+func (uc *authUseCase) Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error) {
+	if uc.register == nil {
+		return nil, apperr.ErrNotFound.WithMessage("Registration is not enabled")
+	}
+
+	// Hash before the transaction; bcrypt does not need a connection.
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, apperr.Internalf("failed to hash password")
+	}
+
+	var user *userdomain.User
+	if err := uc.register.transactor.WithTx(ctx, func(ctx context.Context) error {
+		user, err = uc.register.users.Create(ctx, req.Email, string(passwordHash), req.Name)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	// The authorizer cannot join the transaction, so the role is granted
+	// only once the account is committed. A failed grant removes the
+	// account again, so no user is left without a role.
+	if err := uc.register.roles.AddRoleForUser(user.ID.String(), uc.register.role); err != nil {
+		return nil, apperr.ErrInternal.WithError(errors.Join(err, uc.register.remove(ctx, user.ID.String())))
+	}
+
+	resp := &dto.RegisterResponse{
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		Name:   user.Name,
+		Role:   uc.register.role,
+	}
+	if uc.verify != nil {
+		// The account exists either way; a link that could not be sent can
+		// be asked for again through POST /auth/resend-verification.
+		resp.VerificationSent = uc.sendVerification(ctx, user) == nil
+	}
+	if uc.requireVerified(user) != nil {
+		return resp, nil
+	}
+
+	resp.Tokens, err = uc.issueSession(ctx, user, req.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// remove deletes the account id for good. It runs even when the request
+// has been canceled, since the account is unusable without its role.
+func (r *registration) remove(ctx context.Context, id string) error {
+	return r.transactor.WithTx(context.WithoutCancel(ctx), func(ctx context.Context) error {
+		// Purge only takes deleted users.
+		if err := r.users.Delete(ctx, id); err != nil {
+			return err
+		}
+		return r.users.Purge(ctx, id)
+	})
+}
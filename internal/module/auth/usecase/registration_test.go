@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// fakeUsers creates users in memory. Like the repository, a taken address is
+// a conflict.
+type fakeUsers struct {
+	created []*userdomain.User
+}
+
+func (f *fakeUsers) Create(_ context.Context, email, passwordHash, name string) (*userdomain.User, error) {
+	for _, u := range f.created {
+		if u.Email == email {
+			return nil, apperr.Conflictf("user with email %s already exists", email)
+		}
+	}
+	u := &userdomain.User{ID: uuid.New(), Email: email, Name: name, PasswordHash: passwordHash, IsActive: true}
+	f.created = append(f.created, u)
+	return u, nil
+}
+
+func (f *fakeUsers) Delete(_ context.Context, id string) error {
+	for _, u := range f.created {
+		if u.ID.String() == id {
+			u.IsActive = false
+			return nil
+		}
+	}
+	return apperr.NotFoundf("user %s not found", id)
+}
+
+// Purge removes a deleted user, like the repository
+func (f *fakeUsers) Purge(_ context.Context, id string) error {
+	for i, u := range f.created {
+		if u.ID.String() == id && !u.IsActive {
+			f.created = append(f.created[:i], f.created[i+1:]...)
+			return nil
+		}
+	}
+	return apperr.NotFoundf("deleted user %s not found", id)
+}
+
+// fakeRoles records granted roles
+type fakeRoles struct {
+	granted map[string]string
+	err     error
+}
+
+func (f *fakeRoles) AddRoleForUser(userID, role string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.granted[userID] = role
+	return nil
+}
+
+// fakeTx undoes the users created by a failed transaction. commitErr fails
+// the commit of every transaction whose function succeeds.
+type fakeTx struct {
+	users     *fakeUsers
+	commitErr error
+}
+
+func (f *fakeTx) WithTx(ctx context.Context, fn database.TxFunc, _ ...database.TxOption) error {
+	n := len(f.users.created)
+	err := fn(ctx)
+	if err == nil {
+		err = f.commitErr
+	}
+	if err != nil {
+		f.users.created = f.users.created[:n]
+		return err
+	}
+	return nil
+}
+
+type registerFixture struct {
+	uc        UseCase
+	cache     *mapCache
+	users     *fakeUsers
+	roles     *fakeRoles
+	tx        *fakeTx
+	publisher *fakePublisher
+}
+
+func newRegisterFixture(verify *config.EmailVerificationConfig) *registerFixture {
+	f := &registerFixture{
+		cache:     newMapCache(),
+		users:     &fakeUsers{},
+		roles:     &fakeRoles{granted: map[string]string{}},
+		publisher: &fakePublisher{},
+	}
+	f.tx = &fakeTx{users: f.users}
+	opts := []Option{WithRegistration(f.users, f.roles, f.tx, config.AuthConfig{AllowRegistration: true, DefaultRole: "viewer"})}
+	if verify != nil {
+		opts = append(opts, WithEmailVerification(&fakeMarker{}, f.publisher, *verify))
+	}
+	f.uc = NewUseCase(new(MockUserRepository), f.cache, testJWTConfig(), opts...)
+	return f
+}
+
+func registerRequest() dto.RegisterRequest {
+	return dto.RegisterRequest{Email: "new@example.com", Password: "password123", Name: "New User"}
+}
+
+func TestRegister(t *testing.T) {
+	f := newRegisterFixture(nil)
+
+	resp, err := f.uc.Register(context.Background(), registerRequest())
+	require.NoError(t, err)
+	require.Len(t, f.users.created, 1)
+	user := f.users.created[0]
+	assert.Equal(t, user.ID.String(), resp.UserID)
+	assert.Equal(t, "new@example.com", resp.Email)
+	assert.Equal(t, "viewer", resp.Role)
+	assert.Equal(t, "viewer", f.roles.granted[user.ID.String()])
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("password123")))
+	assert.False(t, resp.VerificationSent, "email verification is off")
+
+	require.NotNil(t, resp.Tokens)
+	assert.Equal(t, user.ID.String(), resp.Tokens.UserID)
+	assert.Contains(t, f.cache.data, tokLookupKey(resp.Tokens.RefreshToken))
+
+	_, err = f.uc.Register(context.Background(), registerRequest())
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+}
+
+func TestRegister_Disabled(t *testing.T) {
+	uc := testUC(new(MockUserRepository), newMapCache())
+	_, err := uc.Register(context.Background(), registerRequest())
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestRegister_RoleGrantFails(t *testing.T) {
+	f := newRegisterFixture(nil)
+	f.roles.err = errors.New("casbin down")
+
+	_, err := f.uc.Register(context.Background(), registerRequest())
+	assert.ErrorIs(t, err, apperr.ErrInternal)
+	assert.Empty(t, f.users.created, "the account is removed again")
+
+	f.roles.err = nil
+	_, err = f.uc.Register(context.Background(), registerRequest())
+	assert.NoError(t, err, "the address is free for another attempt")
+}
+
+func TestRegister_CommitFails(t *testing.T) {
+	f := newRegisterFixture(nil)
+	f.tx.commitErr = errors.New("connection reset")
+
+	_, err := f.uc.Register(context.Background(), registerRequest())
+	assert.EqualError(t, err, "connection reset")
+	assert.Empty(t, f.users.created)
+	assert.Empty(t, f.roles.granted, "no role is granted to an account that was never committed")
+}
+
+func TestRegister_EmailVerification(t *testing.T) {
+	verify := func(requireForLogin bool) *config.EmailVerificationConfig {
+		return &config.EmailVerificationConfig{
+			Enabled:         true,
+			URL:             "https://app.example.com/verify",
+			TokenTTL:        config.Duration(time.Hour),
+			RequireForLogin: requireForLogin,
+		}
+	}
+
+	t.Run("sends a link and logs in", func(t *testing.T) {
+		f := newRegisterFixture(verify(false))
+		resp, err := f.uc.Register(context.Background(), registerRequest())
+		require.NoError(t, err)
+		assert.True(t, resp.VerificationSent)
+		require.Len(t, f.publisher.jobs, 1)
+		assert.Equal(t, "new@example.com", f.publisher.jobs[0].To)
+		verifyTokenFromEmail(t, f.publisher.jobs[0].Body)
+		assert.NotNil(t, resp.Tokens)
+	})
+
+	t.Run("holds the tokens back until verified", func(t *testing.T) {
+		f := newRegisterFixture(verify(true))
+		resp, err := f.uc.Register(context.Background(), registerRequest())
+		require.NoError(t, err)
+		assert.True(t, resp.VerificationSent)
+		assert.Nil(t, resp.Tokens)
+		assert.Len(t, f.users.created, 1)
+	})
+
+	t.Run("a failed send keeps the account", func(t *testing.T) {
+		f := newRegisterFixture(verify(false))
+		f.publisher.err = errors.New("broker down")
+		resp, err := f.uc.Register(context.Background(), registerRequest())
+		require.NoError(t, err)
+		assert.False(t, resp.VerificationSent)
+		assert.NotNil(t, resp.Tokens)
+	})
+}
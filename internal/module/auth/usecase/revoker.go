@@ -1,6 +1,11 @@
 package usecase
 
-import "context"
+import (
+	"context"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+)
 
 // Revoker exposes session-revocation operations to other modules (e.g. user)
 // without leaking auth internals. The concrete implementation lives in
@@ -9,4 +14,38 @@ type Revoker interface {
 	// RevokeAllForUser deletes every active refresh token for the given userID.
 	// It is called by ChangePassword to terminate all existing sessions.
 	RevokeAllForUser(ctx context.Context, userID string) error
+	// RevokeAccessTokens makes every access token issued to userID so far
+	// invalid and revokes their API keys. It is called by a forced password
+	// reset, so credentials issued before it cannot bypass the password
+	// change.
+	RevokeAccessTokens(ctx context.Context, userID string) error
+}
+
+// Verifier is the narrow interface the user module uses to send a
+// verification link when an account is created or its address changes.
+type Verifier interface {
+	// SendVerification emails a verification link to the user's current
+	// address unless it is already verified.
+	SendVerification(ctx context.Context, userID string) error
+}
+
+// EmailChanger is the narrow interface the user module uses to ask for
+// confirmation of a new address set on an account.
+type EmailChanger interface {
+	// SendEmailChange emails a confirmation link to the user's pending
+	// email.
+	SendEmailChange(ctx context.Context, userID string) error
+}
+
+// SessionIssuer lets other login methods (passkeys) issue the same token pair
+// as a password login once they have verified the user themselves.
+type SessionIssuer interface {
+	IssueSession(ctx context.Context, user *userdomain.User) (*dto.LoginResponse, error)
+}
+
+// Impersonator is the narrow interface the user module uses to issue an
+// admin a token to act as another user. The user module checks who may be
+// impersonated and audits it.
+type Impersonator interface {
+	IssueImpersonationToken(ctx context.Context, actorID string, target *userdomain.User) (*userdomain.ImpersonationToken, error)
 }
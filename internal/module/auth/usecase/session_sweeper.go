@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// SessionSweeper maintains refresh-token sessions in bulk. It backs the
+// auth.sessions_sweep worker job.
+type SessionSweeper struct {
+	sessions    sessionStore
+	maxSessions int
+}
+
+// NewSessionSweeper creates a session sweeper enforcing jwtCfg.MaxSessions.
+func NewSessionSweeper(cache port.Cache, jwtCfg config.JWTConfig) *SessionSweeper {
+	return &SessionSweeper{
+		sessions:    sessionStore{cache: cache, ttl: jwtCfg.RefreshTokenDuration()},
+		maxSessions: jwtCfg.MaxSessions,
+	}
+}
+
+// RevokeUsers revokes every session of the given users and returns how many
+// sessions were revoked. With dryRun set it only counts them.
+func (s *SessionSweeper) RevokeUsers(ctx context.Context, userIDs []string, dryRun bool) (int, error) {
+	revoked := 0
+	for _, userID := range userIDs {
+		if dryRun {
+			keys, err := s.sessions.cache.KeysByPrefix(ctx, userKeyPrefix+userID+":")
+			if err != nil {
+				return revoked, err
+			}
+			revoked += len(keys)
+			continue
+		}
+		n, err := s.sessions.revokeUser(ctx, userID)
+		if err != nil {
+			return revoked, err
+		}
+		revoked += n
+	}
+	return revoked, nil
+}
+
+// Sweep scans every refresh-token key. It removes lookup keys whose index key
+// is gone (left behind by revocations), evicts the oldest sessions of users
+// above the session limit, and rebuilds each user's session list. With dryRun
+// set it changes nothing and reports what it would have done.
+//
+// Expired sessions need no cleanup: both keys carry the refresh token TTL.
+func (s *SessionSweeper) Sweep(ctx context.Context, dryRun bool) (*domain.SessionSweepResult, error) {
+	cache := s.sessions.cache
+	result := &domain.SessionSweepResult{}
+
+	idxKeys, err := cache.KeysByPrefix(ctx, userKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[string][]session)
+	live := make(map[string]bool, len(idxKeys))
+	for _, key := range idxKeys {
+		rest := strings.TrimPrefix(key, userKeyPrefix)
+		i := strings.LastIndexByte(rest, ':')
+		if i < 0 {
+			continue
+		}
+		userID, hash := rest[:i], rest[i+1:]
+		v, err := cache.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, port.ErrCacheMiss) {
+				continue // expired since the scan
+			}
+			return nil, err
+		}
+		byUser[userID] = append(byUser[userID], session{Hash: hash, IssuedAt: decodeRecord(v).IssuedAt})
+		live[hash] = true
+	}
+
+	tokKeys, err := cache.KeysByPrefix(ctx, tokKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range tokKeys {
+		hash := strings.TrimPrefix(key, tokKeyPrefix)
+		if live[hash] {
+			continue
+		}
+		orphan, err := s.orphanLookup(ctx, key, hash)
+		if err != nil {
+			return nil, err
+		}
+		if !orphan {
+			continue
+		}
+		if !dryRun {
+			if err := cache.Delete(ctx, key); err != nil {
+				return nil, err
+			}
+		}
+		result.OrphansRemoved++
+	}
+
+	for userID, sessions := range byUser {
+		sortSessions(sessions)
+		if dryRun {
+			if s.maxSessions > 0 && len(sessions) > s.maxSessions {
+				result.Evicted += len(sessions) - s.maxSessions
+				sessions = sessions[len(sessions)-s.maxSessions:]
+			}
+		} else {
+			remaining, evicted, err := s.sessions.evict(ctx, userID, sessions, s.maxSessions, "")
+			result.Evicted += evicted
+			if err != nil {
+				return nil, err
+			}
+			sessions = remaining
+			if err := cache.SetJSON(ctx, sessionListKey(userID), sessions, s.sessions.ttl); err != nil {
+				return nil, err
+			}
+		}
+		result.Users++
+		result.Sessions += len(sessions)
+		result.MaxPerUser = max(result.MaxPerUser, len(sessions))
+	}
+	return result, nil
+}
+
+// orphanLookup re-checks a lookup key that had no index key in the scan.
+// Login writes the lookup key before the index key, so a token issued during
+// the sweep can look orphaned; reading its index key again rules that out.
+func (s *SessionSweeper) orphanLookup(ctx context.Context, key, hash string) (bool, error) {
+	userID, err := s.sessions.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, port.ErrCacheMiss) {
+			return false, nil
+		}
+		return false, err
+	}
+	exists, err := s.sessions.cache.Exists(ctx, userKeyPrefix+string(userID)+":"+hash)
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
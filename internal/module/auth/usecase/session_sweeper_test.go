@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+)
+
+// seedSession writes both keys of a session started at issuedAt, as Login does.
+func seedSession(c *mapCache, userID, token string, issuedAt time.Time) {
+	c.data[tokLookupKey(token)] = []byte(userID)
+	c.data[userIdxKey(userID, token)] = encodeRecord(sessionRecord{ID: token, IssuedAt: issuedAt, LastUsedAt: issuedAt})
+}
+
+func TestLogin_EvictsOldestSessionBeyondLimit(t *testing.T) {
+	ctx := context.Background()
+	user := makeUser("password123")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+	cache := newMapCache()
+	cfg := testJWTConfig()
+	cfg.MaxSessions = 2
+	uc := NewUseCase(mockRepo, cache, cfg)
+
+	login := func() string {
+		resp, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+		require.NoError(t, err)
+		return resp.RefreshToken
+	}
+	first, second, third := login(), login(), login()
+
+	assert.NotContains(t, cache.data, tokLookupKey(first), "oldest session must be evicted")
+	assert.NotContains(t, cache.data, userIdxKey(user.ID.String(), first))
+	_, err := uc.Refresh(ctx, dto.RefreshRequest{RefreshToken: first})
+	assert.Error(t, err, "evicted session must not refresh")
+
+	for _, tok := range []string{second, third} {
+		assert.Contains(t, cache.data, userIdxKey(user.ID.String(), tok))
+	}
+	var listed []session
+	require.NoError(t, cache.GetJSON(ctx, sessionListKey(user.ID.String()), &listed))
+	assert.Len(t, listed, 2)
+}
+
+func TestLogin_UnlimitedSessions(t *testing.T) {
+	ctx := context.Background()
+	user := makeUser("password123")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache) // MaxSessions 0
+
+	for i := 0; i < 5; i++ {
+		_, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+		require.NoError(t, err)
+	}
+	keys, _ := cache.KeysByPrefix(ctx, userKeyPrefix)
+	assert.Len(t, keys, 5)
+}
+
+func TestRefresh_KeepsSessionStart(t *testing.T) {
+	ctx := context.Background()
+	user := makeUser("password123")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+	cache := newMapCache()
+	cfg := testJWTConfig()
+	cfg.MaxSessions = 2
+	uc := NewUseCase(mockRepo, cache, cfg)
+
+	first, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	second, err := uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	started := decodeRecord(cache.data[userIdxKey(user.ID.String(), first.RefreshToken)]).IssuedAt
+
+	// Rotating the first session's token does not make it the newest.
+	rotated, err := uc.Refresh(ctx, dto.RefreshRequest{RefreshToken: first.RefreshToken})
+	require.NoError(t, err)
+	assert.Equal(t, started, decodeRecord(cache.data[userIdxKey(user.ID.String(), rotated.RefreshToken)]).IssuedAt)
+
+	_, err = uc.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+
+	assert.NotContains(t, cache.data, userIdxKey(user.ID.String(), rotated.RefreshToken), "rotated first session is still the oldest")
+	assert.Contains(t, cache.data, userIdxKey(user.ID.String(), second.RefreshToken))
+}
+
+func TestSessionSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	cache := newMapCache()
+	base := time.Now().Add(-time.Hour)
+
+	seedSession(cache, "alice", "a1", base)
+	seedSession(cache, "alice", "a2", base.Add(time.Minute))
+	seedSession(cache, "alice", "a3", base.Add(2*time.Minute))
+	seedSession(cache, "bob", "b1", base)
+	// Left behind by an old revocation: the lookup key without its index key.
+	cache.data[tokLookupKey("orphan")] = []byte("bob")
+
+	cfg := testJWTConfig()
+	cfg.MaxSessions = 2
+	sweeper := NewSessionSweeper(cache, cfg)
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		before := len(cache.data)
+		result, err := sweeper.Sweep(ctx, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.OrphansRemoved)
+		assert.Equal(t, 1, result.Evicted)
+		assert.Equal(t, 3, result.Sessions)
+		assert.Len(t, cache.data, before)
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		result, err := sweeper.Sweep(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Users)
+		assert.Equal(t, 3, result.Sessions)
+		assert.Equal(t, 2, result.MaxPerUser)
+		assert.Equal(t, 1, result.OrphansRemoved)
+		assert.Equal(t, 1, result.Evicted)
+
+		assert.NotContains(t, cache.data, tokLookupKey("orphan"))
+		assert.NotContains(t, cache.data, userIdxKey("alice", "a1"), "alice's oldest session is evicted")
+		assert.NotContains(t, cache.data, tokLookupKey("a1"))
+		assert.Contains(t, cache.data, userIdxKey("alice", "a3"))
+		assert.Contains(t, cache.data, userIdxKey("bob", "b1"))
+
+		var listed []session
+		require.NoError(t, cache.GetJSON(ctx, sessionListKey("alice"), &listed))
+		require.Len(t, listed, 2)
+		assert.Equal(t, tokenHash("a2"), listed[0].Hash)
+	})
+}
+
+func TestSessionSweeper_LegacyIndexValuesAreOldest(t *testing.T) {
+	ctx := context.Background()
+	cache := newMapCache()
+
+	seedSession(cache, "alice", "new", time.Now())
+	cache.data[tokLookupKey("legacy")] = []byte("alice")
+	cache.data[userIdxKey("alice", "legacy")] = []byte("1")
+
+	cfg := testJWTConfig()
+	cfg.MaxSessions = 1
+	result, err := NewSessionSweeper(cache, cfg).Sweep(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Evicted)
+	assert.NotContains(t, cache.data, userIdxKey("alice", "legacy"))
+	assert.Contains(t, cache.data, userIdxKey("alice", "new"))
+}
+
+func TestSessionSweeper_RevokeUsers(t *testing.T) {
+	ctx := context.Background()
+	cache := newMapCache()
+	seedSession(cache, "alice", "a1", time.Now())
+	seedSession(cache, "alice", "a2", time.Now())
+	seedSession(cache, "bob", "b1", time.Now())
+
+	sweeper := NewSessionSweeper(cache, testJWTConfig())
+
+	n, err := sweeper.RevokeUsers(ctx, []string{"alice"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Contains(t, cache.data, userIdxKey("alice", "a1"), "dry run revokes nothing")
+
+	n, err = sweeper.RevokeUsers(ctx, []string{"alice", "nobody"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	for _, tok := range []string{"a1", "a2"} {
+		assert.NotContains(t, cache.data, userIdxKey("alice", tok))
+		assert.NotContains(t, cache.data, tokLookupKey(tok))
+	}
+	assert.Contains(t, cache.data, userIdxKey("bob", "b1"))
+}
@@ -0,0 +1,303 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/google/uuid"
+)
+
+// Refresh-token key prefixes; see tokLookupKey, userIdxKey and sessionListKey.
+const (
+	tokKeyPrefix     = "refresh:tok:"
+	userKeyPrefix    = "refresh:user:"
+	sessionKeyPrefix = "refresh:sessions:"
+)
+
+// sessionListKey returns the per-user session list key:
+// refresh:sessions:<userID>
+// Value stored: JSON []session. It lets Login count and evict a user's
+// sessions without scanning the keyspace. The index keys stay authoritative:
+// entries whose index key is gone are dropped when the list is next written,
+// and the session sweeper rebuilds every list from a full scan.
+func sessionListKey(userID string) string {
+	return sessionKeyPrefix + userID
+}
+
+// session is one entry of the session list. IssuedAt is when the user logged
+// in; Refresh carries it over to the rotated token. The session's details
+// live in its index key (see sessionRecord).
+type session struct {
+	Hash     string    `json:"hash"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// sessionRecord is the value stored under a per-user index key. It describes
+// the session for GET /auth/sessions and is carried over to each rotated
+// token, so ID and IssuedAt stay the same for the session's lifetime.
+type sessionRecord struct {
+	ID         string    `json:"id"`
+	IssuedAt   time.Time `json:"issued_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Device is the name the client gave at login, or one derived from the
+	// User-Agent
+	Device    string `json:"device,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// IP is the client address at login or at the last refresh
+	IP string `json:"ip,omitempty"`
+}
+
+// maxUserAgentLength caps the User-Agent kept in a session record
+const maxUserAgentLength = 255
+
+// newSessionRecord starts a session record at now for the client in ctx.
+// device overrides the name derived from the User-Agent.
+func newSessionRecord(ctx context.Context, device string, now time.Time) sessionRecord {
+	rec := sessionRecord{ID: uuid.NewString(), IssuedAt: now}
+	rec.touch(ctx, now)
+	if device = strings.TrimSpace(device); device != "" {
+		rec.Device = device
+	} else {
+		rec.Device = deviceName(rec.UserAgent)
+	}
+	return rec
+}
+
+// touch records a use of the session at now by the client in ctx. The IP and
+// User-Agent are only replaced when ctx carries them.
+func (r *sessionRecord) touch(ctx context.Context, now time.Time) {
+	r.LastUsedAt = now
+	ac := port.ExtractAuditContext(ctx)
+	if ac.IPAddress != "" {
+		r.IP = ac.IPAddress
+	}
+	if ac.UserAgent != "" {
+		r.UserAgent = ac.UserAgent
+		if len(r.UserAgent) > maxUserAgentLength {
+			r.UserAgent = r.UserAgent[:maxUserAgentLength]
+		}
+	}
+}
+
+// encodeRecord is the value stored under a per-user index key.
+func encodeRecord(r sessionRecord) []byte {
+	b, _ := json.Marshal(r)
+	return b
+}
+
+// decodeRecord parses an index key value. Keys written before session
+// records hold the start time in Unix nanoseconds (see decodeIssuedAt) and
+// decode to a record with no ID.
+func decodeRecord(v []byte) sessionRecord {
+	var r sessionRecord
+	if json.Unmarshal(v, &r) == nil {
+		return r
+	}
+	issuedAt := decodeIssuedAt(v)
+	return sessionRecord{IssuedAt: issuedAt, LastUsedAt: issuedAt}
+}
+
+// decodeIssuedAt parses a pre-record index key value. Keys written before
+// session start times were recorded hold "1" and therefore sort as the oldest.
+func decodeIssuedAt(v []byte) time.Time {
+	n, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// sortSessions orders sessions oldest first.
+func sortSessions(sessions []session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].IssuedAt.Before(sessions[j].IssuedAt)
+	})
+}
+
+// sessionStore manages the refresh-token sessions written by Login and
+// Refresh. It is shared by authUseCase and SessionSweeper.
+type sessionStore struct {
+	cache port.Cache
+	ttl   time.Duration
+}
+
+// track records sess in userID's session list, replacing the entry for the
+// rotated token hash replace (if any), and evicts the oldest sessions beyond
+// limit (0 means unlimited). sess itself is never evicted. It returns the
+// number of sessions evicted.
+//
+// The list is updated read-modify-write, so two concurrent logins of one user
+// can drop each other's entry. An untracked session is still valid; it is
+// counted again after the next sweep.
+func (s sessionStore) track(ctx context.Context, userID string, sess session, replace string, limit int) (int, error) {
+	var listed []session
+	if err := s.cache.GetJSON(ctx, sessionListKey(userID), &listed); err != nil && !errors.Is(err, port.ErrCacheMiss) {
+		return 0, err
+	}
+
+	sessions := make([]session, 0, len(listed)+1)
+	for _, l := range listed {
+		if l.Hash == replace || l.Hash == sess.Hash {
+			continue
+		}
+		ok, err := s.cache.Exists(ctx, userKeyPrefix+userID+":"+l.Hash)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			sessions = append(sessions, l)
+		}
+	}
+	sessions = append(sessions, sess)
+	sortSessions(sessions)
+
+	sessions, evicted, err := s.evict(ctx, userID, sessions, limit, sess.Hash)
+	if err != nil {
+		return evicted, err
+	}
+	return evicted, s.cache.SetJSON(ctx, sessionListKey(userID), sessions, s.ttl)
+}
+
+// evict removes the oldest of sessions (sorted oldest first) until at most
+// limit remain, skipping keep. It returns the sessions left.
+func (s sessionStore) evict(ctx context.Context, userID string, sessions []session, limit int, keep string) ([]session, int, error) {
+	if limit <= 0 || len(sessions) <= limit {
+		return sessions, 0, nil
+	}
+	remaining := make([]session, 0, limit)
+	excess := len(sessions) - limit
+	evicted := 0
+	for i, sess := range sessions {
+		if evicted == excess || sess.Hash == keep {
+			remaining = append(remaining, sess)
+			continue
+		}
+		if err := s.remove(ctx, userID, sess.Hash); err != nil {
+			return append(remaining, sessions[i:]...), evicted, err
+		}
+		evicted++
+	}
+	return remaining, evicted, nil
+}
+
+// remove deletes one session: the index key first, which is what Refresh
+// gates on, then the lookup key.
+func (s sessionStore) remove(ctx context.Context, userID, hash string) error {
+	if err := s.cache.Delete(ctx, userKeyPrefix+userID+":"+hash); err != nil {
+		return err
+	}
+	return s.cache.Delete(ctx, tokKeyPrefix+hash)
+}
+
+// liveSession is a listed session whose index key still exists
+type liveSession struct {
+	Hash   string
+	Record sessionRecord
+}
+
+// list returns userID's live sessions, oldest first, read from the session
+// list and checked against the index keys. A session missing from the list
+// (see track) is not returned until the sweeper rebuilds the list.
+func (s sessionStore) list(ctx context.Context, userID string) ([]liveSession, error) {
+	var listed []session
+	if err := s.cache.GetJSON(ctx, sessionListKey(userID), &listed); err != nil && !errors.Is(err, port.ErrCacheMiss) {
+		return nil, err
+	}
+	sortSessions(listed)
+
+	live := make([]liveSession, 0, len(listed))
+	for _, l := range listed {
+		v, err := s.cache.Get(ctx, userKeyPrefix+userID+":"+l.Hash)
+		if err != nil {
+			if errors.Is(err, port.ErrCacheMiss) {
+				continue
+			}
+			return nil, err
+		}
+		rec := decodeRecord(v)
+		if rec.ID == "" {
+			// Sessions started before session records are named after
+			// their token hash until their next refresh.
+			rec.ID = l.Hash[:32]
+		}
+		live = append(live, liveSession{Hash: l.Hash, Record: rec})
+	}
+	return live, nil
+}
+
+// deviceName derives a short device description such as "Chrome on macOS"
+// from a User-Agent. Clients that are not browsers are named by their
+// product token, e.g. "okhttp".
+func deviceName(ua string) string {
+	var browser string
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	}
+	// iOS and Android user agents also name macOS and Linux, so they are
+	// checked first.
+	var os string
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		os = "iPhone"
+	case strings.Contains(ua, "iPad"):
+		os = "iPad"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(ua, "CrOS"):
+		os = "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+	if product, _, _ := strings.Cut(strings.TrimSpace(ua), "/"); product != "" && !strings.ContainsAny(product, " (") {
+		return product
+	}
+	return "Unknown device"
+}
+
+// revokeUser deletes every session of userID and returns how many it found.
+// The index keys are deleted by prefix, so the sessions are revoked even if
+// listing them fails; the lookup keys are then removed best-effort (the
+// sweeper collects any left behind).
+func (s sessionStore) revokeUser(ctx context.Context, userID string) (int, error) {
+	prefix := userKeyPrefix + userID + ":"
+	keys, listErr := s.cache.KeysByPrefix(ctx, prefix)
+	if err := s.cache.DeleteByPrefix(ctx, prefix); err != nil {
+		return 0, err
+	}
+	_ = s.cache.Delete(ctx, sessionListKey(userID))
+	if listErr != nil {
+		return 0, nil
+	}
+	for _, key := range keys {
+		_ = s.cache.Delete(ctx, tokKeyPrefix+strings.TrimPrefix(key, prefix))
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/auth/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// clientCtx is a request context carrying the client details ClientInfo sets
+func clientCtx(ip, ua string) context.Context {
+	ctx := context.WithValue(context.Background(), logger.IPAddressKey, ip)
+	return context.WithValue(ctx, logger.UserAgentKey, ua)
+}
+
+// sessionID returns the sid claim of an access token issued by testUC
+func sessionID(t *testing.T, accessToken string) string {
+	t.Helper()
+	var claims jwtClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(*jwt.Token) (any, error) {
+		return []byte(testJWTConfig().Secret), nil
+	})
+	require.NoError(t, err)
+	return claims.SessionID
+}
+
+const macChromeUA = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+func TestListSessions(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	uc := testUC(mockRepo, newMapCache())
+	userID := user.ID.String()
+
+	laptop, err := uc.Login(clientCtx("203.0.113.7", macChromeUA), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	phone, err := uc.Login(clientCtx("198.51.100.2", "okhttp/4.12.0"),
+		dto.LoginRequest{Email: user.Email, Password: "password123", DeviceName: "Work phone"})
+	require.NoError(t, err)
+
+	current := sessionID(t, phone.AccessToken)
+	require.NotEmpty(t, current)
+
+	sessions, err := uc.ListSessions(context.Background(), userID, current)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	assert.Equal(t, sessionID(t, laptop.AccessToken), sessions[0].ID)
+	assert.Equal(t, "Chrome on macOS", sessions[0].Device)
+	assert.Equal(t, macChromeUA, sessions[0].UserAgent)
+	assert.Equal(t, "203.0.113.7", sessions[0].IP)
+	assert.False(t, sessions[0].Current)
+
+	assert.Equal(t, current, sessions[1].ID)
+	assert.Equal(t, "Work phone", sessions[1].Device)
+	assert.Equal(t, "198.51.100.2", sessions[1].IP)
+	assert.True(t, sessions[1].Current)
+
+	other, err := uc.ListSessions(context.Background(), "someone-else", "")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestRefresh_KeepsSession(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+	uc := testUC(mockRepo, newMapCache())
+
+	login, err := uc.Login(clientCtx("203.0.113.7", macChromeUA), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	rotated, err := uc.Refresh(clientCtx("203.0.113.99", macChromeUA), dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+
+	id := sessionID(t, login.AccessToken)
+	assert.Equal(t, id, sessionID(t, rotated.AccessToken))
+
+	sessions, err := uc.ListSessions(context.Background(), user.ID.String(), id)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, id, sessions[0].ID)
+	assert.Equal(t, "203.0.113.99", sessions[0].IP, "the last client address is kept")
+	assert.True(t, sessions[0].Current)
+}
+
+func TestRefresh_LegacySessionGetsID(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", mock.Anything, user.ID.String()).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+	userID := user.ID.String()
+
+	// A session written before session records: the index key holds the
+	// start time in Unix nanoseconds.
+	started := time.Now().Add(-time.Hour).Truncate(time.Second)
+	cache.data[tokLookupKey("legacy")] = []byte(userID)
+	cache.data[userIdxKey(userID, "legacy")] = []byte(strconv.FormatInt(started.UnixNano(), 10))
+	require.NoError(t, cache.SetJSON(context.Background(), sessionListKey(userID),
+		[]session{{Hash: tokenHash("legacy"), IssuedAt: started}}, 0))
+
+	sessions, err := uc.ListSessions(context.Background(), userID, "")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, tokenHash("legacy")[:32], sessions[0].ID)
+
+	rotated, err := uc.Refresh(context.Background(), dto.RefreshRequest{RefreshToken: "legacy"})
+	require.NoError(t, err)
+	id := sessionID(t, rotated.AccessToken)
+	require.NotEmpty(t, id)
+
+	sessions, err = uc.ListSessions(context.Background(), userID, id)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, id, sessions[0].ID)
+	assert.Equal(t, started.Format(time.RFC3339), sessions[0].CreatedAt)
+}
+
+func TestRevokeSession(t *testing.T) {
+	user := makeUser("password123")
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	cache := newMapCache()
+	uc := testUC(mockRepo, cache)
+	userID := user.ID.String()
+
+	first, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	second, err := uc.Login(context.Background(), dto.LoginRequest{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	id := sessionID(t, first.AccessToken)
+
+	t.Run("another user's session is not found", func(t *testing.T) {
+		err := uc.RevokeSession(context.Background(), "someone-else", id)
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+		assert.Contains(t, cache.data, userIdxKey(userID, first.RefreshToken))
+	})
+
+	t.Run("revokes only that session", func(t *testing.T) {
+		require.NoError(t, uc.RevokeSession(context.Background(), userID, id))
+		assert.NotContains(t, cache.data, userIdxKey(userID, first.RefreshToken))
+		assert.NotContains(t, cache.data, tokLookupKey(first.RefreshToken))
+		assert.Contains(t, cache.data, userIdxKey(userID, second.RefreshToken))
+
+		_, err := uc.Refresh(context.Background(), dto.RefreshRequest{RefreshToken: first.RefreshToken})
+		assert.Error(t, err)
+
+		sessions, err := uc.ListSessions(context.Background(), userID, "")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, sessionID(t, second.AccessToken), sessions[0].ID)
+	})
+
+	t.Run("already revoked is not found", func(t *testing.T) {
+		err := uc.RevokeSession(context.Background(), userID, id)
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+	})
+}
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{macChromeUA, "Chrome on macOS"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "Safari on iPhone"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0", "Firefox on Linux"},
+		{"okhttp/4.12.0", "okhttp"},
+		{"", "Unknown device"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, deviceName(tt.ua), tt.ua)
+	}
+}
@@ -2,7 +2,9 @@ package docs
 
 import (
 	"embed"
+	"sync"
 
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -23,11 +25,35 @@ const scalarHTML = `<!DOCTYPE html>
 </html>`
 
 // Module represents the API docs module
-type Module struct{}
+type Module struct {
+	registry *routes.Registry
 
-// NewModule creates a new docs module
-func NewModule() *Module {
-	return &Module{}
+	specOnce sync.Once
+	spec     []byte
+	specErr  error
+}
+
+// NewModule creates a new docs module. The served spec is annotated with the
+// permission, rate limit and cache settings of the routes in registry, which
+// may be nil.
+func NewModule(registry *routes.Registry) *Module {
+	return &Module{registry: registry}
+}
+
+// openAPISpec returns the embedded spec annotated with the registered routes.
+// Annotation runs on first use, after every module has mounted its routes; if
+// it fails the raw spec is served instead.
+func (m *Module) openAPISpec() ([]byte, error) {
+	m.specOnce.Do(func() {
+		m.spec, m.specErr = specFS.ReadFile("openapi.yaml")
+		if m.specErr != nil || m.registry == nil {
+			return
+		}
+		if annotated, err := routes.AnnotateOpenAPI(m.spec, m.registry.Routes()); err == nil {
+			m.spec = annotated
+		}
+	})
+	return m.spec, m.specErr
 }
 
 // RegisterRoutes registers docs module routes
@@ -41,7 +67,7 @@ func (m *Module) RegisterRoutes(router fiber.Router) {
 	})
 
 	docs.Get("/openapi.yaml", func(c *fiber.Ctx) error {
-		data, err := specFS.ReadFile("openapi.yaml")
+		data, err := m.openAPISpec()
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("Failed to read OpenAPI spec")
 		}
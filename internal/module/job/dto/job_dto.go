@@ -1,12 +1,18 @@
 package dto
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // DispatchJobRequest represents the request to dispatch a new job
 type DispatchJobRequest struct {
 	Type     string          `json:"type" validate:"required"`
 	Payload  json.RawMessage `json:"payload" validate:"required"`
 	MaxRetry *int            `json:"max_retry,omitempty"`
+	// IdempotencyKey, when set, makes workers run at most one job of the
+	// type with this key successfully, so a retried request is safe
+	IdempotencyKey string `json:"idempotency_key,omitempty" validate:"omitempty,max=255"`
 }
 
 // JobResponse represents the response after dispatching a job
@@ -27,3 +33,30 @@ type JobTypeInfo struct {
 type ListJobTypesResponse struct {
 	Types []JobTypeInfo `json:"types"`
 }
+
+// ListJobsRequest is the tracked job query. Every filter is optional.
+type ListJobsRequest struct {
+	// Pagination
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+
+	// Status and Type take a comma-separated list; a job matches any of
+	// their values
+	Status string `query:"status" validate:"omitempty,max=100"`
+	Type   string `query:"type" validate:"omitempty,max=1000"`
+}
+
+// JobStatusResponse is the tracked state of one job
+type JobStatusResponse struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Queue      string     `json:"queue"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	MaxRetry   int        `json:"max_retry"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -30,7 +30,7 @@ func (h *Handler) Dispatch(c *fiber.Ctx) error {
 		maxRetry = *req.MaxRetry
 	}
 
-	job, err := h.useCase.Dispatch(c.UserContext(), req.Type, req.Payload, maxRetry)
+	job, err := h.useCase.Dispatch(c.UserContext(), req.Type, req.Payload, maxRetry, req.IdempotencyKey)
 	if err != nil {
 		return response.Fail(c, err)
 	}
@@ -42,3 +42,26 @@ func (h *Handler) ListTypes(c *fiber.Ctx) error {
 	result := h.useCase.ListJobTypes(c.UserContext())
 	return response.Success(c, result)
 }
+
+// Get handles GET /jobs/:id
+func (h *Handler) Get(c *fiber.Ctx) error {
+	job, err := h.useCase.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, job)
+}
+
+// List handles GET /jobs
+func (h *Handler) List(c *fiber.Ctx) error {
+	var req dto.ListJobsRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.List(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Paginated(c, result.GetItems(), result.GetMeta())
+}
@@ -13,12 +13,13 @@ import (
 type Module struct {
 	handler    *handler.Handler
 	authorizer port.Authorizer
-	jwtSecret  string
+	authCfg    middleware.AuthConfig
 }
 
-// NewModule creates a new job module
-func NewModule(publisher *worker.Publisher, auditor port.Auditor, authorizer port.Authorizer, jwtSecret string) *Module {
-	uc := usecase.NewUseCase(publisher)
+// NewModule creates a new job module. jobs reads tracked job status; nil
+// when worker.tracking is off.
+func NewModule(publisher *worker.Publisher, jobs port.JobStore, auditor port.Auditor, authorizer port.Authorizer, authCfg middleware.AuthConfig) *Module {
+	uc := usecase.NewUseCase(publisher, jobs)
 	var ucIface usecase.UseCase = uc
 	if auditor != nil {
 		ucIface = usecase.NewAuditedUseCase(ucIface, auditor)
@@ -28,13 +29,13 @@ func NewModule(publisher *worker.Publisher, auditor port.Auditor, authorizer por
 	return &Module{
 		handler:    h,
 		authorizer: authorizer,
-		jwtSecret:  jwtSecret,
+		authCfg:    authCfg,
 	}
 }
 
 // RegisterRoutes registers job module routes
 func (m *Module) RegisterRoutes(router fiber.Router) {
-	authMiddleware := middleware.Auth(middleware.DefaultAuthConfig(m.jwtSecret))
+	authMiddleware := middleware.Auth(m.authCfg)
 
 	jobs := router.Group("/jobs")
 
@@ -44,4 +45,6 @@ func (m *Module) RegisterRoutes(router fiber.Router) {
 
 	jobs.Post("/dispatch", m.handler.Dispatch)
 	jobs.Get("/types", m.handler.ListTypes)
+	jobs.Get("/", m.handler.List)
+	jobs.Get("/:id", m.handler.Get)
 }
@@ -5,10 +5,12 @@ import (
 
 	"github.com/14mdzk/goscratch/internal/module/job/dto"
 	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 )
 
 // AuditedUseCase wraps a UseCase and adds audit logging on Dispatch.
-// ListJobTypes is a read-only operation and is delegated as-is.
+// ListJobTypes, Get and List are read-only operations and are delegated
+// as-is.
 type AuditedUseCase struct {
 	inner   UseCase
 	auditor port.Auditor
@@ -20,8 +22,8 @@ func NewAuditedUseCase(inner UseCase, auditor port.Auditor) *AuditedUseCase {
 }
 
 // Dispatch validates and publishes a job, logging a CREATE audit entry on success.
-func (d *AuditedUseCase) Dispatch(ctx context.Context, jobType string, payload any, maxRetry int) (*dto.JobResponse, error) {
-	resp, err := d.inner.Dispatch(ctx, jobType, payload, maxRetry)
+func (d *AuditedUseCase) Dispatch(ctx context.Context, jobType string, payload any, maxRetry int, idempotencyKey string) (*dto.JobResponse, error) {
+	resp, err := d.inner.Dispatch(ctx, jobType, payload, maxRetry, idempotencyKey)
 	if err != nil {
 		return nil, err
 	}
@@ -31,6 +33,9 @@ func (d *AuditedUseCase) Dispatch(ctx context.Context, jobType string, payload a
 		"job_type":  jobType,
 		"max_retry": maxRetry,
 	}
+	if idempotencyKey != "" {
+		entry.Metadata["idempotency_key"] = idempotencyKey
+	}
 	_ = d.auditor.Log(ctx, entry)
 
 	return resp, nil
@@ -40,3 +45,13 @@ func (d *AuditedUseCase) Dispatch(ctx context.Context, jobType string, payload a
 func (d *AuditedUseCase) ListJobTypes(ctx context.Context) *dto.ListJobTypesResponse {
 	return d.inner.ListJobTypes(ctx)
 }
+
+// Get delegates to inner without audit logging.
+func (d *AuditedUseCase) Get(ctx context.Context, id string) (*dto.JobStatusResponse, error) {
+	return d.inner.Get(ctx, id)
+}
+
+// List delegates to inner without audit logging.
+func (d *AuditedUseCase) List(ctx context.Context, req dto.ListJobsRequest) (shareddomain.CursorPage[dto.JobStatusResponse], error) {
+	return d.inner.List(ctx, req)
+}
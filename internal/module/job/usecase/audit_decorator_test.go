@@ -7,6 +7,7 @@ import (
 
 	"github.com/14mdzk/goscratch/internal/module/job/dto"
 	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -16,8 +17,8 @@ type mockJobUseCase struct {
 	mock.Mock
 }
 
-func (m *mockJobUseCase) Dispatch(ctx context.Context, jobType string, payload any, maxRetry int) (*dto.JobResponse, error) {
-	args := m.Called(ctx, jobType, payload, maxRetry)
+func (m *mockJobUseCase) Dispatch(ctx context.Context, jobType string, payload any, maxRetry int, idempotencyKey string) (*dto.JobResponse, error) {
+	args := m.Called(ctx, jobType, payload, maxRetry, idempotencyKey)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -29,6 +30,19 @@ func (m *mockJobUseCase) ListJobTypes(ctx context.Context) *dto.ListJobTypesResp
 	return args.Get(0).(*dto.ListJobTypesResponse)
 }
 
+func (m *mockJobUseCase) Get(ctx context.Context, id string) (*dto.JobStatusResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.JobStatusResponse), args.Error(1)
+}
+
+func (m *mockJobUseCase) List(ctx context.Context, req dto.ListJobsRequest) (shareddomain.CursorPage[dto.JobStatusResponse], error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(shareddomain.CursorPage[dto.JobStatusResponse]), args.Error(1)
+}
+
 type mockJobAuditor struct {
 	Entries []port.AuditEntry
 }
@@ -53,9 +67,9 @@ func TestJobAuditDecorator_Dispatch(t *testing.T) {
 		dec := NewAuditedUseCase(inner, auditor)
 
 		resp := &dto.JobResponse{ID: "job-1", Type: "email.send", Status: "queued"}
-		inner.On("Dispatch", ctx, "email.send", "payload", 3).Return(resp, nil)
+		inner.On("Dispatch", ctx, "email.send", "payload", 3, "order-42").Return(resp, nil)
 
-		got, err := dec.Dispatch(ctx, "email.send", "payload", 3)
+		got, err := dec.Dispatch(ctx, "email.send", "payload", 3, "order-42")
 		assert.NoError(t, err)
 		assert.Equal(t, resp, got)
 		assert.Len(t, auditor.Entries, 1)
@@ -65,6 +79,7 @@ func TestJobAuditDecorator_Dispatch(t *testing.T) {
 		assert.Equal(t, "job-1", entry.ResourceID)
 		assert.Equal(t, "email.send", entry.Metadata["job_type"])
 		assert.Equal(t, 3, entry.Metadata["max_retry"])
+		assert.Equal(t, "order-42", entry.Metadata["idempotency_key"])
 	})
 
 	t.Run("on failure, does NOT log audit entry", func(t *testing.T) {
@@ -72,9 +87,9 @@ func TestJobAuditDecorator_Dispatch(t *testing.T) {
 		auditor := &mockJobAuditor{}
 		dec := NewAuditedUseCase(inner, auditor)
 
-		inner.On("Dispatch", ctx, "bad", nil, 0).Return(nil, errors.New("invalid"))
+		inner.On("Dispatch", ctx, "bad", nil, 0, "").Return(nil, errors.New("invalid"))
 
-		_, err := dec.Dispatch(ctx, "bad", nil, 0)
+		_, err := dec.Dispatch(ctx, "bad", nil, 0, "")
 		assert.Error(t, err)
 		assert.Empty(t, auditor.Entries)
 	})
@@ -2,19 +2,26 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/14mdzk/goscratch/internal/module/job/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 	"github.com/14mdzk/goscratch/internal/worker"
 	"github.com/14mdzk/goscratch/pkg/apperr"
-	"github.com/google/uuid"
 )
 
 // validJobTypes maps job type identifiers to their descriptions
 var validJobTypes = map[string]string{
-	worker.JobTypeEmailSend:    "Send an email to a recipient",
-	worker.JobTypeAuditCleanup: "Clean up old audit log entries",
-	worker.JobTypeNotification: "Send a notification to a user",
+	worker.JobTypeEmailSend:        "Send an email to a recipient",
+	worker.JobTypeAuditCleanup:     "Clean up old audit log entries",
+	worker.JobTypeNotification:     "Send a notification to a user",
+	worker.JobTypeDormantUsers:     "Email or deactivate accounts with no recent activity",
+	worker.JobTypeNormalizeEmails:  "Rewrite stored emails in canonical form and report collisions",
+	worker.JobTypeSessionSweep:     "Revoke sessions in bulk, remove stale token keys and enforce the session limit",
+	worker.JobTypeAnomalyDetection: "Flag login spikes, admin activity at unusual hours and mass deletions in the audit log",
 }
 
 // jobUseCase handles job business logic.
@@ -22,32 +29,40 @@ var validJobTypes = map[string]string{
 // callers depend on the interface (enables the audit decorator).
 type jobUseCase struct {
 	publisher *worker.Publisher
+	jobs      port.JobStore
 }
 
-// NewUseCase creates a new job use case
-func NewUseCase(publisher *worker.Publisher) UseCase {
+// NewUseCase creates a new job use case. jobs reads tracked job status; nil
+// when worker.tracking is off.
+func NewUseCase(publisher *worker.Publisher, jobs port.JobStore) UseCase {
 	return &jobUseCase{
 		publisher: publisher,
+		jobs:      jobs,
 	}
 }
 
 // Dispatch validates the job type and publishes a job to the queue
-func (uc *jobUseCase) Dispatch(ctx context.Context, jobType string, payload any, maxRetry int) (*dto.JobResponse, error) {
+func (uc *jobUseCase) Dispatch(ctx context.Context, jobType string, payload any, maxRetry int, idempotencyKey string) (*dto.JobResponse, error) {
 	// Validate job type
 	if _, ok := validJobTypes[jobType]; !ok {
 		return nil, apperr.BadRequestf("invalid job type: %s", jobType)
 	}
 
-	// Publish with retry
-	if err := uc.publisher.PublishWithRetry(ctx, jobType, payload, maxRetry); err != nil {
+	job, err := uc.publisher.NewJob(jobType, payload)
+	if err != nil {
+		return nil, apperr.BadRequestf("invalid job payload: %s", err.Error())
+	}
+	job.MaxRetry = maxRetry
+	job.IdempotencyKey = idempotencyKey
+	if err := uc.publisher.PublishRaw(ctx, job); err != nil {
 		return nil, apperr.Internalf("failed to dispatch job: %s", err.Error())
 	}
 
 	return &dto.JobResponse{
-		ID:        uuid.New().String(),
+		ID:        job.ID,
 		Type:      jobType,
-		Status:    "queued",
-		CreatedAt: time.Now().Format(time.RFC3339),
+		Status:    string(port.JobStatusQueued),
+		CreatedAt: job.CreatedAt.Format(time.RFC3339),
 	}, nil
 }
 
@@ -62,3 +77,86 @@ func (uc *jobUseCase) ListJobTypes(ctx context.Context) *dto.ListJobTypesRespons
 	}
 	return &dto.ListJobTypesResponse{Types: types}
 }
+
+// Get returns the tracked status of a job
+func (uc *jobUseCase) Get(ctx context.Context, id string) (*dto.JobStatusResponse, error) {
+	if uc.jobs == nil {
+		return nil, apperr.NotFoundf("job tracking is not enabled")
+	}
+	rec, err := uc.jobs.Get(ctx, id)
+	if errors.Is(err, port.ErrJobNotFound) {
+		return nil, apperr.NotFoundf("job not found")
+	}
+	if err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+	resp := toStatusResponse(*rec)
+	return &resp, nil
+}
+
+// List returns a page of tracked jobs matching req, newest first
+func (uc *jobUseCase) List(ctx context.Context, req dto.ListJobsRequest) (shareddomain.CursorPage[dto.JobStatusResponse], error) {
+	if uc.jobs == nil {
+		return shareddomain.CursorPage[dto.JobStatusResponse]{}, apperr.NotFoundf("job tracking is not enabled")
+	}
+	limit := shareddomain.NormalizeLimit(req.Limit)
+	filter := port.JobFilter{
+		Types: splitList(req.Type),
+		Limit: limit + 1, // one extra to detect another page
+	}
+	for _, st := range splitList(req.Status) {
+		status := port.JobStatus(strings.ToLower(st))
+		switch status {
+		case port.JobStatusQueued, port.JobStatusRunning, port.JobStatusSucceeded, port.JobStatusFailed, port.JobStatusDead:
+		default:
+			return shareddomain.CursorPage[dto.JobStatusResponse]{}, apperr.BadRequestf("invalid status %q: want queued, running, succeeded, failed or dead", st)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if req.Cursor != "" {
+		cursor, err := shareddomain.DecodeCursor(req.Cursor)
+		if err != nil || cursor == nil {
+			return shareddomain.CursorPage[dto.JobStatusResponse]{}, apperr.BadRequestf("invalid cursor")
+		}
+		filter.Cursor = cursor.LastID
+	}
+
+	recs, err := uc.jobs.List(ctx, filter)
+	if err != nil {
+		return shareddomain.CursorPage[dto.JobStatusResponse]{}, apperr.ErrInternal.WithError(err)
+	}
+	items := make([]dto.JobStatusResponse, 0, len(recs))
+	for _, rec := range recs {
+		items = append(items, toStatusResponse(rec))
+	}
+	return shareddomain.NewCursorPage(items, limit, func(j dto.JobStatusResponse) *shareddomain.Cursor {
+		return &shareddomain.Cursor{LastID: j.ID}
+	}), nil
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items
+func splitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func toStatusResponse(rec port.JobRecord) dto.JobStatusResponse {
+	return dto.JobStatusResponse{
+		ID:         rec.ID,
+		Type:       rec.Type,
+		Queue:      rec.Queue,
+		Status:     string(rec.Status),
+		Attempts:   rec.Attempts,
+		MaxRetry:   rec.MaxRetry,
+		Error:      rec.Error,
+		CreatedAt:  rec.CreatedAt,
+		StartedAt:  rec.StartedAt,
+		FinishedAt: rec.FinishedAt,
+		UpdatedAt:  rec.UpdatedAt,
+	}
+}
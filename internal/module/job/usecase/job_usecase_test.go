@@ -2,13 +2,16 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/14mdzk/goscratch/internal/module/job/dto"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
 	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockQueue implements port.Queue for testing
@@ -16,7 +19,7 @@ type MockQueue struct {
 	mock.Mock
 }
 
-func (m *MockQueue) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+func (m *MockQueue) Publish(ctx context.Context, exchange, routingKey string, body []byte, _ ...port.PublishOption) error {
 	args := m.Called(ctx, exchange, routingKey, body)
 	return args.Error(0)
 }
@@ -26,7 +29,7 @@ func (m *MockQueue) PublishJSON(ctx context.Context, exchange, routingKey string
 	return args.Error(0)
 }
 
-func (m *MockQueue) Consume(ctx context.Context, queue string, handler func(body []byte) error) error {
+func (m *MockQueue) Consume(ctx context.Context, queue string, handler func(msg port.Message) error) error {
 	args := m.Called(ctx, queue, handler)
 	return args.Error(0)
 }
@@ -36,7 +39,7 @@ func (m *MockQueue) Ping(ctx context.Context) error {
 	return args.Error(0)
 }
 
-func (m *MockQueue) DeclareQueue(ctx context.Context, name string, durable bool) error {
+func (m *MockQueue) DeclareQueue(ctx context.Context, name string, durable bool, _ ...port.QueueOption) error {
 	args := m.Called(ctx, name, durable)
 	return args.Error(0)
 }
@@ -66,10 +69,10 @@ func TestUseCase_Dispatch(t *testing.T) {
 		mockQueue.On("Publish", ctx, "", "jobs", mock.AnythingOfType("[]uint8")).Return(nil)
 
 		publisher := worker.NewPublisher(mockQueue, "jobs", "")
-		uc := NewUseCase(publisher)
+		uc := NewUseCase(publisher, nil)
 
 		payload := map[string]string{"to": "user@example.com", "subject": "Hello"}
-		result, err := uc.Dispatch(ctx, "email.send", payload, 3)
+		result, err := uc.Dispatch(ctx, "email.send", payload, 3, "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
@@ -85,10 +88,10 @@ func TestUseCase_Dispatch(t *testing.T) {
 		mockQueue.On("Publish", ctx, "", "jobs", mock.AnythingOfType("[]uint8")).Return(nil)
 
 		publisher := worker.NewPublisher(mockQueue, "jobs", "")
-		uc := NewUseCase(publisher)
+		uc := NewUseCase(publisher, nil)
 
 		payload := map[string]int{"older_than_days": 90}
-		result, err := uc.Dispatch(ctx, "audit.cleanup", payload, 1)
+		result, err := uc.Dispatch(ctx, "audit.cleanup", payload, 1, "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
@@ -101,10 +104,10 @@ func TestUseCase_Dispatch(t *testing.T) {
 		mockQueue.On("Publish", ctx, "", "jobs", mock.AnythingOfType("[]uint8")).Return(nil)
 
 		publisher := worker.NewPublisher(mockQueue, "jobs", "")
-		uc := NewUseCase(publisher)
+		uc := NewUseCase(publisher, nil)
 
 		payload := map[string]string{"user_id": "123", "message": "Hello"}
-		result, err := uc.Dispatch(ctx, "notification.send", payload, 5)
+		result, err := uc.Dispatch(ctx, "notification.send", payload, 5, "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
@@ -115,10 +118,10 @@ func TestUseCase_Dispatch(t *testing.T) {
 	t.Run("invalid_job_type", func(t *testing.T) {
 		mockQueue := new(MockQueue)
 		publisher := worker.NewPublisher(mockQueue, "jobs", "")
-		uc := NewUseCase(publisher)
+		uc := NewUseCase(publisher, nil)
 
 		payload := map[string]string{"foo": "bar"}
-		result, err := uc.Dispatch(ctx, "invalid.type", payload, 3)
+		result, err := uc.Dispatch(ctx, "invalid.type", payload, 3, "")
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -134,10 +137,10 @@ func TestUseCase_Dispatch(t *testing.T) {
 		mockQueue.On("Publish", ctx, "", "jobs", mock.AnythingOfType("[]uint8")).Return(assert.AnError)
 
 		publisher := worker.NewPublisher(mockQueue, "jobs", "")
-		uc := NewUseCase(publisher)
+		uc := NewUseCase(publisher, nil)
 
 		payload := map[string]string{"to": "user@example.com"}
-		result, err := uc.Dispatch(ctx, "email.send", payload, 3)
+		result, err := uc.Dispatch(ctx, "email.send", payload, 3, "")
 
 		assert.Nil(t, result)
 		assert.Error(t, err)
@@ -155,12 +158,12 @@ func TestUseCase_ListJobTypes(t *testing.T) {
 	t.Run("returns_all_types", func(t *testing.T) {
 		mockQueue := new(MockQueue)
 		publisher := worker.NewPublisher(mockQueue, "jobs", "")
-		uc := NewUseCase(publisher)
+		uc := NewUseCase(publisher, nil)
 
 		result := uc.ListJobTypes(ctx)
 
 		assert.NotNil(t, result)
-		assert.Len(t, result.Types, 3)
+		assert.Len(t, result.Types, 7)
 
 		// Collect types
 		typeMap := make(map[string]string)
@@ -171,6 +174,10 @@ func TestUseCase_ListJobTypes(t *testing.T) {
 		assert.Contains(t, typeMap, "email.send")
 		assert.Contains(t, typeMap, "audit.cleanup")
 		assert.Contains(t, typeMap, "notification.send")
+		assert.Contains(t, typeMap, "users.dormant")
+		assert.Contains(t, typeMap, "users.normalize_emails")
+		assert.Contains(t, typeMap, "auth.sessions_sweep")
+		assert.Contains(t, typeMap, "security.detect_anomalies")
 
 		// Verify descriptions are not empty
 		for _, desc := range typeMap {
@@ -178,3 +185,143 @@ func TestUseCase_ListJobTypes(t *testing.T) {
 		}
 	})
 }
+
+// mockJobStore implements port.JobStore for testing
+type mockJobStore struct {
+	records []port.JobRecord
+	filter  port.JobFilter
+	err     error
+}
+
+func (m *mockJobStore) Record(_ context.Context, rec port.JobRecord) error {
+	m.records = append(m.records, rec)
+	return m.err
+}
+
+func (m *mockJobStore) Get(_ context.Context, id string) (*port.JobRecord, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, rec := range m.records {
+		if rec.ID == id {
+			return &rec, nil
+		}
+	}
+	return nil, port.ErrJobNotFound
+}
+
+func (m *mockJobStore) List(_ context.Context, filter port.JobFilter) ([]port.JobRecord, error) {
+	m.filter = filter
+	if len(m.records) > filter.Limit {
+		return m.records[:filter.Limit], m.err
+	}
+	return m.records, m.err
+}
+
+func TestUseCase_Dispatch_ReturnsTrackedJobID(t *testing.T) {
+	ctx := context.Background()
+	mockQueue := new(MockQueue)
+	mockQueue.On("Publish", ctx, "", "jobs", mock.AnythingOfType("[]uint8")).Return(nil)
+	store := &mockJobStore{}
+	uc := NewUseCase(worker.NewPublisher(mockQueue, "jobs", "", worker.WithJobRecorder(store)), store)
+
+	result, err := uc.Dispatch(ctx, "email.send", map[string]string{"to": "user@example.com"}, 2, "")
+	require.NoError(t, err)
+
+	got, err := uc.Get(ctx, result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "queued", got.Status)
+	assert.Equal(t, "email.send", got.Type)
+	assert.Equal(t, 2, got.MaxRetry)
+}
+
+func TestUseCase_Dispatch_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	mockQueue := new(MockQueue)
+	mockQueue.On("Publish", ctx, "", "jobs", mock.AnythingOfType("[]uint8")).Return(nil)
+	uc := NewUseCase(worker.NewPublisher(mockQueue, "jobs", ""), nil)
+
+	_, err := uc.Dispatch(ctx, "email.send", map[string]string{"to": "user@example.com"}, 3, "welcome:42")
+	require.NoError(t, err)
+
+	job, err := worker.DecodeJob(mockQueue.Calls[0].Arguments.Get(3).([]byte))
+	require.NoError(t, err)
+	assert.Equal(t, "welcome:42", job.IdempotencyKey)
+}
+
+func TestUseCase_Get(t *testing.T) {
+	ctx := context.Background()
+	publisher := worker.NewPublisher(new(MockQueue), "jobs", "")
+
+	t.Run("tracking_off", func(t *testing.T) {
+		_, err := NewUseCase(publisher, nil).Get(ctx, "job-1")
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		_, err := NewUseCase(publisher, &mockJobStore{}).Get(ctx, "missing")
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		_, err := NewUseCase(publisher, &mockJobStore{err: errors.New("db down")}).Get(ctx, "job-1")
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeInternalError, appErr.Code)
+	})
+
+	t.Run("found", func(t *testing.T) {
+		store := &mockJobStore{records: []port.JobRecord{
+			{ID: "job-1", Type: "email.send", Status: port.JobStatusFailed, Attempts: 4, Error: "smtp down"},
+		}}
+		got, err := NewUseCase(publisher, store).Get(ctx, "job-1")
+		require.NoError(t, err)
+		assert.Equal(t, "failed", got.Status)
+		assert.Equal(t, 4, got.Attempts)
+		assert.Equal(t, "smtp down", got.Error)
+	})
+}
+
+func TestUseCase_List(t *testing.T) {
+	ctx := context.Background()
+	publisher := worker.NewPublisher(new(MockQueue), "jobs", "")
+
+	t.Run("filters_and_pages", func(t *testing.T) {
+		store := &mockJobStore{records: []port.JobRecord{
+			{ID: "job-3", Status: port.JobStatusDead},
+			{ID: "job-2", Status: port.JobStatusFailed},
+			{ID: "job-1", Status: port.JobStatusFailed},
+		}}
+		page, err := NewUseCase(publisher, store).List(ctx, dto.ListJobsRequest{Limit: 2, Status: "failed, DEAD", Type: "email.send"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []port.JobStatus{port.JobStatusFailed, port.JobStatusDead}, store.filter.Statuses)
+		assert.Equal(t, []string{"email.send"}, store.filter.Types)
+		assert.Equal(t, 3, store.filter.Limit)
+		require.Len(t, page.GetItems(), 2)
+		assert.True(t, page.GetMeta().HasMore)
+
+		require.NotNil(t, page.GetMeta().NextCursor)
+		_, err = NewUseCase(publisher, store).List(ctx, dto.ListJobsRequest{Cursor: *page.GetMeta().NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, "job-2", store.filter.Cursor)
+	})
+
+	t.Run("invalid_status", func(t *testing.T) {
+		_, err := NewUseCase(publisher, &mockJobStore{}).List(ctx, dto.ListJobsRequest{Status: "done"})
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeBadRequest, appErr.Code)
+	})
+
+	t.Run("tracking_off", func(t *testing.T) {
+		_, err := NewUseCase(publisher, nil).List(ctx, dto.ListJobsRequest{})
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+	})
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 
 	"github.com/14mdzk/goscratch/internal/module/job/dto"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 )
 
 // UseCase defines the interface for job business logic operations.
 // Handlers and decorators depend on this interface rather than on the
 // concrete *UseCase struct, enabling testability and the audit decorator.
 type UseCase interface {
-	Dispatch(ctx context.Context, jobType string, payload any, maxRetry int) (*dto.JobResponse, error)
+	Dispatch(ctx context.Context, jobType string, payload any, maxRetry int, idempotencyKey string) (*dto.JobResponse, error)
 	ListJobTypes(ctx context.Context) *dto.ListJobTypesResponse
+	Get(ctx context.Context, id string) (*dto.JobStatusResponse, error)
+	List(ctx context.Context, req dto.ListJobsRequest) (shareddomain.CursorPage[dto.JobStatusResponse], error)
 }
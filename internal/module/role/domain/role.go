@@ -1,11 +1,18 @@
 package domain
 
-import "github.com/14mdzk/goscratch/internal/port"
+import (
+	"regexp"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/google/uuid"
+)
 
 // Role represents a role in the system
 type Role struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // Permission represents a permission (object + action)
@@ -14,22 +21,35 @@ type Permission struct {
 	Action string `json:"action"`
 }
 
+// String returns the permission as "object:action"
+func (p Permission) String() string {
+	return p.Object + ":" + p.Action
+}
+
 // RoleAssignment represents a user-to-role assignment
 type RoleAssignment struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
 }
 
-// PredefinedRoles returns all predefined roles in the system
+// PredefinedRoles are the roles every installation has. They are seeded by
+// migration and cannot be deleted.
 var PredefinedRoles = []Role{
 	{Name: port.RoleSuperAdmin, Description: "Full system access with all permissions"},
 	{Name: port.RoleAdmin, Description: "Administrative access with most permissions"},
 	{Name: port.RoleEditor, Description: "Can create and edit content"},
 	{Name: port.RoleViewer, Description: "Read-only access"},
+	{Name: port.RoleOwner, Description: "Permissions every user has on what they own"},
+}
+
+// IsGrantable reports whether the role can be assigned to a user. The owner
+// role cannot: it applies to each user on the objects they own.
+func IsGrantable(name string) bool {
+	return name != port.RoleOwner
 }
 
-// IsValidRole checks if the given role name is a predefined role
-func IsValidRole(name string) bool {
+// IsPredefinedRole checks if the given role name is a predefined role
+func IsPredefinedRole(name string) bool {
 	for _, r := range PredefinedRoles {
 		if r.Name == name {
 			return true
@@ -37,3 +57,15 @@ func IsValidRole(name string) bool {
 	}
 	return false
 }
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// ValidRoleName reports whether name can name a new role: a lower-case
+// letter followed by at most 63 lower-case letters, digits, '_' or '-'. A
+// UUID is refused, since Casbin rules cannot tell it from a user ID.
+func ValidRoleName(name string) bool {
+	if _, err := uuid.Parse(name); err == nil {
+		return false
+	}
+	return roleNamePattern.MatchString(name)
+}
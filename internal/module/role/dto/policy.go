@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy file formats
+const (
+	PolicyFormatYAML = "yaml"
+	PolicyFormatJSON = "json"
+)
+
+// Policy is the full authorization policy as exported and imported: the
+// roles that exist, the permissions of roles and users, the permissions
+// denied to them, and role grants.
+type Policy struct {
+	Roles       []PolicyRole       `json:"roles" yaml:"roles"`
+	Permissions []PolicyPermission `json:"permissions" yaml:"permissions"`
+	Denies      []PolicyPermission `json:"denies,omitempty" yaml:"denies,omitempty"`
+	Grants      []PolicyGrant      `json:"grants" yaml:"grants"`
+}
+
+// PolicyRole is a role of a Policy
+type PolicyRole struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PolicyPermission lets Subject, a role or a user ID, perform Action on
+// Object (a "p" rule). Under Policy.Denies it refuses it instead, whatever
+// Subject's roles allow (a "p" rule with effect deny).
+type PolicyPermission struct {
+	Subject string `json:"subject" yaml:"subject"`
+	Object  string `json:"object" yaml:"object"`
+	Action  string `json:"action" yaml:"action"`
+}
+
+// PolicyGrant grants Role to User, globally or only in Domain (a "g" or
+// "g2" rule)
+type PolicyGrant struct {
+	User   string `json:"user" yaml:"user"`
+	Role   string `json:"role" yaml:"role"`
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+}
+
+// PolicyDiff is what importing a Policy changed or, on a dry run, would
+// change. Rules are in Casbin's CSV form, e.g. "p, admin, users, read".
+type PolicyDiff struct {
+	DryRun       bool     `json:"dry_run"`
+	RolesCreated []string `json:"roles_created"`
+	Added        []string `json:"added"`
+	Removed      []string `json:"removed"`
+}
+
+// Empty reports whether the import changes nothing
+func (d *PolicyDiff) Empty() bool {
+	return len(d.RolesCreated) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DecodePolicy parses a policy file in YAML or JSON, which is also YAML.
+// Unknown fields are an error, so a typo cannot silently drop rules.
+func DecodePolicy(data []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var p Policy
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// EncodePolicy renders p in format, PolicyFormatYAML or PolicyFormatJSON
+func EncodePolicy(p *Policy, format string) ([]byte, error) {
+	switch format {
+	case PolicyFormatYAML:
+		return yaml.Marshal(p)
+	case PolicyFormatJSON:
+		b, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unknown policy format %q (want %s or %s)", format, PolicyFormatYAML, PolicyFormatJSON)
+	}
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePolicy(t *testing.T) {
+	yamlDoc := `
+roles:
+  - name: auditor
+permissions:
+  - {subject: auditor, object: audit, action: read}
+grants:
+  - {user: carol, role: auditor, domain: acme}
+`
+	p, err := DecodePolicy([]byte(yamlDoc))
+	require.NoError(t, err)
+	assert.Equal(t, PolicyGrant{User: "carol", Role: "auditor", Domain: "acme"}, p.Grants[0])
+
+	encoded, err := EncodePolicy(p, PolicyFormatJSON)
+	require.NoError(t, err)
+	fromJSON, err := DecodePolicy(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, p, fromJSON, "JSON round-trips")
+
+	_, err = DecodePolicy([]byte("grant:\n  - {user: carol, role: auditor}\n"))
+	assert.Error(t, err, "unknown fields are refused")
+}
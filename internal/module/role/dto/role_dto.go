@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // AssignRoleRequest represents the request to assign a role to a user
 type AssignRoleRequest struct {
 	UserID string `json:"user_id" validate:"required,uuid"`
@@ -26,10 +28,19 @@ type RemovePermissionRequest struct {
 	Action string `json:"action" validate:"required"`
 }
 
-// RoleResponse represents a role in the response
+// CreateRoleRequest represents the request to create a role
+type CreateRoleRequest struct {
+	Name        string `json:"name" validate:"required,max=64"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+// RoleResponse represents a role in the response. Predefined roles cannot
+// be deleted.
 type RoleResponse struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Predefined  bool      `json:"predefined"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // PermissionResponse represents a permission in the response
@@ -56,6 +67,15 @@ type UserPermissionsResponse struct {
 	Permissions []PermissionResponse `json:"permissions"`
 }
 
+// MyPermissionsResponse lists the caller's roles and everything they may do,
+// as sorted "object:action" strings. "*" in either part matches anything.
+type MyPermissionsResponse struct {
+	UserID      string   `json:"user_id"`
+	Tenant      string   `json:"tenant,omitempty"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
 // AddUserPermissionRequest represents the request to add a direct permission to a user
 type AddUserPermissionRequest struct {
 	Object string `json:"object" validate:"required"`
@@ -3,7 +3,9 @@ package handler
 import (
 	"github.com/14mdzk/goscratch/internal/module/role/dto"
 	"github.com/14mdzk/goscratch/internal/module/role/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
 	"github.com/14mdzk/goscratch/internal/platform/validator"
+	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/14mdzk/goscratch/pkg/response"
 	"github.com/gofiber/fiber/v2"
 )
@@ -20,10 +22,35 @@ func NewHandler(useCase usecase.UseCase) *Handler {
 
 // ListRoles returns all available roles
 func (h *Handler) ListRoles(c *fiber.Ctx) error {
-	roles := h.useCase.ListRoles(c.UserContext())
+	roles, err := h.useCase.ListRoles(c.UserContext())
+	if err != nil {
+		return response.Fail(c, err)
+	}
 	return response.Success(c, roles)
 }
 
+// CreateRole creates a role
+func (h *Handler) CreateRole(c *fiber.Ctx) error {
+	var req dto.CreateRoleRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	role, err := h.useCase.CreateRole(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Created(c, role)
+}
+
+// DeleteRole deletes a role and its permissions
+func (h *Handler) DeleteRole(c *fiber.Ctx) error {
+	if err := h.useCase.DeleteRole(c.UserContext(), c.Params("role")); err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Message(c, "Role deleted successfully")
+}
+
 // AssignRole assigns a role to a user
 func (h *Handler) AssignRole(c *fiber.Ctx) error {
 	var req dto.AssignRoleRequest
@@ -124,6 +151,16 @@ func (h *Handler) GetUserPermissions(c *fiber.Ctx) error {
 	return response.Success(c, result)
 }
 
+// GetMyPermissions returns the caller's roles and effective permissions, in
+// the request's tenant when it names one
+func (h *Handler) GetMyPermissions(c *fiber.Ctx) error {
+	result, err := h.useCase.GetMyPermissions(c.UserContext(), middleware.GetUserID(c), middleware.GetTenantID(c))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, result)
+}
+
 // ListAllPermissions returns all permissions grouped by role (permission catalog)
 func (h *Handler) ListAllPermissions(c *fiber.Ctx) error {
 	result, err := h.useCase.ListAllPermissions(c.UserContext())
@@ -181,3 +218,44 @@ func (h *Handler) CheckUserPermission(c *fiber.Ctx) error {
 		Allowed: allowed,
 	})
 }
+
+// ExportPolicy sends the full policy as a file, in YAML or, with
+// ?format=json, JSON
+func (h *Handler) ExportPolicy(c *fiber.Ctx) error {
+	format := c.Query("format", dto.PolicyFormatYAML)
+	if format != dto.PolicyFormatYAML && format != dto.PolicyFormatJSON {
+		return response.Fail(c, apperr.BadRequestf("format must be %s or %s", dto.PolicyFormatYAML, dto.PolicyFormatJSON))
+	}
+
+	policy, err := h.useCase.ExportPolicy(c.UserContext())
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	body, err := dto.EncodePolicy(policy, format)
+	if err != nil {
+		return response.Fail(c, apperr.ErrInternal.WithError(err))
+	}
+
+	contentType := "application/yaml"
+	if format == dto.PolicyFormatJSON {
+		contentType = fiber.MIMEApplicationJSON
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="policy.`+format+`"`)
+	return c.Send(body)
+}
+
+// ImportPolicy makes the stored policy match the YAML or JSON policy in the
+// body. With ?dry_run=true it only reports the changes.
+func (h *Handler) ImportPolicy(c *fiber.Ctx) error {
+	policy, err := dto.DecodePolicy(c.Body())
+	if err != nil {
+		return response.Fail(c, apperr.BadRequestf("invalid policy: %v", err))
+	}
+
+	diff, err := h.useCase.ImportPolicy(c.UserContext(), *policy, c.QueryBool("dry_run"))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, diff)
+}
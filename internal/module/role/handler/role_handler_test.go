@@ -7,14 +7,18 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	roledomain "github.com/14mdzk/goscratch/internal/module/role/domain"
 	roledto "github.com/14mdzk/goscratch/internal/module/role/dto"
 	"github.com/14mdzk/goscratch/internal/module/role/usecase"
 	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockAuthorizer is a mock implementation of port.Authorizer
@@ -52,6 +56,56 @@ func (m *MockAuthorizer) GetUsersForRole(role string) ([]string, error) {
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockAuthorizer) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	args := m.Called(sub, dom, obj, act)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	args := m.Called(sub, owner, dom, obj, act)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) EnforceBatch(ctx context.Context, requests []port.EnforceRequest) ([]bool, error) {
+	args := m.Called(ctx, requests)
+	return args.Get(0).([]bool), args.Error(1)
+}
+
+func (m *MockAuthorizer) AddDeny(sub, obj, act string) error {
+	args := m.Called(sub, obj, act)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) RemoveDeny(sub, obj, act string) error {
+	args := m.Called(sub, obj, act)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) GetRules() ([]port.PolicyRule, error) {
+	args := m.Called()
+	return args.Get(0).([]port.PolicyRule), args.Error(1)
+}
+
+func (m *MockAuthorizer) AddRoleForUserInDomain(userID, role, domain string) error {
+	args := m.Called(userID, role, domain)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) RemoveRoleForUserInDomain(userID, role, domain string) error {
+	args := m.Called(userID, role, domain)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) GetRolesForUserInDomain(userID, domain string) ([]string, error) {
+	args := m.Called(userID, domain)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAuthorizer) GetUsersForRoleInDomain(role, domain string) ([]string, error) {
+	args := m.Called(role, domain)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockAuthorizer) HasRoleForUser(userID, role string) (bool, error) {
 	args := m.Called(userID, role)
 	return args.Bool(0), args.Error(1)
@@ -113,10 +167,52 @@ func (m *MockAuthorizer) Close() error {
 
 var _ port.Authorizer = (*MockAuthorizer)(nil)
 
+// fakeRoleRepo is an in-memory usecase.Repository holding the predefined
+// roles
+type fakeRoleRepo struct {
+	roles []roledomain.Role
+}
+
+func newFakeRoleRepo() *fakeRoleRepo {
+	return &fakeRoleRepo{roles: append([]roledomain.Role(nil), roledomain.PredefinedRoles...)}
+}
+
+func (r *fakeRoleRepo) Create(_ context.Context, name, description string) (*roledomain.Role, error) {
+	if _, err := r.Get(context.Background(), name); err == nil {
+		return nil, apperr.Conflictf("role %s already exists", name)
+	}
+	role := roledomain.Role{Name: name, Description: description}
+	r.roles = append(r.roles, role)
+	return &role, nil
+}
+
+func (r *fakeRoleRepo) Get(_ context.Context, name string) (*roledomain.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+	return nil, apperr.NotFoundf("role %s not found", name)
+}
+
+func (r *fakeRoleRepo) List(_ context.Context) ([]roledomain.Role, error) {
+	return r.roles, nil
+}
+
+func (r *fakeRoleRepo) Delete(_ context.Context, name string) error {
+	for i, role := range r.roles {
+		if role.Name == name {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return nil
+		}
+	}
+	return apperr.NotFoundf("role %s not found", name)
+}
+
 // setupTestApp creates a Fiber app with the handler for testing
 func setupTestApp(mockAuth *MockAuthorizer) (*fiber.App, *Handler) {
 	app := fiber.New()
-	uc := usecase.NewUseCase(mockAuth)
+	uc := usecase.NewUseCase(newFakeRoleRepo(), mockAuth)
 	h := NewHandler(uc)
 	return app, h
 }
@@ -146,7 +242,58 @@ func TestListRoles(t *testing.T) {
 	result := parseResponseBody(t, resp)
 	assert.True(t, result["success"].(bool))
 	data := result["data"].([]any)
-	assert.Len(t, data, 4)
+	assert.Len(t, data, 5)
+}
+
+func TestCreateRole(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	app, h := setupTestApp(mockAuth)
+	app.Post("/roles", h.CreateRole)
+
+	post := func(body map[string]string) *http.Response {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/roles", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := post(map[string]string{"name": "auditor", "description": "Reads audit logs"})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	data := parseResponseBody(t, resp)["data"].(map[string]any)
+	assert.Equal(t, "auditor", data["name"])
+	assert.Equal(t, false, data["predefined"])
+
+	assert.Equal(t, http.StatusConflict, post(map[string]string{"name": "auditor"}).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, post(map[string]string{"name": "Not Valid"}).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, post(map[string]string{}).StatusCode)
+}
+
+func TestDeleteRole(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	app, h := setupTestApp(mockAuth)
+	app.Post("/roles", h.CreateRole)
+	app.Delete("/roles/:role", h.DeleteRole)
+
+	body, _ := json.Marshal(map[string]string{"name": "auditor"})
+	req := httptest.NewRequest(http.MethodPost, "/roles", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := app.Test(req)
+	assert.NoError(t, err)
+
+	mockAuth.On("GetUsersForRole", "auditor").Return([]string{}, nil)
+	mockAuth.On("GetPermissionsForRole", "auditor").Return([][]string{}, nil)
+
+	del := func(role string) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/roles/"+role, nil))
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, del("auditor"))
+	assert.Equal(t, http.StatusNotFound, del("auditor"))
+	assert.Equal(t, http.StatusBadRequest, del("admin"))
+	mockAuth.AssertExpectations(t)
 }
 
 func TestAssignRole_Success(t *testing.T) {
@@ -380,6 +527,78 @@ func TestGetUserPermissions_Success(t *testing.T) {
 	assert.Len(t, perms, 2)
 }
 
+func TestGetMyPermissions(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	app, h := setupTestApp(mockAuth)
+	app.Get("/me/permissions", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "user-123")
+		c.Locals("tenant_id", "acme")
+		return c.Next()
+	}, h.GetMyPermissions)
+
+	mockAuth.On("GetRolesForUser", "user-123").Return([]string{"viewer"}, nil)
+	mockAuth.On("GetImplicitPermissionsForUser", "user-123").Return([][]string{
+		{"viewer", "users", "read"},
+		{"user-123", "files", "read"},
+	}, nil)
+	mockAuth.On("GetRolesForUserInDomain", "user-123", "acme").Return([]string{"editor"}, nil)
+	mockAuth.On("GetPermissionsForRole", "editor").Return([][]string{
+		{"editor", "users", "read"},
+		{"editor", "users", "update"},
+	}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/me/permissions", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := parseResponseBody(t, resp)["data"].(map[string]any)
+	assert.Equal(t, "user-123", data["user_id"])
+	assert.Equal(t, "acme", data["tenant"])
+	assert.Equal(t, []any{"editor", "viewer"}, data["roles"])
+	assert.Equal(t, []any{"files:read", "users:read", "users:update"}, data["permissions"])
+	mockAuth.AssertExpectations(t)
+}
+
+func TestPolicyExportImport(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	app, h := setupTestApp(mockAuth)
+	app.Get("/roles/policy", h.ExportPolicy)
+	app.Put("/roles/policy", h.ImportPolicy)
+	mockAuth.On("GetRules").Return([]port.PolicyRule{
+		{Type: "p", Values: []string{"admin", "users", "read"}},
+		{Type: "g", Values: []string{"alice", "admin"}},
+	}, nil)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/roles/policy", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+	exported, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(exported), "subject: admin")
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/roles/policy?format=json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/roles/policy?format=xml", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	edited := strings.Replace(string(exported), "user: alice", "user: bob", 1)
+	resp, err = app.Test(httptest.NewRequest(http.MethodPut, "/roles/policy?dry_run=true", strings.NewReader(edited)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	data := parseResponseBody(t, resp)["data"].(map[string]any)
+	assert.Equal(t, true, data["dry_run"])
+	assert.Equal(t, []any{"g, bob, admin"}, data["added"])
+	assert.Equal(t, []any{"g, alice, admin"}, data["removed"])
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPut, "/roles/policy", strings.NewReader("grants: [")))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestAddRolePermission_ValidationError(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
 	app, h := setupTestApp(mockAuth)
@@ -408,6 +627,7 @@ func TestListAllPermissions_Success(t *testing.T) {
 	}, nil)
 	mockAuth.On("GetPermissionsForRole", "editor").Return([][]string{}, nil)
 	mockAuth.On("GetPermissionsForRole", "viewer").Return([][]string{}, nil)
+	mockAuth.On("GetPermissionsForRole", "owner").Return([][]string{}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/roles/permissions", nil)
 	resp, err := app.Test(req)
@@ -418,7 +638,7 @@ func TestListAllPermissions_Success(t *testing.T) {
 	assert.True(t, result["success"].(bool))
 	data := result["data"].(map[string]any)
 	roles := data["roles"].([]any)
-	assert.Len(t, roles, 4)
+	assert.Len(t, roles, 5)
 	mockAuth.AssertExpectations(t)
 }
 
@@ -562,10 +782,14 @@ type stubRoleUseCase struct {
 
 var _ usecase.UseCase = (*stubRoleUseCase)(nil)
 
-func (s *stubRoleUseCase) ListRoles(_ context.Context) []roledto.RoleResponse {
+func (s *stubRoleUseCase) ListRoles(_ context.Context) ([]roledto.RoleResponse, error) {
 	s.listRolesCalled = true
-	return []roledto.RoleResponse{}
+	return []roledto.RoleResponse{}, nil
+}
+func (s *stubRoleUseCase) CreateRole(_ context.Context, _ roledto.CreateRoleRequest) (*roledto.RoleResponse, error) {
+	return nil, nil
 }
+func (s *stubRoleUseCase) DeleteRole(_ context.Context, _ string) error    { return nil }
 func (s *stubRoleUseCase) AssignRole(_ context.Context, _, _ string) error { return nil }
 func (s *stubRoleUseCase) RemoveRole(_ context.Context, _, _ string) error { return nil }
 func (s *stubRoleUseCase) GetRoleUsers(_ context.Context, _ string) (*roledto.RoleUsersResponse, error) {
@@ -584,6 +808,18 @@ func (s *stubRoleUseCase) GetUserRoles(_ context.Context, _ string) (*roledto.Us
 func (s *stubRoleUseCase) GetUserPermissions(_ context.Context, _ string) (*roledto.UserPermissionsResponse, error) {
 	return nil, nil
 }
+func (s *stubRoleUseCase) GetMyPermissions(_ context.Context, _, _ string) (*roledto.MyPermissionsResponse, error) {
+	return nil, nil
+}
+func (s *stubRoleUseCase) ExportPolicy(_ context.Context) (*roledto.Policy, error) {
+	return nil, nil
+}
+func (s *stubRoleUseCase) ImportPolicy(_ context.Context, _ roledto.Policy, _ bool) (*roledto.PolicyDiff, error) {
+	return nil, nil
+}
+func (s *stubRoleUseCase) SeedPolicy(_ context.Context, _ roledto.Policy, _ bool) (*roledto.PolicyDiff, error) {
+	return nil, nil
+}
 func (s *stubRoleUseCase) ListAllPermissions(_ context.Context) (*roledto.AllPermissionsResponse, error) {
 	return nil, nil
 }
@@ -1,59 +1,91 @@
 package role
 
 import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
 	"github.com/14mdzk/goscratch/internal/module/role/handler"
+	"github.com/14mdzk/goscratch/internal/module/role/repository"
 	"github.com/14mdzk/goscratch/internal/module/role/usecase"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/gofiber/fiber/v2"
 )
 
 // Module represents the role management module
 type Module struct {
-	handler    *handler.Handler
-	authorizer port.Authorizer
-	jwtSecret  string
+	handler *handler.Handler
+	uc      usecase.UseCase
+	routes  routes.Config
+	authCfg middleware.AuthConfig
 }
 
-// NewModule creates a new role module
-func NewModule(authorizer port.Authorizer, jwtSecret string) *Module {
-	uc := usecase.NewUseCase(authorizer)
-	h := handler.NewHandler(uc)
+// NewModule creates a new role module. Role changes are audited through
+// auditor.
+func NewModule(repo *repository.Repository, authorizer port.Authorizer, auditor port.Auditor, authCfg middleware.AuthConfig, routeCfg routes.Config) *Module {
+	uc := usecase.NewAuditedUseCase(usecase.NewUseCase(repo, authorizer), auditor)
 
 	return &Module{
-		handler:    h,
-		authorizer: authorizer,
-		jwtSecret:  jwtSecret,
+		handler: handler.NewHandler(uc),
+		uc:      uc,
+		routes:  routeCfg,
+		authCfg: authCfg,
+	}
+}
+
+// SeedPolicy reads the policy file at path, in the format of
+// GET /roles/policy, and adds the roles and rules it lists that are
+// missing. With prune, stored permissions and denies it does not list are
+// removed too.
+func (m *Module) SeedPolicy(ctx context.Context, path string, prune bool) (*dto.PolicyDiff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := dto.DecodePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file: %w", err)
 	}
+	return m.uc.SeedPolicy(ctx, *p, prune)
 }
 
 // RegisterRoutes registers role module routes
 func (m *Module) RegisterRoutes(router fiber.Router) {
-	authMiddleware := middleware.Auth(middleware.DefaultAuthConfig(m.jwtSecret))
-	requireRead := middleware.RequirePermission(m.authorizer, "roles", "read")
-	requireManage := middleware.RequirePermission(m.authorizer, "roles", "manage")
+	authMiddleware := middleware.Auth(m.authCfg)
+	r := routes.New(router, m.routes)
 
 	// Role management routes
-	roles := router.Group("/roles")
-	roles.Use(authMiddleware)
-
-	roles.Get("/", requireRead, m.handler.ListRoles)
-	// Register /permissions before /:role/permissions to avoid route conflicts
-	roles.Get("/permissions", requireRead, m.handler.ListAllPermissions)
-	roles.Post("/assign", requireManage, m.handler.AssignRole)
-	roles.Post("/revoke", requireManage, m.handler.RevokeRole)
-	roles.Get("/:role/users", requireRead, m.handler.GetRoleUsers)
-	roles.Get("/:role/permissions", requireRead, m.handler.GetRolePermissions)
-	roles.Post("/:role/permissions", requireManage, m.handler.AddRolePermission)
-	roles.Delete("/:role/permissions", requireManage, m.handler.RemoveRolePermission)
+	roles := r.Group("/roles").Authenticated(authMiddleware)
+
+	roles.Get("/", m.handler.ListRoles).Require("roles:read")
+	roles.Post("/", m.handler.CreateRole).Require("roles:manage")
+	// Register /permissions and /policy before /:role routes to avoid route conflicts
+	roles.Get("/permissions", m.handler.ListAllPermissions).Require("roles:read")
+	roles.Post("/assign", m.handler.AssignRole).Require("roles:manage")
+	roles.Post("/revoke", m.handler.RevokeRole).Require("roles:manage")
+	roles.Get("/policy", m.handler.ExportPolicy).Require("roles:read")
+	roles.Put("/policy", m.handler.ImportPolicy).Require("roles:manage")
+	roles.Delete("/:role", m.handler.DeleteRole).Require("roles:manage")
+	roles.Get("/:role/users", m.handler.GetRoleUsers).Require("roles:read")
+	roles.Get("/:role/permissions", m.handler.GetRolePermissions).Require("roles:read")
+	roles.Post("/:role/permissions", m.handler.AddRolePermission).Require("roles:manage")
+	roles.Delete("/:role/permissions", m.handler.RemoveRolePermission).Require("roles:manage")
 
 	// User role/permission lookup routes (under /users/:id)
-	users := router.Group("/users")
-	users.Use(authMiddleware)
-
-	users.Get("/:id/roles", requireRead, m.handler.GetUserRoles)
-	users.Get("/:id/permissions", requireRead, m.handler.GetUserPermissions)
-	users.Post("/:id/permissions", requireManage, m.handler.AddUserPermission)
-	users.Delete("/:id/permissions", requireManage, m.handler.RemoveUserPermission)
-	users.Get("/:id/permissions/check", requireRead, m.handler.CheckUserPermission)
+	users := r.Group("/users").Authenticated(authMiddleware)
+
+	users.Get("/:id/roles", m.handler.GetUserRoles).Require("roles:read")
+	users.Get("/:id/permissions", m.handler.GetUserPermissions).Require("roles:read")
+	users.Post("/:id/permissions", m.handler.AddUserPermission).Require("roles:manage")
+	users.Delete("/:id/permissions", m.handler.RemoveUserPermission).Require("roles:manage")
+	users.Get("/:id/permissions/check", m.handler.CheckUserPermission).Require("roles:read")
+
+	// The caller's own roles and permissions, e.g. for building menus
+	me := r.Group("/me").Authenticated(authMiddleware)
+	me.Get("/permissions", m.handler.GetMyPermissions)
+
+	r.Mount()
 }
@@ -0,0 +1,18 @@
+-- name: CreateRole :one
+INSERT INTO roles (name, description)
+VALUES ($1, $2)
+RETURNING name, description, created_at;
+
+-- name: GetRole :one
+SELECT name, description, created_at
+FROM roles
+WHERE name = $1;
+
+-- name: ListRoles :many
+SELECT name, description, created_at
+FROM roles
+ORDER BY created_at ASC, name ASC;
+
+-- name: DeleteRole :execrows
+DELETE FROM roles
+WHERE name = $1;
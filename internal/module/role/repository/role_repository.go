@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/role/domain"
+	"github.com/14mdzk/goscratch/internal/module/role/repository/sqlc"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores roles in the roles table using SQLC-generated queries.
+// Their permissions and assignments live in casbin_rules, behind
+// port.Authorizer. Like the user repository it is TX-aware.
+type Repository struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithQueryTimeout bounds every query issued by the repository to d (see
+// database.WithQueryTimeout). Zero disables the per-query deadline.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(r *Repository) {
+		r.queryTimeout = d
+	}
+}
+
+// NewRepository creates a new role repository
+func NewRepository(pool *pgxpool.Pool, opts ...Option) *Repository {
+	r := &Repository{pool: pool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// queries returns a *sqlc.Queries bound to the transaction in ctx, or to the
+// pool when no transaction is active.
+func (r *Repository) queries(ctx context.Context) *sqlc.Queries {
+	return sqlc.New(database.DBFromContext(ctx, r.pool))
+}
+
+// Create stores a new role. A taken name is a conflict.
+func (r *Repository) Create(ctx context.Context, name, description string) (*domain.Role, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("insert", "roles", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "CreateRole", "roles")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	row, err := r.queries(ctx).CreateRole(ctx, sqlc.CreateRoleParams{Name: name, Description: description})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to create role: %w", err), "role "+name)
+	}
+
+	return sqlcRoleToDomain(&row), nil
+}
+
+// Get returns the role with the given name
+func (r *Repository) Get(ctx context.Context, name string) (*domain.Role, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "roles", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "GetRole", "roles")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	row, err := r.queries(ctx).GetRole(ctx, name)
+	if err != nil {
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to get role: %w", err), "role "+name)
+	}
+
+	return sqlcRoleToDomain(&row), nil
+}
+
+// List returns every role, oldest first
+func (r *Repository) List(ctx context.Context) ([]domain.Role, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "roles", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ListRoles", "roles")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.queries(ctx).ListRoles(ctx)
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := make([]domain.Role, 0, len(rows))
+	for i := range rows {
+		roles = append(roles, *sqlcRoleToDomain(&rows[i]))
+	}
+	return roles, nil
+}
+
+// Delete removes the role with the given name
+func (r *Repository) Delete(ctx context.Context, name string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("delete", "roles", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "DeleteRole", "roles")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	n, err := r.queries(ctx).DeleteRole(ctx, name)
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if n == 0 {
+		return apperr.NotFoundf("role %s not found", name)
+	}
+	return nil
+}
+
+// sqlcRoleToDomain converts a SQLC row to a domain role
+func sqlcRoleToDomain(r *sqlc.Role) *domain.Role {
+	role := &domain.Role{
+		Name:        r.Name,
+		Description: r.Description,
+	}
+	if r.CreatedAt.Valid {
+		role.CreatedAt = r.CreatedAt.Time
+	}
+	return role
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// AuditedUseCase wraps a UseCase and adds audit logging on every mutating
+// operation: role creation and deletion, role assignments, and role and
+// direct user permissions. Read-only methods are delegated as-is.
+type AuditedUseCase struct {
+	inner   UseCase
+	auditor port.Auditor
+}
+
+// compile-time assertion that AuditedUseCase satisfies UseCase.
+var _ UseCase = (*AuditedUseCase)(nil)
+
+// NewAuditedUseCase creates a new AuditedUseCase decorator.
+func NewAuditedUseCase(inner UseCase, auditor port.Auditor) *AuditedUseCase {
+	return &AuditedUseCase{inner: inner, auditor: auditor}
+}
+
+// ListRoles delegates to inner without audit logging.
+func (d *AuditedUseCase) ListRoles(ctx context.Context) ([]dto.RoleResponse, error) {
+	return d.inner.ListRoles(ctx)
+}
+
+// CreateRole creates a role and logs a CREATE audit entry on success.
+func (d *AuditedUseCase) CreateRole(ctx context.Context, req dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	resp, err := d.inner.CreateRole(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "role", resp.Name)
+	entry.NewValue = map[string]any{
+		"name":        resp.Name,
+		"description": resp.Description,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// DeleteRole deletes a role and logs a DELETE audit entry on success. The
+// entry records the permissions the role had.
+func (d *AuditedUseCase) DeleteRole(ctx context.Context, role string) error {
+	// Capture the permissions before they are removed. A failure here is
+	// left for DeleteRole to report.
+	perms, _ := d.inner.GetRolePermissions(ctx, role)
+
+	if err := d.inner.DeleteRole(ctx, role); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "role", role)
+	entry.OldValue = map[string]any{"permissions": perms}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// AssignRole assigns a role and logs a CREATE audit entry on user_role.
+func (d *AuditedUseCase) AssignRole(ctx context.Context, userID, role string) error {
+	if err := d.inner.AssignRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "user_role", userID)
+	entry.NewValue = map[string]any{"role": role}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// RemoveRole revokes a role and logs a DELETE audit entry on user_role.
+func (d *AuditedUseCase) RemoveRole(ctx context.Context, userID, role string) error {
+	if err := d.inner.RemoveRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "user_role", userID)
+	entry.OldValue = map[string]any{"role": role}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// GetRoleUsers delegates to inner without audit logging.
+func (d *AuditedUseCase) GetRoleUsers(ctx context.Context, role string) (*dto.RoleUsersResponse, error) {
+	return d.inner.GetRoleUsers(ctx, role)
+}
+
+// GetRolePermissions delegates to inner without audit logging.
+func (d *AuditedUseCase) GetRolePermissions(ctx context.Context, role string) ([]dto.PermissionResponse, error) {
+	return d.inner.GetRolePermissions(ctx, role)
+}
+
+// AddPermissionToRole adds a permission and logs a CREATE audit entry on
+// role_permission.
+func (d *AuditedUseCase) AddPermissionToRole(ctx context.Context, role, object, action string) error {
+	if err := d.inner.AddPermissionToRole(ctx, role, object, action); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "role_permission", role)
+	entry.NewValue = map[string]any{"object": object, "action": action}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// RemovePermissionFromRole removes a permission and logs a DELETE audit
+// entry on role_permission.
+func (d *AuditedUseCase) RemovePermissionFromRole(ctx context.Context, role, object, action string) error {
+	if err := d.inner.RemovePermissionFromRole(ctx, role, object, action); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "role_permission", role)
+	entry.OldValue = map[string]any{"object": object, "action": action}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// GetUserRoles delegates to inner without audit logging.
+func (d *AuditedUseCase) GetUserRoles(ctx context.Context, userID string) (*dto.UserRolesResponse, error) {
+	return d.inner.GetUserRoles(ctx, userID)
+}
+
+// GetUserPermissions delegates to inner without audit logging.
+func (d *AuditedUseCase) GetUserPermissions(ctx context.Context, userID string) (*dto.UserPermissionsResponse, error) {
+	return d.inner.GetUserPermissions(ctx, userID)
+}
+
+// GetMyPermissions delegates to inner without audit logging.
+func (d *AuditedUseCase) GetMyPermissions(ctx context.Context, userID, tenant string) (*dto.MyPermissionsResponse, error) {
+	return d.inner.GetMyPermissions(ctx, userID, tenant)
+}
+
+// ListAllPermissions delegates to inner without audit logging.
+func (d *AuditedUseCase) ListAllPermissions(ctx context.Context) (*dto.AllPermissionsResponse, error) {
+	return d.inner.ListAllPermissions(ctx)
+}
+
+// AddUserPermission adds a direct permission and logs a CREATE audit entry
+// on user_permission.
+func (d *AuditedUseCase) AddUserPermission(ctx context.Context, userID, object, action string) error {
+	if err := d.inner.AddUserPermission(ctx, userID, object, action); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "user_permission", userID)
+	entry.NewValue = map[string]any{"object": object, "action": action}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// RemoveUserPermission removes a direct permission and logs a DELETE audit
+// entry on user_permission.
+func (d *AuditedUseCase) RemoveUserPermission(ctx context.Context, userID, object, action string) error {
+	if err := d.inner.RemoveUserPermission(ctx, userID, object, action); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "user_permission", userID)
+	entry.OldValue = map[string]any{"object": object, "action": action}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// CheckPermission delegates to inner without audit logging.
+func (d *AuditedUseCase) CheckPermission(ctx context.Context, userID, object, action string) (bool, error) {
+	return d.inner.CheckPermission(ctx, userID, object, action)
+}
+
+// ExportPolicy delegates to inner without audit logging.
+func (d *AuditedUseCase) ExportPolicy(ctx context.Context) (*dto.Policy, error) {
+	return d.inner.ExportPolicy(ctx)
+}
+
+// ImportPolicy imports a policy and, unless it was a dry run or changed
+// nothing, logs an UPDATE audit entry on policy recording the changes.
+func (d *AuditedUseCase) ImportPolicy(ctx context.Context, p dto.Policy, dryRun bool) (*dto.PolicyDiff, error) {
+	diff, err := d.inner.ImportPolicy(ctx, p, dryRun)
+	if err != nil || diff.DryRun {
+		return diff, err
+	}
+	d.logPolicyChange(ctx, diff, "import")
+	return diff, nil
+}
+
+// SeedPolicy seeds a policy and, unless it changed nothing, logs an UPDATE
+// audit entry on policy recording the changes.
+func (d *AuditedUseCase) SeedPolicy(ctx context.Context, p dto.Policy, prune bool) (*dto.PolicyDiff, error) {
+	diff, err := d.inner.SeedPolicy(ctx, p, prune)
+	if err != nil {
+		return diff, err
+	}
+	d.logPolicyChange(ctx, diff, "seed")
+	return diff, nil
+}
+
+// logPolicyChange logs the changes a policy import or seed made, if any;
+// source tells the two apart
+func (d *AuditedUseCase) logPolicyChange(ctx context.Context, diff *dto.PolicyDiff, source string) {
+	if diff.Empty() {
+		return
+	}
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "policy", "")
+	entry.NewValue = map[string]any{
+		"roles_created": diff.RolesCreated,
+		"added":         diff.Added,
+		"removed":       diff.Removed,
+	}
+	entry.Metadata = map[string]any{"source": source}
+	_ = d.auditor.Log(ctx, entry)
+}
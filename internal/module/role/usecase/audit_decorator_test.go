@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// recordingAuditor records the entries it is given
+type recordingAuditor struct {
+	entries []port.AuditEntry
+}
+
+func (a *recordingAuditor) Log(_ context.Context, entry port.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+func TestRoleAuditDecorator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create role logs the role", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		dec := NewAuditedUseCase(NewUseCase(newFakeRoleRepo(), new(MockAuthorizer)), auditor)
+
+		_, err := dec.CreateRole(ctx, dto.CreateRoleRequest{Name: "auditor", Description: "Reads audit logs"})
+		require.NoError(t, err)
+
+		require.Len(t, auditor.entries, 1)
+		e := auditor.entries[0]
+		assert.Equal(t, port.AuditActionCreate, e.Action)
+		assert.Equal(t, "role", e.Resource)
+		assert.Equal(t, "auditor", e.ResourceID)
+		assert.Equal(t, map[string]any{"name": "auditor", "description": "Reads audit logs"}, e.NewValue)
+	})
+
+	t.Run("delete role logs its permissions", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		repo := newFakeRoleRepo()
+		_, _ = repo.Create(ctx, "auditor", "")
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("GetUsersForRole", "auditor").Return([]string{}, nil)
+		mockAuth.On("GetPermissionsForRole", "auditor").Return([][]string{{"auditor", "audit", "read"}}, nil)
+		mockAuth.On("RemovePermissionForRole", "auditor", "audit", "read").Return(nil)
+		dec := NewAuditedUseCase(NewUseCase(repo, mockAuth), auditor)
+
+		require.NoError(t, dec.DeleteRole(ctx, "auditor"))
+
+		require.Len(t, auditor.entries, 1)
+		e := auditor.entries[0]
+		assert.Equal(t, port.AuditActionDelete, e.Action)
+		assert.Equal(t, "role", e.Resource)
+		assert.Equal(t, map[string]any{"permissions": []dto.PermissionResponse{{Object: "audit", Action: "read"}}}, e.OldValue)
+	})
+
+	t.Run("assignments and permissions name their subject", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("HasRoleForUser", "user-1", "editor").Return(false, nil).Once()
+		mockAuth.On("AddRoleForUser", "user-1", "editor").Return(nil)
+		mockAuth.On("HasRoleForUser", "user-1", "editor").Return(true, nil).Once()
+		mockAuth.On("RemoveRoleForUser", "user-1", "editor").Return(nil)
+		mockAuth.On("AddPermissionForRole", "editor", "posts", "write").Return(nil)
+		mockAuth.On("RemovePermissionForRole", "editor", "posts", "write").Return(nil)
+		mockAuth.On("AddPermissionForUser", "user-1", "reports", "export").Return(nil)
+		mockAuth.On("RemovePermissionForUser", "user-1", "reports", "export").Return(nil)
+		dec := NewAuditedUseCase(NewUseCase(newFakeRoleRepo(), mockAuth), auditor)
+
+		require.NoError(t, dec.AssignRole(ctx, "user-1", "editor"))
+		require.NoError(t, dec.RemoveRole(ctx, "user-1", "editor"))
+		require.NoError(t, dec.AddPermissionToRole(ctx, "editor", "posts", "write"))
+		require.NoError(t, dec.RemovePermissionFromRole(ctx, "editor", "posts", "write"))
+		require.NoError(t, dec.AddUserPermission(ctx, "user-1", "reports", "export"))
+		require.NoError(t, dec.RemoveUserPermission(ctx, "user-1", "reports", "export"))
+
+		type logged struct {
+			action     port.AuditAction
+			resource   string
+			resourceID string
+		}
+		var got []logged
+		for _, e := range auditor.entries {
+			got = append(got, logged{e.Action, e.Resource, e.ResourceID})
+		}
+		assert.Equal(t, []logged{
+			{port.AuditActionCreate, "user_role", "user-1"},
+			{port.AuditActionDelete, "user_role", "user-1"},
+			{port.AuditActionCreate, "role_permission", "editor"},
+			{port.AuditActionDelete, "role_permission", "editor"},
+			{port.AuditActionCreate, "user_permission", "user-1"},
+			{port.AuditActionDelete, "user_permission", "user-1"},
+		}, got)
+		assert.Equal(t, map[string]any{"role": "editor"}, auditor.entries[0].NewValue)
+		assert.Equal(t, map[string]any{"object": "posts", "action": "write"}, auditor.entries[3].OldValue)
+	})
+
+	t.Run("failures are not logged", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("GetPermissionsForRole", "viewer").Return([][]string{}, nil)
+		dec := NewAuditedUseCase(NewUseCase(newFakeRoleRepo(), mockAuth), auditor)
+
+		_, err := dec.CreateRole(ctx, dto.CreateRoleRequest{Name: "admin"})
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		assert.Error(t, dec.DeleteRole(ctx, "viewer"))
+		assert.Error(t, dec.AssignRole(ctx, "user-1", "nonexistent"))
+		assert.Empty(t, auditor.entries)
+	})
+
+	t.Run("policy imports log their changes, dry runs do not", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("GetRules").Return([]port.PolicyRule{{Type: "g", Values: []string{"user-1", "admin"}}}, nil)
+		mockAuth.On("AddRoleForUser", "user-2", "admin").Return(nil)
+		dec := NewAuditedUseCase(NewUseCase(newFakeRoleRepo(), mockAuth), auditor)
+		policy := dto.Policy{Grants: []dto.PolicyGrant{{User: "user-1", Role: "admin"}, {User: "user-2", Role: "admin"}}}
+
+		_, err := dec.ImportPolicy(ctx, policy, true)
+		require.NoError(t, err)
+		assert.Empty(t, auditor.entries)
+
+		_, err = dec.ImportPolicy(ctx, policy, false)
+		require.NoError(t, err)
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, port.AuditActionUpdate, auditor.entries[0].Action)
+		assert.Equal(t, "policy", auditor.entries[0].Resource)
+		assert.Equal(t, []string{"g, user-2, admin"}, auditor.entries[0].NewValue.(map[string]any)["added"])
+		assert.Equal(t, "import", auditor.entries[0].Metadata["source"])
+	})
+
+	t.Run("policy seeds log their changes", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("LoadPolicy").Return(nil)
+		mockAuth.On("GetRules").Return([]port.PolicyRule{}, nil)
+		mockAuth.On("AddPermissionForRole", "viewer", "posts", "read").Return(nil)
+		dec := NewAuditedUseCase(NewUseCase(newFakeRoleRepo(), mockAuth), auditor)
+
+		_, err := dec.SeedPolicy(ctx, dto.Policy{Permissions: []dto.PolicyPermission{{Subject: "viewer", Object: "posts", Action: "read"}}}, false)
+		require.NoError(t, err)
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, "seed", auditor.entries[0].Metadata["source"])
+	})
+}
@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/14mdzk/goscratch/internal/module/role/domain"
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// Rule types a Policy holds. Rules of other types, which only a custom model
+// defines, are neither exported nor touched by an import.
+const (
+	rulePermission  = "p"
+	ruleGrant       = "g"
+	ruleDomainGrant = "g2"
+)
+
+// ExportPolicy returns every role, permission, deny rule and role grant
+func (uc *roleUseCase) ExportPolicy(ctx context.Context) (*dto.Policy, error) {
+	roles, err := uc.repo.List(ctx)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	rules, err := uc.authorizer.GetRules()
+	if err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+
+	p := &dto.Policy{
+		Roles:       make([]dto.PolicyRole, 0, len(roles)),
+		Permissions: []dto.PolicyPermission{},
+		Grants:      []dto.PolicyGrant{},
+	}
+	for _, r := range roles {
+		p.Roles = append(p.Roles, dto.PolicyRole{Name: r.Name, Description: r.Description})
+	}
+	for _, r := range rules {
+		switch {
+		case r.Type == rulePermission && len(r.Values) == 4 && r.Values[3] == port.EffectDeny:
+			p.Denies = append(p.Denies, dto.PolicyPermission{Subject: r.Values[0], Object: r.Values[1], Action: r.Values[2]})
+		case r.Type == rulePermission && len(r.Values) >= 3:
+			p.Permissions = append(p.Permissions, dto.PolicyPermission{Subject: r.Values[0], Object: r.Values[1], Action: r.Values[2]})
+		case r.Type == ruleGrant && len(r.Values) >= 2:
+			p.Grants = append(p.Grants, dto.PolicyGrant{User: r.Values[0], Role: r.Values[1]})
+		case r.Type == ruleDomainGrant && len(r.Values) >= 3:
+			p.Grants = append(p.Grants, dto.PolicyGrant{User: r.Values[0], Role: r.Values[1], Domain: r.Values[2]})
+		}
+	}
+	return p, nil
+}
+
+// ImportPolicy makes the stored policy match p: roles in p that do not exist
+// are created, rules in p that are not stored are added, and stored rules
+// not in p are removed. Roles are never deleted. Importing the same policy
+// again changes nothing. With dryRun, the changes are only reported.
+//
+// Rules are added before any is removed, so a grant moved from one rule to
+// another is never missing in between. Should the import fail halfway,
+// importing again completes it.
+func (uc *roleUseCase) ImportPolicy(ctx context.Context, p dto.Policy, dryRun bool) (*dto.PolicyDiff, error) {
+	if len(p.Permissions) == 0 && len(p.Grants) == 0 {
+		return nil, apperr.BadRequestf("policy has no permissions or grants; importing it would remove every rule")
+	}
+	return uc.reconcile(ctx, p, dryRun, func(port.PolicyRule) bool { return true })
+}
+
+// SeedPolicy adds what p lists and is missing: roles, permissions, denies
+// and grants. With prune, stored permissions and denies p does not list are
+// removed as well; grants never are, as they are made at runtime. The
+// policy is reloaded first, so rules seeded by another instance count.
+func (uc *roleUseCase) SeedPolicy(ctx context.Context, p dto.Policy, prune bool) (*dto.PolicyDiff, error) {
+	if err := uc.authorizer.LoadPolicy(); err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+	return uc.reconcile(ctx, p, false, func(r port.PolicyRule) bool {
+		return prune && r.Type == rulePermission
+	})
+}
+
+// reconcile creates the roles of p that are missing, adds the rules of p
+// that are not stored, and removes the stored rules p does not list for
+// which prunable is true
+func (uc *roleUseCase) reconcile(ctx context.Context, p dto.Policy, dryRun bool, prunable func(port.PolicyRule) bool) (*dto.PolicyDiff, error) {
+	existing, err := uc.repo.List(ctx)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	known := make(map[string]bool, len(existing)+len(p.Roles))
+	for _, r := range existing {
+		known[r.Name] = true
+	}
+
+	diff := &dto.PolicyDiff{DryRun: dryRun, RolesCreated: []string{}, Added: []string{}, Removed: []string{}}
+	var newRoles []dto.PolicyRole
+	for i, r := range p.Roles {
+		if known[r.Name] {
+			continue
+		}
+		if !domain.ValidRoleName(r.Name) {
+			return nil, apperr.BadRequestf("roles[%d]: invalid role name: %s", i, r.Name)
+		}
+		known[r.Name] = true
+		newRoles = append(newRoles, r)
+		diff.RolesCreated = append(diff.RolesCreated, r.Name)
+	}
+
+	want, err := policyRules(p, known)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := uc.authorizer.GetRules()
+	if err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+	have := make(map[string]port.PolicyRule, len(stored))
+	for _, r := range stored {
+		if managedRule(r) {
+			have[r.String()] = r
+		}
+	}
+
+	var add, remove []port.PolicyRule
+	for key, r := range want {
+		if _, ok := have[key]; !ok {
+			add = append(add, r)
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	for key, r := range have {
+		if _, ok := want[key]; !ok && prunable(r) {
+			remove = append(remove, r)
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	if dryRun {
+		return diff, nil
+	}
+
+	for _, r := range newRoles {
+		if _, err := uc.repo.Create(ctx, r.Name, r.Description); err != nil {
+			return nil, internalError(err)
+		}
+	}
+	for _, r := range add {
+		if err := uc.applyRule(r, true); err != nil {
+			return nil, apperr.ErrInternal.WithError(err)
+		}
+	}
+	for _, r := range remove {
+		if err := uc.applyRule(r, false); err != nil {
+			return nil, apperr.ErrInternal.WithError(err)
+		}
+	}
+	return diff, nil
+}
+
+// policyRules returns the rules of p keyed by their CSV form, after checking
+// each entry. Grants must name a role in known.
+func policyRules(p dto.Policy, known map[string]bool) (map[string]port.PolicyRule, error) {
+	rules := make(map[string]port.PolicyRule, len(p.Permissions)+len(p.Grants))
+	add := func(r port.PolicyRule) { rules[r.String()] = r }
+
+	for i, perm := range p.Permissions {
+		if perm.Subject == "" || perm.Object == "" || perm.Action == "" {
+			return nil, apperr.BadRequestf("permissions[%d]: subject, object and action are required", i)
+		}
+		add(port.PolicyRule{Type: rulePermission, Values: []string{perm.Subject, perm.Object, perm.Action}})
+	}
+	for i, deny := range p.Denies {
+		if deny.Subject == "" || deny.Object == "" || deny.Action == "" {
+			return nil, apperr.BadRequestf("denies[%d]: subject, object and action are required", i)
+		}
+		add(port.PolicyRule{Type: rulePermission, Values: []string{deny.Subject, deny.Object, deny.Action, port.EffectDeny}})
+	}
+	for i, g := range p.Grants {
+		switch {
+		case g.User == "" || g.Role == "":
+			return nil, apperr.BadRequestf("grants[%d]: user and role are required", i)
+		case !domain.IsGrantable(g.Role):
+			return nil, apperr.BadRequestf("grants[%d]: role %s applies to owners and cannot be assigned", i, g.Role)
+		case !known[g.Role]:
+			return nil, apperr.BadRequestf("grants[%d]: unknown role %s; list it under roles", i, g.Role)
+		case g.Domain == "":
+			add(port.PolicyRule{Type: ruleGrant, Values: []string{g.User, g.Role}})
+		default:
+			add(port.PolicyRule{Type: ruleDomainGrant, Values: []string{g.User, g.Role, g.Domain}})
+		}
+	}
+	return rules, nil
+}
+
+// managedRule reports whether r is a rule a Policy holds
+func managedRule(r port.PolicyRule) bool {
+	switch r.Type {
+	case rulePermission:
+		return len(r.Values) == 3 || len(r.Values) == 4 && r.Values[3] == port.EffectDeny
+	case ruleDomainGrant:
+		return len(r.Values) == 3
+	case ruleGrant:
+		return len(r.Values) == 2
+	default:
+		return false
+	}
+}
+
+// applyRule adds or removes r through the authorizer method for its type,
+// so decision caches are invalidated as for any other change
+func (uc *roleUseCase) applyRule(r port.PolicyRule, add bool) error {
+	v := r.Values
+	switch {
+	case r.Type == rulePermission && len(v) == 4 && add:
+		return uc.authorizer.AddDeny(v[0], v[1], v[2])
+	case r.Type == rulePermission && len(v) == 4:
+		return uc.authorizer.RemoveDeny(v[0], v[1], v[2])
+	case r.Type == rulePermission && add:
+		return uc.authorizer.AddPermissionForRole(v[0], v[1], v[2])
+	case r.Type == rulePermission:
+		return uc.authorizer.RemovePermissionForRole(v[0], v[1], v[2])
+	case r.Type == ruleGrant && add:
+		return uc.authorizer.AddRoleForUser(v[0], v[1])
+	case r.Type == ruleGrant:
+		return uc.authorizer.RemoveRoleForUser(v[0], v[1])
+	case r.Type == ruleDomainGrant && add:
+		return uc.authorizer.AddRoleForUserInDomain(v[0], v[1], v[2])
+	case r.Type == ruleDomainGrant:
+		return uc.authorizer.RemoveRoleForUserInDomain(v[0], v[1], v[2])
+	default:
+		return fmt.Errorf("unsupported rule %s", r)
+	}
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// storedRules are the rules the policy tests start from
+var storedRules = []port.PolicyRule{
+	{Type: "p", Values: []string{"admin", "users", "read"}},
+	{Type: "p", Values: []string{"admin", "users", "delete"}},
+	{Type: "p", Values: []string{"dave", "users", "delete", "deny"}},
+	{Type: "g", Values: []string{"alice", "admin"}},
+	{Type: "g2", Values: []string{"bob", "editor", "acme"}},
+}
+
+func TestExportPolicy(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+	mockAuth.On("GetRules").Return(storedRules, nil)
+
+	p, err := uc.ExportPolicy(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, p.Roles, 5)
+	assert.Equal(t, []dto.PolicyPermission{
+		{Subject: "admin", Object: "users", Action: "read"},
+		{Subject: "admin", Object: "users", Action: "delete"},
+	}, p.Permissions)
+	assert.Equal(t, []dto.PolicyPermission{{Subject: "dave", Object: "users", Action: "delete"}}, p.Denies)
+	assert.Equal(t, []dto.PolicyGrant{
+		{User: "alice", Role: "admin"},
+		{User: "bob", Role: "editor", Domain: "acme"},
+	}, p.Grants)
+}
+
+func TestImportPolicy(t *testing.T) {
+	policy := dto.Policy{
+		Roles: []dto.PolicyRole{{Name: "auditor", Description: "Reads audit logs"}},
+		Permissions: []dto.PolicyPermission{
+			{Subject: "admin", Object: "users", Action: "read"},
+			{Subject: "auditor", Object: "audit", Action: "read"},
+		},
+		Denies: []dto.PolicyPermission{{Subject: "erin", Object: "audit", Action: "read"}},
+		Grants: []dto.PolicyGrant{
+			{User: "alice", Role: "admin"},
+			{User: "bob", Role: "editor", Domain: "acme"},
+			{User: "carol", Role: "auditor"},
+		},
+	}
+	wantDiff := func(dryRun bool) *dto.PolicyDiff {
+		return &dto.PolicyDiff{
+			DryRun:       dryRun,
+			RolesCreated: []string{"auditor"},
+			Added:        []string{"g, carol, auditor", "p, auditor, audit, read", "p, erin, audit, read, deny"},
+			Removed:      []string{"p, admin, users, delete", "p, dave, users, delete, deny"},
+		}
+	}
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		repo := newFakeRoleRepo()
+		uc := NewUseCase(repo, mockAuth)
+		mockAuth.On("GetRules").Return(storedRules, nil)
+
+		diff, err := uc.ImportPolicy(context.Background(), policy, true)
+		require.NoError(t, err)
+		assert.Equal(t, wantDiff(true), diff)
+		assert.Len(t, repo.roles, 5, "no role created")
+		mockAuth.AssertNotCalled(t, "AddPermissionForRole", "auditor", "audit", "read")
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		repo := newFakeRoleRepo()
+		uc := NewUseCase(repo, mockAuth)
+		mockAuth.On("GetRules").Return(storedRules, nil)
+		mockAuth.On("AddPermissionForRole", "auditor", "audit", "read").Return(nil)
+		mockAuth.On("AddRoleForUser", "carol", "auditor").Return(nil)
+		mockAuth.On("RemovePermissionForRole", "admin", "users", "delete").Return(nil)
+		mockAuth.On("AddDeny", "erin", "audit", "read").Return(nil)
+		mockAuth.On("RemoveDeny", "dave", "users", "delete").Return(nil)
+
+		diff, err := uc.ImportPolicy(context.Background(), policy, false)
+		require.NoError(t, err)
+		assert.Equal(t, wantDiff(false), diff)
+		assert.Equal(t, "auditor", repo.roles[len(repo.roles)-1].Name)
+		mockAuth.AssertExpectations(t)
+	})
+
+	t.Run("importing the stored policy changes nothing", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+		mockAuth.On("GetRules").Return(storedRules, nil)
+		exported, err := uc.ExportPolicy(context.Background())
+		require.NoError(t, err)
+
+		diff, err := uc.ImportPolicy(context.Background(), *exported, false)
+		require.NoError(t, err)
+		assert.True(t, diff.Empty())
+	})
+
+	t.Run("rules of other types are left alone", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+		mockAuth.On("GetRules").Return(append([]port.PolicyRule{{Type: "p2", Values: []string{"x", "y"}}}, storedRules...), nil)
+		exported, err := uc.ExportPolicy(context.Background())
+		require.NoError(t, err)
+
+		diff, err := uc.ImportPolicy(context.Background(), *exported, true)
+		require.NoError(t, err)
+		assert.Empty(t, diff.Removed)
+	})
+}
+
+func TestSeedPolicy(t *testing.T) {
+	seed := dto.Policy{
+		Roles: []dto.PolicyRole{{Name: "auditor"}},
+		Permissions: []dto.PolicyPermission{
+			{Subject: "admin", Object: "users", Action: "read"},
+			{Subject: "auditor", Object: "audit", Action: "read"},
+		},
+	}
+
+	t.Run("adds what is missing", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		repo := newFakeRoleRepo()
+		uc := NewUseCase(repo, mockAuth)
+		mockAuth.On("LoadPolicy").Return(nil)
+		mockAuth.On("GetRules").Return(storedRules, nil)
+		mockAuth.On("AddPermissionForRole", "auditor", "audit", "read").Return(nil)
+
+		diff, err := uc.SeedPolicy(context.Background(), seed, false)
+		require.NoError(t, err)
+		assert.Equal(t, &dto.PolicyDiff{
+			RolesCreated: []string{"auditor"},
+			Added:        []string{"p, auditor, audit, read"},
+			Removed:      []string{},
+		}, diff)
+		assert.Equal(t, "auditor", repo.roles[len(repo.roles)-1].Name)
+		mockAuth.AssertExpectations(t)
+	})
+
+	t.Run("prune removes permissions and denies, never grants", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+		mockAuth.On("LoadPolicy").Return(nil)
+		mockAuth.On("GetRules").Return(storedRules, nil)
+		mockAuth.On("AddPermissionForRole", "auditor", "audit", "read").Return(nil)
+		mockAuth.On("RemovePermissionForRole", "admin", "users", "delete").Return(nil)
+		mockAuth.On("RemoveDeny", "dave", "users", "delete").Return(nil)
+
+		diff, err := uc.SeedPolicy(context.Background(), seed, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p, admin, users, delete", "p, dave, users, delete, deny"}, diff.Removed)
+		mockAuth.AssertExpectations(t)
+	})
+
+	t.Run("an empty seed changes nothing", func(t *testing.T) {
+		mockAuth := new(MockAuthorizer)
+		uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+		mockAuth.On("LoadPolicy").Return(nil)
+		mockAuth.On("GetRules").Return(storedRules, nil)
+
+		diff, err := uc.SeedPolicy(context.Background(), dto.Policy{}, false)
+		require.NoError(t, err)
+		assert.True(t, diff.Empty())
+	})
+}
+
+func TestImportPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy dto.Policy
+	}{
+		{"empty", dto.Policy{}},
+		{"incomplete permission", dto.Policy{Permissions: []dto.PolicyPermission{{Subject: "admin", Object: "users"}}}},
+		{"incomplete deny", dto.Policy{
+			Permissions: []dto.PolicyPermission{{Subject: "admin", Object: "users", Action: "read"}},
+			Denies:      []dto.PolicyPermission{{Subject: "alice", Action: "read"}},
+		}},
+		{"unknown role", dto.Policy{Grants: []dto.PolicyGrant{{User: "alice", Role: "auditor"}}}},
+		{"owner grant", dto.Policy{Grants: []dto.PolicyGrant{{User: "alice", Role: port.RoleOwner}}}},
+		{"invalid role name", dto.Policy{
+			Roles:  []dto.PolicyRole{{Name: "Bad Name"}},
+			Grants: []dto.PolicyGrant{{User: "alice", Role: "admin"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthorizer)
+			uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+			mockAuth.On("GetRules").Return(storedRules, nil)
+
+			_, err := uc.ImportPolicy(context.Background(), tt.policy, true)
+			appErr, ok := apperr.AsAppError(err)
+			require.True(t, ok, "got %v", err)
+			assert.Equal(t, apperr.CodeBadRequest, appErr.Code)
+		})
+	}
+}
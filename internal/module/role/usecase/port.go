@@ -3,14 +3,26 @@ package usecase
 import (
 	"context"
 
+	"github.com/14mdzk/goscratch/internal/module/role/domain"
 	"github.com/14mdzk/goscratch/internal/module/role/dto"
 )
 
+// Repository stores the roles that exist. Implemented by
+// repository.Repository; a missing role is an apperr not-found error.
+type Repository interface {
+	Create(ctx context.Context, name, description string) (*domain.Role, error)
+	Get(ctx context.Context, name string) (*domain.Role, error)
+	List(ctx context.Context) ([]domain.Role, error)
+	Delete(ctx context.Context, name string) error
+}
+
 // UseCase defines the interface for role and permission business logic operations.
 // Handlers and decorators depend on this interface rather than on the concrete
 // *roleUseCase struct, enabling testability and extensibility.
 type UseCase interface {
-	ListRoles(ctx context.Context) []dto.RoleResponse
+	ListRoles(ctx context.Context) ([]dto.RoleResponse, error)
+	CreateRole(ctx context.Context, req dto.CreateRoleRequest) (*dto.RoleResponse, error)
+	DeleteRole(ctx context.Context, role string) error
 	AssignRole(ctx context.Context, userID, role string) error
 	RemoveRole(ctx context.Context, userID, role string) error
 	GetRoleUsers(ctx context.Context, role string) (*dto.RoleUsersResponse, error)
@@ -19,8 +31,12 @@ type UseCase interface {
 	RemovePermissionFromRole(ctx context.Context, role, object, action string) error
 	GetUserRoles(ctx context.Context, userID string) (*dto.UserRolesResponse, error)
 	GetUserPermissions(ctx context.Context, userID string) (*dto.UserPermissionsResponse, error)
+	GetMyPermissions(ctx context.Context, userID, tenant string) (*dto.MyPermissionsResponse, error)
 	ListAllPermissions(ctx context.Context) (*dto.AllPermissionsResponse, error)
 	AddUserPermission(ctx context.Context, userID, object, action string) error
 	RemoveUserPermission(ctx context.Context, userID, object, action string) error
 	CheckPermission(ctx context.Context, userID, object, action string) (bool, error)
+	ExportPolicy(ctx context.Context) (*dto.Policy, error)
+	ImportPolicy(ctx context.Context, p dto.Policy, dryRun bool) (*dto.PolicyDiff, error)
+	SeedPolicy(ctx context.Context, p dto.Policy, prune bool) (*dto.PolicyDiff, error)
 }
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"sort"
 
 	"github.com/14mdzk/goscratch/internal/module/role/domain"
 	"github.com/14mdzk/goscratch/internal/module/role/dto"
@@ -13,23 +15,51 @@ import (
 // Returned via the UseCase interface; the concrete type is unexported so
 // callers depend on the interface (enables the audit decorator pattern).
 type roleUseCase struct {
+	repo       Repository
 	authorizer port.Authorizer
 }
 
 // compile-time assertion that roleUseCase satisfies UseCase.
 var _ UseCase = (*roleUseCase)(nil)
 
-// NewUseCase creates a new role use case.
-func NewUseCase(authorizer port.Authorizer) UseCase {
+// NewUseCase creates a new role use case. repo holds the roles that exist;
+// authorizer holds their permissions and assignments.
+func NewUseCase(repo Repository, authorizer port.Authorizer) UseCase {
 	return &roleUseCase{
+		repo:       repo,
 		authorizer: authorizer,
 	}
 }
 
+// requireRole returns a 400 unless role exists
+func (uc *roleUseCase) requireRole(ctx context.Context, role string) error {
+	_, err := uc.repo.Get(ctx, role)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, apperr.ErrNotFound):
+		return apperr.BadRequestf("invalid role: %s", role)
+	default:
+		return internalError(err)
+	}
+}
+
+// internalError returns err as is when it is already an application error,
+// and wrapped in apperr.ErrInternal otherwise
+func internalError(err error) error {
+	if _, ok := apperr.AsAppError(err); ok {
+		return err
+	}
+	return apperr.ErrInternal.WithError(err)
+}
+
 // AssignRole assigns a role to a user
 func (uc *roleUseCase) AssignRole(ctx context.Context, userID, role string) error {
-	if !domain.IsValidRole(role) {
-		return apperr.BadRequestf("invalid role: %s", role)
+	if !domain.IsGrantable(role) {
+		return apperr.BadRequestf("role %s applies to owners and cannot be assigned", role)
+	}
+	if err := uc.requireRole(ctx, role); err != nil {
+		return err
 	}
 
 	// Check if user already has this role
@@ -50,8 +80,8 @@ func (uc *roleUseCase) AssignRole(ctx context.Context, userID, role string) erro
 
 // RemoveRole removes a role from a user
 func (uc *roleUseCase) RemoveRole(ctx context.Context, userID, role string) error {
-	if !domain.IsValidRole(role) {
-		return apperr.BadRequestf("invalid role: %s", role)
+	if err := uc.requireRole(ctx, role); err != nil {
+		return err
 	}
 
 	// Check if user has this role
@@ -85,8 +115,8 @@ func (uc *roleUseCase) GetUserRoles(ctx context.Context, userID string) (*dto.Us
 
 // GetRoleUsers returns all users with a specific role
 func (uc *roleUseCase) GetRoleUsers(ctx context.Context, role string) (*dto.RoleUsersResponse, error) {
-	if !domain.IsValidRole(role) {
-		return nil, apperr.BadRequestf("invalid role: %s", role)
+	if err := uc.requireRole(ctx, role); err != nil {
+		return nil, err
 	}
 
 	userIDs, err := uc.authorizer.GetUsersForRole(role)
@@ -100,22 +130,78 @@ func (uc *roleUseCase) GetRoleUsers(ctx context.Context, role string) (*dto.Role
 	}, nil
 }
 
-// ListRoles returns all predefined roles
-func (uc *roleUseCase) ListRoles(ctx context.Context) []dto.RoleResponse {
-	roles := make([]dto.RoleResponse, 0, len(domain.PredefinedRoles))
-	for _, r := range domain.PredefinedRoles {
-		roles = append(roles, dto.RoleResponse{
-			Name:        r.Name,
-			Description: r.Description,
-		})
+// ListRoles returns all roles, oldest first
+func (uc *roleUseCase) ListRoles(ctx context.Context) ([]dto.RoleResponse, error) {
+	roles, err := uc.repo.List(ctx)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	resp := make([]dto.RoleResponse, 0, len(roles))
+	for i := range roles {
+		resp = append(resp, toRoleResponse(&roles[i]))
+	}
+	return resp, nil
+}
+
+// CreateRole creates a role without permissions
+func (uc *roleUseCase) CreateRole(ctx context.Context, req dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	if !domain.ValidRoleName(req.Name) {
+		return nil, apperr.BadRequestf("invalid role name: %s", req.Name)
+	}
+
+	role, err := uc.repo.Create(ctx, req.Name, req.Description)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	resp := toRoleResponse(role)
+	return &resp, nil
+}
+
+// DeleteRole deletes a role and its permissions. Predefined roles cannot be
+// deleted, nor can roles still granted to users: revoke them first.
+func (uc *roleUseCase) DeleteRole(ctx context.Context, role string) error {
+	if domain.IsPredefinedRole(role) {
+		return apperr.BadRequestf("role %s is predefined and cannot be deleted", role)
+	}
+	if _, err := uc.repo.Get(ctx, role); err != nil {
+		return internalError(err)
+	}
+
+	userIDs, err := uc.authorizer.GetUsersForRole(role)
+	if err != nil {
+		return apperr.ErrInternal.WithError(err)
+	}
+	if len(userIDs) > 0 {
+		return apperr.Conflictf("role %s is still granted to %d users", role, len(userIDs))
+	}
+
+	// Permissions go first: should this fail halfway, the role remains and
+	// the delete can be retried.
+	perms, err := uc.authorizer.GetPermissionsForRole(role)
+	if err != nil {
+		return apperr.ErrInternal.WithError(err)
+	}
+	for _, p := range perms {
+		if len(p) < 3 {
+			continue
+		}
+		if err := uc.authorizer.RemovePermissionForRole(role, p[1], p[2]); err != nil {
+			return apperr.ErrInternal.WithError(err)
+		}
 	}
-	return roles
+
+	if err := uc.repo.Delete(ctx, role); err != nil {
+		return internalError(err)
+	}
+	return nil
 }
 
 // AddPermissionToRole adds a permission to a role
 func (uc *roleUseCase) AddPermissionToRole(ctx context.Context, role, object, action string) error {
-	if !domain.IsValidRole(role) {
-		return apperr.BadRequestf("invalid role: %s", role)
+	if err := uc.requireRole(ctx, role); err != nil {
+		return err
 	}
 
 	if err := uc.authorizer.AddPermissionForRole(role, object, action); err != nil {
@@ -127,8 +213,8 @@ func (uc *roleUseCase) AddPermissionToRole(ctx context.Context, role, object, ac
 
 // RemovePermissionFromRole removes a permission from a role
 func (uc *roleUseCase) RemovePermissionFromRole(ctx context.Context, role, object, action string) error {
-	if !domain.IsValidRole(role) {
-		return apperr.BadRequestf("invalid role: %s", role)
+	if err := uc.requireRole(ctx, role); err != nil {
+		return err
 	}
 
 	if err := uc.authorizer.RemovePermissionForRole(role, object, action); err != nil {
@@ -140,8 +226,8 @@ func (uc *roleUseCase) RemovePermissionFromRole(ctx context.Context, role, objec
 
 // GetRolePermissions returns all permissions for a role
 func (uc *roleUseCase) GetRolePermissions(ctx context.Context, role string) ([]dto.PermissionResponse, error) {
-	if !domain.IsValidRole(role) {
-		return nil, apperr.BadRequestf("invalid role: %s", role)
+	if err := uc.requireRole(ctx, role); err != nil {
+		return nil, err
 	}
 
 	perms, err := uc.authorizer.GetPermissionsForRole(role)
@@ -165,6 +251,60 @@ func (uc *roleUseCase) GetUserPermissions(ctx context.Context, userID string) (*
 	}, nil
 }
 
+// GetMyPermissions returns the roles and effective permissions of a user:
+// their direct permissions and those of their roles, including roles they
+// were granted in tenant when it is set. Roles in other tenants and the owner
+// role's permissions, which depend on the object, are not included.
+func (uc *roleUseCase) GetMyPermissions(ctx context.Context, userID, tenant string) (*dto.MyPermissionsResponse, error) {
+	roles, err := uc.authorizer.GetRolesForUser(userID)
+	if err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+	rules, err := uc.authorizer.GetImplicitPermissionsForUser(userID)
+	if err != nil {
+		return nil, apperr.ErrInternal.WithError(err)
+	}
+
+	if tenant != "" {
+		tenantRoles, err := uc.authorizer.GetRolesForUserInDomain(userID, tenant)
+		if err != nil {
+			return nil, apperr.ErrInternal.WithError(err)
+		}
+		for _, role := range tenantRoles {
+			perms, err := uc.authorizer.GetPermissionsForRole(role)
+			if err != nil {
+				return nil, apperr.ErrInternal.WithError(err)
+			}
+			rules = append(rules, perms...)
+		}
+		roles = append(roles, tenantRoles...)
+	}
+
+	perms := make([]string, 0, len(rules))
+	for _, p := range toPermissionResponses(rules) {
+		perms = append(perms, domain.Permission{Object: p.Object, Action: p.Action}.String())
+	}
+
+	return &dto.MyPermissionsResponse{
+		UserID:      userID,
+		Tenant:      tenant,
+		Roles:       sortedUnique(roles),
+		Permissions: sortedUnique(perms),
+	}, nil
+}
+
+// sortedUnique returns ss sorted, without duplicates
+func sortedUnique(ss []string) []string {
+	sort.Strings(ss)
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if len(out) == 0 || s != out[len(out)-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // CheckPermission checks if a user has a specific permission
 func (uc *roleUseCase) CheckPermission(ctx context.Context, userID, object, action string) (bool, error) {
 	allowed, err := uc.authorizer.EnforceWithContext(ctx, userID, object, action)
@@ -174,10 +314,15 @@ func (uc *roleUseCase) CheckPermission(ctx context.Context, userID, object, acti
 	return allowed, nil
 }
 
-// ListAllPermissions returns all permissions grouped by predefined role
+// ListAllPermissions returns all permissions grouped by role
 func (uc *roleUseCase) ListAllPermissions(ctx context.Context) (*dto.AllPermissionsResponse, error) {
-	entries := make([]dto.RolePermissionsEntry, 0, len(domain.PredefinedRoles))
-	for _, r := range domain.PredefinedRoles {
+	roles, err := uc.repo.List(ctx)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	entries := make([]dto.RolePermissionsEntry, 0, len(roles))
+	for _, r := range roles {
 		perms, err := uc.authorizer.GetPermissionsForRole(r.Name)
 		if err != nil {
 			return nil, apperr.ErrInternal.WithError(err)
@@ -218,6 +363,16 @@ func (uc *roleUseCase) RemoveUserPermission(ctx context.Context, userID, object,
 	return nil
 }
 
+// toRoleResponse converts a domain role to its response DTO
+func toRoleResponse(r *domain.Role) dto.RoleResponse {
+	return dto.RoleResponse{
+		Name:        r.Name,
+		Description: r.Description,
+		Predefined:  domain.IsPredefinedRole(r.Name),
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
 // toPermissionResponses converts raw permission slices to PermissionResponse DTOs
 // Each permission slice is expected to be [subject, object, action]
 func toPermissionResponses(perms [][]string) []dto.PermissionResponse {
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/14mdzk/goscratch/internal/module/role/domain"
+	"github.com/14mdzk/goscratch/internal/module/role/dto"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockAuthorizer is a mock implementation of port.Authorizer
@@ -46,6 +50,56 @@ func (m *MockAuthorizer) GetUsersForRole(role string) ([]string, error) {
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockAuthorizer) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	args := m.Called(sub, dom, obj, act)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	args := m.Called(sub, owner, dom, obj, act)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) EnforceBatch(ctx context.Context, requests []port.EnforceRequest) ([]bool, error) {
+	args := m.Called(ctx, requests)
+	return args.Get(0).([]bool), args.Error(1)
+}
+
+func (m *MockAuthorizer) AddDeny(sub, obj, act string) error {
+	args := m.Called(sub, obj, act)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) RemoveDeny(sub, obj, act string) error {
+	args := m.Called(sub, obj, act)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) GetRules() ([]port.PolicyRule, error) {
+	args := m.Called()
+	return args.Get(0).([]port.PolicyRule), args.Error(1)
+}
+
+func (m *MockAuthorizer) AddRoleForUserInDomain(userID, role, domain string) error {
+	args := m.Called(userID, role, domain)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) RemoveRoleForUserInDomain(userID, role, domain string) error {
+	args := m.Called(userID, role, domain)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) GetRolesForUserInDomain(userID, domain string) ([]string, error) {
+	args := m.Called(userID, domain)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAuthorizer) GetUsersForRoleInDomain(role, domain string) ([]string, error) {
+	args := m.Called(role, domain)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockAuthorizer) HasRoleForUser(userID, role string) (bool, error) {
 	args := m.Called(userID, role)
 	return args.Bool(0), args.Error(1)
@@ -108,9 +162,67 @@ func (m *MockAuthorizer) Close() error {
 // Ensure MockAuthorizer implements port.Authorizer
 var _ port.Authorizer = (*MockAuthorizer)(nil)
 
+// fakeRoleRepo is an in-memory Repository holding the predefined roles
+type fakeRoleRepo struct {
+	roles []domain.Role
+	err   error
+}
+
+var _ Repository = (*fakeRoleRepo)(nil)
+
+func newFakeRoleRepo() *fakeRoleRepo {
+	return &fakeRoleRepo{roles: append([]domain.Role(nil), domain.PredefinedRoles...)}
+}
+
+func (r *fakeRoleRepo) Create(_ context.Context, name, description string) (*domain.Role, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, role := range r.roles {
+		if role.Name == name {
+			return nil, apperr.Conflictf("role %s already exists", name)
+		}
+	}
+	role := domain.Role{Name: name, Description: description, CreatedAt: time.Now()}
+	r.roles = append(r.roles, role)
+	return &role, nil
+}
+
+func (r *fakeRoleRepo) Get(_ context.Context, name string) (*domain.Role, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, role := range r.roles {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+	return nil, apperr.NotFoundf("role %s not found", name)
+}
+
+func (r *fakeRoleRepo) List(_ context.Context) ([]domain.Role, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.roles, nil
+}
+
+func (r *fakeRoleRepo) Delete(_ context.Context, name string) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i, role := range r.roles {
+		if role.Name == name {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return nil
+		}
+	}
+	return apperr.NotFoundf("role %s not found", name)
+}
+
 func TestAssignRole_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("HasRoleForUser", "user-123", "admin").Return(false, nil)
@@ -123,7 +235,7 @@ func TestAssignRole_Success(t *testing.T) {
 
 func TestAssignRole_InvalidRole(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	err := uc.AssignRole(ctx, "user-123", "invalid_role")
@@ -134,9 +246,21 @@ func TestAssignRole_InvalidRole(t *testing.T) {
 	assert.Equal(t, apperr.CodeBadRequest, appErr.Code)
 }
 
+func TestAssignRole_OwnerRole(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+
+	err := uc.AssignRole(context.Background(), "user-123", port.RoleOwner)
+
+	appErr, ok := apperr.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, apperr.CodeBadRequest, appErr.Code)
+	mockAuth.AssertNotCalled(t, "AddRoleForUser", mock.Anything, mock.Anything)
+}
+
 func TestAssignRole_AlreadyHasRole(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("HasRoleForUser", "user-123", "admin").Return(true, nil)
@@ -151,7 +275,7 @@ func TestAssignRole_AlreadyHasRole(t *testing.T) {
 
 func TestRemoveRole_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("HasRoleForUser", "user-123", "editor").Return(true, nil)
@@ -164,7 +288,7 @@ func TestRemoveRole_Success(t *testing.T) {
 
 func TestRemoveRole_InvalidRole(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	err := uc.RemoveRole(ctx, "user-123", "nonexistent")
@@ -177,7 +301,7 @@ func TestRemoveRole_InvalidRole(t *testing.T) {
 
 func TestRemoveRole_UserDoesNotHaveRole(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("HasRoleForUser", "user-123", "viewer").Return(false, nil)
@@ -192,7 +316,7 @@ func TestRemoveRole_UserDoesNotHaveRole(t *testing.T) {
 
 func TestGetUserRoles_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetRolesForUser", "user-123").Return([]string{"admin", "editor"}, nil)
@@ -205,7 +329,7 @@ func TestGetUserRoles_Success(t *testing.T) {
 
 func TestGetUserRoles_Error(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetRolesForUser", "user-123").Return([]string{}, errors.New("db error"))
@@ -216,7 +340,7 @@ func TestGetUserRoles_Error(t *testing.T) {
 
 func TestGetRoleUsers_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetUsersForRole", "admin").Return([]string{"user-1", "user-2"}, nil)
@@ -229,7 +353,7 @@ func TestGetRoleUsers_Success(t *testing.T) {
 
 func TestGetRoleUsers_InvalidRole(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	_, err := uc.GetRoleUsers(ctx, "fake_role")
@@ -242,20 +366,22 @@ func TestGetRoleUsers_InvalidRole(t *testing.T) {
 
 func TestListRoles(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
-	roles := uc.ListRoles(ctx)
-	assert.Len(t, roles, 4)
+	roles, err := uc.ListRoles(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, roles, 5)
 	assert.Equal(t, "superadmin", roles[0].Name)
 	assert.Equal(t, "admin", roles[1].Name)
 	assert.Equal(t, "editor", roles[2].Name)
 	assert.Equal(t, "viewer", roles[3].Name)
+	assert.Equal(t, "owner", roles[4].Name)
 }
 
 func TestAddPermissionToRole_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("AddPermissionForRole", "admin", "users", "read").Return(nil)
@@ -267,7 +393,7 @@ func TestAddPermissionToRole_Success(t *testing.T) {
 
 func TestAddPermissionToRole_InvalidRole(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	err := uc.AddPermissionToRole(ctx, "bogus", "users", "read")
@@ -280,7 +406,7 @@ func TestAddPermissionToRole_InvalidRole(t *testing.T) {
 
 func TestRemovePermissionFromRole_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("RemovePermissionForRole", "editor", "posts", "write").Return(nil)
@@ -292,7 +418,7 @@ func TestRemovePermissionFromRole_Success(t *testing.T) {
 
 func TestGetRolePermissions_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetPermissionsForRole", "admin").Return([][]string{
@@ -311,7 +437,7 @@ func TestGetRolePermissions_Success(t *testing.T) {
 
 func TestGetUserPermissions_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetImplicitPermissionsForUser", "user-123").Return([][]string{
@@ -329,7 +455,7 @@ func TestGetUserPermissions_Success(t *testing.T) {
 
 func TestCheckPermission_Allowed(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("EnforceWithContext", ctx, "user-123", "users", "read").Return(true, nil)
@@ -341,7 +467,7 @@ func TestCheckPermission_Allowed(t *testing.T) {
 
 func TestCheckPermission_Denied(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("EnforceWithContext", ctx, "user-123", "users", "delete").Return(false, nil)
@@ -353,7 +479,7 @@ func TestCheckPermission_Denied(t *testing.T) {
 
 func TestCheckPermission_Error(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("EnforceWithContext", ctx, "user-123", "users", "read").Return(false, errors.New("enforcer error"))
@@ -362,9 +488,37 @@ func TestCheckPermission_Error(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetMyPermissions_NoTenant(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+
+	mockAuth.On("GetRolesForUser", "user-123").Return([]string{}, nil)
+	mockAuth.On("GetImplicitPermissionsForUser", "user-123").Return([][]string{}, nil)
+
+	result, err := uc.GetMyPermissions(context.Background(), "user-123", "")
+	require.NoError(t, err)
+	assert.Empty(t, result.Tenant)
+	assert.NotNil(t, result.Roles, "an empty list, not null")
+	assert.NotNil(t, result.Permissions)
+	mockAuth.AssertNotCalled(t, "GetRolesForUserInDomain", mock.Anything, mock.Anything)
+}
+
+func TestGetMyPermissions_Error(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
+
+	mockAuth.On("GetRolesForUser", "user-123").Return([]string{}, nil)
+	mockAuth.On("GetImplicitPermissionsForUser", "user-123").Return([][]string{}, errors.New("db error"))
+
+	_, err := uc.GetMyPermissions(context.Background(), "user-123", "")
+	appErr, ok := apperr.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, apperr.CodeInternalError, appErr.Code)
+}
+
 func TestListAllPermissions_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetPermissionsForRole", "superadmin").Return([][]string{
@@ -378,11 +532,14 @@ func TestListAllPermissions_Success(t *testing.T) {
 		{"editor", "users", "read"},
 	}, nil)
 	mockAuth.On("GetPermissionsForRole", "viewer").Return([][]string{}, nil)
+	mockAuth.On("GetPermissionsForRole", "owner").Return([][]string{
+		{"owner", "users", "read"},
+	}, nil)
 
 	result, err := uc.ListAllPermissions(ctx)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
-	assert.Len(t, result.Roles, 4)
+	assert.Len(t, result.Roles, 5)
 	assert.Equal(t, "superadmin", result.Roles[0].Role)
 	assert.Len(t, result.Roles[0].Permissions, 1)
 	assert.Equal(t, "admin", result.Roles[1].Role)
@@ -394,7 +551,7 @@ func TestListAllPermissions_Success(t *testing.T) {
 
 func TestListAllPermissions_Error(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("GetPermissionsForRole", "superadmin").Return([][]string{}, errors.New("db error"))
@@ -410,7 +567,7 @@ func TestListAllPermissions_Error(t *testing.T) {
 
 func TestAddUserPermission_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("AddPermissionForUser", "user-123", "posts", "write").Return(nil)
@@ -422,7 +579,7 @@ func TestAddUserPermission_Success(t *testing.T) {
 
 func TestAddUserPermission_Error(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("AddPermissionForUser", "user-123", "posts", "write").Return(errors.New("adapter error"))
@@ -438,7 +595,7 @@ func TestAddUserPermission_Error(t *testing.T) {
 
 func TestRemoveUserPermission_Success(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("RemovePermissionForUser", "user-123", "posts", "write").Return(nil)
@@ -450,7 +607,7 @@ func TestRemoveUserPermission_Success(t *testing.T) {
 
 func TestRemoveUserPermission_Error(t *testing.T) {
 	mockAuth := new(MockAuthorizer)
-	uc := NewUseCase(mockAuth)
+	uc := NewUseCase(newFakeRoleRepo(), mockAuth)
 	ctx := context.Background()
 
 	mockAuth.On("RemovePermissionForUser", "user-123", "posts", "write").Return(errors.New("adapter error"))
@@ -477,3 +634,121 @@ func TestToPermissionResponses_ShortSlice(t *testing.T) {
 	})
 	assert.Empty(t, result)
 }
+
+func TestAssignRole_CustomRole(t *testing.T) {
+	mockAuth := new(MockAuthorizer)
+	repo := newFakeRoleRepo()
+	_, _ = repo.Create(context.Background(), "auditor", "")
+	uc := NewUseCase(repo, mockAuth)
+
+	mockAuth.On("HasRoleForUser", "user-123", "auditor").Return(false, nil)
+	mockAuth.On("AddRoleForUser", "user-123", "auditor").Return(nil)
+
+	assert.NoError(t, uc.AssignRole(context.Background(), "user-123", "auditor"))
+	mockAuth.AssertExpectations(t)
+}
+
+func TestAssignRole_RepositoryError(t *testing.T) {
+	repo := newFakeRoleRepo()
+	repo.err = errors.New("db down")
+	uc := NewUseCase(repo, new(MockAuthorizer))
+
+	err := uc.AssignRole(context.Background(), "user-123", "editor")
+
+	appErr, ok := apperr.AsAppError(err)
+	assert.True(t, ok)
+	assert.Equal(t, apperr.CodeInternalError, appErr.Code)
+}
+
+func TestCreateRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		repo := newFakeRoleRepo()
+		uc := NewUseCase(repo, new(MockAuthorizer))
+
+		resp, err := uc.CreateRole(ctx, dto.CreateRoleRequest{Name: "auditor", Description: "Reads audit logs"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "auditor", resp.Name)
+		assert.Equal(t, "Reads audit logs", resp.Description)
+		assert.False(t, resp.Predefined)
+		assert.Len(t, repo.roles, len(domain.PredefinedRoles)+1)
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		uc := NewUseCase(newFakeRoleRepo(), new(MockAuthorizer))
+		for _, name := range []string{"Auditor", "1st", "has space", "0191f2a4-7c1e-7d55-8f3a-6a5b4c3d2e1f"} {
+			_, err := uc.CreateRole(ctx, dto.CreateRoleRequest{Name: name})
+			assert.ErrorIs(t, err, apperr.ErrBadRequest, name)
+		}
+	})
+
+	t.Run("taken name", func(t *testing.T) {
+		uc := NewUseCase(newFakeRoleRepo(), new(MockAuthorizer))
+
+		_, err := uc.CreateRole(ctx, dto.CreateRoleRequest{Name: "editor"})
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+	})
+}
+
+func TestDeleteRole(t *testing.T) {
+	ctx := context.Background()
+	withAuditor := func() *fakeRoleRepo {
+		repo := newFakeRoleRepo()
+		_, _ = repo.Create(ctx, "auditor", "")
+		return repo
+	}
+
+	t.Run("removes permissions then the role", func(t *testing.T) {
+		repo := withAuditor()
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("GetUsersForRole", "auditor").Return([]string{}, nil)
+		mockAuth.On("GetPermissionsForRole", "auditor").Return([][]string{
+			{"auditor", "audit", "read"},
+			{"auditor", "users", "read"},
+		}, nil)
+		mockAuth.On("RemovePermissionForRole", "auditor", "audit", "read").Return(nil)
+		mockAuth.On("RemovePermissionForRole", "auditor", "users", "read").Return(nil)
+
+		assert.NoError(t, NewUseCase(repo, mockAuth).DeleteRole(ctx, "auditor"))
+		assert.Len(t, repo.roles, len(domain.PredefinedRoles))
+		mockAuth.AssertExpectations(t)
+	})
+
+	t.Run("predefined role", func(t *testing.T) {
+		err := NewUseCase(newFakeRoleRepo(), new(MockAuthorizer)).DeleteRole(ctx, "viewer")
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		err := NewUseCase(newFakeRoleRepo(), new(MockAuthorizer)).DeleteRole(ctx, "auditor")
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("role still granted", func(t *testing.T) {
+		repo := withAuditor()
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("GetUsersForRole", "auditor").Return([]string{"user-1"}, nil)
+
+		err := NewUseCase(repo, mockAuth).DeleteRole(ctx, "auditor")
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		assert.Len(t, repo.roles, len(domain.PredefinedRoles)+1)
+		mockAuth.AssertNotCalled(t, "GetPermissionsForRole", mock.Anything)
+	})
+
+	t.Run("permission removal fails", func(t *testing.T) {
+		repo := withAuditor()
+		mockAuth := new(MockAuthorizer)
+		mockAuth.On("GetUsersForRole", "auditor").Return([]string{}, nil)
+		mockAuth.On("GetPermissionsForRole", "auditor").Return([][]string{{"auditor", "audit", "read"}}, nil)
+		mockAuth.On("RemovePermissionForRole", "auditor", "audit", "read").Return(errors.New("adapter error"))
+
+		err := NewUseCase(repo, mockAuth).DeleteRole(ctx, "auditor")
+
+		assert.ErrorIs(t, err, apperr.ErrInternal)
+		assert.Len(t, repo.roles, len(domain.PredefinedRoles)+1, "the role must remain so the delete can be retried")
+	})
+}
@@ -2,12 +2,17 @@ package handler
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/14mdzk/goscratch/internal/module/sse/ticket"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/14mdzk/goscratch/pkg/response"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,14 +20,79 @@ import (
 
 // Handler handles SSE HTTP requests
 type Handler struct {
-	broker port.SSEBroker
+	broker      port.SSEBroker
+	tickets     *ticket.Store
+	pollTimeout time.Duration
+	access      TopicAccess
 }
 
-// NewHandler creates a new SSE handler
-func NewHandler(broker port.SSEBroker) *Handler {
-	return &Handler{broker: broker}
+// TopicAccess restricts topics to the users holding a permission
+type TopicAccess struct {
+	Authorizer port.Authorizer
+	// Permissions maps a topic to the "object:action" permission needed to
+	// subscribe to it. Topics not listed are open to every user; listed
+	// ones are closed to all when Authorizer is nil.
+	Permissions map[string]string
 }
 
+// NewHandler creates a new SSE handler. tickets backs IssueTicket and
+// TicketAuth (default: an in-memory store); pollTimeout is how long Poll
+// waits for an event (default: 25s); access guards Subscribe, Poll and
+// IssueTicket.
+func NewHandler(broker port.SSEBroker, tickets *ticket.Store, pollTimeout time.Duration, access TopicAccess) *Handler {
+	if tickets == nil {
+		tickets = ticket.NewStore(nil, 0)
+	}
+	if pollTimeout <= 0 {
+		pollTimeout = 25 * time.Second
+	}
+	return &Handler{broker: broker, tickets: tickets, pollTimeout: pollTimeout, access: access}
+}
+
+const (
+	// maxPollEvents caps the events in one poll response.
+	maxPollEvents = 100
+	// maxResumeEvents caps the events replayed to a reconnecting stream.
+	maxResumeEvents = 1000
+)
+
+// PollEvent is an event in a poll response. Data is the event's text, as
+// an SSE stream would carry it.
+type PollEvent struct {
+	ID    string `json:"id,omitempty"`
+	Event string `json:"event,omitempty"`
+	Data  string `json:"data"`
+	Retry int    `json:"retry,omitempty"`
+}
+
+// PollResponse is the result of a poll
+type PollResponse struct {
+	Events []PollEvent `json:"events"`
+	// Cursor is passed as ?cursor= on the next poll.
+	Cursor string `json:"cursor"`
+	// Missed reports that events were dropped from the replay buffer before
+	// this client polled for them.
+	Missed bool `json:"missed"`
+	// More asks the client to poll again straight away.
+	More bool `json:"more"`
+}
+
+// TicketRequest is the body of IssueTicket. Topics are the ones the stream
+// opened with the ticket subscribes to.
+type TicketRequest struct {
+	Topics []string `json:"topics"`
+}
+
+// TicketResponse is a freshly issued ticket
+type TicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ticketTopicsKey holds the topics of the ticket a request was
+// authenticated with.
+const ticketTopicsKey = "sse_ticket_topics"
+
 // BroadcastRequest represents a broadcast request body
 type BroadcastRequest struct {
 	Event string `json:"event"`
@@ -37,15 +107,15 @@ func (h *Handler) Subscribe(c *fiber.Ctx) error {
 		return response.Unauthorized(c, "Authentication required")
 	}
 
-	// Parse optional topics
-	var topics []string
-	if topicsParam := c.Query("topics"); topicsParam != "" {
-		for _, t := range strings.Split(topicsParam, ",") {
-			t = strings.TrimSpace(t)
-			if t != "" {
-				topics = append(topics, t)
-			}
-		}
+	// A ticket fixes the topics; ?topics= cannot widen them.
+	topics, byTicket := c.Locals(ticketTopicsKey).([]string)
+	if !byTicket {
+		topics = parseTopics(c.Query("topics"))
+	}
+	// Checked again for a ticket: the permission may have been revoked
+	// since it was issued.
+	if err := h.checkTopics(c, topics); err != nil {
+		return response.Fail(c, err)
 	}
 
 	// Generate per-connection UUID — keying by userID would let a second tab
@@ -56,27 +126,49 @@ func (h *Handler) Subscribe(c *fiber.Ctx) error {
 	// Subscribe to broker
 	ch := h.broker.Subscribe(connID, topics...)
 
+	// A reconnecting browser sends the last event ID it saw; replay what it
+	// missed. Subscribing first means nothing falls between the replay and
+	// the live events, though an event may appear in both. A client that
+	// reopens the stream with a new ticket cannot set the header, so it may
+	// pass ?last_event_id= instead.
+	var missed []port.Event
+	if replayer, ok := h.broker.(port.SSEReplayer); ok {
+		lastID := c.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = c.Query("last_event_id")
+		}
+		if lastID != "" {
+			if r, err := replayer.Replay(lastID, topics, maxResumeEvents); err == nil {
+				missed = r.Events
+			}
+		}
+	}
+
 	// Set SSE headers
 	c.Set("Content-Type", "text/event-stream")
 	c.Set("Cache-Control", "no-cache")
 	c.Set("Connection", "keep-alive")
 
 	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		replayed := make(map[string]bool, len(missed))
+		for _, event := range missed {
+			replayed[event.ID] = true
+			writeEvent(w, event)
+		}
+		if err := w.Flush(); err != nil {
+			h.broker.Unsubscribe(connID)
+			return
+		}
+
 		for event := range ch {
-			// Write SSE formatted event
-			if event.ID != "" {
-				fmt.Fprintf(w, "id: %s\n", event.ID)
-			}
-			if event.Event != "" {
-				fmt.Fprintf(w, "event: %s\n", event.Event)
+			// Live events already replayed come first; skip them.
+			if len(replayed) > 0 {
+				if replayed[event.ID] {
+					continue
+				}
+				replayed = nil
 			}
-			if len(event.Data) > 0 {
-				fmt.Fprintf(w, "data: %s\n", string(event.Data))
-			}
-			if event.Retry > 0 {
-				fmt.Fprintf(w, "retry: %d\n", event.Retry)
-			}
-			fmt.Fprint(w, "\n")
+			writeEvent(w, event)
 
 			// Flush to send the event immediately
 			if err := w.Flush(); err != nil {
@@ -92,6 +184,137 @@ func (h *Handler) Subscribe(c *fiber.Ctx) error {
 	return nil
 }
 
+// IssueTicket issues a one-time ticket that opens the SSE stream as the
+// caller, for clients such as EventSource that cannot send the
+// Authorization header. The ticket goes in the stream URL in place of the
+// JWT; it expires quickly and is accepted once, so a logged URL is useless.
+func (h *Handler) IssueTicket(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	var req TicketRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.Fail(c, apperr.BadRequestf("Invalid request body"))
+		}
+	}
+	topics := make([]string, 0, len(req.Topics))
+	for _, t := range req.Topics {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, t)
+		}
+	}
+	if err := h.checkTopics(c, topics); err != nil {
+		return response.Fail(c, err)
+	}
+
+	token, expiresAt, err := h.tickets.Issue(c.UserContext(), ticket.Ticket{UserID: userID, Topics: topics})
+	if err != nil {
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return response.Created(c, TicketResponse{Ticket: token, ExpiresAt: expiresAt})
+}
+
+// TicketAuth authenticates requests carrying ?ticket= by redeeming the
+// ticket, and hands every other request to auth. Mount it only in front of
+// Subscribe: a ticket grants the stream and nothing else.
+func (h *Handler) TicketAuth(auth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("ticket")
+		if token == "" {
+			return auth(c)
+		}
+
+		t, err := h.tickets.Redeem(c.UserContext(), token)
+		if errors.Is(err, ticket.ErrInvalid) {
+			return response.Unauthorized(c, "Invalid or expired ticket")
+		}
+		if err != nil {
+			return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+		}
+
+		topics := t.Topics
+		if topics == nil {
+			topics = []string{}
+		}
+		c.Locals("user_id", t.UserID)
+		c.Locals(ticketTopicsKey, topics)
+		ctx := context.WithValue(c.UserContext(), logger.UserIDKey, t.UserID)
+		ctx = context.WithValue(ctx, logger.IPAddressKey, c.IP())
+		ctx = context.WithValue(ctx, logger.UserAgentKey, c.Get("User-Agent"))
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// Poll returns the events broadcast since ?cursor=, for clients behind
+// proxies that break streaming responses. With no new events it waits up to
+// the poll timeout for one. A request without a cursor returns the current
+// cursor straight away.
+func (h *Handler) Poll(c *fiber.Ctx) error {
+	if middleware.GetUserID(c) == "" {
+		return response.Unauthorized(c, "Authentication required")
+	}
+	replayer, ok := h.broker.(port.SSEReplayer)
+	if !ok {
+		return response.Fail(c, errPollUnavailable)
+	}
+
+	cursor := c.Query("cursor")
+	topics := parseTopics(c.Query("topics"))
+	if err := h.checkTopics(c, topics); err != nil {
+		return response.Fail(c, err)
+	}
+	r, err := replayer.Replay(cursor, topics, maxPollEvents)
+	if cursor != "" {
+		deadline := time.NewTimer(h.pollTimeout)
+		defer deadline.Stop()
+		ctx := c.UserContext()
+		for err == nil && len(r.Events) == 0 && !r.Missed {
+			select {
+			case <-r.Wait:
+			case <-deadline.C:
+				return h.pollResponse(c, r)
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			}
+			// Resume from r.Cursor so events filtered out by topic are not
+			// examined again.
+			r, err = replayer.Replay(r.Cursor, topics, maxPollEvents)
+		}
+	}
+	switch {
+	case errors.Is(err, port.ErrInvalidCursor):
+		return response.Fail(c, apperr.ErrBadRequest.WithMessage("Invalid cursor"))
+	case errors.Is(err, port.ErrReplayDisabled):
+		return response.Fail(c, errPollUnavailable)
+	case err != nil:
+		return response.Fail(c, apperr.ErrServiceUnavailable.WithError(err))
+	}
+	return h.pollResponse(c, r)
+}
+
+// errPollUnavailable is returned when the broker keeps no events (SSE
+// disabled or sse.replay_size 0).
+var errPollUnavailable = apperr.ErrServiceUnavailable.WithMessage("Event polling is not available")
+
+func (h *Handler) pollResponse(c *fiber.Ctx, r port.ReplayResult) error {
+	out := PollResponse{
+		Events: make([]PollEvent, 0, len(r.Events)),
+		Cursor: r.Cursor,
+		Missed: r.Missed,
+		More:   r.More,
+	}
+	for _, e := range r.Events {
+		out.Events = append(out.Events, PollEvent{ID: e.ID, Event: e.Event, Data: string(e.Data), Retry: e.Retry})
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return response.Success(c, out)
+}
+
 // Broadcast handles broadcasting an event to all clients or a specific topic
 func (h *Handler) Broadcast(c *fiber.Ctx) error {
 	var req BroadcastRequest
@@ -121,3 +344,54 @@ func (h *Handler) ClientCount(c *fiber.Ctx) error {
 		"count": count,
 	})
 }
+
+// checkTopics returns an error for the first of topics the user may not
+// subscribe to
+func (h *Handler) checkTopics(c *fiber.Ctx, topics []string) error {
+	for _, topic := range topics {
+		perm, ok := h.access.Permissions[topic]
+		if !ok {
+			continue
+		}
+		allowed := false
+		if h.access.Authorizer != nil {
+			obj, act, _ := strings.Cut(perm, ":")
+			var err error
+			if allowed, err = middleware.HasPermission(c, h.access.Authorizer, obj, act); err != nil {
+				return apperr.Internalf("authorization check failed")
+			}
+		}
+		if !allowed {
+			return apperr.ErrForbidden.WithMessage(fmt.Sprintf("Topic %s requires the %s permission", topic, perm))
+		}
+	}
+	return nil
+}
+
+// parseTopics splits the comma-separated ?topics= parameter.
+func parseTopics(param string) []string {
+	var topics []string
+	for _, t := range strings.Split(param, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, t)
+		}
+	}
+	return topics
+}
+
+// writeEvent writes event in the SSE wire format.
+func writeEvent(w *bufio.Writer, event port.Event) {
+	if event.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", event.ID)
+	}
+	if event.Event != "" {
+		fmt.Fprintf(w, "event: %s\n", event.Event)
+	}
+	if len(event.Data) > 0 {
+		fmt.Fprintf(w, "data: %s\n", string(event.Data))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n", event.Retry)
+	}
+	fmt.Fprint(w, "\n")
+}
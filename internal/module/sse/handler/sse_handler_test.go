@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http/httptest"
@@ -8,7 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/adapter/casbin"
 	"github.com/14mdzk/goscratch/internal/adapter/sse"
+	"github.com/14mdzk/goscratch/internal/module/sse/ticket"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -17,7 +20,7 @@ import (
 
 func setupTestApp(broker port.SSEBroker) (*fiber.App, *Handler) {
 	app := fiber.New()
-	h := NewHandler(broker)
+	h := NewHandler(broker, nil, 0, TopicAccess{})
 	return app, h
 }
 
@@ -141,7 +144,7 @@ func TestSubscribe_StreamHeaders(t *testing.T) {
 	broker := sse.NewBroker(10)
 
 	app := fiber.New()
-	h := NewHandler(broker)
+	h := NewHandler(broker, nil, 0, TopicAccess{})
 
 	app.Get("/sse/subscribe", func(c *fiber.Ctx) error {
 		c.Locals("user_id", "test-user-headers")
@@ -170,3 +173,264 @@ func TestSubscribe_StreamHeaders(t *testing.T) {
 	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
 	assert.Equal(t, "keep-alive", resp.Header.Get("Connection"))
 }
+
+func newPollApp(broker port.SSEBroker, timeout time.Duration) *fiber.App {
+	app := fiber.New()
+	h := NewHandler(broker, nil, timeout, TopicAccess{})
+	app.Get("/events/poll", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "poller")
+		return h.Poll(c)
+	})
+	return app
+}
+
+func poll(t *testing.T, app *fiber.App, query string) (int, PollResponse) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", "/events/poll"+query, nil), 5000)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var result struct {
+		Data PollResponse `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result.Data
+}
+
+func TestPoll(t *testing.T) {
+	broker := sse.NewBrokerWithOptions(sse.Options{ReplaySize: 10})
+	defer broker.Close()
+	app := newPollApp(broker, 50*time.Millisecond)
+
+	status, start := poll(t, app, "")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Empty(t, start.Events)
+	require.NotEmpty(t, start.Cursor)
+
+	broker.BroadcastToTopic("orders", port.NewEvent("order.created", []byte(`{"id":1}`)))
+	broker.BroadcastToTopic("billing", port.NewEvent("invoice.paid", nil))
+
+	status, got := poll(t, app, "?topics=orders&cursor="+start.Cursor)
+	require.Equal(t, fiber.StatusOK, status)
+	require.Len(t, got.Events, 1)
+	assert.Equal(t, "order.created", got.Events[0].Event)
+	assert.Equal(t, `{"id":1}`, got.Events[0].Data)
+
+	t.Run("times out with the same cursor", func(t *testing.T) {
+		began := time.Now()
+		status, empty := poll(t, app, "?topics=orders&cursor="+got.Cursor)
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Empty(t, empty.Events)
+		assert.Equal(t, got.Cursor, empty.Cursor)
+		assert.GreaterOrEqual(t, time.Since(began), 50*time.Millisecond)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		status, _ := poll(t, app, "?cursor=nope")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+}
+
+func TestPoll_WaitsForEvent(t *testing.T) {
+	broker := sse.NewBrokerWithOptions(sse.Options{ReplaySize: 10})
+	defer broker.Close()
+	app := newPollApp(broker, 5*time.Second)
+	_, start := poll(t, app, "")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		broker.BroadcastToTopic("billing", port.NewEvent("skipped", nil))
+		time.Sleep(20 * time.Millisecond)
+		broker.Broadcast(port.NewEvent("announcement", []byte("hi")))
+	}()
+
+	status, got := poll(t, app, "?topics=orders&cursor="+start.Cursor)
+	require.Equal(t, fiber.StatusOK, status)
+	require.Len(t, got.Events, 1, "events for other topics do not end the poll")
+	assert.Equal(t, "announcement", got.Events[0].Event)
+}
+
+func TestPoll_Unavailable(t *testing.T) {
+	for name, broker := range map[string]port.SSEBroker{
+		"no-op broker": sse.NewNoOpBroker(),
+		"replay off":   sse.NewBroker(10),
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, _ := poll(t, newPollApp(broker, time.Millisecond), "")
+			assert.Equal(t, fiber.StatusServiceUnavailable, status)
+		})
+	}
+}
+
+func TestSubscribe_ResumesFromLastEventID(t *testing.T) {
+	broker := sse.NewBrokerWithOptions(sse.Options{ReplaySize: 10})
+	broker.Broadcast(port.NewEvent("first", []byte("1")))
+	broker.Broadcast(port.NewEvent("second", []byte("2")))
+	r, err := broker.Replay("0.0", nil, 10)
+	require.NoError(t, err)
+	firstID := r.Events[0].ID
+
+	app := fiber.New()
+	h := NewHandler(broker, nil, 0, TopicAccess{})
+	app.Get("/sse/subscribe", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "resumer")
+		return h.Subscribe(c)
+	})
+	go func() {
+		for i := 0; i < 50 && broker.ClientCount() == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = broker.Close()
+	}()
+
+	req := httptest.NewRequest("GET", "/sse/subscribe", nil)
+	req.Header.Set("Last-Event-ID", firstID)
+	resp, err := app.Test(req, 5000)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "event: second\ndata: 2\n")
+	assert.NotContains(t, string(body), "event: first")
+}
+
+func TestIssueTicket(t *testing.T) {
+	app := fiber.New()
+	h := NewHandler(sse.NewNoOpBroker(), nil, 0, TopicAccess{})
+	app.Post("/events/ticket", func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.Locals("user_id", user)
+		}
+		return h.IssueTicket(c)
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("POST", "/events/ticket", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("issues a ticket", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/events/ticket", strings.NewReader(`{"topics":["orders"," "]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", "user-1")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+
+		var result struct {
+			Data TicketResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.NotEmpty(t, result.Data.Ticket)
+		assert.True(t, result.Data.ExpiresAt.After(time.Now()))
+
+		got, err := h.tickets.Redeem(req.Context(), result.Data.Ticket)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", got.UserID)
+		assert.Equal(t, []string{"orders"}, got.Topics)
+	})
+}
+
+func TestTicketAuth(t *testing.T) {
+	broker := sse.NewBrokerWithOptions(sse.Options{ReplaySize: 10})
+	h := NewHandler(broker, nil, 0, TopicAccess{})
+	jwtAuth := func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusUnauthorized).SendString("jwt required")
+	}
+	app := fiber.New()
+	app.Get("/sse/subscribe", h.TicketAuth(jwtAuth), h.Subscribe)
+
+	token, _, err := h.tickets.Issue(context.Background(), ticket.Ticket{UserID: "user-1", Topics: []string{"orders"}})
+	require.NoError(t, err)
+
+	go func() {
+		for i := 0; i < 50 && broker.ClientCount() == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		broker.BroadcastToTopic("billing", port.NewEvent("invoice.paid", nil))
+		broker.BroadcastToTopic("orders", port.NewEvent("order.created", []byte("1")))
+		_ = broker.Close()
+	}()
+
+	// ?topics= cannot widen the topics the ticket was issued for.
+	resp, err := app.Test(httptest.NewRequest("GET", "/sse/subscribe?topics=billing&ticket="+token, nil), 5000)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "event: order.created")
+	assert.NotContains(t, string(body), "invoice.paid")
+
+	t.Run("ticket is accepted once", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/sse/subscribe?ticket="+token, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "Invalid or expired ticket")
+	})
+
+	t.Run("without a ticket the JWT is required", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/sse/subscribe", nil))
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "jwt required", string(body))
+	})
+}
+
+// auditReader allows audit:read to the user "admin" only
+type auditReader struct{ *casbin.NoOpAdapter }
+
+func (auditReader) Enforce(sub, obj, act string) (bool, error) {
+	return sub == "admin" && obj == "audit" && act == "read", nil
+}
+
+func TestTopicAccess(t *testing.T) {
+	broker := sse.NewBrokerWithOptions(sse.Options{ReplaySize: 10, PrivateTopics: []string{"audit"}})
+	defer broker.Close()
+	h := NewHandler(broker, nil, 0, TopicAccess{
+		Authorizer:  auditReader{casbin.NewNoOpAdapter()},
+		Permissions: map[string]string{"audit": "audit:read"},
+	})
+	app := fiber.New()
+	withUser := func(next fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", c.Get("X-User"))
+			return next(c)
+		}
+	}
+	app.Get("/sse/subscribe", withUser(h.Subscribe))
+	app.Get("/events/poll", withUser(h.Poll))
+	app.Post("/events/ticket", withUser(h.IssueTicket))
+
+	do := func(method, target, user, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("restricted topic needs the permission", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusForbidden, do("GET", "/sse/subscribe?topics=orders,audit", "user-1", ""))
+		assert.Equal(t, fiber.StatusForbidden, do("GET", "/events/poll?topics=audit", "user-1", ""))
+		assert.Equal(t, fiber.StatusForbidden, do("POST", "/events/ticket", "user-1", `{"topics":["audit"]}`))
+
+		assert.Equal(t, fiber.StatusOK, do("GET", "/events/poll?topics=audit", "admin", ""))
+		assert.Equal(t, fiber.StatusCreated, do("POST", "/events/ticket", "admin", `{"topics":["audit"]}`))
+	})
+
+	t.Run("open topics need none", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusOK, do("GET", "/events/poll?topics=orders", "user-1", ""))
+		assert.Equal(t, fiber.StatusOK, do("GET", "/events/poll", "user-1", ""))
+	})
+
+	t.Run("no authorizer closes restricted topics", func(t *testing.T) {
+		closed := NewHandler(broker, nil, 0, TopicAccess{Permissions: map[string]string{"audit": "audit:read"}})
+		app := fiber.New()
+		app.Get("/events/poll", withUser(closed.Poll))
+		req := httptest.NewRequest("GET", "/events/poll?topics=audit", nil)
+		req.Header.Set("X-User", "admin")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	})
+}
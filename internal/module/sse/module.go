@@ -1,40 +1,75 @@
 package sse
 
 import (
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
 	"github.com/14mdzk/goscratch/internal/module/sse/handler"
+	"github.com/14mdzk/goscratch/internal/module/sse/ticket"
+	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/gofiber/fiber/v2"
 )
 
 // Module represents the SSE module
 type Module struct {
-	handler    *handler.Handler
-	authorizer port.Authorizer
-	jwtSecret  string
+	handler *handler.Handler
+	routes  routes.Config
+	authCfg middleware.AuthConfig
 }
 
-// NewModule creates a new SSE module
-func NewModule(broker port.SSEBroker, authorizer port.Authorizer, jwtSecret string) *Module {
-	h := handler.NewHandler(broker)
+// NewModule creates a new SSE module. Stream tickets are shared through
+// cacheAdapter when it is Redis, and kept in memory otherwise.
+func NewModule(broker port.SSEBroker, cacheAdapter port.Cache, authCfg middleware.AuthConfig, cfg config.SSEConfig, routeCfg routes.Config) *Module {
+	if _, noop := cacheAdapter.(*cache.NoOpCache); noop {
+		cacheAdapter = nil
+	}
+	tickets := ticket.NewStore(cacheAdapter, time.Duration(cfg.TicketTTL))
+	h := handler.NewHandler(broker, tickets, time.Duration(cfg.PollTimeout), handler.TopicAccess{
+		Authorizer:  routeCfg.Authorizer,
+		Permissions: cfg.TopicPermissions,
+	})
 
 	return &Module{
-		handler:    h,
-		authorizer: authorizer,
-		jwtSecret:  jwtSecret,
+		handler: h,
+		routes:  routeCfg,
+		authCfg: authCfg,
 	}
 }
 
 // RegisterRoutes registers SSE module routes
 func (m *Module) RegisterRoutes(router fiber.Router) {
-	authMiddleware := middleware.Auth(middleware.DefaultAuthConfig(m.jwtSecret))
+	authMiddleware := middleware.Auth(m.authCfg)
+
+	// EventSource cannot set the Authorization header, so the stream also
+	// accepts a ticket from POST /events/ticket. The other /sse routes
+	// still need the JWT.
+	ticketAuth := m.handler.TicketAuth(authMiddleware)
+	sseAuth := func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodGet && strings.HasSuffix(strings.TrimSuffix(c.Path(), "/"), "/sse/subscribe") {
+			return ticketAuth(c)
+		}
+		return authMiddleware(c)
+	}
 
-	sseGroup := router.Group("/sse")
+	r := routes.New(router, m.routes)
+	sseGroup := r.Group("/sse").Authenticated(sseAuth)
 
-	// SSE subscribe - requires authentication
-	sseGroup.Get("/subscribe", authMiddleware, m.handler.Subscribe)
+	// SSE subscribe - requires authentication or a stream ticket
+	sseGroup.Get("/subscribe", m.handler.Subscribe)
 
 	// Admin-only routes
-	sseGroup.Post("/broadcast", authMiddleware, middleware.RequirePermission(m.authorizer, "sse", "broadcast"), m.handler.Broadcast)
-	sseGroup.Get("/clients", authMiddleware, middleware.RequirePermission(m.authorizer, "sse", "read"), m.handler.ClientCount)
+	sseGroup.Post("/broadcast", m.handler.Broadcast).Require("sse:broadcast")
+	sseGroup.Get("/clients", m.handler.ClientCount).Require("sse:read")
+
+	eventsGroup := r.Group("/events").Authenticated(authMiddleware)
+	// Long polling over the SSE replay buffer
+	eventsGroup.Get("/poll", m.handler.Poll)
+	// One-time tickets for opening the stream without a header
+	eventsGroup.Post("/ticket", m.handler.IssueTicket)
+
+	r.Mount()
 }
@@ -0,0 +1,143 @@
+// Package ticket issues one-time tickets that authenticate an SSE stream.
+//
+// EventSource cannot send an Authorization header, and putting the JWT in
+// the stream URL would leave a long-lived credential in proxy and access
+// logs. Instead the client trades its JWT for a ticket through an
+// authenticated POST and opens the stream with ?ticket=. A ticket is bound
+// to the user and topics it was issued for, expires after a few seconds and
+// is accepted once.
+package ticket
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// DefaultTTL is how long a ticket is valid when Store is given no TTL
+const DefaultTTL = 30 * time.Second
+
+const keyPrefix = "sse:ticket:"
+
+// ErrInvalid is returned by Redeem for a ticket that is unknown, expired or
+// already used.
+var ErrInvalid = errors.New("sse ticket: invalid, expired or already used")
+
+// Ticket is what a ticket grants
+type Ticket struct {
+	UserID string   `json:"user_id"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+// Store issues and redeems tickets. With a cache they are shared through it,
+// so a ticket issued by one instance opens a stream on another; without one
+// they are kept in memory and only this instance accepts them.
+type Store struct {
+	cache port.Cache
+	ttl   time.Duration
+
+	mu  sync.Mutex
+	mem map[string]entry
+}
+
+type entry struct {
+	ticket    Ticket
+	expiresAt time.Time
+}
+
+// NewStore creates a ticket store. cache may be nil; ttl defaults to
+// DefaultTTL.
+func NewStore(cache port.Cache, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{cache: cache, ttl: ttl, mem: make(map[string]entry)}
+}
+
+// TTL returns how long issued tickets are valid
+func (s *Store) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue creates a ticket for t and returns it with its expiry
+func (s *Store) Issue(ctx context.Context, t Ticket) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, fmt.Errorf("generate ticket: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := time.Now().Add(s.ttl)
+
+	if s.cache != nil {
+		if err := s.cache.SetJSON(ctx, key(token), t, s.ttl); err != nil {
+			return "", time.Time{}, fmt.Errorf("store ticket: %w", err)
+		}
+		return token, expiresAt, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, e := range s.mem {
+		if now.After(e.expiresAt) {
+			delete(s.mem, k)
+		}
+	}
+	s.mem[key(token)] = entry{ticket: t, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// Redeem returns what token grants and invalidates it. It returns ErrInvalid
+// for anything but the first redemption of a live ticket.
+func (s *Store) Redeem(ctx context.Context, token string) (Ticket, error) {
+	if token == "" {
+		return Ticket{}, ErrInvalid
+	}
+	k := key(token)
+
+	if s.cache == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		e, ok := s.mem[k]
+		delete(s.mem, k)
+		if !ok || time.Now().After(e.expiresAt) {
+			return Ticket{}, ErrInvalid
+		}
+		return e.ticket, nil
+	}
+
+	// port.Cache has no atomic get-and-delete. The increment is atomic, so
+	// of two concurrent redemptions only the first sees 1.
+	claims, err := s.cache.Increment(ctx, k+":claimed")
+	if err != nil {
+		return Ticket{}, fmt.Errorf("claim ticket: %w", err)
+	}
+	_ = s.cache.Expire(ctx, k+":claimed", s.ttl)
+	if claims != 1 {
+		return Ticket{}, ErrInvalid
+	}
+
+	var t Ticket
+	if err := s.cache.GetJSON(ctx, k, &t); err != nil {
+		if errors.Is(err, port.ErrCacheMiss) {
+			return Ticket{}, ErrInvalid
+		}
+		return Ticket{}, fmt.Errorf("load ticket: %w", err)
+	}
+	_ = s.cache.Delete(ctx, k)
+	return t, nil
+}
+
+// key stores tickets by hash, so the cache never holds a usable ticket.
+func key(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return keyPrefix + hex.EncodeToString(h[:])
+}
@@ -0,0 +1,106 @@
+package ticket
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+)
+
+func stores(t *testing.T, ttl time.Duration) (map[string]*Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = redisCache.Close() })
+	return map[string]*Store{
+		"memory": NewStore(nil, ttl),
+		"redis":  NewStore(redisCache, ttl),
+	}, mr
+}
+
+func TestStore_IssueRedeem(t *testing.T) {
+	ctx := context.Background()
+	all, _ := stores(t, time.Minute)
+	for name, s := range all {
+		t.Run(name, func(t *testing.T) {
+			token, expiresAt, err := s.Issue(ctx, Ticket{UserID: "user-1", Topics: []string{"orders"}})
+			require.NoError(t, err)
+			assert.NotEmpty(t, token)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, 5*time.Second)
+
+			got, err := s.Redeem(ctx, token)
+			require.NoError(t, err)
+			assert.Equal(t, Ticket{UserID: "user-1", Topics: []string{"orders"}}, got)
+
+			_, err = s.Redeem(ctx, token)
+			assert.ErrorIs(t, err, ErrInvalid, "a ticket is accepted once")
+
+			_, err = s.Redeem(ctx, "never-issued")
+			assert.ErrorIs(t, err, ErrInvalid)
+			_, err = s.Redeem(ctx, "")
+			assert.ErrorIs(t, err, ErrInvalid)
+		})
+	}
+}
+
+func TestStore_Expired(t *testing.T) {
+	ctx := context.Background()
+	all, mr := stores(t, 20*time.Millisecond)
+
+	memToken, _, err := all["memory"].Issue(ctx, Ticket{UserID: "user-1"})
+	require.NoError(t, err)
+	redisToken, _, err := all["redis"].Issue(ctx, Ticket{UserID: "user-1"})
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	mr.FastForward(30 * time.Millisecond)
+
+	_, err = all["memory"].Redeem(ctx, memToken)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = all["redis"].Redeem(ctx, redisToken)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestStore_ConcurrentRedeem(t *testing.T) {
+	ctx := context.Background()
+	all, _ := stores(t, time.Minute)
+	for name, s := range all {
+		t.Run(name, func(t *testing.T) {
+			token, _, err := s.Issue(ctx, Ticket{UserID: "user-1"})
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			var redeemed atomic.Int32
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := s.Redeem(ctx, token); err == nil {
+						redeemed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(1), redeemed.Load())
+		})
+	}
+}
+
+func TestStore_HashesTickets(t *testing.T) {
+	ctx := context.Background()
+	all, mr := stores(t, time.Minute)
+	token, _, err := all["redis"].Issue(ctx, Ticket{UserID: "user-1"})
+	require.NoError(t, err)
+
+	for _, k := range mr.Keys() {
+		assert.NotContains(t, k, token)
+	}
+	assert.Equal(t, DefaultTTL, NewStore(nil, 0).TTL())
+}
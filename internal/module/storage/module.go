@@ -10,12 +10,12 @@ import (
 
 // Module represents the storage module
 type Module struct {
-	handler   *handler.Handler
-	jwtSecret string
+	handler *handler.Handler
+	authCfg middleware.AuthConfig
 }
 
 // NewModule creates a new storage module
-func NewModule(storage port.Storage, auditor port.Auditor, jwtSecret string) *Module {
+func NewModule(storage port.Storage, auditor port.Auditor, authCfg middleware.AuthConfig) *Module {
 	uc := usecase.NewUseCase(storage, nil)
 	var ucIface usecase.UseCase = uc
 	if auditor != nil {
@@ -24,14 +24,14 @@ func NewModule(storage port.Storage, auditor port.Auditor, jwtSecret string) *Mo
 	h := handler.NewHandler(ucIface)
 
 	return &Module{
-		handler:   h,
-		jwtSecret: jwtSecret,
+		handler: h,
+		authCfg: authCfg,
 	}
 }
 
 // RegisterRoutes registers storage module routes
 func (m *Module) RegisterRoutes(router fiber.Router) {
-	authMiddleware := middleware.Auth(middleware.DefaultAuthConfig(m.jwtSecret))
+	authMiddleware := middleware.Auth(m.authCfg)
 
 	files := router.Group("/files")
 
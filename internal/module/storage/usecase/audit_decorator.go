@@ -11,8 +11,9 @@ import (
 )
 
 // AuditedUseCase wraps a UseCase and adds audit logging on every mutating
-// operation. Read-only methods (Download, GetURL, List) are delegated as-is
-// to keep the audit log signal-to-noise ratio high.
+// operation. Downloads are audited when the auditor samples reads (see
+// port.LogRead); GetURL and List are delegated as-is to keep the audit log
+// signal-to-noise ratio high.
 type AuditedUseCase struct {
 	inner   UseCase
 	auditor port.Auditor
@@ -40,9 +41,17 @@ func (d *AuditedUseCase) Upload(ctx context.Context, file multipart.File, header
 	return resp, nil
 }
 
-// Download delegates to inner without audit logging.
+// Download opens a file and logs a READ audit entry on success, if the
+// auditor samples the read.
 func (d *AuditedUseCase) Download(ctx context.Context, path string) (io.ReadCloser, string, error) {
-	return d.inner.Download(ctx, path)
+	rc, contentType, err := d.inner.Download(ctx, path)
+	if err != nil {
+		return nil, contentType, err
+	}
+
+	_ = port.LogRead(ctx, d.auditor, port.NewAuditEntry(ctx, port.AuditActionRead, "file", path))
+
+	return rc, contentType, nil
 }
 
 // Delete removes a file and logs a DELETE audit entry on success.
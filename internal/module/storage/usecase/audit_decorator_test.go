@@ -14,6 +14,7 @@ import (
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // mockStorageUseCase is a testify mock satisfying the UseCase interface.
@@ -73,6 +74,13 @@ func (m *mockStorageAuditor) Query(_ context.Context, _ port.AuditFilter) ([]por
 
 func (m *mockStorageAuditor) Close() error { return nil }
 
+// samplingStorageAuditor audits every read
+type samplingStorageAuditor struct {
+	mockStorageAuditor
+}
+
+func (*samplingStorageAuditor) SampleRead(string) bool { return true }
+
 func newTestFileHeader(t *testing.T, name string) (*multipart.FileHeader, multipart.File) {
 	t.Helper()
 	body := &bytes.Buffer{}
@@ -189,6 +197,26 @@ func TestStorageAuditDecorator_ReadOnly_NoAudit(t *testing.T) {
 		assert.Empty(t, auditor.Entries)
 	})
 
+	t.Run("Download is audited when reads are sampled", func(t *testing.T) {
+		inner := new(mockStorageUseCase)
+		auditor := &samplingStorageAuditor{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		rc := io.NopCloser(bytes.NewBufferString("data"))
+		inner.On("Download", ctx, "x").Return(rc, "application/octet-stream", nil)
+		inner.On("Download", ctx, "missing").Return(nil, "", errors.New("not found"))
+
+		_, _, err := dec.Download(ctx, "x")
+		assert.NoError(t, err)
+		_, _, err = dec.Download(ctx, "missing")
+		assert.Error(t, err)
+
+		require.Len(t, auditor.Entries, 1, "failed downloads are not audited")
+		assert.Equal(t, port.AuditActionRead, auditor.Entries[0].Action)
+		assert.Equal(t, "file", auditor.Entries[0].Resource)
+		assert.Equal(t, "x", auditor.Entries[0].ResourceID)
+	})
+
 	t.Run("GetURL delegates without audit", func(t *testing.T) {
 		inner := new(mockStorageUseCase)
 		auditor := &mockStorageAuditor{}
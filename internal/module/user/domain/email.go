@@ -0,0 +1,42 @@
+package domain
+
+import "strings"
+
+// gmailDomains are the domains Gmail delivers for. googlemail.com is an alias
+// of gmail.com.
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// NormalizeEmail returns the canonical form of an email address, used for
+// storage, lookup and uniqueness: surrounding whitespace is trimmed and the
+// address is lower-cased, so Test@Example.com and test@example.com are the
+// same account.
+//
+// With gmailAliases, Gmail addresses also drop dots and any "+tag" from the
+// local part and use the gmail.com domain, because Gmail delivers all of those
+// spellings to one mailbox. Other providers' local parts are left alone: dots
+// and plus tags are significant elsewhere.
+func NormalizeEmail(email string, gmailAliases bool) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !gmailAliases {
+		return email
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if !gmailDomains[domain] {
+		return email
+	}
+
+	local, _, _ = strings.Cut(local, "+")
+	local = strings.ReplaceAll(local, ".", "")
+	if local == "" {
+		return email
+	}
+	return local + "@gmail.com"
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           string
+		gmailAliases bool
+		want         string
+	}{
+		{"lower-cases", "Test@Example.COM", false, "test@example.com"},
+		{"trims whitespace", "  test@example.com\t", false, "test@example.com"},
+		{"keeps gmail dots and tags by default", "Jane.Doe+news@Gmail.com", false, "jane.doe+news@gmail.com"},
+		{"strips gmail dots and tags", "Jane.Doe+news@Gmail.com", true, "janedoe@gmail.com"},
+		{"maps googlemail to gmail", "jane.doe@googlemail.com", true, "janedoe@gmail.com"},
+		{"leaves other providers alone", "jane.doe+news@example.com", true, "jane.doe+news@example.com"},
+		{"keeps a gmail address with an empty local part", "+tag@gmail.com", true, "+tag@gmail.com"},
+		{"tolerates a missing @", "Not-An-Email", true, "not-an-email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeEmail(tt.in, tt.gmailAliases))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+// User lifecycle event types. Each is also the routing key the event is
+// published with.
+const (
+	EventUserCreated     = "user.created"
+	EventUserUpdated     = "user.updated"
+	EventUserDeactivated = "user.deactivated"
+)
+
+// Reasons a user.deactivated event gives
+const (
+	DeactivatedByAdmin  = "deactivated"
+	DeactivatedByDelete = "deleted"
+	DeactivatedByMerge  = "merged"
+)
+
+// Event is the envelope of a user lifecycle event. ID is unique per event,
+// so consumers can drop a redelivered one.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Actor is the caller that made the change; nil when there was none,
+	// e.g. a background job.
+	Actor *EventActor `json:"actor"`
+	Data  EventData   `json:"data"`
+}
+
+// EventActor identifies who made a change. ImpersonatorID is set when an
+// admin made it while impersonating UserID.
+type EventActor struct {
+	UserID         string `json:"user_id"`
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+}
+
+// EventData is the payload of a user lifecycle event
+type EventData struct {
+	User EventUser `json:"user"`
+	// Changed names the fields a user.updated event changed
+	Changed []string `json:"changed,omitempty"`
+	// Reason is why a user.deactivated event's user was deactivated
+	Reason string `json:"reason,omitempty"`
+}
+
+// EventUser is the user's state after the change
+type EventUser struct {
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	IsActive     bool   `json:"is_active"`
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// NewEventUser returns u's state for an event
+func NewEventUser(u *User) EventUser {
+	return EventUser{
+		ID:           u.ID.String(),
+		Email:        u.Email,
+		Name:         u.Name,
+		IsActive:     u.IsActive,
+		PendingEmail: u.PendingEmail,
+	}
+}
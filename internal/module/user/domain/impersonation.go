@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// ImpersonationToken is a short-lived access token that lets an admin act as
+// another user. It carries both identities and cannot be refreshed.
+type ImpersonationToken struct {
+	AccessToken string
+	TokenID     string // the token's jti, by which it can be revoked early
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Login is one successful login of a user, as kept in their login history
+type Login struct {
+	ID         int64     `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	IPAddress  string    `json:"ip_address,omitempty"` // empty when the client address was unknown
+	UserAgent  string    `json:"user_agent,omitempty"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+}
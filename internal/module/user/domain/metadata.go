@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// Metadata limits. The database additionally caps a user's metadata at
+// MaxMetadataSize bytes of JSON.
+const (
+	MaxMetadataKeyLength = 64
+	MaxMetadataPatchKeys = 50    // keys one update may set or remove
+	MaxMetadataFilters   = 10    // metadata filters one list query may use
+	MaxMetadataSize      = 16384 // bytes
+)
+
+// metadataKeyPattern restricts keys to characters that are safe in a query
+// parameter name (metadata.<key>=...) and a CSV cell.
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidMetadataKey reports whether key may be used as a metadata key.
+func ValidMetadataKey(key string) bool {
+	return len(key) <= MaxMetadataKeyLength && metadataKeyPattern.MatchString(key)
+}
+
+// MetadataPatch changes some keys of a user's metadata: keys in Set are
+// written, keys in Unset are removed, and every other key is kept.
+type MetadataPatch struct {
+	Set   map[string]json.RawMessage
+	Unset []string
+}
+
+// Empty reports whether the patch changes nothing.
+func (p MetadataPatch) Empty() bool {
+	return len(p.Set) == 0 && len(p.Unset) == 0
+}
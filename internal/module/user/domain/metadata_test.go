@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidMetadataKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"department", true},
+		{"cost_center.eu-west", true},
+		{"Locale2", true},
+		{"", false},
+		{"has space", false},
+		{"a=b", false},
+		{"emoji😀", false},
+		{strings.Repeat("k", MaxMetadataKeyLength), true},
+		{strings.Repeat("k", MaxMetadataKeyLength+1), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ValidMetadataKey(tt.key), tt.key)
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/types"
+)
+
+// Digest frequencies of the summary email
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Digests are the valid Notifications.Digest values
+var Digests = []string{DigestOff, DigestDaily, DigestWeekly}
+
+// Preferences are a user's personal settings
+type Preferences struct {
+	Locale        string                  `json:"locale"`   // BCP 47 tag, e.g. "en" or "pt-BR"
+	Timezone      string                  `json:"timezone"` // IANA name, e.g. "Europe/Berlin"
+	Notifications NotificationPreferences `json:"notifications"`
+	UpdatedAt     *time.Time              `json:"updated_at,omitempty"` // nil until first changed
+}
+
+// NotificationPreferences choose which notifications a user receives
+type NotificationPreferences struct {
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+	Digest string `json:"digest"` // one of Digests
+}
+
+// DefaultPreferences are the preferences of a user who has not changed any.
+// They match the column defaults of the user_preferences table.
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Locale:   "en",
+		Timezone: "UTC",
+		Notifications: NotificationPreferences{
+			Email:  true,
+			Push:   true,
+			Digest: DigestWeekly,
+		},
+	}
+}
+
+// PreferencesPatch changes some preferences; an unset field is kept.
+type PreferencesPatch struct {
+	Locale   types.Opt[string]
+	Timezone types.Opt[string]
+	Email    types.Opt[bool]
+	Push     types.Opt[bool]
+	Digest   types.Opt[string]
+}
+
+// Empty reports whether the patch changes nothing.
+func (p PreferencesPatch) Empty() bool {
+	return !p.Locale.Set && !p.Timezone.Set && !p.Email.Set && !p.Push.Set && !p.Digest.Set
+}
+
+// localePattern accepts the common shapes of a BCP 47 tag: a language,
+// optionally followed by a script and a region ("en", "zh-Hant-TW", "es-419").
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+// ValidLocale reports whether locale is a language tag such as "en-US".
+func ValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// ValidTimezone reports whether tz names an IANA time zone. "Local" is
+// refused: it means the server's zone, not the user's.
+func ValidTimezone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// ValidDigest reports whether digest is one of Digests.
+func ValidDigest(digest string) bool {
+	return slices.Contains(Digests, digest)
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidLocale(t *testing.T) {
+	for _, locale := range []string{"en", "pt-BR", "zh-Hant-TW", "es-419", "fil"} {
+		assert.True(t, ValidLocale(locale), locale)
+	}
+	for _, locale := range []string{"", "EN", "en_US", "en-us", "english", "en-US-x"} {
+		assert.False(t, ValidLocale(locale), locale)
+	}
+}
+
+func TestValidTimezone(t *testing.T) {
+	for _, tz := range []string{"UTC", "Europe/Berlin", "America/Argentina/Buenos_Aires"} {
+		assert.True(t, ValidTimezone(tz), tz)
+	}
+	for _, tz := range []string{"", "Local", "Mars/Olympus", "../etc/passwd"} {
+		assert.False(t, ValidTimezone(tz), tz)
+	}
+}
+
+func TestDefaultPreferences(t *testing.T) {
+	p := DefaultPreferences()
+	assert.True(t, ValidLocale(p.Locale))
+	assert.True(t, ValidTimezone(p.Timezone))
+	assert.True(t, ValidDigest(p.Notifications.Digest))
+	assert.Nil(t, p.UpdatedAt)
+}
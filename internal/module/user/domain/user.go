@@ -1,21 +1,50 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 	"github.com/14mdzk/goscratch/pkg/types"
 	"github.com/google/uuid"
 )
 
 // User represents a user entity
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Name         string    `json:"name"`
-	IsActive     bool      `json:"is_active"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uuid.UUID  `json:"id"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"-"`
+	Name         string     `json:"name"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty"` // nil until the first tracked request
+	// EmailVerifiedAt is nil until the user verifies their current address
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// DeletedAt is set while the user is soft-deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Metadata holds free-form profile attributes as a JSON object
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	// MustChangePassword is set by an admin-forced password reset and cleared
+	// when the password is next changed
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	// LastLoginAt and LastLoginIP describe the latest successful login; nil
+	// and empty until the user first logs in
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	LastLoginIP string     `json:"last_login_ip,omitempty"`
+	// PendingEmail is the address the user asked to change to, until they
+	// confirm it through the link sent there
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// EmailVerified reports whether the user has verified their current address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// Deleted reports whether the user is soft-deleted.
+func (u *User) Deleted() bool {
+	return u.DeletedAt != nil
 }
 
 // UserFilter contains filter options for listing users with optional filtering
@@ -29,10 +58,45 @@ type UserFilter struct {
 	Search   types.Opt[string] // Search by name or email
 	Email    types.Opt[string] // Exact email match
 	IsActive types.Opt[bool]   // Filter by active status
+	Role     types.Opt[string] // Users granted this role directly
+
+	// Metadata matches users whose metadata has these string values;
+	// MetadataKeys matches users whose metadata has all of these keys
+	Metadata     map[string]string
+	MetadataKeys []string
+
+	// IncludeDeleted also returns soft-deleted users, which are left out by
+	// default
+	IncludeDeleted bool
 
-	// Sorting
-	SortBy    string // Field to sort by (default: created_at)
-	SortOrder string // asc or desc (default: desc)
+	// Sort orders the list by these SortFields, then by ID; without it users
+	// are listed in ID order. CursorValues are the cursor row's values of the
+	// sort fields, as returned by User.SortValues.
+	Sort         []shareddomain.SortField
+	CursorValues []string
+}
+
+// SortFields are the fields users can be listed by
+var SortFields = []string{"name", "email", "created_at", "updated_at"}
+
+// SortValues returns u's values of the sort fields for a keyset cursor.
+// Timestamps are RFC 3339 with nanoseconds, so the next page starts exactly
+// after u.
+func (u *User) SortValues(sort []shareddomain.SortField) []string {
+	values := make([]string, 0, len(sort))
+	for _, f := range sort {
+		switch f.Field {
+		case "name":
+			values = append(values, u.Name)
+		case "email":
+			values = append(values, u.Email)
+		case "created_at":
+			values = append(values, u.CreatedAt.UTC().Format(time.RFC3339Nano))
+		case "updated_at":
+			values = append(values, u.UpdatedAt.UTC().Format(time.RFC3339Nano))
+		}
+	}
+	return values
 }
 
 // DefaultLimit is the default pagination limit
@@ -49,10 +113,4 @@ func (f *UserFilter) NormalizeFilter() {
 	if f.Limit > MaxLimit {
 		f.Limit = MaxLimit
 	}
-	if f.SortBy == "" {
-		f.SortBy = "created_at"
-	}
-	if f.SortOrder == "" {
-		f.SortOrder = "desc"
-	}
 }
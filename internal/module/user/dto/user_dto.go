@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/14mdzk/goscratch/pkg/types"
+import (
+	"encoding/json"
+
+	"github.com/14mdzk/goscratch/pkg/types"
+)
 
 // CreateUserRequest represents the request to create a user
 type CreateUserRequest struct {
@@ -9,10 +13,52 @@ type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 }
 
+// MaxBatchCreateUsers is the most users one batch create request may carry
+const MaxBatchCreateUsers = 100
+
+// BatchCreateUsersRequest represents the request to create several users at
+// once. Each item is validated like CreateUserRequest.
+type BatchCreateUsersRequest struct {
+	Users  []CreateUserRequest `json:"users" validate:"required,min=1,max=100"`
+	Atomic bool                `json:"atomic"` // Create none of the users if any fails
+}
+
+// Batch item outcomes
+const (
+	BatchItemCreated    = "created"
+	BatchItemFailed     = "failed"
+	BatchItemRolledBack = "rolled_back" // Would have been created, but an atomic batch failed
+)
+
+// BatchCreateUsersResponse reports the outcome of every item, in request order
+type BatchCreateUsersResponse struct {
+	Created int                     `json:"created"`
+	Failed  int                     `json:"failed"`
+	Results []BatchCreateUserResult `json:"results"`
+}
+
+// BatchCreateUserResult is the outcome of one batch item
+type BatchCreateUserResult struct {
+	Index  int           `json:"index"`
+	Email  string        `json:"email"`
+	Status string        `json:"status"`
+	User   *UserResponse `json:"user,omitempty"`
+	Error  *BatchError   `json:"error,omitempty"`
+}
+
+// BatchError is why a batch item failed
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 // UpdateUserRequest represents the request to update a user
 type UpdateUserRequest struct {
 	Name  string `json:"name" validate:"omitempty,min=2,max=100"`
 	Email string `json:"email" validate:"omitempty,email"`
+	// Metadata patches the user's metadata key by key: a key set to a value
+	// is written, a key set to null is removed, and keys left out are kept.
+	Metadata map[string]types.NOpt[json.RawMessage] `json:"metadata" validate:"omitempty,max=50"`
 }
 
 // ChangePasswordRequest represents the request to change password
@@ -21,6 +67,38 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"new_password" validate:"required,min=8"`
 }
 
+// ForcePasswordResetRequest represents an admin-forced password reset
+type ForcePasswordResetRequest struct {
+	// TemporaryPassword replaces the user's password with a generated one,
+	// returned once in the response. Otherwise the current password keeps
+	// working until it is changed.
+	TemporaryPassword bool `json:"temporary_password"`
+}
+
+// ForcePasswordResetResponse reports a forced password reset
+type ForcePasswordResetResponse struct {
+	UserID             string `json:"user_id"`
+	MustChangePassword bool   `json:"must_change_password"`
+	// TemporaryPassword is only set when one was requested. It is not
+	// stored in plain text and cannot be retrieved again.
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+	// SessionsRevoked is false when revocation failed.
+	SessionsRevoked bool `json:"sessions_revoked"`
+}
+
+// ImpersonationResponse carries a token that lets the caller act as UserID.
+// It cannot be refreshed; a new one must be requested once it expires.
+type ImpersonationResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"` // seconds
+	ExpiresAt   string `json:"expires_at"`
+	// TokenID is the token's jti, for revoking it early via POST /auth/revoke.
+	TokenID string `json:"token_id"`
+	UserID  string `json:"user_id"`
+	ActorID string `json:"actor_id"`
+}
+
 // UserResponse represents the user response
 type UserResponse struct {
 	ID        string `json:"id"`
@@ -28,7 +106,30 @@ type UserResponse struct {
 	Name      string `json:"name"`
 	IsActive  bool   `json:"is_active"`
 	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	// UpdatedAt and Roles are left out of audit diffs: the one changes with
+	// every update, the other is audited by the role module.
+	UpdatedAt string `json:"updated_at" audit:"-"`
+	// LastSeenAt is null until the user's first tracked authenticated request.
+	LastSeenAt *string `json:"last_seen_at"`
+	// EmailVerifiedAt is null until the user verifies their current address.
+	EmailVerifiedAt *string `json:"email_verified_at"`
+	// DeletedAt is null unless the user is soft-deleted.
+	DeletedAt *string `json:"deleted_at"`
+	// LastLoginAt and LastLoginIP describe the latest successful login; null
+	// until the user first logs in.
+	LastLoginAt *string `json:"last_login_at"`
+	LastLoginIP *string `json:"last_login_ip"`
+	// MustChangePassword is set after an admin-forced password reset until
+	// the user changes their password.
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	// PendingEmail is the address an email change is waiting to be confirmed
+	// for; null when no change is pending.
+	PendingEmail *string `json:"pending_email"`
+	// Metadata holds free-form profile attributes; {} when there are none.
+	Metadata json.RawMessage `json:"metadata"`
+	// Roles are the roles granted to the user directly. They are filled in
+	// by get and list, and omitted elsewhere and when the user has none.
+	Roles []string `json:"roles,omitempty" audit:"-"`
 }
 
 // ListUsersRequest represents the request to list users with optional filters
@@ -41,4 +142,110 @@ type ListUsersRequest struct {
 	Search   types.Opt[string] `query:"search"`    // Search by name or email
 	Email    types.Opt[string] `query:"email"`     // Exact email match
 	IsActive types.Opt[bool]   `query:"is_active"` // Filter by active status
+	Role     types.Opt[string] `query:"role"`      // Users granted this role directly
+
+	IncludeDeleted bool `query:"include_deleted"` // Also list soft-deleted users
+
+	// Metadata is read by the handler from metadata.<key>=<value> parameters
+	Metadata    map[string]string `query:"-"`
+	HasMetadata string            `query:"has_metadata"` // Comma-separated metadata keys that must all be present
+
+	// Sort is a comma-separated list of fields, e.g. "name,-created_at"; a
+	// leading "-" sorts descending. Users are listed in ID order without it.
+	Sort string `query:"sort"`
+}
+
+// ExportUsersRequest represents the user export query. The filters are those
+// of ListUsersRequest.
+type ExportUsersRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=csv ndjson"` // csv (default) or ndjson
+	Async  bool   `query:"async"`                                        // Generate in the worker and return a download link
+
+	Search   types.Opt[string] `query:"search"`
+	Email    types.Opt[string] `query:"email"`
+	IsActive types.Opt[bool]   `query:"is_active"`
+	Role     types.Opt[string] `query:"role"`
+
+	IncludeDeleted bool `query:"include_deleted"`
+
+	Metadata    map[string]string `query:"-"`
+	HasMetadata string            `query:"has_metadata"`
+}
+
+// UserExportResponse reports an asynchronous export
+type UserExportResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // pending or ready
+	Format string `json:"format,omitempty"`
+	// URL is the download link once the export is ready. It expires; fetch
+	// the export again for a fresh one.
+	URL string `json:"url,omitempty"`
+}
+
+// ListInactiveUsersRequest represents the inactive-users report query
+type ListInactiveUsersRequest struct {
+	Days  int `query:"days" validate:"omitempty,min=1,max=3650"` // Inactive for at least this many days (default: 90)
+	Limit int `query:"limit" validate:"omitempty,min=1,max=1000"`
+}
+
+// ListLoginsRequest represents the request to list a user's recent logins
+type ListLoginsRequest struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// LoginResponse represents one entry of a user's login history
+type LoginResponse struct {
+	LoggedInAt string `json:"logged_in_at"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+}
+
+// MergeUsersRequest represents the request to merge a duplicate account into
+// the user addressed by the route
+type MergeUsersRequest struct {
+	SourceID string `json:"source_id" validate:"required,uuid"` // Duplicate account to fold in and deactivate
+	DryRun   bool   `json:"dry_run"`                            // Report what would move without committing
+}
+
+// MergeUsersResponse reports what a merge moved, or would move on a dry run
+type MergeUsersResponse struct {
+	TargetID               string   `json:"target_id"`
+	SourceID               string   `json:"source_id"`
+	DryRun                 bool     `json:"dry_run"`
+	AuditEntriesReassigned int64    `json:"audit_entries_reassigned"`
+	RoleGrants             []string `json:"role_grants"`
+	AuthzRulesMoved        int64    `json:"authz_rules_moved"`
+	SourceDeactivated      bool     `json:"source_deactivated"`
+	// SessionsRevoked is false on dry runs and when revocation failed.
+	SessionsRevoked bool `json:"sessions_revoked"`
+}
+
+// PreferencesResponse represents a user's preferences
+type PreferencesResponse struct {
+	Locale        string                          `json:"locale"`
+	Timezone      string                          `json:"timezone"`
+	Notifications NotificationPreferencesResponse `json:"notifications"`
+	UpdatedAt     *string                         `json:"updated_at" audit:"-"` // null until first changed
+}
+
+// NotificationPreferencesResponse represents a user's notification settings
+type NotificationPreferencesResponse struct {
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+	Digest string `json:"digest"`
+}
+
+// UpdatePreferencesRequest changes some of the caller's preferences. Omitted
+// fields keep their value.
+type UpdatePreferencesRequest struct {
+	Locale        types.Opt[string]                    `json:"locale"`   // BCP 47 tag, e.g. "pt-BR"
+	Timezone      types.Opt[string]                    `json:"timezone"` // IANA name, e.g. "Europe/Berlin"
+	Notifications UpdateNotificationPreferencesRequest `json:"notifications"`
+}
+
+// UpdateNotificationPreferencesRequest changes some notification settings
+type UpdateNotificationPreferencesRequest struct {
+	Email  types.Opt[bool]   `json:"email"`
+	Push   types.Opt[bool]   `json:"push"`
+	Digest types.Opt[string] `json:"digest"` // off, daily or weekly
 }
@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"bufio"
+	"fmt"
+	"strings"
+
 	"github.com/14mdzk/goscratch/internal/module/user/dto"
 	"github.com/14mdzk/goscratch/internal/module/user/usecase"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
@@ -35,6 +39,7 @@ func (h *Handler) List(c *fiber.Ctx) error {
 	if err := validator.ValidateQuery(c, &req); err != nil {
 		return validator.HandleValidationError(c, err)
 	}
+	req.Metadata = metadataQuery(c)
 
 	result, err := h.useCase.List(c.UserContext(), req)
 	if err != nil {
@@ -43,6 +48,105 @@ func (h *Handler) List(c *fiber.Ctx) error {
 	return response.Paginated(c, result.GetItems(), result.GetMeta())
 }
 
+// metadataQuery collects the metadata.<key>=<value> query parameters, which
+// the query parser cannot bind to a map. It returns nil when there are none.
+func metadataQuery(c *fiber.Ctx) map[string]string {
+	var filter map[string]string
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		key, ok := strings.CutPrefix(string(k), "metadata.")
+		if !ok {
+			return
+		}
+		if filter == nil {
+			filter = make(map[string]string)
+		}
+		filter[key] = string(v)
+	})
+	return filter
+}
+
+// ListInactive reports active users with no recent activity
+func (h *Handler) ListInactive(c *fiber.Ctx) error {
+	var req dto.ListInactiveUsersRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	users, err := h.useCase.ListInactive(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, users)
+}
+
+// ListLogins returns a user's recent logins, newest first
+func (h *Handler) ListLogins(c *fiber.Ctx) error {
+	var req dto.ListLoginsRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	logins, err := h.useCase.ListLogins(c.UserContext(), c.Params("id"), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, logins)
+}
+
+// Export streams every user matching the list filters as CSV or NDJSON. With
+// async=true the export is generated by the worker instead, and the response
+// carries an ID to poll with GetExport.
+func (h *Handler) Export(c *fiber.Ctx) error {
+	var req dto.ExportUsersRequest
+	if err := validator.ValidateQuery(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+	req.Metadata = metadataQuery(c)
+	if req.Format == "" {
+		req.Format = usecase.ExportFormatCSV
+	}
+
+	if req.Async {
+		export, err := h.useCase.StartExport(c.UserContext(), req)
+		if err != nil {
+			return response.Fail(c, err)
+		}
+		return response.Accepted(c, export)
+	}
+
+	// The body is written after the handler returns; capture the context
+	// rather than c.
+	ctx := c.UserContext()
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.`+req.Format+`"`)
+	return response.StreamBody(c, usecase.ExportContentType(req.Format), func(w *bufio.Writer) {
+		if _, err := h.useCase.Export(ctx, req, w); err != nil {
+			exportAborted(w, req.Format)
+		}
+	})
+}
+
+// exportAborted ends an export cut short by an error. The 200 status is
+// already on the wire, so the body ends with a marker line instead: a CSV
+// record holding only the code, or an NDJSON error object. The underlying
+// error is not written.
+func exportAborted(w *bufio.Writer, format string) {
+	if format == usecase.ExportFormatNDJSON {
+		_, _ = w.WriteString(`{"error":{"code":"` + response.CodeStreamAborted + `","message":"The export was interrupted"}}` + "\n")
+		return
+	}
+	_, _ = w.WriteString(response.CodeStreamAborted + "\n")
+}
+
+// GetExport reports an asynchronous export and, once it is ready, its
+// download link
+func (h *Handler) GetExport(c *fiber.Ctx) error {
+	export, err := h.useCase.GetExport(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, export)
+}
+
 // Create creates a new user
 func (h *Handler) Create(c *fiber.Ctx) error {
 	var req dto.CreateUserRequest
@@ -59,13 +163,47 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 	return response.Created(c, user)
 }
 
-// Update updates a user
+// CreateBatch creates several users in one transaction. Every item is
+// validated first; if any is invalid nothing is created and the 400 details
+// are keyed by item, e.g. "users[2].email". Otherwise the response lists the
+// outcome of each item, with 201 when all were created and 200 when some
+// failed.
+func (h *Handler) CreateBatch(c *fiber.Ctx) error {
+	var req dto.BatchCreateUsersRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+	invalid := make(map[string]string)
+	for i := range req.Users {
+		for field, msg := range validator.ValidateStruct(&req.Users[i]) {
+			invalid[fmt.Sprintf("users[%d].%s", i, field)] = msg
+		}
+	}
+	if len(invalid) > 0 {
+		return response.ValidationFailed(c, invalid)
+	}
+
+	resp, err := h.useCase.CreateBatch(c.UserContext(), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	if resp.Failed == 0 {
+		return response.Created(c, resp)
+	}
+	return response.Success(c, resp)
+}
+
+// Update updates a user. An impersonation token cannot change an email
+// address, since password reset links go to it.
 func (h *Handler) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var req dto.UpdateUserRequest
 	if err := validator.ValidateAndBind(c, &req); err != nil {
 		return validator.HandleValidationError(c, err)
 	}
+	if req.Email != "" && middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot change an email address while impersonating")
+	}
 
 	user, err := h.useCase.Update(c.UserContext(), id, req)
 	if err != nil {
@@ -80,6 +218,10 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 	if userID == "" {
 		return response.Unauthorized(c, "")
 	}
+	// The user's password is theirs alone, even to an admin acting as them.
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot change the password of an impersonated user")
+	}
 
 	var req dto.ChangePasswordRequest
 	if err := validator.ValidateAndBind(c, &req); err != nil {
@@ -92,6 +234,75 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 	return response.Message(c, "Password changed successfully")
 }
 
+// Impersonate issues the caller a short-lived token to act as the user. A
+// caller already impersonating someone cannot start another impersonation.
+func (h *Handler) Impersonate(c *fiber.Ctx) error {
+	actorID := middleware.GetUserID(c)
+	if actorID == "" {
+		return response.Unauthorized(c, "")
+	}
+	if middleware.GetActorID(c) != "" {
+		return response.Forbidden(c, "Cannot impersonate while impersonating")
+	}
+
+	result, err := h.useCase.Impersonate(c.UserContext(), actorID, c.Params("id"))
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Created(c, result)
+}
+
+// ForcePasswordReset makes a user change their password at next login,
+// optionally replacing it with a generated temporary password. The body may
+// be empty.
+func (h *Handler) ForcePasswordReset(c *fiber.Ctx) error {
+	var req dto.ForcePasswordResetRequest
+	if len(c.Body()) > 0 {
+		if err := validator.ValidateAndBind(c, &req); err != nil {
+			return validator.HandleValidationError(c, err)
+		}
+	}
+
+	result, err := h.useCase.ForcePasswordReset(c.UserContext(), c.Params("id"), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, result)
+}
+
+// GetPreferences returns the current user's preferences
+func (h *Handler) GetPreferences(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return response.Unauthorized(c, "")
+	}
+
+	prefs, err := h.useCase.GetPreferences(c.UserContext(), userID)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, prefs)
+}
+
+// UpdatePreferences changes the current user's preferences
+func (h *Handler) UpdatePreferences(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return response.Unauthorized(c, "")
+	}
+
+	var req dto.UpdatePreferencesRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	prefs, err := h.useCase.UpdatePreferences(c.UserContext(), userID, req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, prefs)
+}
+
 // Delete soft-deletes a user
 func (h *Handler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -101,6 +312,24 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	return response.NoContent(c)
 }
 
+// Restore brings back a soft-deleted user
+func (h *Handler) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := h.useCase.Restore(c.UserContext(), id); err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Message(c, "User restored successfully")
+}
+
+// Purge permanently deletes a soft-deleted user
+func (h *Handler) Purge(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := h.useCase.Purge(c.UserContext(), id); err != nil {
+		return response.Fail(c, err)
+	}
+	return response.NoContent(c)
+}
+
 // GetMe retrieves the current user's profile
 func (h *Handler) GetMe(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -132,3 +361,17 @@ func (h *Handler) Deactivate(c *fiber.Ctx) error {
 	}
 	return response.Message(c, "User deactivated successfully")
 }
+
+// Merge merges the duplicate account in the body into the user in the route
+func (h *Handler) Merge(c *fiber.Ctx) error {
+	var req dto.MergeUsersRequest
+	if err := validator.ValidateAndBind(c, &req); err != nil {
+		return validator.HandleValidationError(c, err)
+	}
+
+	result, err := h.useCase.Merge(c.UserContext(), c.Params("id"), req)
+	if err != nil {
+		return response.Fail(c, err)
+	}
+	return response.Success(c, result)
+}
@@ -2,14 +2,20 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/14mdzk/goscratch/internal/module/user/dto"
 	"github.com/14mdzk/goscratch/internal/module/user/usecase"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -669,3 +675,480 @@ func TestDeactivate(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
 }
+
+// --- Merge Tests ---
+
+// mergeStub is a UseCase whose Merge records its arguments; every other
+// method is left to the nil embedded interface.
+type mergeStub struct {
+	usecase.UseCase
+	targetID string
+	req      dto.MergeUsersRequest
+}
+
+func (s *mergeStub) Merge(_ context.Context, targetID string, req dto.MergeUsersRequest) (*dto.MergeUsersResponse, error) {
+	s.targetID, s.req = targetID, req
+	return &dto.MergeUsersResponse{TargetID: targetID, SourceID: req.SourceID, DryRun: req.DryRun}, nil
+}
+
+func TestMerge(t *testing.T) {
+	const targetID = "01234567-89ab-cdef-0123-456789abcdef"
+	const sourceID = "11111111-2222-3333-4444-555555555555"
+
+	newApp := func(stub *mergeStub) *fiber.App {
+		app := fiber.New()
+		app.Post("/users/:id/merge", NewHandler(stub).Merge)
+		return app
+	}
+
+	t.Run("passes route target and body to the usecase", func(t *testing.T) {
+		stub := &mergeStub{}
+		body := `{"source_id":"` + sourceID + `","dry_run":true}`
+		req := httptest.NewRequest(http.MethodPost, "/users/"+targetID+"/merge", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := newApp(stub).Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, targetID, stub.targetID)
+		assert.Equal(t, dto.MergeUsersRequest{SourceID: sourceID, DryRun: true}, stub.req)
+
+		data := parseResponse(t, resp)["data"].(map[string]interface{})
+		assert.Equal(t, true, data["dry_run"])
+	})
+
+	t.Run("rejects a missing or malformed source_id", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"source_id":"not-a-uuid"}`} {
+			stub := &mergeStub{}
+			req := httptest.NewRequest(http.MethodPost, "/users/"+targetID+"/merge", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := newApp(stub).Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+			assert.Empty(t, stub.targetID, body)
+		}
+	})
+}
+
+// --- Restore and Purge Tests ---
+
+// deletionStub is a UseCase whose Restore and Purge record the ID and return
+// err; every other method is left to the nil embedded interface.
+type deletionStub struct {
+	usecase.UseCase
+	id  string
+	err error
+}
+
+func (s *deletionStub) Restore(_ context.Context, id string) error {
+	s.id = id
+	return s.err
+}
+
+func (s *deletionStub) Purge(_ context.Context, id string) error {
+	s.id = id
+	return s.err
+}
+
+func TestRestoreAndPurge(t *testing.T) {
+	const id = "01234567-89ab-cdef-0123-456789abcdef"
+
+	newApp := func(stub *deletionStub) *fiber.App {
+		h := NewHandler(stub)
+		app := fiber.New()
+		app.Post("/users/:id/restore", h.Restore)
+		app.Delete("/users/:id/purge", h.Purge)
+		return app
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		stub := &deletionStub{}
+		resp, err := newApp(stub).Test(httptest.NewRequest(http.MethodPost, "/users/"+id+"/restore", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, id, stub.id)
+		assert.Equal(t, "User restored successfully", parseResponse(t, resp)["message"])
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		stub := &deletionStub{}
+		resp, err := newApp(stub).Test(httptest.NewRequest(http.MethodDelete, "/users/"+id+"/purge", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, id, stub.id)
+	})
+
+	t.Run("purge of a user that is not deleted", func(t *testing.T) {
+		stub := &deletionStub{err: apperr.Conflictf("user %s must be deleted before it is purged", id)}
+		resp, err := newApp(stub).Test(httptest.NewRequest(http.MethodDelete, "/users/"+id+"/purge", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}
+
+// --- Export Tests ---
+
+// exportStub is a UseCase whose export methods record their requests; every
+// other method is left to the nil embedded interface.
+type exportStub struct {
+	usecase.UseCase
+	req     dto.ExportUsersRequest
+	body    string
+	err     error
+	started bool
+}
+
+func (s *exportStub) Export(_ context.Context, req dto.ExportUsersRequest, w io.Writer) (int, error) {
+	s.req = req
+	_, _ = io.WriteString(w, s.body)
+	return 1, s.err
+}
+
+func (s *exportStub) StartExport(_ context.Context, req dto.ExportUsersRequest) (*dto.UserExportResponse, error) {
+	s.req, s.started = req, true
+	return &dto.UserExportResponse{ID: "export-1", Status: usecase.ExportStatusPending, Format: req.Format}, nil
+}
+
+func TestExport(t *testing.T) {
+	newApp := func(stub *exportStub) *fiber.App {
+		app := fiber.New()
+		app.Get("/users/export", NewHandler(stub).Export)
+		return app
+	}
+	get := func(t *testing.T, stub *exportStub, query string) (*http.Response, string) {
+		t.Helper()
+		resp, err := newApp(stub).Test(httptest.NewRequest(http.MethodGet, "/users/export"+query, nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	t.Run("streams csv by default", func(t *testing.T) {
+		stub := &exportStub{body: "id\n1\n"}
+		resp, body := get(t, stub, "")
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="users.csv"`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, "id\n1\n", body)
+		assert.Equal(t, usecase.ExportFormatCSV, stub.req.Format)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		stub := &exportStub{body: "{}\n"}
+		resp, _ := get(t, stub, "?format=ndjson")
+
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("a failed stream ends with a marker", func(t *testing.T) {
+		stub := &exportStub{body: "{\"id\":\"1\"}\n", err: errors.New("connection reset")}
+		resp, body := get(t, stub, "?format=ndjson")
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "status is already sent when the error occurs")
+		assert.Contains(t, body, `"code":"STREAM_ABORTED"`)
+		assert.NotContains(t, body, "connection reset")
+	})
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		resp, _ := get(t, &exportStub{}, "?format=xlsx")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("async returns 202 with the export id", func(t *testing.T) {
+		stub := &exportStub{}
+		resp, err := newApp(stub).Test(httptest.NewRequest(http.MethodGet, "/users/export?async=true&format=ndjson", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.True(t, stub.started)
+		data := parseResponse(t, resp)["data"].(map[string]interface{})
+		assert.Equal(t, "export-1", data["id"])
+		assert.Equal(t, "ndjson", data["format"])
+	})
+}
+
+// --- Metadata filter Tests ---
+
+// listStub is a UseCase whose List records the request; every other method
+// is left to the nil embedded interface.
+type listStub struct {
+	usecase.UseCase
+	req dto.ListUsersRequest
+}
+
+func (s *listStub) List(_ context.Context, req dto.ListUsersRequest) (shareddomain.CursorPage[dto.UserResponse], error) {
+	s.req = req
+	return shareddomain.CursorPage[dto.UserResponse]{}, nil
+}
+
+func TestList_MetadataFilters(t *testing.T) {
+	types.RegisterFiberDecoders()
+	stub := &listStub{}
+	app := fiber.New()
+	app.Get("/users", NewHandler(stub).List)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?metadata.department=sales&metadata.locale=de&has_metadata=phone&search=jane", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]string{"department": "sales", "locale": "de"}, stub.req.Metadata)
+	assert.Equal(t, "phone", stub.req.HasMetadata)
+	assert.Equal(t, "jane", stub.req.Search.Val)
+}
+
+type batchStub struct {
+	usecase.UseCase
+	req    *dto.BatchCreateUsersRequest
+	failed int
+}
+
+func (s *batchStub) CreateBatch(_ context.Context, req dto.BatchCreateUsersRequest) (*dto.BatchCreateUsersResponse, error) {
+	s.req = &req
+	return &dto.BatchCreateUsersResponse{Created: len(req.Users) - s.failed, Failed: s.failed}, nil
+}
+
+func TestCreateBatch(t *testing.T) {
+	post := func(stub *batchStub, body string) *http.Response {
+		h := NewHandler(stub)
+		app := fiber.New()
+		app.Post("/users/batch", h.CreateBatch)
+		req := httptest.NewRequest(http.MethodPost, "/users/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	const valid = `{"email":"a@example.com","password":"password123","name":"User A"}`
+
+	t.Run("all created", func(t *testing.T) {
+		stub := &batchStub{}
+		resp := post(stub, `{"users":[`+valid+`],"atomic":true}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NotNil(t, stub.req)
+		assert.True(t, stub.req.Atomic)
+		assert.Len(t, stub.req.Users, 1)
+	})
+
+	t.Run("some failed", func(t *testing.T) {
+		resp := post(&batchStub{failed: 1}, `{"users":[`+valid+`,`+valid+`]}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("invalid item rejects the batch", func(t *testing.T) {
+		stub := &batchStub{}
+		resp := post(stub, `{"users":[`+valid+`,{"email":"nope","password":"password123","name":"B"}]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Nil(t, stub.req)
+		details := parseResponse(t, resp)["error"].(map[string]any)["details"].(map[string]any)
+		assert.Contains(t, details, "users[1].email")
+		assert.Contains(t, details, "users[1].name")
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		resp := post(&batchStub{}, `{"users":[]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+type preferencesStub struct {
+	usecase.UseCase
+	userID string
+	req    dto.UpdatePreferencesRequest
+}
+
+func (s *preferencesStub) GetPreferences(_ context.Context, userID string) (*dto.PreferencesResponse, error) {
+	s.userID = userID
+	return &dto.PreferencesResponse{Locale: "en", Timezone: "UTC"}, nil
+}
+
+func (s *preferencesStub) UpdatePreferences(_ context.Context, userID string, req dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error) {
+	s.userID, s.req = userID, req
+	return &dto.PreferencesResponse{Locale: req.Locale.GetOr("en"), Timezone: "UTC"}, nil
+}
+
+func TestPreferences(t *testing.T) {
+	const userID = "01234567-89ab-cdef-0123-456789abcdef"
+
+	newApp := func(stub *preferencesStub, authenticated bool) *fiber.App {
+		h := NewHandler(stub)
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			if authenticated {
+				c.Locals("user_id", userID)
+			}
+			return c.Next()
+		})
+		app.Get("/users/me/preferences", h.GetPreferences)
+		app.Put("/users/me/preferences", h.UpdatePreferences)
+		return app
+	}
+
+	t.Run("get", func(t *testing.T) {
+		stub := &preferencesStub{}
+		resp, err := newApp(stub, true).Test(httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, userID, stub.userID)
+	})
+
+	t.Run("put", func(t *testing.T) {
+		stub := &preferencesStub{}
+		req := httptest.NewRequest(http.MethodPut, "/users/me/preferences",
+			strings.NewReader(`{"locale":"pt-BR","notifications":{"push":false}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(stub, true).Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, types.Some("pt-BR"), stub.req.Locale)
+		assert.Equal(t, types.Some(false), stub.req.Notifications.Push)
+		assert.False(t, stub.req.Timezone.Set)
+		assert.Equal(t, "pt-BR", parseResponse(t, resp)["data"].(map[string]any)["locale"])
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		resp, err := newApp(&preferencesStub{}, false).Test(httptest.NewRequest(http.MethodGet, "/users/me/preferences", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+type forceResetStub struct {
+	usecase.UseCase
+	id  string
+	req dto.ForcePasswordResetRequest
+}
+
+func (s *forceResetStub) ForcePasswordReset(_ context.Context, id string, req dto.ForcePasswordResetRequest) (*dto.ForcePasswordResetResponse, error) {
+	s.id, s.req = id, req
+	resp := &dto.ForcePasswordResetResponse{UserID: id, MustChangePassword: true, SessionsRevoked: true}
+	if req.TemporaryPassword {
+		resp.TemporaryPassword = "temporary"
+	}
+	return resp, nil
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	const userID = "01234567-89ab-cdef-0123-456789abcdef"
+
+	newApp := func(stub *forceResetStub) *fiber.App {
+		app := fiber.New()
+		app.Post("/users/:id/force-password-reset", NewHandler(stub).ForcePasswordReset)
+		return app
+	}
+
+	t.Run("empty body", func(t *testing.T) {
+		stub := &forceResetStub{}
+		resp, err := newApp(stub).Test(httptest.NewRequest(http.MethodPost, "/users/"+userID+"/force-password-reset", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, userID, stub.id)
+		assert.False(t, stub.req.TemporaryPassword)
+		data := parseResponse(t, resp)["data"].(map[string]any)
+		assert.Equal(t, true, data["must_change_password"])
+		assert.NotContains(t, data, "temporary_password")
+	})
+
+	t.Run("temporary password", func(t *testing.T) {
+		stub := &forceResetStub{}
+		req := httptest.NewRequest(http.MethodPost, "/users/"+userID+"/force-password-reset",
+			strings.NewReader(`{"temporary_password":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(stub).Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, stub.req.TemporaryPassword)
+		assert.Equal(t, "temporary", parseResponse(t, resp)["data"].(map[string]any)["temporary_password"])
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+userID+"/force-password-reset",
+			strings.NewReader(`{"temporary_password":`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(&forceResetStub{}).Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+type impersonateStub struct {
+	usecase.UseCase
+	actorID, id string
+}
+
+func (s *impersonateStub) Update(_ context.Context, id string, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
+	s.id = id
+	return &dto.UserResponse{ID: id, Email: req.Email, Name: req.Name}, nil
+}
+
+func (s *impersonateStub) Impersonate(_ context.Context, actorID, id string) (*dto.ImpersonationResponse, error) {
+	s.actorID, s.id = actorID, id
+	return &dto.ImpersonationResponse{AccessToken: "tok", TokenType: "Bearer", UserID: id, ActorID: actorID}, nil
+}
+
+func TestImpersonate(t *testing.T) {
+	const adminID = "admin-1"
+	const userID = "01234567-89ab-cdef-0123-456789abcdef"
+
+	newApp := func(stub *impersonateStub, actorID string) *fiber.App {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", adminID)
+			if actorID != "" {
+				c.Locals("actor_id", actorID)
+			}
+			return c.Next()
+		})
+		app.Post("/users/:id/impersonate", NewHandler(stub).Impersonate)
+		app.Post("/users/me/password", NewHandler(stub).ChangePassword)
+		app.Put("/users/:id", NewHandler(stub).Update)
+		return app
+	}
+
+	t.Run("issues a token to the caller", func(t *testing.T) {
+		stub := &impersonateStub{}
+		resp, err := newApp(stub, "").Test(httptest.NewRequest(http.MethodPost, "/users/"+userID+"/impersonate", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, adminID, stub.actorID)
+		assert.Equal(t, userID, stub.id)
+		assert.Equal(t, "tok", parseResponse(t, resp)["data"].(map[string]any)["access_token"])
+	})
+
+	t.Run("refused while impersonating", func(t *testing.T) {
+		stub := &impersonateStub{}
+		resp, err := newApp(stub, "root-1").Test(httptest.NewRequest(http.MethodPost, "/users/"+userID+"/impersonate", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, stub.id)
+	})
+
+	t.Run("email change refused while impersonating", func(t *testing.T) {
+		update := func(body string) *http.Request {
+			req := httptest.NewRequest(http.MethodPut, "/users/"+userID, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			return req
+		}
+		stub := &impersonateStub{}
+		resp, err := newApp(stub, "root-1").Test(update(`{"email":"new@example.com"}`))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, stub.id)
+
+		resp, err = newApp(stub, "root-1").Test(update(`{"name":"New Name"}`))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "other fields can still be changed")
+	})
+
+	t.Run("password change refused while impersonating", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users/me/password",
+			strings.NewReader(`{"current_password":"x","new_password":"newpassword"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp(&impersonateStub{}, "root-1").Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -134,6 +134,37 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, http.StatusConflict, resp.StatusCode)
 	})
 
+	t.Run("batch create reports each item", func(t *testing.T) {
+		item := func(email string) map[string]string {
+			return map[string]string{"email": email, "password": "StrongPass123!", "name": "Batch User"}
+		}
+		body, _ := json.Marshal(map[string]any{
+			"users": []map[string]string{
+				item("batch1@example.com"),
+				item("newuser@example.com"), // taken above
+				item("batch2@example.com"),
+				item("batch2@example.com"), // repeated in the batch
+			},
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/users/batch", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+env.accessToken)
+
+		resp, err := env.app.Test(req, -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		data := parseJSON(t, resp)["data"].(map[string]interface{})
+		assert.Equal(t, float64(2), data["created"])
+		assert.Equal(t, float64(2), data["failed"])
+		var statuses []string
+		for _, r := range data["results"].([]interface{}) {
+			statuses = append(statuses, r.(map[string]interface{})["status"].(string))
+		}
+		assert.Equal(t, []string{"created", "failed", "created", "failed"}, statuses)
+	})
+
 	t.Run("create user without auth returns 401", func(t *testing.T) {
 		body, _ := json.Marshal(map[string]string{
 			"email":    "unauth@example.com",
@@ -6,54 +6,77 @@ import (
 	"github.com/14mdzk/goscratch/internal/module/user/usecase"
 	"github.com/14mdzk/goscratch/internal/platform/database"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/gofiber/fiber/v2"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Module represents the user module
 type Module struct {
-	handler    *handler.Handler
-	authorizer port.Authorizer
-	jwtSecret  string
+	handler *handler.Handler
+	routes  routes.Config
+	authCfg middleware.AuthConfig
 }
 
 // NewModule creates a new user module.
+// repo is shared with the auth module so both use the same pool and
+// repository options (e.g. the per-query timeout).
 // authRevoker is the auth module's session-revocation interface, injected so
-// ChangePassword can terminate all active refresh tokens for the user without
-// importing the auth package (avoiding a circular dependency).
-func NewModule(pool *pgxpool.Pool, transactor *database.Transactor, auditor port.Auditor, authorizer port.Authorizer, cache port.Cache, jwtSecret string, authRevoker usecase.AuthRevoker) *Module {
-	repo := repository.NewRepository(pool)
-	uc := usecase.NewUseCase(repo, transactor, cache, authRevoker)
+// ChangePassword and Merge can terminate all active refresh tokens for a user
+// without importing the auth package (avoiding a circular dependency).
+// verifier emails new addresses a verification link; nil when email
+// verification is disabled.
+// emailChanger emails new addresses a confirmation link and keeps them
+// pending until it is used; nil when email change confirmation is disabled,
+// which applies new addresses at once.
+// impersonator is the auth module's impersonation token issuer.
+// exports enables asynchronous exports generated by the worker; nil when no
+// job publisher is available.
+// events publishes user lifecycle events; nil when they are disabled.
+// routeCfg supplies the authorizer and registry used by the route builder.
+func NewModule(repo *repository.Repository, transactor *database.Transactor, auditor port.Auditor, authorizer port.Authorizer, cache port.Cache, authCfg middleware.AuthConfig, authRevoker usecase.AuthRevoker, verifier usecase.EmailVerifier, emailChanger usecase.EmailChanger, impersonator usecase.Impersonator, exports *usecase.AsyncExport, events *usecase.LifecycleEvents, routeCfg routes.Config) *Module {
+	uc := usecase.NewUseCase(repo, transactor, cache, authRevoker, authorizer, verifier, emailChanger, impersonator, exports, events)
 	audited := usecase.NewAuditedUseCase(uc, auditor)
 	h := handler.NewHandler(audited)
 
 	return &Module{
-		handler:    h,
-		authorizer: authorizer,
-		jwtSecret:  jwtSecret,
+		handler: h,
+		routes:  routeCfg,
+		authCfg: authCfg,
 	}
 }
 
 // RegisterRoutes registers user module routes
 func (m *Module) RegisterRoutes(router fiber.Router) {
-	authMiddleware := middleware.Auth(middleware.DefaultAuthConfig(m.jwtSecret))
+	authMiddleware := middleware.Auth(m.authCfg)
 
-	users := router.Group("/users")
-
-	// Protected routes - require authentication
-	users.Use(authMiddleware)
+	r := routes.New(router, m.routes)
+	users := r.Group("/users").Authenticated(authMiddleware)
 
 	// User self-management (no permission required beyond auth)
 	users.Get("/me", m.handler.GetMe)
 	users.Post("/me/password", m.handler.ChangePassword)
+	users.Get("/me/preferences", m.handler.GetPreferences)
+	users.Put("/me/preferences", m.handler.UpdatePreferences)
 
 	// User management - require specific permissions
-	users.Get("/", middleware.RequirePermission(m.authorizer, "users", "read"), m.handler.List)
-	users.Get("/:id", middleware.RequirePermission(m.authorizer, "users", "read"), m.handler.GetByID)
-	users.Post("/", middleware.RequirePermission(m.authorizer, "users", "create"), m.handler.Create)
-	users.Put("/:id", middleware.RequirePermission(m.authorizer, "users", "update"), m.handler.Update)
-	users.Delete("/:id", middleware.RequirePermission(m.authorizer, "users", "delete"), m.handler.Delete)
-	users.Post("/:id/activate", middleware.RequirePermission(m.authorizer, "users", "update"), m.handler.Activate)
-	users.Post("/:id/deactivate", middleware.RequirePermission(m.authorizer, "users", "update"), m.handler.Deactivate)
+	users.Get("/", m.handler.List).Require("users:read")
+	users.Get("/inactive", m.handler.ListInactive).Require("users:read")
+	users.Get("/export", m.handler.Export).Require("users:read")
+	users.Get("/exports/:id", m.handler.GetExport).Require("users:read")
+	users.Get("/:id", m.handler.GetByID).Require("users:read").OwnedBy(middleware.OwnerFromParam("id"))
+	users.Get("/:id/logins", m.handler.ListLogins).Require("users:read")
+	users.Post("/", m.handler.Create).Require("users:create")
+	users.Post("/batch", m.handler.CreateBatch).Require("users:create")
+	users.Put("/:id", m.handler.Update).Require("users:update")
+	users.Delete("/:id", m.handler.Delete).Require("users:delete")
+	users.Post("/:id/restore", m.handler.Restore).Require("users:delete")
+	users.Delete("/:id/purge", m.handler.Purge).Require("users:purge")
+	users.Post("/:id/activate", m.handler.Activate).Require("users:update")
+	users.Post("/:id/deactivate", m.handler.Deactivate).Require("users:update")
+	users.Post("/:id/force-password-reset", m.handler.ForcePasswordReset).Require("users:update")
+	users.Post("/:id/merge", m.handler.Merge).Require("users:merge")
+	users.Post("/:id/impersonate", m.handler.Impersonate).Require("users:impersonate")
+
+	r.Mount()
 }
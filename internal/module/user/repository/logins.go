@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/repository/sqlc"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RecordLogin stores login as the user's latest login and adds it to their
+// login history, trimmed to the newest keep entries. With keep <= 0 only the
+// latest login is stored. Unknown users are ignored.
+func (r *Repository) RecordLogin(ctx context.Context, login domain.Login, keep int) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("insert", "user_logins", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "RecordUserLogin", "user_logins")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid := pgutil.UUIDToPgtype(login.UserID)
+	at := pgtype.Timestamptz{Time: login.LoggedInAt, Valid: true}
+	ip := pgtype.Text{String: login.IPAddress, Valid: login.IPAddress != ""}
+
+	q := r.queries(ctx)
+	if err := q.RecordUserLastLogin(ctx, sqlc.RecordUserLastLoginParams{
+		LoggedInAt: at,
+		IpAddress:  ip,
+		ID:         uid,
+	}); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to record last login: %w", err)
+	}
+	if keep <= 0 {
+		return nil
+	}
+
+	err := q.InsertUserLogin(ctx, sqlc.InsertUserLoginParams{
+		UserID:     uid,
+		IpAddress:  ip,
+		UserAgent:  pgtype.Text{String: login.UserAgent, Valid: login.UserAgent != ""},
+		LoggedInAt: at,
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		if pgErr := pgutil.PgError(err); pgErr != nil && pgErr.Code == pgutil.SQLStateForeignKeyViolation {
+			return nil // the user was purged meanwhile
+		}
+		return fmt.Errorf("failed to insert login: %w", err)
+	}
+
+	if err := q.TrimUserLogins(ctx, sqlc.TrimUserLoginsParams{UserID: uid, Keep: int32(keep)}); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to trim login history: %w", err)
+	}
+	return nil
+}
+
+// ListLogins returns up to limit of a user's recorded logins, newest first.
+func (r *Repository) ListLogins(ctx context.Context, userID string, limit int) ([]domain.Login, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "user_logins", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ListUserLogins", "user_logins")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", userID)
+	}
+
+	rows, err := r.queries(ctx).ListUserLogins(ctx, sqlc.ListUserLoginsParams{
+		UserID: pgutil.UUIDToPgtype(uid),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list logins: %w", err)
+	}
+
+	logins := make([]domain.Login, 0, len(rows))
+	for _, row := range rows {
+		logins = append(logins, domain.Login{
+			ID:         row.ID,
+			UserID:     pgutil.PgtypeToUUID(row.UserID),
+			IPAddress:  row.IpAddress.String,
+			UserAgent:  row.UserAgent.String,
+			LoggedInAt: row.LoggedInAt.Time,
+		})
+	}
+	return logins, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/repository/sqlc"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/14mdzk/goscratch/pkg/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GetPreferences returns a user's preferences, or domain.DefaultPreferences
+// if they have not changed any.
+func (r *Repository) GetPreferences(ctx context.Context, userID string) (*domain.Preferences, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "user_preferences", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "GetUserPreferences", "user_preferences")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", userID)
+	}
+
+	p, err := r.queries(ctx).GetUserPreferences(ctx, pgutil.UUIDToPgtype(uid))
+	if pgutil.IsNoRows(err) {
+		defaults := domain.DefaultPreferences()
+		return &defaults, nil
+	}
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to get user preferences: %w", err), "preferences of user "+userID)
+	}
+	return sqlcPreferencesToDomain(&p), nil
+}
+
+// UpdatePreferences applies patch to a user's preferences, creating their
+// row with the defaults first if they have none. Fields the patch leaves
+// unset keep their value. The patch is expected to be validated.
+func (r *Repository) UpdatePreferences(ctx context.Context, userID string, patch domain.PreferencesPatch) (*domain.Preferences, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "user_preferences", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "UpdateUserPreferences", "user_preferences")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", userID)
+	}
+
+	q := r.queries(ctx)
+	if err := q.EnsureUserPreferences(ctx, pgutil.UUIDToPgtype(uid)); err != nil {
+		observability.RecordSpanError(ctx, err)
+		if pgErr := pgutil.PgError(err); pgErr != nil && pgErr.Code == pgutil.SQLStateForeignKeyViolation {
+			return nil, apperr.NotFoundf("user %s not found", userID)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to create user preferences: %w", err), "preferences of user "+userID)
+	}
+
+	p, err := q.UpdateUserPreferences(ctx, sqlc.UpdateUserPreferencesParams{
+		Locale:             optText(patch.Locale),
+		Timezone:           optText(patch.Timezone),
+		EmailNotifications: optBool(patch.Email),
+		PushNotifications:  optBool(patch.Push),
+		Digest:             optText(patch.Digest),
+		UserID:             pgutil.UUIDToPgtype(uid),
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to update user preferences: %w", err), "preferences of user "+userID)
+	}
+	return sqlcPreferencesToDomain(&p), nil
+}
+
+func optText(o types.Opt[string]) pgtype.Text {
+	return pgtype.Text{String: o.Val, Valid: o.Set}
+}
+
+func optBool(o types.Opt[bool]) pgtype.Bool {
+	return pgtype.Bool{Bool: o.Val, Valid: o.Set}
+}
+
+func sqlcPreferencesToDomain(p *sqlc.UserPreference) *domain.Preferences {
+	prefs := &domain.Preferences{
+		Locale:   p.Locale,
+		Timezone: p.Timezone,
+		Notifications: domain.NotificationPreferences{
+			Email:  p.EmailNotifications,
+			Push:   p.PushNotifications,
+			Digest: p.Digest,
+		},
+	}
+	if p.UpdatedAt.Valid {
+		prefs.UpdatedAt = &p.UpdatedAt.Time
+	}
+	return prefs
+}
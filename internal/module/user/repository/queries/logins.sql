@@ -0,0 +1,27 @@
+-- name: RecordUserLastLogin :exec
+-- Never moves last_login_at backwards, so late writes are harmless.
+UPDATE users
+SET last_login_at = sqlc.arg(logged_in_at), last_login_ip = sqlc.narg(ip_address)
+WHERE id = sqlc.arg(id) AND (last_login_at IS NULL OR last_login_at < sqlc.arg(logged_in_at));
+
+-- name: InsertUserLogin :exec
+INSERT INTO user_logins (user_id, ip_address, user_agent, logged_in_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: TrimUserLogins :exec
+-- Keeps the user's newest keep logins.
+DELETE FROM user_logins
+WHERE user_id = sqlc.arg(user_id)
+  AND id NOT IN (
+    SELECT id FROM user_logins
+    WHERE user_id = sqlc.arg(user_id)
+    ORDER BY logged_in_at DESC, id DESC
+    LIMIT sqlc.arg(keep)
+  );
+
+-- name: ListUserLogins :many
+SELECT id, user_id, ip_address, user_agent, logged_in_at
+FROM user_logins
+WHERE user_id = $1
+ORDER BY logged_in_at DESC, id DESC
+LIMIT $2;
@@ -0,0 +1,21 @@
+-- name: GetUserPreferences :one
+SELECT user_id, locale, timezone, email_notifications, push_notifications, digest, updated_at
+FROM user_preferences
+WHERE user_id = $1;
+
+-- name: EnsureUserPreferences :exec
+INSERT INTO user_preferences (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: UpdateUserPreferences :one
+-- A NULL argument keeps the current value.
+UPDATE user_preferences
+SET locale = COALESCE(sqlc.narg(locale), locale),
+    timezone = COALESCE(sqlc.narg(timezone), timezone),
+    email_notifications = COALESCE(sqlc.narg(email_notifications), email_notifications),
+    push_notifications = COALESCE(sqlc.narg(push_notifications), push_notifications),
+    digest = COALESCE(sqlc.narg(digest), digest),
+    updated_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+RETURNING user_id, locale, timezone, email_notifications, push_notifications, digest, updated_at;
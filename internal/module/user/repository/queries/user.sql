@@ -1,60 +1,102 @@
 -- name: GetUserByID :one
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
 WHERE id = $1;
 
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
-WHERE email = $1 AND is_active = true;
+WHERE lower(email) = lower($1) AND is_active = true;
 
 -- name: ListUsers :many
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
 WHERE (sqlc.narg(cursor)::uuid IS NULL OR id > sqlc.narg(cursor))
   AND (sqlc.narg(search)::text IS NULL OR name ILIKE '%' || sqlc.narg(search) || '%' OR email ILIKE '%' || sqlc.narg(search) || '%')
-  AND (sqlc.narg(email_filter)::text IS NULL OR email = sqlc.narg(email_filter))
+  AND (sqlc.narg(email_filter)::text IS NULL OR lower(email) = lower(sqlc.narg(email_filter)))
   AND (sqlc.narg(is_active)::bool IS NULL OR is_active = sqlc.narg(is_active))
+  AND (sqlc.narg(role)::text IS NULL OR EXISTS (
+    SELECT 1 FROM casbin_rules
+    WHERE p_type = 'g' AND v0 = users.id::text AND v1 = sqlc.narg(role)
+  ))
+  AND (sqlc.arg(include_deleted)::bool OR deleted_at IS NULL)
+  AND (sqlc.narg(metadata)::jsonb IS NULL OR metadata @> sqlc.narg(metadata))
+  AND (sqlc.narg(metadata_keys)::text[] IS NULL OR metadata ?& sqlc.narg(metadata_keys))
 ORDER BY id ASC
 LIMIT $1;
 
 -- name: ListUsersPrev :many
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
 WHERE (sqlc.narg(cursor)::uuid IS NULL OR id < sqlc.narg(cursor))
   AND (sqlc.narg(search)::text IS NULL OR name ILIKE '%' || sqlc.narg(search) || '%' OR email ILIKE '%' || sqlc.narg(search) || '%')
-  AND (sqlc.narg(email_filter)::text IS NULL OR email = sqlc.narg(email_filter))
+  AND (sqlc.narg(email_filter)::text IS NULL OR lower(email) = lower(sqlc.narg(email_filter)))
   AND (sqlc.narg(is_active)::bool IS NULL OR is_active = sqlc.narg(is_active))
+  AND (sqlc.narg(role)::text IS NULL OR EXISTS (
+    SELECT 1 FROM casbin_rules
+    WHERE p_type = 'g' AND v0 = users.id::text AND v1 = sqlc.narg(role)
+  ))
+  AND (sqlc.arg(include_deleted)::bool OR deleted_at IS NULL)
+  AND (sqlc.narg(metadata)::jsonb IS NULL OR metadata @> sqlc.narg(metadata))
+  AND (sqlc.narg(metadata_keys)::text[] IS NULL OR metadata ?& sqlc.narg(metadata_keys))
 ORDER BY id DESC
 LIMIT $1;
 
 -- name: CreateUser :one
 INSERT INTO users (email, password_hash, name, is_active)
 VALUES ($1, $2, $3, true)
-RETURNING id, email, password_hash, name, is_active, created_at, updated_at;
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email;
 
 -- name: UpdateUser :one
 UPDATE users
 SET name = COALESCE(NULLIF($2, ''), name),
     email = COALESCE(NULLIF($3, ''), email),
+    -- A new address has not been verified yet.
+    email_verified_at = CASE
+        WHEN NULLIF($3, '') IS NULL OR lower($3) = lower(email) THEN email_verified_at
+    END,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, name, is_active, created_at, updated_at;
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email;
+
+-- name: UpdateUserMetadata :one
+UPDATE users
+SET metadata = (metadata || sqlc.arg(set_keys)::jsonb) - sqlc.arg(unset_keys)::text[],
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email;
 
 -- name: UpdatePassword :exec
 UPDATE users
-SET password_hash = $2, updated_at = NOW()
+SET password_hash = $2, must_change_password = false, updated_at = NOW()
 WHERE id = $1 AND is_active = true;
 
+-- name: ForcePasswordReset :execrows
+-- A NULL password_hash keeps the current password.
+UPDATE users
+SET must_change_password = true,
+    password_hash = COALESCE(sqlc.narg(password_hash), password_hash),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
 -- name: DeleteUser :exec
 UPDATE users
-SET is_active = false, updated_at = NOW()
+SET is_active = false, deleted_at = COALESCE(deleted_at, NOW()), updated_at = NOW()
 WHERE id = $1;
 
+-- name: RestoreUser :execrows
+UPDATE users
+SET is_active = true, deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: PurgeUser :execrows
+DELETE FROM users
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
 -- name: ActivateUser :exec
 UPDATE users
 SET is_active = true, updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: DeactivateUser :exec
 UPDATE users
@@ -66,4 +108,56 @@ SELECT COUNT(*) FROM users
 WHERE (sqlc.narg(is_active)::bool IS NULL OR is_active = sqlc.narg(is_active));
 
 -- name: UserExistsByEmail :one
-SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND is_active = true);
+SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1) AND is_active = true);
+
+-- name: TouchUserLastSeen :exec
+UPDATE users
+SET last_seen_at = $2
+WHERE id = $1 AND (last_seen_at IS NULL OR last_seen_at < $2);
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND lower(email) = lower($2) AND is_active = true AND email_verified_at IS NULL;
+
+-- name: SetUserPendingEmail :one
+-- A NULL pending_email cancels a pending change.
+UPDATE users
+SET pending_email = sqlc.narg(pending_email), updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email;
+
+-- name: ApplyUserPendingEmail :execrows
+-- The confirmed address counts as verified.
+UPDATE users
+SET email = pending_email, pending_email = NULL, email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND lower(pending_email) = lower($2) AND is_active = true;
+
+-- name: ListInactiveUsers :many
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
+FROM users
+WHERE is_active = true
+  AND COALESCE(last_seen_at, created_at) < sqlc.arg(seen_before)::timestamptz
+ORDER BY COALESCE(last_seen_at, created_at) ASC, id ASC
+LIMIT $1;
+
+-- name: ReassignUserAuditLogs :execrows
+UPDATE audit_logs
+SET user_id = @target_id
+WHERE user_id = @source_id AND chain_seq IS NULL;
+
+-- name: ListUserRoleGrants :many
+SELECT v1 FROM casbin_rules
+WHERE p_type = 'g' AND v0 = $1
+ORDER BY v1;
+
+-- name: CopyUserAuthzRules :execrows
+INSERT INTO casbin_rules (p_type, v0, v1, v2, v3, v4, v5)
+SELECT p_type, @target_id::text, v1, v2, v3, v4, v5
+FROM casbin_rules
+WHERE v0 = @source_id AND p_type IN ('p', 'g')
+ON CONFLICT (p_type, v0, v1, v2, v3, v4, v5) DO NOTHING;
+
+-- name: DeleteUserAuthzRules :execrows
+DELETE FROM casbin_rules
+WHERE v0 = $1 AND p_type IN ('p', 'g');
@@ -36,11 +36,37 @@ type CasbinRule struct {
 }
 
 type User struct {
-	ID           pgtype.UUID        `db:"id" json:"id"`
-	Email        string             `db:"email" json:"email"`
-	PasswordHash string             `db:"password_hash" json:"password_hash"`
-	Name         string             `db:"name" json:"name"`
-	IsActive     pgtype.Bool        `db:"is_active" json:"is_active"`
-	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	ID                 pgtype.UUID        `db:"id" json:"id"`
+	Email              string             `db:"email" json:"email"`
+	PasswordHash       string             `db:"password_hash" json:"password_hash"`
+	Name               string             `db:"name" json:"name"`
+	IsActive           pgtype.Bool        `db:"is_active" json:"is_active"`
+	CreatedAt          pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	LastSeenAt         pgtype.Timestamptz `db:"last_seen_at" json:"last_seen_at"`
+	EmailVerifiedAt    pgtype.Timestamptz `db:"email_verified_at" json:"email_verified_at"`
+	DeletedAt          pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	Metadata           []byte             `db:"metadata" json:"metadata"`
+	MustChangePassword bool               `db:"must_change_password" json:"must_change_password"`
+	LastLoginAt        pgtype.Timestamptz `db:"last_login_at" json:"last_login_at"`
+	LastLoginIp        pgtype.Text        `db:"last_login_ip" json:"last_login_ip"`
+	PendingEmail       pgtype.Text        `db:"pending_email" json:"pending_email"`
+}
+
+type UserLogin struct {
+	ID         int64              `db:"id" json:"id"`
+	UserID     pgtype.UUID        `db:"user_id" json:"user_id"`
+	IpAddress  pgtype.Text        `db:"ip_address" json:"ip_address"`
+	UserAgent  pgtype.Text        `db:"user_agent" json:"user_agent"`
+	LoggedInAt pgtype.Timestamptz `db:"logged_in_at" json:"logged_in_at"`
+}
+
+type UserPreference struct {
+	UserID             pgtype.UUID        `db:"user_id" json:"user_id"`
+	Locale             string             `db:"locale" json:"locale"`
+	Timezone           string             `db:"timezone" json:"timezone"`
+	EmailNotifications bool               `db:"email_notifications" json:"email_notifications"`
+	PushNotifications  bool               `db:"push_notifications" json:"push_notifications"`
+	Digest             string             `db:"digest" json:"digest"`
+	UpdatedAt          pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
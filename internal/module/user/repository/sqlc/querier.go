@@ -12,16 +12,42 @@ import (
 
 type Querier interface {
 	ActivateUser(ctx context.Context, id pgtype.UUID) error
+	// The confirmed address counts as verified.
+	ApplyUserPendingEmail(ctx context.Context, arg ApplyUserPendingEmailParams) (int64, error)
+	CopyUserAuthzRules(ctx context.Context, arg CopyUserAuthzRulesParams) (int64, error)
 	CountUsers(ctx context.Context, isActive pgtype.Bool) (int64, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeactivateUser(ctx context.Context, id pgtype.UUID) error
 	DeleteUser(ctx context.Context, id pgtype.UUID) error
+	DeleteUserAuthzRules(ctx context.Context, v0 string) (int64, error)
+	EnsureUserPreferences(ctx context.Context, userID pgtype.UUID) error
+	// A NULL password_hash keeps the current password.
+	ForcePasswordReset(ctx context.Context, arg ForcePasswordResetParams) (int64, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
+	GetUserPreferences(ctx context.Context, userID pgtype.UUID) (UserPreference, error)
+	InsertUserLogin(ctx context.Context, arg InsertUserLoginParams) error
+	ListInactiveUsers(ctx context.Context, arg ListInactiveUsersParams) ([]User, error)
+	ListUserLogins(ctx context.Context, arg ListUserLoginsParams) ([]UserLogin, error)
+	ListUserRoleGrants(ctx context.Context, v0 string) ([]string, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
 	ListUsersPrev(ctx context.Context, arg ListUsersPrevParams) ([]User, error)
+	MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error)
+	PurgeUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	ReassignUserAuditLogs(ctx context.Context, arg ReassignUserAuditLogsParams) (int64, error)
+	// Never moves last_login_at backwards, so late writes are harmless.
+	RecordUserLastLogin(ctx context.Context, arg RecordUserLastLoginParams) error
+	RestoreUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	// A NULL pending_email cancels a pending change.
+	SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error)
+	TouchUserLastSeen(ctx context.Context, arg TouchUserLastSeenParams) error
+	// Keeps the user's newest keep logins.
+	TrimUserLogins(ctx context.Context, arg TrimUserLoginsParams) error
 	UpdatePassword(ctx context.Context, arg UpdatePasswordParams) error
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserMetadata(ctx context.Context, arg UpdateUserMetadataParams) (User, error)
+	// A NULL argument keeps the current value.
+	UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (UserPreference, error)
 	UserExistsByEmail(ctx context.Context, email string) (bool, error)
 }
 
@@ -14,7 +14,7 @@ import (
 const activateUser = `-- name: ActivateUser :exec
 UPDATE users
 SET is_active = true, updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) ActivateUser(ctx context.Context, id pgtype.UUID) error {
@@ -22,6 +22,47 @@ func (q *Queries) ActivateUser(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const applyUserPendingEmail = `-- name: ApplyUserPendingEmail :execrows
+UPDATE users
+SET email = pending_email, pending_email = NULL, email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND lower(pending_email) = lower($2) AND is_active = true
+`
+
+type ApplyUserPendingEmailParams struct {
+	ID    pgtype.UUID `db:"id" json:"id"`
+	Lower string      `db:"lower" json:"lower"`
+}
+
+// The confirmed address counts as verified.
+func (q *Queries) ApplyUserPendingEmail(ctx context.Context, arg ApplyUserPendingEmailParams) (int64, error) {
+	result, err := q.db.Exec(ctx, applyUserPendingEmail, arg.ID, arg.Lower)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const copyUserAuthzRules = `-- name: CopyUserAuthzRules :execrows
+INSERT INTO casbin_rules (p_type, v0, v1, v2, v3, v4, v5)
+SELECT p_type, $1::text, v1, v2, v3, v4, v5
+FROM casbin_rules
+WHERE v0 = $2 AND p_type IN ('p', 'g')
+ON CONFLICT (p_type, v0, v1, v2, v3, v4, v5) DO NOTHING
+`
+
+type CopyUserAuthzRulesParams struct {
+	TargetID string `db:"target_id" json:"target_id"`
+	SourceID string `db:"source_id" json:"source_id"`
+}
+
+func (q *Queries) CopyUserAuthzRules(ctx context.Context, arg CopyUserAuthzRulesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, copyUserAuthzRules, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const countUsers = `-- name: CountUsers :one
 SELECT COUNT(*) FROM users
 WHERE ($1::bool IS NULL OR is_active = $1)
@@ -37,7 +78,7 @@ func (q *Queries) CountUsers(ctx context.Context, isActive pgtype.Bool) (int64,
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash, name, is_active)
 VALUES ($1, $2, $3, true)
-RETURNING id, email, password_hash, name, is_active, created_at, updated_at
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 `
 
 type CreateUserParams struct {
@@ -57,6 +98,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.Metadata,
+		&i.MustChangePassword,
+		&i.LastLoginAt,
+		&i.LastLoginIp,
+		&i.PendingEmail,
 	)
 	return i, err
 }
@@ -74,7 +123,7 @@ func (q *Queries) DeactivateUser(ctx context.Context, id pgtype.UUID) error {
 
 const deleteUser = `-- name: DeleteUser :exec
 UPDATE users
-SET is_active = false, updated_at = NOW()
+SET is_active = false, deleted_at = COALESCE(deleted_at, NOW()), updated_at = NOW()
 WHERE id = $1
 `
 
@@ -83,10 +132,45 @@ func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const deleteUserAuthzRules = `-- name: DeleteUserAuthzRules :execrows
+DELETE FROM casbin_rules
+WHERE v0 = $1 AND p_type IN ('p', 'g')
+`
+
+func (q *Queries) DeleteUserAuthzRules(ctx context.Context, v0 string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserAuthzRules, v0)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const forcePasswordReset = `-- name: ForcePasswordReset :execrows
+UPDATE users
+SET must_change_password = true,
+    password_hash = COALESCE($1, password_hash),
+    updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL
+`
+
+type ForcePasswordResetParams struct {
+	PasswordHash pgtype.Text `db:"password_hash" json:"password_hash"`
+	ID           pgtype.UUID `db:"id" json:"id"`
+}
+
+// A NULL password_hash keeps the current password.
+func (q *Queries) ForcePasswordReset(ctx context.Context, arg ForcePasswordResetParams) (int64, error) {
+	result, err := q.db.Exec(ctx, forcePasswordReset, arg.PasswordHash, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
-WHERE email = $1 AND is_active = true
+WHERE lower(email) = lower($1) AND is_active = true
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -100,12 +184,20 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.Metadata,
+		&i.MustChangePassword,
+		&i.LastLoginAt,
+		&i.LastLoginIp,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
 WHERE id = $1
 `
@@ -121,27 +213,122 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.Metadata,
+		&i.MustChangePassword,
+		&i.LastLoginAt,
+		&i.LastLoginIp,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
+const listInactiveUsers = `-- name: ListInactiveUsers :many
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
+FROM users
+WHERE is_active = true
+  AND COALESCE(last_seen_at, created_at) < $2::timestamptz
+ORDER BY COALESCE(last_seen_at, created_at) ASC, id ASC
+LIMIT $1
+`
+
+type ListInactiveUsersParams struct {
+	Limit      int32              `db:"limit" json:"limit"`
+	SeenBefore pgtype.Timestamptz `db:"seen_before" json:"seen_before"`
+}
+
+func (q *Queries) ListInactiveUsers(ctx context.Context, arg ListInactiveUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listInactiveUsers, arg.Limit, arg.SeenBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.Name,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastSeenAt,
+			&i.EmailVerifiedAt,
+			&i.DeletedAt,
+			&i.Metadata,
+			&i.MustChangePassword,
+			&i.LastLoginAt,
+			&i.LastLoginIp,
+			&i.PendingEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoleGrants = `-- name: ListUserRoleGrants :many
+SELECT v1 FROM casbin_rules
+WHERE p_type = 'g' AND v0 = $1
+ORDER BY v1
+`
+
+func (q *Queries) ListUserRoleGrants(ctx context.Context, v0 string) ([]string, error) {
+	rows, err := q.db.Query(ctx, listUserRoleGrants, v0)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var v1 string
+		if err := rows.Scan(&v1); err != nil {
+			return nil, err
+		}
+		items = append(items, v1)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
 WHERE ($2::uuid IS NULL OR id > $2)
   AND ($3::text IS NULL OR name ILIKE '%' || $3 || '%' OR email ILIKE '%' || $3 || '%')
-  AND ($4::text IS NULL OR email = $4)
+  AND ($4::text IS NULL OR lower(email) = lower($4))
   AND ($5::bool IS NULL OR is_active = $5)
+  AND ($6::text IS NULL OR EXISTS (
+    SELECT 1 FROM casbin_rules
+    WHERE p_type = 'g' AND v0 = users.id::text AND v1 = $6
+  ))
+  AND ($7::bool OR deleted_at IS NULL)
+  AND ($8::jsonb IS NULL OR metadata @> $8)
+  AND ($9::text[] IS NULL OR metadata ?& $9)
 ORDER BY id ASC
 LIMIT $1
 `
 
 type ListUsersParams struct {
-	Limit       int32       `db:"limit" json:"limit"`
-	Cursor      pgtype.UUID `db:"cursor" json:"cursor"`
-	Search      pgtype.Text `db:"search" json:"search"`
-	EmailFilter pgtype.Text `db:"email_filter" json:"email_filter"`
-	IsActive    pgtype.Bool `db:"is_active" json:"is_active"`
+	Limit          int32       `db:"limit" json:"limit"`
+	Cursor         pgtype.UUID `db:"cursor" json:"cursor"`
+	Search         pgtype.Text `db:"search" json:"search"`
+	EmailFilter    pgtype.Text `db:"email_filter" json:"email_filter"`
+	IsActive       pgtype.Bool `db:"is_active" json:"is_active"`
+	Role           pgtype.Text `db:"role" json:"role"`
+	IncludeDeleted bool        `db:"include_deleted" json:"include_deleted"`
+	Metadata       []byte      `db:"metadata" json:"metadata"`
+	MetadataKeys   []string    `db:"metadata_keys" json:"metadata_keys"`
 }
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
@@ -151,6 +338,10 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 		arg.Search,
 		arg.EmailFilter,
 		arg.IsActive,
+		arg.Role,
+		arg.IncludeDeleted,
+		arg.Metadata,
+		arg.MetadataKeys,
 	)
 	if err != nil {
 		return nil, err
@@ -167,6 +358,14 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LastSeenAt,
+			&i.EmailVerifiedAt,
+			&i.DeletedAt,
+			&i.Metadata,
+			&i.MustChangePassword,
+			&i.LastLoginAt,
+			&i.LastLoginIp,
+			&i.PendingEmail,
 		); err != nil {
 			return nil, err
 		}
@@ -179,22 +378,33 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 }
 
 const listUsersPrev = `-- name: ListUsersPrev :many
-SELECT id, email, password_hash, name, is_active, created_at, updated_at
+SELECT id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 FROM users
 WHERE ($2::uuid IS NULL OR id < $2)
   AND ($3::text IS NULL OR name ILIKE '%' || $3 || '%' OR email ILIKE '%' || $3 || '%')
-  AND ($4::text IS NULL OR email = $4)
+  AND ($4::text IS NULL OR lower(email) = lower($4))
   AND ($5::bool IS NULL OR is_active = $5)
+  AND ($6::text IS NULL OR EXISTS (
+    SELECT 1 FROM casbin_rules
+    WHERE p_type = 'g' AND v0 = users.id::text AND v1 = $6
+  ))
+  AND ($7::bool OR deleted_at IS NULL)
+  AND ($8::jsonb IS NULL OR metadata @> $8)
+  AND ($9::text[] IS NULL OR metadata ?& $9)
 ORDER BY id DESC
 LIMIT $1
 `
 
 type ListUsersPrevParams struct {
-	Limit       int32       `db:"limit" json:"limit"`
-	Cursor      pgtype.UUID `db:"cursor" json:"cursor"`
-	Search      pgtype.Text `db:"search" json:"search"`
-	EmailFilter pgtype.Text `db:"email_filter" json:"email_filter"`
-	IsActive    pgtype.Bool `db:"is_active" json:"is_active"`
+	Limit          int32       `db:"limit" json:"limit"`
+	Cursor         pgtype.UUID `db:"cursor" json:"cursor"`
+	Search         pgtype.Text `db:"search" json:"search"`
+	EmailFilter    pgtype.Text `db:"email_filter" json:"email_filter"`
+	IsActive       pgtype.Bool `db:"is_active" json:"is_active"`
+	Role           pgtype.Text `db:"role" json:"role"`
+	IncludeDeleted bool        `db:"include_deleted" json:"include_deleted"`
+	Metadata       []byte      `db:"metadata" json:"metadata"`
+	MetadataKeys   []string    `db:"metadata_keys" json:"metadata_keys"`
 }
 
 func (q *Queries) ListUsersPrev(ctx context.Context, arg ListUsersPrevParams) ([]User, error) {
@@ -204,6 +414,10 @@ func (q *Queries) ListUsersPrev(ctx context.Context, arg ListUsersPrevParams) ([
 		arg.Search,
 		arg.EmailFilter,
 		arg.IsActive,
+		arg.Role,
+		arg.IncludeDeleted,
+		arg.Metadata,
+		arg.MetadataKeys,
 	)
 	if err != nil {
 		return nil, err
@@ -220,6 +434,14 @@ func (q *Queries) ListUsersPrev(ctx context.Context, arg ListUsersPrevParams) ([
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LastSeenAt,
+			&i.EmailVerifiedAt,
+			&i.DeletedAt,
+			&i.Metadata,
+			&i.MustChangePassword,
+			&i.LastLoginAt,
+			&i.LastLoginIp,
+			&i.PendingEmail,
 		); err != nil {
 			return nil, err
 		}
@@ -231,9 +453,126 @@ func (q *Queries) ListUsersPrev(ctx context.Context, arg ListUsersPrevParams) ([
 	return items, nil
 }
 
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :execrows
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND lower(email) = lower($2) AND is_active = true AND email_verified_at IS NULL
+`
+
+type MarkUserEmailVerifiedParams struct {
+	ID    pgtype.UUID `db:"id" json:"id"`
+	Lower string      `db:"lower" json:"lower"`
+}
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markUserEmailVerified, arg.ID, arg.Lower)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeUser = `-- name: PurgeUser :execrows
+DELETE FROM users
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) PurgeUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reassignUserAuditLogs = `-- name: ReassignUserAuditLogs :execrows
+UPDATE audit_logs
+SET user_id = $1
+WHERE user_id = $2 AND chain_seq IS NULL
+`
+
+type ReassignUserAuditLogsParams struct {
+	TargetID pgtype.UUID `db:"target_id" json:"target_id"`
+	SourceID pgtype.UUID `db:"source_id" json:"source_id"`
+}
+
+func (q *Queries) ReassignUserAuditLogs(ctx context.Context, arg ReassignUserAuditLogsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignUserAuditLogs, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE users
+SET is_active = true, deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserPendingEmail = `-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
+`
+
+type SetUserPendingEmailParams struct {
+	ID           pgtype.UUID `db:"id" json:"id"`
+	PendingEmail pgtype.Text `db:"pending_email" json:"pending_email"`
+}
+
+// A NULL pending_email cancels a pending change.
+func (q *Queries) SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserPendingEmail, arg.ID, arg.PendingEmail)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.Metadata,
+		&i.MustChangePassword,
+		&i.LastLoginAt,
+		&i.LastLoginIp,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const touchUserLastSeen = `-- name: TouchUserLastSeen :exec
+UPDATE users
+SET last_seen_at = $2
+WHERE id = $1 AND (last_seen_at IS NULL OR last_seen_at < $2)
+`
+
+type TouchUserLastSeenParams struct {
+	ID         pgtype.UUID        `db:"id" json:"id"`
+	LastSeenAt pgtype.Timestamptz `db:"last_seen_at" json:"last_seen_at"`
+}
+
+func (q *Queries) TouchUserLastSeen(ctx context.Context, arg TouchUserLastSeenParams) error {
+	_, err := q.db.Exec(ctx, touchUserLastSeen, arg.ID, arg.LastSeenAt)
+	return err
+}
+
 const updatePassword = `-- name: UpdatePassword :exec
 UPDATE users
-SET password_hash = $2, updated_at = NOW()
+SET password_hash = $2, must_change_password = false, updated_at = NOW()
 WHERE id = $1 AND is_active = true
 `
 
@@ -251,9 +590,13 @@ const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET name = COALESCE(NULLIF($2, ''), name),
     email = COALESCE(NULLIF($3, ''), email),
+    -- A new address has not been verified yet.
+    email_verified_at = CASE
+        WHEN NULLIF($3, '') IS NULL OR lower($3) = lower(email) THEN email_verified_at
+    END,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, name, is_active, created_at, updated_at
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
 `
 
 type UpdateUserParams struct {
@@ -273,12 +616,57 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.Metadata,
+		&i.MustChangePassword,
+		&i.LastLoginAt,
+		&i.LastLoginIp,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const updateUserMetadata = `-- name: UpdateUserMetadata :one
+UPDATE users
+SET metadata = (metadata || $1::jsonb) - $2::text[],
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email
+`
+
+type UpdateUserMetadataParams struct {
+	SetKeys   []byte      `db:"set_keys" json:"set_keys"`
+	UnsetKeys []string    `db:"unset_keys" json:"unset_keys"`
+	ID        pgtype.UUID `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateUserMetadata(ctx context.Context, arg UpdateUserMetadataParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserMetadata, arg.SetKeys, arg.UnsetKeys, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.Metadata,
+		&i.MustChangePassword,
+		&i.LastLoginAt,
+		&i.LastLoginIp,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
 const userExistsByEmail = `-- name: UserExistsByEmail :one
-SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND is_active = true)
+SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1) AND is_active = true)
 `
 
 func (q *Queries) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
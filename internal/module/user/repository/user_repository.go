@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
+	"iter"
+	"strings"
 	"time"
 
 	"github.com/14mdzk/goscratch/internal/module/user/domain"
@@ -22,12 +24,44 @@ import (
 // It is TX-aware: if a pgx.Tx is present in the context (placed there by
 // database.Transactor.WithTx), all SQL operations run within that transaction.
 type Repository struct {
-	pool *pgxpool.Pool
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+	gmailAliases bool
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithQueryTimeout bounds every query issued by the repository to d (see
+// database.WithQueryTimeout). Zero disables the per-query deadline.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(r *Repository) {
+		r.queryTimeout = d
+	}
+}
+
+// WithGmailAliases folds Gmail dot and "+tag" spellings of an address into one
+// account (see domain.NormalizeEmail).
+func WithGmailAliases(enabled bool) Option {
+	return func(r *Repository) {
+		r.gmailAliases = enabled
+	}
 }
 
 // NewRepository creates a new user repository
-func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+func NewRepository(pool *pgxpool.Pool, opts ...Option) *Repository {
+	r := &Repository{pool: pool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NormalizeEmail returns the canonical form the repository stores and looks
+// up email, honouring WithGmailAliases. Every method taking an email applies
+// it, so callers may pass addresses as the user typed them.
+func (r *Repository) NormalizeEmail(email string) string {
+	return domain.NormalizeEmail(email, r.gmailAliases)
 }
 
 // queries returns a *sqlc.Queries bound to the transaction in ctx, or to the
@@ -46,6 +80,9 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*domain.User, erro
 	ctx, span := observability.WrapDBOperation(ctx, "GetUserByID", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return nil, apperr.NotFoundf("user %s not found", id)
@@ -53,12 +90,11 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*domain.User, erro
 
 	pgUUID := pgutil.UUIDToPgtype(uid)
 	user, err := r.queries(ctx).GetUserByID(ctx, pgUUID)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, apperr.NotFoundf("user %s not found", id)
-	}
 	if err != nil {
-		observability.RecordSpanError(ctx, err)
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to get user: %w", err), "user "+id)
 	}
 
 	return sqlcUserToDomain(&user), nil
@@ -74,13 +110,16 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*domain.User
 	ctx, span := observability.WrapDBOperation(ctx, "GetUserByEmail", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	email = r.NormalizeEmail(email)
 	user, err := r.queries(ctx).GetUserByEmail(ctx, email)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, apperr.NotFoundf("user with email %s not found", email)
-	}
 	if err != nil {
-		observability.RecordSpanError(ctx, err)
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to get user: %w", err), "user with email "+email)
 	}
 
 	return sqlcUserToDomain(&user), nil
@@ -96,6 +135,9 @@ func (r *Repository) List(ctx context.Context, filter domain.UserFilter) ([]doma
 	ctx, span := observability.WrapDBOperation(ctx, "ListUsers", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	// Normalize filter
 	filter.NormalizeFilter()
 
@@ -107,42 +149,44 @@ func (r *Repository) List(ctx context.Context, filter domain.UserFilter) ([]doma
 
 	// Build common filter params
 	cursorUUID := pgutil.NullableUUID(filter.Cursor)
-	var searchParam, emailParam pgtype.Text
-	var isActiveParam pgtype.Bool
-
-	if filter.Search.Set && filter.Search.Val != "" {
-		searchParam = pgtype.Text{String: filter.Search.Val, Valid: true}
-	}
-	if filter.Email.Set && filter.Email.Val != "" {
-		emailParam = pgtype.Text{String: filter.Email.Val, Valid: true}
-	}
-	if filter.IsActive.Set {
-		isActiveParam = pgtype.Bool{Bool: filter.IsActive.Val, Valid: true}
+	params, err := r.filterParams(filter)
+	if err != nil {
+		return nil, err
 	}
 
 	var users []sqlc.User
-	var err error
 
-	if isBackward {
-		// Backward pagination: fetch items before cursor in DESC order
-		params := sqlc.ListUsersPrevParams{
-			Limit:       int32(limit),
-			Cursor:      cursorUUID,
-			Search:      searchParam,
-			EmailFilter: emailParam,
-			IsActive:    isActiveParam,
+	if len(filter.Sort) > 0 {
+		users, err = r.listSorted(ctx, filter, params, limit, isBackward)
+		if err != nil {
+			return nil, err
 		}
-		users, err = r.queries(ctx).ListUsersPrev(ctx, params)
+	} else if isBackward {
+		// Backward pagination: fetch items before cursor in DESC order
+		users, err = r.queries(ctx).ListUsersPrev(ctx, sqlc.ListUsersPrevParams{
+			Limit:          int32(limit),
+			Cursor:         cursorUUID,
+			Search:         params.search,
+			EmailFilter:    params.email,
+			IsActive:       params.isActive,
+			Role:           params.role,
+			IncludeDeleted: params.includeDeleted,
+			Metadata:       params.metadata,
+			MetadataKeys:   params.metadataKeys,
+		})
 	} else {
 		// Forward pagination: fetch items after cursor in ASC order
-		params := sqlc.ListUsersParams{
-			Limit:       int32(limit),
-			Cursor:      cursorUUID,
-			Search:      searchParam,
-			EmailFilter: emailParam,
-			IsActive:    isActiveParam,
-		}
-		users, err = r.queries(ctx).ListUsers(ctx, params)
+		users, err = r.queries(ctx).ListUsers(ctx, sqlc.ListUsersParams{
+			Limit:          int32(limit),
+			Cursor:         cursorUUID,
+			Search:         params.search,
+			EmailFilter:    params.email,
+			IsActive:       params.isActive,
+			Role:           params.role,
+			IncludeDeleted: params.includeDeleted,
+			Metadata:       params.metadata,
+			MetadataKeys:   params.metadataKeys,
+		})
 	}
 
 	if err != nil {
@@ -165,6 +209,214 @@ func (r *Repository) List(ctx context.Context, filter domain.UserFilter) ([]doma
 	return result, nil
 }
 
+// sortColumns maps the fields in domain.SortFields to their column type.
+// created_at and updated_at default to NOW() and are never NULL in practice,
+// which the keyset comparison relies on.
+var sortColumns = map[string]string{
+	"name":       "text",
+	"email":      "text",
+	"created_at": "timestamptz",
+	"updated_at": "timestamptz",
+}
+
+// listSorted runs ListUsers in filter.Sort order, then by ID. sqlc cannot
+// generate a query whose ORDER BY is chosen per request, so the order and
+// the keyset condition are built here from sortColumns. Like ListUsersPrev,
+// a backward page is fetched in reverse order.
+func (r *Repository) listSorted(ctx context.Context, filter domain.UserFilter, params userFilterParams, limit int, backward bool) ([]sqlc.User, error) {
+	args := params.args()
+
+	var where strings.Builder
+	where.WriteString(userFilterSQL)
+	if filter.Cursor != "" {
+		cursorID, err := uuid.Parse(filter.Cursor)
+		if err != nil || len(filter.CursorValues) != len(filter.Sort) {
+			return nil, apperr.BadRequestf("invalid cursor")
+		}
+		// (a > $8) OR (a = $8 AND b < $9) OR (a = $8 AND b = $9 AND id > $10)
+		var keys, ops, placeholders []string
+		for i, f := range filter.Sort {
+			var value any = filter.CursorValues[i]
+			if sortColumns[f.Field] == "timestamptz" {
+				t, err := time.Parse(time.RFC3339Nano, filter.CursorValues[i])
+				if err != nil {
+					return nil, apperr.BadRequestf("invalid cursor")
+				}
+				value = t
+			}
+			args = append(args, value)
+			keys = append(keys, f.Field)
+			ops = append(ops, keysetOp(f.Desc, backward))
+			placeholders = append(placeholders, fmt.Sprintf("$%d::%s", len(args), sortColumns[f.Field]))
+		}
+		args = append(args, cursorID)
+		keys = append(keys, "id")
+		ops = append(ops, keysetOp(false, backward))
+		placeholders = append(placeholders, fmt.Sprintf("$%d::uuid", len(args)))
+
+		where.WriteString("\n  AND (")
+		for i := range keys {
+			if i > 0 {
+				where.WriteString(" OR ")
+			}
+			where.WriteString("(")
+			for j := range i {
+				fmt.Fprintf(&where, "%s = %s AND ", keys[j], placeholders[j])
+			}
+			fmt.Fprintf(&where, "%s %s %s)", keys[i], ops[i], placeholders[i])
+		}
+		where.WriteString(")")
+	}
+
+	order := make([]string, 0, len(filter.Sort)+1)
+	for _, f := range filter.Sort {
+		order = append(order, f.Field+" "+sortDirection(f.Desc != backward))
+	}
+	order = append(order, "id "+sortDirection(backward))
+
+	args = append(args, limit)
+	sql := fmt.Sprintf("SELECT %s\nFROM users\nWHERE %s\nORDER BY %s\nLIMIT $%d",
+		userColumns, where.String(), strings.Join(order, ", "), len(args))
+
+	rows, err := database.DBFromContext(ctx, r.pool).Query(ctx, sql, args...)
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[sqlc.User])
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+// keysetOp is the comparison selecting rows after the cursor row in a column
+// sorted desc, or before it when paging backward.
+func keysetOp(desc, backward bool) string {
+	if desc != backward {
+		return "<"
+	}
+	return ">"
+}
+
+func sortDirection(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// userColumns are the columns of sqlc.User, for the hand-written queries below
+const userColumns = `id, email, password_hash, name, is_active, created_at, updated_at, last_seen_at, email_verified_at, deleted_at, metadata, must_change_password, last_login_at, last_login_ip, pending_email`
+
+// userFilterSQL is the search filter of ListUsers, with the parameters
+// numbered from $1 in userFilterParams.args order.
+const userFilterSQL = `($1::text IS NULL OR name ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+  AND ($2::text IS NULL OR lower(email) = lower($2))
+  AND ($3::bool IS NULL OR is_active = $3)
+  AND ($4::text IS NULL OR EXISTS (
+    SELECT 1 FROM casbin_rules
+    WHERE p_type = 'g' AND v0 = users.id::text AND v1 = $4
+  ))
+  AND ($5::bool OR deleted_at IS NULL)
+  AND ($6::jsonb IS NULL OR metadata @> $6)
+  AND ($7::text[] IS NULL OR metadata ?& $7)`
+
+// streamUsersSQL is ListUsers without the cursor and limit. It is run through
+// pgutil.StreamRows rather than sqlc, whose :many queries collect every row
+// into a slice.
+const streamUsersSQL = `SELECT ` + userColumns + `
+FROM users
+WHERE ` + userFilterSQL + `
+ORDER BY id ASC`
+
+// Stream returns an iterator over every user matching the filter's search
+// filters, in ID order; the cursor, limit and direction are ignored. Rows are
+// read from the open cursor as the caller consumes them, so an export of any
+// size runs in constant memory.
+//
+// The query runs when iteration starts and holds a pool connection until it
+// ends. It is not bounded by the repository's query timeout, since an export
+// takes as long as its consumer needs; cancel ctx to stop it.
+func (r *Repository) Stream(ctx context.Context, filter domain.UserFilter) iter.Seq2[domain.User, error] {
+	params, err := r.filterParams(filter)
+	args := params.args()
+
+	return func(yield func(domain.User, error) bool) {
+		if err != nil {
+			yield(domain.User{}, err)
+			return
+		}
+
+		start := time.Now()
+		defer func() {
+			observability.RecordDBQuery("select", "users", time.Since(start))
+		}()
+
+		ctx, span := observability.WrapDBOperation(ctx, "StreamUsers", "users")
+		defer span.End()
+
+		rows := pgutil.StreamRows(ctx, database.DBFromContext(ctx, r.pool), streamUsersSQL, args, pgx.RowToStructByName[sqlc.User])
+		for u, err := range rows {
+			if err != nil {
+				observability.RecordSpanError(ctx, err)
+				yield(domain.User{}, fmt.Errorf("failed to stream users: %w", err))
+				return
+			}
+			if !yield(*sqlcUserToDomain(&u), nil) {
+				return
+			}
+		}
+	}
+}
+
+// userFilterParams are the query parameters of the optional search filters
+// shared by List and Stream; an unset filter is NULL.
+type userFilterParams struct {
+	search, email  pgtype.Text
+	isActive       pgtype.Bool
+	role           pgtype.Text
+	includeDeleted bool
+	metadata       []byte
+	metadataKeys   []string
+}
+
+// args returns the parameters in the order userFilterSQL numbers them.
+func (p userFilterParams) args() []any {
+	return []any{p.search, p.email, p.isActive, p.role, p.includeDeleted, p.metadata, p.metadataKeys}
+}
+
+// filterParams converts the optional search filters shared by List and Stream
+// to query parameters.
+func (r *Repository) filterParams(filter domain.UserFilter) (userFilterParams, error) {
+	p := userFilterParams{includeDeleted: filter.IncludeDeleted}
+	if filter.Search.Set && filter.Search.Val != "" {
+		p.search = pgtype.Text{String: filter.Search.Val, Valid: true}
+	}
+	if filter.Email.Set && filter.Email.Val != "" {
+		p.email = pgtype.Text{String: r.NormalizeEmail(filter.Email.Val), Valid: true}
+	}
+	if filter.IsActive.Set {
+		p.isActive = pgtype.Bool{Bool: filter.IsActive.Val, Valid: true}
+	}
+	if filter.Role.Set && filter.Role.Val != "" {
+		p.role = pgtype.Text{String: filter.Role.Val, Valid: true}
+	}
+	if len(filter.Metadata) > 0 {
+		// Matched by containment: metadata @> {"department":"sales"}
+		b, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return p, fmt.Errorf("failed to encode metadata filter: %w", err)
+		}
+		p.metadata = b
+	}
+	if len(filter.MetadataKeys) > 0 {
+		p.metadataKeys = filter.MetadataKeys
+	}
+	return p, nil
+}
+
 // Create creates a new user
 func (r *Repository) Create(ctx context.Context, email, passwordHash, name string) (*domain.User, error) {
 	start := time.Now()
@@ -175,6 +427,10 @@ func (r *Repository) Create(ctx context.Context, email, passwordHash, name strin
 	ctx, span := observability.WrapDBOperation(ctx, "CreateUser", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	email = r.NormalizeEmail(email)
 	user, err := r.queries(ctx).CreateUser(ctx, sqlc.CreateUserParams{
 		Email:        email,
 		PasswordHash: passwordHash,
@@ -182,10 +438,7 @@ func (r *Repository) Create(ctx context.Context, email, passwordHash, name strin
 	})
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
-		if pgutil.IsDuplicateKeyError(err) {
-			return nil, apperr.Conflictf("user with email %s already exists", email)
-		}
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to create user: %w", err), "user with email "+email)
 	}
 
 	return sqlcUserToDomain(&user), nil
@@ -201,31 +454,84 @@ func (r *Repository) Update(ctx context.Context, id, name, email string) (*domai
 	ctx, span := observability.WrapDBOperation(ctx, "UpdateUser", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return nil, apperr.NotFoundf("user %s not found", id)
 	}
 
+	// An empty email keeps the current one; NormalizeEmail leaves "" as is.
+	email = r.NormalizeEmail(email)
 	user, err := r.queries(ctx).UpdateUser(ctx, sqlc.UpdateUserParams{
 		ID:      pgutil.UUIDToPgtype(uid),
 		Column2: name,
 		Column3: email,
 	})
-	if errors.Is(err, pgx.ErrNoRows) {
+	if err != nil {
+		err = fmt.Errorf("failed to update user: %w", err)
+		if pgutil.IsNoRows(err) {
+			return nil, pgutil.MapError(err, "user "+id)
+		}
+		observability.RecordSpanError(ctx, err)
+		return nil, pgutil.MapError(err, "user with email "+email)
+	}
+
+	return sqlcUserToDomain(&user), nil
+}
+
+// UpdateMetadata applies patch to a user's metadata in a single statement, so
+// concurrent patches to different keys do not overwrite each other. Metadata
+// grown past domain.MaxMetadataSize is rejected.
+func (r *Repository) UpdateMetadata(ctx context.Context, id string, patch domain.MetadataPatch) (*domain.User, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "UpdateUserMetadata", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
 		return nil, apperr.NotFoundf("user %s not found", id)
 	}
+
+	set := patch.Set
+	if set == nil {
+		set = map[string]json.RawMessage{}
+	}
+	setKeys, err := json.Marshal(set)
+	if err != nil {
+		return nil, apperr.BadRequestf("invalid metadata: %v", err)
+	}
+	// A NULL array would null the whole column.
+	unsetKeys := patch.Unset
+	if unsetKeys == nil {
+		unsetKeys = []string{}
+	}
+
+	user, err := r.queries(ctx).UpdateUserMetadata(ctx, sqlc.UpdateUserMetadataParams{
+		SetKeys:   setKeys,
+		UnsetKeys: unsetKeys,
+		ID:        pgutil.UUIDToPgtype(uid),
+	})
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
-		if pgutil.IsDuplicateKeyError(err) {
-			return nil, apperr.Conflictf("user with email %s already exists", email)
+		if pgErr := pgutil.PgError(err); pgErr != nil && pgErr.ConstraintName == "users_metadata_size" {
+			return nil, apperr.BadRequestf("metadata may not exceed %d bytes", domain.MaxMetadataSize)
 		}
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		return nil, pgutil.MapError(fmt.Errorf("failed to update user metadata: %w", err), "user "+id)
 	}
 
 	return sqlcUserToDomain(&user), nil
 }
 
-// UpdatePassword updates a user's password
+// UpdatePassword updates a user's password and clears must_change_password
 func (r *Repository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
 	start := time.Now()
 	defer func() {
@@ -235,6 +541,9 @@ func (r *Repository) UpdatePassword(ctx context.Context, id, passwordHash string
 	ctx, span := observability.WrapDBOperation(ctx, "UpdatePassword", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return apperr.NotFoundf("user %s not found", id)
@@ -246,13 +555,49 @@ func (r *Repository) UpdatePassword(ctx context.Context, id, passwordHash string
 	})
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
-		return fmt.Errorf("failed to update password: %w", err)
+		return pgutil.MapError(fmt.Errorf("failed to update password: %w", err), "user "+id)
 	}
 
 	return nil
 }
 
-// Delete soft-deletes a user (sets is_active = false)
+// ForcePasswordReset makes a user change their password at next use. A
+// non-empty passwordHash replaces the current password as well. Deleted users
+// are reported as not found.
+func (r *Repository) ForcePasswordReset(ctx context.Context, id, passwordHash string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ForcePasswordReset", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("user %s not found", id)
+	}
+
+	n, err := r.queries(ctx).ForcePasswordReset(ctx, sqlc.ForcePasswordResetParams{
+		PasswordHash: pgtype.Text{String: passwordHash, Valid: passwordHash != ""},
+		ID:           pgutil.UUIDToPgtype(uid),
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return pgutil.MapError(fmt.Errorf("failed to force password reset: %w", err), "user "+id)
+	}
+	if n == 0 {
+		return apperr.NotFoundf("user %s not found", id)
+	}
+
+	return nil
+}
+
+// Delete soft-deletes a user: it is deactivated and marked deleted. Deleting
+// an already deleted user keeps the original deletion time.
 func (r *Repository) Delete(ctx context.Context, id string) error {
 	start := time.Now()
 	defer func() {
@@ -262,6 +607,9 @@ func (r *Repository) Delete(ctx context.Context, id string) error {
 	ctx, span := observability.WrapDBOperation(ctx, "DeleteUser", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return apperr.NotFoundf("user %s not found", id)
@@ -270,13 +618,88 @@ func (r *Repository) Delete(ctx context.Context, id string) error {
 	err = r.queries(ctx).DeleteUser(ctx, pgutil.UUIDToPgtype(uid))
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
-		return fmt.Errorf("failed to delete user: %w", err)
+		return pgutil.MapError(fmt.Errorf("failed to delete user: %w", err), "user "+id)
 	}
 
 	return nil
 }
 
-// Activate activates a user (sets is_active = true)
+// Restore undoes Delete: the user is reactivated and no longer marked
+// deleted. A user that is not deleted is reported as not found.
+func (r *Repository) Restore(ctx context.Context, id string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "RestoreUser", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("deleted user %s not found", id)
+	}
+
+	n, err := r.queries(ctx).RestoreUser(ctx, pgutil.UUIDToPgtype(uid))
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return pgutil.MapError(fmt.Errorf("failed to restore user: %w", err), "user "+id)
+	}
+	if n == 0 {
+		return apperr.NotFoundf("deleted user %s not found", id)
+	}
+
+	return nil
+}
+
+// Purge permanently removes a soft-deleted user together with their role
+// grants and direct permissions. Rows referencing the user in other tables
+// are removed or unlinked by their foreign keys. A user that is not deleted
+// is reported as not found.
+//
+// The two statements should run in one transaction. The enforcer's in-memory
+// policy is not touched; callers reload it after the transaction commits.
+func (r *Repository) Purge(ctx context.Context, id string) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("delete", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "PurgeUser", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return apperr.NotFoundf("deleted user %s not found", id)
+	}
+
+	q := r.queries(ctx)
+	n, err := q.PurgeUser(ctx, pgutil.UUIDToPgtype(uid))
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return pgutil.MapError(fmt.Errorf("failed to purge user: %w", err), "user "+id)
+	}
+	if n == 0 {
+		return apperr.NotFoundf("deleted user %s not found", id)
+	}
+
+	// Policy rows key users by their canonical ID.
+	if _, err := q.DeleteUserAuthzRules(ctx, uid.String()); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return pgutil.MapError(fmt.Errorf("failed to delete authorization rules: %w", err), "authorization rules of user "+id)
+	}
+
+	return nil
+}
+
+// Activate activates a user (sets is_active = true). Deleted users are left
+// as they are; Restore brings them back.
 func (r *Repository) Activate(ctx context.Context, id string) error {
 	start := time.Now()
 	defer func() {
@@ -286,6 +709,9 @@ func (r *Repository) Activate(ctx context.Context, id string) error {
 	ctx, span := observability.WrapDBOperation(ctx, "ActivateUser", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return apperr.NotFoundf("user %s not found", id)
@@ -294,7 +720,7 @@ func (r *Repository) Activate(ctx context.Context, id string) error {
 	err = r.queries(ctx).ActivateUser(ctx, pgutil.UUIDToPgtype(uid))
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
-		return fmt.Errorf("failed to activate user: %w", err)
+		return pgutil.MapError(fmt.Errorf("failed to activate user: %w", err), "user "+id)
 	}
 
 	return nil
@@ -310,6 +736,9 @@ func (r *Repository) Deactivate(ctx context.Context, id string) error {
 	ctx, span := observability.WrapDBOperation(ctx, "DeactivateUser", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return apperr.NotFoundf("user %s not found", id)
@@ -318,7 +747,7 @@ func (r *Repository) Deactivate(ctx context.Context, id string) error {
 	err = r.queries(ctx).DeactivateUser(ctx, pgutil.UUIDToPgtype(uid))
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
-		return fmt.Errorf("failed to deactivate user: %w", err)
+		return pgutil.MapError(fmt.Errorf("failed to deactivate user: %w", err), "user "+id)
 	}
 
 	return nil
@@ -334,7 +763,10 @@ func (r *Repository) ExistsByEmail(ctx context.Context, email string) (bool, err
 	ctx, span := observability.WrapDBOperation(ctx, "UserExistsByEmail", "users")
 	defer span.End()
 
-	exists, err := r.queries(ctx).UserExistsByEmail(ctx, email)
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	exists, err := r.queries(ctx).UserExistsByEmail(ctx, r.NormalizeEmail(email))
 	if err != nil {
 		observability.RecordSpanError(ctx, err)
 		return false, fmt.Errorf("failed to check email existence: %w", err)
@@ -353,6 +785,9 @@ func (r *Repository) Count(ctx context.Context, isActive *bool) (int64, error) {
 	ctx, span := observability.WrapDBOperation(ctx, "CountUsers", "users")
 	defer span.End()
 
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	var isActiveParam pgtype.Bool
 	if isActive != nil {
 		isActiveParam = pgtype.Bool{Bool: *isActive, Valid: true}
@@ -367,6 +802,264 @@ func (r *Repository) Count(ctx context.Context, isActive *bool) (int64, error) {
 	return count, nil
 }
 
+// TouchLastSeen records at as the user's last activity. It never moves
+// last_seen_at backwards, so out-of-order writes from several instances are
+// harmless. Unknown or malformed IDs are ignored.
+func (r *Repository) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "TouchUserLastSeen", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+
+	err = r.queries(ctx).TouchUserLastSeen(ctx, sqlc.TouchUserLastSeenParams{
+		ID:         pgutil.UUIDToPgtype(uid),
+		LastSeenAt: pgtype.Timestamptz{Time: at, Valid: true},
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return fmt.Errorf("failed to update last seen: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user verified email. It reports false
+// when the user is unknown, inactive or already verified, or when email is no
+// longer their address.
+func (r *Repository) MarkEmailVerified(ctx context.Context, id, email string) (bool, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "MarkUserEmailVerified", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return false, nil
+	}
+
+	n, err := r.queries(ctx).MarkUserEmailVerified(ctx, sqlc.MarkUserEmailVerifiedParams{
+		ID:    pgutil.UUIDToPgtype(uid),
+		Lower: email,
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return false, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// SetPendingEmail records email as the address the user asked to change to,
+// replacing any earlier pending change. An empty email cancels it.
+func (r *Repository) SetPendingEmail(ctx context.Context, id, email string) (*domain.User, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "SetUserPendingEmail", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperr.NotFoundf("user %s not found", id)
+	}
+
+	email = r.NormalizeEmail(email)
+	user, err := r.queries(ctx).SetUserPendingEmail(ctx, sqlc.SetUserPendingEmailParams{
+		ID:           pgutil.UUIDToPgtype(uid),
+		PendingEmail: pgtype.Text{String: email, Valid: email != ""},
+	})
+	if err != nil {
+		if !pgutil.IsNoRows(err) {
+			observability.RecordSpanError(ctx, err)
+		}
+		return nil, pgutil.MapError(fmt.Errorf("failed to set pending email: %w", err), "user "+id)
+	}
+
+	return sqlcUserToDomain(&user), nil
+}
+
+// ApplyPendingEmail makes the user's pending email their verified address. It
+// reports false when the user is unknown or inactive, or when email is no
+// longer their pending address. An address taken by another user since the
+// change was requested is a conflict.
+func (r *Repository) ApplyPendingEmail(ctx context.Context, id, email string) (bool, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ApplyUserPendingEmail", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return false, nil
+	}
+
+	n, err := r.queries(ctx).ApplyUserPendingEmail(ctx, sqlc.ApplyUserPendingEmailParams{
+		ID:    pgutil.UUIDToPgtype(uid),
+		Lower: email,
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return false, pgutil.MapError(fmt.Errorf("failed to apply pending email: %w", err), "user with email "+email)
+	}
+
+	return n > 0, nil
+}
+
+// ListInactive returns active users whose last activity (or creation, if they
+// were never seen) is before the cutoff, least recently seen first.
+func (r *Repository) ListInactive(ctx context.Context, before time.Time, limit int) ([]domain.User, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "users", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ListInactiveUsers", "users")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.queries(ctx).ListInactiveUsers(ctx, sqlc.ListInactiveUsersParams{
+		Limit:      int32(limit),
+		SeenBefore: pgtype.Timestamptz{Time: before, Valid: true},
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list inactive users: %w", err)
+	}
+
+	users := make([]domain.User, 0, len(rows))
+	for i := range rows {
+		users = append(users, *sqlcUserToDomain(&rows[i]))
+	}
+
+	return users, nil
+}
+
+// ReassignAuditLogs moves the audit entries recorded for sourceID to targetID
+// and returns how many were moved. Entries in the audit hash chain
+// (audit.chain) keep sourceID, since their hash covers it; the MERGE entry
+// maps them to targetID.
+func (r *Repository) ReassignAuditLogs(ctx context.Context, sourceID, targetID string) (int64, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "audit_logs", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ReassignUserAuditLogs", "audit_logs")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	source, err := uuid.Parse(sourceID)
+	if err != nil {
+		return 0, apperr.NotFoundf("user %s not found", sourceID)
+	}
+	target, err := uuid.Parse(targetID)
+	if err != nil {
+		return 0, apperr.NotFoundf("user %s not found", targetID)
+	}
+
+	n, err := r.queries(ctx).ReassignUserAuditLogs(ctx, sqlc.ReassignUserAuditLogsParams{
+		TargetID: pgutil.UUIDToPgtype(target),
+		SourceID: pgutil.UUIDToPgtype(source),
+	})
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return 0, pgutil.MapError(fmt.Errorf("failed to reassign audit logs: %w", err), "audit logs of user "+sourceID)
+	}
+
+	return n, nil
+}
+
+// ListRoleGrants returns the roles granted directly to a user in the
+// authorization policy table, sorted by name.
+func (r *Repository) ListRoleGrants(ctx context.Context, userID string) ([]string, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("select", "casbin_rules", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ListUserRoleGrants", "casbin_rules")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	roles, err := r.queries(ctx).ListUserRoleGrants(ctx, userID)
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return nil, fmt.Errorf("failed to list role grants: %w", err)
+	}
+
+	return roles, nil
+}
+
+// ReassignAuthzRules moves the role grants and direct permissions of sourceID
+// to targetID in the authorization policy table. Rules targetID already has
+// are kept once. It returns how many rules were removed from sourceID.
+//
+// The enforcer's in-memory policy is not touched; callers reload it after the
+// surrounding transaction commits.
+func (r *Repository) ReassignAuthzRules(ctx context.Context, sourceID, targetID string) (int64, error) {
+	start := time.Now()
+	defer func() {
+		observability.RecordDBQuery("update", "casbin_rules", time.Since(start))
+	}()
+
+	ctx, span := observability.WrapDBOperation(ctx, "ReassignUserAuthzRules", "casbin_rules")
+	defer span.End()
+
+	ctx, cancel := database.WithQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	q := r.queries(ctx)
+	if _, err := q.CopyUserAuthzRules(ctx, sqlc.CopyUserAuthzRulesParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}); err != nil {
+		observability.RecordSpanError(ctx, err)
+		return 0, pgutil.MapError(fmt.Errorf("failed to copy authorization rules: %w", err), "authorization rules of user "+sourceID)
+	}
+
+	n, err := q.DeleteUserAuthzRules(ctx, sourceID)
+	if err != nil {
+		observability.RecordSpanError(ctx, err)
+		return 0, pgutil.MapError(fmt.Errorf("failed to delete authorization rules: %w", err), "authorization rules of user "+sourceID)
+	}
+
+	return n, nil
+}
+
 // sqlcUserToDomain converts SQLC User to domain User
 func sqlcUserToDomain(u *sqlc.User) *domain.User {
 	var createdAt, updatedAt time.Time
@@ -382,13 +1075,41 @@ func sqlcUserToDomain(u *sqlc.User) *domain.User {
 		isActive = u.IsActive.Bool
 	}
 
+	var lastSeenAt *time.Time
+	if u.LastSeenAt.Valid {
+		lastSeenAt = &u.LastSeenAt.Time
+	}
+
+	var emailVerifiedAt *time.Time
+	if u.EmailVerifiedAt.Valid {
+		emailVerifiedAt = &u.EmailVerifiedAt.Time
+	}
+
+	var deletedAt *time.Time
+	if u.DeletedAt.Valid {
+		deletedAt = &u.DeletedAt.Time
+	}
+
+	var lastLoginAt *time.Time
+	if u.LastLoginAt.Valid {
+		lastLoginAt = &u.LastLoginAt.Time
+	}
+
 	return &domain.User{
-		ID:           pgutil.PgtypeToUUID(u.ID),
-		Email:        u.Email,
-		PasswordHash: u.PasswordHash,
-		Name:         u.Name,
-		IsActive:     isActive,
-		CreatedAt:    createdAt,
-		UpdatedAt:    updatedAt,
+		ID:                 pgutil.PgtypeToUUID(u.ID),
+		Email:              u.Email,
+		PasswordHash:       u.PasswordHash,
+		Name:               u.Name,
+		IsActive:           isActive,
+		CreatedAt:          createdAt,
+		UpdatedAt:          updatedAt,
+		LastSeenAt:         lastSeenAt,
+		EmailVerifiedAt:    emailVerifiedAt,
+		DeletedAt:          deletedAt,
+		Metadata:           u.Metadata,
+		MustChangePassword: u.MustChangePassword,
+		LastLoginAt:        lastLoginAt,
+		LastLoginIP:        u.LastLoginIp.String,
+		PendingEmail:       u.PendingEmail.String,
 	}
 }
@@ -4,9 +4,16 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/14mdzk/goscratch/internal/module/user/domain"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -170,6 +177,80 @@ func TestRepository_List(t *testing.T) {
 		// Returns limit + 1 for hasMore check
 		assert.LessOrEqual(t, len(users), 3)
 	})
+
+	t.Run("by_role", func(t *testing.T) {
+		granted, err := repo.GetByEmail(ctx, "test_list_a@example.com")
+		require.NoError(t, err)
+		_, err = db.pool.Exec(ctx, "INSERT INTO casbin_rules (p_type, v0, v1) VALUES ('g', $1, 'test_role')", granted.ID.String())
+		require.NoError(t, err)
+		defer func() {
+			_, _ = db.pool.Exec(ctx, "DELETE FROM casbin_rules WHERE v1 = 'test_role'")
+		}()
+
+		users, err := repo.List(ctx, domain.UserFilter{Limit: 10, Role: types.Some("test_role")})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, granted.ID, users[0].ID)
+	})
+
+	t.Run("sorted_keyset", func(t *testing.T) {
+		sort := []shareddomain.SortField{{Field: "name", Desc: true}}
+		filter := domain.UserFilter{Limit: 2, Search: types.Some("test_list_"), Sort: sort}
+		names := func(users []domain.User) []string {
+			out := make([]string, 0, len(users))
+			for _, u := range users {
+				out = append(out, u.Name)
+			}
+			return out
+		}
+
+		first, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"User 5", "User 4", "User 3"}, names(first))
+
+		last := first[1]
+		filter.Cursor, filter.CursorValues = last.ID.String(), last.SortValues(sort)
+		second, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"User 3", "User 2", "User 1"}, names(second))
+
+		back := second[0]
+		filter.Cursor, filter.CursorValues, filter.Direction = back.ID.String(), back.SortValues(sort), "prev"
+		prev, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"User 5", "User 4"}, names(prev))
+	})
+}
+
+func TestRepository_Stream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	repo := NewRepository(db.pool)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		_, err := repo.Create(ctx, "test_stream_"+string(rune('a'+i-1))+"@example.com", "hash", "Streamed "+string(rune('0'+i)))
+		require.NoError(t, err)
+	}
+
+	var emails []string
+	for u, err := range repo.Stream(ctx, domain.UserFilter{Search: types.Some("test_stream_")}) {
+		require.NoError(t, err)
+		emails = append(emails, u.Email)
+	}
+	assert.Len(t, emails, 3)
+
+	n := 0
+	for range repo.Stream(ctx, domain.UserFilter{Search: types.Some("test_stream_")}) {
+		n++
+		break // stopping early closes the rows
+	}
+	assert.Equal(t, 1, n)
 }
 
 func TestRepository_Update(t *testing.T) {
@@ -221,10 +302,258 @@ func TestRepository_Delete(t *testing.T) {
 		err = repo.Delete(ctx, created.ID.String())
 		require.NoError(t, err)
 
-		// Should not find deleted user
-		_, err = repo.GetByID(ctx, created.ID.String())
+		// Deleted users can still be read by ID, marked deleted
+		deleted, err := repo.GetByID(ctx, created.ID.String())
+		require.NoError(t, err)
+		assert.True(t, deleted.Deleted())
+		assert.False(t, deleted.IsActive)
+	})
+
+	t.Run("include_deleted", func(t *testing.T) {
+		created, err := repo.Create(ctx, "test_listed_deleted@example.com", "hash", "Listed Deleted")
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, created.ID.String()))
+
+		filter := domain.UserFilter{Email: types.Some("test_listed_deleted@example.com")}
+		users, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		filter.IncludeDeleted = true
+		users, err = repo.List(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, created.ID, users[0].ID)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		created, err := repo.Create(ctx, "test_restore@example.com", "hash", "To Restore")
+		require.NoError(t, err)
+		id := created.ID.String()
+
+		// Only deleted users can be restored
+		assert.Error(t, repo.Restore(ctx, id))
+
+		require.NoError(t, repo.Delete(ctx, id))
+		require.NoError(t, repo.Restore(ctx, id))
+
+		restored, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.False(t, restored.Deleted())
+		assert.True(t, restored.IsActive)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		created, err := repo.Create(ctx, "test_purge@example.com", "hash", "To Purge")
+		require.NoError(t, err)
+		id := created.ID.String()
+		_, err = db.pool.Exec(ctx, "INSERT INTO casbin_rules (p_type, v0, v1) VALUES ('g', $1, 'editor')", id)
+		require.NoError(t, err)
+
+		// Only deleted users can be purged
+		assert.Error(t, repo.Purge(ctx, id))
+
+		require.NoError(t, repo.Delete(ctx, id))
+		require.NoError(t, repo.Purge(ctx, id))
+
+		_, err = repo.GetByID(ctx, id)
 		assert.Error(t, err)
+		grants, err := repo.ListRoleGrants(ctx, id)
+		require.NoError(t, err)
+		assert.Empty(t, grants)
+	})
+}
+
+func TestRepository_Metadata(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	repo := NewRepository(db.pool)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, "test_metadata@example.com", "hash", "Meta User")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(created.Metadata))
+	id := created.ID.String()
+
+	t.Run("patch", func(t *testing.T) {
+		updated, err := repo.UpdateMetadata(ctx, id, domain.MetadataPatch{Set: map[string]json.RawMessage{
+			"department": json.RawMessage(`"sales"`),
+			"phone":      json.RawMessage(`"+1 555 0100"`),
+		}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"department":"sales","phone":"+1 555 0100"}`, string(updated.Metadata))
+
+		// Keys left out of a patch are kept
+		updated, err = repo.UpdateMetadata(ctx, id, domain.MetadataPatch{
+			Set:   map[string]json.RawMessage{"locale": json.RawMessage(`"de"`)},
+			Unset: []string{"phone"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"department":"sales","locale":"de"}`, string(updated.Metadata))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		users, err := repo.List(ctx, domain.UserFilter{Metadata: map[string]string{"department": "sales"}})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, created.ID, users[0].ID)
+
+		users, err = repo.List(ctx, domain.UserFilter{MetadataKeys: []string{"department", "phone"}})
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("size_limit", func(t *testing.T) {
+		big, _ := json.Marshal(strings.Repeat("x", domain.MaxMetadataSize))
+		_, err := repo.UpdateMetadata(ctx, id, domain.MetadataPatch{Set: map[string]json.RawMessage{"blob": big}})
+		assert.Error(t, err)
+	})
+}
+
+func TestRepository_Preferences(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	repo := NewRepository(db.pool)
+	ctx := context.Background()
+
+	user, err := repo.Create(ctx, "test_prefs@example.com", "hash", "Prefs User")
+	require.NoError(t, err)
+	id := user.ID.String()
+
+	prefs, err := repo.GetPreferences(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultPreferences(), *prefs, "no row yet")
+
+	prefs, err = repo.UpdatePreferences(ctx, id, domain.PreferencesPatch{
+		Timezone: types.Some("Europe/Berlin"),
+		Push:     types.Some(false),
 	})
+	require.NoError(t, err)
+	assert.Equal(t, "en", prefs.Locale, "unset fields keep the default")
+	assert.Equal(t, "Europe/Berlin", prefs.Timezone)
+	assert.False(t, prefs.Notifications.Push)
+	assert.True(t, prefs.Notifications.Email)
+	assert.NotNil(t, prefs.UpdatedAt)
+
+	prefs, err = repo.UpdatePreferences(ctx, id, domain.PreferencesPatch{Locale: types.Some("de")})
+	require.NoError(t, err)
+	assert.Equal(t, "de", prefs.Locale)
+	assert.Equal(t, "Europe/Berlin", prefs.Timezone, "kept from the first update")
+
+	got, err := repo.GetPreferences(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, prefs.Locale, got.Locale)
+
+	_, err = repo.UpdatePreferences(ctx, "01234567-89ab-cdef-0123-456789abcdef", domain.PreferencesPatch{Locale: types.Some("de")})
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestRepository_Logins(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	repo := NewRepository(db.pool)
+	ctx := context.Background()
+
+	user, err := repo.Create(ctx, "test_logins@example.com", "hash", "Logins User")
+	require.NoError(t, err)
+	assert.Nil(t, user.LastLoginAt)
+	id := user.ID.String()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	for i := range 4 {
+		require.NoError(t, repo.RecordLogin(ctx, domain.Login{
+			UserID:     user.ID,
+			IPAddress:  fmt.Sprintf("203.0.113.%d", i),
+			UserAgent:  "curl/8.0",
+			LoggedInAt: base.Add(time.Duration(i) * time.Minute),
+		}, 3))
+	}
+
+	got, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastLoginAt)
+	assert.True(t, base.Add(3*time.Minute).Equal(*got.LastLoginAt))
+	assert.Equal(t, "203.0.113.3", got.LastLoginIP)
+
+	logins, err := repo.ListLogins(ctx, id, 10)
+	require.NoError(t, err)
+	require.Len(t, logins, 3, "trimmed to keep")
+	assert.Equal(t, "203.0.113.3", logins[0].IPAddress, "newest first")
+	assert.Equal(t, "203.0.113.1", logins[2].IPAddress)
+
+	// A late write does not move the last login back
+	require.NoError(t, repo.RecordLogin(ctx, domain.Login{UserID: user.ID, LoggedInAt: base.Add(-time.Hour)}, 0))
+	got, err = repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.3", got.LastLoginIP)
+
+	_, err = repo.ListLogins(ctx, "invalid-uuid", 10)
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestRepository_PendingEmail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	repo := NewRepository(db.pool)
+	ctx := context.Background()
+
+	user, err := repo.Create(ctx, "test_pending@example.com", "hash", "Pending User")
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, "test_pending_other@example.com", "hash", "Other User")
+	require.NoError(t, err)
+	id := user.ID.String()
+
+	got, err := repo.SetPendingEmail(ctx, id, "Test_Pending_New@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "test_pending@example.com", got.Email, "the address is unchanged until confirmed")
+	assert.Equal(t, "test_pending_new@example.com", got.PendingEmail)
+
+	applied, err := repo.ApplyPendingEmail(ctx, id, "test_pending_stale@example.com")
+	require.NoError(t, err)
+	assert.False(t, applied, "only the pending address is applied")
+
+	applied, err = repo.ApplyPendingEmail(ctx, id, "test_pending_new@example.com")
+	require.NoError(t, err)
+	assert.True(t, applied)
+	got, err = repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "test_pending_new@example.com", got.Email)
+	assert.Empty(t, got.PendingEmail)
+	assert.True(t, got.EmailVerified(), "the confirmed address is verified")
+
+	// An address taken since the change was requested is a conflict
+	_, err = repo.SetPendingEmail(ctx, id, "test_pending_other@example.com")
+	require.NoError(t, err)
+	_, err = repo.ApplyPendingEmail(ctx, id, other.Email)
+	assert.ErrorIs(t, err, apperr.ErrConflict)
+
+	// An empty address cancels the change
+	got, err = repo.SetPendingEmail(ctx, id, "")
+	require.NoError(t, err)
+	assert.Empty(t, got.PendingEmail)
+
+	_, err = repo.SetPendingEmail(ctx, "invalid-uuid", "test_pending_x@example.com")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
 }
 
 func TestRepository_ExistsByEmail(t *testing.T) {
@@ -284,6 +613,37 @@ func TestRepository_UpdatePassword(t *testing.T) {
 		err := repo.UpdatePassword(ctx, "invalid-uuid", "newhash")
 		assert.Error(t, err)
 	})
+
+	t.Run("forced_reset", func(t *testing.T) {
+		created, err := repo.Create(ctx, "test_forcereset@example.com", "oldhash", "User")
+		require.NoError(t, err)
+		id := created.ID.String()
+		assert.False(t, created.MustChangePassword)
+
+		// Without a hash the password is kept
+		require.NoError(t, repo.ForcePasswordReset(ctx, id, ""))
+		user, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, user.MustChangePassword)
+		assert.Equal(t, "oldhash", user.PasswordHash)
+
+		require.NoError(t, repo.ForcePasswordReset(ctx, id, "temphash"))
+		user, err = repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "temphash", user.PasswordHash)
+
+		// Changing the password clears the flag
+		require.NoError(t, repo.UpdatePassword(ctx, id, "newhash"))
+		user, err = repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.False(t, user.MustChangePassword)
+
+		require.NoError(t, repo.Delete(ctx, id))
+		err = repo.ForcePasswordReset(ctx, id, "")
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+	})
 }
 
 func TestRepository_Activate(t *testing.T) {
@@ -399,3 +759,96 @@ func TestRepository_Count(t *testing.T) {
 		assert.GreaterOrEqual(t, count, int64(0))
 	})
 }
+
+func TestRepository_ReassignForMerge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	repo := NewRepository(db.pool)
+	ctx := context.Background()
+
+	source, err := repo.Create(ctx, "test_merge_source@example.com", "hash", "Source")
+	require.NoError(t, err)
+	target, err := repo.Create(ctx, "test_merge_target@example.com", "hash", "Target")
+	require.NoError(t, err)
+	sourceID, targetID := source.ID.String(), target.ID.String()
+
+	t.Cleanup(func() {
+		_, _ = db.pool.Exec(ctx, "DELETE FROM casbin_rules WHERE v0 IN ($1, $2)", sourceID, targetID)
+	})
+
+	_, err = db.pool.Exec(ctx,
+		"INSERT INTO audit_logs (user_id, action, resource) VALUES ($1, 'LOGIN', 'auth'), ($1, 'UPDATE', 'user')",
+		source.ID)
+	require.NoError(t, err)
+	_, err = db.pool.Exec(ctx, `INSERT INTO casbin_rules (p_type, v0, v1) VALUES
+		('g', $1, 'editor'), ('g', $1, 'viewer'), ('g', $2, 'viewer')`, sourceID, targetID)
+	require.NoError(t, err)
+
+	roles, err := repo.ListRoleGrants(ctx, sourceID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor", "viewer"}, roles)
+
+	moved, err := repo.ReassignAuditLogs(ctx, sourceID, targetID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), moved)
+
+	removed, err := repo.ReassignAuthzRules(ctx, sourceID, targetID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	roles, err = repo.ListRoleGrants(ctx, targetID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor", "viewer"}, roles)
+
+	roles, err = repo.ListRoleGrants(ctx, sourceID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+}
+
+func TestRepository_EmailNormalization(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDB(t)
+	defer db.cleanup(t)
+
+	ctx := context.Background()
+
+	t.Run("case_insensitive", func(t *testing.T) {
+		repo := NewRepository(db.pool)
+
+		user, err := repo.Create(ctx, "  Test_Norm@Example.COM ", "hash", "Norm")
+		require.NoError(t, err)
+		assert.Equal(t, "test_norm@example.com", user.Email)
+
+		found, err := repo.GetByEmail(ctx, "TEST_NORM@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+
+		exists, err := repo.ExistsByEmail(ctx, "Test_Norm@Example.com")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		_, err = repo.Create(ctx, "test_NORM@example.com", "hash", "Dup")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("gmail_aliases", func(t *testing.T) {
+		repo := NewRepository(db.pool, WithGmailAliases(true))
+
+		user, err := repo.Create(ctx, "Test_Gmail.User+signup@googlemail.com", "hash", "Gmail")
+		require.NoError(t, err)
+		assert.Equal(t, "test_gmailuser@gmail.com", user.Email)
+
+		found, err := repo.GetByEmail(ctx, "test_gmail.user@gmail.com")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+	})
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/14mdzk/goscratch/internal/module/user/dto"
 	"github.com/14mdzk/goscratch/internal/port"
@@ -9,7 +11,10 @@ import (
 )
 
 // AuditedUseCase wraps a UseCase and adds audit logging on every mutating
-// operation. Read-only methods (GetByID, List) are delegated as-is.
+// operation. Reading one user is audited when the auditor samples reads
+// (see port.LogRead); other read-only methods (List, ListInactive,
+// ListLogins, GetExport) are delegated as-is. Exports are always audited
+// because they copy the user table out.
 type AuditedUseCase struct {
 	inner   UseCase
 	auditor port.Auditor
@@ -20,9 +25,17 @@ func NewAuditedUseCase(inner UseCase, auditor port.Auditor) *AuditedUseCase {
 	return &AuditedUseCase{inner: inner, auditor: auditor}
 }
 
-// GetByID delegates to inner without audit logging.
+// GetByID gets a user and logs a READ audit entry on success, if the
+// auditor samples the read.
 func (d *AuditedUseCase) GetByID(ctx context.Context, id string) (*dto.UserResponse, error) {
-	return d.inner.GetByID(ctx, id)
+	resp, err := d.inner.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = port.LogRead(ctx, d.auditor, port.NewAuditEntry(ctx, port.AuditActionRead, "user", resp.ID))
+
+	return resp, nil
 }
 
 // List delegates to inner without audit logging.
@@ -30,6 +43,16 @@ func (d *AuditedUseCase) List(ctx context.Context, req dto.ListUsersRequest) (sh
 	return d.inner.List(ctx, req)
 }
 
+// ListInactive delegates to inner without audit logging.
+func (d *AuditedUseCase) ListInactive(ctx context.Context, req dto.ListInactiveUsersRequest) ([]dto.UserResponse, error) {
+	return d.inner.ListInactive(ctx, req)
+}
+
+// ListLogins delegates to inner without audit logging.
+func (d *AuditedUseCase) ListLogins(ctx context.Context, id string, req dto.ListLoginsRequest) ([]dto.LoginResponse, error) {
+	return d.inner.ListLogins(ctx, id, req)
+}
+
 // Create creates a user and logs a CREATE audit entry on success.
 func (d *AuditedUseCase) Create(ctx context.Context, req dto.CreateUserRequest) (*dto.UserResponse, error) {
 	resp, err := d.inner.Create(ctx, req)
@@ -47,7 +70,32 @@ func (d *AuditedUseCase) Create(ctx context.Context, req dto.CreateUserRequest)
 	return resp, nil
 }
 
-// Update updates a user and logs an UPDATE audit entry on success.
+// CreateBatch creates users in a batch and logs a CREATE audit entry for each
+// user created. Nothing is logged for failed items or a rolled-back batch.
+func (d *AuditedUseCase) CreateBatch(ctx context.Context, req dto.BatchCreateUsersRequest) (*dto.BatchCreateUsersResponse, error) {
+	resp, err := d.inner.CreateBatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range resp.Results {
+		if r.Status != dto.BatchItemCreated {
+			continue
+		}
+		entry := port.NewAuditEntry(ctx, port.AuditActionCreate, "user", r.User.ID)
+		entry.NewValue = map[string]any{
+			"email": r.User.Email,
+			"name":  r.User.Name,
+		}
+		entry.Metadata = map[string]any{"batch": true}
+		_ = d.auditor.Log(ctx, entry)
+	}
+
+	return resp, nil
+}
+
+// Update updates a user and logs an UPDATE audit entry on success, with
+// the fields that changed as its old and new values.
 func (d *AuditedUseCase) Update(ctx context.Context, id string, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
 	// Capture old state before mutation.
 	oldUser, err := d.inner.GetByID(ctx, id)
@@ -61,8 +109,7 @@ func (d *AuditedUseCase) Update(ctx context.Context, id string, req dto.UpdateUs
 	}
 
 	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", resp.ID)
-	entry.OldValue = map[string]any{"email": oldUser.Email, "name": oldUser.Name}
-	entry.NewValue = map[string]any{"email": resp.Email, "name": resp.Name}
+	entry.SetDiff(oldUser, resp)
 	_ = d.auditor.Log(ctx, entry)
 
 	return resp, nil
@@ -81,6 +128,56 @@ func (d *AuditedUseCase) ChangePassword(ctx context.Context, id string, req dto.
 	return nil
 }
 
+// ForcePasswordReset forces a password change and logs an UPDATE audit
+// entry on success. The temporary password, if any, is never logged; only
+// whether one was generated.
+func (d *AuditedUseCase) ForcePasswordReset(ctx context.Context, id string, req dto.ForcePasswordResetRequest) (*dto.ForcePasswordResetResponse, error) {
+	resp, err := d.inner.ForcePasswordReset(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", id)
+	entry.NewValue = map[string]any{"must_change_password": true}
+	entry.Metadata = map[string]any{
+		"field":              "password",
+		"forced":             true,
+		"temporary_password": req.TemporaryPassword,
+		"sessions_revoked":   resp.SessionsRevoked,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// GetPreferences delegates to inner without audit logging.
+func (d *AuditedUseCase) GetPreferences(ctx context.Context, userID string) (*dto.PreferencesResponse, error) {
+	return d.inner.GetPreferences(ctx, userID)
+}
+
+// UpdatePreferences changes a user's preferences and logs an UPDATE audit
+// entry on user_preferences when a value changed. An update that sets every
+// field to its current value is not logged.
+func (d *AuditedUseCase) UpdatePreferences(ctx context.Context, userID string, req dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error) {
+	old, err := d.inner.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.inner.UpdatePreferences(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user_preferences", userID)
+	if !entry.SetDiff(old, resp) {
+		return resp, nil
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
 // Delete soft-deletes a user and logs a DELETE audit entry on success.
 func (d *AuditedUseCase) Delete(ctx context.Context, id string) error {
 	// Capture old state before deletion.
@@ -103,6 +200,47 @@ func (d *AuditedUseCase) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// Restore restores a soft-deleted user and logs an UPDATE audit entry on
+// success.
+func (d *AuditedUseCase) Restore(ctx context.Context, id string) error {
+	if err := d.inner.Restore(ctx, id); err != nil {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", id)
+	entry.OldValue = map[string]any{"deleted": true}
+	entry.NewValue = map[string]any{"deleted": false, "is_active": true}
+	_ = d.auditor.Log(ctx, entry)
+
+	return nil
+}
+
+// Purge permanently deletes a user and logs a DELETE audit entry marked hard.
+// Like Merge, a committed purge is audited even when a post-commit step
+// failed.
+func (d *AuditedUseCase) Purge(ctx context.Context, id string) error {
+	// Capture old state; nothing is left to read afterwards.
+	oldUser, err := d.inner.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = d.inner.Purge(ctx, id)
+	if err != nil && !errors.Is(err, errUserPurged) {
+		return err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionDelete, "user", oldUser.ID)
+	entry.OldValue = map[string]any{
+		"email": oldUser.Email,
+		"name":  oldUser.Name,
+	}
+	entry.Metadata = map[string]any{"hard": true}
+	_ = d.auditor.Log(ctx, entry)
+
+	return err
+}
+
 // Activate activates a user and logs an UPDATE audit entry on success.
 // If the user is already active the inner usecase returns nil as a no-op;
 // the decorator mirrors that behaviour and does not emit an audit entry.
@@ -152,3 +290,85 @@ func (d *AuditedUseCase) Deactivate(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// Merge merges two users and, once the merge has committed, logs a MERGE
+// audit entry on the surviving user. The entry is written after the source's
+// history was reassigned, so it is not moved itself. Dry runs are not audited.
+// A committed merge is audited even when a post-commit step failed.
+func (d *AuditedUseCase) Merge(ctx context.Context, targetID string, req dto.MergeUsersRequest) (*dto.MergeUsersResponse, error) {
+	resp, err := d.inner.Merge(ctx, targetID, req)
+	if resp == nil || resp.DryRun {
+		return resp, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionMerge, "user", resp.TargetID)
+	entry.OldValue = map[string]any{"source_id": resp.SourceID}
+	entry.Metadata = map[string]any{
+		"audit_entries_reassigned": resp.AuditEntriesReassigned,
+		"role_grants":              resp.RoleGrants,
+		"authz_rules_moved":        resp.AuthzRulesMoved,
+		"source_deactivated":       resp.SourceDeactivated,
+		"sessions_revoked":         resp.SessionsRevoked,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, err
+}
+
+// Impersonate issues an impersonation token and logs an IMPERSONATE audit
+// entry on the impersonated user. The token itself is never logged; its ID
+// is, so the entry can be matched to a revocation.
+func (d *AuditedUseCase) Impersonate(ctx context.Context, actorID, id string) (*dto.ImpersonationResponse, error) {
+	resp, err := d.inner.Impersonate(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionImpersonate, "user", resp.UserID)
+	entry.Metadata = map[string]any{
+		"token_id":   resp.TokenID,
+		"expires_at": resp.ExpiresAt,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// Export streams users and logs a READ audit entry once the stream ends,
+// recording how many rows left and whether the export completed.
+func (d *AuditedUseCase) Export(ctx context.Context, req dto.ExportUsersRequest, w io.Writer) (int, error) {
+	n, err := d.inner.Export(ctx, req, w)
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionRead, "user", "")
+	entry.Metadata = map[string]any{
+		"export":    exportFormat(req),
+		"rows":      n,
+		"completed": err == nil,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return n, err
+}
+
+// StartExport enqueues an export and logs a READ audit entry carrying its ID.
+func (d *AuditedUseCase) StartExport(ctx context.Context, req dto.ExportUsersRequest) (*dto.UserExportResponse, error) {
+	resp, err := d.inner.StartExport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionRead, "user", "")
+	entry.Metadata = map[string]any{
+		"export":    resp.Format,
+		"export_id": resp.ID,
+		"async":     true,
+	}
+	_ = d.auditor.Log(ctx, entry)
+
+	return resp, nil
+}
+
+// GetExport delegates to inner without audit logging.
+func (d *AuditedUseCase) GetExport(ctx context.Context, id string) (*dto.UserExportResponse, error) {
+	return d.inner.GetExport(ctx, id)
+}
@@ -2,16 +2,23 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/14mdzk/goscratch/internal/module/user/dto"
 	"github.com/14mdzk/goscratch/internal/port"
 	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // mockUseCase is a testify mock that satisfies the UseCase interface.
@@ -40,6 +47,30 @@ func (m *mockUseCase) Create(ctx context.Context, req dto.CreateUserRequest) (*d
 	return args.Get(0).(*dto.UserResponse), args.Error(1)
 }
 
+func (m *mockUseCase) CreateBatch(ctx context.Context, req dto.BatchCreateUsersRequest) (*dto.BatchCreateUsersResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BatchCreateUsersResponse), args.Error(1)
+}
+
+func (m *mockUseCase) GetPreferences(ctx context.Context, userID string) (*dto.PreferencesResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PreferencesResponse), args.Error(1)
+}
+
+func (m *mockUseCase) UpdatePreferences(ctx context.Context, userID string, req dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.PreferencesResponse), args.Error(1)
+}
+
 func (m *mockUseCase) Update(ctx context.Context, id string, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
 	args := m.Called(ctx, id, req)
 	if args.Get(0) == nil {
@@ -53,11 +84,45 @@ func (m *mockUseCase) ChangePassword(ctx context.Context, id string, req dto.Cha
 	return args.Error(0)
 }
 
+func (m *mockUseCase) ForcePasswordReset(ctx context.Context, id string, req dto.ForcePasswordResetRequest) (*dto.ForcePasswordResetResponse, error) {
+	args := m.Called(ctx, id, req)
+	if v := args.Get(0); v != nil {
+		return v.(*dto.ForcePasswordResetResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUseCase) ListLogins(ctx context.Context, id string, req dto.ListLoginsRequest) ([]dto.LoginResponse, error) {
+	args := m.Called(ctx, id, req)
+	if v := args.Get(0); v != nil {
+		return v.([]dto.LoginResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUseCase) Impersonate(ctx context.Context, actorID, id string) (*dto.ImpersonationResponse, error) {
+	args := m.Called(ctx, actorID, id)
+	if v := args.Get(0); v != nil {
+		return v.(*dto.ImpersonationResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *mockUseCase) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *mockUseCase) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockUseCase) Purge(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *mockUseCase) Activate(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -68,6 +133,43 @@ func (m *mockUseCase) Deactivate(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *mockUseCase) ListInactive(ctx context.Context, req dto.ListInactiveUsersRequest) ([]dto.UserResponse, error) {
+	args := m.Called(ctx, req)
+	if v := args.Get(0); v != nil {
+		return v.([]dto.UserResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUseCase) Merge(ctx context.Context, targetID string, req dto.MergeUsersRequest) (*dto.MergeUsersResponse, error) {
+	args := m.Called(ctx, targetID, req)
+	if v := args.Get(0); v != nil {
+		return v.(*dto.MergeUsersResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUseCase) Export(ctx context.Context, req dto.ExportUsersRequest, w io.Writer) (int, error) {
+	args := m.Called(ctx, req, w)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockUseCase) StartExport(ctx context.Context, req dto.ExportUsersRequest) (*dto.UserExportResponse, error) {
+	args := m.Called(ctx, req)
+	if v := args.Get(0); v != nil {
+		return v.(*dto.UserExportResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUseCase) GetExport(ctx context.Context, id string) (*dto.UserExportResponse, error) {
+	args := m.Called(ctx, id)
+	if v := args.Get(0); v != nil {
+		return v.(*dto.UserExportResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 // mockAuditorDecorator is a simple in-memory auditor for decorator tests.
 type mockAuditorDecorator struct {
 	Entries []port.AuditEntry
@@ -88,6 +190,13 @@ func (m *mockAuditorDecorator) Query(_ context.Context, _ port.AuditFilter) ([]p
 
 func (m *mockAuditorDecorator) Close() error { return nil }
 
+// samplingAuditorDecorator audits every read
+type samplingAuditorDecorator struct {
+	mockAuditorDecorator
+}
+
+func (*samplingAuditorDecorator) SampleRead(string) bool { return true }
+
 // helper to build a UserResponse for a given UUID.
 func buildUserResp(id uuid.UUID, email, name string, isActive bool) *dto.UserResponse {
 	return &dto.UserResponse{
@@ -101,14 +210,14 @@ func buildUserResp(id uuid.UUID, email, name string, isActive bool) *dto.UserRes
 }
 
 // ---------------------------------------------------------------------------
-// GetByID — no audit expected
+// GetByID — audited only when the auditor samples reads
 // ---------------------------------------------------------------------------
 
 func TestAuditDecorator_GetByID(t *testing.T) {
 	ctx := context.Background()
 	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
 
-	t.Run("delegates to inner and produces no audit entry", func(t *testing.T) {
+	t.Run("produces no audit entry unless reads are sampled", func(t *testing.T) {
 		inner := new(mockUseCase)
 		auditor := &mockAuditorDecorator{}
 		dec := NewAuditedUseCase(inner, auditor)
@@ -137,6 +246,22 @@ func TestAuditDecorator_GetByID(t *testing.T) {
 		assert.Empty(t, auditor.Entries)
 		inner.AssertExpectations(t)
 	})
+
+	t.Run("logs READ when the read is sampled", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &samplingAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("GetByID", ctx, testID.String()).Return(buildUserResp(testID, "u@example.com", "User", true), nil)
+
+		_, err := dec.GetByID(ctx, testID.String())
+
+		require.NoError(t, err)
+		require.Len(t, auditor.Entries, 1)
+		assert.Equal(t, port.AuditActionRead, auditor.Entries[0].Action)
+		assert.Equal(t, "user", auditor.Entries[0].Resource)
+		assert.Equal(t, testID.String(), auditor.Entries[0].ResourceID)
+	})
 }
 
 // ---------------------------------------------------------------------------
@@ -214,6 +339,124 @@ func TestAuditDecorator_Create(t *testing.T) {
 	})
 }
 
+func TestAuditDecorator_CreateBatch(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	req := dto.BatchCreateUsersRequest{Users: []dto.CreateUserRequest{
+		{Email: "new@example.com", Password: "password123", Name: "New User"},
+		{Email: "dup@example.com", Password: "password123", Name: "Dup User"},
+	}}
+
+	t.Run("logs CREATE for created items only", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("CreateBatch", ctx, req).Return(&dto.BatchCreateUsersResponse{
+			Created: 1,
+			Failed:  1,
+			Results: []dto.BatchCreateUserResult{
+				{Index: 0, Email: "new@example.com", Status: dto.BatchItemCreated, User: buildUserResp(testID, "new@example.com", "New User", true)},
+				{Index: 1, Email: "dup@example.com", Status: dto.BatchItemFailed, Error: &dto.BatchError{Code: "CONFLICT", Message: "taken"}},
+			},
+		}, nil)
+
+		_, err := dec.CreateBatch(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionCreate, entry.Action)
+		assert.Equal(t, testID.String(), entry.ResourceID)
+		assert.Equal(t, map[string]any{"batch": true}, entry.Metadata)
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("rolled back batch logs nothing", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("CreateBatch", ctx, req).Return(&dto.BatchCreateUsersResponse{
+			Failed: 1,
+			Results: []dto.BatchCreateUserResult{
+				{Index: 0, Email: "new@example.com", Status: dto.BatchItemRolledBack},
+				{Index: 1, Email: "dup@example.com", Status: dto.BatchItemFailed, Error: &dto.BatchError{Code: "CONFLICT", Message: "taken"}},
+			},
+		}, nil)
+
+		_, err := dec.CreateBatch(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Empty(t, auditor.Entries)
+		inner.AssertExpectations(t)
+	})
+}
+
+func TestAuditDecorator_UpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	userID := "01234567-89ab-cdef-0123-456789abcdef"
+	old := &dto.PreferencesResponse{
+		Locale:        "en",
+		Timezone:      "UTC",
+		Notifications: dto.NotificationPreferencesResponse{Email: true, Push: true, Digest: "weekly"},
+	}
+
+	t.Run("logs UPDATE with old and new values on change", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		req := dto.UpdatePreferencesRequest{Timezone: types.Some("Europe/Berlin")}
+		updated := *old
+		updated.Timezone = "Europe/Berlin"
+		inner.On("GetPreferences", ctx, userID).Return(old, nil)
+		inner.On("UpdatePreferences", ctx, userID, req).Return(&updated, nil)
+
+		_, err := dec.UpdatePreferences(ctx, userID, req)
+
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionUpdate, entry.Action)
+		assert.Equal(t, "user_preferences", entry.Resource)
+		assert.Equal(t, userID, entry.ResourceID)
+		assert.Equal(t, "UTC", entry.OldValue.(map[string]any)["timezone"])
+		assert.Equal(t, "Europe/Berlin", entry.NewValue.(map[string]any)["timezone"])
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("unchanged preferences are not logged", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		req := dto.UpdatePreferencesRequest{Locale: types.Some("en")}
+		inner.On("GetPreferences", ctx, userID).Return(old, nil)
+		inner.On("UpdatePreferences", ctx, userID, req).Return(old, nil)
+
+		_, err := dec.UpdatePreferences(ctx, userID, req)
+
+		assert.NoError(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+
+	t.Run("on failure, does NOT log audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		req := dto.UpdatePreferencesRequest{Timezone: types.Some("Mars/Olympus")}
+		inner.On("GetPreferences", ctx, userID).Return(old, nil)
+		inner.On("UpdatePreferences", ctx, userID, req).Return(nil, errors.New("invalid timezone"))
+
+		_, err := dec.UpdatePreferences(ctx, userID, req)
+
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
 // ---------------------------------------------------------------------------
 // Update
 // ---------------------------------------------------------------------------
@@ -254,6 +497,31 @@ func TestAuditDecorator_Update(t *testing.T) {
 		inner.AssertExpectations(t)
 	})
 
+	t.Run("records metadata when it is patched", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		oldResp := buildUserResp(testID, "u@example.com", "User", true)
+		oldResp.Metadata = json.RawMessage(`{"locale":"en"}`)
+		req := dto.UpdateUserRequest{Metadata: map[string]types.NOpt[json.RawMessage]{
+			"locale": types.NSome(json.RawMessage(`"de"`)),
+		}}
+		newResp := buildUserResp(testID, "u@example.com", "User", true)
+		newResp.Metadata = json.RawMessage(`{"locale":"de"}`)
+
+		inner.On("GetByID", ctx, testID.String()).Return(oldResp, nil)
+		inner.On("Update", ctx, testID.String(), req).Return(newResp, nil)
+
+		_, err := dec.Update(ctx, testID.String(), req)
+
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, map[string]any{"metadata": oldResp.Metadata}, entry.OldValue, "unchanged fields are left out")
+		assert.Equal(t, map[string]any{"metadata": newResp.Metadata}, entry.NewValue)
+	})
+
 	t.Run("on GetByID failure, does NOT call Update and does NOT log", func(t *testing.T) {
 		inner := new(mockUseCase)
 		auditor := &mockAuditorDecorator{}
@@ -332,6 +600,63 @@ func TestAuditDecorator_ChangePassword(t *testing.T) {
 	})
 }
 
+// ---------------------------------------------------------------------------
+// ForcePasswordReset
+// ---------------------------------------------------------------------------
+
+func TestAuditDecorator_ForcePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	req := dto.ForcePasswordResetRequest{TemporaryPassword: true}
+
+	t.Run("on success, logs UPDATE audit entry without the temporary password", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("ForcePasswordReset", ctx, testID.String(), req).Return(&dto.ForcePasswordResetResponse{
+			UserID:             testID.String(),
+			MustChangePassword: true,
+			TemporaryPassword:  "s3cretTempPassw0",
+			SessionsRevoked:    true,
+		}, nil)
+
+		resp, err := dec.ForcePasswordReset(ctx, testID.String(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "s3cretTempPassw0", resp.TemporaryPassword)
+		require.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionUpdate, entry.Action)
+		assert.Equal(t, "user", entry.Resource)
+		assert.Equal(t, testID.String(), entry.ResourceID)
+		assert.Equal(t, map[string]any{"must_change_password": true}, entry.NewValue)
+		assert.Equal(t, map[string]any{
+			"field":              "password",
+			"forced":             true,
+			"temporary_password": true,
+			"sessions_revoked":   true,
+		}, entry.Metadata)
+		assert.NotContains(t, fmt.Sprint(entry), "s3cretTempPassw0")
+
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("on failure, does NOT log audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("ForcePasswordReset", ctx, testID.String(), req).Return(nil, errors.New("user not found"))
+
+		_, err := dec.ForcePasswordReset(ctx, testID.String(), req)
+
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+		inner.AssertExpectations(t)
+	})
+}
+
 // ---------------------------------------------------------------------------
 // Delete
 // ---------------------------------------------------------------------------
@@ -396,6 +721,96 @@ func TestAuditDecorator_Delete(t *testing.T) {
 	})
 }
 
+// ---------------------------------------------------------------------------
+// Restore and Purge
+// ---------------------------------------------------------------------------
+
+func TestAuditDecorator_Restore(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+
+	t.Run("on success, logs UPDATE audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Restore", ctx, testID.String()).Return(nil)
+
+		err := dec.Restore(ctx, testID.String())
+
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionUpdate, entry.Action)
+		assert.Equal(t, testID.String(), entry.ResourceID)
+		assert.Equal(t, map[string]any{"deleted": true}, entry.OldValue)
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("on failure, does NOT log", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Restore", ctx, testID.String()).Return(errors.New("not deleted"))
+
+		assert.Error(t, dec.Restore(ctx, testID.String()))
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
+func TestAuditDecorator_Purge(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	oldResp := buildUserResp(testID, "gone@example.com", "Gone User", false)
+
+	t.Run("on success, logs hard DELETE audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("GetByID", ctx, testID.String()).Return(oldResp, nil)
+		inner.On("Purge", ctx, testID.String()).Return(nil)
+
+		err := dec.Purge(ctx, testID.String())
+
+		assert.NoError(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionDelete, entry.Action)
+		assert.Equal(t, testID.String(), entry.ResourceID)
+		assert.Equal(t, "gone@example.com", entry.OldValue.(map[string]any)["email"])
+		assert.Equal(t, map[string]any{"hard": true}, entry.Metadata)
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("a committed purge is logged despite a post-commit error", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("GetByID", ctx, testID.String()).Return(oldResp, nil)
+		inner.On("Purge", ctx, testID.String()).Return(fmt.Errorf("%w but reload failed", errUserPurged))
+
+		err := dec.Purge(ctx, testID.String())
+
+		assert.Error(t, err)
+		assert.Len(t, auditor.Entries, 1)
+	})
+
+	t.Run("on failure, does NOT log", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("GetByID", ctx, testID.String()).Return(oldResp, nil)
+		inner.On("Purge", ctx, testID.String()).Return(errors.New("not deleted"))
+
+		assert.Error(t, dec.Purge(ctx, testID.String()))
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
 // ---------------------------------------------------------------------------
 // Activate
 // ---------------------------------------------------------------------------
@@ -556,3 +971,222 @@ func TestAuditDecorator_Deactivate(t *testing.T) {
 		inner.AssertExpectations(t)
 	})
 }
+
+// ---------------------------------------------------------------------------
+// Merge
+// ---------------------------------------------------------------------------
+
+func TestAuditDecorator_Merge(t *testing.T) {
+	ctx := context.Background()
+	targetID := "01234567-89ab-cdef-0123-456789abcdef"
+	sourceID := "11111111-2222-3333-4444-555555555555"
+	req := dto.MergeUsersRequest{SourceID: sourceID}
+
+	merged := func(dryRun bool) *dto.MergeUsersResponse {
+		return &dto.MergeUsersResponse{
+			TargetID:               targetID,
+			SourceID:               sourceID,
+			DryRun:                 dryRun,
+			AuditEntriesReassigned: 3,
+			RoleGrants:             []string{"editor"},
+			AuthzRulesMoved:        1,
+			SourceDeactivated:      true,
+			SessionsRevoked:        !dryRun,
+		}
+	}
+
+	t.Run("on success, logs MERGE entry on the target", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Merge", ctx, targetID, req).Return(merged(false), nil)
+
+		resp, err := dec.Merge(ctx, targetID, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, merged(false), resp)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionMerge, entry.Action)
+		assert.Equal(t, "user", entry.Resource)
+		assert.Equal(t, targetID, entry.ResourceID)
+		assert.Equal(t, map[string]any{"source_id": sourceID}, entry.OldValue)
+		assert.Equal(t, int64(3), entry.Metadata["audit_entries_reassigned"])
+		assert.Equal(t, []string{"editor"}, entry.Metadata["role_grants"])
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("dry run is not audited", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		dry := dto.MergeUsersRequest{SourceID: sourceID, DryRun: true}
+		inner.On("Merge", ctx, targetID, dry).Return(merged(true), nil)
+
+		resp, err := dec.Merge(ctx, targetID, dry)
+
+		assert.NoError(t, err)
+		assert.True(t, resp.DryRun)
+		assert.Empty(t, auditor.Entries)
+	})
+
+	t.Run("committed merge with post-commit failure is still audited", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		partial := merged(false)
+		partial.SessionsRevoked = false
+		inner.On("Merge", ctx, targetID, req).Return(partial, errors.New("users merged but refresh token revocation failed"))
+
+		resp, err := dec.Merge(ctx, targetID, req)
+
+		assert.Error(t, err)
+		assert.NotNil(t, resp)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, false, auditor.Entries[0].Metadata["sessions_revoked"])
+	})
+
+	t.Run("on failure, does NOT log audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Merge", ctx, targetID, req).Return(nil, errors.New("db error"))
+
+		resp, err := dec.Merge(ctx, targetID, req)
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Impersonate
+// ---------------------------------------------------------------------------
+
+func TestAuditDecorator_Impersonate(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.UserIDKey, "admin-1")
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef").String()
+
+	t.Run("on success, logs IMPERSONATE without the token", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Impersonate", ctx, "admin-1", testID).Return(&dto.ImpersonationResponse{
+			AccessToken: "secret.jwt.token",
+			TokenID:     "jti-1",
+			ExpiresAt:   "2026-10-15T09:45:00Z",
+			UserID:      testID,
+			ActorID:     "admin-1",
+		}, nil)
+
+		_, err := dec.Impersonate(ctx, "admin-1", testID)
+
+		require.NoError(t, err)
+		require.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionImpersonate, entry.Action)
+		assert.Equal(t, "admin-1", entry.UserID)
+		assert.Equal(t, "user", entry.Resource)
+		assert.Equal(t, testID, entry.ResourceID)
+		assert.Equal(t, map[string]any{"token_id": "jti-1", "expires_at": "2026-10-15T09:45:00Z"}, entry.Metadata)
+		assert.NotContains(t, fmt.Sprint(entry), "secret.jwt.token")
+	})
+
+	t.Run("on failure, does NOT log audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Impersonate", ctx, "admin-1", testID).Return(nil, apperr.ErrForbidden)
+
+		_, err := dec.Impersonate(ctx, "admin-1", testID)
+
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Export / StartExport — READ audit entries
+// ---------------------------------------------------------------------------
+
+func TestAuditDecorator_Export(t *testing.T) {
+	ctx := context.Background()
+	req := dto.ExportUsersRequest{Format: ExportFormatNDJSON}
+
+	t.Run("logs rows and completion once the stream ends", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Export", ctx, req, io.Discard).Return(42, nil)
+
+		n, err := dec.Export(ctx, req, io.Discard)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, n)
+		assert.Len(t, auditor.Entries, 1)
+		entry := auditor.Entries[0]
+		assert.Equal(t, port.AuditActionRead, entry.Action)
+		assert.Equal(t, "user", entry.Resource)
+		assert.Equal(t, "ndjson", entry.Metadata["export"])
+		assert.Equal(t, 42, entry.Metadata["rows"])
+		assert.Equal(t, true, entry.Metadata["completed"])
+	})
+
+	t.Run("an interrupted export is still audited", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("Export", ctx, req, io.Discard).Return(10, errors.New("connection reset"))
+
+		_, err := dec.Export(ctx, req, io.Discard)
+
+		assert.Error(t, err)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, 10, auditor.Entries[0].Metadata["rows"])
+		assert.Equal(t, false, auditor.Entries[0].Metadata["completed"])
+	})
+}
+
+func TestAuditDecorator_StartExport(t *testing.T) {
+	ctx := context.Background()
+	req := dto.ExportUsersRequest{Async: true}
+
+	t.Run("logs the export id", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		started := &dto.UserExportResponse{ID: uuid.NewString(), Status: ExportStatusPending, Format: ExportFormatCSV}
+		inner.On("StartExport", ctx, req).Return(started, nil)
+
+		resp, err := dec.StartExport(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, started, resp)
+		assert.Len(t, auditor.Entries, 1)
+		assert.Equal(t, started.ID, auditor.Entries[0].Metadata["export_id"])
+		assert.Equal(t, true, auditor.Entries[0].Metadata["async"])
+	})
+
+	t.Run("on failure, does NOT log audit entry", func(t *testing.T) {
+		inner := new(mockUseCase)
+		auditor := &mockAuditorDecorator{}
+		dec := NewAuditedUseCase(inner, auditor)
+
+		inner.On("StartExport", ctx, req).Return(nil, errors.New("queue down"))
+
+		_, err := dec.StartExport(ctx, req)
+
+		assert.Error(t, err)
+		assert.Empty(t, auditor.Entries)
+	})
+}
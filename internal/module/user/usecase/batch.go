@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// errBatchRollback rolls back an atomic batch after every item has been
+// tried, so the response reports all of the failures at once.
+var errBatchRollback = errors.New("atomic batch failed")
+
+// CreateBatch creates req.Users in a single transaction and reports the
+// outcome of each. Every item runs in its own savepoint, so a failed item
+// (typically an email that is taken, or repeated in the batch) is rolled back
+// alone and the rest are committed. With req.Atomic any failure rolls back
+// the whole batch and the items that succeeded are reported as rolled back.
+//
+// Items are expected to be validated by the caller. Verification emails are
+// sent once the transaction has committed.
+func (uc *userUseCase) CreateBatch(ctx context.Context, req dto.BatchCreateUsersRequest) (*dto.BatchCreateUsersResponse, error) {
+	if len(req.Users) == 0 || len(req.Users) > dto.MaxBatchCreateUsers {
+		return nil, apperr.BadRequestf("a batch must have between 1 and %d users", dto.MaxBatchCreateUsers)
+	}
+
+	hashes, err := hashPasswords(req.Users)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *dto.BatchCreateUsersResponse
+	var created []*userdomain.User
+	err = uc.transactor.WithTx(ctx, func(ctx context.Context) error {
+		// Reset on every attempt; WithTx reruns this after a serialization
+		// failure.
+		resp = &dto.BatchCreateUsersResponse{Results: make([]dto.BatchCreateUserResult, len(req.Users))}
+		created = created[:0]
+
+		for i, item := range req.Users {
+			result := &resp.Results[i]
+			result.Index, result.Email = i, item.Email
+
+			var user *userdomain.User
+			err := uc.transactor.WithTx(ctx, func(ctx context.Context) error {
+				exists, err := uc.repo.ExistsByEmail(ctx, item.Email)
+				if err != nil {
+					return err
+				}
+				if exists {
+					return apperr.Conflictf("user with email %s already exists", item.Email)
+				}
+				user, err = uc.repo.Create(ctx, item.Email, hashes[i], item.Name)
+				return err
+			})
+			if err != nil {
+				appErr, ok := apperr.AsAppError(err)
+				if !ok || appErr.HTTPStatus >= 500 {
+					// Not the item's fault; the transaction may be unusable.
+					return err
+				}
+				result.Status = dto.BatchItemFailed
+				result.Error = &dto.BatchError{Code: appErr.Code, Message: appErr.Message}
+				resp.Failed++
+				continue
+			}
+
+			result.Status = dto.BatchItemCreated
+			result.User = toUserResponse(user)
+			created = append(created, user)
+			resp.Created++
+		}
+
+		if req.Atomic && resp.Failed > 0 {
+			return errBatchRollback
+		}
+		for _, user := range created {
+			if err := uc.publishEvent(ctx, userdomain.EventUserCreated, userdomain.EventData{User: userdomain.NewEventUser(user)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchRollback) {
+		for i := range resp.Results {
+			if r := &resp.Results[i]; r.Status == dto.BatchItemCreated {
+				r.Status, r.User = dto.BatchItemRolledBack, nil
+			}
+		}
+		resp.Created = 0
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range created {
+		uc.sendVerification(ctx, user)
+	}
+	return resp, nil
+}
+
+// hashPasswords bcrypt-hashes the passwords of users, a few at a time. It
+// runs before the transaction, which does not need to wait on the CPU.
+func hashPasswords(users []dto.CreateUserRequest) ([]string, error) {
+	hashes := make([]string, len(users))
+	errs := make([]error, len(users))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+
+	var wg sync.WaitGroup
+	for i, u := range users {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			h, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+			hashes[i], errs[i] = string(h), err
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, apperr.Internalf("failed to hash password")
+	}
+	return hashes, nil
+}
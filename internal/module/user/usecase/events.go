@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/google/uuid"
+)
+
+// eventPublishTimeout bounds publishing one lifecycle event
+const eventPublishTimeout = 5 * time.Second
+
+// LifecycleEvents enables user lifecycle events, published to Exchange with
+// the event type as the routing key. With Outbox set, events are written to
+// the outbox in the transaction of their change and the worker relays them;
+// otherwise Queue publishes them once the change commits.
+type LifecycleEvents struct {
+	Queue    port.Queue
+	Exchange string
+	Outbox   port.Outbox
+}
+
+// publishEvent publishes a lifecycle event of eventType. Call it inside the
+// transaction of the change, if there is one.
+//
+// With an outbox the event is written in that transaction, and an error is
+// returned so the change rolls back with its event. Otherwise the event is
+// published once the transaction commits, even if the client has gone away.
+// The change stands either way, so a failure is logged rather than returned.
+func (uc *userUseCase) publishEvent(ctx context.Context, eventType string, data userdomain.EventData) error {
+	if uc.events == nil {
+		return nil
+	}
+
+	event := userdomain.Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+	if ac := port.ExtractAuditContext(ctx); ac.UserID != "" {
+		event.Actor = &userdomain.EventActor{UserID: ac.UserID, ImpersonatorID: ac.ActorID}
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("failed to encode user event", "event_type", eventType, "user_id", data.User.ID, "error", err)
+		return nil
+	}
+	opts := []port.PublishOption{port.WithPublishContentType("application/json"), port.WithPublishMessageID(event.ID)}
+
+	if uc.events.Outbox != nil {
+		if err := uc.events.Outbox.Add(ctx, uc.events.Exchange, eventType, body, opts...); err != nil {
+			return fmt.Errorf("failed to store %s event: %w", eventType, err)
+		}
+		return nil
+	}
+
+	database.AfterCommit(ctx, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), eventPublishTimeout)
+		defer cancel()
+		if err := uc.events.Queue.Publish(ctx, uc.events.Exchange, eventType, body, opts...); err != nil {
+			slog.Error("failed to publish user event", "event_type", eventType, "event_id", event.ID, "user_id", data.User.ID, "error", err)
+		}
+	})
+	return nil
+}
+
+// publishDeactivated publishes a user.deactivated event for user id, read
+// back after the change
+func (uc *userUseCase) publishDeactivated(ctx context.Context, id, reason string) error {
+	if uc.events == nil {
+		return nil
+	}
+	user, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		if uc.events.Outbox != nil {
+			return err
+		}
+		slog.Error("failed to read user for event", "event_type", userdomain.EventUserDeactivated, "user_id", id, "error", err)
+		return nil
+	}
+	return uc.publishEvent(ctx, userdomain.EventUserDeactivated, userdomain.EventData{User: userdomain.NewEventUser(user), Reason: reason})
+}
+
+// eventTx runs fn, a change made with a single statement, in a transaction
+// when events go through the outbox, so the change and its event commit
+// together. Otherwise fn runs on its own.
+func (uc *userUseCase) eventTx(ctx context.Context, fn database.TxFunc) error {
+	if uc.events == nil || uc.events.Outbox == nil {
+		return fn(ctx)
+	}
+	return uc.transactor.WithTx(ctx, fn)
+}
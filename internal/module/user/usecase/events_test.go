@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// publishedEvent is a message published to fakeEventQueue
+type publishedEvent struct {
+	exchange    string
+	routingKey  string
+	contentType string
+	event       userdomain.Event
+}
+
+// fakeEventQueue records the events published to it. Only Publish is
+// implemented.
+type fakeEventQueue struct {
+	port.Queue
+	published []publishedEvent
+	err       error
+}
+
+func (q *fakeEventQueue) Publish(_ context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	if q.err != nil {
+		return q.err
+	}
+	var event userdomain.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+	q.published = append(q.published, publishedEvent{
+		exchange:    exchange,
+		routingKey:  routingKey,
+		contentType: port.ApplyPublishOptions(opts...).ContentType,
+		event:       event,
+	})
+	return nil
+}
+
+// fakeOutbox records the events added to it
+type fakeOutbox struct {
+	added []publishedEvent
+	ids   []string
+	err   error
+}
+
+func (o *fakeOutbox) Add(_ context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	if o.err != nil {
+		return o.err
+	}
+	var event userdomain.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+	o.added = append(o.added, publishedEvent{exchange: exchange, routingKey: routingKey, event: event})
+	o.ids = append(o.ids, port.ApplyPublishOptions(opts...).MessageID)
+	return nil
+}
+
+func newEventsUseCase(repo userRepo, queue *fakeEventQueue) *userUseCase {
+	uc := newUseCase(repo, &fakeTx{}, nil, nil)
+	uc.events = &LifecycleEvents{Queue: queue, Exchange: "user.events"}
+	return uc
+}
+
+func TestUseCase_LifecycleEvents(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.UserIDKey, "admin-1")
+	id := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+
+	t.Run("create publishes user.created", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ExistsByEmail", mock.Anything, "new@example.com").Return(false, nil)
+		repo.On("Create", mock.Anything, "new@example.com", mock.AnythingOfType("string"), "New User").
+			Return(&userdomain.User{ID: id, Email: "new@example.com", Name: "New User", IsActive: true}, nil)
+		queue := &fakeEventQueue{}
+
+		_, err := newEventsUseCase(repo, queue).Create(ctx, dto.CreateUserRequest{Email: "new@example.com", Password: "password123", Name: "New User"})
+
+		require.NoError(t, err)
+		require.Len(t, queue.published, 1)
+		got := queue.published[0]
+		assert.Equal(t, "user.events", got.exchange)
+		assert.Equal(t, userdomain.EventUserCreated, got.routingKey)
+		assert.Equal(t, "application/json", got.contentType)
+		assert.Equal(t, userdomain.EventUserCreated, got.event.Type)
+		assert.NotEmpty(t, got.event.ID)
+		assert.False(t, got.event.OccurredAt.IsZero())
+		assert.Equal(t, &userdomain.EventActor{UserID: "admin-1"}, got.event.Actor)
+		assert.Equal(t, userdomain.EventUser{ID: id.String(), Email: "new@example.com", Name: "New User", IsActive: true}, got.event.Data.User)
+	})
+
+	t.Run("update names the changed fields", func(t *testing.T) {
+		repo := new(MockRepository)
+		patch := userdomain.MetadataPatch{Unset: []string{"phone"}}
+		repo.On("Update", mock.Anything, id.String(), "Renamed", "").Return(&userdomain.User{ID: id, Name: "Renamed"}, nil)
+		repo.On("UpdateMetadata", mock.Anything, id.String(), patch).Return(&userdomain.User{ID: id, Name: "Renamed"}, nil)
+		queue := &fakeEventQueue{}
+
+		_, err := newEventsUseCase(repo, queue).Update(ctx, id.String(), dto.UpdateUserRequest{
+			Name:     "Renamed",
+			Metadata: map[string]types.NOpt[json.RawMessage]{"phone": types.Null[json.RawMessage]()},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, queue.published, 1)
+		assert.Equal(t, userdomain.EventUserUpdated, queue.published[0].event.Type)
+		assert.Equal(t, []string{"name", "metadata"}, queue.published[0].event.Data.Changed)
+	})
+
+	t.Run("deactivate publishes user.deactivated", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id, IsActive: true}, nil)
+		repo.On("Deactivate", mock.Anything, id.String()).Return(nil)
+		queue := &fakeEventQueue{}
+
+		require.NoError(t, newEventsUseCase(repo, queue).Deactivate(ctx, id.String()))
+
+		require.Len(t, queue.published, 1)
+		got := queue.published[0].event
+		assert.Equal(t, userdomain.EventUserDeactivated, got.Type)
+		assert.Equal(t, userdomain.DeactivatedByAdmin, got.Data.Reason)
+		assert.False(t, got.Data.User.IsActive)
+	})
+
+	t.Run("delete publishes user.deactivated", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Delete", mock.Anything, id.String()).Return(nil)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id}, nil)
+		queue := &fakeEventQueue{}
+
+		require.NoError(t, newEventsUseCase(repo, queue).Delete(ctx, id.String()))
+
+		require.Len(t, queue.published, 1)
+		assert.Equal(t, userdomain.DeactivatedByDelete, queue.published[0].event.Data.Reason)
+	})
+
+	t.Run("already inactive user publishes nothing", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id}, nil)
+		queue := &fakeEventQueue{}
+
+		require.NoError(t, newEventsUseCase(repo, queue).Deactivate(ctx, id.String()))
+
+		assert.Empty(t, queue.published)
+	})
+
+	t.Run("impersonated change names both identities", func(t *testing.T) {
+		ctx := context.WithValue(ctx, logger.ActorIDKey, "support-1")
+		repo := new(MockRepository)
+		repo.On("Update", mock.Anything, id.String(), "Renamed", "").Return(&userdomain.User{ID: id, Name: "Renamed"}, nil)
+		queue := &fakeEventQueue{}
+
+		_, err := newEventsUseCase(repo, queue).Update(ctx, id.String(), dto.UpdateUserRequest{Name: "Renamed"})
+
+		require.NoError(t, err)
+		require.Len(t, queue.published, 1)
+		assert.Equal(t, &userdomain.EventActor{UserID: "admin-1", ImpersonatorID: "support-1"}, queue.published[0].event.Actor)
+	})
+
+	t.Run("publish failure does not fail the change", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id, IsActive: true}, nil)
+		repo.On("Deactivate", mock.Anything, id.String()).Return(nil)
+		queue := &fakeEventQueue{err: errors.New("broker down")}
+
+		assert.NoError(t, newEventsUseCase(repo, queue).Deactivate(ctx, id.String()))
+	})
+
+	t.Run("no actor without a caller", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id, IsActive: true}, nil)
+		repo.On("Deactivate", mock.Anything, id.String()).Return(nil)
+		queue := &fakeEventQueue{}
+
+		require.NoError(t, newEventsUseCase(repo, queue).Deactivate(context.Background(), id.String()))
+
+		require.Len(t, queue.published, 1)
+		assert.Nil(t, queue.published[0].event.Actor)
+	})
+}
+
+func TestUseCase_LifecycleEventsOutbox(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+
+	newOutboxUseCase := func(repo userRepo, tx *fakeTx, outbox *fakeOutbox, queue *fakeEventQueue) *userUseCase {
+		uc := newUseCase(repo, tx, nil, nil)
+		uc.events = &LifecycleEvents{Queue: queue, Exchange: "user.events", Outbox: outbox}
+		return uc
+	}
+
+	t.Run("events are stored in the change's transaction", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id, IsActive: true}, nil)
+		repo.On("Deactivate", mock.Anything, id.String()).Return(nil)
+		tx, outbox, queue := &fakeTx{}, &fakeOutbox{}, &fakeEventQueue{}
+
+		require.NoError(t, newOutboxUseCase(repo, tx, outbox, queue).Deactivate(ctx, id.String()))
+
+		assert.True(t, tx.committed)
+		assert.Empty(t, queue.published, "the relay publishes outbox events")
+		require.Len(t, outbox.added, 1)
+		got := outbox.added[0]
+		assert.Equal(t, "user.events", got.exchange)
+		assert.Equal(t, userdomain.EventUserDeactivated, got.routingKey)
+		assert.Equal(t, []string{got.event.ID}, outbox.ids, "the event ID is the message ID")
+	})
+
+	t.Run("an outbox failure rolls back the change", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Delete", mock.Anything, id.String()).Return(nil)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id}, nil)
+		tx := &fakeTx{}
+
+		err := newOutboxUseCase(repo, tx, &fakeOutbox{err: errors.New("db down")}, &fakeEventQueue{}).Delete(ctx, id.String())
+
+		assert.ErrorContains(t, err, "db down")
+		assert.True(t, tx.rolledBack)
+	})
+
+	t.Run("a dry-run merge stores nothing", func(t *testing.T) {
+		sourceID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+		repo := new(MockRepository)
+		repo.On("GetByID", mock.Anything, id.String()).Return(&userdomain.User{ID: id, IsActive: true}, nil)
+		repo.On("GetByID", mock.Anything, sourceID.String()).Return(&userdomain.User{ID: sourceID, IsActive: true}, nil)
+		repo.On("ListRoleGrants", mock.Anything, sourceID.String()).Return([]string{}, nil)
+		repo.On("ReassignAuditLogs", mock.Anything, sourceID.String(), id.String()).Return(int64(0), nil)
+		repo.On("ReassignAuthzRules", mock.Anything, sourceID.String(), id.String()).Return(int64(0), nil)
+		repo.On("Deactivate", mock.Anything, sourceID.String()).Return(nil)
+		outbox := &fakeOutbox{}
+
+		_, err := newOutboxUseCase(repo, &fakeTx{}, outbox, &fakeEventQueue{}).Merge(ctx, id.String(), dto.MergeUsersRequest{SourceID: sourceID.String(), DryRun: true})
+
+		require.NoError(t, err)
+		assert.Empty(t, outbox.added)
+	})
+}
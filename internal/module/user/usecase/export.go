@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/google/uuid"
+)
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// Asynchronous export states
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+)
+
+// exportFlushEvery is how many users are written between flushes, matching
+// response.StreamArray.
+const exportFlushEvery = 64
+
+// exportURLTTL is how long a download link returned by GetExport is valid.
+const exportURLTTL = 15 * time.Minute
+
+// exportColumns is the CSV header row. NDJSON lines carry the same fields as
+// dto.UserResponse.
+var exportColumns = []string{"id", "email", "name", "is_active", "created_at", "updated_at", "last_seen_at", "email_verified_at", "deleted_at", "metadata"}
+
+// AsyncExport enables StartExport and GetExport. Publisher enqueues the
+// users.export job; Storage holds the files the worker writes.
+type AsyncExport struct {
+	Publisher JobPublisher
+	Storage   port.Storage
+}
+
+// ExportContentType returns the media type of an export in format.
+func ExportContentType(format string) string {
+	if format == ExportFormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportPath is where an asynchronous export is stored.
+func ExportPath(exportID, format string) string {
+	return "exports/users/" + exportID + "." + format
+}
+
+// WriteUsers writes users to w as CSV (the default) or NDJSON and returns how
+// many were written. When w has a Flush method (e.g. *bufio.Writer) it is
+// flushed every few dozen users, so a streamed response starts arriving
+// before the export is complete.
+//
+// It stops at the first iteration or write error and returns it; what has
+// been written stays written.
+func WriteUsers(w io.Writer, format string, users iter.Seq2[userdomain.User, error]) (int, error) {
+	var enc exportEncoder
+	if format == ExportFormatNDJSON {
+		enc = &ndjsonEncoder{w: w, enc: json.NewEncoder(w)}
+	} else {
+		enc = &csvEncoder{w: w, csv: csv.NewWriter(w)}
+	}
+
+	if err := enc.begin(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for u, err := range users {
+		if err != nil {
+			_ = enc.flush()
+			return n, err
+		}
+		if err := enc.write(toUserResponse(&u)); err != nil {
+			return n, err
+		}
+		n++
+		if n%exportFlushEvery == 0 {
+			if err := enc.flush(); err != nil {
+				return n, err // consumer went away; stop pulling rows
+			}
+		}
+	}
+	return n, enc.flush()
+}
+
+// exportEncoder writes one export format.
+type exportEncoder interface {
+	begin() error
+	write(u *dto.UserResponse) error
+	flush() error
+}
+
+type csvEncoder struct {
+	w   io.Writer
+	csv *csv.Writer
+}
+
+func (e *csvEncoder) begin() error { return e.csv.Write(exportColumns) }
+
+func (e *csvEncoder) write(u *dto.UserResponse) error {
+	return e.csv.Write([]string{
+		u.ID, u.Email, u.Name, strconv.FormatBool(u.IsActive), u.CreatedAt, u.UpdatedAt,
+		derefOrEmpty(u.LastSeenAt), derefOrEmpty(u.EmailVerifiedAt), derefOrEmpty(u.DeletedAt), string(u.Metadata),
+	})
+}
+
+func (e *csvEncoder) flush() error {
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	return flushWriter(e.w)
+}
+
+type ndjsonEncoder struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+// write relies on json.Encoder ending every value with a newline.
+func (e *ndjsonEncoder) write(u *dto.UserResponse) error { return e.enc.Encode(u) }
+
+func (e *ndjsonEncoder) flush() error { return flushWriter(e.w) }
+
+// flushWriter flushes w if it buffers.
+func flushWriter(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func derefOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// exportFormat returns the requested format, defaulting to CSV.
+func exportFormat(req dto.ExportUsersRequest) string {
+	if req.Format == "" {
+		return ExportFormatCSV
+	}
+	return req.Format
+}
+
+// exportFilter converts the export query to a repository filter.
+func exportFilter(req dto.ExportUsersRequest) (userdomain.UserFilter, error) {
+	metadata, metadataKeys, err := metadataFilter(req.Metadata, req.HasMetadata)
+	if err != nil {
+		return userdomain.UserFilter{}, err
+	}
+	return userdomain.UserFilter{
+		Search:         req.Search,
+		Email:          req.Email,
+		IsActive:       req.IsActive,
+		Role:           req.Role,
+		IncludeDeleted: req.IncludeDeleted,
+		Metadata:       metadata,
+		MetadataKeys:   metadataKeys,
+	}, nil
+}
+
+// Export streams every user matching req's filters to w in req.Format and
+// returns how many were written. Rows are read from a database cursor as w
+// accepts them, so nothing is collected in memory.
+func (uc *userUseCase) Export(ctx context.Context, req dto.ExportUsersRequest, w io.Writer) (int, error) {
+	filter, err := exportFilter(req)
+	if err != nil {
+		return 0, err
+	}
+	return WriteUsers(w, req.Format, uc.repo.Stream(ctx, filter))
+}
+
+// StartExport enqueues a users.export job that writes the export to storage,
+// and returns its ID for GetExport.
+func (uc *userUseCase) StartExport(ctx context.Context, req dto.ExportUsersRequest) (*dto.UserExportResponse, error) {
+	if uc.exports == nil {
+		return nil, apperr.ErrServiceUnavailable.WithMessage("Asynchronous exports are not enabled")
+	}
+
+	filter, err := exportFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	format := exportFormat(req)
+	payload := worker.UserExportPayload{
+		ExportID: uuid.NewString(),
+		Format:   format,
+		Search:   req.Search.Val,
+		Email:    req.Email.Val,
+		Role:     req.Role.Val,
+
+		IncludeDeleted: req.IncludeDeleted,
+		Metadata:       filter.Metadata,
+		MetadataKeys:   filter.MetadataKeys,
+	}
+	if req.IsActive.Set {
+		payload.IsActive = &req.IsActive.Val
+	}
+	if err := uc.exports.Publisher.Publish(ctx, worker.JobTypeUserExport, payload); err != nil {
+		return nil, apperr.Internalf("failed to queue user export")
+	}
+
+	return &dto.UserExportResponse{ID: payload.ExportID, Status: ExportStatusPending, Format: format}, nil
+}
+
+// GetExport reports whether an asynchronous export has been written and, once
+// it has, returns a download link. The worker uploads the file only when the
+// export is complete, so there is no partial state to report; an export whose
+// job failed stays pending.
+func (uc *userUseCase) GetExport(ctx context.Context, id string) (*dto.UserExportResponse, error) {
+	if uc.exports == nil {
+		return nil, apperr.ErrServiceUnavailable.WithMessage("Asynchronous exports are not enabled")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.NotFoundf("export %s not found", id)
+	}
+
+	for _, format := range []string{ExportFormatCSV, ExportFormatNDJSON} {
+		path := ExportPath(id, format)
+		ok, err := uc.exports.Storage.Exists(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up export: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		url, err := uc.exports.Storage.GetURL(ctx, path, exportURLTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign export link: %w", err)
+		}
+		return &dto.UserExportResponse{ID: id, Status: ExportStatusReady, Format: format, URL: url}, nil
+	}
+
+	return &dto.UserExportResponse{ID: id, Status: ExportStatusPending}, nil
+}
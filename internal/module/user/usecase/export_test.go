@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// usersSeq yields users, then tailErr if it is set.
+func usersSeq(users []userdomain.User, tailErr error) iter.Seq2[userdomain.User, error] {
+	return func(yield func(userdomain.User, error) bool) {
+		for _, u := range users {
+			if !yield(u, nil) {
+				return
+			}
+		}
+		if tailErr != nil {
+			yield(userdomain.User{}, tailErr)
+		}
+	}
+}
+
+func exportUsers(n int) []userdomain.User {
+	seen := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	users := make([]userdomain.User, n)
+	for i := range users {
+		users[i] = userdomain.User{
+			ID:           uuid.New(),
+			Email:        "user" + string(rune('a'+i%26)) + "@example.com",
+			Name:         "User, \"quoted\"",
+			PasswordHash: "secret-hash",
+			IsActive:     true,
+			CreatedAt:    seen,
+			UpdatedAt:    seen,
+		}
+	}
+	users[0].LastSeenAt = &seen
+	return users
+}
+
+func TestWriteUsers_CSV(t *testing.T) {
+	users := exportUsers(100) // crosses a flush boundary
+	var buf bytes.Buffer
+
+	n, err := WriteUsers(&buf, ExportFormatCSV, usersSeq(users, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 100, n)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 101)
+	assert.Equal(t, exportColumns, records[0])
+	assert.Equal(t, users[0].ID.String(), records[1][0])
+	assert.Equal(t, "User, \"quoted\"", records[1][2], "fields are quoted")
+	assert.Equal(t, "2026-01-02T03:04:05Z", records[1][6])
+	assert.Equal(t, "", records[2][6], "never seen")
+	assert.NotContains(t, buf.String(), "secret-hash")
+}
+
+func TestWriteUsers_NDJSON(t *testing.T) {
+	users := exportUsers(3)
+	var buf bytes.Buffer
+
+	n, err := WriteUsers(&buf, ExportFormatNDJSON, usersSeq(users, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	var first dto.UserResponse
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, users[0].ID.String(), first.ID)
+	assert.NotContains(t, buf.String(), "secret-hash")
+}
+
+func TestWriteUsers_IterationError(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	n, err := WriteUsers(w, ExportFormatCSV, usersSeq(exportUsers(2), errors.New("connection reset")))
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"), "rows written before the error are flushed")
+}
+
+func TestExport(t *testing.T) {
+	repo := new(MockRepository)
+	uc := newUseCase(repo, nil, nil, nil)
+	req := dto.ExportUsersRequest{Format: ExportFormatNDJSON, Search: types.Some("ann"), IsActive: types.Some(true)}
+
+	filter := userdomain.UserFilter{Search: types.Some("ann"), IsActive: types.Some(true)}
+	repo.On("Stream", mock.Anything, filter).Return(usersSeq(exportUsers(2), nil))
+
+	var buf bytes.Buffer
+	n, err := uc.Export(context.Background(), req, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	repo.AssertExpectations(t)
+}
+
+// exportPublisher records published jobs
+type exportPublisher struct {
+	jobType string
+	payload any
+	err     error
+}
+
+func (p *exportPublisher) Publish(_ context.Context, jobType string, payload any) error {
+	p.jobType, p.payload = jobType, payload
+	return p.err
+}
+
+// exportStorage is a port.Storage holding a set of paths; methods the export
+// does not use are left to the nil embedded interface.
+type exportStorage struct {
+	port.Storage
+	files map[string]bool
+}
+
+func (s *exportStorage) Exists(_ context.Context, path string) (bool, error) {
+	return s.files[path], nil
+}
+
+func (s *exportStorage) GetURL(_ context.Context, path string, _ time.Duration) (string, error) {
+	return "https://files.example.com/" + path + "?sig=x", nil
+}
+
+func TestStartExport(t *testing.T) {
+	t.Run("enqueues the job with the filters", func(t *testing.T) {
+		pub := &exportPublisher{}
+		uc := newUseCase(new(MockRepository), nil, nil, nil)
+		uc.exports = &AsyncExport{Publisher: pub, Storage: &exportStorage{}}
+
+		resp, err := uc.StartExport(context.Background(), dto.ExportUsersRequest{Async: true, Email: types.Some("a@example.com"), IsActive: types.Some(false)})
+		require.NoError(t, err)
+		assert.Equal(t, ExportStatusPending, resp.Status)
+		assert.Equal(t, ExportFormatCSV, resp.Format)
+
+		assert.Equal(t, worker.JobTypeUserExport, pub.jobType)
+		payload := pub.payload.(worker.UserExportPayload)
+		assert.Equal(t, resp.ID, payload.ExportID)
+		assert.Equal(t, "a@example.com", payload.Email)
+		require.NotNil(t, payload.IsActive)
+		assert.False(t, *payload.IsActive)
+	})
+
+	t.Run("a failed publish is an internal error", func(t *testing.T) {
+		uc := newUseCase(new(MockRepository), nil, nil, nil)
+		uc.exports = &AsyncExport{Publisher: &exportPublisher{err: errors.New("broker down")}, Storage: &exportStorage{}}
+
+		_, err := uc.StartExport(context.Background(), dto.ExportUsersRequest{Async: true})
+		assert.ErrorIs(t, err, apperr.ErrInternal)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		uc := newUseCase(new(MockRepository), nil, nil, nil)
+		_, err := uc.StartExport(context.Background(), dto.ExportUsersRequest{Async: true})
+		assert.ErrorIs(t, err, apperr.ErrServiceUnavailable)
+	})
+}
+
+func TestGetExport(t *testing.T) {
+	id := uuid.NewString()
+	store := &exportStorage{files: map[string]bool{}}
+	uc := newUseCase(new(MockRepository), nil, nil, nil)
+	uc.exports = &AsyncExport{Publisher: &exportPublisher{}, Storage: store}
+
+	resp, err := uc.GetExport(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatusPending, resp.Status)
+	assert.Empty(t, resp.URL)
+
+	store.files[ExportPath(id, ExportFormatNDJSON)] = true
+	resp, err = uc.GetExport(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, ExportStatusReady, resp.Status)
+	assert.Equal(t, ExportFormatNDJSON, resp.Format)
+	assert.Equal(t, "https://files.example.com/exports/users/"+id+".ndjson?sig=x", resp.URL)
+
+	_, err = uc.GetExport(context.Background(), "../../etc/passwd")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// temporaryPasswordLength is the length of a generated temporary password.
+// Its alphabet leaves out characters that are easily confused (0/O, 1/l/I).
+const temporaryPasswordLength = 16
+
+const temporaryPasswordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// ForcePasswordReset makes a user change their password before they can use
+// the API again. With req.TemporaryPassword the password is replaced by a
+// generated one, returned in the response; otherwise it is kept.
+//
+// Afterwards the user's access and refresh tokens are revoked, so they have
+// to log in again and get a token that only reaches the change-password
+// endpoint. A failed revocation does not fail the call, since a generated
+// password could not be shown again: the response reports it with
+// SessionsRevoked false, and repeating the reset retries it.
+func (uc *userUseCase) ForcePasswordReset(ctx context.Context, id string, req dto.ForcePasswordResetRequest) (*dto.ForcePasswordResetResponse, error) {
+	var temporary, passwordHash string
+	if req.TemporaryPassword {
+		var err error
+		if temporary, err = generateTemporaryPassword(); err != nil {
+			return nil, apperr.Internalf("failed to generate temporary password")
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(temporary), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, apperr.Internalf("failed to hash password")
+		}
+		passwordHash = string(hash)
+	}
+
+	if err := uc.repo.ForcePasswordReset(ctx, id, passwordHash); err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ForcePasswordResetResponse{
+		UserID:             id,
+		MustChangePassword: true,
+		TemporaryPassword:  temporary,
+	}
+	if uc.authRevoker == nil {
+		return resp, nil
+	}
+
+	// Access tokens first: until they are refused, one issued before the
+	// reset still reaches every endpoint.
+	if uc.authRevoker.RevokeAccessTokens(ctx, id) == nil && uc.authRevoker.RevokeAllForUser(ctx, id) == nil {
+		resp.SessionsRevoked = true
+	}
+
+	return resp, nil
+}
+
+// generateTemporaryPassword returns a random password drawn uniformly from
+// temporaryPasswordAlphabet.
+func generateTemporaryPassword() (string, error) {
+	b := make([]byte, temporaryPasswordLength)
+	// Rejection sampling: bytes past the largest multiple of the alphabet
+	// size would bias the result.
+	limit := byte(256 - 256%len(temporaryPasswordAlphabet))
+	buf := make([]byte, 1)
+	for i := 0; i < len(b); {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		if buf[0] >= limit {
+			continue
+		}
+		b[i] = temporaryPasswordAlphabet[int(buf[0])%len(temporaryPasswordAlphabet)]
+		i++
+	}
+	return string(b), nil
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+func TestUseCase_ForcePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef").String()
+
+	t.Run("keeps the password and revokes every token", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ForcePasswordReset", ctx, testID, "").Return(nil)
+		revoker := new(MockAuthRevoker)
+		revoker.On("RevokeAccessTokens", ctx, testID).Return(nil)
+		revoker.On("RevokeAllForUser", ctx, testID).Return(nil)
+
+		uc := newUseCase(repo, nil, nil, revoker)
+		resp, err := uc.ForcePasswordReset(ctx, testID, dto.ForcePasswordResetRequest{})
+
+		require.NoError(t, err)
+		assert.Equal(t, &dto.ForcePasswordResetResponse{
+			UserID:             testID,
+			MustChangePassword: true,
+			SessionsRevoked:    true,
+		}, resp)
+		repo.AssertExpectations(t)
+		revoker.AssertExpectations(t)
+	})
+
+	t.Run("stores the hash of a generated temporary password", func(t *testing.T) {
+		var stored string
+		repo := new(MockRepository)
+		repo.On("ForcePasswordReset", ctx, testID, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { stored = args.String(2) }).
+			Return(nil)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		resp, err := uc.ForcePasswordReset(ctx, testID, dto.ForcePasswordResetRequest{TemporaryPassword: true})
+
+		require.NoError(t, err)
+		assert.Len(t, resp.TemporaryPassword, temporaryPasswordLength)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored), []byte(resp.TemporaryPassword)))
+		assert.False(t, resp.SessionsRevoked, "no revoker configured")
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ForcePasswordReset", ctx, testID, "").Return(apperr.NotFoundf("user %s not found", testID))
+		revoker := new(MockAuthRevoker)
+
+		uc := newUseCase(repo, nil, nil, revoker)
+		_, err := uc.ForcePasswordReset(ctx, testID, dto.ForcePasswordResetRequest{})
+
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeNotFound, appErr.Code)
+		revoker.AssertNotCalled(t, "RevokeAccessTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("revocation failure is reported, not returned", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ForcePasswordReset", ctx, testID, mock.AnythingOfType("string")).Return(nil)
+		revoker := new(MockAuthRevoker)
+		revoker.On("RevokeAccessTokens", ctx, testID).Return(port.ErrCacheUnavailable)
+
+		uc := newUseCase(repo, nil, nil, revoker)
+		resp, err := uc.ForcePasswordReset(ctx, testID, dto.ForcePasswordResetRequest{TemporaryPassword: true})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.TemporaryPassword, "the password is still shown")
+		assert.False(t, resp.SessionsRevoked)
+		revoker.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGenerateTemporaryPassword(t *testing.T) {
+	seen := map[string]bool{}
+	for range 20 {
+		p, err := generateTemporaryPassword()
+		require.NoError(t, err)
+		require.Len(t, p, temporaryPasswordLength)
+		for _, r := range p {
+			assert.True(t, strings.ContainsRune(temporaryPasswordAlphabet, r), "unexpected %q", r)
+		}
+		assert.False(t, seen[p], "repeated password")
+		seen[p] = true
+	}
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// Impersonate issues actorID a short-lived access token to act as user id.
+// Requests made with it are audited under both identities.
+//
+// Only active users can be impersonated, and never a superadmin: the token
+// would let the actor act with the target's permissions, which for a
+// superadmin are already everything.
+func (uc *userUseCase) Impersonate(ctx context.Context, actorID, id string) (*dto.ImpersonationResponse, error) {
+	if uc.impersonator == nil {
+		return nil, apperr.Internalf("impersonation is not available")
+	}
+
+	target, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	targetID := target.ID.String()
+	if targetID == actorID {
+		return nil, apperr.BadRequestf("cannot impersonate yourself")
+	}
+	if target.Deleted() || !target.IsActive {
+		return nil, apperr.Conflictf("user %s is inactive", id)
+	}
+	if uc.roles != nil {
+		roles, err := uc.roles.GetRolesForUser(targetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read roles for user %s: %w", targetID, err)
+		}
+		if slices.Contains(roles, port.RoleSuperAdmin) {
+			return nil, apperr.ErrForbidden.WithMessage("superadmins cannot be impersonated")
+		}
+	}
+
+	token, err := uc.impersonator.IssueImpersonationToken(ctx, actorID, target)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ImpersonationResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(token.ExpiresAt).Seconds()),
+		ExpiresAt:   token.ExpiresAt.UTC().Format(time.RFC3339),
+		TokenID:     token.TokenID,
+		UserID:      targetID,
+		ActorID:     actorID,
+	}, nil
+}
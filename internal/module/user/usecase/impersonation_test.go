@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// MockImpersonator is a testify mock for Impersonator
+type MockImpersonator struct {
+	mock.Mock
+}
+
+func (m *MockImpersonator) IssueImpersonationToken(ctx context.Context, actorID string, target *userdomain.User) (*userdomain.ImpersonationToken, error) {
+	args := m.Called(ctx, actorID, target)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userdomain.ImpersonationToken), args.Error(1)
+}
+
+func TestUseCase_Impersonate(t *testing.T) {
+	ctx := context.Background()
+	actorID := uuid.NewString()
+	target := &userdomain.User{ID: uuid.New(), Email: "user@example.com", IsActive: true}
+	id := target.ID.String()
+
+	newUC := func(repo *MockRepository, impersonator Impersonator, roles RoleReader) *userUseCase {
+		uc := newUseCase(repo, nil, nil, nil)
+		uc.impersonator = impersonator
+		uc.roles = roles
+		return uc
+	}
+
+	t.Run("issues a token", func(t *testing.T) {
+		expiresAt := time.Now().Add(15 * time.Minute)
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, id).Return(target, nil)
+		impersonator := new(MockImpersonator)
+		impersonator.On("IssueImpersonationToken", ctx, actorID, target).
+			Return(&userdomain.ImpersonationToken{AccessToken: "tok", TokenID: "jti-1", ExpiresAt: expiresAt}, nil)
+
+		resp, err := newUC(repo, impersonator, fakeRoles{}).Impersonate(ctx, actorID, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, "tok", resp.AccessToken)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.Equal(t, "jti-1", resp.TokenID)
+		assert.Equal(t, id, resp.UserID)
+		assert.Equal(t, actorID, resp.ActorID)
+		assert.Equal(t, expiresAt.UTC().Format(time.RFC3339), resp.ExpiresAt)
+		assert.InDelta(t, 15*60, resp.ExpiresIn, 2)
+		impersonator.AssertExpectations(t)
+	})
+
+	t.Run("refuses", func(t *testing.T) {
+		inactive := *target
+		inactive.IsActive = false
+		deletedAt := time.Now()
+		deleted := *target
+		deleted.DeletedAt = &deletedAt
+
+		tests := []struct {
+			name    string
+			actorID string
+			user    *userdomain.User
+			roles   fakeRoles
+			want    error
+		}{
+			{"self", id, target, fakeRoles{}, apperr.ErrBadRequest},
+			{"inactive", actorID, &inactive, fakeRoles{}, apperr.ErrConflict},
+			{"deleted", actorID, &deleted, fakeRoles{}, apperr.ErrConflict},
+			{"superadmin", actorID, target, fakeRoles{grants: map[string][]string{id: {port.RoleSuperAdmin}}}, apperr.ErrForbidden},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				repo := new(MockRepository)
+				repo.On("GetByID", ctx, id).Return(tt.user, nil)
+				impersonator := new(MockImpersonator)
+
+				_, err := newUC(repo, impersonator, tt.roles).Impersonate(ctx, tt.actorID, id)
+
+				assert.ErrorIs(t, err, tt.want)
+				impersonator.AssertNotCalled(t, "IssueImpersonationToken", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, id).Return(nil, apperr.NotFoundf("user not found"))
+
+		_, err := newUC(repo, new(MockImpersonator), nil).Impersonate(ctx, actorID, id)
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+
+	t.Run("role lookup fails", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, id).Return(target, nil)
+
+		_, err := newUC(repo, new(MockImpersonator), fakeRoles{err: errors.New("enforcer down")}).Impersonate(ctx, actorID, id)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := newUC(new(MockRepository), nil, nil).Impersonate(ctx, actorID, id)
+		assert.ErrorIs(t, err, apperr.ErrInternal)
+	})
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
+)
+
+// emptyMetadata is the metadata of a user without any
+var emptyMetadata = json.RawMessage(`{}`)
+
+// metadataPatch converts the metadata of an update request to a patch: keys
+// with a value are set and keys sent as null are removed.
+func metadataPatch(fields map[string]types.NOpt[json.RawMessage]) (userdomain.MetadataPatch, error) {
+	var patch userdomain.MetadataPatch
+	for key, field := range fields {
+		if !userdomain.ValidMetadataKey(key) {
+			return patch, apperr.BadRequestf("invalid metadata key %q", key)
+		}
+		if !field.Valid {
+			patch.Unset = append(patch.Unset, key)
+			continue
+		}
+		if patch.Set == nil {
+			patch.Set = make(map[string]json.RawMessage)
+		}
+		patch.Set[key] = field.Val
+	}
+	sort.Strings(patch.Unset)
+	return patch, nil
+}
+
+// metadataFilter validates the metadata filters of a list or export query:
+// values to match, and a comma-separated list of keys that must be present.
+func metadataFilter(values map[string]string, hasKeys string) (map[string]string, []string, error) {
+	var keys []string
+	for _, key := range strings.Split(hasKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(values)+len(keys) > userdomain.MaxMetadataFilters {
+		return nil, nil, apperr.BadRequestf("at most %d metadata filters are allowed", userdomain.MaxMetadataFilters)
+	}
+	for key := range values {
+		if !userdomain.ValidMetadataKey(key) {
+			return nil, nil, apperr.BadRequestf("invalid metadata key %q", key)
+		}
+	}
+	for _, key := range keys {
+		if !userdomain.ValidMetadataKey(key) {
+			return nil, nil, apperr.BadRequestf("invalid metadata key %q", key)
+		}
+	}
+	return values, keys, nil
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
+)
+
+func TestMetadataPatch(t *testing.T) {
+	var req dto.UpdateUserRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"metadata":{"department":"sales","phone":null,"floor":3}}`), &req))
+
+	patch, err := metadataPatch(req.Metadata)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]json.RawMessage{
+		"department": json.RawMessage(`"sales"`),
+		"floor":      json.RawMessage(`3`),
+	}, patch.Set)
+	assert.Equal(t, []string{"phone"}, patch.Unset)
+
+	patch, err = metadataPatch(nil)
+	require.NoError(t, err)
+	assert.True(t, patch.Empty())
+
+	_, err = metadataPatch(map[string]types.NOpt[json.RawMessage]{"bad key": types.Null[json.RawMessage]()})
+	assert.ErrorIs(t, err, apperr.ErrBadRequest)
+}
+
+func TestMetadataFilter(t *testing.T) {
+	values, keys, err := metadataFilter(map[string]string{"locale": "de"}, "phone, department,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"locale": "de"}, values)
+	assert.Equal(t, []string{"phone", "department"}, keys)
+
+	_, keys, err = metadataFilter(nil, "")
+	require.NoError(t, err)
+	assert.Nil(t, keys)
+
+	_, _, err = metadataFilter(map[string]string{"a b": "c"}, "")
+	assert.ErrorIs(t, err, apperr.ErrBadRequest)
+
+	_, _, err = metadataFilter(nil, "k1,k2,k3,k4,k5,k6,k7,k8,k9,k10,k11")
+	assert.ErrorIs(t, err, apperr.ErrBadRequest)
+}
+
+func TestUseCase_UpdateMetadata(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+
+	t.Run("patches metadata in the update transaction", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Update", ctx, testID.String(), "", "").Return(&userdomain.User{ID: testID}, nil)
+		repo.On("UpdateMetadata", ctx, testID.String(), userdomain.MetadataPatch{Unset: []string{"phone"}}).
+			Return(&userdomain.User{ID: testID, Metadata: json.RawMessage(`{"locale":"en"}`)}, nil)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, nil)
+		resp, err := uc.Update(ctx, testID.String(), dto.UpdateUserRequest{
+			Metadata: map[string]types.NOpt[json.RawMessage]{"phone": types.Null[json.RawMessage]()},
+		})
+
+		require.NoError(t, err)
+		assert.True(t, tx.committed)
+		assert.JSONEq(t, `{"locale":"en"}`, string(resp.Metadata))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("without metadata no transaction is needed", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Update", ctx, testID.String(), "New", "").Return(&userdomain.User{ID: testID, Name: "New"}, nil)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		resp, err := uc.Update(ctx, testID.String(), dto.UpdateUserRequest{Name: "New"})
+
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(resp.Metadata), "users without metadata get an empty object")
+		repo.AssertNotCalled(t, "UpdateMetadata", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects an invalid key before writing", func(t *testing.T) {
+		repo := new(MockRepository)
+
+		uc := newUseCase(repo, &fakeTx{}, nil, nil)
+		_, err := uc.Update(ctx, testID.String(), dto.UpdateUserRequest{
+			Metadata: map[string]types.NOpt[json.RawMessage]{"a=b": types.NSome(json.RawMessage(`1`))},
+		})
+
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
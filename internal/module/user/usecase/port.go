@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"io"
 
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
 	"github.com/14mdzk/goscratch/internal/module/user/dto"
 	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 )
@@ -14,11 +16,24 @@ type UseCase interface {
 	GetByID(ctx context.Context, id string) (*dto.UserResponse, error)
 	List(ctx context.Context, req dto.ListUsersRequest) (shareddomain.CursorPage[dto.UserResponse], error)
 	Create(ctx context.Context, req dto.CreateUserRequest) (*dto.UserResponse, error)
+	CreateBatch(ctx context.Context, req dto.BatchCreateUsersRequest) (*dto.BatchCreateUsersResponse, error)
 	Update(ctx context.Context, id string, req dto.UpdateUserRequest) (*dto.UserResponse, error)
 	ChangePassword(ctx context.Context, id string, req dto.ChangePasswordRequest) error
+	ForcePasswordReset(ctx context.Context, id string, req dto.ForcePasswordResetRequest) (*dto.ForcePasswordResetResponse, error)
+	GetPreferences(ctx context.Context, userID string) (*dto.PreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, userID string, req dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error)
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, id string) error
 	Activate(ctx context.Context, id string) error
 	Deactivate(ctx context.Context, id string) error
+	ListInactive(ctx context.Context, req dto.ListInactiveUsersRequest) ([]dto.UserResponse, error)
+	ListLogins(ctx context.Context, id string, req dto.ListLoginsRequest) ([]dto.LoginResponse, error)
+	Merge(ctx context.Context, targetID string, req dto.MergeUsersRequest) (*dto.MergeUsersResponse, error)
+	Impersonate(ctx context.Context, actorID, id string) (*dto.ImpersonationResponse, error)
+	Export(ctx context.Context, req dto.ExportUsersRequest, w io.Writer) (int, error)
+	StartExport(ctx context.Context, req dto.ExportUsersRequest) (*dto.UserExportResponse, error)
+	GetExport(ctx context.Context, id string) (*dto.UserExportResponse, error)
 }
 
 // AuthRevoker is a narrow port for revoking auth sessions. The user module
@@ -28,4 +43,55 @@ type UseCase interface {
 type AuthRevoker interface {
 	// RevokeAllForUser terminates all active refresh tokens for the given user.
 	RevokeAllForUser(ctx context.Context, userID string) error
+	// RevokeAccessTokens invalidates every access token issued to the user.
+	RevokeAccessTokens(ctx context.Context, userID string) error
+}
+
+// EmailVerifier emails a user a link to verify their address. The auth
+// module satisfies it via its Verifier() accessor when email verification is
+// enabled.
+type EmailVerifier interface {
+	// SendVerification issues a verification token for the user's current
+	// address and enqueues the email carrying it.
+	SendVerification(ctx context.Context, userID string) error
+}
+
+// EmailChanger emails a user a link to confirm their pending email. The auth
+// module satisfies it via its EmailChanger() accessor when email change
+// confirmation is enabled.
+type EmailChanger interface {
+	// SendEmailChange issues a confirmation token for the user's pending
+	// email and enqueues the email carrying it.
+	SendEmailChange(ctx context.Context, userID string) error
+}
+
+// Impersonator issues an admin a short-lived access token to act as another
+// user. The auth module satisfies it via its Impersonator() accessor.
+type Impersonator interface {
+	IssueImpersonationToken(ctx context.Context, actorID string, target *userdomain.User) (*userdomain.ImpersonationToken, error)
+}
+
+// RoleReader reads the roles granted to a user directly. port.Authorizer
+// satisfies this interface.
+type RoleReader interface {
+	GetRolesForUser(userID string) ([]string, error)
+}
+
+// Authorizer is the part of port.Authorizer the user usecase depends on.
+type Authorizer interface {
+	PolicyReloader
+	RoleReader
+}
+
+// PolicyReloader reloads the authorization policy from storage. Merge rewrites
+// policy rows inside its own transaction, bypassing the enforcer, and reloads
+// afterwards. port.Authorizer satisfies this interface.
+type PolicyReloader interface {
+	LoadPolicy() error
+}
+
+// JobPublisher enqueues background jobs. The concrete *worker.Publisher
+// satisfies it.
+type JobPublisher interface {
+	Publish(ctx context.Context, jobType string, payload any) error
 }
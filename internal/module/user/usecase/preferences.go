@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+)
+
+// preferencesCacheTTL bounds how long a cached copy can outlive a change
+// made behind the usecase's back, e.g. directly in the database.
+const preferencesCacheTTL = time.Hour
+
+func preferencesCacheKey(userID string) string {
+	return "user:preferences:" + userID
+}
+
+// GetPreferences returns a user's preferences, read through the cache.
+func (uc *userUseCase) GetPreferences(ctx context.Context, userID string) (*dto.PreferencesResponse, error) {
+	key := preferencesCacheKey(userID)
+	if uc.cache != nil {
+		var cached userdomain.Preferences
+		if err := uc.cache.GetJSON(ctx, key, &cached); err == nil {
+			return toPreferencesResponse(&cached), nil
+		}
+		// A miss or an unavailable cache falls back to the database.
+	}
+
+	prefs, err := uc.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if uc.cache != nil {
+		_ = uc.cache.SetJSON(ctx, key, prefs, preferencesCacheTTL)
+	}
+	return toPreferencesResponse(prefs), nil
+}
+
+// UpdatePreferences changes the fields set in req and caches the result.
+// If the cache cannot be written the cached copy is dropped instead, so a
+// later read does not return the old preferences.
+func (uc *userUseCase) UpdatePreferences(ctx context.Context, userID string, req dto.UpdatePreferencesRequest) (*dto.PreferencesResponse, error) {
+	patch, err := preferencesPatch(req)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Empty() {
+		return uc.GetPreferences(ctx, userID)
+	}
+
+	prefs, err := uc.repo.UpdatePreferences(ctx, userID, patch)
+	if err != nil {
+		return nil, err
+	}
+	if uc.cache != nil {
+		key := preferencesCacheKey(userID)
+		if err := uc.cache.SetJSON(ctx, key, prefs, preferencesCacheTTL); err != nil {
+			_ = uc.cache.Delete(ctx, key)
+		}
+	}
+	return toPreferencesResponse(prefs), nil
+}
+
+// preferencesPatch validates req and converts it to a repository patch.
+func preferencesPatch(req dto.UpdatePreferencesRequest) (userdomain.PreferencesPatch, error) {
+	patch := userdomain.PreferencesPatch{
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+		Email:    req.Notifications.Email,
+		Push:     req.Notifications.Push,
+		Digest:   req.Notifications.Digest,
+	}
+	if patch.Locale.Set && !userdomain.ValidLocale(patch.Locale.Val) {
+		return patch, apperr.BadRequestf("invalid locale %q; use a language tag such as en or pt-BR", patch.Locale.Val)
+	}
+	if patch.Timezone.Set && !userdomain.ValidTimezone(patch.Timezone.Val) {
+		return patch, apperr.BadRequestf("invalid timezone %q; use an IANA name such as Europe/Berlin", patch.Timezone.Val)
+	}
+	if patch.Digest.Set && !userdomain.ValidDigest(patch.Digest.Val) {
+		return patch, apperr.BadRequestf("invalid digest %q; must be one of %s", patch.Digest.Val, strings.Join(userdomain.Digests, ", "))
+	}
+	return patch, nil
+}
+
+func toPreferencesResponse(p *userdomain.Preferences) *dto.PreferencesResponse {
+	resp := &dto.PreferencesResponse{
+		Locale:   p.Locale,
+		Timezone: p.Timezone,
+		Notifications: dto.NotificationPreferencesResponse{
+			Email:  p.Notifications.Email,
+			Push:   p.Notifications.Push,
+			Digest: p.Notifications.Digest,
+		},
+	}
+	if p.UpdatedAt != nil {
+		s := p.UpdatedAt.Format(time.RFC3339)
+		resp.UpdatedAt = &s
+	}
+	return resp
+}
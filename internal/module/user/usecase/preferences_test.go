@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
+)
+
+func newPreferencesUseCase(t *testing.T, repo *MockRepository) (*userUseCase, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	c, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	return newUseCase(repo, nil, c, nil), mr
+}
+
+func TestUseCase_GetPreferences(t *testing.T) {
+	ctx := context.Background()
+	const userID = "01234567-89ab-cdef-0123-456789abcdef"
+
+	t.Run("reads through the cache", func(t *testing.T) {
+		repo := new(MockRepository)
+		defaults := userdomain.DefaultPreferences()
+		repo.On("GetPreferences", ctx, userID).Return(&defaults, nil).Once()
+		uc, mr := newPreferencesUseCase(t, repo)
+
+		first, err := uc.GetPreferences(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "en", first.Locale)
+		assert.Nil(t, first.UpdatedAt)
+		assert.True(t, mr.Exists(preferencesCacheKey(userID)))
+
+		second, err := uc.GetPreferences(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+		repo.AssertExpectations(t) // the repository was read once
+	})
+
+	t.Run("without a cache", func(t *testing.T) {
+		repo := new(MockRepository)
+		defaults := userdomain.DefaultPreferences()
+		repo.On("GetPreferences", ctx, userID).Return(&defaults, nil).Twice()
+		uc := newUseCase(repo, nil, nil, nil)
+
+		for range 2 {
+			_, err := uc.GetPreferences(ctx, userID)
+			require.NoError(t, err)
+		}
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_UpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	const userID = "01234567-89ab-cdef-0123-456789abcdef"
+
+	t.Run("patches and refreshes the cache", func(t *testing.T) {
+		repo := new(MockRepository)
+		updatedAt := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+		updated := userdomain.DefaultPreferences()
+		updated.Timezone, updated.Notifications.Digest, updated.UpdatedAt = "Europe/Berlin", userdomain.DigestOff, &updatedAt
+		repo.On("UpdatePreferences", ctx, userID, userdomain.PreferencesPatch{
+			Timezone: types.Some("Europe/Berlin"),
+			Digest:   types.Some(userdomain.DigestOff),
+		}).Return(&updated, nil)
+		uc, _ := newPreferencesUseCase(t, repo)
+
+		resp, err := uc.UpdatePreferences(ctx, userID, dto.UpdatePreferencesRequest{
+			Timezone:      types.Some("Europe/Berlin"),
+			Notifications: dto.UpdateNotificationPreferencesRequest{Digest: types.Some(userdomain.DigestOff)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Berlin", resp.Timezone)
+		require.NotNil(t, resp.UpdatedAt)
+		assert.Equal(t, "2026-05-06T07:08:09Z", *resp.UpdatedAt)
+
+		// Served from the cache, not the repository
+		cached, err := uc.GetPreferences(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, resp, cached)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("an empty request changes nothing", func(t *testing.T) {
+		repo := new(MockRepository)
+		defaults := userdomain.DefaultPreferences()
+		repo.On("GetPreferences", ctx, userID).Return(&defaults, nil)
+		uc := newUseCase(repo, nil, nil, nil)
+
+		resp, err := uc.UpdatePreferences(ctx, userID, dto.UpdatePreferencesRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", resp.Timezone)
+		repo.AssertNotCalled(t, "UpdatePreferences")
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		uc := newUseCase(new(MockRepository), nil, nil, nil)
+		for _, req := range []dto.UpdatePreferencesRequest{
+			{Locale: types.Some("english")},
+			{Timezone: types.Some("Mars/Olympus")},
+			{Notifications: dto.UpdateNotificationPreferencesRequest{Digest: types.Some("hourly")}},
+		} {
+			_, err := uc.UpdatePreferences(ctx, userID, req)
+			assert.ErrorIs(t, err, apperr.ErrBadRequest)
+		}
+	})
+}
@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"time"
 
 	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
@@ -21,33 +23,76 @@ type userRepo interface {
 	GetByID(ctx context.Context, id string) (*userdomain.User, error)
 	GetByEmail(ctx context.Context, email string) (*userdomain.User, error)
 	List(ctx context.Context, filter userdomain.UserFilter) ([]userdomain.User, error)
+	Stream(ctx context.Context, filter userdomain.UserFilter) iter.Seq2[userdomain.User, error]
 	Create(ctx context.Context, email, passwordHash, name string) (*userdomain.User, error)
 	Update(ctx context.Context, id, name, email string) (*userdomain.User, error)
+	UpdateMetadata(ctx context.Context, id string, patch userdomain.MetadataPatch) (*userdomain.User, error)
+	SetPendingEmail(ctx context.Context, id, email string) (*userdomain.User, error)
 	UpdatePassword(ctx context.Context, id, passwordHash string) error
+	ForcePasswordReset(ctx context.Context, id, passwordHash string) error
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, id string) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	Activate(ctx context.Context, id string) error
 	Deactivate(ctx context.Context, id string) error
+	ListInactive(ctx context.Context, before time.Time, limit int) ([]userdomain.User, error)
+	ReassignAuditLogs(ctx context.Context, sourceID, targetID string) (int64, error)
+	ListRoleGrants(ctx context.Context, userID string) ([]string, error)
+	ReassignAuthzRules(ctx context.Context, sourceID, targetID string) (int64, error)
+	GetPreferences(ctx context.Context, userID string) (*userdomain.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID string, patch userdomain.PreferencesPatch) (*userdomain.Preferences, error)
+	ListLogins(ctx context.Context, userID string, limit int) ([]userdomain.Login, error)
+}
+
+// txRunner runs fn inside a database transaction. *database.Transactor
+// satisfies it; tests substitute a fake.
+type txRunner interface {
+	WithTx(ctx context.Context, fn database.TxFunc, opts ...database.TxOption) error
 }
 
 // userUseCase handles user business logic
 type userUseCase struct {
-	repo        userRepo
-	transactor  *database.Transactor
-	cache       port.Cache
-	authRevoker AuthRevoker
+	repo         userRepo
+	transactor   txRunner
+	cache        port.Cache
+	authRevoker  AuthRevoker
+	policy       PolicyReloader
+	roles        RoleReader
+	verifier     EmailVerifier
+	emailChanger EmailChanger
+	impersonator Impersonator
+	exports      *AsyncExport
+	events       *LifecycleEvents
 }
 
 // NewUseCase creates a new user use case.
 // authRevoker is the auth module's session-revocation interface; it may be nil
-// in tests that do not exercise ChangePassword revocation.
-func NewUseCase(repo *repository.Repository, transactor *database.Transactor, cache port.Cache, authRevoker AuthRevoker) UseCase {
-	return newUseCase(repo, transactor, cache, authRevoker)
+// in tests that do not exercise ChangePassword revocation. authorizer supplies
+// the roles in get and list responses and is reloaded after Merge rewrites
+// authorization rules; it may be nil. verifier sends
+// verification emails for new addresses; nil disables them. emailChanger
+// sends confirmation links for email changes; nil applies changes at once.
+// impersonator issues impersonation tokens; nil disables impersonation.
+// exports enables asynchronous exports; nil disables them. events publishes
+// lifecycle events; nil disables them.
+func NewUseCase(repo *repository.Repository, transactor *database.Transactor, cache port.Cache, authRevoker AuthRevoker, authorizer Authorizer, verifier EmailVerifier, emailChanger EmailChanger, impersonator Impersonator, exports *AsyncExport, events *LifecycleEvents) UseCase {
+	uc := newUseCase(repo, transactor, cache, authRevoker)
+	if authorizer != nil {
+		uc.policy = authorizer
+		uc.roles = authorizer
+	}
+	uc.verifier = verifier
+	uc.emailChanger = emailChanger
+	uc.impersonator = impersonator
+	uc.exports = exports
+	uc.events = events
+	return uc
 }
 
 // newUseCase is the internal constructor that accepts the userRepo interface,
 // enabling unit tests (same package) to inject mock repositories.
-func newUseCase(repo userRepo, transactor *database.Transactor, cache port.Cache, authRevoker AuthRevoker) UseCase {
+func newUseCase(repo userRepo, transactor txRunner, cache port.Cache, authRevoker AuthRevoker) *userUseCase {
 	return &userUseCase{
 		repo:        repo,
 		transactor:  transactor,
@@ -62,15 +107,31 @@ func (uc *userUseCase) GetByID(ctx context.Context, id string) (*dto.UserRespons
 	if err != nil {
 		return nil, err
 	}
-	return toUserResponse(user), nil
+	resp := toUserResponse(user)
+	if err := uc.withRoles(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // List retrieves a paginated list of users
 func (uc *userUseCase) List(ctx context.Context, req dto.ListUsersRequest) (shareddomain.CursorPage[dto.UserResponse], error) {
 	limit := shareddomain.NormalizeLimit(req.Limit)
 
+	metadata, metadataKeys, err := metadataFilter(req.Metadata, req.HasMetadata)
+	if err != nil {
+		return shareddomain.CursorPage[dto.UserResponse]{}, err
+	}
+
+	sort, err := shareddomain.ParseSort(req.Sort, userdomain.SortFields...)
+	if err != nil {
+		return shareddomain.CursorPage[dto.UserResponse]{}, apperr.BadRequestf("invalid sort: %v", err)
+	}
+	sortSpec := shareddomain.FormatSort(sort)
+
 	// Decode cursor if provided
 	var cursorID string
+	var cursorValues []string
 	var direction string
 	hasCursor := false
 
@@ -80,6 +141,13 @@ func (uc *userUseCase) List(ctx context.Context, req dto.ListUsersRequest) (shar
 			return shareddomain.CursorPage[dto.UserResponse]{}, apperr.BadRequestf("invalid cursor")
 		}
 		if cursor != nil {
+			// A keyset cursor only makes sense in the order it was issued for
+			if cursor.Sort != sortSpec {
+				return shareddomain.CursorPage[dto.UserResponse]{}, apperr.BadRequestf("cursor was issued for a different sort")
+			}
+			if cursorValues, err = cursor.LastValues(len(sort)); err != nil {
+				return shareddomain.CursorPage[dto.UserResponse]{}, apperr.BadRequestf("invalid cursor")
+			}
 			cursorID = cursor.LastID
 			direction = string(cursor.Direction)
 			hasCursor = true
@@ -94,6 +162,14 @@ func (uc *userUseCase) List(ctx context.Context, req dto.ListUsersRequest) (shar
 		Search:    req.Search,
 		Email:     req.Email,
 		IsActive:  req.IsActive,
+		Role:      req.Role,
+
+		IncludeDeleted: req.IncludeDeleted,
+		Metadata:       metadata,
+		MetadataKeys:   metadataKeys,
+
+		Sort:         sort,
+		CursorValues: cursorValues,
 	}
 
 	users, err := uc.repo.List(ctx, filter)
@@ -101,15 +177,27 @@ func (uc *userUseCase) List(ctx context.Context, req dto.ListUsersRequest) (shar
 		return shareddomain.CursorPage[dto.UserResponse]{}, err
 	}
 
-	// Convert to responses
+	// Convert to responses. Sort values are taken from the users rather than
+	// the responses, whose timestamps are truncated to seconds.
 	responses := make([]dto.UserResponse, 0, len(users))
+	sortValues := make(map[string][]string, len(users))
 	for _, u := range users {
-		responses = append(responses, *toUserResponse(&u))
+		resp := toUserResponse(&u)
+		if err := uc.withRoles(resp); err != nil {
+			return shareddomain.CursorPage[dto.UserResponse]{}, err
+		}
+		responses = append(responses, *resp)
+		if len(sort) > 0 {
+			sortValues[resp.ID] = u.SortValues(sort)
+		}
 	}
 
 	// Create bidirectional cursor page
 	return shareddomain.NewBidirectionalCursorPage(responses, limit, direction, hasCursor, func(u dto.UserResponse) *shareddomain.Cursor {
-		return &shareddomain.Cursor{LastID: u.ID}
+		if len(sort) == 0 {
+			return &shareddomain.Cursor{LastID: u.ID}
+		}
+		return &shareddomain.Cursor{LastID: u.ID, LastValue: sortValues[u.ID], Sort: sortSpec}
 	}), nil
 }
 
@@ -137,26 +225,148 @@ func (uc *userUseCase) Create(ctx context.Context, req dto.CreateUserRequest) (*
 		}
 
 		// Create user (within the same transaction)
-		user, err = uc.repo.Create(ctx, req.Email, string(passwordHash), req.Name)
-		return err
+		if user, err = uc.repo.Create(ctx, req.Email, string(passwordHash), req.Name); err != nil {
+			return err
+		}
+		return uc.publishEvent(ctx, userdomain.EventUserCreated, userdomain.EventData{User: userdomain.NewEventUser(user)})
 	}); err != nil {
 		return nil, err
 	}
 
+	uc.sendVerification(ctx, user)
 	return toUserResponse(user), nil
 }
 
-// Update updates a user
+// Update updates a user. Changing the email address clears its verification
+// and sends a verification email to the new address. Metadata is patched key
+// by key in the same transaction.
 func (uc *userUseCase) Update(ctx context.Context, id string, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
-	// Update user
-	user, err := uc.repo.Update(ctx, id, req.Name, req.Email)
+	patch, err := metadataPatch(req.Metadata)
 	if err != nil {
 		return nil, err
 	}
 
+	if uc.emailChanger != nil && req.Email != "" {
+		return uc.updatePendingEmail(ctx, id, req, patch)
+	}
+
+	var user *userdomain.User
+	update := func(ctx context.Context) error {
+		var err error
+		if user, err = uc.repo.Update(ctx, id, req.Name, req.Email); err != nil {
+			return err
+		}
+		if !patch.Empty() {
+			if user, err = uc.repo.UpdateMetadata(ctx, id, patch); err != nil {
+				return err
+			}
+		}
+		return uc.publishEvent(ctx, userdomain.EventUserUpdated, userdomain.EventData{
+			User:    userdomain.NewEventUser(user),
+			Changed: changedFields(req, "email", patch),
+		})
+	}
+	if patch.Empty() {
+		err = uc.eventTx(ctx, update)
+	} else {
+		err = uc.transactor.WithTx(ctx, update)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != "" {
+		uc.sendVerification(ctx, user)
+	}
 	return toUserResponse(user), nil
 }
 
+// changedFields names the fields req sets, calling the email emailField
+func changedFields(req dto.UpdateUserRequest, emailField string, patch userdomain.MetadataPatch) []string {
+	var changed []string
+	if req.Name != "" {
+		changed = append(changed, "name")
+	}
+	if req.Email != "" {
+		changed = append(changed, emailField)
+	}
+	if !patch.Empty() {
+		changed = append(changed, "metadata")
+	}
+	return changed
+}
+
+// updatePendingEmail is Update while email changes need confirmation: the new
+// address is stored as the user's pending email and a confirmation link is
+// sent to it, and the current address stays until the link is used. Setting
+// the current address cancels a pending change. The writes and the link are
+// one transaction, so when the link cannot be sent (e.g. too many requests)
+// nothing is changed.
+func (uc *userUseCase) updatePendingEmail(ctx context.Context, id string, req dto.UpdateUserRequest, patch userdomain.MetadataPatch) (*dto.UserResponse, error) {
+	pending, err := uc.pendingEmail(ctx, id, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *userdomain.User
+	err = uc.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if req.Name != "" {
+			if _, err := uc.repo.Update(ctx, id, req.Name, ""); err != nil {
+				return err
+			}
+		}
+		if user, err = uc.repo.SetPendingEmail(ctx, id, pending); err != nil {
+			return err
+		}
+		if !patch.Empty() {
+			if user, err = uc.repo.UpdateMetadata(ctx, id, patch); err != nil {
+				return err
+			}
+		}
+		if err := uc.publishEvent(ctx, userdomain.EventUserUpdated, userdomain.EventData{
+			User:    userdomain.NewEventUser(user),
+			Changed: changedFields(req, "pending_email", patch),
+		}); err != nil {
+			return err
+		}
+		if pending == "" {
+			return nil
+		}
+		return uc.emailChanger.SendEmailChange(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserResponse(user), nil
+}
+
+// pendingEmail returns the address user id asks to change to, or "" when it
+// is already theirs. An address of another user is a conflict.
+func (uc *userUseCase) pendingEmail(ctx context.Context, id, email string) (string, error) {
+	owner, err := uc.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if ae, ok := apperr.AsAppError(err); ok && ae.Code == apperr.CodeNotFound {
+			return email, nil
+		}
+		return "", err
+	}
+	if owner.ID.String() != id {
+		return "", apperr.Conflictf("user with email %s already exists", email)
+	}
+	return "", nil
+}
+
+// sendVerification asks the verifier to email user a verification link. The
+// user is already saved, so a failure is not returned: the user can ask for
+// another link through POST /auth/resend-verification.
+func (uc *userUseCase) sendVerification(ctx context.Context, user *userdomain.User) {
+	if uc.verifier == nil || user.EmailVerified() {
+		return
+	}
+	_ = uc.verifier.SendVerification(ctx, user.ID.String())
+}
+
 // ChangePassword changes a user's password and revokes all active refresh
 // tokens for that user. Revocation is mandatory: if the cache backend is
 // unavailable (ErrCacheUnavailable from NoOpCache or a Redis error) the
@@ -199,35 +409,112 @@ func (uc *userUseCase) ChangePassword(ctx context.Context, id string, req dto.Ch
 	return nil
 }
 
-// Delete soft-deletes a user
+// Delete soft-deletes a user. The account is deactivated and left out of
+// lists until it is restored or purged.
 func (uc *userUseCase) Delete(ctx context.Context, id string) error {
-	// Delete user
-	if err := uc.repo.Delete(ctx, id); err != nil {
+	return uc.eventTx(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return uc.publishDeactivated(ctx, id, userdomain.DeactivatedByDelete)
+	})
+}
+
+// Restore brings back a soft-deleted user, active again. Role grants are
+// kept while a user is deleted, so they apply again as before.
+func (uc *userUseCase) Restore(ctx context.Context, id string) error {
+	user, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.Deleted() {
+		return apperr.Conflictf("user %s is not deleted", id)
+	}
+
+	return uc.eventTx(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+
+		user.IsActive, user.DeletedAt = true, nil
+		return uc.publishEvent(ctx, userdomain.EventUserUpdated, userdomain.EventData{
+			User:    userdomain.NewEventUser(user),
+			Changed: []string{"is_active", "deleted_at"},
+		})
+	})
+}
+
+// errUserPurged marks a Purge error returned after the purge committed.
+var errUserPurged = errors.New("user purged")
+
+// Purge permanently deletes a user that has been soft-deleted, together with
+// their authorization rules, in one transaction. Requiring the soft delete
+// first keeps a single request from destroying an active account.
+//
+// After commit the authorization policy is reloaded and any refresh tokens
+// left are revoked. Those steps cannot join the transaction; if either fails
+// the user stays purged and an error is returned.
+func (uc *userUseCase) Purge(ctx context.Context, id string) error {
+	var userID string
+	if err := uc.transactor.WithTx(ctx, func(ctx context.Context) error {
+		user, err := uc.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !user.Deleted() {
+			return apperr.Conflictf("user %s must be deleted before it is purged", id)
+		}
+		userID = user.ID.String()
+		return uc.repo.Purge(ctx, userID)
+	}); err != nil {
 		return err
 	}
 
+	var errs []error
+	if uc.policy != nil {
+		if err := uc.policy.LoadPolicy(); err != nil {
+			errs = append(errs, fmt.Errorf("authorization policy reload failed: %w", err))
+		}
+	}
+	if uc.authRevoker != nil {
+		if err := uc.authRevoker.RevokeAllForUser(ctx, userID); err != nil {
+			errs = append(errs, fmt.Errorf("refresh token revocation failed: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w but %w", errUserPurged, errors.Join(errs...))
+	}
+
 	return nil
 }
 
-// Activate activates a user
+// Activate activates a user. A deleted user has to be restored instead.
 func (uc *userUseCase) Activate(ctx context.Context, id string) error {
 	// Verify user exists
 	user, err := uc.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if user.Deleted() {
+		return apperr.Conflictf("user %s is deleted; restore it instead", id)
+	}
 
 	// Already active
 	if user.IsActive {
 		return nil
 	}
 
-	// Activate user
-	if err := uc.repo.Activate(ctx, id); err != nil {
-		return err
-	}
+	return uc.eventTx(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Activate(ctx, id); err != nil {
+			return err
+		}
 
-	return nil
+		user.IsActive = true
+		return uc.publishEvent(ctx, userdomain.EventUserUpdated, userdomain.EventData{
+			User:    userdomain.NewEventUser(user),
+			Changed: []string{"is_active"},
+		})
+	})
 }
 
 // Deactivate deactivates a user
@@ -243,22 +530,221 @@ func (uc *userUseCase) Deactivate(ctx context.Context, id string) error {
 		return nil
 	}
 
-	// Deactivate user
-	if err := uc.repo.Deactivate(ctx, id); err != nil {
-		return err
+	return uc.eventTx(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Deactivate(ctx, id); err != nil {
+			return err
+		}
+
+		user.IsActive = false
+		return uc.publishEvent(ctx, userdomain.EventUserDeactivated, userdomain.EventData{
+			User:   userdomain.NewEventUser(user),
+			Reason: userdomain.DeactivatedByAdmin,
+		})
+	})
+}
+
+// Inactive-users report defaults
+const (
+	DefaultInactiveDays  = 90
+	DefaultInactiveLimit = 100
+)
+
+// ListInactive returns active users with no tracked activity in the last
+// req.Days days, least recently seen first. Users never seen since tracking
+// began are measured from their creation time.
+func (uc *userUseCase) ListInactive(ctx context.Context, req dto.ListInactiveUsersRequest) ([]dto.UserResponse, error) {
+	days := req.Days
+	if days <= 0 {
+		days = DefaultInactiveDays
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultInactiveLimit
 	}
 
+	before := time.Now().AddDate(0, 0, -days)
+	users, err := uc.repo.ListInactive(ctx, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, *toUserResponse(&users[i]))
+	}
+	return responses, nil
+}
+
+// DefaultLoginsLimit is how many logins ListLogins returns by default
+const DefaultLoginsLimit = 20
+
+// ListLogins returns the user's most recent logins, newest first. Only the
+// last activity.login_history_size logins are kept.
+func (uc *userUseCase) ListLogins(ctx context.Context, id string, req dto.ListLoginsRequest) ([]dto.LoginResponse, error) {
+	if _, err := uc.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLoginsLimit
+	}
+	logins, err := uc.repo.ListLogins(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.LoginResponse, 0, len(logins))
+	for _, l := range logins {
+		responses = append(responses, dto.LoginResponse{
+			LoggedInAt: l.LoggedInAt.Format(time.RFC3339),
+			IPAddress:  l.IPAddress,
+			UserAgent:  l.UserAgent,
+		})
+	}
+	return responses, nil
+}
+
+// errMergeDryRun rolls back a dry-run merge after every step has run, so the
+// preview reports exactly what a real merge would do.
+var errMergeDryRun = errors.New("merge dry run")
+
+// Merge folds the duplicate account req.SourceID into targetID in a single
+// transaction: audit entries, role grants and direct permissions move to the
+// target and the source is deactivated. With req.DryRun the transaction is
+// rolled back and the response previews the changes.
+//
+// After commit the authorization policy is reloaded and the source's refresh
+// tokens are revoked. Those steps cannot join the transaction; if either
+// fails the merge stands, and the response is returned with an error.
+func (uc *userUseCase) Merge(ctx context.Context, targetID string, req dto.MergeUsersRequest) (*dto.MergeUsersResponse, error) {
+	if req.SourceID == targetID {
+		return nil, apperr.BadRequestf("cannot merge a user into itself")
+	}
+
+	resp := &dto.MergeUsersResponse{DryRun: req.DryRun}
+
+	err := uc.transactor.WithTx(ctx, func(ctx context.Context) error {
+		target, err := uc.repo.GetByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		source, err := uc.repo.GetByID(ctx, req.SourceID)
+		if err != nil {
+			return err
+		}
+		// Compare and store canonical IDs: the policy table keys users by
+		// their lower-case UUID.
+		if source.ID == target.ID {
+			return apperr.BadRequestf("cannot merge a user into itself")
+		}
+		if !target.IsActive {
+			return apperr.Conflictf("target user %s is inactive", targetID)
+		}
+		resp.TargetID = target.ID.String()
+		resp.SourceID = source.ID.String()
+
+		if resp.RoleGrants, err = uc.repo.ListRoleGrants(ctx, resp.SourceID); err != nil {
+			return err
+		}
+		if resp.AuditEntriesReassigned, err = uc.repo.ReassignAuditLogs(ctx, resp.SourceID, resp.TargetID); err != nil {
+			return err
+		}
+		if resp.AuthzRulesMoved, err = uc.repo.ReassignAuthzRules(ctx, resp.SourceID, resp.TargetID); err != nil {
+			return err
+		}
+		if source.IsActive {
+			if err := uc.repo.Deactivate(ctx, resp.SourceID); err != nil {
+				return err
+			}
+			resp.SourceDeactivated = true
+		}
+
+		if req.DryRun {
+			return errMergeDryRun
+		}
+		if resp.SourceDeactivated {
+			return uc.publishDeactivated(ctx, resp.SourceID, userdomain.DeactivatedByMerge)
+		}
+		return nil
+	})
+	if req.DryRun && errors.Is(err, errMergeDryRun) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if uc.policy != nil {
+		if err := uc.policy.LoadPolicy(); err != nil {
+			errs = append(errs, fmt.Errorf("authorization policy reload failed: %w", err))
+		}
+	}
+	if uc.authRevoker != nil {
+		if err := uc.authRevoker.RevokeAllForUser(ctx, resp.SourceID); err != nil {
+			errs = append(errs, fmt.Errorf("refresh token revocation failed: %w", err))
+		} else {
+			resp.SessionsRevoked = true
+		}
+	}
+	if len(errs) > 0 {
+		return resp, fmt.Errorf("users merged but %w", errors.Join(errs...))
+	}
+
+	return resp, nil
+}
+
+// withRoles fills in resp.Roles from the authorizer. Casbin holds its policy
+// in memory, so this is a map lookup per user rather than a query.
+func (uc *userUseCase) withRoles(resp *dto.UserResponse) error {
+	if uc.roles == nil {
+		return nil
+	}
+	roles, err := uc.roles.GetRolesForUser(resp.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read roles for user %s: %w", resp.ID, err)
+	}
+	resp.Roles = roles
 	return nil
 }
 
 // toUserResponse converts a domain user to a response DTO
 func toUserResponse(user *userdomain.User) *dto.UserResponse {
-	return &dto.UserResponse{
-		ID:        user.ID.String(),
-		Email:     user.Email,
-		Name:      user.Name,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+	resp := &dto.UserResponse{
+		ID:                 user.ID.String(),
+		Email:              user.Email,
+		Name:               user.Name,
+		IsActive:           user.IsActive,
+		CreatedAt:          user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          user.UpdatedAt.Format(time.RFC3339),
+		Metadata:           user.Metadata,
+		MustChangePassword: user.MustChangePassword,
+	}
+	if len(resp.Metadata) == 0 {
+		resp.Metadata = emptyMetadata
+	}
+	if user.LastSeenAt != nil {
+		lastSeen := user.LastSeenAt.Format(time.RFC3339)
+		resp.LastSeenAt = &lastSeen
+	}
+	if user.EmailVerifiedAt != nil {
+		verified := user.EmailVerifiedAt.Format(time.RFC3339)
+		resp.EmailVerifiedAt = &verified
+	}
+	if user.DeletedAt != nil {
+		deleted := user.DeletedAt.Format(time.RFC3339)
+		resp.DeletedAt = &deleted
+	}
+	if user.LastLoginAt != nil {
+		lastLogin := user.LastLoginAt.Format(time.RFC3339)
+		resp.LastLoginAt = &lastLogin
+	}
+	if user.LastLoginIP != "" {
+		resp.LastLoginIP = &user.LastLoginIP
+	}
+	if user.PendingEmail != "" {
+		resp.PendingEmail = &user.PendingEmail
 	}
+	return resp
 }
@@ -3,16 +3,22 @@ package usecase
 import (
 	"context"
 	"errors"
+	"iter"
+	"net/http"
 	"testing"
 	"time"
 
 	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
 	"github.com/14mdzk/goscratch/internal/module/user/dto"
+	"github.com/14mdzk/goscratch/internal/platform/database"
 	"github.com/14mdzk/goscratch/internal/port"
+	shareddomain "github.com/14mdzk/goscratch/internal/shared/domain"
 	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -42,6 +48,11 @@ func (m *MockRepository) List(ctx context.Context, filter userdomain.UserFilter)
 	return args.Get(0).([]userdomain.User), args.Error(1)
 }
 
+func (m *MockRepository) Stream(ctx context.Context, filter userdomain.UserFilter) iter.Seq2[userdomain.User, error] {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(iter.Seq2[userdomain.User, error])
+}
+
 func (m *MockRepository) Create(ctx context.Context, email, passwordHash, name string) (*userdomain.User, error) {
 	args := m.Called(ctx, email, passwordHash, name)
 	if args.Get(0) == nil {
@@ -58,16 +69,63 @@ func (m *MockRepository) Update(ctx context.Context, id, name, email string) (*u
 	return args.Get(0).(*userdomain.User), args.Error(1)
 }
 
+func (m *MockRepository) UpdateMetadata(ctx context.Context, id string, patch userdomain.MetadataPatch) (*userdomain.User, error) {
+	args := m.Called(ctx, id, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userdomain.User), args.Error(1)
+}
+
+func (m *MockRepository) SetPendingEmail(ctx context.Context, id, email string) (*userdomain.User, error) {
+	args := m.Called(ctx, id, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userdomain.User), args.Error(1)
+}
+
+func (m *MockRepository) GetPreferences(ctx context.Context, userID string) (*userdomain.Preferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userdomain.Preferences), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePreferences(ctx context.Context, userID string, patch userdomain.PreferencesPatch) (*userdomain.Preferences, error) {
+	args := m.Called(ctx, userID, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userdomain.Preferences), args.Error(1)
+}
+
 func (m *MockRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
 	args := m.Called(ctx, id, passwordHash)
 	return args.Error(0)
 }
 
+func (m *MockRepository) ForcePasswordReset(ctx context.Context, id, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
 func (m *MockRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockRepository) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Purge(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	args := m.Called(ctx, email)
 	return args.Bool(0), args.Error(1)
@@ -88,6 +146,40 @@ func (m *MockRepository) Deactivate(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockRepository) ListInactive(ctx context.Context, before time.Time, limit int) ([]userdomain.User, error) {
+	args := m.Called(ctx, before, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]userdomain.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ListLogins(ctx context.Context, userID string, limit int) ([]userdomain.Login, error) {
+	args := m.Called(ctx, userID, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]userdomain.Login), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ReassignAuditLogs(ctx context.Context, sourceID, targetID string) (int64, error) {
+	args := m.Called(ctx, sourceID, targetID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) ListRoleGrants(ctx context.Context, userID string) ([]string, error) {
+	args := m.Called(ctx, userID)
+	if v := args.Get(0); v != nil {
+		return v.([]string), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ReassignAuthzRules(ctx context.Context, sourceID, targetID string) (int64, error) {
+	args := m.Called(ctx, sourceID, targetID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // MockCache is a testify mock for port.Cache, used to verify ChangePassword
 // revocation behaviour in isolation.
 type MockCache struct {
@@ -417,6 +509,114 @@ func TestUseCase_List(t *testing.T) {
 	})
 }
 
+func TestUseCase_ListSorted(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 3, 4, 5, 6, 7, 123456000, time.UTC)
+	users := []userdomain.User{
+		{ID: uuid.New(), Name: "Ann", CreatedAt: created},
+		{ID: uuid.New(), Name: "Bob", CreatedAt: created.Add(-time.Second)},
+		{ID: uuid.New(), Name: "Cy", CreatedAt: created.Add(-2 * time.Second)},
+	}
+	sort := []shareddomain.SortField{{Field: "name"}, {Field: "created_at", Desc: true}}
+
+	t.Run("cursor_carries_sort_values", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("List", ctx, userdomain.UserFilter{Limit: 2, Sort: sort}).Return(users, nil)
+
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		page, err := uc.List(ctx, dto.ListUsersRequest{Limit: 2, Sort: "name,-created_at"})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		require.NotNil(t, page.NextCursor)
+
+		cursor, err := shareddomain.DecodeCursor(*page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, users[1].ID.String(), cursor.LastID)
+		assert.Equal(t, "name,-created_at", cursor.Sort)
+		values, err := cursor.LastValues(2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bob", "2026-03-04T05:06:06.123456Z"}, values)
+
+		// The cursor leads to the next page in the same order
+		mockRepo.On("List", ctx, userdomain.UserFilter{
+			Limit: 2, Sort: sort, Cursor: cursor.LastID, CursorValues: values, Direction: "next",
+		}).Return(users[2:], nil)
+		_, err = uc.List(ctx, dto.ListUsersRequest{Limit: 2, Sort: "name,-created_at", Cursor: *page.NextCursor})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid_sort", func(t *testing.T) {
+		uc := newUseCase(new(MockRepository), nil, nil, nil)
+		_, err := uc.List(ctx, dto.ListUsersRequest{Sort: "password_hash"})
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+	})
+
+	t.Run("cursor_for_another_sort", func(t *testing.T) {
+		uc := newUseCase(new(MockRepository), nil, nil, nil)
+		cursor := (&shareddomain.Cursor{LastID: users[0].ID.String(), LastValue: []string{"Ann"}, Sort: "name"}).Encode()
+
+		_, err := uc.List(ctx, dto.ListUsersRequest{Sort: "-name", Cursor: cursor})
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+
+		_, err = uc.List(ctx, dto.ListUsersRequest{Cursor: cursor})
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+	})
+}
+
+// fakeRoles serves role grants from a map
+type fakeRoles struct {
+	grants map[string][]string
+	err    error
+}
+
+func (f fakeRoles) GetRolesForUser(userID string) ([]string, error) {
+	return f.grants[userID], f.err
+}
+
+func TestUseCase_Roles(t *testing.T) {
+	ctx := context.Background()
+	admin := userdomain.User{ID: uuid.New(), Email: "admin@example.com"}
+	plain := userdomain.User{ID: uuid.New(), Email: "plain@example.com"}
+	roles := fakeRoles{grants: map[string][]string{admin.ID.String(): {"admin", "editor"}}}
+
+	t.Run("get_includes_roles", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("GetByID", ctx, admin.ID.String()).Return(&admin, nil)
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		uc.roles = roles
+
+		resp, err := uc.GetByID(ctx, admin.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin", "editor"}, resp.Roles)
+	})
+
+	t.Run("list_filters_by_role_and_includes_roles", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("List", ctx, userdomain.UserFilter{Limit: 20, Role: types.Some("admin")}).Return([]userdomain.User{admin, plain}, nil)
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		uc.roles = roles
+
+		page, err := uc.List(ctx, dto.ListUsersRequest{Role: types.Some("admin")})
+		require.NoError(t, err)
+		items := page.GetItems()
+		require.Len(t, items, 2)
+		assert.Equal(t, []string{"admin", "editor"}, items[0].Roles)
+		assert.Empty(t, items[1].Roles)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("authorizer_error", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("GetByID", ctx, admin.ID.String()).Return(&admin, nil)
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		uc.roles = fakeRoles{err: errors.New("enforcer not loaded")}
+
+		_, err := uc.GetByID(ctx, admin.ID.String())
+		assert.Error(t, err)
+	})
+}
+
 func TestUserDTO_Validation(t *testing.T) {
 	t.Run("valid_create_request", func(t *testing.T) {
 		req := dto.CreateUserRequest{
@@ -754,6 +954,11 @@ func (m *MockAuthRevoker) RevokeAllForUser(ctx context.Context, userID string) e
 	return args.Error(0)
 }
 
+func (m *MockAuthRevoker) RevokeAccessTokens(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 // TestChangePassword_AuthRevokerCalled verifies that ChangePassword delegates
 // session revocation to AuthRevoker.RevokeAllForUser with the correct userID.
 func TestChangePassword_AuthRevokerCalled(t *testing.T) {
@@ -831,3 +1036,621 @@ func TestChangePassword_AuthRevokerCalled(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestUseCase_ListInactive(t *testing.T) {
+	ctx := context.Background()
+	lastSeen := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("defaults_and_mapping", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("ListInactive", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Until(before) < -89*24*time.Hour && time.Until(before) > -91*24*time.Hour
+		}), DefaultInactiveLimit).Return([]userdomain.User{
+			{ID: uuid.New(), Email: "never@example.com"},
+			{ID: uuid.New(), Email: "seen@example.com", LastSeenAt: &lastSeen},
+		}, nil)
+
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		users, err := uc.ListInactive(ctx, dto.ListInactiveUsersRequest{})
+
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Nil(t, users[0].LastSeenAt)
+		require.NotNil(t, users[1].LastSeenAt)
+		assert.Equal(t, "2026-01-02T03:04:05Z", *users[1].LastSeenAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("custom_days_and_limit", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("ListInactive", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Until(before) < -6*24*time.Hour && time.Until(before) > -8*24*time.Hour
+		}), 10).Return([]userdomain.User{}, nil)
+
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		users, err := uc.ListInactive(ctx, dto.ListInactiveUsersRequest{Days: 7, Limit: 10})
+
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUseCase_ListLogins(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	loggedIn := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("defaults_and_mapping", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("GetByID", ctx, id.String()).Return(&userdomain.User{ID: id, LastLoginAt: &loggedIn, LastLoginIP: "203.0.113.7"}, nil)
+		mockRepo.On("ListLogins", ctx, id.String(), DefaultLoginsLimit).Return([]userdomain.Login{
+			{ID: 2, UserID: id, IPAddress: "203.0.113.7", UserAgent: "curl/8.0", LoggedInAt: loggedIn},
+			{ID: 1, UserID: id, LoggedInAt: loggedIn.Add(-time.Hour)},
+		}, nil)
+
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		logins, err := uc.ListLogins(ctx, id.String(), dto.ListLoginsRequest{})
+
+		require.NoError(t, err)
+		assert.Equal(t, []dto.LoginResponse{
+			{LoggedInAt: "2026-01-02T03:04:05Z", IPAddress: "203.0.113.7", UserAgent: "curl/8.0"},
+			{LoggedInAt: "2026-01-02T02:04:05Z"},
+		}, logins)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown_user", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("GetByID", ctx, id.String()).Return(nil, apperr.NotFoundf("user %s not found", id))
+
+		uc := newUseCase(mockRepo, nil, nil, nil)
+		_, err := uc.ListLogins(ctx, id.String(), dto.ListLoginsRequest{Limit: 5})
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+		mockRepo.AssertNotCalled(t, "ListLogins", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestToUserResponse_LastLogin(t *testing.T) {
+	resp := toUserResponse(&userdomain.User{ID: uuid.New()})
+	assert.Nil(t, resp.LastLoginAt)
+	assert.Nil(t, resp.LastLoginIP)
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	resp = toUserResponse(&userdomain.User{ID: uuid.New(), LastLoginAt: &at, LastLoginIP: "203.0.113.7"})
+	require.NotNil(t, resp.LastLoginAt)
+	assert.Equal(t, "2026-01-02T03:04:05Z", *resp.LastLoginAt)
+	require.NotNil(t, resp.LastLoginIP)
+	assert.Equal(t, "203.0.113.7", *resp.LastLoginIP)
+}
+
+// ---------------------------------------------------------------------------
+// Merge
+// ---------------------------------------------------------------------------
+
+// fakeTx runs the transaction body inline and records whether it would have
+// committed (fn returned nil) or rolled back.
+type fakeTx struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (f *fakeTx) WithTx(ctx context.Context, fn database.TxFunc, _ ...database.TxOption) error {
+	if err := fn(ctx); err != nil {
+		f.rolledBack = true
+		return err
+	}
+	f.committed = true
+	return nil
+}
+
+type mockPolicyReloader struct {
+	mock.Mock
+}
+
+func (m *mockPolicyReloader) LoadPolicy() error {
+	return m.Called().Error(0)
+}
+
+func TestUseCase_Merge(t *testing.T) {
+	ctx := context.Background()
+	targetID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	sourceID := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	target := &userdomain.User{ID: targetID, Email: "jane@example.com", IsActive: true}
+	source := &userdomain.User{ID: sourceID, Email: "Jane@Example.com", IsActive: true}
+
+	// expectMerge sets up the in-transaction repository calls.
+	expectMerge := func(repo *MockRepository) {
+		repo.On("GetByID", ctx, targetID.String()).Return(target, nil)
+		repo.On("GetByID", ctx, sourceID.String()).Return(source, nil)
+		repo.On("ListRoleGrants", ctx, sourceID.String()).Return([]string{"editor"}, nil)
+		repo.On("ReassignAuditLogs", ctx, sourceID.String(), targetID.String()).Return(int64(4), nil)
+		repo.On("ReassignAuthzRules", ctx, sourceID.String(), targetID.String()).Return(int64(2), nil)
+		repo.On("Deactivate", ctx, sourceID.String()).Return(nil)
+	}
+
+	t.Run("commits, reloads policy and revokes source sessions", func(t *testing.T) {
+		repo := new(MockRepository)
+		expectMerge(repo)
+		revoker := new(MockAuthRevoker)
+		revoker.On("RevokeAllForUser", ctx, sourceID.String()).Return(nil)
+		policy := new(mockPolicyReloader)
+		policy.On("LoadPolicy").Return(nil)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, revoker)
+		uc.policy = policy
+
+		resp, err := uc.Merge(ctx, targetID.String(), dto.MergeUsersRequest{SourceID: sourceID.String()})
+
+		require.NoError(t, err)
+		assert.True(t, tx.committed)
+		assert.Equal(t, &dto.MergeUsersResponse{
+			TargetID:               targetID.String(),
+			SourceID:               sourceID.String(),
+			AuditEntriesReassigned: 4,
+			RoleGrants:             []string{"editor"},
+			AuthzRulesMoved:        2,
+			SourceDeactivated:      true,
+			SessionsRevoked:        true,
+		}, resp)
+		repo.AssertExpectations(t)
+		revoker.AssertExpectations(t)
+		policy.AssertExpectations(t)
+	})
+
+	t.Run("dry run rolls back and skips post-commit steps", func(t *testing.T) {
+		repo := new(MockRepository)
+		expectMerge(repo)
+		revoker := new(MockAuthRevoker)
+		policy := new(mockPolicyReloader)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, revoker)
+		uc.policy = policy
+
+		resp, err := uc.Merge(ctx, targetID.String(), dto.MergeUsersRequest{SourceID: sourceID.String(), DryRun: true})
+
+		require.NoError(t, err)
+		assert.True(t, tx.rolledBack)
+		assert.False(t, tx.committed)
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, int64(4), resp.AuditEntriesReassigned)
+		assert.True(t, resp.SourceDeactivated)
+		assert.False(t, resp.SessionsRevoked)
+		revoker.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+		policy.AssertNotCalled(t, "LoadPolicy")
+	})
+
+	t.Run("rejects merging a user into itself", func(t *testing.T) {
+		repo := new(MockRepository)
+		tx := &fakeTx{}
+		uc := newUseCase(repo, tx, nil, nil)
+
+		resp, err := uc.Merge(ctx, targetID.String(), dto.MergeUsersRequest{SourceID: targetID.String()})
+
+		assert.Nil(t, resp)
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeBadRequest, appErr.Code)
+		assert.False(t, tx.committed)
+	})
+
+	t.Run("rejects an inactive target", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, targetID.String()).Return(&userdomain.User{ID: targetID}, nil)
+		repo.On("GetByID", ctx, sourceID.String()).Return(source, nil)
+		tx := &fakeTx{}
+		uc := newUseCase(repo, tx, nil, nil)
+
+		resp, err := uc.Merge(ctx, targetID.String(), dto.MergeUsersRequest{SourceID: sourceID.String()})
+
+		assert.Nil(t, resp)
+		appErr, ok := apperr.AsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperr.CodeConflict, appErr.Code)
+		assert.True(t, tx.rolledBack)
+	})
+
+	t.Run("repository failure rolls back", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, targetID.String()).Return(target, nil)
+		repo.On("GetByID", ctx, sourceID.String()).Return(source, nil)
+		repo.On("ListRoleGrants", ctx, sourceID.String()).Return([]string{}, nil)
+		repo.On("ReassignAuditLogs", ctx, sourceID.String(), targetID.String()).Return(int64(0), errors.New("db error"))
+		revoker := new(MockAuthRevoker)
+		tx := &fakeTx{}
+		uc := newUseCase(repo, tx, nil, revoker)
+
+		resp, err := uc.Merge(ctx, targetID.String(), dto.MergeUsersRequest{SourceID: sourceID.String()})
+
+		assert.Nil(t, resp)
+		assert.EqualError(t, err, "db error")
+		assert.True(t, tx.rolledBack)
+		repo.AssertNotCalled(t, "Deactivate", mock.Anything, mock.Anything)
+		revoker.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("revocation failure returns the committed result with an error", func(t *testing.T) {
+		repo := new(MockRepository)
+		expectMerge(repo)
+		revoker := new(MockAuthRevoker)
+		revoker.On("RevokeAllForUser", ctx, sourceID.String()).Return(errors.New("cache unavailable"))
+		tx := &fakeTx{}
+		uc := newUseCase(repo, tx, nil, revoker)
+
+		resp, err := uc.Merge(ctx, targetID.String(), dto.MergeUsersRequest{SourceID: sourceID.String()})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "users merged but refresh token revocation failed")
+		require.NotNil(t, resp)
+		assert.True(t, tx.committed)
+		assert.False(t, resp.SessionsRevoked)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Restore and Purge
+// ---------------------------------------------------------------------------
+
+func TestUseCase_Restore(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	deletedAt := time.Now()
+
+	t.Run("restores a deleted user", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, testID.String()).Return(&userdomain.User{ID: testID, DeletedAt: &deletedAt}, nil)
+		repo.On("Restore", ctx, testID.String()).Return(nil)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		require.NoError(t, uc.Restore(ctx, testID.String()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a user that is not deleted", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, testID.String()).Return(&userdomain.User{ID: testID}, nil)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		err := uc.Restore(ctx, testID.String())
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		repo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("activate refuses a deleted user", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, testID.String()).Return(&userdomain.User{ID: testID, DeletedAt: &deletedAt}, nil)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		err := uc.Activate(ctx, testID.String())
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		repo.AssertNotCalled(t, "Activate", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUseCase_Purge(t *testing.T) {
+	ctx := context.Background()
+	testID := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	deletedAt := time.Now()
+	deleted := &userdomain.User{ID: testID, DeletedAt: &deletedAt}
+
+	t.Run("purges, reloads policy and revokes sessions", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, testID.String()).Return(deleted, nil)
+		repo.On("Purge", ctx, testID.String()).Return(nil)
+		revoker := new(MockAuthRevoker)
+		revoker.On("RevokeAllForUser", ctx, testID.String()).Return(nil)
+		policy := new(mockPolicyReloader)
+		policy.On("LoadPolicy").Return(nil)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, revoker)
+		uc.policy = policy
+
+		require.NoError(t, uc.Purge(ctx, testID.String()))
+		assert.True(t, tx.committed)
+		repo.AssertExpectations(t)
+		revoker.AssertExpectations(t)
+		policy.AssertExpectations(t)
+	})
+
+	t.Run("requires a soft delete first", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, testID.String()).Return(&userdomain.User{ID: testID, IsActive: true}, nil)
+		revoker := new(MockAuthRevoker)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, revoker)
+		err := uc.Purge(ctx, testID.String())
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		assert.True(t, tx.rolledBack)
+		repo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+		revoker.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("policy reload failure is reported after commit", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByID", ctx, testID.String()).Return(deleted, nil)
+		repo.On("Purge", ctx, testID.String()).Return(nil)
+		policy := new(mockPolicyReloader)
+		policy.On("LoadPolicy").Return(errors.New("adapter down"))
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, nil)
+		uc.policy = policy
+
+		err := uc.Purge(ctx, testID.String())
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errUserPurged)
+		assert.Contains(t, err.Error(), "authorization policy reload failed")
+		assert.True(t, tx.committed)
+	})
+}
+
+// MockEmailVerifier is a testify mock for EmailVerifier
+type MockEmailVerifier struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerifier) SendVerification(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func TestUseCase_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	verifiedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("create sends a link", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+		repo.On("Create", ctx, "new@example.com", mock.AnythingOfType("string"), "New User").
+			Return(&userdomain.User{ID: id, Email: "new@example.com", Name: "New User", IsActive: true}, nil)
+		verifier := new(MockEmailVerifier)
+		verifier.On("SendVerification", ctx, id.String()).Return(nil)
+
+		uc := newUseCase(repo, &fakeTx{}, nil, nil)
+		uc.verifier = verifier
+		resp, err := uc.Create(ctx, dto.CreateUserRequest{Email: "new@example.com", Password: "password123", Name: "New User"})
+
+		require.NoError(t, err)
+		assert.Nil(t, resp.EmailVerifiedAt)
+		verifier.AssertExpectations(t)
+	})
+
+	t.Run("send failure does not fail create", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+		repo.On("Create", ctx, "new@example.com", mock.AnythingOfType("string"), "New User").
+			Return(&userdomain.User{ID: id, Email: "new@example.com", Name: "New User", IsActive: true}, nil)
+		verifier := new(MockEmailVerifier)
+		verifier.On("SendVerification", ctx, id.String()).Return(errors.New("queue down"))
+
+		uc := newUseCase(repo, &fakeTx{}, nil, nil)
+		uc.verifier = verifier
+		_, err := uc.Create(ctx, dto.CreateUserRequest{Email: "new@example.com", Password: "password123", Name: "New User"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("email change sends a link", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Update", ctx, id.String(), "", "changed@example.com").
+			Return(&userdomain.User{ID: id, Email: "changed@example.com", IsActive: true}, nil)
+		verifier := new(MockEmailVerifier)
+		verifier.On("SendVerification", ctx, id.String()).Return(nil)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		uc.verifier = verifier
+		_, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Email: "changed@example.com"})
+
+		require.NoError(t, err)
+		verifier.AssertExpectations(t)
+	})
+
+	t.Run("name change or unchanged verified address sends nothing", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Update", ctx, id.String(), "Renamed", "").
+			Return(&userdomain.User{ID: id, Email: "user@example.com", IsActive: true}, nil)
+		repo.On("Update", ctx, id.String(), "", "user@example.com").
+			Return(&userdomain.User{ID: id, Email: "user@example.com", IsActive: true, EmailVerifiedAt: &verifiedAt}, nil)
+		verifier := new(MockEmailVerifier)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		uc.verifier = verifier
+		_, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Name: "Renamed"})
+		require.NoError(t, err)
+		resp, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Email: "user@example.com"})
+		require.NoError(t, err)
+
+		require.NotNil(t, resp.EmailVerifiedAt)
+		assert.Equal(t, "2026-01-02T03:04:05Z", *resp.EmailVerifiedAt)
+		verifier.AssertNotCalled(t, "SendVerification", mock.Anything, mock.Anything)
+	})
+}
+
+// MockEmailChanger is a testify mock for EmailChanger
+type MockEmailChanger struct {
+	mock.Mock
+}
+
+func (m *MockEmailChanger) SendEmailChange(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func TestUseCase_EmailChange(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	notFound := apperr.NotFoundf("user with email new@example.com not found")
+
+	t.Run("new address is kept pending and sent a link", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByEmail", ctx, "new@example.com").Return(nil, notFound)
+		repo.On("Update", ctx, id.String(), "Renamed", "").
+			Return(&userdomain.User{ID: id, Email: "user@example.com", Name: "Renamed"}, nil)
+		repo.On("SetPendingEmail", ctx, id.String(), "new@example.com").
+			Return(&userdomain.User{ID: id, Email: "user@example.com", Name: "Renamed", PendingEmail: "new@example.com"}, nil)
+		changer := new(MockEmailChanger)
+		changer.On("SendEmailChange", ctx, id.String()).Return(nil)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, nil)
+		uc.emailChanger = changer
+		resp, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Name: "Renamed", Email: "new@example.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", resp.Email, "the address changes only once confirmed")
+		require.NotNil(t, resp.PendingEmail)
+		assert.Equal(t, "new@example.com", *resp.PendingEmail)
+		assert.True(t, tx.committed)
+		changer.AssertExpectations(t)
+		repo.AssertNotCalled(t, "Update", ctx, id.String(), mock.Anything, "new@example.com")
+	})
+
+	t.Run("address of another user is a conflict", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByEmail", ctx, "taken@example.com").
+			Return(&userdomain.User{ID: uuid.New(), Email: "taken@example.com"}, nil)
+		changer := new(MockEmailChanger)
+
+		uc := newUseCase(repo, &fakeTx{}, nil, nil)
+		uc.emailChanger = changer
+		_, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Email: "taken@example.com"})
+
+		var ae *apperr.Error
+		require.ErrorAs(t, err, &ae)
+		assert.Equal(t, apperr.CodeConflict, ae.Code)
+		changer.AssertNotCalled(t, "SendEmailChange", mock.Anything, mock.Anything)
+	})
+
+	t.Run("current address cancels a pending change", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByEmail", ctx, "user@example.com").Return(&userdomain.User{ID: id, Email: "user@example.com"}, nil)
+		repo.On("SetPendingEmail", ctx, id.String(), "").
+			Return(&userdomain.User{ID: id, Email: "user@example.com"}, nil)
+		changer := new(MockEmailChanger)
+
+		uc := newUseCase(repo, &fakeTx{}, nil, nil)
+		uc.emailChanger = changer
+		resp, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Email: "user@example.com"})
+
+		require.NoError(t, err)
+		assert.Nil(t, resp.PendingEmail)
+		changer.AssertNotCalled(t, "SendEmailChange", mock.Anything, mock.Anything)
+	})
+
+	t.Run("send failure rolls the update back", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetByEmail", ctx, "new@example.com").Return(nil, notFound)
+		repo.On("SetPendingEmail", ctx, id.String(), "new@example.com").
+			Return(&userdomain.User{ID: id, Email: "user@example.com", PendingEmail: "new@example.com"}, nil)
+		changer := new(MockEmailChanger)
+		throttled := apperr.New("EMAIL_CHANGE_THROTTLED", "Too many email change requests", http.StatusTooManyRequests)
+		changer.On("SendEmailChange", ctx, id.String()).Return(throttled)
+		tx := &fakeTx{}
+
+		uc := newUseCase(repo, tx, nil, nil)
+		uc.emailChanger = changer
+		_, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Email: "new@example.com"})
+
+		assert.ErrorIs(t, err, throttled)
+		assert.True(t, tx.rolledBack)
+	})
+
+	t.Run("name only change needs no confirmation", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Update", ctx, id.String(), "Renamed", "").
+			Return(&userdomain.User{ID: id, Email: "user@example.com", Name: "Renamed"}, nil)
+		changer := new(MockEmailChanger)
+
+		uc := newUseCase(repo, nil, nil, nil)
+		uc.emailChanger = changer
+		_, err := uc.Update(ctx, id.String(), dto.UpdateUserRequest{Name: "Renamed"})
+
+		require.NoError(t, err)
+		changer.AssertNotCalled(t, "SendEmailChange", mock.Anything, mock.Anything)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// CreateBatch
+// ---------------------------------------------------------------------------
+
+func TestUseCase_CreateBatch(t *testing.T) {
+	ctx := context.Background()
+	req := dto.BatchCreateUsersRequest{Users: []dto.CreateUserRequest{
+		{Email: "a@example.com", Password: "password123", Name: "User A"},
+		{Email: "taken@example.com", Password: "password123", Name: "Taken"},
+		{Email: "b@example.com", Password: "password123", Name: "User B"},
+	}}
+
+	expectBatch := func(repo *MockRepository) {
+		repo.On("ExistsByEmail", ctx, "a@example.com").Return(false, nil)
+		repo.On("ExistsByEmail", ctx, "taken@example.com").Return(true, nil)
+		repo.On("ExistsByEmail", ctx, "b@example.com").Return(false, nil)
+		for _, email := range []string{"a@example.com", "b@example.com"} {
+			repo.On("Create", ctx, email, mock.MatchedBy(func(hash string) bool {
+				return bcrypt.CompareHashAndPassword([]byte(hash), []byte("password123")) == nil
+			}), mock.AnythingOfType("string")).Return(&userdomain.User{ID: uuid.New(), Email: email}, nil)
+		}
+	}
+
+	t.Run("partial", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		expectBatch(mockRepo)
+		tx := &fakeTx{}
+
+		resp, err := newUseCase(mockRepo, tx, nil, nil).CreateBatch(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, 2, resp.Created)
+		assert.Equal(t, 1, resp.Failed)
+		require.Len(t, resp.Results, 3)
+		assert.Equal(t, dto.BatchItemCreated, resp.Results[0].Status)
+		assert.Equal(t, "a@example.com", resp.Results[0].User.Email)
+		assert.Equal(t, dto.BatchItemFailed, resp.Results[1].Status)
+		assert.Equal(t, apperr.CodeConflict, resp.Results[1].Error.Code)
+		assert.Equal(t, 1, resp.Results[1].Index)
+		assert.Equal(t, dto.BatchItemCreated, resp.Results[2].Status)
+		assert.True(t, tx.committed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		expectBatch(mockRepo)
+		atomic := req
+		atomic.Atomic = true
+
+		resp, err := newUseCase(mockRepo, &fakeTx{}, nil, nil).CreateBatch(ctx, atomic)
+		require.NoError(t, err)
+		assert.Zero(t, resp.Created)
+		assert.Equal(t, 1, resp.Failed)
+		assert.Equal(t, dto.BatchItemRolledBack, resp.Results[0].Status)
+		assert.Nil(t, resp.Results[0].User)
+		assert.Equal(t, dto.BatchItemFailed, resp.Results[1].Status)
+		assert.Equal(t, dto.BatchItemRolledBack, resp.Results[2].Status)
+	})
+
+	t.Run("database error fails the batch", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("ExistsByEmail", ctx, "a@example.com").Return(false, errors.New("connection reset"))
+
+		_, err := newUseCase(mockRepo, &fakeTx{}, nil, nil).CreateBatch(ctx, req)
+		assert.Error(t, err)
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		uc := newUseCase(new(MockRepository), &fakeTx{}, nil, nil)
+		_, err := uc.CreateBatch(ctx, dto.BatchCreateUsersRequest{})
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+
+		_, err = uc.CreateBatch(ctx, dto.BatchCreateUsersRequest{Users: make([]dto.CreateUserRequest, dto.MaxBatchCreateUsers+1)})
+		assert.ErrorIs(t, err, apperr.ErrBadRequest)
+	})
+}
@@ -5,34 +5,68 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	nethttp "net/http"
+	"os"
+	"slices"
 	"time"
 
 	"github.com/14mdzk/goscratch/internal/adapter/audit"
 	"github.com/14mdzk/goscratch/internal/adapter/cache"
 	casbinadapter "github.com/14mdzk/goscratch/internal/adapter/casbin"
 	emailadapter "github.com/14mdzk/goscratch/internal/adapter/email"
+	"github.com/14mdzk/goscratch/internal/adapter/geoip"
+	"github.com/14mdzk/goscratch/internal/adapter/jobstatus"
+	oauthadapter "github.com/14mdzk/goscratch/internal/adapter/oauth"
 	"github.com/14mdzk/goscratch/internal/adapter/queue"
 	"github.com/14mdzk/goscratch/internal/adapter/sse"
 	"github.com/14mdzk/goscratch/internal/adapter/storage"
+	"github.com/14mdzk/goscratch/internal/module/admin"
+	auditmodule "github.com/14mdzk/goscratch/internal/module/audit"
+	auditusecase "github.com/14mdzk/goscratch/internal/module/audit/usecase"
 	"github.com/14mdzk/goscratch/internal/module/auth"
+	"github.com/14mdzk/goscratch/internal/module/auth/apikey"
+	apikeyrepo "github.com/14mdzk/goscratch/internal/module/auth/apikey/repository"
+	"github.com/14mdzk/goscratch/internal/module/auth/oauth"
+	oauthrepo "github.com/14mdzk/goscratch/internal/module/auth/oauth/repository"
+	"github.com/14mdzk/goscratch/internal/module/auth/passkey"
+	passkeyrepo "github.com/14mdzk/goscratch/internal/module/auth/passkey/repository"
+	authusecase "github.com/14mdzk/goscratch/internal/module/auth/usecase"
 	"github.com/14mdzk/goscratch/internal/module/docs"
 	"github.com/14mdzk/goscratch/internal/module/health"
 	"github.com/14mdzk/goscratch/internal/module/job"
 	"github.com/14mdzk/goscratch/internal/module/role"
+	rolerepo "github.com/14mdzk/goscratch/internal/module/role/repository"
 	ssemodule "github.com/14mdzk/goscratch/internal/module/sse"
 	storagemodule "github.com/14mdzk/goscratch/internal/module/storage"
 	"github.com/14mdzk/goscratch/internal/module/user"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
 	userrepo "github.com/14mdzk/goscratch/internal/module/user/repository"
+	userusecase "github.com/14mdzk/goscratch/internal/module/user/usecase"
+	"github.com/14mdzk/goscratch/internal/platform/assets"
+	"github.com/14mdzk/goscratch/internal/platform/chaos"
 	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/feature"
+	"github.com/14mdzk/goscratch/internal/platform/honeytoken"
 	"github.com/14mdzk/goscratch/internal/platform/http"
 	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
+	"github.com/14mdzk/goscratch/internal/platform/loadshed"
 	"github.com/14mdzk/goscratch/internal/platform/observability"
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/14mdzk/goscratch/internal/platform/shutdown"
+	"github.com/14mdzk/goscratch/internal/platform/watchdog"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/internal/worker/outbox"
+	"github.com/14mdzk/goscratch/migrations"
 	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/response"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -49,20 +83,21 @@ type App struct {
 	Auditor         port.Auditor
 	Authorizer      port.Authorizer
 	Email           port.EmailSender
+	Features        *feature.Flags
+	ReadOnly        *readonly.Switch
 	metricsServer   *nethttp.Server
 	tracerShutdown  func(context.Context) error
 	rateLimitCloser io.Closer
+	loadShedder     *loadshed.Shedder
+	watchdog        *watchdog.Watchdog
+	canaries        *honeytoken.Detector
+	ipRules         *iprules.Set
+	routes          *routes.Registry
+	shutdownExtra   []shutdown.Component
 }
 
 // New creates a new App instance with all dependencies
 func New(ctx context.Context, cfg *config.Config) (*App, error) {
-	// Validate secure-defaults invariants before constructing any adapter.
-	// A bad JWT secret (placeholder or too short) means every issued token
-	// is forgeable, so we hard-fail rather than silently boot.
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
 	// Initialize logger
 	logLevel := "info"
 	if cfg.IsDevelopment() {
@@ -73,6 +108,30 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		Format: "json",
 	})
 
+	// Report env overrides before validating, so a rejected or misspelled
+	// env var is visible next to the validation failure it causes.
+	logEnvReport(log, cfg.EnvReport())
+
+	// Validate secure-defaults invariants before constructing any adapter.
+	// A bad JWT secret (placeholder or too short) means every issued token
+	// is forgeable, so we hard-fail rather than silently boot.
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	// Label every log line, span and metric series with the same resource
+	// attributes as the worker.
+	resourceAttrs, err := observability.RenderResourceAttributes(cfg.Observability.ResourceAttributes, observability.ResourceInfo{
+		Service:     cfg.App.Name,
+		Environment: cfg.App.Env,
+		Version:     "1.0.0",
+		Process:     "api",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid observability.resource_attributes: %w", err)
+	}
+	log = &logger.Logger{Logger: log.With(resourceAttrs.LogArgs()...)}
+
 	// Initialize tracing
 	var tracerShutdown func(context.Context) error
 	if cfg.Observability.Tracing.Enabled {
@@ -83,6 +142,7 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 			Environment:    cfg.App.Env,
 			Endpoint:       cfg.Observability.Tracing.Endpoint,
 			Enabled:        true,
+			Attributes:     resourceAttrs,
 		})
 		if err != nil {
 			log.Warn("Failed to initialize tracing", "error", err)
@@ -92,14 +152,53 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		}
 	}
 
-	// Initialize database
+	// Initialize chaos injector (dev-only; Validate rejects it in production).
+	// The pool hook, adapter wrappers and middleware are installed only when
+	// chaos is enabled, so a disabled config adds zero per-call overhead.
+	var injector *chaos.Injector
+	var poolOpts []database.PoolOption
+	if cfg.Chaos.Enabled {
+		injector = chaos.New(chaos.Config{
+			Latency:   cfg.Chaos.Latency(),
+			Jitter:    cfg.Chaos.Jitter(),
+			ErrorRate: cfg.Chaos.ErrorRate,
+			Targets:   chaos.ParseTargets(cfg.Chaos.Targets),
+		})
+		poolOpts = append(poolOpts, func(pc *pgxpool.Config) {
+			pc.PrepareConn = injector.PrepareConn
+		})
+		log.Warn("CHAOS ENABLED: injecting faults into HTTP, DB, cache and queue operations",
+			"latency_ms", cfg.Chaos.LatencyMs,
+			"jitter_ms", cfg.Chaos.JitterMs,
+			"error_rate", cfg.Chaos.ErrorRate,
+			"targets", cfg.Chaos.Targets,
+			"allow_headers", cfg.Chaos.AllowHeaders,
+		)
+	}
+
+	// Initialize database. The boot ping runs under chaos.Suppress so an
+	// injected fault cannot abort startup.
 	log.Info("Connecting to database...")
-	pool, err := database.NewPostgresPool(ctx, cfg.Database)
+	pool, err := database.NewPostgresPool(chaos.Suppress(ctx), cfg.Database, poolOpts...)
 	if err != nil {
 		return nil, err
 	}
 	log.Info("Database connected successfully")
 
+	// Migrate before anything reads the schema: Casbin loads its policies
+	// right after this.
+	if cfg.Database.AutoMigrate {
+		source, fromDisk, err := assets.Dir("migrations", migrations.FS)
+		if err != nil {
+			return nil, fmt.Errorf("database.auto_migrate: %w", err)
+		}
+		version, err := database.Migrate(cfg.Database.DSN(), source)
+		if err != nil {
+			return nil, fmt.Errorf("database.auto_migrate: %w", err)
+		}
+		log.Info("Database migrated", "version", version, "from_disk", fromDisk)
+	}
+
 	// Initialize cache (Redis or NoOp).
 	// WARNING: NoOpCache is a no-op that cannot store or revoke refresh tokens.
 	// Running with NoOpCache means:
@@ -121,23 +220,77 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		log.Warn("SECURITY WARNING: Redis is disabled (redis.enabled=false); login will be rejected and refresh-token revocation will not function")
 		cacheAdapter = cache.NewNoOpCache()
 	}
+	if _, noop := cacheAdapter.(*cache.NoOpCache); injector != nil && !noop {
+		cacheAdapter = chaos.WrapCache(cacheAdapter, injector)
+	}
 
-	// Initialize queue (RabbitMQ or NoOp)
-	var queueAdapter port.Queue
-	if cfg.RabbitMQ.Enabled {
-		log.Info("Connecting to RabbitMQ...")
-		queueAdapter, err = queue.NewRabbitMQWithOptions(cfg.RabbitMQ.URL, queue.Options{
-			PrefetchCount: cfg.RabbitMQ.PrefetchCount,
+	// Initialize the read-only switch. It is shared through Redis so one admin
+	// call reaches every instance; without Redis it is local to this process.
+	// Honeytoken lockdowns use the same shared cache.
+	var sharedCache port.Cache
+	if _, noop := cacheAdapter.(*cache.NoOpCache); !noop {
+		sharedCache = cacheAdapter
+	} else {
+		log.Warn("Read-only switch is local to this instance: Redis is unavailable")
+	}
+	readOnly := readonly.New(sharedCache, readonly.Config{
+		Refresh:  time.Duration(cfg.ReadOnly.Refresh),
+		Logger:   log,
+		OnChange: func(st readonly.State) { observability.SetReadOnly(st.Enabled) },
+	})
+	readOnly.Start(ctx)
+
+	// Initialize IP rules. Runtime rules are shared through the same cache.
+	var ipRules *iprules.Set
+	if cfg.IPRules.Enabled {
+		fixed := make([]iprules.Rule, 0, len(cfg.IPRules.Rules))
+		for _, r := range cfg.IPRules.Rules {
+			fixed = append(fixed, iprules.Rule{Action: iprules.Action(r.Action), CIDR: r.CIDR, Prefix: r.Prefix, Note: r.Note})
+		}
+		ipRules, err = iprules.New(sharedCache, fixed, iprules.Config{
+			Refresh: time.Duration(cfg.IPRules.Refresh),
+			Logger:  log,
 		})
 		if err != nil {
-			log.Warn("Failed to connect to RabbitMQ, using no-op queue", "error", err)
+			return nil, fmt.Errorf("invalid ip_rules: %w", err)
+		}
+		ipRules.Start(ctx)
+	}
+
+	// Load the GeoIP database for country restrictions
+	var geoDB *geoip.CSVDatabase
+	if cfg.GeoRestriction.Enabled {
+		geoDB, err = geoip.LoadCSV(cfg.GeoRestriction.Database)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("GeoIP database loaded", "path", cfg.GeoRestriction.Database, "ranges", geoDB.Len())
+	}
+
+	// Initialize queue (RabbitMQ, NATS JetStream or NoOp)
+	var queueAdapter port.Queue
+	if broker := cfg.QueueBroker(); broker != "" {
+		log.Info("Connecting to queue broker...", "broker", broker)
+		queueAdapter, err = queue.Connect(cfg)
+		if err != nil {
+			log.Warn("Failed to connect to queue broker, using no-op queue", "broker", broker, "error", err)
 			queueAdapter = queue.NewNoOpQueue()
 		} else {
-			log.Info("RabbitMQ connected successfully")
+			log.Info("Queue broker connected successfully", "broker", broker)
+			// Publishers declare the job queue too, so jobs enqueued before
+			// the first worker starts are kept under the configured policies.
+			if err := queue.DeclareTopology(ctx, queueAdapter, cfg.Worker, cfg.Worker.QueueName); err != nil {
+				log.Warn("Failed to declare job queue topology", "error", err)
+			}
 		}
 	} else {
 		queueAdapter = queue.NewNoOpQueue()
 	}
+	// Taken before chaos wrapping: admin inspection is never faulted.
+	queueInspector, _ := queueAdapter.(port.QueueInspector)
+	if _, noop := queueAdapter.(*queue.NoOpQueue); injector != nil && !noop {
+		queueAdapter = chaos.WrapQueue(queueAdapter, injector)
+	}
 
 	// Initialize storage
 	var storageAdapter port.Storage
@@ -165,19 +318,72 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 
 	// Initialize SSE broker
 	var sseBroker port.SSEBroker
+	var watched []watchdog.Buffer
 	if cfg.SSE.Enabled {
-		sseBroker = sse.NewBroker(100)
+		broker := sse.NewBrokerWithOptions(sse.Options{
+			BufferSize:    100,
+			ReplaySize:    cfg.SSE.ReplaySize,
+			PrivateTopics: slices.Collect(maps.Keys(cfg.SSE.TopicPermissions)),
+		})
+		watched = append(watched, watchdog.Buffer{Name: "sse", Usage: broker.ChannelUsage()})
+		sseBroker = broker
 	} else {
 		sseBroker = sse.NewNoOpBroker()
 	}
 
+	// The leak watchdog reports sustained goroutine growth and SSE clients
+	// whose channels stay full.
+	var leakWatchdog *watchdog.Watchdog
+	if cfg.Watchdog.Enabled {
+		leakWatchdog = watchdog.New(watchdog.Config{
+			Interval:        time.Duration(cfg.Watchdog.Interval),
+			Window:          cfg.Watchdog.Window,
+			GoroutineGrowth: cfg.Watchdog.GoroutineGrowth,
+			Saturation:      cfg.Watchdog.Saturation,
+			Buffers:         watched,
+			Logger:          log,
+			OnSample:        recordWatchdogSample,
+			OnAlert:         observability.RecordWatchdogAlert,
+		})
+		leakWatchdog.Start()
+	}
+
 	// Initialize auditor
 	var auditor port.Auditor
+	var auditChain port.AuditChainVerifier // nil unless entries are chained
 	if cfg.Audit.Enabled {
-		auditor = audit.NewPostgresAuditor(pool)
+		pgAuditor := audit.NewPostgresFromConfig(pool, cfg.Audit)
+		auditor = pgAuditor
+		if cfg.Audit.Chain {
+			log.Info("Chaining audit entries for tamper evidence")
+			auditChain = pgAuditor
+		}
+		if cfg.Audit.Async.Enabled {
+			log.Info("Writing audit entries in batches", "batch_size", cfg.Audit.Async.BatchSize, "flush_interval", cfg.Audit.Async.FlushInterval.String())
+			auditor = audit.NewBufferedFromConfig(auditor, cfg.Audit.Async, audit.BufferedOptions{OnDrop: observability.RecordAuditWriteDropped, Logger: log})
+		}
+		if cfg.SSE.Enabled {
+			log.Info("Streaming audit entries over SSE", "topic", audit.StreamTopic)
+			auditor = audit.NewStreamingAuditor(auditor, sseBroker)
+		}
 	} else {
 		auditor = audit.NewNoOpAuditor()
 	}
+	if cfg.Audit.Export.Enabled {
+		log.Info("Exporting audit events...", "format", cfg.Audit.Export.Format, "sinks", cfg.Audit.Export.Sinks())
+		auditor, err = audit.NewExporterFromConfig(auditor, cfg.Audit.Export, audit.ServiceInfo{
+			Name:        cfg.App.Name,
+			Version:     "1.0.0",
+			Environment: cfg.App.Env,
+		}, audit.ExportOptions{OnDrop: observability.RecordAuditExportDropped, Logger: log})
+		if err != nil {
+			return nil, fmt.Errorf("audit export enabled but init failed: %w", err)
+		}
+	}
+	if cfg.Audit.Reads.Enabled {
+		log.Info("Auditing record reads", "sample_rate", cfg.Audit.Reads.SampleRate, "resources", cfg.Audit.Reads.Resources)
+		auditor = audit.NewReadSamplingFromConfig(auditor, cfg.Audit.Reads)
+	}
 
 	// Initialize authorizer (Casbin).
 	// Fail-fast when authorization is explicitly enabled: a transient DB blip at
@@ -186,12 +392,61 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 	var authorizer port.Authorizer
 	if cfg.Authorization.Enabled {
 		log.Info("Initializing Casbin authorization...")
-		authorizer, err = casbinadapter.NewAdapter(casbinadapter.Config{
-			DatabaseURL: cfg.Database.DSN(),
-		})
+		var modelText []byte
+		if path := cfg.Authorization.ModelPath; path != "" {
+			modelText, err = os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("authorization.model_path: %w", err)
+			}
+		}
+		// decisions is the shared decision cache, if enabled below. A watcher
+		// reload drops it, so no decision an instance made before catching
+		// up with a change outlives the reload.
+		var decisions *casbinadapter.CachedAuthorizer
+		casbinCfg := casbinadapter.Config{
+			DatabaseURL:    cfg.Database.DSN(),
+			ModelText:      string(modelText),
+			ReloadInterval: time.Duration(cfg.Authorization.ReloadInterval),
+			OnReload: func(trigger string, d time.Duration, err error) {
+				observability.RecordPolicyReload(trigger, d, err)
+				if decisions != nil && trigger == casbinadapter.ReloadTriggerWatcher && err == nil {
+					if err := decisions.Invalidate(context.Background()); err != nil {
+						log.Warn("Failed to drop cached authorization decisions", "error", err)
+					}
+				}
+			},
+			OnEnforce: observability.RecordEnforce,
+		}
+		// The watcher makes a change on any instance, or straight in
+		// casbin_rules, reach every instance within the debounce.
+		if cfg.Authorization.Watch {
+			watcher, err := casbinadapter.NewPostgresWatcher(ctx, cfg.Database.DSN(), time.Duration(cfg.Authorization.WatchDebounce))
+			if err != nil {
+				return nil, fmt.Errorf("authorization.watch enabled but the policy watcher failed: %w", err)
+			}
+			casbinCfg.Watcher = watcher
+		}
+		authorizer, err = casbinadapter.NewAdapter(casbinCfg)
 		if err != nil {
+			if casbinCfg.Watcher != nil {
+				casbinCfg.Watcher.Close()
+			}
 			return nil, fmt.Errorf("authorization enabled but Casbin init failed: %w", err)
 		}
+		if dc := cfg.Authorization.DecisionCache; dc.Enabled {
+			if _, noop := cacheAdapter.(*cache.NoOpCache); noop {
+				log.Warn("Authorization decision cache disabled: Redis is unavailable")
+			} else {
+				decisions = casbinadapter.NewCachedAuthorizer(authorizer, cacheAdapter, casbinadapter.CachedAuthorizerOptions{
+					TTL:    time.Duration(dc.TTL),
+					OnHit:  func() { observability.RecordCacheHit("authz_decision") },
+					OnMiss: func() { observability.RecordCacheMiss("authz_decision") },
+				})
+				authorizer = decisions
+			}
+		}
+		// Record every rule change, whichever module or endpoint makes it.
+		authorizer = casbinadapter.NewAuditedAuthorizer(authorizer, auditor)
 		log.Info("Casbin authorization initialized successfully")
 	} else {
 		// Authorization is explicitly disabled — use NoOp (e.g. local dev without DB).
@@ -199,19 +454,51 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		authorizer = casbinadapter.NewNoOpAdapter()
 	}
 
+	// Initialize feature flags. Per-request overrides (X-Feature-Override) are
+	// open to everyone outside production; in production only admins may use
+	// them. The admin check is evaluated lazily, after route-level Auth.
+	features := feature.New(feature.Config{
+		Defaults:       cfg.Features.Flags,
+		AllowOverrides: !cfg.IsProduction(),
+		IsAdmin: func(userID string) bool {
+			for _, role := range []string{port.RoleSuperAdmin, port.RoleAdmin} {
+				if ok, err := authorizer.HasRoleForUser(userID, role); err == nil && ok {
+					return true
+				}
+			}
+			return false
+		},
+	})
+
 	// Initialize email sender
-	var emailSender port.EmailSender
 	if cfg.Email.Enabled {
-		log.Info("Initializing SMTP email sender...")
-		emailSender = emailadapter.NewSMTPSender(emailadapter.SMTPConfig{
-			Host:     cfg.Email.Host,
-			Port:     cfg.Email.Port,
-			Username: cfg.Email.Username,
-			Password: cfg.Email.Password,
-			From:     cfg.Email.From,
+		log.Info("Initializing SMTP email sender...", "tenants", len(cfg.Email.Tenants))
+	}
+	emailSender := emailadapter.NewSender(cfg.Email, log)
+
+	// Initialize honeytoken detection
+	var canaries *honeytoken.Detector
+	if cfg.Honeytoken.Enabled {
+		canaries = honeytoken.New(honeytoken.Config{
+			Users: cfg.Honeytoken.Users,
+			NormalizeEmail: func(email string) string {
+				return userdomain.NormalizeEmail(email, cfg.Users.GmailAliases)
+			},
+			APIKeyHashes: cfg.Honeytoken.APIKeyHashes,
+			Notify:       cfg.Honeytoken.Notify,
+			Lockdown:     cfg.Honeytoken.Lockdown,
+			LockdownTTL:  time.Duration(cfg.Honeytoken.LockdownTTL),
+			Auditor:      auditor,
+			Email:        emailSender,
+			Cache:        sharedCache,
+			Logger:       log,
+			OnTrip:       observability.RecordHoneytokenTripped,
 		})
-	} else {
-		emailSender = emailadapter.NewNoOpSender(log)
+		log.Info("Honeytoken detection enabled",
+			"users", len(cfg.Honeytoken.Users),
+			"api_keys", len(cfg.Honeytoken.APIKeyHashes),
+			"lockdown", cfg.Honeytoken.Lockdown,
+		)
 	}
 
 	// Initialize HTTP server
@@ -242,6 +529,26 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		log.Warn("CORS wildcard origin '*' is used in production - this is insecure, set explicit origins via CORS_ALLOW_ORIGINS")
 	}
 	app.Use(middleware.CORS(corsConfig))
+	app.Use(middleware.FeatureOverride())
+
+	// Negotiate the response version before anything can respond, so every
+	// response, errors included, carries the negotiated media type. Modules
+	// register their response transformers on responseVersions.
+	responseVersions := response.NewVersions()
+	app.Use(middleware.APIVersion(middleware.APIVersionConfig{
+		Versions: responseVersions,
+		Default:  cfg.APIVersion.Default,
+	}))
+
+	// Cancel the request context when the client hangs up, so database
+	// queries and outbound calls made on its behalf stop early.
+	if cfg.Server.DisconnectPoll > 0 {
+		app.Use(middleware.CancelOnDisconnect(middleware.CancelOnDisconnectConfig{
+			PollInterval: time.Duration(cfg.Server.DisconnectPoll),
+			Logger:       log,
+			OnCancel:     observability.RecordRequestCancelled,
+		}))
+	}
 
 	// Add tracing middleware if enabled
 	if cfg.Observability.Tracing.Enabled {
@@ -257,7 +564,10 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		app.Use(observability.PrometheusMiddleware())
 
 		mux := nethttp.NewServeMux()
-		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/metrics", promhttp.InstrumentMetricHandler(
+			prometheus.DefaultRegisterer,
+			promhttp.HandlerFor(resourceAttrs.Gatherer(prometheus.DefaultGatherer), promhttp.HandlerOpts{}),
+		))
 		metricsAddr := fmt.Sprintf("127.0.0.1:%d", cfg.Observability.Metrics.Port)
 		metricsServer = &nethttp.Server{
 			Addr:              metricsAddr,
@@ -272,8 +582,90 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		}()
 	}
 
+	// Per-request DB statement totals; must wrap Logger so it can log them
+	if cfg.Observability.DBStats.Enabled {
+		dbStatsCfg := middleware.DBStatsConfig{
+			Logger:      log,
+			WarnQueries: cfg.Observability.DBStats.WarnQueries,
+		}
+		if cfg.Observability.Metrics.Enabled {
+			dbStatsCfg.Record = observability.RecordRequestDBStats
+		}
+		app.Use(middleware.DBStats(dbStatsCfg))
+	}
+
 	app.Use(middleware.Logger(log))
 
+	if injector != nil {
+		app.Use(middleware.Chaos(middleware.ChaosConfig{
+			Injector:     injector,
+			AllowHeaders: cfg.Chaos.AllowHeaders,
+		}))
+	}
+
+	// IP rules, country restrictions, canary API keys and locked-down IPs
+	// are refused before anything else spends work on the request.
+	if ipRules != nil {
+		app.Use(middleware.IPRules(middleware.IPRulesConfig{
+			Rules:   ipRules,
+			Logger:  log,
+			OnBlock: observability.RecordIPBlocked,
+		}))
+	}
+	if geoDB != nil {
+		rules := make([]middleware.GeoRule, 0, len(cfg.GeoRestriction.Rules))
+		for _, r := range cfg.GeoRestriction.Rules {
+			rules = append(rules, middleware.GeoRule{Prefix: r.Prefix, Countries: r.Countries})
+		}
+		app.Use(middleware.GeoRestriction(middleware.GeoRestrictionConfig{
+			GeoIP:         geoDB,
+			Rules:         rules,
+			BlockUnknown:  cfg.GeoRestriction.BlockUnknown,
+			Auditor:       auditor,
+			AuditInterval: time.Duration(cfg.GeoRestriction.AuditInterval),
+			Logger:        log,
+			OnBlock:       observability.RecordCountryBlocked,
+		}))
+	}
+	if canaries != nil {
+		app.Use(middleware.Honeytoken(middleware.HoneytokenConfig{Detector: canaries}))
+	}
+
+	// Read-only mode rejects mutations before they reach shedding, rate
+	// limiting or a handler. The admin route must stay reachable to turn it off.
+	app.Use(middleware.ReadOnly(middleware.ReadOnlyConfig{
+		Switch: readOnly,
+		Allow:  append([]string{"/admin/read-only"}, cfg.ReadOnly.Allow...),
+	}))
+
+	// Load shedding runs before rate limiting so a shed request costs no
+	// Redis round trip. It is still logged and counted by the middleware above.
+	var shedder *loadshed.Shedder
+	if cfg.LoadShed.Enabled {
+		shedder = loadshed.New(loadshed.Config{
+			Interval:      time.Duration(cfg.LoadShed.Interval),
+			PoolWait:      time.Duration(cfg.LoadShed.PoolWait),
+			LoopLag:       time.Duration(cfg.LoadShed.LoopLag),
+			HeapBytes:     uint64(cfg.LoadShed.HeapMB) << 20,
+			PoolStats:     loadshed.PgxPoolStats(pool),
+			Logger:        log,
+			OnLevelChange: func(l loadshed.Level) { observability.SetLoadShedLevel(int(l)) },
+		})
+		shedder.Start()
+		app.Use(middleware.LoadShed(middleware.LoadShedConfig{
+			Shedder:          shedder,
+			LowPriority:      cfg.LoadShed.LowPriority,
+			CriticalPriority: cfg.LoadShed.CriticalPriority,
+			Logger:           log,
+			OnShed:           observability.RecordLoadShed,
+		}))
+		log.Info("Load shedding enabled",
+			"pool_wait", cfg.LoadShed.PoolWait.String(),
+			"loop_lag", cfg.LoadShed.LoopLag.String(),
+			"heap_mb", cfg.LoadShed.HeapMB,
+		)
+	}
+
 	// Add rate limiting middleware if enabled
 	var rateLimitCloser io.Closer
 	if cfg.RateLimit.Enabled {
@@ -295,14 +687,73 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		log.Info("Rate limiting enabled", "max", rlMax, "window_sec", cfg.RateLimit.WindowSec)
 	}
 
+	// Bulkheads run after rate limiting, so a client over its limit never
+	// takes a slot, and before the module routes they guard.
+	if cfg.Bulkhead.Enabled {
+		for _, g := range cfg.Bulkhead.Groups {
+			bulkhead := middleware.Bulkhead(middleware.BulkheadConfig{
+				Name:          g.Name,
+				MaxConcurrent: g.MaxConcurrent,
+				MaxQueue:      g.MaxQueue,
+				QueueTimeout:  time.Duration(g.QueueTimeout),
+				RejectStatus:  cfg.Bulkhead.RejectStatus,
+				Logger:        log,
+				OnReject:      observability.RecordBulkheadRejection,
+			})
+			for _, prefix := range g.Prefixes {
+				app.Use(prefix, bulkhead)
+			}
+			log.Info("Bulkhead enabled", "bulkhead", g.Name, "prefixes", g.Prefixes, "max_concurrent", g.MaxConcurrent, "max_queue", g.MaxQueue)
+		}
+	}
+
 	// Initialize worker publisher
-	publisher := worker.NewPublisher(queueAdapter, cfg.Worker.QueueName, cfg.Worker.Exchange)
+	codecs, err := worker.ParseCodecs(cfg.Worker.Codec, cfg.Worker.JobCodecs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid worker codecs: %w", err)
+	}
+	priorities, err := worker.ParsePriorities(cfg.Worker.JobPriorities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid worker job priorities: %w", err)
+	}
+	// With tracking on, every published job gets a row in the jobs table
+	// that workers keep up to date and GET /jobs reads.
+	var jobStore port.JobStore
+	publisherOpts := []worker.PublisherOption{worker.WithPriorities(priorities)}
+	if cfg.Worker.Tracking {
+		jobStore = jobstatus.NewPostgresStore(pool)
+		publisherOpts = append(publisherOpts, worker.WithJobRecorder(jobStore))
+	}
+	publisher := worker.NewPublisherWithCodecs(queueAdapter, cfg.Worker.QueueName, cfg.Worker.Exchange, codecs, publisherOpts...)
 
 	// Initialize transactor
-	transactor := database.NewTransactor(pool)
+	transactor := database.NewTransactor(pool, database.WithRetryPolicy(database.RetryPolicy{
+		MaxAttempts: cfg.Database.TxMaxAttempts,
+		Backoff:     time.Duration(cfg.Database.TxRetryBackoff),
+		OnRetry:     observability.RecordTxRetry,
+		OnExhausted: observability.RecordTxRetryExhausted,
+	}))
+
+	// Routes declared through the route builder are recorded in the registry,
+	// which backs GET /admin/routes and the OpenAPI annotations.
+	routeRegistry := routes.NewRegistry()
+	routeCfg := routes.Config{
+		Authorizer: authorizer,
+		Cache:      cacheAdapter,
+		UseRedis:   cfg.Redis.Enabled,
+		Registry:   routeRegistry,
+
+		OnDeprecatedCall: observability.RecordDeprecatedRequest,
+	}
+	if cfg.Authorization.TenantParam != "" || cfg.Authorization.TenantHeader != "" {
+		routeCfg.Tenant = &middleware.TenantConfig{
+			Param:  cfg.Authorization.TenantParam,
+			Header: cfg.Authorization.TenantHeader,
+		}
+	}
 
 	// Register modules
-	docsModule := docs.NewModule()
+	docsModule := docs.NewModule(routeRegistry)
 
 	// Wire health checkers. Postgres is always checked. Cache and queue checkers
 	// are included unconditionally — their implementations self-skip (return nil)
@@ -320,18 +771,175 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 	// auth module so both use the same *pgxpool.Pool connection rather than
 	// opening a second one (audit finding: auth/module.go:20 instantiated its
 	// own userrepo.Repository).
-	sharedUserRepo := userrepo.NewRepository(pool)
+	sharedUserRepo := userrepo.NewRepository(pool,
+		userrepo.WithQueryTimeout(cfg.Database.QueryTimeout()),
+		userrepo.WithGmailAliases(cfg.Users.GmailAliases),
+	)
+
+	// Activity tracking inspects the user ID after the handler chain, so it
+	// sees what route-level Auth stored even though it is registered globally.
+	if cfg.Activity.Enabled {
+		app.Use(middleware.Activity(middleware.ActivityConfig{
+			Recorder: sharedUserRepo,
+			Cache:    cacheAdapter,
+			Interval: cfg.Activity.Debounce(),
+		}))
+	}
+
+	// Like activity tracking, request auditing reads the actor after the
+	// handler chain.
+	if cfg.Audit.HTTP.Enabled {
+		log.Info("Auditing mutating requests", "include", cfg.Audit.HTTP.Include, "exclude", cfg.Audit.HTTP.Exclude)
+		app.Use(middleware.AuditRequests(middleware.AuditRequestsConfig{
+			Auditor: auditor,
+			Include: cfg.Audit.HTTP.Include,
+			Exclude: cfg.Audit.HTTP.Exclude,
+			Logger:  log,
+		}))
+	}
 
 	// Auth module is constructed first so its Revoker can be injected into the
 	// user module (ChangePassword must revoke auth sessions cross-module).
-	authModule := auth.NewModule(sharedUserRepo, cacheAdapter, auditor, cfg.JWT)
-	userModule := user.NewModule(pool, transactor, auditor, authorizer, cacheAdapter, cfg.JWT.Secret, authModule.Revoker())
-	roleModule := role.NewModule(authorizer, cfg.JWT.Secret)
-	storageModule := storagemodule.NewModule(storageAdapter, auditor, cfg.JWT.Secret)
-	sseModule := ssemodule.NewModule(sseBroker, authorizer, cfg.JWT.Secret)
-	jobModule := job.NewModule(publisher, auditor, authorizer, cfg.JWT.Secret)
+	var passwordReset *auth.PasswordReset
+	if cfg.PasswordReset.Enabled {
+		passwordReset = &auth.PasswordReset{Users: sharedUserRepo, Publisher: publisher, Config: cfg.PasswordReset}
+		log.Info("Password reset enabled", "token_ttl", cfg.PasswordReset.TokenTTL.String())
+	}
+	var emailVerification *auth.EmailVerification
+	if cfg.EmailVerification.Enabled {
+		emailVerification = &auth.EmailVerification{Users: sharedUserRepo, Publisher: publisher, Config: cfg.EmailVerification}
+		log.Info("Email verification enabled",
+			"token_ttl", cfg.EmailVerification.TokenTTL.String(),
+			"require_for_login", cfg.EmailVerification.RequireForLogin,
+		)
+	}
+	var emailChange *auth.EmailChange
+	if cfg.EmailChange.Enabled {
+		emailChange = &auth.EmailChange{Users: sharedUserRepo, Publisher: publisher, Config: cfg.EmailChange}
+		log.Info("Email change confirmation enabled", "token_ttl", cfg.EmailChange.TokenTTL.String())
+	}
+	var registration *auth.Registration
+	if cfg.Auth.AllowRegistration {
+		registration = &auth.Registration{Users: sharedUserRepo, Roles: authorizer, Transactor: transactor, Config: cfg.Auth}
+		log.Info("Self-registration enabled", "default_role", cfg.Auth.DefaultRole)
+	}
+	if cfg.Security.LoginThrottle.Enabled {
+		log.Info("Login throttle enabled",
+			"max_email_failures", cfg.Security.LoginThrottle.MaxEmailFailures,
+			"max_ip_failures", cfg.Security.LoginThrottle.MaxIPFailures,
+			"lockout_duration", cfg.Security.LoginThrottle.LockoutDuration.String(),
+		)
+	}
+	jwtKeys, err := jwtkeys.Load(cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+	if jwtKeys.Asymmetric() {
+		log.Info("JWT signing with a private key", "algorithm", jwtKeys.Algorithm(), "kid", jwtKeys.KeyID(), "verification_keys", len(jwtKeys.JWKS().Keys))
+	}
+	// Every module verifies access tokens with the same keys and claims, and
+	// refuses the ones revoked through logout or POST /auth/revoke.
+	authCfg := middleware.NewAuthConfig(jwtKeys, cfg.JWT.Issuer, cfg.JWT.Audience)
+	authCfg.Denylist = cacheAdapter
+	loginHistory := &auth.LoginHistory{Users: sharedUserRepo, HistorySize: cfg.Activity.LoginHistorySize}
+	// The API key repository is created before the auth module, which
+	// revokes a user's keys along with their tokens.
+	var apiKeyRepo *apikeyrepo.Repository
+	var apiKeyRevoker authusecase.APIKeyRevoker
+	if cfg.APIKey.Enabled {
+		apiKeyRepo = apikeyrepo.NewRepository(pool, apikeyrepo.WithQueryTimeout(cfg.Database.QueryTimeout()))
+		apiKeyRevoker = apiKeyRepo
+	}
+	authModule := auth.NewModule(sharedUserRepo, cacheAdapter, auditor, cfg.JWT, authCfg, authorizer, canaries, passwordReset, emailVerification, emailChange, registration, loginHistory, cfg.Security.LoginThrottle, apiKeyRevoker)
+	var passkeyModule *passkey.Module
+	if cfg.Passkey.Enabled {
+		passkeyRepo := passkeyrepo.NewRepository(pool, passkeyrepo.WithQueryTimeout(cfg.Database.QueryTimeout()))
+		passkeyModule, err = passkey.NewModule(passkeyRepo, sharedUserRepo, authModule.SessionIssuer(), cacheAdapter, auditor, authCfg, cfg.Passkey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure passkeys: %w", err)
+		}
+		log.Info("Passkeys enabled", "rp_id", cfg.Passkey.RPID, "origins", cfg.Passkey.Origins)
+	}
+	var oauthModule *oauth.Module
+	if cfg.OAuth.Enabled {
+		providers, err := oauthadapter.New(cfg.OAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure OAuth providers: %w", err)
+		}
+		identityRepo := oauthrepo.NewRepository(pool, oauthrepo.WithQueryTimeout(cfg.Database.QueryTimeout()))
+		oauthModule = oauth.NewModule(providers, identityRepo, sharedUserRepo, authModule.SessionIssuer(), transactor, cacheAdapter, auditor, cfg.OAuth)
+		log.Info("OAuth login enabled", "providers", len(providers), "allow_signup", cfg.OAuth.AllowSignup)
+	}
+	var apiKeyModule *apikey.Module
+	if cfg.APIKey.Enabled {
+		apiKeyModule = apikey.NewModule(apiKeyRepo, sharedUserRepo, auditor, authCfg, cfg.APIKey)
+		// Set before the modules below copy routeCfg, so their
+		// authenticated groups accept API keys.
+		routeCfg.APIKeys = apiKeyModule.AuthConfig()
+		log.Info("API keys enabled", "header", cfg.APIKey.Header, "max_per_user", cfg.APIKey.MaxPerUser)
+	}
+	// Asynchronous exports need a worker to consume the users.export job.
+	var userExports *userusecase.AsyncExport
+	if cfg.QueueBroker() != "" {
+		userExports = &userusecase.AsyncExport{Publisher: publisher, Storage: storageAdapter}
+	}
+	var userEvents *userusecase.LifecycleEvents
+	if cfg.Users.Events.Enabled {
+		userEvents = &userusecase.LifecycleEvents{Queue: queueAdapter, Exchange: cfg.Users.Events.Exchange}
+		// cmd/worker relays the outbox; without a worker events wait there.
+		if cfg.Worker.Outbox.Enabled {
+			userEvents.Outbox = outbox.NewPostgresStore(pool)
+		}
+		if err := queueAdapter.DeclareExchange(ctx, cfg.Users.Events.Exchange, "topic", true); err != nil {
+			log.Warn("Failed to declare user events exchange", "exchange", cfg.Users.Events.Exchange, "error", err)
+		}
+		log.Info("User lifecycle events enabled", "exchange", cfg.Users.Events.Exchange, "outbox", cfg.Worker.Outbox.Enabled)
+	}
+	userModule := user.NewModule(sharedUserRepo, transactor, auditor, authorizer, cacheAdapter, authCfg, authModule.Revoker(), authModule.Verifier(), authModule.EmailChanger(), authModule.Impersonator(), userExports, userEvents, routeCfg)
+	roleRepo := rolerepo.NewRepository(pool, rolerepo.WithQueryTimeout(cfg.Database.QueryTimeout()))
+	roleModule := role.NewModule(roleRepo, authorizer, auditor, authCfg, routeCfg)
+	if cfg.Authorization.Enabled && cfg.Authorization.PolicyFile != "" {
+		// One replica seeds at a time; the rest then find nothing missing.
+		err := database.WithAdvisoryLock(ctx, pool, policySeedLockKey, func(ctx context.Context) error {
+			diff, err := roleModule.SeedPolicy(ctx, cfg.Authorization.PolicyFile, cfg.Authorization.PolicyPrune)
+			if err != nil {
+				return err
+			}
+			log.Info("Authorization policy seeded", "file", cfg.Authorization.PolicyFile,
+				"roles_created", len(diff.RolesCreated), "added", len(diff.Added), "removed", len(diff.Removed))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("authorization.policy_file: %w", err)
+		}
+	}
+	storageModule := storagemodule.NewModule(storageAdapter, auditor, authCfg)
+	sseModule := ssemodule.NewModule(sseBroker, cacheAdapter, authCfg, cfg.SSE, routeCfg)
+	jobModule := job.NewModule(publisher, jobStore, auditor, authorizer, authCfg)
+	adminModule := admin.NewModule(cfg, authCfg, readOnly, routeCfg, queueInspector, ipRules)
+	// Large audit exports are generated by the worker, like user exports.
+	var auditExports *auditusecase.AsyncExport
+	if cfg.QueueBroker() != "" {
+		auditExports = &auditusecase.AsyncExport{Publisher: publisher, Storage: storageAdapter}
+	}
+	auditModule := auditmodule.NewModule(auditor, auditChain, auditExports, authCfg, routeCfg)
 
-	server.RegisterModules(docsModule, healthModule, userModule, authModule, roleModule, storageModule, sseModule, jobModule)
+	server.RegisterModules(docsModule, healthModule, userModule, authModule, roleModule, storageModule, sseModule, jobModule, adminModule, auditModule)
+	if passkeyModule != nil {
+		server.RegisterModules(passkeyModule)
+	}
+	if oauthModule != nil {
+		server.RegisterModules(oauthModule)
+	}
+	if apiKeyModule != nil {
+		server.RegisterModules(apiKeyModule)
+	}
+
+	// Transformers are registered by now, so the default version can be
+	// checked against the versions actually served.
+	if def := cfg.APIVersion.Default; def > responseVersions.Latest() {
+		return nil, fmt.Errorf("api_version.default is %d but the API serves versions 1 to %d", def, responseVersions.Latest())
+	}
 
 	// Start the authorizer lifecycle (backstop reload tick + watcher subscription).
 	// Failure here is fatal: a half-initialised authorizer would silently drop
@@ -352,135 +960,213 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		Auditor:         auditor,
 		Authorizer:      authorizer,
 		Email:           emailSender,
+		Features:        features,
+		ReadOnly:        readOnly,
 		metricsServer:   metricsServer,
 		tracerShutdown:  tracerShutdown,
 		rateLimitCloser: rateLimitCloser,
+		loadShedder:     shedder,
+		watchdog:        leakWatchdog,
+		canaries:        canaries,
+		ipRules:         ipRules,
+		routes:          routeRegistry,
 	}, nil
 }
 
+// recordWatchdogSample publishes a watchdog sample as gauges. The goroutine
+// count itself is already exported as go_goroutines.
+func recordWatchdogSample(s watchdog.Sample) {
+	observability.SetGoroutineGrowth(s.Growth)
+	for _, b := range s.Buffers {
+		observability.SetChannelBuffer(b.Name, b.Utilization(), b.Saturated)
+	}
+}
+
+// policySeedLockKey is the Postgres advisory lock held while the policy
+// file is seeded
+const policySeedLockKey int64 = 0x676f736372617463 // "goscratc"
+
 // Start starts the application
 func (a *App) Start() error {
 	a.Logger.Info("Starting application", "port", a.Config.Server.Port)
 	return a.Server.Start()
 }
 
-// defaultShutdownBudget is the fallback total budget when the parent context
-// has no deadline. The runPhase fractions are calibrated against this.
-const defaultShutdownBudget = 30 * time.Second
-
-// Shutdown gracefully shuts down the application in deterministic phases.
-//
-// Phase order is chosen so that downstream emitters drain before their sinks
-// close: HTTP requests finish (server), policy bus quiets (authorizer), SSE
-// streams disconnect cleanly, then DB closes, then the tracer is stopped LAST
-// so spans emitted by prior phases still flush. Each phase gets a fraction of
-// the total deadline budget so a slow first phase cannot starve later ones.
-func (a *App) Shutdown(ctx context.Context) error {
-	a.Logger.Info("Shutting down application...")
+// Shutdown component names. Components added with RegisterShutdown can
+// depend on them.
+const (
+	ShutdownHTTPServer      = "http_server"
+	ShutdownMetrics         = "metrics"
+	ShutdownWatchdog        = "watchdog"
+	ShutdownSSE             = "sse"
+	ShutdownAuthorizer      = "authorizer"
+	ShutdownLoadShed        = "load_shed"
+	ShutdownReadOnly        = "read_only"
+	ShutdownIPRules         = "ip_rules"
+	ShutdownRateLimit       = "rate_limit"
+	ShutdownRouteRateLimits = "route_rate_limits"
+	ShutdownCache           = "cache"
+	ShutdownQueue           = "queue"
+	ShutdownStorage         = "storage"
+	ShutdownAuditor         = "auditor"
+	ShutdownHoneytoken      = "honeytoken"
+	ShutdownEmail           = "email"
+	ShutdownDatabase        = "database"
+	ShutdownTracer          = "tracer"
+)
 
-	totalBudget := defaultShutdownBudget
-	if deadline, ok := ctx.Deadline(); ok {
-		if remaining := time.Until(deadline); remaining > 0 {
-			totalBudget = remaining
-		}
+// RegisterShutdown adds a component for Shutdown to stop, e.g. a background
+// consumer started after New. It fails when the component's dependencies
+// form a cycle with the existing ones.
+func (a *App) RegisterShutdown(c shutdown.Component) error {
+	a.shutdownExtra = append(a.shutdownExtra, c)
+	if _, err := a.shutdownRegistry().Order(); err != nil {
+		a.shutdownExtra = a.shutdownExtra[:len(a.shutdownExtra)-1]
+		return err
 	}
+	return nil
+}
 
-	runPhase := func(name string, fraction float64, fn func(context.Context) error) {
-		budget := time.Duration(float64(totalBudget) * fraction)
-		if budget <= 0 {
-			budget = 100 * time.Millisecond
-		}
-		phaseCtx, cancel := context.WithTimeout(ctx, budget)
-		defer cancel()
-		start := time.Now()
-		if err := fn(phaseCtx); err != nil {
-			a.Logger.Error("shutdown phase failed", "phase", name, "error", err)
+// shutdownRegistry registers the app's components with what each of them
+// uses, so Shutdown stops users before the things they use: HTTP requests
+// finish before the adapters they call close, SSE streams disconnect before
+// the broker closes, and the database closes after everything that queries
+// it. The tracer stops LAST so spans emitted while stopping still flush.
+//
+// Timeouts are upper bounds; they shrink proportionally when Shutdown's
+// deadline is shorter than their sum. Only components that exist are
+// registered, so a partially built App shuts down too.
+func (a *App) shutdownRegistry() *shutdown.Registry {
+	reg := &shutdown.Registry{Logger: a.Logger}
+	add := func(present bool, c shutdown.Component) {
+		if present {
+			reg.Register(c)
 		}
-		a.Logger.Info("shutdown phase complete",
-			"phase", name,
-			"duration_ms", time.Since(start).Milliseconds(),
-			"budget_ms", budget.Milliseconds(),
-		)
+	}
+	closer := func(c io.Closer) func(context.Context) error {
+		return func(context.Context) error { return c.Close() }
 	}
 
-	// 1. HTTP server — drain in-flight requests. Gets the largest slice
-	//    because clients may be mid-stream.
-	runPhase("http_server", 0.40, func(ctx context.Context) error {
-		if a.Server == nil {
-			return nil
-		}
-		return a.Server.Shutdown(ctx)
+	// Drain in-flight requests first. It gets the largest timeout because
+	// clients may be mid-stream.
+	add(a.Server != nil, shutdown.Component{
+		Name: ShutdownHTTPServer,
+		DependsOn: []string{
+			ShutdownSSE, ShutdownAuthorizer, ShutdownLoadShed, ShutdownReadOnly,
+			ShutdownIPRules, ShutdownRateLimit, ShutdownRouteRateLimits, ShutdownCache,
+			ShutdownQueue, ShutdownStorage, ShutdownAuditor, ShutdownHoneytoken, ShutdownDatabase,
+		},
+		Timeout: 12 * time.Second,
+		Stop:    func(ctx context.Context) error { return a.Server.Shutdown(ctx) },
 	})
-
-	// 2. Metrics listener — internal, fast.
-	runPhase("metrics", 0.05, func(ctx context.Context) error {
-		if a.metricsServer == nil {
-			return nil
-		}
-		return a.metricsServer.Shutdown(ctx)
+	add(a.metricsServer != nil, shutdown.Component{
+		Name:    ShutdownMetrics,
+		Timeout: 1500 * time.Millisecond,
+		Stop:    func(ctx context.Context) error { return a.metricsServer.Shutdown(ctx) },
 	})
-
-	// 3. SSE broker — close subscriber channels so range loops exit before
-	//    we yank their downstream adapters.
-	runPhase("sse", 0.05, func(_ context.Context) error {
-		if a.SSE == nil {
-			return nil
-		}
-		return a.SSE.Close()
+	add(a.watchdog != nil, shutdown.Component{
+		Name:      ShutdownWatchdog,
+		DependsOn: []string{ShutdownSSE},
+		Stop:      closer(a.watchdog),
 	})
-
-	// 4. Authorizer — Casbin DB handle + watcher goroutine + backstop ticker.
-	//    Closed before the main DB pool so no in-flight policy load races
-	//    against a shutting-down pool.
-	runPhase("authorizer", 0.10, func(_ context.Context) error {
-		if a.Authorizer == nil {
+	// Closing subscriber channels lets stream range loops exit.
+	add(a.SSE != nil, shutdown.Component{
+		Name:    ShutdownSSE,
+		Timeout: 1500 * time.Millisecond,
+		Stop:    closer(a.SSE),
+	})
+	// Casbin DB handle, watcher goroutine and backstop ticker. Closed before
+	// the main pool so no in-flight policy load races a closing pool.
+	add(a.Authorizer != nil, shutdown.Component{
+		Name:      ShutdownAuthorizer,
+		DependsOn: []string{ShutdownDatabase},
+		Timeout:   3 * time.Second,
+		Stop:      closer(a.Authorizer),
+	})
+	add(a.loadShedder != nil, shutdown.Component{
+		Name:      ShutdownLoadShed,
+		DependsOn: []string{ShutdownDatabase},
+		Stop:      closer(a.loadShedder),
+	})
+	add(a.ReadOnly != nil, shutdown.Component{
+		Name:      ShutdownReadOnly,
+		DependsOn: []string{ShutdownCache},
+		Stop:      closer(a.ReadOnly),
+	})
+	add(a.ipRules != nil, shutdown.Component{
+		Name:      ShutdownIPRules,
+		DependsOn: []string{ShutdownCache},
+		Stop:      closer(a.ipRules),
+	})
+	add(a.rateLimitCloser != nil, shutdown.Component{
+		Name:      ShutdownRateLimit,
+		DependsOn: []string{ShutdownCache},
+		Stop:      closer(a.rateLimitCloser),
+	})
+	add(a.routes != nil, shutdown.Component{
+		Name:      ShutdownRouteRateLimits,
+		DependsOn: []string{ShutdownCache},
+		Stop:      closer(a.routes),
+	})
+	// Alert emails still being sent need the email sender.
+	add(a.canaries != nil, shutdown.Component{
+		Name:      ShutdownHoneytoken,
+		DependsOn: []string{ShutdownCache, ShutdownAuditor, ShutdownEmail},
+		Stop: func(context.Context) error {
+			a.canaries.Close()
 			return nil
-		}
-		return a.Authorizer.Close()
+		},
 	})
-
-	// 5. Bulk adapters. None of these accept a ctx; we run them under the
-	//    phase budget so a hung Close cannot stall the rest.
-	runPhase("adapters", 0.15, func(_ context.Context) error {
-		if a.rateLimitCloser != nil {
-			if err := a.rateLimitCloser.Close(); err != nil {
-				a.Logger.Error("rate limit backend close", "error", err)
-			}
-		}
-		if a.Cache != nil {
-			_ = a.Cache.Close()
-		}
-		if a.Queue != nil {
-			_ = a.Queue.Close()
-		}
-		if a.Storage != nil {
-			_ = a.Storage.Close()
-		}
-		if a.Auditor != nil {
-			_ = a.Auditor.Close()
-		}
-		if a.Email != nil {
-			_ = a.Email.Close()
-		}
-		return nil
+	add(a.Cache != nil, shutdown.Component{Name: ShutdownCache, Stop: closer(a.Cache)})
+	add(a.Queue != nil, shutdown.Component{Name: ShutdownQueue, Stop: closer(a.Queue)})
+	add(a.Storage != nil, shutdown.Component{Name: ShutdownStorage, Stop: closer(a.Storage)})
+	add(a.Auditor != nil, shutdown.Component{
+		Name:      ShutdownAuditor,
+		DependsOn: []string{ShutdownDatabase},
+		Stop:      closer(a.Auditor),
 	})
-
-	// 6. DB — last database-using thing closed.
-	runPhase("database", 0.10, func(_ context.Context) error {
-		if a.DB != nil {
+	add(a.Email != nil, shutdown.Component{Name: ShutdownEmail, Stop: closer(a.Email)})
+	add(a.DB != nil, shutdown.Component{
+		Name:    ShutdownDatabase,
+		Timeout: 3 * time.Second,
+		Stop: func(context.Context) error {
 			a.DB.Close()
-		}
-		return nil
-	})
-
-	// 7. Tracer — LAST so spans from every prior phase flush through it.
-	runPhase("tracer", 0.15, func(ctx context.Context) error {
-		if a.tracerShutdown == nil {
 			return nil
-		}
-		return a.tracerShutdown(ctx)
+		},
+	})
+	add(a.tracerShutdown != nil, shutdown.Component{
+		Name:     ShutdownTracer,
+		Priority: 100, // after everything, without every component naming it
+		Timeout:  4500 * time.Millisecond,
+		Stop:     a.tracerShutdown,
 	})
 
+	for _, c := range a.shutdownExtra {
+		reg.Register(c)
+	}
+	return reg
+}
+
+// Shutdown gracefully stops the application's components in dependency order
+// (see shutdownRegistry). Every component is stopped even when others fail or
+// time out; their errors are returned joined.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.Logger.Info("Shutting down application...")
+	err := a.shutdownRegistry().Shutdown(ctx)
 	a.Logger.Info("Application shutdown complete")
-	return nil
+	return err
+}
+
+// logEnvReport logs which env vars overrode the config file, which were
+// rejected, and which look like typos. Only names are logged, never values.
+func logEnvReport(log *logger.Logger, r config.EnvReport) {
+	if len(r.Applied) > 0 {
+		log.Info("Applied config env overrides", "vars", r.Applied)
+	}
+	for _, v := range r.Invalid {
+		log.Warn("Ignored invalid config env override", "var", v)
+	}
+	for _, v := range r.Unknown {
+		log.Warn("Unknown config env var matched no setting", "var", v)
+	}
 }
@@ -2,11 +2,13 @@ package app
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/platform/shutdown"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/stretchr/testify/assert"
@@ -25,11 +27,31 @@ func (f *fakeAuthorizer) Enforce(_, _, _ string) (bool, error) { panic("unused")
 func (f *fakeAuthorizer) EnforceWithContext(context.Context, string, string, string) (bool, error) {
 	panic("unused")
 }
-func (f *fakeAuthorizer) AddRoleForUser(_, _ string) error             { panic("unused") }
-func (f *fakeAuthorizer) RemoveRoleForUser(_, _ string) error          { panic("unused") }
-func (f *fakeAuthorizer) GetRolesForUser(string) ([]string, error)     { panic("unused") }
-func (f *fakeAuthorizer) GetUsersForRole(string) ([]string, error)     { panic("unused") }
-func (f *fakeAuthorizer) HasRoleForUser(_, _ string) (bool, error)     { panic("unused") }
+func (f *fakeAuthorizer) AddRoleForUser(_, _ string) error         { panic("unused") }
+func (f *fakeAuthorizer) RemoveRoleForUser(_, _ string) error      { panic("unused") }
+func (f *fakeAuthorizer) GetRolesForUser(string) ([]string, error) { panic("unused") }
+func (f *fakeAuthorizer) GetUsersForRole(string) ([]string, error) { panic("unused") }
+func (f *fakeAuthorizer) HasRoleForUser(_, _ string) (bool, error) { panic("unused") }
+func (f *fakeAuthorizer) EnforceInDomain(_, _, _, _ string) (bool, error) {
+	panic("unused")
+}
+func (f *fakeAuthorizer) EnforceOwnership(_, _, _, _, _ string) (bool, error) {
+	panic("unused")
+}
+func (f *fakeAuthorizer) EnforceBatch(context.Context, []port.EnforceRequest) ([]bool, error) {
+	panic("unused")
+}
+func (f *fakeAuthorizer) AddDeny(_, _, _ string) error                   { panic("unused") }
+func (f *fakeAuthorizer) RemoveDeny(_, _, _ string) error                { panic("unused") }
+func (f *fakeAuthorizer) GetRules() ([]port.PolicyRule, error)           { panic("unused") }
+func (f *fakeAuthorizer) AddRoleForUserInDomain(_, _, _ string) error    { panic("unused") }
+func (f *fakeAuthorizer) RemoveRoleForUserInDomain(_, _, _ string) error { panic("unused") }
+func (f *fakeAuthorizer) GetRolesForUserInDomain(_, _ string) ([]string, error) {
+	panic("unused")
+}
+func (f *fakeAuthorizer) GetUsersForRoleInDomain(_, _ string) ([]string, error) {
+	panic("unused")
+}
 func (f *fakeAuthorizer) AddPermissionForRole(_, _, _ string) error    { panic("unused") }
 func (f *fakeAuthorizer) RemovePermissionForRole(_, _, _ string) error { panic("unused") }
 func (f *fakeAuthorizer) GetPermissionsForRole(string) ([][]string, error) {
@@ -190,6 +212,62 @@ func TestApp_Shutdown_RespectsParentBudget(t *testing.T) {
 		"shutdown overran the parent 100ms budget (got %s)", total)
 }
 
+// TestApp_Shutdown_JoinsErrors verifies a failing component is reported
+// without keeping later components from stopping.
+func TestApp_Shutdown_JoinsErrors(t *testing.T) {
+	rec := &callRecorder{}
+	errClose := errors.New("watcher still running")
+
+	a := &App{
+		Logger:     logger.New(logger.Config{Level: "error", Format: "json"}),
+		Authorizer: &fakeAuthorizerOrder{fakeAuthorizer: &fakeAuthorizer{err: errClose}, rec: rec},
+		tracerShutdown: func(_ context.Context) error {
+			rec.record("tracer")
+			return nil
+		},
+	}
+
+	err := a.Shutdown(context.Background())
+	require.ErrorIs(t, err, errClose)
+	assert.Contains(t, err.Error(), "authorizer: ")
+	assert.Equal(t, []string{"authorizer", "tracer"}, rec.snapshot())
+}
+
+// TestApp_RegisterShutdown verifies that an added component stops before the
+// components it depends on, and that a cycle is refused.
+func TestApp_RegisterShutdown(t *testing.T) {
+	rec := &callRecorder{}
+
+	a := &App{
+		Logger:     logger.New(logger.Config{Level: "error", Format: "json"}),
+		Authorizer: &fakeAuthorizerOrder{fakeAuthorizer: &fakeAuthorizer{}, rec: rec},
+		SSE:        &fakeSSE{rec: rec},
+	}
+	require.NoError(t, a.RegisterShutdown(shutdown.Component{
+		Name:      "outbox",
+		DependsOn: []string{ShutdownSSE, ShutdownAuthorizer},
+		Stop: func(context.Context) error {
+			rec.record("outbox")
+			return nil
+		},
+	}))
+	err := a.RegisterShutdown(shutdown.Component{
+		Name:      "relay",
+		DependsOn: []string{"relay-peer"},
+		Stop:      func(context.Context) error { return nil },
+	})
+	require.NoError(t, err, "unknown dependencies are ignored")
+	err = a.RegisterShutdown(shutdown.Component{
+		Name:      "relay-peer",
+		DependsOn: []string{"relay"},
+		Stop:      func(context.Context) error { return nil },
+	})
+	require.Error(t, err)
+
+	require.NoError(t, a.Shutdown(context.Background()))
+	assert.Equal(t, []string{"outbox", "sse", "authorizer"}, rec.snapshot())
+}
+
 func indexOf(ss []string, target string) int {
 	for i, s := range ss {
 		if s == target {
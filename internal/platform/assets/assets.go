@@ -0,0 +1,43 @@
+// Package assets resolves the files the binaries embed at build time. A copy
+// on disk, at the path the file has in the repository, overrides the
+// embedded one, so a deployment can change a file without rebuilding.
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// File returns the contents of the file at path when it exists on disk, and
+// embedded otherwise. fromDisk reports which one was returned. A file that
+// exists but cannot be read is an error, not a fallback.
+func File(path string, embedded []byte) (data []byte, fromDisk bool, err error) {
+	data, err = os.ReadFile(path)
+	switch {
+	case err == nil:
+		return data, true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return embedded, false, nil
+	default:
+		return nil, false, err
+	}
+}
+
+// Dir returns the directory at path when it exists on disk, and embedded
+// otherwise. The directory is taken as a whole: files missing from a
+// directory on disk are not filled in from embedded.
+func Dir(path string, embedded fs.FS) (dir fs.FS, fromDisk bool, err error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return os.DirFS(path), true, nil
+	case err == nil:
+		return nil, false, fmt.Errorf("%s is not a directory", path)
+	case errors.Is(err, fs.ErrNotExist):
+		return embedded, false, nil
+	default:
+		return nil, false, err
+	}
+}
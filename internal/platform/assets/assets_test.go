@@ -0,0 +1,59 @@
+package assets
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	data, fromDisk, err := File(path, []byte("embedded"))
+	require.NoError(t, err)
+	assert.False(t, fromDisk)
+	assert.Equal(t, "embedded", string(data))
+
+	require.NoError(t, os.WriteFile(path, []byte("disk"), 0o600))
+	data, fromDisk, err = File(path, []byte("embedded"))
+	require.NoError(t, err)
+	assert.True(t, fromDisk)
+	assert.Equal(t, "disk", string(data))
+
+	// A directory where the file should be is not silently ignored.
+	_, _, err = File(dir, []byte("embedded"))
+	assert.Error(t, err)
+}
+
+func TestDir(t *testing.T) {
+	embedded := fstest.MapFS{"1.sql": {Data: []byte("embedded")}}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "migrations")
+
+	got, fromDisk, err := Dir(path, embedded)
+	require.NoError(t, err)
+	assert.False(t, fromDisk)
+	data, err := fs.ReadFile(got, "1.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "embedded", string(data))
+
+	require.NoError(t, os.Mkdir(path, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "2.sql"), []byte("disk"), 0o600))
+	got, fromDisk, err = Dir(path, embedded)
+	require.NoError(t, err)
+	assert.True(t, fromDisk)
+	_, err = fs.ReadFile(got, "1.sql")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "the disk directory replaces the embedded one")
+	data, err = fs.ReadFile(got, "2.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "disk", string(data))
+
+	_, _, err = Dir(filepath.Join(path, "2.sql"), embedded)
+	assert.Error(t, err)
+}
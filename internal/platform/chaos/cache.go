@@ -0,0 +1,110 @@
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// chaosCache decorates a port.Cache with fault injection.
+type chaosCache struct {
+	inner port.Cache
+	inj   *Injector
+}
+
+// WrapCache returns a port.Cache that injects faults before delegating to inner.
+// Close is never faulted so shutdown stays deterministic.
+func WrapCache(inner port.Cache, inj *Injector) port.Cache {
+	return &chaosCache{inner: inner, inj: inj}
+}
+
+var _ port.Cache = (*chaosCache)(nil)
+
+func (c *chaosCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return nil, err
+	}
+	return c.inner.Get(ctx, key)
+}
+
+func (c *chaosCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return err
+	}
+	return c.inner.Set(ctx, key, value, ttl)
+}
+
+func (c *chaosCache) Delete(ctx context.Context, key string) error {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return err
+	}
+	return c.inner.Delete(ctx, key)
+}
+
+func (c *chaosCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return err
+	}
+	return c.inner.DeleteByPrefix(ctx, prefix)
+}
+
+func (c *chaosCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return nil, err
+	}
+	return c.inner.KeysByPrefix(ctx, prefix)
+}
+
+func (c *chaosCache) Exists(ctx context.Context, key string) (bool, error) {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return false, err
+	}
+	return c.inner.Exists(ctx, key)
+}
+
+func (c *chaosCache) SetJSON(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return err
+	}
+	return c.inner.SetJSON(ctx, key, value, ttl)
+}
+
+func (c *chaosCache) GetJSON(ctx context.Context, key string, dest any) error {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return err
+	}
+	return c.inner.GetJSON(ctx, key, dest)
+}
+
+func (c *chaosCache) Increment(ctx context.Context, key string) (int64, error) {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return 0, err
+	}
+	return c.inner.Increment(ctx, key)
+}
+
+func (c *chaosCache) Decrement(ctx context.Context, key string) (int64, error) {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return 0, err
+	}
+	return c.inner.Decrement(ctx, key)
+}
+
+func (c *chaosCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return err
+	}
+	return c.inner.Expire(ctx, key, ttl)
+}
+
+func (c *chaosCache) SlidingWindowAllow(ctx context.Context, key string, maxReqs int, window time.Duration) (bool, int, int, error) {
+	if err := c.inj.Inject(ctx, TargetCache); err != nil {
+		return false, 0, 0, err
+	}
+	return c.inner.SlidingWindowAllow(ctx, key, maxReqs, window)
+}
+
+func (c *chaosCache) Close() error {
+	return c.inner.Close()
+}
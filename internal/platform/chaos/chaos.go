@@ -0,0 +1,176 @@
+// Package chaos provides dev-only fault injection for resilience testing.
+//
+// An Injector adds configurable latency and a random error rate to the
+// operations it guards. The HTTP middleware (middleware.Chaos) guards request
+// handling; WrapCache and WrapQueue decorate the cache and queue adapters;
+// Injector.PrepareConn hooks into pgxpool so every DB connection acquire is
+// subject to the same faults. Per-request overrides travel on the context so a
+// single request can exercise a timeout path without restarting the process.
+//
+// Chaos must never run in production — config.Validate rejects it.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Target identifies the kind of operation a fault is injected into.
+type Target string
+
+const (
+	TargetHTTP  Target = "http"
+	TargetDB    Target = "db"
+	TargetCache Target = "cache"
+	TargetQueue Target = "queue"
+)
+
+// ErrInjected is returned by every operation the injector decided to fail.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Config holds fault-injection settings.
+type Config struct {
+	Latency   time.Duration // Fixed delay added before each guarded operation
+	Jitter    time.Duration // Random extra delay in [0, Jitter)
+	ErrorRate float64       // Probability in [0, 1] that an operation fails with ErrInjected
+	Targets   []Target      // Operations to guard; empty means all targets
+}
+
+// Override replaces the configured faults for a single request.
+// It is attached to the context by middleware.Chaos from request headers.
+type Override struct {
+	Latency   time.Duration
+	ErrorRate float64
+	Targets   []Target // empty means all targets
+}
+
+type overrideKey struct{}
+type suppressKey struct{}
+
+// WithOverride returns a context carrying o. Guarded operations that receive
+// the context use o instead of the injector's Config.
+func WithOverride(ctx context.Context, o Override) context.Context {
+	return context.WithValue(ctx, overrideKey{}, o)
+}
+
+// OverrideFromContext returns the override stored in ctx, if any.
+func OverrideFromContext(ctx context.Context) (Override, bool) {
+	o, ok := ctx.Value(overrideKey{}).(Override)
+	return o, ok
+}
+
+// Suppress returns a context under which no faults are injected.
+// Used for boot-time probes (e.g. the initial pool ping) that must not fail
+// at random.
+func Suppress(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressKey{}, true)
+}
+
+// Injector decides, per operation, whether to delay and whether to fail.
+// A nil *Injector is valid and never injects anything.
+type Injector struct {
+	cfg   Config
+	randF func() float64
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New creates an Injector. ErrorRate is clamped to [0, 1].
+func New(cfg Config) *Injector {
+	cfg.ErrorRate = clampRate(cfg.ErrorRate)
+	return &Injector{
+		cfg:   cfg,
+		randF: rand.Float64,
+		sleep: sleepCtx,
+	}
+}
+
+// Inject applies the configured latency and error rate for target.
+// It returns ctx.Err() if the context ends during the delay, ErrInjected if
+// the operation was chosen to fail, and nil otherwise.
+func (i *Injector) Inject(ctx context.Context, target Target) error {
+	if i == nil {
+		return nil
+	}
+	if s, _ := ctx.Value(suppressKey{}).(bool); s {
+		return nil
+	}
+
+	latency, jitter, rate, targets := i.cfg.Latency, i.cfg.Jitter, i.cfg.ErrorRate, i.cfg.Targets
+	if o, ok := OverrideFromContext(ctx); ok {
+		latency, jitter, rate, targets = o.Latency, 0, clampRate(o.ErrorRate), o.Targets
+	}
+
+	if !matches(targets, target) {
+		return nil
+	}
+
+	delay := latency
+	if jitter > 0 {
+		delay += time.Duration(i.randF() * float64(jitter))
+	}
+	if delay > 0 {
+		if err := i.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	if rate > 0 && i.randF() < rate {
+		return ErrInjected
+	}
+	return nil
+}
+
+// PrepareConn is a pgxpool.Config.PrepareConn hook that injects faults into
+// every connection acquire. Returning (true, err) hands the connection back to
+// the pool intact and fails only the instigating query.
+func (i *Injector) PrepareConn(ctx context.Context, _ *pgx.Conn) (bool, error) {
+	return true, i.Inject(ctx, TargetDB)
+}
+
+// ParseTargets converts config strings into Targets, ignoring blanks.
+func ParseTargets(names []string) []Target {
+	targets := make([]Target, 0, len(names))
+	for _, n := range names {
+		if n != "" {
+			targets = append(targets, Target(n))
+		}
+	}
+	return targets
+}
+
+func matches(targets []Target, t Target) bool {
+	if len(targets) == 0 {
+		return true
+	}
+	for _, candidate := range targets {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+func clampRate(r float64) float64 {
+	if r < 0 {
+		return 0
+	}
+	if r > 1 {
+		return 1
+	}
+	return r
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,116 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/14mdzk/goscratch/internal/adapter/queue"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestInjector returns an injector with deterministic randomness and a
+// recording sleep so tests never wait on a real timer.
+func newTestInjector(cfg Config, roll float64) (*Injector, *[]time.Duration) {
+	var slept []time.Duration
+	inj := New(cfg)
+	inj.randF = func() float64 { return roll }
+	inj.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return inj, &slept
+}
+
+func TestInject_NilInjectorIsNoOp(t *testing.T) {
+	var inj *Injector
+	assert.NoError(t, inj.Inject(context.Background(), TargetDB))
+}
+
+func TestInject_AddsLatencyAndJitter(t *testing.T) {
+	inj, slept := newTestInjector(Config{Latency: 100 * time.Millisecond, Jitter: 50 * time.Millisecond}, 0.5)
+
+	require.NoError(t, inj.Inject(context.Background(), TargetHTTP))
+	require.Len(t, *slept, 1)
+	assert.Equal(t, 125*time.Millisecond, (*slept)[0])
+}
+
+func TestInject_ErrorRate(t *testing.T) {
+	inj, _ := newTestInjector(Config{ErrorRate: 0.3}, 0.2)
+	assert.ErrorIs(t, inj.Inject(context.Background(), TargetCache), ErrInjected)
+
+	inj, _ = newTestInjector(Config{ErrorRate: 0.3}, 0.4)
+	assert.NoError(t, inj.Inject(context.Background(), TargetCache))
+}
+
+func TestInject_RespectsTargets(t *testing.T) {
+	inj, _ := newTestInjector(Config{ErrorRate: 1, Targets: []Target{TargetDB}}, 0)
+
+	assert.ErrorIs(t, inj.Inject(context.Background(), TargetDB), ErrInjected)
+	assert.NoError(t, inj.Inject(context.Background(), TargetQueue))
+}
+
+func TestInject_OverrideReplacesConfig(t *testing.T) {
+	inj, slept := newTestInjector(Config{ErrorRate: 1}, 0.5)
+
+	ctx := WithOverride(context.Background(), Override{Latency: time.Second, ErrorRate: 0})
+	require.NoError(t, inj.Inject(ctx, TargetHTTP))
+	assert.Equal(t, []time.Duration{time.Second}, *slept)
+
+	ctx = WithOverride(context.Background(), Override{ErrorRate: 1, Targets: []Target{TargetCache}})
+	assert.NoError(t, inj.Inject(ctx, TargetHTTP))
+	assert.ErrorIs(t, inj.Inject(ctx, TargetCache), ErrInjected)
+}
+
+func TestInject_Suppressed(t *testing.T) {
+	inj, slept := newTestInjector(Config{Latency: time.Second, ErrorRate: 1}, 0)
+
+	assert.NoError(t, inj.Inject(Suppress(context.Background()), TargetDB))
+	assert.Empty(t, *slept)
+}
+
+func TestInject_CancelledDuringDelay(t *testing.T) {
+	inj := New(Config{Latency: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := inj.Inject(ctx, TargetDB)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestNew_ClampsErrorRate(t *testing.T) {
+	assert.Equal(t, 1.0, New(Config{ErrorRate: 7}).cfg.ErrorRate)
+	assert.Equal(t, 0.0, New(Config{ErrorRate: -1}).cfg.ErrorRate)
+}
+
+func TestPrepareConn_KeepsConnection(t *testing.T) {
+	inj, _ := newTestInjector(Config{ErrorRate: 1}, 0)
+
+	ok, err := inj.PrepareConn(context.Background(), nil)
+	assert.True(t, ok, "connection must be returned to the pool, not destroyed")
+	assert.ErrorIs(t, err, ErrInjected)
+}
+
+func TestWrapCache_InjectsBeforeDelegating(t *testing.T) {
+	inj, _ := newTestInjector(Config{ErrorRate: 1}, 0)
+	c := WrapCache(cache.NewNoOpCache(), inj)
+
+	_, err := c.Get(context.Background(), "k")
+	assert.ErrorIs(t, err, ErrInjected)
+	assert.ErrorIs(t, c.Set(context.Background(), "k", nil, time.Minute), ErrInjected)
+	assert.NoError(t, c.Close(), "Close is never faulted")
+}
+
+func TestWrapQueue_InjectsBeforeDelegating(t *testing.T) {
+	inj, _ := newTestInjector(Config{ErrorRate: 1}, 0)
+	q := WrapQueue(queue.NewNoOpQueue(), inj)
+
+	assert.ErrorIs(t, q.Publish(context.Background(), "", "jobs", []byte("{}")), ErrInjected)
+	assert.ErrorIs(t, q.(port.DelayedPublisher).PublishDelayed(context.Background(), "", "jobs", []byte("{}"), time.Second), ErrInjected)
+	assert.NoError(t, q.Ping(context.Background()), "Ping is never faulted")
+	assert.NoError(t, q.Close())
+}
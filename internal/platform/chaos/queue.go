@@ -0,0 +1,89 @@
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// chaosQueue decorates a port.Queue with fault injection.
+type chaosQueue struct {
+	inner port.Queue
+	inj   *Injector
+}
+
+// WrapQueue returns a port.Queue that injects faults before delegating to inner.
+// Consume faults are applied per delivered message (the handler sees the error
+// as if it had failed), so retry and requeue paths can be exercised.
+// Ping and Close are never faulted.
+func WrapQueue(inner port.Queue, inj *Injector) port.Queue {
+	return &chaosQueue{inner: inner, inj: inj}
+}
+
+var _ port.Queue = (*chaosQueue)(nil)
+
+func (q *chaosQueue) Publish(ctx context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+		return err
+	}
+	return q.inner.Publish(ctx, exchange, routingKey, body, opts...)
+}
+
+// PublishDelayed implements port.DelayedPublisher when inner does, and
+// returns port.ErrDelayUnsupported otherwise.
+func (q *chaosQueue) PublishDelayed(ctx context.Context, exchange, routingKey string, body []byte, delay time.Duration, opts ...port.PublishOption) error {
+	dp, ok := q.inner.(port.DelayedPublisher)
+	if !ok {
+		return port.ErrDelayUnsupported
+	}
+	if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+		return err
+	}
+	return dp.PublishDelayed(ctx, exchange, routingKey, body, delay, opts...)
+}
+
+func (q *chaosQueue) PublishJSON(ctx context.Context, exchange, routingKey string, message any) error {
+	if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+		return err
+	}
+	return q.inner.PublishJSON(ctx, exchange, routingKey, message)
+}
+
+func (q *chaosQueue) Consume(ctx context.Context, queue string, handler func(msg port.Message) error) error {
+	return q.inner.Consume(ctx, queue, func(msg port.Message) error {
+		if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+			return err
+		}
+		return handler(msg)
+	})
+}
+
+func (q *chaosQueue) Ping(ctx context.Context) error {
+	return q.inner.Ping(ctx)
+}
+
+func (q *chaosQueue) DeclareQueue(ctx context.Context, name string, durable bool, opts ...port.QueueOption) error {
+	if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+		return err
+	}
+	return q.inner.DeclareQueue(ctx, name, durable, opts...)
+}
+
+func (q *chaosQueue) DeclareExchange(ctx context.Context, name, kind string, durable bool) error {
+	if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+		return err
+	}
+	return q.inner.DeclareExchange(ctx, name, kind, durable)
+}
+
+func (q *chaosQueue) BindQueue(ctx context.Context, queue, exchange, routingKey string) error {
+	if err := q.inj.Inject(ctx, TargetQueue); err != nil {
+		return err
+	}
+	return q.inner.BindQueue(ctx, queue, exchange, routingKey)
+}
+
+func (q *chaosQueue) Close() error {
+	return q.inner.Close()
+}
@@ -4,32 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/platform/assets"
 	"github.com/joho/godotenv"
 )
 
 // Config holds all application configuration
 type Config struct {
-	App           AppConfig           `json:"app"`
-	Server        ServerConfig        `json:"server"`
-	Database      DatabaseConfig      `json:"database"`
-	JWT           JWTConfig           `json:"jwt"`
-	CORS          CORSConfig          `json:"cors"`
-	Redis         RedisConfig         `json:"redis"`
-	RabbitMQ      RabbitMQConfig      `json:"rabbitmq"`
-	Storage       StorageConfig       `json:"storage"`
-	SSE           SSEConfig           `json:"sse"`
-	Audit         AuditConfig         `json:"audit"`
-	Authorization AuthorizationConfig `json:"authorization"`
-	Worker        WorkerConfig        `json:"worker"`
-	Observability ObservabilityConfig `json:"observability"`
-	Email         EmailConfig         `json:"email"`
-	RateLimit     RateLimitConfig     `json:"rate_limit"`
-	Health        HealthConfig        `json:"health"`
+	App               AppConfig               `json:"app"`
+	Server            ServerConfig            `json:"server"`
+	Database          DatabaseConfig          `json:"database"`
+	JWT               JWTConfig               `json:"jwt"`
+	Auth              AuthConfig              `json:"auth"`
+	PasswordReset     PasswordResetConfig     `json:"password_reset"`
+	EmailVerification EmailVerificationConfig `json:"email_verification"`
+	EmailChange       EmailChangeConfig       `json:"email_change"`
+	Passkey           PasskeyConfig           `json:"passkey"`
+	OAuth             OAuthConfig             `json:"oauth"`
+	APIKey            APIKeyConfig            `json:"api_key"`
+	Security          SecurityConfig          `json:"security"`
+	CORS              CORSConfig              `json:"cors"`
+	APIVersion        APIVersionConfig        `json:"api_version"`
+	Redis             RedisConfig             `json:"redis"`
+	RabbitMQ          RabbitMQConfig          `json:"rabbitmq"`
+	NATS              NATSConfig              `json:"nats"`
+	Storage           StorageConfig           `json:"storage"`
+	SSE               SSEConfig               `json:"sse"`
+	Audit             AuditConfig             `json:"audit"`
+	Honeytoken        HoneytokenConfig        `json:"honeytoken"`
+	IPRules           IPRulesConfig           `json:"ip_rules"`
+	GeoRestriction    GeoRestrictionConfig    `json:"geo_restriction"`
+	Authorization     AuthorizationConfig     `json:"authorization"`
+	Worker            WorkerConfig            `json:"worker"`
+	Scheduler         SchedulerConfig         `json:"scheduler"`
+	Observability     ObservabilityConfig     `json:"observability"`
+	Email             EmailConfig             `json:"email"`
+	RateLimit         RateLimitConfig         `json:"rate_limit"`
+	Bulkhead          BulkheadConfig          `json:"bulkhead"`
+	LoadShed          LoadShedConfig          `json:"load_shed"`
+	Watchdog          WatchdogConfig          `json:"watchdog"`
+	ReadOnly          ReadOnlyConfig          `json:"read_only"`
+	Health            HealthConfig            `json:"health"`
+	Chaos             ChaosConfig             `json:"chaos"`
+	Features          FeaturesConfig          `json:"features"`
+	Activity          ActivityConfig          `json:"activity"`
+	Users             UsersConfig             `json:"users"`
+
+	// sources records where each leaf value came from; see Settings.
+	sources map[string]Source
+	// envReport records how environment overrides were applied; see EnvReport.
+	envReport EnvReport
 }
 
 type AppConfig struct {
@@ -40,23 +66,47 @@ type AppConfig struct {
 type ServerConfig struct {
 	Host           string   `json:"host" env:"SERVER_HOST"`
 	Port           int      `json:"port" env:"SERVER_PORT"`
-	ReadTimeout    int      `json:"read_timeout" env:"SERVER_READ_TIMEOUT"`
-	WriteTimeout   int      `json:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
-	IdleTimeout    int      `json:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ReadTimeout    Duration `json:"read_timeout" env:"SERVER_READ_TIMEOUT" unit:"s"`
+	WriteTimeout   Duration `json:"write_timeout" env:"SERVER_WRITE_TIMEOUT" unit:"s"`
+	IdleTimeout    Duration `json:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" unit:"s"`
 	TrustedProxies []string `json:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
 	ProxyHeader    string   `json:"proxy_header" env:"SERVER_PROXY_HEADER"`
+	// DisconnectPoll is how often an in-flight request checks whether its
+	// client has gone away, cancelling the request context if so; 0 disables.
+	DisconnectPoll Duration `json:"disconnect_poll" env:"SERVER_DISCONNECT_POLL"`
 }
 
 type DatabaseConfig struct {
-	Host            string `json:"host" env:"DB_HOST"`
-	Port            int    `json:"port" env:"DB_PORT"`
-	User            string `json:"user" env:"DB_USER"`
-	Password        string `json:"password" env:"DB_PASSWORD"`
-	Name            string `json:"name" env:"DB_NAME"`
-	SSLMode         string `json:"ssl_mode" env:"DB_SSL_MODE"`
-	MaxOpenConns    int    `json:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
-	MaxIdleConns    int    `json:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
-	ConnMaxLifetime int    `json:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	Host            string   `json:"host" env:"DB_HOST"`
+	Port            int      `json:"port" env:"DB_PORT"`
+	User            string   `json:"user" env:"DB_USER"`
+	Password        string   `json:"password" env:"DB_PASSWORD" secret:"true"`
+	Name            string   `json:"name" env:"DB_NAME"`
+	SSLMode         string   `json:"ssl_mode" env:"DB_SSL_MODE"`
+	MaxOpenConns    int      `json:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `json:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" unit:"s"`
+	// StatementTimeoutMs and IdleInTxTimeoutMs are applied server-side to
+	// every pooled connection; 0 leaves the server default (no limit).
+	StatementTimeoutMs int `json:"statement_timeout_ms" env:"DB_STATEMENT_TIMEOUT_MS"`
+	IdleInTxTimeoutMs  int `json:"idle_in_transaction_timeout_ms" env:"DB_IDLE_IN_TX_TIMEOUT_MS"`
+	// QueryTimeoutMs is the client-side deadline repositories put on each
+	// individual query; 0 disables it.
+	QueryTimeoutMs int `json:"query_timeout_ms" env:"DB_QUERY_TIMEOUT_MS"`
+	// TxMaxAttempts is how many times a transaction that fails with a
+	// serialization failure or deadlock is run, including the first; 0 uses
+	// the default (3). TxRetryBackoff is the delay before the first retry,
+	// doubled for each later one; 0 uses the default (10ms).
+	TxMaxAttempts  int      `json:"tx_max_attempts" env:"DB_TX_MAX_ATTEMPTS"`
+	TxRetryBackoff Duration `json:"tx_retry_backoff" env:"DB_TX_RETRY_BACKOFF"`
+	// AutoMigrate applies pending migrations when the API starts, from the
+	// migrations directory when it exists and the embedded copy otherwise.
+	AutoMigrate bool `json:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+// QueryTimeout returns the per-query deadline as a duration.
+func (c DatabaseConfig) QueryTimeout() time.Duration {
+	return time.Duration(c.QueryTimeoutMs) * time.Millisecond
 }
 
 // DSN returns the PostgreSQL connection string
@@ -68,11 +118,176 @@ func (c DatabaseConfig) DSN() string {
 }
 
 type JWTConfig struct {
-	Secret          string `json:"secret" env:"JWT_SECRET"`
-	AccessTokenTTL  int    `json:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL"`
-	RefreshTokenTTL int    `json:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL"`
-	Issuer          string `json:"issuer" env:"JWT_ISSUER"`
-	Audience        string `json:"audience" env:"JWT_AUDIENCE"`
+	// Algorithm signs access tokens: HS256 (the default) with Secret, or
+	// RS256 / ES256 with PrivateKeyFile, whose public key is served at
+	// /.well-known/jwks.json.
+	Algorithm string `json:"algorithm" env:"JWT_ALGORITHM"`
+	// Secret is the HS256 signing secret; unused with RS256 and ES256.
+	Secret string `json:"secret" env:"JWT_SECRET" secret:"true"`
+	// PrivateKeyFile is the PEM private key (RSA for RS256, P-256 for ES256)
+	// new tokens are signed with.
+	PrivateKeyFile string `json:"private_key_file" env:"JWT_PRIVATE_KEY_FILE"`
+	// VerificationKeyFiles are further PEM keys tokens are accepted from and
+	// that the JWKS publishes: the previous key after a rotation, or the next
+	// one ahead of it.
+	VerificationKeyFiles []string `json:"verification_key_files" env:"JWT_VERIFICATION_KEY_FILES"`
+
+	AccessTokenTTL  Duration `json:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL" unit:"m"`
+	RefreshTokenTTL Duration `json:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL" unit:"m"`
+	Issuer          string   `json:"issuer" env:"JWT_ISSUER"`
+	Audience        string   `json:"audience" env:"JWT_AUDIENCE"`
+	// MaxSessions caps the refresh-token sessions a user may hold at once; a
+	// login beyond it evicts that user's oldest session. 0 means unlimited.
+	MaxSessions int `json:"max_sessions" env:"JWT_MAX_SESSIONS"`
+	// ImpersonationTTL is the lifetime of the tokens superadmins receive to
+	// act as another user; 0 means AccessTokenTTL. It may not exceed
+	// AccessTokenTTL, which bounds how long a revocation is remembered.
+	ImpersonationTTL Duration `json:"impersonation_ttl" env:"JWT_IMPERSONATION_TTL" unit:"m"`
+}
+
+// PasswordResetConfig configures the forgot-password flow. Reset links are
+// emailed by the worker through an email.send job.
+type PasswordResetConfig struct {
+	Enabled bool `json:"enabled" env:"PASSWORD_RESET_ENABLED"`
+	// TokenTTL is how long a reset link stays valid; 0 uses the default (30m).
+	TokenTTL Duration `json:"token_ttl" env:"PASSWORD_RESET_TOKEN_TTL" unit:"m"`
+	// URL is the page where users choose their new password. The token is
+	// added to it as the "token" query parameter.
+	URL string `json:"url" env:"PASSWORD_RESET_URL"`
+	// EmailTenant is the email sender profile to use; empty means the default.
+	EmailTenant string `json:"email_tenant" env:"PASSWORD_RESET_EMAIL_TENANT"`
+}
+
+// EmailVerificationConfig configures email address verification. Verification
+// links are emailed by the worker through an email.send job.
+type EmailVerificationConfig struct {
+	Enabled bool `json:"enabled" env:"EMAIL_VERIFICATION_ENABLED"`
+	// TokenTTL is how long a verification link stays valid; 0 uses the
+	// default (24h).
+	TokenTTL Duration `json:"token_ttl" env:"EMAIL_VERIFICATION_TOKEN_TTL" unit:"h"`
+	// URL is the page that submits the token to POST /auth/verify-email. The
+	// token is added to it as the "token" query parameter.
+	URL string `json:"url" env:"EMAIL_VERIFICATION_URL"`
+	// EmailTenant is the email sender profile to use; empty means the default.
+	EmailTenant string `json:"email_tenant" env:"EMAIL_VERIFICATION_EMAIL_TENANT"`
+	// RequireForLogin rejects logins to accounts whose address is unverified.
+	RequireForLogin bool `json:"require_for_login" env:"EMAIL_VERIFICATION_REQUIRED"`
+}
+
+// EmailChangeConfig configures confirmed email changes. When enabled, a new
+// address set through PUT /users/:id is kept pending until the user follows
+// the confirmation link emailed to it by the worker.
+type EmailChangeConfig struct {
+	Enabled bool `json:"enabled" env:"EMAIL_CHANGE_ENABLED"`
+	// TokenTTL is how long a confirmation link stays valid; 0 uses the
+	// default (24h).
+	TokenTTL Duration `json:"token_ttl" env:"EMAIL_CHANGE_TOKEN_TTL" unit:"h"`
+	// URL is the page that submits the token to POST
+	// /auth/confirm-email-change. The token is added to it as the "token"
+	// query parameter.
+	URL string `json:"url" env:"EMAIL_CHANGE_URL"`
+	// EmailTenant is the email sender profile to use; empty means the default.
+	EmailTenant string `json:"email_tenant" env:"EMAIL_CHANGE_EMAIL_TENANT"`
+}
+
+// PasskeyConfig configures WebAuthn passkey login. The relying party (RP) is
+// the site passkeys are bound to: browsers only offer a passkey on pages whose
+// origin belongs to its RP ID.
+type PasskeyConfig struct {
+	Enabled bool `json:"enabled" env:"PASSKEY_ENABLED"`
+	// RPID is the domain passkeys are registered for, e.g. "example.com".
+	// Changing it makes existing passkeys unusable.
+	RPID string `json:"rp_id" env:"PASSKEY_RP_ID"`
+	// RPDisplayName is the site name authenticators show to the user
+	RPDisplayName string `json:"rp_display_name" env:"PASSKEY_RP_DISPLAY_NAME"`
+	// Origins are the origins of the pages running the ceremonies, e.g.
+	// "https://app.example.com". Each must be RPID or a subdomain of it.
+	Origins []string `json:"origins" env:"PASSKEY_ORIGINS"`
+	// CeremonyTimeout bounds the time between beginning and finishing a
+	// registration or login; 0 uses the default (5m).
+	CeremonyTimeout Duration `json:"ceremony_timeout" env:"PASSKEY_CEREMONY_TIMEOUT" unit:"s"`
+}
+
+// APIKeyConfig configures API keys for machine-to-machine callers. A key acts
+// as the user who created it, limited to the key's scopes.
+type APIKeyConfig struct {
+	Enabled bool `json:"enabled" env:"API_KEY_ENABLED"`
+	// Header is the request header carrying the key
+	Header string `json:"header" env:"API_KEY_HEADER"`
+	// MaxPerUser caps the active (unrevoked, unexpired) keys a user holds
+	MaxPerUser int `json:"max_per_user" env:"API_KEY_MAX_PER_USER"`
+	// MaxTTL caps a key's lifetime; keys created without an expiry get it.
+	// 0 allows keys that never expire.
+	MaxTTL Duration `json:"max_ttl" env:"API_KEY_MAX_TTL" unit:"h"`
+}
+
+// SecurityConfig groups the brute-force protections.
+type SecurityConfig struct {
+	LoginThrottle LoginThrottleConfig `json:"login_throttle"`
+}
+
+// LoginThrottleConfig configures brute-force protection for password login.
+// Failed attempts are counted per email address and per client IP. Past
+// DelayAfter failures each further attempt must wait, doubling from BaseDelay
+// up to MaxDelay; at MaxEmailFailures (or MaxIPFailures) the address (or IP)
+// is locked out for LockoutDuration.
+type LoginThrottleConfig struct {
+	Enabled bool `json:"enabled" env:"SECURITY_LOGIN_THROTTLE_ENABLED"`
+	// Window is how long a failure counts; the counters reset after a
+	// window without failures.
+	Window Duration `json:"window" env:"SECURITY_LOGIN_WINDOW" unit:"m"`
+	// DelayAfter is the failures allowed before delays start; 0 disables
+	// delays.
+	DelayAfter int      `json:"delay_after" env:"SECURITY_LOGIN_DELAY_AFTER"`
+	BaseDelay  Duration `json:"base_delay" env:"SECURITY_LOGIN_BASE_DELAY" unit:"s"`
+	MaxDelay   Duration `json:"max_delay" env:"SECURITY_LOGIN_MAX_DELAY" unit:"s"`
+	// MaxEmailFailures locks an address out, correct password or not
+	MaxEmailFailures int `json:"max_email_failures" env:"SECURITY_LOGIN_MAX_EMAIL_FAILURES"`
+	// MaxIPFailures locks a client IP out of login for every address; 0
+	// disables the IP lockout.
+	MaxIPFailures   int      `json:"max_ip_failures" env:"SECURITY_LOGIN_MAX_IP_FAILURES"`
+	LockoutDuration Duration `json:"lockout_duration" env:"SECURITY_LOGIN_LOCKOUT_DURATION" unit:"m"`
+}
+
+// AuthConfig configures how accounts come to exist.
+type AuthConfig struct {
+	// AllowRegistration serves POST /auth/register, where anyone can create
+	// an account. Off, users are created by an admin (or by OAuth signup).
+	AllowRegistration bool `json:"allow_registration" env:"AUTH_ALLOW_REGISTRATION"`
+	// DefaultRole is granted to every registered user: viewer or editor.
+	DefaultRole string `json:"default_role" env:"AUTH_DEFAULT_ROLE"`
+}
+
+// OAuthConfig configures login with external identity providers (OAuth2
+// authorization code flow with PKCE). Providers are set in the config file
+// only; client secrets are read from the env vars they name.
+type OAuthConfig struct {
+	Enabled bool `json:"enabled" env:"OAUTH_ENABLED"`
+	// StateTTL bounds the time between starting a login and the provider
+	// redirecting back; 0 uses the default (10m).
+	StateTTL Duration `json:"state_ttl" env:"OAUTH_STATE_TTL" unit:"m"`
+	// AllowSignup creates an account for an identity that matches no user.
+	// Off, only existing users (matched by verified email) can sign in.
+	AllowSignup bool `json:"allow_signup" env:"OAUTH_ALLOW_SIGNUP"`
+	// Providers are keyed by the name used in the login URL
+	// (/auth/oauth/<name>).
+	Providers map[string]OAuthProviderConfig `json:"providers"`
+}
+
+// OAuthProviderConfig is one identity provider.
+type OAuthProviderConfig struct {
+	// Type is "google", "github" or "oidc" (any OpenID Connect issuer)
+	Type     string `json:"type"`
+	ClientID string `json:"client_id"`
+	// ClientSecretEnv names the env var holding the client secret
+	ClientSecretEnv string `json:"client_secret_env"`
+	// IssuerURL is the OpenID Connect issuer; "oidc" providers only
+	IssuerURL string `json:"issuer_url"`
+	// RedirectURL is this API's callback URL as registered with the
+	// provider, ending in /auth/oauth/<name>/callback
+	RedirectURL string `json:"redirect_url"`
+	// Scopes replaces the default scopes for the provider type
+	Scopes []string `json:"scopes"`
 }
 
 type CORSConfig struct {
@@ -82,21 +297,30 @@ type CORSConfig struct {
 	AllowCredentials bool   `json:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
 }
 
-// AccessTokenDuration returns the access token TTL as time.Duration (in minutes)
+// AccessTokenDuration returns the access token TTL as time.Duration
 func (c JWTConfig) AccessTokenDuration() time.Duration {
-	return time.Duration(c.AccessTokenTTL) * time.Minute
+	return time.Duration(c.AccessTokenTTL)
+}
+
+// ImpersonationDuration returns the impersonation token TTL as
+// time.Duration, defaulting to the access token TTL
+func (c JWTConfig) ImpersonationDuration() time.Duration {
+	if c.ImpersonationTTL == 0 {
+		return c.AccessTokenDuration()
+	}
+	return time.Duration(c.ImpersonationTTL)
 }
 
-// RefreshTokenDuration returns the refresh token TTL as time.Duration (in minutes)
+// RefreshTokenDuration returns the refresh token TTL as time.Duration
 func (c JWTConfig) RefreshTokenDuration() time.Duration {
-	return time.Duration(c.RefreshTokenTTL) * time.Minute
+	return time.Duration(c.RefreshTokenTTL)
 }
 
 type RedisConfig struct {
 	Enabled  bool   `json:"enabled" env:"REDIS_ENABLED"`
 	Host     string `json:"host" env:"REDIS_HOST"`
 	Port     int    `json:"port" env:"REDIS_PORT"`
-	Password string `json:"password" env:"REDIS_PASSWORD"`
+	Password string `json:"password" env:"REDIS_PASSWORD" secret:"true"`
 	DB       int    `json:"db" env:"REDIS_DB"`
 }
 
@@ -107,10 +331,23 @@ func (c RedisConfig) Addr() string {
 
 type RabbitMQConfig struct {
 	Enabled       bool   `json:"enabled" env:"RABBITMQ_ENABLED"`
-	URL           string `json:"url" env:"RABBITMQ_URL"`
+	URL           string `json:"url" env:"RABBITMQ_URL" secret:"url"`
 	PrefetchCount int    `json:"prefetch_count" env:"RABBITMQ_PREFETCH_COUNT"`
 }
 
+// NATSConfig configures NATS JetStream as the job queue broker, instead of
+// RabbitMQ. Each queue is a work-queue stream; see queue.NATS.
+type NATSConfig struct {
+	Enabled bool   `json:"enabled" env:"NATS_ENABLED"`
+	URL     string `json:"url" env:"NATS_URL" secret:"url"`
+	// StreamPrefix namespaces the streams and subjects (default: goscratch)
+	StreamPrefix  string `json:"stream_prefix" env:"NATS_STREAM_PREFIX"`
+	PrefetchCount int    `json:"prefetch_count" env:"NATS_PREFETCH_COUNT"`
+	// AckWait is how long a job may run before it is redelivered; it must
+	// outlast the worker's 5m handler timeout (default: 6m)
+	AckWait Duration `json:"ack_wait" env:"NATS_ACK_WAIT"`
+}
+
 type StorageConfig struct {
 	Mode  string       `json:"mode" env:"STORAGE_MODE"` // "local", "s3", "both"
 	Local LocalStorage `json:"local"`
@@ -125,20 +362,283 @@ type S3Storage struct {
 	Endpoint  string `json:"endpoint" env:"S3_ENDPOINT"`
 	Bucket    string `json:"bucket" env:"S3_BUCKET"`
 	Region    string `json:"region" env:"S3_REGION"`
-	AccessKey string `json:"access_key" env:"S3_ACCESS_KEY"`
-	SecretKey string `json:"secret_key" env:"S3_SECRET_KEY"`
+	AccessKey string `json:"access_key" env:"S3_ACCESS_KEY" secret:"true"`
+	SecretKey string `json:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
 }
 
 type SSEConfig struct {
 	Enabled bool `json:"enabled" env:"SSE_ENABLED"`
+	// ReplaySize is how many recent broadcasts are kept for reconnecting
+	// streams and GET /events/poll. 0 disables both.
+	ReplaySize int `json:"replay_size" env:"SSE_REPLAY_SIZE"`
+	// PollTimeout is how long GET /events/poll waits for an event; keep it
+	// under the idle timeout of proxies in front of the API.
+	PollTimeout Duration `json:"poll_timeout" env:"SSE_POLL_TIMEOUT"`
+	// TicketTTL is how long a ticket from POST /events/ticket can open a
+	// stream.
+	TicketTTL Duration `json:"ticket_ttl" env:"SSE_TICKET_TTL"`
+	// TopicPermissions maps a topic to the "object:action" permission needed
+	// to subscribe to it. A listed topic reaches only clients that name it
+	// when subscribing, never those subscribed to every topic.
+	TopicPermissions map[string]string `json:"topic_permissions"`
 }
 
 type AuditConfig struct {
 	Enabled bool `json:"enabled" env:"AUDIT_ENABLED"`
+	// Export ships audit and auth events to a SIEM. It works with audit
+	// logging off too: events are then exported without being stored.
+	Export AuditExportConfig `json:"export"`
+	// Async writes entries in batches off the request path
+	Async AuditAsyncConfig `json:"async"`
+	// Retention configures the audit.cleanup job
+	Retention AuditRetentionConfig `json:"retention"`
+	// Chain makes stored entries tamper-evident: each one stores a hash of
+	// its content and of the entry before it. Writes are serialised across
+	// instances.
+	Chain bool `json:"chain" env:"AUDIT_CHAIN"`
+	// HTTP audits every mutating request, next to what modules audit
+	HTTP AuditHTTPConfig `json:"http"`
+	// Reads audits a sample of record reads, e.g. a user profile being
+	// viewed
+	Reads AuditReadsConfig `json:"reads"`
+}
+
+// AuditReadsConfig configures the READ entries written when a single record
+// is viewed. Auditing every read is too noisy for most deployments, so a
+// sample can be taken instead.
+type AuditReadsConfig struct {
+	Enabled bool `json:"enabled" env:"AUDIT_READS_ENABLED"`
+	// SampleRate is the fraction of reads audited, from 0 to 1; 1 audits
+	// every read
+	SampleRate float64 `json:"sample_rate" env:"AUDIT_READS_SAMPLE_RATE"`
+	// Resources limits read auditing to these resources, e.g. "user";
+	// empty audits reads of every resource
+	Resources []string `json:"resources" env:"AUDIT_READS_RESOURCES"`
+}
+
+// AuditHTTPConfig configures the audit entry written for every POST, PUT,
+// PATCH and DELETE request
+type AuditHTTPConfig struct {
+	Enabled bool `json:"enabled" env:"AUDIT_HTTP_ENABLED"`
+	// Include limits auditing to these path prefixes, e.g. "/api/admin";
+	// empty audits every path
+	Include []string `json:"include" env:"AUDIT_HTTP_INCLUDE"`
+	// Exclude skips these path prefixes, even when Include lists them
+	Exclude []string `json:"exclude" env:"AUDIT_HTTP_EXCLUDE"`
+}
+
+// AuditRetentionConfig configures how long audit entries are kept and
+// whether the audit.cleanup job archives them before deleting them
+type AuditRetentionConfig struct {
+	// Days is how long entries are kept (default: 90). A job's
+	// retention_days overrides it.
+	Days int `json:"days" env:"AUDIT_RETENTION_DAYS"`
+	// Archive uploads expired entries to storage as gzipped NDJSON before
+	// they are deleted
+	Archive bool `json:"archive" env:"AUDIT_RETENTION_ARCHIVE"`
+	// ArchivePrefix is the storage path archives are written under
+	// (default: audit-archive)
+	ArchivePrefix string `json:"archive_prefix" env:"AUDIT_RETENTION_ARCHIVE_PREFIX"`
+}
+
+// AuditAsyncConfig configures batched audit writes. Entries are queued and
+// written by a background goroutine; the queue is flushed on shutdown.
+type AuditAsyncConfig struct {
+	Enabled bool `json:"enabled" env:"AUDIT_ASYNC_ENABLED"`
+	// BufferSize is how many entries wait to be written before requests
+	// write synchronously again (default: 1024).
+	BufferSize int `json:"buffer_size" env:"AUDIT_ASYNC_BUFFER_SIZE"`
+	// BatchSize is the most entries written at once (default: 100).
+	BatchSize int `json:"batch_size" env:"AUDIT_ASYNC_BATCH_SIZE"`
+	// FlushInterval is the longest an entry waits for a batch to fill
+	// (default: 1s).
+	FlushInterval Duration `json:"flush_interval" env:"AUDIT_ASYNC_FLUSH_INTERVAL"`
+}
+
+// APIVersionConfig configures response version negotiation. Clients pick a
+// version with Accept: application/vnd.goscratch.vN+json; the others get
+// Default.
+type APIVersionConfig struct {
+	// Default is the version served without a vendor Accept type (0 means
+	// 1). Raise it once clients have moved to a newer version.
+	Default int `json:"default" env:"API_VERSION_DEFAULT"`
+}
+
+// HoneytokenConfig configures canary credentials: accounts and API keys no
+// legitimate client uses, whose use raises an alert.
+type HoneytokenConfig struct {
+	Enabled bool `json:"enabled" env:"HONEYTOKEN_ENABLED"`
+	// Users are the emails of canary accounts. Logins to them always fail.
+	Users []string `json:"users" env:"HONEYTOKEN_USERS"`
+	// APIKeyHashes are the SHA-256 hex digests of canary API keys, looked
+	// for in the X-API-Key and Authorization headers.
+	APIKeyHashes []string `json:"api_key_hashes" env:"HONEYTOKEN_API_KEY_HASHES"`
+	// Notify lists the addresses alerts are emailed to
+	Notify []string `json:"notify" env:"HONEYTOKEN_NOTIFY"`
+	// Lockdown refuses every request from the source IP of a tripped canary
+	// for LockdownTTL (default: 1h).
+	Lockdown    bool     `json:"lockdown" env:"HONEYTOKEN_LOCKDOWN"`
+	LockdownTTL Duration `json:"lockdown_ttl" env:"HONEYTOKEN_LOCKDOWN_TTL"`
+}
+
+// IPRulesConfig configures IP allow and deny rules (see
+// internal/platform/iprules). Rules added at runtime via /admin/ip-rules are
+// kept in Redis next to the fixed ones listed here.
+type IPRulesConfig struct {
+	Enabled bool `json:"enabled" env:"IP_RULES_ENABLED"`
+	// Refresh is how often each instance re-reads the runtime rules.
+	Refresh Duration `json:"refresh" env:"IP_RULES_REFRESH"`
+	// Rules are fixed rules (config file only); they cannot be removed at
+	// runtime.
+	Rules []IPRuleConfig `json:"rules"`
+}
+
+// GeoRestrictionConfig configures country-based blocking of route groups.
+// Countries are resolved from a local GeoIP database (see
+// internal/adapter/geoip).
+type GeoRestrictionConfig struct {
+	Enabled bool `json:"enabled" env:"GEO_RESTRICTION_ENABLED"`
+	// Database is a CSV file of start_ip,end_ip,country_code ranges, such as
+	// DB-IP's free "IP to Country Lite" database.
+	Database string `json:"database" env:"GEO_RESTRICTION_DATABASE"`
+	// BlockUnknown also blocks addresses with no known country from the
+	// restricted routes.
+	BlockUnknown bool `json:"block_unknown" env:"GEO_RESTRICTION_BLOCK_UNKNOWN"`
+	// AuditInterval is the least time between two audit entries for the
+	// same blocked address (default: 1m).
+	AuditInterval Duration `json:"audit_interval" env:"GEO_RESTRICTION_AUDIT_INTERVAL"`
+	// Rules list the countries blocked from each route group (config file
+	// only).
+	Rules []GeoRuleConfig `json:"rules"`
+}
+
+// GeoRuleConfig blocks countries from the routes under a prefix
+type GeoRuleConfig struct {
+	// Prefix is a path and everything below it, e.g. "/admin". Empty
+	// applies to every route.
+	Prefix    string   `json:"prefix"`
+	Countries []string `json:"countries"` // ISO 3166-1 alpha-2 codes
+}
+
+// IPRuleConfig is one fixed IP rule
+type IPRuleConfig struct {
+	Action string `json:"action"` // "allow" or "deny"
+	CIDR   string `json:"cidr"`   // range or bare address
+	// Prefix limits the rule to a path and everything below it, e.g.
+	// "/admin". Empty applies to every route.
+	Prefix string `json:"prefix"`
+	Note   string `json:"note"`
+}
+
+// AuditExportConfig configures the security event export
+type AuditExportConfig struct {
+	Enabled bool `json:"enabled" env:"AUDIT_EXPORT_ENABLED"`
+	// Format is "ecs" (Elastic Common Schema JSON, default) or "cef"
+	// (ArcSight Common Event Format).
+	Format string `json:"format" env:"AUDIT_EXPORT_FORMAT"`
+	// Sink is "file" (default; one event per line, appended to Path),
+	// "http" (batches POSTed to URL), "loki", "elasticsearch" or "kafka".
+	// Several may be listed, comma-separated; each gets every event.
+	Sink string `json:"sink" env:"AUDIT_EXPORT_SINK"`
+	Path string `json:"path" env:"AUDIT_EXPORT_PATH"`
+	URL  string `json:"url" env:"AUDIT_EXPORT_URL" secret:"url"`
+	// Authorization is sent as the Authorization header of http sink
+	// requests, e.g. "ApiKey ...".
+	Authorization string `json:"authorization" env:"AUDIT_EXPORT_AUTHORIZATION" secret:"true"`
+	// BufferSize is how many events wait for the sink before new ones are
+	// dropped (default: 1024).
+	BufferSize int `json:"buffer_size" env:"AUDIT_EXPORT_BUFFER_SIZE"`
+	// BatchSize is the most events sent at once (default: 100).
+	BatchSize int `json:"batch_size" env:"AUDIT_EXPORT_BATCH_SIZE"`
+	// FlushInterval is the longest an event waits for a batch to fill
+	// (default: 1s).
+	FlushInterval Duration `json:"flush_interval" env:"AUDIT_EXPORT_FLUSH_INTERVAL"`
+	// Timeout bounds each send (default: 5s).
+	Timeout Duration `json:"timeout" env:"AUDIT_EXPORT_TIMEOUT"`
+	// Loki, Elasticsearch and Kafka configure the sinks of those names
+	Loki          AuditLokiConfig          `json:"loki"`
+	Elasticsearch AuditElasticsearchConfig `json:"elasticsearch"`
+	Kafka         AuditKafkaConfig         `json:"kafka"`
+}
+
+// Sinks returns the sinks Sink lists, "file" when it is empty
+func (c AuditExportConfig) Sinks() []string {
+	if strings.TrimSpace(c.Sink) == "" {
+		return []string{"file"}
+	}
+	sinks := strings.Split(c.Sink, ",")
+	for i, s := range sinks {
+		sinks[i] = strings.TrimSpace(s)
+	}
+	return sinks
+}
+
+// AuditLokiConfig configures the loki audit export sink
+type AuditLokiConfig struct {
+	// URL is Loki's base URL; batches are pushed to /loki/api/v1/push
+	URL string `json:"url" env:"AUDIT_EXPORT_LOKI_URL" secret:"url"`
+	// TenantID is sent as X-Scope-OrgID to a multi-tenant Loki
+	TenantID      string `json:"tenant_id" env:"AUDIT_EXPORT_LOKI_TENANT_ID"`
+	Authorization string `json:"authorization" env:"AUDIT_EXPORT_LOKI_AUTHORIZATION" secret:"true"`
+	// Labels are the labels of the stream events are pushed to (default:
+	// job=goscratch-audit). Keep them few and fixed.
+	Labels map[string]string `json:"labels"`
+}
+
+// AuditElasticsearchConfig configures the elasticsearch audit export sink
+type AuditElasticsearchConfig struct {
+	// URL is the cluster's base URL; batches go to <url>/<index>/_bulk
+	URL string `json:"url" env:"AUDIT_EXPORT_ELASTICSEARCH_URL" secret:"url"`
+	// Index is the index or data stream events are created in (default:
+	// goscratch-audit)
+	Index         string `json:"index" env:"AUDIT_EXPORT_ELASTICSEARCH_INDEX"`
+	Authorization string `json:"authorization" env:"AUDIT_EXPORT_ELASTICSEARCH_AUTHORIZATION" secret:"true"`
+}
+
+// AuditKafkaConfig configures the kafka audit export sink, which produces
+// through a Kafka REST Proxy (Confluent v2 API)
+type AuditKafkaConfig struct {
+	// URL is the REST Proxy's base URL; batches go to <url>/topics/<topic>
+	URL           string `json:"url" env:"AUDIT_EXPORT_KAFKA_URL" secret:"url"`
+	Topic         string `json:"topic" env:"AUDIT_EXPORT_KAFKA_TOPIC"`
+	Authorization string `json:"authorization" env:"AUDIT_EXPORT_KAFKA_AUTHORIZATION" secret:"true"`
 }
 
 type AuthorizationConfig struct {
 	Enabled bool `json:"enabled" env:"AUTHORIZATION_ENABLED"`
+	// ModelPath names a Casbin model file replacing the built-in RBAC model
+	ModelPath string `json:"model_path" env:"AUTHORIZATION_MODEL_PATH"`
+	// Watch reloads the policy on every instance when casbin_rules changes,
+	// through Postgres LISTEN/NOTIFY
+	Watch bool `json:"watch" env:"AUTHORIZATION_WATCH"`
+	// WatchDebounce coalesces changes arriving within it into one reload;
+	// 0 means 500ms
+	WatchDebounce Duration `json:"watch_debounce" env:"AUTHORIZATION_WATCH_DEBOUNCE" unit:"ms"`
+	// ReloadInterval is the backstop full reload, catching any missed
+	// change; 0 means 5 minutes
+	ReloadInterval Duration `json:"reload_interval" env:"AUTHORIZATION_RELOAD_INTERVAL"`
+	// TenantParam and TenantHeader name the route parameter and header a
+	// request's tenant is read from, the parameter first. Roles granted in
+	// that tenant then apply to the request. Both empty disables tenancy.
+	TenantParam  string `json:"tenant_param" env:"AUTHORIZATION_TENANT_PARAM"`
+	TenantHeader string `json:"tenant_header" env:"AUTHORIZATION_TENANT_HEADER"`
+	// PolicyFile names a YAML or JSON policy, in the format of
+	// GET /roles/policy, seeded at startup: the roles and rules it lists
+	// are added when missing
+	PolicyFile string `json:"policy_file" env:"AUTHORIZATION_POLICY_FILE"`
+	// PolicyPrune also removes stored permissions and denies PolicyFile
+	// does not list. Role grants are never removed.
+	PolicyPrune bool `json:"policy_prune" env:"AUTHORIZATION_POLICY_PRUNE"`
+	// DecisionCache shares permission decisions across instances
+	DecisionCache DecisionCacheConfig `json:"decision_cache"`
+}
+
+// DecisionCacheConfig configures the decision cache kept in Redis in front
+// of the authorizer
+type DecisionCacheConfig struct {
+	Enabled bool `json:"enabled" env:"AUTHORIZATION_DECISION_CACHE_ENABLED"`
+	// TTL bounds how long a decision is served
+	TTL Duration `json:"ttl" env:"AUTHORIZATION_DECISION_CACHE_TTL"`
 }
 
 type WorkerConfig struct {
@@ -146,11 +646,114 @@ type WorkerConfig struct {
 	Concurrency int    `json:"concurrency" env:"WORKER_CONCURRENCY"`
 	QueueName   string `json:"queue_name" env:"WORKER_QUEUE_NAME"`
 	Exchange    string `json:"exchange" env:"WORKER_EXCHANGE"`
+	// Queues, when set, replaces QueueName for cmd/worker, which consumes
+	// every listed queue. Publishers still use QueueName.
+	Queues []string `json:"queues" env:"WORKER_QUEUES"`
+	// Only restricts cmd/worker to these job types; other jobs are left on
+	// the queue for other workers.
+	Only []string `json:"only" env:"WORKER_ONLY"`
+	// DryRun makes cmd/worker log each job and put it back instead of
+	// running it, stopping after one pass over its queues.
+	DryRun bool `json:"dry_run" env:"WORKER_DRY_RUN"`
+	// DeadLetterQueue is the queue failed jobs are dead-lettered to. It is
+	// listed next to the job queues by the admin queue endpoints.
+	DeadLetterQueue string `json:"dead_letter_queue" env:"WORKER_DEAD_LETTER_QUEUE"`
+	// DLQ makes cmd/worker publish jobs that fail permanently or exhaust
+	// their retries to <queue>.dlq, from where the admin API can requeue
+	// them. Without it such jobs are logged and dropped.
+	DLQ bool `json:"dlq" env:"WORKER_DLQ"`
+	// Tracking records each job's status in the jobs table: the API and
+	// workers write it, and GET /jobs reads it. Without it jobs are not
+	// tracked and the status endpoints return 404.
+	Tracking bool `json:"tracking" env:"WORKER_TRACKING"`
+	// IdempotencyTTL is how long cmd/worker remembers the idempotency key
+	// of a succeeded job, skipping later jobs with the same key. Keys are
+	// kept in Redis; without it they are not checked.
+	IdempotencyTTL Duration `json:"idempotency_ttl" env:"WORKER_IDEMPOTENCY_TTL"`
+	// QueuePolicy is declared on every job queue by the API and the worker.
+	QueuePolicy QueuePolicyConfig `json:"queue_policy"`
+	// Codec encodes published jobs: "json" (default), "msgpack" or
+	// "protobuf". JobCodecs overrides it per job type. Workers decode by
+	// each message's content type, so they need neither.
+	Codec     string            `json:"codec" env:"WORKER_CODEC"`
+	JobCodecs map[string]string `json:"job_codecs"`
+	// JobPriorities sets the priority published jobs get by type: "low",
+	// "normal" (default) or "high". They only reorder jobs on queues
+	// declared with queue_policy.priority.
+	JobPriorities map[string]string `json:"job_priorities"`
+	// Outbox relays messages written to the outbox table to the queue.
+	// With it enabled, user lifecycle events go through the outbox.
+	Outbox OutboxConfig `json:"outbox"`
+}
+
+// OutboxConfig configures the transactional outbox. Producers write
+// messages in the transaction of their change and every cmd/worker
+// instance relays them, so the API needs a running worker to publish.
+type OutboxConfig struct {
+	Enabled bool `json:"enabled" env:"WORKER_OUTBOX_ENABLED"`
+	// PollInterval is the wait between polls that found no full batch
+	// (default: 1s)
+	PollInterval Duration `json:"poll_interval" env:"WORKER_OUTBOX_POLL_INTERVAL"`
+	// BatchSize caps the messages published per poll (default: 100)
+	BatchSize int `json:"batch_size" env:"WORKER_OUTBOX_BATCH_SIZE"`
+	// Retention is how long published messages are kept (default: 168h)
+	Retention Duration `json:"retention" env:"WORKER_OUTBOX_RETENTION"`
+}
+
+// SchedulerConfig configures the scheduler in cmd/worker, which enqueues
+// recurring jobs on worker.queue_name. With Redis, each firing is claimed in
+// the cache so only one worker instance enqueues it.
+type SchedulerConfig struct {
+	Enabled bool `json:"enabled" env:"SCHEDULER_ENABLED"`
+	// Timezone cron expressions are evaluated in (default: UTC)
+	Timezone string `json:"timezone" env:"SCHEDULER_TIMEZONE"`
+	// Database adds the enabled rows of the job_schedules table, reloaded
+	// every Refresh (default: 1m)
+	Database bool     `json:"database" env:"SCHEDULER_DATABASE"`
+	Refresh  Duration `json:"refresh" env:"SCHEDULER_REFRESH"`
+	// Schedules are the recurring jobs set in config
+	Schedules []ScheduleConfig `json:"schedules"`
+}
+
+// ScheduleConfig is one recurring job
+type ScheduleConfig struct {
+	// Name identifies the schedule in logs and locks; unique
+	Name string `json:"name"`
+	// Cron is a five-field cron expression or a descriptor such as @daily
+	Cron    string         `json:"cron"`
+	JobType string         `json:"job_type"`
+	Payload map[string]any `json:"payload"`
+	// MaxRetry overrides the job's default retry count when above 0
+	MaxRetry int `json:"max_retry"`
+}
+
+// QueuePolicyConfig sets broker-side policies on the job queues. Zero values
+// keep RabbitMQ's defaults. Expired, dropped and rejected jobs go to
+// worker.dead_letter_queue when it is set.
+type QueuePolicyConfig struct {
+	MessageTTL Duration `json:"message_ttl" env:"WORKER_QUEUE_MESSAGE_TTL"`
+	MaxLength  int      `json:"max_length" env:"WORKER_QUEUE_MAX_LENGTH"`
+	// Overflow is "drop-head" (default), "reject-publish" or
+	// "reject-publish-dlx".
+	Overflow string `json:"overflow" env:"WORKER_QUEUE_OVERFLOW"`
+	// DeadLetterExchange, when set, is declared (direct) and bound to the
+	// dead-letter queue; otherwise dead letters use the default exchange.
+	DeadLetterExchange string `json:"dead_letter_exchange" env:"WORKER_QUEUE_DEAD_LETTER_EXCHANGE"`
+	Lazy               bool   `json:"lazy" env:"WORKER_QUEUE_LAZY"`
+	// Priority declares the job queues as priority queues, so higher
+	// priority jobs are delivered first. RabbitMQ cannot add it to an
+	// existing queue; delete or rename the queue first.
+	Priority bool `json:"priority" env:"WORKER_QUEUE_PRIORITY"`
 }
 
 type ObservabilityConfig struct {
 	Metrics MetricsConfig `json:"metrics"`
 	Tracing TracingConfig `json:"tracing"`
+	DBStats DBStatsConfig `json:"db_stats"`
+	// ResourceAttributes labels every trace, metric series and log line,
+	// e.g. {"team": "payments", "region": "{{env \"REGION\"}}"}. Values are
+	// text/templates; see observability.RenderResourceAttributes.
+	ResourceAttributes map[string]string `json:"resource_attributes"`
 }
 
 type MetricsConfig struct {
@@ -163,13 +766,44 @@ type TracingConfig struct {
 	Endpoint string `json:"endpoint" env:"TRACING_ENDPOINT"`
 }
 
+// DBStatsConfig controls per-request database statement metrics.
+type DBStatsConfig struct {
+	Enabled bool `json:"enabled" env:"DB_STATS_ENABLED"`
+	// WarnQueries logs a warning for requests running more statements than
+	// this (likely N+1); 0 disables the warning.
+	WarnQueries int `json:"warn_queries" env:"DB_STATS_WARN_QUERIES"`
+}
+
 type EmailConfig struct {
-	Enabled  bool   `json:"enabled" env:"EMAIL_ENABLED"`
-	Host     string `json:"host" env:"EMAIL_HOST"`
-	Port     int    `json:"port" env:"EMAIL_PORT"`
-	Username string `json:"username" env:"EMAIL_USERNAME"`
-	Password string `json:"password" env:"EMAIL_PASSWORD"`
-	From     string `json:"from" env:"EMAIL_FROM"`
+	Enabled    bool   `json:"enabled" env:"EMAIL_ENABLED"`
+	Host       string `json:"host" env:"EMAIL_HOST"`
+	Port       int    `json:"port" env:"EMAIL_PORT"`
+	Username   string `json:"username" env:"EMAIL_USERNAME"`
+	Password   string `json:"password" env:"EMAIL_PASSWORD" secret:"true"`
+	From       string `json:"from" env:"EMAIL_FROM"`
+	FromName   string `json:"from_name" env:"EMAIL_FROM_NAME"`
+	ReplyTo    string `json:"reply_to" env:"EMAIL_REPLY_TO"`
+	Footer     string `json:"footer" env:"EMAIL_FOOTER"`
+	HTMLFooter string `json:"html_footer" env:"EMAIL_HTML_FOOTER"`
+	// Tenants overrides the sender per tenant, keyed by the tenant named on
+	// the message. Set in the config file only.
+	Tenants map[string]EmailTenantConfig `json:"tenants"`
+}
+
+// EmailTenantConfig is one tenant's sender. Without a host the tenant uses the
+// default SMTP server and credentials; other empty fields fall back to the
+// default one by one. The password is read from the env var named by
+// PasswordEnv so secrets stay out of the config file.
+type EmailTenantConfig struct {
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Username    string `json:"username"`
+	PasswordEnv string `json:"password_env"`
+	From        string `json:"from"`
+	FromName    string `json:"from_name"`
+	ReplyTo     string `json:"reply_to"`
+	Footer      string `json:"footer"`
+	HTMLFooter  string `json:"html_footer"`
 }
 
 type RateLimitConfig struct {
@@ -178,6 +812,66 @@ type RateLimitConfig struct {
 	WindowSec int  `json:"window_sec" env:"RATE_LIMIT_WINDOW_SEC"`
 }
 
+// BulkheadConfig caps concurrent in-flight requests per route group (see
+// middleware.Bulkhead). Groups are set in the config file only.
+type BulkheadConfig struct {
+	Enabled bool `json:"enabled" env:"BULKHEAD_ENABLED"`
+	// RejectStatus is the status for rejected requests: 503 (default) or 429.
+	RejectStatus int                   `json:"reject_status" env:"BULKHEAD_REJECT_STATUS"`
+	Groups       []BulkheadGroupConfig `json:"groups"`
+}
+
+// BulkheadGroupConfig is one bulkhead. Requests whose path starts with any of
+// Prefixes share its MaxConcurrent slots.
+type BulkheadGroupConfig struct {
+	Name          string   `json:"name"`
+	Prefixes      []string `json:"prefixes"`
+	MaxConcurrent int      `json:"max_concurrent"`
+	MaxQueue      int      `json:"max_queue"`
+	// QueueTimeout is a Go duration string; a bare number is seconds.
+	QueueTimeout Duration `json:"queue_timeout"`
+}
+
+// LoadShedConfig controls adaptive load shedding (see internal/platform/loadshed).
+// A zero threshold disables that signal. Route priorities are set in the
+// config file only; unlisted routes are normal priority.
+type LoadShedConfig struct {
+	Enabled  bool     `json:"enabled" env:"LOAD_SHED_ENABLED"`
+	Interval Duration `json:"interval" env:"LOAD_SHED_INTERVAL"`
+	// PoolWait is the average DB connection-acquire wait that counts as saturated.
+	PoolWait Duration `json:"pool_wait" env:"LOAD_SHED_POOL_WAIT"`
+	// LoopLag is the scheduler lag that counts as saturated.
+	LoopLag Duration `json:"loop_lag" env:"LOAD_SHED_LOOP_LAG"`
+	// HeapMB is the live heap size, in MiB, that counts as saturated.
+	HeapMB           int      `json:"heap_mb" env:"LOAD_SHED_HEAP_MB"`
+	LowPriority      []string `json:"low_priority"`
+	CriticalPriority []string `json:"critical_priority"`
+}
+
+// WatchdogConfig configures the goroutine and channel leak watchdog (see
+// internal/platform/watchdog). The API and the worker each run their own.
+type WatchdogConfig struct {
+	Enabled  bool     `json:"enabled" env:"WATCHDOG_ENABLED"`
+	Interval Duration `json:"interval" env:"WATCHDOG_INTERVAL"`
+	// Window is how many samples a condition must last to be reported.
+	Window int `json:"window" env:"WATCHDOG_WINDOW"`
+	// GoroutineGrowth is the sustained goroutine growth reported as a leak.
+	GoroutineGrowth int `json:"goroutine_growth" env:"WATCHDOG_GOROUTINE_GROWTH"`
+	// Saturation is the fill level, from 0 to 1, at which a channel counts
+	// as saturated.
+	Saturation float64 `json:"saturation" env:"WATCHDOG_SATURATION"`
+}
+
+// ReadOnlyConfig configures the read-only switch (see internal/platform/readonly).
+// The switch itself is flipped at runtime via PUT /admin/read-only.
+type ReadOnlyConfig struct {
+	// Refresh is how often each instance re-reads the shared switch state.
+	Refresh Duration `json:"refresh" env:"READ_ONLY_REFRESH"`
+	// Allow lists path prefixes that may still mutate in read-only mode
+	// (config file only). /admin/read-only is always allowed.
+	Allow []string `json:"allow"`
+}
+
 type HealthConfig struct {
 	// ReadinessTimeout is the total budget for all parallel readiness sub-checks.
 	// Zero value defaults to 2s. Set via HEALTH_READINESS_TIMEOUT_SEC (integer seconds).
@@ -192,85 +886,118 @@ func (c HealthConfig) ReadinessTimeout() time.Duration {
 	return time.Duration(c.ReadinessTimeoutSec) * time.Second
 }
 
-// Load reads configuration from JSON file and applies environment variable overrides
-func Load(path string) (*Config, error) {
-	_ = godotenv.Load() // Load .env file if it exists (silently ignore if missing)
+// ChaosConfig controls dev-only fault injection (see internal/platform/chaos).
+// Validate rejects Enabled=true when app.env is "production".
+type ChaosConfig struct {
+	Enabled      bool     `json:"enabled" env:"CHAOS_ENABLED"`
+	LatencyMs    int      `json:"latency_ms" env:"CHAOS_LATENCY_MS"`
+	JitterMs     int      `json:"jitter_ms" env:"CHAOS_JITTER_MS"`
+	ErrorRate    float64  `json:"error_rate" env:"CHAOS_ERROR_RATE"`
+	Targets      []string `json:"targets" env:"CHAOS_TARGETS"`
+	AllowHeaders bool     `json:"allow_headers" env:"CHAOS_ALLOW_HEADERS"`
+}
 
-	cfg := &Config{}
+// Latency returns the fixed injected delay as a duration.
+func (c ChaosConfig) Latency() time.Duration {
+	return time.Duration(c.LatencyMs) * time.Millisecond
+}
+
+// Jitter returns the maximum random extra delay as a duration.
+func (c ChaosConfig) Jitter() time.Duration {
+	return time.Duration(c.JitterMs) * time.Millisecond
+}
+
+// FeaturesConfig declares feature flags and their default state.
+// Flags have no env override; per-request overrides go through the
+// X-Feature-Override header (non-production, or admin callers in production).
+type FeaturesConfig struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// ActivityConfig controls last_seen_at tracking for authenticated users and
+// the login history.
+type ActivityConfig struct {
+	Enabled         bool `json:"enabled" env:"ACTIVITY_ENABLED"`
+	DebounceSeconds int  `json:"debounce_seconds" env:"ACTIVITY_DEBOUNCE_SECONDS"`
+	// LoginHistorySize is how many recent logins are kept per user; 0 keeps
+	// none and only records last_login_at. Logins are recorded whether or
+	// not Enabled is set.
+	LoginHistorySize int `json:"login_history_size" env:"ACTIVITY_LOGIN_HISTORY_SIZE"`
+}
+
+// Debounce returns the minimum interval between last_seen_at writes per user.
+func (c ActivityConfig) Debounce() time.Duration {
+	return time.Duration(c.DebounceSeconds) * time.Second
+}
 
+type UsersConfig struct {
+	// GmailAliases treats Gmail dot and "+tag" spellings of an address as one
+	// account (see domain.NormalizeEmail). Addresses are always compared
+	// case-insensitively.
+	GmailAliases bool `json:"gmail_aliases" env:"USERS_GMAIL_ALIASES"`
+	// Events publishes user lifecycle events to the queue
+	Events UserEventsConfig `json:"events"`
+}
+
+// UserEventsConfig configures user lifecycle events (user.created,
+// user.updated, user.deactivated), published to a topic exchange with the
+// event type as the routing key.
+type UserEventsConfig struct {
+	Enabled bool `json:"enabled" env:"USERS_EVENTS_ENABLED"`
+	// Exchange is declared at startup as a durable topic exchange.
+	Exchange string `json:"exchange" env:"USERS_EVENTS_EXCHANGE"`
+}
+
+// Load reads configuration from JSON file and applies environment variable overrides
+func Load(path string) (*Config, error) {
 	// Read JSON config file
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	return parse(data)
+}
+
+// LoadOrDefault is Load, except that when path does not exist the config is
+// read from defaults instead, normally the config.default.json embedded in
+// the binary. fromDisk reports whether path was used.
+func LoadOrDefault(path string, defaults []byte) (cfg *Config, fromDisk bool, err error) {
+	data, fromDisk, err := assets.File(path, defaults)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg, err = parse(data)
+	return cfg, fromDisk, err
+}
+
+// parse decodes a JSON config and applies environment variable overrides
+func parse(data []byte) (*Config, error) {
+	_ = godotenv.Load() // Load .env file if it exists (silently ignore if missing)
 
+	cfg := &Config{}
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	// Apply environment variable overrides
-	applyEnvOverrides(cfg)
+	// The file already parsed above, so this cannot fail.
+	var raw map[string]any
+	_ = json.Unmarshal(data, &raw)
 
-	return cfg, nil
-}
+	// Bare-number durations are in each field's legacy unit
+	applyDurationUnits(cfg, raw)
 
-// applyEnvOverrides recursively applies environment variable overrides to config struct
-func applyEnvOverrides(cfg interface{}) {
-	v := reflect.ValueOf(cfg)
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
-	}
+	// Apply environment variable overrides
+	cfg.envReport = applyEnvOverrides(cfg)
+	cfg.envReport.Unknown = unknownEnvVars(cfg, os.Environ())
 
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := t.Field(i)
-
-		// Handle nested structs
-		if field.Kind() == reflect.Struct {
-			applyEnvOverrides(field.Addr().Interface())
-			continue
-		}
-
-		// Get env tag
-		envTag := fieldType.Tag.Get("env")
-		if envTag == "" {
-			continue
-		}
-
-		// Get environment variable value
-		envValue := os.Getenv(envTag)
-		if envValue == "" {
-			continue
-		}
-
-		// Set field value based on type
-		if !field.CanSet() {
-			continue
-		}
-
-		switch field.Kind() {
-		case reflect.String:
-			field.SetString(envValue)
-		case reflect.Int, reflect.Int64:
-			if intVal, err := strconv.ParseInt(envValue, 10, 64); err == nil {
-				field.SetInt(intVal)
-			}
-		case reflect.Bool:
-			field.SetBool(strings.ToLower(envValue) == "true" || envValue == "1")
-		case reflect.Slice:
-			if field.Type().Elem().Kind() == reflect.String {
-				parts := strings.Split(envValue, ",")
-				result := make([]string, 0, len(parts))
-				for _, p := range parts {
-					if v := strings.TrimSpace(p); v != "" {
-						result = append(result, v)
-					}
-				}
-				field.Set(reflect.ValueOf(result))
-			}
-		}
+	applied := make(map[string]bool)
+	for _, name := range cfg.envReport.Applied {
+		applied[name] = true
 	}
+
+	cfg.sources = resolveSources(cfg, raw, applied)
+
+	return cfg, nil
 }
 
 // PlaceholderJWTSecret is the insecure placeholder shipped in
@@ -281,32 +1008,23 @@ const (
 	MinJWTSecretLen      = 32
 )
 
-// Validate enforces secure-defaults invariants on the loaded configuration.
-// It MUST be called before any adapter is constructed.
-func (c *Config) Validate() error {
-	if c.JWT.Secret == "" {
-		return fmt.Errorf("jwt.secret is required: set the JWT_SECRET env override to a value of at least %d bytes", MinJWTSecretLen)
-	}
-	if c.JWT.Secret == PlaceholderJWTSecret {
-		return fmt.Errorf("jwt.secret is the committed placeholder %q: set the JWT_SECRET env override to a real value of at least %d bytes", PlaceholderJWTSecret, MinJWTSecretLen)
-	}
-	if len(c.JWT.Secret) < MinJWTSecretLen {
-		return fmt.Errorf("jwt.secret is %d bytes; minimum is %d: set the JWT_SECRET env override to a longer value", len(c.JWT.Secret), MinJWTSecretLen)
-	}
-	if c.JWT.Issuer == "" {
-		return fmt.Errorf("jwt.issuer is required: set JWT_ISSUER to the service identifier (e.g. \"goscratch\")")
-	}
-	if c.JWT.Audience == "" {
-		return fmt.Errorf("jwt.audience is required: set JWT_AUDIENCE to the expected audience (e.g. \"goscratch-api\")")
-	}
-	return nil
-}
-
 // IsDevelopment returns true if the app is running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.App.Env == "development"
 }
 
+// QueueBroker returns the enabled queue broker: "rabbitmq", "nats", or ""
+// when neither is.
+func (c *Config) QueueBroker() string {
+	switch {
+	case c.RabbitMQ.Enabled:
+		return "rabbitmq"
+	case c.NATS.Enabled:
+		return "nats"
+	}
+	return ""
+}
+
 // IsProduction returns true if the app is running in production mode
 func (c *Config) IsProduction() bool {
 	return c.App.Env == "production"
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -99,8 +100,8 @@ func TestLoad_ValidJSON(t *testing.T) {
 	assert.Equal(t, "goscratch", cfg.Database.Name)
 	assert.Equal(t, "disable", cfg.Database.SSLMode)
 	assert.Equal(t, "my-jwt-secret", cfg.JWT.Secret)
-	assert.Equal(t, 15, cfg.JWT.AccessTokenTTL)
-	assert.Equal(t, 10080, cfg.JWT.RefreshTokenTTL)
+	assert.Equal(t, 15*time.Minute, cfg.JWT.AccessTokenDuration())
+	assert.Equal(t, 7*24*time.Hour, cfg.JWT.RefreshTokenDuration())
 	assert.True(t, cfg.Redis.Enabled)
 	assert.Equal(t, 6379, cfg.Redis.Port)
 	assert.False(t, cfg.RabbitMQ.Enabled)
@@ -120,6 +121,25 @@ func TestLoad_InvalidJSON(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to parse config file")
 }
 
+func TestLoadOrDefault(t *testing.T) {
+	defaults := []byte(`{"app": {"name": "embedded"}}`)
+
+	cfg, fromDisk, err := LoadOrDefault(filepath.Join(t.TempDir(), "config.json"), defaults)
+	require.NoError(t, err)
+	assert.False(t, fromDisk)
+	assert.Equal(t, "embedded", cfg.App.Name)
+
+	cfg, fromDisk, err = LoadOrDefault(writeTempConfig(t, validConfigJSON), defaults)
+	require.NoError(t, err)
+	assert.True(t, fromDisk)
+	assert.Equal(t, "goscratch", cfg.App.Name)
+
+	t.Setenv("APP_NAME", "from-env")
+	cfg, _, err = LoadOrDefault(filepath.Join(t.TempDir(), "config.json"), defaults)
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.App.Name, "env overrides apply to the embedded config")
+}
+
 func TestApplyEnvOverrides_String(t *testing.T) {
 	path := writeTempConfig(t, validConfigJSON)
 	t.Setenv("APP_NAME", "overridden-name")
@@ -222,11 +242,8 @@ func TestValidate_RejectsEmptyJWTSecret(t *testing.T) {
 
 func TestValidate_AcceptsLongUniqueJWTSecret(t *testing.T) {
 	// 32 bytes, not the placeholder, with non-empty issuer and audience.
-	cfg := &Config{JWT: JWTConfig{
-		Secret:   "a-32-byte-real-secret-xxxxxxxxxx",
-		Issuer:   "goscratch",
-		Audience: "goscratch-api",
-	}}
+	cfg := validConfig()
+	cfg.JWT.Secret = "a-32-byte-real-secret-xxxxxxxxxx"
 	require.Len(t, cfg.JWT.Secret, MinJWTSecretLen)
 	require.NoError(t, cfg.Validate())
 }
@@ -255,9 +272,48 @@ func TestValidate_RejectsEmptyAudience(t *testing.T) {
 
 func TestJWTDurations(t *testing.T) {
 	j := JWTConfig{
-		AccessTokenTTL:  15,
-		RefreshTokenTTL: 10080,
+		AccessTokenTTL:  Duration(15 * time.Minute),
+		RefreshTokenTTL: Duration(7 * 24 * time.Hour),
 	}
 	assert.Equal(t, "15m0s", j.AccessTokenDuration().String())
 	assert.Equal(t, "168h0m0s", j.RefreshTokenDuration().String())
+	assert.Equal(t, "15m0s", j.ImpersonationDuration().String(), "defaults to the access token TTL")
+
+	j.ImpersonationTTL = Duration(5 * time.Minute)
+	assert.Equal(t, "5m0s", j.ImpersonationDuration().String())
+}
+
+func TestApplyEnvOverrides_Float(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	t.Setenv("CHAOS_ERROR_RATE", "0.25")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, cfg.Chaos.ErrorRate, 1e-9)
+}
+
+func TestValidate_RejectsChaosInProduction(t *testing.T) {
+	cfg := validConfig()
+	cfg.App.Env = "production"
+	cfg.Chaos.Enabled = true
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CHAOS_ENABLED")
+
+	cfg.App.Env = "development"
+	require.NoError(t, cfg.Validate())
+}
+
+func TestValidate_RejectsChaosErrorRateOutOfRange(t *testing.T) {
+	cfg := &Config{
+		JWT: JWTConfig{
+			Secret:   "a-32-byte-real-secret-xxxxxxxxxx",
+			Issuer:   "goscratch",
+			Audience: "goscratch-api",
+		},
+		Chaos: ChaosConfig{Enabled: true, ErrorRate: 1.5},
+	}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chaos.error_rate")
 }
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Source says where an effective config value came from.
+type Source string
+
+const (
+	SourceDefault Source = "default" // not set in the file nor the environment (zero value)
+	SourceFile    Source = "file"    // set in the JSON config file
+	SourceEnv     Source = "env"     // overridden by an environment variable (or .env)
+)
+
+// redactedValue replaces non-empty secrets in Settings output.
+const redactedValue = "[REDACTED]"
+
+// Setting is one leaf of the effective configuration.
+type Setting struct {
+	Key    string `json:"key"` // dotted JSON path, e.g. "database.password"
+	Value  any    `json:"value"`
+	Source Source `json:"source"`
+	Env    string `json:"env,omitempty"` // env var that can override it
+}
+
+// Settings returns every leaf of the effective configuration in key order,
+// with secrets redacted and the source of each value. Fields tagged
+// `secret:"true"` are replaced by "[REDACTED]" when set (an empty secret is
+// shown as "" so "not configured" stays visible); `secret:"url"` keeps the URL
+// but masks its password.
+func (c *Config) Settings() []Setting {
+	var out []Setting
+	walkLeaves(reflect.ValueOf(c).Elem(), "", func(key string, f reflect.StructField, v reflect.Value) {
+		source, ok := c.sources[key]
+		if !ok {
+			source = SourceDefault
+		}
+		out = append(out, Setting{
+			Key:    key,
+			Value:  redact(f, v),
+			Source: source,
+			Env:    f.Tag.Get("env"),
+		})
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// resolveSources records, per leaf key, whether its value came from the
+// environment, the file, or neither. raw is the config file decoded into
+// generic maps; applied holds the env var names that were applied.
+func resolveSources(cfg *Config, raw map[string]any, applied map[string]bool) map[string]Source {
+	sources := make(map[string]Source)
+	walkLeaves(reflect.ValueOf(cfg).Elem(), "", func(key string, f reflect.StructField, _ reflect.Value) {
+		switch {
+		case applied[f.Tag.Get("env")]:
+			sources[key] = SourceEnv
+		case hasPath(raw, key):
+			sources[key] = SourceFile
+		default:
+			sources[key] = SourceDefault
+		}
+	})
+	return sources
+}
+
+// walkLeaves calls fn for every exported non-struct field, keyed by the
+// dotted path of JSON names.
+func walkLeaves(v reflect.Value, prefix string, fn func(key string, f reflect.StructField, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			walkLeaves(v.Field(i), key, fn)
+			continue
+		}
+		fn(key, f, v.Field(i))
+	}
+}
+
+func hasPath(raw map[string]any, key string) bool {
+	_, ok := lookup(raw, key)
+	return ok
+}
+
+// lookupPath returns the value at a dotted key in raw, or nil.
+func lookupPath(raw map[string]any, key string) any {
+	v, _ := lookup(raw, key)
+	return v
+}
+
+func lookup(raw map[string]any, key string) (any, bool) {
+	var cur any = raw
+	for _, part := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func redact(f reflect.StructField, v reflect.Value) any {
+	mode := f.Tag.Get("secret")
+	if mode == "" || v.Kind() != reflect.String {
+		return v.Interface()
+	}
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	if mode == "url" {
+		if u, err := url.Parse(s); err == nil && u.Scheme != "" {
+			return u.Redacted()
+		}
+	}
+	return redactedValue
+}
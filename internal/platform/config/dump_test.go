@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func settingsByKey(t *testing.T, cfg *Config) map[string]Setting {
+	t.Helper()
+	out := make(map[string]Setting)
+	for _, s := range cfg.Settings() {
+		out[s.Key] = s
+	}
+	return out
+}
+
+func TestSettings_Sources(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	t.Setenv("SERVER_PORT", "8080")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	settings := settingsByKey(t, cfg)
+
+	assert.Equal(t, SourceEnv, settings["server.port"].Source)
+	assert.Equal(t, 8080, settings["server.port"].Value)
+	assert.Equal(t, "SERVER_PORT", settings["server.port"].Env)
+
+	assert.Equal(t, SourceFile, settings["server.host"].Source)
+	assert.Equal(t, SourceFile, settings["storage.local.base_path"].Source)
+
+	// Not present in validConfigJSON.
+	assert.Equal(t, SourceDefault, settings["email.host"].Source)
+}
+
+func TestSettings_SortedByKey(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	settings := cfg.Settings()
+	require.NotEmpty(t, settings)
+	for i := 1; i < len(settings); i++ {
+		assert.Less(t, settings[i-1].Key, settings[i].Key)
+	}
+}
+
+func TestSettings_RedactsSecrets(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	t.Setenv("RABBITMQ_URL", "amqp://app:hunter2@mq:5672/")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	settings := settingsByKey(t, cfg)
+
+	assert.Equal(t, redactedValue, settings["database.password"].Value)
+	assert.Equal(t, redactedValue, settings["jwt.secret"].Value)
+	assert.Equal(t, "", settings["redis.password"].Value, "empty secrets stay visible as unset")
+	assert.Equal(t, "amqp://app:xxxxx@mq:5672/", settings["rabbitmq.url"].Value)
+
+	raw, err := json.Marshal(cfg.Settings())
+	require.NoError(t, err)
+	for _, secret := range []string{"my-jwt-secret", "hunter2", `"secret"`} {
+		assert.False(t, strings.Contains(string(raw), secret), "dump leaks %s", secret)
+	}
+}
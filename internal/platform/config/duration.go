@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration that the config file and env vars can give
+// either as a Go duration string ("15m", "90s", "1h30m") or, for backward
+// compatibility, as a bare number in the field's legacy unit. The legacy unit
+// is declared with a `unit` struct tag ("ms", "s", "m" or "h"; default "s"),
+// so `"access_token_ttl": 15` still means 15 minutes.
+type Duration time.Duration
+
+// String formats the duration like time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON encodes the duration as a Go duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a duration string or a number. A number is read as
+// seconds here; Load rescales it for fields with another `unit` tag.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := parseDuration(s, time.Second)
+		if err != nil {
+			return err
+		}
+		*d = v
+		return nil
+	}
+	var n float64
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("duration must be a string like \"15m\" or a number, got %s", b)
+	}
+	*d = Duration(n * float64(time.Second))
+	return nil
+}
+
+// parseDuration parses a Go duration string, or a bare number in unit.
+func parseDuration(s string, unit time.Duration) (Duration, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return Duration(n * float64(unit)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use Go syntax, e.g. \"15m\", or a number)", s)
+	}
+	return Duration(d), nil
+}
+
+var (
+	configDurationType = reflect.TypeOf(Duration(0))
+
+	durationUnits = map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+	}
+)
+
+// durationUnit returns the legacy unit declared on f, defaulting to seconds.
+func durationUnit(f reflect.StructField) time.Duration {
+	if u, ok := durationUnits[f.Tag.Get("unit")]; ok {
+		return u
+	}
+	return time.Second
+}
+
+// applyDurationUnits rescales Duration fields that the config file gave as a
+// bare number: UnmarshalJSON read them as seconds, but the field's `unit` tag
+// may say otherwise. raw is the config file decoded into generic maps.
+func applyDurationUnits(cfg *Config, raw map[string]any) {
+	walkLeaves(reflect.ValueOf(cfg).Elem(), "", func(key string, f reflect.StructField, v reflect.Value) {
+		if f.Type != configDurationType {
+			return
+		}
+		if n, ok := lookupPath(raw, key).(float64); ok {
+			v.SetInt(int64(n * float64(durationUnit(f))))
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{`"15m"`, 15 * time.Minute},
+		{`"1h30m"`, 90 * time.Minute},
+		{`"0"`, 0},
+		{`30`, 30 * time.Second},
+		{`1.5`, 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		var d Duration
+		require.NoError(t, json.Unmarshal([]byte(tt.in), &d), tt.in)
+		assert.Equal(t, tt.want, time.Duration(d), tt.in)
+	}
+
+	var d Duration
+	assert.Error(t, json.Unmarshal([]byte(`"15 minutes"`), &d))
+	assert.Error(t, json.Unmarshal([]byte(`true`), &d))
+}
+
+func TestDuration_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(Duration(90 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, `"1m30s"`, string(b))
+}
+
+func TestLoad_NumericDurationsKeepLegacyUnits(t *testing.T) {
+	// validConfigJSON uses the pre-Duration numeric form.
+	path := writeTempConfig(t, validConfigJSON)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 15*time.Minute, cfg.JWT.AccessTokenDuration())
+	assert.Equal(t, 10080*time.Minute, cfg.JWT.RefreshTokenDuration())
+	assert.Equal(t, 10*time.Second, time.Duration(cfg.Server.ReadTimeout))
+	assert.Equal(t, 30*time.Second, time.Duration(cfg.Server.IdleTimeout))
+	assert.Equal(t, 300*time.Second, time.Duration(cfg.Database.ConnMaxLifetime))
+}
+
+func TestLoad_StringDurations(t *testing.T) {
+	content := strings.NewReplacer(
+		`"access_token_ttl": 15`, `"access_token_ttl": "30s"`,
+		`"read_timeout": 10`, `"read_timeout": "2m"`,
+		`"conn_max_lifetime": 300`, `"conn_max_lifetime": "1h"`,
+	).Replace(validConfigJSON)
+	path := writeTempConfig(t, content)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.JWT.AccessTokenDuration())
+	assert.Equal(t, 2*time.Minute, time.Duration(cfg.Server.ReadTimeout))
+	assert.Equal(t, time.Hour, time.Duration(cfg.Database.ConnMaxLifetime))
+}
+
+func TestLoad_InvalidDurationString(t *testing.T) {
+	content := strings.Replace(validConfigJSON, `"read_timeout": 10`, `"read_timeout": "ten seconds"`, 1)
+	path := writeTempConfig(t, content)
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file")
+}
+
+func TestApplyEnvOverrides_Duration(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	t.Setenv("JWT_ACCESS_TOKEN_TTL", "5")    // legacy: minutes
+	t.Setenv("JWT_REFRESH_TOKEN_TTL", "72h") // duration string
+	t.Setenv("SERVER_WRITE_TIMEOUT", "45")   // legacy: seconds
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.JWT.AccessTokenDuration())
+	assert.Equal(t, 72*time.Hour, cfg.JWT.RefreshTokenDuration())
+	assert.Equal(t, 45*time.Second, time.Duration(cfg.Server.WriteTimeout))
+}
+
+func TestSettings_DurationsRenderAsStrings(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(cfg.Settings())
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `{"key":"jwt.access_token_ttl","value":"15m0s","source":"file","env":"JWT_ACCESS_TOKEN_TTL"}`)
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvReport summarizes what Load did with environment variables. It holds env
+// var names only — never values — so it is safe to log.
+type EnvReport struct {
+	// Applied lists env vars that overrode a config value.
+	Applied []string
+	// Invalid lists env vars that matched a field but could not be parsed,
+	// as "NAME: reason". The file value is kept for these.
+	Invalid []string
+	// Unknown lists env vars that share a prefix with the config's env vars
+	// (DB_, REDIS_, ...) but match no field — usually a typo. Each entry may
+	// carry a "(did you mean X?)" hint.
+	Unknown []string
+}
+
+// EnvReport returns how environment overrides were applied by Load.
+func (c *Config) EnvReport() EnvReport {
+	return c.envReport
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides recursively applies environment variable overrides to the
+// config struct. Supported field types are strings, bools, signed and
+// unsigned ints, floats, time.Duration (Go syntax, e.g. "15m"), Duration (Go
+// syntax or a bare number in the field's `unit`), slices of any of those
+// (comma-separated), and pointers to any of those or to nested structs. A nil pointer is only allocated when an override inside it applies.
+func applyEnvOverrides(cfg interface{}) EnvReport {
+	var report EnvReport
+	v := reflect.ValueOf(cfg)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	applyEnvStruct(v, &report)
+	return report
+}
+
+func applyEnvStruct(v reflect.Value, report *EnvReport) bool {
+	changed := false
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		// Handle nested structs, by value or by pointer
+		if field.Kind() == reflect.Struct {
+			changed = applyEnvStruct(field, report) || changed
+			continue
+		}
+		if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct {
+			target := field
+			if field.IsNil() {
+				target = reflect.New(field.Type().Elem())
+			}
+			if applyEnvStruct(target.Elem(), report) {
+				field.Set(target)
+				changed = true
+			}
+			continue
+		}
+
+		// Get env tag
+		envTag := fieldType.Tag.Get("env")
+		if envTag == "" {
+			continue
+		}
+
+		// Get environment variable value
+		envValue := os.Getenv(envTag)
+		if envValue == "" {
+			continue
+		}
+
+		if err := setFromEnv(field, envValue, durationUnit(fieldType)); err != nil {
+			report.Invalid = append(report.Invalid, fmt.Sprintf("%s: %v", envTag, err))
+			continue
+		}
+		report.Applied = append(report.Applied, envTag)
+		changed = true
+	}
+	return changed
+}
+
+// setFromEnv parses raw into field. field is left untouched on error. unit is
+// the legacy unit for bare numbers given to a config.Duration.
+func setFromEnv(field reflect.Value, raw string, unit time.Duration) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setFromEnv(elem.Elem(), raw, unit); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	case reflect.Slice:
+		parts := strings.Split(raw, ",")
+		result := reflect.MakeSlice(field.Type(), 0, len(parts))
+		for _, p := range parts {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setFromEnv(elem, p, unit); err != nil {
+				return err
+			}
+			result = reflect.Append(result, elem)
+		}
+		field.Set(result)
+		return nil
+	}
+	return setScalar(field, raw, unit)
+}
+
+func setScalar(field reflect.Value, raw string, unit time.Duration) error {
+	if field.Type() == configDurationType {
+		d, err := parseDuration(raw, unit)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (use Go syntax, e.g. \"15m\")", raw)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// envTags returns every env tag declared on cfg, recursing into nested and
+// pointer structs.
+func envTags(t reflect.Type) []string {
+	var tags []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			tags = append(tags, envTags(ft)...)
+			continue
+		}
+		if tag := f.Tag.Get("env"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// unknownEnvVars returns the names in environ ("NAME=value" pairs) that share
+// a prefix with one of cfg's env tags but match none of them, sorted.
+func unknownEnvVars(cfg *Config, environ []string) []string {
+	known := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	for _, tag := range envTags(reflect.TypeOf(cfg).Elem()) {
+		known[tag] = true
+		if p, _, ok := strings.Cut(tag, "_"); ok {
+			prefixes[p+"_"] = true
+		}
+	}
+
+	var unknown []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if known[name] {
+			continue
+		}
+		p, _, ok := strings.Cut(name, "_")
+		if !ok || !prefixes[p+"_"] {
+			continue
+		}
+		if hint := closestEnvTag(name, known); hint != "" {
+			name = fmt.Sprintf("%s (did you mean %s?)", name, hint)
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// closestEnvTag returns the known tag within edit distance 2 of name, if any.
+func closestEnvTag(name string, known map[string]bool) string {
+	best, bestDist := "", 3
+	for tag := range known {
+		if d := editDistance(name, tag); d < bestDist || (d == bestDist && best != "" && tag < best) {
+			best, bestDist = tag, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
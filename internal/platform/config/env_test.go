@@ -0,0 +1,114 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envFixture exercises the field kinds Config does not use yet.
+type envFixture struct {
+	Timeout  time.Duration   `env:"FIX_TIMEOUT"`
+	Ratio    float32         `env:"FIX_RATIO"`
+	Count    uint16          `env:"FIX_COUNT"`
+	Ports    []int           `env:"FIX_PORTS"`
+	Waits    []time.Duration `env:"FIX_WAITS"`
+	MaxConns *int            `env:"FIX_MAX_CONNS"`
+	Nested   *envNested
+	Unused   *envUnused
+}
+
+type envNested struct {
+	Host string `env:"FIX_NESTED_HOST"`
+}
+
+type envUnused struct {
+	Port int `env:"FIX_UNUSED_PORT"`
+}
+
+func TestApplyEnvOverrides_ExtendedTypes(t *testing.T) {
+	t.Setenv("FIX_TIMEOUT", "15m")
+	t.Setenv("FIX_RATIO", "0.5")
+	t.Setenv("FIX_COUNT", "42")
+	t.Setenv("FIX_PORTS", "80, 443,")
+	t.Setenv("FIX_WAITS", "1s,250ms")
+	t.Setenv("FIX_MAX_CONNS", "7")
+	t.Setenv("FIX_NESTED_HOST", "db.internal")
+
+	var fx envFixture
+	report := applyEnvOverrides(&fx)
+
+	assert.Equal(t, 15*time.Minute, fx.Timeout)
+	assert.InDelta(t, 0.5, fx.Ratio, 1e-6)
+	assert.Equal(t, uint16(42), fx.Count)
+	assert.Equal(t, []int{80, 443}, fx.Ports)
+	assert.Equal(t, []time.Duration{time.Second, 250 * time.Millisecond}, fx.Waits)
+	require.NotNil(t, fx.MaxConns)
+	assert.Equal(t, 7, *fx.MaxConns)
+	require.NotNil(t, fx.Nested, "pointer struct is allocated when an override inside applies")
+	assert.Equal(t, "db.internal", fx.Nested.Host)
+	assert.Nil(t, fx.Unused, "pointer struct stays nil when nothing inside applies")
+
+	assert.Len(t, report.Applied, 7)
+	assert.Empty(t, report.Invalid)
+}
+
+func TestApplyEnvOverrides_InvalidValuesAreReported(t *testing.T) {
+	t.Setenv("FIX_TIMEOUT", "15 minutes")
+	t.Setenv("FIX_COUNT", "-1")
+	t.Setenv("FIX_PORTS", "80,https")
+
+	fx := envFixture{Timeout: time.Second, Ports: []int{8080}}
+	report := applyEnvOverrides(&fx)
+
+	assert.Empty(t, report.Applied)
+	require.Len(t, report.Invalid, 3)
+	assert.Contains(t, report.Invalid[0], "FIX_TIMEOUT")
+	assert.Equal(t, time.Second, fx.Timeout, "invalid values keep the previous value")
+	assert.Equal(t, []int{8080}, fx.Ports)
+}
+
+func TestApplyEnvOverrides_InvalidBoolKeepsFileValue(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	t.Setenv("REDIS_ENABLED", "yes please")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Redis.Enabled)
+	assert.Equal(t, []string{`REDIS_ENABLED: invalid bool "yes please"`}, cfg.EnvReport().Invalid)
+}
+
+func TestLoad_EnvReport(t *testing.T) {
+	path := writeTempConfig(t, validConfigJSON)
+	t.Setenv("APP_NAME", "x")
+	t.Setenv("DB_HOSTT", "typo")
+	t.Setenv("REDIS_CLUSTER_MODE", "on")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	report := cfg.EnvReport()
+
+	assert.Contains(t, report.Applied, "APP_NAME")
+	assert.Contains(t, report.Unknown, "DB_HOSTT (did you mean DB_HOST?)")
+	assert.Contains(t, report.Unknown, "REDIS_CLUSTER_MODE")
+}
+
+func TestUnknownEnvVars_IgnoresForeignPrefixes(t *testing.T) {
+	unknown := unknownEnvVars(&Config{}, []string{
+		"PATH=/usr/bin",
+		"HOME=/root",
+		"CONFIG_PATH=config.json",
+		"DB_HOST=localhost",
+		"JWT_SECRETS=x",
+	})
+	assert.Equal(t, []string{"JWT_SECRETS (did you mean JWT_SECRET?)"}, unknown)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("DB_HOST", "DB_HOST"))
+	assert.Equal(t, 1, editDistance("DB_HOSTT", "DB_HOST"))
+	assert.Equal(t, 2, editDistance("REDIS_PROT", "REDIS_PORT"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
@@ -0,0 +1,921 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/cron"
+)
+
+// ValidationError lists every problem found by Validate, so an operator can
+// fix the whole configuration in one pass instead of one restart per field.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error renders the problems as a single multi-line report.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration problem(s):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// lokiLabelName matches the label names Loki accepts
+var lokiLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validSSLModes are the sslmode values libpq (and pgx) accept.
+var validSSLModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// Validate enforces secure-defaults invariants and per-feature required
+// fields on the loaded configuration. It MUST be called before any adapter is
+// constructed. All problems are collected and returned together as a
+// *ValidationError; each one names the key and the env var that fixes it.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	c.validateJWT(v)
+
+	v.port("server.port", "SERVER_PORT", c.Server.Port)
+	v.nonNegativeDuration("server.read_timeout", "SERVER_READ_TIMEOUT", c.Server.ReadTimeout)
+	v.nonNegativeDuration("server.write_timeout", "SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout)
+	v.nonNegativeDuration("server.idle_timeout", "SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout)
+	v.nonNegativeDuration("server.disconnect_poll", "SERVER_DISCONNECT_POLL", c.Server.DisconnectPoll)
+
+	v.required("database.host", "DB_HOST", c.Database.Host)
+	v.port("database.port", "DB_PORT", c.Database.Port)
+	v.required("database.user", "DB_USER", c.Database.User)
+	v.required("database.name", "DB_NAME", c.Database.Name)
+	if c.Database.SSLMode != "" && !validSSLModes[c.Database.SSLMode] {
+		v.addf("database.ssl_mode is %q; must be one of disable, allow, prefer, require, verify-ca, verify-full (DB_SSL_MODE)", c.Database.SSLMode)
+	}
+	v.nonNegative("database.max_open_conns", "DB_MAX_OPEN_CONNS", c.Database.MaxOpenConns)
+	v.nonNegativeDuration("database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime)
+	v.nonNegative("database.statement_timeout_ms", "DB_STATEMENT_TIMEOUT_MS", c.Database.StatementTimeoutMs)
+	v.nonNegative("database.idle_in_transaction_timeout_ms", "DB_IDLE_IN_TX_TIMEOUT_MS", c.Database.IdleInTxTimeoutMs)
+	v.nonNegative("database.tx_max_attempts", "DB_TX_MAX_ATTEMPTS", c.Database.TxMaxAttempts)
+	v.nonNegativeDuration("database.tx_retry_backoff", "DB_TX_RETRY_BACKOFF", c.Database.TxRetryBackoff)
+	v.nonNegative("database.query_timeout_ms", "DB_QUERY_TIMEOUT_MS", c.Database.QueryTimeoutMs)
+
+	if c.Redis.Enabled {
+		v.required("redis.host", "REDIS_HOST", c.Redis.Host)
+		v.port("redis.port", "REDIS_PORT", c.Redis.Port)
+		v.nonNegative("redis.db", "REDIS_DB", c.Redis.DB)
+	}
+
+	if c.RabbitMQ.Enabled {
+		if v.required("rabbitmq.url", "RABBITMQ_URL", c.RabbitMQ.URL) {
+			if u, err := url.Parse(c.RabbitMQ.URL); err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+				v.addf("rabbitmq.url must be an amqp:// or amqps:// URL (RABBITMQ_URL)")
+			}
+		}
+		v.nonNegative("rabbitmq.prefetch_count", "RABBITMQ_PREFETCH_COUNT", c.RabbitMQ.PrefetchCount)
+	}
+	if c.NATS.Enabled {
+		c.validateNATS(v)
+	}
+
+	switch c.Storage.Mode {
+	case "", "local":
+		v.required("storage.local.base_path", "STORAGE_LOCAL_PATH", c.Storage.Local.BasePath)
+	case "s3", "both":
+		v.required("storage.s3.bucket", "S3_BUCKET", c.Storage.S3.Bucket)
+		v.required("storage.s3.region", "S3_REGION", c.Storage.S3.Region)
+		// S3 falls back to local storage on init failure.
+		v.required("storage.local.base_path", "STORAGE_LOCAL_PATH", c.Storage.Local.BasePath)
+	default:
+		v.addf("storage.mode is %q; must be \"local\", \"s3\" or \"both\" (STORAGE_MODE)", c.Storage.Mode)
+	}
+
+	if c.Email.Enabled {
+		v.required("email.host", "EMAIL_HOST", c.Email.Host)
+		v.port("email.port", "EMAIL_PORT", c.Email.Port)
+		v.required("email.from", "EMAIL_FROM", c.Email.From)
+		c.validateEmailTenants(v)
+	}
+
+	if c.RateLimit.Enabled {
+		v.positive("rate_limit.max", "RATE_LIMIT_MAX", c.RateLimit.Max)
+		v.positive("rate_limit.window_sec", "RATE_LIMIT_WINDOW_SEC", c.RateLimit.WindowSec)
+	}
+
+	if c.Observability.Metrics.Enabled {
+		if v.port("observability.metrics.port", "METRICS_PORT", c.Observability.Metrics.Port) &&
+			c.Observability.Metrics.Port == c.Server.Port {
+			v.addf("observability.metrics.port must differ from server.port (both %d) (METRICS_PORT)", c.Server.Port)
+		}
+	}
+	if c.Observability.DBStats.Enabled {
+		v.nonNegative("observability.db_stats.warn_queries", "DB_STATS_WARN_QUERIES", c.Observability.DBStats.WarnQueries)
+	}
+	if c.Observability.Tracing.Enabled {
+		v.required("observability.tracing.endpoint", "TRACING_ENDPOINT", c.Observability.Tracing.Endpoint)
+	}
+	for key := range c.Observability.ResourceAttributes {
+		// "__" label names are reserved by Prometheus.
+		if strings.TrimSpace(key) == "" || strings.HasPrefix(key, "__") {
+			v.addf("observability.resource_attributes key %q must be non-empty and not start with \"__\" (config file)", key)
+		}
+	}
+
+	if c.Bulkhead.Enabled {
+		c.validateBulkheads(v)
+	}
+	if c.LoadShed.Enabled {
+		c.validateLoadShed(v)
+	}
+	if c.Watchdog.Enabled {
+		c.validateWatchdog(v)
+	}
+	if c.PasswordReset.Enabled {
+		c.validatePasswordReset(v)
+	}
+	if c.Passkey.Enabled {
+		c.validatePasskey(v)
+	}
+	if c.OAuth.Enabled {
+		c.validateOAuth(v)
+	}
+	if c.Auth.AllowRegistration {
+		c.validateRegistration(v)
+	}
+	if c.APIKey.Enabled {
+		c.validateAPIKey(v)
+	}
+	if c.Security.LoginThrottle.Enabled {
+		c.validateLoginThrottle(v)
+	}
+	if c.EmailVerification.Enabled {
+		c.validateEmailVerification(v)
+	} else if c.EmailVerification.RequireForLogin {
+		v.addf("email_verification.require_for_login needs email_verification.enabled, or nobody could verify and log in (EMAIL_VERIFICATION_REQUIRED)")
+	}
+	if c.EmailChange.Enabled {
+		c.validateEmailChange(v)
+	}
+	if c.Users.Events.Enabled {
+		v.required("users.events.exchange", "USERS_EVENTS_EXCHANGE", c.Users.Events.Exchange)
+		if !c.RabbitMQ.Enabled {
+			v.addf("users.events.enabled needs rabbitmq.enabled, or events would be dropped (USERS_EVENTS_ENABLED)")
+		}
+	}
+	if c.SSE.Enabled {
+		v.nonNegative("sse.replay_size", "SSE_REPLAY_SIZE", c.SSE.ReplaySize)
+		v.nonNegativeDuration("sse.poll_timeout", "SSE_POLL_TIMEOUT", c.SSE.PollTimeout)
+		v.nonNegativeDuration("sse.ticket_ttl", "SSE_TICKET_TTL", c.SSE.TicketTTL)
+		c.validateTopicPermissions(v)
+	}
+	if c.Audit.Export.Enabled {
+		c.validateAuditExport(v)
+	}
+	if a := c.Audit.Async; a.Enabled {
+		v.nonNegative("audit.async.buffer_size", "AUDIT_ASYNC_BUFFER_SIZE", a.BufferSize)
+		v.nonNegative("audit.async.batch_size", "AUDIT_ASYNC_BATCH_SIZE", a.BatchSize)
+		v.nonNegativeDuration("audit.async.flush_interval", "AUDIT_ASYNC_FLUSH_INTERVAL", a.FlushInterval)
+	}
+	if h := c.Audit.HTTP; h.Enabled {
+		for _, p := range append(slices.Clone(h.Include), h.Exclude...) {
+			if !strings.HasPrefix(p, "/") {
+				v.addf("audit.http has path prefix %q; prefixes start with / (AUDIT_HTTP_INCLUDE, AUDIT_HTTP_EXCLUDE)", p)
+			}
+		}
+	}
+	if r := c.Audit.Reads; r.Enabled && (r.SampleRate <= 0 || r.SampleRate > 1) {
+		v.addf("audit.reads.sample_rate is %v; must be above 0 and at most 1 (AUDIT_READS_SAMPLE_RATE)", r.SampleRate)
+	}
+	v.nonNegative("audit.retention.days", "AUDIT_RETENTION_DAYS", c.Audit.Retention.Days)
+	if p := c.Audit.Retention.ArchivePrefix; strings.HasPrefix(p, "/") || slices.Contains(strings.Split(p, "/"), "..") {
+		v.addf("audit.retention.archive_prefix is %q; must be a relative path without \"..\" (AUDIT_RETENTION_ARCHIVE_PREFIX)", p)
+	}
+	if c.Honeytoken.Enabled {
+		c.validateHoneytoken(v)
+	}
+	if c.IPRules.Enabled {
+		c.validateIPRules(v)
+	}
+	if c.GeoRestriction.Enabled {
+		c.validateGeoRestriction(v)
+	}
+	if c.Authorization.Enabled {
+		v.nonNegativeDuration("authorization.watch_debounce", "AUTHORIZATION_WATCH_DEBOUNCE", c.Authorization.WatchDebounce)
+		v.nonNegativeDuration("authorization.reload_interval", "AUTHORIZATION_RELOAD_INTERVAL", c.Authorization.ReloadInterval)
+		if c.Authorization.DecisionCache.Enabled {
+			v.positiveDuration("authorization.decision_cache.ttl", "AUTHORIZATION_DECISION_CACHE_TTL", c.Authorization.DecisionCache.TTL)
+			if !c.Redis.Enabled {
+				v.addf("authorization.decision_cache.enabled needs redis.enabled, or nothing would be cached (AUTHORIZATION_DECISION_CACHE_ENABLED)")
+			}
+		}
+	}
+	v.nonNegativeDuration("worker.idempotency_ttl", "WORKER_IDEMPOTENCY_TTL", c.Worker.IdempotencyTTL)
+	c.validateQueuePolicy(v)
+	c.validateCodecs(v)
+	c.validatePriorities(v)
+	if c.Worker.Outbox.Enabled {
+		c.validateOutbox(v)
+	}
+	if c.Scheduler.Enabled {
+		c.validateScheduler(v)
+	}
+	v.nonNegative("api_version.default", "API_VERSION_DEFAULT", c.APIVersion.Default)
+	v.nonNegativeDuration("read_only.refresh", "READ_ONLY_REFRESH", c.ReadOnly.Refresh)
+	for _, p := range c.ReadOnly.Allow {
+		if !strings.HasPrefix(p, "/") {
+			v.addf("read_only.allow entry %q must start with \"/\" (config file)", p)
+		}
+	}
+
+	if c.Chaos.Enabled && c.IsProduction() {
+		v.addf("chaos.enabled must be false in production: unset CHAOS_ENABLED")
+	}
+	if c.Chaos.ErrorRate < 0 || c.Chaos.ErrorRate > 1 {
+		v.addf("chaos.error_rate is %v; must be between 0 and 1 (CHAOS_ERROR_RATE)", c.Chaos.ErrorRate)
+	}
+	v.nonNegative("chaos.latency_ms", "CHAOS_LATENCY_MS", c.Chaos.LatencyMs)
+	v.nonNegative("chaos.jitter_ms", "CHAOS_JITTER_MS", c.Chaos.JitterMs)
+
+	v.nonNegative("activity.debounce_seconds", "ACTIVITY_DEBOUNCE_SECONDS", c.Activity.DebounceSeconds)
+	v.nonNegative("activity.login_history_size", "ACTIVITY_LOGIN_HISTORY_SIZE", c.Activity.LoginHistorySize)
+	v.nonNegative("health.readiness_timeout_sec", "HEALTH_READINESS_TIMEOUT_SEC", c.Health.ReadinessTimeoutSec)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validateJWT checks the signing key and token claims. A bad secret
+// (placeholder or too short) means every issued token is forgeable.
+func (c *Config) validateJWT(v *validator) {
+	switch c.JWT.Algorithm {
+	case "", "HS256":
+		c.validateJWTSecret(v)
+		if len(c.JWT.VerificationKeyFiles) > 0 {
+			v.addf("jwt.verification_key_files is only used with RS256 or ES256; set JWT_ALGORITHM or remove JWT_VERIFICATION_KEY_FILES")
+		}
+	case "RS256", "ES256":
+		// The key files are read, and their type checked, at startup.
+		v.required("jwt.private_key_file", "JWT_PRIVATE_KEY_FILE", c.JWT.PrivateKeyFile)
+	default:
+		v.addf("jwt.algorithm is %q; must be one of HS256, RS256, ES256 (JWT_ALGORITHM)", c.JWT.Algorithm)
+	}
+	if c.JWT.Issuer == "" {
+		v.addf("jwt.issuer is required: set JWT_ISSUER to the service identifier (e.g. \"goscratch\")")
+	}
+	if c.JWT.Audience == "" {
+		v.addf("jwt.audience is required: set JWT_AUDIENCE to the expected audience (e.g. \"goscratch-api\")")
+	}
+	v.positiveDuration("jwt.access_token_ttl", "JWT_ACCESS_TOKEN_TTL", c.JWT.AccessTokenTTL)
+	v.positiveDuration("jwt.refresh_token_ttl", "JWT_REFRESH_TOKEN_TTL", c.JWT.RefreshTokenTTL)
+	v.nonNegative("jwt.max_sessions", "JWT_MAX_SESSIONS", c.JWT.MaxSessions)
+	if v.nonNegativeDuration("jwt.impersonation_ttl", "JWT_IMPERSONATION_TTL", c.JWT.ImpersonationTTL) && c.JWT.ImpersonationTTL > c.JWT.AccessTokenTTL {
+		v.addf("jwt.impersonation_ttl (%s) must not exceed jwt.access_token_ttl (%s) (JWT_IMPERSONATION_TTL)", c.JWT.ImpersonationDuration(), c.JWT.AccessTokenDuration())
+	}
+}
+
+// validateJWTSecret checks the HS256 signing secret.
+func (c *Config) validateJWTSecret(v *validator) {
+	switch {
+	case c.JWT.Secret == "":
+		v.addf("jwt.secret is required: set the JWT_SECRET env override to a value of at least %d bytes", MinJWTSecretLen)
+	case c.JWT.Secret == PlaceholderJWTSecret:
+		v.addf("jwt.secret is the committed placeholder %q: set the JWT_SECRET env override to a real value of at least %d bytes", PlaceholderJWTSecret, MinJWTSecretLen)
+	case len(c.JWT.Secret) < MinJWTSecretLen:
+		v.addf("jwt.secret is %d bytes; minimum is %d: set the JWT_SECRET env override to a longer value", len(c.JWT.Secret), MinJWTSecretLen)
+	}
+}
+
+// maxPasswordResetTTL caps password_reset.token_ttl
+const maxPasswordResetTTL = Duration(24 * time.Hour)
+
+// validatePasswordReset checks the reset link settings. A reset token is as
+// good as the password it replaces, so links may live a day at most.
+func (c *Config) validatePasswordReset(v *validator) {
+	pr := c.PasswordReset
+	c.validateEmailedLink(v, "password_reset", "PASSWORD_RESET", pr.URL, pr.EmailTenant)
+	if pr.TokenTTL < 0 || pr.TokenTTL > maxPasswordResetTTL {
+		v.addf("password_reset.token_ttl is %s; must be between 0 and %s (PASSWORD_RESET_TOKEN_TTL)", pr.TokenTTL, maxPasswordResetTTL)
+	}
+}
+
+// registrationRoles are the roles auth.default_role may grant. Anyone can
+// register, so the admin roles are never handed out this way.
+var registrationRoles = []string{"viewer", "editor"}
+
+// validateRegistration checks the self-registration settings.
+func (c *Config) validateRegistration(v *validator) {
+	if !slices.Contains(registrationRoles, c.Auth.DefaultRole) {
+		v.addf("auth.default_role is %q; must be one of %s (AUTH_DEFAULT_ROLE)", c.Auth.DefaultRole, strings.Join(registrationRoles, ", "))
+	}
+}
+
+// maxEmailVerificationTTL caps email_verification.token_ttl
+const maxEmailVerificationTTL = Duration(7 * 24 * time.Hour)
+
+// validateEmailVerification checks the verification link settings.
+func (c *Config) validateEmailVerification(v *validator) {
+	ev := c.EmailVerification
+	c.validateEmailedLink(v, "email_verification", "EMAIL_VERIFICATION", ev.URL, ev.EmailTenant)
+	if ev.TokenTTL < 0 || ev.TokenTTL > maxEmailVerificationTTL {
+		v.addf("email_verification.token_ttl is %s; must be between 0 and %s (EMAIL_VERIFICATION_TOKEN_TTL)", ev.TokenTTL, maxEmailVerificationTTL)
+	}
+}
+
+// maxEmailChangeTTL caps email_change.token_ttl
+const maxEmailChangeTTL = Duration(7 * 24 * time.Hour)
+
+// validateEmailChange checks the confirmation link settings.
+func (c *Config) validateEmailChange(v *validator) {
+	ec := c.EmailChange
+	c.validateEmailedLink(v, "email_change", "EMAIL_CHANGE", ec.URL, ec.EmailTenant)
+	if ec.TokenTTL < 0 || ec.TokenTTL > maxEmailChangeTTL {
+		v.addf("email_change.token_ttl is %s; must be between 0 and %s (EMAIL_CHANGE_TOKEN_TTL)", ec.TokenTTL, maxEmailChangeTTL)
+	}
+}
+
+// maxPasskeyCeremonyTimeout caps passkey.ceremony_timeout
+const maxPasskeyCeremonyTimeout = Duration(10 * time.Minute)
+
+// validatePasskey checks the relying party settings. Browsers refuse a
+// ceremony whose origin is not the RP ID or one of its subdomains, so such a
+// setup could never work.
+func (c *Config) validatePasskey(v *validator) {
+	pk := c.Passkey
+	rpID := v.required("passkey.rp_id", "PASSKEY_RP_ID", pk.RPID)
+	if rpID && strings.ContainsAny(pk.RPID, ":/") {
+		v.addf("passkey.rp_id is %q; must be a bare domain such as \"example.com\", without scheme or port (PASSKEY_RP_ID)", pk.RPID)
+		rpID = false
+	}
+	v.required("passkey.rp_display_name", "PASSKEY_RP_DISPLAY_NAME", pk.RPDisplayName)
+	if len(pk.Origins) == 0 {
+		v.addf("passkey.origins is required (PASSKEY_ORIGINS)")
+	}
+	for _, origin := range pk.Origins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			v.addf("passkey.origins has %q; each origin must be an absolute http:// or https:// URL without a path (PASSKEY_ORIGINS)", origin)
+			continue
+		}
+		if host := u.Hostname(); rpID && host != pk.RPID && !strings.HasSuffix(host, "."+pk.RPID) {
+			v.addf("passkey.origins has %q, which is not %s or a subdomain of it (PASSKEY_ORIGINS)", origin, pk.RPID)
+		}
+	}
+	if pk.CeremonyTimeout < 0 || pk.CeremonyTimeout > maxPasskeyCeremonyTimeout {
+		v.addf("passkey.ceremony_timeout is %s; must be between 0 and %s (PASSKEY_CEREMONY_TIMEOUT)", pk.CeremonyTimeout, maxPasskeyCeremonyTimeout)
+	}
+}
+
+// maxOAuthStateTTL caps oauth.state_ttl
+const maxOAuthStateTTL = Duration(time.Hour)
+
+// validateOAuth checks the identity providers. Providers have no env vars,
+// so problems point at the config file.
+func (c *Config) validateOAuth(v *validator) {
+	o := c.OAuth
+	if o.StateTTL < 0 || o.StateTTL > maxOAuthStateTTL {
+		v.addf("oauth.state_ttl is %s; must be between 0 and %s (OAUTH_STATE_TTL)", o.StateTTL, maxOAuthStateTTL)
+	}
+	if len(o.Providers) == 0 {
+		v.addf("oauth.providers is empty; configure at least one provider")
+	}
+	names := make([]string, 0, len(o.Providers))
+	for name := range o.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := o.Providers[name]
+		key := "oauth.providers." + name
+		switch p.Type {
+		case "google", "github":
+			if p.IssuerURL != "" {
+				v.addf("%s.issuer_url is only used by type \"oidc\"", key)
+			}
+		case "oidc":
+			if u, err := url.Parse(p.IssuerURL); err != nil || u.Scheme != "https" || u.Host == "" {
+				v.addf("%s.issuer_url must be an absolute https:// URL", key)
+			}
+		default:
+			v.addf("%s.type is %q; must be one of google, github, oidc", key, p.Type)
+		}
+		if p.ClientID == "" {
+			v.addf("%s.client_id is required", key)
+		}
+		if p.ClientSecretEnv == "" {
+			v.addf("%s.client_secret_env is required", key)
+		} else if os.Getenv(p.ClientSecretEnv) == "" {
+			v.addf("%s.client_secret_env names %s, which is not set", key, p.ClientSecretEnv)
+		}
+		u, err := url.Parse(p.RedirectURL)
+		switch {
+		case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+			v.addf("%s.redirect_url must be an absolute http:// or https:// URL", key)
+		case !strings.HasSuffix(u.Path, "/auth/oauth/"+name+"/callback"):
+			v.addf("%s.redirect_url must end in /auth/oauth/%s/callback", key, name)
+		}
+	}
+}
+
+// validateAPIKey checks the API key settings. The key header must not be
+// one JWT authentication reads, or requests would be taken for the other kind.
+func (c *Config) validateAPIKey(v *validator) {
+	k := c.APIKey
+	if v.required("api_key.header", "API_KEY_HEADER", k.Header) {
+		switch strings.ToLower(k.Header) {
+		case "authorization", "cookie":
+			v.addf("api_key.header is %q; must not be a header JWT authentication uses (API_KEY_HEADER)", k.Header)
+		}
+	}
+	if k.MaxPerUser < 1 {
+		v.addf("api_key.max_per_user is %d; must be at least 1 (API_KEY_MAX_PER_USER)", k.MaxPerUser)
+	}
+	v.nonNegativeDuration("api_key.max_ttl", "API_KEY_MAX_TTL", k.MaxTTL)
+}
+
+// validateLoginThrottle checks the login throttle thresholds. Delays only
+// need durations when they are on.
+func (c *Config) validateLoginThrottle(v *validator) {
+	t := c.Security.LoginThrottle
+	v.positiveDuration("security.login_throttle.window", "SECURITY_LOGIN_WINDOW", t.Window)
+	v.positive("security.login_throttle.max_email_failures", "SECURITY_LOGIN_MAX_EMAIL_FAILURES", t.MaxEmailFailures)
+	v.nonNegative("security.login_throttle.max_ip_failures", "SECURITY_LOGIN_MAX_IP_FAILURES", t.MaxIPFailures)
+	v.positiveDuration("security.login_throttle.lockout_duration", "SECURITY_LOGIN_LOCKOUT_DURATION", t.LockoutDuration)
+	if v.nonNegative("security.login_throttle.delay_after", "SECURITY_LOGIN_DELAY_AFTER", t.DelayAfter) && t.DelayAfter > 0 {
+		if v.positiveDuration("security.login_throttle.base_delay", "SECURITY_LOGIN_BASE_DELAY", t.BaseDelay) && t.MaxDelay < t.BaseDelay {
+			v.addf("security.login_throttle.max_delay (%s) must be at least base_delay (%s) (SECURITY_LOGIN_MAX_DELAY)", t.MaxDelay, t.BaseDelay)
+		}
+	}
+}
+
+// validateEmailedLink checks the url and email_tenant settings shared by the
+// flows that email a link. key and env prefix the setting names.
+func (c *Config) validateEmailedLink(v *validator, key, env, link, tenant string) {
+	if v.required(key+".url", env+"_URL", link) {
+		if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("%s.url must be an absolute http:// or https:// URL (%s_URL)", key, env)
+		}
+	}
+	if tenant != "" {
+		if _, ok := c.Email.Tenants[tenant]; !ok {
+			v.addf("%s.email_tenant is %q, which is not in email.tenants (%s_EMAIL_TENANT)", key, tenant, env)
+		}
+	}
+}
+
+// validateEmailTenants checks the per-tenant senders. Tenants have no env
+// vars, so problems point at the config file.
+func (c *Config) validateEmailTenants(v *validator) {
+	names := make([]string, 0, len(c.Email.Tenants))
+	for name := range c.Email.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := c.Email.Tenants[name]
+		key := fmt.Sprintf("email.tenants.%s", name)
+		if strings.TrimSpace(name) == "" {
+			v.addf("email.tenants has an empty tenant name (config file)")
+			continue
+		}
+		if t.Host == "" {
+			if t.Port != 0 || t.Username != "" || t.PasswordEnv != "" {
+				v.addf("%s sets port, username or password_env without host; set host too, or drop them to use the default SMTP server (config file)", key)
+			}
+		} else if t.Port < 1 || t.Port > 65535 {
+			v.addf("%s.port is %d; must be between 1 and 65535 (config file)", key, t.Port)
+		}
+		if t.PasswordEnv != "" && os.Getenv(t.PasswordEnv) == "" {
+			v.addf("%s.password_env names %s, which is not set", key, t.PasswordEnv)
+		}
+	}
+}
+
+// validateBulkheads checks the bulkhead groups. Groups have no env vars, so
+// problems point at the config file.
+func (c *Config) validateBulkheads(v *validator) {
+	switch c.Bulkhead.RejectStatus {
+	case 0, 429, 503:
+	default:
+		v.addf("bulkhead.reject_status is %d; must be 429 or 503 (BULKHEAD_REJECT_STATUS)", c.Bulkhead.RejectStatus)
+	}
+
+	names := make(map[string]bool)
+	for i, g := range c.Bulkhead.Groups {
+		key := fmt.Sprintf("bulkhead.groups[%d]", i)
+		switch {
+		case g.Name == "":
+			v.addf("%s.name is required (config file)", key)
+		case names[g.Name]:
+			v.addf("%s.name %q is used by another group (config file)", key, g.Name)
+		}
+		names[g.Name] = true
+
+		if len(g.Prefixes) == 0 {
+			v.addf("%s.prefixes must list at least one path prefix (config file)", key)
+		}
+		for _, p := range g.Prefixes {
+			if !strings.HasPrefix(p, "/") {
+				v.addf("%s.prefixes entry %q must start with \"/\" (config file)", key, p)
+			}
+		}
+		if g.MaxConcurrent <= 0 {
+			v.addf("%s.max_concurrent is %d; must be greater than 0 (config file)", key, g.MaxConcurrent)
+		}
+		if g.MaxQueue < 0 {
+			v.addf("%s.max_queue is %d; must not be negative (config file)", key, g.MaxQueue)
+		}
+		if g.QueueTimeout < 0 {
+			v.addf("%s.queue_timeout is %s; must not be negative (config file)", key, g.QueueTimeout)
+		}
+	}
+}
+
+// validateQueuePolicy checks the broker policy declared on the job queues.
+// Policies that dead-letter messages need somewhere to send them.
+func (c *Config) validateQueuePolicy(v *validator) {
+	qp := c.Worker.QueuePolicy
+	v.nonNegativeDuration("worker.queue_policy.message_ttl", "WORKER_QUEUE_MESSAGE_TTL", qp.MessageTTL)
+	v.nonNegative("worker.queue_policy.max_length", "WORKER_QUEUE_MAX_LENGTH", qp.MaxLength)
+	switch qp.Overflow {
+	case "", "drop-head", "reject-publish", "reject-publish-dlx":
+	default:
+		v.addf("worker.queue_policy.overflow is %q; must be drop-head, reject-publish or reject-publish-dlx (WORKER_QUEUE_OVERFLOW)", qp.Overflow)
+	}
+	if c.Worker.DeadLetterQueue == "" {
+		if qp.Overflow == "reject-publish-dlx" {
+			v.addf("worker.queue_policy.overflow reject-publish-dlx needs worker.dead_letter_queue (WORKER_DEAD_LETTER_QUEUE)")
+		}
+		if qp.DeadLetterExchange != "" {
+			v.addf("worker.queue_policy.dead_letter_exchange needs worker.dead_letter_queue (WORKER_DEAD_LETTER_QUEUE)")
+		}
+	}
+}
+
+// validateScheduler checks the timezone and the config schedules. Database
+// schedules are checked as they are loaded.
+func (c *Config) validateScheduler(v *validator) {
+	if tz := c.Scheduler.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			v.addf("scheduler.timezone %q is not a known time zone (SCHEDULER_TIMEZONE)", tz)
+		}
+	}
+	v.nonNegativeDuration("scheduler.refresh", "SCHEDULER_REFRESH", c.Scheduler.Refresh)
+
+	names := make(map[string]bool)
+	for i, s := range c.Scheduler.Schedules {
+		key := fmt.Sprintf("scheduler.schedules[%d]", i)
+		switch {
+		case s.Name == "":
+			v.addf("%s.name is required (config file)", key)
+		case names[s.Name]:
+			v.addf("%s.name %q is used by another schedule (config file)", key, s.Name)
+		}
+		names[s.Name] = true
+
+		if _, err := cron.Parse(s.Cron); err != nil {
+			v.addf("%s.cron is invalid: %v (config file)", key, err)
+		}
+		if s.JobType == "" {
+			v.addf("%s.job_type is required (config file)", key)
+		}
+		if s.MaxRetry < 0 {
+			v.addf("%s.max_retry is %d; must not be negative (config file)", key, s.MaxRetry)
+		}
+	}
+}
+
+// validateNATS checks the NATS broker and the queue policies JetStream
+// cannot express.
+func (c *Config) validateNATS(v *validator) {
+	if c.RabbitMQ.Enabled {
+		v.addf("nats.enabled and rabbitmq.enabled are both set; enable one queue broker (NATS_ENABLED)")
+	}
+	if v.required("nats.url", "NATS_URL", c.NATS.URL) {
+		for _, raw := range strings.Split(c.NATS.URL, ",") {
+			if u, err := url.Parse(strings.TrimSpace(raw)); err != nil || (u.Scheme != "nats" && u.Scheme != "tls") {
+				v.addf("nats.url must be nats:// or tls:// URLs (NATS_URL)")
+				break
+			}
+		}
+	}
+	v.nonNegative("nats.prefetch_count", "NATS_PREFETCH_COUNT", c.NATS.PrefetchCount)
+	v.nonNegativeDuration("nats.ack_wait", "NATS_ACK_WAIT", c.NATS.AckWait)
+	if c.Worker.DeadLetterQueue != "" {
+		v.addf("worker.dead_letter_queue is not supported with nats; use worker.dlq (WORKER_DEAD_LETTER_QUEUE)")
+	}
+	if c.Worker.QueuePolicy.Overflow == "reject-publish-dlx" {
+		v.addf("worker.queue_policy.overflow reject-publish-dlx is not supported with nats (WORKER_QUEUE_OVERFLOW)")
+	}
+	if c.Worker.QueuePolicy.Priority {
+		v.addf("worker.queue_policy.priority is not supported with nats (WORKER_QUEUE_PRIORITY)")
+	}
+}
+
+// validateCodecs checks the job codec names.
+func (c *Config) validateCodecs(v *validator) {
+	known := func(name string) bool {
+		switch name {
+		case "", "json", "msgpack", "protobuf":
+			return true
+		}
+		return false
+	}
+	if !known(c.Worker.Codec) {
+		v.addf("worker.codec is %q; must be json, msgpack or protobuf (WORKER_CODEC)", c.Worker.Codec)
+	}
+	jobTypes := make([]string, 0, len(c.Worker.JobCodecs))
+	for jobType := range c.Worker.JobCodecs {
+		jobTypes = append(jobTypes, jobType)
+	}
+	sort.Strings(jobTypes)
+	for _, jobType := range jobTypes {
+		if name := c.Worker.JobCodecs[jobType]; name == "" || !known(name) {
+			v.addf("worker.job_codecs.%s is %q; must be json, msgpack or protobuf (config file)", jobType, name)
+		}
+	}
+}
+
+// validatePriorities checks the job priority names.
+func (c *Config) validatePriorities(v *validator) {
+	jobTypes := make([]string, 0, len(c.Worker.JobPriorities))
+	for jobType := range c.Worker.JobPriorities {
+		jobTypes = append(jobTypes, jobType)
+	}
+	sort.Strings(jobTypes)
+	for _, jobType := range jobTypes {
+		switch name := c.Worker.JobPriorities[jobType]; name {
+		case "low", "normal", "high":
+		default:
+			v.addf("worker.job_priorities.%s is %q; must be low, normal or high (config file)", jobType, name)
+		}
+	}
+}
+
+// validateOutbox checks the outbox relay settings. Relayed messages need a
+// broker to go to.
+func (c *Config) validateOutbox(v *validator) {
+	if c.QueueBroker() == "" {
+		v.addf("worker.outbox.enabled needs rabbitmq.enabled or nats.enabled, or messages would never be relayed (WORKER_OUTBOX_ENABLED)")
+	}
+	v.nonNegativeDuration("worker.outbox.poll_interval", "WORKER_OUTBOX_POLL_INTERVAL", c.Worker.Outbox.PollInterval)
+	v.nonNegative("worker.outbox.batch_size", "WORKER_OUTBOX_BATCH_SIZE", c.Worker.Outbox.BatchSize)
+	v.nonNegativeDuration("worker.outbox.retention", "WORKER_OUTBOX_RETENTION", c.Worker.Outbox.Retention)
+}
+
+// validateTopicPermissions checks that every SSE topic permission reads
+// "object:action".
+func (c *Config) validateTopicPermissions(v *validator) {
+	topics := make([]string, 0, len(c.SSE.TopicPermissions))
+	for topic := range c.SSE.TopicPermissions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		perm := c.SSE.TopicPermissions[topic]
+		if obj, act, ok := strings.Cut(perm, ":"); topic == "" || !ok || obj == "" || act == "" {
+			v.addf("sse.topic_permissions.%s is %q; must be \"object:action\" (config file)", topic, perm)
+		}
+	}
+}
+
+// validateAuditExport checks the SIEM export format and sink.
+func (c *Config) validateAuditExport(v *validator) {
+	ex := c.Audit.Export
+	switch ex.Format {
+	case "", "ecs", "cef":
+	default:
+		v.addf("audit.export.format is %q; must be \"ecs\" or \"cef\" (AUDIT_EXPORT_FORMAT)", ex.Format)
+	}
+	endpoint := func(key, env, value string) {
+		if v.required(key, env, value) {
+			if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				v.addf("%s must be an http:// or https:// URL (%s)", key, env)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for _, sink := range ex.Sinks() {
+		if seen[sink] {
+			v.addf("audit.export.sink lists %q twice (AUDIT_EXPORT_SINK)", sink)
+			continue
+		}
+		seen[sink] = true
+		switch sink {
+		case "file":
+			v.required("audit.export.path", "AUDIT_EXPORT_PATH", ex.Path)
+		case "http":
+			endpoint("audit.export.url", "AUDIT_EXPORT_URL", ex.URL)
+		case "loki":
+			endpoint("audit.export.loki.url", "AUDIT_EXPORT_LOKI_URL", ex.Loki.URL)
+			names := make([]string, 0, len(ex.Loki.Labels))
+			for name := range ex.Loki.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if !lokiLabelName.MatchString(name) || ex.Loki.Labels[name] == "" {
+					v.addf("audit.export.loki.labels.%s is not a valid Loki label; names are letters, digits and _, and values non-empty (config file)", name)
+				}
+			}
+		case "elasticsearch":
+			endpoint("audit.export.elasticsearch.url", "AUDIT_EXPORT_ELASTICSEARCH_URL", ex.Elasticsearch.URL)
+			if ex.Format == "cef" {
+				v.addf("audit.export.sink elasticsearch indexes JSON documents and needs format \"ecs\" (AUDIT_EXPORT_FORMAT)")
+			}
+		case "kafka":
+			endpoint("audit.export.kafka.url", "AUDIT_EXPORT_KAFKA_URL", ex.Kafka.URL)
+			v.required("audit.export.kafka.topic", "AUDIT_EXPORT_KAFKA_TOPIC", ex.Kafka.Topic)
+		default:
+			v.addf("audit.export.sink has %q; must be \"file\", \"http\", \"loki\", \"elasticsearch\" or \"kafka\" (AUDIT_EXPORT_SINK)", sink)
+		}
+	}
+	v.nonNegative("audit.export.buffer_size", "AUDIT_EXPORT_BUFFER_SIZE", ex.BufferSize)
+	v.nonNegative("audit.export.batch_size", "AUDIT_EXPORT_BATCH_SIZE", ex.BatchSize)
+	v.nonNegativeDuration("audit.export.flush_interval", "AUDIT_EXPORT_FLUSH_INTERVAL", ex.FlushInterval)
+	v.nonNegativeDuration("audit.export.timeout", "AUDIT_EXPORT_TIMEOUT", ex.Timeout)
+}
+
+// validateHoneytoken checks the canary credentials and where alerts go.
+func (c *Config) validateHoneytoken(v *validator) {
+	ht := c.Honeytoken
+	if len(ht.Users) == 0 && len(ht.APIKeyHashes) == 0 {
+		v.addf("honeytoken is enabled but lists no users or api_key_hashes (HONEYTOKEN_USERS, HONEYTOKEN_API_KEY_HASHES)")
+	}
+	for _, u := range ht.Users {
+		if !strings.Contains(u, "@") {
+			v.addf("honeytoken.users entry %q must be an email address (HONEYTOKEN_USERS)", u)
+		}
+	}
+	for _, h := range ht.APIKeyHashes {
+		if len(h) != 64 || strings.Trim(strings.ToLower(h), "0123456789abcdef") != "" {
+			v.addf("honeytoken.api_key_hashes entry %q must be a SHA-256 hex digest (HONEYTOKEN_API_KEY_HASHES)", h)
+		}
+	}
+	if len(ht.Notify) > 0 && !c.Email.Enabled {
+		v.addf("honeytoken.notify needs email.enabled; alerts would not be sent (HONEYTOKEN_NOTIFY)")
+	}
+	v.nonNegativeDuration("honeytoken.lockdown_ttl", "HONEYTOKEN_LOCKDOWN_TTL", ht.LockdownTTL)
+}
+
+// validateIPRules checks the fixed IP rules.
+func (c *Config) validateIPRules(v *validator) {
+	v.nonNegativeDuration("ip_rules.refresh", "IP_RULES_REFRESH", c.IPRules.Refresh)
+	for i, r := range c.IPRules.Rules {
+		if r.Action != "allow" && r.Action != "deny" {
+			v.addf("ip_rules.rules[%d].action is %q; must be \"allow\" or \"deny\" (config file)", i, r.Action)
+		}
+		if !validCIDR(r.CIDR) {
+			v.addf("ip_rules.rules[%d].cidr %q must be a CIDR range or an IP address (config file)", i, r.CIDR)
+		}
+		if r.Prefix != "" && !strings.HasPrefix(r.Prefix, "/") {
+			v.addf("ip_rules.rules[%d].prefix %q must start with \"/\" (config file)", i, r.Prefix)
+		}
+	}
+}
+
+// validateGeoRestriction checks the GeoIP database and the country rules.
+func (c *Config) validateGeoRestriction(v *validator) {
+	g := c.GeoRestriction
+	v.required("geo_restriction.database", "GEO_RESTRICTION_DATABASE", g.Database)
+	v.nonNegativeDuration("geo_restriction.audit_interval", "GEO_RESTRICTION_AUDIT_INTERVAL", g.AuditInterval)
+	if len(g.Rules) == 0 {
+		v.addf("geo_restriction is enabled but has no rules: list the countries to block in geo_restriction.rules (config file)")
+	}
+	for i, r := range g.Rules {
+		if r.Prefix != "" && !strings.HasPrefix(r.Prefix, "/") {
+			v.addf("geo_restriction.rules[%d].prefix %q must start with \"/\" (config file)", i, r.Prefix)
+		}
+		if len(r.Countries) == 0 {
+			v.addf("geo_restriction.rules[%d].countries is empty (config file)", i)
+		}
+		for _, cc := range r.Countries {
+			if !validCountryCode(cc) {
+				v.addf("geo_restriction.rules[%d] country %q must be an ISO 3166-1 alpha-2 code such as \"KP\" (config file)", i, cc)
+			}
+		}
+	}
+}
+
+func validCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func validCIDR(s string) bool {
+	if strings.Contains(s, "/") {
+		_, err := netip.ParsePrefix(s)
+		return err == nil
+	}
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// validator accumulates problems. Each check reports whether it passed so
+// dependent checks can be skipped.
+type validator struct {
+	problems []string
+}
+
+// validateWatchdog checks the watchdog sampling settings.
+func (c *Config) validateWatchdog(v *validator) {
+	wd := c.Watchdog
+	v.nonNegativeDuration("watchdog.interval", "WATCHDOG_INTERVAL", wd.Interval)
+	v.nonNegative("watchdog.window", "WATCHDOG_WINDOW", wd.Window)
+	v.nonNegative("watchdog.goroutine_growth", "WATCHDOG_GOROUTINE_GROWTH", wd.GoroutineGrowth)
+	if wd.Saturation < 0 || wd.Saturation > 1 {
+		v.addf("watchdog.saturation is %v; must be between 0 and 1 (WATCHDOG_SATURATION)", wd.Saturation)
+	}
+}
+
+// validateLoadShed checks the load shedding thresholds and route priorities.
+func (c *Config) validateLoadShed(v *validator) {
+	ls := c.LoadShed
+	v.nonNegativeDuration("load_shed.interval", "LOAD_SHED_INTERVAL", ls.Interval)
+	v.nonNegativeDuration("load_shed.pool_wait", "LOAD_SHED_POOL_WAIT", ls.PoolWait)
+	v.nonNegativeDuration("load_shed.loop_lag", "LOAD_SHED_LOOP_LAG", ls.LoopLag)
+	v.nonNegative("load_shed.heap_mb", "LOAD_SHED_HEAP_MB", ls.HeapMB)
+	if ls.PoolWait <= 0 && ls.LoopLag <= 0 && ls.HeapMB <= 0 {
+		v.addf("load_shed is enabled but pool_wait, loop_lag and heap_mb are all 0: set at least one threshold (LOAD_SHED_POOL_WAIT, LOAD_SHED_LOOP_LAG, LOAD_SHED_HEAP_MB)")
+	}
+	checkPrefixes := func(key string, prefixes []string) {
+		for _, p := range prefixes {
+			if !strings.HasPrefix(p, "/") {
+				v.addf("%s entry %q must start with \"/\" (config file)", key, p)
+			}
+		}
+	}
+	checkPrefixes("load_shed.low_priority", ls.LowPriority)
+	checkPrefixes("load_shed.critical_priority", ls.CriticalPriority)
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(key, env, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.addf("%s is required (%s)", key, env)
+		return false
+	}
+	return true
+}
+
+func (v *validator) port(key, env string, value int) bool {
+	if value < 1 || value > 65535 {
+		v.addf("%s is %d; must be between 1 and 65535 (%s)", key, value, env)
+		return false
+	}
+	return true
+}
+
+func (v *validator) positive(key, env string, value int) bool {
+	if value <= 0 {
+		v.addf("%s is %d; must be greater than 0 (%s)", key, value, env)
+		return false
+	}
+	return true
+}
+
+func (v *validator) nonNegative(key, env string, value int) bool {
+	if value < 0 {
+		v.addf("%s is %d; must not be negative (%s)", key, value, env)
+		return false
+	}
+	return true
+}
+
+func (v *validator) positiveDuration(key, env string, value Duration) bool {
+	if value <= 0 {
+		v.addf("%s is %s; must be greater than 0 (%s)", key, value, env)
+		return false
+	}
+	return true
+}
+
+func (v *validator) nonNegativeDuration(key, env string, value Duration) bool {
+	if value < 0 {
+		v.addf("%s is %s; must not be negative (%s)", key, value, env)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5" // pgx5:// driver
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migrate applies the pending up migrations in source, golang-migrate files
+// at its root, to the database at dsn (a postgres:// URL). It returns the
+// schema version the database is at afterwards. Concurrent callers are
+// serialized by the driver's advisory lock, so every replica may call it at
+// startup.
+func Migrate(dsn string, source fs.FS) (uint, error) {
+	src, err := iofs.New(source, ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	// golang-migrate's pgx/v5 driver is registered under pgx5://
+	m, err := migrate.NewWithSourceInstance("iofs", src, "pgx5"+strings.TrimPrefix(dsn, "postgres"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, fmt.Errorf("failed to run migrations up: %w", err)
+	}
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return version, fmt.Errorf("schema version %d is dirty; fix it and force the version with the migrate CLI", version)
+	}
+	return version, nil
+}
@@ -29,8 +29,12 @@ func DBFromContext(ctx context.Context, pool *pgxpool.Pool) DBTX {
 	return pool
 }
 
+// PoolOption customises the pgxpool config before the pool is created
+// (e.g. installing a PrepareConn hook).
+type PoolOption func(*pgxpool.Config)
+
 // NewPostgresPool creates a new PostgreSQL connection pool
-func NewPostgresPool(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+func NewPostgresPool(ctx context.Context, cfg config.DatabaseConfig, opts ...PoolOption) (*pgxpool.Pool, error) {
 	poolCfg, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database config: %w", err)
@@ -39,9 +43,15 @@ func NewPostgresPool(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.P
 	// Configure pool settings
 	poolCfg.MaxConns = int32(cfg.MaxOpenConns)
 	poolCfg.MinConns = int32(cfg.MaxIdleConns)
-	poolCfg.MaxConnLifetime = time.Duration(cfg.ConnMaxLifetime) * time.Second
+	poolCfg.MaxConnLifetime = time.Duration(cfg.ConnMaxLifetime)
 	poolCfg.MaxConnIdleTime = 30 * time.Minute
 	poolCfg.HealthCheckPeriod = 1 * time.Minute
+	applySessionTimeouts(poolCfg, cfg)
+	poolCfg.ConnConfig.Tracer = statsTracer{}
+
+	for _, opt := range opts {
+		opt(poolCfg)
+	}
 
 	// Create pool
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
@@ -62,31 +72,113 @@ func NewPostgresPool(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.P
 // transaction. It exists so unit tests can inject a fake pool / tx and
 // exercise the rollback-context path without a live database.
 type txBeginner interface {
-	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 }
 
 // Transactor provides transaction support
 type Transactor struct {
-	pool txBeginner
+	pool  txBeginner
+	retry RetryPolicy
+}
+
+// TransactorOption configures a Transactor.
+type TransactorOption func(*Transactor)
+
+// WithRetryPolicy sets how WithTx retries transactions that fail with a
+// serialization failure or a deadlock.
+func WithRetryPolicy(p RetryPolicy) TransactorOption {
+	return func(t *Transactor) {
+		t.retry = p
+	}
 }
 
 // NewTransactor creates a new transactor
-func NewTransactor(pool *pgxpool.Pool) *Transactor {
-	return &Transactor{pool: pool}
+func NewTransactor(pool *pgxpool.Pool, opts ...TransactorOption) *Transactor {
+	return newTransactor(pool, opts...)
+}
+
+func newTransactor(pool txBeginner, opts ...TransactorOption) *Transactor {
+	t := &Transactor{pool: pool}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 // TxFunc is a function that runs within a transaction
 type TxFunc func(ctx context.Context) error
 
-// WithTx executes a function within a database transaction
-func (t *Transactor) WithTx(ctx context.Context, fn TxFunc) error {
-	tx, err := t.pool.Begin(ctx)
+// WithTx executes a function within a database transaction. opts can raise the
+// isolation level or change the number of attempts for this call.
+//
+// If the transaction fails with a serialization failure (SQLSTATE 40001) or a
+// deadlock (40P01) it is rolled back and fn runs again in a new transaction,
+// after a backoff, until the attempts run out (see RetryPolicy). fn must
+// therefore not have side effects outside the database. Errors mapped by
+// pgutil.MapError keep the original error wrapped, so they are retried too.
+//
+// Called with a context that already carries a transaction, WithTx nests: fn
+// runs inside a savepoint of that transaction. An error from fn rolls back to
+// the savepoint and is returned, leaving the outer transaction usable; success
+// releases the savepoint, and the work commits with the outer transaction.
+// Nested calls ignore opts and never retry on their own, because a
+// serialization failure or deadlock aborts the whole outer transaction; the
+// error propagates and the outermost WithTx retries it.
+func (t *Transactor) WithTx(ctx context.Context, fn TxFunc, opts ...TxOption) error {
+	if outer := GetTx(ctx); outer != nil {
+		sp, err := outer.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		return runTx(ctx, sp, fn)
+	}
+
+	retry := t.retry.withDefaults()
+	o := txOptions{maxAttempts: retry.MaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = t.beginAndRun(ctx, o.pgx, fn)
+		reason := retryReason(err)
+		if reason == "" {
+			return err
+		}
+		if attempt >= o.maxAttempts {
+			if retry.OnExhausted != nil {
+				retry.OnExhausted(reason)
+			}
+			return err
+		}
+		if retry.OnRetry != nil {
+			retry.OnRetry(reason)
+		}
+		if !sleepCtx(ctx, retry.delay(attempt)) {
+			return err
+		}
+	}
+}
+
+// beginAndRun runs fn in a single new transaction.
+func (t *Transactor) beginAndRun(ctx context.Context, txOptions pgx.TxOptions, fn TxFunc) error {
+	tx, err := t.pool.BeginTx(ctx, txOptions)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	return runTx(ctx, tx, fn)
+}
 
+// runTx runs fn in tx, which is a transaction or a savepoint, then commits
+// or rolls it back. For a savepoint pgx turns Commit into RELEASE SAVEPOINT
+// and Rollback into ROLLBACK TO SAVEPOINT.
+func runTx(ctx context.Context, tx pgx.Tx, fn TxFunc) error {
+	outerCtx := ctx
+	hooks := &afterCommitHooks{}
 	// Store tx in context for repositories to use
 	ctx = context.WithValue(ctx, txKey{}, tx)
+	ctx = context.WithValue(ctx, afterCommitKey{}, hooks)
 
 	defer func() {
 		if p := recover(); p != nil {
@@ -112,6 +204,15 @@ func (t *Transactor) WithTx(ctx context.Context, fn TxFunc) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	// A released savepoint hands its hooks to the enclosing transaction;
+	// they run when that commits.
+	if outer, ok := outerCtx.Value(afterCommitKey{}).(*afterCommitHooks); ok {
+		outer.fns = append(outer.fns, hooks.fns...)
+		return nil
+	}
+	for _, fn := range hooks.fns {
+		fn(outerCtx)
+	}
 	return nil
 }
 
@@ -129,3 +230,26 @@ func GetTx(ctx context.Context) pgx.Tx {
 	tx, _ := ctx.Value(txKey{}).(pgx.Tx)
 	return tx
 }
+
+// afterCommitKey is the context key for the hooks of the innermost
+// transaction or savepoint
+type afterCommitKey struct{}
+
+type afterCommitHooks struct {
+	fns []func(ctx context.Context)
+}
+
+// AfterCommit runs fn once the transaction carried by ctx commits, with the
+// context WithTx was called with. Hooks of a transaction that rolls back,
+// including one WithTx retries, are dropped. Without a transaction fn runs
+// at once.
+//
+// Use it for side effects that must not happen unless the change does, such
+// as publishing an event about it.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+	fn(ctx)
+}
@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/14mdzk/goscratch/pkg/pgutil"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -63,6 +66,7 @@ type fakeTx struct {
 	rollbackHadDeadline bool
 	rollbackDeadline    time.Time
 	rollbackErrFunc     func(ctx context.Context) error
+	savepoints          []*fakeTx
 }
 
 func (f *fakeTx) Rollback(ctx context.Context) error {
@@ -83,13 +87,22 @@ func (f *fakeTx) Commit(ctx context.Context) error {
 	return nil
 }
 
-// fakeBeginner returns the same fakeTx for every Begin call.
+// Begin starts a savepoint, recorded in savepoints so tests can inspect it.
+func (f *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	sp := &fakeTx{}
+	f.savepoints = append(f.savepoints, sp)
+	return sp, nil
+}
+
+// fakeBeginner returns the same fakeTx for every BeginTx call.
 type fakeBeginner struct {
 	tx       *fakeTx
 	beginErr error
+	opts     []pgx.TxOptions // options passed to each BeginTx call
 }
 
-func (f *fakeBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
+func (f *fakeBeginner) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	f.opts = append(f.opts, txOptions)
 	if f.beginErr != nil {
 		return nil, f.beginErr
 	}
@@ -142,3 +155,264 @@ func TestWithTx_RollbackUsesFreshContext(t *testing.T) {
 		require.True(t, tx.commitCalled)
 	})
 }
+
+func TestWithTx_RetriesConflicts(t *testing.T) {
+	serializationErr := &pgconn.PgError{Code: pgutil.SQLStateSerializationFailure}
+	deadlockErr := &pgconn.PgError{Code: pgutil.SQLStateDeadlockDetected}
+
+	// newTestTransactor records retry callbacks and keeps backoff negligible.
+	newTestTransactor := func(beginner *fakeBeginner, maxAttempts int) (*Transactor, *[]string) {
+		var events []string
+		tor := newTransactor(beginner, WithRetryPolicy(RetryPolicy{
+			MaxAttempts: maxAttempts,
+			Backoff:     time.Microsecond,
+			OnRetry:     func(reason string) { events = append(events, "retry:"+reason) },
+			OnExhausted: func(reason string) { events = append(events, "exhausted:"+reason) },
+		}))
+		return tor, &events
+	}
+
+	t.Run("succeeds_after_retry", func(t *testing.T) {
+		tx := &fakeTx{}
+		tor, events := newTestTransactor(&fakeBeginner{tx: tx}, 3)
+
+		calls := 0
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			calls++
+			switch calls {
+			case 1:
+				// Repositories wrap and map errors; the retry must still see it.
+				return pgutil.MapError(fmt.Errorf("failed to update: %w", serializationErr), "user")
+			case 2:
+				return deadlockErr
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.True(t, tx.commitCalled)
+		assert.Equal(t, []string{"retry:" + RetryReasonSerialization, "retry:" + RetryReasonDeadlock}, *events)
+	})
+
+	t.Run("gives_up_after_max_attempts", func(t *testing.T) {
+		tor, events := newTestTransactor(&fakeBeginner{tx: &fakeTx{}}, 2)
+
+		calls := 0
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			calls++
+			return serializationErr
+		})
+		require.ErrorIs(t, err, serializationErr)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, []string{"retry:" + RetryReasonSerialization, "exhausted:" + RetryReasonSerialization}, *events)
+	})
+
+	t.Run("per_call_max_attempts", func(t *testing.T) {
+		tor, _ := newTestTransactor(&fakeBeginner{tx: &fakeTx{}}, 5)
+
+		calls := 0
+		_ = tor.WithTx(context.Background(), func(ctx context.Context) error {
+			calls++
+			return serializationErr
+		}, WithMaxAttempts(1))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("other_errors_are_not_retried", func(t *testing.T) {
+		tor, events := newTestTransactor(&fakeBeginner{tx: &fakeTx{}}, 3)
+
+		calls := 0
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			calls++
+			return &pgconn.PgError{Code: pgutil.SQLStateUniqueViolation}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, *events)
+	})
+
+	t.Run("stops_when_ctx_is_done", func(t *testing.T) {
+		tor := newTransactor(&fakeBeginner{tx: &fakeTx{}}, WithRetryPolicy(RetryPolicy{Backoff: time.Hour}))
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+		err := tor.WithTx(ctx, func(ctx context.Context) error {
+			calls++
+			cancel()
+			return serializationErr
+		})
+		require.ErrorIs(t, err, serializationErr)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestWithTx_Isolation(t *testing.T) {
+	beginner := &fakeBeginner{tx: &fakeTx{}}
+	tor := NewTransactor(nil)
+	tor.pool = beginner
+
+	require.NoError(t, tor.WithTx(context.Background(), func(ctx context.Context) error { return nil }))
+	require.NoError(t, tor.WithTx(context.Background(), func(ctx context.Context) error { return nil }, WithIsolation(pgx.Serializable)))
+
+	require.Len(t, beginner.opts, 2)
+	assert.Equal(t, pgx.TxIsoLevel(""), beginner.opts[0].IsoLevel)
+	assert.Equal(t, pgx.Serializable, beginner.opts[1].IsoLevel)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}.withDefaults()
+
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 6: 50 * time.Millisecond} {
+		d := p.delay(attempt)
+		assert.GreaterOrEqual(t, d, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, d, want, "attempt %d", attempt)
+	}
+}
+
+func TestWithTx_NestedUsesSavepoints(t *testing.T) {
+	t.Run("inner_error_rolls_back_to_savepoint", func(t *testing.T) {
+		tx := &fakeTx{}
+		tor := newTransactor(&fakeBeginner{tx: tx})
+
+		innerErr := errors.New("inner failed")
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			gotErr := tor.WithTx(ctx, func(ctx context.Context) error {
+				assert.Same(t, tx.savepoints[0], GetTx(ctx), "inner fn must run in the savepoint")
+				return innerErr
+			})
+			require.ErrorIs(t, gotErr, innerErr)
+			// The outer transaction carries on after the failed step.
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, tx.savepoints, 1)
+		assert.True(t, tx.savepoints[0].rollbackCalled)
+		assert.False(t, tx.savepoints[0].commitCalled)
+		assert.False(t, tx.rollbackCalled)
+		assert.True(t, tx.commitCalled)
+	})
+
+	t.Run("inner_success_releases_savepoint", func(t *testing.T) {
+		tx := &fakeTx{}
+		beginner := &fakeBeginner{tx: tx}
+		tor := newTransactor(beginner)
+
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			return tor.WithTx(ctx, func(ctx context.Context) error { return nil })
+		})
+		require.NoError(t, err)
+
+		assert.Len(t, beginner.opts, 1, "a nested call must not begin a second transaction")
+		require.Len(t, tx.savepoints, 1)
+		assert.True(t, tx.savepoints[0].commitCalled)
+		assert.True(t, tx.commitCalled)
+	})
+
+	t.Run("inner_error_returned_by_outer_rolls_back_everything", func(t *testing.T) {
+		tx := &fakeTx{}
+		tor := newTransactor(&fakeBeginner{tx: tx})
+
+		innerErr := errors.New("inner failed")
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			return tor.WithTx(ctx, func(ctx context.Context) error { return innerErr })
+		})
+		require.ErrorIs(t, err, innerErr)
+		assert.True(t, tx.savepoints[0].rollbackCalled)
+		assert.True(t, tx.rollbackCalled)
+		assert.False(t, tx.commitCalled)
+	})
+
+	t.Run("conflicts_are_retried_by_the_outermost_call", func(t *testing.T) {
+		tx := &fakeTx{}
+		tor := newTransactor(&fakeBeginner{tx: tx}, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Microsecond}))
+
+		outerCalls, innerCalls := 0, 0
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			outerCalls++
+			return tor.WithTx(ctx, func(ctx context.Context) error {
+				innerCalls++
+				if innerCalls == 1 {
+					return &pgconn.PgError{Code: pgutil.SQLStateSerializationFailure}
+				}
+				return nil
+			}, WithMaxAttempts(5))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, outerCalls, "the whole outer transaction is re-run")
+		assert.Equal(t, 2, innerCalls, "the savepoint itself is not retried")
+	})
+}
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("runs_after_commit", func(t *testing.T) {
+		tx := &fakeTx{}
+		tor := newTransactor(&fakeBeginner{tx: tx})
+
+		var ran []string
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func(ctx context.Context) {
+				assert.True(t, tx.commitCalled, "hooks run after the commit")
+				assert.Nil(t, GetTx(ctx), "hooks run outside the transaction")
+				ran = append(ran, "outer")
+			})
+			return tor.WithTx(ctx, func(ctx context.Context) error {
+				AfterCommit(ctx, func(context.Context) { ran = append(ran, "savepoint") })
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer", "savepoint"}, ran)
+	})
+
+	t.Run("dropped_on_rollback", func(t *testing.T) {
+		tor := newTransactor(&fakeBeginner{tx: &fakeTx{}})
+
+		ran := false
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func(context.Context) { ran = true })
+			return errors.New("boom")
+		})
+		require.Error(t, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("dropped_with_a_rolled_back_savepoint", func(t *testing.T) {
+		tor := newTransactor(&fakeBeginner{tx: &fakeTx{}})
+
+		var ran []string
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			_ = tor.WithTx(ctx, func(ctx context.Context) error {
+				AfterCommit(ctx, func(context.Context) { ran = append(ran, "failed step") })
+				return errors.New("step failed")
+			})
+			AfterCommit(ctx, func(context.Context) { ran = append(ran, "outer") })
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer"}, ran)
+	})
+
+	t.Run("dropped_for_a_retried_attempt", func(t *testing.T) {
+		tor := newTransactor(&fakeBeginner{tx: &fakeTx{}}, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: time.Microsecond}))
+
+		attempts, ran := 0, 0
+		err := tor.WithTx(context.Background(), func(ctx context.Context) error {
+			attempts++
+			AfterCommit(ctx, func(context.Context) { ran++ })
+			if attempts == 1 {
+				return &pgconn.PgError{Code: pgutil.SQLStateSerializationFailure}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, 1, ran)
+	})
+
+	t.Run("runs_at_once_without_a_transaction", func(t *testing.T) {
+		ran := false
+		AfterCommit(context.Background(), func(context.Context) { ran = true })
+		assert.True(t, ran)
+	})
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryStats accumulates the statements run on behalf of one unit of work,
+// typically an HTTP request. It is safe for concurrent use: a request may fan
+// out queries across goroutines.
+type QueryStats struct {
+	count atomic.Int64
+	nanos atomic.Int64
+}
+
+// Count returns the number of statements recorded.
+func (s *QueryStats) Count() int64 {
+	return s.count.Load()
+}
+
+// Duration returns the cumulative time spent in recorded statements.
+func (s *QueryStats) Duration() time.Duration {
+	return time.Duration(s.nanos.Load())
+}
+
+// Add records one statement that took d. The pgx tracer calls it; it is
+// exported for statements that bypass pgx.
+func (s *QueryStats) Add(d time.Duration) {
+	s.count.Add(1)
+	s.nanos.Add(int64(d))
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats returns a context that collects statement metrics into the
+// returned QueryStats. Every query run with this context (or one derived from
+// it) through a pool created by NewPostgresPool is counted.
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := &QueryStats{}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// QueryStatsFromContext returns the collector stored in ctx, or nil.
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
+
+// statsTracer is the pgx tracer that feeds QueryStats. It only does work when
+// the query context carries a collector, so background jobs and scripts pay
+// for a single context lookup.
+type statsTracer struct{}
+
+var (
+	_ pgx.QueryTracer    = statsTracer{}
+	_ pgx.BatchTracer    = statsTracer{}
+	_ pgx.CopyFromTracer = statsTracer{}
+)
+
+type traceStartKey struct{}
+
+func (statsTracer) start(ctx context.Context) context.Context {
+	if QueryStatsFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, traceStartKey{}, time.Now())
+}
+
+func (statsTracer) end(ctx context.Context) {
+	start, ok := ctx.Value(traceStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	QueryStatsFromContext(ctx).Add(time.Since(start))
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t statsTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return t.start(ctx)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t statsTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	t.end(ctx)
+}
+
+// TraceBatchStart implements pgx.BatchTracer. A batch counts as one
+// statement: it is a single round trip.
+func (t statsTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx)
+}
+
+// TraceBatchQuery implements pgx.BatchTracer.
+func (statsTracer) TraceBatchQuery(context.Context, *pgx.Conn, pgx.TraceBatchQueryData) {}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (t statsTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchEndData) {
+	t.end(ctx)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t statsTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceCopyFromStartData) context.Context {
+	return t.start(ctx)
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t statsTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceCopyFromEndData) {
+	t.end(ctx)
+}
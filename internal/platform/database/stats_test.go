@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryStatsFromContext_Missing(t *testing.T) {
+	assert.Nil(t, QueryStatsFromContext(context.Background()))
+}
+
+func TestStatsTracer_NoCollectorIsNoop(t *testing.T) {
+	ctx := context.Background()
+	tr := statsTracer{}
+
+	got := tr.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	assert.Equal(t, ctx, got, "context must be returned unchanged without a collector")
+	tr.TraceQueryEnd(got, nil, pgx.TraceQueryEndData{})
+}
+
+func TestStatsTracer_CountsQueriesBatchesAndCopies(t *testing.T) {
+	ctx, stats := WithQueryStats(context.Background())
+	tr := statsTracer{}
+
+	qctx := tr.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	time.Sleep(2 * time.Millisecond)
+	tr.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{})
+
+	bctx := tr.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{})
+	tr.TraceBatchQuery(bctx, nil, pgx.TraceBatchQueryData{})
+	tr.TraceBatchQuery(bctx, nil, pgx.TraceBatchQueryData{})
+	tr.TraceBatchEnd(bctx, nil, pgx.TraceBatchEndData{})
+
+	cctx := tr.TraceCopyFromStart(ctx, nil, pgx.TraceCopyFromStartData{})
+	tr.TraceCopyFromEnd(cctx, nil, pgx.TraceCopyFromEndData{})
+
+	assert.Equal(t, int64(3), stats.Count(), "a batch is one round trip")
+	assert.GreaterOrEqual(t, stats.Duration(), 2*time.Millisecond)
+}
+
+func TestStatsTracer_DerivedContextsShareCollector(t *testing.T) {
+	ctx, stats := WithQueryStats(context.Background())
+	derived, cancel := WithQueryTimeout(ctx, time.Second)
+	defer cancel()
+
+	tr := statsTracer{}
+	tr.TraceQueryEnd(tr.TraceQueryStart(derived, nil, pgx.TraceQueryStartData{}), nil, pgx.TraceQueryEndData{})
+	assert.Equal(t, int64(1), stats.Count())
+}
+
+func TestQueryStats_ConcurrentAdd(t *testing.T) {
+	_, stats := WithQueryStats(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats.Add(time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(50), stats.Count())
+	assert.Equal(t, 50*time.Millisecond, stats.Duration())
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sqlStateQueryCanceled is raised by the server when statement_timeout fires
+// (or a query is cancelled via pg_cancel_backend).
+const sqlStateQueryCanceled = "57014"
+
+// WithQueryTimeout bounds a single query to d. If ctx already carries an
+// earlier deadline (e.g. the request deadline) that one is kept, so the
+// per-query budget can only tighten. A non-positive d returns ctx unchanged.
+//
+// Always call the returned cancel once the query (including scanning) is
+// done; repositories do this with a defer right after the call.
+func WithQueryTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// IsQueryTimeout reports whether err came from a per-query deadline on the
+// client side or from the server's statement_timeout.
+func IsQueryTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == sqlStateQueryCanceled
+}
+
+// applySessionTimeouts sets statement_timeout and
+// idle_in_transaction_session_timeout as startup parameters, so every pooled
+// connection carries them from the moment it is opened at no extra round
+// trip. These are the server-side backstop: they fire even when a caller
+// forgot a context deadline or the client vanished mid-transaction.
+func applySessionTimeouts(poolCfg *pgxpool.Config, cfg config.DatabaseConfig) {
+	params := poolCfg.ConnConfig.RuntimeParams
+	if cfg.StatementTimeoutMs > 0 {
+		params["statement_timeout"] = strconv.Itoa(cfg.StatementTimeoutMs)
+	}
+	if cfg.IdleInTxTimeoutMs > 0 {
+		params["idle_in_transaction_session_timeout"] = strconv.Itoa(cfg.IdleInTxTimeoutMs)
+	}
+}
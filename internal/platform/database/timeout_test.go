@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	t.Run("sets_deadline", func(t *testing.T) {
+		ctx, cancel := WithQueryTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("keeps_earlier_parent_deadline", func(t *testing.T) {
+		parent, parentCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer parentCancel()
+
+		ctx, cancel := WithQueryTimeout(parent, time.Minute)
+		defer cancel()
+
+		assert.Equal(t, parent, ctx, "a looser query timeout must not replace the request deadline")
+	})
+
+	t.Run("tightens_later_parent_deadline", func(t *testing.T) {
+		parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+		defer parentCancel()
+
+		ctx, cancel := WithQueryTimeout(parent, 100*time.Millisecond)
+		defer cancel()
+
+		deadline, _ := ctx.Deadline()
+		assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), deadline, 50*time.Millisecond)
+	})
+
+	t.Run("disabled_when_non_positive", func(t *testing.T) {
+		parent := context.Background()
+		ctx, cancel := WithQueryTimeout(parent, 0)
+		defer cancel()
+
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+}
+
+func TestIsQueryTimeout(t *testing.T) {
+	assert.False(t, IsQueryTimeout(nil))
+	assert.True(t, IsQueryTimeout(context.DeadlineExceeded))
+	assert.True(t, IsQueryTimeout(fmt.Errorf("failed to get user: %w", context.DeadlineExceeded)))
+	assert.True(t, IsQueryTimeout(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "57014"})))
+	assert.False(t, IsQueryTimeout(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsQueryTimeout(context.Canceled))
+}
+
+func TestApplySessionTimeouts(t *testing.T) {
+	newPoolCfg := func(t *testing.T) *pgxpool.Config {
+		t.Helper()
+		poolCfg, err := pgxpool.ParseConfig("postgres://u:p@localhost:5432/db")
+		require.NoError(t, err)
+		return poolCfg
+	}
+
+	t.Run("sets_runtime_params", func(t *testing.T) {
+		poolCfg := newPoolCfg(t)
+		applySessionTimeouts(poolCfg, config.DatabaseConfig{StatementTimeoutMs: 30000, IdleInTxTimeoutMs: 60000})
+
+		assert.Equal(t, "30000", poolCfg.ConnConfig.RuntimeParams["statement_timeout"])
+		assert.Equal(t, "60000", poolCfg.ConnConfig.RuntimeParams["idle_in_transaction_session_timeout"])
+	})
+
+	t.Run("zero_leaves_server_default", func(t *testing.T) {
+		poolCfg := newPoolCfg(t)
+		applySessionTimeouts(poolCfg, config.DatabaseConfig{})
+
+		assert.NotContains(t, poolCfg.ConnConfig.RuntimeParams, "statement_timeout")
+		assert.NotContains(t, poolCfg.ConnConfig.RuntimeParams, "idle_in_transaction_session_timeout")
+	})
+}
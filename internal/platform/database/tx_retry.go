@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/jackc/pgx/v5"
+)
+
+// Retry reasons passed to RetryPolicy.OnRetry and OnExhausted
+const (
+	RetryReasonSerialization = "serialization_failure"
+	RetryReasonDeadlock      = "deadlock_detected"
+)
+
+// RetryPolicy controls how WithTx retries transactions that fail with a
+// serialization failure or a deadlock. Zero fields take their defaults.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts including the first; 1 disables retries (default: 3)
+	Backoff     time.Duration // Delay before the first retry, doubled for each later one (default: 10ms)
+	MaxBackoff  time.Duration // Upper bound on a single delay (default: 1s)
+
+	// OnRetry is called before each retry with the reason, e.g. to count
+	// it. Optional.
+	OnRetry func(reason string)
+	// OnExhausted is called when the last attempt fails with a retryable
+	// error. Optional.
+	OnExhausted func(reason string)
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 10 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Second
+	}
+	return p
+}
+
+// delay returns the backoff before retry number attempt (1-based): Backoff
+// doubled per attempt, capped at MaxBackoff, with jitter over its upper half
+// so conflicting transactions do not retry in lockstep.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxBackoff)
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// TxOption configures a single WithTx call.
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	pgx         pgx.TxOptions
+	maxAttempts int
+}
+
+// WithIsolation runs the transaction at level, e.g. pgx.Serializable or
+// pgx.RepeatableRead. The default is the server's (read committed). Stricter
+// levels fail with serialization failures under contention; WithTx retries
+// those.
+func WithIsolation(level pgx.TxIsoLevel) TxOption {
+	return func(o *txOptions) {
+		o.pgx.IsoLevel = level
+	}
+}
+
+// WithMaxAttempts overrides RetryPolicy.MaxAttempts for this call. n <= 0
+// keeps the policy's value.
+func WithMaxAttempts(n int) TxOption {
+	return func(o *txOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// retryReason returns why err is worth retrying, or "" if it is not.
+func retryReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case pgutil.IsSerializationFailure(err):
+		return RetryReasonSerialization
+	case pgutil.IsDeadlock(err):
+		return RetryReasonDeadlock
+	default:
+		return ""
+	}
+}
+
+// sleepCtx waits for d, or until ctx is done. It reports whether the full
+// delay elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,120 @@
+// Package feature evaluates boolean feature flags.
+//
+// Flags are declared in config (features.flags). A request may carry
+// per-request overrides (parsed from the X-Feature-Override header by
+// middleware.FeatureOverride) so QA can exercise dark-launched code paths
+// without flipping the flag for everyone. Overrides are honoured only when the
+// Flags instance allows them globally (non-production) or the caller is an
+// admin — the admin check runs lazily at evaluation time, after the route's
+// Auth middleware has placed the user ID on the context.
+package feature
+
+import (
+	"context"
+	"strings"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// AdminChecker reports whether userID may use feature overrides in
+// environments where overrides are not globally allowed.
+type AdminChecker func(userID string) bool
+
+// Config holds feature flag configuration
+type Config struct {
+	Defaults       map[string]bool // Flag name → default state
+	AllowOverrides bool            // Honour overrides for every caller (non-production)
+	IsAdmin        AdminChecker    // Optional; gates overrides when AllowOverrides is false
+}
+
+// Flags evaluates feature flags with optional per-request overrides.
+// A nil *Flags reports every flag as disabled.
+type Flags struct {
+	defaults       map[string]bool
+	allowOverrides bool
+	isAdmin        AdminChecker
+}
+
+// New creates a Flags evaluator. The defaults map is copied.
+func New(cfg Config) *Flags {
+	defaults := make(map[string]bool, len(cfg.Defaults))
+	for k, v := range cfg.Defaults {
+		defaults[k] = v
+	}
+	return &Flags{
+		defaults:       defaults,
+		allowOverrides: cfg.AllowOverrides,
+		isAdmin:        cfg.IsAdmin,
+	}
+}
+
+// Enabled reports whether the named flag is on for the request carried by ctx.
+// A permitted override wins over the configured default; unknown flags are off.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	if f == nil {
+		return false
+	}
+	if overrides, ok := OverridesFromContext(ctx); ok {
+		if v, found := overrides[name]; found && f.overridePermitted(ctx) {
+			return v
+		}
+	}
+	return f.defaults[name]
+}
+
+// overridePermitted applies the non-production-or-admin rule.
+func (f *Flags) overridePermitted(ctx context.Context) bool {
+	if f.allowOverrides {
+		return true
+	}
+	if f.isAdmin == nil {
+		return false
+	}
+	userID, _ := ctx.Value(logger.UserIDKey).(string)
+	if userID == "" {
+		return false
+	}
+	return f.isAdmin(userID)
+}
+
+type overridesKey struct{}
+
+// WithOverrides returns a context carrying per-request flag overrides.
+func WithOverrides(ctx context.Context, overrides map[string]bool) context.Context {
+	return context.WithValue(ctx, overridesKey{}, overrides)
+}
+
+// OverridesFromContext returns the overrides stored in ctx, if any.
+func OverridesFromContext(ctx context.Context) (map[string]bool, bool) {
+	o, ok := ctx.Value(overridesKey{}).(map[string]bool)
+	return o, ok
+}
+
+// ParseOverrides parses a header value of the form "flag-a=on,flag-b=off".
+// Accepted values are on/off, true/false and 1/0; a bare name means on.
+// Malformed entries are skipped.
+func ParseOverrides(header string) map[string]bool {
+	overrides := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !hasValue {
+			overrides[name] = true
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "on", "true", "1":
+			overrides[name] = true
+		case "off", "false", "0":
+			overrides[name] = false
+		}
+	}
+	return overrides
+}
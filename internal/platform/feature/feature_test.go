@@ -0,0 +1,81 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnabled_UsesDefaults(t *testing.T) {
+	f := New(Config{Defaults: map[string]bool{"beta": true, "legacy": false}})
+
+	assert.True(t, f.Enabled(context.Background(), "beta"))
+	assert.False(t, f.Enabled(context.Background(), "legacy"))
+	assert.False(t, f.Enabled(context.Background(), "unknown"))
+}
+
+func TestEnabled_NilFlags(t *testing.T) {
+	var f *Flags
+	assert.False(t, f.Enabled(context.Background(), "beta"))
+}
+
+func TestEnabled_OverrideAllowedOutsideProduction(t *testing.T) {
+	f := New(Config{Defaults: map[string]bool{"beta": false}, AllowOverrides: true})
+	ctx := WithOverrides(context.Background(), map[string]bool{"beta": true})
+
+	assert.True(t, f.Enabled(ctx, "beta"))
+}
+
+func TestEnabled_OverrideRequiresAdminInProduction(t *testing.T) {
+	f := New(Config{
+		Defaults: map[string]bool{"beta": false},
+		IsAdmin:  func(userID string) bool { return userID == "admin-1" },
+	})
+	overrides := map[string]bool{"beta": true}
+
+	anon := WithOverrides(context.Background(), overrides)
+	assert.False(t, f.Enabled(anon, "beta"), "anonymous caller must not override")
+
+	user := context.WithValue(anon, logger.UserIDKey, "user-1")
+	assert.False(t, f.Enabled(user, "beta"), "non-admin must not override")
+
+	admin := context.WithValue(anon, logger.UserIDKey, "admin-1")
+	assert.True(t, f.Enabled(admin, "beta"))
+}
+
+func TestEnabled_OverrideIgnoredWithoutAdminChecker(t *testing.T) {
+	f := New(Config{Defaults: map[string]bool{"beta": false}})
+	ctx := context.WithValue(context.Background(), logger.UserIDKey, "admin-1")
+	ctx = WithOverrides(ctx, map[string]bool{"beta": true})
+
+	assert.False(t, f.Enabled(ctx, "beta"))
+}
+
+func TestNew_CopiesDefaults(t *testing.T) {
+	defaults := map[string]bool{"beta": true}
+	f := New(Config{Defaults: defaults})
+	defaults["beta"] = false
+
+	assert.True(t, f.Enabled(context.Background(), "beta"))
+}
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]bool
+	}{
+		{"on/off", "a=on,b=off", map[string]bool{"a": true, "b": false}},
+		{"bool and numeric", "a=true, b=0", map[string]bool{"a": true, "b": false}},
+		{"bare name means on", "a", map[string]bool{"a": true}},
+		{"malformed value skipped", "a=maybe,b=1", map[string]bool{"b": true}},
+		{"empty entries skipped", " ,=on,,a=OFF", map[string]bool{"a": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseOverrides(tt.header))
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// Package honeytoken detects the use of canary credentials.
+//
+// A canary is a credential that no legitimate client ever uses: a user
+// account nobody logs into, or an API key planted in a config file, a
+// backup or a CI secret store. Any use of one means the place it was planted
+// has leaked, so a Detector raises a high-priority alert the moment it is
+// presented. Canary users can never log in, and canary API keys are rejected.
+// The attacker sees the same error as for any bad credential.
+//
+// An alert is logged at error level and written to the audit log as an ALERT
+// entry, so it also reaches the SIEM export. It is emailed to the configured
+// addresses. With lockdown on, the source IP is also refused for a while.
+package honeytoken
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// Kinds of canary credentials
+const (
+	KindUser   = "user"
+	KindAPIKey = "api_key"
+)
+
+// blockKeyPrefix prefixes the cache keys of locked-down IPs
+const blockKeyPrefix = "honeytoken:block:"
+
+// Config holds detector settings
+type Config struct {
+	// Users are the emails of canary accounts
+	Users []string
+	// NormalizeEmail canonicalizes emails the way user lookups do, so every
+	// spelling that reaches a canary account trips it. Defaults to trimming
+	// and lower-casing.
+	NormalizeEmail func(string) string
+	// APIKeyHashes are the SHA-256 hex digests of canary API keys. Only
+	// digests are configured, so the configuration itself holds no usable
+	// canary.
+	APIKeyHashes []string
+	// Notify lists the addresses alerts are emailed to
+	Notify []string
+	// Lockdown refuses every request from the source IP of a tripped canary
+	// for LockdownTTL (default: 1h).
+	Lockdown    bool
+	LockdownTTL time.Duration
+	// NotifyTimeout bounds sending the alert email (default: 10s)
+	NotifyTimeout time.Duration
+
+	Auditor port.Auditor
+	Email   port.EmailSender
+	// Cache shares lockdowns between instances. Without it they are local to
+	// the process.
+	Cache  port.Cache
+	Logger *logger.Logger
+	// OnTrip is called for every tripped canary, e.g. to count it. Optional.
+	OnTrip func(kind string)
+}
+
+// Hit describes one use of a canary credential
+type Hit struct {
+	Kind string
+	// Credential identifies the canary: the email of a canary user, or the
+	// first 12 characters of a canary API key's digest.
+	Credential string
+	IPAddress  string
+	UserAgent  string
+	Method     string
+	Path       string
+}
+
+// Detector recognises canary credentials and raises alerts for them. A nil
+// *Detector recognises nothing.
+type Detector struct {
+	cfg   Config
+	users map[string]bool
+	keys  map[string]bool
+
+	mu      sync.Mutex
+	blocked map[string]time.Time // used without a cache
+
+	wg sync.WaitGroup
+}
+
+// New creates a detector
+func New(cfg Config) *Detector {
+	if cfg.LockdownTTL <= 0 {
+		cfg.LockdownTTL = time.Hour
+	}
+	if cfg.NotifyTimeout <= 0 {
+		cfg.NotifyTimeout = 10 * time.Second
+	}
+	if cfg.NormalizeEmail == nil {
+		cfg.NormalizeEmail = func(email string) string {
+			return strings.ToLower(strings.TrimSpace(email))
+		}
+	}
+	d := &Detector{
+		cfg:     cfg,
+		users:   make(map[string]bool, len(cfg.Users)),
+		keys:    make(map[string]bool, len(cfg.APIKeyHashes)),
+		blocked: make(map[string]time.Time),
+	}
+	for _, u := range cfg.Users {
+		d.users[cfg.NormalizeEmail(u)] = true
+	}
+	for _, h := range cfg.APIKeyHashes {
+		d.keys[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	return d
+}
+
+// HashAPIKey returns the digest to configure for a canary API key
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// IsUser reports whether email belongs to a canary account
+func (d *Detector) IsUser(email string) bool {
+	return d != nil && len(d.users) > 0 && d.users[d.cfg.NormalizeEmail(email)]
+}
+
+// MatchAPIKey reports whether key is a canary API key, and returns the
+// identifier alerts use for it.
+func (d *Detector) MatchAPIKey(key string) (string, bool) {
+	if d == nil || len(d.keys) == 0 || key == "" {
+		return "", false
+	}
+	digest := HashAPIKey(key)
+	if !d.keys[digest] {
+		return "", false
+	}
+	return digest[:12], true
+}
+
+// Trip raises the alert for hit. It returns once the alert is logged,
+// audited and the lockdown is in place. The email is sent in the
+// background, so the response time does not tell the caller anything.
+func (d *Detector) Trip(ctx context.Context, hit Hit) {
+	if d == nil {
+		return
+	}
+	if d.cfg.OnTrip != nil {
+		d.cfg.OnTrip(hit.Kind)
+	}
+	if d.cfg.Logger != nil {
+		d.cfg.Logger.Error("honeytoken tripped: a canary credential was used",
+			"kind", hit.Kind, "credential", hit.Credential,
+			"ip", hit.IPAddress, "user_agent", hit.UserAgent, "method", hit.Method, "path", hit.Path,
+			"lockdown", d.cfg.Lockdown && hit.IPAddress != "")
+	}
+
+	if d.cfg.Auditor != nil {
+		entry := port.NewAuditEntry(ctx, port.AuditActionAlert, "honeytoken", hit.Credential)
+		entry.IPAddress, entry.UserAgent = hit.IPAddress, hit.UserAgent
+		entry.Metadata = map[string]any{
+			"alert":    "honeytoken_used",
+			"kind":     hit.Kind,
+			"severity": "critical",
+			"method":   hit.Method,
+			"path":     hit.Path,
+		}
+		if err := d.cfg.Auditor.Log(context.WithoutCancel(ctx), entry); err != nil && d.cfg.Logger != nil {
+			d.cfg.Logger.Error("failed to audit honeytoken alert", "error", err)
+		}
+	}
+
+	if d.cfg.Lockdown && hit.IPAddress != "" {
+		d.block(context.WithoutCancel(ctx), hit.IPAddress)
+	}
+
+	if d.cfg.Email != nil && len(d.cfg.Notify) > 0 {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.notify(hit)
+		}()
+	}
+}
+
+// Blocked reports whether ip is locked down
+func (d *Detector) Blocked(ctx context.Context, ip string) bool {
+	if d == nil || !d.cfg.Lockdown || ip == "" {
+		return false
+	}
+	if d.cfg.Cache != nil {
+		ok, err := d.cfg.Cache.Exists(ctx, blockKeyPrefix+ip)
+		return err == nil && ok
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	until, ok := d.blocked[ip]
+	if ok && time.Now().After(until) {
+		delete(d.blocked, ip)
+		return false
+	}
+	return ok
+}
+
+// Close waits for alert emails still being sent
+func (d *Detector) Close() {
+	if d != nil {
+		d.wg.Wait()
+	}
+}
+
+func (d *Detector) block(ctx context.Context, ip string) {
+	if d.cfg.Cache != nil {
+		if err := d.cfg.Cache.Set(ctx, blockKeyPrefix+ip, []byte("1"), d.cfg.LockdownTTL); err != nil && d.cfg.Logger != nil {
+			d.cfg.Logger.Error("failed to lock down honeytoken source", "ip", ip, "error", err)
+		}
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.blocked[ip] = time.Now().Add(d.cfg.LockdownTTL)
+}
+
+func (d *Detector) notify(hit Hit) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.NotifyTimeout)
+	defer cancel()
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "A canary credential was used. The place it was planted has probably leaked.\n\n")
+	fmt.Fprintf(&body, "Kind:        %s\n", hit.Kind)
+	fmt.Fprintf(&body, "Credential:  %s\n", hit.Credential)
+	fmt.Fprintf(&body, "Source IP:   %s\n", hit.IPAddress)
+	fmt.Fprintf(&body, "User-Agent:  %s\n", hit.UserAgent)
+	if hit.Path != "" {
+		fmt.Fprintf(&body, "Request:     %s %s\n", hit.Method, hit.Path)
+	}
+	fmt.Fprintf(&body, "Time:        %s\n", time.Now().UTC().Format(time.RFC3339))
+	if d.cfg.Lockdown && hit.IPAddress != "" {
+		fmt.Fprintf(&body, "\nThe source IP is locked out for %s.\n", d.cfg.LockdownTTL)
+	}
+
+	err := d.cfg.Email.Send(ctx, port.EmailMessage{
+		To:      d.cfg.Notify,
+		Subject: fmt.Sprintf("[CRITICAL] Honeytoken tripped: %s %s", hit.Kind, hit.Credential),
+		Body:    body.String(),
+	})
+	if err != nil && d.cfg.Logger != nil {
+		d.cfg.Logger.Error("failed to send honeytoken alert", "error", err)
+	}
+}
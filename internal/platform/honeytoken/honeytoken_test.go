@@ -0,0 +1,155 @@
+package honeytoken
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+type recordingAuditor struct {
+	mu      sync.Mutex
+	entries []port.AuditEntry
+}
+
+func (a *recordingAuditor) Log(_ context.Context, e port.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return nil, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []port.EmailMessage
+	err  error
+}
+
+func (s *recordingSender) Send(_ context.Context, msg port.EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return s.err
+}
+
+func (s *recordingSender) Close() error { return nil }
+
+func TestDetector_Matches(t *testing.T) {
+	d := New(Config{
+		Users:        []string{" Canary@Example.com "},
+		APIKeyHashes: []string{strings.ToUpper(HashAPIKey("sk_live_canary"))},
+	})
+
+	assert.True(t, d.IsUser("canary@example.com"))
+	assert.True(t, d.IsUser("CANARY@example.com"))
+	assert.False(t, d.IsUser("someone@example.com"))
+
+	id, ok := d.MatchAPIKey("sk_live_canary")
+	assert.True(t, ok)
+	assert.Equal(t, HashAPIKey("sk_live_canary")[:12], id)
+	_, ok = d.MatchAPIKey("sk_live_real")
+	assert.False(t, ok)
+	_, ok = d.MatchAPIKey("")
+	assert.False(t, ok)
+
+	t.Run("custom normalization", func(t *testing.T) {
+		d := New(Config{
+			Users:          []string{"canary@gmail.com"},
+			NormalizeEmail: func(e string) string { return strings.ReplaceAll(strings.ToLower(e), ".", "") },
+		})
+		assert.True(t, d.IsUser("Can.ary@gmail.com"))
+	})
+}
+
+func TestDetector_Nil(t *testing.T) {
+	var d *Detector
+	assert.False(t, d.IsUser("canary@example.com"))
+	_, ok := d.MatchAPIKey("key")
+	assert.False(t, ok)
+	assert.False(t, d.Blocked(context.Background(), "203.0.113.7"))
+	d.Trip(context.Background(), Hit{Kind: KindUser})
+	d.Close()
+}
+
+func TestDetector_Trip(t *testing.T) {
+	auditor := &recordingAuditor{}
+	sender := &recordingSender{}
+	var trips []string
+	d := New(Config{
+		Users:   []string{"canary@example.com"},
+		Notify:  []string{"security@example.com"},
+		Auditor: auditor,
+		Email:   sender,
+		OnTrip:  func(kind string) { trips = append(trips, kind) },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // the alert must survive the request going away
+	d.Trip(ctx, Hit{Kind: KindAPIKey, Credential: "0123456789ab", IPAddress: "203.0.113.7", UserAgent: "curl/8.0", Method: "GET", Path: "/api/users"})
+	d.Close()
+
+	assert.Equal(t, []string{KindAPIKey}, trips)
+	require.Len(t, auditor.entries, 1)
+	entry := auditor.entries[0]
+	assert.Equal(t, port.AuditActionAlert, entry.Action)
+	assert.Equal(t, "honeytoken", entry.Resource)
+	assert.Equal(t, "0123456789ab", entry.ResourceID)
+	assert.Equal(t, "203.0.113.7", entry.IPAddress)
+	assert.Equal(t, "critical", entry.Metadata["severity"])
+	assert.Equal(t, KindAPIKey, entry.Metadata["kind"])
+
+	require.Len(t, sender.sent, 1)
+	msg := sender.sent[0]
+	assert.Equal(t, []string{"security@example.com"}, msg.To)
+	assert.Contains(t, msg.Subject, "[CRITICAL]")
+	assert.Contains(t, msg.Body, "203.0.113.7")
+	assert.Contains(t, msg.Body, "GET /api/users")
+	assert.NotContains(t, msg.Body, "locked out")
+
+	assert.False(t, d.Blocked(context.Background(), "203.0.113.7"), "no lockdown unless enabled")
+}
+
+func TestDetector_TripSurvivesEmailFailure(t *testing.T) {
+	auditor := &recordingAuditor{}
+	d := New(Config{Notify: []string{"security@example.com"}, Auditor: auditor, Email: &recordingSender{err: errors.New("smtp down")}})
+	d.Trip(context.Background(), Hit{Kind: KindUser, Credential: "canary@example.com"})
+	d.Close()
+	assert.Len(t, auditor.entries, 1)
+}
+
+func TestDetector_Lockdown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = redisCache.Close() })
+
+	for name, c := range map[string]port.Cache{"memory": nil, "redis": redisCache} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			d := New(Config{Lockdown: true, LockdownTTL: 50 * time.Millisecond, Cache: c})
+			d.Trip(ctx, Hit{Kind: KindUser, Credential: "canary@example.com", IPAddress: "203.0.113.7"})
+
+			assert.True(t, d.Blocked(ctx, "203.0.113.7"))
+			assert.False(t, d.Blocked(ctx, "198.51.100.1"))
+
+			time.Sleep(60 * time.Millisecond)
+			mr.FastForward(60 * time.Millisecond)
+			assert.False(t, d.Blocked(ctx, "203.0.113.7"), "lockdown expires")
+		})
+	}
+}
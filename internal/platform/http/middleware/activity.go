@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ActivityRecorder persists a user's last activity time.
+// *userrepo.Repository satisfies it.
+type ActivityRecorder interface {
+	TouchLastSeen(ctx context.Context, userID string, at time.Time) error
+}
+
+// ActivityConfig holds activity tracking configuration
+type ActivityConfig struct {
+	Recorder ActivityRecorder
+	Cache    port.Cache    // Cross-instance debounce; NoOp falls back to per-instance only
+	Interval time.Duration // Minimum time between writes per user (default: 5 minutes)
+	Timeout  time.Duration // Bound on the debounce + write (default: 1 second)
+}
+
+// activityKeyPrefix namespaces debounce keys in the cache.
+const activityKeyPrefix = "activity:last_seen:"
+
+// maxActivityEntries bounds the per-instance debounce map; beyond it, expired
+// entries are swept before inserting.
+const maxActivityEntries = 10000
+
+// Activity returns a middleware that records last_seen_at for authenticated
+// users. It runs globally and inspects the user ID after the handler chain,
+// so it picks up whatever route-level Auth placed in Locals. Requests made
+// with an impersonation token are not recorded.
+//
+// Writes are debounced twice: a per-instance map skips users seen within
+// Interval without touching Redis, and a Redis counter (INCR + EXPIRE) lets
+// only one instance per Interval write to the database. At most one UPDATE
+// per user per Interval reaches Postgres. Failures are logged and never
+// affect the response.
+func Activity(cfg ActivityConfig) fiber.Handler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 1 * time.Second
+	}
+
+	t := &activityTracker{cfg: cfg, seen: make(map[string]time.Time)}
+
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		// Requests made by an admin impersonating the user are not theirs.
+		if userID := GetUserID(c); userID != "" && GetActorID(c) == "" {
+			// Clone: the ID may alias fasthttp's request buffer, which is
+			// reused after the handler returns, and it is kept as a map key.
+			t.touch(c.UserContext(), strings.Clone(userID), time.Now())
+		}
+		return err
+	}
+}
+
+type activityTracker struct {
+	cfg  ActivityConfig
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// touch records activity for userID unless it was recorded within Interval.
+func (t *activityTracker) touch(parent context.Context, userID string, now time.Time) {
+	if !t.claimLocal(userID, now) {
+		return
+	}
+
+	// The response is already built; a cancelled request context must not
+	// abort the write, but it still needs a bound.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), t.cfg.Timeout)
+	defer cancel()
+
+	if t.cfg.Cache != nil {
+		key := activityKeyPrefix + userID
+		n, err := t.cfg.Cache.Increment(ctx, key)
+		switch {
+		case err != nil:
+			// Redis down: fall back to per-instance debounce only.
+			slog.Warn("activity debounce unavailable", "user_id", userID, "error", err)
+		case n > 1:
+			return // another instance recorded this user within Interval
+		case n == 1:
+			if err := t.cfg.Cache.Expire(ctx, key, t.cfg.Interval); err != nil {
+				slog.Warn("activity debounce expire failed", "user_id", userID, "error", err)
+			}
+		}
+		// n == 0 is the NoOp cache: per-instance debounce only.
+	}
+
+	if err := t.cfg.Recorder.TouchLastSeen(ctx, userID, now); err != nil {
+		slog.Error("failed to record user activity", "user_id", userID, "error", err)
+	}
+}
+
+// claimLocal reports whether userID is due for a write on this instance and,
+// if so, marks it as written at now.
+func (t *activityTracker) claimLocal(userID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.seen[userID]; ok && now.Sub(last) < t.cfg.Interval {
+		return false
+	}
+
+	if len(t.seen) >= maxActivityEntries {
+		for id, last := range t.seen {
+			if now.Sub(last) >= t.cfg.Interval {
+				delete(t.seen, id)
+			}
+		}
+	}
+	t.seen[userID] = now
+	return true
+}
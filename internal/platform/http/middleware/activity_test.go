@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeActivityRecorder struct {
+	mu    sync.Mutex
+	calls []string
+	err   error
+}
+
+func (r *fakeActivityRecorder) TouchLastSeen(_ context.Context, userID string, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, userID)
+	return r.err
+}
+
+func (r *fakeActivityRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls)
+}
+
+// newActivityApp mounts Activity globally and a route that sets user_id the
+// way Auth does, from the X-Test-User header.
+func newActivityApp(cfg ActivityConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(Activity(cfg))
+	app.Get("/", func(c *fiber.Ctx) error {
+		if uid := c.Get("X-Test-User"); uid != "" {
+			c.Locals("user_id", uid)
+		}
+		if actor := c.Get("X-Test-Actor"); actor != "" {
+			c.Locals("actor_id", actor)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func doActivityRequest(t *testing.T, app *fiber.App, userID string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestActivity_SkipsAnonymous(t *testing.T) {
+	rec := &fakeActivityRecorder{}
+	app := newActivityApp(ActivityConfig{Recorder: rec})
+
+	doActivityRequest(t, app, "")
+	assert.Zero(t, rec.count())
+}
+
+func TestActivity_SkipsImpersonation(t *testing.T) {
+	rec := &fakeActivityRecorder{}
+	app := newActivityApp(ActivityConfig{Recorder: rec})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Test-User", "u-1")
+	req.Header.Set("X-Test-Actor", "admin-1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Zero(t, rec.count())
+}
+
+func TestActivity_DebouncesPerInstance(t *testing.T) {
+	rec := &fakeActivityRecorder{}
+	app := newActivityApp(ActivityConfig{Recorder: rec, Cache: cache.NewNoOpCache(), Interval: time.Minute})
+
+	doActivityRequest(t, app, "u-1")
+	doActivityRequest(t, app, "u-1")
+	doActivityRequest(t, app, "u-2")
+
+	assert.Equal(t, []string{"u-1", "u-2"}, rec.calls)
+}
+
+func TestActivity_DebouncesAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	rec := &fakeActivityRecorder{}
+	cfg := ActivityConfig{Recorder: rec, Cache: redisCache, Interval: time.Minute}
+
+	// Two instances share Redis; only the first should write.
+	doActivityRequest(t, newActivityApp(cfg), "u-1")
+	doActivityRequest(t, newActivityApp(cfg), "u-1")
+	assert.Equal(t, 1, rec.count())
+
+	ttl := mr.TTL(activityKeyPrefix + "u-1")
+	assert.Equal(t, time.Minute, ttl, "debounce key must expire after Interval")
+
+	// Once the key expires, a fresh instance writes again.
+	mr.FastForward(time.Minute + time.Second)
+	doActivityRequest(t, newActivityApp(cfg), "u-1")
+	assert.Equal(t, 2, rec.count())
+}
+
+func TestActivity_RecorderErrorDoesNotFailRequest(t *testing.T) {
+	rec := &fakeActivityRecorder{err: errors.New("db down")}
+	app := newActivityApp(ActivityConfig{Recorder: rec})
+
+	doActivityRequest(t, app, "u-1")
+	assert.Equal(t, 1, rec.count())
+}
+
+func TestActivityTracker_SweepsExpiredEntries(t *testing.T) {
+	tr := &activityTracker{cfg: ActivityConfig{Interval: time.Minute}, seen: make(map[string]time.Time)}
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < maxActivityEntries; i++ {
+		tr.seen[string(rune(i))] = old
+	}
+
+	assert.True(t, tr.claimLocal("fresh", time.Now()))
+	assert.Len(t, tr.seen, 1, "expired entries should be swept once the map is full")
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubVerifier accepts the keys in keys
+type stubVerifier struct {
+	keys map[string]*authdomain.APIKeyPrincipal
+}
+
+func (v stubVerifier) VerifyAPIKey(_ context.Context, key string) (*authdomain.APIKeyPrincipal, error) {
+	if p, ok := v.keys[key]; ok {
+		return p, nil
+	}
+	return nil, apperr.ErrUnauthorized.WithMessage("Invalid API key")
+}
+
+func testVerifier(scopes ...string) stubVerifier {
+	return stubVerifier{keys: map[string]*authdomain.APIKeyPrincipal{
+		"gsk_good": {KeyID: "key-1", UserID: "user-1", Email: "bot@example.com", Name: "Bot", Scopes: scopes},
+	}}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	app := fiber.New()
+	app.Get("/test", APIKeyAuth(APIKeyAuthConfig{Verifier: testVerifier("*")}), func(c *fiber.Ctx) error {
+		claims := GetClaims(c)
+		require.NotNil(t, claims)
+		assert.Equal(t, "user-1", claims.UserID)
+		assert.Equal(t, "bot@example.com", claims.Email)
+		assert.Equal(t, "user-1", GetUserID(c))
+		require.NotNil(t, GetAPIKey(c))
+		assert.Equal(t, "key-1", GetAPIKey(c).KeyID)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"valid key", "gsk_good", http.StatusOK},
+		{"missing key", "", http.StatusUnauthorized},
+		{"unknown key", "gsk_bad", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.key != "" {
+				req.Header.Set(DefaultAPIKeyHeader, tt.key)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAuthOrAPIKey(t *testing.T) {
+	jwtAuth := func(c *fiber.Ctx) error {
+		c.Locals("user_id", "jwt-user")
+		return c.Next()
+	}
+	app := fiber.New()
+	app.Get("/test", AuthOrAPIKey(jwtAuth, APIKeyAuthConfig{Verifier: testVerifier("*")}), func(c *fiber.Ctx) error {
+		return c.SendString(GetUserID(c))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(DefaultAPIKeyHeader, "gsk_good")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", readBody(t, resp))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "jwt-user", readBody(t, resp), "requests without the header go to JWT auth")
+
+	req = httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(DefaultAPIKeyHeader, "gsk_bad")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "a bad key does not fall back to JWT auth")
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	allowAll := &mockAuthorizer{
+		enforceFunc:        func(_, _, _ string) (bool, error) { return true, nil },
+		hasRoleForUserFunc: func(_, _ string) (bool, error) { return true, nil },
+	}
+
+	tests := []struct {
+		name    string
+		scopes  []string
+		handler fiber.Handler
+		status  int
+	}{
+		{"exact scope", []string{"users:read"}, RequirePermission(allowAll, "users", "read"), http.StatusOK},
+		{"object wildcard", []string{"users:*"}, RequirePermission(allowAll, "users", "read"), http.StatusOK},
+		{"full access", []string{"*"}, RequirePermission(allowAll, "users", "read"), http.StatusOK},
+		{"other action", []string{"users:read"}, RequirePermission(allowAll, "users", "delete"), http.StatusForbidden},
+		{"other object", []string{"roles:*"}, RequirePermission(allowAll, "users", "read"), http.StatusForbidden},
+		{"no scopes", nil, RequirePermission(allowAll, "users", "read"), http.StatusForbidden},
+		{"any permission, one in scope", []string{"users:update"}, RequireAnyPermission(allowAll, "users:delete", "users:update"), http.StatusOK},
+		{"any permission, none in scope", []string{"users:read"}, RequireAnyPermission(allowAll, "users:delete", "users:update"), http.StatusForbidden},
+		{"all permissions, one out of scope", []string{"users:read"}, RequireAllPermissions(allowAll, "users:read", "users:update"), http.StatusForbidden},
+		{"role with full access", []string{"*"}, RequireRole(allowAll, "admin"), http.StatusOK},
+		{"role with scoped key", []string{"users:*"}, RequireRole(allowAll, "admin"), http.StatusForbidden},
+		{"any role with scoped key", []string{"users:*"}, RequireAnyRole(allowAll, "admin"), http.StatusForbidden},
+		{"explicit scope", []string{"users:read"}, RequireAPIKeyScope("*"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/test", APIKeyAuth(APIKeyAuthConfig{Verifier: testVerifier(tt.scopes...)}), tt.handler, func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(DefaultAPIKeyHeader, "gsk_good")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAPIKeyScopes_OwnerPermissionStillRequired(t *testing.T) {
+	denyAll := &mockAuthorizer{}
+	app := fiber.New()
+	app.Get("/test", APIKeyAuth(APIKeyAuthConfig{Verifier: testVerifier("*")}), RequirePermission(denyAll, "users", "read"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(DefaultAPIKeyHeader, "gsk_good")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestRequireAPIKeyScope_JWTRequestPasses(t *testing.T) {
+	app := setupAuthzApp(RequireAPIKeyScope("*"), "user-1")
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
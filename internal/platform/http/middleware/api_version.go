@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeUnsupportedAPIVersion is the error code returned when the Accept
+// header asks only for response versions the API does not serve
+const CodeUnsupportedAPIVersion = "UNSUPPORTED_API_VERSION"
+
+// DefaultVendor is the vendor in the versioned media types,
+// application/vnd.goscratch.vN+json
+const DefaultVendor = "goscratch"
+
+// APIVersionConfig holds API version negotiation configuration
+type APIVersionConfig struct {
+	// Versions holds the response transformers; its Latest is the highest
+	// version clients can ask for.
+	Versions *response.Versions
+	// Default is the version served to clients that do not ask for one
+	// (default: 1).
+	Default int
+	// Vendor names the vendor media types (default: DefaultVendor)
+	Vendor string
+}
+
+// APIVersion returns a middleware that negotiates the response version from
+// the Accept header. A client asks for version N with
+// application/vnd.<vendor>.vN+json and gets responses of that media type,
+// built by response.Success and friends through cfg.Versions. Clients that
+// do not ask get cfg.Default as plain application/json. When every vendor
+// type in Accept names an unknown version and Accept offers no generic JSON
+// fallback, the request is refused with 406 UNSUPPORTED_API_VERSION.
+func APIVersion(cfg APIVersionConfig) fiber.Handler {
+	if cfg.Default <= 0 {
+		cfg.Default = 1
+	}
+	if cfg.Vendor == "" {
+		cfg.Vendor = DefaultVendor
+	}
+	prefix := "application/vnd." + strings.ToLower(cfg.Vendor) + ".v"
+
+	return func(c *fiber.Ctx) error {
+		c.Vary(fiber.HeaderAccept)
+		latest := cfg.Versions.Latest()
+
+		version, refused := negotiateVersion(c.Get(fiber.HeaderAccept), prefix, latest)
+		explicit := version > 0
+		if !explicit {
+			if refused {
+				return response.Fail(c, apperr.New(CodeUnsupportedAPIVersion,
+					fmt.Sprintf("Unsupported API version; supported versions are 1 to %d", latest),
+					fiber.StatusNotAcceptable))
+			}
+			version = min(cfg.Default, latest)
+		}
+		response.SetVersion(c, cfg.Versions, cfg.Vendor, version, explicit)
+		return c.Next()
+	}
+}
+
+// negotiateVersion picks the supported vendor version the client prefers,
+// by q value and then by order. It returns 0 when there is none, with
+// refused set when the client asked only for unsupported versions.
+func negotiateVersion(accept, prefix string, latest int) (version int, refused bool) {
+	if accept == "" {
+		return 0, false
+	}
+	bestQ := 0.0
+	asked, fallback := false, false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		switch mediaType {
+		case fiber.MIMEApplicationJSON, "application/*", "*/*":
+			fallback = true
+			continue
+		}
+		n, ok := strings.CutPrefix(mediaType, prefix)
+		if !ok {
+			continue
+		}
+		n, ok = strings.CutSuffix(n, "+json")
+		if !ok {
+			continue
+		}
+		asked = true
+		v, err := strconv.Atoi(n)
+		if err != nil || v < 1 || v > latest {
+			continue
+		}
+		if q > bestQ {
+			version, bestQ = v, q
+		}
+	}
+	return version, version == 0 && asked && !fallback
+}
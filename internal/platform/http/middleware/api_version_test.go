@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gadget struct {
+	Name string `json:"name"`
+}
+
+func TestAPIVersion(t *testing.T) {
+	versions := response.NewVersions()
+	response.Register(versions, 2, func(g gadget) any { return map[string]string{"title": g.Name} })
+
+	newApp := func(def int) *fiber.App {
+		app := fiber.New()
+		app.Use(APIVersion(APIVersionConfig{Versions: versions, Default: def}))
+		app.Get("/gadget", func(c *fiber.Ctx) error {
+			c.Set("X-Version", string(rune('0'+response.Version(c))))
+			return response.Success(c, gadget{Name: "Gear"})
+		})
+		return app
+	}
+
+	tests := []struct {
+		name        string
+		def         int
+		accept      string
+		status      int
+		version     string
+		contentType string
+		field       string
+	}{
+		{"no accept", 1, "", 200, "1", "application/json", "name"},
+		{"plain json", 1, "application/json", 200, "1", "application/json", "name"},
+		{"version 2", 1, "application/vnd.goscratch.v2+json", 200, "2", "application/vnd.goscratch.v2+json", "title"},
+		{"version 1 explicitly", 2, "application/vnd.goscratch.v1+json", 200, "1", "application/vnd.goscratch.v1+json", "name"},
+		{"default flipped to 2", 2, "application/json", 200, "2", "application/json", "title"},
+		{"case insensitive", 1, "Application/VND.GoScratch.V2+JSON", 200, "2", "application/vnd.goscratch.v2+json", "title"},
+		{"preferred by q", 1, "application/vnd.goscratch.v1+json;q=0.9, application/vnd.goscratch.v2+json", 200, "2", "application/vnd.goscratch.v2+json", "title"},
+		{"q=0 is refused", 1, "application/vnd.goscratch.v2+json;q=0, application/json", 200, "1", "application/json", "name"},
+		{"unknown version with fallback", 1, "application/vnd.goscratch.v9+json, application/json;q=0.5", 200, "1", "application/json", "name"},
+		{"unknown version skipped", 1, "application/vnd.goscratch.v9+json, application/vnd.goscratch.v2+json;q=0.5", 200, "2", "application/vnd.goscratch.v2+json", "title"},
+		{"unknown version only", 1, "application/vnd.goscratch.v9+json", 406, "", "application/json", ""},
+		{"other vendor", 1, "application/vnd.github.v3+json", 200, "1", "application/json", "name"},
+		{"browser", 1, "text/html,application/xhtml+xml", 200, "1", "application/json", "name"},
+		{"wildcard", 1, "application/vnd.goscratch.v9+json, */*", 200, "1", "application/json", "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/gadget", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := newApp(tt.def).Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, "Accept", resp.Header.Get("Vary"))
+			assert.Equal(t, tt.contentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.version, resp.Header.Get("X-Version"))
+
+			body, _ := io.ReadAll(resp.Body)
+			var out struct {
+				Data  map[string]any `json:"data"`
+				Error struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(body, &out))
+			if tt.field != "" {
+				assert.Equal(t, "Gear", out.Data[tt.field])
+			} else {
+				assert.Equal(t, CodeUnsupportedAPIVersion, out.Error.Code)
+			}
+		})
+	}
+}
+
+func TestAPIVersion_DefaultCappedAtLatest(t *testing.T) {
+	app := fiber.New()
+	app.Use(APIVersion(APIVersionConfig{Versions: response.NewVersions(), Default: 3}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(string(rune('0' + response.Version(c)))) })
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "1", string(body))
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/platform/http/pathmatch"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditRequestsConfig holds HTTP request auditing configuration
+type AuditRequestsConfig struct {
+	Auditor port.Auditor
+	// Include limits auditing to these path prefixes; empty audits every
+	// path. A prefix matches the path itself and everything below it,
+	// ignoring case as the router does.
+	Include []string
+	// Exclude skips these path prefixes, even when Include lists them
+	Exclude []string
+	Logger  *logger.Logger
+}
+
+// AuditRequests returns a middleware that writes an AuditActionRequest
+// entry for every POST, PUT, PATCH and DELETE request, so a mutation leaves
+// a trace even when its handler does not audit it. Like Activity it runs
+// globally and builds the entry after the handler chain, so the actor is
+// whoever route-level Auth authenticated. A failed write is logged and never
+// affects the response.
+func AuditRequests(cfg AuditRequestsConfig) fiber.Handler {
+	include := trimPrefixes(cfg.Include)
+	exclude := trimPrefixes(cfg.Exclude)
+
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return c.Next()
+		}
+		path := c.Path()
+		if (len(include) > 0 && !pathmatch.UnderAny(path, include)) || pathmatch.UnderAny(path, exclude) {
+			return c.Next()
+		}
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+		// Copied: the values alias fasthttp buffers reused after the handler
+		// returns, and an asynchronous auditor keeps the entry.
+		method := strings.Clone(c.Method())
+		route := c.Route().Path
+		entry := port.NewAuditEntry(c.UserContext(), port.AuditActionRequest, "http_request", method+" "+route)
+		if entry.IPAddress == "" {
+			entry.IPAddress = strings.Clone(c.IP())
+		}
+		if entry.UserAgent == "" {
+			entry.UserAgent = strings.Clone(c.Get(fiber.HeaderUserAgent))
+		}
+		if entry.RequestID == "" {
+			entry.RequestID = GetRequestID(c)
+		}
+		entry.Metadata = map[string]any{
+			"method": method,
+			"route":  route,
+			"path":   strings.Clone(path),
+			"status": status,
+		}
+		if status >= 400 {
+			entry.Metadata["outcome"] = "failed"
+		}
+		if lerr := cfg.Auditor.Log(context.WithoutCancel(c.UserContext()), entry); lerr != nil && cfg.Logger != nil {
+			cfg.Logger.Error("Failed to audit request", "method", method, "route", route, "error", lerr)
+		}
+		return err
+	}
+}
+
+// errorStatus is the status ErrorHandler will answer err with
+func errorStatus(err error) int {
+	if appErr, ok := apperr.AsAppError(err); ok {
+		return appErr.HTTPStatus
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// trimPrefixes drops the trailing slash of each prefix, and empty ones
+func trimPrefixes(prefixes []string) []string {
+	out := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		if p = strings.TrimSuffix(strings.TrimSpace(p), "/"); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditRequestsApp(cfg AuditRequestsConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Use(AuditRequests(cfg))
+	auth := func(c *fiber.Ctx) error {
+		c.Locals("user_id", "user-1")
+		c.SetUserContext(setContextValue(c.UserContext(), logger.UserIDKey, "user-1"))
+		return c.Next()
+	}
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Get("/api/users", ok)
+	app.Patch("/api/users/:id", auth, ok)
+	app.Delete("/api/users/:id", auth, func(*fiber.Ctx) error { return apperr.NotFoundf("user not found") })
+	app.Post("/api/auth/login", ok)
+	app.Post("/api/internal/sync", ok)
+	return app
+}
+
+func TestAuditRequests(t *testing.T) {
+	auditor := &recordingAuditor{}
+	app := newAuditRequestsApp(AuditRequestsConfig{Auditor: auditor, Exclude: []string{"/api/auth/"}})
+	do := func(method, path string) {
+		t.Helper()
+		_, err := app.Test(httptest.NewRequest(method, path, nil))
+		require.NoError(t, err)
+	}
+
+	do("GET", "/api/users")
+	do("POST", "/api/auth/login")
+	require.Empty(t, auditor.entries, "reads and excluded paths are not audited")
+
+	do("PATCH", "/api/users/42")
+	do("DELETE", "/api/users/7")
+	require.Len(t, auditor.entries, 2)
+
+	e := auditor.entries[0]
+	assert.Equal(t, port.AuditActionRequest, e.Action)
+	assert.Equal(t, "http_request", e.Resource)
+	assert.Equal(t, "PATCH /api/users/:id", e.ResourceID)
+	assert.Equal(t, "user-1", e.UserID, "the actor is read after route-level auth ran")
+	assert.NotEmpty(t, e.IPAddress)
+	assert.Equal(t, "PATCH", e.Metadata["method"])
+	assert.Equal(t, "/api/users/42", e.Metadata["path"])
+	assert.Equal(t, fiber.StatusNoContent, e.Metadata["status"])
+	assert.NotEmpty(t, e.RequestID)
+	assert.NotContains(t, e.Metadata, "outcome")
+
+	e = auditor.entries[1]
+	assert.Equal(t, fiber.StatusNotFound, e.Metadata["status"], "the status of a returned error")
+	assert.Equal(t, "failed", e.Metadata["outcome"])
+}
+
+func TestAuditRequests_Include(t *testing.T) {
+	auditor := &recordingAuditor{}
+	app := newAuditRequestsApp(AuditRequestsConfig{Auditor: auditor, Include: []string{"/api/users", "/api/internal"}, Exclude: []string{"/api/internal/sync"}})
+	for _, r := range [][2]string{{"POST", "/api/auth/login"}, {"POST", "/api/internal/sync"}, {"PATCH", "/api/users/1"}} {
+		_, err := app.Test(httptest.NewRequest(r[0], r[1], nil))
+		require.NoError(t, err)
+	}
+	require.Len(t, auditor.entries, 1)
+	assert.Equal(t, "PATCH /api/users/:id", auditor.entries[0].ResourceID)
+}
+
+func TestAuditRequests_IgnoresPathCase(t *testing.T) {
+	// The router serves /API/Users/1 from the /api/users/:id handler, so
+	// include and exclude have to match it the same way.
+	auditor := &recordingAuditor{}
+	app := newAuditRequestsApp(AuditRequestsConfig{Auditor: auditor, Include: []string{"/api/users", "/api/internal"}, Exclude: []string{"/api/internal/sync"}})
+	for _, r := range [][2]string{{"PATCH", "/API/Users/1"}, {"POST", "/api/INTERNAL/Sync"}} {
+		_, err := app.Test(httptest.NewRequest(r[0], r[1], nil))
+		require.NoError(t, err)
+	}
+	require.Len(t, auditor.entries, 1)
+	assert.Equal(t, "PATCH /api/users/:id", auditor.entries[0].ResourceID)
+}
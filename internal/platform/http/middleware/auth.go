@@ -1,10 +1,15 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
+	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/14mdzk/goscratch/pkg/response"
@@ -14,42 +19,99 @@ import (
 
 // AuthConfig holds authentication middleware configuration
 type AuthConfig struct {
+	// Keys verifies tokens. When nil, tokens are verified as HS256 with
+	// JWTSecret.
+	Keys         *jwtkeys.KeySet
 	JWTSecret    string
 	JWTIssuer    string
 	JWTAudience  string
 	TokenLookup  string // "header:Authorization" or "cookie:token"
 	ContextKey   string // Key to store user claims in context
 	ErrorHandler fiber.ErrorHandler
+	// Denylist, when set, is where revoked access tokens are looked up: by
+	// their jti (see authdomain.RevokedTokenKey; tokens without one are not
+	// checked) and by their user's token version (see
+	// authdomain.TokenVersionKey).
+	Denylist port.Cache
+	// PasswordChangeRoutes are the only routes ("METHOD /path") a token
+	// issued to a user who must change their password may call; every other
+	// route refuses it with 403 PASSWORD_CHANGE_REQUIRED. NewAuthConfig and
+	// DefaultAuthConfig set DefaultPasswordChangeRoutes.
+	PasswordChangeRoutes []string
+}
+
+// CodePasswordChangeRequired is the error code of requests refused because
+// the caller must change their password first
+const CodePasswordChangeRequired = "PASSWORD_CHANGE_REQUIRED"
+
+// DefaultPasswordChangeRoutes lets a user who must change their password do
+// so, or log out.
+var DefaultPasswordChangeRoutes = []string{
+	"POST /users/me/password",
+	"POST /auth/logout",
 }
 
 // DefaultAuthConfig returns default authentication configuration
 func DefaultAuthConfig(jwtSecret string) AuthConfig {
 	return AuthConfig{
-		JWTSecret:   jwtSecret,
-		JWTIssuer:   "goscratch",
-		JWTAudience: "goscratch-api",
-		TokenLookup: "header:Authorization",
-		ContextKey:  "user",
+		JWTSecret:            jwtSecret,
+		JWTIssuer:            "goscratch",
+		JWTAudience:          "goscratch-api",
+		TokenLookup:          "header:Authorization",
+		ContextKey:           "user",
+		PasswordChangeRoutes: DefaultPasswordChangeRoutes,
 	}
 }
 
+// NewAuthConfig returns the default configuration verifying tokens with keys,
+// issued by issuer for audience.
+func NewAuthConfig(keys *jwtkeys.KeySet, issuer, audience string) AuthConfig {
+	return AuthConfig{
+		Keys:                 keys,
+		JWTIssuer:            issuer,
+		JWTAudience:          audience,
+		TokenLookup:          "header:Authorization",
+		ContextKey:           "user",
+		PasswordChangeRoutes: DefaultPasswordChangeRoutes,
+	}
+}
+
+// keys returns the key set tokens are verified with
+func (cfg AuthConfig) keys() *jwtkeys.KeySet {
+	if cfg.Keys != nil {
+		return cfg.Keys
+	}
+	return jwtkeys.NewHMAC(cfg.JWTSecret)
+}
+
 // Claims is the JWT-library bound claims struct used only inside this
 // package for token parsing and signing. All other code uses authdomain.Claims.
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	SessionID string `json:"sid,omitempty"`
+	Version   int64  `json:"ver,omitempty"`
+	// MustChangePassword limits the token to cfg.PasswordChangeRoutes
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	// ActorID is the admin impersonating UserID with this token
+	ActorID string `json:"actor_id,omitempty"`
 }
 
 // toDomainClaims maps JWT library claims to the domain Claims type.
 func toDomainClaims(c *Claims) *authdomain.Claims {
 	dc := &authdomain.Claims{
-		Subject: c.Subject,
-		UserID:  c.UserID,
-		Email:   c.Email,
-		Name:    c.Name,
-		Issuer:  c.Issuer,
+		Subject:            c.Subject,
+		UserID:             c.UserID,
+		Email:              c.Email,
+		Name:               c.Name,
+		SessionID:          c.SessionID,
+		TokenID:            c.ID,
+		TokenVersion:       c.Version,
+		Issuer:             c.Issuer,
+		MustChangePassword: c.MustChangePassword,
+		ActorID:            c.ActorID,
 	}
 	if c.Audience != nil {
 		dc.Audience = []string(c.Audience)
@@ -68,6 +130,7 @@ func toDomainClaims(c *Claims) *authdomain.Claims {
 
 // Auth returns an authentication middleware
 func Auth(cfg AuthConfig) fiber.Handler {
+	keys := cfg.keys()
 	return func(c *fiber.Ctx) error {
 		// Extract token
 		token, err := extractToken(c, cfg.TokenLookup)
@@ -76,7 +139,7 @@ func Auth(cfg AuthConfig) fiber.Handler {
 		}
 
 		// Parse and validate token
-		raw, err := parseToken(token, cfg.JWTSecret, cfg.JWTIssuer, cfg.JWTAudience)
+		raw, err := parseToken(token, keys, cfg.JWTIssuer, cfg.JWTAudience)
 		if err != nil {
 			if errors.Is(err, jwt.ErrTokenExpired) {
 				return response.Unauthorized(c, "Token has expired")
@@ -85,48 +148,84 @@ func Auth(cfg AuthConfig) fiber.Handler {
 		}
 
 		claims := toDomainClaims(raw)
+		if revoked, err := isRevoked(c.UserContext(), cfg.Denylist, claims); err != nil {
+			// Fail closed: a revoked token must not pass while the cache is down.
+			return response.Fail(c, apperr.ErrServiceUnavailable)
+		} else if revoked {
+			return response.Unauthorized(c, "Token has been revoked")
+		}
+		if claims.MustChangePassword && !cfg.allowsPendingPasswordChange(c) {
+			return response.Fail(c, errPasswordChangeRequired)
+		}
 
-		// Store domain claims in context
-		c.Locals(cfg.ContextKey, claims)
-		c.Locals("user_id", claims.UserID)
-
-		// Add to user context for logger and auditor
-		ctx := c.UserContext()
-		ctx = setContextValue(ctx, logger.UserIDKey, claims.UserID)
-		ctx = setContextValue(ctx, logger.IPAddressKey, c.IP())
-		ctx = setContextValue(ctx, logger.UserAgentKey, c.Get("User-Agent"))
-		c.SetUserContext(ctx)
-
+		storeClaims(c, cfg, claims)
 		return c.Next()
 	}
 }
 
 // OptionalAuth is like Auth but doesn't fail if no token is present
 func OptionalAuth(cfg AuthConfig) fiber.Handler {
+	keys := cfg.keys()
 	return func(c *fiber.Ctx) error {
 		token, err := extractToken(c, cfg.TokenLookup)
 		if err != nil || token == "" {
 			return c.Next()
 		}
 
-		raw, err := parseToken(token, cfg.JWTSecret, cfg.JWTIssuer, cfg.JWTAudience)
+		raw, err := parseToken(token, keys, cfg.JWTIssuer, cfg.JWTAudience)
 		if err != nil {
 			return c.Next() // Invalid token, continue without auth
 		}
 
 		claims := toDomainClaims(raw)
+		if revoked, err := isRevoked(c.UserContext(), cfg.Denylist, claims); err != nil || revoked {
+			return c.Next()
+		}
+		if claims.MustChangePassword && !cfg.allowsPendingPasswordChange(c) {
+			return c.Next()
+		}
 
-		c.Locals(cfg.ContextKey, claims)
-		c.Locals("user_id", claims.UserID)
+		storeClaims(c, cfg, claims)
+		return c.Next()
+	}
+}
 
-		ctx := c.UserContext()
-		ctx = setContextValue(ctx, logger.UserIDKey, claims.UserID)
-		ctx = setContextValue(ctx, logger.IPAddressKey, c.IP())
-		ctx = setContextValue(ctx, logger.UserAgentKey, c.Get("User-Agent"))
-		c.SetUserContext(ctx)
+// storeClaims stores the caller's claims in Locals, and their identity and
+// client details in the user context for the logger and auditor. With an
+// impersonation token the actor is stored too, so audit entries record both.
+func storeClaims(c *fiber.Ctx, cfg AuthConfig, claims *authdomain.Claims) {
+	c.Locals(cfg.ContextKey, claims)
+	c.Locals("user_id", claims.UserID)
+	if claims.ActorID != "" {
+		c.Locals("actor_id", claims.ActorID)
+	}
 
-		return c.Next()
+	ctx := c.UserContext()
+	ctx = setContextValue(ctx, logger.UserIDKey, claims.UserID)
+	if claims.ActorID != "" {
+		ctx = setContextValue(ctx, logger.ActorIDKey, claims.ActorID)
 	}
+	ctx = setContextValue(ctx, logger.IPAddressKey, c.IP())
+	ctx = setContextValue(ctx, logger.UserAgentKey, c.Get("User-Agent"))
+	c.SetUserContext(ctx)
+}
+
+var errPasswordChangeRequired = apperr.New(CodePasswordChangeRequired, "Your password must be changed before continuing", fiber.StatusForbidden)
+
+// allowsPendingPasswordChange reports whether the request is to one of
+// cfg.PasswordChangeRoutes
+func (cfg AuthConfig) allowsPendingPasswordChange(c *fiber.Ctx) bool {
+	path := c.Path()
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	route := c.Method() + " " + path
+	for _, r := range cfg.PasswordChangeRoutes {
+		if r == route {
+			return true
+		}
+	}
+	return false
 }
 
 // extractToken extracts the token from the request
@@ -158,7 +257,11 @@ func extractToken(c *fiber.Ctx, lookup string) (string, error) {
 // audience checks. Both iss and aud must be non-empty in the server config; a
 // token that omits or mismatches either claim is unconditionally rejected
 // (should-fix: audit middleware/auth.go:129).
-func parseToken(tokenString, secret, issuer, audience string) (*Claims, error) {
+//
+// Only the algorithms of keys are accepted, and each token is checked with
+// the key its kid header names, so an HS256 token cannot pass as RS256 or the
+// reverse.
+func parseToken(tokenString string, keys *jwtkeys.KeySet, issuer, audience string) (*Claims, error) {
 	// Strict: the server config must provide both iss and aud (enforced by
 	// config.Validate). A call with empty issuer or audience is a programming
 	// error — reject the token immediately rather than skipping validation.
@@ -167,18 +270,12 @@ func parseToken(tokenString, secret, issuer, audience string) (*Claims, error) {
 	}
 
 	parserOpts := []jwt.ParserOption{
-		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithValidMethods(keys.Methods()),
 		jwt.WithIssuer(issuer),
 		jwt.WithAudience(audience),
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, apperr.ErrUnauthorized
-		}
-		return []byte(secret), nil
-	}, parserOpts...)
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.Keyfunc, parserOpts...)
 
 	if err != nil {
 		return nil, err
@@ -192,6 +289,39 @@ func parseToken(tokenString, secret, issuer, audience string) (*Claims, error) {
 	return claims, nil
 }
 
+// isRevoked reports whether the access token with claims has been revoked,
+// either on its own (its jti is on denylist) or by a bump of its user's token
+// version. A cache miss means not revoked; any other cache error is returned.
+func isRevoked(ctx context.Context, denylist port.Cache, claims *authdomain.Claims) (bool, error) {
+	if denylist == nil {
+		return false, nil
+	}
+	if claims.TokenID != "" {
+		_, err := denylist.Get(ctx, authdomain.RevokedTokenKey(claims.TokenID))
+		switch {
+		case err == nil:
+			return true, nil
+		case !errors.Is(err, port.ErrCacheMiss):
+			return false, err
+		}
+	}
+	if claims.UserID == "" {
+		return false, nil
+	}
+	b, err := denylist.Get(ctx, authdomain.TokenVersionKey(claims.UserID))
+	switch {
+	case errors.Is(err, port.ErrCacheMiss):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	version, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("token version of user %s: %w", claims.UserID, err)
+	}
+	return claims.TokenVersion < version, nil
+}
+
 // GetClaims retrieves the domain claims from context
 func GetClaims(c *fiber.Ctx) *authdomain.Claims {
 	if claims, ok := c.Locals("user").(*authdomain.Claims); ok {
@@ -207,3 +337,12 @@ func GetUserID(c *fiber.Ctx) string {
 	}
 	return ""
 }
+
+// GetActorID retrieves the ID of the admin impersonating the user, or ""
+// when the request is not made with an impersonation token
+func GetActorID(c *fiber.Ctx) string {
+	if actorID, ok := c.Locals("actor_id").(string); ok {
+		return actorID
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/perf"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// authBudgets caps allocations per op for the JWT hot path. Every
+// authenticated request pays for parseToken, so growth here is multiplied
+// by total request volume.
+var authBudgets = perf.Budgets{
+	"jwt.parse":        52,
+	"jwt.parse_claims": 54,
+}
+
+func signBenchToken(tb testing.TB) string {
+	tb.Helper()
+	claims := validClaims()
+	tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString([]byte(testJWTSecret))
+	require.NoError(tb, err)
+	return tokenStr
+}
+
+// BenchmarkParseToken measures HS256 verification plus strict iss/aud checks.
+func BenchmarkParseToken(b *testing.B) {
+	token := signBenchToken(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := parseToken(token, testKeys, "goscratch", "goscratch-api"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseToken_ToDomainClaims adds the domain-claims mapping done by Auth.
+func BenchmarkParseToken_ToDomainClaims(b *testing.B) {
+	token := signBenchToken(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		raw, err := parseToken(token, testKeys, "goscratch", "goscratch-api")
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = toDomainClaims(raw)
+	}
+}
+
+func TestAllocBudget_JWT(t *testing.T) {
+	token := signBenchToken(t)
+	authBudgets.Check(t, "jwt.parse", func() {
+		_, _ = parseToken(token, testKeys, "goscratch", "goscratch-api")
+	})
+	authBudgets.Check(t, "jwt.parse_claims", func() {
+		raw, _ := parseToken(token, testKeys, "goscratch", "goscratch-api")
+		_ = toDomainClaims(raw)
+	})
+}
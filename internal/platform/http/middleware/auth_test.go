@@ -2,14 +2,23 @@ package middleware
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
 	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
 	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +27,8 @@ import (
 
 const testJWTSecret = "test-secret-key-for-unit-tests"
 
+var testKeys = jwtkeys.NewHMAC(testJWTSecret)
+
 func generateTestToken(t *testing.T, secret string, claims Claims) string {
 	t.Helper()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
@@ -300,22 +311,22 @@ func TestParseToken_StrictIssAud(t *testing.T) {
 	validToken := generateTestToken(t, testJWTSecret, validClaims())
 
 	t.Run("empty issuer in config rejects token", func(t *testing.T) {
-		_, err := parseToken(validToken, testJWTSecret, "", "goscratch-api")
+		_, err := parseToken(validToken, testKeys, "", "goscratch-api")
 		assert.Error(t, err)
 	})
 
 	t.Run("empty audience in config rejects token", func(t *testing.T) {
-		_, err := parseToken(validToken, testJWTSecret, "goscratch", "")
+		_, err := parseToken(validToken, testKeys, "goscratch", "")
 		assert.Error(t, err)
 	})
 
 	t.Run("both empty rejects token", func(t *testing.T) {
-		_, err := parseToken(validToken, testJWTSecret, "", "")
+		_, err := parseToken(validToken, testKeys, "", "")
 		assert.Error(t, err)
 	})
 
 	t.Run("correct issuer and audience accepts token", func(t *testing.T) {
-		claims, err := parseToken(validToken, testJWTSecret, "goscratch", "goscratch-api")
+		claims, err := parseToken(validToken, testKeys, "goscratch", "goscratch-api")
 		assert.NoError(t, err)
 		assert.Equal(t, "user-123", claims.UserID)
 	})
@@ -363,3 +374,227 @@ func TestAuth_RejectsWhenIssuerOrAudienceEmpty(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
 }
+
+// testKeyPEM returns a PKCS #8 PEM encoding of key
+func testKeyPEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestAuth_RS256(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys, err := jwtkeys.New(jwtkeys.RS256, testKeyPEM(t, rsaKey))
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(Auth(NewAuthConfig(keys, "goscratch", "goscratch-api")))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendString(GetUserID(c))
+	})
+
+	claims := validClaims()
+	signed, err := keys.Sign(&claims)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		token string
+		want  int
+	}{
+		"signed with the key":  {signed, fiber.StatusOK},
+		"signed with a secret": {generateTestToken(t, testJWTSecret, validClaims()), fiber.StatusUnauthorized},
+		"tampered claims":      {signed[:len(signed)-4] + "AAAA", fiber.StatusUnauthorized},
+		"not a token":          {"garbage", fiber.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, resp.StatusCode)
+		})
+	}
+}
+
+// TestParseToken_RejectsPublicKeyAsSecret covers algorithm confusion: an HS256
+// token "signed" with the published public key must not verify.
+func TestParseToken_RejectsPublicKeyAsSecret(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := jwtkeys.New(jwtkeys.ES256, testKeyPEM(t, ecKey))
+	require.NoError(t, err)
+
+	pubDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	claims := validClaims()
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+	forged.Header["kid"] = keys.KeyID()
+	token, err := forged.SignedString(pubPEM)
+	require.NoError(t, err)
+
+	_, err = parseToken(token, keys, "goscratch", "goscratch-api")
+	assert.Error(t, err)
+}
+
+func TestAuth_Denylist(t *testing.T) {
+	mr := miniredis.RunT(t)
+	denylist, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer denylist.Close()
+	require.NoError(t, mr.Set(authdomain.RevokedTokenKey("revoked-jti"), "user-123"))
+
+	cfg := DefaultAuthConfig(testJWTSecret)
+	cfg.Denylist = denylist
+
+	app := fiber.New()
+	app.Get("/required", Auth(cfg), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/optional", OptionalAuth(cfg), func(c *fiber.Ctx) error {
+		if GetClaims(c) == nil {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	withID := func(jti string) string {
+		claims := validClaims()
+		claims.ID = jti
+		return generateTestToken(t, testJWTSecret, claims)
+	}
+	do := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1) // redis retries before failing
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, do("/required", withID("live-jti")))
+	assert.Equal(t, fiber.StatusOK, do("/required", withID("")), "tokens without a jti are not checked")
+	assert.Equal(t, fiber.StatusUnauthorized, do("/required", withID("revoked-jti")))
+	assert.Equal(t, fiber.StatusNoContent, do("/optional", withID("revoked-jti")), "a revoked token is ignored")
+
+	mr.Close()
+	assert.Equal(t, fiber.StatusServiceUnavailable, do("/required", withID("live-jti")), "fails closed while the cache is down")
+	assert.Equal(t, fiber.StatusNoContent, do("/optional", withID("live-jti")))
+}
+
+func TestAuth_TokenVersion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	denylist, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer denylist.Close()
+
+	cfg := DefaultAuthConfig(testJWTSecret)
+	cfg.Denylist = denylist
+	app := fiber.New()
+	app.Use(Auth(cfg))
+	app.Get("/test", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	withVersion := func(version int64) string {
+		claims := validClaims()
+		claims.ID = "jti-1"
+		claims.Version = version
+		return generateTestToken(t, testJWTSecret, claims)
+	}
+	do := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, do(withVersion(0)), "no version stored")
+
+	require.NoError(t, mr.Set(authdomain.TokenVersionKey("user-123"), "2"))
+	assert.Equal(t, fiber.StatusUnauthorized, do(withVersion(0)), "issued before logout-all")
+	assert.Equal(t, fiber.StatusUnauthorized, do(withVersion(1)))
+	assert.Equal(t, fiber.StatusOK, do(withVersion(2)))
+
+	require.NoError(t, mr.Set(authdomain.TokenVersionKey("user-123"), "garbage"))
+	assert.Equal(t, fiber.StatusServiceUnavailable, do(withVersion(2)))
+}
+
+func TestAuth_MustChangePassword(t *testing.T) {
+	cfg := DefaultAuthConfig(testJWTSecret)
+	app := fiber.New()
+	users := app.Group("/users", Auth(cfg))
+	users.Get("/me", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	users.Post("/me/password", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	claims := validClaims()
+	claims.MustChangePassword = true
+	restricted := generateTestToken(t, testJWTSecret, claims)
+
+	do := func(method, path, token string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := do(http.MethodGet, "/users/me", restricted)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), CodePasswordChangeRequired)
+
+	assert.Equal(t, fiber.StatusOK, do(http.MethodPost, "/users/me/password", restricted).StatusCode)
+	assert.Equal(t, fiber.StatusOK, do(http.MethodPost, "/users/me/password/", restricted).StatusCode, "trailing slash")
+	assert.Equal(t, fiber.StatusOK, do(http.MethodGet, "/users/me", generateTestToken(t, testJWTSecret, validClaims())).StatusCode)
+}
+
+func TestOptionalAuth_MustChangePassword(t *testing.T) {
+	app := fiber.New()
+	app.Use(OptionalAuth(DefaultAuthConfig(testJWTSecret)))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		if GetClaims(c) != nil {
+			return c.SendStatus(fiber.StatusOK)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	claims := validClaims()
+	claims.MustChangePassword = true
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, testJWTSecret, claims))
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode, "treated as anonymous")
+}
+
+func TestAuth_Impersonation(t *testing.T) {
+	app := fiber.New()
+	var actorID string
+	var ctx context.Context
+	app.Use(Auth(DefaultAuthConfig(testJWTSecret)))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		actorID = GetActorID(c)
+		ctx = c.UserContext()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	do := func(claims Claims) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, testJWTSecret, claims))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+
+	claims := validClaims()
+	claims.ActorID = "admin-1"
+	do(claims)
+	assert.Equal(t, "admin-1", actorID)
+	assert.Equal(t, "user-123", ctx.Value(logger.UserIDKey))
+	assert.Equal(t, "admin-1", ctx.Value(logger.ActorIDKey))
+
+	do(validClaims())
+	assert.Empty(t, actorID)
+	assert.Nil(t, ctx.Value(logger.ActorIDKey))
+}
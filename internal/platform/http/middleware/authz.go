@@ -14,7 +14,8 @@ type AuthzConfig struct {
 	Action     string // e.g., "read", "create", "update", "delete"
 }
 
-// RequirePermission creates middleware that checks if user has the required permission
+// RequirePermission creates middleware that checks if user has the required
+// permission, in the request's tenant when Tenant resolved one
 func RequirePermission(authorizer port.Authorizer, obj, act string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := GetUserID(c)
@@ -22,7 +23,60 @@ func RequirePermission(authorizer port.Authorizer, obj, act string) fiber.Handle
 			return response.Unauthorized(c, "authentication required")
 		}
 
-		allowed, err := authorizer.Enforce(userID, obj, act)
+		// An API key is limited to its scopes on top of its owner's
+		// permissions.
+		if !apiKeyAllows(c, obj+":"+act) {
+			return response.Forbidden(c, "API key scope does not allow this request")
+		}
+
+		allowed, err := enforce(c, authorizer, userID, obj, act)
+		if err != nil {
+			return response.Fail(c, apperr.Internalf("authorization check failed"))
+		}
+
+		if !allowed {
+			return response.Forbidden(c, "insufficient permissions")
+		}
+
+		return c.Next()
+	}
+}
+
+// OwnerExtractor returns the ID of the user owning the object a request acts
+// on, or "" when it has none. An error ends the request: an apperr is sent
+// as is, anything else as a 500.
+type OwnerExtractor func(c *fiber.Ctx) (string, error)
+
+// OwnerFromParam returns an OwnerExtractor reading the owner from the route
+// parameter name, e.g. "id" for /users/:id, whose object is a user
+func OwnerFromParam(name string) OwnerExtractor {
+	return func(c *fiber.Ctx) (string, error) {
+		return c.Params(name), nil
+	}
+}
+
+// RequireOwnershipOrPermission creates middleware that lets the owner of the
+// object, as returned by owner, do what port.RoleOwner may do with it, and
+// everyone else what their permission obj:act allows
+// (port.Authorizer.EnforceOwnership). A user can then always read their own
+// profile, say, without the role-level permission to read every profile.
+func RequireOwnershipOrPermission(authorizer port.Authorizer, obj, act string, owner OwnerExtractor) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := GetUserID(c)
+		if userID == "" {
+			return response.Unauthorized(c, "authentication required")
+		}
+
+		if !apiKeyAllows(c, obj+":"+act) {
+			return response.Forbidden(c, "API key scope does not allow this request")
+		}
+
+		ownerID, err := owner(c)
+		if err != nil {
+			return response.Fail(c, err)
+		}
+
+		allowed, err := authorizer.EnforceOwnership(userID, ownerID, GetTenantID(c), obj, act)
 		if err != nil {
 			return response.Fail(c, apperr.Internalf("authorization check failed"))
 		}
@@ -35,7 +89,8 @@ func RequirePermission(authorizer port.Authorizer, obj, act string) fiber.Handle
 	}
 }
 
-// RequireRole creates middleware that checks if user has the required role
+// RequireRole creates middleware that checks if user has the required role,
+// globally or in the request's tenant
 func RequireRole(authorizer port.Authorizer, role string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := GetUserID(c)
@@ -43,12 +98,18 @@ func RequireRole(authorizer port.Authorizer, role string) fiber.Handler {
 			return response.Unauthorized(c, "authentication required")
 		}
 
-		hasRole, err := authorizer.HasRoleForUser(userID, role)
+		// A role stands for all of its permissions, so only an unscoped
+		// API key ("*") can use it.
+		if !apiKeyAllows(c, "*") {
+			return response.Forbidden(c, "API key scope does not allow this request")
+		}
+
+		granted, err := hasRole(c, authorizer, userID, role)
 		if err != nil {
 			return response.Fail(c, apperr.Internalf("authorization check failed"))
 		}
 
-		if !hasRole {
+		if !granted {
 			return response.Forbidden(c, "insufficient role")
 		}
 
@@ -66,7 +127,10 @@ func RequireAnyPermission(authorizer port.Authorizer, permissions ...string) fib
 
 		for _, perm := range permissions {
 			obj, act := parsePermission(perm)
-			allowed, err := authorizer.Enforce(userID, obj, act)
+			if !apiKeyAllows(c, obj+":"+act) {
+				continue
+			}
+			allowed, err := enforce(c, authorizer, userID, obj, act)
 			if err != nil {
 				continue
 			}
@@ -89,7 +153,10 @@ func RequireAllPermissions(authorizer port.Authorizer, permissions ...string) fi
 
 		for _, perm := range permissions {
 			obj, act := parsePermission(perm)
-			allowed, err := authorizer.Enforce(userID, obj, act)
+			if !apiKeyAllows(c, obj+":"+act) {
+				return response.Forbidden(c, "API key scope does not allow this request")
+			}
+			allowed, err := enforce(c, authorizer, userID, obj, act)
 			if err != nil || !allowed {
 				return response.Forbidden(c, "insufficient permissions")
 			}
@@ -107,12 +174,16 @@ func RequireAnyRole(authorizer port.Authorizer, roles ...string) fiber.Handler {
 			return response.Unauthorized(c, "authentication required")
 		}
 
+		if !apiKeyAllows(c, "*") {
+			return response.Forbidden(c, "API key scope does not allow this request")
+		}
+
 		for _, role := range roles {
-			hasRole, err := authorizer.HasRoleForUser(userID, role)
+			granted, err := hasRole(c, authorizer, userID, role)
 			if err != nil {
 				continue
 			}
-			if hasRole {
+			if granted {
 				return c.Next()
 			}
 		}
@@ -121,6 +192,46 @@ func RequireAnyRole(authorizer port.Authorizer, roles ...string) fiber.Handler {
 	}
 }
 
+// HasPermission reports whether the request's user holds obj:act, as
+// RequirePermission checks it, for handlers whose permission depends on the
+// request. It is false without a user.
+func HasPermission(c *fiber.Ctx, authorizer port.Authorizer, obj, act string) (bool, error) {
+	userID := GetUserID(c)
+	if userID == "" || !apiKeyAllows(c, obj+":"+act) {
+		return false, nil
+	}
+	return enforce(c, authorizer, userID, obj, act)
+}
+
+// enforce checks a permission in the request's tenant, or globally when it
+// names none
+func enforce(c *fiber.Ctx, authorizer port.Authorizer, userID, obj, act string) (bool, error) {
+	if tenant := GetTenantID(c); tenant != "" {
+		return authorizer.EnforceInDomain(userID, tenant, obj, act)
+	}
+	return authorizer.Enforce(userID, obj, act)
+}
+
+// hasRole reports whether the user was granted role globally or in the
+// request's tenant
+func hasRole(c *fiber.Ctx, authorizer port.Authorizer, userID, role string) (bool, error) {
+	ok, err := authorizer.HasRoleForUser(userID, role)
+	tenant := GetTenantID(c)
+	if err != nil || ok || tenant == "" {
+		return ok, err
+	}
+	roles, err := authorizer.GetRolesForUserInDomain(userID, tenant)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // parsePermission splits "object:action" into obj and act
 func parsePermission(perm string) (obj, act string) {
 	for i := 0; i < len(perm); i++ {
@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,8 +16,12 @@ import (
 
 // mockAuthorizer implements port.Authorizer for testing
 type mockAuthorizer struct {
-	enforceFunc        func(sub, obj, act string) (bool, error)
-	hasRoleForUserFunc func(userID, role string) (bool, error)
+	enforceFunc         func(sub, obj, act string) (bool, error)
+	enforceInDomainFunc func(sub, dom, obj, act string) (bool, error)
+	hasRoleForUserFunc  func(userID, role string) (bool, error)
+	domainRolesFunc     func(userID, domain string) ([]string, error)
+	// ownerPerms are the permissions ("obj:act") of port.RoleOwner
+	ownerPerms map[string]bool
 }
 
 func (m *mockAuthorizer) Enforce(sub, obj, act string) (bool, error) {
@@ -29,6 +35,18 @@ func (m *mockAuthorizer) EnforceWithContext(_ context.Context, sub, obj, act str
 	return m.Enforce(sub, obj, act)
 }
 
+func (m *mockAuthorizer) EnforceBatch(_ context.Context, requests []port.EnforceRequest) ([]bool, error) {
+	results := make([]bool, len(requests))
+	for i, r := range requests {
+		allowed, err := m.EnforceInDomain(r.Sub, r.Dom, r.Obj, r.Act)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = allowed
+	}
+	return results, nil
+}
+
 func (m *mockAuthorizer) HasRoleForUser(userID, role string) (bool, error) {
 	if m.hasRoleForUserFunc != nil {
 		return m.hasRoleForUserFunc(userID, role)
@@ -36,9 +54,36 @@ func (m *mockAuthorizer) HasRoleForUser(userID, role string) (bool, error) {
 	return false, nil
 }
 
+func (m *mockAuthorizer) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	if m.enforceInDomainFunc != nil {
+		return m.enforceInDomainFunc(sub, dom, obj, act)
+	}
+	return m.Enforce(sub, obj, act)
+}
+
+func (m *mockAuthorizer) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	allowed, err := m.EnforceInDomain(sub, dom, obj, act)
+	if err != nil || allowed || sub != owner {
+		return allowed, err
+	}
+	return m.ownerPerms[obj+":"+act], nil
+}
+
+func (m *mockAuthorizer) GetRolesForUserInDomain(userID, domain string) ([]string, error) {
+	if m.domainRolesFunc != nil {
+		return m.domainRolesFunc(userID, domain)
+	}
+	return nil, nil
+}
+
 // Stub implementations for the rest of the interface
-func (m *mockAuthorizer) AddRoleForUser(_, _ string) error                   { return nil }
-func (m *mockAuthorizer) RemoveRoleForUser(_, _ string) error                { return nil }
+func (m *mockAuthorizer) AddRoleForUser(_, _ string) error               { return nil }
+func (m *mockAuthorizer) RemoveRoleForUser(_, _ string) error            { return nil }
+func (m *mockAuthorizer) AddRoleForUserInDomain(_, _, _ string) error    { return nil }
+func (m *mockAuthorizer) RemoveRoleForUserInDomain(_, _, _ string) error { return nil }
+func (m *mockAuthorizer) GetUsersForRoleInDomain(_, _ string) ([]string, error) {
+	return nil, nil
+}
 func (m *mockAuthorizer) GetRolesForUser(_ string) ([]string, error)         { return nil, nil }
 func (m *mockAuthorizer) GetUsersForRole(_ string) ([]string, error)         { return nil, nil }
 func (m *mockAuthorizer) AddPermissionForRole(_, _, _ string) error          { return nil }
@@ -50,10 +95,13 @@ func (m *mockAuthorizer) GetPermissionsForUser(_ string) ([][]string, error) { r
 func (m *mockAuthorizer) GetImplicitPermissionsForUser(_ string) ([][]string, error) {
 	return nil, nil
 }
-func (m *mockAuthorizer) LoadPolicy() error             { return nil }
-func (m *mockAuthorizer) SavePolicy() error             { return nil }
-func (m *mockAuthorizer) Start(_ context.Context) error { return nil }
-func (m *mockAuthorizer) Close() error                  { return nil }
+func (m *mockAuthorizer) AddDeny(_, _, _ string) error         { return nil }
+func (m *mockAuthorizer) RemoveDeny(_, _, _ string) error      { return nil }
+func (m *mockAuthorizer) GetRules() ([]port.PolicyRule, error) { return nil, nil }
+func (m *mockAuthorizer) LoadPolicy() error                    { return nil }
+func (m *mockAuthorizer) SavePolicy() error                    { return nil }
+func (m *mockAuthorizer) Start(_ context.Context) error        { return nil }
+func (m *mockAuthorizer) Close() error                         { return nil }
 
 // setupAuthzApp creates a fiber app with user_id pre-set in locals
 func setupAuthzApp(handler fiber.Handler, userID string) *fiber.App {
@@ -125,6 +173,31 @@ func TestRequirePermission_EnforceError(t *testing.T) {
 	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
 }
 
+func TestHasPermission(t *testing.T) {
+	mock := &mockAuthorizer{
+		enforceFunc: func(sub, obj, act string) (bool, error) {
+			return sub == "user-1" && obj == "audit" && act == "read", nil
+		},
+	}
+	check := func(userID, obj, act string) bool {
+		var allowed bool
+		app := setupAuthzApp(func(c *fiber.Ctx) error {
+			var err error
+			allowed, err = HasPermission(c, mock, obj, act)
+			require.NoError(t, err)
+			return c.Next()
+		}, userID)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		return allowed
+	}
+
+	assert.True(t, check("user-1", "audit", "read"))
+	assert.False(t, check("user-1", "audit", "verify"))
+	assert.False(t, check("", "audit", "read"), "no user holds nothing")
+}
+
 func TestRequireRole_HasRole(t *testing.T) {
 	mock := &mockAuthorizer{
 		hasRoleForUserFunc: func(userID, role string) (bool, error) {
@@ -215,6 +288,58 @@ func TestRequireAllPermissions_OneMissing(t *testing.T) {
 	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
 }
 
+func TestRequireOwnershipOrPermission(t *testing.T) {
+	mock := &mockAuthorizer{
+		enforceFunc: func(sub, obj, act string) (bool, error) {
+			return sub == "admin-1", nil
+		},
+		ownerPerms: map[string]bool{"users:read": true},
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		path   string
+		act    string
+		want   int
+	}{
+		{"owner without permission", "user-1", "/users/user-1", "read", fiber.StatusOK},
+		{"owner outside owner permissions", "user-1", "/users/user-1", "delete", fiber.StatusForbidden},
+		{"someone else's object", "user-1", "/users/user-2", "read", fiber.StatusForbidden},
+		{"permission holder", "admin-1", "/users/user-2", "delete", fiber.StatusOK},
+		{"unauthenticated", "", "/users/user-1", "read", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				if tt.userID != "" {
+					c.Locals("user_id", tt.userID)
+				}
+				return c.Next()
+			})
+			app.Get("/users/:id", RequireOwnershipOrPermission(mock, "users", tt.act, OwnerFromParam("id")), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestRequireOwnershipOrPermission_ExtractorError(t *testing.T) {
+	owner := func(*fiber.Ctx) (string, error) {
+		return "", apperr.NotFoundf("order not found")
+	}
+	app := setupAuthzApp(RequireOwnershipOrPermission(&mockAuthorizer{}, "orders", "read", owner), "user-1")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
 func TestParsePermission(t *testing.T) {
 	tests := []struct {
 		perm    string
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bulkhead rejection reasons, passed to BulkheadConfig.OnReject
+const (
+	BulkheadQueueFull    = "queue_full"    // every slot busy and the wait queue full
+	BulkheadQueueTimeout = "queue_timeout" // waited QueueTimeout without getting a slot
+	BulkheadCanceled     = "canceled"      // request context ended while waiting
+)
+
+// BulkheadConfig holds the limits for one bulkhead
+type BulkheadConfig struct {
+	// Name identifies the bulkhead in logs and metrics.
+	Name string
+	// MaxConcurrent is the number of requests that may run at once (default: 10).
+	MaxConcurrent int
+	// MaxQueue is the number of requests that may wait for a free slot.
+	// 0 rejects as soon as every slot is busy.
+	MaxQueue int
+	// QueueTimeout bounds how long a queued request waits (default: 1s).
+	QueueTimeout time.Duration
+	// RejectStatus is the response status for rejected requests: 503
+	// (default) or 429.
+	RejectStatus int
+	Logger       *logger.Logger
+	// OnReject is called with Name and the rejection reason, e.g.
+	// observability.RecordBulkheadRejection. Optional.
+	OnReject func(name, reason string)
+}
+
+// Bulkhead returns a middleware that caps the number of requests running
+// through it at once. Each call creates an independent set of slots, so mount
+// one handler per route group to keep a heavy endpoint (exports, uploads) from
+// taking every database connection.
+//
+// A request that finds every slot busy waits in a short queue; once the queue
+// is full, or the wait exceeds QueueTimeout, it is rejected immediately with
+// Retry-After instead of piling up behind the slow requests.
+//
+// The slot is released when the handler returns. A response streamed with
+// SetBodyStreamWriter keeps writing after that and is not counted.
+func Bulkhead(cfg BulkheadConfig) fiber.Handler {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 10
+	}
+	if cfg.MaxQueue < 0 {
+		cfg.MaxQueue = 0
+	}
+	if cfg.QueueTimeout <= 0 {
+		cfg.QueueTimeout = time.Second
+	}
+	if cfg.RejectStatus != fiber.StatusTooManyRequests {
+		cfg.RejectStatus = fiber.StatusServiceUnavailable
+	}
+
+	slots := make(chan struct{}, cfg.MaxConcurrent)
+	var waiting atomic.Int64
+
+	reject := func(c *fiber.Ctx, reason string) error {
+		if cfg.OnReject != nil {
+			cfg.OnReject(cfg.Name, reason)
+		}
+		if cfg.Logger != nil {
+			cfg.Logger.Warn("Bulkhead rejected request",
+				"bulkhead", cfg.Name,
+				"reason", reason,
+				"method", c.Method(),
+				"path", c.Path(),
+				"request_id", GetRequestID(c),
+			)
+		}
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return response.Fail(c, apperr.New("SERVER_BUSY", "Server is busy, please try again later", cfg.RejectStatus))
+	}
+
+	// acquire takes a slot, waiting in the queue if there is room. It returns
+	// the rejection reason on failure.
+	acquire := func(c *fiber.Ctx) string {
+		select {
+		case slots <- struct{}{}:
+			return ""
+		default:
+		}
+
+		if waiting.Add(1) > int64(cfg.MaxQueue) {
+			waiting.Add(-1)
+			return BulkheadQueueFull
+		}
+		defer waiting.Add(-1)
+
+		timer := time.NewTimer(cfg.QueueTimeout)
+		defer timer.Stop()
+		select {
+		case slots <- struct{}{}:
+			return ""
+		case <-timer.C:
+			return BulkheadQueueTimeout
+		case <-c.UserContext().Done():
+			return BulkheadCanceled
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if reason := acquire(c); reason != "" {
+			return reject(c, reason)
+		}
+		defer func() { <-slots }()
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingApp mounts a bulkhead in front of /slow, whose handler signals
+// entered and then blocks until release is closed. /fast returns at once.
+func blockingApp(cfg BulkheadConfig) (app *fiber.App, entered chan struct{}, release chan struct{}) {
+	entered = make(chan struct{}, 10)
+	release = make(chan struct{})
+
+	app = fiber.New()
+	app.Use(Bulkhead(cfg))
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		entered <- struct{}{}
+		<-release
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app, entered, release
+}
+
+// startRequest sends req in the background and returns a channel that
+// receives its status code.
+func startRequest(t *testing.T, app *fiber.App, path string) <-chan int {
+	t.Helper()
+	done := make(chan int, 1)
+	go func() {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			done <- 0
+			return
+		}
+		done <- resp.StatusCode
+	}()
+	return done
+}
+
+type rejections struct {
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (r *rejections) record(name, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reasons = append(r.reasons, name+":"+reason)
+}
+
+func TestBulkhead_RejectsWhenFull(t *testing.T) {
+	var rej rejections
+	app, entered, release := blockingApp(BulkheadConfig{
+		Name:          "exports",
+		MaxConcurrent: 1,
+		OnReject:      rej.record,
+	})
+
+	first := startRequest(t, app, "/slow")
+	<-entered
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/fast", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, []string{"exports:" + BulkheadQueueFull}, rej.reasons)
+
+	close(release)
+	assert.Equal(t, fiber.StatusOK, <-first)
+
+	// The slot is free again.
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/fast", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestBulkhead_QueuedRequestRunsWhenSlotFrees(t *testing.T) {
+	app, entered, release := blockingApp(BulkheadConfig{
+		MaxConcurrent: 1,
+		MaxQueue:      1,
+		QueueTimeout:  5 * time.Second,
+	})
+
+	first := startRequest(t, app, "/slow")
+	<-entered
+	queued := startRequest(t, app, "/fast")
+
+	// Give the second request time to join the queue, then free the slot.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, fiber.StatusOK, <-first)
+	assert.Equal(t, fiber.StatusOK, <-queued)
+}
+
+func TestBulkhead_QueueTimeout(t *testing.T) {
+	var rej rejections
+	app, entered, release := blockingApp(BulkheadConfig{
+		Name:          "uploads",
+		MaxConcurrent: 1,
+		MaxQueue:      1,
+		QueueTimeout:  20 * time.Millisecond,
+		RejectStatus:  fiber.StatusTooManyRequests,
+		OnReject:      rej.record,
+	})
+	defer close(release)
+
+	startRequest(t, app, "/slow")
+	<-entered
+
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/fast", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, []string{"uploads:" + BulkheadQueueTimeout}, rej.reasons)
+}
+
+func TestBulkhead_IndependentInstances(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	app := fiber.New()
+	app.Use("/exports", Bulkhead(BulkheadConfig{Name: "exports", MaxConcurrent: 1}))
+	app.Use("/users", Bulkhead(BulkheadConfig{Name: "users", MaxConcurrent: 1}))
+	app.Get("/exports", func(c *fiber.Ctx) error {
+		close(entered)
+		<-release
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/users", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	startRequest(t, app, "/exports")
+	<-entered
+
+	// A saturated exports bulkhead does not affect /users.
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/users", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/chaos"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Chaos request headers. Honoured only when ChaosConfig.AllowHeaders is true.
+const (
+	ChaosLatencyHeader   = "X-Chaos-Latency"    // Go duration, e.g. "750ms"
+	ChaosErrorRateHeader = "X-Chaos-Error-Rate" // float in [0, 1]
+	ChaosTargetsHeader   = "X-Chaos-Targets"    // CSV of http,db,cache,queue
+)
+
+// ChaosConfig holds chaos middleware configuration
+type ChaosConfig struct {
+	Injector     *chaos.Injector
+	AllowHeaders bool // Let X-Chaos-* headers override the injector config per request
+}
+
+// Chaos returns a dev-only middleware that injects latency and errors into
+// request handling. When header overrides are allowed, the parsed override is
+// placed on the request's user context so DB, cache and queue calls made with
+// c.UserContext() observe the same faults.
+func Chaos(cfg ChaosConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		if cfg.AllowHeaders {
+			if o, ok := parseChaosOverride(c); ok {
+				ctx = chaos.WithOverride(ctx, o)
+				c.SetUserContext(ctx)
+			}
+		}
+
+		if err := cfg.Injector.Inject(ctx, chaos.TargetHTTP); err != nil {
+			if errors.Is(err, chaos.ErrInjected) {
+				return response.Fail(c, apperr.New("CHAOS_INJECTED", "Injected fault", fiber.StatusServiceUnavailable))
+			}
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// parseChaosOverride builds an Override from the X-Chaos-* headers.
+// Returns false when none of the headers are present; malformed values are
+// treated as zero rather than rejected so a typo never blocks a request.
+func parseChaosOverride(c *fiber.Ctx) (chaos.Override, bool) {
+	latency := c.Get(ChaosLatencyHeader)
+	rate := c.Get(ChaosErrorRateHeader)
+	targets := c.Get(ChaosTargetsHeader)
+	if latency == "" && rate == "" && targets == "" {
+		return chaos.Override{}, false
+	}
+
+	var o chaos.Override
+	if d, err := time.ParseDuration(latency); err == nil && d > 0 {
+		o.Latency = d
+	}
+	if r, err := strconv.ParseFloat(rate, 64); err == nil {
+		o.ErrorRate = r
+	}
+	if targets != "" {
+		parts := strings.Split(targets, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		o.Targets = chaos.ParseTargets(parts)
+	}
+	return o, true
+}
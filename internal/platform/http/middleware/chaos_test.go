@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/chaos"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaos_InjectsError(t *testing.T) {
+	app := fiber.New()
+	app.Use(Chaos(ChaosConfig{Injector: chaos.New(chaos.Config{ErrorRate: 1})}))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestChaos_PassesThroughWhenTargetExcluded(t *testing.T) {
+	app := fiber.New()
+	app.Use(Chaos(ChaosConfig{Injector: chaos.New(chaos.Config{
+		ErrorRate: 1,
+		Targets:   []chaos.Target{chaos.TargetDB},
+	})}))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestChaos_HeaderOverridePropagatesToUserContext(t *testing.T) {
+	app := fiber.New()
+	app.Use(Chaos(ChaosConfig{Injector: chaos.New(chaos.Config{}), AllowHeaders: true}))
+
+	var captured chaos.Override
+	var found bool
+	app.Get("/test", func(c *fiber.Ctx) error {
+		captured, found = chaos.OverrideFromContext(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(ChaosLatencyHeader, "1ms")
+	req.Header.Set(ChaosErrorRateHeader, "1")
+	req.Header.Set(ChaosTargetsHeader, "db, cache")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "http target is not listed, so the handler runs")
+	require.True(t, found)
+	assert.Equal(t, time.Millisecond, captured.Latency)
+	assert.Equal(t, 1.0, captured.ErrorRate)
+	assert.Equal(t, []chaos.Target{chaos.TargetDB, chaos.TargetCache}, captured.Targets)
+}
+
+func TestChaos_HeadersIgnoredWhenNotAllowed(t *testing.T) {
+	app := fiber.New()
+	app.Use(Chaos(ChaosConfig{Injector: chaos.New(chaos.Config{})}))
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(ChaosErrorRateHeader, "1")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientInfo adds the client IP and User-Agent to the request context, as
+// Auth does for authenticated requests. Public routes that start or resume a
+// session (login, refresh) use it so the session and its audit entries
+// record the client.
+func ClientInfo() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		ctx = setContextValue(ctx, logger.IPAddressKey, c.IP())
+		ctx = setContextValue(ctx, logger.UserAgentKey, c.Get("User-Agent"))
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
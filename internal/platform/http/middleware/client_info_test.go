@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientInfo(t *testing.T) {
+	var captured context.Context
+	app := fiber.New()
+	app.Post("/login", ClientInfo(), func(c *fiber.Ctx) error {
+		captured = c.UserContext()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.Header.Set("User-Agent", "Test-Agent/1.0")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	require.NotNil(t, captured)
+	assert.Equal(t, "Test-Agent/1.0", captured.Value(logger.UserAgentKey))
+	assert.NotEmpty(t, captured.Value(logger.IPAddressKey))
+}
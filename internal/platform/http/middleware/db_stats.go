@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DBStatsConfig holds request-scoped database metrics configuration
+type DBStatsConfig struct {
+	Logger *logger.Logger
+	// WarnQueries logs a warning when a request runs more statements than
+	// this, which usually means an N+1 loop. 0 disables the warning.
+	WarnQueries int
+	// Record receives the per-request totals keyed by route pattern, e.g.
+	// observability.RecordRequestDBStats. Optional.
+	Record func(method, route string, queries int64, duration time.Duration)
+}
+
+// DBStats returns a middleware that counts the database statements each
+// request runs and their cumulative duration. It puts a database.QueryStats
+// collector in the request's user context, which the pool's pgx tracer
+// fills in; Logger adds the totals to the request log line.
+//
+// Register it before Logger so the collector exists when Logger reads it.
+func DBStats(cfg DBStatsConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, stats := database.WithQueryStats(c.UserContext())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// Route is only resolved after the chain has run
+		route := c.Route().Path
+		if cfg.Record != nil {
+			cfg.Record(c.Method(), route, stats.Count(), stats.Duration())
+		}
+		if cfg.WarnQueries > 0 && stats.Count() > int64(cfg.WarnQueries) && cfg.Logger != nil {
+			cfg.Logger.Warn("High database query count for request",
+				"method", c.Method(),
+				"route", route,
+				"db_queries", stats.Count(),
+				"db_duration_ms", stats.Duration().Milliseconds(),
+				"threshold", cfg.WarnQueries,
+				"request_id", GetRequestID(c),
+			)
+		}
+		return err
+	}
+}
+
+// GetQueryStats returns the request's database statement totals, or nil when
+// DBStats is not installed.
+func GetQueryStats(c *fiber.Ctx) *database.QueryStats {
+	return database.QueryStatsFromContext(c.UserContext())
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryingHandler simulates n statements the way the pgx tracer records them.
+func queryingHandler(n int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stats := database.QueryStatsFromContext(c.UserContext())
+		for i := 0; i < n; i++ {
+			stats.Add(time.Millisecond)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+}
+
+func TestDBStats_RecordsPerRoute(t *testing.T) {
+	type record struct {
+		method, route string
+		queries       int64
+		duration      time.Duration
+	}
+	var got []record
+
+	app := fiber.New()
+	app.Use(DBStats(DBStatsConfig{
+		Record: func(method, route string, queries int64, d time.Duration) {
+			got = append(got, record{method, route, queries, d})
+		},
+	}))
+	app.Get("/users/:id", queryingHandler(3))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, record{"GET", "/users/:id", 3, 3 * time.Millisecond}, got[0])
+}
+
+func TestDBStats_LoggerIncludesTotals(t *testing.T) {
+	var logBuf bytes.Buffer
+	log := logger.New(logger.Config{Level: "debug", Format: "json", Output: &logBuf})
+
+	app := fiber.New()
+	app.Use(DBStats(DBStatsConfig{Logger: log}))
+	app.Use(Logger(log))
+	app.Get("/items", queryingHandler(2))
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/items", nil))
+	require.NoError(t, err)
+
+	output := logBuf.String()
+	assert.Contains(t, output, `"db_queries":2`)
+	assert.Contains(t, output, `"db_duration_ms":2`)
+}
+
+func TestDBStats_WarnsAboveThreshold(t *testing.T) {
+	var logBuf bytes.Buffer
+	log := logger.New(logger.Config{Level: "debug", Format: "json", Output: &logBuf})
+
+	app := fiber.New()
+	app.Use(DBStats(DBStatsConfig{Logger: log, WarnQueries: 5}))
+	app.Get("/few", queryingHandler(5))
+	app.Get("/many", queryingHandler(6))
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/few", nil))
+	require.NoError(t, err)
+	assert.NotContains(t, logBuf.String(), "High database query count")
+
+	_, err = app.Test(httptest.NewRequest(http.MethodGet, "/many", nil))
+	require.NoError(t, err)
+	assert.Contains(t, logBuf.String(), "High database query count")
+	assert.Contains(t, logBuf.String(), `"route":"/many"`)
+}
+
+func TestLogger_OmitsDBTotalsWithoutDBStats(t *testing.T) {
+	var logBuf bytes.Buffer
+	log := logger.New(logger.Config{Level: "debug", Format: "json", Output: &logBuf})
+
+	app := fiber.New()
+	app.Use(Logger(log))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.NotContains(t, logBuf.String(), "db_queries")
+}
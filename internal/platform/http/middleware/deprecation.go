@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeprecationConfig holds deprecation middleware configuration
+type DeprecationConfig struct {
+	// Since is when the route was deprecated
+	Since time.Time
+	// Sunset is when the route may be removed. Zero omits the Sunset header.
+	Sunset time.Time
+	// Link is the replacement's URL, sent as a successor-version link.
+	// Optional.
+	Link string
+	// OnCall is called for every request to the route, e.g. to count it.
+	// Optional.
+	OnCall func(c *fiber.Ctx)
+}
+
+// Deprecation returns a middleware that announces a deprecated route. Every
+// response carries Deprecation (RFC 9745), and Sunset (RFC 8594) and a
+// successor-version Link when configured. The headers are set before the
+// handler runs, so error responses carry them too.
+func Deprecation(cfg DeprecationConfig) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(cfg.Since.Unix(), 10)
+	var sunset, link string
+	if !cfg.Sunset.IsZero() {
+		sunset = cfg.Sunset.UTC().Format(http.TimeFormat)
+	}
+	if cfg.Link != "" {
+		link = "<" + cfg.Link + `>; rel="successor-version"`
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", deprecation)
+		if sunset != "" {
+			c.Set("Sunset", sunset)
+		}
+		if link != "" {
+			c.Append(fiber.HeaderLink, link)
+		}
+		if cfg.OnCall != nil {
+			cfg.OnCall(c)
+		}
+		return c.Next()
+	}
+}
+
+// ClientName identifies the calling client by the product name at the start
+// of its User-Agent, e.g. "okhttp" for "okhttp/4.12.0". Anything but
+// letters, digits, '.', '_' and '-' is dropped and the name is capped at 32
+// characters. It returns "unknown" without a usable User-Agent.
+func ClientName(c *fiber.Ctx) string {
+	ua := strings.TrimSpace(c.Get(fiber.HeaderUserAgent))
+	if i := strings.IndexAny(ua, "/ ("); i >= 0 {
+		ua = ua[:i]
+	}
+	var b strings.Builder
+	for _, r := range ua {
+		if b.Len() == 32 {
+			break
+		}
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "unknown"
+	}
+	return b.String()
+}
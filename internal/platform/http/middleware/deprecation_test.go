@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecation(t *testing.T) {
+	since := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	var calls int
+	app := fiber.New()
+	app.Get("/v1/users", Deprecation(DeprecationConfig{
+		Since:  since,
+		Sunset: sunset,
+		Link:   "/v2/users",
+		OnCall: func(*fiber.Ctx) { calls++ },
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotFound)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/users", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "@1782864000", resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `</v2/users>; rel="successor-version"`, resp.Header.Get("Link"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestDeprecation_OnlySince(t *testing.T) {
+	app := fiber.New()
+	app.Get("/old", Deprecation(DeprecationConfig{Since: time.Unix(1700000000, 0)}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/old", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "@1700000000", resp.Header.Get("Deprecation"))
+	assert.Empty(t, resp.Header.Get("Sunset"))
+	assert.Empty(t, resp.Header.Get("Link"))
+}
+
+func TestClientName(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(ClientName(c)) })
+
+	for ua, want := range map[string]string{
+		"okhttp/4.12.0":                   "okhttp",
+		"Mozilla/5.0 (X11; Linux x86_64)": "Mozilla",
+		"billing-sync":                    "billing-sync",
+		"acme sdk 1.0":                    "acme",
+		"":                                "unknown",
+		"/1.0":                            "unknown",
+		"$$$<script>":                     "script",
+		"averyveryveryveryverylongclientname/1.0": "averyveryveryveryverylongclientn",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", ua)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, want, string(body), ua)
+	}
+}
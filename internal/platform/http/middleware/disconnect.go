@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrClientDisconnected is the cancellation cause of a request context whose
+// client went away (see CancelOnDisconnect). Check it with
+// errors.Is(context.Cause(ctx), ErrClientDisconnected).
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// StatusClientClosedRequest is the non-standard status (nginx's 499) logged
+// for requests abandoned by the client. The client never sees it.
+const StatusClientClosedRequest = 499
+
+// CancelOnDisconnectConfig holds disconnect detection settings
+type CancelOnDisconnectConfig struct {
+	// PollInterval is how often the connection is checked while the handler
+	// runs (default: 200ms).
+	PollInterval time.Duration
+	Logger       *logger.Logger
+	// OnCancel is called with the method and route of every request cancelled
+	// by a disconnect, e.g. observability.RecordRequestCancelled. Optional.
+	OnCancel func(method, path string)
+}
+
+// CancelOnDisconnect returns a middleware that cancels c.UserContext() when
+// the client closes its connection before the response is written. fasthttp
+// does not do this on its own, so without it an abandoned request keeps its
+// database connection, cache calls and storage transfers running to the end.
+//
+// While the handler runs, the connection is checked every PollInterval with a
+// non-blocking peek, which neither consumes nor waits for data. A closed or
+// reset connection cancels the context with cause ErrClientDisconnected;
+// everything that honours the context (pgx, go-redis, the AWS SDK, storage
+// uploads) then stops. Detection works on plain TCP and TLS connections on
+// Unix; elsewhere, and for in-memory connections such as app.Test, the
+// middleware only passes the request through.
+//
+// A client that half-closes its side after sending the request (shutdown(SHUT_WR))
+// looks disconnected too. Common HTTP clients do not do that.
+//
+// When the handler returns, the context is cancelled unless the response is a
+// body stream, which may still be reading through it.
+func CancelOnDisconnect(cfg CancelOnDisconnectConfig) fiber.Handler {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 200 * time.Millisecond
+	}
+
+	return func(c *fiber.Ctx) error {
+		conn := c.Context().Conn()
+		if conn == nil {
+			return c.Next()
+		}
+		if _, supported := peekClosed(conn); !supported {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithCancelCause(c.UserContext())
+		c.SetUserContext(ctx)
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(cfg.PollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					if closed, _ := peekClosed(conn); closed {
+						cancel(ErrClientDisconnected)
+						return
+					}
+				}
+			}
+		}()
+
+		err := c.Next()
+
+		// The watcher must be gone before fasthttp reuses the connection.
+		close(stop)
+		wg.Wait()
+
+		if ClientDisconnected(c) {
+			if cfg.OnCancel != nil {
+				cfg.OnCancel(c.Method(), c.Route().Path)
+			}
+			if cfg.Logger != nil {
+				cfg.Logger.Info("Request cancelled by client disconnect",
+					"method", c.Method(),
+					"path", c.Path(),
+					"request_id", GetRequestID(c),
+				)
+			}
+		}
+		if !c.Response().IsBodyStream() {
+			cancel(context.Canceled)
+		}
+		return err
+	}
+}
+
+// ClientDisconnected reports whether the request was cancelled because the
+// client went away.
+func ClientDisconnected(c *fiber.Ctx) bool {
+	return errors.Is(context.Cause(c.UserContext()), ErrClientDisconnected)
+}
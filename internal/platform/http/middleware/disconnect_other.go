@@ -0,0 +1,11 @@
+//go:build !unix
+
+package middleware
+
+import "net"
+
+// peekClosed is not implemented on this platform; CancelOnDisconnect passes
+// requests through.
+func peekClosed(net.Conn) (closed, supported bool) {
+	return false, false
+}
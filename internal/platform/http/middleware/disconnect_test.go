@@ -0,0 +1,134 @@
+//go:build unix
+
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveDisconnectApp serves app on a real TCP listener, since disconnects
+// cannot be observed through app.Test, and returns its address.
+func serveDisconnectApp(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return ln.Addr().String()
+}
+
+func sendRequest(t *testing.T, addr, path string) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("GET " + path + " HTTP/1.1\r\nHost: test\r\n\r\n"))
+	require.NoError(t, err)
+	return conn
+}
+
+type cancelRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *cancelRecorder) record(method, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, method+" "+path)
+}
+
+func (r *cancelRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestCancelOnDisconnect_CancelsWhenClientLeaves(t *testing.T) {
+	rec := &cancelRecorder{}
+	started := make(chan struct{})
+	cause := make(chan error, 1)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(CancelOnDisconnect(CancelOnDisconnectConfig{PollInterval: 10 * time.Millisecond, OnCancel: rec.record}))
+	app.Get("/slow/:id", func(c *fiber.Ctx) error {
+		close(started)
+		select {
+		case <-c.UserContext().Done():
+			cause <- context.Cause(c.UserContext())
+			return c.UserContext().Err()
+		case <-time.After(5 * time.Second):
+			cause <- nil
+			return c.SendString("done")
+		}
+	})
+	addr := serveDisconnectApp(t, app)
+
+	conn := sendRequest(t, addr, "/slow/1")
+	<-started
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-cause:
+		assert.ErrorIs(t, err, ErrClientDisconnected)
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler context was not cancelled after the client disconnected")
+	}
+	assert.Eventually(t, func() bool { return len(rec.get()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"GET /slow/:id"}, rec.get())
+}
+
+func TestCancelOnDisconnect_ConnectedClientIsNotCancelled(t *testing.T) {
+	rec := &cancelRecorder{}
+	var handlerCtx context.Context
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(CancelOnDisconnect(CancelOnDisconnectConfig{PollInterval: 5 * time.Millisecond, OnCancel: rec.record}))
+	app.Get("/work", func(c *fiber.Ctx) error {
+		handlerCtx = c.UserContext()
+		// Outlive several polls.
+		time.Sleep(50 * time.Millisecond)
+		if err := c.UserContext().Err(); err != nil {
+			return err
+		}
+		return c.SendString("ok")
+	})
+	addr := serveDisconnectApp(t, app)
+
+	conn := sendRequest(t, addr, "/work")
+	defer conn.Close()
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Empty(t, rec.get())
+	// The context is released once the handler is done.
+	require.NotNil(t, handlerCtx)
+	assert.ErrorIs(t, context.Cause(handlerCtx), context.Canceled)
+	assert.NotErrorIs(t, context.Cause(handlerCtx), ErrClientDisconnected)
+}
+
+func TestCancelOnDisconnect_PassesThroughWithoutSocket(t *testing.T) {
+	app := fiber.New()
+	app.Use(CancelOnDisconnect(CancelOnDisconnectConfig{}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		// app.Test uses an in-memory connection, so no watcher is attached.
+		assert.Nil(t, c.UserContext().Done())
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
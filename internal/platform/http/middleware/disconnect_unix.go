@@ -0,0 +1,52 @@
+//go:build unix
+
+package middleware
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// peekClosed reports whether the peer has closed or reset conn, without
+// consuming or waiting for data. supported is false when conn has no socket
+// to inspect.
+func peekClosed(conn net.Conn) (closed, supported bool) {
+	// Unwrap *tls.Conn and similar wrappers down to the socket.
+	for {
+		inner, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = inner.NetConn()
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false, false
+	}
+
+	var (
+		buf     [1]byte
+		n       int
+		peekErr error
+	)
+	if err := raw.Read(func(fd uintptr) bool {
+		n, _, peekErr = syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		return true // never wait for readability
+	}); err != nil {
+		return true, true // closed on our side
+	}
+
+	switch {
+	case peekErr == nil:
+		return n == 0, true // 0 bytes is an orderly shutdown; >0 is pipelined data
+	case errors.Is(peekErr, syscall.EAGAIN), errors.Is(peekErr, syscall.EWOULDBLOCK), errors.Is(peekErr, syscall.EINTR):
+		return false, true // open, nothing to read
+	default:
+		return true, true // ECONNRESET and friends
+	}
+}
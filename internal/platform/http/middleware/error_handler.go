@@ -7,9 +7,33 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// init pre-marshals the fixed error envelopes emitted by this package's
+// middleware. These fire on every rejected request (bad token, missing
+// permission, rate limited), so skipping JSON encoding matters under attack.
+func init() {
+	for _, e := range []struct{ code, message string }{
+		{apperr.CodeUnauthorized, "Missing or invalid token"},
+		{apperr.CodeUnauthorized, "Token has expired"},
+		{apperr.CodeUnauthorized, "Invalid token"},
+		{apperr.CodeUnauthorized, "authentication required"},
+		{apperr.CodeForbidden, "insufficient permissions"},
+		{apperr.CodeForbidden, "insufficient role"},
+		{"RATE_LIMIT_EXCEEDED", "Too many requests, please try again later"},
+		{"RATE_LIMIT_ERROR", "Service temporarily unavailable, please try again later"},
+	} {
+		response.RegisterStaticError(e.code, e.message)
+	}
+}
+
 // ErrorHandler returns a centralized error handling middleware
 func ErrorHandler(log *logger.Logger) fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
+		// The client is gone, so the failure is expected and nobody reads
+		// the response: answer 499 without logging a server error.
+		if ClientDisconnected(c) {
+			return c.SendStatus(StatusClientClosedRequest)
+		}
+
 		// Check if it's an application error
 		if appErr, ok := apperr.AsAppError(err); ok {
 			// Log server errors
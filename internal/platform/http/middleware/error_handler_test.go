@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -205,3 +206,24 @@ func TestFiberStatusToCode(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorHandler_ClientDisconnected(t *testing.T) {
+	var logBuf bytes.Buffer
+	log := newTestLogger(&logBuf)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: ErrorHandler(log),
+	})
+
+	app.Get("/test", func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancelCause(c.UserContext())
+		cancel(ErrClientDisconnected)
+		c.SetUserContext(ctx)
+		return apperr.ErrInternal.WithError(ctx.Err())
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.Equal(t, StatusClientClosedRequest, resp.StatusCode)
+	assert.Empty(t, logBuf.String(), "an abandoned request is not a server error")
+}
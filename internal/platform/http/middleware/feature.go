@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/14mdzk/goscratch/internal/platform/feature"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FeatureOverrideHeader carries per-request feature flag overrides,
+// e.g. "new-checkout=on,legacy-search=off".
+const FeatureOverrideHeader = "X-Feature-Override"
+
+// FeatureOverride returns a middleware that parses X-Feature-Override into the
+// request's user context. It does not decide whether the override is allowed:
+// feature.Flags checks that at evaluation time, once Auth has identified the
+// caller, so the middleware can run globally ahead of route-level auth.
+func FeatureOverride() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(FeatureOverrideHeader)
+		if header == "" {
+			return c.Next()
+		}
+		if overrides := feature.ParseOverrides(header); len(overrides) > 0 {
+			c.SetUserContext(feature.WithOverrides(c.UserContext(), overrides))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/feature"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureOverride_StoresOverridesInUserContext(t *testing.T) {
+	flags := feature.New(feature.Config{Defaults: map[string]bool{"beta": false}, AllowOverrides: true})
+
+	app := fiber.New()
+	app.Use(FeatureOverride())
+	var enabled bool
+	app.Get("/test", func(c *fiber.Ctx) error {
+		enabled = flags.Enabled(c.UserContext(), "beta")
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(FeatureOverrideHeader, "beta=on")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
+
+func TestFeatureOverride_NoHeader(t *testing.T) {
+	app := fiber.New()
+	app.Use(FeatureOverride())
+	var found bool
+	app.Get("/test", func(c *fiber.Ctx) error {
+		_, found = feature.OverridesFromContext(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/http/pathmatch"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeCountryBlocked is the error code returned for requests refused by a
+// country restriction
+const CodeCountryBlocked = "COUNTRY_BLOCKED"
+
+// UnknownCountry labels addresses the GeoIP database has no country for
+const UnknownCountry = "unknown"
+
+// GeoRule blocks a set of countries from the routes under a prefix
+type GeoRule struct {
+	// Prefix is a path and everything below it, e.g. "/admin", matched
+	// ignoring case as the router does. Empty applies to every route.
+	Prefix string
+	// Countries are ISO 3166-1 alpha-2 codes
+	Countries []string
+}
+
+// GeoRestrictionConfig holds country restriction middleware configuration
+type GeoRestrictionConfig struct {
+	GeoIP port.GeoIP
+	Rules []GeoRule
+	// BlockUnknown also refuses addresses with no known country on the
+	// restricted routes
+	BlockUnknown bool
+	// Auditor records blocked attempts. Optional.
+	Auditor port.Auditor
+	// AuditInterval is the least time between two audit entries for the
+	// same address (default: 1m), so a client hammering a blocked route
+	// cannot turn each request into a database write.
+	AuditInterval time.Duration
+	Logger        *logger.Logger
+	// OnBlock is called for every refused request with the client's country,
+	// or UnknownCountry, e.g. to count it. Optional.
+	OnBlock func(country string)
+}
+
+// GeoRestriction returns a middleware that refuses requests from the
+// countries blocked for their path with 403 COUNTRY_BLOCKED. A prefix
+// matches the path itself and everything below it. The client IP is c.IP(),
+// so behind a proxy server.trusted_proxies must be set.
+func GeoRestriction(cfg GeoRestrictionConfig) fiber.Handler {
+	if cfg.AuditInterval <= 0 {
+		cfg.AuditInterval = time.Minute
+	}
+	type scope struct {
+		prefix    string
+		countries map[string]bool
+	}
+	scopes := make([]scope, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		s := scope{prefix: strings.TrimSuffix(r.Prefix, "/"), countries: make(map[string]bool, len(r.Countries))}
+		for _, cc := range r.Countries {
+			s.countries[strings.ToUpper(strings.TrimSpace(cc))] = true
+		}
+		scopes = append(scopes, s)
+	}
+	audits := &auditThrottle{interval: cfg.AuditInterval, last: make(map[string]time.Time)}
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		var country, ip string
+		var blockedBy *scope
+		looked := false
+		for i := range scopes {
+			s := &scopes[i]
+			if !pathmatch.Under(path, s.prefix) {
+				continue
+			}
+			// Only requests to a restricted route pay for the lookup.
+			if !looked {
+				ip = c.IP()
+				country = cfg.GeoIP.Country(ip)
+				looked = true
+			}
+			if (country == "" && cfg.BlockUnknown) || (country != "" && s.countries[country]) {
+				blockedBy = s
+				break
+			}
+		}
+		if blockedBy == nil {
+			return c.Next()
+		}
+
+		label := country
+		if label == "" {
+			label = UnknownCountry
+		}
+		if cfg.OnBlock != nil {
+			cfg.OnBlock(label)
+		}
+		if cfg.Logger != nil {
+			cfg.Logger.Warn("Request refused by country restriction",
+				"ip", ip, "country", label, "method", c.Method(), "path", path, "scope", blockedBy.prefix)
+		}
+		if cfg.Auditor != nil && audits.allow(ip, time.Now()) {
+			entry := port.NewAuditEntry(c.UserContext(), port.AuditActionBlock, "country_restriction", label)
+			entry.IPAddress, entry.UserAgent = ip, c.Get(fiber.HeaderUserAgent)
+			entry.Metadata = map[string]any{
+				"outcome": "failed",
+				"reason":  "country_blocked",
+				"country": label,
+				"scope":   blockedBy.prefix,
+				"method":  c.Method(),
+				"path":    path,
+			}
+			if err := cfg.Auditor.Log(context.WithoutCancel(c.UserContext()), entry); err != nil && cfg.Logger != nil {
+				cfg.Logger.Error("Failed to audit blocked request", "error", err)
+			}
+		}
+		return response.Fail(c, apperr.New(CodeCountryBlocked, "Access from your country is not allowed", fiber.StatusForbidden))
+	}
+}
+
+// auditThrottle admits one audit entry per address per interval
+type auditThrottle struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     map[string]time.Time
+}
+
+func (t *auditThrottle) allow(ip string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.last[ip]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	// Forget addresses that have gone quiet before the map grows large.
+	if len(t.last) >= 10000 {
+		for k, v := range t.last {
+			if now.Sub(v) >= t.interval {
+				delete(t.last, k)
+			}
+		}
+	}
+	t.last[ip] = now
+	return true
+}
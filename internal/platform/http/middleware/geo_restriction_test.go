@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedGeoIP places every address in one country
+type fixedGeoIP string
+
+func (g fixedGeoIP) Country(string) string { return string(g) }
+
+type recordingAuditor struct {
+	mu      sync.Mutex
+	entries []port.AuditEntry
+}
+
+func (a *recordingAuditor) Log(_ context.Context, e port.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+func (a *recordingAuditor) Query(context.Context, port.AuditFilter) ([]port.AuditEntry, error) {
+	return nil, nil
+}
+
+func (a *recordingAuditor) Close() error { return nil }
+
+func newGeoApp(cfg GeoRestrictionConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(GeoRestriction(cfg))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/users", ok)
+	app.Get("/admin/config", ok)
+	app.Get("/administrator", ok)
+	return app
+}
+
+func geoStatus(t *testing.T, app *fiber.App, path string) int {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestGeoRestriction(t *testing.T) {
+	auditor := &recordingAuditor{}
+	var blocked []string
+	app := newGeoApp(GeoRestrictionConfig{
+		GeoIP:   fixedGeoIP("KP"),
+		Rules:   []GeoRule{{Prefix: "/admin/", Countries: []string{"kp", "IR"}}},
+		Auditor: auditor,
+		OnBlock: func(country string) { blocked = append(blocked, country) },
+	})
+
+	assert.Equal(t, fiber.StatusOK, geoStatus(t, app, "/users"))
+	assert.Equal(t, fiber.StatusOK, geoStatus(t, app, "/administrator"))
+	assert.Equal(t, fiber.StatusForbidden, geoStatus(t, app, "/admin/config"))
+	assert.Equal(t, fiber.StatusForbidden, geoStatus(t, app, "/admin/config"))
+	assert.Equal(t, []string{"KP", "KP"}, blocked)
+
+	require.Len(t, auditor.entries, 1, "one audit entry per address per interval")
+	entry := auditor.entries[0]
+	assert.Equal(t, port.AuditActionBlock, entry.Action)
+	assert.Equal(t, "country_restriction", entry.Resource)
+	assert.Equal(t, "KP", entry.ResourceID)
+	assert.Equal(t, "0.0.0.0", entry.IPAddress)
+	assert.Equal(t, "/admin", entry.Metadata["scope"])
+	assert.Equal(t, "/admin/config", entry.Metadata["path"])
+}
+
+func TestGeoRestriction_IgnoresPathCase(t *testing.T) {
+	// The router serves /ADMIN/config from the /admin/config handler, so the
+	// rule for /admin has to cover it.
+	app := newGeoApp(GeoRestrictionConfig{
+		GeoIP: fixedGeoIP("KP"),
+		Rules: []GeoRule{{Prefix: "/admin", Countries: []string{"KP"}}},
+	})
+	assert.Equal(t, fiber.StatusForbidden, geoStatus(t, app, "/Admin/config"))
+	assert.Equal(t, fiber.StatusForbidden, geoStatus(t, app, "/ADMIN/config"))
+	assert.Equal(t, fiber.StatusOK, geoStatus(t, app, "/Administrator"))
+}
+
+func TestGeoRestriction_OtherCountriesPass(t *testing.T) {
+	app := newGeoApp(GeoRestrictionConfig{
+		GeoIP: fixedGeoIP("ID"),
+		Rules: []GeoRule{{Countries: []string{"KP"}}},
+	})
+	assert.Equal(t, fiber.StatusOK, geoStatus(t, app, "/admin/config"))
+}
+
+func TestGeoRestriction_UnknownCountry(t *testing.T) {
+	rules := []GeoRule{{Prefix: "/admin", Countries: []string{"KP"}}}
+	app := newGeoApp(GeoRestrictionConfig{GeoIP: fixedGeoIP(""), Rules: rules})
+	assert.Equal(t, fiber.StatusOK, geoStatus(t, app, "/admin/config"))
+
+	var blocked []string
+	app = newGeoApp(GeoRestrictionConfig{
+		GeoIP:        fixedGeoIP(""),
+		Rules:        rules,
+		BlockUnknown: true,
+		OnBlock:      func(country string) { blocked = append(blocked, country) },
+	})
+	assert.Equal(t, fiber.StatusOK, geoStatus(t, app, "/users"), "unrestricted routes stay open")
+	assert.Equal(t, fiber.StatusForbidden, geoStatus(t, app, "/admin/config"))
+	assert.Equal(t, []string{UnknownCountry}, blocked)
+}
+
+func TestAuditThrottle(t *testing.T) {
+	th := &auditThrottle{interval: time.Minute, last: make(map[string]time.Time)}
+	now := time.Now()
+	assert.True(t, th.allow("203.0.113.7", now))
+	assert.False(t, th.allow("203.0.113.7", now.Add(30*time.Second)))
+	assert.True(t, th.allow("198.51.100.1", now))
+	assert.True(t, th.allow("203.0.113.7", now.Add(time.Minute)))
+}
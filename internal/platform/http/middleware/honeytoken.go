@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/platform/honeytoken"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HoneytokenConfig holds honeytoken middleware configuration
+type HoneytokenConfig struct {
+	Detector *honeytoken.Detector
+}
+
+// Honeytoken returns a middleware that trips the detector for requests
+// presenting a canary API key, in the X-API-Key header or as the
+// Authorization credential, and rejects them like any invalid token. With
+// lockdown on it also refuses every request from a locked-down IP with 403.
+func Honeytoken(cfg HoneytokenConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Detector.Blocked(c.UserContext(), c.IP()) {
+			return response.Forbidden(c, "Access denied")
+		}
+
+		for _, key := range presentedKeys(c) {
+			id, ok := cfg.Detector.MatchAPIKey(key)
+			if !ok {
+				continue
+			}
+			cfg.Detector.Trip(c.UserContext(), honeytoken.Hit{
+				Kind:       honeytoken.KindAPIKey,
+				Credential: id,
+				IPAddress:  c.IP(),
+				UserAgent:  c.Get(fiber.HeaderUserAgent),
+				Method:     c.Method(),
+				Path:       c.Path(),
+			})
+			return response.Unauthorized(c, "Missing or invalid token")
+		}
+		return c.Next()
+	}
+}
+
+// presentedKeys returns the credentials a request carries: the X-API-Key
+// header and the Authorization credential without its scheme.
+func presentedKeys(c *fiber.Ctx) []string {
+	var keys []string
+	if k := strings.TrimSpace(c.Get("X-API-Key")); k != "" {
+		keys = append(keys, k)
+	}
+	if auth := strings.TrimSpace(c.Get(fiber.HeaderAuthorization)); auth != "" {
+		if _, cred, ok := strings.Cut(auth, " "); ok {
+			auth = strings.TrimSpace(cred)
+		}
+		keys = append(keys, auth)
+	}
+	return keys
+}
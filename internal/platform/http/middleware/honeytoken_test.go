@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/honeytoken"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoneytoken(t *testing.T) {
+	var trips []string
+	d := honeytoken.New(honeytoken.Config{
+		APIKeyHashes: []string{honeytoken.HashAPIKey("sk_live_canary")},
+		Lockdown:     true,
+		OnTrip:       func(kind string) { trips = append(trips, kind) },
+	})
+	app := fiber.New()
+	app.Use(Honeytoken(HoneytokenConfig{Detector: d}))
+	app.Get("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	get := func(header, value string) int {
+		req := httptest.NewRequest("GET", "/users", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, get("Authorization", "Bearer some.jwt.token"))
+	assert.Equal(t, fiber.StatusOK, get("X-API-Key", "sk_live_real"))
+	assert.Empty(t, trips)
+
+	assert.Equal(t, fiber.StatusUnauthorized, get("X-API-Key", "sk_live_canary"))
+	assert.Equal(t, []string{honeytoken.KindAPIKey}, trips)
+
+	// The source IP is now locked down, whatever it sends.
+	assert.Equal(t, fiber.StatusForbidden, get("", ""))
+}
+
+func TestHoneytoken_AuthorizationHeader(t *testing.T) {
+	d := honeytoken.New(honeytoken.Config{APIKeyHashes: []string{honeytoken.HashAPIKey("sk_live_canary")}})
+	app := fiber.New()
+	app.Use(Honeytoken(HoneytokenConfig{Detector: d}))
+	app.Get("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	for _, value := range []string{"Bearer sk_live_canary", "ApiKey sk_live_canary", "sk_live_canary"} {
+		req := httptest.NewRequest("GET", "/users", nil)
+		req.Header.Set("Authorization", value)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode, value)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/users", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "no lockdown unless enabled")
+}
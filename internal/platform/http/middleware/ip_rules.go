@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeIPBlocked is the error code returned for requests refused by an IP rule
+const CodeIPBlocked = "IP_BLOCKED"
+
+// IPRulesConfig holds IP rules middleware configuration
+type IPRulesConfig struct {
+	Rules  *iprules.Set
+	Logger *logger.Logger
+	// OnBlock is called for every refused request with the decision's
+	// reason, e.g. to count it. Optional.
+	OnBlock func(reason string)
+}
+
+// IPRules returns a middleware that refuses requests the rule set does not
+// allow with 403 IP_BLOCKED. The client IP is c.IP(), so behind a proxy
+// server.trusted_proxies must be set.
+func IPRules(cfg IPRulesConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		d := cfg.Rules.Check(c.IP(), c.Path())
+		if d.Allowed {
+			return c.Next()
+		}
+
+		if cfg.OnBlock != nil {
+			cfg.OnBlock(d.Reason())
+		}
+		if cfg.Logger != nil {
+			args := []any{"ip", c.IP(), "method", c.Method(), "path", c.Path(), "reason", d.Reason()}
+			if d.Rule != nil {
+				args = append(args, "rule", d.Rule.ID, "cidr", d.Rule.CIDR)
+			} else {
+				args = append(args, "scope", d.Prefix)
+			}
+			cfg.Logger.Warn("Request refused by IP rules", args...)
+		}
+		return response.Fail(c, apperr.New(CodeIPBlocked, "Access from this address is not allowed", fiber.StatusForbidden))
+	}
+}
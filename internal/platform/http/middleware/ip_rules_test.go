@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/iprules"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPRules(t *testing.T) {
+	// app.Test requests come from 0.0.0.0.
+	set, err := iprules.New(nil, []iprules.Rule{
+		{Action: iprules.Allow, CIDR: "10.8.0.0/16", Prefix: "/admin"},
+		{Action: iprules.Deny, CIDR: "0.0.0.0", Prefix: "/internal"},
+	}, iprules.Config{})
+	require.NoError(t, err)
+
+	var blocked []string
+	app := fiber.New()
+	app.Use(IPRules(IPRulesConfig{Rules: set, OnBlock: func(reason string) { blocked = append(blocked, reason) }}))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/users", ok)
+	app.Get("/admin/config", ok)
+	app.Get("/internal/debug", ok)
+
+	status := func(path string) (int, string) {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		var out struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &out)
+		return resp.StatusCode, out.Error.Code
+	}
+
+	code, _ := status("/users")
+	assert.Equal(t, fiber.StatusOK, code)
+
+	code, errCode := status("/admin/config")
+	assert.Equal(t, fiber.StatusForbidden, code)
+	assert.Equal(t, CodeIPBlocked, errCode)
+
+	code, _ = status("/internal/debug")
+	assert.Equal(t, fiber.StatusForbidden, code)
+
+	// The router ignores case, so the rules must too.
+	code, _ = status("/Admin/config")
+	assert.Equal(t, fiber.StatusForbidden, code)
+	code, _ = status("/INTERNAL/debug")
+	assert.Equal(t, fiber.StatusForbidden, code)
+
+	assert.Equal(t, []string{"not_allowed", "denied", "not_allowed", "denied"}, blocked)
+}
+
+func TestIPRules_NilSetAllows(t *testing.T) {
+	app := fiber.New()
+	app.Use(IPRules(IPRulesConfig{}))
+	app.Get("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	resp, err := app.Test(httptest.NewRequest("GET", "/users", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
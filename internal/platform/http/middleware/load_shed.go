@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/14mdzk/goscratch/internal/platform/http/pathmatch"
+	"github.com/14mdzk/goscratch/internal/platform/loadshed"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// loadShedRetryAfter is the Retry-After sent with shed requests, in seconds.
+// The shedder steps down at most once per sampling interval, so retrying
+// sooner than a few intervals is usually shed again.
+const loadShedRetryAfter = "5"
+
+// LoadShedConfig holds load shedding middleware configuration
+type LoadShedConfig struct {
+	Shedder *loadshed.Shedder
+	// LowPriority and CriticalPriority are path prefixes. A prefix matches the
+	// path itself and everything below it, ignoring case as the router does;
+	// critical wins when both match. Every other route is normal priority.
+	LowPriority      []string
+	CriticalPriority []string
+	Logger           *logger.Logger
+	// OnShed is called with the priority of each shed request, e.g.
+	// observability.RecordLoadShed. Optional.
+	OnShed func(priority string)
+}
+
+// LoadShed returns a middleware that rejects requests the shedder currently
+// sheds with 503 and Retry-After, before they reach the database.
+func LoadShed(cfg LoadShedConfig) fiber.Handler {
+	low := trimSlashes(cfg.LowPriority)
+	critical := trimSlashes(cfg.CriticalPriority)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Shedder.Level() == loadshed.LevelNone {
+			return c.Next()
+		}
+
+		priority := routePriority(c.Path(), low, critical)
+		if !cfg.Shedder.Sheds(priority) {
+			return c.Next()
+		}
+
+		if cfg.OnShed != nil {
+			cfg.OnShed(priority.String())
+		}
+		if cfg.Logger != nil {
+			cfg.Logger.Warn("Request shed under load",
+				"priority", priority.String(),
+				"level", cfg.Shedder.Level().String(),
+				"method", c.Method(),
+				"path", c.Path(),
+				"request_id", GetRequestID(c),
+			)
+		}
+		c.Set(fiber.HeaderRetryAfter, loadShedRetryAfter)
+		return response.Fail(c, apperr.New("SERVER_OVERLOADED", "Server is overloaded, please try again later", fiber.StatusServiceUnavailable))
+	}
+}
+
+// routePriority returns the priority of path under the configured prefixes.
+func routePriority(path string, low, critical []string) loadshed.Priority {
+	if pathmatch.UnderAny(path, critical) {
+		return loadshed.PriorityCritical
+	}
+	if pathmatch.UnderAny(path, low) {
+		return loadshed.PriorityLow
+	}
+	return loadshed.PriorityNormal
+}
+
+// trimSlashes drops the trailing slash of each prefix. Unlike trimPrefixes
+// it keeps empty ones, so "/" still covers every path.
+func trimSlashes(prefixes []string) []string {
+	out := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		out[i] = strings.TrimSuffix(p, "/")
+	}
+	return out
+}
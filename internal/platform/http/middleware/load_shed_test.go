@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/loadshed"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shedderAt returns a shedder driven to level by a single loop-lag sample.
+func shedderAt(t *testing.T, level loadshed.Level) *loadshed.Shedder {
+	t.Helper()
+	s := loadshed.New(loadshed.Config{LoopLag: 100 * time.Millisecond})
+	lag := time.Duration(level) * 100 * time.Millisecond
+	require.Equal(t, level, s.Observe(loadshed.Sample{LoopLag: lag}))
+	return s
+}
+
+func loadShedApp(cfg LoadShedConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(LoadShed(cfg))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/files/:id", ok)
+	app.Get("/filesystem", ok)
+	app.Get("/users", ok)
+	app.Get("/health", ok)
+	return app
+}
+
+func TestLoadShed(t *testing.T) {
+	tests := []struct {
+		name  string
+		level loadshed.Level
+		path  string
+		want  int
+	}{
+		{"none serves low", loadshed.LevelNone, "/files/1", fiber.StatusOK},
+		{"low sheds low", loadshed.LevelLow, "/files/1", fiber.StatusServiceUnavailable},
+		{"low serves normal", loadshed.LevelLow, "/users", fiber.StatusOK},
+		{"prefix matches whole segments", loadshed.LevelLow, "/filesystem", fiber.StatusOK},
+		{"prefix ignores case", loadshed.LevelLow, "/FILES/1", fiber.StatusServiceUnavailable},
+		{"critical prefix ignores case", loadshed.LevelNormal, "/Health", fiber.StatusOK},
+		{"normal sheds normal", loadshed.LevelNormal, "/users", fiber.StatusServiceUnavailable},
+		{"normal serves critical", loadshed.LevelNormal, "/health", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shed []string
+			app := loadShedApp(LoadShedConfig{
+				Shedder:          shedderAt(t, tt.level),
+				LowPriority:      []string{"/files/"},
+				CriticalPriority: []string{"/health"},
+				OnShed:           func(p string) { shed = append(shed, p) },
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.StatusCode)
+			if tt.want == fiber.StatusServiceUnavailable {
+				assert.Equal(t, loadShedRetryAfter, resp.Header.Get(fiber.HeaderRetryAfter))
+				assert.Len(t, shed, 1)
+			} else {
+				assert.Empty(t, shed)
+			}
+		})
+	}
+}
+
+func TestLoadShed_CriticalWinsOverLow(t *testing.T) {
+	app := loadShedApp(LoadShedConfig{
+		Shedder:          shedderAt(t, loadshed.LevelNormal),
+		LowPriority:      []string{"/files"},
+		CriticalPriority: []string{"/files"},
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/files/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestLoadShed_NilShedderServesEverything(t *testing.T) {
+	app := loadShedApp(LoadShedConfig{LowPriority: []string{"/files"}})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/files/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
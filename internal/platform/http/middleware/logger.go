@@ -39,6 +39,12 @@ func Logger(log *logger.Logger) fiber.Handler {
 			fields["user_id"] = userID
 		}
 
+		// Add database totals if DBStats is installed
+		if stats := GetQueryStats(c); stats != nil {
+			fields["db_queries"] = stats.Count()
+			fields["db_duration_ms"] = stats.Duration().Milliseconds()
+		}
+
 		// Add error if present
 		if err != nil {
 			fields["error"] = err.Error()
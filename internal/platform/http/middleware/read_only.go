@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/14mdzk/goscratch/internal/platform/http/pathmatch"
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeReadOnly is the error code returned for mutations refused in read-only mode
+const CodeReadOnly = "READ_ONLY"
+
+// ReadOnlyConfig holds read-only middleware configuration
+type ReadOnlyConfig struct {
+	Switch *readonly.Switch
+	// Allow lists path prefixes that may still mutate, e.g. "/auth" so users
+	// can log in, and the admin route that turns the switch off. A prefix
+	// matches the path itself and everything below it, ignoring case as the
+	// router does.
+	Allow []string
+}
+
+// ReadOnly returns a middleware that rejects mutating requests (anything but
+// GET, HEAD and OPTIONS) with 503 READ_ONLY while the switch is on.
+func ReadOnly(cfg ReadOnlyConfig) fiber.Handler {
+	allow := trimSlashes(cfg.Allow)
+
+	return func(c *fiber.Ctx) error {
+		if !cfg.Switch.Enabled() || isSafeMethod(c.Method()) || pathmatch.UnderAny(c.Path(), allow) {
+			return c.Next()
+		}
+
+		message := "Service is in read-only mode; changes are temporarily disabled"
+		if reason := cfg.Switch.State().Reason; reason != "" {
+			message += ": " + reason
+		}
+		return response.Fail(c, apperr.New(CodeReadOnly, message, fiber.StatusServiceUnavailable))
+	}
+}
+
+// isSafeMethod reports whether method never mutates state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
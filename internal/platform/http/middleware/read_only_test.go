@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/readonly"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readOnlyApp(t *testing.T, enabled bool, reason string) *fiber.App {
+	t.Helper()
+	sw := readonly.New(nil, readonly.Config{})
+	require.NoError(t, sw.Set(context.Background(), readonly.State{Enabled: enabled, Reason: reason}))
+
+	app := fiber.New()
+	app.Use(ReadOnly(ReadOnlyConfig{Switch: sw, Allow: []string{"/auth", "/admin/read-only"}}))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.All("/users", ok)
+	app.Post("/auth/login", ok)
+	app.Put("/admin/read-only", ok)
+	return app
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		method  string
+		path    string
+		want    int
+	}{
+		{"off allows writes", false, http.MethodPost, "/users", fiber.StatusOK},
+		{"on allows GET", true, http.MethodGet, "/users", fiber.StatusOK},
+		{"on allows HEAD", true, http.MethodHead, "/users", fiber.StatusOK},
+		{"on allows OPTIONS", true, http.MethodOptions, "/users", fiber.StatusOK},
+		{"on rejects POST", true, http.MethodPost, "/users", fiber.StatusServiceUnavailable},
+		{"on rejects PATCH", true, http.MethodPatch, "/users", fiber.StatusServiceUnavailable},
+		{"on rejects DELETE", true, http.MethodDelete, "/users", fiber.StatusServiceUnavailable},
+		{"on allows listed prefix", true, http.MethodPost, "/auth/login", fiber.StatusOK},
+		{"on allows the switch itself", true, http.MethodPut, "/admin/read-only", fiber.StatusOK},
+		{"on allows a listed prefix in any case", true, http.MethodPut, "/Admin/Read-Only", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := readOnlyApp(t, tt.enabled, "")
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestReadOnly_ErrorBody(t *testing.T) {
+	app := readOnlyApp(t, true, "database failover in progress")
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/users", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), CodeReadOnly)
+	assert.Contains(t, string(body), "database failover in progress")
+}
+
+func TestReadOnly_NilSwitch(t *testing.T) {
+	app := fiber.New()
+	app.Use(ReadOnly(ReadOnlyConfig{}))
+	app.Post("/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/users", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
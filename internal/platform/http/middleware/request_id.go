@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"strings"
+
 	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,8 +18,10 @@ const (
 // RequestID adds a unique request ID to each request
 func RequestID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Check if request ID already exists in headers
-		requestID := c.Get(RequestIDHeader)
+		// Check if request ID already exists in headers. Copied, as the
+		// header buffer is reused once the request is done and the ID can
+		// outlive it, e.g. in an audit entry written asynchronously.
+		requestID := strings.Clone(c.Get(RequestIDHeader))
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResponseCacheHeader reports whether a response came from the cache ("HIT")
+// or from the handler ("MISS")
+const ResponseCacheHeader = "X-Cache"
+
+// ResponseCacheConfig holds response cache configuration
+type ResponseCacheConfig struct {
+	Cache port.Cache
+	TTL   time.Duration // How long a response is served from the cache (default: 30s)
+	// KeyPrefix namespaces the entries, e.g. per route. Optional.
+	KeyPrefix string
+}
+
+// cachedResponse is what ResponseCache stores per entry
+type cachedResponse struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// ResponseCache returns a middleware that caches successful GET responses for
+// TTL. Entries are keyed by the full URL (path and query), the caller's user
+// ID and the negotiated response version, so an authenticated response is
+// never served to another user, nor one version's shape to a client that
+// asked for another.
+// Only 200 responses with a buffered body are stored; cache errors fall
+// through to the handler.
+//
+// Mount it after Auth and RequirePermission so a cached response is never
+// served to a caller the handler would have rejected.
+func ResponseCache(cfg ResponseCacheConfig) fiber.Handler {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 30 * time.Second
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet || cfg.Cache == nil {
+			return c.Next()
+		}
+
+		key := "httpcache:" + cfg.KeyPrefix + GetUserID(c) + ":v" + strconv.Itoa(response.Version(c)) + ";" + response.ContentType(c) + ":" + c.OriginalURL()
+		var hit cachedResponse
+		if err := cfg.Cache.GetJSON(c.UserContext(), key, &hit); err == nil {
+			c.Set(ResponseCacheHeader, "HIT")
+			c.Set(fiber.HeaderContentType, hit.ContentType)
+			return c.Status(fiber.StatusOK).Send(hit.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		c.Set(ResponseCacheHeader, "MISS")
+
+		resp := c.Response()
+		if resp.StatusCode() != fiber.StatusOK || resp.IsBodyStream() {
+			return nil
+		}
+		entry := cachedResponse{
+			ContentType: string(resp.Header.ContentType()),
+			Body:        append([]byte(nil), resp.Body()...),
+		}
+		_ = cfg.Cache.SetJSON(c.UserContext(), key, entry, cfg.TTL)
+		return nil
+	}
+}
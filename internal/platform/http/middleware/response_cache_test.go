@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newResponseCacheApp mounts ResponseCache in front of a handler that counts
+// its calls. The X-User header stands in for Auth.
+func newResponseCacheApp(cfg ResponseCacheConfig, status int, calls *int) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if userID := c.Get("X-User"); userID != "" {
+			c.Locals("user_id", userID)
+		}
+		return c.Next()
+	})
+	handler := func(c *fiber.Ctx) error {
+		*calls++
+		return c.Status(status).SendString(c.OriginalURL())
+	}
+	app.Get("/items", ResponseCache(cfg), handler)
+	app.Post("/items", ResponseCache(cfg), handler)
+	return app
+}
+
+func doResponseCacheRequest(t *testing.T, app *fiber.App, method, target, user string) (string, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.Header.Get(ResponseCacheHeader), string(body)
+}
+
+func TestResponseCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	calls := 0
+	app := newResponseCacheApp(ResponseCacheConfig{Cache: redisCache, TTL: time.Minute}, fiber.StatusOK, &calls)
+
+	state, body := doResponseCacheRequest(t, app, "GET", "/items?page=1", "u-1")
+	assert.Equal(t, "MISS", state)
+	assert.Equal(t, "/items?page=1", body)
+
+	state, body = doResponseCacheRequest(t, app, "GET", "/items?page=1", "u-1")
+	assert.Equal(t, "HIT", state)
+	assert.Equal(t, "/items?page=1", body)
+	assert.Equal(t, 1, calls)
+
+	// Another query string or another user is a separate entry.
+	state, _ = doResponseCacheRequest(t, app, "GET", "/items?page=2", "u-1")
+	assert.Equal(t, "MISS", state)
+	state, _ = doResponseCacheRequest(t, app, "GET", "/items?page=1", "u-2")
+	assert.Equal(t, "MISS", state)
+	assert.Equal(t, 3, calls)
+
+	// Entries expire after TTL.
+	mr.FastForward(time.Minute + time.Second)
+	state, _ = doResponseCacheRequest(t, app, "GET", "/items?page=1", "u-1")
+	assert.Equal(t, "MISS", state)
+}
+
+func TestResponseCache_SkipsNonGETAndErrors(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	calls := 0
+	app := newResponseCacheApp(ResponseCacheConfig{Cache: redisCache}, fiber.StatusOK, &calls)
+	doResponseCacheRequest(t, app, "POST", "/items", "")
+	state, _ := doResponseCacheRequest(t, app, "POST", "/items", "")
+	assert.Empty(t, state)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	app = newResponseCacheApp(ResponseCacheConfig{Cache: redisCache}, fiber.StatusNotFound, &calls)
+	doResponseCacheRequest(t, app, "GET", "/items", "")
+	state, _ = doResponseCacheRequest(t, app, "GET", "/items", "")
+	assert.Equal(t, "MISS", state)
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_SeparatesAPIVersions(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	versions := response.NewVersions()
+	response.Register(versions, 2, func(g gadget) any { return map[string]string{"title": g.Name} })
+	calls := 0
+	app := fiber.New()
+	app.Use(APIVersion(APIVersionConfig{Versions: versions}))
+	app.Get("/gadget", ResponseCache(ResponseCacheConfig{Cache: redisCache, TTL: time.Minute}), func(c *fiber.Ctx) error {
+		calls++
+		return response.Success(c, gadget{Name: "Gear"})
+	})
+
+	get := func(accept string) (string, string, string) {
+		req := httptest.NewRequest("GET", "/gadget", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get(ResponseCacheHeader), resp.Header.Get("Content-Type"), string(body)
+	}
+
+	state, _, v1 := get("")
+	assert.Equal(t, "MISS", state)
+	state, contentType, body := get("application/vnd.goscratch.v2+json")
+	assert.Equal(t, "MISS", state)
+	assert.Equal(t, "application/vnd.goscratch.v2+json", contentType)
+	assert.Contains(t, body, `"title"`)
+	// Asking for version 1 explicitly is labeled differently from the default.
+	state, contentType, _ = get("application/vnd.goscratch.v1+json")
+	assert.Equal(t, "MISS", state)
+	assert.Equal(t, "application/vnd.goscratch.v1+json", contentType)
+
+	state, contentType, body = get("")
+	assert.Equal(t, "HIT", state)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, v1, body)
+	assert.Equal(t, 3, calls)
+}
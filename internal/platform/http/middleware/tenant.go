@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeInvalidTenant is the error code of requests naming a malformed tenant
+const CodeInvalidTenant = "INVALID_TENANT"
+
+// tenantPattern matches tenant IDs: slugs or UUIDs that fit a casbin_rules
+// column
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+var errInvalidTenant = apperr.New(CodeInvalidTenant, "Invalid tenant", fiber.StatusBadRequest)
+
+// TenantConfig holds tenant resolution configuration
+type TenantConfig struct {
+	// Param is the route parameter naming the tenant, e.g. "tenant" for
+	// /orgs/:tenant/projects. It wins over Header. Empty disables it.
+	Param string
+	// Header names the tenant on routes without Param. Empty disables it.
+	Header string
+}
+
+// Tenant returns a route-level middleware that resolves the request's tenant
+// from the cfg.Param route parameter or, failing that, the cfg.Header header,
+// and stores it for GetTenantID. Permission and role checks then apply the
+// caller's roles in that tenant (port.Authorizer.EnforceInDomain) as well as
+// their global roles. Requests naming no tenant are checked globally; a
+// malformed tenant is refused with 400 INVALID_TENANT.
+//
+// It must run after route matching, where parameters are known: the route
+// builder adds it to every route when routes.Config.Tenant is set.
+func Tenant(cfg TenantConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var tenant string
+		if cfg.Param != "" {
+			tenant = c.Params(cfg.Param)
+		}
+		if tenant == "" && cfg.Header != "" {
+			tenant = c.Get(cfg.Header)
+		}
+		if tenant == "" {
+			return c.Next()
+		}
+		if !tenantPattern.MatchString(tenant) {
+			return response.Fail(c, errInvalidTenant)
+		}
+		c.Locals("tenant_id", tenant)
+		return c.Next()
+	}
+}
+
+// GetTenantID retrieves the tenant Tenant resolved, or "" when the request
+// names none
+func GetTenantID(c *fiber.Ctx) string {
+	if tenant, ok := c.Locals("tenant_id").(string); ok {
+		return tenant
+	}
+	return ""
+}
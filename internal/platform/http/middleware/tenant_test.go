@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenant(t *testing.T) {
+	app := fiber.New()
+	tenant := Tenant(TenantConfig{Param: "tenant", Header: "X-Tenant-ID"})
+	echo := func(c *fiber.Ctx) error { return c.SendString(GetTenantID(c)) }
+	app.Get("/orgs/:tenant/things", tenant, echo)
+	app.Get("/things", tenant, echo)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+		tenant string
+	}{
+		{"none", "/things", "", fiber.StatusOK, ""},
+		{"header", "/things", "acme", fiber.StatusOK, "acme"},
+		{"param", "/orgs/acme/things", "", fiber.StatusOK, "acme"},
+		{"param wins", "/orgs/acme/things", "globex", fiber.StatusOK, "acme"},
+		{"uuid", "/things", "0b6c7a2e-4f1d-4c8e-9a53-2d8f6e1b7c40", fiber.StatusOK, "0b6c7a2e-4f1d-4c8e-9a53-2d8f6e1b7c40"},
+		{"malformed header", "/things", "acme corp", fiber.StatusBadRequest, ""},
+		{"malformed param", "/orgs/-acme/things", "", fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == fiber.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.tenant, string(body))
+			}
+		})
+	}
+}
+
+func TestRequirePermission_InTenant(t *testing.T) {
+	mock := &mockAuthorizer{
+		enforceFunc: func(string, string, string) (bool, error) { return false, nil },
+		enforceInDomainFunc: func(sub, dom, obj, act string) (bool, error) {
+			return sub == "user-1" && dom == "acme" && obj == "projects" && act == "read", nil
+		},
+	}
+	app := fiber.New()
+	app.Get("/projects", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "user-1")
+		return c.Next()
+	}, Tenant(TenantConfig{Header: "X-Tenant-ID"}), RequirePermission(mock, "projects", "read"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for tenant, status := range map[string]int{"acme": fiber.StatusOK, "globex": fiber.StatusForbidden, "": fiber.StatusForbidden} {
+		req := httptest.NewRequest("GET", "/projects", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, "tenant %q", tenant)
+	}
+}
+
+func TestRequireRole_InTenant(t *testing.T) {
+	mock := &mockAuthorizer{
+		domainRolesFunc: func(userID, domain string) ([]string, error) {
+			if userID == "user-1" && domain == "acme" {
+				return []string{"editor"}, nil
+			}
+			return nil, nil
+		},
+	}
+	app := fiber.New()
+	app.Get("/projects", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "user-1")
+		return c.Next()
+	}, Tenant(TenantConfig{Header: "X-Tenant-ID"}), RequireRole(mock, "editor"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for tenant, status := range map[string]int{"acme": fiber.StatusOK, "globex": fiber.StatusForbidden, "": fiber.StatusForbidden} {
+		req := httptest.NewRequest("GET", "/projects", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, "tenant %q", tenant)
+	}
+}
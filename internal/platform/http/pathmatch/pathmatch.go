@@ -0,0 +1,40 @@
+// Package pathmatch matches request paths against route prefixes the way
+// the router does.
+//
+// The server leaves fiber.Config.CaseSensitive off, so /Admin/config and
+// /ADMIN/config reach the handler registered for /admin/config. Anything
+// that guards or selects routes by prefix — IP rules, geo restriction,
+// request auditing — has to match the same way, or a change of case walks
+// around it.
+package pathmatch
+
+import "strings"
+
+// Under reports whether path is prefix or lies below it, ignoring case. A
+// prefix matches whole segments: /admin covers /admin and /Admin/config but
+// not /administrator. An empty prefix covers every path.
+func Under(path, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	if len(path) < len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
+}
+
+// UnderAny reports whether path is under any of prefixes.
+func UnderAny(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if Under(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the form of prefix that prefixes differing only in case share,
+// for grouping rules by the routes they cover.
+func Key(prefix string) string {
+	return strings.ToLower(prefix)
+}
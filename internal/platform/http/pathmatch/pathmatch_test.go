@@ -0,0 +1,45 @@
+package pathmatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnder(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		prefix string
+		want   bool
+	}{
+		{"empty prefix", "/anything", "", true},
+		{"exact", "/admin", "/admin", true},
+		{"below", "/admin/config", "/admin", true},
+		{"trailing slash", "/admin/", "/admin", true},
+		{"mixed case path", "/Admin/config", "/admin", true},
+		{"upper case path", "/ADMIN", "/admin", true},
+		{"mixed case prefix", "/admin/config", "/Admin", true},
+		{"whole segments only", "/administrator", "/admin", false},
+		{"whole segments ignoring case", "/ADMINISTRATOR", "/admin", false},
+		{"shorter path", "/adm", "/admin", false},
+		{"other route", "/users", "/admin", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Under(tt.path, tt.prefix))
+		})
+	}
+}
+
+func TestUnderAny(t *testing.T) {
+	prefixes := []string{"/users", "/admin"}
+	assert.True(t, UnderAny("/Users/42", prefixes))
+	assert.True(t, UnderAny("/ADMIN", prefixes))
+	assert.False(t, UnderAny("/health", prefixes))
+	assert.False(t, UnderAny("/admin", nil))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("/admin"), Key("/Admin"))
+}
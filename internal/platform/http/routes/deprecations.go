@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"sort"
+	"time"
+)
+
+// maxClients caps the distinct client names tracked across all deprecated
+// routes, which also bounds the metric labels built from them. Calls from
+// further clients are counted under OtherClient.
+const maxClients = 100
+
+// OtherClient names the clients beyond the first maxClients
+const OtherClient = "other"
+
+// usage counts the calls to one deprecated route
+type usage struct {
+	requests int64
+	lastSeen time.Time
+	clients  map[string]*ClientUsage
+}
+
+// ClientUsage counts one client's calls to a deprecated route
+type ClientUsage struct {
+	Client   string
+	Requests int64
+	LastSeen time.Time
+}
+
+// DeprecationReport is the usage of one deprecated route since the registry
+// was created
+type DeprecationReport struct {
+	Method string
+	Path   string
+	DeprecationInfo
+	Requests int64
+	LastSeen time.Time     // zero when never called
+	Clients  []ClientUsage // busiest first
+	// Removable is set once the sunset has passed and no call has been seen
+	// for the quiet period the report was built with.
+	Removable bool
+}
+
+// recordDeprecatedCall counts a call to a deprecated route and returns the
+// client name it was counted under. A nil *Registry counts nothing and
+// returns OtherClient.
+func (r *Registry) recordDeprecatedCall(method, path, client string, now time.Time) string {
+	if r == nil {
+		return OtherClient
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.usage == nil {
+		r.usage = make(map[string]*usage)
+		r.clients = make(map[string]bool)
+	}
+	if !r.clients[client] {
+		if len(r.clients) >= maxClients {
+			client = OtherClient
+		} else {
+			r.clients[client] = true
+		}
+	}
+
+	key := method + " " + path
+	u := r.usage[key]
+	if u == nil {
+		u = &usage{clients: make(map[string]*ClientUsage)}
+		r.usage[key] = u
+	}
+	u.requests++
+	u.lastSeen = now
+	cu := u.clients[client]
+	if cu == nil {
+		cu = &ClientUsage{Client: client}
+		u.clients[client] = cu
+	}
+	cu.Requests++
+	cu.LastSeen = now
+	return client
+}
+
+// TrackingSince returns when the registry started counting calls. Usage is
+// kept in memory, per instance, so it restarts with the process.
+func (r *Registry) TrackingSince() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	return r.created
+}
+
+// Deprecations reports the usage of every deprecated route, sorted by path,
+// then method. A route is removable once its sunset has passed and it has
+// not been called for quiet, as far as this instance has seen for at least
+// that long.
+func (r *Registry) Deprecations(now time.Time, quiet time.Duration) []DeprecationReport {
+	if r == nil {
+		return nil
+	}
+	routes := r.Routes()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []DeprecationReport
+	for _, info := range routes {
+		if info.Deprecation == nil {
+			continue
+		}
+		rep := DeprecationReport{Method: info.Method, Path: info.Path, DeprecationInfo: *info.Deprecation}
+		if u := r.usage[info.Method+" "+info.Path]; u != nil {
+			rep.Requests, rep.LastSeen = u.requests, u.lastSeen
+			for _, cu := range u.clients {
+				rep.Clients = append(rep.Clients, *cu)
+			}
+			sort.Slice(rep.Clients, func(i, j int) bool {
+				if rep.Clients[i].Requests != rep.Clients[j].Requests {
+					return rep.Clients[i].Requests > rep.Clients[j].Requests
+				}
+				return rep.Clients[i].Client < rep.Clients[j].Client
+			})
+		}
+		sunsetPassed := !rep.Sunset.IsZero() && !now.Before(rep.Sunset)
+		quietSince := now.Add(-quiet)
+		rep.Removable = sunsetPassed && !r.created.After(quietSince) && rep.LastSeen.Before(quietSince)
+		out = append(out, rep)
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_Deprecated(t *testing.T) {
+	since := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	registry := NewRegistry()
+	var calls []string
+	app := fiber.New()
+	r := New(app, Config{
+		Registry:         registry,
+		OnDeprecatedCall: func(method, path, client string) { calls = append(calls, method+" "+path+" "+client) },
+	})
+	widgets := r.Group("/widgets").Authenticated(fakeAuth)
+	widgets.Get("/search", ok).Deprecated(since).Sunset(sunset).Successor("/widgets")
+	widgets.Get("/", ok)
+	r.Mount()
+
+	get := func(path, ua string) {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", "alice")
+		req.Header.Set("User-Agent", ua)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		if path == "/widgets/search" {
+			assert.Equal(t, "@1782864000", resp.Header.Get("Deprecation"))
+			assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+			assert.Equal(t, `</widgets>; rel="successor-version"`, resp.Header.Get("Link"))
+		} else {
+			assert.Empty(t, resp.Header.Get("Deprecation"))
+		}
+	}
+	get("/widgets/search", "okhttp/4.12.0")
+	get("/widgets/search", "okhttp/4.11.0")
+	get("/widgets/search", "billing-sync/2.0")
+	get("/widgets", "okhttp/4.12.0")
+
+	assert.Equal(t, []string{
+		"GET /widgets/search okhttp",
+		"GET /widgets/search okhttp",
+		"GET /widgets/search billing-sync",
+	}, calls)
+
+	infos := registry.Routes()
+	require.Len(t, infos, 2)
+	assert.Nil(t, infos[0].Deprecation)
+	assert.Equal(t, &DeprecationInfo{Since: since, Sunset: sunset, Successor: "/widgets"}, infos[1].Deprecation)
+
+	reports := registry.Deprecations(time.Now(), 0)
+	require.Len(t, reports, 1)
+	rep := reports[0]
+	assert.Equal(t, "/widgets/search", rep.Path)
+	assert.Equal(t, int64(3), rep.Requests)
+	assert.False(t, rep.LastSeen.IsZero())
+	require.Len(t, rep.Clients, 2)
+	assert.Equal(t, "okhttp", rep.Clients[0].Client)
+	assert.Equal(t, int64(2), rep.Clients[0].Requests)
+	assert.Equal(t, "billing-sync", rep.Clients[1].Client)
+	assert.False(t, rep.Removable, "sunset has not passed")
+}
+
+func TestRegistry_DeprecationsRemovable(t *testing.T) {
+	start := time.Now()
+	registry := NewRegistry()
+	registry.add(Info{Method: "GET", Path: "/old", Deprecation: &DeprecationInfo{Since: start.Add(-90 * 24 * time.Hour), Sunset: start.Add(24 * time.Hour)}})
+	registry.add(Info{Method: "GET", Path: "/forever", Deprecation: &DeprecationInfo{Since: start.Add(-90 * 24 * time.Hour)}})
+	week := 7 * 24 * time.Hour
+
+	removable := func(now time.Time) map[string]bool {
+		out := map[string]bool{}
+		for _, rep := range registry.Deprecations(now, week) {
+			out[rep.Path] = rep.Removable
+		}
+		return out
+	}
+
+	assert.Equal(t, map[string]bool{"/old": false, "/forever": false}, removable(start.Add(2*24*time.Hour)),
+		"sunset passed, but the registry has not watched for a full quiet period")
+	assert.Equal(t, map[string]bool{"/old": true, "/forever": false}, removable(start.Add(8*24*time.Hour)),
+		"a route without a sunset is never removable")
+
+	registry.recordDeprecatedCall("GET", "/old", "okhttp", start.Add(7*24*time.Hour))
+	assert.False(t, removable(start.Add(8 * 24 * time.Hour))["/old"], "called within the quiet period")
+	assert.True(t, removable(start.Add(15 * 24 * time.Hour))["/old"])
+}
+
+func TestRegistry_CapsClients(t *testing.T) {
+	registry := NewRegistry()
+	now := time.Now()
+	for i := 0; i < maxClients; i++ {
+		assert.Equal(t, fmt.Sprint("client-", i), registry.recordDeprecatedCall("GET", "/old", fmt.Sprint("client-", i), now))
+	}
+	assert.Equal(t, OtherClient, registry.recordDeprecatedCall("GET", "/old", "one-too-many", now))
+	assert.Equal(t, "client-0", registry.recordDeprecatedCall("GET", "/other", "client-0", now), "known clients keep their name")
+
+	var nilRegistry *Registry
+	assert.Equal(t, OtherClient, nilRegistry.recordDeprecatedCall("GET", "/old", "okhttp", now))
+	assert.Nil(t, nilRegistry.Deprecations(now, 0))
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI extension keys added by AnnotateOpenAPI
+const (
+	ExtPermission = "x-permission"
+	ExtRateLimit  = "x-rate-limit"
+	ExtCacheTTL   = "x-cache-ttl"
+	ExtSunset     = "x-sunset"
+)
+
+// AnnotateOpenAPI adds the declared permission, rate limit and cache TTL of
+// each route to the matching operation of an OpenAPI YAML document, as the
+// x-permission, x-rate-limit and x-cache-ttl extensions. Deprecated routes
+// are marked deprecated, with their sunset date in x-sunset. Operations
+// without a matching route, and routes without a matching operation, are
+// left alone.
+// Existing extensions with those keys are replaced, so the spec cannot drift
+// from what the code enforces.
+func AnnotateOpenAPI(spec []byte, routes []Info) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: empty document")
+	}
+	paths := mappingValue(doc.Content[0], "paths")
+	if paths == nil {
+		return spec, nil
+	}
+
+	for _, info := range routes {
+		item := mappingValue(paths, OpenAPIPath(info.Path))
+		if item == nil {
+			continue
+		}
+		op := mappingValue(item, strings.ToLower(info.Method))
+		if op == nil || op.Kind != yaml.MappingNode {
+			continue
+		}
+		if info.Permission != "" {
+			setMappingValue(op, ExtPermission, scalar(info.Permission))
+		}
+		if info.RateLimit != nil {
+			setMappingValue(op, ExtRateLimit, &yaml.Node{
+				Kind: yaml.MappingNode,
+				Content: []*yaml.Node{
+					scalar("max"), {Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(info.RateLimit.Max)},
+					scalar("window"), scalar(info.RateLimit.Window.String()),
+				},
+			})
+		}
+		if info.CacheTTL > 0 {
+			setMappingValue(op, ExtCacheTTL, scalar(info.CacheTTL.String()))
+		}
+		if info.Deprecation != nil {
+			setMappingValue(op, "deprecated", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+			if !info.Deprecation.Sunset.IsZero() {
+				setMappingValue(op, ExtSunset, scalar(info.Deprecation.Sunset.UTC().Format(time.RFC3339)))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// OpenAPIPath converts a fiber path (/users/:id) to OpenAPI syntax
+// (/users/{id}).
+func OpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = "{" + strings.TrimSuffix(s[1:], "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func scalar(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+// mappingValue returns the value for key in mapping node m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key in mapping node m, replacing an existing value.
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, scalar(key), value)
+}
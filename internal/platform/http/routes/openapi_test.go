@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testSpec = `openapi: 3.0.3
+info:
+  title: Test
+  version: "1"
+paths:
+  /widgets:
+    get:
+      summary: List widgets
+      x-permission: stale:value
+    post:
+      summary: Create widget
+  /widgets/{id}:
+    get:
+      summary: Get widget
+`
+
+func TestAnnotateOpenAPI(t *testing.T) {
+	out, err := AnnotateOpenAPI([]byte(testSpec), []Info{
+		{Method: "GET", Path: "/widgets", Permission: "widgets:read", RateLimit: &RateLimitInfo{Max: 100, Window: time.Minute}},
+		{Method: "POST", Path: "/widgets", Permission: "widgets:create", Deprecation: &DeprecationInfo{
+			Since:  time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+			Sunset: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{Method: "GET", Path: "/widgets/:id", Permission: "widgets:read", CacheTTL: 30 * time.Second},
+		{Method: "DELETE", Path: "/widgets/:id", Permission: "widgets:delete"}, // not in the spec
+	})
+	require.NoError(t, err)
+
+	var doc struct {
+		Paths map[string]map[string]map[string]any `yaml:"paths"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &doc))
+
+	list := doc.Paths["/widgets"]["get"]
+	assert.Equal(t, "List widgets", list["summary"])
+	assert.Equal(t, "widgets:read", list[ExtPermission])
+	assert.Equal(t, map[string]any{"max": 100, "window": "1m0s"}, list[ExtRateLimit])
+	assert.NotContains(t, list, ExtCacheTTL)
+
+	assert.NotContains(t, list, "deprecated")
+
+	create := doc.Paths["/widgets"]["post"]
+	assert.Equal(t, "widgets:create", create[ExtPermission])
+	assert.Equal(t, true, create["deprecated"])
+	assert.Equal(t, "2027-01-01T00:00:00Z", create[ExtSunset])
+	assert.Equal(t, "30s", doc.Paths["/widgets/{id}"]["get"][ExtCacheTTL])
+	assert.NotContains(t, doc.Paths["/widgets/{id}"], "delete")
+}
+
+func TestAnnotateOpenAPI_InvalidSpec(t *testing.T) {
+	_, err := AnnotateOpenAPI([]byte("paths: [unclosed"), nil)
+	assert.Error(t, err)
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	assert.Equal(t, "/users/{id}", OpenAPIPath("/users/:id"))
+	assert.Equal(t, "/roles/{role}/permissions", OpenAPIPath("/roles/:role/permissions"))
+	assert.Equal(t, "/files/{name}", OpenAPIPath("/files/:name?"))
+	assert.Equal(t, "/health", OpenAPIPath("/health"))
+}
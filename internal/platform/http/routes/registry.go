@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Info describes a mounted route and the middleware declared on it
+type Info struct {
+	Method        string
+	Path          string // fiber syntax, e.g. /users/:id
+	Permission    string // "object:action", empty when none is required
+	OwnerAccess   bool   // the object's owner may pass Permission as port.RoleOwner
+	Authenticated bool
+	RateLimit     *RateLimitInfo   // nil when the route has no own limit
+	CacheTTL      time.Duration    // 0 when responses are not cached
+	Deprecation   *DeprecationInfo // nil unless the route is deprecated
+}
+
+// DeprecationInfo is a deprecated route's schedule
+type DeprecationInfo struct {
+	Since     time.Time
+	Sunset    time.Time // zero when no removal date is set
+	Successor string    // replacement URL, empty when none
+}
+
+// RateLimitInfo is a route's declared rate limit
+type RateLimitInfo struct {
+	Max    int
+	Window time.Duration
+}
+
+// Registry records the routes mounted by every builder that shares it. It
+// also owns the rate limiters those routes created; Close releases them. A nil
+// *Registry records nothing.
+type Registry struct {
+	mu      sync.RWMutex
+	routes  []Info
+	closers []io.Closer
+	created time.Time
+	usage   map[string]*usage // keyed by "METHOD path"
+	clients map[string]bool   // client names seen, capped at maxClients
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		created: time.Now(),
+		usage:   make(map[string]*usage),
+		clients: make(map[string]bool),
+	}
+}
+
+func (r *Registry) add(info Info) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, info)
+}
+
+func (r *Registry) addCloser(c io.Closer) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closers = append(r.closers, c)
+}
+
+// Routes returns the recorded routes sorted by path, then method
+func (r *Registry) Routes() []Info {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	out := append([]Info(nil), r.routes...)
+	r.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// Permissions returns every permission declared on a route, sorted and
+// deduplicated: the catalog of permissions the API actually checks.
+func (r *Registry) Permissions() []string {
+	seen := make(map[string]bool)
+	perms := []string{}
+	for _, info := range r.Routes() {
+		if info.Permission != "" && !seen[info.Permission] {
+			seen[info.Permission] = true
+			perms = append(perms, info.Permission)
+		}
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+// Close releases the rate limiters created for recorded routes
+func (r *Registry) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	closers := r.closers
+	r.closers = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
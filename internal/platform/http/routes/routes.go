@@ -0,0 +1,316 @@
+// Package routes provides a declarative route builder for module
+// registration.
+//
+// Cross-cutting middleware is declared next to the route it guards instead of
+// being stacked by hand:
+//
+//	r := routes.New(router, m.routes)
+//	users := r.Group("/users").Authenticated(authMiddleware)
+//	users.Get("/", m.handler.List).Require("users:read").RateLimit(100).Cache(30 * time.Second)
+//	users.Get("/search", m.handler.Search).Deprecated(since).Sunset(removal).Successor("/users")
+//	r.Mount()
+//
+// Routes are registered with fiber when Mount is called, in declaration
+// order. Every mounted route is also recorded in the Registry, which feeds the
+// admin route catalog and the OpenAPI annotations served by the docs module.
+package routes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultRateWindow is the window used by Route.RateLimit
+const defaultRateWindow = time.Minute
+
+// Config holds the dependencies shared by every route a builder mounts
+type Config struct {
+	Authorizer port.Authorizer // Checks Require; required if any route uses it
+	Cache      port.Cache      // Backs Cache, and RateLimit when UseRedis is set
+	UseRedis   bool            // Share RateLimit counters across instances through Cache
+	Registry   *Registry       // Records mounted routes; optional
+	// APIKeys, when set, lets Authenticated groups accept an API key in
+	// place of a JWT. Routes without Require then need a full-access ("*")
+	// key. Optional.
+	APIKeys *middleware.APIKeyAuthConfig
+	// OnDeprecatedCall is called for every request to a deprecated route
+	// with its method, path and client name, e.g. to count it. Optional.
+	OnDeprecatedCall func(method, path, client string)
+	// Tenant, when set, resolves every route's tenant ahead of its
+	// permission check (middleware.Tenant), so Require applies the caller's
+	// roles in that tenant. Optional.
+	Tenant *middleware.TenantConfig
+}
+
+// Builder declares the routes of one module. Group returns a child builder
+// that shares the parent's route list, so a single Mount on the root
+// registers everything.
+type Builder struct {
+	router fiber.Router
+	prefix string
+	auth   bool
+	cfg    Config
+	routes *[]*Route
+}
+
+// New creates a builder on router
+func New(router fiber.Router, cfg Config) *Builder {
+	return &Builder{
+		router: router,
+		cfg:    cfg,
+		routes: new([]*Route),
+	}
+}
+
+// Group creates a child builder under prefix. handlers run for every route in
+// the group, as with fiber's Group.
+func (b *Builder) Group(prefix string, handlers ...fiber.Handler) *Builder {
+	return &Builder{
+		router: b.router.Group(prefix, handlers...),
+		prefix: b.prefix + prefix,
+		auth:   b.auth,
+		cfg:    b.cfg,
+		routes: b.routes,
+	}
+}
+
+// Authenticated runs auth (typically middleware.Auth) before every route in
+// b and marks them as authenticated in the registry. With Config.APIKeys,
+// requests carrying an API key are authenticated by the key instead.
+func (b *Builder) Authenticated(auth fiber.Handler) *Builder {
+	if b.cfg.APIKeys != nil {
+		auth = middleware.AuthOrAPIKey(auth, *b.cfg.APIKeys)
+	}
+	b.router.Use(auth)
+	b.auth = true
+	return b
+}
+
+// Get declares a GET route
+func (b *Builder) Get(path string, handler fiber.Handler) *Route {
+	return b.add(fiber.MethodGet, path, handler)
+}
+
+// Post declares a POST route
+func (b *Builder) Post(path string, handler fiber.Handler) *Route {
+	return b.add(fiber.MethodPost, path, handler)
+}
+
+// Put declares a PUT route
+func (b *Builder) Put(path string, handler fiber.Handler) *Route {
+	return b.add(fiber.MethodPut, path, handler)
+}
+
+// Patch declares a PATCH route
+func (b *Builder) Patch(path string, handler fiber.Handler) *Route {
+	return b.add(fiber.MethodPatch, path, handler)
+}
+
+// Delete declares a DELETE route
+func (b *Builder) Delete(path string, handler fiber.Handler) *Route {
+	return b.add(fiber.MethodDelete, path, handler)
+}
+
+func (b *Builder) add(method, path string, handler fiber.Handler) *Route {
+	r := &Route{
+		builder: b,
+		method:  method,
+		path:    path,
+		handler: handler,
+	}
+	*b.routes = append(*b.routes, r)
+	return r
+}
+
+// Mount registers every declared route with fiber and records it in the
+// registry. Call it once, on the root builder, after declaring all routes.
+// It panics on an invalid declaration, like fiber does for a bad route.
+func (b *Builder) Mount() {
+	for _, r := range *b.routes {
+		r.mount()
+	}
+	*b.routes = nil
+}
+
+// Route is one declared route. Its methods add middleware and return the
+// route for chaining.
+type Route struct {
+	builder    *Builder
+	method     string
+	path       string
+	handler    fiber.Handler
+	permission string
+	owner      middleware.OwnerExtractor
+	rateMax    int
+	rateWindow time.Duration
+	cacheTTL   time.Duration
+	deprecated time.Time
+	sunset     time.Time
+	successor  string
+}
+
+// Require guards the route with an "object:action" permission, e.g.
+// "users:read", checked by middleware.RequirePermission.
+func (r *Route) Require(permission string) *Route {
+	r.permission = permission
+	return r
+}
+
+// OwnedBy lets the owner of the object the route acts on, as returned by
+// owner, through Require when port.RoleOwner has the permission
+// (middleware.RequireOwnershipOrPermission). It needs Require.
+func (r *Route) OwnedBy(owner middleware.OwnerExtractor) *Route {
+	r.owner = owner
+	return r
+}
+
+// RateLimit allows max requests per minute per caller on this route.
+func (r *Route) RateLimit(max int) *Route {
+	return r.RateLimitPer(max, defaultRateWindow)
+}
+
+// RateLimitPer allows max requests per window per caller on this route.
+// Callers are identified by user ID when authenticated, otherwise by IP.
+func (r *Route) RateLimitPer(max int, window time.Duration) *Route {
+	r.rateMax = max
+	r.rateWindow = window
+	return r
+}
+
+// Cache serves successful responses from the cache for ttl
+// (middleware.ResponseCache). GET routes only.
+func (r *Route) Cache(ttl time.Duration) *Route {
+	r.cacheTTL = ttl
+	return r
+}
+
+// Deprecated marks the route deprecated since the given time. Responses carry
+// a Deprecation header and calls are counted per client (see
+// Registry.Deprecations).
+func (r *Route) Deprecated(since time.Time) *Route {
+	r.deprecated = since
+	return r
+}
+
+// Sunset sets when a deprecated route may be removed, sent as the Sunset
+// header.
+func (r *Route) Sunset(at time.Time) *Route {
+	r.sunset = at
+	return r
+}
+
+// Successor points callers of a deprecated route at its replacement, sent
+// as a successor-version Link header.
+func (r *Route) Successor(link string) *Route {
+	r.successor = link
+	return r
+}
+
+// fullPath returns the route path including every group prefix, without a
+// trailing slash.
+func (r *Route) fullPath() string {
+	p := r.builder.prefix + r.path
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// mount registers r with fiber. Middleware runs in the order deprecation,
+// tenant, permission, rate limit, cache, so a cached response is never served to a
+// caller who lacks the permission and cache hits still count toward the
+// limit. Deprecation comes first so calls refused for a missing permission
+// are announced and counted too.
+func (r *Route) mount() {
+	b := r.builder
+	info := Info{
+		Method:        r.method,
+		Path:          r.fullPath(),
+		Permission:    r.permission,
+		OwnerAccess:   r.owner != nil,
+		Authenticated: b.auth,
+		CacheTTL:      r.cacheTTL,
+	}
+
+	var handlers []fiber.Handler
+	if r.deprecated.IsZero() {
+		if !r.sunset.IsZero() || r.successor != "" {
+			panic(fmt.Sprintf("routes: %s %s: Sunset and Successor need Deprecated", info.Method, info.Path))
+		}
+	} else {
+		if !r.sunset.IsZero() && r.sunset.Before(r.deprecated) {
+			panic(fmt.Sprintf("routes: %s %s: Sunset is before Deprecated", info.Method, info.Path))
+		}
+		info.Deprecation = &DeprecationInfo{Since: r.deprecated, Sunset: r.sunset, Successor: r.successor}
+		method, path, onCall := info.Method, info.Path, b.cfg.OnDeprecatedCall
+		handlers = append(handlers, middleware.Deprecation(middleware.DeprecationConfig{
+			Since:  r.deprecated,
+			Sunset: r.sunset,
+			Link:   r.successor,
+			OnCall: func(c *fiber.Ctx) {
+				client := b.cfg.Registry.recordDeprecatedCall(method, path, middleware.ClientName(c), time.Now())
+				if onCall != nil {
+					onCall(method, path, client)
+				}
+			},
+		}))
+	}
+	if b.cfg.Tenant != nil {
+		handlers = append(handlers, middleware.Tenant(*b.cfg.Tenant))
+	}
+	if r.permission != "" {
+		obj, act, ok := strings.Cut(r.permission, ":")
+		if !ok || obj == "" || act == "" {
+			panic(fmt.Sprintf("routes: %s %s: permission %q must be \"object:action\"", info.Method, info.Path, r.permission))
+		}
+		if b.cfg.Authorizer == nil {
+			panic(fmt.Sprintf("routes: %s %s: Require needs Config.Authorizer", info.Method, info.Path))
+		}
+		if r.owner != nil {
+			handlers = append(handlers, middleware.RequireOwnershipOrPermission(b.cfg.Authorizer, obj, act, r.owner))
+		} else {
+			handlers = append(handlers, middleware.RequirePermission(b.cfg.Authorizer, obj, act))
+		}
+	} else if r.owner != nil {
+		panic(fmt.Sprintf("routes: %s %s: OwnedBy needs Require", info.Method, info.Path))
+	} else if b.auth && b.cfg.APIKeys != nil {
+		// No permission to match the key's scopes against
+		handlers = append(handlers, middleware.RequireAPIKeyScope("*"))
+	}
+	if r.rateMax > 0 {
+		routeKey := "route:" + info.Method + " " + info.Path + ":"
+		limit, closer := middleware.RateLimit(middleware.RateLimitConfig{
+			Max:    r.rateMax,
+			Window: r.rateWindow,
+			KeyFunc: func(c *fiber.Ctx) string {
+				if userID := middleware.GetUserID(c); userID != "" {
+					return routeKey + "user:" + userID
+				}
+				return routeKey + "ip:" + c.IP()
+			},
+			UseRedis: b.cfg.UseRedis,
+		}, b.cfg.Cache)
+		b.cfg.Registry.addCloser(closer)
+		handlers = append(handlers, limit)
+		info.RateLimit = &RateLimitInfo{Max: r.rateMax, Window: r.rateWindow}
+	}
+	if r.cacheTTL > 0 {
+		if r.method != fiber.MethodGet {
+			panic(fmt.Sprintf("routes: %s %s: Cache is only valid on GET routes", info.Method, info.Path))
+		}
+		handlers = append(handlers, middleware.ResponseCache(middleware.ResponseCacheConfig{
+			Cache:     b.cfg.Cache,
+			TTL:       r.cacheTTL,
+			KeyPrefix: info.Method + " " + info.Path + ":",
+		}))
+	}
+	handlers = append(handlers, r.handler)
+
+	b.router.Add(r.method, r.path, handlers...)
+	b.cfg.Registry.add(info)
+}
@@ -0,0 +1,296 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthorizer grants exactly the permissions in allowed, keyed by
+// "sub obj:act", or "sub@dom obj:act" in a domain; "owner@dom obj:act"
+// grants port.RoleOwner's. Only the Enforce methods are implemented.
+type fakeAuthorizer struct {
+	port.Authorizer
+	allowed map[string]bool
+}
+
+func (f *fakeAuthorizer) Enforce(sub, obj, act string) (bool, error) {
+	return f.allowed[sub+" "+obj+":"+act], nil
+}
+
+func (f *fakeAuthorizer) EnforceInDomain(sub, dom, obj, act string) (bool, error) {
+	return f.allowed[sub+"@"+dom+" "+obj+":"+act], nil
+}
+
+func (f *fakeAuthorizer) EnforceOwnership(sub, owner, dom, obj, act string) (bool, error) {
+	if sub == owner && f.allowed[port.RoleOwner+"@"+dom+" "+obj+":"+act] {
+		return true, nil
+	}
+	return f.EnforceInDomain(sub, dom, obj, act)
+}
+
+// fakeAuth stands in for middleware.Auth: it authenticates the caller named in
+// the X-User header and rejects requests without one.
+func fakeAuth(c *fiber.Ctx) error {
+	userID := c.Get("X-User")
+	if userID == "" {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	c.Locals("user_id", userID)
+	return c.Next()
+}
+
+func ok(c *fiber.Ctx) error {
+	return c.SendString("ok")
+}
+
+func do(t *testing.T, app *fiber.App, method, path, user string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestBuilder_RequirePermission(t *testing.T) {
+	authz := &fakeAuthorizer{allowed: map[string]bool{"alice widgets:read": true}}
+	app := fiber.New()
+	r := New(app, Config{Authorizer: authz})
+	widgets := r.Group("/widgets").Authenticated(fakeAuth)
+	widgets.Get("/", ok).Require("widgets:read")
+	widgets.Post("/", ok).Require("widgets:create")
+	widgets.Get("/mine", ok)
+	r.Mount()
+
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/widgets", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusForbidden, do(t, app, "POST", "/widgets", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusUnauthorized, do(t, app, "GET", "/widgets", "").StatusCode)
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/widgets/mine", "bob").StatusCode)
+}
+
+func TestBuilder_Tenant(t *testing.T) {
+	authz := &fakeAuthorizer{allowed: map[string]bool{"alice@acme projects:read": true}}
+	app := fiber.New()
+	r := New(app, Config{Authorizer: authz, Tenant: &middleware.TenantConfig{Param: "tenant", Header: "X-Tenant-ID"}})
+	r.Group("/orgs/:tenant/projects").Authenticated(fakeAuth).Get("/", ok).Require("projects:read")
+	r.Group("/projects").Authenticated(fakeAuth).Get("/", ok).Require("projects:read")
+	r.Mount()
+
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/orgs/acme/projects", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusForbidden, do(t, app, "GET", "/orgs/globex/projects", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusForbidden, do(t, app, "GET", "/projects", "alice").StatusCode, "no tenant is a global check")
+
+	req := httptest.NewRequest("GET", "/projects", nil)
+	req.Header.Set("X-User", "alice")
+	req.Header.Set("X-Tenant-ID", "acme")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "tenant from the header")
+}
+
+func TestBuilder_OwnedBy(t *testing.T) {
+	authz := &fakeAuthorizer{allowed: map[string]bool{"admin@ widgets:read": true, "owner@ widgets:read": true}}
+	app := fiber.New()
+	reg := NewRegistry()
+	r := New(app, Config{Authorizer: authz, Registry: reg})
+	r.Group("/widgets").Authenticated(fakeAuth).Get("/:owner", ok).Require("widgets:read").OwnedBy(middleware.OwnerFromParam("owner"))
+	r.Mount()
+
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/widgets/alice", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusForbidden, do(t, app, "GET", "/widgets/bob", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/widgets/bob", "admin").StatusCode)
+	assert.True(t, reg.Routes()[0].OwnerAccess)
+
+	assert.PanicsWithValue(t, "routes: GET /gadgets: OwnedBy needs Require", func() {
+		r := New(fiber.New(), Config{Authorizer: authz})
+		r.Get("/gadgets", ok).OwnedBy(middleware.OwnerFromParam("id"))
+		r.Mount()
+	})
+}
+
+// fakeAPIKeys accepts the key "k" for alice, scoped to widgets:read
+type fakeAPIKeys struct{}
+
+func (fakeAPIKeys) VerifyAPIKey(_ context.Context, key string) (*authdomain.APIKeyPrincipal, error) {
+	if key != "k" {
+		return nil, apperr.ErrUnauthorized
+	}
+	return &authdomain.APIKeyPrincipal{KeyID: "1", UserID: "alice", Scopes: []string{"widgets:read"}}, nil
+}
+
+func TestBuilder_APIKeys(t *testing.T) {
+	authz := &fakeAuthorizer{allowed: map[string]bool{"alice widgets:read": true, "alice widgets:create": true}}
+	app := fiber.New()
+	r := New(app, Config{Authorizer: authz, APIKeys: &middleware.APIKeyAuthConfig{Verifier: fakeAPIKeys{}}})
+	widgets := r.Group("/widgets").Authenticated(fakeAuth)
+	widgets.Get("/", ok).Require("widgets:read")
+	widgets.Post("/", ok).Require("widgets:create")
+	widgets.Get("/mine", ok)
+	r.Mount()
+
+	withKey := func(method, path, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(middleware.DefaultAPIKeyHeader, key)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, fiber.StatusOK, withKey("GET", "/widgets", "k"))
+	assert.Equal(t, fiber.StatusUnauthorized, withKey("GET", "/widgets", "wrong"))
+	assert.Equal(t, fiber.StatusForbidden, withKey("POST", "/widgets", "k"), "out of the key's scopes")
+	assert.Equal(t, fiber.StatusForbidden, withKey("GET", "/widgets/mine", "k"), "no permission to scope; needs a full-access key")
+
+	// Without a key the group's own auth still applies
+	assert.Equal(t, fiber.StatusOK, do(t, app, "POST", "/widgets", "alice").StatusCode)
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/widgets/mine", "bob").StatusCode)
+}
+
+func TestBuilder_RateLimit(t *testing.T) {
+	registry := NewRegistry()
+	t.Cleanup(func() { _ = registry.Close() })
+
+	app := fiber.New()
+	r := New(app, Config{Registry: registry})
+	r.Get("/limited", ok).RateLimit(2)
+	r.Get("/other", ok).RateLimit(2)
+	r.Mount()
+
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/limited", "").StatusCode)
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/limited", "").StatusCode)
+	assert.Equal(t, fiber.StatusTooManyRequests, do(t, app, "GET", "/limited", "").StatusCode)
+
+	// Each route has its own budget.
+	assert.Equal(t, fiber.StatusOK, do(t, app, "GET", "/other", "").StatusCode)
+}
+
+func TestBuilder_Cache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	calls := 0
+	app := fiber.New()
+	r := New(app, Config{Cache: c})
+	r.Get("/cached", func(c *fiber.Ctx) error {
+		calls++
+		return c.JSON(fiber.Map{"calls": calls})
+	}).Cache(time.Minute)
+	r.Mount()
+
+	first := do(t, app, "GET", "/cached", "")
+	assert.Equal(t, "MISS", first.Header.Get(middleware.ResponseCacheHeader))
+	second := do(t, app, "GET", "/cached", "")
+	assert.Equal(t, "HIT", second.Header.Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, fiber.MIMEApplicationJSON, second.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, 1, calls)
+}
+
+func TestBuilder_InvalidDeclarations(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		declare func(b *Builder)
+	}{
+		{
+			name:    "malformed permission",
+			cfg:     Config{Authorizer: &fakeAuthorizer{}},
+			declare: func(b *Builder) { b.Get("/x", ok).Require("widgets") },
+		},
+		{
+			name:    "missing authorizer",
+			declare: func(b *Builder) { b.Get("/x", ok).Require("widgets:read") },
+		},
+		{
+			name:    "cache on POST",
+			declare: func(b *Builder) { b.Post("/x", ok).Cache(time.Minute) },
+		},
+		{
+			name:    "sunset without deprecated",
+			declare: func(b *Builder) { b.Get("/x", ok).Sunset(time.Now()) },
+		},
+		{
+			name:    "successor without deprecated",
+			declare: func(b *Builder) { b.Get("/x", ok).Successor("/y") },
+		},
+		{
+			name:    "sunset before deprecated",
+			declare: func(b *Builder) { b.Get("/x", ok).Deprecated(time.Now()).Sunset(time.Now().Add(-time.Hour)) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(fiber.New(), tt.cfg)
+			tt.declare(r)
+			assert.Panics(t, r.Mount)
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	app := fiber.New()
+	r := New(app, Config{Authorizer: &fakeAuthorizer{}, Registry: registry})
+	widgets := r.Group("/widgets").Authenticated(fakeAuth)
+	widgets.Post("/", ok).Require("widgets:create")
+	widgets.Get("/", ok).Require("widgets:read").RateLimitPer(5, time.Second)
+	widgets.Get("/:id", ok).Require("widgets:read").Cache(time.Minute)
+	r.Get("/public", ok)
+	r.Mount()
+	t.Cleanup(func() { _ = registry.Close() })
+
+	got := registry.Routes()
+	require.Len(t, got, 4)
+	assert.Equal(t, Info{Method: "GET", Path: "/public"}, got[0])
+	assert.Equal(t, Info{
+		Method:        "GET",
+		Path:          "/widgets",
+		Permission:    "widgets:read",
+		Authenticated: true,
+		RateLimit:     &RateLimitInfo{Max: 5, Window: time.Second},
+	}, got[1])
+	assert.Equal(t, "POST", got[2].Method)
+	assert.Equal(t, "/widgets", got[2].Path)
+	assert.Equal(t, "/widgets/:id", got[3].Path)
+	assert.Equal(t, time.Minute, got[3].CacheTTL)
+
+	assert.Equal(t, []string{"widgets:create", "widgets:read"}, registry.Permissions())
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	var registry *Registry
+	assert.Nil(t, registry.Routes())
+	assert.Empty(t, registry.Permissions())
+	assert.NoError(t, registry.Close())
+}
+
+type errCloser struct{ err error }
+
+func (e errCloser) Close() error { return e.err }
+
+func TestRegistry_CloseJoinsErrors(t *testing.T) {
+	registry := NewRegistry()
+	boom := errors.New("boom")
+	registry.addCloser(errCloser{})
+	registry.addCloser(errCloser{err: boom})
+
+	assert.ErrorIs(t, registry.Close(), boom)
+	assert.NoError(t, registry.Close())
+}
@@ -32,9 +32,9 @@ type Server struct {
 //     logged and trusted-proxy checking is left disabled (socket addr is used).
 func NewServer(cfg config.ServerConfig, log *logger.Logger, isProduction bool) *Server {
 	fiberCfg := fiber.Config{
-		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
-		IdleTimeout:  time.Duration(cfg.IdleTimeout) * time.Second,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.IdleTimeout),
 		// Centralized error handler returns a generic message for unknown
 		// errors and preserves apperr-typed structured responses.
 		ErrorHandler: middleware.ErrorHandler(log),
@@ -0,0 +1,405 @@
+// Package iprules enforces IP allow and deny rules, globally or per route
+// group.
+//
+// A rule allows or denies a CIDR range, either for every route or for the
+// routes under a path prefix, e.g. "/admin only from the office VPN". A
+// request is refused when a deny rule for its path covers its IP, or when a
+// prefix that matches its path has allow rules and none of them covers its
+// IP. Each prefix's allowlist is checked on its own, so a global allowlist
+// does not open /admin to addresses its own allowlist leaves out.
+//
+// Rules come from the configuration, which is fixed, and from the admin API,
+// which adds and removes them at runtime. Runtime rules live in the shared
+// cache under CacheKey so one admin call reaches every instance. Each
+// instance keeps a compiled local copy, refreshed every Config.Refresh, so
+// checking a request never costs a cache round trip. Without a shared cache
+// (nil port.Cache) runtime rules are local to the process.
+package iprules
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/http/pathmatch"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// CacheKey is the cache key holding the runtime rules
+const CacheKey = "iprules:rules"
+
+// Action is what a rule does to the addresses it covers
+type Action string
+
+const (
+	Allow Action = "allow"
+	Deny  Action = "deny"
+)
+
+// Sources of rules
+const (
+	SourceConfig  = "config"
+	SourceRuntime = "runtime"
+)
+
+var (
+	// ErrNotFound is returned by Remove for an unknown rule ID
+	ErrNotFound = errors.New("ip rule not found")
+	// ErrFixed is returned by Remove for a rule from the configuration
+	ErrFixed = errors.New("ip rule comes from the configuration and cannot be removed at runtime")
+)
+
+// Rule allows or denies a range of addresses
+type Rule struct {
+	ID     string `json:"id"`
+	Action Action `json:"action"`
+	// CIDR is the covered range. A bare address covers just itself.
+	CIDR string `json:"cidr"`
+	// Prefix limits the rule to a path and everything below it, matched
+	// ignoring case as the router does. Empty applies to every route.
+	Prefix    string    `json:"prefix,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Source    string    `json:"source"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Decision is the outcome of checking a request
+type Decision struct {
+	Allowed bool
+	// Rule is the deny rule that refused the request, if any
+	Rule *Rule
+	// Prefix is the scope whose allowlist left the address out, when no
+	// deny rule applied. Empty means the global allowlist.
+	Prefix string
+}
+
+// Reason names why a request was refused: "denied" or "not_allowed"
+func (d Decision) Reason() string {
+	switch {
+	case d.Allowed:
+		return ""
+	case d.Rule != nil:
+		return "denied"
+	default:
+		return "not_allowed"
+	}
+}
+
+// ParseCIDR parses a CIDR range or a bare address
+func ParseCIDR(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Validate checks r and normalizes its CIDR and prefix
+func (r *Rule) Validate() error {
+	if r.Action != Allow && r.Action != Deny {
+		return fmt.Errorf("action is %q; must be %q or %q", r.Action, Allow, Deny)
+	}
+	p, err := ParseCIDR(r.CIDR)
+	if err != nil {
+		return err
+	}
+	r.CIDR = p.String()
+	if r.Prefix != "" && !strings.HasPrefix(r.Prefix, "/") {
+		return fmt.Errorf("prefix %q must start with \"/\"", r.Prefix)
+	}
+	r.Prefix = strings.TrimSuffix(r.Prefix, "/")
+	return nil
+}
+
+// compiled is a rule with its parsed range
+type compiled struct {
+	Rule
+	net netip.Prefix
+}
+
+func compile(rules []Rule) []compiled {
+	out := make([]compiled, 0, len(rules))
+	for _, r := range rules {
+		p, err := ParseCIDR(r.CIDR)
+		if err != nil {
+			continue // rules are validated before they are stored
+		}
+		out = append(out, compiled{Rule: r, net: p})
+	}
+	return out
+}
+
+// Evaluate checks ip against rules for a request to path
+func Evaluate(rules []Rule, ip, path string) Decision {
+	return evaluate(compile(rules), ip, path)
+}
+
+func evaluate(rules []compiled, ip, path string) Decision {
+	addr, err := netip.ParseAddr(ip)
+	if err == nil {
+		addr = addr.Unmap()
+	}
+	var scopes []string
+	admitted := make(map[string]bool)
+	for i := range rules {
+		r := &rules[i]
+		if !pathmatch.Under(path, r.Prefix) {
+			continue
+		}
+		in := err == nil && r.net.Contains(addr)
+		switch r.Action {
+		case Deny:
+			if in {
+				rule := r.Rule
+				return Decision{Rule: &rule}
+			}
+		case Allow:
+			// /Admin and /admin guard the same routes, so they share one
+			// allowlist.
+			key := pathmatch.Key(r.Prefix)
+			if _, seen := admitted[key]; !seen {
+				scopes = append(scopes, r.Prefix)
+			}
+			admitted[key] = admitted[key] || in
+		}
+	}
+	for _, p := range scopes {
+		if !admitted[pathmatch.Key(p)] {
+			return Decision{Prefix: p}
+		}
+	}
+	return Decision{Allowed: true}
+}
+
+// Config holds rule set settings
+type Config struct {
+	Refresh time.Duration // How often runtime rules are re-read (default: 5s)
+	Logger  *logger.Logger
+}
+
+// Set is the active rule set. A nil *Set allows everything.
+type Set struct {
+	cache  port.Cache
+	cfg    Config
+	fixed  []Rule
+	mu     sync.Mutex // serializes Add and Remove on this instance
+	active atomic.Pointer[state]
+
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type state struct {
+	runtime  []Rule
+	compiled []compiled
+}
+
+// New creates a rule set with the fixed rules, backed by cache, which may be
+// nil for process-local runtime rules. Call Start to load and refresh the
+// runtime rules.
+func New(cache port.Cache, fixed []Rule, cfg Config) (*Set, error) {
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = 5 * time.Second
+	}
+	for i := range fixed {
+		if err := fixed[i].Validate(); err != nil {
+			return nil, fmt.Errorf("ip rule %d: %w", i, err)
+		}
+		fixed[i].ID = fmt.Sprintf("config-%d", i)
+		fixed[i].Source = SourceConfig
+	}
+	s := &Set{
+		cache: cache,
+		cfg:   cfg,
+		fixed: fixed,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	s.apply(nil)
+	return s, nil
+}
+
+// Check decides whether ip may make a request to path
+func (s *Set) Check(ip, path string) Decision {
+	if s == nil {
+		return Decision{Allowed: true}
+	}
+	return evaluate(s.active.Load().compiled, ip, path)
+}
+
+// Rules returns the fixed rules followed by the runtime rules
+func (s *Set) Rules() []Rule {
+	if s == nil {
+		return nil
+	}
+	st := s.active.Load()
+	out := make([]Rule, 0, len(s.fixed)+len(st.runtime))
+	out = append(out, s.fixed...)
+	return append(out, st.runtime...)
+}
+
+// Add validates r and adds it as a runtime rule. With a shared cache the
+// rules are written there first, so a failed write changes nothing. Other
+// instances pick the rule up within one refresh interval.
+func (s *Set) Add(ctx context.Context, r Rule) (Rule, error) {
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Rule{}, fmt.Errorf("generate ip rule id: %w", err)
+	}
+	r.ID = hex.EncodeToString(id)
+	r.Source = SourceRuntime
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runtime, err := s.load(ctx)
+	if err != nil {
+		return Rule{}, err
+	}
+	if err := s.store(ctx, append(runtime, r)); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+// Remove deletes the runtime rule with id
+func (s *Set) Remove(ctx context.Context, id string) error {
+	for _, r := range s.fixed {
+		if r.ID == id {
+			return ErrFixed
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runtime, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	kept := make([]Rule, 0, len(runtime))
+	for _, r := range runtime {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(runtime) {
+		return ErrNotFound
+	}
+	return s.store(ctx, kept)
+}
+
+// load returns the current runtime rules, read from the shared cache when
+// there is one so a change made by another instance is not overwritten.
+// Concurrent changes on two instances within the same moment can still race;
+// the last write wins.
+func (s *Set) load(ctx context.Context) ([]Rule, error) {
+	if s.cache == nil {
+		return append([]Rule(nil), s.active.Load().runtime...), nil
+	}
+	var runtime []Rule
+	if err := s.cache.GetJSON(ctx, CacheKey, &runtime); err != nil && !errors.Is(err, port.ErrCacheMiss) {
+		return nil, fmt.Errorf("failed to read ip rules: %w", err)
+	}
+	return runtime, nil
+}
+
+func (s *Set) store(ctx context.Context, runtime []Rule) error {
+	if s.cache != nil {
+		if err := s.cache.SetJSON(ctx, CacheKey, runtime, 0); err != nil {
+			return fmt.Errorf("failed to store ip rules: %w", err)
+		}
+	}
+	s.apply(runtime)
+	return nil
+}
+
+// Refresh re-reads the runtime rules. On a cache error the local copy is
+// kept, so a cache outage neither drops nor invents rules.
+func (s *Set) Refresh(ctx context.Context) error {
+	if s.cache == nil {
+		return nil
+	}
+	var runtime []Rule
+	if err := s.cache.GetJSON(ctx, CacheKey, &runtime); err != nil {
+		if !errors.Is(err, port.ErrCacheMiss) {
+			return fmt.Errorf("failed to read ip rules: %w", err)
+		}
+		runtime = nil
+	}
+	s.apply(runtime)
+	return nil
+}
+
+func (s *Set) apply(runtime []Rule) {
+	all := make([]Rule, 0, len(s.fixed)+len(runtime))
+	all = append(all, s.fixed...)
+	all = append(all, runtime...)
+	s.active.Store(&state{runtime: runtime, compiled: compile(all)})
+}
+
+// Start loads the runtime rules and then refreshes them every Refresh until
+// Close is called.
+func (s *Set) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil && s.cfg.Logger != nil {
+		s.cfg.Logger.Warn("Failed to load IP rules", "error", err)
+	}
+	if s.cache == nil {
+		return
+	}
+
+	s.started.Store(true)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.Refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				rctx, cancel := context.WithTimeout(context.Background(), s.cfg.Refresh)
+				if err := s.Refresh(rctx); err != nil && s.cfg.Logger != nil {
+					s.cfg.Logger.Warn("Failed to refresh IP rules", "error", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Close stops refreshing. It is safe to call more than once, and before Start.
+func (s *Set) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.started.Load() {
+		<-s.done
+	}
+	return nil
+}
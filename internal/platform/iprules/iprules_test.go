@@ -0,0 +1,191 @@
+package iprules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, *cache.RedisCache) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	c, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return mr, c
+}
+
+func TestParseCIDR(t *testing.T) {
+	for in, want := range map[string]string{
+		"10.8.0.0/16":      "10.8.0.0/16",
+		"10.8.3.4/16":      "10.8.0.0/16",
+		"203.0.113.7":      "203.0.113.7/32",
+		"::ffff:192.0.2.1": "192.0.2.1/32",
+		"2001:db8::/32":    "2001:db8::/32",
+	} {
+		p, err := ParseCIDR(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, p.String(), in)
+	}
+	for _, in := range []string{"", "10.8.0.0/33", "not-an-ip", "10.8.0"} {
+		_, err := ParseCIDR(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	r := Rule{Action: Allow, CIDR: "10.8.3.4/16", Prefix: "/admin/"}
+	require.NoError(t, r.Validate())
+	assert.Equal(t, "10.8.0.0/16", r.CIDR)
+	assert.Equal(t, "/admin", r.Prefix)
+
+	r = Rule{Action: Allow, CIDR: "10.0.0.1", Prefix: "/"}
+	require.NoError(t, r.Validate())
+	assert.Equal(t, "", r.Prefix, "\"/\" is every route")
+
+	assert.Error(t, (&Rule{Action: "block", CIDR: "10.0.0.1"}).Validate())
+	assert.Error(t, (&Rule{Action: Deny, CIDR: "nope"}).Validate())
+	assert.Error(t, (&Rule{Action: Deny, CIDR: "10.0.0.1", Prefix: "admin"}).Validate())
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		{ID: "vpn", Action: Allow, CIDR: "10.8.0.0/16", Prefix: "/admin"},
+		{ID: "office", Action: Allow, CIDR: "192.0.2.0/24", Prefix: "/admin"},
+		{ID: "bad", Action: Deny, CIDR: "203.0.113.0/24"},
+		{ID: "bad-vpn-host", Action: Deny, CIDR: "10.8.9.9", Prefix: "/admin"},
+	}
+	tests := []struct {
+		name, ip, path string
+		reason         string
+		rule           string
+		scope          string
+	}{
+		{"public route, any address", "198.51.100.1", "/api/users", "", "", ""},
+		{"global deny", "203.0.113.7", "/api/users", "denied", "bad", ""},
+		{"global deny reaches admin too", "203.0.113.7", "/admin/config", "denied", "bad", ""},
+		{"admin from vpn", "10.8.1.2", "/admin/config", "", "", ""},
+		{"admin from office", "192.0.2.10", "/admin", "", "", ""},
+		{"admin from elsewhere", "198.51.100.1", "/admin/config", "not_allowed", "", "/admin"},
+		{"deny beats allow", "10.8.9.9", "/admin/config", "denied", "bad-vpn-host", ""},
+		{"prefix matches whole segments", "198.51.100.1", "/administrator", "", "", ""},
+		{"mixed-case path cannot dodge an allowlist", "198.51.100.1", "/Admin/config", "not_allowed", "", "/admin"},
+		{"upper-case path cannot dodge a deny", "10.8.9.9", "/ADMIN/config", "denied", "bad-vpn-host", ""},
+		{"ipv4-mapped address", "::ffff:10.8.1.2", "/admin/config", "", "", ""},
+		{"unparsable address fails an allowlist", "", "/admin/config", "not_allowed", "", "/admin"},
+		{"unparsable address passes without one", "", "/api/users", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(rules, tt.ip, tt.path)
+			assert.Equal(t, tt.reason, d.Reason())
+			assert.Equal(t, tt.reason == "", d.Allowed)
+			if tt.rule != "" {
+				require.NotNil(t, d.Rule)
+				assert.Equal(t, tt.rule, d.Rule.ID)
+			}
+			assert.Equal(t, tt.scope, d.Prefix)
+		})
+	}
+
+	t.Run("each scope's allowlist applies on its own", func(t *testing.T) {
+		rules := []Rule{
+			{Action: Allow, CIDR: "0.0.0.0/0"},
+			{Action: Allow, CIDR: "10.8.0.0/16", Prefix: "/admin"},
+		}
+		assert.True(t, Evaluate(rules, "198.51.100.1", "/api/users").Allowed)
+		assert.False(t, Evaluate(rules, "198.51.100.1", "/admin/config").Allowed)
+	})
+
+	t.Run("prefixes differing in case share an allowlist", func(t *testing.T) {
+		rules := []Rule{
+			{Action: Allow, CIDR: "10.8.0.0/16", Prefix: "/admin"},
+			{Action: Allow, CIDR: "192.0.2.0/24", Prefix: "/Admin"},
+		}
+		assert.True(t, Evaluate(rules, "192.0.2.10", "/admin/config").Allowed)
+		assert.True(t, Evaluate(rules, "10.8.1.2", "/ADMIN/config").Allowed)
+		assert.False(t, Evaluate(rules, "198.51.100.1", "/aDmIn").Allowed)
+	})
+}
+
+func TestNilSet(t *testing.T) {
+	var s *Set
+	assert.True(t, s.Check("203.0.113.7", "/admin").Allowed)
+	assert.Nil(t, s.Rules())
+	assert.NoError(t, s.Close())
+}
+
+func TestNew_RejectsInvalidFixedRules(t *testing.T) {
+	_, err := New(nil, []Rule{{Action: Allow, CIDR: "bogus"}}, Config{})
+	assert.ErrorContains(t, err, "ip rule 0")
+}
+
+func TestSet_LocalOnly(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(nil, []Rule{{Action: Allow, CIDR: "10.8.0.0/16", Prefix: "/admin"}}, Config{})
+	require.NoError(t, err)
+
+	assert.True(t, s.Check("203.0.113.7", "/api/users").Allowed)
+	added, err := s.Add(ctx, Rule{Action: Deny, CIDR: "203.0.113.7", Note: "scanner", CreatedBy: "u-1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, added.ID)
+	assert.Equal(t, SourceRuntime, added.Source)
+	assert.False(t, added.CreatedAt.IsZero())
+	assert.False(t, s.Check("203.0.113.7", "/api/users").Allowed)
+
+	rules := s.Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, "config-0", rules[0].ID)
+	assert.Equal(t, SourceConfig, rules[0].Source)
+	assert.Equal(t, added, rules[1])
+
+	// Refresh without a cache keeps the runtime rules.
+	require.NoError(t, s.Refresh(ctx))
+	assert.Len(t, s.Rules(), 2)
+
+	assert.ErrorIs(t, s.Remove(ctx, "config-0"), ErrFixed)
+	assert.ErrorIs(t, s.Remove(ctx, "missing"), ErrNotFound)
+	require.NoError(t, s.Remove(ctx, added.ID))
+	assert.True(t, s.Check("203.0.113.7", "/api/users").Allowed)
+
+	_, err = s.Add(ctx, Rule{Action: Deny, CIDR: "nope"})
+	assert.Error(t, err)
+}
+
+func TestSet_SharedAcrossInstances(t *testing.T) {
+	mr, c := newRedis(t)
+	ctx := context.Background()
+	a, err := New(c, nil, Config{})
+	require.NoError(t, err)
+	b, err := New(c, nil, Config{Refresh: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	added, err := a.Add(ctx, Rule{Action: Deny, CIDR: "203.0.113.0/24"})
+	require.NoError(t, err)
+	assert.True(t, b.Check("203.0.113.7", "/").Allowed, "b sees the rule only after a refresh")
+
+	b.Start(ctx)
+	defer b.Close()
+	assert.Eventually(t, func() bool { return !b.Check("203.0.113.7", "/").Allowed }, time.Second, 5*time.Millisecond)
+
+	// b removes the rule a added; a picks that up on refresh.
+	require.NoError(t, b.Remove(ctx, added.ID))
+	require.NoError(t, a.Refresh(ctx))
+	assert.True(t, a.Check("203.0.113.7", "/").Allowed)
+
+	// A cache outage keeps the local copy.
+	_, err = a.Add(ctx, Rule{Action: Deny, CIDR: "198.51.100.1"})
+	require.NoError(t, err)
+	mr.SetError("boom")
+	assert.Error(t, a.Refresh(ctx))
+	assert.False(t, a.Check("198.51.100.1", "/").Allowed)
+	_, err = a.Add(ctx, Rule{Action: Deny, CIDR: "198.51.100.2"})
+	assert.Error(t, err, "a failed write changes nothing")
+	assert.True(t, a.Check("198.51.100.2", "/").Allowed)
+	mr.SetError("")
+}
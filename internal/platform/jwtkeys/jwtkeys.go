@@ -0,0 +1,312 @@
+// Package jwtkeys holds the keys access tokens are signed and verified with.
+//
+// With HS256 a single shared secret does both, so every service that checks
+// tokens can also mint them. With RS256 or ES256 tokens are signed with a
+// private key and verified with its public key, which is published as a JSON
+// Web Key Set (RFC 7517) so other services can check tokens on their own.
+//
+// Every asymmetric key is identified by its RFC 7638 thumbprint, carried in
+// the token's "kid" header. The thumbprint depends only on the public key, so
+// it is the same on every instance and across restarts. Rotation works by
+// listing extra verification keys next to the signing key:
+//
+//  1. publish the next key as a verification key, so verifiers that cache the
+//     JWKS learn it before it is used;
+//  2. make it the signing key and keep the old one as a verification key
+//     until the last token it signed has expired;
+//  3. drop the old key.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// minRSABits is the smallest RSA key accepted for RS256
+const minRSABits = 2048
+
+// ErrUnknownKey is returned for a token whose kid names no known key
+var ErrUnknownKey = errors.New("jwtkeys: unknown signing key")
+
+// verifyKey is a key tokens are checked with
+type verifyKey struct {
+	method jwt.SigningMethod
+	key    any // []byte for HS256, otherwise the public key
+}
+
+// KeySet signs tokens with its signing key and verifies them with any of its
+// keys. It is immutable and safe for concurrent use.
+type KeySet struct {
+	method  jwt.SigningMethod
+	signKey any    // []byte for HS256, otherwise the private key
+	kid     string // empty for HS256
+	// keys holds the verification keys by kid; the HS256 secret is under "".
+	keys    map[string]verifyKey
+	methods []string
+	jwks    JWKS
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public half of an RSA or EC signing key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Load builds the key set cfg describes, reading the key files it names.
+func Load(cfg config.JWTConfig) (*KeySet, error) {
+	if cfg.Algorithm == "" || cfg.Algorithm == HS256 {
+		return NewHMAC(cfg.Secret), nil
+	}
+	private, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: read private key: %w", err)
+	}
+	verification := make([][]byte, 0, len(cfg.VerificationKeyFiles))
+	for _, name := range cfg.VerificationKeyFiles {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: read verification key: %w", err)
+		}
+		verification = append(verification, b)
+	}
+	return New(cfg.Algorithm, private, verification...)
+}
+
+// NewHMAC returns a key set signing and verifying HS256 tokens with secret.
+func NewHMAC(secret string) *KeySet {
+	key := []byte(secret)
+	return &KeySet{
+		method:  jwt.SigningMethodHS256,
+		signKey: key,
+		keys:    map[string]verifyKey{"": {method: jwt.SigningMethodHS256, key: key}},
+		methods: []string{HS256},
+		jwks:    JWKS{Keys: []JWK{}},
+	}
+}
+
+// New returns a key set signing alg (RS256 or ES256) tokens with the PEM
+// private key privatePEM. Tokens are verified with its public key and with
+// the PEM keys in verification, which may be public or private keys of
+// either algorithm.
+func New(alg string, privatePEM []byte, verification ...[]byte) (*KeySet, error) {
+	key, err := parseKey(privatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("jwtkeys: private key: a public key cannot sign")
+	}
+	method, err := methodFor(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: private key: %w", err)
+	}
+	if method.Alg() != alg {
+		return nil, fmt.Errorf("jwtkeys: private key is a %s key, not %s", method.Alg(), alg)
+	}
+
+	ks := &KeySet{method: method, signKey: signer, keys: make(map[string]verifyKey)}
+	if ks.kid, err = ks.add(signer.Public()); err != nil {
+		return nil, fmt.Errorf("jwtkeys: private key: %w", err)
+	}
+	for i, b := range verification {
+		key, err := parseKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: verification key %d: %w", i+1, err)
+		}
+		if s, ok := key.(crypto.Signer); ok {
+			key = s.Public()
+		}
+		if _, err := ks.add(key); err != nil {
+			return nil, fmt.Errorf("jwtkeys: verification key %d: %w", i+1, err)
+		}
+	}
+	return ks, nil
+}
+
+// add registers a public key for verification and returns its kid. A key
+// listed twice is added once.
+func (ks *KeySet) add(pub crypto.PublicKey) (string, error) {
+	method, err := methodFor(pub)
+	if err != nil {
+		return "", err
+	}
+	jwk, err := publicJWK(pub, method.Alg())
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ks.keys[jwk.Kid]; ok {
+		return jwk.Kid, nil
+	}
+	ks.keys[jwk.Kid] = verifyKey{method: method, key: pub}
+	ks.jwks.Keys = append(ks.jwks.Keys, jwk)
+	if !slices.Contains(ks.methods, method.Alg()) {
+		ks.methods = append(ks.methods, method.Alg())
+	}
+	return jwk.Kid, nil
+}
+
+// Algorithm returns the algorithm new tokens are signed with
+func (ks *KeySet) Algorithm() string { return ks.method.Alg() }
+
+// KeyID returns the kid of the signing key; empty for HS256
+func (ks *KeySet) KeyID() string { return ks.kid }
+
+// Asymmetric reports whether tokens are signed with a private key, i.e.
+// whether there are public keys to publish.
+func (ks *KeySet) Asymmetric() bool { return ks.kid != "" }
+
+// Methods returns the algorithms of the verification keys, for
+// jwt.WithValidMethods.
+func (ks *KeySet) Methods() []string { return ks.methods }
+
+// JWKS returns the public verification keys, signing key first. It is empty
+// for HS256: a shared secret is never published.
+func (ks *KeySet) JWKS() JWKS { return ks.jwks }
+
+// Sign returns the signed compact JWT for claims, with the signing key's kid
+// in its header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	if ks.kid != "" {
+		token.Header["kid"] = ks.kid
+	}
+	return token.SignedString(ks.signKey)
+}
+
+// Keyfunc is the jwt.Keyfunc verifying tokens against the set. It picks the
+// key named by the token's kid and rejects a token whose algorithm is not
+// that key's, so an RSA public key is never used as an HMAC secret.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if ks.kid == "" {
+		// HS256: tokens carry no kid.
+		kid = ""
+	}
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("jwtkeys: token signed with %s, key %q is %s", token.Method.Alg(), kid, k.method.Alg())
+	}
+	return k.key, nil
+}
+
+// parseKey decodes the first PEM block of b as a private or public key
+func parseKey(b []byte) (any, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// methodFor returns the signing method of a public key: RS256 for RSA keys
+// of at least minRSABits, ES256 for P-256 keys.
+func methodFor(pub crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key is %d bits; minimum is %d", k.N.BitLen(), minRSABits)
+		}
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("EC key is on %s; ES256 needs P-256", k.Curve.Params().Name)
+		}
+		return jwt.SigningMethodES256, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+}
+
+// publicJWK returns the JWK of pub, with its RFC 7638 thumbprint as kid.
+func publicJWK(pub crypto.PublicKey, alg string) (JWK, error) {
+	jwk := JWK{Use: "sig", Alg: alg}
+	var members any
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(k.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(k.E)).Bytes())
+		// The thumbprint hashes the required members in lexical order.
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case *ecdsa.PublicKey:
+		point, err := k.Bytes()
+		if err != nil {
+			return JWK{}, err
+		}
+		// Uncompressed point: 0x04 || X || Y, each coordinate 32 bytes.
+		jwk.Kty, jwk.Crv = "EC", "P-256"
+		jwk.X, jwk.Y = b64(point[1:33]), b64(point[33:])
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return JWK{}, err
+	}
+	sum := sha256.Sum256(b)
+	jwk.Kid = b64(sum[:])
+	return jwk, nil
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
@@ -0,0 +1,222 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+)
+
+func privatePEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicPEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newRSA(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return k
+}
+
+func newEC(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return k
+}
+
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "user-123",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+// verify parses token with ks the way the auth middleware does
+func verify(ks *KeySet, token string) error {
+	_, err := jwt.Parse(token, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()))
+	return err
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	tests := []struct {
+		alg string
+		key any
+	}{
+		{RS256, newRSA(t)},
+		{ES256, newEC(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			ks, err := New(tt.alg, privatePEM(t, tt.key))
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, ks.Algorithm())
+			assert.True(t, ks.Asymmetric())
+
+			token, err := ks.Sign(testClaims())
+			require.NoError(t, err)
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Method.Alg())
+			assert.Equal(t, ks.KeyID(), parsed.Header["kid"])
+
+			require.NoError(t, verify(ks, token))
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, newKey := newRSA(t), newEC(t)
+	before, err := New(RS256, privatePEM(t, oldKey))
+	require.NoError(t, err)
+	oldToken, err := before.Sign(testClaims())
+	require.NoError(t, err)
+
+	// The new key signs; the old one is kept, as a public key, for the
+	// tokens it signed. The algorithm may change with the key.
+	after, err := New(ES256, privatePEM(t, newKey), publicPEM(t, &oldKey.PublicKey))
+	require.NoError(t, err)
+	assert.NoError(t, verify(after, oldToken), "tokens signed with the previous key stay valid")
+	assert.ElementsMatch(t, []string{ES256, RS256}, after.Methods())
+
+	newToken, err := after.Sign(testClaims())
+	require.NoError(t, err)
+	assert.Error(t, verify(before, newToken), "the new key is unknown to the old set")
+
+	jwks := after.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, after.KeyID(), jwks.Keys[0].Kid, "signing key first")
+	assert.Equal(t, before.KeyID(), jwks.Keys[1].Kid, "the kid is stable across restarts and rotations")
+
+	// Dropping the old key ends its tokens
+	dropped, err := New(ES256, privatePEM(t, newKey))
+	require.NoError(t, err)
+	assert.Error(t, verify(dropped, oldToken))
+}
+
+func TestKeySet_UnknownKeyID(t *testing.T) {
+	ks, err := New(ES256, privatePEM(t, newEC(t)))
+	require.NoError(t, err)
+	other, err := New(ES256, privatePEM(t, newEC(t)))
+	require.NoError(t, err)
+
+	token, err := other.Sign(testClaims())
+	require.NoError(t, err)
+	assert.ErrorIs(t, verify(ks, token), ErrUnknownKey)
+}
+
+func TestKeySet_DuplicateVerificationKey(t *testing.T) {
+	key := newEC(t)
+	ks, err := New(ES256, privatePEM(t, key), publicPEM(t, &key.PublicKey), privatePEM(t, key))
+	require.NoError(t, err)
+	assert.Len(t, ks.JWKS().Keys, 1)
+}
+
+func TestKeySet_RejectsOtherAlgorithmForKey(t *testing.T) {
+	key := newEC(t)
+	ks, err := New(ES256, privatePEM(t, key))
+	require.NoError(t, err)
+
+	// An HS256 token claiming the EC key's kid, "signed" with its public key
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	token.Header["kid"] = ks.KeyID()
+	signed, err := token.SignedString(publicPEM(t, &key.PublicKey))
+	require.NoError(t, err)
+	assert.Error(t, verify(ks, signed))
+}
+
+func TestNew_Errors(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ec := newEC(t)
+
+	tests := []struct {
+		name         string
+		alg          string
+		private      []byte
+		verification [][]byte
+		want         string
+	}{
+		{"not PEM", ES256, []byte("not a key"), nil, "no PEM block"},
+		{"public key to sign", ES256, publicPEM(t, &ec.PublicKey), nil, "cannot sign"},
+		{"algorithm mismatch", RS256, privatePEM(t, ec), nil, "ES256 key, not RS256"},
+		{"short RSA key", RS256, privatePEM(t, small), nil, "minimum is 2048"},
+		{"wrong curve", ES256, privatePEM(t, p384), nil, "needs P-256"},
+		{"bad verification key", ES256, privatePEM(t, ec), [][]byte{publicPEM(t, &small.PublicKey)}, "verification key 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.alg, tt.private, tt.verification...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	current, previous := newEC(t), newRSA(t)
+	currentFile := filepath.Join(dir, "current.pem")
+	previousFile := filepath.Join(dir, "previous.pem")
+	require.NoError(t, os.WriteFile(currentFile, privatePEM(t, current), 0o600))
+	require.NoError(t, os.WriteFile(previousFile, publicPEM(t, &previous.PublicKey), 0o600))
+
+	ks, err := Load(config.JWTConfig{Algorithm: ES256, PrivateKeyFile: currentFile, VerificationKeyFiles: []string{previousFile}})
+	require.NoError(t, err)
+	assert.Equal(t, ES256, ks.Algorithm())
+	assert.Len(t, ks.JWKS().Keys, 2)
+
+	_, err = Load(config.JWTConfig{Algorithm: ES256, PrivateKeyFile: filepath.Join(dir, "missing.pem")})
+	assert.ErrorContains(t, err, "read private key")
+
+	hmac, err := Load(config.JWTConfig{Secret: "a-secret-of-at-least-thirty-two-bytes"})
+	require.NoError(t, err)
+	assert.Equal(t, HS256, hmac.Algorithm())
+	assert.False(t, hmac.Asymmetric())
+	assert.Empty(t, hmac.JWKS().Keys, "a shared secret is never published")
+
+	token, err := hmac.Sign(testClaims())
+	require.NoError(t, err)
+	assert.NoError(t, verify(hmac, token))
+}
+
+// TestPublicJWK_Thumbprint checks the kid against the example in RFC 7638,
+// section 3.1.
+func TestPublicJWK_Thumbprint(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+	jwk, err := publicJWK(pub, RS256)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwk.Kid)
+	assert.Equal(t, "AQAB", jwk.E)
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, "sig", jwk.Use)
+}
@@ -0,0 +1,277 @@
+// Package loadshed detects when the service is approaching saturation and
+// decides which requests to turn away.
+//
+// A Shedder samples three signals on a fixed interval: the average time a
+// request waited for a database connection, how late the sampling tick itself
+// fired (Go scheduler lag, the analogue of event-loop latency), and the live
+// heap size. Each signal is compared with its threshold. When the worst
+// signal reaches its threshold the shedder starts rejecting low-priority
+// requests; at twice the threshold it rejects normal-priority requests too.
+// Critical requests (health checks, login) are never shed.
+//
+// Levels rise as soon as a sample crosses a trip point but fall one step at a
+// time, and only once the signals are back below 80% of that level's trip
+// point, so the shedder does not flap around a threshold.
+//
+// middleware.LoadShed maps routes to priorities and consults the shedder.
+package loadshed
+
+import (
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Priority ranks a request for shedding. Lower priorities are shed first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityCritical
+)
+
+// String returns the priority name used in logs and metrics.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// Level is how much the shedder is currently turning away.
+type Level int
+
+const (
+	LevelNone   Level = iota // serve everything
+	LevelLow                 // shed low-priority requests
+	LevelNormal              // shed low- and normal-priority requests
+)
+
+// String returns the level name used in logs.
+func (l Level) String() string {
+	switch l {
+	case LevelLow:
+		return "shed_low"
+	case LevelNormal:
+		return "shed_normal"
+	default:
+		return "none"
+	}
+}
+
+// recoverFactor is the fraction of a level's trip point the signals must fall
+// below before the shedder steps down from that level.
+const recoverFactor = 0.8
+
+// Sample is one reading of the saturation signals.
+type Sample struct {
+	PoolWait  time.Duration // average connection-acquire wait since the previous sample
+	LoopLag   time.Duration // how late the sampling tick was delivered
+	HeapBytes uint64        // live heap objects
+}
+
+// Config holds the shedder settings. A zero threshold disables that signal.
+type Config struct {
+	Interval  time.Duration // Sampling interval (default: 1s)
+	PoolWait  time.Duration // Average acquire wait that counts as saturated
+	LoopLag   time.Duration // Scheduler lag that counts as saturated
+	HeapBytes uint64        // Heap size that counts as saturated
+
+	// PoolStats reports the pool's cumulative acquire count and total time
+	// spent waiting for a connection. Nil disables the pool signal. See
+	// PgxPoolStats.
+	PoolStats func() (acquires int64, waited time.Duration)
+
+	Logger *logger.Logger
+	// OnLevelChange is called whenever the level changes, e.g. to update a
+	// gauge. Optional.
+	OnLevelChange func(Level)
+}
+
+// Shedder tracks the current shedding level. A nil *Shedder never sheds.
+type Shedder struct {
+	cfg   Config
+	level atomic.Int32
+	heap  func() uint64
+
+	// Previous pool counters, touched only by the sampling goroutine.
+	lastAcquires int64
+	lastWaited   time.Duration
+
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a shedder. Call Start to begin sampling.
+func New(cfg Config) *Shedder {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	s := &Shedder{
+		cfg:  cfg,
+		heap: readHeapBytes,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if cfg.PoolStats != nil {
+		s.lastAcquires, s.lastWaited = cfg.PoolStats()
+	}
+	return s
+}
+
+// Level returns the current shedding level.
+func (s *Shedder) Level() Level {
+	if s == nil {
+		return LevelNone
+	}
+	return Level(s.level.Load())
+}
+
+// Sheds reports whether a request of priority p should be rejected now.
+func (s *Shedder) Sheds(p Priority) bool {
+	return int(p) < int(s.Level())
+}
+
+// Start samples the signals every Interval until Close is called.
+func (s *Shedder) Start() {
+	s.started.Store(true)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case tick := <-ticker.C:
+				s.Observe(s.sample(time.Since(tick)))
+			}
+		}
+	}()
+}
+
+// Close stops sampling. It is safe to call more than once, and before Start.
+func (s *Shedder) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.started.Load() {
+		<-s.done
+	}
+	return nil
+}
+
+// PgxPoolStats adapts a pgx pool for Config.PoolStats.
+func PgxPoolStats(pool *pgxpool.Pool) func() (int64, time.Duration) {
+	return func() (int64, time.Duration) {
+		st := pool.Stat()
+		return st.AcquireCount(), st.EmptyAcquireWaitTime()
+	}
+}
+
+// sample reads the current signals. lag is measured by the caller.
+func (s *Shedder) sample(lag time.Duration) Sample {
+	sm := Sample{LoopLag: lag}
+	if s.cfg.HeapBytes > 0 {
+		sm.HeapBytes = s.heap()
+	}
+	if s.cfg.PoolStats != nil {
+		acquires, waited := s.cfg.PoolStats()
+		if n := acquires - s.lastAcquires; n > 0 {
+			sm.PoolWait = (waited - s.lastWaited) / time.Duration(n)
+		}
+		s.lastAcquires, s.lastWaited = acquires, waited
+	}
+	return sm
+}
+
+// Observe folds one sample into the level and returns the new level.
+func (s *Shedder) Observe(sm Sample) Level {
+	pressure := s.pressure(sm)
+	current := s.Level()
+
+	next := current
+	switch target := levelFor(pressure); {
+	case target > current:
+		next = target
+	case target < current && pressure < recoverFactor*tripPoint(current):
+		next = current - 1
+	}
+	if next == current {
+		return current
+	}
+
+	s.level.Store(int32(next))
+	if s.cfg.OnLevelChange != nil {
+		s.cfg.OnLevelChange(next)
+	}
+	if s.cfg.Logger != nil {
+		args := []any{
+			"level", next.String(),
+			"previous", current.String(),
+			"pool_wait_ms", sm.PoolWait.Milliseconds(),
+			"loop_lag_ms", sm.LoopLag.Milliseconds(),
+			"heap_bytes", sm.HeapBytes,
+		}
+		if next > current {
+			s.cfg.Logger.Warn("Load shedding raised", args...)
+		} else {
+			s.cfg.Logger.Info("Load shedding lowered", args...)
+		}
+	}
+	return next
+}
+
+// pressure returns the worst signal as a fraction of its threshold.
+func (s *Shedder) pressure(sm Sample) float64 {
+	var p float64
+	if s.cfg.PoolWait > 0 {
+		p = max(p, float64(sm.PoolWait)/float64(s.cfg.PoolWait))
+	}
+	if s.cfg.LoopLag > 0 {
+		p = max(p, float64(sm.LoopLag)/float64(s.cfg.LoopLag))
+	}
+	if s.cfg.HeapBytes > 0 {
+		p = max(p, float64(sm.HeapBytes)/float64(s.cfg.HeapBytes))
+	}
+	return p
+}
+
+// levelFor maps a pressure to the level it trips.
+func levelFor(pressure float64) Level {
+	switch {
+	case pressure >= tripPoint(LevelNormal):
+		return LevelNormal
+	case pressure >= tripPoint(LevelLow):
+		return LevelLow
+	default:
+		return LevelNone
+	}
+}
+
+// tripPoint is the pressure at which level l is entered.
+func tripPoint(l Level) float64 {
+	return float64(l)
+}
+
+// heapMetric is the runtime/metrics name for bytes held by live heap objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// readHeapBytes reads the live heap size without stopping the world.
+func readHeapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
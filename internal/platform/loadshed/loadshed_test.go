@@ -0,0 +1,124 @@
+package loadshed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilShedder_NeverSheds(t *testing.T) {
+	var s *Shedder
+	assert.Equal(t, LevelNone, s.Level())
+	assert.False(t, s.Sheds(PriorityLow))
+}
+
+func TestShedder_Sheds(t *testing.T) {
+	s := New(Config{LoopLag: 100 * time.Millisecond})
+
+	s.level.Store(int32(LevelLow))
+	assert.True(t, s.Sheds(PriorityLow))
+	assert.False(t, s.Sheds(PriorityNormal))
+	assert.False(t, s.Sheds(PriorityCritical))
+
+	s.level.Store(int32(LevelNormal))
+	assert.True(t, s.Sheds(PriorityLow))
+	assert.True(t, s.Sheds(PriorityNormal))
+	assert.False(t, s.Sheds(PriorityCritical))
+}
+
+func TestShedder_Observe(t *testing.T) {
+	var changes []Level
+	s := New(Config{
+		LoopLag:       100 * time.Millisecond,
+		OnLevelChange: func(l Level) { changes = append(changes, l) },
+	})
+	lag := func(ms int) Sample { return Sample{LoopLag: time.Duration(ms) * time.Millisecond} }
+
+	steps := []struct {
+		sample Sample
+		want   Level
+	}{
+		{lag(50), LevelNone},
+		{lag(100), LevelLow},    // at threshold
+		{lag(250), LevelNormal}, // past twice the threshold
+		{lag(170), LevelNormal}, // below the trip point, above 80% of it
+		{lag(10), LevelLow},     // steps down one level per sample
+		{lag(10), LevelNone},
+	}
+	for i, step := range steps {
+		assert.Equal(t, step.want, s.Observe(step.sample), "step %d", i)
+	}
+	assert.Equal(t, []Level{LevelLow, LevelNormal, LevelLow, LevelNone}, changes)
+}
+
+func TestShedder_Observe_HysteresisAtLowLevel(t *testing.T) {
+	s := New(Config{LoopLag: 100 * time.Millisecond})
+	require.Equal(t, LevelLow, s.Observe(Sample{LoopLag: 120 * time.Millisecond}))
+
+	// 90% of the threshold is not low enough to recover.
+	assert.Equal(t, LevelLow, s.Observe(Sample{LoopLag: 90 * time.Millisecond}))
+	assert.Equal(t, LevelNone, s.Observe(Sample{LoopLag: 70 * time.Millisecond}))
+}
+
+func TestShedder_Observe_WorstSignalWins(t *testing.T) {
+	s := New(Config{
+		PoolWait:  50 * time.Millisecond,
+		LoopLag:   100 * time.Millisecond,
+		HeapBytes: 1 << 30,
+	})
+
+	level := s.Observe(Sample{
+		PoolWait:  10 * time.Millisecond,
+		LoopLag:   5 * time.Millisecond,
+		HeapBytes: 3 << 30,
+	})
+	assert.Equal(t, LevelNormal, level)
+}
+
+func TestShedder_Observe_DisabledSignalIgnored(t *testing.T) {
+	s := New(Config{PoolWait: 50 * time.Millisecond})
+	assert.Equal(t, LevelNone, s.Observe(Sample{LoopLag: time.Hour, HeapBytes: 1 << 40}))
+}
+
+func TestShedder_SamplePoolWaitIsPerAcquireDelta(t *testing.T) {
+	acquires, waited := int64(100), 10*time.Second
+	s := New(Config{
+		PoolWait: 50 * time.Millisecond,
+		PoolStats: func() (int64, time.Duration) {
+			return acquires, waited
+		},
+	})
+
+	// 20 acquires waited 2s in total since New.
+	acquires, waited = 120, 12*time.Second
+	assert.Equal(t, 100*time.Millisecond, s.sample(0).PoolWait)
+
+	// No acquires since the last sample: no wait.
+	assert.Zero(t, s.sample(0).PoolWait)
+}
+
+func TestShedder_SampleHeap(t *testing.T) {
+	s := New(Config{HeapBytes: 1 << 20})
+	s.heap = func() uint64 { return 42 }
+	assert.Equal(t, uint64(42), s.sample(0).HeapBytes)
+
+	s = New(Config{LoopLag: time.Second})
+	s.heap = func() uint64 { return 42 }
+	assert.Zero(t, s.sample(0).HeapBytes, "heap not read when its threshold is off")
+}
+
+func TestReadHeapBytes(t *testing.T) {
+	assert.Positive(t, readHeapBytes())
+}
+
+func TestShedder_StartClose(t *testing.T) {
+	s := New(Config{Interval: time.Millisecond, LoopLag: time.Hour})
+	s.Start()
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+
+	require.NoError(t, New(Config{}).Close(), "close before start")
+}
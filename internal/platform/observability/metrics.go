@@ -2,6 +2,7 @@ package observability
 
 import (
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -74,6 +75,192 @@ var (
 		[]string{"operation", "table"},
 	)
 
+	// Per-request database metrics, keyed by route pattern
+	httpRequestDBQueries = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_db_queries",
+			Help:    "Database statements run per HTTP request",
+			Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100},
+		},
+		[]string{"method", "path"},
+	)
+
+	httpRequestDBDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_db_duration_seconds",
+			Help:    "Cumulative database time per HTTP request in seconds",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"method", "path"},
+	)
+
+	dbTxRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_tx_retries_total",
+			Help: "Transactions re-run after a serialization failure or deadlock, by reason",
+		},
+		[]string{"reason"},
+	)
+
+	httpIPBlocked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_ip_blocked_total",
+			Help: "Requests refused by IP rules, by reason (denied, not_allowed)",
+		},
+		[]string{"reason"},
+	)
+
+	httpCountryBlocked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_country_blocked_total",
+			Help: "Requests refused by country restrictions, by country",
+		},
+		[]string{"country"},
+	)
+
+	httpDeprecatedRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_deprecated_requests_total",
+			Help: "Requests to deprecated routes, by method, route and client",
+		},
+		[]string{"method", "route", "client"},
+	)
+
+	honeytokenTripped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "honeytoken_tripped_total",
+			Help: "Uses of canary credentials, by kind",
+		},
+		[]string{"kind"},
+	)
+
+	auditExportDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "audit_export_dropped_total",
+			Help: "Audit events not exported to the SIEM, by reason",
+		},
+		[]string{"reason"},
+	)
+
+	auditWriteDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "audit_write_dropped_total",
+			Help: "Audit entries lost because their batch could not be written, by reason",
+		},
+		[]string{"reason"},
+	)
+
+	casbinPolicyReloads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "casbin_policy_reloads_total",
+			Help: "Casbin policy reloads, by trigger and result",
+		},
+		[]string{"trigger", "result"},
+	)
+
+	casbinPolicyReloadDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "casbin_policy_reload_duration_seconds",
+			Help:    "Casbin policy reload duration in seconds",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"trigger"},
+	)
+
+	casbinEnforceTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "casbin_enforce_total",
+			Help: "Casbin authorization decisions, by object, action and result",
+		},
+		[]string{"object", "action", "result"},
+	)
+
+	casbinEnforceDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "casbin_enforce_duration_seconds",
+			Help:    "Casbin authorization decision latency in seconds, by whether the decision cache served it",
+			Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1},
+		},
+		[]string{"cached"},
+	)
+
+	dbTxRetriesExhausted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_tx_retries_exhausted_total",
+			Help: "Transactions that still failed with a serialization failure or deadlock after their last attempt, by reason",
+		},
+		[]string{"reason"},
+	)
+
+	httpBulkheadRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_bulkhead_rejections_total",
+			Help: "Requests rejected by a bulkhead, by bulkhead and reason",
+		},
+		[]string{"bulkhead", "reason"},
+	)
+
+	httpLoadShed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_load_shed_total",
+			Help: "Requests rejected by load shedding, by route priority",
+		},
+		[]string{"priority"},
+	)
+
+	httpRequestsCancelled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_cancelled_total",
+			Help: "Requests cancelled because the client disconnected before the response",
+		},
+		[]string{"method", "path"},
+	)
+
+	readOnlyEnabled = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "read_only_enabled",
+			Help: "1 while read-only mode is on, 0 otherwise",
+		},
+	)
+
+	loadShedLevel = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "load_shed_level",
+			Help: "Current load shedding level: 0 none, 1 shedding low priority, 2 shedding low and normal priority",
+		},
+	)
+
+	goroutineGrowth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "watchdog_goroutine_growth",
+			Help: "Goroutine growth sustained over the watchdog window",
+		},
+	)
+
+	channelBufferUtilization = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "channel_buffer_utilization",
+			Help: "Fraction of a watched channel buffer's capacity in use",
+		},
+		[]string{"buffer"},
+	)
+
+	channelBufferSaturated = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "channel_buffer_saturated",
+			Help: "Channels of a watched buffer filled to the saturation threshold",
+		},
+		[]string{"buffer"},
+	)
+
+	watchdogAlertsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "watchdog_alerts_total",
+			Help: "Leak conditions raised by the watchdog",
+		},
+		[]string{"signal"},
+	)
+
 	// Cache metrics
 	cacheHitsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -140,6 +327,145 @@ func RecordDBQuery(operation, table string, duration time.Duration) {
 	dbQueryDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
 }
 
+// RecordRequestDBStats records the database statements one HTTP request ran
+// and their cumulative duration, labelled by route pattern.
+func RecordRequestDBStats(method, path string, queries int64, duration time.Duration) {
+	httpRequestDBQueries.WithLabelValues(method, path).Observe(float64(queries))
+	httpRequestDBDuration.WithLabelValues(method, path).Observe(duration.Seconds())
+}
+
+// RecordTxRetry records a transaction re-run after a conflict.
+func RecordTxRetry(reason string) {
+	dbTxRetries.WithLabelValues(reason).Inc()
+}
+
+// RecordTxRetryExhausted records a transaction that ran out of attempts.
+func RecordTxRetryExhausted(reason string) {
+	dbTxRetriesExhausted.WithLabelValues(reason).Inc()
+}
+
+// RecordIPBlocked records a request refused by IP rules.
+func RecordIPBlocked(reason string) {
+	httpIPBlocked.WithLabelValues(reason).Inc()
+}
+
+// RecordCountryBlocked records a request refused by a country restriction.
+// Countries are bounded by the configured rules plus "unknown".
+func RecordCountryBlocked(country string) {
+	httpCountryBlocked.WithLabelValues(country).Inc()
+}
+
+// RecordDeprecatedRequest records a request to a deprecated route. Client
+// names are capped by the route registry, so the labels stay bounded.
+func RecordDeprecatedRequest(method, route, client string) {
+	httpDeprecatedRequests.WithLabelValues(method, route, client).Inc()
+}
+
+// RecordHoneytokenTripped records a use of a canary credential.
+func RecordHoneytokenTripped(kind string) {
+	honeytokenTripped.WithLabelValues(kind).Inc()
+}
+
+// RecordAuditExportDropped records an audit event the SIEM export dropped.
+func RecordAuditExportDropped(reason string) {
+	auditExportDropped.WithLabelValues(reason).Inc()
+}
+
+// RecordAuditWriteDropped records an audit entry a batched write lost.
+func RecordAuditWriteDropped(reason string) {
+	auditWriteDropped.WithLabelValues(reason).Inc()
+}
+
+// RecordPolicyReload records a full Casbin policy reload. trigger is
+// "watcher", "backstop" or "api".
+func RecordPolicyReload(trigger string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	casbinPolicyReloads.WithLabelValues(trigger, result).Inc()
+	casbinPolicyReloadDuration.WithLabelValues(trigger).Observe(duration.Seconds())
+}
+
+// maxEnforceLabels caps the distinct object/action pairs casbin_enforce_total
+// tracks. Routes check a fixed set, but the permission check endpoint takes
+// any pair; decisions on further pairs are counted under "other".
+const maxEnforceLabels = 500
+
+var (
+	enforceLabelsMu sync.Mutex
+	enforceLabels   = make(map[[2]string]bool)
+)
+
+// RecordEnforce records a Casbin authorization decision. result is "allow",
+// "deny" or "error".
+func RecordEnforce(obj, act string, allowed, cached bool, duration time.Duration, err error) {
+	result := "deny"
+	switch {
+	case err != nil:
+		result = "error"
+	case allowed:
+		result = "allow"
+	}
+	enforceLabelsMu.Lock()
+	if pair := [2]string{obj, act}; !enforceLabels[pair] {
+		if len(enforceLabels) < maxEnforceLabels {
+			enforceLabels[pair] = true
+		} else {
+			obj, act = "other", "other"
+		}
+	}
+	enforceLabelsMu.Unlock()
+	casbinEnforceTotal.WithLabelValues(obj, act, result).Inc()
+	casbinEnforceDuration.WithLabelValues(strconv.FormatBool(cached)).Observe(duration.Seconds())
+}
+
+// RecordBulkheadRejection records a request rejected by a bulkhead.
+func RecordBulkheadRejection(bulkhead, reason string) {
+	httpBulkheadRejections.WithLabelValues(bulkhead, reason).Inc()
+}
+
+// RecordLoadShed records a request rejected by load shedding.
+func RecordLoadShed(priority string) {
+	httpLoadShed.WithLabelValues(priority).Inc()
+}
+
+// RecordRequestCancelled records a request abandoned by its client.
+func RecordRequestCancelled(method, path string) {
+	httpRequestsCancelled.WithLabelValues(method, path).Inc()
+}
+
+// SetLoadShedLevel records the current load shedding level.
+func SetLoadShedLevel(level int) {
+	loadShedLevel.Set(float64(level))
+}
+
+// SetGoroutineGrowth records the goroutine growth sustained over the
+// watchdog window.
+func SetGoroutineGrowth(n int) {
+	goroutineGrowth.Set(float64(n))
+}
+
+// SetChannelBuffer records the fill level of a watched channel buffer.
+func SetChannelBuffer(buffer string, utilization float64, saturated int) {
+	channelBufferUtilization.WithLabelValues(buffer).Set(utilization)
+	channelBufferSaturated.WithLabelValues(buffer).Set(float64(saturated))
+}
+
+// RecordWatchdogAlert records a leak condition raised by the watchdog.
+func RecordWatchdogAlert(signal string) {
+	watchdogAlertsTotal.WithLabelValues(signal).Inc()
+}
+
+// SetReadOnly records whether read-only mode is on.
+func SetReadOnly(enabled bool) {
+	if enabled {
+		readOnlyEnabled.Set(1)
+	} else {
+		readOnlyEnabled.Set(0)
+	}
+}
+
 // RecordCacheHit records a cache hit
 func RecordCacheHit(cache string) {
 	cacheHitsTotal.WithLabelValues(cache).Inc()
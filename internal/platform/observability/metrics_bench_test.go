@@ -0,0 +1,48 @@
+package observability
+
+import (
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/perf"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// metricsBudgets caps allocations per op added by PrometheusMiddleware on
+// top of fiber routing. It wraps every request on the public listener.
+var metricsBudgets = perf.Budgets{
+	"metrics.middleware": 3,
+}
+
+func newMetricsBenchHandler() (fasthttp.RequestHandler, *fasthttp.RequestCtx) {
+	app := fiber.New()
+	app.Use(PrometheusMiddleware())
+	app.Get("/api/users/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.Header.SetMethod(fiber.MethodGet)
+	fctx.Request.SetRequestURI("/api/users/0190f5c8")
+	return app.Handler(), fctx
+}
+
+// BenchmarkPrometheusMiddleware measures a routed request through the
+// metrics middleware (counter, three histograms, gauge inc/dec).
+func BenchmarkPrometheusMiddleware(b *testing.B) {
+	handler, fctx := newMetricsBenchHandler()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		handler(fctx)
+		fctx.Response.Reset()
+	}
+}
+
+func TestAllocBudget_Metrics(t *testing.T) {
+	handler, fctx := newMetricsBenchHandler()
+	metricsBudgets.Check(t, "metrics.middleware", func() {
+		handler(fctx)
+		fctx.Response.Reset()
+	})
+}
@@ -0,0 +1,135 @@
+package observability
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ResourceInfo is the data resource attribute templates are rendered with,
+// e.g. "{{.Environment}}-{{env \"REGION\"}}".
+type ResourceInfo struct {
+	Service     string // app.name
+	Environment string // app.env
+	Version     string
+	Process     string // "api" or "worker"
+	Hostname    string
+}
+
+// ResourceAttributes are the rendered observability.resource_attributes,
+// attached to every trace, metric and log line of the process.
+type ResourceAttributes map[string]string
+
+// RenderResourceAttributes renders each value of templates as a text/template
+// against info. Templates may also call env to read an environment variable
+// ("" when unset). A template that fails to parse or execute is an error
+// naming its key.
+func RenderResourceAttributes(templates map[string]string, info ResourceInfo) (ResourceAttributes, error) {
+	if info.Hostname == "" {
+		info.Hostname, _ = os.Hostname()
+	}
+	funcs := template.FuncMap{"env": os.Getenv}
+
+	attrs := make(ResourceAttributes, len(templates))
+	for _, key := range sortedKeys(templates) {
+		tmpl, err := template.New(key).Funcs(funcs).Parse(templates[key])
+		if err != nil {
+			return nil, fmt.Errorf("resource attribute %q: %w", key, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, info); err != nil {
+			return nil, fmt.Errorf("resource attribute %q: %w", key, err)
+		}
+		attrs[key] = buf.String()
+	}
+	return attrs, nil
+}
+
+// KeyValues returns the attributes as OpenTelemetry resource attributes,
+// sorted by key.
+func (a ResourceAttributes) KeyValues() []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(a))
+	for _, key := range sortedKeys(a) {
+		kvs = append(kvs, attribute.String(key, a[key]))
+	}
+	return kvs
+}
+
+// LogArgs returns the attributes as slog key-value pairs, sorted by key.
+func (a ResourceAttributes) LogArgs() []any {
+	args := make([]any, 0, len(a)*2)
+	for _, key := range sortedKeys(a) {
+		args = append(args, key, a[key])
+	}
+	return args
+}
+
+// Gatherer wraps g so every gathered series carries the attributes as
+// labels. Keys are turned into valid label names ("deployment.region"
+// becomes "deployment_region"); a label the series already has is kept.
+func (a ResourceAttributes) Gatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if len(a) == 0 {
+		return g
+	}
+	labels := make([]*dto.LabelPair, 0, len(a))
+	for _, key := range sortedKeys(a) {
+		name, value := labelName(key), a[key]
+		labels = append(labels, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		for _, mf := range mfs {
+			for _, m := range mf.Metric {
+				m.Label = mergeLabels(m.Label, labels)
+			}
+		}
+		return mfs, err
+	})
+}
+
+// mergeLabels adds extra to own, skipping names own already has, and keeps
+// the result sorted by name as the exposition format expects.
+func mergeLabels(own, extra []*dto.LabelPair) []*dto.LabelPair {
+	have := make(map[string]bool, len(own))
+	for _, l := range own {
+		have[l.GetName()] = true
+	}
+	merged := own
+	for _, l := range extra {
+		if !have[l.GetName()] {
+			merged = append(merged, l)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].GetName() < merged[j].GetName() })
+	return merged
+}
+
+// labelName maps an attribute key onto the Prometheus label name charset.
+func labelName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, key)
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
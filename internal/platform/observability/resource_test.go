@@ -0,0 +1,72 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestRenderResourceAttributes(t *testing.T) {
+	t.Setenv("REGION", "eu-west-1")
+	info := ResourceInfo{Service: "goscratch", Environment: "staging", Version: "1.0.0", Process: "worker", Hostname: "host-1"}
+
+	attrs, err := RenderResourceAttributes(map[string]string{
+		"team":              "payments",
+		"deployment.region": `{{env "REGION"}}`,
+		"deployment.name":   "{{.Service}}-{{.Environment}}",
+		"instance":          "{{.Hostname}}/{{.Process}}",
+		"missing":           `{{env "UNSET_RESOURCE_VAR"}}`,
+	}, info)
+	require.NoError(t, err)
+	assert.Equal(t, ResourceAttributes{
+		"team":              "payments",
+		"deployment.region": "eu-west-1",
+		"deployment.name":   "goscratch-staging",
+		"instance":          "host-1/worker",
+		"missing":           "",
+	}, attrs)
+
+	t.Run("parse error names the key", func(t *testing.T) {
+		_, err := RenderResourceAttributes(map[string]string{"team": "{{.Service"}, info)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"team"`)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := RenderResourceAttributes(map[string]string{"team": "{{.Team}}"}, info)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"team"`)
+	})
+}
+
+func TestResourceAttributes_KeyValuesAndLogArgs(t *testing.T) {
+	attrs := ResourceAttributes{"team": "payments", "region": "eu"}
+
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("region", "eu"),
+		attribute.String("team", "payments"),
+	}, attrs.KeyValues())
+	assert.Equal(t, []any{"region", "eu", "team", "payments"}, attrs.LogArgs())
+}
+
+func TestResourceAttributes_Gatherer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test counter."}, []string{"path"})
+	reg.MustRegister(requests)
+	requests.WithLabelValues("/users").Inc()
+
+	attrs := ResourceAttributes{"deployment.region": "eu", "path": "ignored", "9lives": "cat"}
+	err := testutil.GatherAndCompare(attrs.Gatherer(reg), strings.NewReader(`
+# HELP test_requests_total Test counter.
+# TYPE test_requests_total counter
+test_requests_total{_9lives="cat",deployment_region="eu",path="/users"} 1
+`), "test_requests_total")
+	require.NoError(t, err)
+
+	assert.Same(t, prometheus.Gatherer(reg), ResourceAttributes{}.Gatherer(reg))
+}
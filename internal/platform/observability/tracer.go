@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -24,6 +25,7 @@ type TracerConfig struct {
 	Environment    string
 	Endpoint       string // OTLP endpoint (e.g., "localhost:4318")
 	Enabled        bool
+	Attributes     ResourceAttributes // extra resource attributes; may override the ones above
 }
 
 // InitTracer initializes the OpenTelemetry tracer
@@ -44,14 +46,14 @@ func InitTracer(ctx context.Context, cfg TracerConfig) (func(context.Context) er
 	}
 
 	// Create resource with service info
+	attrs := append([]attribute.KeyValue{
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		attribute.String("environment", cfg.Environment),
+	}, cfg.Attributes.KeyValues()...)
 	res, err := resource.Merge(
 		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(cfg.ServiceName),
-			semconv.ServiceVersion(cfg.ServiceVersion),
-			attribute.String("environment", cfg.Environment),
-		),
+		resource.NewWithAttributes(semconv.SchemaURL, attrs...),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
@@ -106,13 +108,14 @@ func TracingMiddleware(serviceName string) fiber.Handler {
 		)
 		defer span.End()
 
-		// Store span context in Fiber context
-		c.SetUserContext(ctx)
-
 		// Add trace ID to response header for debugging
 		traceID := span.SpanContext().TraceID().String()
 		c.Set("X-Trace-ID", traceID)
 
+		// Store span context in Fiber context, with the trace ID for log
+		// lines and audit entries
+		c.SetUserContext(context.WithValue(ctx, logger.TraceIDKey, traceID))
+
 		// Process request
 		err := c.Next()
 
@@ -0,0 +1,193 @@
+// Package readonly implements the incident-response read-only switch.
+//
+// While the switch is on, the HTTP layer rejects mutating requests
+// (middleware.ReadOnly) and the worker defers jobs whose handlers write. Reads
+// keep working, which is what you want during a database failover or a
+// migration that must not race with writes.
+//
+// The state lives in the shared cache under CacheKey so one admin call flips
+// every API and worker instance. Each instance keeps a local copy, refreshed
+// every Config.Refresh, so checking the switch never costs a cache round trip.
+// Without a shared cache (nil port.Cache) the switch is local to the process.
+package readonly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// CacheKey is the cache key holding the shared State.
+const CacheKey = "readonly:state"
+
+// State is the read-only switch state
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"` // shown to clients in the READ_ONLY error
+	Since   time.Time `json:"since"`
+	By      string    `json:"by,omitempty"` // user ID of the admin who flipped it
+}
+
+// equal compares states, using time.Time.Equal for Since.
+func (st State) equal(o State) bool {
+	return st.Enabled == o.Enabled && st.Reason == o.Reason && st.By == o.By && st.Since.Equal(o.Since)
+}
+
+// Config holds switch settings
+type Config struct {
+	Refresh time.Duration // How often the shared state is re-read (default: 2s)
+	Logger  *logger.Logger
+	// OnChange is called when the local copy changes, e.g. to update a gauge.
+	// Optional.
+	OnChange func(State)
+}
+
+// Switch is the read-only switch. A nil *Switch is never enabled.
+type Switch struct {
+	cache port.Cache
+	cfg   Config
+	state atomic.Pointer[State]
+
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a switch backed by cache, which may be nil for a process-local
+// switch. Call Start to begin refreshing the shared state.
+func New(cache port.Cache, cfg Config) *Switch {
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = 2 * time.Second
+	}
+	s := &Switch{
+		cache: cache,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	s.state.Store(&State{})
+	return s
+}
+
+// Enabled reports whether mutations are currently refused.
+func (s *Switch) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	return s.state.Load().Enabled
+}
+
+// State returns the current state.
+func (s *Switch) State() State {
+	if s == nil {
+		return State{}
+	}
+	return *s.state.Load()
+}
+
+// Set changes the switch. With a shared cache the state is written there
+// first, so a failed write leaves every instance, this one included, as it
+// was. Other instances pick the change up within one refresh interval.
+func (s *Switch) Set(ctx context.Context, st State) error {
+	if !st.Enabled {
+		st = State{}
+	} else if st.Since.IsZero() {
+		st.Since = time.Now().UTC()
+	}
+
+	if s.cache != nil {
+		var err error
+		if st.Enabled {
+			err = s.cache.SetJSON(ctx, CacheKey, st, 0)
+		} else {
+			err = s.cache.Delete(ctx, CacheKey)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store read-only state: %w", err)
+		}
+	}
+
+	s.apply(st)
+	return nil
+}
+
+// Refresh re-reads the shared state. On a cache error the local copy is kept,
+// so a cache outage neither turns read-only mode on nor silently off.
+func (s *Switch) Refresh(ctx context.Context) error {
+	if s.cache == nil {
+		return nil
+	}
+	var st State
+	if err := s.cache.GetJSON(ctx, CacheKey, &st); err != nil {
+		if !errors.Is(err, port.ErrCacheMiss) {
+			return fmt.Errorf("failed to read read-only state: %w", err)
+		}
+		st = State{}
+	}
+	s.apply(st)
+	return nil
+}
+
+// apply swaps in st and reports a change.
+func (s *Switch) apply(st State) {
+	prev := s.state.Swap(&st)
+	if prev.equal(st) {
+		return
+	}
+	if s.cfg.OnChange != nil {
+		s.cfg.OnChange(st)
+	}
+	if s.cfg.Logger != nil {
+		if st.Enabled {
+			s.cfg.Logger.Warn("Read-only mode enabled", "reason", st.Reason, "by", st.By, "since", st.Since)
+		} else {
+			s.cfg.Logger.Info("Read-only mode disabled")
+		}
+	}
+}
+
+// Start loads the shared state and then refreshes it every Refresh until
+// Close is called.
+func (s *Switch) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil && s.cfg.Logger != nil {
+		s.cfg.Logger.Warn("Failed to load read-only state", "error", err)
+	}
+	if s.cache == nil {
+		return
+	}
+
+	s.started.Store(true)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.Refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				rctx, cancel := context.WithTimeout(context.Background(), s.cfg.Refresh)
+				if err := s.Refresh(rctx); err != nil && s.cfg.Logger != nil {
+					s.cfg.Logger.Warn("Failed to refresh read-only state", "error", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Close stops refreshing. It is safe to call more than once, and before Start.
+func (s *Switch) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.started.Load() {
+		<-s.done
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package readonly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, *cache.RedisCache) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	c, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return mr, c
+}
+
+func TestNilSwitch(t *testing.T) {
+	var s *Switch
+	assert.False(t, s.Enabled())
+	assert.Equal(t, State{}, s.State())
+}
+
+func TestSwitch_LocalOnly(t *testing.T) {
+	var changes []bool
+	s := New(nil, Config{OnChange: func(st State) { changes = append(changes, st.Enabled) }})
+	ctx := context.Background()
+
+	require.NoError(t, s.Set(ctx, State{Enabled: true, Reason: "db failover", By: "u-1"}))
+	assert.True(t, s.Enabled())
+	st := s.State()
+	assert.Equal(t, "db failover", st.Reason)
+	assert.Equal(t, "u-1", st.By)
+	assert.False(t, st.Since.IsZero())
+
+	// Refresh without a cache keeps the local state.
+	require.NoError(t, s.Refresh(ctx))
+	assert.True(t, s.Enabled())
+
+	require.NoError(t, s.Set(ctx, State{Enabled: false, Reason: "ignored"}))
+	assert.Equal(t, State{}, s.State(), "disabling clears reason and metadata")
+	assert.Equal(t, []bool{true, false}, changes)
+}
+
+func TestSwitch_SharedAcrossInstances(t *testing.T) {
+	_, c := newRedis(t)
+	ctx := context.Background()
+	a := New(c, Config{})
+	b := New(c, Config{})
+
+	require.NoError(t, a.Set(ctx, State{Enabled: true, Reason: "migration"}))
+	assert.False(t, b.Enabled(), "b sees the change only after a refresh")
+
+	require.NoError(t, b.Refresh(ctx))
+	assert.True(t, b.Enabled())
+	assert.Equal(t, "migration", b.State().Reason)
+	assert.True(t, a.State().Since.Equal(b.State().Since))
+
+	require.NoError(t, b.Set(ctx, State{Enabled: false}))
+	require.NoError(t, a.Refresh(ctx))
+	assert.False(t, a.Enabled())
+}
+
+func TestSwitch_RefreshKeepsStateOnCacheError(t *testing.T) {
+	mr, c := newRedis(t)
+	ctx := context.Background()
+	s := New(c, Config{})
+	require.NoError(t, s.Set(ctx, State{Enabled: true}))
+
+	mr.Close()
+	assert.Error(t, s.Refresh(ctx))
+	assert.True(t, s.Enabled(), "a cache outage must not turn read-only mode off")
+}
+
+func TestSwitch_SetFailsWithoutChangingState(t *testing.T) {
+	mr, c := newRedis(t)
+	s := New(c, Config{})
+
+	mr.Close()
+	assert.Error(t, s.Set(context.Background(), State{Enabled: true}))
+	assert.False(t, s.Enabled())
+}
+
+func TestSwitch_StartLoadsAndRefreshes(t *testing.T) {
+	_, c := newRedis(t)
+	ctx := context.Background()
+	require.NoError(t, New(c, Config{}).Set(ctx, State{Enabled: true}))
+
+	s := New(c, Config{Refresh: 10 * time.Millisecond})
+	s.Start(ctx)
+	defer s.Close()
+	assert.True(t, s.Enabled(), "Start loads the shared state before returning")
+
+	require.NoError(t, New(c, Config{}).Set(ctx, State{Enabled: false}))
+	assert.Eventually(t, func() bool { return !s.Enabled() }, time.Second, 5*time.Millisecond)
+}
+
+func TestSwitch_CloseWithoutStart(t *testing.T) {
+	s := New(nil, Config{})
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+}
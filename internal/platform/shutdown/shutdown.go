@@ -0,0 +1,213 @@
+// Package shutdown stops an application's components in dependency order.
+//
+// Each component registers the components it uses. Shutdown stops a component
+// only after everything that uses it has stopped, so a request still being
+// served never finds its database or cache closed underneath it. Among the
+// components that are free to stop, lower priorities go first, then
+// registration order.
+//
+// Every component gets its own timeout. A component that overruns it is
+// abandoned and reported as failed; the rest still stop. Errors are collected
+// and returned together.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// DefaultTimeout is a component's timeout when it sets none
+const DefaultTimeout = time.Second
+
+// minTimeout is the least a component gets when timeouts are scaled down, so
+// one that stops instantly still can.
+const minTimeout = 10 * time.Millisecond
+
+// Component is one thing to stop.
+type Component struct {
+	Name string
+	// DependsOn names the components this one uses. It is stopped before
+	// any of them. Names that were never registered are ignored, so a
+	// component may depend on an optional one.
+	DependsOn []string
+	// Priority orders components that are free to stop at the same time;
+	// lower stops first (default: 0).
+	Priority int
+	// Timeout bounds Stop (default: DefaultTimeout). Shutdown scales all
+	// timeouts down when its context has less time left than their sum.
+	Timeout time.Duration
+	// Stop stops the component. It should return once ctx is done; if it
+	// does not, it is left running and reported as timed out.
+	Stop func(ctx context.Context) error
+}
+
+// Registry holds the components of an application. The zero value is ready
+// to use.
+type Registry struct {
+	// Logger, when set, logs each component as it stops
+	Logger     *logger.Logger
+	components []Component
+	names      map[string]bool
+}
+
+// Register adds c. It panics when c has no name or Stop, or reuses a name;
+// those are programming errors.
+func (r *Registry) Register(c Component) {
+	if c.Name == "" || c.Stop == nil {
+		panic("shutdown: component needs a name and a Stop func")
+	}
+	if r.names == nil {
+		r.names = make(map[string]bool)
+	}
+	if r.names[c.Name] {
+		panic(fmt.Sprintf("shutdown: duplicate component %q", c.Name))
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	r.names[c.Name] = true
+	r.components = append(r.components, c)
+}
+
+// Order returns the component names in the order Shutdown stops them. It
+// fails when the dependencies form a cycle.
+func (r *Registry) Order() ([]string, error) {
+	order, err := r.order()
+	names := make([]string, len(order))
+	for i, c := range order {
+		names[i] = c.Name
+	}
+	return names, err
+}
+
+// order sorts the components topologically. On a cycle it returns the
+// registration order along with the error, so Shutdown can still stop
+// everything.
+func (r *Registry) order() ([]Component, error) {
+	// users[n] counts the registered components using n that have not
+	// stopped yet.
+	users := make(map[string]int, len(r.components))
+	for _, c := range r.components {
+		for _, dep := range dedupe(c.DependsOn) {
+			if r.names[dep] && dep != c.Name {
+				users[dep]++
+			}
+		}
+	}
+
+	done := make(map[string]bool, len(r.components))
+	order := make([]Component, 0, len(r.components))
+	for len(order) < len(r.components) {
+		next := -1
+		for i, c := range r.components {
+			if done[c.Name] || users[c.Name] > 0 {
+				continue
+			}
+			if next < 0 || c.Priority < r.components[next].Priority {
+				next = i
+			}
+		}
+		if next < 0 {
+			var stuck []string
+			for _, c := range r.components {
+				if !done[c.Name] {
+					stuck = append(stuck, c.Name)
+				}
+			}
+			return slices.Clone(r.components), fmt.Errorf("shutdown: dependency cycle among %s", strings.Join(stuck, ", "))
+		}
+		c := r.components[next]
+		done[c.Name] = true
+		order = append(order, c)
+		for _, dep := range dedupe(c.DependsOn) {
+			if r.names[dep] && dep != c.Name {
+				users[dep]--
+			}
+		}
+	}
+	return order, nil
+}
+
+func dedupe(names []string) []string {
+	out := slices.Clone(names)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// Shutdown stops every component in dependency order and returns their
+// errors joined. A failed component does not stop the ones after it.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	order, err := r.order()
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+		if r.Logger != nil {
+			r.Logger.Error("Shutting down in registration order", "error", err)
+		}
+	}
+
+	scale := 1.0
+	if deadline, ok := ctx.Deadline(); ok {
+		var total time.Duration
+		for _, c := range order {
+			total += c.Timeout
+		}
+		if remaining := time.Until(deadline); remaining < total {
+			scale = max(0, float64(remaining)/float64(total))
+		}
+	}
+
+	// Components get their own timeouts instead of ctx's cancellation: an
+	// expired ctx must not skip closing the rest.
+	base := context.WithoutCancel(ctx)
+	for _, c := range order {
+		timeout := max(minTimeout, time.Duration(float64(c.Timeout)*scale))
+		if err := r.stop(base, c, timeout); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stop runs c.Stop under timeout. A Stop that ignores its context is left
+// running once the timeout passes.
+func (r *Registry) stop(ctx context.Context, c Component, timeout time.Duration) error {
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() { result <- c.Stop(stopCtx) }()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-stopCtx.Done():
+		select {
+		case err = <-result:
+		default:
+			err = fmt.Errorf("timed out after %s: %w", timeout.Round(time.Millisecond), context.DeadlineExceeded)
+		}
+	}
+
+	if r.Logger == nil {
+		return err
+	}
+	args := []any{
+		"component", c.Name,
+		"duration_ms", time.Since(start).Milliseconds(),
+		"timeout_ms", timeout.Milliseconds(),
+	}
+	if err != nil {
+		r.Logger.Error("Shutdown component failed", append(args, "error", err)...)
+	} else {
+		r.Logger.Info("Shutdown component complete", args...)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the names of stopped components in order.
+type recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recorder) stop(name string, err error) func(context.Context) error {
+	return func(context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.names = append(r.names, name)
+		return err
+	}
+}
+
+func (r *recorder) stopped() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+func TestRegistry_Order(t *testing.T) {
+	rec := &recorder{}
+	var reg Registry
+	reg.Register(Component{Name: "tracer", Priority: 100, Stop: rec.stop("tracer", nil)})
+	reg.Register(Component{Name: "database", Stop: rec.stop("database", nil)})
+	reg.Register(Component{Name: "cache", Stop: rec.stop("cache", nil)})
+	reg.Register(Component{Name: "worker", DependsOn: []string{"database", "outbox"}, Stop: rec.stop("worker", nil)})
+	reg.Register(Component{Name: "outbox", DependsOn: []string{"database", "database"}, Stop: rec.stop("outbox", nil)})
+	reg.Register(Component{Name: "http", Priority: -1, DependsOn: []string{"cache", "database", "not-registered"}, Stop: rec.stop("http", nil)})
+
+	order, err := reg.Order()
+	require.NoError(t, err)
+	// http goes first on priority; worker before the outbox it uses, both
+	// before the database; the tracer waits for everything.
+	want := []string{"http", "cache", "worker", "outbox", "database", "tracer"}
+	assert.Equal(t, want, order)
+
+	require.NoError(t, reg.Shutdown(context.Background()))
+	assert.Equal(t, want, rec.stopped())
+}
+
+func TestRegistry_ErrorsAreJoined(t *testing.T) {
+	rec := &recorder{}
+	errQueue := errors.New("channel closed")
+	var reg Registry
+	reg.Register(Component{Name: "queue", Stop: rec.stop("queue", errQueue)})
+	reg.Register(Component{Name: "cache", Stop: rec.stop("cache", nil)})
+
+	err := reg.Shutdown(context.Background())
+	require.ErrorIs(t, err, errQueue)
+	assert.Contains(t, err.Error(), "queue: channel closed")
+	assert.Equal(t, []string{"queue", "cache"}, rec.stopped(), "a failure does not stop the rest")
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	rec := &recorder{}
+	release := make(chan struct{})
+	defer close(release)
+	var reg Registry
+	reg.Register(Component{Name: "hung", Timeout: 20 * time.Millisecond, Stop: func(context.Context) error {
+		<-release // ignores its context
+		return nil
+	}})
+	reg.Register(Component{Name: "server", Timeout: time.Second, Stop: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	reg.Register(Component{Name: "database", DependsOn: nil, Stop: rec.stop("database", nil)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := reg.Shutdown(ctx)
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hung: timed out")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"database"}, rec.stopped())
+	// The 1s server timeout is scaled down to fit the 200ms budget.
+	assert.Less(t, elapsed, 400*time.Millisecond)
+}
+
+func TestRegistry_ExpiredContextStillStops(t *testing.T) {
+	rec := &recorder{}
+	var reg Registry
+	reg.Register(Component{Name: "cache", Stop: rec.stop("cache", nil)})
+	reg.Register(Component{Name: "database", Stop: rec.stop("database", nil)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, reg.Shutdown(ctx))
+	assert.Equal(t, []string{"cache", "database"}, rec.stopped())
+}
+
+func TestRegistry_Cycle(t *testing.T) {
+	rec := &recorder{}
+	var reg Registry
+	reg.Register(Component{Name: "a", DependsOn: []string{"b"}, Stop: rec.stop("a", nil)})
+	reg.Register(Component{Name: "b", DependsOn: []string{"a"}, Stop: rec.stop("b", nil)})
+	reg.Register(Component{Name: "c", Stop: rec.stop("c", nil)})
+
+	_, err := reg.Order()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle among a, b")
+
+	// Everything is still stopped, in registration order.
+	err = reg.Shutdown(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, rec.stopped())
+}
+
+func TestRegistry_RegisterPanics(t *testing.T) {
+	var reg Registry
+	noop := func(context.Context) error { return nil }
+	assert.Panics(t, func() { reg.Register(Component{Stop: noop}) })
+	assert.Panics(t, func() { reg.Register(Component{Name: "x"}) })
+	reg.Register(Component{Name: "x", Stop: noop})
+	assert.Panics(t, func() { reg.Register(Component{Name: "x", Stop: noop}) })
+}
@@ -4,10 +4,10 @@ package testutil
 
 import (
 	"context"
-	"embed"
 	"fmt"
 	"time"
 
+	"github.com/14mdzk/goscratch/migrations"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -17,9 +17,6 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-//go:embed migrations/*.sql
-var migrationsFS embed.FS
-
 // StartPostgres starts a PostgreSQL container, runs migrations, and returns the DSN.
 func StartPostgres(ctx context.Context) (connString string, cleanup func(), err error) {
 	pgContainer, err := postgres.Run(ctx,
@@ -58,9 +55,10 @@ func StartPostgres(ctx context.Context) (connString string, cleanup func(), err
 	return connStr, cleanup, nil
 }
 
-// runMigrations applies database migrations from the embedded migrations directory.
+// runMigrations applies the migrations the API ships with (migrations.FS), so
+// tests always run against the current schema.
 func runMigrations(connStr string) error {
-	d, err := iofs.New(migrationsFS, "migrations")
+	d, err := iofs.New(migrations.FS, ".")
 	if err != nil {
 		return fmt.Errorf("failed to create iofs driver: %w", err)
 	}
@@ -115,3 +113,40 @@ func StartRedis(ctx context.Context) (addr string, cleanup func(), err error) {
 
 	return fmt.Sprintf("%s:%s", host, port.Port()), cleanup, nil
 }
+
+// StartNATS starts a NATS server with JetStream enabled and returns its URL.
+func StartNATS(ctx context.Context) (url string, cleanup func(), err error) {
+	natsContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "nats:2.10-alpine",
+			Cmd:          []string{"-js"},
+			ExposedPorts: []string{"4222/tcp"},
+			WaitingFor: wait.ForLog("Server is ready").
+				WithStartupTimeout(15 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start nats container: %w", err)
+	}
+
+	cleanup = func() {
+		if natsContainer != nil {
+			_ = natsContainer.Terminate(ctx)
+		}
+	}
+
+	host, err := natsContainer.Host(ctx)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to get nats host: %w", err)
+	}
+
+	port, err := natsContainer.MappedPort(ctx, "4222")
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to get nats port: %w", err)
+	}
+
+	return fmt.Sprintf("nats://%s:%s", host, port.Port()), cleanup, nil
+}
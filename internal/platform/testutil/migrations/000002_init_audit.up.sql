@@ -1,18 +0,0 @@
--- Audit logs table (optional, controlled by audit.enabled config)
-CREATE TABLE audit_logs (
-    id UUID PRIMARY KEY DEFAULT uuidv7(),
-    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
-    action VARCHAR(50) NOT NULL,
-    resource VARCHAR(100) NOT NULL,
-    resource_id VARCHAR(255),
-    old_value JSONB,
-    new_value JSONB,
-    ip_address INET,
-    user_agent TEXT,
-    created_at TIMESTAMPTZ DEFAULT NOW()
-);
-
-CREATE INDEX idx_audit_user_id ON audit_logs(user_id);
-CREATE INDEX idx_audit_resource ON audit_logs(resource, resource_id);
-CREATE INDEX idx_audit_created_at ON audit_logs(created_at);
-CREATE INDEX idx_audit_action ON audit_logs(action);
@@ -18,12 +18,16 @@ import (
 	userrepo "github.com/14mdzk/goscratch/internal/module/user/repository"
 	"github.com/14mdzk/goscratch/internal/module/job"
 	"github.com/14mdzk/goscratch/internal/module/role"
+	rolerepo "github.com/14mdzk/goscratch/internal/module/role/repository"
 	ssemodule "github.com/14mdzk/goscratch/internal/module/sse"
 	storagemodule "github.com/14mdzk/goscratch/internal/module/storage"
 	"github.com/14mdzk/goscratch/internal/module/user"
 	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/platform/database"
 	httpserver "github.com/14mdzk/goscratch/internal/platform/http"
+	"github.com/14mdzk/goscratch/internal/platform/http/middleware"
+	"github.com/14mdzk/goscratch/internal/platform/http/routes"
+	"github.com/14mdzk/goscratch/internal/platform/jwtkeys"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
 	"github.com/14mdzk/goscratch/pkg/logger"
@@ -43,8 +47,8 @@ func TestJWTSecret() string {
 func TestJWTConfig() config.JWTConfig {
 	return config.JWTConfig{
 		Secret:          testJWTSecret,
-		AccessTokenTTL:  15,
-		RefreshTokenTTL: 720,
+		AccessTokenTTL:  config.Duration(15 * time.Minute),
+		RefreshTokenTTL: config.Duration(720 * time.Minute),
 		Issuer:          "goscratch",
 		Audience:        "goscratch-api",
 	}
@@ -105,9 +109,9 @@ func NewTestApp(ctx context.Context, pgConnStr, redisAddr string) (*fiber.App, f
 	serverCfg := config.ServerConfig{
 		Host:         "127.0.0.1",
 		Port:         0,
-		ReadTimeout:  30,
-		WriteTimeout: 30,
-		IdleTimeout:  60,
+		ReadTimeout:  config.Duration(30 * time.Second),
+		WriteTimeout: config.Duration(30 * time.Second),
+		IdleTimeout:  config.Duration(60 * time.Second),
 	}
 	server := httpserver.NewServer(serverCfg, log, false)
 	app := server.App()
@@ -123,13 +127,17 @@ func NewTestApp(ctx context.Context, pgConnStr, redisAddr string) (*fiber.App, f
 		health.NewQueueChecker(queueAdapter),
 		health.NewAuthzChecker(authorizer),
 	)
+	routeCfg := routes.Config{Authorizer: authorizer, Cache: cacheAdapter}
 	sharedUserRepo := userrepo.NewRepository(pool)
-	authModule := auth.NewModule(sharedUserRepo, cacheAdapter, auditor, jwtCfg)
-	userModule := user.NewModule(pool, transactor, auditor, authorizer, cacheAdapter, jwtCfg.Secret, authModule.Revoker())
-	roleModule := role.NewModule(authorizer, jwtCfg.Secret)
-	storageModule := storagemodule.NewModule(storageAdapter, auditor, jwtCfg.Secret)
-	sseModule := ssemodule.NewModule(sseBroker, authorizer, jwtCfg.Secret)
-	jobModule := job.NewModule(publisher, auditor, authorizer, jwtCfg.Secret)
+	jwtKeys := jwtkeys.NewHMAC(jwtCfg.Secret)
+	authCfg := middleware.NewAuthConfig(jwtKeys, jwtCfg.Issuer, jwtCfg.Audience)
+	authCfg.Denylist = cacheAdapter
+	authModule := auth.NewModule(sharedUserRepo, cacheAdapter, auditor, jwtCfg, authCfg, authorizer, nil, nil, nil, nil, nil, nil, config.LoginThrottleConfig{}, nil)
+	userModule := user.NewModule(sharedUserRepo, transactor, auditor, authorizer, cacheAdapter, authCfg, authModule.Revoker(), authModule.Verifier(), authModule.EmailChanger(), authModule.Impersonator(), nil, nil, routeCfg)
+	roleModule := role.NewModule(rolerepo.NewRepository(pool), authorizer, auditor, authCfg, routeCfg)
+	storageModule := storagemodule.NewModule(storageAdapter, auditor, authCfg)
+	sseModule := ssemodule.NewModule(sseBroker, cacheAdapter, authCfg, config.SSEConfig{}, routeCfg)
+	jobModule := job.NewModule(publisher, nil, auditor, authorizer, authCfg)
 
 	server.RegisterModules(healthModule, userModule, authModule, roleModule, storageModule, sseModule, jobModule)
 
@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/perf"
+)
+
+// validatorBudgets caps allocations per op for struct validation, which runs
+// on every write request body.
+var validatorBudgets = perf.Budgets{
+	"validate.valid":   12,
+	"validate.invalid": 44,
+}
+
+var (
+	benchValid   = testCreateUserRequest{Email: "bench@example.com", Password: "password123", Name: "Bench User"}
+	benchInvalid = testCreateUserRequest{Email: "not-an-email", Password: "short", Name: "B"}
+)
+
+// BenchmarkValidate_Valid measures the happy path (no error map built).
+func BenchmarkValidate_Valid(b *testing.B) {
+	Get()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if err := Validate(&benchValid); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidate_Invalid measures the failure path, including error
+// message formatting for three fields.
+func BenchmarkValidate_Invalid(b *testing.B) {
+	Get()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if err := Validate(&benchInvalid); err == nil {
+			b.Fatal("expected validation error")
+		}
+	}
+}
+
+func TestAllocBudget_Validator(t *testing.T) {
+	validatorBudgets.Check(t, "validate.valid", func() { _ = Validate(&benchValid) })
+	validatorBudgets.Check(t, "validate.invalid", func() { _ = Validate(&benchInvalid) })
+}
@@ -0,0 +1,258 @@
+// Package watchdog watches for slow leaks that only show after hours of
+// uptime: goroutines that are started and never return (a retry loop that
+// outlives its job, a stream whose client went away) and channels that stay
+// full because their reader is stuck.
+//
+// A Watchdog samples the goroutine count and the fill level of the channel
+// buffers it is given on a fixed interval. Goroutine growth is sustained when
+// the count stays above where it was at the start of the window for the whole
+// window; short bursts that drain again never alert. A buffer is stuck when
+// at least one of its channels stays saturated for a whole window.
+//
+// Each condition is logged once when it starts and once when it clears.
+package watchdog
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// SignalGoroutines names goroutine growth in alerts; buffer alerts use the
+// buffer's name.
+const SignalGoroutines = "goroutines"
+
+// Buffer is a set of channels watched together, e.g. the SSE broker's
+// per-client channels.
+type Buffer struct {
+	Name string
+	// Usage yields the length and capacity of each channel
+	Usage iter.Seq2[int, int]
+}
+
+// BufferSample is one reading of a Buffer.
+type BufferSample struct {
+	Name      string
+	Channels  int
+	Queued    int // items waiting across all channels
+	Capacity  int // total capacity of all channels
+	Saturated int // channels filled to the saturation threshold or beyond
+}
+
+// Utilization returns the fraction of the buffer's capacity in use.
+func (b BufferSample) Utilization() float64 {
+	if b.Capacity == 0 {
+		return 0
+	}
+	return float64(b.Queued) / float64(b.Capacity)
+}
+
+// Sample is one reading of all signals.
+type Sample struct {
+	Goroutines int
+	// Growth is how far the goroutine count has stayed above the count at
+	// the start of the window, through the whole window; 0 until a full
+	// window has been sampled.
+	Growth  int
+	Buffers []BufferSample
+}
+
+// Config holds the watchdog settings.
+type Config struct {
+	Interval time.Duration // Sampling interval (default: 30s)
+	Window   int           // Samples a condition must last to count as sustained (default: 10)
+	// GoroutineGrowth is the sustained growth that counts as a leak
+	// (default: 500).
+	GoroutineGrowth int
+	// Saturation is the fill level at which a channel counts as saturated
+	// (default: 0.8).
+	Saturation float64
+	Buffers    []Buffer
+
+	Logger *logger.Logger
+	// OnSample is called with every sample, e.g. to update gauges. Optional.
+	OnSample func(Sample)
+	// OnAlert is called when a condition starts, with SignalGoroutines or the
+	// buffer's name. Optional.
+	OnAlert func(signal string)
+}
+
+// Watchdog samples the signals and tracks the alert state. A nil *Watchdog
+// does nothing.
+type Watchdog struct {
+	cfg        Config
+	goroutines func() int
+
+	// Touched only by the sampling goroutine.
+	history  []int // goroutine counts, oldest first, at most Window+1
+	leaking  bool
+	stuckFor map[string]int // consecutive saturated samples per buffer
+	stuck    map[string]bool
+
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a watchdog. Call Start to begin sampling.
+func New(cfg Config) *Watchdog {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 10
+	}
+	if cfg.GoroutineGrowth <= 0 {
+		cfg.GoroutineGrowth = 500
+	}
+	if cfg.Saturation <= 0 || cfg.Saturation > 1 {
+		cfg.Saturation = 0.8
+	}
+	return &Watchdog{
+		cfg:        cfg,
+		goroutines: runtime.NumGoroutine,
+		stuckFor:   make(map[string]int),
+		stuck:      make(map[string]bool),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start samples the signals every Interval until Close is called.
+func (w *Watchdog) Start() {
+	if w == nil {
+		return
+	}
+	w.started.Store(true)
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				w.Check()
+			}
+		}
+	}()
+}
+
+// Close stops sampling. It is safe to call more than once, before Start, and
+// on a nil *Watchdog.
+func (w *Watchdog) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.stopOnce.Do(func() { close(w.stop) })
+	if w.started.Load() {
+		<-w.done
+	}
+	return nil
+}
+
+// Check takes one sample and updates the alert state. The sampling goroutine
+// calls it; it must not be called concurrently with it.
+func (w *Watchdog) Check() Sample {
+	s := Sample{Goroutines: w.goroutines()}
+	for _, b := range w.cfg.Buffers {
+		s.Buffers = append(s.Buffers, w.sampleBuffer(b))
+	}
+	s.Growth = w.observeGoroutines(s.Goroutines)
+	for _, b := range s.Buffers {
+		w.observeBuffer(b)
+	}
+	if w.cfg.OnSample != nil {
+		w.cfg.OnSample(s)
+	}
+	return s
+}
+
+// sampleBuffer reads the fill level of b's channels.
+func (w *Watchdog) sampleBuffer(b Buffer) BufferSample {
+	bs := BufferSample{Name: b.Name}
+	for length, capacity := range b.Usage {
+		bs.Channels++
+		bs.Queued += length
+		bs.Capacity += capacity
+		if capacity > 0 && float64(length) >= w.cfg.Saturation*float64(capacity) {
+			bs.Saturated++
+		}
+	}
+	return bs
+}
+
+// observeGoroutines records count and returns the sustained growth.
+func (w *Watchdog) observeGoroutines(count int) int {
+	w.history = append(w.history, count)
+	if len(w.history) > w.cfg.Window+1 {
+		w.history = w.history[1:]
+	}
+	growth := 0
+	if len(w.history) == w.cfg.Window+1 {
+		floor := w.history[1]
+		for _, c := range w.history[2:] {
+			floor = min(floor, c)
+		}
+		growth = max(0, floor-w.history[0])
+	}
+
+	switch leaking := growth >= w.cfg.GoroutineGrowth; {
+	case leaking && !w.leaking:
+		w.leaking = true
+		w.alert(SignalGoroutines, "Sustained goroutine growth; possible leak",
+			"goroutines", count,
+			"growth", growth,
+			"window", (time.Duration(w.cfg.Window) * w.cfg.Interval).String(),
+		)
+	case !leaking && w.leaking && growth < w.cfg.GoroutineGrowth/2:
+		w.leaking = false
+		w.resolve("Goroutine growth stopped", "goroutines", count, "growth", growth)
+	}
+	return growth
+}
+
+// observeBuffer records one sample of a buffer and alerts once a saturated
+// channel has been seen for a whole window.
+func (w *Watchdog) observeBuffer(b BufferSample) {
+	if b.Saturated == 0 {
+		w.stuckFor[b.Name] = 0
+		if w.stuck[b.Name] {
+			w.stuck[b.Name] = false
+			w.resolve("Channel buffer drained", "buffer", b.Name, "utilization", b.Utilization())
+		}
+		return
+	}
+	w.stuckFor[b.Name]++
+	if w.stuckFor[b.Name] >= w.cfg.Window && !w.stuck[b.Name] {
+		w.stuck[b.Name] = true
+		w.alert(b.Name, "Channel buffer saturated; readers may be stuck",
+			"buffer", b.Name,
+			"saturated", b.Saturated,
+			"channels", b.Channels,
+			"utilization", b.Utilization(),
+			"window", (time.Duration(w.cfg.Window) * w.cfg.Interval).String(),
+		)
+	}
+}
+
+func (w *Watchdog) alert(signal, msg string, args ...any) {
+	if w.cfg.OnAlert != nil {
+		w.cfg.OnAlert(signal)
+	}
+	if w.cfg.Logger != nil {
+		w.cfg.Logger.Warn(msg, args...)
+	}
+}
+
+func (w *Watchdog) resolve(msg string, args ...any) {
+	if w.cfg.Logger != nil {
+		w.cfg.Logger.Info(msg, args...)
+	}
+}
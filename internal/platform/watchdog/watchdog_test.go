@@ -0,0 +1,112 @@
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilWatchdog(t *testing.T) {
+	var w *Watchdog
+	w.Start()
+	assert.NoError(t, w.Close())
+}
+
+func TestWatchdog_GoroutineGrowth(t *testing.T) {
+	var alerts []string
+	w := New(Config{Window: 3, GoroutineGrowth: 100, OnAlert: func(s string) { alerts = append(alerts, s) }})
+	count := 0
+	w.goroutines = func() int { return count }
+
+	// A burst that drains again never alerts.
+	for _, c := range []int{100, 400, 110, 105, 100, 102} {
+		count = c
+		w.Check()
+	}
+	assert.Empty(t, alerts)
+
+	// Steady growth does.
+	var s Sample
+	for _, c := range []int{150, 260, 370, 480} {
+		count = c
+		s = w.Check()
+	}
+	assert.Equal(t, 480, s.Goroutines)
+	assert.Equal(t, 260-150, s.Growth)
+	assert.Equal(t, []string{SignalGoroutines}, alerts)
+
+	// Still growing: no second alert.
+	count = 600
+	w.Check()
+	assert.Len(t, alerts, 1)
+	assert.True(t, w.leaking)
+
+	// Once the count levels off for a window the condition clears.
+	for range 3 {
+		w.Check()
+	}
+	assert.False(t, w.leaking)
+}
+
+func TestWatchdog_Buffers(t *testing.T) {
+	var alerts []string
+	var samples []Sample
+	lengths := []int{0, 2}
+	w := New(Config{
+		Window: 2,
+		Buffers: []Buffer{{Name: "sse", Usage: func(yield func(int, int) bool) {
+			for _, l := range lengths {
+				if !yield(l, 10) {
+					return
+				}
+			}
+		}}},
+		OnAlert:  func(s string) { alerts = append(alerts, s) },
+		OnSample: func(s Sample) { samples = append(samples, s) },
+	})
+
+	s := w.Check()
+	require.Len(t, s.Buffers, 1)
+	assert.Equal(t, BufferSample{Name: "sse", Channels: 2, Queued: 2, Capacity: 20}, s.Buffers[0])
+	assert.InDelta(t, 0.1, s.Buffers[0].Utilization(), 1e-9)
+
+	// One client stops reading: its channel saturates.
+	lengths = []int{8, 2}
+	s = w.Check()
+	assert.Equal(t, 1, s.Buffers[0].Saturated)
+	assert.Empty(t, alerts, "not sustained for a window yet")
+	w.Check()
+	assert.Equal(t, []string{"sse"}, alerts)
+	w.Check()
+	assert.Len(t, alerts, 1)
+
+	// It drains, and the buffer can alert again later.
+	lengths = []int{0, 0}
+	w.Check()
+	assert.False(t, w.stuck["sse"])
+	assert.Len(t, samples, 5)
+
+	var empty BufferSample
+	assert.Zero(t, empty.Utilization())
+}
+
+func TestWatchdog_StartClose(t *testing.T) {
+	sampled := make(chan Sample, 1)
+	w := New(Config{Interval: time.Millisecond, OnSample: func(s Sample) {
+		select {
+		case sampled <- s:
+		default:
+		}
+	}})
+	w.Start()
+	select {
+	case s := <-sampled:
+		assert.Positive(t, s.Goroutines)
+	case <-time.After(time.Second):
+		t.Fatal("no sample taken")
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,145 @@
+package port
+
+import (
+	"reflect"
+	"strings"
+)
+
+// AuditMasked replaces the value of a sensitive field in an audit diff
+const AuditMasked = "[REDACTED]"
+
+// sensitiveAuditKeys are field names whose values are never written to the
+// audit log. A key matches when it equals one, or starts or ends with one
+// joined by an underscore, e.g. "password_hash" or "refresh_token".
+var sensitiveAuditKeys = []string{"password", "secret", "token", "api_key", "private_key"}
+
+// AuditDiff compares two values of the same struct type, or two maps with
+// string keys, and returns the fields that differ keyed by their JSON name:
+// the old values as oldValue and the new ones as newValue. Both are nil when
+// nothing changed. A nil pointer counts as having no fields, so diffing
+// against nil lists every field of the other value.
+//
+// Struct fields are named and skipped as encoding/json does. A field tagged
+// `audit:"-"` is ignored, and one tagged `audit:"mask"`, or with a sensitive
+// name such as "password", is recorded as changed with AuditMasked in place
+// of its values. Nested structs are compared as a whole.
+func AuditDiff(oldV, newV any) (oldValue, newValue map[string]any) {
+	oldFields, newFields := auditFields(oldV), auditFields(newV)
+	for key, nf := range newFields {
+		of, ok := oldFields[key]
+		if ok && reflect.DeepEqual(of.value, nf.value) {
+			continue
+		}
+		if oldValue == nil {
+			oldValue, newValue = map[string]any{}, map[string]any{}
+		}
+		newValue[key] = nf.masked()
+		if ok {
+			oldValue[key] = of.masked()
+		}
+	}
+	for key, of := range oldFields {
+		if _, ok := newFields[key]; ok {
+			continue
+		}
+		if oldValue == nil {
+			oldValue, newValue = map[string]any{}, map[string]any{}
+		}
+		oldValue[key] = of.masked()
+	}
+	return oldValue, newValue
+}
+
+// SetDiff sets the entry's OldValue and NewValue to the fields that differ
+// between oldV and newV (see AuditDiff) and reports whether any did
+func (e *AuditEntry) SetDiff(oldV, newV any) bool {
+	oldValue, newValue := AuditDiff(oldV, newV)
+	if oldValue == nil {
+		return false
+	}
+	e.OldValue, e.NewValue = oldValue, newValue
+	return true
+}
+
+// auditField is one field of a value being diffed
+type auditField struct {
+	value any
+	mask  bool
+}
+
+func (f auditField) masked() any {
+	if f.mask {
+		return AuditMasked
+	}
+	return f.value
+}
+
+// auditFields returns the fields of v keyed by name
+func auditFields(v any) map[string]auditField {
+	fields := map[string]auditField{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return fields
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		addStructFields(fields, rv)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fields
+		}
+		for it := rv.MapRange(); it.Next(); {
+			key := it.Key().String()
+			fields[key] = auditField{value: it.Value().Interface(), mask: sensitiveAuditKey(key)}
+		}
+	}
+	return fields
+}
+
+// addStructFields adds the exported fields of rv, flattening embedded
+// structs without a JSON name as encoding/json does
+func addStructFields(fields map[string]auditField, rv reflect.Value) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("audit")
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == "-" || name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			fv := rv.Field(i)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				addStructFields(fields, fv)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = auditField{value: rv.Field(i).Interface(), mask: tag == "mask" || sensitiveAuditKey(name)}
+	}
+}
+
+// sensitiveAuditKey reports whether key names a field that must be masked
+func sensitiveAuditKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveAuditKeys {
+		if key == s || strings.HasPrefix(key, s+"_") || strings.HasSuffix(key, "_"+s) {
+			return true
+		}
+	}
+	return false
+}
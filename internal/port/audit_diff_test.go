@@ -0,0 +1,87 @@
+package port_test
+
+import (
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/stretchr/testify/assert"
+)
+
+type diffBase struct {
+	ID string `json:"id"`
+}
+
+type diffProfile struct {
+	diffBase
+	Name         string  `json:"name"`
+	Nickname     *string `json:"nickname"`
+	PasswordHash string  `json:"password_hash"`
+	Pin          string  `json:"pin" audit:"mask"`
+	UpdatedAt    string  `json:"updated_at" audit:"-"`
+	Internal     string  `json:"-"`
+	Plain        int
+	hidden       string
+}
+
+func TestAuditDiff(t *testing.T) {
+	nick := func(s string) *string { return &s }
+	old := diffProfile{
+		diffBase: diffBase{ID: "u-1"}, Name: "Ann", Nickname: nick("annie"), PasswordHash: "h1", Pin: "1234",
+		UpdatedAt: "2026-01-01", Internal: "a", Plain: 1, hidden: "a",
+	}
+
+	t.Run("only changed fields are listed", func(t *testing.T) {
+		updated := old
+		updated.Name = "Anna"
+		updated.Nickname = nick("annie") // same value, another pointer
+		updated.UpdatedAt = "2026-02-01"
+		updated.Internal = "b"
+		updated.hidden = "b"
+
+		oldValue, newValue := port.AuditDiff(old, &updated)
+		assert.Equal(t, map[string]any{"name": "Ann"}, oldValue)
+		assert.Equal(t, map[string]any{"name": "Anna"}, newValue)
+	})
+
+	t.Run("sensitive fields are masked", func(t *testing.T) {
+		updated := old
+		updated.PasswordHash = "h2"
+		updated.Pin = "0000"
+		updated.Plain = 2
+
+		oldValue, newValue := port.AuditDiff(&old, &updated)
+		assert.Equal(t, map[string]any{"password_hash": port.AuditMasked, "pin": port.AuditMasked, "Plain": 1}, oldValue)
+		assert.Equal(t, map[string]any{"password_hash": port.AuditMasked, "pin": port.AuditMasked, "Plain": 2}, newValue)
+	})
+
+	t.Run("no change", func(t *testing.T) {
+		oldValue, newValue := port.AuditDiff(old, old)
+		assert.Nil(t, oldValue)
+		assert.Nil(t, newValue)
+	})
+
+	t.Run("nil lists every field of the other value", func(t *testing.T) {
+		oldValue, newValue := port.AuditDiff((*diffProfile)(nil), diffBase{ID: "u-2"})
+		assert.Empty(t, oldValue)
+		assert.Equal(t, map[string]any{"id": "u-2"}, newValue)
+	})
+
+	t.Run("maps", func(t *testing.T) {
+		oldValue, newValue := port.AuditDiff(
+			map[string]any{"role": "user", "gone": true, "refresh_token": "a"},
+			map[string]any{"role": "admin", "refresh_token": "b"},
+		)
+		assert.Equal(t, map[string]any{"role": "user", "gone": true, "refresh_token": port.AuditMasked}, oldValue)
+		assert.Equal(t, map[string]any{"role": "admin", "refresh_token": port.AuditMasked}, newValue)
+	})
+}
+
+func TestAuditEntry_SetDiff(t *testing.T) {
+	var entry port.AuditEntry
+	assert.False(t, entry.SetDiff(diffBase{ID: "a"}, diffBase{ID: "a"}))
+	assert.Nil(t, entry.OldValue)
+
+	assert.True(t, entry.SetDiff(diffBase{ID: "a"}, diffBase{ID: "b"}))
+	assert.Equal(t, map[string]any{"id": "a"}, entry.OldValue)
+	assert.Equal(t, map[string]any{"id": "b"}, entry.NewValue)
+}
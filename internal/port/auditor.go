@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/14mdzk/goscratch/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Auditor defines the interface for audit logging
@@ -23,6 +24,7 @@ type Auditor interface {
 type AuditEntry struct {
 	ID         string         `json:"id"`
 	UserID     string         `json:"user_id"`
+	ActorID    string         `json:"actor_id,omitempty"` // admin impersonating UserID, if any
 	Action     AuditAction    `json:"action"`
 	Resource   string         `json:"resource"` // e.g., "user", "order"
 	ResourceID string         `json:"resource_id"`
@@ -31,7 +33,11 @@ type AuditEntry struct {
 	Metadata   map[string]any `json:"metadata,omitempty"`
 	IPAddress  string         `json:"ip_address,omitempty"`
 	UserAgent  string         `json:"user_agent,omitempty"`
-	Timestamp  time.Time      `json:"timestamp"`
+	// RequestID and TraceID correlate the entry with the request's log
+	// lines and trace
+	RequestID string    `json:"request_id,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // AuditAction represents the type of action being audited
@@ -44,25 +50,109 @@ const (
 	AuditActionDelete AuditAction = "DELETE"
 	AuditActionLogin  AuditAction = "LOGIN"
 	AuditActionLogout AuditAction = "LOGOUT"
+	AuditActionMerge  AuditAction = "MERGE"
+	// AuditActionAlert records a security alert rather than a user action,
+	// e.g. a tripped honeytoken. Metadata carries "alert" and "severity".
+	AuditActionAlert AuditAction = "ALERT"
+	// AuditActionBlock records a request refused by an access policy before
+	// it reached a handler, e.g. a country restriction.
+	AuditActionBlock AuditAction = "BLOCK"
+	// AuditActionPasswordReset records a forgot-password request or a
+	// completed reset. Metadata carries "stage": "requested" or "completed".
+	AuditActionPasswordReset AuditAction = "PASSWORD_RESET"
+	// AuditActionEmailVerify records a verification link being sent or used.
+	// Metadata carries "stage": "sent" or "completed".
+	AuditActionEmailVerify AuditAction = "EMAIL_VERIFY"
+	// AuditActionImpersonate records an admin starting to impersonate the
+	// user in ResourceID. Metadata carries the token's "token_id" and
+	// "expires_at".
+	AuditActionImpersonate AuditAction = "IMPERSONATE"
+	// AuditActionRequest records a mutating HTTP request, whatever the
+	// handler audited itself. ResourceID is the matched route; Metadata
+	// carries "method", "route", "path" and "status".
+	AuditActionRequest AuditAction = "REQUEST"
 )
 
-// AuditFilter defines filters for querying audit logs
+// AuditFilter defines filters for querying audit logs. Filters combine with
+// AND; empty ones match every entry.
 type AuditFilter struct {
-	UserID     string
-	Action     AuditAction
-	Resource   string
+	UserID string
+	// Involving matches entries the user is the actor or the target of:
+	// written by them, by an admin impersonating them or by them while
+	// impersonating, or made to their user record
+	Involving string
+	// Actions and Resources match entries with any of the values
+	Actions    []AuditAction
+	Resources  []string
 	ResourceID string
-	StartTime  *time.Time
-	EndTime    *time.Time
-	Limit      int
-	Cursor     string
+	RequestID  string
+	TraceID    string
+	// Contains matches entries whose old or new value contains it, as
+	// JSONB containment: {"email": "a@example.com"} matches a value with
+	// that email among other fields
+	Contains map[string]any
+	// Search matches entries whose old or new value contains the text,
+	// case-insensitively, anywhere in its JSON form
+	Search    string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Limit     int
+	// Cursor is the ID of the last entry of the previous page; entries are
+	// returned newest first, starting after it
+	Cursor string
+}
+
+// AuditReadSampler is implemented by auditors that audit record reads.
+// Reads far outnumber changes, so only a sample of them may be kept.
+type AuditReadSampler interface {
+	// SampleRead reports whether a read of resource is audited
+	SampleRead(resource string) bool
+}
+
+// LogRead logs a READ entry for a record being viewed when auditor samples
+// the read. Auditors that do not implement AuditReadSampler audit no reads,
+// so read auditing stays off unless configured.
+func LogRead(ctx context.Context, auditor Auditor, entry AuditEntry) error {
+	if s, ok := auditor.(AuditReadSampler); !ok || !s.SampleRead(entry.Resource) {
+		return nil
+	}
+	return auditor.Log(ctx, entry)
+}
+
+// AuditChainVerifier checks the hash chain of tamper-evident audit entries
+type AuditChainVerifier interface {
+	// VerifyChain checks up to limit chained entries, starting at sequence
+	// number fromSeq (0 for the oldest stored)
+	VerifyChain(ctx context.Context, fromSeq int64, limit int) (*AuditChainReport, error)
+}
+
+// AuditChainReport is the result of verifying the audit chain. The first
+// entry that fails verification ends the check.
+type AuditChainReport struct {
+	Valid    bool  `json:"valid"`
+	Checked  int   `json:"checked"`
+	FirstSeq int64 `json:"first_seq,omitempty"`
+	LastSeq  int64 `json:"last_seq,omitempty"`
+	// LastHash is the hash of the last entry checked. Recording it outside
+	// the database makes later rewrites of the chain up to it detectable.
+	LastHash string `json:"last_hash,omitempty"`
+	// NextSeq is where to continue when limit entries were checked before
+	// the end of the chain
+	NextSeq int64 `json:"next_seq,omitempty"`
+	// BrokenSeq and BrokenID name the entry that failed, and Reason why
+	BrokenSeq int64  `json:"broken_seq,omitempty"`
+	BrokenID  string `json:"broken_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
 }
 
 // AuditContext extracts audit-relevant information from context
 type AuditContext struct {
 	UserID    string
+	ActorID   string // set while an admin impersonates UserID
 	IPAddress string
 	UserAgent string
+	RequestID string
+	TraceID   string // from the trace key, else the span in ctx
 }
 
 // ExtractAuditContext extracts audit context from request context
@@ -72,12 +162,23 @@ func ExtractAuditContext(ctx context.Context) AuditContext {
 	if userID, ok := ctx.Value(logger.UserIDKey).(string); ok {
 		ac.UserID = userID
 	}
+	if actorID, ok := ctx.Value(logger.ActorIDKey).(string); ok {
+		ac.ActorID = actorID
+	}
 	if ip, ok := ctx.Value(logger.IPAddressKey).(string); ok {
 		ac.IPAddress = ip
 	}
 	if ua, ok := ctx.Value(logger.UserAgentKey).(string); ok {
 		ac.UserAgent = ua
 	}
+	if requestID, ok := ctx.Value(logger.RequestIDKey).(string); ok {
+		ac.RequestID = requestID
+	}
+	if traceID, ok := ctx.Value(logger.TraceIDKey).(string); ok {
+		ac.TraceID = traceID
+	} else if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		ac.TraceID = sc.TraceID().String()
+	}
 
 	return ac
 }
@@ -87,11 +188,14 @@ func NewAuditEntry(ctx context.Context, action AuditAction, resource, resourceID
 	ac := ExtractAuditContext(ctx)
 	return AuditEntry{
 		UserID:     ac.UserID,
+		ActorID:    ac.ActorID,
 		Action:     action,
 		Resource:   resource,
 		ResourceID: resourceID,
 		IPAddress:  ac.IPAddress,
 		UserAgent:  ac.UserAgent,
+		RequestID:  ac.RequestID,
+		TraceID:    ac.TraceID,
 		Timestamp:  time.Now(),
 	}
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestExtractAuditContext_TypedKeys(t *testing.T) {
@@ -46,3 +48,39 @@ func TestExtractAuditContext_EmptyContext(t *testing.T) {
 	assert.Empty(t, ac.IPAddress)
 	assert.Empty(t, ac.UserAgent)
 }
+
+func TestNewAuditEntry_CorrelationIDs(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.RequestIDKey, "req-1")
+	ctx = context.WithValue(ctx, logger.TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", "u-1")
+
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry.TraceID)
+}
+
+func TestExtractAuditContext_TraceIDFromSpan(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	ac := port.ExtractAuditContext(ctx)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ac.TraceID)
+	assert.Empty(t, ac.RequestID)
+}
+
+func TestNewAuditEntry_Impersonation(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.UserIDKey, "u-1")
+	ctx = context.WithValue(ctx, logger.ActorIDKey, "admin-1")
+
+	entry := port.NewAuditEntry(ctx, port.AuditActionUpdate, "user", "u-1")
+
+	assert.Equal(t, "u-1", entry.UserID)
+	assert.Equal(t, "admin-1", entry.ActorID)
+}
@@ -1,6 +1,9 @@
 package port
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 // Authorizer defines the interface for authorization operations
 type Authorizer interface {
@@ -10,6 +13,11 @@ type Authorizer interface {
 	// EnforceWithContext checks permission with context for cancellation
 	EnforceWithContext(ctx context.Context, sub, obj, act string) (bool, error)
 
+	// EnforceBatch checks many permissions in one call, e.g. to flag which
+	// items of a list the caller may edit. It returns one decision per
+	// request, in order, or fails as a whole.
+	EnforceBatch(ctx context.Context, requests []EnforceRequest) ([]bool, error)
+
 	// Role management
 	AddRoleForUser(userID, role string) error
 	RemoveRoleForUser(userID, role string) error
@@ -17,6 +25,21 @@ type Authorizer interface {
 	GetUsersForRole(role string) ([]string, error)
 	HasRoleForUser(userID, role string) (bool, error)
 
+	// Domain (tenant) scoped access. A role granted in a domain only applies
+	// to checks in that domain; roles granted without one apply in every
+	// domain. Role permissions are the same in every domain.
+	EnforceInDomain(sub, dom, obj, act string) (bool, error)
+	AddRoleForUserInDomain(userID, role, domain string) error
+	RemoveRoleForUserInDomain(userID, role, domain string) error
+	GetRolesForUserInDomain(userID, domain string) ([]string, error)
+	GetUsersForRoleInDomain(role, domain string) ([]string, error)
+
+	// EnforceOwnership checks if subject may perform action on an object
+	// owned by owner, in domain dom ("" for none): through its own
+	// permissions, or, when subject is owner, through the permissions of
+	// RoleOwner.
+	EnforceOwnership(sub, owner, dom, obj, act string) (bool, error)
+
 	// Permission management for roles
 	AddPermissionForRole(role, obj, act string) error
 	RemovePermissionForRole(role, obj, act string) error
@@ -27,9 +50,20 @@ type Authorizer interface {
 	RemovePermissionForUser(userID, obj, act string) error
 	GetPermissionsForUser(userID string) ([][]string, error)
 
-	// Get all implicit permissions for a user (including via roles)
+	// Get all implicit permissions for a user (including via roles), less
+	// those denied to them
 	GetImplicitPermissionsForUser(userID string) ([][]string, error)
 
+	// Deny rules refuse a user or role an action even where one of their
+	// roles allows it: a deny overrides every allow
+	AddDeny(sub, obj, act string) error
+	RemoveDeny(sub, obj, act string) error
+
+	// GetRules returns every stored rule: permissions ("p") and role grants
+	// ("g", and "g2" for grants in a domain). Deny rules are permissions
+	// whose last value is EffectDeny.
+	GetRules() ([]PolicyRule, error)
+
 	// Policy management
 	LoadPolicy() error
 	SavePolicy() error
@@ -39,10 +73,42 @@ type Authorizer interface {
 	Close() error
 }
 
+// EnforceRequest is one check of EnforceBatch: may Sub perform Act on Obj,
+// in domain Dom ("" for none, as Enforce)
+type EnforceRequest struct {
+	Sub string
+	Dom string
+	Obj string
+	Act string
+}
+
+// PolicyRule is one stored authorization rule, as in casbin_rules: its type
+// and values, e.g. {"p", ["admin", "users", "read"]} or
+// {"g", ["<user id>", "admin"]}
+type PolicyRule struct {
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+}
+
+// String returns the rule in Casbin's CSV form, e.g. "p, admin, users, read"
+func (r PolicyRule) String() string {
+	return strings.Join(append([]string{r.Type}, r.Values...), ", ")
+}
+
+// Effects of a permission rule
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
 // Common roles
 const (
 	RoleSuperAdmin = "superadmin"
 	RoleAdmin      = "admin"
 	RoleEditor     = "editor"
 	RoleViewer     = "viewer"
+
+	// RoleOwner holds the permissions every user has on the objects they
+	// own, checked by EnforceOwnership. It is never granted to a user.
+	RoleOwner = "owner"
 )
@@ -20,6 +20,10 @@ type Cache interface {
 	// NoOpCache returns ErrCacheUnavailable; Redis uses SCAN + DEL.
 	DeleteByPrefix(ctx context.Context, prefix string) error
 
+	// KeysByPrefix returns the names of all keys with the given prefix.
+	// NoOpCache returns ErrCacheUnavailable; Redis uses SCAN.
+	KeysByPrefix(ctx context.Context, prefix string) ([]string, error)
+
 	// Exists checks if a key exists in the cache
 	Exists(ctx context.Context, key string) (bool, error)
 
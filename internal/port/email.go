@@ -1,6 +1,9 @@
 package port
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // EmailSender defines the interface for sending emails
 type EmailSender interface {
@@ -18,4 +21,11 @@ type EmailMessage struct {
 	Subject string   `json:"subject"`
 	Body    string   `json:"body"`
 	IsHTML  bool     `json:"is_html,omitempty"`
+	// Tenant selects the sender profile (SMTP credentials, from address and
+	// branding) in white-labeled deployments; empty means the default.
+	Tenant string `json:"tenant,omitempty"`
 }
+
+// ErrUnknownEmailTenant is returned by Send when EmailMessage.Tenant names no
+// configured sender profile. Retrying cannot succeed.
+var ErrUnknownEmailTenant = errors.New("email: unknown tenant")
@@ -0,0 +1,8 @@
+package port
+
+// GeoIP resolves client addresses to countries
+type GeoIP interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country ip is
+	// registered in, upper case, or "" when it is unknown or ip is invalid.
+	Country(ip string) string
+}
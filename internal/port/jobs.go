@@ -0,0 +1,82 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrJobNotFound is returned by JobStore.Get for an unknown job
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStatus is the state of a tracked job
+type JobStatus string
+
+const (
+	// JobStatusQueued is a job waiting on its queue, including one waiting
+	// to be retried
+	JobStatusQueued JobStatus = "queued"
+	// JobStatusRunning is a job whose handler is running
+	JobStatusRunning JobStatus = "running"
+	// JobStatusSucceeded is a job whose handler returned no error
+	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusFailed is a job that failed permanently or exhausted its
+	// retries and was dropped
+	JobStatusFailed JobStatus = "failed"
+	// JobStatusDead is a failed job moved to its dead-letter queue
+	JobStatusDead JobStatus = "dead"
+)
+
+// Terminal reports whether the job has finished, for good or ill
+func (s JobStatus) Terminal() bool {
+	return s == JobStatusSucceeded || s == JobStatusFailed || s == JobStatusDead
+}
+
+// JobRecord is the tracked state of one job
+type JobRecord struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Queue    string    `json:"queue"`
+	Status   JobStatus `json:"status"`
+	Attempts int       `json:"attempts"`
+	MaxRetry int       `json:"max_retry"`
+	// Error is the message of the last failure. It is cleared when the job
+	// succeeds.
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// StartedAt is when the last attempt started; FinishedAt when a
+	// terminal status was reached
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// JobRecorder records job state changes
+type JobRecorder interface {
+	// Record creates the job's record or moves it to rec's state. A record
+	// keeps its first CreatedAt, a StartedAt left nil keeps the stored one,
+	// and an empty Error keeps the stored one unless the job succeeded.
+	Record(ctx context.Context, rec JobRecord) error
+}
+
+// JobStore records job state and reads it back
+type JobStore interface {
+	JobRecorder
+
+	// Get returns a job's record, or ErrJobNotFound
+	Get(ctx context.Context, id string) (*JobRecord, error)
+
+	// List returns job records matching filter, newest first
+	List(ctx context.Context, filter JobFilter) ([]JobRecord, error)
+}
+
+// JobFilter defines filters for listing jobs. Filters combine with AND;
+// empty ones match every job.
+type JobFilter struct {
+	// Statuses and Types match jobs with any of the values
+	Statuses []JobStatus
+	Types    []string
+	Limit    int
+	// Cursor is the ID of the last job of the previous page
+	Cursor string
+}
@@ -0,0 +1,26 @@
+package port
+
+import "context"
+
+// OAuthProvider signs users in with an external identity provider using the
+// OAuth2 authorization code flow with PKCE.
+type OAuthProvider interface {
+	// AuthCodeURL returns the provider's consent page. The provider redirects
+	// back with state; nonce is bound into the ID token where the provider
+	// issues one; verifier is the PKCE code verifier.
+	AuthCodeURL(state, nonce, verifier string) string
+	// Exchange redeems the code from the callback and returns the identity
+	// it proves. nonce and verifier are the values given to AuthCodeURL.
+	Exchange(ctx context.Context, code, nonce, verifier string) (*ExternalIdentity, error)
+}
+
+// ExternalIdentity is a user as known to an identity provider
+type ExternalIdentity struct {
+	// Subject is the provider's stable ID for the user. Unlike the email
+	// address it never changes.
+	Subject string
+	Email   string
+	// EmailVerified reports whether the provider has verified Email
+	EmailVerified bool
+	Name          string
+}
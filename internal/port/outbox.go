@@ -0,0 +1,14 @@
+package port
+
+import "context"
+
+// Outbox stores messages to publish in the database transaction of the
+// change they describe, so a message is published if and only if its change
+// commits. A relay publishes stored messages to the queue in order.
+type Outbox interface {
+	// Add stores a message for exchange and routingKey like Queue.Publish.
+	// It joins the transaction carried by ctx, if any. Messages without a
+	// WithPublishMessageID option get a generated ID, which the relay
+	// publishes them with.
+	Add(ctx context.Context, exchange, routingKey string, body []byte, opts ...PublishOption) error
+}
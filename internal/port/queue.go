@@ -1,11 +1,15 @@
 package port
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // Queue defines the interface for message queue operations
 type Queue interface {
 	// Publish sends a message to a queue/exchange
-	Publish(ctx context.Context, exchange, routingKey string, body []byte) error
+	Publish(ctx context.Context, exchange, routingKey string, body []byte, opts ...PublishOption) error
 
 	// PublishJSON sends a JSON-serializable message
 	PublishJSON(ctx context.Context, exchange, routingKey string, message any) error
@@ -13,7 +17,7 @@ type Queue interface {
 	// Consume starts consuming messages from a queue
 	// The handler function is called for each message
 	// Return nil to acknowledge, error to reject/requeue
-	Consume(ctx context.Context, queue string, handler func(body []byte) error) error
+	Consume(ctx context.Context, queue string, handler func(msg Message) error) error
 
 	// Ping verifies the broker connection without mutating broker state.
 	// Implementations must NOT create queues, exchanges, bindings, or any other
@@ -23,8 +27,10 @@ type Queue interface {
 	// Health probes should call Ping rather than DeclareQueue.
 	Ping(ctx context.Context) error
 
-	// DeclareQueue ensures a queue exists
-	DeclareQueue(ctx context.Context, name string, durable bool) error
+	// DeclareQueue ensures a queue exists with the given policies. Declaring
+	// an existing queue with different policies fails; the broker does not
+	// change them in place.
+	DeclareQueue(ctx context.Context, name string, durable bool, opts ...QueueOption) error
 
 	// DeclareExchange ensures an exchange exists
 	DeclareExchange(ctx context.Context, name, kind string, durable bool) error
@@ -36,11 +42,188 @@ type Queue interface {
 	Close() error
 }
 
-// Message represents a queue message
+// PublishOptions are per-message properties set by Publish.
+type PublishOptions struct {
+	// ContentType describes the body's encoding (default:
+	// application/octet-stream).
+	ContentType string
+	// Headers are set on the message as is
+	Headers map[string]any
+	// Priority orders the message on a priority queue (see
+	// WithMaxPriority); higher is delivered first. Other queues ignore it.
+	Priority uint8
+	// MessageID identifies the message, so consumers can drop a message
+	// published twice. Brokers that deduplicate by ID do so themselves.
+	MessageID string
+}
+
+// PublishOption sets a PublishOptions field for Publish.
+type PublishOption func(*PublishOptions)
+
+// WithPublishContentType sets the message's content type, which consumers
+// use to pick a decoder.
+func WithPublishContentType(contentType string) PublishOption {
+	return func(o *PublishOptions) { o.ContentType = contentType }
+}
+
+// WithPublishHeaders sets the message's headers.
+func WithPublishHeaders(headers map[string]any) PublishOption {
+	return func(o *PublishOptions) { o.Headers = headers }
+}
+
+// WithPublishPriority sets the message's priority.
+func WithPublishPriority(priority uint8) PublishOption {
+	return func(o *PublishOptions) { o.Priority = priority }
+}
+
+// WithPublishMessageID sets the message's ID.
+func WithPublishMessageID(id string) PublishOption {
+	return func(o *PublishOptions) { o.MessageID = id }
+}
+
+// ApplyPublishOptions folds opts into a PublishOptions.
+func ApplyPublishOptions(opts ...PublishOption) PublishOptions {
+	var o PublishOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ErrDelayUnsupported is returned for a delayed publish to a queue that
+// cannot hold messages back.
+var ErrDelayUnsupported = errors.New("queue: delayed delivery not supported")
+
+// DelayedPublisher publishes messages that the broker holds back for a
+// delay, so a pending delivery survives a restart of the publisher. It is
+// optional: callers type-assert a Queue for it.
+type DelayedPublisher interface {
+	// PublishDelayed publishes body like Publish, delivered to exchange with
+	// routingKey once delay has elapsed. Implementations may round delay up.
+	PublishDelayed(ctx context.Context, exchange, routingKey string, body []byte, delay time.Duration, opts ...PublishOption) error
+}
+
+// QueueOptions are broker-side queue policies set when a queue is declared.
+// Zero values keep the broker's defaults.
+type QueueOptions struct {
+	// MessageTTL expires messages that wait longer than this; expired
+	// messages are dead-lettered when a dead-letter target is set.
+	MessageTTL time.Duration
+	// MaxLength caps the ready messages; Overflow decides what happens to
+	// the next one.
+	MaxLength int
+	// Overflow is "drop-head" (broker default: drop or dead-letter the
+	// oldest), "reject-publish" or "reject-publish-dlx".
+	Overflow string
+	// DeadLetterExchange and DeadLetterRoutingKey route expired, dropped and
+	// rejected messages. An empty exchange with a routing key means the
+	// default exchange, i.e. straight to the queue of that name.
+	DeadLetterExchange   string
+	DeadLetterRoutingKey string
+	// Lazy keeps messages on disk rather than in memory (classic queues;
+	// RabbitMQ 3.12+ ignores it).
+	Lazy bool
+	// Expires deletes the queue once it has gone this long without
+	// consumers or being declared again.
+	Expires time.Duration
+	// MaxPriority makes the queue a priority queue: ready messages with a
+	// higher priority, up to this one, are delivered first.
+	MaxPriority uint8
+}
+
+// DeadLetters reports whether a dead-letter target is set.
+func (o QueueOptions) DeadLetters() bool {
+	return o.DeadLetterExchange != "" || o.DeadLetterRoutingKey != ""
+}
+
+// QueueOption sets a QueueOptions field for DeclareQueue.
+type QueueOption func(*QueueOptions)
+
+// WithMessageTTL expires messages after d.
+func WithMessageTTL(d time.Duration) QueueOption {
+	return func(o *QueueOptions) { o.MessageTTL = d }
+}
+
+// WithMaxLength caps the queue at n ready messages, applying overflow
+// ("" keeps the broker default) to the next one.
+func WithMaxLength(n int, overflow string) QueueOption {
+	return func(o *QueueOptions) {
+		o.MaxLength = n
+		o.Overflow = overflow
+	}
+}
+
+// WithDeadLetter routes expired, dropped and rejected messages to exchange
+// with routingKey.
+func WithDeadLetter(exchange, routingKey string) QueueOption {
+	return func(o *QueueOptions) {
+		o.DeadLetterExchange = exchange
+		o.DeadLetterRoutingKey = routingKey
+	}
+}
+
+// WithLazyMode keeps the queue's messages on disk.
+func WithLazyMode() QueueOption {
+	return func(o *QueueOptions) { o.Lazy = true }
+}
+
+// WithExpires deletes the queue after it has been unused for d.
+func WithExpires(d time.Duration) QueueOption {
+	return func(o *QueueOptions) { o.Expires = d }
+}
+
+// WithMaxPriority makes the queue a priority queue with priorities up to n.
+func WithMaxPriority(n uint8) QueueOption {
+	return func(o *QueueOptions) { o.MaxPriority = n }
+}
+
+// ApplyQueueOptions folds opts into a QueueOptions.
+func ApplyQueueOptions(opts ...QueueOption) QueueOptions {
+	var o QueueOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ErrQueueNotFound is returned by QueueInspector for a queue that does not
+// exist on the broker.
+var ErrQueueNotFound = errors.New("queue: not found")
+
+// QueueInspector reports queue state and purges queues. It is optional:
+// callers type-assert a Queue for it, and the no-op queue does not implement
+// it.
+type QueueInspector interface {
+	// InspectQueue returns the ready message and consumer counts of name
+	// without creating it.
+	InspectQueue(ctx context.Context, name string) (QueueStats, error)
+
+	// PurgeQueue deletes every ready message of name and returns how many it
+	// deleted. Unacknowledged deliveries are not affected.
+	PurgeQueue(ctx context.Context, name string) (int, error)
+
+	// MoveMessages takes up to limit ready messages off from, passes each
+	// through transform and publishes the result straight to queue to.
+	// limit <= 0 moves the messages ready when it starts. A message leaves
+	// from only once it is published; one that fails to transform or
+	// publish is put back and ends the move. It returns how many moved.
+	MoveMessages(ctx context.Context, from, to string, limit int, transform func(Message) (Message, error)) (int, error)
+}
+
+// QueueStats is a point-in-time view of one queue
+type QueueStats struct {
+	Name      string `json:"name"`
+	Messages  int    `json:"messages"`
+	Consumers int    `json:"consumers"`
+}
+
+// Message represents a queue message. ContentType is empty when the
+// publisher set none.
 type Message struct {
 	ID          string
 	Body        []byte
 	ContentType string
 	Headers     map[string]any
+	Priority    uint8
 	Redelivered bool
 }
@@ -1,5 +1,7 @@
 package port
 
+import "errors"
+
 // SSEBroker defines the interface for Server-Sent Events
 type SSEBroker interface {
 	// Subscribe creates a new subscription for a client
@@ -25,6 +27,40 @@ type SSEBroker interface {
 	Close() error
 }
 
+// SSEReplayer is implemented by brokers that keep recent broadcasts, so a
+// client can catch up after reconnecting or poll instead of streaming. It is
+// optional: callers type-assert an SSEBroker for it.
+type SSEReplayer interface {
+	// Replay returns up to limit buffered events after cursor, oldest
+	// first, that a client subscribed to topics would have received. An
+	// empty cursor returns no events and the current position. It returns
+	// ErrInvalidCursor for a cursor it did not issue and ErrReplayDisabled
+	// when the broker keeps no events.
+	Replay(cursor string, topics []string, limit int) (ReplayResult, error)
+}
+
+var (
+	// ErrInvalidCursor is returned by Replay for a malformed cursor
+	ErrInvalidCursor = errors.New("invalid event cursor")
+	// ErrReplayDisabled is returned by Replay when no events are kept
+	ErrReplayDisabled = errors.New("event replay is disabled")
+)
+
+// ReplayResult is the outcome of SSEReplayer.Replay
+type ReplayResult struct {
+	Events []Event
+	// Cursor resumes after the last event examined. Events filtered out by
+	// topic are skipped too, so pass Cursor back rather than the last ID.
+	Cursor string
+	// Missed reports that events after the cursor fell out of the buffer,
+	// or the broker restarted, before they could be replayed.
+	Missed bool
+	// More reports that limit cut the replay short.
+	More bool
+	// Wait is closed when the next event is buffered or the broker closes.
+	Wait <-chan struct{}
+}
+
 // Event represents an SSE event
 type Event struct {
 	ID    string `json:"id,omitempty"`
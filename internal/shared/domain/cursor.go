@@ -21,6 +21,8 @@ type Cursor struct {
 	LastID    string          `json:"last_id"`
 	LastValue any             `json:"last_value,omitempty"`
 	Direction CursorDirection `json:"direction,omitempty"`
+	// Sort is the FormatSort order the cursor was issued for, if any
+	Sort string `json:"sort,omitempty"`
 }
 
 // Encode encodes the cursor to a base64 string
@@ -54,6 +56,33 @@ func DecodeCursor(encoded string) (*Cursor, error) {
 	return &cursor, nil
 }
 
+// LastValues returns LastValue as the list of strings a sorted list stores in
+// it: the sort column values of the last row, in sort order. It fails unless
+// LastValue holds exactly n strings.
+func (c *Cursor) LastValues(n int) ([]string, error) {
+	var values []string
+	switch v := c.LastValue.(type) {
+	case []string:
+		values = v
+	case []any:
+		values = make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid cursor value %v", item)
+			}
+			values = append(values, s)
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("invalid cursor value %v", v)
+	}
+	if len(values) != n {
+		return nil, fmt.Errorf("cursor has %d sort values, want %d", len(values), n)
+	}
+	return values, nil
+}
+
 // PaginationMeta contains pagination metadata separate from the data
 type PaginationMeta struct {
 	NextCursor *string `json:"next_cursor,omitempty"`
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/perf"
+)
+
+// cursorBudgets caps allocations per op for cursor pagination, which runs
+// on every list request.
+var cursorBudgets = perf.Budgets{
+	"cursor.encode": 6,
+	"cursor.decode": 6,
+}
+
+func benchCursor() *Cursor {
+	return &Cursor{
+		LastID:    "0190f5c8-3e2b-7c4a-9a1e-4b2f6d8e0a1c",
+		LastValue: "2026-01-02T15:04:05Z",
+		Direction: CursorDirectionNext,
+	}
+}
+
+// BenchmarkCursorEncode measures JSON + base64 encoding of a typical cursor.
+func BenchmarkCursorEncode(b *testing.B) {
+	c := benchCursor()
+	b.ReportAllocs()
+	for range b.N {
+		_ = c.Encode()
+	}
+}
+
+// BenchmarkCursorDecode measures base64 + JSON decoding of a typical cursor.
+func BenchmarkCursorDecode(b *testing.B) {
+	encoded := benchCursor().Encode()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := DecodeCursor(encoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAllocBudget_Cursor(t *testing.T) {
+	c := benchCursor()
+	encoded := c.Encode()
+	cursorBudgets.Check(t, "cursor.encode", func() { _ = c.Encode() })
+	cursorBudgets.Check(t, "cursor.decode", func() { _, _ = DecodeCursor(encoded) })
+}
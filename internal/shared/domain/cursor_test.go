@@ -312,3 +312,35 @@ func TestNewBidirectionalCursorPage(t *testing.T) {
 		assert.Equal(t, CursorDirectionPrev, prevDecoded.Direction)
 	})
 }
+
+func TestCursor_LastValues(t *testing.T) {
+	t.Run("round_trips_through_encoding", func(t *testing.T) {
+		encoded := (&Cursor{LastID: "abc", LastValue: []string{"Ann", "2024-01-01T00:00:00Z"}, Sort: "name,-created_at"}).Encode()
+		decoded, err := DecodeCursor(encoded)
+		require.NoError(t, err)
+
+		values, err := decoded.LastValues(2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Ann", "2024-01-01T00:00:00Z"}, values)
+		assert.Equal(t, "name,-created_at", decoded.Sort)
+	})
+
+	t.Run("wrong_count", func(t *testing.T) {
+		_, err := (&Cursor{LastValue: []string{"Ann"}}).LastValues(2)
+		assert.Error(t, err)
+	})
+
+	t.Run("no_values", func(t *testing.T) {
+		values, err := (&Cursor{}).LastValues(0)
+		assert.NoError(t, err)
+		assert.Empty(t, values)
+	})
+
+	t.Run("not_strings", func(t *testing.T) {
+		_, err := (&Cursor{LastValue: []any{1.5}}).LastValues(1)
+		assert.Error(t, err)
+
+		_, err = (&Cursor{LastValue: "2024-01-01"}).LastValues(1)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortField is one key of a list sort order
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// MaxSortFields is the most keys a sort order may have
+const MaxSortFields = 3
+
+// ParseSort parses a comma-separated sort parameter such as
+// "name,-created_at". A leading "-" sorts that field descending. Only fields
+// in allowed are accepted, each at most once. An empty spec returns nil,
+// meaning the list's default order.
+func ParseSort(spec string, allowed ...string) ([]SortField, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) > MaxSortFields {
+		return nil, fmt.Errorf("at most %d sort fields are allowed", MaxSortFields)
+	}
+	fields := make([]SortField, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		f := SortField{Field: part}
+		if name, ok := strings.CutPrefix(part, "-"); ok {
+			f = SortField{Field: name, Desc: true}
+		}
+		if !slices.Contains(allowed, f.Field) {
+			return nil, fmt.Errorf("cannot sort by %q; sortable fields are %s", f.Field, strings.Join(allowed, ", "))
+		}
+		if slices.ContainsFunc(fields, func(g SortField) bool { return g.Field == f.Field }) {
+			return nil, fmt.Errorf("sort field %q is repeated", f.Field)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// FormatSort is the inverse of ParseSort. Cursors store it, so a cursor cannot
+// be reused with a different order.
+func FormatSort(fields []SortField) string {
+	var b strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if f.Desc {
+			b.WriteByte('-')
+		}
+		b.WriteString(f.Field)
+	}
+	return b.String()
+}
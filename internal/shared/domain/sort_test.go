@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"name", "created_at", "email", "updated_at"}
+
+	t.Run("empty", func(t *testing.T) {
+		fields, err := ParseSort("", allowed...)
+		require.NoError(t, err)
+		assert.Nil(t, fields)
+	})
+
+	t.Run("mixed_directions", func(t *testing.T) {
+		fields, err := ParseSort("name, -created_at", allowed...)
+		require.NoError(t, err)
+		assert.Equal(t, []SortField{{Field: "name"}, {Field: "created_at", Desc: true}}, fields)
+		assert.Equal(t, "name,-created_at", FormatSort(fields))
+	})
+
+	t.Run("unknown_field", func(t *testing.T) {
+		_, err := ParseSort("password_hash", allowed...)
+		assert.ErrorContains(t, err, "cannot sort by")
+	})
+
+	t.Run("repeated_field", func(t *testing.T) {
+		_, err := ParseSort("name,-name", allowed...)
+		assert.ErrorContains(t, err, "repeated")
+	})
+
+	t.Run("too_many_fields", func(t *testing.T) {
+		_, err := ParseSort("name,email,created_at,updated_at", allowed...)
+		assert.ErrorContains(t, err, "at most")
+	})
+
+	t.Run("empty_field", func(t *testing.T) {
+		_, err := ParseSort("name,", allowed...)
+		assert.Error(t, err)
+	})
+}
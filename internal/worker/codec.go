@@ -0,0 +1,319 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes jobs and their payloads for the queue. Each message carries
+// the codec's content type, so a worker decodes whatever a publisher chose
+// and one queue can mix codecs during a rollout.
+type Codec interface {
+	// Name is the codec's config name, e.g. "msgpack".
+	Name() string
+	// ContentType is set on every message the codec encodes.
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// Content types of the built-in codecs.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeMsgpack  = "application/msgpack"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Built-in codecs. JSON is the default and decodes messages without a
+// content type.
+var (
+	JSONCodec     Codec = jsonCodec{}
+	MsgpackCodec  Codec = msgpackCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+// ErrUnknownCodec is returned for a codec name or content type without a
+// codec.
+var ErrUnknownCodec = errors.New("unknown codec")
+
+// CodecByName returns the built-in codec called name: "json", "msgpack" or
+// "protobuf". An empty name is JSON.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSONCodec, nil
+	case "msgpack":
+		return MsgpackCodec, nil
+	case "protobuf":
+		return ProtobufCodec, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+}
+
+// CodecForContentType returns the codec that decodes messages of
+// contentType. Messages without one, or published as raw bytes
+// (application/octet-stream), predate codecs and are JSON.
+func CodecForContentType(contentType string) (Codec, error) {
+	mediaType := contentType
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		mediaType = parsed
+	}
+	switch mediaType {
+	case "", "application/octet-stream", ContentTypeJSON:
+		return JSONCodec, nil
+	case ContentTypeMsgpack, "application/x-msgpack", "application/vnd.msgpack":
+		return MsgpackCodec, nil
+	case ContentTypeProtobuf, "application/protobuf":
+		return ProtobufCodec, nil
+	}
+	return nil, fmt.Errorf("%w for content type %q", ErrUnknownCodec, contentType)
+}
+
+// Codecs chooses the codec each job type is published with.
+type Codecs struct {
+	// Default is used for job types missing from ByType (default: JSON).
+	Default Codec
+	ByType  map[string]Codec
+}
+
+// ParseCodecs builds Codecs from codec names, as in worker.codec and
+// worker.job_codecs.
+func ParseCodecs(def string, byType map[string]string) (Codecs, error) {
+	c, err := CodecByName(def)
+	if err != nil {
+		return Codecs{}, err
+	}
+	codecs := Codecs{Default: c, ByType: make(map[string]Codec, len(byType))}
+	for jobType, name := range byType {
+		c, err := CodecByName(name)
+		if err != nil {
+			return Codecs{}, fmt.Errorf("job type %s: %w", jobType, err)
+		}
+		codecs.ByType[jobType] = c
+	}
+	return codecs, nil
+}
+
+// For returns the codec jobType is published with.
+func (c Codecs) For(jobType string) Codec {
+	if codec, ok := c.ByType[jobType]; ok {
+		return codec
+	}
+	if c.Default != nil {
+		return c.Default
+	}
+	return JSONCodec
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) ContentType() string                { return ContentTypeJSON }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec reads the json struct tags, so payload types written for JSON
+// work unchanged.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string        { return "msgpack" }
+func (msgpackCodec) ContentType() string { return ContentTypeMsgpack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	// A raw JSON payload (e.g. from POST /api/jobs/dispatch) is re-encoded
+	// as msgpack rather than carried as opaque bytes.
+	if raw, ok := v.(json.RawMessage); ok {
+		decoded, err := decodeJSONValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: raw JSON payload: %w", err)
+		}
+		v = decoded
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// decodeJSONValue decodes raw keeping integers as int64, so they decode back
+// into integer fields.
+func decodeJSONValue(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(v), nil
+}
+
+func normalizeJSONNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, e := range t {
+			t[k] = normalizeJSONNumbers(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = normalizeJSONNumbers(e)
+		}
+	}
+	return v
+}
+
+// protobufCodec encodes the job envelope with a fixed schema and payloads
+// that are proto.Messages:
+//
+//	message Job {
+//	  string id = 1;
+//	  string type = 2;
+//	  bytes payload = 3;
+//	  int64 attempts = 4;
+//	  int64 max_retry = 5;
+//	  int64 created_at_unix_nano = 6;
+//	  int64 priority = 7;
+//	  string idempotency_key = 8;
+//	}
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string        { return "protobuf" }
+func (protobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case *Job:
+		return marshalProtoJob(t), nil
+	case proto.Message:
+		return proto.Marshal(t)
+	}
+	return nil, fmt.Errorf("protobuf: payload %T is not a proto.Message", v)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	switch t := v.(type) {
+	case *Job:
+		return unmarshalProtoJob(data, t)
+	case proto.Message:
+		return proto.Unmarshal(data, t)
+	}
+	return fmt.Errorf("protobuf: cannot decode into %T: not a proto.Message", v)
+}
+
+func marshalProtoJob(j *Job) []byte {
+	var b []byte
+	if j.ID != "" {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, j.ID)
+	}
+	if j.Type != "" {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, j.Type)
+	}
+	if len(j.Payload) > 0 {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, j.Payload)
+	}
+	if j.IdempotencyKey != "" {
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendString(b, j.IdempotencyKey)
+	}
+	for _, f := range []struct {
+		num protowire.Number
+		v   int64
+	}{
+		{4, int64(j.Attempts)},
+		{5, int64(j.MaxRetry)},
+		{6, unixNano(j.CreatedAt)},
+		{7, int64(j.Priority)},
+	} {
+		if f.v != 0 {
+			b = protowire.AppendTag(b, f.num, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(f.v))
+		}
+	}
+	return b
+}
+
+func unmarshalProtoJob(b []byte, j *Job) error {
+	*j = Job{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("protobuf: job: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		switch {
+		case typ == protowire.BytesType && (num >= 1 && num <= 3 || num == 8):
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return fmt.Errorf("protobuf: job field %d: %w", num, protowire.ParseError(n))
+			}
+			switch num {
+			case 1:
+				j.ID = string(v)
+			case 2:
+				j.Type = string(v)
+			case 3:
+				j.Payload = append([]byte(nil), v...)
+			case 8:
+				j.IdempotencyKey = string(v)
+			}
+			b = b[n:]
+		case typ == protowire.VarintType && num >= 4 && num <= 7:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return fmt.Errorf("protobuf: job field %d: %w", num, protowire.ParseError(n))
+			}
+			switch num {
+			case 4:
+				j.Attempts = int(int64(v))
+			case 5:
+				j.MaxRetry = int(int64(v))
+			case 6:
+				j.CreatedAt = time.Unix(0, int64(v))
+			case 7:
+				j.Priority = Priority(v)
+			}
+			b = b[n:]
+		default:
+			// Unknown fields are skipped, as protobuf requires.
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return fmt.Errorf("protobuf: job field %d: %w", num, protowire.ParseError(n))
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func TestCodecForContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        Codec
+	}{
+		{"", JSONCodec},
+		{"application/octet-stream", JSONCodec},
+		{"application/json; charset=utf-8", JSONCodec},
+		{"application/msgpack", MsgpackCodec},
+		{"application/x-msgpack", MsgpackCodec},
+		{"application/vnd.msgpack", MsgpackCodec},
+		{"application/x-protobuf", ProtobufCodec},
+	}
+	for _, tt := range tests {
+		got, err := CodecForContentType(tt.contentType)
+		require.NoError(t, err, tt.contentType)
+		assert.Equal(t, tt.want, got, tt.contentType)
+	}
+
+	_, err := CodecForContentType("text/xml")
+	assert.True(t, errors.Is(err, ErrUnknownCodec))
+}
+
+func TestParseCodecs(t *testing.T) {
+	codecs, err := ParseCodecs("msgpack", map[string]string{"audit.cleanup": "protobuf"})
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufCodec, codecs.For("audit.cleanup"))
+	assert.Equal(t, MsgpackCodec, codecs.For("email.send"))
+	assert.Equal(t, JSONCodec, Codecs{}.For("email.send"))
+
+	_, err = ParseCodecs("", map[string]string{"audit.cleanup": "avro"})
+	assert.ErrorContains(t, err, "audit.cleanup")
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			job, err := NewJobWithCodec("test.codec", testPayload{Name: "round", Count: 7}, codec)
+			require.NoError(t, err)
+			job.Attempts = 2
+			data, err := job.Encode()
+			require.NoError(t, err)
+
+			decoded, err := DecodeMessage(port.Message{Body: data, ContentType: codec.ContentType()})
+			require.NoError(t, err)
+			assert.Equal(t, job.ID, decoded.ID)
+			assert.Equal(t, 2, decoded.Attempts)
+			assert.True(t, job.CreatedAt.Equal(decoded.CreatedAt))
+			assert.Equal(t, codec, decoded.Codec())
+
+			var p testPayload
+			require.NoError(t, decoded.UnmarshalPayload(&p))
+			assert.Equal(t, testPayload{Name: "round", Count: 7}, p)
+		})
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		job, err := NewJobWithCodec("test.codec", wrapperspb.String("round"), ProtobufCodec)
+		require.NoError(t, err)
+		job.Attempts = -1
+		data, err := job.Encode()
+		require.NoError(t, err)
+
+		decoded, err := DecodeMessage(port.Message{Body: data, ContentType: ContentTypeProtobuf})
+		require.NoError(t, err)
+		assert.Equal(t, job.ID, decoded.ID)
+		assert.Equal(t, -1, decoded.Attempts)
+		assert.Equal(t, 3, decoded.MaxRetry)
+		assert.True(t, job.CreatedAt.Equal(decoded.CreatedAt))
+
+		var p wrapperspb.StringValue
+		require.NoError(t, decoded.UnmarshalPayload(&p))
+		assert.Equal(t, "round", p.GetValue())
+	})
+}
+
+func TestMsgpackCodec_RawJSONPayload(t *testing.T) {
+	job, err := NewJobWithCodec("test.codec", json.RawMessage(`{"name":"raw","count":3}`), MsgpackCodec)
+	require.NoError(t, err)
+
+	var p testPayload
+	require.NoError(t, job.UnmarshalPayload(&p))
+	assert.Equal(t, testPayload{Name: "raw", Count: 3}, p)
+}
+
+func TestMsgpackCodec_Smaller(t *testing.T) {
+	payload := map[string]any{"user_id": 12345, "emails": []string{"a@example.com", "b@example.com"}, "active": true}
+	asJSON, err := NewJobWithCodec("users.notify", payload, JSONCodec)
+	require.NoError(t, err)
+	asMsgpack, err := NewJobWithCodec("users.notify", payload, MsgpackCodec)
+	require.NoError(t, err)
+
+	j, err := asJSON.Encode()
+	require.NoError(t, err)
+	m, err := asMsgpack.Encode()
+	require.NoError(t, err)
+	assert.Less(t, len(m), len(j))
+}
+
+func TestProtobufCodec(t *testing.T) {
+	t.Run("payload must be a proto message", func(t *testing.T) {
+		_, err := NewJobWithCodec("test.codec", testPayload{}, ProtobufCodec)
+		assert.ErrorContains(t, err, "not a proto.Message")
+	})
+
+	t.Run("unknown fields are skipped", func(t *testing.T) {
+		job := &Job{ID: "id-1", Type: "test.codec", MaxRetry: 3, CreatedAt: time.Unix(100, 0)}
+		data := marshalProtoJob(job)
+		data = protowire.AppendTag(data, 99, protowire.BytesType)
+		data = protowire.AppendString(data, "added later")
+
+		var decoded Job
+		require.NoError(t, ProtobufCodec.Unmarshal(data, &decoded))
+		assert.Equal(t, "id-1", decoded.ID)
+		assert.Equal(t, 3, decoded.MaxRetry)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := marshalProtoJob(&Job{ID: "id-1"})
+		var decoded Job
+		assert.Error(t, ProtobufCodec.Unmarshal(data[:len(data)-1], &decoded))
+	})
+}
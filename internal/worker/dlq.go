@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+)
+
+// DeadLetterSuffix is appended to a job queue's name to name its dead-letter
+// queue, e.g. jobs.dlq
+const DeadLetterSuffix = ".dlq"
+
+// Headers a dead-lettered job is published with, recording why it failed
+const (
+	HeaderFailedQueue    = "x-failed-queue"
+	HeaderFailedError    = "x-failed-error"
+	HeaderFailedKind     = "x-failed-kind" // "permanent" or "exhausted"
+	HeaderFailedAttempts = "x-failed-attempts"
+	HeaderFailedAt       = "x-failed-at" // RFC 3339
+)
+
+// DeadLetterQueueName returns the dead-letter queue of a job queue
+func DeadLetterQueueName(queue string) string {
+	return queue + DeadLetterSuffix
+}
+
+// publishDeadLetter publishes a failed job to the dead-letter queue of queue, with
+// the failure recorded in its headers, and reports whether it did. The job is
+// acknowledged either way, so a publish failure loses it as it did before
+// dead-lettering.
+func (w *Worker) publishDeadLetter(queue string, job *Job, cause error) bool {
+	data, err := job.Encode()
+	if err != nil {
+		w.logger.Error("Failed to encode job for dead-lettering", "error", err, "job_id", job.ID)
+		return false
+	}
+
+	kind := "exhausted"
+	if joberr.Classify(cause) == joberr.KindPermanent {
+		kind = "permanent"
+	}
+	headers := map[string]any{
+		HeaderFailedQueue:    queue,
+		HeaderFailedError:    cause.Error(),
+		HeaderFailedKind:     kind,
+		HeaderFailedAttempts: int64(job.Attempts),
+		HeaderFailedAt:       time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// The default exchange routes straight to the queue named by the key
+	dlq := DeadLetterQueueName(queue)
+	opts := append(job.publishOptions(), port.WithPublishHeaders(headers))
+	if err := w.queue.Publish(w.ctx, "", dlq, data, opts...); err != nil {
+		w.logger.Error("Failed to dead-letter job", "error", err, "job_id", job.ID, "queue", dlq)
+		return false
+	}
+	w.logger.Info("Dead-lettered job", "job_id", job.ID, "job_type", job.Type, "queue", dlq)
+	return true
+}
+
+// RequeueDeadLetter prepares a dead-lettered message to go back on its job
+// queue: the job's attempts are reset, so it gets every retry again, and the
+// failure headers are dropped. Pass it to port.QueueInspector.MoveMessages.
+func RequeueDeadLetter(msg port.Message) (port.Message, error) {
+	job, err := DecodeMessage(msg)
+	if err != nil {
+		return port.Message{}, fmt.Errorf("decode dead-lettered job: %w", err)
+	}
+	job.Attempts = 0
+	body, err := job.Encode()
+	if err != nil {
+		return port.Message{}, fmt.Errorf("encode job: %w", err)
+	}
+
+	var headers map[string]any
+	for k, v := range msg.Headers {
+		if strings.HasPrefix(k, "x-failed-") {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]any)
+		}
+		headers[k] = v
+	}
+	return port.Message{ID: msg.ID, Body: body, ContentType: job.Codec().ContentType(), Headers: headers, Priority: msg.Priority}, nil
+}
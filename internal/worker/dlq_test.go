@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart_DeclaresDeadLetterQueues(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{
+		Queues:       []string{"jobs", "emails"},
+		QueueOptions: []port.QueueOption{port.WithMessageTTL(time.Hour)},
+		DeadLetter:   true,
+	})
+	require.NoError(t, w.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+
+	assert.Equal(t, []string{"jobs", "jobs.dlq", "emails", "emails.dlq"}, q.declared)
+	assert.Zero(t, q.declaredOpts[1].MessageTTL, "dead-letter queues take no policies")
+}
+
+func TestHandleMessage_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		kind     string
+	}{
+		{name: "exhausted", attempts: 2, err: errors.New("smtp down"), kind: "exhausted"},
+		{name: "permanent", attempts: 0, err: joberr.Permanentf("recipient is required"), kind: "permanent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &mockQueue{}
+			w := New(q, newTestLogger(), Config{Queues: []string{"emails"}, Exchange: "jobs-x", DeadLetter: true})
+			w.RegisterHandler(&testHandler{
+				jobType:  "email.send",
+				handleFn: func(_ context.Context, _ *Job) error { return tt.err },
+			})
+
+			job, _ := NewJob("email.send", "data")
+			job.Attempts = tt.attempts
+			data, _ := job.Encode()
+			require.NoError(t, w.handleMessage(0, "emails", port.Message{Body: data}))
+
+			require.Len(t, q.publishCalls, 1)
+			call := q.lastCall()
+			assert.Equal(t, "", call.exchange, "published through the default exchange")
+			assert.Equal(t, "emails.dlq", call.routingKey)
+			assert.Equal(t, ContentTypeJSON, call.contentType)
+			assert.Equal(t, "emails", call.headers[HeaderFailedQueue])
+			assert.Equal(t, tt.err.Error(), call.headers[HeaderFailedError])
+			assert.Equal(t, tt.kind, call.headers[HeaderFailedKind])
+			assert.Equal(t, int64(tt.attempts+1), call.headers[HeaderFailedAttempts])
+			_, err := time.Parse(time.RFC3339, call.headers[HeaderFailedAt].(string))
+			assert.NoError(t, err)
+
+			dead, err := DecodeMessage(port.Message{Body: call.body, ContentType: call.contentType})
+			require.NoError(t, err)
+			assert.Equal(t, job.ID, dead.ID)
+		})
+	}
+}
+
+func TestHandleMessage_DeadLetterOffDrops(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{})
+	w.RegisterHandler(&testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { return joberr.Permanentf("bad payload") },
+	})
+
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	assert.Empty(t, q.publishCalls)
+}
+
+func TestRequeueDeadLetter(t *testing.T) {
+	job, _ := NewJobWithCodec("email.send", testPayload{Name: "x"}, MsgpackCodec)
+	job.Attempts = 3
+	data, _ := job.Encode()
+
+	msg, err := RequeueDeadLetter(port.Message{
+		ID:          "m-1",
+		Body:        data,
+		ContentType: ContentTypeMsgpack,
+		Priority:    uint8(PriorityHigh),
+		Headers: map[string]any{
+			HeaderFailedQueue: "jobs",
+			HeaderFailedError: "smtp down",
+			"x-tenant":        "acme",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "m-1", msg.ID)
+	assert.Equal(t, ContentTypeMsgpack, msg.ContentType)
+	assert.Equal(t, map[string]any{"x-tenant": "acme"}, msg.Headers)
+	assert.Equal(t, uint8(PriorityHigh), msg.Priority, "the requeued message keeps its priority")
+
+	requeued, err := DecodeMessage(msg)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, requeued.ID)
+	assert.Equal(t, 0, requeued.Attempts)
+
+	_, err = RequeueDeadLetter(port.Message{Body: []byte("{"), ContentType: ContentTypeJSON})
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// dryRun tracks a dry run's single pass over each queue. Every message is
+// published back to the tail of its queue, so seeing one a second time means
+// everything ahead of it has been seen: the pass over that queue is complete
+// and its consumers stop.
+type dryRun struct {
+	mu     sync.Mutex
+	queues map[string]*dryRunQueue
+	left   int
+	done   chan struct{}
+}
+
+type dryRunQueue struct {
+	seen     map[string]bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	finished bool
+}
+
+func newDryRun(parent context.Context, queues []string) *dryRun {
+	d := &dryRun{
+		queues: make(map[string]*dryRunQueue, len(queues)),
+		done:   make(chan struct{}),
+	}
+	for _, name := range queues {
+		if _, ok := d.queues[name]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(parent)
+		d.queues[name] = &dryRunQueue{seen: make(map[string]bool), ctx: ctx, cancel: cancel}
+	}
+	d.left = len(d.queues)
+	return d
+}
+
+// context returns the context consumers of queue run under; it is cancelled
+// when the pass over queue completes.
+func (d *dryRun) context(queue string) context.Context {
+	return d.queues[queue].ctx
+}
+
+// observe records the message identified by key on queue and reports whether
+// it was seen before, and whether that completed the pass over queue.
+func (d *dryRun) observe(queue, key string) (repeat, completed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	q := d.queues[queue]
+	if q.finished {
+		return true, false
+	}
+	if !q.seen[key] {
+		q.seen[key] = true
+		return false, false
+	}
+	q.finished = true
+	q.cancel()
+	d.left--
+	if d.left == 0 {
+		close(d.done)
+	}
+	return true, true
+}
+
+// seen returns how many distinct messages were seen on queue.
+func (d *dryRun) seen(queue string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queues[queue].seen)
+}
+
+// DryRunDone is closed once a dry run has passed over every queue. It is nil,
+// and so never ready, when the worker is not in dry-run mode. A dry run over
+// an empty queue waits for a message to arrive.
+func (w *Worker) DryRunDone() <-chan struct{} {
+	if w.dryRun == nil {
+		return nil
+	}
+	return w.dryRun.done
+}
+
+// inspectMessage logs msg for a dry run and publishes it back to the tail of
+// queue unchanged. The publish happens before the delivery is acknowledged,
+// so a failed publish requeues the message instead of losing it.
+func (w *Worker) inspectMessage(queue string, msg port.Message) error {
+	job, err := DecodeMessage(msg)
+	key := string(msg.Body)
+	if err == nil && job.ID != "" {
+		key = job.ID
+	}
+
+	repeat, completed := w.dryRun.observe(queue, key)
+	switch {
+	case completed:
+		w.logger.Info("Dry run: pass over queue complete", "queue", queue, "messages", w.dryRun.seen(queue))
+	case repeat:
+		// Logged on the first pass.
+	case err != nil:
+		w.logger.Warn("Dry run: undecodable message", "queue", queue, "error", err, "content_type", msg.ContentType, "bytes", len(msg.Body))
+	case w.only == nil || w.only[job.Type]:
+		w.mu.RLock()
+		_, hasHandler := w.handlers[job.Type]
+		w.mu.RUnlock()
+		w.logger.Info("Dry run: job",
+			"queue", queue,
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"attempts", job.Attempts,
+			"max_retry", job.MaxRetry,
+			"created_at", job.CreatedAt,
+			"has_handler", hasHandler,
+			"codec", job.Codec().Name(),
+			"payload", payloadForLog(job),
+		)
+	}
+
+	if err := w.queue.Publish(w.ctx, w.exchange, queue, msg.Body,
+		port.WithPublishContentType(msg.ContentType), port.WithPublishPriority(msg.Priority)); err != nil {
+		return fmt.Errorf("dry run: put message back on %s: %w", queue, err)
+	}
+	return nil
+}
+
+// payloadForLog renders a job payload for the dry-run log: JSON as is, other
+// codecs decoded generically where they can be.
+func payloadForLog(job *Job) string {
+	if job.Codec() == JSONCodec {
+		return string(job.Payload)
+	}
+	var v any
+	if err := job.UnmarshalPayload(&v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("<%d bytes of %s>", len(job.Payload), job.Codec().Name())
+}
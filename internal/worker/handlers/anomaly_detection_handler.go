@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// Security alert kinds
+const (
+	AlertLoginSpike       = "login_spike"        // far more logins than usual
+	AlertUnusualAdminHour = "admin_unusual_hour" // an admin active at an hour they normally are not
+	AlertMassDeletion     = "mass_deletion"      // a user deleting far more than they usually do
+)
+
+// Security alert severities
+const (
+	AlertSeverityMedium = "medium"
+	AlertSeverityHigh   = "high"
+)
+
+const (
+	unusualHourShare       = 0.02 // an hour holding under 2% of an admin's usual activity is unusual
+	minAdminBaselineEvents = 20   // admins with less history are not judged
+)
+
+// AnomalyDetectionPayload tunes an anomaly detection run. Every field is
+// optional.
+type AnomalyDetectionPayload struct {
+	WindowMinutes int     `json:"window_minutes"` // analysed window, ending at the last full window; default: 60
+	BaselineDays  int     `json:"baseline_days"`  // history the window is compared with; default: 14
+	Threshold     float64 `json:"threshold"`      // standard deviations above the mean that count as a spike; default: 3
+	MinLogins     int     `json:"min_logins"`     // fewer logins are never a spike; default: 20
+	MinDeletes    int     `json:"min_deletes"`    // fewer deletions by one user are never a mass deletion; default: 25
+	DryRun        bool    `json:"dry_run"`        // log findings, store and send nothing
+}
+
+// SecurityAlert is one finding of an anomaly detection run
+type SecurityAlert struct {
+	Kind        string
+	Severity    string
+	UserID      string // the user the finding is about, if any
+	Summary     string
+	Details     map[string]any
+	WindowStart time.Time
+	WindowEnd   time.Time
+}
+
+// AlertRecipient is an admin notified of new alerts
+type AlertRecipient struct {
+	UserID string
+	Email  string
+	Name   string
+}
+
+// AnomalyStore is the data the detection job reads and writes.
+// *PostgresAnomalyStore satisfies it.
+type AnomalyStore interface {
+	// CountByUser counts the audit entries with action in each of buckets
+	// consecutive buckets of width bucket starting at since, per user ID
+	// ("" for entries without a user).
+	CountByUser(ctx context.Context, action port.AuditAction, since time.Time, bucket time.Duration, buckets int) (map[string][]int, error)
+	// HourlyActivity counts the mutating audit entries of each of userIDs
+	// between since and until by UTC hour of day.
+	HourlyActivity(ctx context.Context, userIDs []string, since, until time.Time) (map[string][24]int, error)
+	// Admins lists the active admins.
+	Admins(ctx context.Context) ([]AlertRecipient, error)
+	// SaveAlert stores alert and reports whether it is new; the same kind,
+	// user and window is only stored once.
+	SaveAlert(ctx context.Context, alert SecurityAlert) (bool, error)
+}
+
+// AnomalyDetectionHandler compares recent audit activity with a statistical
+// baseline and raises security alerts for login spikes, admin activity at
+// unusual hours and mass deletions. Admins are emailed the new alerts.
+type AnomalyDetectionHandler struct {
+	store       AnomalyStore
+	emailSender port.EmailSender
+	logger      *logger.Logger
+	now         func() time.Time
+}
+
+// NewAnomalyDetectionHandler creates a new anomaly detection handler
+func NewAnomalyDetectionHandler(store AnomalyStore, emailSender port.EmailSender, log *logger.Logger) *AnomalyDetectionHandler {
+	return &AnomalyDetectionHandler{
+		store:       store,
+		emailSender: emailSender,
+		logger:      log,
+		now:         time.Now,
+	}
+}
+
+// Type returns the job type this handler processes
+func (h *AnomalyDetectionHandler) Type() string {
+	return worker.JobTypeAnomalyDetection
+}
+
+// Handle analyses the last full window. Windows are aligned to their length,
+// so runs within the same window find the same alerts, and stored alerts
+// are not stored or sent again.
+func (h *AnomalyDetectionHandler) Handle(ctx context.Context, job *worker.Job) error {
+	var payload AnomalyDetectionPayload
+	if err := job.UnmarshalPayload(&payload); err != nil {
+		return joberr.Permanentf("failed to unmarshal anomaly detection payload: %w", err)
+	}
+	if payload.WindowMinutes <= 0 {
+		payload.WindowMinutes = 60
+	}
+	if payload.BaselineDays <= 0 {
+		payload.BaselineDays = 14
+	}
+	if payload.Threshold <= 0 {
+		payload.Threshold = 3
+	}
+	if payload.MinLogins <= 0 {
+		payload.MinLogins = 20
+	}
+	if payload.MinDeletes <= 0 {
+		payload.MinDeletes = 25
+	}
+
+	window := time.Duration(payload.WindowMinutes) * time.Minute
+	buckets := int(time.Duration(payload.BaselineDays) * 24 * time.Hour / window)
+	if buckets < 2 {
+		return joberr.Permanentf("baseline of %d days is too short for a %s window", payload.BaselineDays, window)
+	}
+	end := h.now().UTC().Truncate(window)
+	start := end.Add(-window)
+	baselineStart := start.Add(-window * time.Duration(buckets))
+
+	h.logger.Info("Starting anomaly detection",
+		"window_start", start.Format(time.RFC3339),
+		"window_end", end.Format(time.RFC3339),
+		"baseline_days", payload.BaselineDays,
+		"dry_run", payload.DryRun,
+		"job_id", job.ID,
+	)
+
+	var alerts []SecurityAlert
+	for _, detect := range []func(context.Context, AnomalyDetectionPayload, time.Time, time.Time, int) ([]SecurityAlert, error){
+		h.detectLoginSpike,
+		h.detectMassDeletions,
+		h.detectUnusualAdminHours,
+	} {
+		found, err := detect(ctx, payload, baselineStart, start, buckets)
+		if err != nil {
+			return err
+		}
+		alerts = append(alerts, found...)
+	}
+
+	var fresh []SecurityAlert
+	for _, a := range alerts {
+		a.WindowStart, a.WindowEnd = start, end
+		h.logger.Warn("Security anomaly detected",
+			"kind", a.Kind,
+			"severity", a.Severity,
+			"user_id", a.UserID,
+			"summary", a.Summary,
+			"dry_run", payload.DryRun,
+			"job_id", job.ID,
+		)
+		if payload.DryRun {
+			continue
+		}
+		isNew, err := h.store.SaveAlert(ctx, a)
+		if err != nil {
+			return fmt.Errorf("failed to save security alert: %w", err)
+		}
+		if isNew {
+			fresh = append(fresh, a)
+		}
+	}
+
+	if len(fresh) > 0 {
+		// The alerts are stored either way; a retry would find them stored
+		// and send nothing, so a failed notification is only logged.
+		if err := h.notify(ctx, fresh); err != nil {
+			h.logger.Error("Failed to notify admins of security alerts", "alerts", len(fresh), "error", err, "job_id", job.ID)
+		}
+	}
+
+	h.logger.Info("Anomaly detection completed",
+		"alerts", len(alerts),
+		"new_alerts", len(fresh),
+		"job_id", job.ID,
+	)
+	return nil
+}
+
+// detectLoginSpike compares the logins in the window, successful or not,
+// with the logins in each window of the baseline.
+func (h *AnomalyDetectionHandler) detectLoginSpike(ctx context.Context, p AnomalyDetectionPayload, baselineStart, _ time.Time, buckets int) ([]SecurityAlert, error) {
+	byUser, err := h.store.CountByUser(ctx, port.AuditActionLogin, baselineStart, windowOf(p), buckets+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count logins: %w", err)
+	}
+	total := make([]int, buckets+1)
+	for _, counts := range byUser {
+		for i, n := range counts {
+			total[i] += n
+		}
+	}
+
+	count := total[buckets]
+	mean, limit, spike := isSpike(total[:buckets], count, p.Threshold, p.MinLogins)
+	if !spike {
+		return nil, nil
+	}
+	return []SecurityAlert{{
+		Kind:     AlertLoginSpike,
+		Severity: spikeSeverity(count, mean, limit),
+		Summary:  fmt.Sprintf("%d logins in %s, usually %.1f", count, windowOf(p), mean),
+		Details:  map[string]any{"logins": count, "baseline_mean": round(mean), "limit": round(limit)},
+	}}, nil
+}
+
+// detectMassDeletions compares each user's deletions in the window with
+// their own deletions in each window of the baseline.
+func (h *AnomalyDetectionHandler) detectMassDeletions(ctx context.Context, p AnomalyDetectionPayload, baselineStart, _ time.Time, buckets int) ([]SecurityAlert, error) {
+	byUser, err := h.store.CountByUser(ctx, port.AuditActionDelete, baselineStart, windowOf(p), buckets+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count deletions: %w", err)
+	}
+
+	var alerts []SecurityAlert
+	for _, userID := range sortedKeys(byUser) {
+		counts := byUser[userID]
+		count := counts[buckets]
+		mean, limit, spike := isSpike(counts[:buckets], count, p.Threshold, p.MinDeletes)
+		if userID == "" || !spike {
+			continue
+		}
+		alerts = append(alerts, SecurityAlert{
+			Kind:     AlertMassDeletion,
+			Severity: AlertSeverityHigh,
+			UserID:   userID,
+			Summary:  fmt.Sprintf("user %s deleted %d records in %s, usually %.1f", userID, count, windowOf(p), mean),
+			Details:  map[string]any{"deletions": count, "baseline_mean": round(mean), "limit": round(limit)},
+		})
+	}
+	return alerts, nil
+}
+
+// detectUnusualAdminHours flags admins active in the window at an hour of
+// day (UTC) that, with its neighbours, holds under 2% of their activity in
+// the baseline.
+func (h *AnomalyDetectionHandler) detectUnusualAdminHours(ctx context.Context, p AnomalyDetectionPayload, baselineStart, start time.Time, _ int) ([]SecurityAlert, error) {
+	admins, err := h.store.Admins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admins: %w", err)
+	}
+	if len(admins) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(admins))
+	for i, a := range admins {
+		ids[i] = a.UserID
+	}
+
+	baseline, err := h.store.HourlyActivity(ctx, ids, baselineStart, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin activity baseline: %w", err)
+	}
+	current, err := h.store.HourlyActivity(ctx, ids, start, start.Add(windowOf(p)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin activity: %w", err)
+	}
+
+	var alerts []SecurityAlert
+	for _, a := range admins {
+		usual := baseline[a.UserID]
+		total := 0
+		for _, n := range usual {
+			total += n
+		}
+		if total < minAdminBaselineEvents {
+			continue
+		}
+
+		var hours []int
+		actions := 0
+		for hour, n := range current[a.UserID] {
+			if n == 0 {
+				continue
+			}
+			near := usual[(hour+23)%24] + usual[hour] + usual[(hour+1)%24]
+			if float64(near) < unusualHourShare*float64(total) {
+				hours = append(hours, hour)
+				actions += n
+			}
+		}
+		if len(hours) == 0 {
+			continue
+		}
+		alerts = append(alerts, SecurityAlert{
+			Kind:     AlertUnusualAdminHour,
+			Severity: AlertSeverityMedium,
+			UserID:   a.UserID,
+			Summary:  fmt.Sprintf("admin %s made %d changes at unusual hours (UTC) %v", a.Email, actions, hours),
+			Details:  map[string]any{"hours_utc": hours, "actions": actions, "baseline_actions": total},
+		})
+	}
+	return alerts, nil
+}
+
+// notify emails the admins a digest of alerts.
+func (h *AnomalyDetectionHandler) notify(ctx context.Context, alerts []SecurityAlert) error {
+	admins, err := h.store.Admins(ctx)
+	if err != nil {
+		return err
+	}
+	to := make([]string, 0, len(admins))
+	for _, a := range admins {
+		to = append(to, a.Email)
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no active admins to notify")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Anomaly detection raised %d new security alert(s) for %s to %s (UTC):\n\n",
+		len(alerts), alerts[0].WindowStart.Format(time.RFC3339), alerts[0].WindowEnd.Format(time.RFC3339))
+	for _, a := range alerts {
+		fmt.Fprintf(&body, "- [%s] %s: %s\n", a.Severity, a.Kind, a.Summary)
+	}
+	body.WriteString("\nThe alerts are stored in the security_alerts table.")
+
+	return h.emailSender.Send(ctx, port.EmailMessage{
+		To:      to,
+		Subject: fmt.Sprintf("Security alerts: %d new finding(s)", len(alerts)),
+		Body:    body.String(),
+	})
+}
+
+// isSpike reports whether count is at least minCount and more than
+// threshold standard deviations above the mean of baseline. The deviation
+// is at least the square root of the mean (the spread of a Poisson count)
+// and at least 1, so a flat or empty baseline does not flag every blip.
+func isSpike(baseline []int, count int, threshold float64, minCount int) (mean, limit float64, spike bool) {
+	for _, n := range baseline {
+		mean += float64(n)
+	}
+	mean /= float64(len(baseline))
+
+	var variance float64
+	for _, n := range baseline {
+		d := float64(n) - mean
+		variance += d * d
+	}
+	sd := math.Max(math.Sqrt(variance/float64(len(baseline))), math.Max(math.Sqrt(mean), 1))
+
+	limit = mean + threshold*sd
+	return mean, limit, count >= minCount && float64(count) > limit
+}
+
+// spikeSeverity is high when count is more than twice as far above the mean
+// as the limit.
+func spikeSeverity(count int, mean, limit float64) string {
+	if float64(count)-mean > 2*(limit-mean) {
+		return AlertSeverityHigh
+	}
+	return AlertSeverityMedium
+}
+
+func windowOf(p AnomalyDetectionPayload) time.Duration {
+	return time.Duration(p.WindowMinutes) * time.Minute
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+func sortedKeys(m map[string][]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresAnomalyStore reads audit_logs and writes security_alerts for
+// AnomalyDetectionHandler.
+type PostgresAnomalyStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresAnomalyStore creates an anomaly store on pool
+func NewPostgresAnomalyStore(pool *pgxpool.Pool) *PostgresAnomalyStore {
+	return &PostgresAnomalyStore{pool: pool}
+}
+
+var _ AnomalyStore = (*PostgresAnomalyStore)(nil)
+
+// CountByUser implements AnomalyStore
+func (s *PostgresAnomalyStore) CountByUser(ctx context.Context, action port.AuditAction, since time.Time, bucket time.Duration, buckets int) (map[string][]int, error) {
+	until := since.Add(bucket * time.Duration(buckets))
+	rows, err := s.pool.Query(ctx, `
+		SELECT COALESCE(user_id::text, ''),
+		       floor(extract(epoch FROM created_at - $2)::float8 / $4::float8)::int AS bucket,
+		       count(*)
+		FROM audit_logs
+		WHERE action = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY 1, 2`,
+		string(action), since, until, bucket.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("count %s entries: %w", action, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string][]int)
+	for rows.Next() {
+		var userID string
+		var b, n int
+		if err := rows.Scan(&userID, &b, &n); err != nil {
+			return nil, fmt.Errorf("scan %s count: %w", action, err)
+		}
+		if b < 0 || b >= buckets {
+			continue
+		}
+		if counts[userID] == nil {
+			counts[userID] = make([]int, buckets)
+		}
+		counts[userID][b] += n
+	}
+	return counts, rows.Err()
+}
+
+// HourlyActivity implements AnomalyStore
+func (s *PostgresAnomalyStore) HourlyActivity(ctx context.Context, userIDs []string, since, until time.Time) (map[string][24]int, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT user_id::text, extract(hour FROM created_at AT TIME ZONE 'UTC')::int, count(*)
+		FROM audit_logs
+		WHERE user_id::text = ANY($1) AND action = ANY($2)
+		  AND created_at >= $3 AND created_at < $4
+		GROUP BY 1, 2`,
+		userIDs, mutatingActions, since, until,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("hourly activity: %w", err)
+	}
+	defer rows.Close()
+
+	hours := make(map[string][24]int)
+	for rows.Next() {
+		var userID string
+		var hour, n int
+		if err := rows.Scan(&userID, &hour, &n); err != nil {
+			return nil, fmt.Errorf("scan hourly activity: %w", err)
+		}
+		h := hours[userID]
+		h[hour%24] += n
+		hours[userID] = h
+	}
+	return hours, rows.Err()
+}
+
+// mutatingActions are the audit actions that count as admin activity
+var mutatingActions = []string{
+	string(port.AuditActionCreate),
+	string(port.AuditActionUpdate),
+	string(port.AuditActionDelete),
+	string(port.AuditActionMerge),
+}
+
+// Admins implements AnomalyStore. Admins are the active users granted the
+// admin or superadmin role in the Casbin policy.
+func (s *PostgresAnomalyStore) Admins(ctx context.Context) ([]AlertRecipient, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT u.id::text, u.email, u.name
+		FROM casbin_rules r
+		JOIN users u ON u.id::text = r.v0
+		WHERE r.p_type = 'g' AND r.v1 = ANY($1) AND u.is_active = true
+		ORDER BY u.email`,
+		[]string{port.RoleAdmin, port.RoleSuperAdmin},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list admins: %w", err)
+	}
+	defer rows.Close()
+
+	var admins []AlertRecipient
+	for rows.Next() {
+		var a AlertRecipient
+		if err := rows.Scan(&a.UserID, &a.Email, &a.Name); err != nil {
+			return nil, fmt.Errorf("scan admin: %w", err)
+		}
+		admins = append(admins, a)
+	}
+	return admins, rows.Err()
+}
+
+// SaveAlert implements AnomalyStore
+func (s *PostgresAnomalyStore) SaveAlert(ctx context.Context, alert SecurityAlert) (bool, error) {
+	var details []byte
+	if alert.Details != nil {
+		var err error
+		if details, err = json.Marshal(alert.Details); err != nil {
+			return false, fmt.Errorf("marshal alert details: %w", err)
+		}
+	}
+	var userID any
+	if alert.UserID != "" {
+		userID = alert.UserID
+	}
+
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO security_alerts (kind, severity, user_id, summary, details, window_start, window_end)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, (COALESCE(user_id::text, '')), window_start) DO NOTHING`,
+		alert.Kind, alert.Severity, userID, alert.Summary, details, alert.WindowStart, alert.WindowEnd,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert security alert: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
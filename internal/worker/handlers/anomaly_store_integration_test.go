@@ -0,0 +1,100 @@
+//go:build integration
+
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresAnomalyStore(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+	store := NewPostgresAnomalyStore(pool)
+
+	var adminID string
+	require.NoError(t, pool.QueryRow(ctx,
+		`INSERT INTO users (email, password_hash, name) VALUES ('anomaly-admin@example.com', 'x', 'Admin') RETURNING id::text`,
+	).Scan(&adminID))
+	_, err = pool.Exec(ctx, `INSERT INTO casbin_rules (p_type, v0, v1) VALUES ('g', $1, 'admin')`, adminID)
+	require.NoError(t, err)
+
+	since := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	for _, e := range []struct {
+		action port.AuditAction
+		userID any
+		at     time.Time
+	}{
+		{port.AuditActionLogin, nil, since.Add(10 * time.Minute)},
+		{port.AuditActionLogin, nil, since.Add(70 * time.Minute)},
+		{port.AuditActionLogin, nil, since.Add(80 * time.Minute)},
+		{port.AuditActionDelete, adminID, since.Add(3*time.Hour + time.Minute)},
+		{port.AuditActionLogin, nil, since.Add(-time.Minute)}, // before the range
+	} {
+		_, err := pool.Exec(ctx,
+			`INSERT INTO audit_logs (user_id, action, resource, created_at) VALUES ($1, $2, 'user', $3)`,
+			e.userID, string(e.action), e.at)
+		require.NoError(t, err)
+	}
+
+	t.Run("CountByUser", func(t *testing.T) {
+		logins, err := store.CountByUser(ctx, port.AuditActionLogin, since, time.Hour, 4)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]int{"": {1, 2, 0, 0}}, logins)
+
+		deletes, err := store.CountByUser(ctx, port.AuditActionDelete, since, time.Hour, 4)
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 0, 0, 1}, deletes[adminID])
+	})
+
+	t.Run("HourlyActivity", func(t *testing.T) {
+		hours, err := store.HourlyActivity(ctx, []string{adminID}, since, since.Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, hours[adminID][3])
+	})
+
+	t.Run("Admins", func(t *testing.T) {
+		admins, err := store.Admins(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, admins, AlertRecipient{UserID: adminID, Email: "anomaly-admin@example.com", Name: "Admin"})
+	})
+
+	t.Run("SaveAlert stores each finding once", func(t *testing.T) {
+		alert := SecurityAlert{
+			Kind:        AlertMassDeletion,
+			Severity:    AlertSeverityHigh,
+			UserID:      adminID,
+			Summary:     "deleted a lot",
+			Details:     map[string]any{"deletions": 30},
+			WindowStart: since,
+			WindowEnd:   since.Add(time.Hour),
+		}
+		isNew, err := store.SaveAlert(ctx, alert)
+		require.NoError(t, err)
+		assert.True(t, isNew)
+
+		isNew, err = store.SaveAlert(ctx, alert)
+		require.NoError(t, err)
+		assert.False(t, isNew)
+
+		alert.UserID = ""
+		alert.Kind = AlertLoginSpike
+		isNew, err = store.SaveAlert(ctx, alert)
+		require.NoError(t, err)
+		assert.True(t, isNew)
+	})
+}
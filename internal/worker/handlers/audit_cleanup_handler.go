@@ -1,30 +1,75 @@
 package handlers
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
+	"path"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
 	"github.com/14mdzk/goscratch/pkg/logger"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Audit retention defaults
+const (
+	DefaultAuditRetentionDays = 90
+	DefaultAuditArchivePrefix = "audit-archive"
+)
+
+// auditCleanupBatchSize is how many entries are deleted, and archived, at once
+const auditCleanupBatchSize = 1000
+
 // AuditCleanupPayload represents the data for audit log cleanup
 type AuditCleanupPayload struct {
 	RetentionDays int `json:"retention_days"`
 }
 
+// AuditRetentionStore reads and deletes expired audit entries.
+// *PostgresAuditRetentionStore satisfies it.
+type AuditRetentionStore interface {
+	// Expired returns up to limit entries created before cutoff, oldest first
+	Expired(ctx context.Context, cutoff time.Time, limit int) ([]port.AuditEntry, error)
+	// Delete removes the entries with the given IDs
+	Delete(ctx context.Context, ids []string) (int64, error)
+	// DeleteBefore removes up to limit entries created before cutoff
+	DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// AuditCleanupOptions configures AuditCleanupHandler
+type AuditCleanupOptions struct {
+	// RetentionDays applies to jobs that set none (default: 90)
+	RetentionDays int
+	// Archive receives expired entries before they are deleted. Nil deletes
+	// them without archiving.
+	Archive port.Storage
+	// ArchivePrefix is the storage path archives are written under
+	// (default: audit-archive)
+	ArchivePrefix string
+}
+
 // AuditCleanupHandler handles audit log cleanup jobs
 type AuditCleanupHandler struct {
-	db     *pgxpool.Pool
+	store  AuditRetentionStore
+	opts   AuditCleanupOptions
 	logger *logger.Logger
 }
 
 // NewAuditCleanupHandler creates a new audit cleanup handler
-func NewAuditCleanupHandler(db *pgxpool.Pool, log *logger.Logger) *AuditCleanupHandler {
+func NewAuditCleanupHandler(store AuditRetentionStore, opts AuditCleanupOptions, log *logger.Logger) *AuditCleanupHandler {
+	if opts.RetentionDays <= 0 {
+		opts.RetentionDays = DefaultAuditRetentionDays
+	}
+	if opts.ArchivePrefix == "" {
+		opts.ArchivePrefix = DefaultAuditArchivePrefix
+	}
 	return &AuditCleanupHandler{
-		db:     db,
+		store:  store,
+		opts:   opts,
 		logger: log,
 	}
 }
@@ -34,21 +79,25 @@ func (h *AuditCleanupHandler) Type() string {
 	return worker.JobTypeAuditCleanup
 }
 
-// Handle processes an audit cleanup job
+// Handle processes an audit cleanup job.
+//
+// With an archive, each batch is uploaded before it is deleted, so an entry
+// is never deleted unarchived. A run that fails between the two uploads the
+// batch again on retry, to the same path.
 func (h *AuditCleanupHandler) Handle(ctx context.Context, job *worker.Job) error {
 	var payload AuditCleanupPayload
 	if err := job.UnmarshalPayload(&payload); err != nil {
-		return fmt.Errorf("failed to unmarshal audit cleanup payload: %w", err)
+		return joberr.Permanentf("failed to unmarshal audit cleanup payload: %w", err)
 	}
 
-	// Default retention: 90 days
 	retentionDays := payload.RetentionDays
 	if retentionDays <= 0 {
-		retentionDays = 90
+		retentionDays = h.opts.RetentionDays
 	}
 
 	h.logger.Info("Starting audit log cleanup",
 		"retention_days", retentionDays,
+		"archive", h.opts.Archive != nil,
 		"job_id", job.ID,
 	)
 
@@ -56,31 +105,29 @@ func (h *AuditCleanupHandler) Handle(ctx context.Context, job *worker.Job) error
 	cutoff := time.Now().AddDate(0, 0, -retentionDays)
 
 	// Delete old audit logs in batches to prevent memory issues
-	batchSize := 1000
 	totalDeleted := int64(0)
+	archives := 0
 
 	for {
-		result, err := h.db.Exec(ctx,
-			`DELETE FROM audit_logs 
-			WHERE id IN (
-				SELECT id 
-				FROM audit_logs 
-				WHERE created_at < $1 
-				LIMIT $2
-			)`,
-			cutoff,
-			batchSize,
-		)
+		var deletedInBatch int64
+		var err error
+		if h.opts.Archive == nil {
+			deletedInBatch, err = h.store.DeleteBefore(ctx, cutoff, auditCleanupBatchSize)
+		} else {
+			deletedInBatch, err = h.archiveBatch(ctx, cutoff)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to delete old audit logs: %w", err)
 		}
 
-		deletedInBatch := result.RowsAffected()
 		totalDeleted += deletedInBatch
 
 		if deletedInBatch == 0 {
 			break // No more rows to delete
 		}
+		if h.opts.Archive != nil {
+			archives++
+		}
 
 		h.logger.Info("Deleted batch of audit logs",
 			"deleted_in_batch", deletedInBatch,
@@ -90,13 +137,58 @@ func (h *AuditCleanupHandler) Handle(ctx context.Context, job *worker.Job) error
 		)
 	}
 
-	rowsDeleted := totalDeleted
-
 	h.logger.Info("Audit log cleanup completed",
-		"rows_deleted", rowsDeleted,
+		"rows_deleted", totalDeleted,
+		"archives", archives,
 		"cutoff_date", cutoff.Format(time.RFC3339),
 		"job_id", job.ID,
 	)
 
 	return nil
 }
+
+// archiveBatch uploads the oldest expired entries and then deletes them
+func (h *AuditCleanupHandler) archiveBatch(ctx context.Context, cutoff time.Time) (int64, error) {
+	entries, err := h.store.Expired(ctx, cutoff, auditCleanupBatchSize)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	data, err := encodeAuditArchive(entries)
+	if err != nil {
+		return 0, fmt.Errorf("encode archive: %w", err)
+	}
+	key := auditArchivePath(h.opts.ArchivePrefix, entries[0])
+	if _, err := h.opts.Archive.Upload(ctx, key, bytes.NewReader(data), port.WithContentType("application/gzip")); err != nil {
+		return 0, fmt.Errorf("upload archive %s: %w", key, err)
+	}
+
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return h.store.Delete(ctx, ids)
+}
+
+// encodeAuditArchive renders entries as gzipped NDJSON, one entry per line
+func encodeAuditArchive(entries []port.AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// auditArchivePath names the archive of a batch after its oldest entry,
+// under a directory per day: prefix/2006/01/02/<time>-<id>.ndjson.gz
+func auditArchivePath(prefix string, first port.AuditEntry) string {
+	ts := first.Timestamp.UTC()
+	return path.Join(prefix, ts.Format("2006/01/02"), ts.Format("20060102T150405.000000000Z")+"-"+first.ID+".ndjson.gz")
+}
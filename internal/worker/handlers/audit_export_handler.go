@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	auditusecase "github.com/14mdzk/goscratch/internal/module/audit/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// AuditExportHandler writes asynchronous audit log exports to storage
+type AuditExportHandler struct {
+	store   auditusecase.AuditQuerier
+	storage port.Storage
+	logger  *logger.Logger
+}
+
+// NewAuditExportHandler creates a new audit export handler reading entries
+// from store, e.g. an *audit.PostgresAuditor
+func NewAuditExportHandler(store auditusecase.AuditQuerier, storage port.Storage, log *logger.Logger) *AuditExportHandler {
+	return &AuditExportHandler{
+		store:   store,
+		storage: storage,
+		logger:  log,
+	}
+}
+
+// Type returns the job type this handler processes
+func (h *AuditExportHandler) Type() string {
+	return worker.JobTypeAuditExport
+}
+
+// ReadOnlySafe reports that exports only read the database, so they keep
+// running in read-only mode.
+func (h *AuditExportHandler) ReadOnlySafe() bool {
+	return true
+}
+
+// Handle writes one export. Like a user export, it is spooled to a temporary
+// file and uploaded once complete, so GET /audit-logs/exports/:id never
+// links to a partial file.
+func (h *AuditExportHandler) Handle(ctx context.Context, job *worker.Job) error {
+	var payload worker.AuditExportPayload
+	if err := job.UnmarshalPayload(&payload); err != nil {
+		return joberr.Permanentf("failed to unmarshal audit export payload: %w", err)
+	}
+	// The ID becomes part of the storage path.
+	if _, err := uuid.Parse(payload.ExportID); err != nil {
+		return joberr.Permanentf("invalid export id %q", payload.ExportID)
+	}
+	if payload.Format != auditusecase.ExportFormatCSV && payload.Format != auditusecase.ExportFormatNDJSON {
+		return joberr.Permanentf("unknown export format %q", payload.Format)
+	}
+
+	filter := port.AuditFilter{
+		UserID:     payload.UserID,
+		Involving:  payload.Involving,
+		Resources:  payload.Resources,
+		ResourceID: payload.ResourceID,
+		RequestID:  payload.RequestID,
+		TraceID:    payload.TraceID,
+		Contains:   payload.Contains,
+		Search:     payload.Search,
+		StartTime:  payload.From,
+		EndTime:    payload.To,
+	}
+	for _, a := range payload.Actions {
+		filter.Actions = append(filter.Actions, port.AuditAction(a))
+	}
+
+	tmp, err := os.CreateTemp("", "audit-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rows, err := auditusecase.WriteEntries(bufio.NewWriter(tmp), payload.Format, auditusecase.Entries(ctx, h.store, filter))
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+
+	path := auditusecase.ExportPath(payload.ExportID, payload.Format)
+	if _, err := h.storage.Upload(ctx, path, tmp, port.WithContentType(auditusecase.ExportContentType(payload.Format))); err != nil {
+		return fmt.Errorf("failed to upload export: %w", err)
+	}
+
+	h.logger.Info("Audit export completed",
+		"export_id", payload.ExportID,
+		"format", payload.Format,
+		"rows", rows,
+		"path", path,
+		"job_id", job.ID,
+	)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresAuditRetentionStore reads and deletes expired audit_logs rows for
+// AuditCleanupHandler.
+type PostgresAuditRetentionStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresAuditRetentionStore creates an audit retention store on pool
+func NewPostgresAuditRetentionStore(pool *pgxpool.Pool) *PostgresAuditRetentionStore {
+	return &PostgresAuditRetentionStore{pool: pool}
+}
+
+var _ AuditRetentionStore = (*PostgresAuditRetentionStore)(nil)
+
+// Expired implements AuditRetentionStore
+func (s *PostgresAuditRetentionStore) Expired(ctx context.Context, cutoff time.Time, limit int) ([]port.AuditEntry, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, COALESCE(user_id::text, ''), COALESCE(actor_id::text, ''), action, resource,
+		       COALESCE(resource_id, ''), old_value, new_value,
+		       COALESCE(host(ip_address), ''), COALESCE(user_agent, ''),
+		       COALESCE(request_id, ''), COALESCE(trace_id, ''), created_at
+		FROM audit_logs
+		WHERE created_at < $1
+		ORDER BY created_at, id
+		LIMIT $2`,
+		cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list expired audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []port.AuditEntry
+	for rows.Next() {
+		var e port.AuditEntry
+		var oldValue, newValue []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ActorID, &e.Action, &e.Resource, &e.ResourceID,
+			&oldValue, &newValue, &e.IPAddress, &e.UserAgent, &e.RequestID, &e.TraceID, &e.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan expired audit log: %w", err)
+		}
+		// Archived as stored, not re-encoded
+		if oldValue != nil {
+			e.OldValue = json.RawMessage(oldValue)
+		}
+		if newValue != nil {
+			e.NewValue = json.RawMessage(newValue)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Delete implements AuditRetentionStore
+func (s *PostgresAuditRetentionStore) Delete(ctx context.Context, ids []string) (int64, error) {
+	result, err := s.pool.Exec(ctx, `DELETE FROM audit_logs WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("delete audit logs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// DeleteBefore implements AuditRetentionStore
+func (s *PostgresAuditRetentionStore) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	result, err := s.pool.Exec(ctx,
+		`DELETE FROM audit_logs
+		WHERE id IN (
+			SELECT id
+			FROM audit_logs
+			WHERE created_at < $1
+			LIMIT $2
+		)`,
+		cutoff,
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("delete old audit logs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// Dormant account policy actions
+const (
+	DormantActionNotify     = "notify"     // email a warning, leave the account active
+	DormantActionDeactivate = "deactivate" // deactivate, then email a notice
+)
+
+// DormantUsersPayload represents the policy for a dormant-account sweep
+type DormantUsersPayload struct {
+	InactiveDays int    `json:"inactive_days"` // default: 180
+	Action       string `json:"action"`        // notify (default) or deactivate
+	Limit        int    `json:"limit"`         // max accounts per run, default: 500
+	DryRun       bool   `json:"dry_run"`       // log what would happen, change nothing
+}
+
+// DormantUserStore is the subset of the user repository the sweep needs.
+// *userrepo.Repository satisfies it.
+type DormantUserStore interface {
+	ListInactive(ctx context.Context, before time.Time, limit int) ([]userdomain.User, error)
+	Deactivate(ctx context.Context, id string) error
+}
+
+// DormantUsersHandler handles dormant-account sweeps
+type DormantUsersHandler struct {
+	store       DormantUserStore
+	emailSender port.EmailSender
+	logger      *logger.Logger
+}
+
+// NewDormantUsersHandler creates a new dormant users handler
+func NewDormantUsersHandler(store DormantUserStore, emailSender port.EmailSender, log *logger.Logger) *DormantUsersHandler {
+	return &DormantUsersHandler{
+		store:       store,
+		emailSender: emailSender,
+		logger:      log,
+	}
+}
+
+// Type returns the job type this handler processes
+func (h *DormantUsersHandler) Type() string {
+	return worker.JobTypeDormantUsers
+}
+
+// Handle processes a dormant-account sweep.
+//
+// Per-user failures are logged and skipped rather than failing the job: a
+// retry would re-email every account that was already processed.
+func (h *DormantUsersHandler) Handle(ctx context.Context, job *worker.Job) error {
+	var payload DormantUsersPayload
+	if err := job.UnmarshalPayload(&payload); err != nil {
+		return joberr.Permanentf("failed to unmarshal dormant users payload: %w", err)
+	}
+
+	if payload.InactiveDays <= 0 {
+		payload.InactiveDays = 180
+	}
+	if payload.Limit <= 0 {
+		payload.Limit = 500
+	}
+	if payload.Action == "" {
+		payload.Action = DormantActionNotify
+	}
+	if payload.Action != DormantActionNotify && payload.Action != DormantActionDeactivate {
+		return joberr.Permanentf("unknown dormant action %q", payload.Action)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -payload.InactiveDays)
+	users, err := h.store.ListInactive(ctx, cutoff, payload.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to list inactive users: %w", err)
+	}
+
+	h.logger.Info("Starting dormant account sweep",
+		"action", payload.Action,
+		"inactive_days", payload.InactiveDays,
+		"candidates", len(users),
+		"dry_run", payload.DryRun,
+		"job_id", job.ID,
+	)
+
+	processed, failed := 0, 0
+	for _, u := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if payload.DryRun {
+			h.logger.Info("Dormant account (dry run)", "user_id", u.ID.String(), "action", payload.Action, "job_id", job.ID)
+			processed++
+			continue
+		}
+
+		if err := h.apply(ctx, payload, u); err != nil {
+			failed++
+			h.logger.Error("Failed to process dormant account",
+				"user_id", u.ID.String(),
+				"action", payload.Action,
+				"error", err,
+				"job_id", job.ID,
+			)
+			continue
+		}
+		processed++
+	}
+
+	h.logger.Info("Dormant account sweep completed",
+		"action", payload.Action,
+		"processed", processed,
+		"failed", failed,
+		"job_id", job.ID,
+	)
+
+	return nil
+}
+
+// apply runs the policy action for one user.
+func (h *DormantUsersHandler) apply(ctx context.Context, payload DormantUsersPayload, u userdomain.User) error {
+	msg := port.EmailMessage{To: []string{u.Email}}
+
+	switch payload.Action {
+	case DormantActionDeactivate:
+		if err := h.store.Deactivate(ctx, u.ID.String()); err != nil {
+			return fmt.Errorf("deactivate: %w", err)
+		}
+		msg.Subject = "Your account has been deactivated"
+		msg.Body = fmt.Sprintf("Hi %s,\n\nYour account was deactivated after %d days without activity. Contact an administrator to restore access.", u.Name, payload.InactiveDays)
+	default:
+		msg.Subject = "Your account is inactive"
+		msg.Body = fmt.Sprintf("Hi %s,\n\nWe have not seen you in %d days. Sign in to keep your account active.", u.Name, payload.InactiveDays)
+	}
+
+	if err := h.emailSender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
@@ -2,20 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
 	"github.com/14mdzk/goscratch/pkg/logger"
 )
 
 // EmailPayload represents the data for sending an email
-type EmailPayload struct {
-	To      string `json:"to"`
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
-	HTML    bool   `json:"html,omitempty"`
-}
+type EmailPayload = worker.EmailPayload
 
 // EmailHandler handles email sending jobs
 type EmailHandler struct {
@@ -36,24 +33,31 @@ func (h *EmailHandler) Type() string {
 	return worker.JobTypeEmailSend
 }
 
+// ReadOnlySafe reports that sending email does not write to the database, so
+// it keeps running in read-only mode.
+func (h *EmailHandler) ReadOnlySafe() bool {
+	return true
+}
+
 // Handle processes an email sending job
 func (h *EmailHandler) Handle(ctx context.Context, job *worker.Job) error {
 	var payload EmailPayload
 	if err := job.UnmarshalPayload(&payload); err != nil {
-		return fmt.Errorf("failed to unmarshal email payload: %w", err)
+		return joberr.Permanentf("failed to unmarshal email payload: %w", err)
 	}
 
 	// Validate payload
 	if payload.To == "" {
-		return fmt.Errorf("email recipient is required")
+		return joberr.Permanentf("email recipient is required")
 	}
 	if payload.Subject == "" {
-		return fmt.Errorf("email subject is required")
+		return joberr.Permanentf("email subject is required")
 	}
 
 	h.logger.Info("Sending email",
 		"to", payload.To,
 		"subject", payload.Subject,
+		"tenant", payload.Tenant,
 		"job_id", job.ID,
 	)
 
@@ -62,9 +66,13 @@ func (h *EmailHandler) Handle(ctx context.Context, job *worker.Job) error {
 		Subject: payload.Subject,
 		Body:    payload.Body,
 		IsHTML:  payload.HTML,
+		Tenant:  payload.Tenant,
 	}
 
 	if err := h.emailSender.Send(ctx, msg); err != nil {
+		if errors.Is(err, port.ErrUnknownEmailTenant) {
+			return joberr.Permanent(fmt.Errorf("failed to send email: %w", err))
+		}
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
@@ -2,22 +2,37 @@ package handlers
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
 	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
 	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type mockEmailSender struct {
 	sent []port.EmailMessage
+	err  error
 }
 
 func (m *mockEmailSender) Send(_ context.Context, msg port.EmailMessage) error {
+	if m.err != nil {
+		return m.err
+	}
 	m.sent = append(m.sent, msg)
 	return nil
 }
@@ -46,6 +61,31 @@ func TestEmailHandler_Type(t *testing.T) {
 	assert.Equal(t, worker.JobTypeEmailSend, h.Type())
 }
 
+func TestReadOnlySafe(t *testing.T) {
+	var h worker.JobHandler = NewEmailHandler(newTestLogger(), &mockEmailSender{})
+	safe, ok := h.(worker.ReadOnlySafe)
+	require.True(t, ok)
+	assert.True(t, safe.ReadOnlySafe(), "sending email writes nothing and keeps running in read-only mode")
+
+	h = NewSessionSweepHandler(&fakeSessionSweeper{}, newTestLogger())
+	safe, ok = h.(worker.ReadOnlySafe)
+	require.True(t, ok)
+	assert.True(t, safe.ReadOnlySafe(), "sessions live in the cache and can be revoked in read-only mode")
+
+	h = NewUserExportHandler(&fakeExportStore{}, &fakeUploadStorage{}, newTestLogger())
+	safe, ok = h.(worker.ReadOnlySafe)
+	require.True(t, ok)
+	assert.True(t, safe.ReadOnlySafe(), "exports only read the database")
+
+	for _, h := range []worker.JobHandler{
+		NewAuditCleanupHandler(nil, AuditCleanupOptions{}, newTestLogger()),
+		NewNormalizeEmailsHandler(nil, newTestLogger()),
+	} {
+		_, ok := h.(worker.ReadOnlySafe)
+		assert.False(t, ok, "%s writes and must pause in read-only mode", h.Type())
+	}
+}
+
 func TestEmailHandler_Handle(t *testing.T) {
 	t.Run("valid_payload", func(t *testing.T) {
 		h := NewEmailHandler(newTestLogger(), &mockEmailSender{})
@@ -70,6 +110,7 @@ func TestEmailHandler_Handle(t *testing.T) {
 		err := h.Handle(context.Background(), job)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "email recipient is required")
+		assert.True(t, joberr.IsPermanent(err), "invalid payloads must not be retried")
 	})
 
 	t.Run("missing_subject", func(t *testing.T) {
@@ -83,6 +124,7 @@ func TestEmailHandler_Handle(t *testing.T) {
 		err := h.Handle(context.Background(), job)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "email subject is required")
+		assert.True(t, joberr.IsPermanent(err), "invalid payloads must not be retried")
 	})
 
 	t.Run("invalid_payload_json", func(t *testing.T) {
@@ -96,6 +138,37 @@ func TestEmailHandler_Handle(t *testing.T) {
 		err := h.Handle(context.Background(), job)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to unmarshal email payload")
+		assert.True(t, joberr.IsPermanent(err), "invalid payloads must not be retried")
+	})
+
+	t.Run("passes_tenant", func(t *testing.T) {
+		sender := &mockEmailSender{}
+		h := NewEmailHandler(newTestLogger(), sender)
+		job := makeJob(t, worker.JobTypeEmailSend, EmailPayload{
+			To:      "user@example.com",
+			Subject: "Welcome",
+			Body:    "Hello!",
+			Tenant:  "acme",
+		})
+
+		require.NoError(t, h.Handle(context.Background(), job))
+		require.Len(t, sender.sent, 1)
+		assert.Equal(t, "acme", sender.sent[0].Tenant)
+	})
+
+	t.Run("unknown_tenant_is_permanent", func(t *testing.T) {
+		sender := &mockEmailSender{err: fmt.Errorf("%w: %q", port.ErrUnknownEmailTenant, "nope")}
+		h := NewEmailHandler(newTestLogger(), sender)
+		job := makeJob(t, worker.JobTypeEmailSend, EmailPayload{
+			To:      "user@example.com",
+			Subject: "Welcome",
+			Body:    "Hello!",
+			Tenant:  "nope",
+		})
+
+		err := h.Handle(context.Background(), job)
+		require.ErrorIs(t, err, port.ErrUnknownEmailTenant)
+		assert.True(t, joberr.IsPermanent(err), "an unknown tenant cannot succeed on retry")
 	})
 
 	t.Run("with_html_flag", func(t *testing.T) {
@@ -114,16 +187,86 @@ func TestEmailHandler_Handle(t *testing.T) {
 
 // --- AuditCleanupHandler Tests ---
 
+// fakeRetentionStore holds audit entries, oldest first
+type fakeRetentionStore struct {
+	entries []port.AuditEntry
+	cutoff  time.Time
+	failDel bool
+}
+
+func (s *fakeRetentionStore) Expired(_ context.Context, cutoff time.Time, limit int) ([]port.AuditEntry, error) {
+	s.cutoff = cutoff
+	var out []port.AuditEntry
+	for _, e := range s.entries {
+		if e.Timestamp.Before(cutoff) && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeRetentionStore) Delete(_ context.Context, ids []string) (int64, error) {
+	if s.failDel {
+		return 0, fmt.Errorf("connection reset")
+	}
+	var kept []port.AuditEntry
+	for _, e := range s.entries {
+		if !slices.Contains(ids, e.ID) {
+			kept = append(kept, e)
+		}
+	}
+	n := len(s.entries) - len(kept)
+	s.entries = kept
+	return int64(n), nil
+}
+
+func (s *fakeRetentionStore) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	expired, _ := s.Expired(ctx, cutoff, limit)
+	ids := make([]string, len(expired))
+	for i, e := range expired {
+		ids[i] = e.ID
+	}
+	return s.Delete(ctx, ids)
+}
+
+// auditEntries returns n entries a day apart, the oldest first and the
+// newest just under a day old
+func auditEntries(n int) []port.AuditEntry {
+	now := time.Now()
+	entries := make([]port.AuditEntry, n)
+	for i := range entries {
+		entries[i] = port.AuditEntry{
+			ID:        uuid.NewString(),
+			Action:    port.AuditActionLogin,
+			Resource:  "auth",
+			Timestamp: now.AddDate(0, 0, i-n).Add(time.Hour),
+		}
+	}
+	return entries
+}
+
+// readArchive decodes a gzipped NDJSON archive
+func readArchive(t *testing.T, data string) []port.AuditEntry {
+	t.Helper()
+	zr, err := gzip.NewReader(strings.NewReader(data))
+	require.NoError(t, err)
+	var entries []port.AuditEntry
+	dec := json.NewDecoder(zr)
+	for dec.More() {
+		var e port.AuditEntry
+		require.NoError(t, dec.Decode(&e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
 func TestAuditCleanupHandler_Type(t *testing.T) {
-	// We pass nil for db since we only test Type()
-	h := NewAuditCleanupHandler(nil, newTestLogger())
+	h := NewAuditCleanupHandler(nil, AuditCleanupOptions{}, newTestLogger())
 	assert.Equal(t, worker.JobTypeAuditCleanup, h.Type())
 }
 
-// Note: AuditCleanupHandler.Handle() requires a real *pgxpool.Pool for the DB call.
-// We test the type and payload unmarshaling without a live database.
 func TestAuditCleanupHandler_Handle_InvalidPayload(t *testing.T) {
-	h := NewAuditCleanupHandler(nil, newTestLogger())
+	h := NewAuditCleanupHandler(nil, AuditCleanupOptions{}, newTestLogger())
 	job := &worker.Job{
 		ID:      "test-id",
 		Type:    worker.JobTypeAuditCleanup,
@@ -133,4 +276,667 @@ func TestAuditCleanupHandler_Handle_InvalidPayload(t *testing.T) {
 	err := h.Handle(context.Background(), job)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to unmarshal audit cleanup payload")
+	assert.True(t, joberr.IsPermanent(err), "invalid payloads must not be retried")
+}
+
+func TestAuditCleanupHandler_Handle(t *testing.T) {
+	t.Run("deletes entries older than the retention", func(t *testing.T) {
+		store := &fakeRetentionStore{entries: auditEntries(10)}
+		h := NewAuditCleanupHandler(store, AuditCleanupOptions{RetentionDays: 3}, newTestLogger())
+
+		require.NoError(t, h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditCleanup, AuditCleanupPayload{})))
+		assert.Len(t, store.entries, 3)
+	})
+
+	t.Run("the job's retention overrides the default", func(t *testing.T) {
+		store := &fakeRetentionStore{entries: auditEntries(10)}
+		h := NewAuditCleanupHandler(store, AuditCleanupOptions{RetentionDays: 3}, newTestLogger())
+
+		require.NoError(t, h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditCleanup, AuditCleanupPayload{RetentionDays: 5})))
+		assert.Len(t, store.entries, 5)
+	})
+
+	t.Run("archives before deleting", func(t *testing.T) {
+		entries := auditEntries(auditCleanupBatchSize + 10)
+		store := &fakeRetentionStore{entries: entries}
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewAuditCleanupHandler(store, AuditCleanupOptions{RetentionDays: 1, Archive: storage, ArchivePrefix: "archives/audit"}, newTestLogger())
+
+		require.NoError(t, h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditCleanup, AuditCleanupPayload{})))
+		assert.Len(t, store.entries, 1)
+		assert.Equal(t, "application/gzip", storage.contentType)
+		require.Len(t, storage.uploads, 2, "one archive per batch")
+
+		first := auditArchivePath("archives/audit", entries[0])
+		assert.True(t, strings.HasPrefix(first, "archives/audit/"+entries[0].Timestamp.UTC().Format("2006/01/02")+"/"))
+		assert.True(t, strings.HasSuffix(first, entries[0].ID+".ndjson.gz"))
+		archived := readArchive(t, storage.uploads[first])
+		require.Len(t, archived, auditCleanupBatchSize)
+		assert.Equal(t, entries[0].ID, archived[0].ID)
+		assert.Equal(t, port.AuditActionLogin, archived[0].Action)
+
+		second := readArchive(t, storage.uploads[auditArchivePath("archives/audit", entries[auditCleanupBatchSize])])
+		assert.Len(t, second, 9)
+	})
+
+	t.Run("a failed delete is retried with the same archive", func(t *testing.T) {
+		store := &fakeRetentionStore{entries: auditEntries(5), failDel: true}
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewAuditCleanupHandler(store, AuditCleanupOptions{RetentionDays: 1, Archive: storage}, newTestLogger())
+		job := makeJob(t, worker.JobTypeAuditCleanup, AuditCleanupPayload{})
+
+		require.Error(t, h.Handle(context.Background(), job))
+		assert.Len(t, store.entries, 5)
+
+		store.failDel = false
+		require.NoError(t, h.Handle(context.Background(), job))
+		assert.Len(t, store.entries, 1)
+		assert.Len(t, storage.uploads, 1, "the retry overwrites the first upload")
+	})
+}
+
+// --- DormantUsersHandler Tests ---
+
+type fakeDormantStore struct {
+	users       []userdomain.User
+	before      time.Time
+	limit       int
+	deactivated []string
+}
+
+func (s *fakeDormantStore) ListInactive(_ context.Context, before time.Time, limit int) ([]userdomain.User, error) {
+	s.before, s.limit = before, limit
+	return s.users, nil
+}
+
+func (s *fakeDormantStore) Deactivate(_ context.Context, id string) error {
+	s.deactivated = append(s.deactivated, id)
+	return nil
+}
+
+func TestDormantUsersHandler_Type(t *testing.T) {
+	h := NewDormantUsersHandler(&fakeDormantStore{}, &mockEmailSender{}, newTestLogger())
+	assert.Equal(t, worker.JobTypeDormantUsers, h.Type())
+}
+
+func TestDormantUsersHandler_Handle(t *testing.T) {
+	dormant := []userdomain.User{
+		{ID: uuid.New(), Email: "a@example.com", Name: "A"},
+		{ID: uuid.New(), Email: "b@example.com", Name: "B"},
+	}
+
+	t.Run("notify_by_default", func(t *testing.T) {
+		store := &fakeDormantStore{users: dormant}
+		sender := &mockEmailSender{}
+		h := NewDormantUsersHandler(store, sender, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeDormantUsers, DormantUsersPayload{}))
+		require.NoError(t, err)
+
+		assert.Equal(t, 500, store.limit)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, -180), store.before, time.Minute)
+		assert.Empty(t, store.deactivated, "notify must not deactivate")
+		require.Len(t, sender.sent, 2)
+		assert.Equal(t, []string{"a@example.com"}, sender.sent[0].To)
+	})
+
+	t.Run("deactivate", func(t *testing.T) {
+		store := &fakeDormantStore{users: dormant}
+		sender := &mockEmailSender{}
+		h := NewDormantUsersHandler(store, sender, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeDormantUsers, DormantUsersPayload{
+			InactiveDays: 30,
+			Action:       DormantActionDeactivate,
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{dormant[0].ID.String(), dormant[1].ID.String()}, store.deactivated)
+		assert.Len(t, sender.sent, 2)
+	})
+
+	t.Run("dry_run_changes_nothing", func(t *testing.T) {
+		store := &fakeDormantStore{users: dormant}
+		sender := &mockEmailSender{}
+		h := NewDormantUsersHandler(store, sender, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeDormantUsers, DormantUsersPayload{
+			Action: DormantActionDeactivate,
+			DryRun: true,
+		}))
+		require.NoError(t, err)
+
+		assert.Empty(t, store.deactivated)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("unknown_action_is_permanent", func(t *testing.T) {
+		h := NewDormantUsersHandler(&fakeDormantStore{users: dormant}, &mockEmailSender{}, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeDormantUsers, DormantUsersPayload{Action: "delete"}))
+		assert.True(t, joberr.IsPermanent(err))
+	})
+}
+
+// --- UserExportHandler Tests ---
+
+type fakeExportStore struct {
+	users  []userdomain.User
+	err    error
+	filter userdomain.UserFilter
+}
+
+func (s *fakeExportStore) Stream(_ context.Context, filter userdomain.UserFilter) iter.Seq2[userdomain.User, error] {
+	s.filter = filter
+	return func(yield func(userdomain.User, error) bool) {
+		for _, u := range s.users {
+			if !yield(u, nil) {
+				return
+			}
+		}
+		if s.err != nil {
+			yield(userdomain.User{}, s.err)
+		}
+	}
+}
+
+// fakeUploadStorage records uploads; other methods are left to the nil
+// embedded interface.
+type fakeUploadStorage struct {
+	port.Storage
+	uploads     map[string]string
+	contentType string
+}
+
+func (s *fakeUploadStorage) Upload(_ context.Context, path string, data io.Reader, opts ...port.UploadOption) (string, error) {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	s.uploads[path] = string(b)
+	s.contentType = port.ApplyOptions(opts).ContentType
+	return path, nil
+}
+
+func TestUserExportHandler_Type(t *testing.T) {
+	h := NewUserExportHandler(&fakeExportStore{}, &fakeUploadStorage{}, newTestLogger())
+	assert.Equal(t, worker.JobTypeUserExport, h.Type())
+}
+
+func TestUserExportHandler_Handle(t *testing.T) {
+	exportID := uuid.NewString()
+	users := []userdomain.User{
+		{ID: uuid.New(), Email: "a@example.com", Name: "A", IsActive: true},
+		{ID: uuid.New(), Email: "b@example.com", Name: "B", IsActive: true},
+	}
+
+	t.Run("uploads the complete export", func(t *testing.T) {
+		store := &fakeExportStore{users: users}
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewUserExportHandler(store, storage, newTestLogger())
+
+		active := true
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeUserExport, worker.UserExportPayload{
+			ExportID: exportID,
+			Format:   "csv",
+			Search:   "example",
+			IsActive: &active,
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "example", store.filter.Search.Val)
+		assert.True(t, store.filter.IsActive.Set)
+		assert.False(t, store.filter.Email.Set)
+
+		body, ok := storage.uploads["exports/users/"+exportID+".csv"]
+		require.True(t, ok)
+		assert.Equal(t, 3, strings.Count(body, "\n"), "header and two rows")
+		assert.Contains(t, body, "a@example.com")
+		assert.Equal(t, "text/csv; charset=utf-8", storage.contentType)
+	})
+
+	t.Run("a failed stream uploads nothing", func(t *testing.T) {
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewUserExportHandler(&fakeExportStore{users: users, err: fmt.Errorf("connection reset")}, storage, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeUserExport, worker.UserExportPayload{ExportID: exportID, Format: "ndjson"}))
+		assert.Error(t, err)
+		assert.False(t, joberr.IsPermanent(err), "retried")
+		assert.Empty(t, storage.uploads)
+	})
+
+	t.Run("invalid payload is permanent", func(t *testing.T) {
+		h := NewUserExportHandler(&fakeExportStore{}, &fakeUploadStorage{uploads: map[string]string{}}, newTestLogger())
+
+		for _, payload := range []worker.UserExportPayload{
+			{ExportID: "../../etc/passwd", Format: "csv"},
+			{ExportID: exportID, Format: "xlsx"},
+		} {
+			err := h.Handle(context.Background(), makeJob(t, worker.JobTypeUserExport, payload))
+			assert.True(t, joberr.IsPermanent(err), "%+v", payload)
+		}
+	})
+}
+
+// --- AuditExportHandler Tests ---
+
+type fakeAuditQuerier struct {
+	entries []port.AuditEntry
+	err     error
+	// errAfter is how many queries return entries before err is returned
+	errAfter int
+	queries  int
+	filter   port.AuditFilter
+}
+
+func (q *fakeAuditQuerier) Query(_ context.Context, filter port.AuditFilter) ([]port.AuditEntry, error) {
+	q.filter = filter
+	q.queries++
+	if q.err != nil && q.queries > q.errAfter {
+		return nil, q.err
+	}
+	return q.entries, nil
+}
+
+func TestAuditExportHandler_Handle(t *testing.T) {
+	exportID := uuid.NewString()
+	entries := []port.AuditEntry{
+		{ID: "e1", Action: port.AuditActionLogin, Resource: "auth"},
+		{ID: "e2", Action: port.AuditActionUpdate, Resource: "user"},
+	}
+
+	t.Run("uploads the complete export", func(t *testing.T) {
+		store := &fakeAuditQuerier{entries: entries}
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewAuditExportHandler(store, storage, newTestLogger())
+		assert.Equal(t, worker.JobTypeAuditExport, h.Type())
+
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditExport, worker.AuditExportPayload{
+			ExportID: exportID,
+			Format:   "ndjson",
+			Actions:  []string{"LOGIN", "UPDATE"},
+			Contains: map[string]any{"email": "a@example.com"},
+			From:     &from,
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []port.AuditAction{port.AuditActionLogin, port.AuditActionUpdate}, store.filter.Actions)
+		assert.Equal(t, map[string]any{"email": "a@example.com"}, store.filter.Contains)
+		require.NotNil(t, store.filter.StartTime)
+		assert.True(t, from.Equal(*store.filter.StartTime))
+		assert.Nil(t, store.filter.EndTime)
+
+		body, ok := storage.uploads["exports/audit/"+exportID+".ndjson"]
+		require.True(t, ok)
+		assert.Equal(t, 2, strings.Count(body, "\n"))
+		assert.Equal(t, "application/x-ndjson", storage.contentType)
+	})
+
+	t.Run("a failed query uploads nothing", func(t *testing.T) {
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewAuditExportHandler(&fakeAuditQuerier{err: fmt.Errorf("connection reset")}, storage, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditExport, worker.AuditExportPayload{ExportID: exportID, Format: "csv"}))
+		assert.Error(t, err)
+		assert.False(t, joberr.IsPermanent(err), "retried")
+		assert.Empty(t, storage.uploads)
+	})
+
+	t.Run("a query failing mid-stream uploads nothing", func(t *testing.T) {
+		// A full page makes the export read the next one, which fails.
+		page := make([]port.AuditEntry, 500)
+		for i := range page {
+			page[i] = port.AuditEntry{ID: fmt.Sprintf("e%d", i), Action: port.AuditActionUpdate, Resource: "user"}
+		}
+		store := &fakeAuditQuerier{entries: page, err: fmt.Errorf("failed to read audit logs: unexpected EOF"), errAfter: 1}
+		storage := &fakeUploadStorage{uploads: map[string]string{}}
+		h := NewAuditExportHandler(store, storage, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditExport, worker.AuditExportPayload{ExportID: exportID, Format: "csv"}))
+		assert.Error(t, err)
+		assert.False(t, joberr.IsPermanent(err), "retried")
+		assert.Equal(t, 2, store.queries)
+		assert.Empty(t, storage.uploads, "a truncated export is never published")
+	})
+
+	t.Run("invalid payload is permanent", func(t *testing.T) {
+		h := NewAuditExportHandler(&fakeAuditQuerier{}, &fakeUploadStorage{uploads: map[string]string{}}, newTestLogger())
+
+		for _, payload := range []worker.AuditExportPayload{
+			{ExportID: "../../etc/passwd", Format: "csv"},
+			{ExportID: exportID, Format: "xlsx"},
+		} {
+			err := h.Handle(context.Background(), makeJob(t, worker.JobTypeAuditExport, payload))
+			assert.True(t, joberr.IsPermanent(err), "%+v", payload)
+		}
+	})
+}
+
+// --- NormalizeEmailsHandler Tests ---
+
+type fakeEmailStore struct {
+	users   []userdomain.User
+	updated map[string]string
+	pages   int
+}
+
+func (s *fakeEmailStore) List(_ context.Context, filter userdomain.UserFilter) ([]userdomain.User, error) {
+	s.pages++
+	start := 0
+	if filter.Cursor != "" {
+		for i, u := range s.users {
+			if u.ID.String() == filter.Cursor {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+filter.Limit+1, len(s.users))
+	return s.users[start:end], nil
+}
+
+func (s *fakeEmailStore) Update(_ context.Context, id, _, email string) (*userdomain.User, error) {
+	if s.updated == nil {
+		s.updated = make(map[string]string)
+	}
+	s.updated[id] = email
+	return &userdomain.User{Email: email}, nil
+}
+
+func (s *fakeEmailStore) NormalizeEmail(email string) string {
+	return userdomain.NormalizeEmail(email, true)
+}
+
+func TestNormalizeEmailsHandler_Type(t *testing.T) {
+	h := NewNormalizeEmailsHandler(&fakeEmailStore{}, newTestLogger())
+	assert.Equal(t, worker.JobTypeNormalizeEmails, h.Type())
+}
+
+func TestNormalizeEmailsHandler_Handle(t *testing.T) {
+	mixedCase := userdomain.User{ID: uuid.New(), Email: "Mixed@Example.com"}
+	canonical := userdomain.User{ID: uuid.New(), Email: "ok@example.com"}
+	dupA := userdomain.User{ID: uuid.New(), Email: "jane.doe@gmail.com"}
+	dupB := userdomain.User{ID: uuid.New(), Email: "JaneDoe+work@gmail.com"}
+	users := []userdomain.User{mixedCase, canonical, dupA, dupB}
+
+	t.Run("rewrites non-canonical emails and skips collisions", func(t *testing.T) {
+		store := &fakeEmailStore{users: users}
+		h := NewNormalizeEmailsHandler(store, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeNormalizeEmails, NormalizeEmailsPayload{}))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{mixedCase.ID.String(): "mixed@example.com"}, store.updated)
+	})
+
+	t.Run("dry_run_changes_nothing", func(t *testing.T) {
+		store := &fakeEmailStore{users: users}
+		h := NewNormalizeEmailsHandler(store, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeNormalizeEmails, NormalizeEmailsPayload{DryRun: true}))
+		require.NoError(t, err)
+
+		assert.Empty(t, store.updated)
+	})
+
+	t.Run("pages_through_all_users", func(t *testing.T) {
+		many := make([]userdomain.User, userdomain.MaxLimit*2+5)
+		for i := range many {
+			many[i] = userdomain.User{ID: uuid.New(), Email: fmt.Sprintf("User%d@Example.com", i)}
+		}
+		store := &fakeEmailStore{users: many}
+		h := NewNormalizeEmailsHandler(store, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeNormalizeEmails, NormalizeEmailsPayload{}))
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, store.pages)
+		assert.Len(t, store.updated, len(many))
+	})
+}
+
+// --- SessionSweepHandler Tests ---
+
+type fakeSessionSweeper struct {
+	revoked  []string
+	sweeps   int
+	dryRun   bool
+	sweepErr error
+}
+
+func (f *fakeSessionSweeper) RevokeUsers(_ context.Context, userIDs []string, dryRun bool) (int, error) {
+	f.revoked = append(f.revoked, userIDs...)
+	f.dryRun = dryRun
+	return len(userIDs), nil
+}
+
+func (f *fakeSessionSweeper) Sweep(_ context.Context, dryRun bool) (*authdomain.SessionSweepResult, error) {
+	f.sweeps++
+	f.dryRun = dryRun
+	if f.sweepErr != nil {
+		return nil, f.sweepErr
+	}
+	return &authdomain.SessionSweepResult{Users: 1, Sessions: 2}, nil
+}
+
+func TestSessionSweepHandler_Type(t *testing.T) {
+	h := NewSessionSweepHandler(&fakeSessionSweeper{}, newTestLogger())
+	assert.Equal(t, worker.JobTypeSessionSweep, h.Type())
+}
+
+func TestSessionSweepHandler_Handle(t *testing.T) {
+	t.Run("sweeps", func(t *testing.T) {
+		sweeper := &fakeSessionSweeper{}
+		h := NewSessionSweepHandler(sweeper, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeSessionSweep, SessionSweepPayload{}))
+		require.NoError(t, err)
+		assert.Equal(t, 1, sweeper.sweeps)
+		assert.Empty(t, sweeper.revoked)
+	})
+
+	t.Run("revokes listed users first", func(t *testing.T) {
+		sweeper := &fakeSessionSweeper{}
+		h := NewSessionSweepHandler(sweeper, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeSessionSweep, SessionSweepPayload{
+			RevokeUserIDs: []string{"u1", "u2"},
+			DryRun:        true,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"u1", "u2"}, sweeper.revoked)
+		assert.Equal(t, 1, sweeper.sweeps)
+		assert.True(t, sweeper.dryRun)
+	})
+
+	t.Run("sweep failure is retryable", func(t *testing.T) {
+		sweeper := &fakeSessionSweeper{sweepErr: port.ErrCacheUnavailable}
+		h := NewSessionSweepHandler(sweeper, newTestLogger())
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeSessionSweep, SessionSweepPayload{}))
+		require.ErrorIs(t, err, port.ErrCacheUnavailable)
+		assert.False(t, joberr.IsPermanent(err))
+	})
+}
+
+// --- AnomalyDetectionHandler Tests ---
+
+type fakeAnomalyStore struct {
+	counts   map[port.AuditAction]map[string][]int
+	admins   []AlertRecipient
+	usual    map[string][24]int // admin activity in the baseline
+	current  map[string][24]int // admin activity in the window
+	saved    []SecurityAlert
+	seen     map[string]bool
+	bucket   time.Duration
+	nBuckets int
+}
+
+func (s *fakeAnomalyStore) CountByUser(_ context.Context, action port.AuditAction, _ time.Time, bucket time.Duration, buckets int) (map[string][]int, error) {
+	s.bucket, s.nBuckets = bucket, buckets
+	return s.counts[action], nil
+}
+
+func (s *fakeAnomalyStore) HourlyActivity(_ context.Context, _ []string, since, until time.Time) (map[string][24]int, error) {
+	if until.Sub(since) > time.Hour {
+		return s.usual, nil
+	}
+	return s.current, nil
+}
+
+func (s *fakeAnomalyStore) Admins(context.Context) ([]AlertRecipient, error) {
+	return s.admins, nil
+}
+
+func (s *fakeAnomalyStore) SaveAlert(_ context.Context, a SecurityAlert) (bool, error) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	key := a.Kind + a.UserID + a.WindowStart.String()
+	if s.seen[key] {
+		return false, nil
+	}
+	s.seen[key] = true
+	s.saved = append(s.saved, a)
+	return true, nil
+}
+
+// series returns a one-day baseline of hourly counts cycling through usual,
+// followed by the window's count.
+func series(window int, usual ...int) []int {
+	out := make([]int, 25)
+	for i := 0; i < 24; i++ {
+		out[i] = usual[i%len(usual)]
+	}
+	out[24] = window
+	return out
+}
+
+func newAnomalyHandler(store AnomalyStore, sender port.EmailSender) *AnomalyDetectionHandler {
+	h := NewAnomalyDetectionHandler(store, sender, newTestLogger())
+	h.now = func() time.Time { return time.Date(2026, 10, 15, 10, 30, 0, 0, time.UTC) }
+	return h
+}
+
+func TestAnomalyDetectionHandler_Type(t *testing.T) {
+	h := NewAnomalyDetectionHandler(&fakeAnomalyStore{}, &mockEmailSender{}, newTestLogger())
+	assert.Equal(t, worker.JobTypeAnomalyDetection, h.Type())
+}
+
+func TestAnomalyDetectionHandler_Handle(t *testing.T) {
+	oneDay := AnomalyDetectionPayload{BaselineDays: 1}
+	admin := AlertRecipient{UserID: "admin-1", Email: "admin@example.com"}
+	officeHours := [24]int{9: 10, 10: 10, 11: 10, 12: 10, 13: 10, 14: 10, 15: 10, 16: 10, 17: 10}
+
+	newStore := func() *fakeAnomalyStore {
+		return &fakeAnomalyStore{
+			counts: map[port.AuditAction]map[string][]int{
+				port.AuditActionLogin: {
+					"":       series(70, 4, 5, 6),
+					"user-1": series(2, 1),
+				},
+				port.AuditActionDelete: {
+					"user-1": series(30, 0),
+					"user-2": series(10, 0),
+					"user-3": series(30, 20, 30, 25),
+					"":       series(100, 0),
+				},
+			},
+			admins:  []AlertRecipient{admin, {UserID: "admin-2", Email: "new@example.com"}},
+			usual:   map[string][24]int{"admin-1": officeHours, "admin-2": {3: 5}},
+			current: map[string][24]int{"admin-1": {3: 4, 10: 2}, "admin-2": {3: 1}},
+		}
+	}
+
+	t.Run("flags anomalies and notifies admins", func(t *testing.T) {
+		store := newStore()
+		sender := &mockEmailSender{}
+		h := newAnomalyHandler(store, sender)
+
+		err := h.Handle(context.Background(), makeJob(t, worker.JobTypeAnomalyDetection, oneDay))
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Hour, store.bucket)
+		assert.Equal(t, 25, store.nBuckets, "a day of hourly buckets plus the window")
+
+		require.Len(t, store.saved, 3)
+		spike, deletion, hours := store.saved[0], store.saved[1], store.saved[2]
+
+		assert.Equal(t, AlertLoginSpike, spike.Kind)
+		assert.Equal(t, AlertSeverityHigh, spike.Severity)
+		assert.Equal(t, 72, spike.Details["logins"])
+		assert.Equal(t, time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC), spike.WindowStart, "the last full window")
+		assert.Equal(t, time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC), spike.WindowEnd)
+
+		assert.Equal(t, AlertMassDeletion, deletion.Kind)
+		assert.Equal(t, "user-1", deletion.UserID, "user-2 is under min_deletes, user-3 deletes this much every hour")
+
+		assert.Equal(t, AlertUnusualAdminHour, hours.Kind)
+		assert.Equal(t, "admin-1", hours.UserID, "admin-2 has too little history to judge")
+		assert.Equal(t, []int{3}, hours.Details["hours_utc"])
+
+		require.Len(t, sender.sent, 1)
+		assert.Equal(t, []string{"admin@example.com", "new@example.com"}, sender.sent[0].To)
+		assert.Contains(t, sender.sent[0].Subject, "3 new")
+		assert.Contains(t, sender.sent[0].Body, "mass_deletion")
+
+		t.Run("a rerun in the same window sends nothing new", func(t *testing.T) {
+			err := h.Handle(context.Background(), makeJob(t, worker.JobTypeAnomalyDetection, oneDay))
+			require.NoError(t, err)
+			assert.Len(t, store.saved, 3)
+			assert.Len(t, sender.sent, 1)
+		})
+	})
+
+	t.Run("quiet window", func(t *testing.T) {
+		store := newStore()
+		store.counts[port.AuditActionLogin][""] = series(8, 4, 5, 6)
+		delete(store.counts, port.AuditActionDelete)
+		store.current = nil
+		sender := &mockEmailSender{}
+
+		err := newAnomalyHandler(store, sender).Handle(context.Background(), makeJob(t, worker.JobTypeAnomalyDetection, oneDay))
+		require.NoError(t, err)
+		assert.Empty(t, store.saved)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("dry run stores and sends nothing", func(t *testing.T) {
+		store := newStore()
+		sender := &mockEmailSender{}
+		payload := oneDay
+		payload.DryRun = true
+
+		err := newAnomalyHandler(store, sender).Handle(context.Background(), makeJob(t, worker.JobTypeAnomalyDetection, payload))
+		require.NoError(t, err)
+		assert.Empty(t, store.saved)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("notification failure is not retried", func(t *testing.T) {
+		store := newStore()
+		sender := &mockEmailSender{err: fmt.Errorf("smtp down")}
+
+		err := newAnomalyHandler(store, sender).Handle(context.Background(), makeJob(t, worker.JobTypeAnomalyDetection, oneDay))
+		require.NoError(t, err)
+		assert.Len(t, store.saved, 3)
+	})
+
+	t.Run("window longer than the baseline is permanent", func(t *testing.T) {
+		err := newAnomalyHandler(newStore(), &mockEmailSender{}).Handle(context.Background(), makeJob(t, worker.JobTypeAnomalyDetection,
+			AnomalyDetectionPayload{WindowMinutes: 24 * 60, BaselineDays: 1}))
+		assert.True(t, joberr.IsPermanent(err))
+	})
+}
+
+func TestIsSpike(t *testing.T) {
+	_, _, spike := isSpike([]int{0, 0, 0}, 3, 3, 1)
+	assert.False(t, spike, "an empty baseline still needs more than threshold events")
+	_, _, spike = isSpike([]int{0, 0, 0}, 4, 3, 1)
+	assert.True(t, spike)
+
+	mean, limit, spike := isSpike([]int{100, 100, 100}, 120, 3, 1)
+	assert.Equal(t, 100.0, mean)
+	assert.Equal(t, 130.0, limit, "a flat baseline uses the Poisson spread")
+	assert.False(t, spike)
 }
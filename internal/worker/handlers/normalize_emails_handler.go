@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// NormalizeEmailsPayload represents the options for an email backfill
+type NormalizeEmailsPayload struct {
+	DryRun bool `json:"dry_run"` // log what would change, change nothing
+}
+
+// EmailNormalizeStore is the subset of the user repository the backfill needs.
+// *userrepo.Repository satisfies it.
+type EmailNormalizeStore interface {
+	List(ctx context.Context, filter userdomain.UserFilter) ([]userdomain.User, error)
+	Update(ctx context.Context, id, name, email string) (*userdomain.User, error)
+	NormalizeEmail(email string) string
+}
+
+// NormalizeEmailsHandler rewrites stored emails into their canonical form and
+// reports accounts whose emails collide once normalized.
+type NormalizeEmailsHandler struct {
+	store  EmailNormalizeStore
+	logger *logger.Logger
+}
+
+// NewNormalizeEmailsHandler creates a new email normalization handler
+func NewNormalizeEmailsHandler(store EmailNormalizeStore, log *logger.Logger) *NormalizeEmailsHandler {
+	return &NormalizeEmailsHandler{
+		store:  store,
+		logger: log,
+	}
+}
+
+// Type returns the job type this handler processes
+func (h *NormalizeEmailsHandler) Type() string {
+	return worker.JobTypeNormalizeEmails
+}
+
+// Handle scans every user, active or not, and groups them by canonical email.
+// A group of one is rewritten if its stored email is not canonical. A larger
+// group is a collision: it is logged and left alone, because only a merge
+// (POST /api/users/:id/merge) can decide which account survives.
+//
+// Per-user failures are logged and skipped; rerunning the job is safe.
+func (h *NormalizeEmailsHandler) Handle(ctx context.Context, job *worker.Job) error {
+	var payload NormalizeEmailsPayload
+	if err := job.UnmarshalPayload(&payload); err != nil {
+		return joberr.Permanentf("failed to unmarshal normalize emails payload: %w", err)
+	}
+
+	groups, scanned, err := h.groupByCanonical(ctx)
+	if err != nil {
+		return err
+	}
+
+	canonical := make([]string, 0, len(groups))
+	for email := range groups {
+		canonical = append(canonical, email)
+	}
+	sort.Strings(canonical)
+
+	normalized, collisions, failed := 0, 0, 0
+	for _, email := range canonical {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		users := groups[email]
+		if len(users) > 1 {
+			collisions++
+			ids := make([]string, len(users))
+			for i, u := range users {
+				ids[i] = u.ID.String()
+			}
+			h.logger.Warn("Email collision; merge these accounts",
+				"email", email,
+				"user_ids", ids,
+				"job_id", job.ID,
+			)
+			continue
+		}
+
+		u := users[0]
+		if u.Email == email {
+			continue
+		}
+		if payload.DryRun {
+			h.logger.Info("Email would be normalized (dry run)", "user_id", u.ID.String(), "email", email, "job_id", job.ID)
+			normalized++
+			continue
+		}
+		if _, err := h.store.Update(ctx, u.ID.String(), "", email); err != nil {
+			failed++
+			h.logger.Error("Failed to normalize email",
+				"user_id", u.ID.String(),
+				"error", err,
+				"job_id", job.ID,
+			)
+			continue
+		}
+		normalized++
+	}
+
+	h.logger.Info("Email normalization completed",
+		"scanned", scanned,
+		"normalized", normalized,
+		"collisions", collisions,
+		"failed", failed,
+		"dry_run", payload.DryRun,
+		"job_id", job.ID,
+	)
+
+	return nil
+}
+
+// groupByCanonical pages through all users by ID and groups them by canonical
+// email. It returns the groups and the number of users scanned.
+func (h *NormalizeEmailsHandler) groupByCanonical(ctx context.Context) (map[string][]userdomain.User, int, error) {
+	groups := make(map[string][]userdomain.User)
+	scanned := 0
+	cursor := ""
+	for {
+		// List fetches one row past the limit to signal another page.
+		page, err := h.store.List(ctx, userdomain.UserFilter{Cursor: cursor, Limit: userdomain.MaxLimit})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list users: %w", err)
+		}
+		more := len(page) > userdomain.MaxLimit
+		if more {
+			page = page[:userdomain.MaxLimit]
+		}
+
+		for _, u := range page {
+			email := h.store.NormalizeEmail(u.Email)
+			groups[email] = append(groups[email], u)
+		}
+		scanned += len(page)
+
+		if !more {
+			return groups, scanned, nil
+		}
+		cursor = page[len(page)-1].ID.String()
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	authdomain "github.com/14mdzk/goscratch/internal/module/auth/domain"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// SessionSweepPayload represents the options for a session sweep
+type SessionSweepPayload struct {
+	RevokeUserIDs []string `json:"revoke_user_ids"` // revoke every session of these users first
+	DryRun        bool     `json:"dry_run"`         // log what would happen, change nothing
+}
+
+// SessionSweeper is the session maintenance the sweep needs.
+// *authusecase.SessionSweeper satisfies it.
+type SessionSweeper interface {
+	RevokeUsers(ctx context.Context, userIDs []string, dryRun bool) (int, error)
+	Sweep(ctx context.Context, dryRun bool) (*authdomain.SessionSweepResult, error)
+}
+
+// SessionSweepHandler handles refresh-token session sweeps
+type SessionSweepHandler struct {
+	sweeper SessionSweeper
+	logger  *logger.Logger
+}
+
+// NewSessionSweepHandler creates a new session sweep handler
+func NewSessionSweepHandler(sweeper SessionSweeper, log *logger.Logger) *SessionSweepHandler {
+	return &SessionSweepHandler{
+		sweeper: sweeper,
+		logger:  log,
+	}
+}
+
+// Type returns the job type this handler processes
+func (h *SessionSweepHandler) Type() string {
+	return worker.JobTypeSessionSweep
+}
+
+// ReadOnlySafe reports that sessions live in the cache, not the database, so
+// sweeps and bulk revocations keep running in read-only mode. Revoking
+// sessions is often part of the incident that turned it on.
+func (h *SessionSweepHandler) ReadOnlySafe() bool {
+	return true
+}
+
+// Handle revokes the sessions of the listed users, then sweeps every session.
+// Both steps are idempotent, so a failed job is safe to retry.
+func (h *SessionSweepHandler) Handle(ctx context.Context, job *worker.Job) error {
+	var payload SessionSweepPayload
+	if err := job.UnmarshalPayload(&payload); err != nil {
+		return joberr.Permanentf("failed to unmarshal session sweep payload: %w", err)
+	}
+
+	if len(payload.RevokeUserIDs) > 0 {
+		revoked, err := h.sweeper.RevokeUsers(ctx, payload.RevokeUserIDs, payload.DryRun)
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		h.logger.Info("Revoked user sessions",
+			"users", len(payload.RevokeUserIDs),
+			"sessions_revoked", revoked,
+			"dry_run", payload.DryRun,
+			"job_id", job.ID,
+		)
+	}
+
+	result, err := h.sweeper.Sweep(ctx, payload.DryRun)
+	if err != nil {
+		return fmt.Errorf("failed to sweep sessions: %w", err)
+	}
+
+	h.logger.Info("Session sweep completed",
+		"users", result.Users,
+		"sessions", result.Sessions,
+		"max_per_user", result.MaxPerUser,
+		"orphans_removed", result.OrphansRemoved,
+		"evicted", result.Evicted,
+		"dry_run", payload.DryRun,
+		"job_id", job.ID,
+	)
+	return nil
+}
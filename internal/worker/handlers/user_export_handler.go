@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+
+	userdomain "github.com/14mdzk/goscratch/internal/module/user/domain"
+	userusecase "github.com/14mdzk/goscratch/internal/module/user/usecase"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/internal/worker"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+	"github.com/14mdzk/goscratch/pkg/types"
+	"github.com/google/uuid"
+)
+
+// UserExportStore is the subset of the user repository an export needs.
+// *userrepo.Repository satisfies it.
+type UserExportStore interface {
+	Stream(ctx context.Context, filter userdomain.UserFilter) iter.Seq2[userdomain.User, error]
+}
+
+// UserExportHandler writes asynchronous user exports to storage
+type UserExportHandler struct {
+	store   UserExportStore
+	storage port.Storage
+	logger  *logger.Logger
+}
+
+// NewUserExportHandler creates a new user export handler
+func NewUserExportHandler(store UserExportStore, storage port.Storage, log *logger.Logger) *UserExportHandler {
+	return &UserExportHandler{
+		store:   store,
+		storage: storage,
+		logger:  log,
+	}
+}
+
+// Type returns the job type this handler processes
+func (h *UserExportHandler) Type() string {
+	return worker.JobTypeUserExport
+}
+
+// ReadOnlySafe reports that exports only read the database, so they keep
+// running in read-only mode.
+func (h *UserExportHandler) ReadOnlySafe() bool {
+	return true
+}
+
+// Handle writes one export.
+//
+// The export is spooled to a temporary file and uploaded once it is complete,
+// so GET /users/exports/:id never links to a partial file. A failed run
+// uploads nothing and is retried from the start.
+func (h *UserExportHandler) Handle(ctx context.Context, job *worker.Job) error {
+	var payload worker.UserExportPayload
+	if err := job.UnmarshalPayload(&payload); err != nil {
+		return joberr.Permanentf("failed to unmarshal user export payload: %w", err)
+	}
+	// The ID becomes part of the storage path.
+	if _, err := uuid.Parse(payload.ExportID); err != nil {
+		return joberr.Permanentf("invalid export id %q", payload.ExportID)
+	}
+	if payload.Format != userusecase.ExportFormatCSV && payload.Format != userusecase.ExportFormatNDJSON {
+		return joberr.Permanentf("unknown export format %q", payload.Format)
+	}
+
+	var filter userdomain.UserFilter
+	if payload.Search != "" {
+		filter.Search = types.Some(payload.Search)
+	}
+	if payload.Email != "" {
+		filter.Email = types.Some(payload.Email)
+	}
+	if payload.IsActive != nil {
+		filter.IsActive = types.Some(*payload.IsActive)
+	}
+	if payload.Role != "" {
+		filter.Role = types.Some(payload.Role)
+	}
+	filter.IncludeDeleted = payload.IncludeDeleted
+	filter.Metadata = payload.Metadata
+	filter.MetadataKeys = payload.MetadataKeys
+
+	tmp, err := os.CreateTemp("", "users-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rows, err := userusecase.WriteUsers(bufio.NewWriter(tmp), payload.Format, h.store.Stream(ctx, filter))
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+
+	path := userusecase.ExportPath(payload.ExportID, payload.Format)
+	if _, err := h.storage.Upload(ctx, path, tmp, port.WithContentType(userusecase.ExportContentType(payload.Format))); err != nil {
+		return fmt.Errorf("failed to upload export: %w", err)
+	}
+
+	h.logger.Info("User export completed",
+		"export_id", payload.ExportID,
+		"format", payload.Format,
+		"rows", rows,
+		"path", path,
+		"job_id", job.ID,
+	)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long a succeeded idempotency key is kept
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// idempotencyLockTTL bounds how long a running job holds its key, so a
+	// worker that dies mid-job does not block the redelivery for long.
+	idempotencyLockTTL = jobTimeout + time.Minute
+
+	// duplicateDelay is how long a job waits before it is redelivered when
+	// another worker is running a job with the same key
+	duplicateDelay = 30 * time.Second
+)
+
+// claim is the outcome of checking a job's idempotency key
+type claim int
+
+const (
+	// claimRun runs the job: it has no key, its key is free, or the cache
+	// could not be reached
+	claimRun claim = iota
+	// claimDone skips the job: a job with its key already succeeded
+	claimDone
+	// claimBusy defers the job: a job with its key is running elsewhere
+	claimBusy
+)
+
+func idempotencyDoneKey(job *Job) string {
+	return "jobs:idempotency:" + job.Type + ":" + job.IdempotencyKey
+}
+
+func idempotencyLockKey(job *Job) string {
+	return "jobs:idempotency:" + job.Type + ":" + job.IdempotencyKey + ":running"
+}
+
+// claimIdempotencyKey checks job's idempotency key and takes it for the run.
+// Cache errors are logged and the job runs: without the cache, delivery is
+// at-least-once as it is for jobs without a key.
+func (w *Worker) claimIdempotencyKey(job *Job) claim {
+	if w.dedupe == nil || job.IdempotencyKey == "" {
+		return claimRun
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), recordTimeout)
+	defer cancel()
+
+	done, err := w.dedupe.Exists(ctx, idempotencyDoneKey(job))
+	if err != nil {
+		w.logger.Warn("Failed to check idempotency key; running job", "error", err, "job_id", job.ID)
+		return claimRun
+	}
+	if done {
+		return claimDone
+	}
+
+	lockKey := idempotencyLockKey(job)
+	n, err := w.dedupe.Increment(ctx, lockKey)
+	if err != nil {
+		w.logger.Warn("Failed to lock idempotency key; running job", "error", err, "job_id", job.ID)
+		return claimRun
+	}
+	if n > 1 {
+		return claimBusy
+	}
+	if err := w.dedupe.Expire(ctx, lockKey, idempotencyLockTTL); err != nil {
+		w.logger.Warn("Failed to set idempotency lock expiry", "error", err, "job_id", job.ID)
+	}
+	return claimRun
+}
+
+// releaseIdempotencyKey ends a run of job. A success marks the key done for
+// Config.IdempotencyTTL; a failure frees it for the retry.
+func (w *Worker) releaseIdempotencyKey(job *Job, succeeded bool) {
+	if w.dedupe == nil || job.IdempotencyKey == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), recordTimeout)
+	defer cancel()
+
+	if succeeded {
+		if err := w.dedupe.Set(ctx, idempotencyDoneKey(job), []byte(job.ID), w.dedupeTTL); err != nil {
+			w.logger.Warn("Failed to mark idempotency key done", "error", err, "job_id", job.ID)
+		}
+	}
+	if err := w.dedupe.Delete(ctx, idempotencyLockKey(job)); err != nil {
+		w.logger.Warn("Failed to unlock idempotency key", "error", err, "job_id", job.ID)
+	}
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func newIdempotencyWorker(t *testing.T, q port.Queue, handleFn func(context.Context, *Job) error) (*Worker, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rc, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { rc.Close() })
+
+	w := New(q, newTestLogger(), Config{Idempotency: rc, IdempotencyTTL: time.Hour})
+	w.RegisterHandler(&testHandler{jobType: "email.send", handleFn: handleFn})
+	return w, mr
+}
+
+func TestHandleMessage_IdempotencyKeyRunsOnce(t *testing.T) {
+	var runs atomic.Int32
+	w, mr := newIdempotencyWorker(t, &mockQueue{}, func(context.Context, *Job) error {
+		runs.Add(1)
+		return nil
+	})
+
+	job, _ := NewJob("email.send", "data")
+	job.IdempotencyKey = "welcome:42"
+	data, _ := job.Encode()
+
+	// A redelivery and a second publish with the same key
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	again, _ := NewJob("email.send", "data")
+	again.IdempotencyKey = "welcome:42"
+	data, _ = again.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+	assert.Equal(t, int32(1), runs.Load())
+	assert.True(t, mr.Exists("jobs:idempotency:email.send:welcome:42"))
+	assert.Equal(t, time.Hour, mr.TTL("jobs:idempotency:email.send:welcome:42"))
+	assert.False(t, mr.Exists("jobs:idempotency:email.send:welcome:42:running"), "the lock is released")
+
+	t.Run("jobs without a key always run", func(t *testing.T) {
+		job, _ := NewJob("email.send", "data")
+		data, _ := job.Encode()
+		require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+		require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+		assert.Equal(t, int32(3), runs.Load())
+	})
+}
+
+func TestHandleMessage_IdempotencyKeyFreedOnFailure(t *testing.T) {
+	q := &delayedQueue{}
+	var runs atomic.Int32
+	w, mr := newIdempotencyWorker(t, q, func(context.Context, *Job) error {
+		if runs.Add(1) == 1 {
+			return errors.New("smtp down")
+		}
+		return nil
+	})
+
+	job, _ := NewJob("email.send", "data")
+	job.IdempotencyKey = "reset:7"
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	assert.False(t, mr.Exists("jobs:idempotency:email.send:reset:7"), "a failure is not remembered")
+
+	// The retry runs
+	require.Len(t, q.publishCalls, 1)
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: q.lastCall().body}))
+	assert.Equal(t, int32(2), runs.Load())
+	assert.True(t, mr.Exists("jobs:idempotency:email.send:reset:7"))
+}
+
+func TestHandleMessage_IdempotencyKeyRunningElsewhere(t *testing.T) {
+	q := &delayedQueue{}
+	var runs atomic.Int32
+	w, mr := newIdempotencyWorker(t, q, func(context.Context, *Job) error {
+		runs.Add(1)
+		return nil
+	})
+	require.NoError(t, mr.Set("jobs:idempotency:email.send:export:1:running", "1"))
+
+	job, _ := NewJob("email.send", "data")
+	job.IdempotencyKey = "export:1"
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+	assert.Zero(t, runs.Load())
+	assert.Equal(t, []time.Duration{duplicateDelay}, q.delays, "put back for later")
+	deferred, err := DecodeJob(q.lastCall().body)
+	require.NoError(t, err)
+	assert.Equal(t, 0, deferred.Attempts, "no attempt is spent")
+}
+
+// downCache is a cache whose backend cannot be reached
+type downCache struct{ *cache.NoOpCache }
+
+func (downCache) Exists(context.Context, string) (bool, error) {
+	return false, port.ErrCacheUnavailable
+}
+
+func TestHandleMessage_IdempotencyCacheDownRunsJob(t *testing.T) {
+	var runs atomic.Int32
+	w := New(&mockQueue{}, newTestLogger(), Config{Idempotency: downCache{cache.NewNoOpCache()}})
+	w.RegisterHandler(&testHandler{jobType: "email.send", handleFn: func(context.Context, *Job) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	job, _ := NewJob("email.send", "data")
+	job.IdempotencyKey = "welcome:1"
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func TestPublisher_PublishOnce(t *testing.T) {
+	q := &mockQueue{}
+	pub := NewPublisher(q, "jobs", "")
+	require.NoError(t, pub.PublishOnce(context.Background(), "email.send", "data", "welcome:42"))
+
+	job, err := DecodeJob(q.lastCall().body)
+	require.NoError(t, err)
+	assert.Equal(t, "welcome:42", job.IdempotencyKey)
+}
+
+func TestProtobufCodec_IdempotencyKey(t *testing.T) {
+	job := &Job{ID: "id-1", Type: "test.codec", IdempotencyKey: "order:9"}
+	var decoded Job
+	require.NoError(t, ProtobufCodec.Unmarshal(marshalProtoJob(job), &decoded))
+	assert.Equal(t, "order:9", decoded.IdempotencyKey)
+}
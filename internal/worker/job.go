@@ -5,22 +5,40 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/port"
 	"github.com/google/uuid"
 )
 
 // Job represents a background job to be processed
 type Job struct {
-	ID        string          `json:"id"`
-	Type      string          `json:"type"`
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Payload is encoded with the job's codec: raw JSON unless the job was
+	// created or received with another Codec. Use UnmarshalPayload.
 	Payload   json.RawMessage `json:"payload"`
 	Attempts  int             `json:"attempts"`
 	MaxRetry  int             `json:"max_retry"`
 	CreatedAt time.Time       `json:"created_at"`
+	// Priority orders the job on a priority queue; zero (jobs published
+	// before priorities) goes out as PriorityNormal.
+	Priority Priority `json:"priority,omitempty"`
+	// IdempotencyKey, when set, makes workers with Config.Idempotency run
+	// at most one job of the type with this key successfully, so a
+	// redelivered or republished job does not repeat its side effects.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	codec Codec
 }
 
 // NewJob creates a new job with the given type and payload
 func NewJob(jobType string, payload any) (*Job, error) {
-	data, err := json.Marshal(payload)
+	return NewJobWithCodec(jobType, payload, JSONCodec)
+}
+
+// NewJobWithCodec creates a new job whose payload, and the job itself when
+// encoded, use codec.
+func NewJobWithCodec(jobType string, payload any, codec Codec) (*Job, error) {
+	data, err := codec.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +50,8 @@ func NewJob(jobType string, payload any) (*Job, error) {
 		Attempts:  0,
 		MaxRetry:  3,
 		CreatedAt: time.Now(),
+		Priority:  PriorityNormal,
+		codec:     codec,
 	}, nil
 }
 
@@ -45,23 +65,60 @@ func NewJobWithRetry(jobType string, payload any, maxRetry int) (*Job, error) {
 	return job, nil
 }
 
-// Encode serializes the job to JSON bytes
+// Record returns the job's tracked state on queue with status
+func (j *Job) Record(queue string, status port.JobStatus) port.JobRecord {
+	return port.JobRecord{
+		ID:        j.ID,
+		Type:      j.Type,
+		Queue:     queue,
+		Status:    status,
+		Attempts:  j.Attempts,
+		MaxRetry:  j.MaxRetry,
+		CreatedAt: j.CreatedAt,
+	}
+}
+
+// Codec returns the codec the job is encoded with (default: JSON).
+func (j *Job) Codec() Codec {
+	if j.codec == nil {
+		return JSONCodec
+	}
+	return j.codec
+}
+
+// Encode serializes the job with its codec; publish it with
+// Codec().ContentType()
 func (j *Job) Encode() ([]byte, error) {
-	return json.Marshal(j)
+	return j.Codec().Marshal(j)
 }
 
 // DecodeJob deserializes a job from JSON bytes
 func DecodeJob(data []byte) (*Job, error) {
+	return decodeJob(data, JSONCodec)
+}
+
+// DecodeMessage deserializes a job with the codec negotiated from the
+// message's content type. The job keeps that codec for retries.
+func DecodeMessage(msg port.Message) (*Job, error) {
+	codec, err := CodecForContentType(msg.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(msg.Body, codec)
+}
+
+func decodeJob(data []byte, codec Codec) (*Job, error) {
 	var job Job
-	if err := json.Unmarshal(data, &job); err != nil {
+	if err := codec.Unmarshal(data, &job); err != nil {
 		return nil, err
 	}
+	job.codec = codec
 	return &job, nil
 }
 
 // UnmarshalPayload decodes the job payload into the given struct
 func (j *Job) UnmarshalPayload(v any) error {
-	return json.Unmarshal(j.Payload, v)
+	return j.Codec().Unmarshal(j.Payload, v)
 }
 
 // CanRetry returns true if the job can be retried
@@ -80,10 +137,19 @@ type JobHandler interface {
 	Type() string
 
 	// Handle processes the job payload
-	// Returns an error if processing fails
+	// Returns an error if processing fails. Wrap it with pkg/joberr to
+	// control retries: Permanent errors are dropped, RateLimited errors are
+	// retried after their delay, and anything else is retried with backoff.
 	Handle(ctx context.Context, job *Job) error
 }
 
+// ReadOnlySafe is implemented by handlers that do not write to the database.
+// While read-only mode is on, the worker runs only handlers that report true
+// and defers every other job.
+type ReadOnlySafe interface {
+	ReadOnlySafe() bool
+}
+
 // JobResult represents the result of processing a job
 type JobResult struct {
 	JobID   string
@@ -92,9 +158,61 @@ type JobResult struct {
 	Retry   bool
 }
 
+// EmailPayload is the payload of an email.send job. It lives here rather
+// than next to the handler so publishers can build it without importing the
+// handlers package.
+type EmailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	HTML    bool   `json:"html,omitempty"`
+	Tenant  string `json:"tenant,omitempty"` // sender profile; empty means the default
+}
+
+// UserExportPayload is the payload of a users.export job, published by the
+// user module for an asynchronous export. The filters are those of
+// GET /users; unset filters are omitted.
+type UserExportPayload struct {
+	ExportID string `json:"export_id"`
+	Format   string `json:"format"` // csv or ndjson
+	Search   string `json:"search,omitempty"`
+	Email    string `json:"email,omitempty"`
+	IsActive *bool  `json:"is_active,omitempty"`
+	Role     string `json:"role,omitempty"`
+
+	IncludeDeleted bool              `json:"include_deleted,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	MetadataKeys   []string          `json:"metadata_keys,omitempty"`
+}
+
+// AuditExportPayload is the payload of an audit.export job, published by
+// the audit module for an export too large to stream. The filters are those
+// of GET /audit-logs; unset filters are omitted.
+type AuditExportPayload struct {
+	ExportID   string         `json:"export_id"`
+	Format     string         `json:"format"` // csv or ndjson
+	UserID     string         `json:"user_id,omitempty"`
+	Involving  string         `json:"involving,omitempty"`
+	Actions    []string       `json:"actions,omitempty"`
+	Resources  []string       `json:"resources,omitempty"`
+	ResourceID string         `json:"resource_id,omitempty"`
+	RequestID  string         `json:"request_id,omitempty"`
+	TraceID    string         `json:"trace_id,omitempty"`
+	Contains   map[string]any `json:"contains,omitempty"`
+	Search     string         `json:"search,omitempty"`
+	From       *time.Time     `json:"from,omitempty"`
+	To         *time.Time     `json:"to,omitempty"`
+}
+
 // Common job types
 const (
-	JobTypeEmailSend    = "email.send"
-	JobTypeAuditCleanup = "audit.cleanup"
-	JobTypeNotification = "notification.send"
+	JobTypeEmailSend        = "email.send"
+	JobTypeAuditCleanup     = "audit.cleanup"
+	JobTypeNotification     = "notification.send"
+	JobTypeDormantUsers     = "users.dormant"
+	JobTypeNormalizeEmails  = "users.normalize_emails"
+	JobTypeUserExport       = "users.export"
+	JobTypeAuditExport      = "audit.export"
+	JobTypeSessionSweep     = "auth.sessions_sweep"
+	JobTypeAnomalyDetection = "security.detect_anomalies"
 )
@@ -159,4 +159,8 @@ func TestJobConstants(t *testing.T) {
 	assert.Equal(t, "email.send", JobTypeEmailSend)
 	assert.Equal(t, "audit.cleanup", JobTypeAuditCleanup)
 	assert.Equal(t, "notification.send", JobTypeNotification)
+	assert.Equal(t, "users.dormant", JobTypeDormantUsers)
+	assert.Equal(t, "users.normalize_emails", JobTypeNormalizeEmails)
+	assert.Equal(t, "auth.sessions_sweep", JobTypeSessionSweep)
+	assert.Equal(t, "security.detect_anomalies", JobTypeAnomalyDetection)
 }
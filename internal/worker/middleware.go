@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// HandlerFunc runs a job, like JobHandler.Handle
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// JobMiddleware wraps the run of every job. It can act before and after
+// calling next, hand next a derived context, or return without calling it.
+// The error it returns decides the job's fate like a handler's does.
+type JobMiddleware func(next HandlerFunc) HandlerFunc
+
+// chain wraps h in mws, the first outermost
+func chain(h HandlerFunc, mws ...JobMiddleware) HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// workerIDKey carries the ID of the consumer goroutine running a job
+type workerIDKey struct{}
+
+// recoverJob turns a handler panic into an error, so the job is retried or
+// dead-lettered like any failure instead of crashing the worker
+func recoverJob(log *logger.Logger) JobMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *Job) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Error("Job panicked",
+						"job_id", job.ID,
+						"job_type", job.Type,
+						"panic", fmt.Sprint(p),
+						"stack", string(debug.Stack()),
+					)
+					err = fmt.Errorf("job panicked: %v", p)
+				}
+			}()
+			return next(ctx, job)
+		}
+	}
+}
+
+// logJob logs the start and outcome of each run. Lines carry the context's
+// trace ID when there is one.
+func logJob(log *logger.Logger) JobMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *Job) error {
+			l := log.WithContext(ctx)
+			workerID, _ := ctx.Value(workerIDKey{}).(int)
+			l.Info("Processing job",
+				"job_id", job.ID,
+				"job_type", job.Type,
+				"attempt", job.Attempts,
+				"worker_id", workerID,
+			)
+
+			start := time.Now()
+			err := next(ctx, job)
+			duration := time.Since(start)
+
+			if err != nil {
+				l.Error("Job failed",
+					"job_id", job.ID,
+					"job_type", job.Type,
+					"error", err,
+					"error_kind", joberr.Classify(err).String(),
+					"duration_ms", duration.Milliseconds(),
+					"attempt", job.Attempts,
+				)
+				return err
+			}
+			l.Info("Job completed successfully",
+				"job_id", job.ID,
+				"job_type", job.Type,
+				"duration_ms", duration.Milliseconds(),
+			)
+			return nil
+		}
+	}
+}
+
+// Timeout cancels a run's context after d. The worker applies it with five
+// minutes to every job; a shorter one can be added for some jobs.
+func Timeout(d time.Duration) JobMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *Job) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, job)
+		}
+	}
+}
+
+// Tracing runs each job in a consumer span named "job <type>", recording
+// its error. Handlers see the span in their context, and its trace ID is
+// set for log lines.
+func Tracing(tracer trace.Tracer) JobMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *Job) error {
+			ctx, span := tracer.Start(ctx, "job "+job.Type,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("job.id", job.ID),
+					attribute.String("job.type", job.Type),
+					attribute.Int("job.attempt", job.Attempts),
+				),
+			)
+			defer span.End()
+			if sc := span.SpanContext(); sc.HasTraceID() {
+				ctx = context.WithValue(ctx, logger.TraceIDKey, sc.TraceID().String())
+			}
+
+			err := next(ctx, job)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				span.SetAttributes(attribute.String("job.error_kind", joberr.Classify(err).String()))
+			}
+			return err
+		}
+	}
+}
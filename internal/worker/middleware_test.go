@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/joberr"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// recordingMiddleware appends name to calls before and after next
+func recordingMiddleware(name string, calls *[]string) JobMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *Job) error {
+			*calls = append(*calls, name+" before")
+			err := next(ctx, job)
+			*calls = append(*calls, name+" after")
+			return err
+		}
+	}
+}
+
+func TestHandleMessage_MiddlewareOrder(t *testing.T) {
+	var calls []string
+	w := New(&mockQueue{}, newTestLogger(), Config{Middleware: []JobMiddleware{
+		recordingMiddleware("outer", &calls),
+		recordingMiddleware("inner", &calls),
+	}})
+	w.RegisterHandler(&testHandler{jobType: "email.send", handleFn: func(ctx context.Context, _ *Job) error {
+		calls = append(calls, "handler")
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline, "the worker's timeout applies inside the middleware")
+		return nil
+	}})
+
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+	assert.Equal(t, []string{"outer before", "inner before", "handler", "inner after", "outer after"}, calls)
+}
+
+func TestHandleMessage_MiddlewareErrorDecidesFate(t *testing.T) {
+	q := &delayedQueue{}
+	ran := false
+	w := New(q, newTestLogger(), Config{Middleware: []JobMiddleware{
+		func(next HandlerFunc) HandlerFunc {
+			return func(context.Context, *Job) error {
+				return joberr.Permanent(errors.New("tenant suspended"))
+			}
+		},
+	}})
+	w.RegisterHandler(&testHandler{jobType: "email.send", handleFn: func(context.Context, *Job) error {
+		ran = true
+		return nil
+	}})
+
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+	assert.False(t, ran, "a middleware may skip the handler")
+	assert.Empty(t, q.publishCalls, "a permanent error is not retried")
+}
+
+func TestHandleMessage_RecoversPanics(t *testing.T) {
+	q := &delayedQueue{}
+	w := New(q, newTestLogger(), Config{})
+	w.RegisterHandler(&testHandler{jobType: "email.send", handleFn: func(context.Context, *Job) error {
+		panic("nil template")
+	}})
+
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+	require.NotPanics(t, func() {
+		require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	})
+
+	require.Len(t, q.publishCalls, 1, "a panicking job is retried like a failed one")
+	retried, err := DecodeJob(q.lastCall().body)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retried.Attempts)
+}
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(10 * time.Millisecond)(func(ctx context.Context, _ *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, h(context.Background(), &Job{}), context.DeadlineExceeded)
+}
+
+func TestTracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+
+	var traceID string
+	h := Tracing(tracer)(func(ctx context.Context, _ *Job) error {
+		assert.True(t, trace.SpanFromContext(ctx).SpanContext().IsValid(), "handlers see the span")
+		traceID, _ = ctx.Value(logger.TraceIDKey).(string)
+		return errors.New("smtp down")
+	})
+	err := h(context.Background(), &Job{ID: "job-1", Type: "email.send", Attempts: 2})
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	assert.Equal(t, "job email.send", span.Name())
+	assert.Equal(t, trace.SpanKindConsumer, span.SpanKind())
+	assert.Equal(t, span.SpanContext().TraceID().String(), traceID)
+	assert.Subset(t, span.Attributes(), []attribute.KeyValue{
+		attribute.String("job.id", "job-1"),
+		attribute.String("job.type", "email.send"),
+		attribute.Int("job.attempt", 2),
+		attribute.String("job.error_kind", "transient"),
+	})
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
@@ -0,0 +1,193 @@
+// Package outbox relays the messages of the transactional outbox to the
+// queue.
+//
+// Producers store messages with port.Outbox in the transaction of the change
+// they describe, so a message exists if and only if its change committed.
+// Every worker instance runs a relay that polls for unpublished messages.
+// Each poll locks a batch with FOR UPDATE SKIP LOCKED, so instances share
+// the work without publishing a message twice at the same time, and
+// publishes it in the order the messages were stored. A failed publish stops
+// the batch, keeping that order, and is retried at the next poll.
+//
+// Delivery is at-least-once: a relay that dies after publishing a message but
+// before marking it published publishes it again. Messages carry a stable ID
+// for consumers to drop duplicates by; JetStream drops them itself within
+// its duplicate window.
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// Defaults for Config fields left zero
+const (
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 100
+	DefaultRetention    = 7 * 24 * time.Hour
+)
+
+const (
+	// purgeInterval is how often published messages past their retention
+	// are deleted
+	purgeInterval = time.Hour
+	// publishTimeout bounds publishing one message
+	publishTimeout = 5 * time.Second
+)
+
+// Message is a stored outbox message
+type Message struct {
+	ID          int64
+	MessageID   string
+	Exchange    string
+	RoutingKey  string
+	ContentType string
+	Headers     map[string]any
+	Priority    uint8
+	Body        []byte
+	// Attempts counts the publishes tried so far
+	Attempts int
+}
+
+// publishOptions returns the options m is published with
+func (m Message) publishOptions() []port.PublishOption {
+	opts := []port.PublishOption{port.WithPublishMessageID(m.MessageID)}
+	if m.ContentType != "" {
+		opts = append(opts, port.WithPublishContentType(m.ContentType))
+	}
+	if len(m.Headers) > 0 {
+		opts = append(opts, port.WithPublishHeaders(m.Headers))
+	}
+	if m.Priority > 0 {
+		opts = append(opts, port.WithPublishPriority(m.Priority))
+	}
+	return opts
+}
+
+// Store holds outbox messages for the relay. *PostgresStore satisfies it.
+type Store interface {
+	// Relay locks up to limit unpublished messages, oldest first, and calls
+	// send for each in order until one fails. In the same transaction the
+	// sent messages are marked published and the failure is recorded on
+	// its message. It returns the number sent and send's error.
+	Relay(ctx context.Context, limit int, send func(context.Context, Message) error) (int, error)
+
+	// Purge deletes messages published before before and returns how many
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Config configures a Relay
+type Config struct {
+	// PollInterval is the wait between polls that found fewer than
+	// BatchSize messages (default: DefaultPollInterval). A full batch is
+	// followed by the next at once.
+	PollInterval time.Duration
+	// BatchSize caps the messages locked and published per poll (default:
+	// DefaultBatchSize)
+	BatchSize int
+	// Retention is how long published messages are kept (default:
+	// DefaultRetention)
+	Retention time.Duration
+}
+
+// Relay publishes outbox messages to the queue
+type Relay struct {
+	store  Store
+	queue  port.Queue
+	logger *logger.Logger
+	cfg    Config
+	now    func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a relay publishing store's messages to q
+func New(store Store, q port.Queue, log *logger.Logger, cfg Config) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	return &Relay{store: store, queue: q, logger: log, cfg: cfg, now: time.Now}
+}
+
+// Start starts polling
+func (r *Relay) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.run(ctx)
+}
+
+// Close stops polling and waits for a batch in progress
+func (r *Relay) Close() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+func (r *Relay) run(ctx context.Context) {
+	defer close(r.done)
+	var purged time.Time
+	for {
+		if r.now().Sub(purged) >= purgeInterval {
+			r.purge(ctx)
+			purged = r.now()
+		}
+
+		n, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Warn("Failed to relay outbox messages", "error", err, "published", n)
+		}
+		if err == nil && n == r.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		timer := time.NewTimer(r.cfg.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// RelayBatch publishes one batch of messages and returns how many were
+// published
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	return r.store.Relay(ctx, r.cfg.BatchSize, r.send)
+}
+
+func (r *Relay) send(ctx context.Context, m Message) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	return r.queue.Publish(ctx, m.Exchange, m.RoutingKey, m.Body, m.publishOptions()...)
+}
+
+// purge deletes published messages past their retention
+func (r *Relay) purge(ctx context.Context) {
+	n, err := r.store.Purge(ctx, r.now().Add(-r.cfg.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Warn("Failed to purge published outbox messages", "error", err)
+		}
+		return
+	}
+	if n > 0 {
+		r.logger.Info("Purged published outbox messages", "count", n)
+	}
+}
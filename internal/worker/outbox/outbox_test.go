@@ -0,0 +1,177 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+func newTestLogger() *logger.Logger {
+	return logger.New(logger.Config{Level: "debug", Format: "json", Output: &bytes.Buffer{}})
+}
+
+// memStore is an in-memory Store
+type memStore struct {
+	mu        sync.Mutex
+	pending   []Message
+	published []Message
+	failures  map[int64]string
+	purges    []time.Time
+}
+
+func (s *memStore) Relay(ctx context.Context, limit int, send func(context.Context, Message) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for len(s.pending) > 0 && n < limit {
+		m := s.pending[0]
+		if err := send(ctx, m); err != nil {
+			if s.failures == nil {
+				s.failures = map[int64]string{}
+			}
+			s.failures[m.ID] = err.Error()
+			s.pending[0].Attempts++
+			return n, err
+		}
+		s.pending = s.pending[1:]
+		s.published = append(s.published, m)
+		n++
+	}
+	return n, nil
+}
+
+func (s *memStore) Purge(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purges = append(s.purges, before)
+	return 0, nil
+}
+
+func (s *memStore) pendingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+type sentMessage struct {
+	exchange, routingKey string
+	body                 []byte
+	opts                 port.PublishOptions
+}
+
+// fakeQueue records published messages. Only Publish is implemented.
+type fakeQueue struct {
+	port.Queue
+	mu   sync.Mutex
+	sent []sentMessage
+	err  error
+}
+
+func (q *fakeQueue) Publish(_ context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	q.sent = append(q.sent, sentMessage{exchange, routingKey, body, port.ApplyPublishOptions(opts...)})
+	return nil
+}
+
+func (q *fakeQueue) routingKeys() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []string
+	for _, m := range q.sent {
+		out = append(out, m.routingKey)
+	}
+	return out
+}
+
+func messages(keys ...string) []Message {
+	out := make([]Message, len(keys))
+	for i, k := range keys {
+		out[i] = Message{ID: int64(i + 1), MessageID: "m-" + k, Exchange: "user.events", RoutingKey: k, Body: []byte(k)}
+	}
+	return out
+}
+
+func TestRelay_RelayBatch(t *testing.T) {
+	store := &memStore{pending: messages("a", "b", "c")}
+	store.pending[0].ContentType = "application/json"
+	store.pending[0].Headers = map[string]any{"x-source": "api"}
+	store.pending[0].Priority = 9
+	q := &fakeQueue{}
+	r := New(store, q, newTestLogger(), Config{BatchSize: 2})
+
+	n, err := r.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"a", "b"}, q.routingKeys())
+	assert.Equal(t, sentMessage{
+		exchange:   "user.events",
+		routingKey: "a",
+		body:       []byte("a"),
+		opts: port.PublishOptions{
+			ContentType: "application/json",
+			Headers:     map[string]any{"x-source": "api"},
+			Priority:    9,
+			MessageID:   "m-a",
+		},
+	}, q.sent[0])
+
+	n, err = r.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 0, store.pendingCount())
+}
+
+func TestRelay_PublishFailureKeepsOrder(t *testing.T) {
+	store := &memStore{pending: messages("a", "b")}
+	q := &fakeQueue{err: errors.New("broker down")}
+	r := New(store, q, newTestLogger(), Config{})
+
+	n, err := r.RelayBatch(context.Background())
+	assert.ErrorContains(t, err, "broker down")
+	assert.Zero(t, n)
+	assert.Equal(t, "broker down", store.failures[1])
+	assert.Equal(t, 2, store.pendingCount(), "nothing after the failed message is published")
+
+	q.err = nil
+	n, err = r.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"a", "b"}, q.routingKeys())
+}
+
+func TestRelay_StartDrainsAndPurges(t *testing.T) {
+	store := &memStore{pending: messages("a", "b", "c", "d", "e")}
+	q := &fakeQueue{}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	r := New(store, q, newTestLogger(), Config{BatchSize: 2, PollInterval: time.Hour, Retention: 24 * time.Hour})
+	r.now = func() time.Time { return now }
+
+	r.Start(context.Background())
+	// Full batches follow each other without waiting for the poll interval
+	require.Eventually(t, func() bool { return store.pendingCount() == 0 }, time.Second, time.Millisecond)
+	r.Close()
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, q.routingKeys())
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, store.purges)
+}
+
+func TestNew_Defaults(t *testing.T) {
+	r := New(&memStore{}, &fakeQueue{}, newTestLogger(), Config{})
+	assert.Equal(t, Config{PollInterval: DefaultPollInterval, BatchSize: DefaultBatchSize, Retention: DefaultRetention}, r.cfg)
+	r.Close() // not started
+}
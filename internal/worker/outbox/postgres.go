@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// PostgresStore keeps outbox messages in the outbox table. Producers add
+// messages through it as a port.Outbox; the relay reads them back as a
+// Store.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates an outbox store on pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+var (
+	_ port.Outbox = (*PostgresStore)(nil)
+	_ Store       = (*PostgresStore)(nil)
+)
+
+// Add implements port.Outbox. Header values are stored as JSON, so numbers
+// are published as float64.
+func (s *PostgresStore) Add(ctx context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
+	o := port.ApplyPublishOptions(opts...)
+	messageID := o.MessageID
+	if messageID == "" {
+		messageID = uuid.NewString()
+	}
+	var headers any
+	if len(o.Headers) > 0 {
+		headers = o.Headers
+	}
+	if body == nil {
+		body = []byte{}
+	}
+
+	_, err := database.DBFromContext(ctx, s.pool).Exec(ctx, `
+		INSERT INTO outbox (message_id, exchange, routing_key, content_type, headers, priority, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		messageID, exchange, routingKey, o.ContentType, headers, int16(o.Priority), body)
+	if err != nil {
+		return fmt.Errorf("failed to add outbox message: %w", err)
+	}
+	return nil
+}
+
+// selectPending locks the oldest unpublished messages, skipping those
+// another relay has locked
+const selectPending = `
+	SELECT id, message_id, exchange, routing_key, content_type, headers, priority, body, attempts
+	FROM outbox
+	WHERE published_at IS NULL
+	ORDER BY id
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+`
+
+// Relay implements Store
+func (s *PostgresStore) Relay(ctx context.Context, limit int, send func(context.Context, Message) error) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	// A no-op once committed. The relay's context is cancelled on shutdown,
+	// so the rollback gets its own.
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	msgs, err := pendingMessages(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent []int64
+	var sendErr error
+	for _, m := range msgs {
+		if sendErr = send(ctx, m); sendErr != nil {
+			if _, err := tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`,
+				m.ID, sendErr.Error()); err != nil {
+				return 0, fmt.Errorf("failed to record outbox failure: %w", err)
+			}
+			break
+		}
+		sent = append(sent, m.ID)
+	}
+	if len(sent) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = NULL, published_at = NOW() WHERE id = ANY($1)`,
+			sent); err != nil {
+			return 0, fmt.Errorf("failed to mark outbox messages published: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox transaction: %w", err)
+	}
+	return len(sent), sendErr
+}
+
+func pendingMessages(ctx context.Context, tx pgx.Tx, limit int) ([]Message, error) {
+	rows, err := tx.Query(ctx, selectPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Message
+	for rows.Next() {
+		var m Message
+		var priority int16
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.Exchange, &m.RoutingKey, &m.ContentType,
+			&m.Headers, &priority, &m.Body, &m.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		m.Priority = uint8(priority)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Purge implements Store
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox messages: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
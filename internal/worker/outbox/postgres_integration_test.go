@@ -0,0 +1,151 @@
+//go:build integration
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+	store := NewPostgresStore(pool)
+	transactor := database.NewTransactor(pool)
+
+	collect := func(got *[]Message) func(context.Context, Message) error {
+		return func(_ context.Context, m Message) error {
+			*got = append(*got, m)
+			return nil
+		}
+	}
+
+	t.Run("Add commits and rolls back with the transaction", func(t *testing.T) {
+		require.NoError(t, transactor.WithTx(ctx, func(ctx context.Context) error {
+			return store.Add(ctx, "user.events", "user.created", []byte(`{"id":1}`),
+				port.WithPublishContentType("application/json"),
+				port.WithPublishHeaders(map[string]any{"x-source": "api"}),
+				port.WithPublishPriority(9),
+				port.WithPublishMessageID("evt-1"))
+		}))
+		rollback := errors.New("rollback")
+		err := transactor.WithTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, store.Add(ctx, "user.events", "user.updated", []byte(`{}`)))
+			return rollback
+		})
+		require.ErrorIs(t, err, rollback)
+
+		var got []Message
+		n, err := store.Relay(ctx, 10, collect(&got))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		require.Len(t, got, 1)
+		assert.Equal(t, Message{
+			ID:          got[0].ID,
+			MessageID:   "evt-1",
+			Exchange:    "user.events",
+			RoutingKey:  "user.created",
+			ContentType: "application/json",
+			Headers:     map[string]any{"x-source": "api"},
+			Priority:    9,
+			Body:        []byte(`{"id":1}`),
+		}, got[0])
+
+		n, err = store.Relay(ctx, 10, collect(&got))
+		require.NoError(t, err)
+		assert.Zero(t, n, "published messages are not relayed again")
+	})
+
+	t.Run("a failed publish stops the batch", func(t *testing.T) {
+		for _, key := range []string{"a", "b", "c"} {
+			require.NoError(t, store.Add(ctx, "", key, []byte(key)))
+		}
+
+		var sent []string
+		n, err := store.Relay(ctx, 10, func(_ context.Context, m Message) error {
+			if m.RoutingKey == "b" {
+				return errors.New("broker down")
+			}
+			sent = append(sent, m.RoutingKey)
+			return nil
+		})
+		assert.ErrorContains(t, err, "broker down")
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"a"}, sent)
+
+		var lastError string
+		var attempts int
+		require.NoError(t, pool.QueryRow(ctx, `SELECT last_error, attempts FROM outbox WHERE routing_key = 'b'`).Scan(&lastError, &attempts))
+		assert.Equal(t, "broker down", lastError)
+		assert.Equal(t, 1, attempts)
+
+		var got []Message
+		n, err = store.Relay(ctx, 10, collect(&got))
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, "b", got[0].RoutingKey)
+		assert.Equal(t, 1, got[0].Attempts)
+		assert.NotEmpty(t, got[0].MessageID, "a generated ID")
+		assert.Equal(t, "c", got[1].RoutingKey)
+	})
+
+	t.Run("concurrent relays skip locked messages", func(t *testing.T) {
+		for _, key := range []string{"x", "y"} {
+			require.NoError(t, store.Add(ctx, "", key, []byte(key)))
+		}
+
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		var first []Message
+		done := make(chan error, 1)
+		go func() {
+			_, err := store.Relay(ctx, 1, func(_ context.Context, m Message) error {
+				first = append(first, m)
+				close(locked)
+				<-release
+				return nil
+			})
+			done <- err
+		}()
+		<-locked
+
+		var second []Message
+		n, err := store.Relay(ctx, 10, collect(&second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		close(release)
+		require.NoError(t, <-done)
+
+		require.Len(t, first, 1)
+		assert.Equal(t, "x", first[0].RoutingKey)
+		assert.Equal(t, "y", second[0].RoutingKey)
+	})
+
+	t.Run("Purge deletes messages published before the cutoff", func(t *testing.T) {
+		require.NoError(t, store.Add(ctx, "", "pending", []byte("p")))
+		n, err := store.Purge(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), n)
+
+		var left int
+		require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&left))
+		assert.Equal(t, 1, left, "unpublished messages are kept")
+	})
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+// Priority orders jobs waiting on a priority queue: the broker delivers
+// higher ones first. Queues that are not priority queues ignore it.
+type Priority uint8
+
+const (
+	PriorityLow    Priority = 1
+	PriorityNormal Priority = 5
+	PriorityHigh   Priority = 9
+)
+
+// MaxPriority is the highest job priority; priority queues are declared with
+// it (see port.WithMaxPriority).
+const MaxPriority = PriorityHigh
+
+// PriorityByName returns the priority called name: "low", "normal" or
+// "high". An empty name is normal.
+func PriorityByName(name string) (Priority, error) {
+	switch name {
+	case "", "normal":
+		return PriorityNormal, nil
+	case "low":
+		return PriorityLow, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return 0, fmt.Errorf("unknown priority %q: want low, normal or high", name)
+}
+
+// ParsePriorities builds per-job-type priorities from names, as in
+// worker.job_priorities.
+func ParsePriorities(byType map[string]string) (map[string]Priority, error) {
+	out := make(map[string]Priority, len(byType))
+	for jobType, name := range byType {
+		p, err := PriorityByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("job type %s: %w", jobType, err)
+		}
+		out[jobType] = p
+	}
+	return out, nil
+}
+
+// String returns the priority's name, or its number when it has none
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return fmt.Sprintf("%d", uint8(p))
+}
+
+// publishOptions are the options a job is published with: its codec's
+// content type and its priority. Jobs published before priorities existed
+// have none and go out as normal.
+func (j *Job) publishOptions() []port.PublishOption {
+	priority := j.Priority
+	if priority == 0 {
+		priority = PriorityNormal
+	}
+	return []port.PublishOption{
+		port.WithPublishContentType(j.Codec().ContentType()),
+		port.WithPublishPriority(uint8(priority)),
+	}
+}
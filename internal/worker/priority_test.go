@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
+)
+
+func TestPriorityByName(t *testing.T) {
+	for name, want := range map[string]Priority{
+		"":       PriorityNormal,
+		"normal": PriorityNormal,
+		"low":    PriorityLow,
+		"high":   PriorityHigh,
+	} {
+		got, err := PriorityByName(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := PriorityByName("urgent")
+	assert.ErrorContains(t, err, `unknown priority "urgent"`)
+}
+
+func TestParsePriorities(t *testing.T) {
+	got, err := ParsePriorities(map[string]string{"email.send": "high", "audit.cleanup": "low"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Priority{"email.send": PriorityHigh, "audit.cleanup": PriorityLow}, got)
+
+	_, err = ParsePriorities(map[string]string{"email.send": "urgent"})
+	assert.ErrorContains(t, err, "job type email.send")
+}
+
+func TestPublisher_Priorities(t *testing.T) {
+	q := &mockQueue{}
+	pub := NewPublisher(q, "jobs", "", WithPriorities(map[string]Priority{
+		"email.send":    PriorityHigh,
+		"audit.cleanup": PriorityLow,
+	}))
+
+	for jobType, want := range map[string]Priority{
+		"email.send":        PriorityHigh,
+		"audit.cleanup":     PriorityLow,
+		"notification.send": PriorityNormal,
+	} {
+		require.NoError(t, pub.Publish(context.Background(), jobType, "data"))
+		call := q.lastCall()
+		assert.Equal(t, uint8(want), call.priority, jobType)
+
+		job, err := DecodeJob(call.body)
+		require.NoError(t, err)
+		assert.Equal(t, want, job.Priority, "%s: the job carries its priority", jobType)
+	}
+
+	t.Run("jobs without a priority go out as normal", func(t *testing.T) {
+		require.NoError(t, pub.PublishRaw(context.Background(), &Job{ID: "legacy", Type: "email.send"}))
+		assert.Equal(t, uint8(PriorityNormal), q.lastCall().priority)
+	})
+}
+
+func TestHandleMessage_RetryKeepsPriority(t *testing.T) {
+	q := &delayedQueue{}
+	w := New(q, newTestLogger(), Config{})
+	w.RegisterHandler(&testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { return errors.New("smtp down") },
+	})
+
+	job, _ := NewJob("email.send", "data")
+	job.Priority = PriorityHigh
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data, Priority: uint8(PriorityHigh)}))
+
+	require.Len(t, q.publishCalls, 1)
+	assert.Equal(t, uint8(PriorityHigh), q.lastCall().priority)
+}
+
+func TestProtobufCodec_Priority(t *testing.T) {
+	job := &Job{ID: "id-1", Type: "test.codec", CreatedAt: time.Unix(100, 0), Priority: PriorityLow}
+	var decoded Job
+	require.NoError(t, ProtobufCodec.Unmarshal(marshalProtoJob(job), &decoded))
+	assert.Equal(t, PriorityLow, decoded.Priority)
+}
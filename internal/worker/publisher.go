@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/14mdzk/goscratch/internal/port"
 )
@@ -12,68 +13,159 @@ type Publisher struct {
 	queue     port.Queue
 	queueName string
 	exchange  string
+	codecs    Codecs
+	recorder  port.JobRecorder
+	// priorities are per-job-type priorities; other types are normal
+	priorities map[string]Priority
 }
 
-// NewPublisher creates a new job publisher
-func NewPublisher(queue port.Queue, queueName, exchange string) *Publisher {
+// PublisherOption configures a Publisher
+type PublisherOption func(*Publisher)
+
+// WithJobRecorder makes the publisher record each job as queued before
+// publishing it, so its status can be tracked. A job that cannot be
+// recorded is not published.
+func WithJobRecorder(recorder port.JobRecorder) PublisherOption {
+	return func(p *Publisher) { p.recorder = recorder }
+}
+
+// WithPriorities sets the priority of the jobs NewJob creates, by job type.
+// Types not in priorities are PriorityNormal.
+func WithPriorities(priorities map[string]Priority) PublisherOption {
+	return func(p *Publisher) { p.priorities = priorities }
+}
+
+// NewPublisher creates a new job publisher that encodes jobs as JSON
+func NewPublisher(queue port.Queue, queueName, exchange string, opts ...PublisherOption) *Publisher {
+	return NewPublisherWithCodecs(queue, queueName, exchange, Codecs{}, opts...)
+}
+
+// NewPublisherWithCodecs creates a job publisher that encodes each job type
+// with the codec codecs chooses for it.
+func NewPublisherWithCodecs(queue port.Queue, queueName, exchange string, codecs Codecs, opts ...PublisherOption) *Publisher {
 	if queueName == "" {
 		queueName = "jobs"
 	}
-	return &Publisher{
+	p := &Publisher{
 		queue:     queue,
 		queueName: queueName,
 		exchange:  exchange,
+		codecs:    codecs,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
 }
 
-// Publish creates and publishes a job to the queue
-func (p *Publisher) Publish(ctx context.Context, jobType string, payload any) error {
-	job, err := NewJob(jobType, payload)
+// NewJob creates a job with the codec the publisher uses for jobType, to be
+// published with PublishRaw. Use it when the caller needs the job's ID.
+func (p *Publisher) NewJob(jobType string, payload any) (*Job, error) {
+	job, err := NewJobWithCodec(jobType, payload, p.codecs.For(jobType))
 	if err != nil {
-		return fmt.Errorf("failed to create job: %w", err)
+		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
-
-	data, err := job.Encode()
-	if err != nil {
-		return fmt.Errorf("failed to encode job: %w", err)
+	if priority, ok := p.priorities[jobType]; ok {
+		job.Priority = priority
 	}
+	return job, nil
+}
 
-	if err := p.queue.Publish(ctx, p.exchange, p.queueName, data); err != nil {
-		return fmt.Errorf("failed to publish job: %w", err)
+// Publish creates and publishes a job to the queue
+func (p *Publisher) Publish(ctx context.Context, jobType string, payload any) error {
+	job, err := p.NewJob(jobType, payload)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return p.PublishRaw(ctx, job)
 }
 
 // PublishWithRetry creates and publishes a job with custom retry count
 func (p *Publisher) PublishWithRetry(ctx context.Context, jobType string, payload any, maxRetry int) error {
-	job, err := NewJobWithRetry(jobType, payload, maxRetry)
+	job, err := p.NewJob(jobType, payload)
 	if err != nil {
-		return fmt.Errorf("failed to create job: %w", err)
+		return err
 	}
+	job.MaxRetry = maxRetry
+	return p.PublishRaw(ctx, job)
+}
+
+// PublishOnce creates and publishes a job with an idempotency key. Workers
+// deduping keys run at most one job of jobType with the key successfully,
+// however often it is published or redelivered.
+func (p *Publisher) PublishOnce(ctx context.Context, jobType string, payload any, idempotencyKey string) error {
+	job, err := p.NewJob(jobType, payload)
+	if err != nil {
+		return err
+	}
+	job.IdempotencyKey = idempotencyKey
+	return p.PublishRaw(ctx, job)
+}
 
+// PublishRaw publishes a pre-created job to the queue with the job's own
+// codec
+func (p *Publisher) PublishRaw(ctx context.Context, job *Job) error {
 	data, err := job.Encode()
 	if err != nil {
 		return fmt.Errorf("failed to encode job: %w", err)
 	}
+	if err := p.recordQueued(ctx, job); err != nil {
+		return err
+	}
 
-	if err := p.queue.Publish(ctx, p.exchange, p.queueName, data); err != nil {
+	if err := p.queue.Publish(ctx, p.exchange, p.queueName, data, job.publishOptions()...); err != nil {
+		p.recordPublishFailed(ctx, job, err)
 		return fmt.Errorf("failed to publish job: %w", err)
 	}
 
 	return nil
 }
 
-// PublishRaw publishes a pre-created job to the queue
-func (p *Publisher) PublishRaw(ctx context.Context, job *Job) error {
+// recordQueued records job as queued. It runs before the publish, so a
+// worker picking the job up at once cannot be overwritten by it.
+func (p *Publisher) recordQueued(ctx context.Context, job *Job) error {
+	if p.recorder == nil {
+		return nil
+	}
+	if err := p.recorder.Record(ctx, job.Record(p.queueName, port.JobStatusQueued)); err != nil {
+		return fmt.Errorf("failed to record job: %w", err)
+	}
+	return nil
+}
+
+// recordPublishFailed marks a job that was recorded but never published as
+// failed. It is best effort: the caller already gets the publish error.
+func (p *Publisher) recordPublishFailed(ctx context.Context, job *Job, cause error) {
+	if p.recorder == nil {
+		return
+	}
+	rec := job.Record(p.queueName, port.JobStatusFailed)
+	rec.Error = "publish: " + cause.Error()
+	now := time.Now()
+	rec.FinishedAt = &now
+	_ = p.recorder.Record(ctx, rec)
+}
+
+// PublishDelayed publishes a pre-created job to be delivered once delay has
+// elapsed. The broker holds the job, so the delay survives restarts; it
+// fails with port.ErrDelayUnsupported when the queue cannot delay delivery.
+func (p *Publisher) PublishDelayed(ctx context.Context, job *Job, delay time.Duration) error {
+	dp, ok := p.queue.(port.DelayedPublisher)
+	if !ok {
+		return fmt.Errorf("failed to publish job: %w", port.ErrDelayUnsupported)
+	}
 	data, err := job.Encode()
 	if err != nil {
 		return fmt.Errorf("failed to encode job: %w", err)
 	}
 
-	if err := p.queue.Publish(ctx, p.exchange, p.queueName, data); err != nil {
-		return fmt.Errorf("failed to publish job: %w", err)
+	if err := p.recordQueued(ctx, job); err != nil {
+		return err
 	}
 
+	if err := dp.PublishDelayed(ctx, p.exchange, p.queueName, data, delay, job.publishOptions()...); err != nil {
+		p.recordPublishFailed(ctx, job, err)
+		return fmt.Errorf("failed to publish job: %w", err)
+	}
 	return nil
 }
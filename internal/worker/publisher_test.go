@@ -5,9 +5,12 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/port"
 )
 
 // mockQueue implements port.Queue for testing
@@ -15,31 +18,51 @@ type mockQueue struct {
 	mu           sync.Mutex
 	publishCalls []publishCall
 	publishErr   error
+	consumed     []string
+	declared     []string
+	declaredOpts []port.QueueOptions
 }
 
 type publishCall struct {
 	exchange   string
 	routingKey string
 	body       []byte
+	// contentType is the content type the message was published with.
+	contentType string
+	headers     map[string]any
+	priority    uint8
 }
 
-func (m *mockQueue) Publish(_ context.Context, exchange, routingKey string, body []byte) error {
+func (m *mockQueue) Publish(_ context.Context, exchange, routingKey string, body []byte, opts ...port.PublishOption) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	o := port.ApplyPublishOptions(opts...)
 	m.publishCalls = append(m.publishCalls, publishCall{
-		exchange:   exchange,
-		routingKey: routingKey,
-		body:       body,
+		exchange:    exchange,
+		routingKey:  routingKey,
+		body:        body,
+		contentType: o.ContentType,
+		headers:     o.Headers,
+		priority:    o.Priority,
 	})
 	return m.publishErr
 }
 
 func (m *mockQueue) PublishJSON(_ context.Context, _, _ string, _ any) error { return nil }
-func (m *mockQueue) Consume(_ context.Context, _ string, _ func(body []byte) error) error {
+func (m *mockQueue) Consume(_ context.Context, queue string, _ func(msg port.Message) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.consumed = append(m.consumed, queue)
+	return nil
+}
+func (m *mockQueue) Ping(_ context.Context) error { return nil }
+func (m *mockQueue) DeclareQueue(_ context.Context, queue string, _ bool, opts ...port.QueueOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.declared = append(m.declared, queue)
+	m.declaredOpts = append(m.declaredOpts, port.ApplyQueueOptions(opts...))
 	return nil
 }
-func (m *mockQueue) Ping(_ context.Context) error                                 { return nil }
-func (m *mockQueue) DeclareQueue(_ context.Context, _ string, _ bool) error       { return nil }
 func (m *mockQueue) DeclareExchange(_ context.Context, _, _ string, _ bool) error { return nil }
 func (m *mockQueue) BindQueue(_ context.Context, _, _, _ string) error            { return nil }
 func (m *mockQueue) Close() error                                                 { return nil }
@@ -50,6 +73,48 @@ func (m *mockQueue) lastCall() publishCall {
 	return m.publishCalls[len(m.publishCalls)-1]
 }
 
+// jobRecorder records job status writes for testing
+type jobRecorder struct {
+	mu      sync.Mutex
+	records []port.JobRecord
+	err     error
+}
+
+func (r *jobRecorder) Record(_ context.Context, rec port.JobRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+	return r.err
+}
+
+func (r *jobRecorder) statuses() []port.JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]port.JobStatus, 0, len(r.records))
+	for _, rec := range r.records {
+		out = append(out, rec.Status)
+	}
+	return out
+}
+
+// delayedQueue is a mockQueue that implements port.DelayedPublisher,
+// recording each delay next to its publish call
+type delayedQueue struct {
+	mockQueue
+	delays     []time.Duration
+	delayedErr error
+}
+
+func (m *delayedQueue) PublishDelayed(ctx context.Context, exchange, routingKey string, body []byte, delay time.Duration, opts ...port.PublishOption) error {
+	if m.delayedErr != nil {
+		return m.delayedErr
+	}
+	m.mu.Lock()
+	m.delays = append(m.delays, delay)
+	m.mu.Unlock()
+	return m.Publish(ctx, exchange, routingKey, body, opts...)
+}
+
 func TestNewPublisher(t *testing.T) {
 	t.Run("with_custom_queue_name", func(t *testing.T) {
 		q := &mockQueue{}
@@ -127,6 +192,24 @@ func TestPublisher_PublishWithRetry(t *testing.T) {
 	})
 }
 
+func TestPublisher_Codecs(t *testing.T) {
+	q := &mockQueue{}
+	pub := NewPublisherWithCodecs(q, "q", "ex", Codecs{ByType: map[string]Codec{"audit.cleanup": MsgpackCodec}})
+
+	require.NoError(t, pub.Publish(context.Background(), "email.send", map[string]string{"to": "x"}))
+	assert.Equal(t, ContentTypeJSON, q.lastCall().contentType)
+
+	require.NoError(t, pub.PublishWithRetry(context.Background(), "audit.cleanup", map[string]int{"days": 30}, 5))
+	call := q.lastCall()
+	assert.Equal(t, ContentTypeMsgpack, call.contentType)
+	job, err := DecodeMessage(port.Message{Body: call.body, ContentType: call.contentType})
+	require.NoError(t, err)
+	assert.Equal(t, 5, job.MaxRetry)
+	var payload map[string]int
+	require.NoError(t, job.UnmarshalPayload(&payload))
+	assert.Equal(t, 30, payload["days"])
+}
+
 func TestPublisher_PublishRaw(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		q := &mockQueue{}
@@ -154,3 +237,68 @@ func TestPublisher_PublishRaw(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to publish job")
 	})
 }
+
+func TestPublisher_PublishDelayed(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		q := &delayedQueue{}
+		pub := NewPublisher(q, "q", "ex")
+
+		job, err := NewJobWithCodec("email.send", "data", MsgpackCodec)
+		require.NoError(t, err)
+		require.NoError(t, pub.PublishDelayed(context.Background(), job, time.Minute))
+
+		assert.Equal(t, []time.Duration{time.Minute}, q.delays)
+		call := q.lastCall()
+		assert.Equal(t, "ex", call.exchange)
+		assert.Equal(t, "q", call.routingKey)
+		assert.Equal(t, ContentTypeMsgpack, call.contentType)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		pub := NewPublisher(&mockQueue{}, "q", "ex")
+		job, _ := NewJob("test", "data")
+		assert.ErrorIs(t, pub.PublishDelayed(context.Background(), job, time.Minute), port.ErrDelayUnsupported)
+	})
+}
+
+func TestPublisher_RecordsJobs(t *testing.T) {
+	t.Run("queued before publish", func(t *testing.T) {
+		q := &mockQueue{}
+		rec := &jobRecorder{}
+		pub := NewPublisher(q, "q", "ex", WithJobRecorder(rec))
+
+		job, err := pub.NewJob("email.send", "data")
+		require.NoError(t, err)
+		job.MaxRetry = 5
+		require.NoError(t, pub.PublishRaw(context.Background(), job))
+
+		require.Len(t, rec.records, 1)
+		got := rec.records[0]
+		assert.Equal(t, job.ID, got.ID)
+		assert.Equal(t, "email.send", got.Type)
+		assert.Equal(t, "q", got.Queue)
+		assert.Equal(t, port.JobStatusQueued, got.Status)
+		assert.Equal(t, 5, got.MaxRetry)
+		assert.Len(t, q.publishCalls, 1)
+	})
+
+	t.Run("record failure skips publish", func(t *testing.T) {
+		q := &mockQueue{}
+		pub := NewPublisher(q, "q", "ex", WithJobRecorder(&jobRecorder{err: errors.New("db down")}))
+
+		err := pub.Publish(context.Background(), "email.send", "data")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to record job")
+		assert.Empty(t, q.publishCalls)
+	})
+
+	t.Run("publish failure marks job failed", func(t *testing.T) {
+		rec := &jobRecorder{}
+		pub := NewPublisher(&mockQueue{publishErr: errors.New("broker down")}, "q", "ex", WithJobRecorder(rec))
+
+		require.Error(t, pub.Publish(context.Background(), "email.send", "data"))
+		assert.Equal(t, []port.JobStatus{port.JobStatusQueued, port.JobStatusFailed}, rec.statuses())
+		assert.Equal(t, "publish: broker down", rec.records[1].Error)
+		assert.NotNil(t, rec.records[1].FinishedAt)
+	})
+}
@@ -0,0 +1,279 @@
+// Package scheduler enqueues recurring jobs on cron schedules.
+//
+// Every worker instance runs a scheduler and wakes at the start of each
+// minute. For each schedule due in that minute, instances sharing a lock
+// cache race to claim the firing; the one that does enqueues the job. Minutes
+// missed while no instance was running are not caught up.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/cron"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+// DefaultRefresh is how often store schedules are reloaded by default
+const DefaultRefresh = time.Minute
+
+// claimTTL is how long a claimed firing is remembered. It only has to
+// outlast the clock skew between instances.
+const claimTTL = 10 * time.Minute
+
+// Schedule is a recurring job
+type Schedule struct {
+	Name     string
+	Cron     string
+	JobType  string
+	Payload  map[string]any
+	MaxRetry int // 0 keeps the job's default
+}
+
+// Store loads schedules kept outside config. *PostgresStore satisfies it.
+type Store interface {
+	Schedules(ctx context.Context) ([]Schedule, error)
+}
+
+// Publisher enqueues jobs. *worker.Publisher satisfies it.
+type Publisher interface {
+	Publish(ctx context.Context, jobType string, payload any) error
+	PublishWithRetry(ctx context.Context, jobType string, payload any, maxRetry int) error
+}
+
+// Config configures a Scheduler
+type Config struct {
+	Schedules []Schedule
+	// Store, when set, adds its schedules, reloaded every Refresh (default:
+	// DefaultRefresh). Config schedules win over store ones of the same name.
+	Store   Store
+	Refresh time.Duration
+	// Location cron expressions are evaluated in (default: UTC)
+	Location *time.Location
+	// Lock, when set, is where instances claim each firing, so only one of
+	// them enqueues it. Without it every instance enqueues every firing.
+	Lock port.Cache
+}
+
+// FromConfig returns the schedules, refresh and location of cfg. The store
+// and lock are left to the caller.
+func FromConfig(cfg config.SchedulerConfig) (Config, error) {
+	out := Config{Refresh: time.Duration(cfg.Refresh), Location: time.UTC}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return Config{}, fmt.Errorf("scheduler: %w", err)
+		}
+		out.Location = loc
+	}
+	for _, s := range cfg.Schedules {
+		out.Schedules = append(out.Schedules, Schedule{
+			Name:     s.Name,
+			Cron:     s.Cron,
+			JobType:  s.JobType,
+			Payload:  s.Payload,
+			MaxRetry: s.MaxRetry,
+		})
+	}
+	return out, nil
+}
+
+// entry is a schedule with its parsed expression
+type entry struct {
+	Schedule
+	cron *cron.Schedule
+}
+
+func newEntry(s Schedule) (entry, error) {
+	if s.Name == "" || s.JobType == "" {
+		return entry{}, fmt.Errorf("scheduler: schedule %q needs a name and a job type", s.Name)
+	}
+	c, err := cron.Parse(s.Cron)
+	if err != nil {
+		return entry{}, fmt.Errorf("scheduler: schedule %q: %w", s.Name, err)
+	}
+	return entry{Schedule: s, cron: c}, nil
+}
+
+// Scheduler enqueues jobs as their schedules come due
+type Scheduler struct {
+	pub    Publisher
+	logger *logger.Logger
+	cfg    Config
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	static  []entry // from Config.Schedules
+	entries []entry // static, then the store's
+	loaded  time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a scheduler. It fails on an invalid or duplicate config
+// schedule.
+func New(pub Publisher, log *logger.Logger, cfg Config) (*Scheduler, error) {
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = DefaultRefresh
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	s := &Scheduler{pub: pub, logger: log, cfg: cfg, now: time.Now}
+	seen := make(map[string]bool)
+	for _, sc := range cfg.Schedules {
+		e, err := newEntry(sc)
+		if err != nil {
+			return nil, err
+		}
+		if seen[sc.Name] {
+			return nil, fmt.Errorf("scheduler: schedule %q is defined twice", sc.Name)
+		}
+		seen[sc.Name] = true
+		s.static = append(s.static, e)
+	}
+	s.entries = s.static
+	return s, nil
+}
+
+// Start loads the store's schedules and starts firing. A store that fails
+// to load is logged and retried at the next refresh.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	if s.cfg.Store != nil {
+		s.reload(ctx)
+	}
+
+	s.mu.RLock()
+	for _, e := range s.entries {
+		s.logger.Info("Scheduled job", "schedule", e.Name, "cron", e.Cron, "job_type", e.JobType,
+			"next_run", e.cron.Next(s.now().In(s.cfg.Location)))
+	}
+	s.mu.RUnlock()
+	if s.cfg.Lock == nil {
+		s.logger.Warn("Scheduler has no lock cache; every worker instance enqueues every scheduled job")
+	}
+
+	go s.run(ctx)
+}
+
+// Close stops firing and waits for a tick in progress
+func (s *Scheduler) Close() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		now := s.now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		t := s.now().Truncate(time.Minute)
+		if s.cfg.Store != nil && t.Sub(s.loaded) >= s.cfg.Refresh {
+			s.reload(ctx)
+		}
+		s.tick(ctx, t)
+	}
+}
+
+// tick enqueues the jobs due in the minute starting at t
+func (s *Scheduler) tick(ctx context.Context, t time.Time) {
+	local := t.In(s.cfg.Location)
+	s.mu.RLock()
+	entries := s.entries
+	s.mu.RUnlock()
+
+	for _, e := range entries {
+		if !e.cron.Matches(local) || !s.claim(ctx, e.Name, t) {
+			continue
+		}
+
+		payload := e.Payload
+		if payload == nil {
+			payload = map[string]any{}
+		}
+		var err error
+		if e.MaxRetry > 0 {
+			err = s.pub.PublishWithRetry(ctx, e.JobType, payload, e.MaxRetry)
+		} else {
+			err = s.pub.Publish(ctx, e.JobType, payload)
+		}
+		if err != nil {
+			s.logger.Error("Failed to enqueue scheduled job", "error", err, "schedule", e.Name, "job_type", e.JobType)
+			continue
+		}
+		s.logger.Info("Enqueued scheduled job", "schedule", e.Name, "job_type", e.JobType, "run", local)
+	}
+}
+
+// claim reports whether this instance enqueues the firing of name at t. The
+// increment is atomic, so of the instances firing together only the first
+// sees 1. A cache error skips the firing rather than risk a duplicate.
+func (s *Scheduler) claim(ctx context.Context, name string, t time.Time) bool {
+	if s.cfg.Lock == nil {
+		return true
+	}
+	key := fmt.Sprintf("scheduler:%s:%d", name, t.Unix())
+	n, err := s.cfg.Lock.Increment(ctx, key)
+	if err != nil {
+		s.logger.Error("Failed to claim scheduled job; skipping it", "error", err, "schedule", name)
+		return false
+	}
+	if n == 1 {
+		_ = s.cfg.Lock.Expire(ctx, key, claimTTL)
+	}
+	return n == 1
+}
+
+// reload replaces the store's schedules. Invalid ones, and ones named like
+// a config schedule, are logged and skipped.
+func (s *Scheduler) reload(ctx context.Context) {
+	s.loaded = s.now()
+	schedules, err := s.cfg.Store.Schedules(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load job schedules; keeping the previous ones", "error", err)
+		return
+	}
+
+	entries := append([]entry(nil), s.static...)
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		seen[e.Name] = true
+	}
+	for _, sc := range schedules {
+		if seen[sc.Name] {
+			s.logger.Warn("Skipping job schedule: name is taken", "schedule", sc.Name)
+			continue
+		}
+		e, err := newEntry(sc)
+		if err != nil {
+			s.logger.Warn("Skipping invalid job schedule", "error", err, "schedule", sc.Name)
+			continue
+		}
+		seen[sc.Name] = true
+		entries = append(entries, e)
+	}
+
+	s.mu.Lock()
+	s.entries = entries
+	s.mu.Unlock()
+}
@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/14mdzk/goscratch/internal/adapter/cache"
+	"github.com/14mdzk/goscratch/internal/platform/config"
+	"github.com/14mdzk/goscratch/pkg/logger"
+)
+
+type publishCall struct {
+	jobType  string
+	payload  any
+	maxRetry int
+}
+
+type mockPublisher struct {
+	mu    sync.Mutex
+	calls []publishCall
+	err   error
+}
+
+func (p *mockPublisher) Publish(ctx context.Context, jobType string, payload any) error {
+	return p.PublishWithRetry(ctx, jobType, payload, 0)
+}
+
+func (p *mockPublisher) PublishWithRetry(_ context.Context, jobType string, payload any, maxRetry int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, publishCall{jobType: jobType, payload: payload, maxRetry: maxRetry})
+	return p.err
+}
+
+func (p *mockPublisher) jobTypes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []string
+	for _, c := range p.calls {
+		out = append(out, c.jobType)
+	}
+	return out
+}
+
+type mockStore struct {
+	schedules []Schedule
+	err       error
+	loads     int
+}
+
+func (s *mockStore) Schedules(context.Context) ([]Schedule, error) {
+	s.loads++
+	return s.schedules, s.err
+}
+
+func newTestLogger() *logger.Logger {
+	return logger.New(logger.Config{Level: "debug", Format: "json", Output: &bytes.Buffer{}})
+}
+
+func newTestLock(t *testing.T) (*cache.RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rc, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { rc.Close() })
+	return rc, mr
+}
+
+var (
+	at0300 = time.Date(2026, 5, 4, 3, 0, 0, 0, time.UTC)
+	at0301 = at0300.Add(time.Minute)
+)
+
+func TestNew_RejectsInvalidSchedules(t *testing.T) {
+	pub := &mockPublisher{}
+	tests := []struct {
+		name      string
+		schedules []Schedule
+	}{
+		{"bad cron", []Schedule{{Name: "a", Cron: "61 * * * *", JobType: "x"}}},
+		{"no job type", []Schedule{{Name: "a", Cron: "@daily"}}},
+		{"no name", []Schedule{{Cron: "@daily", JobType: "x"}}},
+		{"duplicate", []Schedule{
+			{Name: "a", Cron: "@daily", JobType: "x"},
+			{Name: "a", Cron: "@hourly", JobType: "y"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(pub, newTestLogger(), Config{Schedules: tt.schedules})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTick_FiresDueSchedules(t *testing.T) {
+	pub := &mockPublisher{}
+	s, err := New(pub, newTestLogger(), Config{Schedules: []Schedule{
+		{Name: "cleanup", Cron: "0 3 * * *", JobType: "audit.cleanup"},
+		{Name: "sweep", Cron: "0 * * * *", JobType: "auth.sessions_sweep", MaxRetry: 5},
+		{Name: "report", Cron: "30 * * * *", JobType: "report.run", Payload: map[string]any{"kind": "daily"}},
+	}})
+	require.NoError(t, err)
+
+	s.tick(context.Background(), at0300)
+	require.Len(t, pub.calls, 2)
+	assert.Equal(t, publishCall{jobType: "audit.cleanup", payload: map[string]any{}}, pub.calls[0])
+	assert.Equal(t, publishCall{jobType: "auth.sessions_sweep", payload: map[string]any{}, maxRetry: 5}, pub.calls[1])
+
+	s.tick(context.Background(), at0301)
+	assert.Len(t, pub.calls, 2)
+
+	s.tick(context.Background(), at0300.Add(30*time.Minute))
+	require.Len(t, pub.calls, 3)
+	assert.Equal(t, map[string]any{"kind": "daily"}, pub.calls[2].payload)
+}
+
+func TestTick_UsesLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta") // UTC+7
+	require.NoError(t, err)
+	pub := &mockPublisher{}
+	s, err := New(pub, newTestLogger(), Config{
+		Schedules: []Schedule{{Name: "cleanup", Cron: "0 3 * * *", JobType: "audit.cleanup"}},
+		Location:  loc,
+	})
+	require.NoError(t, err)
+
+	s.tick(context.Background(), at0300)
+	assert.Empty(t, pub.calls)
+
+	s.tick(context.Background(), at0300.Add(-7*time.Hour))
+	assert.Len(t, pub.calls, 1)
+}
+
+func TestTick_LockFiresOnce(t *testing.T) {
+	lock, mr := newTestLock(t)
+	schedules := []Schedule{{Name: "cleanup", Cron: "* * * * *", JobType: "audit.cleanup"}}
+
+	pubA, pubB := &mockPublisher{}, &mockPublisher{}
+	a, err := New(pubA, newTestLogger(), Config{Schedules: schedules, Lock: lock})
+	require.NoError(t, err)
+	b, err := New(pubB, newTestLogger(), Config{Schedules: schedules, Lock: lock})
+	require.NoError(t, err)
+
+	a.tick(context.Background(), at0300)
+	b.tick(context.Background(), at0300)
+	assert.Len(t, pubA.calls, 1)
+	assert.Empty(t, pubB.calls)
+
+	// The next minute is a new firing
+	b.tick(context.Background(), at0301)
+	a.tick(context.Background(), at0301)
+	assert.Len(t, pubA.calls, 1)
+	assert.Len(t, pubB.calls, 1)
+
+	assert.Equal(t, claimTTL, mr.TTL("scheduler:cleanup:"+strconv.FormatInt(at0300.Unix(), 10)))
+}
+
+func TestTick_PublishErrorContinues(t *testing.T) {
+	pub := &mockPublisher{err: errors.New("broker down")}
+	s, err := New(pub, newTestLogger(), Config{Schedules: []Schedule{
+		{Name: "a", Cron: "@hourly", JobType: "a"},
+		{Name: "b", Cron: "@hourly", JobType: "b"},
+	}})
+	require.NoError(t, err)
+
+	s.tick(context.Background(), at0300)
+	assert.Equal(t, []string{"a", "b"}, pub.jobTypes())
+}
+
+func TestReload_MergesStoreSchedules(t *testing.T) {
+	store := &mockStore{schedules: []Schedule{
+		{Name: "cleanup", Cron: "* * * * *", JobType: "other"}, // taken by config
+		{Name: "broken", Cron: "not cron", JobType: "x"},
+		{Name: "digest", Cron: "0 3 * * *", JobType: "digest.send"},
+	}}
+	pub := &mockPublisher{}
+	s, err := New(pub, newTestLogger(), Config{
+		Schedules: []Schedule{{Name: "cleanup", Cron: "0 3 * * *", JobType: "audit.cleanup"}},
+		Store:     store,
+	})
+	require.NoError(t, err)
+
+	s.reload(context.Background())
+	s.tick(context.Background(), at0300)
+	assert.Equal(t, []string{"audit.cleanup", "digest.send"}, pub.jobTypes())
+
+	// A failed load keeps the previous schedules
+	store.err = errors.New("db down")
+	s.reload(context.Background())
+	pub.calls = nil
+	s.tick(context.Background(), at0300)
+	assert.Equal(t, []string{"audit.cleanup", "digest.send"}, pub.jobTypes())
+
+	// Removing a row drops its schedule
+	store.err = nil
+	store.schedules = nil
+	s.reload(context.Background())
+	pub.calls = nil
+	s.tick(context.Background(), at0300)
+	assert.Equal(t, []string{"audit.cleanup"}, pub.jobTypes())
+	assert.Equal(t, 3, store.loads)
+}
+
+func TestStartClose(t *testing.T) {
+	store := &mockStore{}
+	s, err := New(&mockPublisher{}, newTestLogger(), Config{Store: store})
+	require.NoError(t, err)
+
+	s.Start(context.Background())
+	s.Close()
+	assert.Equal(t, 1, store.loads)
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg, err := FromConfig(config.SchedulerConfig{
+		Timezone: "Asia/Jakarta",
+		Refresh:  config.Duration(5 * time.Minute),
+		Schedules: []config.ScheduleConfig{
+			{Name: "cleanup", Cron: "0 3 * * *", JobType: "audit.cleanup", MaxRetry: 2},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Jakarta", cfg.Location.String())
+	assert.Equal(t, 5*time.Minute, cfg.Refresh)
+	assert.Equal(t, []Schedule{{Name: "cleanup", Cron: "0 3 * * *", JobType: "audit.cleanup", MaxRetry: 2}}, cfg.Schedules)
+
+	_, err = FromConfig(config.SchedulerConfig{Timezone: "Nowhere/Special"})
+	assert.Error(t, err)
+}
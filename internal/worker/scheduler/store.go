@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore loads the enabled rows of the job_schedules table
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a schedule store on pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+// Schedules implements Store
+func (s *PostgresStore) Schedules(ctx context.Context) ([]Schedule, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT name, cron, job_type, payload, max_retry
+		FROM job_schedules
+		WHERE enabled
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list job schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Schedule
+	for rows.Next() {
+		var sc Schedule
+		if err := rows.Scan(&sc.Name, &sc.Cron, &sc.JobType, &sc.Payload, &sc.MaxRetry); err != nil {
+			return nil, fmt.Errorf("scan job schedule: %w", err)
+		}
+		out = append(out, sc)
+	}
+	return out, rows.Err()
+}
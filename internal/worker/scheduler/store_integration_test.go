@@ -0,0 +1,40 @@
+//go:build integration
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/14mdzk/goscratch/internal/platform/testutil"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+
+	pgConn, pgCleanup, err := testutil.StartPostgres(ctx)
+	require.NoError(t, err)
+	defer pgCleanup()
+
+	pool, err := pgxpool.New(ctx, pgConn)
+	require.NoError(t, err)
+	defer pool.Close()
+	store := NewPostgresStore(pool)
+
+	_, err = pool.Exec(ctx, `
+		INSERT INTO job_schedules (name, cron, job_type, payload, max_retry, enabled) VALUES
+			('weekly-digest', '0 8 * * mon', 'digest.send', '{"kind": "weekly"}', 2, TRUE),
+			('nightly-export', '@daily', 'export.run', '{}', 0, TRUE),
+			('paused', '@hourly', 'export.run', '{}', 0, FALSE)`)
+	require.NoError(t, err)
+
+	schedules, err := store.Schedules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Schedule{
+		{Name: "nightly-export", Cron: "@daily", JobType: "export.run", Payload: map[string]any{}},
+		{Name: "weekly-digest", Cron: "0 8 * * mon", JobType: "digest.send", Payload: map[string]any{"kind": "weekly"}, MaxRetry: 2},
+	}, schedules)
+}
@@ -2,21 +2,37 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/joberr"
 	"github.com/14mdzk/goscratch/pkg/logger"
 )
 
 // Worker consumes jobs from a queue and dispatches them to handlers
 type Worker struct {
-	queue       port.Queue
-	handlers    map[string]JobHandler
-	logger      *logger.Logger
-	concurrency int
-	queueName   string
-	exchange    string
+	queue         port.Queue
+	handlers      map[string]JobHandler
+	logger        *logger.Logger
+	concurrency   int
+	queues        []string
+	exchange      string
+	readOnly      ReadOnlyGate
+	readOnlyDelay time.Duration
+	only          map[string]bool
+	onlyDelay     time.Duration
+	dryRun        *dryRun
+	queueOptions  []port.QueueOption
+	deadLetter    bool
+	jobs          port.JobRecorder
+	dedupe        port.Cache
+	dedupeTTL     time.Duration
+	middleware    []JobMiddleware
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -25,13 +41,64 @@ type Worker struct {
 	mu sync.RWMutex
 }
 
+// ReadOnlyGate reports whether read-only mode is on. *readonly.Switch
+// satisfies it.
+type ReadOnlyGate interface {
+	Enabled() bool
+}
+
 // Config holds worker configuration
 type Config struct {
-	QueueName   string
+	QueueName string
+	// Queues, when set, replaces QueueName: the worker consumes each of them
+	// with Concurrency goroutines and retries a job on the queue it came from.
+	Queues      []string
 	Exchange    string
 	Concurrency int
+	// ReadOnly, when set, pauses handlers that are not ReadOnlySafe while it
+	// is enabled. Optional.
+	ReadOnly ReadOnlyGate
+	// ReadOnlyDelay is how long a job deferred by read-only mode waits before
+	// it is redelivered (default: 30s).
+	ReadOnlyDelay time.Duration
+	// Only, when set, restricts the worker to these job types. Jobs of other
+	// types are put back after OnlyDelay (default: 30s) without spending an
+	// attempt, so other workers can take them.
+	Only      []string
+	OnlyDelay time.Duration
+	// DryRun logs each job instead of running it and puts it back on its
+	// queue unchanged. See DryRunDone.
+	DryRun bool
+	// QueueOptions are the policies each queue is declared with. They must
+	// match those of an existing queue, so pass the same options as the
+	// publishers (see queue.OptionsFromConfig).
+	QueueOptions []port.QueueOption
+	// DeadLetter publishes jobs that fail permanently or exhaust their
+	// retries to their queue's dead-letter queue (see DeadLetterQueueName)
+	// instead of dropping them. Start declares the dead-letter queues.
+	DeadLetter bool
+	// Jobs, when set, records each job's status as it runs: running,
+	// then succeeded, queued again for a retry, failed or dead. Optional.
+	Jobs port.JobRecorder
+	// Idempotency, when set, dedupes jobs that carry an IdempotencyKey. A
+	// job whose key already succeeded is acknowledged without running, and
+	// one whose key is running on another worker is put back after 30s.
+	// Succeeded keys are kept for IdempotencyTTL (default: 24h). Use a
+	// cache shared by every worker, such as Redis. Optional.
+	Idempotency    port.Cache
+	IdempotencyTTL time.Duration
+	// Middleware wraps every handler, the first outermost. The worker adds
+	// its own around them: panic recovery outside, then logging and a
+	// five-minute timeout inside. Optional.
+	Middleware []JobMiddleware
 }
 
+// recordTimeout bounds one job status write
+const recordTimeout = 5 * time.Second
+
+// jobTimeout bounds one run of a handler
+const jobTimeout = 5 * time.Minute
+
 // New creates a new Worker instance
 func New(queue port.Queue, log *logger.Logger, cfg Config) *Worker {
 	if cfg.Concurrency <= 0 {
@@ -43,18 +110,59 @@ func New(queue port.Queue, log *logger.Logger, cfg Config) *Worker {
 	if cfg.Exchange == "" {
 		cfg.Exchange = ""
 	}
+	if cfg.ReadOnlyDelay <= 0 {
+		cfg.ReadOnlyDelay = 30 * time.Second
+	}
+	if cfg.OnlyDelay <= 0 {
+		cfg.OnlyDelay = 30 * time.Second
+	}
+	if len(cfg.Queues) == 0 {
+		cfg.Queues = []string{cfg.QueueName}
+	}
+	if cfg.IdempotencyTTL <= 0 {
+		cfg.IdempotencyTTL = DefaultIdempotencyTTL
+	}
 
+	var only map[string]bool
+	if len(cfg.Only) > 0 {
+		only = make(map[string]bool, len(cfg.Only))
+		for _, t := range cfg.Only {
+			only[t] = true
+		}
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var dry *dryRun
+	if cfg.DryRun {
+		dry = newDryRun(ctx, cfg.Queues)
+	}
+
+	// Recovery also catches panics in the caller's middleware; logging and
+	// the timeout cover the handler alone.
+	middleware := []JobMiddleware{recoverJob(log)}
+	middleware = append(middleware, cfg.Middleware...)
+	middleware = append(middleware, logJob(log), Timeout(jobTimeout))
+
 	return &Worker{
-		queue:       queue,
-		handlers:    make(map[string]JobHandler),
-		logger:      log,
-		concurrency: cfg.Concurrency,
-		queueName:   cfg.QueueName,
-		exchange:    cfg.Exchange,
-		ctx:         ctx,
-		cancel:      cancel,
+		queue:         queue,
+		handlers:      make(map[string]JobHandler),
+		logger:        log,
+		concurrency:   cfg.Concurrency,
+		queues:        cfg.Queues,
+		exchange:      cfg.Exchange,
+		readOnly:      cfg.ReadOnly,
+		readOnlyDelay: cfg.ReadOnlyDelay,
+		only:          only,
+		onlyDelay:     cfg.OnlyDelay,
+		dryRun:        dry,
+		queueOptions:  cfg.QueueOptions,
+		deadLetter:    cfg.DeadLetter,
+		jobs:          cfg.Jobs,
+		dedupe:        cfg.Idempotency,
+		dedupeTTL:     cfg.IdempotencyTTL,
+		middleware:    middleware,
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
@@ -67,22 +175,44 @@ func (w *Worker) RegisterHandler(handler JobHandler) {
 	w.logger.Info("Registered job handler", "type", handler.Type())
 }
 
-// Start begins consuming jobs from the queue
+// Start begins consuming jobs from the queues. It fails if a type in
+// Config.Only has no registered handler, which is usually a typo.
 func (w *Worker) Start() error {
+	w.mu.RLock()
+	for t := range w.only {
+		if _, ok := w.handlers[t]; !ok {
+			w.mu.RUnlock()
+			return fmt.Errorf("worker: job type %q selected by Only has no registered handler", t)
+		}
+	}
+	w.mu.RUnlock()
+
 	w.logger.Info("Starting worker",
-		"queue", w.queueName,
+		"queues", w.queues,
 		"concurrency", w.concurrency,
+		"only", w.onlyTypes(),
+		"dry_run", w.dryRun != nil,
+		"dead_letter", w.deadLetter,
 	)
 
-	// Ensure queue exists
-	if err := w.queue.DeclareQueue(w.ctx, w.queueName, true); err != nil {
-		w.logger.Warn("Failed to declare queue (may already exist)", "error", err)
-	}
+	workerID := 0
+	for _, queue := range w.queues {
+		// Ensure queue exists
+		if err := w.queue.DeclareQueue(w.ctx, queue, true, w.queueOptions...); err != nil {
+			w.logger.Warn("Failed to declare queue (may already exist)", "error", err, "queue", queue)
+		}
+		if w.deadLetter {
+			if err := w.queue.DeclareQueue(w.ctx, DeadLetterQueueName(queue), true); err != nil {
+				return fmt.Errorf("worker: declare dead-letter queue of %q: %w", queue, err)
+			}
+		}
 
-	// Start worker goroutines
-	for i := 0; i < w.concurrency; i++ {
-		w.wg.Add(1)
-		go w.consume(i)
+		// Start worker goroutines
+		for i := 0; i < w.concurrency; i++ {
+			w.wg.Add(1)
+			go w.consume(workerID, queue)
+			workerID++
+		}
 	}
 
 	w.logger.Info("Worker started successfully")
@@ -96,42 +226,53 @@ func (w *Worker) Start() error {
 // #14). We block on w.ctx.Done() so the wg actually covers the consumer's
 // active window — Shutdown's wg.Wait will not return until ctx is cancelled and
 // the underlying delivery goroutine has its cancel signal in hand.
-func (w *Worker) consume(workerID int) {
+func (w *Worker) consume(workerID int, queue string) {
 	defer w.wg.Done()
 
-	w.logger.Debug("Worker goroutine started", "worker_id", workerID)
+	w.logger.Debug("Worker goroutine started", "worker_id", workerID, "queue", queue)
+
+	// A dry run stops consuming a queue once it has passed over it.
+	ctx := w.ctx
+	if w.dryRun != nil {
+		ctx = w.dryRun.context(queue)
+	}
 
-	err := w.queue.Consume(w.ctx, w.queueName, func(body []byte) error {
-		return w.handleMessage(workerID, body)
+	err := w.queue.Consume(ctx, queue, func(msg port.Message) error {
+		return w.handleMessage(workerID, queue, msg)
 	})
 	if err != nil {
-		w.logger.Error("Consumer error", "error", err, "worker_id", workerID)
+		w.logger.Error("Consumer error", "error", err, "worker_id", workerID, "queue", queue)
 		return
 	}
 
-	<-w.ctx.Done()
+	<-ctx.Done()
 
 	w.logger.Debug("Worker goroutine stopped", "worker_id", workerID)
 }
 
-// handleMessage processes a single message
-func (w *Worker) handleMessage(workerID int, msg []byte) error {
+// handleMessage processes a single message received on queue
+func (w *Worker) handleMessage(workerID int, queue string, msg port.Message) error {
+	if w.dryRun != nil {
+		return w.inspectMessage(queue, msg)
+	}
+
 	// Decode job
-	job, err := DecodeJob(msg)
+	job, err := DecodeMessage(msg)
 	if err != nil {
-		w.logger.Error("Failed to decode job", "error", err, "worker_id", workerID)
+		w.logger.Error("Failed to decode job", "error", err, "content_type", msg.ContentType, "worker_id", workerID)
 		return nil // Acknowledge malformed messages to avoid retry loop
 	}
 
-	// Increment attempts
-	job.IncrementAttempts()
-
-	w.logger.Info("Processing job",
-		"job_id", job.ID,
-		"job_type", job.Type,
-		"attempt", job.Attempts,
-		"worker_id", workerID,
-	)
+	// Leave job types outside Only to other workers
+	if w.only != nil && !w.only[job.Type] {
+		w.logger.Debug("Deferring job: type not selected by Only",
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"delay", w.onlyDelay,
+		)
+		w.requeueAfter(queue, job, w.onlyDelay)
+		return nil
+	}
 
 	// Find handler
 	w.mu.RLock()
@@ -143,71 +284,172 @@ func (w *Worker) handleMessage(workerID int, msg []byte) error {
 			"job_type", job.Type,
 			"job_id", job.ID,
 		)
+		w.recordJob(queue, job, port.JobStatusFailed, errors.New("no handler registered for job type"))
 		return nil // Acknowledge unhandled job types
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(w.ctx, 5*time.Minute)
-	defer cancel()
-
-	// Execute handler
-	start := time.Now()
-	err = handler.Handle(ctx, job)
-	duration := time.Since(start)
+	// Read-only mode: put writing jobs back without spending an attempt
+	if w.pausedByReadOnly(handler) {
+		w.logger.Info("Deferring job: read-only mode",
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"delay", w.readOnlyDelay,
+		)
+		w.requeueAfter(queue, job, w.readOnlyDelay)
+		return nil
+	}
 
-	if err != nil {
-		w.logger.Error("Job failed",
+	// Idempotency keys: skip a job that already ran, defer one running now
+	switch w.claimIdempotencyKey(job) {
+	case claimDone:
+		w.logger.Info("Skipping duplicate job: idempotency key already succeeded",
 			"job_id", job.ID,
 			"job_type", job.Type,
-			"error", err,
-			"duration_ms", duration.Milliseconds(),
-			"attempt", job.Attempts,
+			"idempotency_key", job.IdempotencyKey,
 		)
+		w.recordJob(queue, job, port.JobStatusSucceeded, nil)
+		return nil
+	case claimBusy:
+		w.logger.Info("Deferring job: idempotency key is running on another worker",
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"idempotency_key", job.IdempotencyKey,
+			"delay", duplicateDelay,
+		)
+		w.requeueAfter(queue, job, duplicateDelay)
+		return nil
+	}
 
-		// Retry if possible
-		if job.CanRetry() {
-			w.retryJob(job)
-		} else {
+	// Increment attempts
+	job.IncrementAttempts()
+	w.recordJob(queue, job, port.JobStatusRunning, nil)
+
+	// Execute handler inside the middleware chain
+	ctx := context.WithValue(w.ctx, workerIDKey{}, workerID)
+	err = chain(handler.Handle, w.middleware...)(ctx, job)
+	w.releaseIdempotencyKey(job, err == nil)
+
+	if err != nil {
+		switch {
+		case joberr.Classify(err) == joberr.KindPermanent:
+			w.logger.Error("Job failed permanently, not retrying",
+				"job_id", job.ID,
+				"job_type", job.Type,
+				"attempts", job.Attempts,
+			)
+			w.fail(queue, job, err)
+		case job.CanRetry():
+			w.recordJob(queue, job, port.JobStatusQueued, err)
+			if retryAfter, ok := joberr.RetryAfter(err); ok {
+				w.retryJobAfter(queue, job, retryAfter)
+			} else {
+				w.retryJob(queue, job)
+			}
+		default:
 			w.logger.Error("Job exhausted retries",
 				"job_id", job.ID,
 				"job_type", job.Type,
 				"attempts", job.Attempts,
 			)
+			w.fail(queue, job, err)
 		}
 		return nil // Acknowledge to avoid immediate redelivery
 	}
 
-	w.logger.Info("Job completed successfully",
-		"job_id", job.ID,
-		"job_type", job.Type,
-		"duration_ms", duration.Milliseconds(),
-	)
+	w.recordJob(queue, job, port.JobStatusSucceeded, nil)
 
 	return nil
 }
 
+// fail ends a job that will not be retried: it is dead-lettered when that is
+// on, and dropped otherwise or when dead-lettering fails.
+func (w *Worker) fail(queue string, job *Job, cause error) {
+	if w.deadLetter && w.publishDeadLetter(queue, job, cause) {
+		w.recordJob(queue, job, port.JobStatusDead, cause)
+		return
+	}
+	w.recordJob(queue, job, port.JobStatusFailed, cause)
+}
+
+// recordJob records job's status when tracking is on. A failed write is
+// logged; the job goes on regardless. It outlives a shutdown signal, so a
+// job finishing during shutdown still records its outcome.
+func (w *Worker) recordJob(queue string, job *Job, status port.JobStatus, cause error) {
+	if w.jobs == nil {
+		return
+	}
+	rec := job.Record(queue, status)
+	now := time.Now()
+	if status == port.JobStatusRunning {
+		rec.StartedAt = &now
+	}
+	if status.Terminal() {
+		rec.FinishedAt = &now
+	}
+	if cause != nil {
+		rec.Error = cause.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), recordTimeout)
+	defer cancel()
+	if err := w.jobs.Record(ctx, rec); err != nil {
+		w.logger.Warn("Failed to record job status", "error", err, "job_id", job.ID, "status", status)
+	}
+}
+
 // retryJob re-queues a failed job for retry after an exponential backoff.
-//
-// The retry goroutine is registered on w.wg so Shutdown's wg.Wait() does not
-// return until pending retries either fire or cancel. The delay uses a Timer
-// + select on w.ctx.Done() instead of time.Sleep so a long backoff cannot
-// outlive a shutdown signal (block-ship #14: prior code slept past ctx and
-// then attempted Publish on a closed channel).
-func (w *Worker) retryJob(job *Job) {
-	delay := time.Duration(job.Attempts*job.Attempts) * time.Second
+func (w *Worker) retryJob(queue string, job *Job) {
+	w.retryJobAfter(queue, job, time.Duration(job.Attempts*job.Attempts)*time.Second)
+}
 
+// retryJobAfter re-queues a failed job once delay has elapsed. Rate-limited
+// failures use the downstream's retry-after here instead of the backoff.
+func (w *Worker) retryJobAfter(queue string, job *Job, delay time.Duration) {
 	w.logger.Info("Scheduling job retry",
 		"job_id", job.ID,
 		"job_type", job.Type,
 		"attempt", job.Attempts,
 		"delay", delay,
 	)
+	w.requeueAfter(queue, job, delay)
+}
 
+// pausedByReadOnly reports whether read-only mode holds back handler.
+func (w *Worker) pausedByReadOnly(handler JobHandler) bool {
+	if w.readOnly == nil || !w.readOnly.Enabled() {
+		return false
+	}
+	safe, ok := handler.(ReadOnlySafe)
+	return !ok || !safe.ReadOnlySafe()
+}
+
+// requeueAfter publishes job back to queue once delay has elapsed. When the
+// queue implements port.DelayedPublisher the broker holds the job for the
+// delay, so a restart does not lose it. Otherwise, or when the delayed
+// publish fails, the worker holds it in memory.
+//
+// The in-memory retry goroutine is registered on w.wg so Shutdown's wg.Wait() does not
+// return until pending retries either fire or cancel. The delay uses a Timer
+// + select on w.ctx.Done() instead of time.Sleep so a long backoff cannot
+// outlive a shutdown signal (block-ship #14: prior code slept past ctx and
+// then attempted Publish on a closed channel).
+func (w *Worker) requeueAfter(queue string, job *Job, delay time.Duration) {
 	data, err := job.Encode()
 	if err != nil {
 		w.logger.Error("Failed to encode job for retry", "error", err, "job_id", job.ID)
 		return
 	}
+	opts := job.publishOptions()
+
+	if dp, ok := w.queue.(port.DelayedPublisher); ok {
+		err := dp.PublishDelayed(w.ctx, w.exchange, queue, data, delay, opts...)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, port.ErrDelayUnsupported) {
+			w.logger.Warn("Failed to publish delayed retry; holding it in memory", "error", err, "job_id", job.ID)
+		}
+	}
 
 	w.wg.Add(1)
 	go func() {
@@ -230,7 +472,7 @@ func (w *Worker) retryJob(job *Job) {
 			return
 		}
 
-		if err := w.queue.Publish(w.ctx, w.exchange, w.queueName, data); err != nil {
+		if err := w.queue.Publish(w.ctx, w.exchange, queue, data, opts...); err != nil {
 			w.logger.Error("Failed to retry job", "error", err, "job_id", job.ID)
 		}
 	}()
@@ -271,8 +513,24 @@ func (w *Worker) Stats() map[string]any {
 	}
 
 	return map[string]any{
-		"queue":       w.queueName,
+		"queue":       strings.Join(w.queues, ","),
 		"concurrency": w.concurrency,
 		"handlers":    handlers,
+		"only":        w.onlyTypes(),
+		"dry_run":     w.dryRun != nil,
+	}
+}
+
+// onlyTypes returns the job types selected by Config.Only, sorted; nil means
+// every type.
+func (w *Worker) onlyTypes() []string {
+	if w.only == nil {
+		return nil
+	}
+	types := make([]string, 0, len(w.only))
+	for t := range w.only {
+		types = append(types, t)
 	}
+	sort.Strings(types)
+	return types
 }
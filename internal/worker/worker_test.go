@@ -8,6 +8,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/14mdzk/goscratch/internal/port"
+	"github.com/14mdzk/goscratch/pkg/joberr"
 	"github.com/14mdzk/goscratch/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -108,7 +110,7 @@ func TestHandleMessage_DispatchesToCorrectHandler(t *testing.T) {
 	data, err := job.Encode()
 	require.NoError(t, err)
 
-	err = w.handleMessage(0, data)
+	err = w.handleMessage(0, w.queues[0], port.Message{Body: data})
 	assert.NoError(t, err)
 	assert.Equal(t, job.ID, receivedJobID)
 }
@@ -122,7 +124,7 @@ func TestHandleMessage_UnknownJobType(t *testing.T) {
 	data, _ := job.Encode()
 
 	// Should not error (ack malformed/unknown)
-	err := w.handleMessage(0, data)
+	err := w.handleMessage(0, w.queues[0], port.Message{Body: data})
 	assert.NoError(t, err)
 }
 
@@ -131,7 +133,7 @@ func TestHandleMessage_InvalidJSON(t *testing.T) {
 	log := newTestLogger()
 	w := New(q, log, Config{})
 
-	err := w.handleMessage(0, []byte("not-json"))
+	err := w.handleMessage(0, w.queues[0], port.Message{Body: []byte("not-json")})
 	assert.NoError(t, err) // ack malformed messages
 }
 
@@ -152,7 +154,7 @@ func TestHandleMessage_RetryOnFailure(t *testing.T) {
 	job, _ := NewJob("fail.job", "data")
 	data, _ := job.Encode()
 
-	err := w.handleMessage(0, data)
+	err := w.handleMessage(0, w.queues[0], port.Message{Body: data})
 	assert.NoError(t, err) // still returns nil (ack to avoid immediate redelivery)
 
 	// Give the goroutine in retryJob time to publish
@@ -182,7 +184,7 @@ func TestHandleMessage_ExhaustedRetries(t *testing.T) {
 	job.Attempts = 2
 	data, _ := job.Encode()
 
-	err := w.handleMessage(0, data)
+	err := w.handleMessage(0, w.queues[0], port.Message{Body: data})
 	assert.NoError(t, err)
 
 	// No retry should happen
@@ -193,6 +195,66 @@ func TestHandleMessage_ExhaustedRetries(t *testing.T) {
 	assert.Equal(t, 0, callCount, "should not retry exhausted job")
 }
 
+func TestHandleMessage_PermanentErrorNotRetried(t *testing.T) {
+	q := &mockQueue{}
+	log := newTestLogger()
+	w := New(q, log, Config{})
+
+	handler := &testHandler{
+		jobType: "fail.job",
+		handleFn: func(_ context.Context, _ *Job) error {
+			return joberr.Permanentf("recipient is required")
+		},
+	}
+	w.RegisterHandler(handler)
+
+	// Attempts=0, MaxRetry=3: an unclassified error would be retried.
+	job, _ := NewJob("fail.job", "data")
+	data, _ := job.Encode()
+
+	err := w.handleMessage(0, w.queues[0], port.Message{Body: data})
+	assert.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	q.mu.Lock()
+	callCount := len(q.publishCalls)
+	q.mu.Unlock()
+	assert.Equal(t, 0, callCount, "permanent errors must not be retried")
+}
+
+func TestHandleMessage_RateLimitedUsesRetryAfter(t *testing.T) {
+	q := &mockQueue{}
+	log := newTestLogger()
+	w := New(q, log, Config{})
+
+	handler := &testHandler{
+		jobType: "fail.job",
+		handleFn: func(_ context.Context, _ *Job) error {
+			return joberr.RateLimited(errors.New("429 from provider"), 50*time.Millisecond)
+		},
+	}
+	w.RegisterHandler(handler)
+
+	// Attempts=2 -> backoff would be 9s after increment; retry-after wins.
+	job, _ := NewJob("fail.job", "data")
+	job.Attempts = 2
+	job.MaxRetry = 5
+	data, _ := job.Encode()
+
+	err := w.handleMessage(0, w.queues[0], port.Message{Body: data})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.publishCalls) == 1
+	}, time.Second, 10*time.Millisecond, "rate-limited job should be retried after retry-after, not the backoff")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+}
+
 func TestHandleMessage_IncrementsAttempts(t *testing.T) {
 	q := &mockQueue{}
 	log := newTestLogger()
@@ -212,7 +274,7 @@ func TestHandleMessage_IncrementsAttempts(t *testing.T) {
 	job.Attempts = 0
 	data, _ := job.Encode()
 
-	_ = w.handleMessage(0, data)
+	_ = w.handleMessage(0, w.queues[0], port.Message{Body: data})
 	assert.Equal(t, 1, capturedAttempts, "attempts should be incremented before handling")
 }
 
@@ -264,9 +326,9 @@ type dispatchingQueue struct {
 	jobData []byte
 }
 
-func (d *dispatchingQueue) Consume(ctx context.Context, _ string, handler func([]byte) error) error {
+func (d *dispatchingQueue) Consume(ctx context.Context, _ string, handler func(port.Message) error) error {
 	if d.jobData != nil {
-		_ = handler(d.jobData)
+		_ = handler(port.Message{Body: d.jobData})
 	}
 	<-ctx.Done()
 	return nil
@@ -379,7 +441,7 @@ func TestRetry_MidBackoff_CancelsOnCtxDone(t *testing.T) {
 
 	// handleMessage fires the handler synchronously; retryJob spawns a goroutine
 	// with a 9s timer. We call handleMessage directly so we control timing.
-	err = w.handleMessage(0, jobData)
+	err = w.handleMessage(0, w.queues[0], port.Message{Body: jobData})
 	require.NoError(t, err)
 
 	// Shutdown immediately; must return well before the 9s backoff.
@@ -412,7 +474,7 @@ func TestRetry_CancelsOnShutdown(t *testing.T) {
 	// Schedule a retry with a long delay (Attempts=3 -> 9s).
 	job, _ := NewJob("retry.test", "data")
 	job.Attempts = 3
-	w.retryJob(job)
+	w.retryJob(w.queues[0], job)
 
 	// Immediately shutdown. Total Shutdown duration must be much shorter than
 	// the 9s scheduled delay because the retry goroutine selects on ctx.Done.
@@ -444,7 +506,7 @@ func TestRetry_TrackedByWaitGroup(t *testing.T) {
 	// Attempts=1 -> 1*1*time.Second = 1s delay. Wait for it via Shutdown.
 	job, _ := NewJob("retry.test", "data")
 	job.Attempts = 1
-	w.retryJob(job)
+	w.retryJob(w.queues[0], job)
 
 	// Give the timer time to fire. Shutdown waits for wg.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -460,3 +522,372 @@ func TestRetry_TrackedByWaitGroup(t *testing.T) {
 	q.mu.Unlock()
 	assert.Equal(t, 1, callCount, "retry must have published once before Shutdown returned")
 }
+
+type readOnlyGate struct{ enabled bool }
+
+func (g readOnlyGate) Enabled() bool { return g.enabled }
+
+// safeHandler is a testHandler that declares itself ReadOnlySafe
+type safeHandler struct{ testHandler }
+
+func (h *safeHandler) ReadOnlySafe() bool { return true }
+
+func TestHandleMessage_ReadOnlyDefersWritingJobs(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{
+		ReadOnly:      readOnlyGate{enabled: true},
+		ReadOnlyDelay: 10 * time.Millisecond,
+	})
+
+	ran := false
+	w.RegisterHandler(&testHandler{
+		jobType:  "audit.cleanup",
+		handleFn: func(_ context.Context, _ *Job) error { ran = true; return nil },
+	})
+
+	job, _ := NewJob("audit.cleanup", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, w.queues[0], port.Message{Body: data}))
+	assert.False(t, ran, "writing handler must not run in read-only mode")
+
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.publishCalls) == 1
+	}, time.Second, 5*time.Millisecond, "deferred job should be re-published")
+
+	requeued, err := DecodeJob(q.lastCall().body)
+	require.NoError(t, err)
+	assert.Equal(t, 0, requeued.Attempts, "deferral must not spend an attempt")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+}
+
+func TestHandleMessage_ReadOnlyRunsSafeJobs(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{ReadOnly: readOnlyGate{enabled: true}})
+
+	ran := false
+	w.RegisterHandler(&safeHandler{testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { ran = true; return nil },
+	}})
+
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, w.queues[0], port.Message{Body: data}))
+	assert.True(t, ran)
+	assert.Empty(t, q.publishCalls)
+}
+
+func TestHandleMessage_ReadOnlyOffRunsEverything(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{ReadOnly: readOnlyGate{enabled: false}})
+
+	ran := false
+	w.RegisterHandler(&testHandler{
+		jobType:  "audit.cleanup",
+		handleFn: func(_ context.Context, _ *Job) error { ran = true; return nil },
+	})
+
+	job, _ := NewJob("audit.cleanup", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, w.queues[0], port.Message{Body: data}))
+	assert.True(t, ran)
+}
+
+func TestStart_ConsumesEveryQueue(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{
+		QueueName:    "ignored",
+		Queues:       []string{"jobs", "emails"},
+		Concurrency:  2,
+		QueueOptions: []port.QueueOption{port.WithMessageTTL(time.Hour)},
+	})
+	require.NoError(t, w.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+
+	assert.Equal(t, []string{"jobs", "emails"}, q.declared)
+	for _, opts := range q.declaredOpts {
+		assert.Equal(t, time.Hour, opts.MessageTTL)
+	}
+	assert.ElementsMatch(t, []string{"jobs", "jobs", "emails", "emails"}, q.consumed)
+}
+
+func TestHandleMessage_RetriesOnSourceQueue(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{Queues: []string{"jobs", "emails"}})
+	w.RegisterHandler(&testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { return errors.New("smtp down") },
+	})
+
+	job, _ := NewJob("email.send", "data")
+	job.Attempts = -1 // zero backoff on the first retry
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "emails", port.Message{Body: data}))
+
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.publishCalls) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "emails", q.lastCall().routingKey)
+}
+
+func TestHandleMessage_RetryDelayedOnBroker(t *testing.T) {
+	q := &delayedQueue{}
+	w := New(q, newTestLogger(), Config{Exchange: "ex"})
+	w.RegisterHandler(&testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { return errors.New("smtp down") },
+	})
+
+	job, _ := NewJob("email.send", "data")
+	job.Attempts = 1 // second attempt: 4s backoff
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+	// Published at once, with the broker holding it for the backoff
+	require.Len(t, q.publishCalls, 1)
+	assert.Equal(t, []time.Duration{4 * time.Second}, q.delays)
+	assert.Equal(t, "ex", q.lastCall().exchange)
+	assert.Equal(t, "jobs", q.lastCall().routingKey)
+
+	t.Run("falls back to memory when the delayed publish fails", func(t *testing.T) {
+		q := &delayedQueue{delayedErr: errors.New("channel closed")}
+		w := New(q, newTestLogger(), Config{})
+		w.RegisterHandler(&testHandler{
+			jobType:  "email.send",
+			handleFn: func(_ context.Context, _ *Job) error { return errors.New("smtp down") },
+		})
+
+		job, _ := NewJob("email.send", "data")
+		job.Attempts = -1 // zero backoff on the first retry
+		data, _ := job.Encode()
+		require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+		assert.Eventually(t, func() bool {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			return len(q.publishCalls) == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestHandleMessage_RetryKeepsCodec(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{})
+	w.RegisterHandler(&testHandler{
+		jobType: "email.send",
+		handleFn: func(_ context.Context, job *Job) error {
+			var p testPayload
+			require.NoError(t, job.UnmarshalPayload(&p))
+			return errors.New("smtp down")
+		},
+	})
+
+	job, _ := NewJobWithCodec("email.send", testPayload{Name: "x"}, MsgpackCodec)
+	job.Attempts = -1 // zero backoff on the first retry
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data, ContentType: ContentTypeMsgpack}))
+
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.publishCalls) == 1
+	}, time.Second, 5*time.Millisecond)
+	call := q.lastCall()
+	assert.Equal(t, ContentTypeMsgpack, call.contentType)
+	retried, err := DecodeMessage(port.Message{Body: call.body, ContentType: call.contentType})
+	require.NoError(t, err)
+	assert.Equal(t, 0, retried.Attempts)
+}
+
+func TestHandleMessage_UnknownContentTypeIsDropped(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{})
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data, ContentType: "text/xml"}))
+	assert.Empty(t, q.publishCalls)
+}
+
+func TestHandleMessage_OnlyDefersOtherTypes(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{Only: []string{"email.send"}, OnlyDelay: 10 * time.Millisecond})
+
+	ran := map[string]bool{}
+	for _, jobType := range []string{"email.send", "audit.cleanup"} {
+		w.RegisterHandler(&testHandler{
+			jobType:  jobType,
+			handleFn: func(_ context.Context, _ *Job) error { ran[jobType] = true; return nil },
+		})
+	}
+
+	for _, jobType := range []string{"email.send", "audit.cleanup"} {
+		job, _ := NewJob(jobType, "data")
+		data, _ := job.Encode()
+		require.NoError(t, w.handleMessage(0, w.queues[0], port.Message{Body: data}))
+	}
+	assert.True(t, ran["email.send"])
+	assert.False(t, ran["audit.cleanup"], "unselected type must not run")
+
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.publishCalls) == 1
+	}, time.Second, 5*time.Millisecond, "unselected job should be put back")
+	requeued, err := DecodeJob(q.lastCall().body)
+	require.NoError(t, err)
+	assert.Equal(t, "audit.cleanup", requeued.Type)
+	assert.Equal(t, 0, requeued.Attempts, "deferral must not spend an attempt")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+}
+
+func TestStart_OnlyRequiresRegisteredHandler(t *testing.T) {
+	w := New(&mockQueue{}, newTestLogger(), Config{Only: []string{"email.sned"}})
+	w.RegisterHandler(&testHandler{jobType: "email.send"})
+
+	err := w.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"email.sned"`)
+}
+
+func TestDryRun_LogsAndPutsJobsBack(t *testing.T) {
+	q := &mockQueue{}
+	w := New(q, newTestLogger(), Config{Queues: []string{"jobs", "emails"}, DryRun: true})
+
+	ran := false
+	w.RegisterHandler(&testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { ran = true; return nil },
+	})
+
+	first, _ := NewJob("email.send", "a")
+	second, _ := NewJob("email.send", "b")
+	firstData, _ := first.Encode()
+	secondData, _ := second.Encode()
+
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: firstData}))
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: []byte("not-json")}))
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: secondData, ContentType: ContentTypeJSON}))
+	assert.False(t, ran, "dry run must not execute handlers")
+	require.Len(t, q.publishCalls, 3)
+	assert.Equal(t, firstData, q.publishCalls[0].body, "jobs go back unchanged")
+	assert.Equal(t, []byte("not-json"), q.publishCalls[1].body)
+	assert.Equal(t, "jobs", q.publishCalls[2].routingKey)
+	assert.Equal(t, ContentTypeJSON, q.publishCalls[2].contentType, "the content type is kept")
+
+	// Seeing the first job again completes the pass over "jobs" only.
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: firstData}))
+	assert.Error(t, w.dryRun.context("jobs").Err(), "consumers of a finished queue stop")
+	select {
+	case <-w.DryRunDone():
+		t.Fatal("dry run must wait for every queue")
+	default:
+	}
+
+	emailData, _ := NewJob("email.send", "c")
+	data, _ := emailData.Encode()
+	require.NoError(t, w.handleMessage(0, "emails", port.Message{Body: data}))
+	require.NoError(t, w.handleMessage(0, "emails", port.Message{Body: data}))
+	select {
+	case <-w.DryRunDone():
+	case <-time.After(time.Second):
+		t.Fatal("dry run should be done after passing over every queue")
+	}
+	assert.Len(t, q.publishCalls, 6, "every message is put back, including repeats")
+}
+
+func TestDryRun_PublishFailureRequeues(t *testing.T) {
+	q := &mockQueue{publishErr: errors.New("channel closed")}
+	w := New(q, newTestLogger(), Config{DryRun: true})
+
+	job, _ := NewJob("email.send", "a")
+	data, _ := job.Encode()
+	assert.Error(t, w.handleMessage(0, w.queues[0], port.Message{Body: data}), "an error nacks the delivery so the job is not lost")
+}
+
+func TestDryRunDone_NilOutsideDryRun(t *testing.T) {
+	w := New(&mockQueue{}, newTestLogger(), Config{})
+	assert.Nil(t, w.DryRunDone())
+}
+
+func TestHandleMessage_RecordsJobStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   int
+		err        error
+		deadLetter bool
+		want       []port.JobStatus
+		wantError  string
+	}{
+		{name: "succeeded", want: []port.JobStatus{port.JobStatusRunning, port.JobStatusSucceeded}},
+		{name: "retried", err: errors.New("smtp down"), want: []port.JobStatus{port.JobStatusRunning, port.JobStatusQueued}, wantError: "smtp down"},
+		{name: "exhausted", attempts: 2, err: errors.New("smtp down"), want: []port.JobStatus{port.JobStatusRunning, port.JobStatusFailed}, wantError: "smtp down"},
+		{name: "permanent", err: joberr.Permanentf("bad payload"), want: []port.JobStatus{port.JobStatusRunning, port.JobStatusFailed}, wantError: "permanent: bad payload"},
+		{name: "dead-lettered", err: joberr.Permanentf("bad payload"), deadLetter: true, want: []port.JobStatus{port.JobStatusRunning, port.JobStatusDead}, wantError: "permanent: bad payload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &jobRecorder{}
+			w := New(&mockQueue{}, newTestLogger(), Config{Jobs: rec, DeadLetter: tt.deadLetter})
+			defer func() { _ = w.Shutdown(context.Background()) }()
+			w.RegisterHandler(&testHandler{
+				jobType:  "email.send",
+				handleFn: func(_ context.Context, _ *Job) error { return tt.err },
+			})
+
+			job, _ := NewJob("email.send", "data")
+			job.Attempts = tt.attempts
+			data, _ := job.Encode()
+			require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+			assert.Equal(t, tt.want, rec.statuses())
+			running, last := rec.records[0], rec.records[len(rec.records)-1]
+			assert.Equal(t, job.ID, running.ID)
+			assert.Equal(t, "jobs", running.Queue)
+			assert.Equal(t, tt.attempts+1, running.Attempts)
+			assert.NotNil(t, running.StartedAt)
+			assert.Nil(t, running.FinishedAt)
+			assert.Equal(t, tt.wantError, last.Error)
+			assert.Equal(t, last.Status.Terminal(), last.FinishedAt != nil)
+		})
+	}
+}
+
+func TestHandleMessage_RecordsUnhandledJobFailed(t *testing.T) {
+	rec := &jobRecorder{}
+	w := New(&mockQueue{}, newTestLogger(), Config{Jobs: rec})
+
+	job, _ := NewJob("unknown.type", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+
+	require.Equal(t, []port.JobStatus{port.JobStatusFailed}, rec.statuses())
+	assert.Contains(t, rec.records[0].Error, "no handler registered")
+}
+
+func TestHandleMessage_RecordErrorDoesNotFailJob(t *testing.T) {
+	ran := false
+	w := New(&mockQueue{}, newTestLogger(), Config{Jobs: &jobRecorder{err: errors.New("db down")}})
+	w.RegisterHandler(&testHandler{
+		jobType:  "email.send",
+		handleFn: func(_ context.Context, _ *Job) error { ran = true; return nil },
+	})
+
+	job, _ := NewJob("email.send", "data")
+	data, _ := job.Encode()
+	require.NoError(t, w.handleMessage(0, "jobs", port.Message{Body: data}))
+	assert.True(t, ran)
+}
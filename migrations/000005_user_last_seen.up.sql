@@ -0,0 +1,6 @@
+-- Last authenticated activity, written (debounced) by the activity middleware.
+-- NULL means the user has not made an authenticated request since tracking began.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ;
+
+-- Supports the inactive-users report and dormant-account job.
+CREATE INDEX IF NOT EXISTS idx_users_last_seen_at ON users (COALESCE(last_seen_at, created_at)) WHERE is_active = true;
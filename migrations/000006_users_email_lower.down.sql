@@ -0,0 +1,3 @@
+-- The lower-casing backfill is not reverted: the original spelling is gone.
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+DROP INDEX IF EXISTS idx_users_email_lower;
@@ -0,0 +1,36 @@
+-- Emails are unique case-insensitively: the application stores them lower-cased
+-- and looks them up with lower(email).
+--
+-- Abort with a readable message if existing rows differ only by case. Merge
+-- those accounts first (POST /api/users/:id/merge); the users.normalize_emails
+-- job in dry-run mode lists them.
+DO $$
+DECLARE
+    dupes TEXT;
+BEGIN
+    SELECT string_agg(e, ', ') INTO dupes
+    FROM (
+        SELECT lower(trim(email)) AS e
+        FROM users
+        GROUP BY lower(trim(email))
+        HAVING COUNT(*) > 1
+        ORDER BY 1
+        LIMIT 20
+    ) d;
+
+    IF dupes IS NOT NULL THEN
+        RAISE EXCEPTION 'users.email has case-insensitive duplicates: %', dupes
+            USING HINT = 'Merge the duplicate accounts, then rerun the migration.';
+    END IF;
+END $$;
+
+-- Backfill: store every address in canonical (trimmed, lower-case) form.
+UPDATE users
+SET email = lower(trim(email)), updated_at = NOW()
+WHERE email <> lower(trim(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
+
+-- Superseded by idx_users_email_lower; the UNIQUE constraint on email keeps
+-- its own index.
+DROP INDEX IF EXISTS idx_users_email;
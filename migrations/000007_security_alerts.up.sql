@@ -0,0 +1,18 @@
+-- Findings of the security.detect_anomalies job.
+CREATE TABLE IF NOT EXISTS security_alerts (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    kind VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    summary TEXT NOT NULL,
+    details JSONB,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- One alert per finding and window, so re-running the job for a window does
+-- not raise (or notify) it twice.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_security_alerts_unique
+    ON security_alerts (kind, (COALESCE(user_id::text, '')), window_start);
+CREATE INDEX IF NOT EXISTS idx_security_alerts_created_at ON security_alerts (created_at);
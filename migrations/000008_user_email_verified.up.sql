@@ -0,0 +1,6 @@
+-- When the user proved they own their email address. NULL means unverified.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Accounts created before verification existed are treated as verified, so
+-- turning on email_verification.require_for_login does not lock them out.
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
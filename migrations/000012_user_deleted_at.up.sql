@@ -0,0 +1,6 @@
+-- When the user was deleted. NULL means the account has not been deleted;
+-- is_active alone cannot tell a deleted user from a deactivated one.
+--
+-- Users deleted before this migration only have is_active = false, so they
+-- stay indistinguishable from deactivated users and remain listed.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,9 @@
+-- Free-form profile attributes (department, phone, locale, ...) as a JSON
+-- object. The size cap keeps repeated PATCHes from growing a row without
+-- bound; the GIN index serves the @> and ?& filters of GET /api/users.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE users ADD CONSTRAINT users_metadata_object CHECK (jsonb_typeof(metadata) = 'object');
+ALTER TABLE users ADD CONSTRAINT users_metadata_size CHECK (octet_length(metadata::text) <= 16384);
+
+CREATE INDEX IF NOT EXISTS idx_users_metadata ON users USING GIN (metadata);
@@ -0,0 +1,12 @@
+-- Per-user settings served by GET/PUT /api/users/me/preferences. A user has
+-- no row until they first change a setting; until then the column defaults
+-- apply (mirrored by domain.DefaultPreferences).
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL DEFAULT 'en',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    email_notifications BOOLEAN NOT NULL DEFAULT true,
+    push_notifications BOOLEAN NOT NULL DEFAULT true,
+    digest VARCHAR(10) NOT NULL DEFAULT 'weekly' CHECK (digest IN ('off', 'daily', 'weekly')),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- Set by POST /api/users/:id/force-password-reset; while true the user's
+-- access tokens only reach the change-password endpoint. Cleared whenever
+-- the password is changed.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_logins;
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_ip;
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
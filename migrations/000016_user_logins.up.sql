@@ -0,0 +1,16 @@
+-- Successful logins. users keeps the latest one for responses; user_logins
+-- keeps the last activity.login_history_size per user, trimmed on every
+-- login, for GET /api/users/:id/logins. IPs are stored as the text the
+-- server saw, as in refresh-token sessions.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_ip VARCHAR(45);
+
+CREATE TABLE IF NOT EXISTS user_logins (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    logged_in_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_logins_user_id ON user_logins (user_id, logged_in_at DESC, id DESC);
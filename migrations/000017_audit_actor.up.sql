@@ -0,0 +1,5 @@
+-- The admin behind an entry written while they impersonated user_id. NULL
+-- for every entry a user wrote as themselves.
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS actor_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_actor_id ON audit_logs (actor_id) WHERE actor_id IS NOT NULL;
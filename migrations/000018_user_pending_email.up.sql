@@ -0,0 +1,3 @@
+-- Address a user asked to change to through PUT /api/users/:id, waiting for
+-- the confirmation link sent to it. Moved to email on confirmation.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
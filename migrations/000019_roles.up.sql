@@ -0,0 +1,20 @@
+-- Roles that can be granted to users. Casbin only knows a role through the
+-- rules that mention it, so this table is what makes a role exist: roles are
+-- created and deleted through /api/roles, and only these can be assigned.
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(64) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('superadmin', 'Full system access with all permissions'),
+    ('admin', 'Administrative access with most permissions'),
+    ('editor', 'Can create and edit content'),
+    ('viewer', 'Read-only access')
+ON CONFLICT DO NOTHING;
+
+-- Roles already granted through hand-written rules stay manageable.
+INSERT INTO roles (name)
+SELECT DISTINCT v1 FROM casbin_rules WHERE p_type = 'g' AND v1 <> '' AND length(v1) <= 64
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS casbin_rules_changed ON casbin_rules;
+DROP FUNCTION IF EXISTS notify_casbin_rules_changed();
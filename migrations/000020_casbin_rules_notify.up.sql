@@ -0,0 +1,15 @@
+-- Every statement that changes casbin_rules sends a NOTIFY on
+-- casbin_rules_changed, whoever runs it, so each instance's Casbin watcher
+-- reloads the policy. NOTIFY is delivered on commit, and identical
+-- notifications within a transaction are folded into one.
+CREATE OR REPLACE FUNCTION notify_casbin_rules_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('casbin_rules_changed', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS casbin_rules_changed ON casbin_rules;
+CREATE TRIGGER casbin_rules_changed
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON casbin_rules
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_casbin_rules_changed();
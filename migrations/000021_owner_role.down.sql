@@ -0,0 +1,2 @@
+DELETE FROM casbin_rules WHERE p_type = 'p' AND v0 = 'owner';
+DELETE FROM roles WHERE name = 'owner';
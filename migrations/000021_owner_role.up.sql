@@ -0,0 +1,9 @@
+-- The owner role holds the permissions every user has on the objects they
+-- own (routes declared with OwnedBy). It is never granted to a user.
+INSERT INTO roles (name, description) VALUES
+    ('owner', 'Permissions every user has on what they own')
+ON CONFLICT DO NOTHING;
+
+-- Users can read their own profile through /api/users/:id.
+INSERT INTO casbin_rules (p_type, v0, v1, v2) VALUES ('p', 'owner', 'users', 'read')
+ON CONFLICT (p_type, v0, v1, v2, v3, v4, v5) DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM casbin_rules WHERE p_type = 'p' AND v3 = 'deny';
+UPDATE casbin_rules SET v3 = '' WHERE p_type = 'p' AND v3 = 'allow';
@@ -0,0 +1,3 @@
+-- Permission rules gain an effect, p = sub, obj, act, eft, so deny rules
+-- can override what a role allows. Every existing rule allows.
+UPDATE casbin_rules SET v3 = 'allow' WHERE p_type = 'p' AND v3 = '';
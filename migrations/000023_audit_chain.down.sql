@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_audit_chain_seq;
+ALTER TABLE audit_logs
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq;
@@ -0,0 +1,10 @@
+-- Hash chain of tamper-evident audit entries (audit.chain). chain_seq
+-- numbers chained entries without gaps; hash covers an entry's content and
+-- prev_hash, the hash of the entry before it. All three are NULL for entries
+-- written with the chain off.
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS chain_seq BIGINT,
+    ADD COLUMN IF NOT EXISTS prev_hash TEXT,
+    ADD COLUMN IF NOT EXISTS hash TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_chain_seq ON audit_logs (chain_seq) WHERE chain_seq IS NOT NULL;
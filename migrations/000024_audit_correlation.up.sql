@@ -0,0 +1,8 @@
+-- Correlation IDs of audit entries: the X-Request-ID of the request that
+-- wrote the entry and its OpenTelemetry trace ID, NULL when unknown.
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS request_id TEXT,
+    ADD COLUMN IF NOT EXISTS trace_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id) WHERE request_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_trace_id ON audit_logs (trace_id) WHERE trace_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_new_value;
+DROP INDEX IF EXISTS idx_audit_old_value;
@@ -0,0 +1,3 @@
+-- Containment searches over audit values (old_value @> '{"email": ...}')
+CREATE INDEX IF NOT EXISTS idx_audit_old_value ON audit_logs USING GIN (old_value jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_audit_new_value ON audit_logs USING GIN (new_value jsonb_path_ops);
@@ -0,0 +1,13 @@
+-- Recurring jobs for the worker's scheduler, next to those in config when
+-- scheduler.database is on. Rows are reloaded every scheduler.refresh; a
+-- max_retry of 0 keeps the job's default.
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name VARCHAR(100) PRIMARY KEY,
+    cron VARCHAR(100) NOT NULL,
+    job_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    max_retry INT NOT NULL DEFAULT 0 CHECK (max_retry >= 0),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
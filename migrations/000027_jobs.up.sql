@@ -0,0 +1,20 @@
+-- Tracked state of background jobs when worker.tracking is on. Publishers
+-- insert a job as queued; workers move it through running to succeeded,
+-- failed or dead. Rows are never deleted by the application.
+CREATE TABLE IF NOT EXISTS jobs (
+    id VARCHAR(100) PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    queue VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_retry INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created_at ON jobs (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_type_created_at ON jobs (type, created_at DESC);
@@ -0,0 +1,20 @@
+-- Messages written in the transaction of the change they describe and
+-- relayed to the queue by cmd/worker. published_at is set once a message is
+-- published; published rows are deleted after worker.outbox.retention.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(100) NOT NULL,
+    exchange VARCHAR(255) NOT NULL DEFAULT '',
+    routing_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    headers JSONB,
+    priority SMALLINT NOT NULL DEFAULT 0,
+    body BYTEA NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL migrations, so the API binary can apply
+// them without the migrations directory beside it.
+package migrations
+
+import "embed"
+
+// FS holds the golang-migrate files (NNNNNN_name.up.sql and .down.sql) at
+// its root.
+//
+//go:embed *.sql
+var FS embed.FS
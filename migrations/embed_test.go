@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	names, err := fs.Glob(FS, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	for _, name := range names {
+		if up, ok := strings.CutSuffix(name, ".up.sql"); ok {
+			_, err := fs.Stat(FS, up+".down.sql")
+			assert.NoError(t, err, "%s has no down migration", name)
+		}
+	}
+
+	src, err := iofs.New(FS, ".")
+	require.NoError(t, err)
+	defer src.Close()
+	first, err := src.First()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), first)
+}
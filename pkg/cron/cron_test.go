@@ -0,0 +1,107 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"@fortnightly",
+		"* * * foo *",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSchedule_Matches(t *testing.T) {
+	tests := []struct {
+		spec  string
+		time  string
+		match bool
+	}{
+		{"* * * * *", "2026-03-04 05:06", true},
+		{"30 3 * * *", "2026-03-04 03:30", true},
+		{"30 3 * * *", "2026-03-04 03:31", false},
+		{"*/15 * * * *", "2026-03-04 10:45", true},
+		{"*/15 * * * *", "2026-03-04 10:46", false},
+		{"5/20 * * * *", "2026-03-04 10:25", true},
+		{"0 9-17/4 * * *", "2026-03-04 13:00", true},
+		{"0 9-17/4 * * *", "2026-03-04 15:00", false},
+		{"0 0 1,15 * *", "2026-03-15 00:00", true},
+		{"0 0 * jan-mar *", "2026-03-01 00:00", true},
+		{"0 0 * jan-mar *", "2026-04-01 00:00", false},
+		{"0 0 * * mon-fri", "2026-03-07 00:00", false}, // Saturday
+		{"0 0 * * 7", "2026-03-08 00:00", true},        // Sunday
+		{"0 0 * * SUN", "2026-03-08 00:00", true},
+		// Both day fields restricted: either matches
+		{"0 0 1 * mon", "2026-03-02 00:00", true},
+		{"0 0 1 * mon", "2026-03-01 00:00", true},
+		{"0 0 1 * mon", "2026-03-03 00:00", false},
+		// One restricted: both must match
+		{"0 0 */2 * mon", "2026-03-02 00:00", false},
+		{"@hourly", "2026-03-04 05:00", true},
+		{"@daily", "2026-03-04 05:00", false},
+		{"@weekly", "2026-03-08 00:00", true},
+		{"@yearly", "2026-01-01 00:00", true},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.match, s.Matches(at(tt.time)), "%s at %s", tt.spec, tt.time)
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"* * * * *", "2026-03-04 05:06", "2026-03-04 05:07"},
+		{"30 3 * * *", "2026-03-04 03:30", "2026-03-05 03:30"},
+		{"0 * * * *", "2026-12-31 23:59", "2027-01-01 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"0 12 * * fri", "2026-03-04 13:00", "2026-03-06 12:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, at(tt.want), s.Next(at(tt.from)), tt.spec)
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(at("2026-01-01 00:00")).IsZero())
+}
+
+func TestSchedule_NextInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata") // UTC+5:30
+	require.NoError(t, err)
+	s, err := Parse("0 * * * *")
+	require.NoError(t, err)
+
+	next := s.Next(time.Date(2026, 3, 4, 10, 15, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, 3, 4, 11, 0, 0, 0, loc), next)
+}
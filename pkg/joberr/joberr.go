@@ -0,0 +1,138 @@
+// Package joberr classifies background job failures so the worker can decide
+// whether a retry makes sense.
+//
+// Handlers wrap their errors with one of the constructors (or %w one of the
+// sentinels); anything left unclassified is treated as transient, which keeps
+// the historical retry-everything behaviour for code that has not opted in.
+package joberr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinels for errors.Is. They may also be wrapped directly:
+//
+//	return fmt.Errorf("recipient is required: %w", joberr.ErrPermanent)
+var (
+	// ErrTransient marks a failure that may succeed on a later attempt
+	// (network blip, dependency briefly unavailable).
+	ErrTransient = errors.New("transient job error")
+
+	// ErrPermanent marks a failure that no retry can fix (malformed payload,
+	// validation failure, referenced entity gone). The job is dropped.
+	ErrPermanent = errors.New("permanent job error")
+
+	// ErrRateLimited marks a failure caused by a downstream rate limit. The
+	// job is retried no earlier than the accompanying RetryAfter.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Kind is the retry classification of a job error.
+type Kind int
+
+const (
+	KindTransient Kind = iota
+	KindPermanent
+	KindRateLimited
+)
+
+// String returns the kind as used in log fields.
+func (k Kind) String() string {
+	switch k {
+	case KindPermanent:
+		return "permanent"
+	case KindRateLimited:
+		return "rate_limited"
+	default:
+		return "transient"
+	}
+}
+
+// Error carries a classification and, for rate limits, the retry delay.
+type Error struct {
+	Kind       Kind
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.sentinel().Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrPermanent) etc. match the classification.
+func (e *Error) Is(target error) bool {
+	return target == e.sentinel()
+}
+
+func (e *Error) sentinel() error {
+	switch e.Kind {
+	case KindPermanent:
+		return ErrPermanent
+	case KindRateLimited:
+		return ErrRateLimited
+	default:
+		return ErrTransient
+	}
+}
+
+// Transient wraps err as retryable.
+func Transient(err error) error {
+	return &Error{Kind: KindTransient, Err: err}
+}
+
+// Permanent wraps err as not retryable.
+func Permanent(err error) error {
+	return &Error{Kind: KindPermanent, Err: err}
+}
+
+// Permanentf formats a permanent error, typically for payload validation.
+func Permanentf(format string, args ...any) error {
+	return Permanent(fmt.Errorf(format, args...))
+}
+
+// RateLimited wraps err as retryable after retryAfter. A non-positive
+// retryAfter leaves the delay to the worker's normal backoff.
+func RateLimited(err error, retryAfter time.Duration) error {
+	return &Error{Kind: KindRateLimited, RetryAfter: retryAfter, Err: err}
+}
+
+// Classify returns the kind of err. Unclassified errors are transient.
+func Classify(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	switch {
+	case errors.Is(err, ErrPermanent):
+		return KindPermanent
+	case errors.Is(err, ErrRateLimited):
+		return KindRateLimited
+	default:
+		return KindTransient
+	}
+}
+
+// IsPermanent reports whether err must not be retried.
+func IsPermanent(err error) bool {
+	return Classify(err) == KindPermanent
+}
+
+// RetryAfter returns the rate-limit delay carried by err, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *Error
+	if errors.As(err, &e) && e.Kind == KindRateLimited && e.RetryAfter > 0 {
+		return e.RetryAfter, true
+	}
+	return 0, false
+}
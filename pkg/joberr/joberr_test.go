@@ -0,0 +1,60 @@
+package joberr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	base := errors.New("boom")
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"unclassified", base, KindTransient},
+		{"transient", Transient(base), KindTransient},
+		{"permanent", Permanent(base), KindPermanent},
+		{"permanentf", Permanentf("bad payload: %w", base), KindPermanent},
+		{"rate_limited", RateLimited(base, time.Second), KindRateLimited},
+		{"wrapped_permanent", fmt.Errorf("handler: %w", Permanent(base)), KindPermanent},
+		{"sentinel_permanent", fmt.Errorf("missing recipient: %w", ErrPermanent), KindPermanent},
+		{"sentinel_rate_limited", fmt.Errorf("smtp 421: %w", ErrRateLimited), KindRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err))
+		})
+	}
+}
+
+func TestError_IsAndUnwrap(t *testing.T) {
+	base := errors.New("boom")
+	err := fmt.Errorf("send: %w", Permanent(base))
+
+	assert.ErrorIs(t, err, ErrPermanent)
+	assert.ErrorIs(t, err, base)
+	assert.NotErrorIs(t, err, ErrTransient)
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, "send: permanent: boom", err.Error())
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := RetryAfter(fmt.Errorf("wrapped: %w", RateLimited(errors.New("429"), 30*time.Second)))
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	_, ok = RetryAfter(RateLimited(errors.New("429"), 0))
+	assert.False(t, ok, "zero delay falls back to the worker backoff")
+
+	_, ok = RetryAfter(fmt.Errorf("x: %w", ErrRateLimited))
+	assert.False(t, ok, "bare sentinel carries no delay")
+
+	_, ok = RetryAfter(Transient(errors.New("x")))
+	assert.False(t, ok)
+}
@@ -23,6 +23,9 @@ const (
 	IPAddressKey ContextKey = "ip_address"
 	// UserAgentKey is the context key for the client User-Agent header
 	UserAgentKey ContextKey = "user_agent"
+	// ActorIDKey is the context key for the ID of the admin impersonating
+	// the user in UserIDKey; unset when nobody is impersonating
+	ActorIDKey ContextKey = "actor_id"
 )
 
 // Logger wraps slog.Logger with additional functionality
@@ -108,6 +111,9 @@ func (l *Logger) WithContext(ctx context.Context) *Logger {
 	if userID, ok := ctx.Value(UserIDKey).(string); ok && userID != "" {
 		attrs = append(attrs, "user_id", userID)
 	}
+	if actorID, ok := ctx.Value(ActorIDKey).(string); ok && actorID != "" {
+		attrs = append(attrs, "actor_id", actorID)
+	}
 	if traceID, ok := ctx.Value(TraceIDKey).(string); ok && traceID != "" {
 		attrs = append(attrs, "trace_id", traceID)
 	}
@@ -0,0 +1,54 @@
+// Package perf provides allocation-budget assertions for hot-path tests.
+//
+// Benchmarks tell you how fast something is; budgets stop it from quietly
+// getting slower. Each package that owns a hot path declares a Budgets table
+// next to its benchmarks and asserts every entry from a regular Test so
+// `go test ./...` fails the moment a change adds allocations per op.
+//
+//	var budgets = perf.Budgets{"cursor.encode": 6}
+//
+//	func TestAllocBudgets(t *testing.T) {
+//		budgets.Check(t, "cursor.encode", func() { _ = c.Encode() })
+//	}
+//
+// Allocation counts are meaningless under the race detector (which
+// instruments every access), so checks are skipped in -race builds.
+package perf
+
+import (
+	"testing"
+)
+
+// runs is the number of iterations testing.AllocsPerRun averages over.
+const runs = 200
+
+// Budgets maps a hot-path name to its maximum allocations per operation.
+type Budgets map[string]float64
+
+// Check asserts that fn stays within the budget registered under name.
+// An unregistered name is a test bug and fails immediately so budgets
+// cannot be silently bypassed by a typo.
+func (b Budgets) Check(t testing.TB, name string, fn func()) {
+	t.Helper()
+	limit, ok := b[name]
+	if !ok {
+		t.Fatalf("perf: no allocation budget registered for %q", name)
+		return
+	}
+	AssertAllocs(t, name, limit, fn)
+}
+
+// AssertAllocs fails t when fn allocates more than maxAllocs per run on
+// average. fn is invoked once before measuring so lazy initialisation
+// (sync.Once, pools) does not count against the budget.
+func AssertAllocs(t testing.TB, name string, maxAllocs float64, fn func()) {
+	t.Helper()
+	if RaceEnabled {
+		t.Skipf("perf: allocation budget %q skipped under -race", name)
+		return
+	}
+	got := testing.AllocsPerRun(runs, fn)
+	if got > maxAllocs {
+		t.Errorf("perf: %s allocates %.1f/op, budget is %.1f/op", name, got, maxAllocs)
+	}
+}
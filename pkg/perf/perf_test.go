@@ -0,0 +1,43 @@
+package perf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder captures failures so the harness itself can be tested without
+// failing the enclosing test.
+type recorder struct {
+	testing.TB
+	failed  bool
+	skipped bool
+}
+
+func (r *recorder) Helper()               {}
+func (r *recorder) Errorf(string, ...any) { r.failed = true }
+func (r *recorder) Fatalf(string, ...any) { r.failed = true }
+func (r *recorder) Skipf(string, ...any)  { r.skipped = true }
+
+var sink []byte
+
+func TestAssertAllocs_WithinBudget(t *testing.T) {
+	r := &recorder{TB: t}
+	AssertAllocs(r, "noop", 0, func() {})
+	assert.False(t, r.failed)
+}
+
+func TestAssertAllocs_OverBudget(t *testing.T) {
+	if RaceEnabled {
+		t.Skip("allocation counts are not meaningful under -race")
+	}
+	r := &recorder{TB: t}
+	AssertAllocs(r, "alloc", 0, func() { sink = make([]byte, 64) })
+	assert.True(t, r.failed)
+}
+
+func TestBudgets_UnknownNameFails(t *testing.T) {
+	r := &recorder{TB: t}
+	Budgets{"known": 1}.Check(r, "unknown", func() {})
+	assert.True(t, r.failed)
+}
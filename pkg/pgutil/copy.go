@@ -0,0 +1,116 @@
+package pgutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Copier is satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type Copier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// copyMapping is the cached column layout of a struct type.
+type copyMapping struct {
+	columns []string
+	fields  [][]int // reflect field index path per column
+}
+
+var copyMappings sync.Map // reflect.Type → *copyMapping
+
+// CopyFrom inserts rows into table with a single COPY FROM STDIN, turning N
+// INSERT round-trips into one streamed statement. Columns come from `db:"col"`
+// struct tags; untagged fields and `db:"-"` are skipped, and embedded structs
+// are flattened. table may be schema-qualified ("audit.logs").
+//
+// COPY is all-or-nothing and does not support ON CONFLICT: a single duplicate
+// key aborts the whole batch. Filter out existing rows first, or COPY into a
+// temp table and INSERT ... SELECT ... ON CONFLICT from there.
+func CopyFrom[T any](ctx context.Context, db Copier, table string, rows []T) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	m, err := mappingFor(reflect.TypeFor[T]())
+	if err != nil {
+		return 0, err
+	}
+
+	src := pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+		v := reflect.ValueOf(&rows[i]).Elem()
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, fmt.Errorf("pgutil: CopyFrom row %d is nil", i)
+			}
+			v = v.Elem()
+		}
+		values := make([]any, len(m.fields))
+		for j, idx := range m.fields {
+			values[j] = v.FieldByIndex(idx).Interface()
+		}
+		return values, nil
+	})
+
+	n, err := db.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), m.columns, src)
+	if err != nil {
+		return n, fmt.Errorf("copy into %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// CopyColumns returns the column names CopyFrom would use for T.
+func CopyColumns[T any]() ([]string, error) {
+	m, err := mappingFor(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), m.columns...), nil
+}
+
+func mappingFor(t reflect.Type) (*copyMapping, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if cached, ok := copyMappings.Load(t); ok {
+		return cached.(*copyMapping), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("pgutil: CopyFrom requires a struct type, got %s", t)
+	}
+
+	m := &copyMapping{}
+	collectColumns(t, nil, m)
+	if len(m.columns) == 0 {
+		return nil, fmt.Errorf("pgutil: %s has no `db` tagged fields", t)
+	}
+
+	actual, _ := copyMappings.LoadOrStore(t, m)
+	return actual.(*copyMapping), nil
+}
+
+func collectColumns(t reflect.Type, prefix []int, m *copyMapping) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int(nil), prefix...), i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("db") == "" {
+			collectColumns(f.Type, index, m)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		m.columns = append(m.columns, name)
+		m.fields = append(m.fields, index)
+	}
+}
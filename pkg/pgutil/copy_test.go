@@ -0,0 +1,107 @@
+package pgutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCopier records what CopyFrom would send to Postgres.
+type fakeCopier struct {
+	table   pgx.Identifier
+	columns []string
+	rows    [][]any
+	err     error
+}
+
+func (f *fakeCopier) CopyFrom(_ context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	f.table = table
+	f.columns = columns
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		f.rows = append(f.rows, values)
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+	return int64(len(f.rows)), src.Err()
+}
+
+type copyBase struct {
+	ID string `db:"id"`
+}
+
+type copyRow struct {
+	copyBase
+	Name     string `db:"name"`
+	Age      int    `db:"age,omitempty"`
+	Ignored  string `db:"-"`
+	Untagged string
+	private  string `db:"private"` //nolint:unused // unexported fields must be skipped
+}
+
+func TestCopyColumns(t *testing.T) {
+	cols, err := CopyColumns[copyRow]()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "age"}, cols)
+}
+
+func TestCopyFrom_MapsStructFields(t *testing.T) {
+	f := &fakeCopier{}
+	rows := []copyRow{
+		{copyBase: copyBase{ID: "1"}, Name: "alice", Age: 30, Ignored: "x"},
+		{copyBase: copyBase{ID: "2"}, Name: "bob", Age: 40},
+	}
+
+	n, err := CopyFrom(context.Background(), f, "public.people", rows)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, pgx.Identifier{"public", "people"}, f.table)
+	assert.Equal(t, []string{"id", "name", "age"}, f.columns)
+	assert.Equal(t, [][]any{{"1", "alice", 30}, {"2", "bob", 40}}, f.rows)
+}
+
+func TestCopyFrom_PointerRows(t *testing.T) {
+	f := &fakeCopier{}
+
+	n, err := CopyFrom(context.Background(), f, "people", []*copyRow{{Name: "carol"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, pgx.Identifier{"people"}, f.table)
+
+	_, err = CopyFrom(context.Background(), &fakeCopier{}, "people", []*copyRow{nil})
+	assert.Error(t, err)
+}
+
+func TestCopyFrom_EmptyIsNoop(t *testing.T) {
+	f := &fakeCopier{}
+
+	n, err := CopyFrom(context.Background(), f, "people", []copyRow(nil))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Nil(t, f.table, "no COPY should be issued")
+}
+
+func TestCopyFrom_RejectsUnmappedTypes(t *testing.T) {
+	_, err := CopyFrom(context.Background(), &fakeCopier{}, "t", []int{1})
+	assert.Error(t, err)
+
+	type noTags struct{ Name string }
+	_, err = CopyFrom(context.Background(), &fakeCopier{}, "t", []noTags{{Name: "x"}})
+	assert.Error(t, err)
+}
+
+func TestCopyFrom_WrapsError(t *testing.T) {
+	sentinel := errors.New("duplicate key")
+	_, err := CopyFrom(context.Background(), &fakeCopier{err: sentinel}, "people", []copyRow{{Name: "a"}})
+	assert.ErrorIs(t, err, sentinel)
+	assert.Contains(t, err.Error(), "people")
+}
@@ -0,0 +1,80 @@
+package pgutil
+
+import (
+	"errors"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgreSQL SQLSTATE codes recognised by this package
+const (
+	SQLStateUniqueViolation      = "23505"
+	SQLStateForeignKeyViolation  = "23503"
+	SQLStateNotNullViolation     = "23502"
+	SQLStateSerializationFailure = "40001"
+	SQLStateDeadlockDetected     = "40P01"
+)
+
+// IsNoRows reports whether err is, or wraps, pgx.ErrNoRows
+func IsNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// IsSerializationFailure reports whether err is a PostgreSQL
+// serialization_failure, raised when a transaction conflicts with a concurrent
+// one and may succeed if retried
+func IsSerializationFailure(err error) bool {
+	pgErr := PgError(err)
+	return pgErr != nil && pgErr.Code == SQLStateSerializationFailure
+}
+
+// IsDeadlock reports whether err is a PostgreSQL deadlock_detected error. The
+// transaction chosen as the deadlock victim may succeed if retried.
+func IsDeadlock(err error) bool {
+	pgErr := PgError(err)
+	return pgErr != nil && pgErr.Code == SQLStateDeadlockDetected
+}
+
+// MapError translates a database error into an apperr.Error so repositories
+// report the same failure the same way. subject names what the query was
+// about and is used in the client message, e.g. "user 42":
+//
+//   - no rows               -> 404 "<subject> not found"
+//   - unique violation      -> 409 "<subject> already exists"
+//   - foreign key violation -> 409 "<subject> conflicts with a related record"
+//   - serialization failure -> 409 asking the client to retry
+//   - deadlock              -> 409 asking the client to retry
+//
+// err may be wrapped; the result wraps it, so errors.Is and errors.As still
+// see the original (database.Transactor relies on this to retry serialization
+// failures and deadlocks). An err that is already an *apperr.Error, or that
+// matches none of the above, is returned unchanged. MapError(nil, ...)
+// returns nil.
+func MapError(err error, subject string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := apperr.AsAppError(err); ok {
+		return err
+	}
+
+	switch {
+	case IsNoRows(err):
+		return apperr.NotFoundf("%s not found", subject).WithError(err)
+	case IsSerializationFailure(err), IsDeadlock(err):
+		return apperr.ErrConflict.
+			WithMessage("The request conflicted with a concurrent update, please retry").
+			WithError(err)
+	}
+
+	switch pgErr := PgError(err); {
+	case pgErr == nil:
+		return err
+	case pgErr.Code == SQLStateUniqueViolation:
+		return apperr.Conflictf("%s already exists", subject).WithError(err)
+	case pgErr.Code == SQLStateForeignKeyViolation:
+		return apperr.Conflictf("%s conflicts with a related record", subject).WithError(err)
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package pgutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{
+			name:    "no_rows",
+			err:     pgx.ErrNoRows,
+			status:  http.StatusNotFound,
+			message: "user 42 not found",
+		},
+		{
+			name:    "wrapped_no_rows",
+			err:     fmt.Errorf("failed to get user: %w", pgx.ErrNoRows),
+			status:  http.StatusNotFound,
+			message: "user 42 not found",
+		},
+		{
+			name:    "unique_violation",
+			err:     fmt.Errorf("failed to create user: %w", &pgconn.PgError{Code: SQLStateUniqueViolation}),
+			status:  http.StatusConflict,
+			message: "user 42 already exists",
+		},
+		{
+			name:    "foreign_key_violation",
+			err:     &pgconn.PgError{Code: SQLStateForeignKeyViolation},
+			status:  http.StatusConflict,
+			message: "user 42 conflicts with a related record",
+		},
+		{
+			name:    "serialization_failure",
+			err:     &pgconn.PgError{Code: SQLStateSerializationFailure},
+			status:  http.StatusConflict,
+			message: "The request conflicted with a concurrent update, please retry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MapError(tt.err, "user 42")
+
+			appErr, ok := apperr.AsAppError(got)
+			require.True(t, ok, "expected an apperr.Error, got %T", got)
+			assert.Equal(t, tt.status, appErr.HTTPStatus)
+			assert.Equal(t, tt.message, appErr.Message)
+			assert.ErrorIs(t, got, tt.err, "the original error must stay wrapped")
+		})
+	}
+}
+
+func TestMapError_Passthrough(t *testing.T) {
+	assert.NoError(t, MapError(nil, "user"))
+
+	plain := errors.New("connection reset")
+	assert.Same(t, plain, MapError(plain, "user"))
+
+	other := &pgconn.PgError{Code: SQLStateNotNullViolation}
+	assert.Same(t, other, MapError(other, "user"))
+
+	existing := apperr.NotFoundf("user 42 not found")
+	assert.Same(t, existing, MapError(existing, "something else"))
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.False(t, IsSerializationFailure(nil))
+	assert.True(t, IsSerializationFailure(&pgconn.PgError{Code: SQLStateSerializationFailure}))
+	assert.True(t, IsSerializationFailure(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: SQLStateSerializationFailure})))
+	assert.False(t, IsSerializationFailure(&pgconn.PgError{Code: SQLStateUniqueViolation}))
+}
+
+func TestIsNoRows(t *testing.T) {
+	assert.True(t, IsNoRows(pgx.ErrNoRows))
+	assert.True(t, IsNoRows(fmt.Errorf("wrapped: %w", pgx.ErrNoRows)))
+	assert.False(t, IsNoRows(errors.New("other")))
+}
@@ -1,6 +1,7 @@
 package pgutil
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/google/uuid"
@@ -47,8 +48,8 @@ func IsDuplicateKeyError(err error) bool {
 		return false
 	}
 	// Check for pgx error type
-	if pgErr, ok := err.(*pgconn.PgError); ok {
-		return pgErr.Code == "23505" // unique_violation
+	if pgErr := PgError(err); pgErr != nil {
+		return pgErr.Code == SQLStateUniqueViolation
 	}
 	// Fallback to string matching
 	errStr := err.Error()
@@ -60,8 +61,8 @@ func IsForeignKeyViolation(err error) bool {
 	if err == nil {
 		return false
 	}
-	if pgErr, ok := err.(*pgconn.PgError); ok {
-		return pgErr.Code == "23503" // foreign_key_violation
+	if pgErr := PgError(err); pgErr != nil {
+		return pgErr.Code == SQLStateForeignKeyViolation
 	}
 	return strings.Contains(err.Error(), "23503")
 }
@@ -71,15 +72,17 @@ func IsNotNullViolation(err error) bool {
 	if err == nil {
 		return false
 	}
-	if pgErr, ok := err.(*pgconn.PgError); ok {
-		return pgErr.Code == "23502" // not_null_violation
+	if pgErr := PgError(err); pgErr != nil {
+		return pgErr.Code == SQLStateNotNullViolation
 	}
 	return strings.Contains(err.Error(), "23502")
 }
 
-// PgError extracts the underlying PostgreSQL error if present
+// PgError extracts the underlying PostgreSQL error if present, unwrapping
+// wrapped errors
 func PgError(err error) *pgconn.PgError {
-	if pgErr, ok := err.(*pgconn.PgError); ok {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
 		return pgErr
 	}
 	return nil
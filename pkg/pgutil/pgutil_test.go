@@ -2,6 +2,7 @@ package pgutil
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/google/uuid"
@@ -165,6 +166,14 @@ func TestPgError(t *testing.T) {
 		assert.Equal(t, "duplicate", result.Message)
 	})
 
+	t.Run("wrapped_pg_error", func(t *testing.T) {
+		err := fmt.Errorf("failed to create user: %w", &pgconn.PgError{Code: "23505"})
+		result := PgError(err)
+		require.NotNil(t, result)
+		assert.Equal(t, "23505", result.Code)
+		assert.True(t, IsDuplicateKeyError(err))
+	})
+
 	t.Run("non_pg_error", func(t *testing.T) {
 		err := errors.New("regular error")
 		result := PgError(err)
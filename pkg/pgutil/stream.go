@@ -0,0 +1,49 @@
+package pgutil
+
+import (
+	"context"
+	"iter"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier is the subset of database.DBTX (pool or tx) needed to stream rows.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// StreamRows returns an iterator that runs sql when iteration starts and
+// yields one scanned value per row, without collecting the result set into a
+// slice. scan has the pgx.RowToFunc shape, so pgx.RowToStructByName[T] and
+// friends can be passed directly.
+//
+// The query error, any scan error, and rows.Err() are each yielded once as
+// (zero, err), after which iteration ends. Breaking out of the loop early
+// closes the rows and releases the connection.
+func StreamRows[T any](ctx context.Context, q Querier, sql string, args []any, scan pgx.RowToFunc[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := q.Query(ctx, sql, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			item, err := scan(rows)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package pgutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRows is a minimal in-memory pgx.Rows over a slice of single-column rows.
+type fakeRows struct {
+	values []string
+	pos    int
+	err    error
+	closed bool
+}
+
+func (r *fakeRows) Close()                                       { r.closed = true }
+func (r *fakeRows) Err() error                                   { return r.err }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *fakeRows) RawValues() [][]byte                          { return nil }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+func (r *fakeRows) Values() ([]any, error)                       { return []any{r.values[r.pos-1]}, nil }
+
+func (r *fakeRows) Next() bool {
+	if r.closed || r.pos >= len(r.values) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	*(dest[0].(*string)) = r.values[r.pos-1]
+	return nil
+}
+
+type fakeQuerier struct {
+	rows *fakeRows
+	err  error
+}
+
+func (q *fakeQuerier) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.rows, nil
+}
+
+func scanString(row pgx.CollectableRow) (string, error) {
+	var s string
+	err := row.Scan(&s)
+	return s, err
+}
+
+func TestStreamRows_YieldsEveryRow(t *testing.T) {
+	rows := &fakeRows{values: []string{"a", "b", "c"}}
+
+	var got []string
+	for v, err := range StreamRows(context.Background(), &fakeQuerier{rows: rows}, "SELECT", nil, scanString) {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+	assert.True(t, rows.closed)
+}
+
+func TestStreamRows_EarlyBreakClosesRows(t *testing.T) {
+	rows := &fakeRows{values: []string{"a", "b", "c"}}
+
+	for range StreamRows(context.Background(), &fakeQuerier{rows: rows}, "SELECT", nil, scanString) {
+		break
+	}
+
+	assert.True(t, rows.closed)
+	assert.Equal(t, 1, rows.pos)
+}
+
+func TestStreamRows_QueryError(t *testing.T) {
+	queryErr := errors.New("connection refused")
+
+	var errs []error
+	for _, err := range StreamRows(context.Background(), &fakeQuerier{err: queryErr}, "SELECT", nil, scanString) {
+		errs = append(errs, err)
+	}
+
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], queryErr)
+}
+
+func TestStreamRows_RowsErrYieldedLast(t *testing.T) {
+	rowsErr := errors.New("network reset")
+	rows := &fakeRows{values: []string{"a"}, err: rowsErr}
+
+	var got []string
+	var lastErr error
+	for v, err := range StreamRows(context.Background(), &fakeQuerier{rows: rows}, "SELECT", nil, scanString) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		got = append(got, v)
+	}
+
+	assert.Equal(t, []string{"a"}, got)
+	assert.ErrorIs(t, lastErr, rowsErr)
+}
@@ -0,0 +1,155 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxPooledBufferSize caps the capacity of buffers returned to the pool so a
+// single huge export does not pin megabytes of memory for the process lifetime.
+const maxPooledBufferSize = 256 << 10
+
+// jsonBuffer pairs a reusable buffer with an encoder bound to it, so neither
+// is reallocated per response.
+type jsonBuffer struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonBufferPool = sync.Pool{
+	New: func() any {
+		jb := &jsonBuffer{}
+		jb.enc = json.NewEncoder(&jb.buf)
+		return jb
+	},
+}
+
+// writeJSON encodes v into a pooled buffer and copies the result into the
+// response body (fasthttp's own pooled buffer). Compared with fiber's c.JSON,
+// which json.Marshal-s into a fresh slice sized to the payload, this removes
+// the per-response allocation that dominates large list responses. Output is
+// byte-identical to json.Marshal (HTML escaping on, no trailing newline).
+func writeJSON(c *fiber.Ctx, status int, v any) error {
+	jb := jsonBufferPool.Get().(*jsonBuffer)
+	jb.buf.Reset()
+
+	if err := jb.enc.Encode(v); err != nil {
+		jsonBufferPool.Put(jb)
+		return err
+	}
+
+	body := jb.buf.Bytes()
+	body = body[:len(body)-1] // json.Encoder appends '\n'; json.Marshal does not
+
+	c.Status(status)
+	c.Response().Header.SetContentType(ContentType(c))
+	c.Response().SetBody(body)
+
+	if jb.buf.Cap() <= maxPooledBufferSize {
+		jsonBufferPool.Put(jb)
+	}
+	return nil
+}
+
+// ContentType returns the media type JSON responses to the request are sent
+// as: application/json, or the vendor media type the client negotiated a
+// response version with.
+func ContentType(c *fiber.Ctx) string {
+	if n := negotiatedFor(c); n != nil && n.contentType != "" {
+		return n.contentType
+	}
+	return fiber.MIMEApplicationJSON
+}
+
+// versioned returns data in the response version negotiated for the request
+func versioned(c *fiber.Ctx, data any) any {
+	if n := negotiatedFor(c); n != nil {
+		return n.versions.transform(data, n.version)
+	}
+	return data
+}
+
+// staticErrorKey identifies a pre-marshaled error body.
+type staticErrorKey struct {
+	code    string
+	message string
+}
+
+// staticErrors holds pre-marshaled error envelopes for common (code, message)
+// pairs. It is copy-on-write: readers load the map without locking, and
+// RegisterStaticError swaps in an extended copy.
+var (
+	staticErrors   atomic.Pointer[map[staticErrorKey][]byte]
+	staticErrorsMu sync.Mutex
+)
+
+// RegisterStaticError pre-marshals the error envelope for (code, message) so
+// responses that match it skip JSON encoding entirely. Intended for fixed
+// messages emitted on hot paths (auth failures, rate limiting); registering
+// dynamic messages would grow the table without bound.
+func RegisterStaticError(code, message string) {
+	body, err := json.Marshal(Response{
+		Success: false,
+		Error:   &Error{Code: code, Message: message},
+	})
+	if err != nil {
+		return
+	}
+
+	staticErrorsMu.Lock()
+	defer staticErrorsMu.Unlock()
+
+	current := staticErrors.Load()
+	next := make(map[staticErrorKey][]byte, len(*current)+1)
+	for k, v := range *current {
+		next[k] = v
+	}
+	next[staticErrorKey{code: code, message: message}] = body
+	staticErrors.Store(&next)
+}
+
+func init() {
+	empty := make(map[staticErrorKey][]byte)
+	staticErrors.Store(&empty)
+
+	for _, e := range []*apperr.Error{
+		apperr.ErrBadRequest,
+		apperr.ErrUnauthorized,
+		apperr.ErrForbidden,
+		apperr.ErrNotFound,
+		apperr.ErrConflict,
+		apperr.ErrUnprocessableEntity,
+		apperr.ErrUnsupportedMediaType,
+		apperr.ErrInternal,
+		apperr.ErrServiceUnavailable,
+	} {
+		RegisterStaticError(e.Code, e.Message)
+	}
+
+	// Default messages used by the helpers in response.go.
+	RegisterStaticError(apperr.CodeInternalError, defaultInternalMessage)
+	RegisterStaticError(apperr.CodeUnauthorized, defaultUnauthorizedMessage)
+	RegisterStaticError(apperr.CodeForbidden, defaultForbiddenMessage)
+	RegisterStaticError(apperr.CodeNotFound, defaultNotFoundMessage)
+}
+
+// writeError writes an error envelope without details, using a pre-marshaled
+// body when one is registered for (code, message).
+func writeError(c *fiber.Ctx, status int, code, message string) error {
+	if body, ok := (*staticErrors.Load())[staticErrorKey{code: code, message: message}]; ok {
+		c.Status(status)
+		c.Response().Header.SetContentType(ContentType(c))
+		// SetBodyRaw does not copy; the shared slice is never mutated.
+		c.Response().SetBodyRaw(body)
+		return nil
+	}
+	return writeJSON(c, status, Response{
+		Success: false,
+		Error:   &Error{Code: code, Message: message},
+	})
+}
@@ -0,0 +1,79 @@
+package response
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rawBody(t *testing.T, app *fiber.App) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
+
+func TestWriteJSON_MatchesJSONMarshal(t *testing.T) {
+	// HTML-sensitive characters must be escaped exactly as json.Marshal does.
+	data := map[string]any{"html": "<b>&</b>", "n": 1}
+	app := setupApp(func(c *fiber.Ctx) error { return Success(c, data) })
+
+	resp, body := rawBody(t, app)
+	want, err := json.Marshal(Response{Success: true, Data: data})
+	require.NoError(t, err)
+
+	assert.Equal(t, string(want), string(body))
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+}
+
+func TestWriteError_StaticBodyMatchesDynamic(t *testing.T) {
+	app := setupApp(func(c *fiber.Ctx) error { return Fail(c, apperr.ErrNotFound) })
+
+	resp, body := rawBody(t, app)
+	want, err := json.Marshal(Response{Error: &Error{Code: apperr.CodeNotFound, Message: apperr.ErrNotFound.Message}})
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, string(want), string(body))
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+}
+
+func TestRegisterStaticError(t *testing.T) {
+	RegisterStaticError("TEST_STATIC", "registered message")
+	_, ok := (*staticErrors.Load())[staticErrorKey{code: "TEST_STATIC", message: "registered message"}]
+	require.True(t, ok)
+
+	app := setupApp(func(c *fiber.Ctx) error {
+		return Fail(c, apperr.New("TEST_STATIC", "registered message", fiber.StatusTeapot))
+	})
+	resp, result := doRequest(t, app)
+	assert.Equal(t, fiber.StatusTeapot, resp.StatusCode)
+	errObj := result["error"].(map[string]any)
+	assert.Equal(t, "TEST_STATIC", errObj["code"])
+	assert.Equal(t, "registered message", errObj["message"])
+}
+
+func TestWriteJSON_LargeBufferNotPooled(t *testing.T) {
+	big := make([]string, 0, 5000)
+	for range 5000 {
+		big = append(big, "0123456789012345678901234567890123456789012345678901234567890123")
+	}
+	app := setupApp(func(c *fiber.Ctx) error { return Success(c, big) })
+
+	_, body := rawBody(t, app)
+	assert.Greater(t, len(body), maxPooledBufferSize)
+
+	var decoded Response
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.True(t, decoded.Success)
+}
@@ -20,11 +20,21 @@ type Error struct {
 	Details map[string]any `json:"details,omitempty"`
 }
 
-// Success sends a successful response with data
+// Default messages for the error helpers below. Their envelopes are
+// pre-marshaled in encode.go.
+const (
+	defaultInternalMessage     = "An unexpected error occurred"
+	defaultUnauthorizedMessage = "Authentication required"
+	defaultForbiddenMessage    = "Access denied"
+	defaultNotFoundMessage     = "Resource not found"
+)
+
+// Success sends a successful response with data, in the response version
+// negotiated for the request
 func Success(c *fiber.Ctx, data any) error {
-	return c.Status(fiber.StatusOK).JSON(Response{
+	return writeJSON(c, fiber.StatusOK, Response{
 		Success: true,
-		Data:    data,
+		Data:    versioned(c, data),
 	})
 }
 
@@ -38,18 +48,27 @@ type PaginatedResponse struct {
 // Paginated sends a successful response with data and pagination metadata
 // Usage: response.Paginated(c, page.GetItems(), page.GetMeta())
 func Paginated(c *fiber.Ctx, data, pagination any) error {
-	return c.Status(fiber.StatusOK).JSON(PaginatedResponse{
+	return writeJSON(c, fiber.StatusOK, PaginatedResponse{
 		Success:    true,
-		Data:       data,
+		Data:       versioned(c, data),
 		Pagination: pagination,
 	})
 }
 
 // Created sends a 201 response with data
 func Created(c *fiber.Ctx, data any) error {
-	return c.Status(fiber.StatusCreated).JSON(Response{
+	return writeJSON(c, fiber.StatusCreated, Response{
+		Success: true,
+		Data:    versioned(c, data),
+	})
+}
+
+// Accepted sends a 202 response with data, for work that continues in the
+// background
+func Accepted(c *fiber.Ctx, data any) error {
+	return writeJSON(c, fiber.StatusAccepted, Response{
 		Success: true,
-		Data:    data,
+		Data:    versioned(c, data),
 	})
 }
 
@@ -60,7 +79,7 @@ func NoContent(c *fiber.Ctx) error {
 
 // Message sends a successful response with a message
 func Message(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusOK).JSON(Response{
+	return writeJSON(c, fiber.StatusOK, Response{
 		Success: true,
 		Message: message,
 	})
@@ -70,29 +89,17 @@ func Message(c *fiber.Ctx, message string) error {
 func Fail(c *fiber.Ctx, err error) error {
 	// Check if it's an application error
 	if appErr, ok := apperr.AsAppError(err); ok {
-		return c.Status(appErr.HTTPStatus).JSON(Response{
-			Success: false,
-			Error: &Error{
-				Code:    appErr.Code,
-				Message: appErr.Message,
-			},
-		})
+		return writeError(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
 	}
 
 	// Default to internal server error
-	return c.Status(fiber.StatusInternalServerError).JSON(Response{
-		Success: false,
-		Error: &Error{
-			Code:    apperr.CodeInternalError,
-			Message: "An unexpected error occurred",
-		},
-	})
+	return writeError(c, fiber.StatusInternalServerError, apperr.CodeInternalError, defaultInternalMessage)
 }
 
 // FailWithDetails sends an error response with additional details
 func FailWithDetails(c *fiber.Ctx, err error, details map[string]any) error {
 	if appErr, ok := apperr.AsAppError(err); ok {
-		return c.Status(appErr.HTTPStatus).JSON(Response{
+		return writeJSON(c, appErr.HTTPStatus, Response{
 			Success: false,
 			Error: &Error{
 				Code:    appErr.Code,
@@ -102,11 +109,11 @@ func FailWithDetails(c *fiber.Ctx, err error, details map[string]any) error {
 		})
 	}
 
-	return c.Status(fiber.StatusInternalServerError).JSON(Response{
+	return writeJSON(c, fiber.StatusInternalServerError, Response{
 		Success: false,
 		Error: &Error{
 			Code:    apperr.CodeInternalError,
-			Message: "An unexpected error occurred",
+			Message: defaultInternalMessage,
 			Details: details,
 		},
 	})
@@ -119,7 +126,7 @@ func ValidationFailed(c *fiber.Ctx, errors map[string]string) error {
 		details[k] = v
 	}
 
-	return c.Status(fiber.StatusBadRequest).JSON(Response{
+	return writeJSON(c, fiber.StatusBadRequest, Response{
 		Success: false,
 		Error: &Error{
 			Code:    apperr.CodeValidation,
@@ -132,41 +139,23 @@ func ValidationFailed(c *fiber.Ctx, errors map[string]string) error {
 // Unauthorized sends a 401 response
 func Unauthorized(c *fiber.Ctx, message string) error {
 	if message == "" {
-		message = "Authentication required"
+		message = defaultUnauthorizedMessage
 	}
-	return c.Status(fiber.StatusUnauthorized).JSON(Response{
-		Success: false,
-		Error: &Error{
-			Code:    apperr.CodeUnauthorized,
-			Message: message,
-		},
-	})
+	return writeError(c, fiber.StatusUnauthorized, apperr.CodeUnauthorized, message)
 }
 
 // Forbidden sends a 403 response
 func Forbidden(c *fiber.Ctx, message string) error {
 	if message == "" {
-		message = "Access denied"
+		message = defaultForbiddenMessage
 	}
-	return c.Status(fiber.StatusForbidden).JSON(Response{
-		Success: false,
-		Error: &Error{
-			Code:    apperr.CodeForbidden,
-			Message: message,
-		},
-	})
+	return writeError(c, fiber.StatusForbidden, apperr.CodeForbidden, message)
 }
 
 // NotFound sends a 404 response
 func NotFound(c *fiber.Ctx, message string) error {
 	if message == "" {
-		message = "Resource not found"
+		message = defaultNotFoundMessage
 	}
-	return c.Status(fiber.StatusNotFound).JSON(Response{
-		Success: false,
-		Error: &Error{
-			Code:    apperr.CodeNotFound,
-			Message: message,
-		},
-	})
+	return writeError(c, fiber.StatusNotFound, apperr.CodeNotFound, message)
 }
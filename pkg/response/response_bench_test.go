@@ -0,0 +1,148 @@
+package response
+
+import (
+	"testing"
+
+	"github.com/14mdzk/goscratch/pkg/apperr"
+	"github.com/14mdzk/goscratch/pkg/perf"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// responseBudgets caps allocations per op for envelope serialization.
+// Success/Paginated use the pooled encoder (encode.go); response.fail_static
+// covers the pre-marshaled error bodies.
+var responseBudgets = perf.Budgets{
+	"response.success":     8,
+	"response.paginated":   9,
+	"response.fail":        7,
+	"response.fail_static": 3,
+}
+
+type benchItem struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at"`
+}
+
+func benchItems(n int) []benchItem {
+	items := make([]benchItem, n)
+	for i := range items {
+		items[i] = benchItem{
+			ID:        "0190f5c8-3e2b-7c4a-9a1e-4b2f6d8e0a1c",
+			Email:     "user@example.com",
+			Name:      "Example User",
+			IsActive:  true,
+			CreatedAt: "2026-01-02T15:04:05Z",
+		}
+	}
+	return items
+}
+
+// runWithCtx invokes fn against a reusable fiber.Ctx so the measurement
+// covers only envelope construction and JSON encoding, not request routing.
+func runWithCtx(app *fiber.App, fctx *fasthttp.RequestCtx, fn func(c *fiber.Ctx)) {
+	c := app.AcquireCtx(fctx)
+	fn(c)
+	app.ReleaseCtx(c)
+	fctx.Response.Reset()
+}
+
+// BenchmarkSuccess_Object measures a single-object success envelope.
+func BenchmarkSuccess_Object(b *testing.B) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	item := benchItems(1)[0]
+	b.ReportAllocs()
+	for range b.N {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Success(c, item) })
+	}
+}
+
+// BenchmarkPaginated_100 measures a 100-item list page with cursor metadata.
+func BenchmarkPaginated_100(b *testing.B) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	items := benchItems(100)
+	meta := map[string]any{"has_more": true, "next_cursor": "eyJsYXN0X2lkIjoiYWJjIn0="}
+	b.ReportAllocs()
+	for range b.N {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Paginated(c, items, meta) })
+	}
+}
+
+// BenchmarkPaginated_100_FiberJSON is the pre-pooling baseline: fiber's
+// c.JSON marshals into a fresh payload-sized slice on every call. Compare
+// with BenchmarkPaginated_100 to see the pooled-encoder win.
+func BenchmarkPaginated_100_FiberJSON(b *testing.B) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	items := benchItems(100)
+	meta := map[string]any{"has_more": true, "next_cursor": "eyJsYXN0X2lkIjoiYWJjIn0="}
+	b.ReportAllocs()
+	for range b.N {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) {
+			_ = c.Status(fiber.StatusOK).JSON(PaginatedResponse{Success: true, Data: items, Pagination: meta})
+		})
+	}
+}
+
+// BenchmarkFail_Static measures an error whose envelope is pre-marshaled.
+func BenchmarkFail_Static(b *testing.B) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	b.ReportAllocs()
+	for range b.N {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Fail(c, apperr.ErrNotFound) })
+	}
+}
+
+// BenchmarkFail_Static_FiberJSON is the pre-marshaling baseline for
+// BenchmarkFail_Static.
+func BenchmarkFail_Static_FiberJSON(b *testing.B) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	b.ReportAllocs()
+	for range b.N {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) {
+			_ = c.Status(fiber.StatusNotFound).JSON(Response{
+				Error: &Error{Code: apperr.ErrNotFound.Code, Message: apperr.ErrNotFound.Message},
+			})
+		})
+	}
+}
+
+// BenchmarkFail_AppError measures a typical 404 error envelope.
+func BenchmarkFail_AppError(b *testing.B) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	err := apperr.NotFoundf("user not found")
+	b.ReportAllocs()
+	for range b.N {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Fail(c, err) })
+	}
+}
+
+func TestAllocBudget_Response(t *testing.T) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	item := benchItems(1)[0]
+	items := benchItems(100)
+	meta := map[string]any{"has_more": true}
+	notFound := apperr.NotFoundf("user not found")
+
+	responseBudgets.Check(t, "response.success", func() {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Success(c, item) })
+	})
+	responseBudgets.Check(t, "response.paginated", func() {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Paginated(c, items, meta) })
+	})
+	responseBudgets.Check(t, "response.fail", func() {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Fail(c, notFound) })
+	})
+	responseBudgets.Check(t, "response.fail_static", func() {
+		runWithCtx(app, fctx, func(c *fiber.Ctx) { _ = Fail(c, apperr.ErrNotFound) })
+	})
+}
@@ -76,6 +76,16 @@ func TestCreated(t *testing.T) {
 	assert.Equal(t, true, body["success"])
 }
 
+func TestAccepted(t *testing.T) {
+	app := setupApp(func(c *fiber.Ctx) error {
+		return Accepted(c, map[string]string{"id": "123"})
+	})
+
+	resp, body := doRequest(t, app)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, true, body["success"])
+}
+
 func TestNoContent(t *testing.T) {
 	app := setupApp(func(c *fiber.Ctx) error {
 		return NoContent(c)
@@ -0,0 +1,112 @@
+package response
+
+import (
+	"bufio"
+	"encoding/json"
+	"iter"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamFlushEvery is how many items are buffered before a chunk is flushed
+// to the client. Small enough that the first bytes arrive quickly, large
+// enough that chunk framing overhead stays negligible.
+const streamFlushEvery = 64
+
+// CodeStreamAborted is reported in the trailing error object when a stream
+// fails after the 200 status line has already been sent.
+const CodeStreamAborted = "STREAM_ABORTED"
+
+// StreamArray sends a 200 response whose "data" array is written item by item
+// with chunked transfer encoding, so the full result set is never held in
+// memory. It is meant for exports and very large lists, paired with a
+// repository iterator such as pgutil.StreamRows.
+//
+// The envelope keys are written as {"data":[...],"success":true}. Because the
+// status line is already on the wire when the first item is written, a
+// mid-stream error cannot change the status code; instead the envelope is
+// closed with "success":false and an error object. Clients MUST check
+// "success" at the end of the body, not only the status code.
+//
+// items is consumed after the handler returns, on fasthttp's writer
+// goroutine. It must not capture the *fiber.Ctx, and any context it uses for
+// the query must outlive the handler (c.UserContext() does; a context
+// cancelled by a deferred call in the handler does not). If the client
+// disconnects, iteration stops and the iterator's cleanup (e.g. rows.Close)
+// runs.
+//
+// Items are written in the response version negotiated for the request.
+func StreamArray[T any](c *fiber.Ctx, items iter.Seq2[T, error]) error {
+	var transform func(any) any
+	if n := negotiatedFor(c); n != nil {
+		transform = n.versions.lookup(reflect.TypeFor[T](), n.version)
+	}
+	c.Status(fiber.StatusOK)
+	c.Response().Header.SetContentType(ContentType(c))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writeStream(w, items, transform)
+	})
+	return nil
+}
+
+// StreamBody sends a 200 response of contentType whose body is produced by
+// write with chunked transfer encoding, for streamed formats other than the
+// JSON envelope (CSV, NDJSON). The writer is flushed once write returns.
+//
+// write runs after the handler returns, under the same lifetime rules as
+// StreamArray. It owns the whole body, including how a mid-stream failure is
+// reported to the client.
+func StreamBody(c *fiber.Ctx, contentType string, write func(w *bufio.Writer)) error {
+	c.Status(fiber.StatusOK)
+	c.Response().Header.SetContentType(contentType)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		write(w)
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// writeStream writes the envelope for StreamArray, passing each item through
+// transform when it is set. It is separate from the fasthttp callback so it
+// can be tested against any bufio.Writer.
+func writeStream[T any](w *bufio.Writer, items iter.Seq2[T, error], transform func(any) any) {
+	enc := json.NewEncoder(w)
+
+	_, _ = w.WriteString(`{"data":[`)
+
+	var streamErr error
+	n := 0
+	for item, err := range items {
+		if err != nil {
+			streamErr = err
+			break
+		}
+		if n > 0 {
+			_ = w.WriteByte(',')
+		}
+		// Encoder appends '\n' after each value; whitespace between array
+		// elements is valid JSON and keeps the output line-oriented.
+		var v any = item
+		if transform != nil {
+			v = transform(item)
+		}
+		if err := enc.Encode(v); err != nil {
+			streamErr = err
+			break
+		}
+		n++
+		if n%streamFlushEvery == 0 {
+			if err := w.Flush(); err != nil {
+				return // client went away; stop pulling rows
+			}
+		}
+	}
+
+	if streamErr != nil {
+		_, _ = w.WriteString(`],"success":false,"error":{"code":"` + CodeStreamAborted + `","message":"The response stream was interrupted"}}`)
+	} else {
+		_, _ = w.WriteString(`],"success":true}`)
+	}
+	_ = w.Flush()
+}
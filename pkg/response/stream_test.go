@@ -0,0 +1,107 @@
+package response
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seqOf[T any](items []T, tailErr error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, it := range items {
+			if !yield(it, nil) {
+				return
+			}
+		}
+		if tailErr != nil {
+			var zero T
+			yield(zero, tailErr)
+		}
+	}
+}
+
+type streamEnvelope struct {
+	Success bool             `json:"success"`
+	Data    []map[string]int `json:"data"`
+	Error   *Error           `json:"error"`
+}
+
+func TestStreamArray_WritesValidEnvelope(t *testing.T) {
+	items := make([]map[string]int, 200) // crosses several flush boundaries
+	for i := range items {
+		items[i] = map[string]int{"n": i}
+	}
+	app := setupApp(func(c *fiber.Ctx) error {
+		return StreamArray(c, seqOf(items, nil))
+	})
+
+	resp, body := rawBody(t, app)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+
+	var env streamEnvelope
+	require.NoError(t, json.Unmarshal(body, &env))
+	assert.True(t, env.Success)
+	assert.Len(t, env.Data, 200)
+	assert.Equal(t, 199, env.Data[199]["n"])
+	assert.Nil(t, env.Error)
+}
+
+func TestStreamArray_Empty(t *testing.T) {
+	app := setupApp(func(c *fiber.Ctx) error {
+		return StreamArray(c, seqOf([]int{}, nil))
+	})
+
+	_, body := rawBody(t, app)
+	assert.JSONEq(t, `{"data":[],"success":true}`, string(body))
+}
+
+func TestStreamArray_MidStreamErrorClosesEnvelope(t *testing.T) {
+	items := []map[string]int{{"n": 1}, {"n": 2}}
+	app := setupApp(func(c *fiber.Ctx) error {
+		return StreamArray(c, seqOf(items, errors.New("rows: connection reset")))
+	})
+
+	resp, body := rawBody(t, app)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode, "status is already sent when the error occurs")
+
+	var env streamEnvelope
+	require.NoError(t, json.Unmarshal(body, &env))
+	assert.False(t, env.Success)
+	assert.Len(t, env.Data, 2)
+	require.NotNil(t, env.Error)
+	assert.Equal(t, CodeStreamAborted, env.Error.Code)
+	assert.NotContains(t, string(body), "connection reset", "raw error must not leak")
+}
+
+func TestStreamArray_UsesChunkedEncoding(t *testing.T) {
+	app := setupApp(func(c *fiber.Ctx) error {
+		return StreamArray(c, seqOf([]int{1, 2, 3}, nil))
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+}
+
+func TestStreamBody(t *testing.T) {
+	app := setupApp(func(c *fiber.Ctx) error {
+		return StreamBody(c, "text/csv; charset=utf-8", func(w *bufio.Writer) {
+			_, _ = w.WriteString("id\n1\n")
+		})
+	})
+
+	resp, body := rawBody(t, app)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "id\n1\n", string(body))
+}
@@ -0,0 +1,165 @@
+package response
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// versionLocalsKey holds the negotiated *negotiated for a request
+const versionLocalsKey = "response.version"
+
+// VendorMediaType returns the media type clients send in Accept to ask for a
+// response version, e.g. "application/vnd.goscratch.v2+json".
+func VendorMediaType(vendor string, version int) string {
+	return fmt.Sprintf("application/vnd.%s.v%d+json", vendor, version)
+}
+
+// Versions maps response DTOs to their representation in each API version.
+// Version 1 is the DTO itself; a transformer registered for version N turns
+// the DTO into its version N shape. A request for version N uses the
+// transformer with the highest version not above N, so a DTO that has not
+// changed since version 1 needs no registration at all.
+//
+// Register transformers at startup, before serving requests. A nil
+// *Versions transforms nothing.
+type Versions struct {
+	mu     sync.Mutex
+	byType atomic.Pointer[map[reflect.Type][]transformer]
+	latest atomic.Int32
+}
+
+type transformer struct {
+	version int
+	fn      func(any) any
+}
+
+// NewVersions creates a registry that only knows version 1
+func NewVersions() *Versions {
+	v := &Versions{}
+	empty := make(map[reflect.Type][]transformer)
+	v.byType.Store(&empty)
+	v.latest.Store(1)
+	return v
+}
+
+// Register adds the transformer producing version's representation of T.
+// version must be 2 or more; version 1 is T itself. It panics on a duplicate
+// registration, which is a programming error.
+func Register[T any](v *Versions, version int, fn func(T) any) {
+	if version < 2 {
+		panic(fmt.Sprintf("response: version %d transformer for %T: version 1 is the DTO itself", version, *new(T)))
+	}
+	typ := reflect.TypeFor[T]()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	current := *v.byType.Load()
+	for _, t := range current[typ] {
+		if t.version == version {
+			panic(fmt.Sprintf("response: duplicate version %d transformer for %s", version, typ))
+		}
+	}
+
+	next := make(map[reflect.Type][]transformer, len(current)+1)
+	for k, ts := range current {
+		next[k] = ts
+	}
+	ts := append(append([]transformer(nil), current[typ]...), transformer{
+		version: version,
+		fn:      func(x any) any { return fn(x.(T)) },
+	})
+	sort.Slice(ts, func(i, j int) bool { return ts[i].version < ts[j].version })
+	next[typ] = ts
+	v.byType.Store(&next)
+	if int32(version) > v.latest.Load() {
+		v.latest.Store(int32(version))
+	}
+}
+
+// Latest returns the highest version any transformer produces, or 1
+func (v *Versions) Latest() int {
+	if v == nil {
+		return 1
+	}
+	return int(v.latest.Load())
+}
+
+// lookup returns the transformer for typ at version, or nil
+func (v *Versions) lookup(typ reflect.Type, version int) func(any) any {
+	if v == nil || version < 2 {
+		return nil
+	}
+	var fn func(any) any
+	for _, t := range (*v.byType.Load())[typ] {
+		if t.version > version {
+			break
+		}
+		fn = t.fn
+	}
+	return fn
+}
+
+// transform returns data in its version representation. Besides registered
+// types it handles slices of them, element by element.
+func (v *Versions) transform(data any, version int) any {
+	if v == nil || version < 2 || data == nil {
+		return data
+	}
+	typ := reflect.TypeOf(data)
+	if fn := v.lookup(typ, version); fn != nil {
+		return fn(data)
+	}
+	if typ.Kind() != reflect.Slice {
+		return data
+	}
+	fn := v.lookup(typ.Elem(), version)
+	if fn == nil {
+		return data
+	}
+	rv := reflect.ValueOf(data)
+	if rv.IsNil() {
+		return data
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = fn(rv.Index(i).Interface())
+	}
+	return out
+}
+
+// negotiated is the response version chosen for a request
+type negotiated struct {
+	versions    *Versions
+	version     int
+	contentType string // vendor media type when the client asked for one
+}
+
+// SetVersion selects the response version for the request. With explicit
+// set, responses are labeled with the vendor media type instead of
+// application/json. It is called by the API version negotiation middleware.
+func SetVersion(c *fiber.Ctx, versions *Versions, vendor string, version int, explicit bool) {
+	n := &negotiated{versions: versions, version: version}
+	if explicit {
+		n.contentType = VendorMediaType(vendor, version)
+	}
+	c.Locals(versionLocalsKey, n)
+}
+
+// Version returns the response version selected for the request, 1 when
+// none was negotiated.
+func Version(c *fiber.Ctx) int {
+	if n := negotiatedFor(c); n != nil {
+		return n.version
+	}
+	return 1
+}
+
+func negotiatedFor(c *fiber.Ctx) *negotiated {
+	n, _ := c.Locals(versionLocalsKey).(*negotiated)
+	return n
+}
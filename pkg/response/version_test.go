@@ -0,0 +1,126 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"iter"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type widget struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type widgetV2 struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func widgetVersions() *Versions {
+	v := NewVersions()
+	Register(v, 2, func(w widget) any { return widgetV2{ID: w.ID, Title: w.Name} })
+	Register(v, 4, func(w widget) any { return map[string]string{"ref": w.ID} })
+	return v
+}
+
+func TestVersions_Transform(t *testing.T) {
+	v := widgetVersions()
+	w := widget{ID: "w1", Name: "Gear"}
+
+	assert.Equal(t, 4, v.Latest())
+	assert.Equal(t, w, v.transform(w, 1))
+	assert.Equal(t, widgetV2{ID: "w1", Title: "Gear"}, v.transform(w, 2))
+	assert.Equal(t, widgetV2{ID: "w1", Title: "Gear"}, v.transform(w, 3), "version 3 falls back to the version 2 shape")
+	assert.Equal(t, map[string]string{"ref": "w1"}, v.transform(w, 4))
+
+	assert.Equal(t, []any{widgetV2{ID: "w1", Title: "Gear"}}, v.transform([]widget{w}, 2))
+	assert.Equal(t, []widget(nil), v.transform([]widget(nil), 2))
+	assert.Equal(t, "unregistered", v.transform("unregistered", 2))
+	assert.Nil(t, v.transform(nil, 2))
+
+	var nilVersions *Versions
+	assert.Equal(t, 1, nilVersions.Latest())
+	assert.Equal(t, w, nilVersions.transform(w, 2))
+}
+
+func TestRegister_Panics(t *testing.T) {
+	v := widgetVersions()
+	assert.Panics(t, func() { Register(v, 1, func(w widget) any { return w }) })
+	assert.Panics(t, func() { Register(v, 2, func(w widget) any { return w }) })
+}
+
+func TestSuccess_Versioned(t *testing.T) {
+	versions := widgetVersions()
+	w := widget{ID: "w1", Name: "Gear"}
+
+	tests := []struct {
+		name        string
+		version     int
+		explicit    bool
+		contentType string
+		data        map[string]any
+	}{
+		{"not negotiated", 0, false, fiber.MIMEApplicationJSON, map[string]any{"id": "w1", "name": "Gear"}},
+		{"default version", 1, false, fiber.MIMEApplicationJSON, map[string]any{"id": "w1", "name": "Gear"}},
+		{"explicit version 2", 2, true, "application/vnd.goscratch.v2+json", map[string]any{"id": "w1", "title": "Gear"}},
+		{"default version 2", 2, false, fiber.MIMEApplicationJSON, map[string]any{"id": "w1", "title": "Gear"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupApp(func(c *fiber.Ctx) error {
+				if tt.version > 0 {
+					SetVersion(c, versions, "goscratch", tt.version, tt.explicit)
+				}
+				assert.Equal(t, max(tt.version, 1), Version(c))
+				return Success(c, w)
+			})
+			resp, body := doRequest(t, app)
+			assert.Equal(t, tt.contentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.data, body["data"])
+		})
+	}
+}
+
+func TestPaginatedAndErrors_Versioned(t *testing.T) {
+	versions := widgetVersions()
+
+	app := setupApp(func(c *fiber.Ctx) error {
+		SetVersion(c, versions, "goscratch", 2, true)
+		return Paginated(c, []widget{{ID: "w1", Name: "Gear"}}, map[string]int{"page": 1})
+	})
+	resp, body := doRequest(t, app)
+	assert.Equal(t, "application/vnd.goscratch.v2+json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, []any{map[string]any{"id": "w1", "title": "Gear"}}, body["data"])
+
+	app = setupApp(func(c *fiber.Ctx) error {
+		SetVersion(c, versions, "goscratch", 2, true)
+		return NotFound(c, "")
+	})
+	resp, body = doRequest(t, app)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/vnd.goscratch.v2+json", resp.Header.Get("Content-Type"), "errors keep the negotiated media type")
+	assert.Equal(t, false, body["success"])
+}
+
+func TestWriteStream_Transform(t *testing.T) {
+	items := func(yield func(widget, error) bool) {
+		_ = yield(widget{ID: "w1", Name: "Gear"}, nil) && yield(widget{ID: "w2", Name: "Cog"}, nil)
+	}
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeStream(w, iter.Seq2[widget, error](items), widgetVersions().lookup(reflect.TypeFor[widget](), 2))
+	assert.JSONEq(t, `{"data":[{"id":"w1","title":"Gear"},{"id":"w2","title":"Cog"}],"success":true}`, buf.String())
+
+	failing := func(yield func(widget, error) bool) { yield(widget{}, errors.New("boom")) }
+	buf.Reset()
+	writeStream(w, iter.Seq2[widget, error](failing), nil)
+	require.Contains(t, buf.String(), CodeStreamAborted)
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/14mdzk/goscratch/internal/platform/config"
 	"github.com/14mdzk/goscratch/internal/platform/database"
+	"github.com/14mdzk/goscratch/pkg/pgutil"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -185,30 +189,34 @@ func main() {
 
 	// Seed default role permissions
 	fmt.Println("\n🔐 Seeding role permissions...")
+	existing, err := existingPermissions(ctx, pool)
+	if err != nil {
+		log.Fatalf("Failed to load existing permissions: %v", err)
+	}
+
+	var missing []casbinRule
 	for _, p := range seedPermissions {
-		// Check if permission already exists
-		var permExists bool
-		err := pool.QueryRow(ctx,
-			"SELECT EXISTS(SELECT 1 FROM casbin_rules WHERE p_type = 'p' AND v0 = $1 AND v1 = $2 AND v2 = $3)",
-			p.Role, p.Object, p.Action,
-		).Scan(&permExists)
-		if err != nil {
-			log.Printf("⚠️  Error checking permission %s:%s for role %s: %v", p.Object, p.Action, p.Role, err)
+		if existing[[3]string{p.Role, p.Object, p.Action}] {
+			fmt.Printf("⏭️  Permission '%s:%s' already exists for role '%s'\n", p.Object, p.Action, p.Role)
 			continue
 		}
+		missing = append(missing, casbinRule{PType: "p", V0: p.Role, V1: p.Object, V2: p.Action, V3: "allow"})
+	}
 
-		if permExists {
-			fmt.Printf("⏭️  Permission '%s:%s' already exists for role '%s'\n", p.Object, p.Action, p.Role)
-		} else {
-			_, err = pool.Exec(ctx,
-				"INSERT INTO casbin_rules (p_type, v0, v1, v2) VALUES ('p', $1, $2, $3)",
-				p.Role, p.Object, p.Action,
-			)
-			if err != nil {
-				log.Printf("⚠️  Error adding permission %s:%s for role %s: %v", p.Object, p.Action, p.Role, err)
-				continue
-			}
-			fmt.Printf("✅ Added permission '%s:%s' for role '%s'\n", p.Object, p.Action, p.Role)
+	// One COPY instead of an INSERT per rule
+	if n, err := pgutil.CopyFrom(ctx, pool, "casbin_rules", missing); err != nil {
+		log.Printf("⚠️  Error adding permissions: %v", err)
+	} else if n > 0 {
+		for _, r := range missing {
+			fmt.Printf("✅ Added permission '%s:%s' for role '%s'\n", r.V1, r.V2, r.V0)
+		}
+	}
+
+	// Optional synthetic users for load and pagination testing
+	if n, _ := strconv.Atoi(os.Getenv("SEED_BULK_USERS")); n > 0 {
+		fmt.Printf("\n👥 Bulk seeding %d synthetic users...\n", n)
+		if err := seedBulkUsers(ctx, pool, n); err != nil {
+			log.Printf("⚠️  Error bulk seeding users: %v", err)
 		}
 	}
 
@@ -231,3 +239,94 @@ func main() {
 	}
 	fmt.Println("└────────────┴────────────┴────────────┘")
 }
+
+// casbinRule maps a casbin_rules row for pgutil.CopyFrom.
+type casbinRule struct {
+	PType string `db:"p_type"`
+	V0    string `db:"v0"`
+	V1    string `db:"v1"`
+	V2    string `db:"v2"`
+	V3    string `db:"v3"` // a permission's effect, "allow" or "deny"
+}
+
+// bulkUser maps a users row for pgutil.CopyFrom.
+type bulkUser struct {
+	Email        string `db:"email"`
+	PasswordHash string `db:"password_hash"`
+	Name         string `db:"name"`
+	IsActive     bool   `db:"is_active"`
+}
+
+// existingPermissions returns the (role, object, action) policy rows already
+// present, so only missing ones are copied in.
+func existingPermissions(ctx context.Context, pool *pgxpool.Pool) (map[[3]string]bool, error) {
+	rows, err := pool.Query(ctx, "SELECT v0, v1, v2 FROM casbin_rules WHERE p_type = 'p' AND v3 = 'allow'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[[3]string]bool)
+	for rows.Next() {
+		var role, object, action string
+		if err := rows.Scan(&role, &object, &action); err != nil {
+			return nil, err
+		}
+		existing[[3]string{role, object, action}] = true
+	}
+	return existing, rows.Err()
+}
+
+// seedBulkUsers inserts n viewer users with a single COPY. Emails embed the
+// run timestamp so repeated runs never collide. All users share one bcrypt
+// hash of "test123" — hashing per row would dominate the run time.
+func seedBulkUsers(ctx context.Context, pool *pgxpool.Pool, n int) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte("test123"), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	run := time.Now().Unix()
+	users := make([]bulkUser, n)
+	for i := range users {
+		users[i] = bulkUser{
+			Email:        fmt.Sprintf("bulk.%d.%d@example.com", run, i),
+			PasswordHash: string(hash),
+			Name:         fmt.Sprintf("Bulk User %d", i),
+			IsActive:     true,
+		}
+	}
+
+	start := time.Now()
+	copied, err := pgutil.CopyFrom(ctx, pool, "users", users)
+	if err != nil {
+		return err
+	}
+
+	// Role assignments need the generated IDs, so resolve them in one query
+	// and copy the grouping rules in a second COPY.
+	rows, err := pool.Query(ctx, "SELECT id::text FROM users WHERE email LIKE $1", fmt.Sprintf("bulk.%d.%%@example.com", run))
+	if err != nil {
+		return err
+	}
+	var rules []casbinRule
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		rules = append(rules, casbinRule{PType: "g", V0: id, V1: "viewer"})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := pgutil.CopyFrom(ctx, pool, "casbin_rules", rules); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Copied %d users in %s\n", copied, time.Since(start).Round(time.Millisecond))
+	return nil
+}